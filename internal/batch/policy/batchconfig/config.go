@@ -7,6 +7,7 @@ type Config struct {
 	ByteSize   int                `json:"byte_size" yaml:"byte_size"`
 	Count      int                `json:"count" yaml:"count"`
 	Check      string             `json:"check" yaml:"check"`
+	Key        string             `json:"key" yaml:"key"`
 	Period     string             `json:"period" yaml:"period"`
 	Processors []processor.Config `json:"processors" yaml:"processors"`
 }
@@ -17,6 +18,7 @@ func NewConfig() Config {
 		ByteSize:   0,
 		Count:      0,
 		Check:      "",
+		Key:        "",
 		Period:     "",
 		Processors: []processor.Config{},
 	}
@@ -33,6 +35,9 @@ func (p Config) IsNoop() bool {
 	if len(p.Check) > 0 {
 		return false
 	}
+	if len(p.Key) > 0 {
+		return false
+	}
 	if len(p.Period) > 0 {
 		return false
 	}
@@ -56,6 +61,9 @@ func (p Config) IsLimited() bool {
 	if len(p.Check) > 0 {
 		return true
 	}
+	if len(p.Key) > 0 {
+		return true
+	}
 	return false
 }
 
@@ -29,7 +29,7 @@ Allows you to configure a [batching policy](/docs/configuration/batching).`,
 			docs.FieldInt(
 				"count",
 				"A number of messages at which the batch should be flushed. If `0` disables count based batching.",
-			).HasDefault(0),
+			).IsTunable().HasDefault(0),
 			docs.FieldInt(
 				"byte_size",
 				"An amount of bytes at which the batch should be flushed. If `0` disables size based batching.",
@@ -44,6 +44,12 @@ Allows you to configure a [batching policy](/docs/configuration/batching).`,
 				"A [Bloblang query](/docs/guides/bloblang/about/) that should return a boolean value indicating whether a message should end a batch.",
 				`this.type == "end_of_transaction"`,
 			).HasDefault(""),
+			docs.FieldBloblang(
+				"key",
+				"A [Bloblang query](/docs/guides/bloblang/about/) that is executed for each message, and whenever the result differs from that of the previous message the batch is flushed without it, with the message starting the next batch. This allows contiguous messages of an ordered stream to be grouped by a value such as an hour or tenant.",
+				`meta("tenant")`,
+				`this.timestamp.ts_parse("2006-01-02T15:04:05Z07:00").ts_format("2006-01-02T15")`,
+			).HasDefault("").AtVersion("4.3.0"),
 			docs.FieldProcessor(
 				"processors",
 				"A list of [processors](/docs/components/processors/about) to apply to a batch as it is flushed. This allows you to aggregate and archive the batch however you see fit. Please note that all resulting messages are flushed as a single batch, therefore splitting the batch into smaller batches using these processors is a no-op.",
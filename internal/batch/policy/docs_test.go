@@ -26,6 +26,7 @@ func TestBatchPolicySanit(t *testing.T) {
 byte_size: 0
 period: ""
 check: ""
+key: ""
 processors: []
 `
 
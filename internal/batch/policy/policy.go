@@ -1,9 +1,11 @@
 package policy
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"strconv"
+	"sync/atomic"
 	"time"
 
 	"github.com/benthosdev/benthos/v4/internal/batch/policy/batchconfig"
@@ -21,20 +23,30 @@ type Batcher struct {
 	log log.Modular
 
 	byteSize  int
-	count     int
+	count     int64
 	period    time.Duration
 	check     *mapping.Executor
+	key       *mapping.Executor
 	procs     []iprocessor.V1
 	sizeTally int
 	parts     []*message.Part
 
+	// The key of the current batch, and parts with a different key that are
+	// held back, along with their keys, in order to start the next batches.
+	lastKey     []byte
+	carried     []*message.Part
+	carriedKeys [][]byte
+
 	triggered bool
 	lastBatch time.Time
 
+	unregisterCount func()
+
 	mSizeBatch   metrics.StatCounter
 	mCountBatch  metrics.StatCounter
 	mPeriodBatch metrics.StatCounter
 	mCheckBatch  metrics.StatCounter
+	mKeyBatch    metrics.StatCounter
 }
 
 // New creates an empty policy with default rules.
@@ -52,6 +64,12 @@ func New(conf batchconfig.Config, mgr bundle.NewManagement) (*Batcher, error) {
 			return nil, fmt.Errorf("failed to parse check: %v", err)
 		}
 	}
+	var key *mapping.Executor
+	if len(conf.Key) > 0 {
+		if key, err = mgr.BloblEnvironment().NewMapping(conf.Key); err != nil {
+			return nil, fmt.Errorf("failed to parse key: %v", err)
+		}
+	}
 	var period time.Duration
 	if len(conf.Period) > 0 {
 		if period, err = time.ParseDuration(conf.Period); err != nil {
@@ -69,13 +87,14 @@ func New(conf batchconfig.Config, mgr bundle.NewManagement) (*Batcher, error) {
 	}
 
 	batchOn := mgr.Metrics().GetCounterVec("batch_created", "mechanism")
-	return &Batcher{
+	p := &Batcher{
 		log: mgr.Logger(),
 
 		byteSize: conf.ByteSize,
-		count:    conf.Count,
+		count:    int64(conf.Count),
 		period:   period,
 		check:    check,
+		key:      key,
 		procs:    procs,
 
 		lastBatch: time.Now(),
@@ -84,18 +103,89 @@ func New(conf batchconfig.Config, mgr bundle.NewManagement) (*Batcher, error) {
 		mCountBatch:  batchOn.With("count"),
 		mPeriodBatch: batchOn.With("period"),
 		mCheckBatch:  batchOn.With("check"),
-	}, nil
+		mKeyBatch:    batchOn.With("key"),
+	}
+
+	p.unregisterCount = mgr.RegisterTunable("count", "A number of messages at which the batch should be flushed. If `0` disables count based batching.", strconv.Itoa(conf.Count), p.setCount)
+	return p, nil
+}
+
+// setCount changes the count at which batches are flushed, which is called
+// when the count is tuned at runtime.
+func (p *Batcher) setCount(value string) error {
+	count, err := strconv.Atoi(value)
+	if err != nil {
+		return err
+	}
+	if count < 0 {
+		return errors.New("count must not be negative")
+	}
+	if count == 0 && p.byteSize <= 0 && p.period <= 0 && p.check == nil && p.key == nil {
+		return errors.New("count is the only active trigger of the batch policy and cannot be disabled")
+	}
+	atomic.StoreInt64(&p.count, int64(count))
+	return nil
 }
 
 //------------------------------------------------------------------------------
 
 // Add a new message part to this batch policy. Returns true if this part
 // triggers the conditions of the policy.
+//
+// When a key is configured and the key of the part differs from that of the
+// current batch, the part is held back and becomes the first part of the next
+// batch, in which case the batch returned by the next call to Flush will not
+// include it. Parts added whilst others are held back are also held back, and
+// are flushed as one batch per run of consecutive parts with the same key.
 func (p *Batcher) Add(part *message.Part) bool {
+	if p.key != nil {
+		key := p.keyOf(part)
+		if len(p.carried) > 0 {
+			p.carried = append(p.carried, part)
+			p.carriedKeys = append(p.carriedKeys, key)
+			return true
+		}
+		if len(p.parts) > 0 && !bytes.Equal(key, p.lastKey) {
+			p.carried = append(p.carried, part)
+			p.carriedKeys = append(p.carriedKeys, key)
+			p.triggered = true
+			p.mKeyBatch.Incr(1)
+			p.log.Traceln("Batching based on key")
+			return true
+		}
+		p.lastKey = key
+	}
+	return p.add(part)
+}
+
+// keyOf returns the result of the key query for a part.
+func (p *Batcher) keyOf(part *message.Part) []byte {
+	tmpMsg := message.QuickBatch(nil)
+	tmpMsg.Append(part)
+
+	keyPart, err := p.key.MapPart(0, tmpMsg)
+	if err != nil {
+		p.log.Errorf("Failed to execute batch key query: %v\n", err)
+		return nil
+	}
+	if keyPart == nil {
+		return nil
+	}
+	return keyPart.Get()
+}
+
+// Triggered returns true if the conditions of the policy have been met and the
+// buffered parts should be flushed. This can be the case immediately after a
+// call to Flush when the parts held back by a key spanned multiple keys.
+func (p *Batcher) Triggered() bool {
+	return p.triggered
+}
+
+func (p *Batcher) add(part *message.Part) bool {
 	p.sizeTally += len(part.Get())
 	p.parts = append(p.parts, part)
 
-	if !p.triggered && p.count > 0 && len(p.parts) >= p.count {
+	if count := int(atomic.LoadInt64(&p.count)); !p.triggered && count > 0 && len(p.parts) >= count {
 		p.triggered = true
 		p.mCountBatch.Incr(1)
 		p.log.Traceln("Batching based on count")
@@ -160,6 +250,26 @@ func (p *Batcher) flushAny() []*message.Batch {
 	p.lastBatch = time.Now()
 	p.triggered = false
 
+	// The first run of held back parts with the same key becomes the next
+	// batch, which is already complete when further runs remain.
+	if len(p.carried) > 0 {
+		p.lastKey = p.carriedKeys[0]
+		n := 1
+		for n < len(p.carried) && bytes.Equal(p.carriedKeys[n], p.lastKey) {
+			n++
+		}
+		for _, part := range p.carried[:n] {
+			_ = p.add(part)
+		}
+		if p.carried, p.carriedKeys = p.carried[n:], p.carriedKeys[n:]; len(p.carried) > 0 {
+			p.triggered = true
+			p.mKeyBatch.Incr(1)
+			p.log.Traceln("Batching based on key")
+		} else {
+			p.carried, p.carriedKeys = nil, nil
+		}
+	}
+
 	if newMsg == nil {
 		return nil
 	}
@@ -177,9 +287,9 @@ func (p *Batcher) flushAny() []*message.Batch {
 }
 
 // Count returns the number of currently buffered message parts within this
-// policy.
+// policy, including any that are held back for the next batch.
 func (p *Batcher) Count() int {
-	return len(p.parts)
+	return len(p.parts) + len(p.carried)
 }
 
 // UntilNext returns a duration indicating how long until the current batch
@@ -196,6 +306,7 @@ func (p *Batcher) UntilNext() time.Duration {
 
 // CloseAsync shuts down the policy resources.
 func (p *Batcher) CloseAsync() {
+	p.unregisterCount()
 	for _, c := range p.procs {
 		c.CloseAsync()
 	}
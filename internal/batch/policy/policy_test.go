@@ -1,6 +1,7 @@
 package policy_test
 
 import (
+	"bytes"
 	"reflect"
 	"testing"
 	"time"
@@ -12,8 +13,11 @@ import (
 	"github.com/benthosdev/benthos/v4/internal/batch/policy"
 	"github.com/benthosdev/benthos/v4/internal/batch/policy/batchconfig"
 	"github.com/benthosdev/benthos/v4/internal/component/processor"
+	"github.com/benthosdev/benthos/v4/internal/log"
+	"github.com/benthosdev/benthos/v4/internal/manager"
 	"github.com/benthosdev/benthos/v4/internal/manager/mock"
 	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/tunable"
 
 	_ "github.com/benthosdev/benthos/v4/internal/impl/pure"
 )
@@ -30,6 +34,10 @@ func TestPolicyNoop(t *testing.T) {
 	conf.Check = "foo.bar"
 	assert.False(t, conf.IsNoop())
 
+	conf = batchconfig.NewConfig()
+	conf.Key = "this.foo"
+	assert.False(t, conf.IsNoop())
+
 	conf = batchconfig.NewConfig()
 	conf.ByteSize = 10
 	assert.False(t, conf.IsNoop())
@@ -190,6 +198,116 @@ func TestPolicyCheck(t *testing.T) {
 	}
 }
 
+func TestPolicyKey(t *testing.T) {
+	conf := batchconfig.NewConfig()
+	conf.Key = `this.tenant`
+	conf.Count = 3
+
+	pol, err := policy.New(conf, mock.NewManager())
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		pol.CloseAsync()
+		require.NoError(t, pol.WaitForClose(time.Second))
+	})
+
+	assert.False(t, pol.Add(message.NewPart([]byte(`{"tenant":"a","id":1}`))))
+	assert.False(t, pol.Add(message.NewPart([]byte(`{"tenant":"a","id":2}`))))
+	assert.True(t, pol.Add(message.NewPart([]byte(`{"tenant":"b","id":3}`))))
+	assert.Equal(t, 3, pol.Count())
+
+	msg := pol.Flush()
+	assert.Equal(t, [][]byte{
+		[]byte(`{"tenant":"a","id":1}`),
+		[]byte(`{"tenant":"a","id":2}`),
+	}, message.GetAllBytes(msg))
+
+	// The message that changed the key starts the next batch.
+	assert.Equal(t, 1, pol.Count())
+	assert.False(t, pol.Add(message.NewPart([]byte(`{"tenant":"b","id":4}`))))
+	assert.True(t, pol.Add(message.NewPart([]byte(`{"tenant":"b","id":5}`))))
+
+	msg = pol.Flush()
+	assert.Equal(t, [][]byte{
+		[]byte(`{"tenant":"b","id":3}`),
+		[]byte(`{"tenant":"b","id":4}`),
+		[]byte(`{"tenant":"b","id":5}`),
+	}, message.GetAllBytes(msg))
+
+	// Keys are only compared against the current batch.
+	assert.False(t, pol.Add(message.NewPart([]byte(`{"tenant":"c","id":6}`))))
+	assert.True(t, pol.Add(message.NewPart([]byte(`{"tenant":"a","id":7}`))))
+	assert.True(t, pol.Add(message.NewPart([]byte(`{"tenant":"b","id":8}`))))
+
+	msg = pol.Flush()
+	assert.Equal(t, [][]byte{
+		[]byte(`{"tenant":"c","id":6}`),
+	}, message.GetAllBytes(msg))
+
+	// Parts added after the key changed are held back, and flushed as a batch
+	// per key.
+	assert.True(t, pol.Triggered())
+	msg = pol.Flush()
+	assert.Equal(t, [][]byte{
+		[]byte(`{"tenant":"a","id":7}`),
+	}, message.GetAllBytes(msg))
+
+	assert.False(t, pol.Triggered())
+	msg = pol.Flush()
+	assert.Equal(t, [][]byte{
+		[]byte(`{"tenant":"b","id":8}`),
+	}, message.GetAllBytes(msg))
+
+	assert.Nil(t, pol.Flush())
+}
+
+func TestPolicyKeyHeldBackRuns(t *testing.T) {
+	for _, test := range []struct {
+		name  string
+		keys  string
+		after string
+		exp   []string
+	}{
+		{name: "distinct keys", keys: "abc", exp: []string{"a", "b", "c"}},
+		{name: "repeated keys", keys: "abba", exp: []string{"a", "bb", "a"}},
+		{name: "runs", keys: "aabbbcaa", exp: []string{"aa", "bbb", "c", "aa"}},
+		{name: "continues last run", keys: "abc", after: "c", exp: []string{"a", "b", "cc"}},
+		{name: "changes after last run", keys: "abc", after: "b", exp: []string{"a", "b", "c", "b"}},
+	} {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			conf := batchconfig.NewConfig()
+			conf.Key = `content().slice(0, 1)`
+
+			pol, err := policy.New(conf, mock.NewManager())
+			require.NoError(t, err)
+
+			// All parts are added before flushing, as is the case when a
+			// batch is added as a whole.
+			for _, k := range test.keys {
+				pol.Add(message.NewPart([]byte(string(k))))
+			}
+
+			var batches []string
+			for pol.Triggered() {
+				batches = append(batches, string(bytes.Join(message.GetAllBytes(pol.Flush()), nil)))
+			}
+			for _, k := range test.after {
+				if pol.Add(message.NewPart([]byte(string(k)))) {
+					batches = append(batches, string(bytes.Join(message.GetAllBytes(pol.Flush()), nil)))
+				}
+			}
+			for pol.Count() > 0 {
+				batches = append(batches, string(bytes.Join(message.GetAllBytes(pol.Flush()), nil)))
+			}
+			assert.Equal(t, test.exp, batches)
+
+			pol.CloseAsync()
+			require.NoError(t, pol.WaitForClose(time.Second))
+		})
+	}
+}
+
 func TestPolicyCheckAdvanced(t *testing.T) {
 	conf := batchconfig.NewConfig()
 	conf.Check = `batch_size() >= 3`
@@ -310,3 +428,51 @@ func TestPolicySplit(t *testing.T) {
 		t.Error("Non-nil empty flush")
 	}
 }
+
+func TestPolicyTunableCount(t *testing.T) {
+	conf := batchconfig.NewConfig()
+	conf.Count = 3
+
+	tunables := map[string]func(string) error{}
+	mgr := mock.NewManager()
+	mgr.OnRegisterTunable = func(field, value string, set func(string) error) {
+		assert.Equal(t, "3", value)
+		tunables[field] = set
+	}
+
+	pol, err := policy.New(conf, mgr)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		pol.CloseAsync()
+		require.NoError(t, pol.WaitForClose(time.Second))
+	})
+
+	setCount := tunables["count"]
+	require.NotNil(t, setCount)
+
+	assert.Error(t, setCount("nope"))
+	assert.Error(t, setCount("-1"))
+	assert.EqualError(t, setCount("0"), "count is the only active trigger of the batch policy and cannot be disabled")
+	require.NoError(t, setCount("1"))
+
+	assert.True(t, pol.Add(message.NewPart([]byte("foo"))))
+	assert.Equal(t, [][]byte{[]byte("foo")}, message.GetAllBytes(pol.Flush()))
+}
+
+func TestPolicyTunableUnregistered(t *testing.T) {
+	conf := batchconfig.NewConfig()
+	conf.Count = 3
+
+	tunables := tunable.NewRegistry(log.Noop())
+	mgr, err := manager.New(manager.NewResourceConfig(), manager.OptSetTunables(tunables))
+	require.NoError(t, err)
+
+	pol, err := policy.New(conf, mgr.IntoPath("output", "batching"))
+	require.NoError(t, err)
+	assert.Len(t, tunables.List(), 1)
+
+	pol.CloseAsync()
+	require.NoError(t, pol.WaitForClose(time.Second))
+	assert.Empty(t, tunables.List())
+}
@@ -348,3 +348,26 @@ func TestExpressions(t *testing.T) {
 		})
 	}
 }
+
+func TestBatchQueryResolver(t *testing.T) {
+	var calls int
+	r := NewBatchQueryResolver(query.ClosureFunction("batch size", func(ctx query.FunctionContext) (interface{}, error) {
+		calls++
+		return int64(ctx.MsgBatch.Len()), nil
+	}, nil))
+
+	e := NewExpression(StaticResolver("size: "), r)
+
+	batch := message.QuickBatch([][]byte{[]byte("foo"), []byte("bar")})
+	assert.Equal(t, "size: 2", e.String(0, batch))
+	assert.Equal(t, "size: 2", e.String(1, batch))
+	assert.Equal(t, 1, calls)
+
+	batch.Append(message.NewPart([]byte("baz")))
+	assert.Equal(t, "size: 3", e.String(2, batch))
+	assert.Equal(t, 2, calls)
+
+	otherBatch := message.QuickBatch([][]byte{[]byte("foo")})
+	assert.Equal(t, "size: 1", string(e.Bytes(0, otherBatch)))
+	assert.Equal(t, 3, calls)
+}
@@ -2,6 +2,7 @@ package field
 
 import (
 	"strconv"
+	"sync/atomic"
 
 	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
 	"github.com/benthosdev/benthos/v4/internal/message"
@@ -82,6 +83,65 @@ func (q QueryResolver) ResolveBytes(index int, msg Message, escaped bool) []byte
 	return bs
 }
 
+//------------------------------------------------------------------------------
+
+// BatchQueryResolver executes a query that is known to yield the same result
+// for every message of a batch, and therefore the result is only calculated
+// once per batch.
+type BatchQueryResolver struct {
+	q QueryResolver
+
+	// Holds the *batchQueryResult of the most recently resolved batch, which
+	// is keyed by the ID of the batch so that the batch itself isn't kept
+	// alive by the cache.
+	last atomic.Value
+}
+
+type batchQueryResult struct {
+	batchID  uint64
+	batchLen int
+	res      string
+}
+
+// NewBatchQueryResolver creates a field query resolver that returns the result
+// of a query function, where the result is cached for each batch.
+func NewBatchQueryResolver(fn query.Function) *BatchQueryResolver {
+	return &BatchQueryResolver{q: QueryResolver{fn}}
+}
+
+// ResolveString returns a string.
+func (b *BatchQueryResolver) ResolveString(index int, msg Message, escaped bool) string {
+	batch, ok := msg.(*message.Batch)
+	if !ok || batch == nil {
+		return b.q.ResolveString(index, msg, escaped)
+	}
+
+	id, length := batch.ID(), batch.Len()
+
+	last, _ := b.last.Load().(*batchQueryResult)
+	if last == nil || last.batchID != id || last.batchLen != length {
+		// Concurrent resolutions of different batches may race to store their
+		// result, which is fine as the loser simply recalculates next time.
+		last = &batchQueryResult{
+			batchID:  id,
+			batchLen: length,
+			res:      b.q.ResolveString(index, msg, false),
+		}
+		b.last.Store(last)
+	}
+	if escaped {
+		return string(escapeBytes([]byte(last.res)))
+	}
+	return last.res
+}
+
+// ResolveBytes returns a byte slice.
+func (b *BatchQueryResolver) ResolveBytes(index int, msg Message, escaped bool) []byte {
+	return []byte(b.ResolveString(index, msg, escaped))
+}
+
+//------------------------------------------------------------------------------
+
 func escapeBytes(in []byte) []byte {
 	quoted := strconv.Quote(string(in))
 	if len(quoted) < 3 {
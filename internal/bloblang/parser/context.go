@@ -16,6 +16,7 @@ type Context struct {
 	Methods      *query.MethodSet
 	namedContext *namedContext
 	importer     Importer
+	initRecorder *initRecorder
 }
 
 // EmptyContext returns a parser context with no functions, methods or import
@@ -65,15 +66,33 @@ func (pCtx Context) HasNamedContext(name string) bool {
 // InitFunction attempts to initialise a function from the available
 // constructors of the parser context.
 func (pCtx Context) InitFunction(name string, args *query.ParsedParams) (query.Function, error) {
+	if pCtx.initRecorder != nil {
+		pCtx.initRecorder.functions = append(pCtx.initRecorder.functions, name)
+	}
 	return pCtx.Functions.Init(name, args)
 }
 
 // InitMethod attempts to initialise a method from the available constructors of
 // the parser context.
 func (pCtx Context) InitMethod(name string, target query.Function, args *query.ParsedParams) (query.Function, error) {
+	if pCtx.initRecorder != nil {
+		pCtx.initRecorder.methods = append(pCtx.initRecorder.methods, name)
+	}
 	return pCtx.Methods.Init(name, target, args)
 }
 
+// initRecorder records the names of functions and methods initialised by a
+// parser.
+type initRecorder struct {
+	functions []string
+	methods   []string
+}
+
+func (pCtx Context) withInitRecorder(r *initRecorder) Context {
+	pCtx.initRecorder = r
+	return pCtx
+}
+
 // WithImporter returns a Context where imports are made from the provided
 // Importer implementation.
 func (pCtx Context) WithImporter(importer Importer) Context {
@@ -17,12 +17,50 @@ func intoStaticResolver(p Func) Func {
 	}
 }
 
+// Functions that yield the same result for every message of a batch.
+var batchInvariantFunctions = map[string]struct{}{
+	"batch_size": {},
+	"env":        {},
+	"file":       {},
+	"hostname":   {},
+}
+
+// isBatchInvariant returns true if a query provably yields the same result for
+// every message of a batch, which is the case when it does not target any data
+// of a message and only calls batch invariant functions and pure methods.
+func isBatchInvariant(pCtx Context, fn query.Function, rec *initRecorder) bool {
+	if _, targets := fn.QueryTargets(query.TargetsContext{}); len(targets) > 0 {
+		return false
+	}
+	for _, name := range rec.functions {
+		if _, exists := batchInvariantFunctions[name]; !exists {
+			return false
+		}
+	}
+	if len(rec.methods) == 0 {
+		return true
+	}
+	impure := map[string]struct{}{}
+	for _, spec := range pCtx.Methods.Docs() {
+		if spec.Impure {
+			impure[spec.Name] = struct{}{}
+		}
+	}
+	for _, name := range rec.methods {
+		if _, exists := impure[name]; exists {
+			return false
+		}
+	}
+	return true
+}
+
 func aFunction(pCtx Context) Func {
 	return func(input []rune) Result {
+		rec := &initRecorder{}
 		res := Sequence(
 			Term("${!"),
 			Optional(SpacesAndTabs()),
-			MustBe(queryParser(pCtx)),
+			MustBe(queryParser(pCtx.withInitRecorder(rec))),
 			Optional(SpacesAndTabs()),
 			MustBe(Expect(Char('}'), "end of expression")),
 		)(input)
@@ -30,7 +68,19 @@ func aFunction(pCtx Context) Func {
 		if res.Err != nil {
 			return res
 		}
-		res.Payload = field.NewQueryResolver(res.Payload.([]interface{})[2].(query.Function))
+
+		fn := res.Payload.([]interface{})[2].(query.Function)
+		if lit, isLit := fn.(*query.Literal); isLit {
+			// Literals, which includes static functions such as env, are
+			// resolved once at parse time.
+			res.Payload = field.StaticResolver(query.ExecToString(lit, query.FunctionContext{}))
+			return res
+		}
+		if isBatchInvariant(pCtx, fn, rec) {
+			res.Payload = field.NewBatchQueryResolver(fn)
+			return res
+		}
+		res.Payload = field.NewQueryResolver(fn)
 		return res
 	}
 }
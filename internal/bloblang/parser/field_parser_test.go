@@ -31,6 +31,39 @@ func TestFieldStaticExpressionOptimization(t *testing.T) {
 	}
 }
 
+func TestFieldExpressionResolverMemoisation(t *testing.T) {
+	tests := []struct {
+		input    string
+		numDyn   int
+		resolver field.Resolver
+	}{
+		{input: `foo ${! 5 + 5 } bar`, numDyn: 0},
+		{input: `foo ${! "baz" } bar`, numDyn: 0},
+		{input: `foo ${! batch_size() } bar`, numDyn: 1, resolver: &field.BatchQueryResolver{}},
+		{input: `foo ${! batch_size().string().uppercase() } bar`, numDyn: 1, resolver: &field.BatchQueryResolver{}},
+		{input: `foo ${! batch_index() } bar`, numDyn: 1, resolver: &field.QueryResolver{}},
+		{input: `foo ${! batch_size() + batch_index() } bar`, numDyn: 1, resolver: &field.QueryResolver{}},
+		{input: `foo ${! this.foo.or(batch_size()) } bar`, numDyn: 1, resolver: &field.QueryResolver{}},
+		{input: `foo ${! meta("foo") } bar`, numDyn: 1, resolver: &field.QueryResolver{}},
+		{input: `foo ${! count("memoisation_test") } bar`, numDyn: 1, resolver: &field.QueryResolver{}},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.input, func(t *testing.T) {
+			rs, err := parseFieldResolvers(GlobalContext(), test.input)
+			require.Nil(t, err)
+
+			e := field.NewExpression(rs...)
+			assert.Equal(t, test.numDyn, e.NumDynamicExpressions())
+			if test.resolver != nil {
+				require.Len(t, rs, 3)
+				assert.IsType(t, test.resolver, rs[1])
+			}
+		})
+	}
+}
+
 func TestFieldExpressionParserErrors(t *testing.T) {
 	tests := map[string]struct {
 		input string
@@ -211,6 +211,24 @@ func TestMethodParser(t *testing.T) {
 				{content: `{"foo":[1,2,2]}`},
 			},
 		},
+		"regexp replace with query": {
+			input:  `json("foo").re_replace_fn("(?P<n>[0-9]+)", this.n.number() * 2)`,
+			output: `a 2 b 40 c`,
+			messages: []easyMsg{
+				{content: `{"foo":"a 1 b 20 c"}`},
+			},
+		},
+		"regexp replace with query delete and nothing": {
+			input: `json("foo").re_replace_fn("[a-z]+", match this."0" {
+	"keep" => nothing()
+	"drop" => deleted()
+	_ => this.uppercase()
+})`,
+			output: `keep  FOO`,
+			messages: []easyMsg{
+				{content: `{"foo":"keep drop foo"}`},
+			},
+		},
 		"map each inner map": {
 			input:  `json("foo").map_each((this.bar + 10) | "woops")`,
 			output: `[11,"woops",12]`,
@@ -152,6 +152,7 @@ var (
 	MethodCategoryCoercion       = "Type Coercion"
 	MethodCategoryParsing        = "Parsing"
 	MethodCategoryObjectAndArray = "Object & Array Manipulation"
+	MethodCategoryGeo            = "Geospatial"
 	MethodCategoryGeoIP          = "GeoIP"
 	MethodCategoryDeprecated     = "Deprecated"
 	MethodCategoryPlugin         = "Plugin"
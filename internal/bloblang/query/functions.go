@@ -2,6 +2,8 @@ package query
 
 import (
 	"context"
+	crand "crypto/rand"
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"math/rand"
@@ -737,17 +739,130 @@ func nanoidFunction(args *ParsedParams) (Function, error) {
 
 //------------------------------------------------------------------------------
 
-var _ = registerSimpleFunction(
+var _ = registerFunction(
+	NewFunctionSpec(
+		FunctionCategoryGeneral, "uuid_v7",
+		"Generates a new time-ordered UUID (version 7) each time it is invoked and prints a string representation. The first 48 bits of the UUID are a unix timestamp in milliseconds, which is the current time unless a timestamp is provided.",
+		NewExampleSpec("", `root.id = uuid_v7()`),
+		NewExampleSpec("It is possible to specify the timestamp embedded within the UUID, either as a timestamp value, a unix timestamp number or an RFC 3339 string.", `root.id = uuid_v7(this.created_at)`),
+	).Param(ParamAny("timestamp", "An optional timestamp to embed within the ID.").Optional()),
+	uuidV7Function,
+)
+
+func uuidV7Function(args *ParsedParams) (Function, error) {
+	ts, err := optionalTimestampParam(args, "timestamp")
+	if err != nil {
+		return nil, err
+	}
+	return ClosureFunction("function uuid_v7", func(_ FunctionContext) (interface{}, error) {
+		var u uuid.UUID
+		if _, err := crand.Read(u[6:]); err != nil {
+			return nil, err
+		}
+		putUnixMilli48(u[:6], timeOrNow(ts))
+		u.SetVersion(7)
+		u.SetVariant(uuid.VariantRFC4122)
+		return u.String(), nil
+	}, nil), nil
+}
+
+//------------------------------------------------------------------------------
+
+var _ = registerFunction(
+	NewFunctionSpec(
+		FunctionCategoryGeneral, "ulid",
+		"Generates a new [ULID](https://github.com/ulid/spec) each time it is invoked and prints a string representation. ULIDs are lexicographically sortable by the time they were created, which is the current time unless a timestamp is provided.",
+		NewExampleSpec("", `root.id = ulid()`),
+		NewExampleSpec("It is possible to specify the timestamp embedded within the ID, either as a timestamp value, a unix timestamp number or an RFC 3339 string.", `root.id = ulid(this.created_at)`),
+	).Param(ParamAny("timestamp", "An optional timestamp to embed within the ID.").Optional()),
+	ulidFunction,
+)
+
+const crockfordAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+func ulidFunction(args *ParsedParams) (Function, error) {
+	ts, err := optionalTimestampParam(args, "timestamp")
+	if err != nil {
+		return nil, err
+	}
+	return ClosureFunction("function ulid", func(_ FunctionContext) (interface{}, error) {
+		var id [16]byte
+		if _, err := crand.Read(id[6:]); err != nil {
+			return nil, err
+		}
+		putUnixMilli48(id[:6], timeOrNow(ts))
+
+		// A ULID is the 128 bit value encoded as 26 characters of Crockford's
+		// base32, which means the first character only carries three bits.
+		hi, lo := binary.BigEndian.Uint64(id[:8]), binary.BigEndian.Uint64(id[8:])
+		var out [26]byte
+		for i := len(out) - 1; i >= 0; i-- {
+			out[i] = crockfordAlphabet[lo&0x1f]
+			lo = (lo >> 5) | (hi << 59)
+			hi >>= 5
+		}
+		return string(out[:]), nil
+	}, nil), nil
+}
+
+//------------------------------------------------------------------------------
+
+var _ = registerFunction(
 	NewFunctionSpec(
 		FunctionCategoryGeneral, "ksuid",
 		"Generates a new ksuid each time it is invoked and prints a string representation.",
 		NewExampleSpec("", `root.id = ksuid()`),
-	),
-	func(_ FunctionContext) (interface{}, error) {
-		return ksuid.New().String(), nil
-	},
+		NewExampleSpec("It is possible to specify the timestamp embedded within the ID, either as a timestamp value, a unix timestamp number or an RFC 3339 string.", `root.id = ksuid(this.created_at)`),
+	).Param(ParamAny("timestamp", "An optional timestamp to embed within the ID.").Optional()),
+	ksuidFunction,
 )
 
+func ksuidFunction(args *ParsedParams) (Function, error) {
+	ts, err := optionalTimestampParam(args, "timestamp")
+	if err != nil {
+		return nil, err
+	}
+	return ClosureFunction("function ksuid", func(_ FunctionContext) (interface{}, error) {
+		if ts == nil {
+			return ksuid.New().String(), nil
+		}
+		id, err := ksuid.NewRandomWithTime(*ts)
+		if err != nil {
+			return nil, err
+		}
+		return id.String(), nil
+	}, nil), nil
+}
+
+func optionalTimestampParam(args *ParsedParams, name string) (*time.Time, error) {
+	v, err := args.Field(name)
+	if err != nil || v == nil {
+		return nil, err
+	}
+	ts, err := IGetTimestamp(v)
+	if err != nil {
+		return nil, fmt.Errorf("parameter %v: %w", name, err)
+	}
+	return &ts, nil
+}
+
+func timeOrNow(ts *time.Time) time.Time {
+	if ts != nil {
+		return *ts
+	}
+	return time.Now()
+}
+
+func putUnixMilli48(b []byte, t time.Time) {
+	ms := uint64(t.UnixMilli())
+	b[0] = byte(ms >> 40)
+	b[1] = byte(ms >> 32)
+	b[2] = byte(ms >> 24)
+	b[3] = byte(ms >> 16)
+	b[4] = byte(ms >> 8)
+	b[5] = byte(ms)
+}
+
 //------------------------------------------------------------------------------
 
 var _ = registerFunction(
@@ -2,9 +2,12 @@ package query
 
 import (
 	"fmt"
+	"strings"
 	"sync"
 	"testing"
 
+	"github.com/gofrs/uuid"
+	"github.com/segmentio/ksuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -276,6 +279,55 @@ func TestKsuidFunction(t *testing.T) {
 	assert.NotEmpty(t, res)
 }
 
+func TestKsuidFunctionTimestamp(t *testing.T) {
+	e, err := InitFunctionHelper("ksuid", "2016-07-30T22:36:16Z")
+	require.Nil(t, err)
+
+	res, err := e.Exec(FunctionContext{})
+	require.NoError(t, err)
+
+	id, err := ksuid.Parse(res.(string))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1469918176), id.Time().Unix())
+}
+
+func TestULIDFunction(t *testing.T) {
+	e, err := InitFunctionHelper("ulid")
+	require.Nil(t, err)
+
+	res, err := e.Exec(FunctionContext{})
+	require.NoError(t, err)
+	assert.Regexp(t, "^[0-9A-HJKMNP-TV-Z]{26}$", res)
+}
+
+func TestULIDFunctionTimestamp(t *testing.T) {
+	e, err := InitFunctionHelper("ulid", "2016-07-30T22:36:16.385Z")
+	require.Nil(t, err)
+
+	resA, err := e.Exec(FunctionContext{})
+	require.NoError(t, err)
+	resB, err := e.Exec(FunctionContext{})
+	require.NoError(t, err)
+
+	assert.Equal(t, "01ARYZ6S41", resA.(string)[:10])
+	assert.Equal(t, "01ARYZ6S41", resB.(string)[:10])
+	assert.NotEqual(t, resA, resB)
+}
+
+func TestUUIDV7Function(t *testing.T) {
+	e, err := InitFunctionHelper("uuid_v7", "2016-07-30T22:36:16.385Z")
+	require.Nil(t, err)
+
+	res, err := e.Exec(FunctionContext{})
+	require.NoError(t, err)
+
+	u, err := uuid.FromString(res.(string))
+	require.NoError(t, err)
+	assert.Equal(t, byte(7), u.Version())
+	assert.Equal(t, uuid.VariantRFC4122, u.Variant())
+	assert.Equal(t, "01563df36481", strings.ReplaceAll(res.(string), "-", "")[:12])
+}
+
 func TestRandomInt(t *testing.T) {
 	e, err := InitFunctionHelper("random_int")
 	require.Nil(t, err)
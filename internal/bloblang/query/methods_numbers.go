@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"sort"
 )
 
 var _ = registerSimpleMethod(
@@ -245,3 +246,232 @@ var _ = registerSimpleMethod(
 		}), nil
 	},
 )
+
+//------------------------------------------------------------------------------
+
+func numbersFromArray(v interface{}) ([]float64, error) {
+	nums, err := numbersFromArrayAllowEmpty(v)
+	if err != nil {
+		return nil, err
+	}
+	if len(nums) == 0 {
+		return nil, errors.New("the array was empty")
+	}
+	return nums, nil
+}
+
+func numbersFromArrayAllowEmpty(v interface{}) ([]float64, error) {
+	arr, ok := v.([]interface{})
+	if !ok {
+		return nil, NewTypeError(v, ValueArray)
+	}
+	nums := make([]float64, len(arr))
+	for i, n := range arr {
+		f, err := IGetNumber(n)
+		if err != nil {
+			return nil, fmt.Errorf("index %v of array: %w", i, err)
+		}
+		nums[i] = f
+	}
+	return nums, nil
+}
+
+func numbersMean(nums []float64) float64 {
+	var total float64
+	for _, n := range nums {
+		total += n
+	}
+	return total / float64(len(nums))
+}
+
+func numbersVariance(nums []float64) float64 {
+	mean := numbersMean(nums)
+	var total float64
+	for _, n := range nums {
+		total += (n - mean) * (n - mean)
+	}
+	return total / float64(len(nums))
+}
+
+// numbersPercentile returns the pth percentile of a sorted slice of numbers,
+// using linear interpolation between the closest ranks.
+func numbersPercentile(sorted []float64, p float64) float64 {
+	rank := (p / 100) * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	if lower == upper {
+		return sorted[lower]
+	}
+	return sorted[lower] + (rank-float64(lower))*(sorted[upper]-sorted[lower])
+}
+
+var _ = registerSimpleMethod(
+	NewMethodSpec(
+		"mean",
+		"Returns the arithmetic mean of the numerical values found within an array. All values must be numerical and the array must not be empty, otherwise an error is returned.",
+	).InCategory(
+		MethodCategoryNumbers, "",
+		NewExampleSpec("",
+			`root.mean = this.values.mean()`,
+			`{"values":[2,4,4,4,5,5,7,9]}`,
+			`{"mean":5}`,
+		),
+	),
+	func(*ParsedParams) (simpleMethod, error) {
+		return func(v interface{}, ctx FunctionContext) (interface{}, error) {
+			nums, err := numbersFromArray(v)
+			if err != nil {
+				return nil, err
+			}
+			return numbersMean(nums), nil
+		}, nil
+	},
+)
+
+var _ = registerSimpleMethod(
+	NewMethodSpec(
+		"median",
+		"Returns the median of the numerical values found within an array. When the array contains an even number of values the mean of the two middle values is returned. All values must be numerical and the array must not be empty, otherwise an error is returned.",
+	).InCategory(
+		MethodCategoryNumbers, "",
+		NewExampleSpec("",
+			`root.median = this.values.median()`,
+			`{"values":[7,1,3,10]}`,
+			`{"median":5}`,
+		),
+	),
+	func(*ParsedParams) (simpleMethod, error) {
+		return func(v interface{}, ctx FunctionContext) (interface{}, error) {
+			nums, err := numbersFromArray(v)
+			if err != nil {
+				return nil, err
+			}
+			sort.Float64s(nums)
+			return numbersPercentile(nums, 50), nil
+		}, nil
+	},
+)
+
+var _ = registerSimpleMethod(
+	NewMethodSpec(
+		"percentile",
+		"Returns the percentile of the numerical values found within an array, where the percentile is a number between 0 and 100. Values between two ranks are linearly interpolated. All values must be numerical and the array must not be empty, otherwise an error is returned.",
+	).InCategory(
+		MethodCategoryNumbers, "",
+		NewExampleSpec("",
+			`root.p90 = this.values.percentile(90)`,
+			`{"values":[1,2,3,4,5,6,7,8,9,10,11]}`,
+			`{"p90":10}`,
+		),
+	).Param(ParamFloat("p", "The percentile to calculate, between 0 and 100.")),
+	func(args *ParsedParams) (simpleMethod, error) {
+		p, err := args.FieldFloat("p")
+		if err != nil {
+			return nil, err
+		}
+		if p < 0 || p > 100 {
+			return nil, fmt.Errorf("percentile must be between 0 and 100, got %v", p)
+		}
+		return func(v interface{}, ctx FunctionContext) (interface{}, error) {
+			nums, err := numbersFromArray(v)
+			if err != nil {
+				return nil, err
+			}
+			sort.Float64s(nums)
+			return numbersPercentile(nums, p), nil
+		}, nil
+	},
+)
+
+var _ = registerSimpleMethod(
+	NewMethodSpec(
+		"variance",
+		"Returns the population variance of the numerical values found within an array. All values must be numerical and the array must not be empty, otherwise an error is returned.",
+	).InCategory(
+		MethodCategoryNumbers, "",
+		NewExampleSpec("",
+			`root.variance = this.values.variance()`,
+			`{"values":[2,4,4,4,5,5,7,9]}`,
+			`{"variance":4}`,
+		),
+	),
+	func(*ParsedParams) (simpleMethod, error) {
+		return func(v interface{}, ctx FunctionContext) (interface{}, error) {
+			nums, err := numbersFromArray(v)
+			if err != nil {
+				return nil, err
+			}
+			return numbersVariance(nums), nil
+		}, nil
+	},
+)
+
+var _ = registerSimpleMethod(
+	NewMethodSpec(
+		"stddev",
+		"Returns the population standard deviation of the numerical values found within an array. All values must be numerical and the array must not be empty, otherwise an error is returned.",
+	).InCategory(
+		MethodCategoryNumbers, "",
+		NewExampleSpec("",
+			`root.stddev = this.values.stddev()`,
+			`{"values":[2,4,4,4,5,5,7,9]}`,
+			`{"stddev":2}`,
+		),
+	),
+	func(*ParsedParams) (simpleMethod, error) {
+		return func(v interface{}, ctx FunctionContext) (interface{}, error) {
+			nums, err := numbersFromArray(v)
+			if err != nil {
+				return nil, err
+			}
+			return math.Sqrt(numbersVariance(nums)), nil
+		}, nil
+	},
+)
+
+var _ = registerSimpleMethod(
+	NewMethodSpec(
+		"histogram",
+		"Counts the numerical values found within an array into buckets defined by an ascending array of upper bounds, returning an array of counts. A value is counted in the first bucket where it is less than or equal to the upper bound, and the returned array contains an extra final count of values greater than the last bound. An empty array results in all counts being zero.",
+	).InCategory(
+		MethodCategoryNumbers, "",
+		NewExampleSpec("",
+			`root.latencies = this.latencies.histogram([10, 50, 100])`,
+			`{"latencies":[3,12,8,45,60,99,250]}`,
+			`{"latencies":[2,2,2,1]}`,
+		),
+	).Param(ParamArray("buckets", "An ascending array of bucket upper bounds.")),
+	func(args *ParsedParams) (simpleMethod, error) {
+		bucketsArg, err := args.FieldArray("buckets")
+		if err != nil {
+			return nil, err
+		}
+		if len(bucketsArg) == 0 {
+			return nil, errors.New("at least one bucket must be specified")
+		}
+		buckets := make([]float64, len(bucketsArg))
+		for i, b := range bucketsArg {
+			if buckets[i], err = IGetNumber(b); err != nil {
+				return nil, fmt.Errorf("bucket %v: %w", i, err)
+			}
+			if i > 0 && buckets[i] <= buckets[i-1] {
+				return nil, errors.New("buckets must be in ascending order")
+			}
+		}
+		return func(v interface{}, ctx FunctionContext) (interface{}, error) {
+			nums, err := numbersFromArrayAllowEmpty(v)
+			if err != nil {
+				return nil, err
+			}
+			counts := make([]int64, len(buckets)+1)
+			for _, n := range nums {
+				counts[sort.SearchFloat64s(buckets, n)]++
+			}
+			res := make([]interface{}, len(counts))
+			for i, c := range counts {
+				res[i] = c
+			}
+			return res, nil
+		}, nil
+	},
+)
@@ -10,14 +10,20 @@ import (
 	"crypto/sha256"
 	"crypto/sha512"
 	"encoding/ascii85"
+	"encoding/base32"
 	"encoding/base64"
 	"encoding/csv"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"hash"
+	"hash/crc32"
+	"hash/crc64"
+	"hash/fnv"
 	"html"
 	"io"
+	"math/big"
 	"net/url"
 	"path/filepath"
 	"regexp"
@@ -26,7 +32,9 @@ import (
 
 	"github.com/OneOfOne/xxhash"
 	"github.com/microcosm-cc/bluemonday"
+	"github.com/spaolacci/murmur3"
 	"github.com/tilinna/z85"
+	"github.com/zeebo/xxh3"
 	"golang.org/x/text/cases"
 	"golang.org/x/text/language"
 	"gopkg.in/yaml.v3"
@@ -85,7 +93,7 @@ var _ = registerSimpleMethod(
 		"encode", "",
 	).InCategory(
 		MethodCategoryEncoding,
-		"Encodes a string or byte array target according to a chosen scheme and returns a string result. Available schemes are: `base64`, `base64url`, `hex`, `ascii85`.",
+		"Encodes a string or byte array target according to a chosen scheme and returns a string result. Available schemes are: `base64`, `base64url`, `hex`, `ascii85`, `base58`, `base36`, `zbase32`.",
 		// NOTE: z85 has been removed from the list until we can support
 		// misaligned data automatically. It'll still be supported for backwards
 		// compatibility, but given it behaves differently to `ascii85` I think
@@ -100,6 +108,11 @@ var _ = registerSimpleMethod(
 			`this is totally unstructured data`,
 			"{\"encoded\":\"FD,B0+DGm>FDl80Ci\\\"A>F`)8BEckl6F`M&(+Cno&@/\"}",
 		),
+		NewExampleSpec("",
+			`root.encoded = this.value.encode("base58")`,
+			`{"value":"hello world"}`,
+			`{"encoded":"StV1DL6CwTryKyV"}`,
+		),
 	).Param(ParamString("scheme", "The encoding scheme to use.")),
 	func(args *ParsedParams) (simpleMethod, error) {
 		schemeStr, err := args.FieldString("scheme")
@@ -146,6 +159,18 @@ var _ = registerSimpleMethod(
 				}
 				return buf.String(), nil
 			}
+		case "base58":
+			schemeFn = func(b []byte) (string, error) {
+				return encodeBaseX(base58Alphabet, b), nil
+			}
+		case "base36":
+			schemeFn = func(b []byte) (string, error) {
+				return encodeBaseX(base36Alphabet, b), nil
+			}
+		case "zbase32":
+			schemeFn = func(b []byte) (string, error) {
+				return zbase32Encoding.EncodeToString(b), nil
+			}
 		case "z85":
 			schemeFn = func(b []byte) (string, error) {
 				// TODO: Update this to support misaligned input data similar to the
@@ -183,7 +208,7 @@ var _ = registerSimpleMethod(
 		"decode", "",
 	).InCategory(
 		MethodCategoryEncoding,
-		"Decodes an encoded string target according to a chosen scheme and returns the result as a byte array. When mapping the result to a JSON field the value should be cast to a string using the method [`string`][methods.string], or encoded using the method [`encode`][methods.encode], otherwise it will be base64 encoded by default.\n\nAvailable schemes are: `base64`, `base64url`, `hex`, `ascii85`.",
+		"Decodes an encoded string target according to a chosen scheme and returns the result as a byte array. When mapping the result to a JSON field the value should be cast to a string using the method [`string`][methods.string], or encoded using the method [`encode`][methods.encode], otherwise it will be base64 encoded by default.\n\nAvailable schemes are: `base64`, `base64url`, `hex`, `ascii85`, `base58`, `base36`, `zbase32`.",
 		// NOTE: z85 has been removed from the list until we can support
 		// misaligned data automatically. It'll still be supported for backwards
 		// compatibility, but given it behaves differently to `ascii85` I think
@@ -227,6 +252,18 @@ var _ = registerSimpleMethod(
 				e := ascii85.NewDecoder(bytes.NewReader(b))
 				return io.ReadAll(e)
 			}
+		case "base58":
+			schemeFn = func(b []byte) ([]byte, error) {
+				return decodeBaseX(base58Alphabet, string(b))
+			}
+		case "base36":
+			schemeFn = func(b []byte) ([]byte, error) {
+				return decodeBaseX(base36Alphabet, strings.ToLower(string(b)))
+			}
+		case "zbase32":
+			schemeFn = func(b []byte) ([]byte, error) {
+				return zbase32Encoding.DecodeString(string(b))
+			}
 		case "z85":
 			schemeFn = func(b []byte) ([]byte, error) {
 				// TODO: Update this to support misaligned input data similar to the
@@ -257,6 +294,58 @@ var _ = registerSimpleMethod(
 	},
 )
 
+const (
+	base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+	base36Alphabet = "0123456789abcdefghijklmnopqrstuvwxyz"
+)
+
+var zbase32Encoding = base32.NewEncoding("ybndrfg8ejkmcpqxot1uwisza345h769").WithPadding(base32.NoPadding)
+
+// encodeBaseX encodes bytes as a big-endian number in the radix of the given
+// alphabet, where each leading zero byte is preserved as a leading zero digit.
+func encodeBaseX(alphabet string, b []byte) string {
+	zeros := 0
+	for zeros < len(b) && b[zeros] == 0 {
+		zeros++
+	}
+
+	radix := big.NewInt(int64(len(alphabet)))
+	n := new(big.Int).SetBytes(b[zeros:])
+	mod := new(big.Int)
+
+	var digits []byte
+	for n.Sign() > 0 {
+		n.DivMod(n, radix, mod)
+		digits = append(digits, alphabet[mod.Int64()])
+	}
+	for i := 0; i < zeros; i++ {
+		digits = append(digits, alphabet[0])
+	}
+	for i, j := 0, len(digits)-1; i < j; i, j = i+1, j-1 {
+		digits[i], digits[j] = digits[j], digits[i]
+	}
+	return string(digits)
+}
+
+func decodeBaseX(alphabet, s string) ([]byte, error) {
+	zeros := 0
+	for zeros < len(s) && s[zeros] == alphabet[0] {
+		zeros++
+	}
+
+	radix := big.NewInt(int64(len(alphabet)))
+	n := new(big.Int)
+	for i := zeros; i < len(s); i++ {
+		d := strings.IndexByte(alphabet, s[i])
+		if d < 0 {
+			return nil, fmt.Errorf("illegal character %q at index %v", s[i], i)
+		}
+		n.Mul(n, radix)
+		n.Add(n, big.NewInt(int64(d)))
+	}
+	return append(make([]byte, zeros), n.Bytes()...), nil
+}
+
 //------------------------------------------------------------------------------
 
 var _ = registerSimpleMethod(
@@ -701,7 +790,9 @@ var _ = registerSimpleMethod(
 		`
 Hashes a string or byte array according to a chosen algorithm and returns the result as a byte array. When mapping the result to a JSON field the value should be cast to a string using the method `+"[`string`][methods.string], or encoded using the method [`encode`][methods.encode]"+`, otherwise it will be base64 encoded by default.
 
-Available algorithms are: `+"`hmac_sha1`, `hmac_sha256`, `hmac_sha512`, `md5`, `sha1`, `sha256`, `sha512`, `xxhash64`"+`.
+Available algorithms are: `+"`hmac_sha1`, `hmac_sha256`, `hmac_sha512`, `md5`, `sha1`, `sha256`, `sha512`, `xxhash64`, `xxhash128`, `murmur3_32`, `murmur3_128`, `crc32`, `crc32_castagnoli`, `crc64`, `fnv32`, `fnv32a`, `fnv64`, `fnv64a`, `fnv128`, `fnv128a`"+`.
+
+With the exception of `+"`xxhash64`"+`, which for backwards compatibility returns the decimal representation of the hash, non-cryptographic algorithms return the big-endian bytes of the checksum.
 
 The following algorithms require a key, which is specified as a second argument: `+"`hmac_sha1`, `hmac_sha256`, `hmac_sha512`"+`.`,
 		NewExampleSpec("",
@@ -710,6 +801,12 @@ root.h2 = this.value.hash("hmac_sha1","static-key").encode("hex")`,
 			`{"value":"hello world"}`,
 			`{"h1":"2aae6c35c94fcfb415dbe95f408b9ce91ee846ed","h2":"d87e5f068fa08fe90bb95bc7c8344cb809179d76"}`,
 		),
+		NewExampleSpec("",
+			`root.crc = this.value.hash("crc32").encode("hex")
+root.fnv = this.value.hash("fnv64a").encode("hex")`,
+			`{"value":"hello world"}`,
+			`{"crc":"0d4a1185","fnv":"779a65e7023cd2e7"}`,
+		),
 	).
 		Param(ParamString("algorithm", "The hasing algorithm to use.")).
 		Param(ParamString("key", "An optional key to use.").Optional()),
@@ -785,6 +882,33 @@ root.h2 = this.value.hash("hmac_sha1","static-key").encode("hex")`,
 				_, _ = h.Write(b)
 				return []byte(strconv.FormatUint(h.Sum64(), 10)), nil
 			}
+		case "xxhash128":
+			hashFn = func(b []byte) ([]byte, error) {
+				sum := xxh3.Hash128(b).Bytes()
+				return sum[:], nil
+			}
+		case "murmur3_32":
+			hashFn = stdHashFn(func() hash.Hash { return murmur3.New32() })
+		case "murmur3_128":
+			hashFn = stdHashFn(func() hash.Hash { return murmur3.New128() })
+		case "crc32":
+			hashFn = stdHashFn(func() hash.Hash { return crc32.NewIEEE() })
+		case "crc32_castagnoli":
+			hashFn = stdHashFn(func() hash.Hash { return crc32.New(crc32CastagnoliTable) })
+		case "crc64":
+			hashFn = stdHashFn(func() hash.Hash { return crc64.New(crc64ECMATable) })
+		case "fnv32":
+			hashFn = stdHashFn(func() hash.Hash { return fnv.New32() })
+		case "fnv32a":
+			hashFn = stdHashFn(func() hash.Hash { return fnv.New32a() })
+		case "fnv64":
+			hashFn = stdHashFn(func() hash.Hash { return fnv.New64() })
+		case "fnv64a":
+			hashFn = stdHashFn(func() hash.Hash { return fnv.New64a() })
+		case "fnv128":
+			hashFn = stdHashFn(func() hash.Hash { return fnv.New128() })
+		case "fnv128a":
+			hashFn = stdHashFn(func() hash.Hash { return fnv.New128a() })
 		default:
 			return nil, fmt.Errorf("unrecognized hash type: %v", algorithmStr)
 		}
@@ -804,6 +928,19 @@ root.h2 = this.value.hash("hmac_sha1","static-key").encode("hex")`,
 	},
 )
 
+var (
+	crc32CastagnoliTable = crc32.MakeTable(crc32.Castagnoli)
+	crc64ECMATable       = crc64.MakeTable(crc64.ECMA)
+)
+
+func stdHashFn(ctor func() hash.Hash) func([]byte) ([]byte, error) {
+	return func(b []byte) ([]byte, error) {
+		hasher := ctor()
+		_, _ = hasher.Write(b)
+		return hasher.Sum(nil), nil
+	}
+}
+
 //------------------------------------------------------------------------------
 
 var _ = registerSimpleMethod(
@@ -1635,6 +1772,92 @@ func reReplaceAllImpl(args *ParsedParams) (simpleMethod, error) {
 
 //------------------------------------------------------------------------------
 
+var _ = registerSimpleMethod(
+	NewMethodSpec(
+		"re_replace_fn", "",
+	).InCategory(
+		MethodCategoryRegexp,
+		"Replaces all occurrences of the argument regular expression in a string with the result of a query executed for each match. Within the query the context is an object containing the match and the matches of its subexpressions, in the same format as [`re_find_object`](#re_find_object). The query must resolve to a string, number or boolean. If the query resolves to `deleted()` the match is removed, and if it resolves to `nothing()` the match is left unchanged.",
+		NewExampleSpec("",
+			`root.new_value = this.value.re_replace_fn("(?P<amount>[0-9]+)(?P<unit>ms|s)", m -> if m.unit == "s" { "%vms".format(m.amount.number() * 1000) } else { nothing() })`,
+			`{"value":"timeout of 3s with a backoff of 200ms"}`,
+			`{"new_value":"timeout of 3000ms with a backoff of 200ms"}`,
+		),
+		NewExampleSpec("",
+			`root.redacted = this.value.re_replace_fn("\\b(?P<user>[\\w.]+)@(?P<domain>[\\w.]+)\\b", m -> m.user.slice(0, 1) + "***@" + m.domain)`,
+			`{"value":"contact alice@example.com or bob@example.org"}`,
+			`{"redacted":"contact a***@example.com or b***@example.org"}`,
+		),
+	).
+		Param(ParamString("pattern", "The pattern to match against.")).
+		Param(ParamQuery("query", "A query to execute for each match, the result of which replaces the match.", false)),
+	func(args *ParsedParams) (simpleMethod, error) {
+		reStr, err := args.FieldString("pattern")
+		if err != nil {
+			return nil, err
+		}
+		re, err := regexp.Compile(reStr)
+		if err != nil {
+			return nil, err
+		}
+		replaceFn, err := args.FieldQuery("query")
+		if err != nil {
+			return nil, err
+		}
+		groups := re.SubexpNames()
+		for i, k := range groups {
+			if k == "" {
+				groups[i] = fmt.Sprintf("%v", i)
+			}
+		}
+		return func(v interface{}, ctx FunctionContext) (interface{}, error) {
+			var str string
+			switch t := v.(type) {
+			case string:
+				str = t
+			case []byte:
+				str = string(t)
+			default:
+				return nil, NewTypeError(v, ValueString)
+			}
+
+			var result strings.Builder
+			lastIndex := 0
+			for _, indexes := range re.FindAllStringSubmatchIndex(str, -1) {
+				matchObj := make(map[string]interface{}, len(groups))
+				for i, key := range groups {
+					if start := indexes[i*2]; start >= 0 {
+						matchObj[key] = str[start:indexes[i*2+1]]
+					} else {
+						matchObj[key] = ""
+					}
+				}
+
+				newV, err := replaceFn.Exec(ctx.WithValue(matchObj))
+				if err != nil {
+					return nil, fmt.Errorf("failed to process match %q: %w", matchObj["0"], ErrFrom(err, replaceFn))
+				}
+
+				result.WriteString(str[lastIndex:indexes[0]])
+				switch t := newV.(type) {
+				case Delete:
+				case Nothing:
+					result.WriteString(str[indexes[0]:indexes[1]])
+				case string, []byte, int64, uint64, float64, json.Number, bool:
+					result.WriteString(IToString(t))
+				default:
+					return nil, fmt.Errorf("failed to process match %q: %w", matchObj["0"], NewTypeError(newV, ValueString))
+				}
+				lastIndex = indexes[1]
+			}
+			result.WriteString(str[lastIndex:])
+			return result.String(), nil
+		}, nil
+	},
+)
+
+//------------------------------------------------------------------------------
+
 var _ = registerSimpleMethod(
 	NewMethodSpec(
 		"split", "",
@@ -723,6 +723,41 @@ func TestMethods(t *testing.T) {
 				"foo.3.bar": []struct{}{},
 			},
 		},
+		"check median odd": {
+			input: methods(
+				jsonFn(`[5,1,3]`),
+				method("median"),
+			),
+			output: float64(3),
+		},
+		"check percentile max": {
+			input: methods(
+				jsonFn(`[5,1,3,9]`),
+				method("percentile", 100.0),
+			),
+			output: float64(9),
+		},
+		"check percentile interpolated": {
+			input: methods(
+				jsonFn(`[1,2,3,4]`),
+				method("percentile", 25.0),
+			),
+			output: 1.75,
+		},
+		"check histogram boundaries": {
+			input: methods(
+				jsonFn(`[1,2,2,3]`),
+				method("histogram", []interface{}{int64(1), int64(2)}),
+			),
+			output: []interface{}{int64(1), int64(2), int64(1)},
+		},
+		"check histogram empty": {
+			input: methods(
+				jsonFn(`[]`),
+				method("histogram", []interface{}{int64(1), int64(2)}),
+			),
+			output: []interface{}{int64(0), int64(0), int64(0)},
+		},
 		"check sha1 hash": {
 			input: methods(
 				literalFn("hello world"),
@@ -787,6 +822,30 @@ func TestMethods(t *testing.T) {
 			),
 			output: `5020219685658847592`,
 		},
+		"check crc32 hash": {
+			input: methods(
+				literalFn("hello world"),
+				method("hash", "crc32"),
+				method("encode", "hex"),
+			),
+			output: `0d4a1185`,
+		},
+		"check fnv64a hash": {
+			input: methods(
+				literalFn("hello world"),
+				method("hash", "fnv64a"),
+				method("encode", "hex"),
+			),
+			output: `779a65e7023cd2e7`,
+		},
+		"check murmur3_32 hash": {
+			input: methods(
+				literalFn("hello world"),
+				method("hash", "murmur3_32"),
+				method("encode", "hex"),
+			),
+			output: `5e928f0f`,
+		},
 		"check md5 hash": {
 			input: methods(
 				literalFn("hello world"),
@@ -840,6 +899,58 @@ func TestMethods(t *testing.T) {
 			),
 			output: `<<???>>`,
 		},
+		"check base58 encode": {
+			input: methods(
+				literalFn("hello world"),
+				method("encode", "base58"),
+			),
+			output: `StV1DL6CwTryKyV`,
+		},
+		"check base58 encode leading zeros": {
+			input: methods(
+				literalFn("\x00\x00hello world"),
+				method("encode", "base58"),
+			),
+			output: `11StV1DL6CwTryKyV`,
+		},
+		"check base58 decode": {
+			input: methods(
+				literalFn("11StV1DL6CwTryKyV"),
+				method("decode", "base58"),
+				method("string"),
+			),
+			output: "\x00\x00hello world",
+		},
+		"check base36 encode": {
+			input: methods(
+				literalFn("hello world"),
+				method("encode", "base36"),
+			),
+			output: `fuvrsivvnfrbjwajo`,
+		},
+		"check base36 decode": {
+			input: methods(
+				literalFn("FUVRSIVVNFRBJWAJO"),
+				method("decode", "base36"),
+				method("string"),
+			),
+			output: `hello world`,
+		},
+		"check zbase32 encode": {
+			input: methods(
+				literalFn("hello world"),
+				method("encode", "zbase32"),
+			),
+			output: `pb1sa5dxrb5s6hucco`,
+		},
+		"check zbase32 decode": {
+			input: methods(
+				literalFn("pb1sa5dxrb5s6hucco"),
+				method("decode", "zbase32"),
+				method("string"),
+			),
+			output: `hello world`,
+		},
 		"check z85 encode": {
 			input: methods(
 				literalFn("hello world!"),
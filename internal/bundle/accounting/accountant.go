@@ -0,0 +1,172 @@
+package accounting
+
+import (
+	"encoding/json"
+	"net/http"
+	"runtime"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Usage describes the resources used by a component. The CPU time and
+// allocations are estimated from a sample of the executions of processors, or
+// the reads and writes of inputs and outputs, where executions of inputs and
+// outputs are the number of messages that they have consumed or written.
+//
+// Allocations are read from process wide statistics and therefore include the
+// allocations of any other goroutines running at the same time as a sample,
+// which is why they are labelled as approximate.
+type Usage struct {
+	Component        string `json:"component"`
+	Kind             string `json:"kind"`
+	Executions       uint64 `json:"executions"`
+	Samples          uint64 `json:"samples"`
+	CPUNS            int64  `json:"cpu_ns"`
+	ApproxAllocBytes int64  `json:"approx_alloc_bytes"`
+	Bytes            uint64 `json:"bytes"`
+}
+
+type componentUsage struct {
+	kind string
+
+	executions uint64
+	operations uint64
+	bytes      uint64
+
+	mut               sync.Mutex
+	samples           uint64
+	sampledCPUNS      int64
+	sampledAllocBytes int64
+}
+
+// sample returns true when the next operation should be measured.
+func (c *componentUsage) sample(every int) bool {
+	n := atomic.AddUint64(&c.operations, 1)
+	return every <= 1 || n%uint64(every) == 1
+}
+
+func (c *componentUsage) addSample(cpu time.Duration, allocBytes uint64) {
+	c.mut.Lock()
+	c.samples++
+	c.sampledCPUNS += cpu.Nanoseconds()
+	c.sampledAllocBytes += int64(allocBytes)
+	c.mut.Unlock()
+}
+
+func (c *componentUsage) usage(key string) Usage {
+	u := Usage{
+		Component:  key,
+		Kind:       c.kind,
+		Executions: atomic.LoadUint64(&c.executions),
+		Bytes:      atomic.LoadUint64(&c.bytes),
+	}
+
+	operations := atomic.LoadUint64(&c.operations)
+
+	c.mut.Lock()
+	u.Samples = c.samples
+	if c.samples > 0 {
+		scale := float64(operations) / float64(c.samples)
+		u.CPUNS = int64(float64(c.sampledCPUNS) * scale)
+		u.ApproxAllocBytes = int64(float64(c.sampledAllocBytes) * scale)
+	}
+	c.mut.Unlock()
+	return u
+}
+
+//------------------------------------------------------------------------------
+
+// Accountant keeps track of the resources used by components, keyed by their
+// label or, for components without a label, their path.
+type Accountant struct {
+	sampleEvery int
+
+	mut        sync.Mutex
+	components map[string]*componentUsage
+}
+
+// NewAccountant creates an accountant from a config.
+func NewAccountant(conf Config) *Accountant {
+	return &Accountant{
+		sampleEvery: conf.SampleEvery,
+		components:  map[string]*componentUsage{},
+	}
+}
+
+// component returns the usage of a component, components that share a key,
+// such as those of multiple streams, share their usage.
+func (a *Accountant) component(key, kind string) *componentUsage {
+	a.mut.Lock()
+	defer a.mut.Unlock()
+
+	c, exists := a.components[key]
+	if !exists {
+		c = &componentUsage{kind: kind}
+		a.components[key] = c
+	}
+	return c
+}
+
+// Usage returns the usage of all components sorted by their key.
+func (a *Accountant) Usage() []Usage {
+	a.mut.Lock()
+	keys := make([]string, 0, len(a.components))
+	for k := range a.components {
+		keys = append(keys, k)
+	}
+	a.mut.Unlock()
+	sort.Strings(keys)
+
+	usages := make([]Usage, 0, len(keys))
+	for _, k := range keys {
+		usages = append(usages, a.component(k, "").usage(k))
+	}
+	return usages
+}
+
+// Handler returns an HTTP handler that responds with the usage of all
+// components as a JSON array.
+func (a *Accountant) Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		resBytes, err := json.Marshal(a.Usage())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(resBytes)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// measure executes a function locked to its OS thread and returns the CPU time
+// it consumed along with the bytes allocated during its execution. The runtime
+// does not track allocations per goroutine, and therefore allocations are read
+// from process wide statistics and include those of other goroutines executing
+// at the same time, which makes them an approximation that is only accurate
+// when components are not running in parallel.
+func measure(fn func()) (cpu time.Duration, allocBytes uint64) {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+
+	started := time.Now()
+	startCPU, cpuOk := threadCPUTime()
+
+	fn()
+
+	if endCPU, ok := threadCPUTime(); cpuOk && ok {
+		cpu = endCPU - startCPU
+	} else {
+		cpu = time.Since(started)
+	}
+
+	runtime.ReadMemStats(&after)
+	allocBytes = after.TotalAlloc - before.TotalAlloc
+	return
+}
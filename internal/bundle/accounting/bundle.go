@@ -0,0 +1,188 @@
+package accounting
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
+	"github.com/benthosdev/benthos/v4/internal/bundle"
+	"github.com/benthosdev/benthos/v4/internal/bundle/wrap"
+	"github.com/benthosdev/benthos/v4/internal/component"
+	"github.com/benthosdev/benthos/v4/internal/component/input"
+	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+	"github.com/benthosdev/benthos/v4/internal/component/output"
+	"github.com/benthosdev/benthos/v4/internal/component/processor"
+	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/pipeline"
+)
+
+func componentKey(nm bundle.NewManagement) string {
+	if key := nm.Label(); key != "" {
+		return key
+	}
+	return "root." + query.SliceToDotPath(nm.Path()...)
+}
+
+// AccountedBundle modifies a provided bundle environment so that the resources
+// used by components are accounted for. The CPU time and allocations of a
+// sample of processor executions, and of the reads of inputs and writes of
+// outputs, are measured. The number of messages and bytes consumed by inputs
+// and written by outputs are also counted. Inputs are accounted for before any
+// of their processors are executed, and outputs after their processors are
+// executed.
+func AccountedBundle(b *bundle.Environment, a *Accountant) *bundle.Environment {
+	accountedEnv := b.Clone()
+
+	for _, spec := range b.ProcessorDocs() {
+		_ = accountedEnv.ProcessorAdd(func(conf processor.Config, nm bundle.NewManagement) (processor.V1, error) {
+			p, err := b.ProcessorInit(conf, nm)
+			if err != nil {
+				return nil, err
+			}
+			return &accountedProcessor{
+				meter:   a.meter(nm, "processor"),
+				wrapped: p,
+			}, nil
+		}, spec)
+	}
+
+	// Inputs and outputs are given a management that implements
+	// component.ExecutionMeter, which is used in order to measure their reads
+	// and writes.
+	for _, spec := range b.InputDocs() {
+		_ = accountedEnv.InputAdd(func(conf input.Config, nm bundle.NewManagement, pcf ...processor.PipelineConstructorFunc) (input.Streamed, error) {
+			return b.InputInit(conf, a.meter(nm, "input"), pcf...)
+		}, spec)
+	}
+	for _, spec := range b.OutputDocs() {
+		_ = accountedEnv.OutputAdd(func(conf output.Config, nm bundle.NewManagement, pcf ...processor.PipelineConstructorFunc) (output.Streamed, error) {
+			return b.OutputInit(conf, a.meter(nm, "output"), pcf...)
+		}, spec)
+	}
+
+	accountedEnv = wrap.Inputs(accountedEnv, func(i input.Streamed, iConf input.Config, nm bundle.NewManagement) (input.Streamed, error) {
+		counter := &countingProcessor{
+			usage:  a.component(componentKey(nm), "input"),
+			mBytes: nm.Metrics().GetCounter("input_received_bytes"),
+		}
+		return input.WrapWithPipeline(i, counter.pipeline)
+	})
+
+	return wrap.Outputs(accountedEnv, func(o output.Streamed, oConf output.Config, nm bundle.NewManagement) (output.Streamed, error) {
+		counter := &countingProcessor{
+			usage:  a.component(componentKey(nm), "output"),
+			mBytes: nm.Metrics().GetCounter("output_sent_bytes"),
+		}
+		return output.WrapWithPipeline(o, counter.pipeline)
+	})
+}
+
+//------------------------------------------------------------------------------
+
+// meteredManagement measures a sample of the executions of a component.
+type meteredManagement struct {
+	bundle.NewManagement
+
+	usage       *componentUsage
+	sampleEvery int
+	mCPU        metrics.StatCounter
+	mAlloc      metrics.StatCounter
+}
+
+func (a *Accountant) meter(nm bundle.NewManagement, kind string) *meteredManagement {
+	sampleEvery := a.sampleEvery
+	if sampleEvery < 1 {
+		sampleEvery = 1
+	}
+	return &meteredManagement{
+		NewManagement: nm,
+		usage:         a.component(componentKey(nm), kind),
+		sampleEvery:   sampleEvery,
+		mCPU:          nm.Metrics().GetCounter(kind + "_cpu_ns"),
+		mAlloc:        nm.Metrics().GetCounter(kind + "_approx_alloc_bytes"),
+	}
+}
+
+// Unwrap returns the management that is being metered.
+func (m *meteredManagement) Unwrap() bundle.NewManagement {
+	return m.NewManagement
+}
+
+// ConnectBackoff returns the backoff policy of the management that is being
+// metered, if it provides one.
+func (m *meteredManagement) ConnectBackoff() *component.ConnectBackoff {
+	if p, ok := m.NewManagement.(component.ConnectBackoffProvider); ok {
+		return p.ConnectBackoff()
+	}
+	return nil
+}
+
+// MeterExecution executes a function, and measures the resources it uses when
+// the execution is sampled.
+func (m *meteredManagement) MeterExecution(fn func()) {
+	if !m.usage.sample(m.sampleEvery) {
+		fn()
+		return
+	}
+
+	cpu, allocBytes := measure(fn)
+
+	m.usage.addSample(cpu, allocBytes)
+	m.mCPU.Incr(cpu.Nanoseconds() * int64(m.sampleEvery))
+	m.mAlloc.Incr(int64(allocBytes) * int64(m.sampleEvery))
+}
+
+//------------------------------------------------------------------------------
+
+type accountedProcessor struct {
+	meter   *meteredManagement
+	wrapped processor.V1
+}
+
+func (p *accountedProcessor) ProcessMessage(m *message.Batch) (outMsgs []*message.Batch, res error) {
+	atomic.AddUint64(&p.meter.usage.executions, 1)
+	p.meter.MeterExecution(func() {
+		outMsgs, res = p.wrapped.ProcessMessage(m)
+	})
+	return
+}
+
+func (p *accountedProcessor) CloseAsync() {
+	p.wrapped.CloseAsync()
+}
+
+func (p *accountedProcessor) WaitForClose(timeout time.Duration) error {
+	return p.wrapped.WaitForClose(timeout)
+}
+
+//------------------------------------------------------------------------------
+
+// countingProcessor counts the messages and bytes that pass through it without
+// modifying them.
+type countingProcessor struct {
+	usage  *componentUsage
+	mBytes metrics.StatCounter
+}
+
+func (c *countingProcessor) pipeline() (processor.Pipeline, error) {
+	return pipeline.NewProcessor(c), nil
+}
+
+func (c *countingProcessor) ProcessMessage(m *message.Batch) ([]*message.Batch, error) {
+	var bytes int
+	_ = m.Iter(func(i int, part *message.Part) error {
+		bytes += len(part.Get())
+		return nil
+	})
+	atomic.AddUint64(&c.usage.executions, uint64(m.Len()))
+	atomic.AddUint64(&c.usage.bytes, uint64(bytes))
+	c.mBytes.Incr(int64(bytes))
+	return []*message.Batch{m}, nil
+}
+
+func (c *countingProcessor) CloseAsync() {
+}
+
+func (c *countingProcessor) WaitForClose(timeout time.Duration) error {
+	return nil
+}
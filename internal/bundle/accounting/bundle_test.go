@@ -0,0 +1,132 @@
+package accounting_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/bundle"
+	"github.com/benthosdev/benthos/v4/internal/bundle/accounting"
+	"github.com/benthosdev/benthos/v4/internal/component/input"
+	"github.com/benthosdev/benthos/v4/internal/component/output"
+	"github.com/benthosdev/benthos/v4/internal/component/processor"
+	"github.com/benthosdev/benthos/v4/internal/manager"
+	"github.com/benthosdev/benthos/v4/internal/message"
+
+	_ "github.com/benthosdev/benthos/v4/public/components/all"
+)
+
+func TestBundleProcessorAccounting(t *testing.T) {
+	conf := accounting.NewConfig()
+	conf.Enabled = true
+	conf.SampleEvery = 2
+
+	accountant := accounting.NewAccountant(conf)
+	aenv := accounting.AccountedBundle(bundle.GlobalEnvironment, accountant)
+
+	mgr, err := manager.New(
+		manager.NewResourceConfig(),
+		manager.OptSetEnvironment(aenv),
+	)
+	require.NoError(t, err)
+
+	procConf := processor.NewConfig()
+	procConf.Label = "foo"
+	procConf.Type = "bloblang"
+	procConf.Bloblang = `root = range(0, 1000).map_each(i -> content().uppercase())`
+
+	proc, err := mgr.NewProcessor(procConf)
+	require.NoError(t, err)
+
+	for i := 0; i < 4; i++ {
+		msgs, res := proc.ProcessMessage(message.QuickBatch([][]byte{[]byte("hello")}))
+		require.NoError(t, res)
+		require.Len(t, msgs, 1)
+	}
+
+	usage := accountant.Usage()
+	require.Len(t, usage, 1)
+	assert.Equal(t, "foo", usage[0].Component)
+	assert.Equal(t, "processor", usage[0].Kind)
+	assert.Equal(t, uint64(4), usage[0].Executions)
+	assert.Equal(t, uint64(2), usage[0].Samples)
+	assert.Greater(t, usage[0].CPUNS, int64(0))
+	assert.Greater(t, usage[0].ApproxAllocBytes, int64(0))
+
+	w := httptest.NewRecorder()
+	accountant.Handler()(w, httptest.NewRequest(http.MethodGet, "/debug/accounting", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var served []accounting.Usage
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &served))
+	require.Len(t, served, 1)
+	assert.Equal(t, "foo", served[0].Component)
+}
+
+func TestBundleInputOutputAccounting(t *testing.T) {
+	accountant := accounting.NewAccountant(accounting.NewConfig())
+	aenv := accounting.AccountedBundle(bundle.GlobalEnvironment, accountant)
+
+	mgr, err := manager.New(
+		manager.NewResourceConfig(),
+		manager.OptSetEnvironment(aenv),
+	)
+	require.NoError(t, err)
+
+	inConf := input.NewConfig()
+	inConf.Type = "generate"
+	inConf.Generate.Mapping = `root = "hello"`
+	inConf.Generate.Count = 3
+	inConf.Generate.Interval = ""
+
+	procConf := processor.NewConfig()
+	procConf.Type = "bloblang"
+	procConf.Bloblang = `root = content().string() + content().string()`
+	inConf.Processors = append(inConf.Processors, procConf)
+
+	in, err := mgr.IntoPath("input").NewInput(inConf)
+	require.NoError(t, err)
+
+	outConf := output.NewConfig()
+	outConf.Label = "bar"
+	outConf.Type = "drop"
+
+	out, err := mgr.NewOutput(outConf)
+	require.NoError(t, err)
+	require.NoError(t, out.Consume(in.TransactionChan()))
+
+	require.Eventually(t, func() bool {
+		for _, u := range accountant.Usage() {
+			if u.Component == "bar" && u.Executions == 3 {
+				return true
+			}
+		}
+		return false
+	}, time.Second*5, time.Millisecond*10)
+
+	out.CloseAsync()
+	require.NoError(t, out.WaitForClose(time.Second))
+
+	usage := map[string]accounting.Usage{}
+	for _, u := range accountant.Usage() {
+		usage[u.Component] = u
+	}
+
+	assert.Equal(t, "input", usage["root.input"].Kind)
+	assert.Equal(t, uint64(3), usage["root.input"].Executions)
+	assert.Equal(t, uint64(15), usage["root.input"].Bytes)
+
+	assert.Greater(t, usage["root.input"].Samples, uint64(0))
+	assert.Greater(t, usage["root.input"].CPUNS, int64(0))
+
+	assert.Equal(t, "output", usage["bar"].Kind)
+	assert.Equal(t, uint64(3), usage["bar"].Executions)
+	assert.Equal(t, uint64(30), usage["bar"].Bytes)
+	assert.Equal(t, uint64(1), usage["bar"].Samples)
+	assert.Greater(t, usage["bar"].CPUNS, int64(0))
+}
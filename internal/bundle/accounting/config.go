@@ -0,0 +1,27 @@
+package accounting
+
+import (
+	"github.com/benthosdev/benthos/v4/internal/docs"
+)
+
+// Config contains configuration for resource usage accounting.
+type Config struct {
+	Enabled     bool `json:"enabled" yaml:"enabled"`
+	SampleEvery int  `json:"sample_every" yaml:"sample_every"`
+}
+
+// NewConfig returns a config struct with the default values for each field.
+func NewConfig() Config {
+	return Config{
+		Enabled:     false,
+		SampleEvery: 100,
+	}
+}
+
+// Spec returns a field spec for the accounting configuration fields.
+func Spec() docs.FieldSpecs {
+	return docs.FieldSpecs{
+		docs.FieldBool("enabled", "Whether the resource usage of components should be accounted for. Usage is exposed as metrics and can be obtained from the HTTP endpoint `/debug/accounting`.").HasDefault(false),
+		docs.FieldInt("sample_every", "Measure the CPU time and allocations of one in every N processor executions, input reads and output writes, the totals of all executions are estimated from the samples. Measuring an execution pins it to an OS thread and briefly stops the world, therefore sampling too frequently impacts performance. Allocations are read from process wide statistics and therefore include those of components running in parallel with a sample, which makes them approximate.").HasDefault(100),
+	}
+}
@@ -0,0 +1,24 @@
+//go:build linux
+// +build linux
+
+package accounting
+
+import (
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// clockThreadCPUTimeID is CLOCK_THREAD_CPUTIME_ID, which is not exported by
+// the syscall package.
+const clockThreadCPUTimeID = 3
+
+// threadCPUTime returns the CPU time consumed by the calling OS thread, the
+// caller must be locked to its thread.
+func threadCPUTime() (time.Duration, bool) {
+	var ts syscall.Timespec
+	if _, _, errno := syscall.Syscall(syscall.SYS_CLOCK_GETTIME, clockThreadCPUTimeID, uintptr(unsafe.Pointer(&ts)), 0); errno != 0 {
+		return 0, false
+	}
+	return time.Duration(ts.Nano()), true
+}
@@ -0,0 +1,14 @@
+//go:build !linux
+// +build !linux
+
+package accounting
+
+import (
+	"time"
+)
+
+// threadCPUTime is not supported on this platform, in which case the wall time
+// of executions is measured instead.
+func threadCPUTime() (time.Duration, bool) {
+	return 0, false
+}
@@ -0,0 +1,41 @@
+// Package bundletest provides helpers for testing the components constructed by
+// modified bundle environments.
+package bundletest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/component/input"
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+// ReadN reads and acknowledges transactions from an input until at least n
+// message parts have been consumed, and then closes the input.
+func ReadN(t testing.TB, in input.Streamed, n int) []*message.Part {
+	t.Helper()
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*5)
+	defer done()
+
+	var parts []*message.Part
+	for len(parts) < n {
+		select {
+		case tran := <-in.TransactionChan():
+			_ = tran.Payload.Iter(func(i int, p *message.Part) error {
+				parts = append(parts, p)
+				return nil
+			})
+			require.NoError(t, tran.Ack(ctx, nil))
+		case <-ctx.Done():
+			t.Fatal("timed out")
+		}
+	}
+
+	in.CloseAsync()
+	require.NoError(t, in.WaitForClose(time.Second))
+	return parts
+}
@@ -0,0 +1,83 @@
+package flightrecorder
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
+	"github.com/benthosdev/benthos/v4/internal/bundle"
+	"github.com/benthosdev/benthos/v4/internal/component/processor"
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+// RecordedBundle modifies a provided bundle environment so that all processors
+// are wrapped by components that add a record of each execution, containing
+// snapshots of messages before and after and the time taken, to a recorder.
+func RecordedBundle(b *bundle.Environment, r *Recorder) *bundle.Environment {
+	recordedEnv := b.Clone()
+
+	for _, spec := range b.ProcessorDocs() {
+		_ = recordedEnv.ProcessorAdd(func(conf processor.Config, nm bundle.NewManagement) (processor.V1, error) {
+			p, err := b.ProcessorInit(conf, nm)
+			if err != nil {
+				return nil, err
+			}
+			key := nm.Label()
+			if key == "" {
+				key = "root." + query.SliceToDotPath(nm.Path()...)
+			}
+			return &recordedProcessor{
+				key:      key,
+				recorder: r,
+				wrapped:  p,
+			}, nil
+		}, spec)
+	}
+
+	return recordedEnv
+}
+
+//------------------------------------------------------------------------------
+
+type recordedProcessor struct {
+	executions uint64
+
+	key      string
+	recorder *Recorder
+	wrapped  processor.V1
+}
+
+func (r *recordedProcessor) ProcessMessage(m *message.Batch) ([]*message.Batch, error) {
+	n := atomic.AddUint64(&r.executions, 1)
+	if every := r.recorder.sampleEvery; every > 1 && n%uint64(every) != 1 {
+		return r.wrapped.ProcessMessage(m)
+	}
+
+	rec := Record{
+		Processor: r.key,
+		Timestamp: time.Now(),
+		Before:    r.recorder.snapshot(m),
+	}
+
+	outMsgs, res := r.wrapped.ProcessMessage(m)
+
+	rec.DurationNS = time.Since(rec.Timestamp).Nanoseconds()
+	rec.After = make([][]Snapshot, 0, len(outMsgs))
+	for _, outMsg := range outMsgs {
+		rec.After = append(rec.After, r.recorder.snapshot(outMsg))
+	}
+	if res != nil {
+		rec.Error = res.Error()
+	}
+	r.recorder.add(rec)
+
+	return outMsgs, res
+}
+
+func (r *recordedProcessor) CloseAsync() {
+	r.wrapped.CloseAsync()
+}
+
+func (r *recordedProcessor) WaitForClose(timeout time.Duration) error {
+	return r.wrapped.WaitForClose(timeout)
+}
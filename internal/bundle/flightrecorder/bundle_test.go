@@ -0,0 +1,138 @@
+package flightrecorder_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/bundle"
+	"github.com/benthosdev/benthos/v4/internal/bundle/flightrecorder"
+	"github.com/benthosdev/benthos/v4/internal/component/processor"
+	"github.com/benthosdev/benthos/v4/internal/manager"
+	"github.com/benthosdev/benthos/v4/internal/message"
+
+	_ "github.com/benthosdev/benthos/v4/public/components/all"
+)
+
+func TestBundleProcessorRecording(t *testing.T) {
+	conf := flightrecorder.NewConfig()
+	conf.Enabled = true
+	conf.Capacity = 2
+	conf.MaxContentBytes = 5
+
+	recorder := flightrecorder.NewRecorder(conf)
+	renv := flightrecorder.RecordedBundle(bundle.GlobalEnvironment, recorder)
+
+	mgr, err := manager.New(
+		manager.NewResourceConfig(),
+		manager.OptSetEnvironment(renv),
+	)
+	require.NoError(t, err)
+
+	procConf := processor.NewConfig()
+	procConf.Label = "foo"
+	procConf.Type = "bloblang"
+	procConf.Bloblang = `
+meta bar = "baz"
+root = content().uppercase()
+`
+
+	proc, err := mgr.NewProcessor(procConf)
+	require.NoError(t, err)
+
+	for _, input := range []string{"first", "second message", "third"} {
+		inMsg := message.QuickBatch([][]byte{[]byte(input)})
+		msgs, res := proc.ProcessMessage(inMsg)
+		require.NoError(t, res)
+		require.Len(t, msgs, 1)
+	}
+
+	records := recorder.Records()
+	require.Len(t, records, 2)
+
+	assert.Equal(t, "foo", records[0].Processor)
+	assert.Equal(t, []flightrecorder.Snapshot{
+		{Size: 14, Content: "secon", Truncated: true},
+	}, records[0].Before)
+	assert.Equal(t, [][]flightrecorder.Snapshot{
+		{{Size: 14, Content: "SECON", Truncated: true, Metadata: map[string]string{"bar": "baz"}}},
+	}, records[0].After)
+
+	assert.Equal(t, "foo", records[1].Processor)
+	assert.Equal(t, []flightrecorder.Snapshot{
+		{Size: 5, Content: "third"},
+	}, records[1].Before)
+	assert.Equal(t, [][]flightrecorder.Snapshot{
+		{{Size: 5, Content: "THIRD", Metadata: map[string]string{"bar": "baz"}}},
+	}, records[1].After)
+
+	handler := recorder.Handler()
+
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest(http.MethodGet, "/debug/flight_recorder", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var served []flightrecorder.Record
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &served))
+	require.Len(t, served, 2)
+	assert.Equal(t, "THIRD", served[1].After[0][0].Content)
+
+	w = httptest.NewRecorder()
+	handler(w, httptest.NewRequest(http.MethodDelete, "/debug/flight_recorder", nil))
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Empty(t, recorder.Records())
+}
+
+func TestBundleProcessorRecordingSampled(t *testing.T) {
+	conf := flightrecorder.NewConfig()
+	conf.Enabled = true
+	conf.SampleEvery = 2
+
+	recorder := flightrecorder.NewRecorder(conf)
+	renv := flightrecorder.RecordedBundle(bundle.GlobalEnvironment, recorder)
+
+	mgr, err := manager.New(
+		manager.NewResourceConfig(),
+		manager.OptSetEnvironment(renv),
+	)
+	require.NoError(t, err)
+
+	procConf := processor.NewConfig()
+	procConf.Type = "noop"
+
+	proc, err := mgr.NewProcessor(procConf)
+	require.NoError(t, err)
+
+	spool, err := message.NewSpoolFromReader(t.TempDir(), strings.NewReader("spooled"))
+	require.NoError(t, err)
+	defer func() {
+		_ = spool.Remove()
+	}()
+
+	for _, p := range []*message.Part{
+		message.NewSpooledPart(spool),
+		message.NewPart([]byte("second")),
+		message.NewPart([]byte("third")),
+	} {
+		inMsg := message.QuickBatch(nil)
+		inMsg.Append(p)
+		msgs, res := proc.ProcessMessage(inMsg)
+		require.NoError(t, res)
+		require.Len(t, msgs, 1)
+	}
+
+	records := recorder.Records()
+	require.Len(t, records, 2)
+
+	assert.Equal(t, []flightrecorder.Snapshot{
+		{Size: 7, SpoolPath: spool.Path()},
+	}, records[0].Before)
+	assert.Equal(t, []flightrecorder.Snapshot{
+		{Size: 5, Content: "third"},
+	}, records[1].Before)
+}
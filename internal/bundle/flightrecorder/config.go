@@ -0,0 +1,33 @@
+package flightrecorder
+
+import (
+	"github.com/benthosdev/benthos/v4/internal/docs"
+)
+
+// Config contains configuration for the flight recorder.
+type Config struct {
+	Enabled         bool `json:"enabled" yaml:"enabled"`
+	Capacity        int  `json:"capacity" yaml:"capacity"`
+	SampleEvery     int  `json:"sample_every" yaml:"sample_every"`
+	MaxContentBytes int  `json:"max_content_bytes" yaml:"max_content_bytes"`
+}
+
+// NewConfig returns a config struct with the default values for each field.
+func NewConfig() Config {
+	return Config{
+		Enabled:         false,
+		Capacity:        100,
+		SampleEvery:     1,
+		MaxContentBytes: 4096,
+	}
+}
+
+// Spec returns a field spec for the flight recorder configuration fields.
+func Spec() docs.FieldSpecs {
+	return docs.FieldSpecs{
+		docs.FieldBool("enabled", "Whether the flight recorder should record processor executions. Recordings can be obtained from the HTTP endpoint `/debug/flight_recorder`.").HasDefault(false),
+		docs.FieldInt("capacity", "The maximum number of processor executions to keep in memory, once exceeded the oldest recordings are discarded.").HasDefault(100),
+		docs.FieldInt("sample_every", "Record one in every N executions of each processor, which reduces the overhead of recording for pipelines with a high throughput.").HasDefault(1),
+		docs.FieldInt("max_content_bytes", "The maximum number of bytes of each message body to record, larger bodies are truncated. Set to zero in order to record entire message bodies. The size of each message is always recorded, and the bodies of messages that have been spooled to disk are never loaded, instead the path of the spool file is recorded.").HasDefault(4096),
+	}
+}
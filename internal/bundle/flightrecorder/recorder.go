@@ -0,0 +1,150 @@
+package flightrecorder
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+// Snapshot is a copy of a message taken before or after a processor was
+// executed.
+type Snapshot struct {
+	Size      int64             `json:"size"`
+	Content   string            `json:"content,omitempty"`
+	Truncated bool              `json:"truncated,omitempty"`
+	SpoolPath string            `json:"spool_path,omitempty"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+	Error     string            `json:"error,omitempty"`
+}
+
+// Record describes a single execution of a processor against a batch of
+// messages.
+type Record struct {
+	Processor  string       `json:"processor"`
+	Timestamp  time.Time    `json:"timestamp"`
+	DurationNS int64        `json:"duration_ns"`
+	Before     []Snapshot   `json:"before"`
+	After      [][]Snapshot `json:"after"`
+	Error      string       `json:"error,omitempty"`
+}
+
+// Recorder keeps the most recent processor execution records within a fixed
+// size ring buffer.
+type Recorder struct {
+	maxContent  int
+	sampleEvery int
+
+	mut     sync.Mutex
+	records []Record
+	next    int
+	full    bool
+}
+
+// NewRecorder creates a recorder that keeps up to a capacity of records.
+func NewRecorder(conf Config) *Recorder {
+	capacity := conf.Capacity
+	if capacity <= 0 {
+		capacity = 1
+	}
+	return &Recorder{
+		maxContent:  conf.MaxContentBytes,
+		sampleEvery: conf.SampleEvery,
+		records:     make([]Record, capacity),
+	}
+}
+
+func (r *Recorder) add(rec Record) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	r.records[r.next] = rec
+	r.next++
+	if r.next >= len(r.records) {
+		r.next = 0
+		r.full = true
+	}
+}
+
+// Records returns a copy of the records currently held, ordered from oldest to
+// newest.
+func (r *Recorder) Records() []Record {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	if !r.full {
+		recs := make([]Record, r.next)
+		copy(recs, r.records[:r.next])
+		return recs
+	}
+
+	recs := make([]Record, 0, len(r.records))
+	recs = append(recs, r.records[r.next:]...)
+	recs = append(recs, r.records[:r.next]...)
+	return recs
+}
+
+// Reset discards all records.
+func (r *Recorder) Reset() {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	for i := range r.records {
+		r.records[i] = Record{}
+	}
+	r.next = 0
+	r.full = false
+}
+
+// Handler returns an HTTP handler that responds with the records currently
+// held as a JSON array. A DELETE request discards all records.
+func (r *Recorder) Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodDelete {
+			r.Reset()
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		resBytes, err := json.Marshal(r.Records())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(resBytes)
+	}
+}
+
+func (r *Recorder) snapshot(msg *message.Batch) []Snapshot {
+	snaps := make([]Snapshot, msg.Len())
+	_ = msg.Iter(func(i int, p *message.Part) error {
+		snaps[i].Size = p.BodySize()
+
+		// Spooled payloads are referenced rather than loaded into memory.
+		if spool := p.Spool(); spool != nil {
+			snaps[i].SpoolPath = spool.Path()
+		} else {
+			content := p.Get()
+			if r.maxContent > 0 && len(content) > r.maxContent {
+				content = content[:r.maxContent]
+				snaps[i].Truncated = true
+			}
+			snaps[i].Content = string(content)
+		}
+		_ = p.MetaIter(func(k, v string) error {
+			if snaps[i].Metadata == nil {
+				snaps[i].Metadata = map[string]string{}
+			}
+			snaps[i].Metadata[k] = v
+			return nil
+		})
+		if err := p.ErrorGet(); err != nil {
+			snaps[i].Error = err.Error()
+		}
+		return nil
+	})
+	return snaps
+}
@@ -0,0 +1,116 @@
+package latency
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/internal/bundle"
+	"github.com/benthosdev/benthos/v4/internal/bundle/wrap"
+	"github.com/benthosdev/benthos/v4/internal/component/input"
+	"github.com/benthosdev/benthos/v4/internal/component/output"
+	"github.com/benthosdev/benthos/v4/internal/component/processor"
+	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/pipeline"
+)
+
+type objective struct {
+	name      string
+	threshold time.Duration
+	budget    float64
+	window    time.Duration
+}
+
+func objectivesFromConfig(confs []ObjectiveConfig) ([]objective, error) {
+	objectives := make([]objective, 0, len(confs))
+	for i, c := range confs {
+		if c.Name == "" {
+			return nil, fmt.Errorf("objective %v: a name must be specified", i)
+		}
+		o := objective{name: c.Name}
+
+		var err error
+		if o.threshold, err = time.ParseDuration(c.Threshold); err != nil {
+			return nil, fmt.Errorf("objective %v: failed to parse threshold: %w", c.Name, err)
+		}
+		if o.window, err = time.ParseDuration(c.Window); err != nil {
+			return nil, fmt.Errorf("objective %v: failed to parse window: %w", c.Name, err)
+		}
+		if o.window <= 0 {
+			return nil, fmt.Errorf("objective %v: window must be greater than zero", c.Name)
+		}
+		if c.Target <= 0 || c.Target >= 1 {
+			return nil, fmt.Errorf("objective %v: target must be greater than 0 and less than 1, got %v", c.Name, c.Target)
+		}
+		o.budget = 1 - c.Target
+		objectives = append(objectives, o)
+	}
+	return objectives, nil
+}
+
+// TrackedBundle modifies a provided bundle environment so that all inputs stamp
+// consumed messages with their ingest time, and all outputs emit the
+// end-to-end latency of messages once they have been delivered, along with the
+// burn rates of any latency objectives. Messages are stamped before any
+// processors of the input are executed, and the ingest time is read before
+// any processors of the output are executed.
+func TrackedBundle(b *bundle.Environment, conf Config) (*bundle.Environment, error) {
+	if conf.MetadataKey == "" {
+		return nil, errors.New("a metadata key must be specified")
+	}
+	objectives, err := objectivesFromConfig(conf.Objectives)
+	if err != nil {
+		return nil, err
+	}
+
+	stamper := &stampingProcessor{key: conf.MetadataKey}
+	stampPipe := func() (processor.Pipeline, error) {
+		return pipeline.NewProcessor(stamper), nil
+	}
+
+	trackedEnv := wrap.Inputs(b, func(i input.Streamed, iConf input.Config, nm bundle.NewManagement) (input.Streamed, error) {
+		return input.WrapWithPipeline(i, stampPipe)
+	})
+
+	for _, spec := range b.OutputDocs() {
+		_ = trackedEnv.OutputAdd(func(oConf output.Config, nm bundle.NewManagement, pcf ...processor.PipelineConstructorFunc) (output.Streamed, error) {
+			o, err := b.OutputInit(oConf, nm, pcf...)
+			if err != nil {
+				return nil, err
+			}
+			return trackOutput(conf.MetadataKey, objectives, nm.Metrics(), o), nil
+		}, spec)
+	}
+
+	return trackedEnv, nil
+}
+
+//------------------------------------------------------------------------------
+
+type stampingProcessor struct {
+	key string
+}
+
+func (s *stampingProcessor) ProcessMessage(msg *message.Batch) ([]*message.Batch, error) {
+	ingested := strconv.FormatInt(time.Now().UnixNano(), 10)
+
+	newMsg := msg.Copy()
+	_ = newMsg.Iter(func(i int, part *message.Part) error {
+		// When inputs are nested, such as the children of a broker, or when
+		// messages were stamped by an upstream instance, the earliest ingest
+		// time is retained.
+		if part.MetaGet(s.key) == "" {
+			part.MetaSet(s.key, ingested)
+		}
+		return nil
+	})
+	return []*message.Batch{newMsg}, nil
+}
+
+func (s *stampingProcessor) CloseAsync() {
+}
+
+func (s *stampingProcessor) WaitForClose(timeout time.Duration) error {
+	return nil
+}
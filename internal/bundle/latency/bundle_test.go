@@ -0,0 +1,163 @@
+package latency_test
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/bundle"
+	"github.com/benthosdev/benthos/v4/internal/bundle/latency"
+	"github.com/benthosdev/benthos/v4/internal/component/input"
+	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+	"github.com/benthosdev/benthos/v4/internal/component/output"
+	"github.com/benthosdev/benthos/v4/internal/component/processor"
+	"github.com/benthosdev/benthos/v4/internal/manager"
+	"github.com/benthosdev/benthos/v4/internal/message"
+
+	_ "github.com/benthosdev/benthos/v4/public/components/all"
+)
+
+func metricValues(stats *metrics.Local, name string) map[string]int64 {
+	values := map[string]int64{}
+	for k, v := range stats.GetCounters() {
+		n, tagNames, tagValues := metrics.ReverseLabelledPath(k)
+		if n != name {
+			continue
+		}
+		for i, tn := range tagNames {
+			if tn == "objective" {
+				values[tagValues[i]] = v
+			}
+		}
+	}
+	return values
+}
+
+func TestBundleLatencyStamp(t *testing.T) {
+	conf := latency.NewConfig()
+	conf.Enabled = true
+
+	lenv, err := latency.TrackedBundle(bundle.GlobalEnvironment, conf)
+	require.NoError(t, err)
+
+	procConf := processor.NewConfig()
+	procConf.Type = "bloblang"
+	procConf.Bloblang = `meta seen = meta("ingest_timestamp_unix_nano")`
+
+	inConfig := input.NewConfig()
+	inConfig.Type = "generate"
+	inConfig.Generate.Count = 2
+	inConfig.Generate.Interval = "1us"
+	inConfig.Generate.Mapping = `root = "hello world"`
+	inConfig.Processors = append(inConfig.Processors, procConf)
+
+	mgr, err := manager.New(manager.NewResourceConfig(), manager.OptSetEnvironment(lenv))
+	require.NoError(t, err)
+
+	in, err := mgr.NewInput(inConfig)
+	require.NoError(t, err)
+
+	before := time.Now()
+	for i := 0; i < 2; i++ {
+		select {
+		case tran := <-in.TransactionChan():
+			p := tran.Payload.Get(0)
+			nanos, err := strconv.ParseInt(p.MetaGet("ingest_timestamp_unix_nano"), 10, 64)
+			require.NoError(t, err)
+			assert.WithinDuration(t, before, time.Unix(0, nanos), time.Second)
+			assert.Equal(t, p.MetaGet("ingest_timestamp_unix_nano"), p.MetaGet("seen"))
+			require.NoError(t, tran.Ack(context.Background(), nil))
+		case <-time.After(time.Second * 5):
+			t.Fatal("timed out")
+		}
+	}
+
+	in.CloseAsync()
+	require.NoError(t, in.WaitForClose(time.Second))
+}
+
+func TestBundleLatencyOutput(t *testing.T) {
+	conf := latency.NewConfig()
+	conf.Enabled = true
+	conf.Objectives = []latency.ObjectiveConfig{
+		{Name: "fast", Threshold: "1s", Target: 0.5, Window: "1h"},
+		{Name: "slow", Threshold: "1m", Target: 0.9, Window: "1h"},
+	}
+
+	lenv, err := latency.TrackedBundle(bundle.GlobalEnvironment, conf)
+	require.NoError(t, err)
+
+	stats := metrics.NewLocal()
+	mgr, err := manager.New(
+		manager.NewResourceConfig(),
+		manager.OptSetEnvironment(lenv),
+		manager.OptSetMetrics(metrics.NewNamespaced(stats)),
+	)
+	require.NoError(t, err)
+
+	outConf := output.NewConfig()
+	outConf.Type = "drop"
+
+	out, err := mgr.NewOutput(outConf)
+	require.NoError(t, err)
+
+	tChan := make(chan message.Transaction)
+	require.NoError(t, out.Consume(tChan))
+
+	msg := message.QuickBatch([][]byte{[]byte("a"), []byte("b"), []byte("c"), []byte("d")})
+	for i, age := range []time.Duration{time.Second * 5, time.Millisecond, time.Millisecond, 0} {
+		if age > 0 {
+			msg.Get(i).MetaSet("ingest_timestamp_unix_nano", strconv.FormatInt(time.Now().Add(-age).UnixNano(), 10))
+		}
+	}
+
+	resChan := make(chan error)
+	select {
+	case tChan <- message.NewTransaction(msg, resChan):
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out")
+	}
+	select {
+	case err := <-resChan:
+		require.NoError(t, err)
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out")
+	}
+
+	assert.Equal(t, map[string]int64{"fast": 3, "slow": 3}, metricValues(stats, "output_slo_messages"))
+	assert.Equal(t, map[string]int64{"fast": 1, "slow": 0}, metricValues(stats, "output_slo_breaches"))
+
+	// One in three messages breached the fast objective with an error budget
+	// of half of all messages.
+	assert.Equal(t, map[string]int64{"fast": 67, "slow": 0}, metricValues(stats, "output_slo_burn_rate_percent"))
+
+	var latencyTimings int
+	for k, v := range stats.GetTimings() {
+		if name, _, _ := metrics.ReverseLabelledPath(k); name == "output_e2e_latency_ns" {
+			latencyTimings += int(v.Count())
+		}
+	}
+	assert.Equal(t, 3, latencyTimings)
+
+	close(tChan)
+	out.CloseAsync()
+	require.NoError(t, out.WaitForClose(time.Second*5))
+}
+
+func TestBundleLatencyConfigErrors(t *testing.T) {
+	for _, obj := range []latency.ObjectiveConfig{
+		{Name: "", Threshold: "1s", Target: 0.9, Window: "1h"},
+		{Name: "foo", Threshold: "nope", Target: 0.9, Window: "1h"},
+		{Name: "foo", Threshold: "1s", Target: 1, Window: "1h"},
+		{Name: "foo", Threshold: "1s", Target: 0.9, Window: "0s"},
+	} {
+		conf := latency.NewConfig()
+		conf.Objectives = []latency.ObjectiveConfig{obj}
+		_, err := latency.TrackedBundle(bundle.GlobalEnvironment, conf)
+		assert.Error(t, err, obj)
+	}
+}
@@ -0,0 +1,56 @@
+package latency
+
+import (
+	"github.com/benthosdev/benthos/v4/internal/docs"
+)
+
+// ObjectiveConfig describes a service level objective for the end-to-end
+// latency of messages.
+type ObjectiveConfig struct {
+	Name      string  `json:"name" yaml:"name"`
+	Threshold string  `json:"threshold" yaml:"threshold"`
+	Target    float64 `json:"target" yaml:"target"`
+	Window    string  `json:"window" yaml:"window"`
+}
+
+// NewObjectiveConfig returns an objective config struct with the default
+// values for each field.
+func NewObjectiveConfig() ObjectiveConfig {
+	return ObjectiveConfig{
+		Name:      "",
+		Threshold: "",
+		Target:    0.99,
+		Window:    "1h",
+	}
+}
+
+// Config contains configuration for tracking the end-to-end latency of
+// messages.
+type Config struct {
+	Enabled     bool              `json:"enabled" yaml:"enabled"`
+	MetadataKey string            `json:"metadata_key" yaml:"metadata_key"`
+	Objectives  []ObjectiveConfig `json:"objectives" yaml:"objectives"`
+}
+
+// NewConfig returns a config struct with the default values for each field.
+func NewConfig() Config {
+	return Config{
+		Enabled:     false,
+		MetadataKey: "ingest_timestamp_unix_nano",
+		Objectives:  []ObjectiveConfig{},
+	}
+}
+
+// Spec returns a field spec for the latency configuration fields.
+func Spec() docs.FieldSpecs {
+	return docs.FieldSpecs{
+		docs.FieldBool("enabled", "Whether messages should be stamped with the time they were consumed by inputs, and the end-to-end latency of messages emitted as the metric `output_e2e_latency_ns` once they are delivered by outputs.").HasDefault(false),
+		docs.FieldString("metadata_key", "The metadata key to stamp the ingest time of messages within, as a unix timestamp in nanoseconds. Messages that already have this key, such as those consumed from an upstream Benthos instance, retain their original ingest time.").HasDefault("ingest_timestamp_unix_nano"),
+		docs.FieldObject("objectives", "A list of latency objectives, for each of which the metrics `output_slo_messages` and `output_slo_breaches` count the delivered messages and those that exceeded the threshold, and `output_slo_burn_rate_percent` is the rate at which the error budget is consumed over the window, where 100 means it is consumed exactly by the end of the window.").Array().WithChildren(
+			docs.FieldString("name", "A name to identify the objective by, added as the label `objective` to its metrics.").HasDefault(""),
+			docs.FieldString("threshold", "The latency above which a message breaches the objective.", "500ms", "30s").HasDefault(""),
+			docs.FieldFloat("target", "The proportion of messages that should be delivered within the threshold.", 0.99, 0.999).HasDefault(0.99),
+			docs.FieldString("window", "The rolling window over which the burn rate is calculated.").HasDefault("1h"),
+		).HasDefault([]interface{}{}),
+	}
+}
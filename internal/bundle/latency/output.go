@@ -0,0 +1,245 @@
+package latency
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+	"github.com/benthosdev/benthos/v4/internal/component/output"
+	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/shutdown"
+)
+
+const burnBuckets = 60
+
+type burnBucket struct {
+	messages, breaches int64
+}
+
+// burnTracker calculates the rate at which the error budget of an objective is
+// consumed over a rolling window, which is divided into a fixed number of
+// buckets.
+type burnTracker struct {
+	obj objective
+
+	mut       sync.Mutex
+	buckets   [burnBuckets]burnBucket
+	bucketDur time.Duration
+	head      int
+	headStart time.Time
+
+	mMessages metrics.StatCounter
+	mBreaches metrics.StatCounter
+	mBurnRate metrics.StatGauge
+}
+
+func newBurnTracker(obj objective, stats metrics.Type) *burnTracker {
+	return &burnTracker{
+		obj:       obj,
+		bucketDur: obj.window / burnBuckets,
+		headStart: time.Now(),
+		mMessages: stats.GetCounterVec("output_slo_messages", "objective").With(obj.name),
+		mBreaches: stats.GetCounterVec("output_slo_breaches", "objective").With(obj.name),
+		mBurnRate: stats.GetGaugeVec("output_slo_burn_rate_percent", "objective").With(obj.name),
+	}
+}
+
+// advance must be called with the mutex held.
+func (b *burnTracker) advance(now time.Time) {
+	if b.bucketDur <= 0 {
+		return
+	}
+	steps := int(now.Sub(b.headStart) / b.bucketDur)
+	if steps <= 0 {
+		return
+	}
+	if steps >= burnBuckets {
+		b.buckets = [burnBuckets]burnBucket{}
+	} else {
+		for i := 1; i <= steps; i++ {
+			b.buckets[(b.head+i)%burnBuckets] = burnBucket{}
+		}
+	}
+	b.head = (b.head + steps) % burnBuckets
+	b.headStart = b.headStart.Add(time.Duration(steps) * b.bucketDur)
+}
+
+func (b *burnTracker) record(now time.Time, latencies []time.Duration) float64 {
+	var breaches int64
+	for _, l := range latencies {
+		if l > b.obj.threshold {
+			breaches++
+		}
+	}
+	b.mMessages.Incr(int64(len(latencies)))
+	b.mBreaches.Incr(breaches)
+
+	b.mut.Lock()
+	defer b.mut.Unlock()
+
+	b.advance(now)
+	b.buckets[b.head].messages += int64(len(latencies))
+	b.buckets[b.head].breaches += breaches
+	return b.burnRate()
+}
+
+// refresh updates the burn rate gauge without recording messages, which
+// allows it to decay as buckets leave the window while no messages are being
+// delivered.
+func (b *burnTracker) refresh(now time.Time) float64 {
+	b.mut.Lock()
+	defer b.mut.Unlock()
+
+	b.advance(now)
+	return b.burnRate()
+}
+
+// burnRate must be called with the mutex held.
+func (b *burnTracker) burnRate() float64 {
+	var total burnBucket
+	for _, bucket := range b.buckets {
+		total.messages += bucket.messages
+		total.breaches += bucket.breaches
+	}
+
+	var burnRate float64
+	if total.messages > 0 {
+		burnRate = (float64(total.breaches) / float64(total.messages)) / b.obj.budget * 100
+	}
+	b.mBurnRate.Set(int64(math.Round(burnRate)))
+	return burnRate
+}
+
+//------------------------------------------------------------------------------
+
+type trackedOutput struct {
+	key      string
+	mLatency metrics.StatTimer
+	trackers []*burnTracker
+
+	wrapped output.Streamed
+	tChan   chan message.Transaction
+	shutSig *shutdown.Signaller
+}
+
+func trackOutput(key string, objectives []objective, stats metrics.Type, o output.Streamed) *trackedOutput {
+	t := &trackedOutput{
+		key:      key,
+		mLatency: stats.GetTimer("output_e2e_latency_ns"),
+		wrapped:  o,
+		tChan:    make(chan message.Transaction),
+		shutSig:  shutdown.NewSignaller(),
+	}
+	for _, obj := range objectives {
+		t.trackers = append(t.trackers, newBurnTracker(obj, stats))
+	}
+	return t
+}
+
+func (t *trackedOutput) ingestTimes(msg *message.Batch) []time.Time {
+	var times []time.Time
+	_ = msg.Iter(func(i int, p *message.Part) error {
+		if v := p.MetaGet(t.key); v != "" {
+			if nanos, err := strconv.ParseInt(v, 10, 64); err == nil {
+				times = append(times, time.Unix(0, nanos))
+			}
+		}
+		return nil
+	})
+	return times
+}
+
+func (t *trackedOutput) record(ingested []time.Time) {
+	if len(ingested) == 0 {
+		return
+	}
+	now := time.Now()
+	latencies := make([]time.Duration, len(ingested))
+	for i, ts := range ingested {
+		latencies[i] = now.Sub(ts)
+		t.mLatency.Timing(latencies[i].Nanoseconds())
+	}
+	for _, tracker := range t.trackers {
+		tracker.record(now, latencies)
+	}
+}
+
+// refreshInterval returns the shortest bucket duration of the trackers of the
+// output, or zero when it has none.
+func (t *trackedOutput) refreshInterval() time.Duration {
+	var interval time.Duration
+	for _, tracker := range t.trackers {
+		if tracker.bucketDur > 0 && (interval == 0 || tracker.bucketDur < interval) {
+			interval = tracker.bucketDur
+		}
+	}
+	return interval
+}
+
+func (t *trackedOutput) loop(transactions <-chan message.Transaction) {
+	defer close(t.tChan)
+
+	var refreshChan <-chan time.Time
+	if interval := t.refreshInterval(); interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		refreshChan = ticker.C
+	}
+
+	for {
+		var tran message.Transaction
+		var open bool
+		select {
+		case tran, open = <-transactions:
+			if !open {
+				return
+			}
+		case now := <-refreshChan:
+			for _, tracker := range t.trackers {
+				tracker.refresh(now)
+			}
+			continue
+		case <-t.shutSig.CloseNowChan():
+			return
+		}
+
+		// The ingest time is read before the message reaches any processors
+		// of the output, as they might remove metadata.
+		ingested := t.ingestTimes(tran.Payload)
+		select {
+		case t.tChan <- message.NewTransactionFunc(tran.Payload, func(ctx context.Context, err error) error {
+			if err == nil {
+				t.record(ingested)
+			}
+			return tran.Ack(ctx, err)
+		}):
+		case <-t.shutSig.CloseNowChan():
+			return
+		}
+	}
+}
+
+func (t *trackedOutput) Consume(transactions <-chan message.Transaction) error {
+	if err := t.wrapped.Consume(t.tChan); err != nil {
+		return err
+	}
+	go t.loop(transactions)
+	return nil
+}
+
+func (t *trackedOutput) Connected() bool {
+	return t.wrapped.Connected()
+}
+
+func (t *trackedOutput) CloseAsync() {
+	t.wrapped.CloseAsync()
+}
+
+func (t *trackedOutput) WaitForClose(timeout time.Duration) error {
+	err := t.wrapped.WaitForClose(timeout)
+	t.shutSig.CloseNow()
+	return err
+}
@@ -0,0 +1,39 @@
+package latency
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+)
+
+func TestBurnTrackerRefreshDecays(t *testing.T) {
+	stats := metrics.NewLocal()
+	b := newBurnTracker(objective{
+		name:      "foo",
+		threshold: time.Second,
+		budget:    0.5,
+		window:    time.Minute,
+	}, stats)
+
+	start := b.headStart
+	assert.Equal(t, 100.0, b.record(start, []time.Duration{time.Millisecond, time.Minute}))
+
+	gauge := func() int64 {
+		for k, v := range stats.GetCounters() {
+			if name, _, _ := metrics.ReverseLabelledPath(k); name == "output_slo_burn_rate_percent" {
+				return v
+			}
+		}
+		return -1
+	}
+	assert.Equal(t, int64(100), gauge())
+
+	assert.Equal(t, 100.0, b.refresh(start.Add(time.Second*30)))
+	assert.Equal(t, int64(100), gauge())
+
+	assert.Equal(t, 0.0, b.refresh(start.Add(time.Minute)))
+	assert.Equal(t, int64(0), gauge())
+}
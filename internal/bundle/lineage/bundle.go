@@ -0,0 +1,57 @@
+package lineage
+
+import (
+	"github.com/gofrs/uuid"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
+	"github.com/benthosdev/benthos/v4/internal/bundle"
+	"github.com/benthosdev/benthos/v4/internal/bundle/wrap"
+	"github.com/benthosdev/benthos/v4/internal/component/input"
+)
+
+type metaKeys struct {
+	source          string
+	sourceType      string
+	sequence        string
+	offset          string
+	ingestTimestamp string
+	instanceID      string
+	configHash      string
+}
+
+func newMetaKeys(prefix string) metaKeys {
+	return metaKeys{
+		source:          prefix + "source",
+		sourceType:      prefix + "source_type",
+		sequence:        prefix + "sequence",
+		offset:          prefix + "offset",
+		ingestTimestamp: prefix + "ingest_timestamp",
+		instanceID:      prefix + "instance_id",
+		configHash:      prefix + "config_hash",
+	}
+}
+
+// StampedBundle modifies a provided bundle environment so that all inputs are
+// wrapped by components that stamp each consumed message with lineage
+// metadata. The lineage is added before any processors of the input are
+// executed. An optional hash of the running config can be provided, in which
+// case it is also added to the metadata of each message.
+func StampedBundle(b *bundle.Environment, conf Config, configHash string) (*bundle.Environment, error) {
+	instanceID := conf.InstanceID
+	if instanceID == "" {
+		u4, err := uuid.NewV4()
+		if err != nil {
+			return nil, err
+		}
+		instanceID = u4.String()
+	}
+
+	keys := newMetaKeys(conf.MetadataPrefix)
+	return wrap.Inputs(b, func(i input.Streamed, iConf input.Config, nm bundle.NewManagement) (input.Streamed, error) {
+		source := nm.Label()
+		if source == "" {
+			source = "root." + query.SliceToDotPath(nm.Path()...)
+		}
+		return stampInput(keys, source, iConf.Type, instanceID, configHash, i), nil
+	}), nil
+}
@@ -0,0 +1,169 @@
+package lineage_test
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/bundle"
+	"github.com/benthosdev/benthos/v4/internal/bundle/bundletest"
+	"github.com/benthosdev/benthos/v4/internal/bundle/lineage"
+	"github.com/benthosdev/benthos/v4/internal/component/input"
+	"github.com/benthosdev/benthos/v4/internal/component/processor"
+	"github.com/benthosdev/benthos/v4/internal/manager"
+
+	_ "github.com/benthosdev/benthos/v4/public/components/all"
+)
+
+func TestBundleInputLineage(t *testing.T) {
+	conf := lineage.NewConfig()
+	conf.Enabled = true
+	conf.InstanceID = "foo-instance"
+
+	lenv, err := lineage.StampedBundle(bundle.GlobalEnvironment, conf, "abc123")
+	require.NoError(t, err)
+
+	procConf := processor.NewConfig()
+	procConf.Type = "bloblang"
+	procConf.Bloblang = `meta seen_source = meta("lineage_source")`
+
+	inConfig := input.NewConfig()
+	inConfig.Label = "foo"
+	inConfig.Type = "generate"
+	inConfig.Generate.Count = 3
+	inConfig.Generate.Interval = "1us"
+	inConfig.Generate.Mapping = `root = "hello world"`
+	inConfig.Processors = append(inConfig.Processors, procConf)
+
+	mgr, err := manager.New(
+		manager.NewResourceConfig(),
+		manager.OptSetEnvironment(lenv),
+	)
+	require.NoError(t, err)
+
+	in, err := mgr.NewInput(inConfig)
+	require.NoError(t, err)
+
+	parts := bundletest.ReadN(t, in, 3)
+	for i, p := range parts {
+		assert.Equal(t, "foo", p.MetaGet("lineage_source"))
+		assert.Equal(t, "foo", p.MetaGet("seen_source"))
+		assert.Equal(t, "generate", p.MetaGet("lineage_source_type"))
+		assert.Equal(t, strconv.Itoa(i+1), p.MetaGet("lineage_sequence"))
+		assert.Equal(t, "foo-instance", p.MetaGet("lineage_instance_id"))
+		assert.Equal(t, "abc123", p.MetaGet("lineage_config_hash"))
+
+		_, err := time.Parse(time.RFC3339Nano, p.MetaGet("lineage_ingest_timestamp"))
+		assert.NoError(t, err)
+	}
+}
+
+func TestBundleInputLineageNested(t *testing.T) {
+	conf := lineage.NewConfig()
+	conf.Enabled = true
+	conf.MetadataPrefix = "origin_"
+
+	lenv, err := lineage.StampedBundle(bundle.GlobalEnvironment, conf, "")
+	require.NoError(t, err)
+
+	childConf := input.NewConfig()
+	childConf.Type = "generate"
+	childConf.Generate.Count = 2
+	childConf.Generate.Interval = "1us"
+	childConf.Generate.Mapping = `root = "hello world"`
+
+	inConfig := input.NewConfig()
+	inConfig.Label = "foo"
+	inConfig.Type = "broker"
+	inConfig.Broker.Inputs = append(inConfig.Broker.Inputs, childConf, childConf)
+
+	mgr, err := manager.New(
+		manager.NewResourceConfig(),
+		manager.OptSetEnvironment(lenv),
+	)
+	require.NoError(t, err)
+
+	in, err := mgr.NewInput(inConfig)
+	require.NoError(t, err)
+
+	parts := bundletest.ReadN(t, in, 4)
+	for _, p := range parts {
+		assert.Contains(t, []string{
+			"root.broker.inputs.0",
+			"root.broker.inputs.1",
+		}, p.MetaGet("origin_source"))
+		assert.Equal(t, "generate", p.MetaGet("origin_source_type"))
+		assert.NotEmpty(t, p.MetaGet("origin_instance_id"))
+		assert.Empty(t, p.MetaGet("origin_config_hash"))
+	}
+}
+
+func TestBundleInputLineageReplacesUpstream(t *testing.T) {
+	conf := lineage.NewConfig()
+	conf.Enabled = true
+	conf.InstanceID = "foo-instance"
+
+	lenv, err := lineage.StampedBundle(bundle.GlobalEnvironment, conf, "")
+	require.NoError(t, err)
+
+	inConfig := input.NewConfig()
+	inConfig.Label = "foo"
+	inConfig.Type = "generate"
+	inConfig.Generate.Count = 1
+	inConfig.Generate.Interval = "1us"
+	inConfig.Generate.Mapping = `
+root = "hello world"
+meta lineage_source = "upstream"
+meta lineage_instance_id = "upstream-instance"
+meta lineage_config_hash = "upstream-hash"
+`
+
+	mgr, err := manager.New(
+		manager.NewResourceConfig(),
+		manager.OptSetEnvironment(lenv),
+	)
+	require.NoError(t, err)
+
+	in, err := mgr.NewInput(inConfig)
+	require.NoError(t, err)
+
+	parts := bundletest.ReadN(t, in, 1)
+	assert.Equal(t, "foo", parts[0].MetaGet("lineage_source"))
+	assert.Equal(t, "foo-instance", parts[0].MetaGet("lineage_instance_id"))
+	assert.Empty(t, parts[0].MetaGet("lineage_config_hash"))
+}
+
+func TestBundleInputLineageOffset(t *testing.T) {
+	tmpDir := t.TempDir()
+	inPath := filepath.Join(tmpDir, "in.txt")
+	require.NoError(t, os.WriteFile(inPath, []byte("hello\nworld\n"), 0o644))
+
+	conf := lineage.NewConfig()
+	conf.Enabled = true
+
+	lenv, err := lineage.StampedBundle(bundle.GlobalEnvironment, conf, "")
+	require.NoError(t, err)
+
+	inConfig := input.NewConfig()
+	inConfig.Type = "file"
+	inConfig.File.Paths = []string{inPath}
+
+	mgr, err := manager.New(
+		manager.NewResourceConfig(),
+		manager.OptSetEnvironment(lenv),
+	)
+	require.NoError(t, err)
+
+	in, err := mgr.NewInput(inConfig)
+	require.NoError(t, err)
+
+	parts := bundletest.ReadN(t, in, 2)
+	for _, p := range parts {
+		assert.Equal(t, inPath, p.MetaGet("lineage_offset"))
+	}
+}
@@ -0,0 +1,30 @@
+package lineage
+
+import (
+	"github.com/benthosdev/benthos/v4/internal/docs"
+)
+
+// Config contains configuration for stamping messages with lineage metadata.
+type Config struct {
+	Enabled        bool   `json:"enabled" yaml:"enabled"`
+	MetadataPrefix string `json:"metadata_prefix" yaml:"metadata_prefix"`
+	InstanceID     string `json:"instance_id" yaml:"instance_id"`
+}
+
+// NewConfig returns a config struct with the default values for each field.
+func NewConfig() Config {
+	return Config{
+		Enabled:        false,
+		MetadataPrefix: "lineage_",
+		InstanceID:     "",
+	}
+}
+
+// Spec returns a field spec for the lineage configuration fields.
+func Spec() docs.FieldSpecs {
+	return docs.FieldSpecs{
+		docs.FieldBool("enabled", "Whether messages should be stamped with lineage metadata as they are consumed by inputs.").HasDefault(false),
+		docs.FieldString("metadata_prefix", "A prefix added to the key of each lineage metadata field. The fields are `source`, `source_type`, `offset`, `sequence`, `ingest_timestamp`, `instance_id` and `config_hash`, where `offset` is only added for inputs that expose the offset or ID of messages as metadata, such as `kafka` and `aws_sqs`.").HasDefault("lineage_"),
+		docs.FieldString("instance_id", "An identifier of this Benthos instance to add to each message. When left empty a random identifier is generated at startup.").HasDefault(""),
+	}
+}
@@ -0,0 +1,153 @@
+package lineage
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/internal/component/input"
+	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/shutdown"
+)
+
+type stampedCtxKey struct{}
+
+// isStamped returns true when a message part has already been stamped by an
+// input within this process. The mark is kept within the context of the part
+// rather than its metadata so that lineage received from upstream systems,
+// such as within the headers of a Kafka record, is replaced.
+func isStamped(part *message.Part) bool {
+	_, stamped := part.GetContext().Value(stampedCtxKey{}).(struct{})
+	return stamped
+}
+
+// offsetKeys lists, for input types where it is known, the metadata keys that
+// together identify the offset or ID of a message within its source.
+var offsetKeys = map[string][]string{
+	"amqp_0_9":             {"amqp_delivery_tag"},
+	"aws_dynamodb_streams": {"dynamodb_shard", "dynamodb_sequence_number"},
+	"aws_kinesis":          {"kinesis_shard", "kinesis_sequence_number"},
+	"aws_s3":               {"s3_key"},
+	"aws_sqs":              {"sqs_message_id"},
+	"file":                 {"path"},
+	"kafka":                {"kafka_topic", "kafka_partition", "kafka_offset"},
+	"kafka_franz":          {"kafka_topic", "kafka_partition", "kafka_offset"},
+	"mqtt":                 {"mqtt_message_id"},
+	"pulsar":               {"pulsar_message_id"},
+	"redis_streams":        {"redis_stream"},
+}
+
+func sourceOffset(sourceType string, part *message.Part) string {
+	keys := offsetKeys[sourceType]
+	if len(keys) == 0 {
+		return ""
+	}
+	values := make([]string, 0, len(keys))
+	for _, k := range keys {
+		v := part.MetaGet(k)
+		if v == "" {
+			return ""
+		}
+		values = append(values, v)
+	}
+	return strings.Join(values, ":")
+}
+
+type stampedInput struct {
+	keys       metaKeys
+	source     string
+	sourceType string
+	instanceID string
+	configHash string
+
+	seq     uint64
+	wrapped input.Streamed
+	tChan   chan message.Transaction
+	shutSig *shutdown.Signaller
+}
+
+func stampInput(keys metaKeys, source, sourceType, instanceID, configHash string, i input.Streamed) input.Streamed {
+	s := &stampedInput{
+		keys:       keys,
+		source:     source,
+		sourceType: sourceType,
+		instanceID: instanceID,
+		configHash: configHash,
+		wrapped:    i,
+		tChan:      make(chan message.Transaction),
+		shutSig:    shutdown.NewSignaller(),
+	}
+	go s.loop()
+	return s
+}
+
+func (s *stampedInput) stamp(batch *message.Batch, ingested string) {
+	parts := make([]*message.Part, batch.Len())
+	_ = batch.Iter(func(i int, part *message.Part) error {
+		parts[i] = part
+
+		// When inputs are nested, such as the children of a broker, the lineage
+		// of the innermost input is retained.
+		if isStamped(part) {
+			return nil
+		}
+
+		s.seq++
+		part.MetaSet(s.keys.source, s.source)
+		part.MetaSet(s.keys.sourceType, s.sourceType)
+		part.MetaSet(s.keys.sequence, strconv.FormatUint(s.seq, 10))
+		part.MetaSet(s.keys.ingestTimestamp, ingested)
+		part.MetaSet(s.keys.instanceID, s.instanceID)
+		if offset := sourceOffset(s.sourceType, part); offset != "" {
+			part.MetaSet(s.keys.offset, offset)
+		} else {
+			part.MetaDelete(s.keys.offset)
+		}
+		if s.configHash != "" {
+			part.MetaSet(s.keys.configHash, s.configHash)
+		} else {
+			part.MetaDelete(s.keys.configHash)
+		}
+		parts[i] = message.WithContext(context.WithValue(part.GetContext(), stampedCtxKey{}, struct{}{}), part)
+		return nil
+	})
+	batch.SetAll(parts)
+}
+
+func (s *stampedInput) loop() {
+	defer close(s.tChan)
+	readChan := s.wrapped.TransactionChan()
+	for {
+		tran, open := <-readChan
+		if !open {
+			return
+		}
+		ingested := time.Now().Format(time.RFC3339Nano)
+		s.stamp(tran.Payload, ingested)
+		select {
+		case s.tChan <- tran:
+		case <-s.shutSig.CloseNowChan():
+			// Stop flushing if we fully timed out
+			return
+		}
+	}
+}
+
+func (s *stampedInput) TransactionChan() <-chan message.Transaction {
+	return s.tChan
+}
+
+func (s *stampedInput) Connected() bool {
+	return s.wrapped.Connected()
+}
+
+func (s *stampedInput) CloseAsync() {
+	s.wrapped.CloseAsync()
+}
+
+func (s *stampedInput) WaitForClose(timeout time.Duration) error {
+	err := s.wrapped.WaitForClose(timeout)
+	s.shutSig.CloseNow()
+	return err
+}
@@ -0,0 +1,30 @@
+package logfields
+
+import (
+	"fmt"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang/field"
+	"github.com/benthosdev/benthos/v4/internal/bundle"
+	"github.com/benthosdev/benthos/v4/internal/bundle/wrap"
+	"github.com/benthosdev/benthos/v4/internal/component/input"
+)
+
+// EnrichedBundle modifies a provided bundle environment so that all inputs are
+// wrapped by components that resolve a map of interpolated log fields against
+// each consumed message, and attach the results to the context of the message.
+// Components that log in relation to a message are then able to add these
+// fields to their logs. The fields are resolved before any processors of the
+// input are executed.
+func EnrichedBundle(b *bundle.Environment, fields map[string]string) *bundle.Environment {
+	return wrap.Inputs(b, func(i input.Streamed, iConf input.Config, nm bundle.NewManagement) (input.Streamed, error) {
+		exprs := make(map[string]*field.Expression, len(fields))
+		for k, v := range fields {
+			e, err := nm.BloblEnvironment().NewField(v)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse logger message field '%v': %w", k, err)
+			}
+			exprs[k] = e
+		}
+		return enrichInput(exprs, nm.Logger(), i), nil
+	})
+}
@@ -0,0 +1,114 @@
+package logfields_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/bundle"
+	"github.com/benthosdev/benthos/v4/internal/bundle/bundletest"
+	"github.com/benthosdev/benthos/v4/internal/bundle/logfields"
+	"github.com/benthosdev/benthos/v4/internal/component/input"
+	"github.com/benthosdev/benthos/v4/internal/component/processor"
+	"github.com/benthosdev/benthos/v4/internal/log"
+	"github.com/benthosdev/benthos/v4/internal/manager"
+
+	_ "github.com/benthosdev/benthos/v4/public/components/all"
+)
+
+func TestBundleInputMessageFields(t *testing.T) {
+	lenv := logfields.EnrichedBundle(bundle.GlobalEnvironment, map[string]string{
+		"tenant":  `${! meta("tenant") }`,
+		"counter": `${! count("logfields_test") }`,
+	})
+
+	procConf := processor.NewConfig()
+	procConf.Type = "bloblang"
+	procConf.Bloblang = `meta tenant = "changed"`
+
+	inConfig := input.NewConfig()
+	inConfig.Type = "generate"
+	inConfig.Generate.Count = 2
+	inConfig.Generate.Interval = "1us"
+	inConfig.Generate.Mapping = `meta tenant = "foo"`
+	inConfig.Processors = append(inConfig.Processors, procConf)
+
+	mgr, err := manager.New(
+		manager.NewResourceConfig(),
+		manager.OptSetEnvironment(lenv),
+	)
+	require.NoError(t, err)
+
+	in, err := mgr.NewInput(inConfig)
+	require.NoError(t, err)
+
+	parts := bundletest.ReadN(t, in, 2)
+	assert.Equal(t, map[string]string{"tenant": "foo", "counter": "1"}, log.MessageFields(parts[0].GetContext()))
+	assert.Equal(t, map[string]string{"tenant": "foo", "counter": "2"}, log.MessageFields(parts[1].GetContext()))
+	assert.Equal(t, "changed", parts[0].MetaGet("tenant"))
+}
+
+func TestBundleInputMessageFieldsBadInterpolation(t *testing.T) {
+	lenv := logfields.EnrichedBundle(bundle.GlobalEnvironment, map[string]string{
+		"tenant": `${! meta("tenant" }`,
+	})
+
+	mgr, err := manager.New(
+		manager.NewResourceConfig(),
+		manager.OptSetEnvironment(lenv),
+	)
+	require.NoError(t, err)
+
+	inConfig := input.NewConfig()
+	inConfig.Type = "generate"
+	inConfig.Generate.Mapping = `root = "hello world"`
+
+	_, err = mgr.NewInput(inConfig)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "tenant")
+}
+
+func TestBundleInputMessageFieldsProcessorErrors(t *testing.T) {
+	lenv := logfields.EnrichedBundle(bundle.GlobalEnvironment, map[string]string{
+		"tenant": `${! meta("tenant") }`,
+	})
+
+	procConf := processor.NewConfig()
+	procConf.Type = "jmespath"
+	procConf.JMESPath.Query = "foo"
+
+	inConfig := input.NewConfig()
+	inConfig.Type = "generate"
+	inConfig.Generate.Count = 1
+	inConfig.Generate.Interval = "1us"
+	inConfig.Generate.Mapping = `
+meta tenant = "foo"
+root = "not json"
+`
+	inConfig.Processors = append(inConfig.Processors, procConf)
+
+	logConf := log.NewConfig()
+	logConf.AddTimeStamp = false
+	logConf.Format = "logfmt"
+	logConf.LogLevel = "DEBUG"
+
+	var buf bytes.Buffer
+	logger, err := log.NewV2(&buf, logConf)
+	require.NoError(t, err)
+
+	mgr, err := manager.New(
+		manager.NewResourceConfig(),
+		manager.OptSetEnvironment(lenv),
+		manager.OptSetLogger(logger),
+	)
+	require.NoError(t, err)
+
+	in, err := mgr.NewInput(inConfig)
+	require.NoError(t, err)
+
+	parts := bundletest.ReadN(t, in, 1)
+	require.Error(t, parts[0].ErrorGet())
+	assert.Regexp(t, `msg="Processor failed: [^"]*".* tenant=foo`, buf.String())
+}
@@ -0,0 +1,99 @@
+package logfields
+
+import (
+	"context"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang/field"
+	"github.com/benthosdev/benthos/v4/internal/component/input"
+	"github.com/benthosdev/benthos/v4/internal/log"
+	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/shutdown"
+)
+
+type enrichedInput struct {
+	exprs map[string]*field.Expression
+	log   log.Modular
+
+	wrapped input.Streamed
+	tChan   chan message.Transaction
+	shutSig *shutdown.Signaller
+}
+
+func enrichInput(exprs map[string]*field.Expression, logger log.Modular, i input.Streamed) input.Streamed {
+	e := &enrichedInput{
+		exprs:   exprs,
+		log:     logger,
+		wrapped: i,
+		tChan:   make(chan message.Transaction),
+		shutSig: shutdown.NewSignaller(),
+	}
+	go e.loop()
+	return e
+}
+
+func (e *enrichedInput) enrich(batch *message.Batch) {
+	parts := make([]*message.Part, batch.Len())
+	_ = batch.Iter(func(i int, part *message.Part) error {
+		parts[i] = part
+
+		// When inputs are nested, such as the children of a broker, the fields
+		// resolved by the innermost input are retained.
+		if log.MessageFields(part.GetContext()) != nil {
+			return nil
+		}
+
+		fields := make(map[string]string, len(e.exprs))
+		for k, expr := range e.exprs {
+			fields[k] = expr.String(i, batch)
+		}
+		parts[i] = message.WithContext(log.ContextWithMessageFields(part.GetContext(), fields), part)
+		return nil
+	})
+	batch.SetAll(parts)
+}
+
+func (e *enrichedInput) loop() {
+	defer close(e.tChan)
+	readChan := e.wrapped.TransactionChan()
+	for {
+		tran, open := <-readChan
+		if !open {
+			return
+		}
+		e.enrich(tran.Payload)
+
+		// Log rejections of the messages consumed by the input with the fields
+		// that have been resolved for them.
+		enriched := message.NewTransactionFunc(tran.Payload, func(ctx context.Context, err error) error {
+			if err != nil {
+				log.ForBatch(e.log, tran.Payload).Debugf("Messages were rejected: %v", err)
+			}
+			return tran.Ack(ctx, err)
+		})
+		select {
+		case e.tChan <- *enriched.WithContext(tran.Context()):
+		case <-e.shutSig.CloseNowChan():
+			// Stop flushing if we fully timed out
+			return
+		}
+	}
+}
+
+func (e *enrichedInput) TransactionChan() <-chan message.Transaction {
+	return e.tChan
+}
+
+func (e *enrichedInput) Connected() bool {
+	return e.wrapped.Connected()
+}
+
+func (e *enrichedInput) CloseAsync() {
+	e.wrapped.CloseAsync()
+}
+
+func (e *enrichedInput) WaitForClose(timeout time.Duration) error {
+	err := e.wrapped.WaitForClose(timeout)
+	e.shutSig.CloseNow()
+	return err
+}
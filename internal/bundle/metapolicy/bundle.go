@@ -0,0 +1,52 @@
+package metapolicy
+
+import (
+	"time"
+
+	"github.com/benthosdev/benthos/v4/internal/bundle"
+	"github.com/benthosdev/benthos/v4/internal/bundle/wrap"
+	"github.com/benthosdev/benthos/v4/internal/component/output"
+	"github.com/benthosdev/benthos/v4/internal/component/processor"
+	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/metadata"
+	"github.com/benthosdev/benthos/v4/internal/pipeline"
+)
+
+// PolicyBundle modifies a provided bundle environment so that all outputs
+// apply a metadata policy to messages before they are written. The policy is
+// applied after any processors of the output are executed.
+func PolicyBundle(b *bundle.Environment, conf metadata.PolicyConfig) (*bundle.Environment, error) {
+	policy, err := conf.Policy()
+	if err != nil {
+		return nil, err
+	}
+
+	policyPipe := func() (processor.Pipeline, error) {
+		return pipeline.NewProcessor(&policyProcessor{policy: policy}), nil
+	}
+	return wrap.Outputs(b, func(o output.Streamed, oConf output.Config, nm bundle.NewManagement) (output.Streamed, error) {
+		return output.WrapWithPipeline(o, policyPipe)
+	}), nil
+}
+
+//------------------------------------------------------------------------------
+
+type policyProcessor struct {
+	policy *metadata.Policy
+}
+
+func (p *policyProcessor) ProcessMessage(msg *message.Batch) ([]*message.Batch, error) {
+	newMsg := msg.Copy()
+	_ = newMsg.Iter(func(i int, part *message.Part) error {
+		p.policy.Apply(part)
+		return nil
+	})
+	return []*message.Batch{newMsg}, nil
+}
+
+func (p *policyProcessor) CloseAsync() {
+}
+
+func (p *policyProcessor) WaitForClose(timeout time.Duration) error {
+	return nil
+}
@@ -0,0 +1,104 @@
+package metapolicy_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/bundle"
+	"github.com/benthosdev/benthos/v4/internal/bundle/metapolicy"
+	"github.com/benthosdev/benthos/v4/internal/component/output"
+	"github.com/benthosdev/benthos/v4/internal/component/processor"
+	"github.com/benthosdev/benthos/v4/internal/manager"
+	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/metadata"
+
+	_ "github.com/benthosdev/benthos/v4/public/components/all"
+)
+
+func TestBundleOutputMetadataPolicy(t *testing.T) {
+	policyConf := metadata.NewPolicyConfig()
+	policyConf.ExcludePrefixes = []string{"internal_"}
+	policyConf.RedactPatterns = []string{"^token$"}
+
+	penv, err := metapolicy.PolicyBundle(bundle.GlobalEnvironment, policyConf)
+	require.NoError(t, err)
+
+	// Metadata added by output processors is also subject to the policy
+	blobConf := processor.NewConfig()
+	blobConf.Type = "bloblang"
+	blobConf.Bloblang = `meta internal_added = "nope"`
+
+	outConfig := output.NewConfig()
+	outConfig.Type = "inproc"
+	outConfig.Inproc = "foo"
+	outConfig.Processors = append(outConfig.Processors, blobConf)
+
+	mgr, err := manager.New(
+		manager.NewResourceConfig(),
+		manager.OptSetEnvironment(penv),
+	)
+	require.NoError(t, err)
+
+	out, err := mgr.NewOutput(outConfig)
+	require.NoError(t, err)
+
+	tranChan := make(chan message.Transaction)
+	require.NoError(t, out.Consume(tranChan))
+
+	pipeChan, err := mgr.GetPipe("foo")
+	require.NoError(t, err)
+
+	inPart := message.NewPart([]byte("hello world"))
+	inPart.MetaSet("internal_id", "abc")
+	inPart.MetaSet("token", "secret")
+	inPart.MetaSet("topic", "bar")
+
+	inBatch := message.QuickBatch(nil)
+	inBatch.Append(inPart)
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*5)
+	defer done()
+
+	resChan := make(chan error, 1)
+	select {
+	case tranChan <- message.NewTransaction(inBatch, resChan):
+	case <-ctx.Done():
+		t.Fatal("timed out")
+	}
+
+	var outMeta map[string]string
+	select {
+	case tran := <-pipeChan:
+		outMeta = map[string]string{}
+		_ = tran.Payload.Get(0).MetaIter(func(k, v string) error {
+			outMeta[k] = v
+			return nil
+		})
+		require.NoError(t, tran.Ack(ctx, nil))
+	case <-ctx.Done():
+		t.Fatal("timed out")
+	}
+
+	select {
+	case err := <-resChan:
+		require.NoError(t, err)
+	case <-ctx.Done():
+		t.Fatal("timed out")
+	}
+
+	assert.Equal(t, map[string]string{
+		"token": "[REDACTED]",
+		"topic": "bar",
+	}, outMeta)
+
+	// The original message must not be modified
+	assert.Equal(t, "abc", inPart.MetaGet("internal_id"))
+	assert.Equal(t, "secret", inPart.MetaGet("token"))
+
+	out.CloseAsync()
+	require.NoError(t, out.WaitForClose(time.Second))
+}
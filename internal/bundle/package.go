@@ -31,12 +31,30 @@ import (
 var nameRegexpRaw = `^[a-z0-9]+(_[a-z0-9]+)*$`
 var nameRegexp = regexp.MustCompile(nameRegexpRaw)
 
+var labelNameRegexp = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// ValidateLabels returns an error if any of the names of a map of labels
+// intended for observability components are invalid or reserved.
+func ValidateLabels(labels map[string]string) error {
+	for k := range labels {
+		if !labelNameRegexp.MatchString(k) {
+			return fmt.Errorf("label name '%v' must match the pattern %v", k, labelNameRegexp.String())
+		}
+		switch k {
+		case "stream", "label", "path":
+			return fmt.Errorf("label name '%v' is reserved", k)
+		}
+	}
+	return nil
+}
+
 // NewManagement defines the latest API for a Benthos manager, which will become
 // the only API (internally) in Benthos V4.
 type NewManagement interface {
 	ForStream(id string) NewManagement
 	IntoPath(segments ...string) NewManagement
 	WithAddedMetrics(m metrics.Type) NewManagement
+	WithLabels(labels map[string]string) NewManagement
 
 	Path() []string
 	Label() string
@@ -47,6 +65,7 @@ type NewManagement interface {
 	BloblEnvironment() *bloblang.Environment
 
 	RegisterEndpoint(path, desc string, h http.HandlerFunc)
+	RegisterTunable(field, desc, value string, set func(value string) error) (unregister func())
 
 	NewBuffer(conf buffer.Config) (buffer.Streamed, error)
 	NewCache(conf cache.Config) (cache.V1, error)
@@ -0,0 +1,27 @@
+package quarantine
+
+import (
+	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
+	"github.com/benthosdev/benthos/v4/internal/bundle"
+	"github.com/benthosdev/benthos/v4/internal/bundle/wrap"
+	"github.com/benthosdev/benthos/v4/internal/component/input"
+)
+
+// QuarantinedBundle modifies a provided bundle environment so that all inputs
+// are wrapped by components that retry the delivery of messages that are
+// rejected downstream, and once all retries are exhausted store the messages
+// within the quarantine and acknowledge them. Messages are quarantined before
+// any processors of the input are executed, and therefore re-injected messages
+// are processed again.
+func QuarantinedBundle(b *bundle.Environment, q *Quarantine) *bundle.Environment {
+	return wrap.Inputs(b, func(i input.Streamed, iConf input.Config, nm bundle.NewManagement) (input.Streamed, error) {
+		key := nm.Label()
+		if key == "" {
+			key = "root." + query.SliceToDotPath(nm.Path()...)
+		}
+		qi := quarantineInput(q, key, q.conf.MaxRetries, nm.Logger(), i)
+		q.register(key, nm, qi.reinjectChan)
+		go qi.loop()
+		return qi, nil
+	})
+}
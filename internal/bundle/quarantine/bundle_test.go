@@ -0,0 +1,120 @@
+package quarantine_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/bundle"
+	"github.com/benthosdev/benthos/v4/internal/bundle/quarantine"
+	"github.com/benthosdev/benthos/v4/internal/component/cache"
+	"github.com/benthosdev/benthos/v4/internal/component/input"
+	"github.com/benthosdev/benthos/v4/internal/manager"
+	"github.com/benthosdev/benthos/v4/internal/message"
+
+	_ "github.com/benthosdev/benthos/v4/public/components/all"
+)
+
+func readTran(t *testing.T, in input.Streamed) message.Transaction {
+	t.Helper()
+
+	select {
+	case tran, open := <-in.TransactionChan():
+		require.True(t, open)
+		return tran
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out")
+	}
+	return message.Transaction{}
+}
+
+func TestBundleInputQuarantine(t *testing.T) {
+	ctx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+
+	conf := quarantine.NewConfig()
+	conf.Enabled = true
+	conf.Cache = "foocache"
+	conf.MaxRetries = 2
+
+	q, err := quarantine.New(conf)
+	require.NoError(t, err)
+
+	qenv := quarantine.QuarantinedBundle(bundle.GlobalEnvironment, q)
+
+	resConf := manager.NewResourceConfig()
+	cacheConf := cache.NewConfig()
+	cacheConf.Label = "foocache"
+	cacheConf.Type = "memory"
+	resConf.ResourceCaches = append(resConf.ResourceCaches, cacheConf)
+
+	mgr, err := manager.New(resConf, manager.OptSetEnvironment(qenv))
+	require.NoError(t, err)
+
+	inConfig := input.NewConfig()
+	inConfig.Label = "foo"
+	inConfig.Type = "generate"
+	inConfig.Generate.Interval = "1h"
+	inConfig.Generate.Mapping = `
+root = "hello world"
+meta bar = "baz"
+`
+
+	in, err := mgr.NewInput(inConfig)
+	require.NoError(t, err)
+	defer func() {
+		in.CloseAsync()
+		require.NoError(t, in.WaitForClose(time.Second))
+	}()
+
+	for i := 0; i < 3; i++ {
+		tran := readTran(t, in)
+		require.Equal(t, 1, tran.Payload.Len())
+		assert.Equal(t, "hello world", string(tran.Payload.Get(0).Get()))
+		require.NoError(t, tran.Ack(ctx, errors.New("nope")))
+	}
+
+	entries, err := q.Entries(ctx)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "foo", entries[0].Component)
+	assert.Equal(t, "nope", entries[0].Error)
+	assert.Empty(t, entries[0].Messages)
+
+	handler := q.Handler()
+
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest(http.MethodGet, "/quarantine?id="+entries[0].ID, nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var entry quarantine.Entry
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &entry))
+	assert.Equal(t, []quarantine.Message{
+		{Content: []byte("hello world"), Metadata: map[string]string{"bar": "baz"}},
+	}, entry.Messages)
+
+	w = httptest.NewRecorder()
+	handler(w, httptest.NewRequest(http.MethodPost, "/quarantine?id="+entries[0].ID, nil))
+	require.Equal(t, http.StatusNoContent, w.Code, w.Body.String())
+
+	tran := readTran(t, in)
+	require.Equal(t, 1, tran.Payload.Len())
+	assert.Equal(t, "hello world", string(tran.Payload.Get(0).Get()))
+	assert.Equal(t, "baz", tran.Payload.Get(0).MetaGet("bar"))
+	require.NoError(t, tran.Ack(ctx, nil))
+
+	entries, err = q.Entries(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	w = httptest.NewRecorder()
+	handler(w, httptest.NewRequest(http.MethodGet, "/quarantine?id="+entry.ID, nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,33 @@
+package quarantine
+
+import (
+	"github.com/benthosdev/benthos/v4/internal/docs"
+)
+
+// Config contains configuration for the quarantine store.
+type Config struct {
+	Enabled    bool   `json:"enabled" yaml:"enabled"`
+	Cache      string `json:"cache" yaml:"cache"`
+	KeyPrefix  string `json:"key_prefix" yaml:"key_prefix"`
+	MaxRetries int    `json:"max_retries" yaml:"max_retries"`
+}
+
+// NewConfig returns a config struct with the default values for each field.
+func NewConfig() Config {
+	return Config{
+		Enabled:    false,
+		Cache:      "",
+		KeyPrefix:  "quarantine_",
+		MaxRetries: 3,
+	}
+}
+
+// Spec returns a field spec for the quarantine configuration fields.
+func Spec() docs.FieldSpecs {
+	return docs.FieldSpecs{
+		docs.FieldBool("enabled", "Whether messages that fail to be delivered should be quarantined. Quarantined messages can be browsed, deleted and re-injected into the input they were consumed from via the HTTP endpoint `/quarantine`.").HasDefault(false),
+		docs.FieldString("cache", "The name of a [cache resource](/docs/components/caches/about) to store quarantined messages within.").HasDefault(""),
+		docs.FieldString("key_prefix", "A prefix to add to the keys of all quarantined messages stored within the cache.").HasDefault("quarantine_"),
+		docs.FieldInt("max_retries", "The maximum number of times delivery of a batch of messages is retried before it is quarantined.").HasDefault(3),
+	}
+}
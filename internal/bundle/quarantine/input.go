@@ -0,0 +1,151 @@
+package quarantine
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/internal/component/input"
+	"github.com/benthosdev/benthos/v4/internal/log"
+	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/shutdown"
+)
+
+type quarantinedInput struct {
+	component  string
+	maxRetries int
+	q          *Quarantine
+	log        log.Modular
+
+	inFlight     sync.WaitGroup
+	wrapped      input.Streamed
+	resendChan   chan message.Transaction
+	reinjectChan chan *Entry
+	tChan        chan message.Transaction
+	shutSig      *shutdown.Signaller
+}
+
+func quarantineInput(q *Quarantine, component string, maxRetries int, logger log.Modular, i input.Streamed) *quarantinedInput {
+	return &quarantinedInput{
+		component:    component,
+		maxRetries:   maxRetries,
+		q:            q,
+		log:          logger,
+		wrapped:      i,
+		resendChan:   make(chan message.Transaction),
+		reinjectChan: make(chan *Entry),
+		tChan:        make(chan message.Transaction),
+		shutSig:      shutdown.NewSignaller(),
+	}
+}
+
+func retryDelay(attempts int) time.Duration {
+	if attempts > 10 {
+		return time.Second
+	}
+	return time.Millisecond * time.Duration(1<<attempts)
+}
+
+// deliver creates a transaction for a payload where failed deliveries are
+// retried up to a maximum number of attempts, after which the payload is
+// quarantined. The provided ack func is called once the payload has either
+// been delivered or quarantined, and is only called with an error when the
+// payload could not be quarantined.
+func (q *quarantinedInput) deliver(payload *message.Batch, attempts int, ackFn func(context.Context, error) error) message.Transaction {
+	return message.NewTransactionFunc(payload.Copy(), func(ctx context.Context, err error) error {
+		if err == nil {
+			defer q.inFlight.Done()
+			return ackFn(ctx, nil)
+		}
+
+		if attempts < q.maxRetries {
+			go func() {
+				select {
+				case <-time.After(retryDelay(attempts)):
+				case <-q.shutSig.CloseNowChan():
+					return
+				}
+				select {
+				case q.resendChan <- q.deliver(payload, attempts+1, ackFn):
+				case <-q.shutSig.CloseNowChan():
+				}
+			}()
+			return nil
+		}
+
+		defer q.inFlight.Done()
+		if qErr := q.q.add(ctx, q.component, payload, err); qErr != nil {
+			q.log.Errorf("Failed to quarantine messages: %v\n", qErr)
+			return ackFn(ctx, err)
+		}
+		q.log.Warnf("Quarantined messages after %v failed delivery attempts: %v\n", attempts+1, err)
+		return ackFn(ctx, nil)
+	})
+}
+
+func (q *quarantinedInput) loop() {
+	defer close(q.tChan)
+	defer q.q.unregister(q.component, q.reinjectChan)
+
+	readChan := q.wrapped.TransactionChan()
+	reinjectChan := q.reinjectChan
+
+	var drainedChan chan struct{}
+	for {
+		var tran message.Transaction
+		select {
+		case t, open := <-readChan:
+			if !open {
+				// Stop reading new messages and wait for all pending
+				// deliveries to be resolved.
+				readChan, reinjectChan = nil, nil
+				drainedChan = make(chan struct{})
+				go func(c chan struct{}) {
+					q.inFlight.Wait()
+					close(c)
+				}(drainedChan)
+				continue
+			}
+			q.inFlight.Add(1)
+			tran = q.deliver(t.Payload, 0, t.Ack)
+		case e := <-reinjectChan:
+			q.inFlight.Add(1)
+			tran = q.deliver(e.Batch(), 0, func(ctx context.Context, err error) error {
+				if err != nil {
+					return nil
+				}
+				return q.q.Delete(ctx, e.ID)
+			})
+		case tran = <-q.resendChan:
+		case <-drainedChan:
+			return
+		case <-q.shutSig.CloseNowChan():
+			return
+		}
+
+		select {
+		case q.tChan <- tran:
+		case <-q.shutSig.CloseNowChan():
+			// Stop flushing if we fully timed out
+			return
+		}
+	}
+}
+
+func (q *quarantinedInput) TransactionChan() <-chan message.Transaction {
+	return q.tChan
+}
+
+func (q *quarantinedInput) Connected() bool {
+	return q.wrapped.Connected()
+}
+
+func (q *quarantinedInput) CloseAsync() {
+	q.wrapped.CloseAsync()
+}
+
+func (q *quarantinedInput) WaitForClose(timeout time.Duration) error {
+	err := q.wrapped.WaitForClose(timeout)
+	q.shutSig.CloseNow()
+	return err
+}
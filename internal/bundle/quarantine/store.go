@@ -0,0 +1,421 @@
+package quarantine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gofrs/uuid"
+
+	"github.com/benthosdev/benthos/v4/internal/bundle"
+	"github.com/benthosdev/benthos/v4/internal/component"
+	"github.com/benthosdev/benthos/v4/internal/component/cache"
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+// ErrEntryNotFound is returned when a quarantine entry does not exist.
+var ErrEntryNotFound = errors.New("quarantine entry not found")
+
+// Message is a copy of a quarantined message.
+type Message struct {
+	Content  []byte            `json:"content"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// Entry describes a batch of messages that failed to be delivered after all
+// retries.
+type Entry struct {
+	ID        string    `json:"id"`
+	Seq       uint64    `json:"seq"`
+	Component string    `json:"component"`
+	Error     string    `json:"error"`
+	Timestamp time.Time `json:"timestamp"`
+	Messages  []Message `json:"messages,omitempty"`
+}
+
+// Page is a page of quarantined entries without their messages. When Next is
+// non-zero more entries may exist, and can be listed from that sequence.
+type Page struct {
+	Entries []Entry `json:"entries"`
+	Next    uint64  `json:"next,omitempty"`
+}
+
+// Batch returns the quarantined messages of an entry as a batch.
+func (e *Entry) Batch() *message.Batch {
+	parts := make([]*message.Part, len(e.Messages))
+	for i, m := range e.Messages {
+		parts[i] = message.NewPart(m.Content)
+		for k, v := range m.Metadata {
+			parts[i].MetaSet(k, v)
+		}
+	}
+	b := message.QuickBatch(nil)
+	b.SetAll(parts)
+	return b
+}
+
+// Quarantine stores batches of messages that failed to be delivered within a
+// cache resource, and routes stored batches back into the inputs they were
+// consumed from on request.
+//
+// Each entry is stored under its own key, and is listed by a slot key claimed
+// with the next free sequence number. Slots are claimed with the Add semantics
+// of the cache, and therefore multiple instances are able to share a cache
+// without coordinating. The slots of deleted entries are kept as empty
+// tombstones so that listings can continue past them.
+type Quarantine struct {
+	conf Config
+
+	mut     sync.Mutex
+	mgr     bundle.NewManagement
+	nextSeq uint64
+	inputs  map[string]chan<- *Entry
+}
+
+// New creates a quarantine store from a config.
+func New(conf Config) (*Quarantine, error) {
+	if conf.Cache == "" {
+		return nil, errors.New("a cache resource must be specified")
+	}
+	return &Quarantine{
+		conf:    conf,
+		nextSeq: 1,
+		inputs:  map[string]chan<- *Entry{},
+	}, nil
+}
+
+// Open creates a quarantine store from a config that accesses the cache
+// resources of a manager directly, allowing entries to be read and deleted
+// outside of a running pipeline. Entries of an opened store cannot be
+// re-injected unless inputs are also registered with it.
+func Open(conf Config, mgr bundle.NewManagement) (*Quarantine, error) {
+	q, err := New(conf)
+	if err != nil {
+		return nil, err
+	}
+	q.mgr = mgr
+	return q, nil
+}
+
+func (q *Quarantine) register(component string, nm bundle.NewManagement, reinjectChan chan<- *Entry) {
+	q.mut.Lock()
+	defer q.mut.Unlock()
+
+	if q.mgr == nil {
+		q.mgr = nm
+	}
+	q.inputs[component] = reinjectChan
+}
+
+func (q *Quarantine) unregister(component string, reinjectChan chan<- *Entry) {
+	q.mut.Lock()
+	defer q.mut.Unlock()
+
+	if q.inputs[component] == reinjectChan {
+		delete(q.inputs, component)
+	}
+}
+
+func (q *Quarantine) entryKey(id string) string {
+	return q.conf.KeyPrefix + id
+}
+
+func (q *Quarantine) slotKey(seq uint64) string {
+	return q.conf.KeyPrefix + "slot_" + strconv.FormatUint(seq, 10)
+}
+
+// The sequence hint is a best effort record of the next free slot, which
+// avoids probing from the first slot when a quarantine is first used.
+func (q *Quarantine) seqHintKey() string {
+	return q.conf.KeyPrefix + "next_slot"
+}
+
+func (q *Quarantine) accessCache(ctx context.Context, fn func(c cache.V1) error) error {
+	q.mut.Lock()
+	mgr := q.mgr
+	q.mut.Unlock()
+
+	if mgr == nil {
+		return errors.New("no inputs have been registered with the quarantine")
+	}
+	var cErr error
+	if err := mgr.AccessCache(ctx, q.conf.Cache, func(c cache.V1) {
+		cErr = fn(c)
+	}); err != nil {
+		return err
+	}
+	return cErr
+}
+
+// claimSlot writes a listing record to the next free slot and returns its
+// sequence number.
+func (q *Quarantine) claimSlot(ctx context.Context, c cache.V1, fn func(seq uint64) ([]byte, error)) (uint64, error) {
+	q.mut.Lock()
+	seq := q.nextSeq
+	q.mut.Unlock()
+
+	if seq == 1 {
+		if hintBytes, err := c.Get(ctx, q.seqHintKey()); err == nil {
+			if hint, err := strconv.ParseUint(string(hintBytes), 10, 64); err == nil && hint > seq {
+				seq = hint
+			}
+		}
+	}
+
+	for {
+		slotBytes, err := fn(seq)
+		if err != nil {
+			return 0, err
+		}
+		err = c.Add(ctx, q.slotKey(seq), slotBytes, nil)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, component.ErrKeyAlreadyExists) {
+			return 0, err
+		}
+		seq++
+	}
+
+	q.mut.Lock()
+	if seq >= q.nextSeq {
+		q.nextSeq = seq + 1
+	}
+	q.mut.Unlock()
+
+	_ = c.Set(ctx, q.seqHintKey(), []byte(strconv.FormatUint(seq+1, 10)), nil)
+	return seq, nil
+}
+
+func (q *Quarantine) add(ctx context.Context, component string, batch *message.Batch, cause error) error {
+	u4, err := uuid.NewV4()
+	if err != nil {
+		return err
+	}
+
+	entry := Entry{
+		ID:        u4.String(),
+		Component: component,
+		Error:     cause.Error(),
+		Timestamp: time.Now(),
+		Messages:  make([]Message, batch.Len()),
+	}
+	_ = batch.Iter(func(i int, p *message.Part) error {
+		entry.Messages[i].Content = p.Get()
+		_ = p.MetaIter(func(k, v string) error {
+			if entry.Messages[i].Metadata == nil {
+				entry.Messages[i].Metadata = map[string]string{}
+			}
+			entry.Messages[i].Metadata[k] = v
+			return nil
+		})
+		return nil
+	})
+
+	return q.accessCache(ctx, func(c cache.V1) error {
+		seq, err := q.claimSlot(ctx, c, func(seq uint64) ([]byte, error) {
+			listed := entry
+			listed.Seq = seq
+			listed.Messages = nil
+			return json.Marshal(listed)
+		})
+		if err != nil {
+			return err
+		}
+
+		entry.Seq = seq
+		entryBytes, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		return c.Set(ctx, q.entryKey(entry.ID), entryBytes, nil)
+	})
+}
+
+// List returns a page of up to limit quarantined entries without their
+// messages, ordered from oldest to newest, starting from a sequence number.
+// The slots of at most limit entries, including deleted entries, are read.
+func (q *Quarantine) List(ctx context.Context, from uint64, limit int) (Page, error) {
+	if from == 0 {
+		from = 1
+	}
+	if limit <= 0 {
+		limit = defaultListLimit
+	}
+
+	page := Page{Entries: []Entry{}}
+	err := q.accessCache(ctx, func(c cache.V1) error {
+		for seq := from; seq < from+uint64(limit); seq++ {
+			slotBytes, err := c.Get(ctx, q.slotKey(seq))
+			if err != nil {
+				if errors.Is(err, component.ErrKeyNotFound) {
+					return nil
+				}
+				return err
+			}
+			if len(slotBytes) == 0 {
+				continue
+			}
+			var entry Entry
+			if err := json.Unmarshal(slotBytes, &entry); err != nil {
+				return fmt.Errorf("failed to parse quarantine slot %v: %w", seq, err)
+			}
+			page.Entries = append(page.Entries, entry)
+		}
+		page.Next = from + uint64(limit)
+		return nil
+	})
+	return page, err
+}
+
+const (
+	defaultListLimit = 100
+	maxListLimit     = 1000
+)
+
+// Entries returns all quarantined entries without their messages, ordered from
+// oldest to newest.
+func (q *Quarantine) Entries(ctx context.Context) ([]Entry, error) {
+	var entries []Entry
+	for from := uint64(1); from != 0; {
+		page, err := q.List(ctx, from, defaultListLimit)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, page.Entries...)
+		from = page.Next
+	}
+	return entries, nil
+}
+
+// Get returns a quarantined entry along with its messages.
+func (q *Quarantine) Get(ctx context.Context, id string) (*Entry, error) {
+	var entry Entry
+	if err := q.accessCache(ctx, func(c cache.V1) error {
+		entryBytes, err := c.Get(ctx, q.entryKey(id))
+		if err != nil {
+			if errors.Is(err, component.ErrKeyNotFound) {
+				return ErrEntryNotFound
+			}
+			return err
+		}
+		return json.Unmarshal(entryBytes, &entry)
+	}); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// Delete removes a quarantined entry.
+func (q *Quarantine) Delete(ctx context.Context, id string) error {
+	entry, err := q.Get(ctx, id)
+	if err != nil {
+		if errors.Is(err, ErrEntryNotFound) {
+			return nil
+		}
+		return err
+	}
+	return q.accessCache(ctx, func(c cache.V1) error {
+		// The slot is replaced with a tombstone rather than deleted so that it
+		// is never claimed again.
+		if err := c.Set(ctx, q.slotKey(entry.Seq), []byte{}, nil); err != nil {
+			return err
+		}
+		return c.Delete(ctx, q.entryKey(id))
+	})
+}
+
+// Reinject sends the messages of a quarantined entry back into the input that
+// they were consumed from. The entry is removed once the messages have been
+// delivered successfully.
+func (q *Quarantine) Reinject(ctx context.Context, id string) error {
+	entry, err := q.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	q.mut.Lock()
+	reinjectChan, exists := q.inputs[entry.Component]
+	q.mut.Unlock()
+	if !exists {
+		return fmt.Errorf("input '%v' is not running", entry.Component)
+	}
+
+	select {
+	case reinjectChan <- entry:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return nil
+}
+
+// Handler returns an HTTP handler for browsing and managing quarantined
+// entries. A GET request without an `id` query parameter responds with a page
+// of entries without their messages, starting from the sequence of the `from`
+// query parameter and limited by the `limit` query parameter, and with an `id`
+// responds with that entry including its messages. A DELETE request removes the entry of the given
+// `id`, and a POST request re-injects it.
+func (q *Quarantine) Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		id := r.URL.Query().Get("id")
+
+		var res interface{}
+		var err error
+		switch {
+		case r.Method == http.MethodGet && id == "":
+			var from uint64
+			var limit int
+			if fromStr := r.URL.Query().Get("from"); fromStr != "" {
+				if from, err = strconv.ParseUint(fromStr, 10, 64); err != nil {
+					http.Error(w, fmt.Sprintf("failed to parse from: %v", err), http.StatusBadRequest)
+					return
+				}
+			}
+			if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+				if limit, err = strconv.Atoi(limitStr); err != nil || limit <= 0 || limit > maxListLimit {
+					http.Error(w, fmt.Sprintf("limit must be between 1 and %v", maxListLimit), http.StatusBadRequest)
+					return
+				}
+			}
+			res, err = q.List(ctx, from, limit)
+		case r.Method == http.MethodGet:
+			res, err = q.Get(ctx, id)
+		case id == "":
+			http.Error(w, "an id must be specified", http.StatusBadRequest)
+			return
+		case r.Method == http.MethodDelete:
+			err = q.Delete(ctx, id)
+		case r.Method == http.MethodPost:
+			err = q.Reinject(ctx, id)
+		default:
+			http.Error(w, "method not supported", http.StatusMethodNotAllowed)
+			return
+		}
+		if err != nil {
+			status := http.StatusInternalServerError
+			if errors.Is(err, ErrEntryNotFound) {
+				status = http.StatusNotFound
+			}
+			http.Error(w, err.Error(), status)
+			return
+		}
+		if res == nil {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		resBytes, err := json.Marshal(res)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(resBytes)
+	}
+}
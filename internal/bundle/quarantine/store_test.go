@@ -0,0 +1,74 @@
+package quarantine
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/manager/mock"
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+func TestQuarantineListing(t *testing.T) {
+	ctx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+
+	mgr := mock.NewManager()
+	mgr.Caches["foocache"] = map[string]mock.CacheItem{}
+
+	conf := NewConfig()
+	conf.Cache = "foocache"
+
+	qA, err := Open(conf, mgr)
+	require.NoError(t, err)
+
+	qB, err := Open(conf, mgr)
+	require.NoError(t, err)
+
+	for _, q := range []*Quarantine{qA, qB, qA, qB} {
+		require.NoError(t, q.add(ctx, "foo", message.QuickBatch([][]byte{[]byte("hello")}), errors.New("nope")))
+	}
+
+	entries, err := qB.Entries(ctx)
+	require.NoError(t, err)
+	require.Len(t, entries, 4)
+	for i, e := range entries {
+		assert.Equal(t, uint64(i+1), e.Seq)
+	}
+
+	require.NoError(t, qA.Delete(ctx, entries[1].ID))
+
+	page, err := qA.List(ctx, 1, 2)
+	require.NoError(t, err)
+	require.Len(t, page.Entries, 1)
+	assert.Equal(t, entries[0].ID, page.Entries[0].ID)
+	assert.Equal(t, uint64(3), page.Next)
+
+	page, err = qA.List(ctx, page.Next, 2)
+	require.NoError(t, err)
+	require.Len(t, page.Entries, 2)
+	assert.Equal(t, entries[2].ID, page.Entries[0].ID)
+	assert.Equal(t, entries[3].ID, page.Entries[1].ID)
+
+	page, err = qA.List(ctx, page.Next, 2)
+	require.NoError(t, err)
+	assert.Empty(t, page.Entries)
+	assert.Zero(t, page.Next)
+
+	// Deleted slots are never claimed again.
+	require.NoError(t, qA.add(ctx, "foo", message.QuickBatch([][]byte{[]byte("hello")}), errors.New("nope")))
+	entries, err = qA.Entries(ctx)
+	require.NoError(t, err)
+	require.Len(t, entries, 4)
+	assert.Equal(t, uint64(5), entries[3].Seq)
+
+	w := httptest.NewRecorder()
+	qA.Handler()(w, httptest.NewRequest(http.MethodGet, "/quarantine?limit=0", nil))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,66 @@
+// Package wrap provides helpers for modifying bundle environments so that the
+// components they construct are wrapped with additional behaviour.
+package wrap
+
+import (
+	"github.com/benthosdev/benthos/v4/internal/bundle"
+	"github.com/benthosdev/benthos/v4/internal/component/input"
+	iprocessors "github.com/benthosdev/benthos/v4/internal/component/input/processors"
+	"github.com/benthosdev/benthos/v4/internal/component/output"
+	oprocessors "github.com/benthosdev/benthos/v4/internal/component/output/processors"
+	"github.com/benthosdev/benthos/v4/internal/component/processor"
+)
+
+// InputFunc wraps an input that has been constructed from a config.
+type InputFunc func(i input.Streamed, conf input.Config, nm bundle.NewManagement) (input.Streamed, error)
+
+// OutputFunc wraps an output that has been constructed from a config.
+type OutputFunc func(o output.Streamed, conf output.Config, nm bundle.NewManagement) (output.Streamed, error)
+
+// Inputs returns a clone of a bundle environment where each input is
+// constructed by the provided environment and then wrapped with a function.
+// The wrapping is applied before any processors of the input are executed,
+// and therefore messages pass through the wrapper before they are processed.
+func Inputs(b *bundle.Environment, fn InputFunc) *bundle.Environment {
+	wrappedEnv := b.Clone()
+	for _, spec := range b.InputDocs() {
+		_ = wrappedEnv.InputAdd(func(iConf input.Config, nm bundle.NewManagement, pcf ...processor.PipelineConstructorFunc) (input.Streamed, error) {
+			pcf = iprocessors.AppendFromConfig(iConf, nm, pcf...)
+			iConf.Processors = nil
+
+			i, err := b.InputInit(iConf, nm)
+			if err != nil {
+				return nil, err
+			}
+			if i, err = fn(i, iConf, nm); err != nil {
+				return nil, err
+			}
+			return input.WrapWithPipelines(i, pcf...)
+		}, spec)
+	}
+	return wrappedEnv
+}
+
+// Outputs returns a clone of a bundle environment where each output is
+// constructed by the provided environment and then wrapped with a function.
+// The wrapping is applied after any processors of the output are executed,
+// and therefore messages pass through the wrapper once they are processed.
+func Outputs(b *bundle.Environment, fn OutputFunc) *bundle.Environment {
+	wrappedEnv := b.Clone()
+	for _, spec := range b.OutputDocs() {
+		_ = wrappedEnv.OutputAdd(func(oConf output.Config, nm bundle.NewManagement, pcf ...processor.PipelineConstructorFunc) (output.Streamed, error) {
+			pcf = oprocessors.AppendFromConfig(oConf, nm, pcf...)
+			oConf.Processors = nil
+
+			o, err := b.OutputInit(oConf, nm)
+			if err != nil {
+				return nil, err
+			}
+			if o, err = fn(o, oConf, nm); err != nil {
+				return nil, err
+			}
+			return output.WrapWithPipelines(o, pcf...)
+		}, spec)
+	}
+	return wrappedEnv
+}
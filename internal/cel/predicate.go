@@ -0,0 +1,124 @@
+// Package cel provides predicates written in the Common Expression Language
+// (CEL) that can be used as an alternative to Bloblang queries.
+package cel
+
+import (
+	"encoding/json"
+	"fmt"
+
+	gcel "github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang/mapping"
+	"github.com/benthosdev/benthos/v4/internal/docs"
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+// Description is a documentation snippet describing the variables available
+// to CEL predicates, which can be appended to the descriptions of fields.
+const Description = "The structured contents of the message are available as the variable `this`, the raw contents as the string `content` and metadata as the string map `meta`."
+
+// FieldSpec returns a documentation field spec for a CEL predicate with a
+// given name and description.
+func FieldSpec(name, description string, examples ...interface{}) docs.FieldSpec {
+	return docs.FieldString(name, description+" "+Description, examples...).HasDefault("").AtVersion("4.3.0")
+}
+
+// Predicate is a compiled CEL expression that resolves to a boolean value
+// when executed against a message.
+type Predicate struct {
+	prg gcel.Program
+}
+
+var env *gcel.Env
+
+func init() {
+	var err error
+	if env, err = gcel.NewEnv(
+		gcel.Variable("this", gcel.DynType),
+		gcel.Variable("content", gcel.StringType),
+		gcel.Variable("meta", gcel.MapType(gcel.StringType, gcel.StringType)),
+		gcel.CrossTypeNumericComparisons(true),
+	); err != nil {
+		panic(err)
+	}
+}
+
+// NewPredicate parses and type checks a CEL expression, returning an error if
+// the expression is invalid or does not resolve to a boolean.
+func NewPredicate(expr string) (*Predicate, error) {
+	ast, issues := env.Compile(expr)
+	if issues != nil && issues.Err() != nil {
+		return nil, issues.Err()
+	}
+	if !gcel.BoolType.IsAssignableType(ast.OutputType()) {
+		return nil, fmt.Errorf("expression must resolve to a bool, got %v", ast.OutputType())
+	}
+	prg, err := env.Program(ast)
+	if err != nil {
+		return nil, err
+	}
+	return &Predicate{prg: prg}, nil
+}
+
+// QueryPart executes the predicate on a particular message index of a batch
+// and returns the boolean result. The variable `this` is only parsed from the
+// message contents when the expression references it.
+func (p *Predicate) QueryPart(index int, msg mapping.Message) (bool, error) {
+	part := msg.Get(index)
+
+	meta := map[string]string{}
+	_ = part.MetaIter(func(k, v string) error {
+		meta[k] = v
+		return nil
+	})
+
+	res, _, err := p.prg.Eval(map[string]interface{}{
+		"this":    func() ref.Val { return structuredVal(part) },
+		"content": string(part.Get()),
+		"meta":    meta,
+	})
+	if err != nil {
+		return false, err
+	}
+	b, ok := res.Value().(bool)
+	if !ok {
+		return false, fmt.Errorf("expected expression to resolve to a bool, got %v", res.Type().TypeName())
+	}
+	return b, nil
+}
+
+func structuredVal(part *message.Part) ref.Val {
+	v, err := part.JSON()
+	if err != nil {
+		return types.NewErr("failed to parse message as JSON: %v", err)
+	}
+	return types.DefaultTypeAdapter.NativeToValue(normaliseNumbers(v))
+}
+
+// normaliseNumbers converts json.Number values into int64 or float64 values
+// as CEL does not recognise them as numbers.
+func normaliseNumbers(v interface{}) interface{} {
+	switch t := v.(type) {
+	case json.Number:
+		if i, err := t.Int64(); err == nil {
+			return i
+		}
+		f, _ := t.Float64()
+		return f
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(t))
+		for k, e := range t {
+			m[k] = normaliseNumbers(e)
+		}
+		return m
+	case []interface{}:
+		s := make([]interface{}, len(t))
+		for i, e := range t {
+			s[i] = normaliseNumbers(e)
+		}
+		return s
+	}
+	return v
+}
@@ -0,0 +1,107 @@
+package cel
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+func TestPredicate(t *testing.T) {
+	tests := []struct {
+		name        string
+		expr        string
+		content     string
+		meta        map[string]string
+		expected    bool
+		errContains string
+	}{
+		{
+			name:     "structured field",
+			expr:     `this.type == "foo"`,
+			content:  `{"type":"foo"}`,
+			expected: true,
+		},
+		{
+			name:     "numeric comparison",
+			expr:     `this.count > 2 && this.count == 3`,
+			content:  `{"count":3}`,
+			expected: true,
+		},
+		{
+			name:     "float comparison",
+			expr:     `this.ratio < 0.5`,
+			content:  `{"ratio":0.75}`,
+			expected: false,
+		},
+		{
+			name:     "list macro",
+			expr:     `this.tags.exists(t, t == "urgent")`,
+			content:  `{"tags":["low","urgent"]}`,
+			expected: true,
+		},
+		{
+			name:     "raw content",
+			expr:     `content.startsWith("hello")`,
+			content:  `hello world`,
+			expected: true,
+		},
+		{
+			name:     "metadata",
+			expr:     `"topic" in meta && meta.topic == "bar"`,
+			content:  `not json`,
+			meta:     map[string]string{"topic": "bar"},
+			expected: true,
+		},
+		{
+			name:        "invalid json",
+			expr:        `this.type == "foo"`,
+			content:     `not json`,
+			errContains: "failed to parse message as JSON",
+		},
+		{
+			name:        "missing field",
+			expr:        `this.nope == "foo"`,
+			content:     `{"type":"foo"}`,
+			errContains: "no such key",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			p, err := NewPredicate(test.expr)
+			require.NoError(t, err)
+
+			part := message.NewPart([]byte(test.content))
+			for k, v := range test.meta {
+				part.MetaSet(k, v)
+			}
+			batch := message.QuickBatch(nil)
+			batch.Append(part)
+
+			res, err := p.QueryPart(0, batch)
+			if test.errContains != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.errContains)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, res)
+		})
+	}
+}
+
+func TestPredicateParseErrors(t *testing.T) {
+	_, err := NewPredicate(`this.type ==`)
+	require.Error(t, err)
+
+	_, err = NewPredicate(`content + "foo"`)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "must resolve to a bool")
+
+	_, err = NewPredicate(`nope == "foo"`)
+	require.Error(t, err)
+}
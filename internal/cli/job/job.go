@@ -0,0 +1,298 @@
+// Package job tracks the progress of a pipeline that runs as a finite batch job
+// with a state file, allowing a job to be resumed after a crash and to not be
+// repeated once it has completed.
+package job
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/internal/log"
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+// Statuses of a job.
+const (
+	StatusRunning     = "running"
+	StatusCompleted   = "completed"
+	StatusInterrupted = "interrupted"
+)
+
+// Summary describes the messages consumed by a run of a job.
+type Summary struct {
+	Read      int64  `json:"read"`
+	Delivered int64  `json:"delivered"`
+	Skipped   int64  `json:"skipped"`
+	Failed    int64  `json:"failed"`
+	Duration  string `json:"duration"`
+}
+
+// State is the persisted state of a job.
+type State struct {
+	Status     string `json:"status"`
+	ConfigHash string `json:"config_hash"`
+
+	// Acknowledged is the number of transactions at the start of the input
+	// that have been acknowledged, which are skipped when the job resumes.
+	Acknowledged int64 `json:"acknowledged"`
+
+	Runs      int       `json:"runs"`
+	StartedAt time.Time `json:"started_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Summary   Summary   `json:"summary"`
+}
+
+// Tracker tracks the transactions of the input of a job, where each
+// transaction is assigned a sequence in the order it is read and the state
+// records how many sequences from the start have been acknowledged.
+//
+// Inputs resend transactions that are nacked, and so the sequence of a nacked
+// transaction is given to the next transaction read with the same payload.
+type Tracker struct {
+	path string
+	log  log.Modular
+
+	mut     sync.Mutex
+	state   State
+	started time.Time
+	resume  int64
+	nextSeq int64
+	done    map[int64]struct{}
+	retries map[uint64][]int64
+	summary Summary
+	dirty   bool
+}
+
+// New creates a tracker of a job with a state file at a path, which is read
+// when it exists. Jobs without a path are tracked in memory only. Returns an
+// error if an existing state was recorded for a different config.
+func New(path, configHash string, logger log.Modular) (*Tracker, error) {
+	t := &Tracker{
+		path:    path,
+		log:     logger,
+		started: time.Now(),
+		done:    map[int64]struct{}{},
+		retries: map[uint64][]int64{},
+	}
+
+	if path != "" {
+		b, err := os.ReadFile(path)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("failed to read job state: %w", err)
+		}
+		if err == nil {
+			if err := json.Unmarshal(b, &t.state); err != nil {
+				return nil, fmt.Errorf("failed to parse job state: %w", err)
+			}
+			if t.state.ConfigHash != configHash {
+				return nil, fmt.Errorf("job state '%v' was recorded for a different config, remove it in order to run the job from the start", path)
+			}
+		}
+	}
+
+	if t.state.Status != StatusCompleted {
+		t.resume = t.state.Acknowledged
+		if t.state.StartedAt.IsZero() {
+			t.state.StartedAt = t.started
+		}
+	}
+	t.state.ConfigHash = configHash
+	return t, nil
+}
+
+// Completed returns whether the state records that the job has already
+// completed.
+func (t *Tracker) Completed() bool {
+	t.mut.Lock()
+	defer t.mut.Unlock()
+	return t.state.Status == StatusCompleted
+}
+
+// Resuming returns the number of transactions that will be skipped as they
+// were acknowledged by a previous run.
+func (t *Tracker) Resuming() int64 {
+	return t.resume
+}
+
+func payloadHash(b *message.Batch) uint64 {
+	h := fnv.New64a()
+	_ = b.Iter(func(i int, p *message.Part) error {
+		_, _ = h.Write(p.Get())
+		_, _ = h.Write([]byte{0})
+		return nil
+	})
+	return h.Sum64()
+}
+
+// assign returns the sequence of a transaction with a payload hash, and whether
+// it should be skipped as it was acknowledged by a previous run.
+func (t *Tracker) assign(b *message.Batch, hash uint64) (seq int64, skip bool) {
+	t.mut.Lock()
+	defer t.mut.Unlock()
+
+	if seqs := t.retries[hash]; len(seqs) > 0 {
+		seq = seqs[0]
+		if len(seqs) > 1 {
+			t.retries[hash] = seqs[1:]
+		} else {
+			delete(t.retries, hash)
+		}
+		return seq, false
+	}
+
+	seq = t.nextSeq
+	t.nextSeq++
+	if seq < t.resume {
+		t.summary.Skipped += int64(b.Len())
+		t.resolve(seq)
+		return seq, true
+	}
+	t.summary.Read += int64(b.Len())
+	return seq, false
+}
+
+// resolve marks a sequence as acknowledged, advancing the acknowledged prefix
+// when possible. Must be called with the mutex held.
+func (t *Tracker) resolve(seq int64) {
+	t.done[seq] = struct{}{}
+	for {
+		if _, exists := t.done[t.state.Acknowledged]; !exists {
+			break
+		}
+		delete(t.done, t.state.Acknowledged)
+		t.state.Acknowledged++
+		t.dirty = true
+	}
+}
+
+func (t *Tracker) ack(seq int64, hash uint64, size int, err error) {
+	t.mut.Lock()
+	defer t.mut.Unlock()
+
+	if err != nil {
+		t.summary.Failed += int64(size)
+		t.retries[hash] = append(t.retries[hash], seq)
+		return
+	}
+	t.summary.Delivered += int64(size)
+	t.resolve(seq)
+}
+
+// Intercept tracks the transactions of an input, acknowledging transactions
+// that were acknowledged by a previous run without passing them on.
+func (t *Tracker) Intercept(in <-chan message.Transaction) <-chan message.Transaction {
+	out := make(chan message.Transaction)
+	go func() {
+		defer close(out)
+		for tran := range in {
+			tran := tran
+
+			// The payload is hashed before it is processed, as processors may
+			// modify it before the transaction is acknowledged.
+			hash, size := payloadHash(tran.Payload), tran.Payload.Len()
+			seq, skip := t.assign(tran.Payload, hash)
+			if skip {
+				if err := tran.Ack(context.Background(), nil); err != nil {
+					t.log.Errorf("Failed to acknowledge skipped message: %v\n", err)
+				}
+				continue
+			}
+
+			tracked := message.NewTransactionFunc(tran.Payload, func(ctx context.Context, err error) error {
+				t.ack(seq, hash, size, err)
+				return tran.Ack(ctx, err)
+			})
+			out <- *tracked.WithContext(tran.Context())
+		}
+	}()
+	return out
+}
+
+// Persist writes the state at an interval whenever it has changed, until the
+// returned func is called.
+func (t *Tracker) Persist(interval time.Duration) (stop func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := t.write(StatusRunning, false); err != nil {
+					t.log.Errorf("Failed to write job state: %v\n", err)
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// Finish records the final state of the run and returns its summary.
+func (t *Tracker) Finish(completed bool) (Summary, error) {
+	status := StatusInterrupted
+	if completed {
+		status = StatusCompleted
+	}
+	err := t.write(status, true)
+
+	t.mut.Lock()
+	summary := t.state.Summary
+	t.mut.Unlock()
+	return summary, err
+}
+
+func (t *Tracker) write(status string, final bool) error {
+	t.mut.Lock()
+	if !t.dirty && !final && t.state.Status == status {
+		t.mut.Unlock()
+		return nil
+	}
+	t.dirty = false
+	t.state.Status = status
+	if final {
+		t.state.Runs++
+	}
+	t.state.UpdatedAt = time.Now()
+	t.state.Summary = t.summary
+	t.state.Summary.Duration = time.Since(t.started).Round(time.Millisecond).String()
+	b, err := json.MarshalIndent(t.state, "", "  ")
+	t.mut.Unlock()
+
+	if err != nil || t.path == "" {
+		return err
+	}
+
+	// The state is written to a temporary file first so that a crash never
+	// leaves a partially written state behind.
+	tmp, err := os.CreateTemp(filepath.Dir(t.path), filepath.Base(t.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err = tmp.Write(append(b, '\n')); err == nil {
+		err = tmp.Sync()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), t.path)
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+	}
+	return err
+}
@@ -0,0 +1,152 @@
+package job
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/log"
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+type testInput struct {
+	t   *testing.T
+	in  chan message.Transaction
+	out <-chan message.Transaction
+}
+
+func newTestInput(t *testing.T, tracker *Tracker) *testInput {
+	in := make(chan message.Transaction)
+	return &testInput{
+		t:   t,
+		in:  in,
+		out: tracker.Intercept(in),
+	}
+}
+
+// send writes a message to the intercepted input and returns the transaction
+// passed on, or nil if the message was skipped.
+func (i *testInput) send(content string) *message.Transaction {
+	i.t.Helper()
+
+	// Acknowledgements are signalled per message so that a skipped message
+	// isn't confused with the later acknowledgement of a prior message.
+	acked := make(chan struct{}, 1)
+	i.in <- message.NewTransactionFunc(message.QuickBatch([][]byte{[]byte(content)}), func(ctx context.Context, err error) error {
+		if err == nil {
+			acked <- struct{}{}
+		}
+		return nil
+	})
+	select {
+	case tran := <-i.out:
+		return &tran
+	case <-acked:
+		return nil
+	}
+}
+
+func TestTrackerResume(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "job.json")
+
+	tracker, err := New(path, "foo", log.Noop())
+	require.NoError(t, err)
+	assert.False(t, tracker.Completed())
+	assert.Equal(t, int64(0), tracker.Resuming())
+
+	input := newTestInput(t, tracker)
+	var trans []*message.Transaction
+	for _, c := range []string{"a", "b", "c", "d"} {
+		tran := input.send(c)
+		require.NotNil(t, tran)
+		trans = append(trans, tran)
+	}
+
+	// Acknowledgements out of order only advance the contiguous prefix.
+	require.NoError(t, trans[0].Ack(context.Background(), nil))
+	require.NoError(t, trans[2].Ack(context.Background(), nil))
+	assert.Equal(t, int64(1), tracker.state.Acknowledged)
+
+	// A nacked transaction keeps its sequence when it is resent.
+	require.NoError(t, trans[1].Ack(context.Background(), errors.New("nope")))
+	retry := input.send("b")
+	require.NotNil(t, retry)
+	require.NoError(t, retry.Ack(context.Background(), nil))
+	assert.Equal(t, int64(3), tracker.state.Acknowledged)
+
+	summary, err := tracker.Finish(false)
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), summary.Read)
+	assert.Equal(t, int64(3), summary.Delivered)
+	assert.Equal(t, int64(1), summary.Failed)
+
+	// The next run skips messages that were acknowledged.
+	tracker, err = New(path, "foo", log.Noop())
+	require.NoError(t, err)
+	assert.False(t, tracker.Completed())
+	assert.Equal(t, int64(3), tracker.Resuming())
+
+	input = newTestInput(t, tracker)
+	for _, c := range []string{"a", "b", "c"} {
+		assert.Nil(t, input.send(c))
+	}
+	tran := input.send("d")
+	require.NotNil(t, tran)
+	require.NoError(t, tran.Ack(context.Background(), nil))
+	close(input.in)
+
+	summary, err = tracker.Finish(true)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), summary.Read)
+	assert.Equal(t, int64(1), summary.Delivered)
+	assert.Equal(t, int64(3), summary.Skipped)
+
+	tracker, err = New(path, "foo", log.Noop())
+	require.NoError(t, err)
+	assert.True(t, tracker.Completed())
+	assert.Equal(t, int64(4), tracker.state.Acknowledged)
+	assert.Equal(t, 2, tracker.state.Runs)
+}
+
+func TestTrackerConfigMismatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "job.json")
+
+	tracker, err := New(path, "foo", log.Noop())
+	require.NoError(t, err)
+	_, err = tracker.Finish(false)
+	require.NoError(t, err)
+
+	_, err = New(path, "bar", log.Noop())
+	require.Error(t, err)
+}
+
+func TestTrackerPersist(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "job.json")
+
+	tracker, err := New(path, "foo", log.Noop())
+	require.NoError(t, err)
+
+	input := newTestInput(t, tracker)
+	tran := input.send("a")
+	require.NotNil(t, tran)
+	require.NoError(t, tran.Ack(context.Background(), nil))
+
+	require.NoError(t, tracker.write(StatusRunning, false))
+
+	state, err := New(path, "foo", log.Noop())
+	require.NoError(t, err)
+	assert.Equal(t, StatusRunning, state.state.Status)
+	assert.Equal(t, int64(1), state.Resuming())
+
+	// Temporary files are renamed over the state.
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "job.json", entries[0].Name())
+}
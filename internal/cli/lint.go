@@ -124,15 +124,35 @@ Exits with a status code 1 if any linting errors are detected:
   benthos lint ./configs/...
 
 If a path ends with '...' then Benthos will walk the target and lint any
-files with the .yaml or .yml extension.`[1:],
+files with the .yaml or .yml extension.
+
+With the --server flag Benthos instead serves an HTTP endpoint at /lint that
+accepts a config as the body of a POST request and responds with the lint
+results as JSON:
+
+  benthos lint --server --server-address 0.0.0.0:4195`[1:],
 		Flags: []cli.Flag{
 			&cli.BoolFlag{
 				Name:  "deprecated",
 				Value: false,
 				Usage: "Print linting errors for the presence of deprecated fields.",
 			},
+			&cli.BoolFlag{
+				Name:  "server",
+				Value: false,
+				Usage: "Serve an HTTP endpoint for linting configs instead of linting files.",
+			},
+			&cli.StringFlag{
+				Name:  "server-address",
+				Value: "localhost:4195",
+				Usage: "The address to bind the lint server to.",
+			},
 		},
 		Action: func(c *cli.Context) error {
+			if c.Bool("server") {
+				return runLintServer(c.String("server-address"))
+			}
+
 			targets, err := ifilepath.GlobsAndSuperPaths(c.Args().Slice(), "yaml", "yml")
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "Lint paths error: %v\n", err)
@@ -0,0 +1,37 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/benthosdev/benthos/v4/internal/config"
+)
+
+func runLintServer(bindAddress string) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/lint", config.LintHandler())
+
+	server := http.Server{
+		Addr:    bindAddress,
+		Handler: mux,
+	}
+
+	go func() {
+		sigChan := make(chan os.Signal, 1)
+		signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+
+		// Wait for termination signal
+		<-sigChan
+		_ = server.Shutdown(context.Background())
+	}()
+
+	fmt.Fprintf(os.Stderr, "Serving lint endpoint at: http://%v/lint\n", bindAddress)
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		return fmt.Errorf("failed to listen and serve: %w", err)
+	}
+	return nil
+}
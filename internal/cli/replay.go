@@ -0,0 +1,501 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/urfave/cli/v2"
+	"gopkg.in/yaml.v3"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang/mapping"
+	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
+	"github.com/benthosdev/benthos/v4/internal/bundle"
+	"github.com/benthosdev/benthos/v4/internal/bundle/quarantine"
+	"github.com/benthosdev/benthos/v4/internal/component/input"
+	"github.com/benthosdev/benthos/v4/internal/config"
+	"github.com/benthosdev/benthos/v4/internal/log"
+	"github.com/benthosdev/benthos/v4/internal/manager"
+	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/stream"
+)
+
+const replayPipe = "benthos_replay"
+
+func replayCliCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "replay",
+		Usage: "Replay quarantined or dead lettered messages into a config",
+		Description: `
+Reads messages from either the quarantine store of a config or the input
+described by a source config file (such as a dead letter topic or bucket),
+filters them by a time range and a Bloblang query, and re-injects them into
+the pipeline and output of the target config at a controlled rate:
+
+  benthos -c ./config.yaml replay --since 2022-01-01T00:00:00Z
+  benthos -c ./config.yaml replay --filter 'meta("kafka_topic") == "orders"' --rate 100
+  benthos -c ./config.yaml replay --source ./dlq.yaml --timestamp 'root = meta("kafka_timestamp_unix")'
+
+When the source is the quarantine the config provides the quarantine settings
+and cache resources, and time ranges apply to the time at which messages were
+quarantined. Entries can be removed from the quarantine once all of their
+messages have been replayed with the --delete flag.
+
+A source config file contains a single input config, and messages read from it
+are acknowledged once they have either been delivered or filtered out. Time
+ranges of input sources are applied to the result of the --timestamp mapping,
+which should resolve to either a unix timestamp or an RFC 3339 string.
+
+The input of the target config is replaced, but its processors are kept, and
+therefore replayed messages are processed the same as when first consumed. Use
+--dry-run to print the messages that would be replayed as JSON lines instead.`[1:],
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "source",
+				Value: "quarantine",
+				Usage: "Either 'quarantine' or a path to a YAML file containing an input config to read messages from.",
+			},
+			&cli.StringFlag{
+				Name:  "since",
+				Value: "",
+				Usage: "An optional RFC 3339 timestamp, messages older than which are skipped.",
+			},
+			&cli.StringFlag{
+				Name:  "until",
+				Value: "",
+				Usage: "An optional RFC 3339 timestamp, messages newer than which are skipped.",
+			},
+			&cli.StringFlag{
+				Name:  "timestamp",
+				Value: "",
+				Usage: "A Bloblang mapping that resolves the timestamp of messages read from an input source.",
+			},
+			&cli.StringFlag{
+				Name:  "filter",
+				Value: "",
+				Usage: "An optional Bloblang query, messages for which it does not resolve to true are skipped.",
+			},
+			&cli.Float64Flag{
+				Name:  "rate",
+				Value: 0,
+				Usage: "The maximum number of messages to replay per second, zero means unlimited.",
+			},
+			&cli.BoolFlag{
+				Name:  "delete",
+				Value: false,
+				Usage: "Delete quarantine entries once all of their messages have been replayed.",
+			},
+			&cli.BoolFlag{
+				Name:  "dry-run",
+				Value: false,
+				Usage: "Print the messages that would be replayed instead of replaying them.",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			os.Exit(cmdReplay(c))
+			return nil
+		},
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// replayBatch is a batch of messages read from a replay source, where ack is
+// called once the batch has either been delivered or discarded. The timestamp
+// is zero when messages of the batch are timestamped individually.
+type replayBatch struct {
+	msg       *message.Batch
+	timestamp time.Time
+	ack       func(ctx context.Context, partial bool, err error) error
+}
+
+type replaySource interface {
+	next(ctx context.Context) (*replayBatch, error)
+	close()
+}
+
+type replayQuarantineSource struct {
+	q       *quarantine.Quarantine
+	entries []quarantine.Entry
+	delete  bool
+	log     log.Modular
+}
+
+func newReplayQuarantineSource(ctx context.Context, conf quarantine.Config, mgr bundle.NewManagement, filter *replayFilter, del bool) (*replayQuarantineSource, error) {
+	q, err := quarantine.Open(conf, mgr)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := q.Entries(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read quarantine entries: %w", err)
+	}
+	s := &replayQuarantineSource{q: q, delete: del, log: mgr.Logger()}
+	for _, e := range entries {
+		if filter.inRange(e.Timestamp) {
+			s.entries = append(s.entries, e)
+		}
+	}
+	return s, nil
+}
+
+func (s *replayQuarantineSource) next(ctx context.Context) (*replayBatch, error) {
+	for len(s.entries) > 0 {
+		id := s.entries[0].ID
+		s.entries = s.entries[1:]
+
+		entry, err := s.q.Get(ctx, id)
+		if errors.Is(err, quarantine.ErrEntryNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read quarantine entry '%v': %w", id, err)
+		}
+		return &replayBatch{
+			msg:       entry.Batch(),
+			timestamp: entry.Timestamp,
+			ack: func(ctx context.Context, partial bool, err error) error {
+				if err != nil || partial || !s.delete {
+					return nil
+				}
+				if err := s.q.Delete(ctx, id); err != nil {
+					s.log.Errorf("Failed to delete quarantine entry '%v': %v\n", id, err)
+				}
+				return nil
+			},
+		}, nil
+	}
+	return nil, io.EOF
+}
+
+func (s *replayQuarantineSource) close() {}
+
+type replayInputSource struct {
+	in input.Streamed
+}
+
+func newReplayInputSource(path string, mgr bundle.NewManagement) (*replayInputSource, error) {
+	confBytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	conf := input.NewConfig()
+	if err := yaml.Unmarshal(config.ReplaceEnvVariables(confBytes), &conf); err != nil {
+		return nil, fmt.Errorf("failed to parse source config: %w", err)
+	}
+	in, err := mgr.NewInput(conf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create source input: %w", err)
+	}
+	return &replayInputSource{in: in}, nil
+}
+
+func (s *replayInputSource) next(ctx context.Context) (*replayBatch, error) {
+	select {
+	case t, open := <-s.in.TransactionChan():
+		if !open {
+			return nil, io.EOF
+		}
+		return &replayBatch{
+			msg: t.Payload,
+			ack: func(ctx context.Context, partial bool, err error) error {
+				return t.Ack(ctx, err)
+			},
+		}, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (s *replayInputSource) close() {
+	s.in.CloseAsync()
+	_ = s.in.WaitForClose(time.Second * 10)
+}
+
+//------------------------------------------------------------------------------
+
+type replayFilter struct {
+	since, until time.Time
+	timestamp    *mapping.Executor
+	query        *mapping.Executor
+}
+
+func (f *replayFilter) inRange(t time.Time) bool {
+	if !f.since.IsZero() && t.Before(f.since) {
+		return false
+	}
+	if !f.until.IsZero() && t.After(f.until) {
+		return false
+	}
+	return true
+}
+
+// match returns whether a message of a batch should be replayed, where
+// batchTimestamp is zero when the timestamp of the message should be resolved
+// with the timestamp mapping.
+func (f *replayFilter) match(index int, msg *message.Batch, batchTimestamp time.Time) (bool, error) {
+	if batchTimestamp.IsZero() && f.timestamp != nil {
+		p, err := f.timestamp.MapPart(index, msg)
+		if err != nil {
+			return false, fmt.Errorf("failed to resolve timestamp: %w", err)
+		}
+		if p == nil {
+			return false, errors.New("timestamp mapping resulted in a deleted message")
+		}
+		var v interface{}
+		if v, err = p.JSON(); err != nil {
+			v = string(p.Get())
+		}
+		ts, err := query.IGetTimestamp(v)
+		if err != nil {
+			return false, fmt.Errorf("failed to parse timestamp: %w", err)
+		}
+		if !f.inRange(ts) {
+			return false, nil
+		}
+	}
+	if f.query == nil {
+		return true, nil
+	}
+	return f.query.QueryPart(index, msg)
+}
+
+// replayPacer limits the rate at which messages are replayed.
+type replayPacer struct {
+	interval time.Duration
+	next     time.Time
+}
+
+func (p *replayPacer) wait(ctx context.Context, n int) error {
+	if p.interval <= 0 {
+		return nil
+	}
+	now := time.Now()
+	if p.next.Before(now) {
+		p.next = now
+	}
+	wait := time.Until(p.next)
+	p.next = p.next.Add(p.interval * time.Duration(n))
+	if wait <= 0 {
+		return nil
+	}
+	select {
+	case <-time.After(wait):
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return nil
+}
+
+func printReplayed(msg *message.Batch) error {
+	return msg.Iter(func(i int, p *message.Part) error {
+		meta := map[string]string{}
+		_ = p.MetaIter(func(k, v string) error {
+			meta[k] = v
+			return nil
+		})
+		lineBytes, err := json.Marshal(map[string]interface{}{
+			"content":  string(p.Get()),
+			"metadata": meta,
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(lineBytes))
+		return nil
+	})
+}
+
+//------------------------------------------------------------------------------
+
+func cmdReplay(c *cli.Context) int {
+	_, _, confReader := readConfig(c.String("config"), false, c.StringSlice("resources"), nil, c.StringSlice("set"))
+	conf := config.New()
+	if _, err := confReader.Read(&conf); err != nil {
+		fmt.Fprintf(os.Stderr, "Configuration file read error: %v\n", err)
+		return 1
+	}
+
+	logger, err := log.New(os.Stderr, conf.Logger)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create logger: %v\n", err)
+		return 1
+	}
+
+	mgr, err := manager.New(conf.ResourceConfig, manager.OptSetLogger(logger))
+	if err != nil {
+		logger.Errorf("Failed to create resources: %v\n", err)
+		return 1
+	}
+	defer func() {
+		mgr.CloseAsync()
+		_ = mgr.WaitForClose(time.Second * 10)
+	}()
+
+	filter := &replayFilter{}
+	for _, t := range []struct {
+		flag   string
+		target *time.Time
+	}{
+		{"since", &filter.since},
+		{"until", &filter.until},
+	} {
+		if v := c.String(t.flag); v != "" {
+			if *t.target, err = time.Parse(time.RFC3339Nano, v); err != nil {
+				logger.Errorf("Failed to parse --%v: %v\n", t.flag, err)
+				return 1
+			}
+		}
+	}
+	if m := c.String("timestamp"); m != "" {
+		if filter.timestamp, err = mgr.BloblEnvironment().NewMapping(m); err != nil {
+			logger.Errorf("Failed to parse --timestamp: %v\n", err)
+			return 1
+		}
+	}
+	if m := c.String("filter"); m != "" {
+		if filter.query, err = mgr.BloblEnvironment().NewMapping(m); err != nil {
+			logger.Errorf("Failed to parse --filter: %v\n", err)
+			return 1
+		}
+	}
+
+	ctx, done := signal.NotifyContext(optContext, os.Interrupt, syscall.SIGTERM)
+	defer done()
+
+	var src replaySource
+	if sourcePath := c.String("source"); sourcePath == "quarantine" {
+		src, err = newReplayQuarantineSource(ctx, conf.Quarantine, mgr, filter, c.Bool("delete"))
+	} else {
+		if filter.timestamp == nil && !(filter.since.IsZero() && filter.until.IsZero()) {
+			logger.Errorln("A --timestamp mapping is required in order to filter input sources by time")
+			return 1
+		}
+		src, err = newReplayInputSource(sourcePath, mgr)
+	}
+	if err != nil {
+		logger.Errorf("Failed to create replay source: %v\n", err)
+		return 1
+	}
+	defer src.close()
+
+	var tranChan chan message.Transaction
+	var strm *stream.Type
+	if !c.Bool("dry-run") {
+		tranChan = make(chan message.Transaction)
+		mgr.SetPipe(replayPipe, tranChan)
+
+		inConf := input.NewConfig()
+		inConf.Type = "inproc"
+		inConf.Inproc = replayPipe
+		inConf.Processors = conf.Input.Processors
+		conf.Input = inConf
+
+		if strm, err = stream.New(conf.Config, mgr); err != nil {
+			logger.Errorf("Failed to create target stream: %v\n", err)
+			return 1
+		}
+	}
+
+	pacer := &replayPacer{}
+	if rate := c.Float64("rate"); rate > 0 {
+		pacer.interval = time.Duration(float64(time.Second) / rate)
+	}
+
+	var pending sync.WaitGroup
+	var replayed, skipped, failed int64
+
+replayLoop:
+	for {
+		b, err := src.next(ctx)
+		if err != nil {
+			if !errors.Is(err, io.EOF) && ctx.Err() == nil {
+				logger.Errorf("Failed to read from replay source: %v\n", err)
+			}
+			break
+		}
+
+		var parts []*message.Part
+		_ = b.msg.Iter(func(i int, p *message.Part) error {
+			matched, err := filter.match(i, b.msg, b.timestamp)
+			if err != nil {
+				logger.Warnf("Skipping message: %v\n", err)
+			}
+			if matched {
+				parts = append(parts, p)
+			}
+			return nil
+		})
+		partial := len(parts) < b.msg.Len()
+		atomic.AddInt64(&skipped, int64(b.msg.Len()-len(parts)))
+
+		if len(parts) == 0 {
+			_ = b.ack(ctx, partial, nil)
+			continue
+		}
+		if err := pacer.wait(ctx, len(parts)); err != nil {
+			break
+		}
+
+		replayMsg := message.QuickBatch(nil)
+		replayMsg.SetAll(parts)
+
+		if tranChan == nil {
+			if err := printReplayed(replayMsg); err != nil {
+				logger.Errorf("Failed to print message: %v\n", err)
+			}
+			atomic.AddInt64(&replayed, int64(len(parts)))
+			continue
+		}
+
+		pending.Add(1)
+		select {
+		case tranChan <- message.NewTransactionFunc(replayMsg, func(ctx context.Context, err error) error {
+			defer pending.Done()
+			if err != nil {
+				atomic.AddInt64(&failed, int64(len(parts)))
+				logger.Errorf("Failed to replay messages: %v\n", err)
+			} else {
+				atomic.AddInt64(&replayed, int64(len(parts)))
+			}
+			return b.ack(ctx, partial, err)
+		}):
+		case <-ctx.Done():
+			pending.Done()
+			break replayLoop
+		}
+	}
+
+	if strm != nil {
+		pendingDone := make(chan struct{})
+		go func() {
+			pending.Wait()
+			close(pendingDone)
+		}()
+		select {
+		case <-pendingDone:
+		case <-ctx.Done():
+		}
+		if err := strm.Stop(time.Second * 30); err != nil {
+			logger.Errorf("Failed to cleanly stop target stream: %v\n", err)
+		}
+		mgr.UnsetPipe(replayPipe, tranChan)
+	}
+
+	logger.Infof(
+		"Replayed %v messages, skipped %v and failed to replay %v\n",
+		atomic.LoadInt64(&replayed), atomic.LoadInt64(&skipped), atomic.LoadInt64(&failed),
+	)
+	if atomic.LoadInt64(&failed) > 0 || ctx.Err() != nil {
+		return 1
+	}
+	return 0
+}
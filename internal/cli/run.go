@@ -163,6 +163,16 @@ func Run() {
 			Value:   false,
 			Usage:   "EXPERIMENTAL: watch config files for changes and automatically apply them",
 		},
+		&cli.BoolFlag{
+			Name:  "job",
+			Value: false,
+			Usage: "run the config as a batch job that exits once its input is exhausted, logging a summary and exiting with status 0 when completed or 2 when interrupted",
+		},
+		&cli.StringFlag{
+			Name:  "job.state",
+			Value: "",
+			Usage: "a path to a file recording the progress of a job, which allows an interrupted job to resume and prevents a completed job from running again",
+		},
 	}
 	if len(customFlags) > 0 {
 		flags = append(flags, customFlags...)
@@ -177,7 +187,8 @@ Either run Benthos as a stream processor or choose a command:
   benthos list inputs
   benthos create kafka//file > ./config.yaml
   benthos -c ./config.yaml
-  benthos -r "./production/*.yaml" -c ./config.yaml`[1:],
+  benthos -r "./production/*.yaml" -c ./config.yaml
+  benthos --job --job.state ./import.state.json -c ./import.yaml`[1:],
 		Flags: flags,
 		Before: func(c *cli.Context) error {
 			if dotEnvFile := c.String("env-file"); dotEnvFile != "" {
@@ -232,6 +243,8 @@ Either run Benthos as a stream processor or choose a command:
 				false,
 				false,
 				nil,
+				c.Bool("job"),
+				c.String("job.state"),
 			))
 			return nil
 		},
@@ -273,6 +286,7 @@ variables have been resolved:
 				},
 			},
 			lintCliCommand(),
+			replayCliCommand(),
 			{
 				Name:  "streams",
 				Usage: "Run Benthos in streams mode",
@@ -310,6 +324,8 @@ https://benthos.dev/docs/guides/streams_mode/about`[1:],
 						!c.Bool("no-api"),
 						true,
 						c.Args().Slice(),
+						false,
+						"",
 					))
 					return nil
 				},
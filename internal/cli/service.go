@@ -2,9 +2,9 @@ package cli
 
 import (
 	"context"
+	"crypto/sha256"
 	"errors"
 	"fmt"
-	"io"
 	"net/http"
 	"os"
 	"os/signal"
@@ -15,11 +15,11 @@ import (
 	"time"
 
 	"go.opentelemetry.io/otel/trace"
-	"gopkg.in/natefinch/lumberjack.v2"
 	"gopkg.in/yaml.v3"
 
 	"github.com/benthosdev/benthos/v4/internal/api"
 	"github.com/benthosdev/benthos/v4/internal/bundle"
+	"github.com/benthosdev/benthos/v4/internal/cli/job"
 	"github.com/benthosdev/benthos/v4/internal/component/metrics"
 	"github.com/benthosdev/benthos/v4/internal/config"
 	"github.com/benthosdev/benthos/v4/internal/docs"
@@ -28,6 +28,7 @@ import (
 	"github.com/benthosdev/benthos/v4/internal/manager/mock"
 	"github.com/benthosdev/benthos/v4/internal/stream"
 	strmmgr "github.com/benthosdev/benthos/v4/internal/stream/manager"
+	"github.com/benthosdev/benthos/v4/internal/tunable"
 )
 
 //------------------------------------------------------------------------------
@@ -174,17 +175,20 @@ func initNormalMode(
 	manager *manager.Type,
 	logger log.Modular,
 	stats *metrics.Namespaced,
+	streamOpts ...func(*stream.Type),
 ) (newStream stoppable, stoppedChan chan struct{}) {
 	stoppedChan = make(chan struct{})
 
 	streamInit := func() (stoppable, error) {
 		return stream.New(
 			conf.Config, manager,
-			stream.OptOnClose(func() {
-				if !watching {
-					close(stoppedChan)
-				}
-			}),
+			append([]func(*stream.Type){
+				stream.OptOnClose(func() {
+					if !watching {
+						close(stoppedChan)
+					}
+				}),
+			}, streamOpts...)...,
 		)
 	}
 
@@ -232,7 +236,9 @@ func cmdService(
 	strict, watching, enableStreamsAPI bool,
 	streamsMode bool,
 	streamsPaths []string,
-) int {
+	jobMode bool,
+	jobStatePath string,
+) (exitCode int) {
 	mainPath, inferredMainPath, confReader := readConfig(confPath, streamsMode, resourcesPaths, streamsPaths, confOverrides)
 	conf := config.New()
 
@@ -247,32 +253,14 @@ func cmdService(
 	}
 
 	// Logging and stats aggregation.
-	var logger log.Modular
-
-	if conf.Logger.File.Path != "" {
-		var writer io.Writer
-		if conf.Logger.File.Rotate {
-			writer = &lumberjack.Logger{
-				Filename:   conf.Logger.File.Path,
-				MaxSize:    10,
-				MaxAge:     conf.Logger.File.RotateMaxAge,
-				MaxBackups: 1,
-				Compress:   true,
-			}
-		} else {
-			writer, err = os.OpenFile(conf.Logger.File.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o666)
-		}
-		if err == nil {
-			logger, err = log.NewV2(writer, conf.Logger)
-		}
+	var logger *log.Logger
+
+	// Note: Only log to Stderr if our output is stdout, brokers aren't counted
+	// here as this is only a special circumstance for very basic use cases.
+	if !streamsMode && conf.Output.Type == "stdout" {
+		logger, err = log.New(os.Stderr, conf.Logger)
 	} else {
-		// Note: Only log to Stderr if our output is stdout, brokers aren't counted
-		// here as this is only a special circumstance for very basic use cases.
-		if !streamsMode && conf.Output.Type == "stdout" {
-			logger, err = log.NewV2(os.Stderr, conf.Logger)
-		} else {
-			logger, err = log.NewV2(os.Stdout, conf.Logger)
-		}
+		logger, err = log.New(os.Stdout, conf.Logger)
 	}
 	if err != nil {
 		fmt.Printf("Failed to create logger: %v\n", err)
@@ -299,6 +287,11 @@ func cmdService(
 		return 1
 	}
 
+	if jobMode && (streamsMode || watching) {
+		logger.Errorln("Running a job is not supported in streams mode or with the watcher enabled")
+		return 1
+	}
+
 	// We use a temporary manager with just the logger initialised for metrics
 	// instantiation. Doing this means that metrics plugins will use a global
 	// environment for child plugins and bloblang mappings, which we might want
@@ -349,16 +342,64 @@ func cmdService(
 		logger.Errorf("Failed to initialise API: %v\n", err)
 		return 1
 	}
+	httpServer.RegisterEndpoint(
+		"/lint",
+		"POST: Lint a candidate config provided as the request body and respond with the structured results as JSON. Deprecated fields are reported as errors with the query parameter `deprecated=true`.",
+		config.LintHandler(),
+	)
 
-	// Create resource manager.
-	manager, err := manager.New(
-		conf.ResourceConfig,
+	tunables := tunable.NewRegistry(logger)
+	tunables.Register("logger.level", "The minimum severity level for emitting logs.", conf.Logger.LogLevel, logger.SetLevel)
+	httpServer.RegisterEndpoint(
+		"/tunables",
+		"Lists the config fields that can be changed at runtime with their current values as JSON, a POST request with a JSON object body containing the fields `name` and `value` changes a field.",
+		tunables.Handler(),
+	)
+
+	mgrOpts := []manager.OptFunc{
 		manager.OptSetAPIReg(httpServer),
+		manager.OptSetTunables(tunables),
 		manager.OptSetLogger(logger),
 		manager.OptSetMetrics(stats),
 		manager.OptSetTracer(trac),
 		manager.OptSetStreamsMode(streamsMode),
-	)
+	}
+
+	var configHash string
+	if conf.Lineage.Enabled || jobMode {
+		if hashBytes, err := yaml.Marshal(&sanitNode); err == nil {
+			configHash = fmt.Sprintf("%x", sha256.Sum256(hashBytes))
+		}
+	}
+
+	var jobTracker *job.Tracker
+	var streamOpts []func(*stream.Type)
+	if jobMode {
+		if jobTracker, err = job.New(jobStatePath, configHash, logger); err != nil {
+			logger.Errorf("Failed to initialise job: %v\n", err)
+			return 1
+		}
+		if jobTracker.Completed() {
+			logger.With("path", jobStatePath).Infoln("Job has already completed, remove its state file in order to run it again")
+			return 0
+		}
+		if resuming := jobTracker.Resuming(); resuming > 0 {
+			logger.With("path", jobStatePath).Infof("Resuming job, skipping %v acknowledged transactions\n", resuming)
+		}
+		streamOpts = append(streamOpts, stream.OptInterceptInput(jobTracker.Intercept))
+	}
+
+	env, err := config.WrapEnvironment(bundle.GlobalEnvironment, conf, configHash, logger, httpServer)
+	if err != nil {
+		logger.Errorf("Failed to initialise environment: %v\n", err)
+		return 1
+	}
+	if env != bundle.GlobalEnvironment {
+		mgrOpts = append(mgrOpts, manager.OptSetEnvironment(env))
+	}
+
+	// Create resource manager.
+	manager, err := manager.New(conf.ResourceConfig, mgrOpts...)
 	if err != nil {
 		logger.Errorf("Failed to create resource: %v\n", err)
 		return 1
@@ -371,7 +412,7 @@ func cmdService(
 	if streamsMode {
 		stoppableStream = initStreamsMode(strict, watching, enableStreamsAPI, confReader, manager, logger, stats)
 	} else {
-		stoppableStream, dataStreamClosedChan = initNormalMode(conf, strict, watching, confReader, manager, logger, stats)
+		stoppableStream, dataStreamClosedChan = initNormalMode(conf, strict, watching, confReader, manager, logger, stats, streamOpts...)
 	}
 
 	// Start HTTP server.
@@ -393,6 +434,33 @@ func cmdService(
 		}
 	}
 
+	// A job is finished once the service has shut down, at which point all
+	// messages in flight have been resolved.
+	var jobCompleted bool
+	if jobTracker != nil {
+		stopPersisting := jobTracker.Persist(time.Second)
+		defer func() {
+			stopPersisting()
+			summary, err := jobTracker.Finish(jobCompleted)
+			if err != nil {
+				logger.Errorf("Failed to write job state: %v\n", err)
+			}
+			status := job.StatusCompleted
+			if !jobCompleted {
+				status = job.StatusInterrupted
+				exitCode = 2
+			}
+			logger.With(
+				"status", status,
+				"read", summary.Read,
+				"delivered", summary.Delivered,
+				"skipped", summary.Skipped,
+				"failed", summary.Failed,
+				"duration", summary.Duration,
+			).Infoln("Job summary")
+		}()
+	}
+
 	// Defer clean up.
 	defer func() {
 		go func() {
@@ -443,6 +511,7 @@ func cmdService(
 		logger.Infoln("Received SIGTERM, the service is closing")
 	case <-dataStreamClosedChan:
 		logger.Infoln("Pipeline has terminated. Shutting down the service")
+		jobCompleted = true
 	case <-httpServerClosedChan:
 		logger.Infoln("HTTP Server has terminated. Shutting down the service")
 	case <-optContext.Done():
@@ -0,0 +1,275 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	yaml "gopkg.in/yaml.v3"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang/mapping"
+	"github.com/benthosdev/benthos/v4/internal/component/input"
+	"github.com/benthosdev/benthos/v4/internal/component/processor"
+	"github.com/benthosdev/benthos/v4/internal/config"
+	"github.com/benthosdev/benthos/v4/internal/log"
+	"github.com/benthosdev/benthos/v4/internal/manager"
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+const captureTargetProcessors = "/pipeline/processors"
+
+var errMessageCaptured = errors.New("message was captured for a test definition")
+
+// CaptureConfig contains settings for capturing input messages into a test
+// definition.
+type CaptureConfig struct {
+	// Count is the maximum number of messages to capture.
+	Count int
+
+	// Timeout is the maximum period of time to spend reading messages.
+	Timeout time.Duration
+
+	// Mapping is an optional Bloblang mapping executed on each captured
+	// message, which can be used in order to sanitise messages or, by deleting
+	// them, skip them.
+	Mapping string
+
+	// ResourcesPaths are files containing resources to add to the config.
+	ResourcesPaths []string
+}
+
+type capturedPart struct {
+	Content     *string           `yaml:"content,omitempty"`
+	JSONContent *yaml.Node        `yaml:"json_content,omitempty"`
+	Metadata    map[string]string `yaml:"metadata,omitempty"`
+}
+
+type capturedConditions struct {
+	ContentEquals *string    `yaml:"content_equals,omitempty"`
+	JSONEquals    *yaml.Node `yaml:"json_equals,omitempty"`
+}
+
+type capturedCase struct {
+	Name             string                 `yaml:"name"`
+	TargetProcessors string                 `yaml:"target_processors"`
+	InputBatch       []capturedPart         `yaml:"input_batch"`
+	OutputBatches    [][]capturedConditions `yaml:"output_batches"`
+}
+
+// jsonContentNode returns a YAML node of the contents of a message when they
+// are a JSON object or array, which makes for a more readable test definition.
+func jsonContentNode(b []byte) *yaml.Node {
+	var v interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return nil
+	}
+	switch v.(type) {
+	case map[string]interface{}, []interface{}:
+	default:
+		return nil
+	}
+
+	// Decoding the JSON document as YAML rather than marshalling the value
+	// preserves the order of keys and the precision of numbers.
+	var doc yaml.Node
+	if err := yaml.Unmarshal(b, &doc); err != nil || len(doc.Content) == 0 {
+		return nil
+	}
+	n := doc.Content[0]
+	clearYAMLStyle(n)
+	return n
+}
+
+// clearYAMLStyle removes the flow style and quotes of a node decoded from JSON,
+// scalars are still quoted where necessary in order to preserve their tags.
+func clearYAMLStyle(n *yaml.Node) {
+	n.Style = 0
+	for _, c := range n.Content {
+		clearYAMLStyle(c)
+	}
+}
+
+func newCapturedPart(p *message.Part) capturedPart {
+	var c capturedPart
+	if c.JSONContent = jsonContentNode(p.Get()); c.JSONContent == nil {
+		content := string(p.Get())
+		c.Content = &content
+	}
+	_ = p.MetaIter(func(k, v string) error {
+		if c.Metadata == nil {
+			c.Metadata = map[string]string{}
+		}
+		c.Metadata[k] = v
+		return nil
+	})
+	return c
+}
+
+func newCapturedConditions(p *message.Part) capturedConditions {
+	var c capturedConditions
+	if c.JSONEquals = jsonContentNode(p.Get()); c.JSONEquals == nil {
+		content := string(p.Get())
+		c.ContentEquals = &content
+	}
+	return c
+}
+
+// Capture reads messages from the input of a config and returns a test
+// definition with a case for each message. The expected output of each case is
+// the result of executing the pipeline processors of the config against the
+// message, and therefore the test definition passes until the behaviour of the
+// config changes.
+//
+// Captured messages are not acknowledged, and are rejected once capturing has
+// finished so that inputs that support it are able to deliver them again.
+func Capture(ctx context.Context, configPath string, conf CaptureConfig, logger log.Modular) ([]byte, int, error) {
+	benthosConf := config.New()
+	if _, err := config.ReadFileLinted(configPath, false, &benthosConf); err != nil {
+		return nil, 0, fmt.Errorf("failed to read config '%v': %w", configPath, err)
+	}
+
+	mgrConf := benthosConf.ResourceConfig
+	if err := addResourcesFrom(conf.ResourcesPaths, &mgrConf); err != nil {
+		return nil, 0, err
+	}
+
+	mgr, err := manager.New(mgrConf, manager.OptSetLogger(logger))
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to initialise resources: %w", err)
+	}
+	defer func() {
+		mgr.CloseAsync()
+		_ = mgr.WaitForClose(time.Second * 10)
+	}()
+
+	var sanitise *mapping.Executor
+	if conf.Mapping != "" {
+		if sanitise, err = mgr.BloblEnvironment().NewMapping(conf.Mapping); err != nil {
+			return nil, 0, fmt.Errorf("failed to parse capture mapping: %w", err)
+		}
+	}
+
+	var procs []processor.V1
+	if len(benthosConf.Pipeline.Processors) > 0 {
+		provider := NewProcessorsProvider(
+			configPath,
+			OptAddResourcesPaths(conf.ResourcesPaths),
+			OptProcessorsProviderSetLogger(logger),
+		)
+		if procs, err = provider.Provide(captureTargetProcessors, nil, nil); err != nil {
+			return nil, 0, fmt.Errorf("failed to initialise processors '%v': %w", captureTargetProcessors, err)
+		}
+	}
+
+	if conf.Timeout > 0 {
+		var done func()
+		ctx, done = context.WithTimeout(ctx, conf.Timeout)
+		defer done()
+	}
+
+	parts, err := captureParts(ctx, mgr, benthosConf.Input, conf.Count, sanitise, logger)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(parts) == 0 {
+		return nil, 0, errors.New("no messages were captured")
+	}
+
+	cases := make([]capturedCase, len(parts))
+	for i, p := range parts {
+		cases[i] = capturedCase{
+			Name:             fmt.Sprintf("Captured message %v", i),
+			TargetProcessors: captureTargetProcessors,
+			InputBatch:       []capturedPart{newCapturedPart(p)},
+			OutputBatches:    [][]capturedConditions{},
+		}
+
+		inputMsg := message.QuickBatch(nil)
+		inputMsg.SetAll([]*message.Part{p.Copy()})
+		outputBatches, err := processor.ExecuteAll(procs, inputMsg)
+		if err != nil {
+			return nil, 0, fmt.Errorf("processors resulted in error for message %v: %w", i, err)
+		}
+		for _, b := range outputBatches {
+			var conds []capturedConditions
+			_ = b.Iter(func(_ int, p *message.Part) error {
+				conds = append(conds, newCapturedConditions(p))
+				return nil
+			})
+			cases[i].OutputBatches = append(cases[i].OutputBatches, conds)
+		}
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(map[string]interface{}{
+		"tests": cases,
+	}); err != nil {
+		return nil, 0, err
+	}
+	return buf.Bytes(), len(parts), nil
+}
+
+func captureParts(
+	ctx context.Context,
+	mgr *manager.Type,
+	conf input.Config,
+	count int,
+	sanitise *mapping.Executor,
+	logger log.Modular,
+) ([]*message.Part, error) {
+	in, err := mgr.NewInput(conf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create input: %w", err)
+	}
+
+	var pending []message.Transaction
+	defer func() {
+		in.CloseAsync()
+
+		rejectCtx, done := context.WithTimeout(context.Background(), time.Second*5)
+		defer done()
+		for _, t := range pending {
+			_ = t.Ack(rejectCtx, errMessageCaptured)
+		}
+		_ = in.WaitForClose(time.Second * 10)
+	}()
+
+	var parts []*message.Part
+	for len(parts) < count {
+		var t message.Transaction
+		var open bool
+		select {
+		case t, open = <-in.TransactionChan():
+			if !open {
+				return parts, nil
+			}
+		case <-ctx.Done():
+			return parts, nil
+		}
+		pending = append(pending, t)
+
+		_ = t.Payload.Iter(func(i int, p *message.Part) error {
+			if len(parts) >= count {
+				return nil
+			}
+			if sanitise != nil {
+				var err error
+				if p, err = sanitise.MapPart(i, t.Payload); err != nil {
+					logger.Warnf("Skipping message: capture mapping failed: %v\n", err)
+					return nil
+				}
+				if p == nil {
+					return nil
+				}
+			}
+			parts = append(parts, p.Copy())
+			return nil
+		})
+	}
+	return parts, nil
+}
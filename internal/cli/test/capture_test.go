@@ -0,0 +1,105 @@
+package test_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/cli/test"
+	"github.com/benthosdev/benthos/v4/internal/log"
+
+	_ "github.com/benthosdev/benthos/v4/internal/impl/pure"
+)
+
+func TestCapture(t *testing.T) {
+	testDir := t.TempDir()
+
+	configPath := filepath.Join(testDir, "foo.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte(`
+input:
+  generate:
+    interval: 1ms
+    mapping: |
+      root = { "id": count("TestCapture"), "email": "foo@example.com" }
+      meta kind = "gen"
+pipeline:
+  processors:
+    - bloblang: 'root = if this.id == 3 { deleted() } else { this.id.string() + ": " + this.email }'
+output:
+  drop: {}
+`), 0o644))
+
+	defBytes, captured, err := test.Capture(context.Background(), configPath, test.CaptureConfig{
+		Count:   3,
+		Timeout: time.Second * 30,
+		Mapping: `
+root = this
+root.email = "redacted"
+root = if this.id == 2 { deleted() }
+`,
+	}, log.Noop())
+	require.NoError(t, err)
+	assert.Equal(t, 3, captured)
+
+	assert.Equal(t, `tests:
+  - name: Captured message 0
+    target_processors: /pipeline/processors
+    input_batch:
+      - json_content:
+          email: redacted
+          id: 1
+        metadata:
+          kind: gen
+    output_batches:
+      - - content_equals: '1: redacted'
+  - name: Captured message 1
+    target_processors: /pipeline/processors
+    input_batch:
+      - json_content:
+          email: redacted
+          id: 3
+        metadata:
+          kind: gen
+    output_batches: []
+  - name: Captured message 2
+    target_processors: /pipeline/processors
+    input_batch:
+      - json_content:
+          email: redacted
+          id: 4
+        metadata:
+          kind: gen
+    output_batches:
+      - - content_equals: '4: redacted'
+`, string(defBytes))
+
+	require.NoError(t, os.WriteFile(filepath.Join(testDir, "foo_benthos_test.yaml"), defBytes, 0o644))
+	assert.True(t, test.RunAll([]string{configPath}, "_benthos_test", true, log.Noop(), nil))
+}
+
+func TestCaptureNoMessages(t *testing.T) {
+	testDir := t.TempDir()
+
+	configPath := filepath.Join(testDir, "foo.yaml")
+	require.NoError(t, os.WriteFile(configPath, []byte(`
+input:
+  generate:
+    count: 2
+    interval: ""
+    mapping: 'root = "foo"'
+output:
+  drop: {}
+`), 0o644))
+
+	_, _, err := test.Capture(context.Background(), configPath, test.CaptureConfig{
+		Count:   10,
+		Timeout: time.Millisecond * 100,
+		Mapping: `root = deleted()`,
+	}, log.Noop())
+	require.EqualError(t, err, "no messages were captured")
+}
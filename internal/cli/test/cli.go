@@ -3,6 +3,9 @@ package test
 import (
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/urfave/cli/v2"
 
@@ -23,6 +26,14 @@ fail the process will report the errors and exit with a status code 1.
   benthos test ./foo_configs/*.yaml ./bar_configs/*.yaml
   benthos test ./foo.yaml
 
+A test definition can be generated for a config by capturing messages from its
+input with the --capture flag, where each captured message becomes a test case
+that expects the current output of the pipeline processors. Messages can be
+sanitised (or skipped by deleting them) with a Bloblang mapping:
+
+  benthos test --capture 10 ./foo.yaml
+  benthos test --capture 10 --capture.mapping 'root.email = "redacted"' ./foo.yaml
+
 For more information check out the docs at:
 https://benthos.dev/docs/configuration/unit_testing`[1:],
 		Flags: []cli.Flag{
@@ -31,6 +42,21 @@ https://benthos.dev/docs/configuration/unit_testing`[1:],
 				Value: "",
 				Usage: "allow components to write logs at a provided level to stdout.",
 			},
+			&cli.IntFlag{
+				Name:  "capture",
+				Value: 0,
+				Usage: "capture a number of messages from the input of a config into a new test definition rather than executing tests.",
+			},
+			&cli.StringFlag{
+				Name:  "capture.mapping",
+				Value: "",
+				Usage: "an optional Bloblang mapping for sanitising captured messages, messages that the mapping deletes are skipped.",
+			},
+			&cli.DurationFlag{
+				Name:  "capture.timeout",
+				Value: time.Minute,
+				Usage: "the maximum period of time to spend capturing messages.",
+			},
 		},
 		Action: func(c *cli.Context) error {
 			if len(c.StringSlice("set")) > 0 {
@@ -43,6 +69,14 @@ https://benthos.dev/docs/configuration/unit_testing`[1:],
 				fmt.Printf("Failed to resolve resource glob pattern: %v\n", err)
 				os.Exit(1)
 			}
+			if count := c.Int("capture"); count > 0 {
+				os.Exit(cmdCapture(c, testSuffix, CaptureConfig{
+					Count:          count,
+					Timeout:        c.Duration("capture.timeout"),
+					Mapping:        c.String("capture.mapping"),
+					ResourcesPaths: resourcesPaths,
+				}))
+			}
 			if logLevel := c.String("log"); len(logLevel) > 0 {
 				logConf := log.NewConfig()
 				logConf.LogLevel = logLevel
@@ -62,3 +96,41 @@ https://benthos.dev/docs/configuration/unit_testing`[1:],
 		},
 	}
 }
+
+func cmdCapture(c *cli.Context, testSuffix string, conf CaptureConfig) int {
+	if c.Args().Len() != 1 {
+		fmt.Fprintln(os.Stderr, "Capturing messages requires exactly one config path")
+		return 1
+	}
+	configPath, definitionPath := GetPathPair(c.Args().First(), testSuffix)
+	if _, err := os.Stat(definitionPath); err == nil {
+		fmt.Fprintf(os.Stderr, "Test definition '%v' already exists\n", definitionPath)
+		return 1
+	}
+
+	logger := log.Noop()
+	if logLevel := c.String("log"); len(logLevel) > 0 {
+		logConf := log.NewConfig()
+		logConf.LogLevel = logLevel
+		var err error
+		if logger, err = log.NewV2(os.Stdout, logConf); err != nil {
+			fmt.Printf("Failed to init logger: %v\n", err)
+			return 1
+		}
+	}
+
+	ctx, done := signal.NotifyContext(c.Context, os.Interrupt, syscall.SIGTERM)
+	defer done()
+
+	defBytes, captured, err := Capture(ctx, configPath, conf, logger)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to capture messages: %v\n", err)
+		return 1
+	}
+	if err := os.WriteFile(definitionPath, defBytes, 0o644); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write test definition: %v\n", err)
+		return 1
+	}
+	fmt.Printf("Captured %v messages into test definition '%v'\n", captured, definitionPath)
+	return 0
+}
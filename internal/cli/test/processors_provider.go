@@ -212,6 +212,25 @@ func resolveProcessorsPointer(targetFile, jsonPtr string) (filePath, procPath st
 	return
 }
 
+// addResourcesFrom parses resources from a list of files and adds them to a
+// resource config.
+func addResourcesFrom(paths []string, mgrWrapper *manager.ResourceConfig) error {
+	for _, path := range paths {
+		resourceBytes, _, err := config.ReadFileEnvSwap(path)
+		if err != nil {
+			return fmt.Errorf("failed to parse resources config file '%v': %v", path, err)
+		}
+		extraMgrWrapper := manager.NewResourceConfig()
+		if err = yaml.Unmarshal(resourceBytes, &extraMgrWrapper); err != nil {
+			return fmt.Errorf("failed to parse resources config file '%v': %v", path, err)
+		}
+		if err = mgrWrapper.AddFrom(&extraMgrWrapper); err != nil {
+			return fmt.Errorf("failed to merge resources from '%v': %v", path, err)
+		}
+	}
+	return nil
+}
+
 func (p *ProcessorsProvider) getConfs(jsonPtr string, environment map[string]string, mocks map[string]yaml.Node) (cachedConfig, error) {
 	cacheKey := confTargetID(jsonPtr, environment, mocks)
 
@@ -253,18 +272,8 @@ func (p *ProcessorsProvider) getConfs(jsonPtr string, environment map[string]str
 		return confs, fmt.Errorf("failed to parse config file '%v': %v", targetPath, err)
 	}
 
-	for _, path := range p.resourcesPaths {
-		resourceBytes, _, err := config.ReadFileEnvSwap(path)
-		if err != nil {
-			return confs, fmt.Errorf("failed to parse resources config file '%v': %v", path, err)
-		}
-		extraMgrWrapper := manager.NewResourceConfig()
-		if err = yaml.Unmarshal(resourceBytes, &extraMgrWrapper); err != nil {
-			return confs, fmt.Errorf("failed to parse resources config file '%v': %v", path, err)
-		}
-		if err = mgrWrapper.AddFrom(&extraMgrWrapper); err != nil {
-			return confs, fmt.Errorf("failed to merge resources from '%v': %v", path, err)
-		}
+	if err = addResourcesFrom(p.resourcesPaths, &mgrWrapper); err != nil {
+		return confs, err
 	}
 
 	confs.mgr = mgrWrapper
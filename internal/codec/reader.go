@@ -24,7 +24,7 @@ import (
 var ReaderDocs = docs.FieldString(
 	"codec", "The way in which the bytes of a data source should be converted into discrete messages, codecs are useful for specifying how large files or continuous streams of data might be processed in small chunks rather than loading it all in memory. It's possible to consume lines using a custom delimiter with the `delim:x` codec, where x is the character sequence custom delimiter. Codecs can be chained with `/`, for example a gzip compressed CSV file can be consumed with the codec `gzip/csv`.", "lines", "delim:\t", "delim:foobar", "gzip/csv",
 ).HasAnnotatedOptions(
-	"auto", "EXPERIMENTAL: Attempts to derive a codec for each file based on information such as the extension. For example, a .tar.gz file would be consumed with the `gzip/tar` codec. Defaults to all-bytes.",
+	"auto", "EXPERIMENTAL: Attempts to derive a codec for each file based on information such as the extension. For example, a .tar.gz file would be consumed with the `gzip/tar` codec, and a .jsonl file with the `lines` codec. Inputs that know the content encoding of a file, such as `aws_s3`, also decompress files with a gzip encoding. Defaults to all-bytes.",
 	"all-bytes", "Consume the entire file as a single binary message.",
 	"chunker:x", "Consume the file in chunks of a given number of bytes.",
 	"csv", "Consume structured rows as comma separated values, the first row must be a header row.",
@@ -34,6 +34,7 @@ var ReaderDocs = docs.FieldString(
 	"lines", "Consume the file in segments divided by linebreaks.",
 	"multipart", "Consumes the output of another codec and batches messages together. A batch ends when an empty message is consumed. For example, the codec `lines/multipart` could be used to consume multipart messages where an empty line indicates the end of each batch.",
 	"regex:(?m)^\\d\\d:\\d\\d:\\d\\d", "Consume the file in segments divided by regular expression.",
+	"spool", "Consume the entire file as a single message, where the contents are written to a temporary file rather than held in memory. Components that support streamed payloads, such as the `compress` and `decompress` processors and object storage outputs, operate on the file directly, making this codec suitable for files larger than the available memory.",
 	"tar", "Parse the file as a tar archive, and consume each file of the archive as a message.",
 ).LinterFunc(nil) // Disable default option linter as it doesn't include foo:bar formats.
 
@@ -208,6 +209,10 @@ func partReader(codec string, conf ReaderConfig) (ReaderConstructor, bool, error
 		return func(path string, r io.ReadCloser, fn ReaderAckFn) (Reader, error) {
 			return &allBytesReader{r, fn, false}, nil
 		}, true, nil
+	case "spool":
+		return func(path string, r io.ReadCloser, fn ReaderAckFn) (Reader, error) {
+			return &spoolReader{r, fn, false}, nil
+		}, true, nil
 	case "lines":
 		return func(path string, r io.ReadCloser, fn ReaderAckFn) (Reader, error) {
 			return newLinesReader(conf, r, fn)
@@ -284,24 +289,7 @@ func GetReader(codec string, conf ReaderConfig) (ReaderConstructor, error) {
 
 func autoCodec(conf ReaderConfig) ReaderConstructor {
 	return func(path string, r io.ReadCloser, fn ReaderAckFn) (Reader, error) {
-		codec := "all-bytes"
-		switch filepath.Ext(path) {
-		case ".csv":
-			codec = "csv"
-		case ".csv.gz", ".csv.gzip":
-			codec = "gzip/csv"
-		case ".tar":
-			codec = "tar"
-		case ".tgz":
-			codec = "gzip/tar"
-		}
-		if strings.HasSuffix(path, ".tar.gzip") {
-			codec = "gzip/tar"
-		} else if strings.HasSuffix(path, ".tar.gz") {
-			codec = "gzip/tar"
-		}
-
-		ctor, err := GetReader(codec, conf)
+		ctor, err := GetReader(AutoCodecFor(path, ""), conf)
 		if err != nil {
 			return nil, fmt.Errorf("failed to infer codec: %v", err)
 		}
@@ -309,6 +297,46 @@ func autoCodec(conf ReaderConfig) ReaderConstructor {
 	}
 }
 
+// AutoCodecFor returns the codec that the auto codec would derive for a file
+// from its path and, when known, the content encoding of the source. A gzip
+// content encoding describes the same compression as a .gz extension, and
+// therefore the contents are only decompressed once.
+func AutoCodecFor(path, contentEncoding string) string {
+	path = strings.ToLower(path)
+
+	compressed := false
+	switch strings.ToLower(strings.TrimSpace(contentEncoding)) {
+	case "gzip", "x-gzip":
+		compressed = true
+	}
+	if strings.HasSuffix(path, ".tgz") {
+		return "gzip/tar"
+	}
+	for _, ext := range []string{".gz", ".gzip"} {
+		if strings.HasSuffix(path, ext) {
+			path = strings.TrimSuffix(path, ext)
+			compressed = true
+			break
+		}
+	}
+
+	codec := "all-bytes"
+	switch filepath.Ext(path) {
+	case ".csv":
+		codec = "csv"
+	case ".tsv":
+		codec = "csv:\t"
+	case ".tar":
+		codec = "tar"
+	case ".jsonl", ".ndjson":
+		codec = "lines"
+	}
+	if compressed {
+		codec = "gzip/" + codec
+	}
+	return codec
+}
+
 //------------------------------------------------------------------------------
 
 type allBytesReader struct {
@@ -340,6 +368,43 @@ func (a *allBytesReader) Close(ctx context.Context) error {
 
 //------------------------------------------------------------------------------
 
+type spoolReader struct {
+	i        io.ReadCloser
+	ack      ReaderAckFn
+	consumed bool
+}
+
+func (s *spoolReader) Next(ctx context.Context) ([]*message.Part, ReaderAckFn, error) {
+	if s.consumed {
+		return nil, nil, io.EOF
+	}
+	s.consumed = true
+	spool, err := message.NewSpoolFromReader("", s.i)
+	if err != nil {
+		_ = s.ack(ctx, err)
+		return nil, nil, err
+	}
+	p := message.NewSpooledPart(spool)
+
+	// The spool, along with any spools derived from it by processors, is no
+	// longer needed once the message has been acknowledged.
+	return []*message.Part{p}, func(ctx context.Context, err error) error {
+		defer func() {
+			_ = spool.Remove()
+		}()
+		return s.ack(ctx, err)
+	}, nil
+}
+
+func (s *spoolReader) Close(ctx context.Context) error {
+	if !s.consumed {
+		_ = s.ack(ctx, errors.New("service shutting down"))
+	}
+	return s.i.Close()
+}
+
+//------------------------------------------------------------------------------
+
 type linesReader struct {
 	buf       *bufio.Scanner
 	r         io.ReadCloser
@@ -8,6 +8,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"os"
 	"sync"
 	"testing"
 
@@ -100,10 +101,10 @@ func testReaderSuite(t *testing.T, codec, path string, data []byte, expected ...
 			}
 			p, ackFn, err := r.Next(context.Background())
 			require.NoError(t, err)
-			require.NoError(t, ackFn(context.Background(), nil))
 			require.Len(t, p, 1)
 			assert.Equal(t, exp, string(p[0].Get()))
 			allReads[string(p[0].Get())] = p[0].Get()
+			require.NoError(t, ackFn(context.Background(), nil))
 		}
 
 		_, _, err = r.Next(context.Background())
@@ -136,10 +137,10 @@ func testReaderSuite(t *testing.T, codec, path string, data []byte, expected ...
 		for _, exp := range expected {
 			p, ackFn, err := r.Next(context.Background())
 			require.NoError(t, err)
-			require.NoError(t, ackFn(context.Background(), nil))
 			require.Len(t, p, 1)
 			assert.Equal(t, exp, string(p[0].Get()))
 			allReads[string(p[0].Get())] = p[0].Get()
+			require.NoError(t, ackFn(context.Background(), nil))
 		}
 
 		_, _, err = r.Next(context.Background())
@@ -172,10 +173,10 @@ func testReaderSuite(t *testing.T, codec, path string, data []byte, expected ...
 		for _, exp := range expected {
 			p, ackFn, err := r.Next(context.Background())
 			require.NoError(t, err)
-			require.NoError(t, ackFn(context.Background(), nil))
 			require.Len(t, p, 1)
 			assert.Equal(t, exp, string(p[0].Get()))
 			allReads[string(p[0].Get())] = p[0].Get()
+			require.NoError(t, ackFn(context.Background(), nil))
 		}
 
 		_, _, err = r.Next(context.Background())
@@ -372,6 +373,42 @@ func TestAutoReader(t *testing.T) {
 	testReaderSuite(t, "auto", "foo.csv", data)
 }
 
+func TestAutoCodecFor(t *testing.T) {
+	for _, test := range []struct {
+		path     string
+		encoding string
+		codec    string
+	}{
+		{path: "foo.csv", codec: "csv"},
+		{path: "foo.CSV.gz", codec: "gzip/csv"},
+		{path: "foo.csv.gzip", codec: "gzip/csv"},
+		{path: "foo.tsv", codec: "csv:\t"},
+		{path: "foo.tar.gz", codec: "gzip/tar"},
+		{path: "foo.tgz", codec: "gzip/tar"},
+		{path: "foo.jsonl", codec: "lines"},
+		{path: "foo.ndjson.gz", codec: "gzip/lines"},
+		{path: "foo.json", codec: "all-bytes"},
+		{path: "foo.json", encoding: "gzip", codec: "gzip/all-bytes"},
+		{path: "foo.csv.gz", encoding: "gzip", codec: "gzip/csv"},
+		{path: "foo.jsonl", encoding: "identity", codec: "lines"},
+	} {
+		assert.Equal(t, test.codec, AutoCodecFor(test.path, test.encoding), test.path)
+	}
+}
+
+func TestAutoGzipReader(t *testing.T) {
+	var gzipBuf bytes.Buffer
+	zw := gzip.NewWriter(&gzipBuf)
+	_, _ = zw.Write([]byte("col1,col2,col3\nfoo1,bar1,baz1\nfoo2,bar2,baz2"))
+	zw.Close()
+
+	testReaderSuite(
+		t, "auto", "foo.csv.gz", gzipBuf.Bytes(),
+		`{"col1":"foo1","col2":"bar1","col3":"baz1"}`,
+		`{"col1":"foo2","col2":"bar2","col3":"baz2"}`,
+	)
+}
+
 func TestCSVGzipReader(t *testing.T) {
 	var gzipBuf bytes.Buffer
 	zw := gzip.NewWriter(&gzipBuf)
@@ -405,6 +442,47 @@ func TestAllBytesReader(t *testing.T) {
 	testReaderSuite(t, "all-bytes", "", data, "foo\nbar\nbaz")
 }
 
+func TestSpoolReader(t *testing.T) {
+	data := []byte("foo\nbar\nbaz")
+	testReaderSuite(t, "spool", "", data, "foo\nbar\nbaz")
+
+	ctor, err := GetReader("gzip/spool", NewReaderConfig())
+	require.NoError(t, err)
+
+	var gzipBuf bytes.Buffer
+	zw := gzip.NewWriter(&gzipBuf)
+	_, err = zw.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+
+	r, err := ctor("", noopCloser{&gzipBuf, false}, func(ctx context.Context, err error) error {
+		return nil
+	})
+	require.NoError(t, err)
+
+	p, ackFn, err := r.Next(context.Background())
+	require.NoError(t, err)
+	require.Len(t, p, 1)
+
+	spool := p[0].Spool()
+	require.NotNil(t, spool)
+	assert.Equal(t, int64(len(data)), spool.Size())
+	assert.Equal(t, int64(len(data)), p[0].BodySize())
+
+	br, err := p[0].BodyReader()
+	require.NoError(t, err)
+	streamed, err := io.ReadAll(br)
+	require.NoError(t, err)
+	require.NoError(t, br.Close())
+	assert.Equal(t, data, streamed)
+
+	require.NoError(t, ackFn(context.Background(), nil))
+	_, err = os.Stat(spool.Path())
+	assert.True(t, os.IsNotExist(err))
+
+	require.NoError(t, r.Close(context.Background()))
+}
+
 func TestDelimReader(t *testing.T) {
 	data := []byte("fooXbarXbaz")
 	testReaderSuite(t, "delim:X", "", data, "foo", "bar", "baz")
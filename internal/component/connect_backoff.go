@@ -0,0 +1,127 @@
+package component
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"gopkg.in/yaml.v3"
+)
+
+// ConnectBackoffConfig describes the backoff policy applied between
+// consecutive attempts of an input or output to connect to its target.
+type ConnectBackoffConfig struct {
+	InitialInterval string  `json:"initial_interval" yaml:"initial_interval"`
+	Multiplier      float64 `json:"multiplier" yaml:"multiplier"`
+	MaxInterval     string  `json:"max_interval" yaml:"max_interval"`
+	MaxElapsedTime  string  `json:"max_elapsed_time" yaml:"max_elapsed_time"`
+	FailFast        bool    `json:"fail_fast" yaml:"fail_fast"`
+}
+
+// NewConnectBackoffConfig returns a ConnectBackoffConfig with default values.
+func NewConnectBackoffConfig() ConnectBackoffConfig {
+	return ConnectBackoffConfig{
+		InitialInterval: "500ms",
+		Multiplier:      1.5,
+		MaxInterval:     "1s",
+		MaxElapsedTime:  "0s",
+		FailFast:        false,
+	}
+}
+
+// UnmarshalYAML ensures that fields omitted from a config are given their
+// default values.
+func (c *ConnectBackoffConfig) UnmarshalYAML(value *yaml.Node) error {
+	type confAlias ConnectBackoffConfig
+	aliased := confAlias(NewConnectBackoffConfig())
+	if err := value.Decode(&aliased); err != nil {
+		return err
+	}
+	*c = ConnectBackoffConfig(aliased)
+	return nil
+}
+
+// Get returns a new ConnectBackoff from the config, or an error if the config
+// is invalid.
+func (c ConnectBackoffConfig) Get() (*ConnectBackoff, error) {
+	boff := backoff.NewExponentialBackOff()
+
+	var err error
+	if boff.InitialInterval, err = time.ParseDuration(c.InitialInterval); err != nil {
+		return nil, fmt.Errorf("invalid initial interval: %w", err)
+	}
+	if boff.MaxInterval, err = time.ParseDuration(c.MaxInterval); err != nil {
+		return nil, fmt.Errorf("invalid max interval: %w", err)
+	}
+	if boff.MaxElapsedTime, err = time.ParseDuration(c.MaxElapsedTime); err != nil {
+		return nil, fmt.Errorf("invalid max elapsed time: %w", err)
+	}
+	if c.Multiplier < 1 {
+		return nil, fmt.Errorf("multiplier must be at least 1, got %v", c.Multiplier)
+	}
+	boff.Multiplier = c.Multiplier
+	boff.Reset()
+
+	return &ConnectBackoff{
+		boff:     boff,
+		failFast: c.FailFast,
+	}, nil
+}
+
+// ConnectBackoff determines how long an input or output waits between
+// consecutive attempts to connect to its target, and when to give up.
+type ConnectBackoff struct {
+	boff      *backoff.ExponentialBackOff
+	failFast  bool
+	connected bool
+}
+
+// NewConnectBackoff returns a ConnectBackoff that never gives up, which is the
+// policy used by components that are not configured otherwise.
+func NewConnectBackoff(initialInterval, maxInterval time.Duration) *ConnectBackoff {
+	boff := backoff.NewExponentialBackOff()
+	boff.InitialInterval = initialInterval
+	boff.MaxInterval = maxInterval
+	boff.MaxElapsedTime = 0
+	boff.Reset()
+	return &ConnectBackoff{boff: boff}
+}
+
+// NextBackOff returns the period to wait after a failed connection attempt
+// before trying again, or false if the component should give up.
+func (c *ConnectBackoff) NextBackOff() (time.Duration, bool) {
+	if c.failFast && !c.connected {
+		return 0, false
+	}
+	next := c.boff.NextBackOff()
+	if next == backoff.Stop {
+		return 0, false
+	}
+	return next, true
+}
+
+// Reset must be called after each successful connection attempt.
+func (c *ConnectBackoff) Reset() {
+	c.connected = true
+	c.boff.Reset()
+}
+
+// ConnectBackoffProvider is optionally implemented by the observability APIs
+// provided to inputs and outputs in order to customise the backoff policy
+// applied between their connection attempts.
+type ConnectBackoffProvider interface {
+	// ConnectBackoff returns a new backoff policy, or nil if the component
+	// should use its default policy.
+	ConnectBackoff() *ConnectBackoff
+}
+
+// GetConnectBackoff returns the backoff policy provided by an observability
+// API, or a policy with the given defaults if one isn't provided.
+func GetConnectBackoff(mgr Observability, initialInterval, maxInterval time.Duration) *ConnectBackoff {
+	if p, ok := mgr.(ConnectBackoffProvider); ok {
+		if c := p.ConnectBackoff(); c != nil {
+			return c
+		}
+	}
+	return NewConnectBackoff(initialInterval, maxInterval)
+}
@@ -0,0 +1,60 @@
+package component
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestConnectBackoffConfigDefaults(t *testing.T) {
+	var conf ConnectBackoffConfig
+	require.NoError(t, yaml.Unmarshal([]byte(`max_interval: 10s`), &conf))
+
+	exp := NewConnectBackoffConfig()
+	exp.MaxInterval = "10s"
+	assert.Equal(t, exp, conf)
+}
+
+func TestConnectBackoffConfigErrors(t *testing.T) {
+	for name, fn := range map[string]func(c *ConnectBackoffConfig){
+		"bad initial interval": func(c *ConnectBackoffConfig) { c.InitialInterval = "nope" },
+		"bad max interval":     func(c *ConnectBackoffConfig) { c.MaxInterval = "nope" },
+		"bad max elapsed":      func(c *ConnectBackoffConfig) { c.MaxElapsedTime = "nope" },
+		"bad multiplier":       func(c *ConnectBackoffConfig) { c.Multiplier = 0.5 },
+	} {
+		conf := NewConnectBackoffConfig()
+		fn(&conf)
+		_, err := conf.Get()
+		assert.Error(t, err, name)
+	}
+}
+
+func TestConnectBackoffFailFast(t *testing.T) {
+	conf := NewConnectBackoffConfig()
+	conf.FailFast = true
+
+	boff, err := conf.Get()
+	require.NoError(t, err)
+
+	_, ok := boff.NextBackOff()
+	assert.False(t, ok)
+
+	// Once connected we continue to back off on failed reconnects.
+	boff.Reset()
+	next, ok := boff.NextBackOff()
+	assert.True(t, ok)
+	assert.Greater(t, next, time.Duration(0))
+	assert.LessOrEqual(t, next, time.Second)
+}
+
+func TestConnectBackoffDefaultNeverGivesUp(t *testing.T) {
+	boff := NewConnectBackoff(time.Millisecond, time.Millisecond*10)
+	for i := 0; i < 100; i++ {
+		next, ok := boff.NextBackOff()
+		require.True(t, ok)
+		assert.LessOrEqual(t, next, time.Millisecond*15)
+	}
+}
@@ -18,7 +18,8 @@ import (
 // input.Async component.
 type AsyncReader struct {
 	connected   int32
-	connBackoff backoff.BackOff
+	connBackoff *component.ConnectBackoff
+	readBackoff backoff.BackOff
 
 	allowSkipAcks bool
 
@@ -44,7 +45,8 @@ func NewAsyncReader(
 	boff.MaxElapsedTime = 0
 
 	rdr := &AsyncReader{
-		connBackoff:   boff,
+		connBackoff:   component.GetConnectBackoff(mgr, time.Millisecond*100, time.Second),
+		readBackoff:   boff,
 		allowSkipAcks: allowSkipAcks,
 		typeStr:       typeStr,
 		reader:        r,
@@ -103,8 +105,13 @@ func (r *AsyncReader) loop() {
 				}
 				r.mgr.Logger().Errorf("Failed to connect to %v: %v\n", r.typeStr, err)
 				mFailedConn.Incr(1)
+				wait, ok := r.connBackoff.NextBackOff()
+				if !ok {
+					r.mgr.Logger().Errorf("Giving up connecting to %v, shutting down\n", r.typeStr)
+					return false
+				}
 				select {
-				case <-time.After(r.connBackoff.NextBackOff()):
+				case <-time.After(wait):
 				case <-initConnCtx.Done():
 					return false
 				}
@@ -122,7 +129,16 @@ func (r *AsyncReader) loop() {
 
 	for {
 		readCtx, readDone := r.shutSig.CloseAtLeisureCtx(context.Background())
-		msg, ackFn, err := r.reader.ReadWithContext(readCtx)
+		var msg *message.Batch
+		var ackFn AsyncAckFn
+		var err error
+		if meter, ok := r.mgr.(component.ExecutionMeter); ok {
+			meter.MeterExecution(func() {
+				msg, ackFn, err = r.reader.ReadWithContext(readCtx)
+			})
+		} else {
+			msg, ackFn, err = r.reader.ReadWithContext(readCtx)
+		}
 		readDone()
 
 		// If our reader says it is not connected.
@@ -148,13 +164,13 @@ func (r *AsyncReader) loop() {
 				r.mgr.Logger().Errorf("Failed to read message: %v\n", err)
 			}
 			select {
-			case <-time.After(r.connBackoff.NextBackOff()):
+			case <-time.After(r.readBackoff.NextBackOff()):
 			case <-r.shutSig.CloseAtLeisureChan():
 				return
 			}
 			continue
 		} else {
-			r.connBackoff.Reset()
+			r.readBackoff.Reset()
 			mRcvd.Incr(int64(msg.Len()))
 			r.mgr.Logger().Tracef("Consumed %v messages from '%v'.\n", msg.Len(), r.typeStr)
 		}
@@ -136,6 +136,49 @@ func TestAsyncReaderCantConnect(t *testing.T) {
 	}
 }
 
+type connectBackoffManager struct {
+	*mock.Manager
+	conf component.ConnectBackoffConfig
+}
+
+func (m connectBackoffManager) ConnectBackoff() *component.ConnectBackoff {
+	boff, _ := m.conf.Get()
+	return boff
+}
+
+func TestAsyncReaderCantConnectGiveUp(t *testing.T) {
+	failFastConf := component.NewConnectBackoffConfig()
+	failFastConf.FailFast = true
+
+	maxElapsedConf := component.NewConnectBackoffConfig()
+	maxElapsedConf.InitialInterval = "1ms"
+	maxElapsedConf.MaxInterval = "5ms"
+	maxElapsedConf.MaxElapsedTime = "50ms"
+
+	for name, conf := range map[string]component.ConnectBackoffConfig{
+		"fail fast":   failFastConf,
+		"max elapsed": maxElapsedConf,
+	} {
+		conf := conf
+		t.Run(name, func(t *testing.T) {
+			r, err := input.NewAsyncReader("foo", true, asyncReaderCantConnect{}, connectBackoffManager{
+				Manager: mock.NewManager(),
+				conf:    conf,
+			})
+			require.NoError(t, err)
+
+			// We should give up connecting and close without being asked to.
+			select {
+			case _, open := <-r.TransactionChan():
+				assert.False(t, open)
+			case <-time.After(time.Second * 5):
+				t.Fatal("timed out")
+			}
+			require.NoError(t, r.WaitForClose(time.Second))
+		})
+	}
+}
+
 //------------------------------------------------------------------------------
 
 type asyncReaderCantRead struct {
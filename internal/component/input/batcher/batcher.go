@@ -79,6 +79,15 @@ func (m *Impl) loop() {
 			return
 		}
 
+		// Parts held back by the batch policy belong to the latest
+		// transaction, which is then also acknowledged by the next batch.
+		var carriedTrans []*transaction.Tracked
+		if m.batcher.Count() > 0 && len(pendingTrans) > 0 {
+			splits := pendingTrans[len(pendingTrans)-1].Split(2)
+			pendingTrans[len(pendingTrans)-1] = splits[0]
+			carriedTrans = splits[1:]
+		}
+
 		resChan := make(chan error)
 		select {
 		case m.messagesOut <- message.NewTransaction(sendMsg, resChan):
@@ -107,13 +116,15 @@ func (m *Impl) loop() {
 				done()
 			}
 		}(resChan, pendingTrans)
-		pendingTrans = nil
+		pendingTrans = carriedTrans
 	}
 
 	defer func() {
 		// Final flush of remaining documents.
 		m.log.Debugln("Flushing remaining messages of batch.")
-		flushBatchFn()
+		for m.batcher.Count() > 0 {
+			flushBatchFn()
+		}
 
 		// Wait for all pending acks to resolve.
 		m.log.Debugln("Waiting for pending acks to resolve before shutting down.")
@@ -159,8 +170,11 @@ func (m *Impl) loop() {
 			return
 		}
 
-		if flushBatch {
+		// Parts held back by a key can complete further batches, which are
+		// flushed straight away.
+		for flushBatch {
 			flushBatchFn()
+			flushBatch = m.batcher.Triggered()
 		}
 	}
 }
@@ -363,3 +363,91 @@ func TestBatcherFinalFlush(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+func TestBatcherKeyWithinTransaction(t *testing.T) {
+	for _, test := range []struct {
+		name  string
+		input []string
+		exp   [][]string
+	}{
+		{
+			name:  "distinct keys",
+			input: []string{"a1", "b1", "c1"},
+			exp:   [][]string{{"a1"}, {"b1"}, {"c1"}},
+		},
+		{
+			name:  "repeated keys",
+			input: []string{"a1", "b1", "b2", "a2"},
+			exp:   [][]string{{"a1"}, {"b1", "b2"}, {"a2"}},
+		},
+		{
+			name:  "runs",
+			input: []string{"a1", "a2", "b1", "c1", "c2", "d1"},
+			exp:   [][]string{{"a1", "a2"}, {"b1"}, {"c1", "c2"}, {"d1"}},
+		},
+	} {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			mockInput := &mock.Input{
+				TChan: make(chan message.Transaction),
+			}
+
+			batchConf := batchconfig.NewConfig()
+			batchConf.Key = `content().slice(0, 1)`
+			batchPol, err := policy.New(batchConf, mock.NewManager())
+			require.NoError(t, err)
+
+			b := batcher.New(batchPol, mockInput, log.Noop(), metrics.Noop())
+
+			var in [][]byte
+			for _, s := range test.input {
+				in = append(in, []byte(s))
+			}
+
+			resChan := make(chan error, 1)
+			select {
+			case mockInput.TChan <- message.NewTransaction(message.QuickBatch(in), resChan):
+			case <-time.After(time.Second):
+				t.Fatal("timed out")
+			}
+			mockInput.CloseAsync()
+
+			firstErr := errors.New("first error")
+			for i, exp := range test.exp {
+				select {
+				case tran := <-b.TransactionChan():
+					var batch []string
+					for _, p := range message.GetAllBytes(tran.Payload) {
+						assert.Equal(t, exp[0][0], p[0], "batch contains multiple keys")
+						batch = append(batch, string(p))
+					}
+					assert.Equal(t, exp, batch)
+
+					select {
+					case <-resChan:
+						t.Fatal("transaction acknowledged before all of its batches")
+					default:
+					}
+
+					var ackErr error
+					if i == 0 {
+						ackErr = firstErr
+					}
+					require.NoError(t, tran.Ack(context.Background(), ackErr))
+				case <-time.After(time.Second):
+					t.Fatal("timed out")
+				}
+			}
+
+			select {
+			case res := <-resChan:
+				assert.Equal(t, firstErr, res)
+			case <-time.After(time.Second):
+				t.Fatal("timed out")
+			}
+
+			b.CloseAsync()
+			require.NoError(t, b.WaitForClose(time.Second*5))
+		})
+	}
+}
@@ -5,6 +5,7 @@ import (
 
 	yaml "gopkg.in/yaml.v3"
 
+	"github.com/benthosdev/benthos/v4/internal/component"
 	"github.com/benthosdev/benthos/v4/internal/component/processor"
 	"github.com/benthosdev/benthos/v4/internal/docs"
 )
@@ -33,6 +34,7 @@ type Config struct {
 	HTTPClient        HTTPClientConfig        `json:"http_client" yaml:"http_client"`
 	HTTPServer        HTTPServerConfig        `json:"http_server" yaml:"http_server"`
 	Inproc            InprocConfig            `json:"inproc" yaml:"inproc"`
+	Join              JoinConfig              `json:"join" yaml:"join"`
 	Kafka             KafkaConfig             `json:"kafka" yaml:"kafka"`
 	MQTT              MQTTConfig              `json:"mqtt" yaml:"mqtt"`
 	Nanomsg           NanomsgConfig           `json:"nanomsg" yaml:"nanomsg"`
@@ -53,6 +55,10 @@ type Config struct {
 	Subprocess        SubprocessConfig        `json:"subprocess" yaml:"subprocess"`
 	Websocket         WebsocketConfig         `json:"websocket" yaml:"websocket"`
 	Processors        []processor.Config      `json:"processors" yaml:"processors"`
+	Throttle          *ThrottleConfig         `json:"throttle,omitempty" yaml:"throttle,omitempty"`
+	Labels            map[string]string       `json:"labels,omitempty" yaml:"labels,omitempty"`
+
+	ConnectBackoff *component.ConnectBackoffConfig `json:"connect_backoff,omitempty" yaml:"connect_backoff,omitempty"`
 }
 
 // NewConfig returns a configuration struct fully populated with default values.
@@ -80,6 +86,7 @@ func NewConfig() Config {
 		HTTPClient:        NewHTTPClientConfig(),
 		HTTPServer:        NewHTTPServerConfig(),
 		Inproc:            NewInprocConfig(),
+		Join:              NewJoinConfig(),
 		Kafka:             NewKafkaConfig(),
 		MQTT:              NewMQTTConfig(),
 		Nanomsg:           NewNanomsgConfig(),
@@ -100,6 +107,10 @@ func NewConfig() Config {
 		Subprocess:        NewSubprocessConfig(),
 		Websocket:         NewWebsocketConfig(),
 		Processors:        []processor.Config{},
+		Throttle:          nil,
+		Labels:            nil,
+
+		ConnectBackoff: nil,
 	}
 }
 
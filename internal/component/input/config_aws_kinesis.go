@@ -27,17 +27,34 @@ func NewDynamoDBCheckpointConfig() DynamoDBCheckpointConfig {
 	}
 }
 
+// KinesisEnhancedFanOutConfig contains configuration parameters for consuming
+// Kinesis shards with an enhanced fan-out consumer.
+type KinesisEnhancedFanOutConfig struct {
+	Enabled      bool   `json:"enabled" yaml:"enabled"`
+	ConsumerName string `json:"consumer_name" yaml:"consumer_name"`
+}
+
+// NewKinesisEnhancedFanOutConfig returns a KinesisEnhancedFanOutConfig config
+// struct with default values.
+func NewKinesisEnhancedFanOutConfig() KinesisEnhancedFanOutConfig {
+	return KinesisEnhancedFanOutConfig{
+		Enabled:      false,
+		ConsumerName: "",
+	}
+}
+
 // AWSKinesisConfig is configuration values for the input type.
 type AWSKinesisConfig struct {
 	session.Config  `json:",inline" yaml:",inline"`
-	Streams         []string                 `json:"streams" yaml:"streams"`
-	DynamoDB        DynamoDBCheckpointConfig `json:"dynamodb" yaml:"dynamodb"`
-	CheckpointLimit int                      `json:"checkpoint_limit" yaml:"checkpoint_limit"`
-	CommitPeriod    string                   `json:"commit_period" yaml:"commit_period"`
-	LeasePeriod     string                   `json:"lease_period" yaml:"lease_period"`
-	RebalancePeriod string                   `json:"rebalance_period" yaml:"rebalance_period"`
-	StartFromOldest bool                     `json:"start_from_oldest" yaml:"start_from_oldest"`
-	Batching        batchconfig.Config       `json:"batching" yaml:"batching"`
+	Streams         []string                    `json:"streams" yaml:"streams"`
+	DynamoDB        DynamoDBCheckpointConfig    `json:"dynamodb" yaml:"dynamodb"`
+	EnhancedFanOut  KinesisEnhancedFanOutConfig `json:"enhanced_fan_out" yaml:"enhanced_fan_out"`
+	CheckpointLimit int                         `json:"checkpoint_limit" yaml:"checkpoint_limit"`
+	CommitPeriod    string                      `json:"commit_period" yaml:"commit_period"`
+	LeasePeriod     string                      `json:"lease_period" yaml:"lease_period"`
+	RebalancePeriod string                      `json:"rebalance_period" yaml:"rebalance_period"`
+	StartFromOldest bool                        `json:"start_from_oldest" yaml:"start_from_oldest"`
+	Batching        batchconfig.Config          `json:"batching" yaml:"batching"`
 }
 
 // NewAWSKinesisConfig creates a new Config with default values.
@@ -46,6 +63,7 @@ func NewAWSKinesisConfig() AWSKinesisConfig {
 		Config:          session.NewConfig(),
 		Streams:         []string{},
 		DynamoDB:        NewDynamoDBCheckpointConfig(),
+		EnhancedFanOut:  NewKinesisEnhancedFanOutConfig(),
 		CheckpointLimit: 1024,
 		CommitPeriod:    "5s",
 		LeasePeriod:     "30s",
@@ -6,13 +6,15 @@ import (
 
 // AWSS3SQSConfig contains configuration for hooking up the S3 input with an SQS queue.
 type AWSS3SQSConfig struct {
-	URL          string `json:"url" yaml:"url"`
-	Endpoint     string `json:"endpoint" yaml:"endpoint"`
-	EnvelopePath string `json:"envelope_path" yaml:"envelope_path"`
-	KeyPath      string `json:"key_path" yaml:"key_path"`
-	BucketPath   string `json:"bucket_path" yaml:"bucket_path"`
-	DelayPeriod  string `json:"delay_period" yaml:"delay_period"`
-	MaxMessages  int64  `json:"max_messages" yaml:"max_messages"`
+	URL          string            `json:"url" yaml:"url"`
+	Endpoint     string            `json:"endpoint" yaml:"endpoint"`
+	EnvelopePath string            `json:"envelope_path" yaml:"envelope_path"`
+	KeyPath      string            `json:"key_path" yaml:"key_path"`
+	BucketPath   string            `json:"bucket_path" yaml:"bucket_path"`
+	KeyFilter    string            `json:"key_filter" yaml:"key_filter"`
+	BucketRoles  map[string]string `json:"bucket_roles" yaml:"bucket_roles"`
+	DelayPeriod  string            `json:"delay_period" yaml:"delay_period"`
+	MaxMessages  int64             `json:"max_messages" yaml:"max_messages"`
 }
 
 // NewAWSS3SQSConfig creates a new AWSS3SQSConfig with default values.
@@ -23,6 +25,8 @@ func NewAWSS3SQSConfig() AWSS3SQSConfig {
 		EnvelopePath: "",
 		KeyPath:      "Records.*.s3.object.key",
 		BucketPath:   "Records.*.s3.bucket.name",
+		KeyFilter:    "",
+		BucketRoles:  map[string]string{},
 		DelayPeriod:  "",
 		MaxMessages:  10,
 	}
@@ -34,6 +38,7 @@ type AWSS3Config struct {
 	Bucket             string         `json:"bucket" yaml:"bucket"`
 	Codec              string         `json:"codec" yaml:"codec"`
 	Prefix             string         `json:"prefix" yaml:"prefix"`
+	Suffix             string         `json:"suffix" yaml:"suffix"`
 	ForcePathStyleURLs bool           `json:"force_path_style_urls" yaml:"force_path_style_urls"`
 	DeleteObjects      bool           `json:"delete_objects" yaml:"delete_objects"`
 	SQS                AWSS3SQSConfig `json:"sqs" yaml:"sqs"`
@@ -45,6 +50,7 @@ func NewAWSS3Config() AWSS3Config {
 		Config:             sess.NewConfig(),
 		Bucket:             "",
 		Prefix:             "",
+		Suffix:             "",
 		Codec:              "all-bytes",
 		ForcePathStyleURLs: false,
 		DeleteObjects:      false,
@@ -2,15 +2,27 @@ package input
 
 import (
 	"cloud.google.com/go/pubsub"
+	"github.com/benthosdev/benthos/v4/internal/impl/gcp/auth"
 )
 
 // GCPPubSubConfig contains configuration values for the input type.
 type GCPPubSubConfig struct {
-	ProjectID              string `json:"project" yaml:"project"`
-	SubscriptionID         string `json:"subscription" yaml:"subscription"`
-	MaxOutstandingMessages int    `json:"max_outstanding_messages" yaml:"max_outstanding_messages"`
-	MaxOutstandingBytes    int    `json:"max_outstanding_bytes" yaml:"max_outstanding_bytes"`
-	Sync                   bool   `json:"sync" yaml:"sync"`
+	ProjectID              string                      `json:"project" yaml:"project"`
+	SubscriptionID         string                      `json:"subscription" yaml:"subscription"`
+	MaxOutstandingMessages int                         `json:"max_outstanding_messages" yaml:"max_outstanding_messages"`
+	MaxOutstandingBytes    int                         `json:"max_outstanding_bytes" yaml:"max_outstanding_bytes"`
+	Sync                   bool                        `json:"sync" yaml:"sync"`
+	ExactlyOnce            bool                        `json:"exactly_once" yaml:"exactly_once"`
+	AckExtension           GCPPubSubAckExtensionConfig `json:"ack_extension" yaml:"ack_extension"`
+	Credentials            auth.Config                 `json:"credentials" yaml:"credentials"`
+}
+
+// GCPPubSubAckExtensionConfig contains configuration fields for how the ack
+// deadlines of messages consumed by the GCPPubSub input type are extended.
+type GCPPubSubAckExtensionConfig struct {
+	MaxExtension       string `json:"max_extension" yaml:"max_extension"`
+	MaxExtensionPeriod string `json:"max_extension_period" yaml:"max_extension_period"`
+	MinExtensionPeriod string `json:"min_extension_period" yaml:"min_extension_period"`
 }
 
 // NewGCPPubSubConfig creates a new Config with default values.
@@ -21,5 +33,12 @@ func NewGCPPubSubConfig() GCPPubSubConfig {
 		MaxOutstandingMessages: pubsub.DefaultReceiveSettings.MaxOutstandingMessages,
 		MaxOutstandingBytes:    pubsub.DefaultReceiveSettings.MaxOutstandingBytes,
 		Sync:                   false,
+		ExactlyOnce:            false,
+		AckExtension: GCPPubSubAckExtensionConfig{
+			MaxExtension:       "60m",
+			MaxExtensionPeriod: "0s",
+			MinExtensionPeriod: "0s",
+		},
+		Credentials: auth.New(),
 	}
 }
@@ -0,0 +1,21 @@
+package input
+
+// JoinConfig contains configuration values for the Join input type.
+type JoinConfig struct {
+	Inputs       []Config `json:"inputs" yaml:"inputs"`
+	Key          string   `json:"key" yaml:"key"`
+	Window       string   `json:"window" yaml:"window"`
+	Type         string   `json:"type" yaml:"type"`
+	LateArrivals string   `json:"late_arrivals" yaml:"late_arrivals"`
+}
+
+// NewJoinConfig creates a new JoinConfig with default values.
+func NewJoinConfig() JoinConfig {
+	return JoinConfig{
+		Inputs:       []Config{},
+		Key:          "",
+		Window:       "1m",
+		Type:         "inner",
+		LateArrivals: "drop",
+	}
+}
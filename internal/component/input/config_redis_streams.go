@@ -17,6 +17,30 @@ type RedisStreamsConfig struct {
 	StartFromOldest bool     `json:"start_from_oldest" yaml:"start_from_oldest"`
 	CommitPeriod    string   `json:"commit_period" yaml:"commit_period"`
 	Timeout         string   `json:"timeout" yaml:"timeout"`
+
+	AutoClaim RedisStreamsAutoClaimConfig `json:"auto_claim" yaml:"auto_claim"`
+}
+
+// RedisStreamsAutoClaimConfig contains configuration fields for claiming
+// entries that have been pending within a consumer group for too long.
+type RedisStreamsAutoClaimConfig struct {
+	Enabled          bool   `json:"enabled" yaml:"enabled"`
+	MinIdleTime      string `json:"min_idle_time" yaml:"min_idle_time"`
+	Period           string `json:"period" yaml:"period"`
+	MaxRetries       int64  `json:"max_retries" yaml:"max_retries"`
+	DeadLetterStream string `json:"dead_letter_stream" yaml:"dead_letter_stream"`
+}
+
+// NewRedisStreamsAutoClaimConfig creates a new RedisStreamsAutoClaimConfig
+// with default values.
+func NewRedisStreamsAutoClaimConfig() RedisStreamsAutoClaimConfig {
+	return RedisStreamsAutoClaimConfig{
+		Enabled:          false,
+		MinIdleTime:      "1m",
+		Period:           "10s",
+		MaxRetries:       0,
+		DeadLetterStream: "",
+	}
 }
 
 // NewRedisStreamsConfig creates a new RedisStreamsConfig with default values.
@@ -32,5 +56,7 @@ func NewRedisStreamsConfig() RedisStreamsConfig {
 		StartFromOldest: true,
 		CommitPeriod:    "1s",
 		Timeout:         "1s",
+
+		AutoClaim: NewRedisStreamsAutoClaimConfig(),
 	}
 }
@@ -32,9 +32,41 @@ func NewSequenceShardedJoinConfig() SequenceShardedJoinConfig {
 	}
 }
 
+// SequenceHandoverConfig describes an optional mechanism for handing over
+// from backfill inputs to a final live input once the backfill inputs are
+// exhausted.
+//
+// A watermark is extracted from each message and the highest watermark of the
+// delivered backfill messages becomes the boundary of the handover, which is
+// stored within a cache. Messages of the live input at or below the boundary
+// overlap with the backfill and are deduplicated by a key stored within the
+// same cache.
+type SequenceHandoverConfig struct {
+	Enabled     bool   `json:"enabled" yaml:"enabled"`
+	Watermark   string `json:"watermark" yaml:"watermark"`
+	Key         string `json:"key" yaml:"key"`
+	Cache       string `json:"cache" yaml:"cache"`
+	TTL         string `json:"ttl" yaml:"ttl"`
+	BoundaryKey string `json:"boundary_key" yaml:"boundary_key"`
+}
+
+// NewSequenceHandoverConfig creates a new sequence handover configuration
+// with default values.
+func NewSequenceHandoverConfig() SequenceHandoverConfig {
+	return SequenceHandoverConfig{
+		Enabled:     false,
+		Watermark:   "",
+		Key:         "",
+		Cache:       "",
+		TTL:         "",
+		BoundaryKey: "sequence_handover_boundary",
+	}
+}
+
 // SequenceConfig contains configuration values for the Sequence input type.
 type SequenceConfig struct {
 	ShardedJoin SequenceShardedJoinConfig `json:"sharded_join" yaml:"sharded_join"`
+	Handover    SequenceHandoverConfig    `json:"handover" yaml:"handover"`
 	Inputs      []Config                  `json:"inputs" yaml:"inputs"`
 }
 
@@ -42,6 +74,7 @@ type SequenceConfig struct {
 func NewSequenceConfig() SequenceConfig {
 	return SequenceConfig{
 		ShardedJoin: NewSequenceShardedJoinConfig(),
+		Handover:    NewSequenceHandoverConfig(),
 		Inputs:      []Config{},
 	}
 }
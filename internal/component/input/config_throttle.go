@@ -0,0 +1,30 @@
+package input
+
+// ThrottleConfig contains configuration values for limiting the rate at which
+// an input is consumed, and for pausing consumption during scheduled windows.
+type ThrottleConfig struct {
+	MaxMessagesPerSecond float64             `json:"max_messages_per_second" yaml:"max_messages_per_second"`
+	MaxBytesPerSecond    float64             `json:"max_bytes_per_second" yaml:"max_bytes_per_second"`
+	PauseWindows         []PauseWindowConfig `json:"pause_windows" yaml:"pause_windows"`
+}
+
+// NewThrottleConfig creates a new ThrottleConfig with default values.
+func NewThrottleConfig() ThrottleConfig {
+	return ThrottleConfig{
+		MaxMessagesPerSecond: 0,
+		MaxBytesPerSecond:    0,
+		PauseWindows:         []PauseWindowConfig{},
+	}
+}
+
+// IsNoop returns true if the config does not limit consumption in any way.
+func (c ThrottleConfig) IsNoop() bool {
+	return c.MaxMessagesPerSecond <= 0 && c.MaxBytesPerSecond <= 0 && len(c.PauseWindows) == 0
+}
+
+// PauseWindowConfig describes a recurring window of time during which an input
+// is not consumed.
+type PauseWindowConfig struct {
+	Schedule string `json:"schedule" yaml:"schedule"`
+	Duration string `json:"duration" yaml:"duration"`
+}
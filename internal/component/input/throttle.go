@@ -0,0 +1,352 @@
+package input
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/robfig/cron/v3"
+
+	"github.com/benthosdev/benthos/v4/internal/component"
+	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/shutdown"
+)
+
+type pauseWindow struct {
+	schedule cron.Schedule
+	duration time.Duration
+}
+
+// activeUntil returns the end of the occurrence of the window that contains
+// the provided time, or false if the time falls outside of the window.
+func (w pauseWindow) activeUntil(t time.Time) (time.Time, bool) {
+	start := w.schedule.Next(t.Add(-w.duration))
+	if start.After(t) {
+		return time.Time{}, false
+	}
+	return start.Add(w.duration), true
+}
+
+func parsePauseWindow(conf PauseWindowConfig) (pauseWindow, error) {
+	expr := conf.Schedule
+	if !strings.HasPrefix(expr, "TZ=") && !strings.HasPrefix(expr, "CRON_TZ=") {
+		expr = "TZ=UTC " + expr
+	}
+	parser := cron.NewParser(cron.SecondOptional | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+	schedule, err := parser.Parse(expr)
+	if err != nil {
+		return pauseWindow{}, fmt.Errorf("failed to parse schedule '%v': %w", conf.Schedule, err)
+	}
+	duration, err := time.ParseDuration(conf.Duration)
+	if err != nil {
+		return pauseWindow{}, fmt.Errorf("failed to parse duration '%v': %w", conf.Duration, err)
+	}
+	if duration <= 0 {
+		return pauseWindow{}, errors.New("duration must be greater than zero")
+	}
+	return pauseWindow{schedule: schedule, duration: duration}, nil
+}
+
+//------------------------------------------------------------------------------
+
+// Throttled is an input type that wraps another input and limits the rate at
+// which transactions are consumed from it, and pauses consumption entirely
+// during scheduled windows or when paused manually. Since the wrapped input is
+// simply not read from whilst throttled or paused its connection remains open.
+type Throttled struct {
+	maxMessages float64
+	maxBytes    float64
+	windows     []pauseWindow
+	nowFn       func() time.Time
+
+	inFlight int64
+
+	pauseMut   sync.Mutex
+	resumeChan chan struct{}
+	pauseSig   chan struct{}
+
+	mgr component.Observability
+
+	wrapped Streamed
+	tChan   chan message.Transaction
+	shutSig *shutdown.Signaller
+}
+
+// WrapWithThrottle wraps an input with a throttle that enforces the limits and
+// pause windows of a config.
+func WrapWithThrottle(conf ThrottleConfig, in Streamed, mgr component.Observability) (*Throttled, error) {
+	if conf.MaxMessagesPerSecond < 0 {
+		return nil, errors.New("max_messages_per_second must not be negative")
+	}
+	if conf.MaxBytesPerSecond < 0 {
+		return nil, errors.New("max_bytes_per_second must not be negative")
+	}
+	windows := make([]pauseWindow, 0, len(conf.PauseWindows))
+	for i, wConf := range conf.PauseWindows {
+		w, err := parsePauseWindow(wConf)
+		if err != nil {
+			return nil, fmt.Errorf("pause window %v: %w", i, err)
+		}
+		windows = append(windows, w)
+	}
+
+	t := &Throttled{
+		maxMessages: conf.MaxMessagesPerSecond,
+		maxBytes:    conf.MaxBytesPerSecond,
+		windows:     windows,
+		nowFn:       time.Now,
+		mgr:         mgr,
+		wrapped:     in,
+		pauseSig:    make(chan struct{}, 1),
+		tChan:       make(chan message.Transaction),
+		shutSig:     shutdown.NewSignaller(),
+	}
+	go t.loop()
+	return t, nil
+}
+
+// Pause stops the wrapped input from being consumed until Resume is called.
+func (t *Throttled) Pause() {
+	t.pauseMut.Lock()
+	defer t.pauseMut.Unlock()
+	if t.resumeChan != nil {
+		return
+	}
+	t.resumeChan = make(chan struct{})
+	select {
+	case t.pauseSig <- struct{}{}:
+	default:
+	}
+}
+
+// Resume continues consumption of the wrapped input after a call to Pause.
+func (t *Throttled) Resume() {
+	t.pauseMut.Lock()
+	defer t.pauseMut.Unlock()
+	if t.resumeChan != nil {
+		close(t.resumeChan)
+		t.resumeChan = nil
+	}
+}
+
+// Paused returns whether consumption of the wrapped input has been paused by a
+// call to Pause.
+func (t *Throttled) Paused() bool {
+	t.pauseMut.Lock()
+	defer t.pauseMut.Unlock()
+	return t.resumeChan != nil
+}
+
+// InFlight returns the number of transactions that have been consumed from the
+// wrapped input and are yet to be acknowledged.
+func (t *Throttled) InFlight() int {
+	return int(atomic.LoadInt64(&t.inFlight))
+}
+
+func (t *Throttled) waitForResume() {
+	t.pauseMut.Lock()
+	resumeChan := t.resumeChan
+	t.pauseMut.Unlock()
+	if resumeChan == nil {
+		return
+	}
+	select {
+	case <-resumeChan:
+	case <-t.shutSig.CloseAtLeisureChan():
+	}
+}
+
+// pausedUntil returns the latest time at which the pause windows that are
+// currently active end, or false if none are active.
+func (t *Throttled) pausedUntil(now time.Time) (time.Time, bool) {
+	var until time.Time
+	for _, w := range t.windows {
+		if end, active := w.activeUntil(now); active && end.After(until) {
+			until = end
+		}
+	}
+	return until, !until.IsZero()
+}
+
+// nextPause returns the earliest time after now at which a pause window
+// begins, or false if there are no pause windows.
+func (t *Throttled) nextPause(now time.Time) (time.Time, bool) {
+	var next time.Time
+	for _, w := range t.windows {
+		if start := w.schedule.Next(now); next.IsZero() || start.Before(next) {
+			next = start
+		}
+	}
+	return next, !next.IsZero()
+}
+
+// cost returns the duration of consumption that a batch accounts for under the
+// configured limits.
+func (t *Throttled) cost(batch *message.Batch) time.Duration {
+	var cost time.Duration
+	if t.maxMessages > 0 {
+		cost = time.Duration(float64(batch.Len()) / t.maxMessages * float64(time.Second))
+	}
+	if t.maxBytes > 0 {
+		var size int
+		_ = batch.Iter(func(i int, p *message.Part) error {
+			size += len(p.Get())
+			return nil
+		})
+		if bCost := time.Duration(float64(size) / t.maxBytes * float64(time.Second)); bCost > cost {
+			cost = bCost
+		}
+	}
+	return cost
+}
+
+// waitUntil blocks until the provided time or until the throttle is closed,
+// in which case false is returned.
+func (t *Throttled) waitUntil(until time.Time) bool {
+	d := until.Sub(t.nowFn())
+	if d <= 0 {
+		return true
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-t.shutSig.CloseAtLeisureChan():
+		return false
+	}
+}
+
+// read blocks until a transaction is read from the wrapped input, or returns
+// early when either a pause window begins, consumption is paused or the
+// throttle is closed.
+func (t *Throttled) read(readChan <-chan message.Transaction) (tran message.Transaction, open, interrupted bool) {
+	var pauseChan <-chan time.Time
+	if start, exists := t.nextPause(t.nowFn()); exists {
+		timer := time.NewTimer(start.Sub(t.nowFn()))
+		defer timer.Stop()
+		pauseChan = timer.C
+	}
+	select {
+	case tran, open = <-readChan:
+	case <-pauseChan:
+		interrupted = true
+	case <-t.pauseSig:
+		interrupted = true
+	case <-t.shutSig.CloseAtLeisureChan():
+		interrupted = true
+	}
+	return
+}
+
+// track returns a transaction that is counted as in flight until it is
+// acknowledged.
+func (t *Throttled) track(tran message.Transaction) message.Transaction {
+	atomic.AddInt64(&t.inFlight, 1)
+	tracked := message.NewTransactionFunc(tran.Payload, func(ctx context.Context, err error) error {
+		atomic.AddInt64(&t.inFlight, -1)
+		return tran.Ack(ctx, err)
+	})
+	return *tracked.WithContext(tran.Context())
+}
+
+func (t *Throttled) loop() {
+	defer func() {
+		close(t.tChan)
+		t.shutSig.ShutdownComplete()
+	}()
+
+	readChan := t.wrapped.TransactionChan()
+	var nextAllowed time.Time
+	for !t.shutSig.ShouldCloseAtLeisure() {
+		if t.Paused() {
+			t.mgr.Logger().Infoln("Pausing consumption until resumed")
+			t.waitForResume()
+			if !t.shutSig.ShouldCloseAtLeisure() {
+				t.mgr.Logger().Infoln("Resuming consumption")
+			}
+			continue
+		}
+		if until, paused := t.pausedUntil(t.nowFn()); paused {
+			t.mgr.Logger().Infof("Pausing consumption until %v\n", until.Format(time.RFC3339))
+			if t.waitUntil(until) {
+				t.mgr.Logger().Infoln("Resuming consumption")
+			}
+			continue
+		}
+		if !t.waitUntil(nextAllowed) {
+			break
+		}
+
+		tran, open, interrupted := t.read(readChan)
+		if interrupted {
+			continue
+		}
+		if !open {
+			return
+		}
+
+		if now := t.nowFn(); nextAllowed.Before(now) {
+			nextAllowed = now
+		}
+		nextAllowed = nextAllowed.Add(t.cost(tran.Payload))
+
+		tran = t.track(tran)
+		select {
+		case t.tChan <- tran:
+		case <-t.shutSig.CloseNowChan():
+			return
+		}
+	}
+
+	// Once closing, any remaining transactions of the wrapped input are
+	// flushed without throttling.
+	for {
+		tran, open := <-readChan
+		if !open {
+			return
+		}
+		tran = t.track(tran)
+		select {
+		case t.tChan <- tran:
+		case <-t.shutSig.CloseNowChan():
+			return
+		}
+	}
+}
+
+// Closed returns true once the throttle has been instructed to close or has
+// stopped consuming the wrapped input.
+func (t *Throttled) Closed() bool {
+	return t.shutSig.ShouldCloseAtLeisure() || t.shutSig.HasClosed()
+}
+
+// TransactionChan returns a transactions channel for consuming messages from
+// this input type.
+func (t *Throttled) TransactionChan() <-chan message.Transaction {
+	return t.tChan
+}
+
+// Connected returns a boolean indicating whether this input is currently
+// connected to its target.
+func (t *Throttled) Connected() bool {
+	return t.wrapped.Connected()
+}
+
+// CloseAsync shuts down the throttle and the wrapped input.
+func (t *Throttled) CloseAsync() {
+	t.shutSig.CloseAtLeisure()
+	t.wrapped.CloseAsync()
+}
+
+// WaitForClose blocks until the throttle and the wrapped input have closed
+// down.
+func (t *Throttled) WaitForClose(timeout time.Duration) error {
+	err := t.wrapped.WaitForClose(timeout)
+	t.shutSig.CloseNow()
+	return err
+}
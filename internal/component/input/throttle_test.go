@@ -0,0 +1,176 @@
+package input_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/component/input"
+	"github.com/benthosdev/benthos/v4/internal/manager/mock"
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+func sendThrottleTransactions(t *testing.T, ts chan<- message.Transaction, contents ...string) {
+	t.Helper()
+	go func() {
+		for _, c := range contents {
+			ts <- message.NewTransaction(message.QuickBatch([][]byte{[]byte(c)}), make(chan error, 1))
+		}
+	}()
+}
+
+func TestThrottleRates(t *testing.T) {
+	tests := []struct {
+		name     string
+		conf     input.ThrottleConfig
+		contents []string
+		minTime  time.Duration
+	}{
+		{
+			name: "messages per second",
+			conf: input.ThrottleConfig{
+				MaxMessagesPerSecond: 20,
+			},
+			contents: []string{"a", "b", "c", "d", "e"},
+			minTime:  time.Millisecond * 200,
+		},
+		{
+			name: "bytes per second",
+			conf: input.ThrottleConfig{
+				MaxBytesPerSecond: 100,
+			},
+			contents: []string{"0123456789", "0123456789", "0123456789"},
+			minTime:  time.Millisecond * 200,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			mockIn := &mockInput{ts: make(chan message.Transaction)}
+			in, err := input.WrapWithThrottle(test.conf, mockIn, mock.NewManager())
+			require.NoError(t, err)
+
+			sendThrottleTransactions(t, mockIn.ts, test.contents...)
+
+			start := time.Now()
+			for _, exp := range test.contents {
+				select {
+				case tran := <-in.TransactionChan():
+					assert.Equal(t, exp, string(tran.Payload.Get(0).Get()))
+					require.NoError(t, tran.Ack(context.Background(), nil))
+				case <-time.After(time.Second * 5):
+					t.Fatal("timed out")
+				}
+			}
+			assert.GreaterOrEqual(t, time.Since(start), test.minTime)
+
+			in.CloseAsync()
+			_ = in.WaitForClose(time.Second)
+		})
+	}
+}
+
+func TestThrottlePauseWindow(t *testing.T) {
+	conf := input.NewThrottleConfig()
+	conf.PauseWindows = append(conf.PauseWindows, input.PauseWindowConfig{
+		Schedule: "* * * * * *",
+		Duration: "1h",
+	})
+
+	mockIn := &mockInput{ts: make(chan message.Transaction)}
+	in, err := input.WrapWithThrottle(conf, mockIn, mock.NewManager())
+	require.NoError(t, err)
+
+	select {
+	case mockIn.ts <- message.NewTransaction(message.QuickBatch([][]byte{[]byte("foo")}), make(chan error, 1)):
+		t.Fatal("expected input to not be read from during pause window")
+	case <-time.After(time.Millisecond * 100):
+	}
+
+	in.CloseAsync()
+	_ = in.WaitForClose(time.Second)
+
+	select {
+	case _, open := <-in.TransactionChan():
+		assert.False(t, open)
+	case <-time.After(time.Second):
+		t.Fatal("timed out")
+	}
+}
+
+func TestThrottleBadConfig(t *testing.T) {
+	tests := []struct {
+		name        string
+		conf        input.ThrottleConfig
+		errContains string
+	}{
+		{
+			name:        "negative rate",
+			conf:        input.ThrottleConfig{MaxMessagesPerSecond: -1},
+			errContains: "must not be negative",
+		},
+		{
+			name: "bad schedule",
+			conf: input.ThrottleConfig{PauseWindows: []input.PauseWindowConfig{
+				{Schedule: "not a schedule", Duration: "1h"},
+			}},
+			errContains: "failed to parse schedule",
+		},
+		{
+			name: "bad duration",
+			conf: input.ThrottleConfig{PauseWindows: []input.PauseWindowConfig{
+				{Schedule: "0 2 * * *", Duration: "nope"},
+			}},
+			errContains: "failed to parse duration",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			_, err := input.WrapWithThrottle(test.conf, &mockInput{ts: make(chan message.Transaction)}, mock.NewManager())
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), test.errContains)
+		})
+	}
+}
+
+func TestThrottleManualPause(t *testing.T) {
+	mockIn := &mockInput{ts: make(chan message.Transaction)}
+	in, err := input.WrapWithThrottle(input.NewThrottleConfig(), mockIn, mock.NewManager())
+	require.NoError(t, err)
+
+	in.Pause()
+	assert.True(t, in.Paused())
+
+	resChan := make(chan error, 1)
+	select {
+	case mockIn.ts <- message.NewTransaction(message.QuickBatch([][]byte{[]byte("foo")}), resChan):
+		t.Fatal("expected input to not be read from whilst paused")
+	case <-time.After(time.Millisecond * 100):
+	}
+
+	in.Resume()
+	assert.False(t, in.Paused())
+
+	sendThrottleTransactions(t, mockIn.ts, "foo")
+
+	var tran message.Transaction
+	select {
+	case tran = <-in.TransactionChan():
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out")
+	}
+	assert.Equal(t, "foo", string(tran.Payload.Get(0).Get()))
+	assert.Equal(t, 1, in.InFlight())
+
+	require.NoError(t, tran.Ack(context.Background(), nil))
+	assert.Equal(t, 0, in.InFlight())
+
+	in.CloseAsync()
+	_ = in.WaitForClose(time.Second)
+}
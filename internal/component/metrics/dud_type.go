@@ -17,6 +17,9 @@ func (d DudStat) Timing(delta int64) {}
 // Set does nothing.
 func (d DudStat) Set(value int64) {}
 
+// Observe does nothing.
+func (d DudStat) Observe(value float64) {}
+
 //------------------------------------------------------------------------------
 
 var _ Type = DudType{}
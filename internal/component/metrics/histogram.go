@@ -0,0 +1,39 @@
+package metrics
+
+// timerHistogram records the values of a histogram with a timer, truncating
+// them to integers.
+type timerHistogram struct {
+	t StatTimer
+}
+
+func (h timerHistogram) Observe(value float64) {
+	h.t.Timing(int64(value))
+}
+
+type timerHistogramVec struct {
+	t StatTimerVec
+}
+
+func (h timerHistogramVec) With(labelValues ...string) StatHistogram {
+	return timerHistogram{t: h.t.With(labelValues...)}
+}
+
+// GetHistogramVec returns an editable histogram stat for a given path with
+// labels from a metrics type. When the type does not implement HistogramType
+// the values are instead recorded with a timer.
+func GetHistogramVec(t Type, path string, buckets []float64, labelNames ...string) StatHistogramVec {
+	if ht, ok := t.(HistogramType); ok {
+		return ht.GetHistogramVec(path, buckets, labelNames...)
+	}
+	return timerHistogramVec{t: t.GetTimerVec(path, labelNames...)}
+}
+
+// GetSummaryVec returns an editable summary stat for a given path with labels
+// from a metrics type. When the type does not implement HistogramType the
+// values are instead recorded with a timer.
+func GetSummaryVec(t Type, path string, labelNames ...string) StatHistogramVec {
+	if ht, ok := t.(HistogramType); ok {
+		return ht.GetSummaryVec(path, labelNames...)
+	}
+	return timerHistogramVec{t: t.GetTimerVec(path, labelNames...)}
+}
@@ -133,6 +133,18 @@ func (c *gaugeVecWithStatic) With(values ...string) StatGauge {
 	return c.child.With(newValues...)
 }
 
+type histogramVecWithStatic struct {
+	staticValues []string
+	child        StatHistogramVec
+}
+
+func (c *histogramVecWithStatic) With(values ...string) StatHistogram {
+	newValues := make([]string, 0, len(c.staticValues)+len(values))
+	newValues = append(newValues, c.staticValues...)
+	newValues = append(newValues, values...)
+	return c.child.With(newValues...)
+}
+
 //------------------------------------------------------------------------------
 
 // GetCounter returns an editable counter stat for a given path.
@@ -237,6 +249,50 @@ func (n *Namespaced) GetGaugeVec(path string, labelNames ...string) StatGaugeVec
 	return n.child.GetGaugeVec(path, labelNames...)
 }
 
+// GetHistogramVec returns an editable histogram stat for a given path with
+// labels, these labels must be consistent with any other metrics registered on
+// the same path.
+func (n *Namespaced) GetHistogramVec(path string, buckets []float64, labelNames ...string) StatHistogramVec {
+	path, staticKeys, staticValues := n.getPathAndLabels(path)
+	if path == "" {
+		return FakeHistogramVec(func(...string) StatHistogram {
+			return DudStat{}
+		})
+	}
+	if len(staticKeys) > 0 {
+		newNames := make([]string, 0, len(staticKeys)+len(labelNames))
+		newNames = append(newNames, staticKeys...)
+		newNames = append(newNames, labelNames...)
+		return &histogramVecWithStatic{
+			staticValues: staticValues,
+			child:        GetHistogramVec(n.child, path, buckets, newNames...),
+		}
+	}
+	return GetHistogramVec(n.child, path, buckets, labelNames...)
+}
+
+// GetSummaryVec returns an editable summary stat for a given path with labels,
+// these labels must be consistent with any other metrics registered on the same
+// path.
+func (n *Namespaced) GetSummaryVec(path string, labelNames ...string) StatHistogramVec {
+	path, staticKeys, staticValues := n.getPathAndLabels(path)
+	if path == "" {
+		return FakeHistogramVec(func(...string) StatHistogram {
+			return DudStat{}
+		})
+	}
+	if len(staticKeys) > 0 {
+		newNames := make([]string, 0, len(staticKeys)+len(labelNames))
+		newNames = append(newNames, staticKeys...)
+		newNames = append(newNames, labelNames...)
+		return &histogramVecWithStatic{
+			staticValues: staticValues,
+			child:        GetSummaryVec(n.child, path, newNames...),
+		}
+	}
+	return GetSummaryVec(n.child, path, labelNames...)
+}
+
 // Close stops aggregating stats and cleans up resources.
 func (n *Namespaced) Close() error {
 	return n.child.Close()
@@ -253,3 +253,18 @@ root = this.replace_all("bar","baz")`, log.Noop())
 	assert.Contains(t, body, "\ngaugetwo{extra1=\"extravalue1\",extra2=\"extravalue2\",label2=\"value3\",static1=\"sbaz1\"} 12")
 	assert.Contains(t, body, "\ntimertwo_sum{extra1=\"extravalue1\",extra2=\"extravalue2\",label3=\"value4\",label4=\"value5\",static1=\"sbaz1\"} 1.3e-08")
 }
+
+func TestNamespacedHistogramFallback(t *testing.T) {
+	local := metrics.NewLocal()
+
+	nm := metrics.NewNamespaced(local).WithLabels("static1", "svalue1")
+
+	nm.GetHistogramVec("histone", []float64{1, 10}, "label1").With("value1").Observe(5.7)
+	nm.GetSummaryVec("sumone").With().Observe(12)
+
+	timings := local.GetTimings()
+	require.Contains(t, timings, `histone{label1="value1",static1="svalue1"}`)
+	assert.Equal(t, int64(5), timings[`histone{label1="value1",static1="svalue1"}`].Max())
+	require.Contains(t, timings, `sumone{static1="svalue1"}`)
+	assert.Equal(t, int64(12), timings[`sumone{static1="svalue1"}`].Max())
+}
@@ -32,6 +32,14 @@ type StatGauge interface {
 	Decr(count int64)
 }
 
+// StatHistogram is a representation of a single histogram or summary metric
+// stat, which records the distribution of observed values. Interactions with
+// this stat are thread safe.
+type StatHistogram interface {
+	// Observe records a value.
+	Observe(value float64)
+}
+
 //------------------------------------------------------------------------------
 
 // StatCounterVec creates StatCounters with dynamic labels.
@@ -52,6 +60,12 @@ type StatGaugeVec interface {
 	With(labelValues ...string) StatGauge
 }
 
+// StatHistogramVec creates StatHistograms with dynamic labels.
+type StatHistogramVec interface {
+	// With returns a StatHistogram with a set of label values.
+	With(labelValues ...string) StatHistogram
+}
+
 //------------------------------------------------------------------------------
 
 // Type is an interface for metrics aggregation.
@@ -88,3 +102,20 @@ type Type interface {
 	// Close stops aggregating stats and cleans up resources.
 	Close() error
 }
+
+// HistogramType is an optional extension of Type implemented by metrics types
+// that are able to record the distribution of arbitrary values. The functions
+// GetHistogramVec and GetSummaryVec should be used in order to obtain these
+// stats from any Type, as they fall back to timers when it is not implemented.
+type HistogramType interface {
+	// GetHistogramVec returns an editable histogram stat for a given path with
+	// labels, where values are counted within buckets identified by their
+	// upper bounds. These labels must be consistent with any other metrics
+	// registered on the same path.
+	GetHistogramVec(path string, buckets []float64, labelNames ...string) StatHistogramVec
+
+	// GetSummaryVec returns an editable summary stat for a given path with
+	// labels, which tracks quantiles of the values observed. These labels must
+	// be consistent with any other metrics registered on the same path.
+	GetSummaryVec(path string, labelNames ...string) StatHistogramVec
+}
@@ -16,6 +16,13 @@ type Observability interface {
 	Tracer() trace.TracerProvider
 }
 
+// ExecutionMeter is optionally implemented by the observability APIs provided
+// to inputs and outputs in order to measure the resources used by each read or
+// write of the component.
+type ExecutionMeter interface {
+	MeterExecution(fn func())
+}
+
 type mockObs struct{}
 
 func (m mockObs) Metrics() metrics.Type {
@@ -6,7 +6,6 @@ import (
 	"sync/atomic"
 	"time"
 
-	"github.com/cenkalti/backoff/v4"
 	"go.opentelemetry.io/otel/trace"
 
 	"github.com/benthosdev/benthos/v4/internal/batch"
@@ -50,12 +49,14 @@ type AsyncWriter struct {
 	maxInflight int
 	noCancel    bool
 	writer      AsyncSink
+	connBackoff *component.ConnectBackoff
 
 	injectTracingMap *mapping.Executor
 
 	log    log.Modular
 	stats  metrics.Type
 	tracer trace.TracerProvider
+	meter  component.ExecutionMeter
 
 	transactions <-chan message.Transaction
 
@@ -68,12 +69,14 @@ func NewAsyncWriter(typeStr string, maxInflight int, w AsyncSink, mgr component.
 		typeStr:      typeStr,
 		maxInflight:  maxInflight,
 		writer:       w,
+		connBackoff:  component.GetConnectBackoff(mgr, time.Millisecond*500, time.Second),
 		log:          mgr.Logger(),
 		stats:        mgr.Metrics(),
 		tracer:       mgr.Tracer(),
 		transactions: nil,
 		shutSig:      shutdown.NewSignaller(),
 	}
+	aWriter.meter, _ = mgr.(component.ExecutionMeter)
 	return aWriter, nil
 }
 
@@ -104,7 +107,13 @@ func (w *AsyncWriter) latencyMeasuringWrite(msg *message.Batch) (latencyNs int64
 		ctx, done = w.shutSig.CloseAtLeisureCtx(context.Background())
 		defer done()
 	}
-	err = w.writer.WriteWithContext(ctx, msg)
+	if w.meter != nil {
+		w.meter.MeterExecution(func() {
+			err = w.writer.WriteWithContext(ctx, msg)
+		})
+	} else {
+		err = w.writer.WriteWithContext(ctx, msg)
+	}
 	latencyNs = time.Since(t0).Nanoseconds()
 	return latencyNs, err
 }
@@ -163,11 +172,6 @@ func (w *AsyncWriter) loop() {
 		w.shutSig.ShutdownComplete()
 	}()
 
-	connBackoff := backoff.NewExponentialBackOff()
-	connBackoff.InitialInterval = time.Millisecond * 500
-	connBackoff.MaxInterval = time.Second
-	connBackoff.MaxElapsedTime = 0
-
 	closeLeisureCtx, done := w.shutSig.CloseAtLeisureCtx(context.Background())
 	defer done()
 
@@ -181,13 +185,19 @@ func (w *AsyncWriter) loop() {
 				}
 				w.log.Errorf("Failed to connect to %v: %v\n", w.typeStr, err)
 				mFailedConn.Incr(1)
+				wait, ok := w.connBackoff.NextBackOff()
+				if !ok {
+					w.log.Errorf("Giving up connecting to %v, shutting down\n", w.typeStr)
+					w.shutSig.CloseAtLeisure()
+					return false
+				}
 				select {
-				case <-time.After(connBackoff.NextBackOff()):
+				case <-time.After(wait):
 				case <-initConnCtx.Done():
 					return false
 				}
 			} else {
-				connBackoff.Reset()
+				w.connBackoff.Reset()
 				return true
 			}
 		}
@@ -272,9 +282,9 @@ func (w *AsyncWriter) loop() {
 				if w.typeStr != "reject" {
 					// TODO: Maybe reintroduce a sleep here if we encounter a
 					// busy retry loop.
-					w.log.Errorf("Failed to send message to %v: %v\n", w.typeStr, err)
+					log.ForBatch(w.log, ts.Payload).Errorf("Failed to send message to %v: %v\n", w.typeStr, err)
 				} else {
-					w.log.Debugf("Rejecting message: %v\n", err)
+					log.ForBatch(w.log, ts.Payload).Debugf("Rejecting message: %v\n", err)
 				}
 			} else {
 				mBatchSent.Incr(1)
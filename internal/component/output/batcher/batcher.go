@@ -117,41 +117,51 @@ func (m *Impl) loop() {
 			flushBatch = true
 		}
 
-		if !flushBatch {
-			continue
-		}
-
-		sendMsg := m.batcher.Flush()
-		if sendMsg == nil {
-			continue
-		}
+		// Parts held back by a key can complete further batches, which are
+		// flushed straight away.
+		for flushBatch {
+			sendMsg := m.batcher.Flush()
+			if sendMsg == nil {
+				break
+			}
 
-		resChan := make(chan error)
-		select {
-		case m.messagesOut <- message.NewTransaction(sendMsg, resChan):
-		case <-m.shutSig.CloseAtLeisureChan():
-			return
-		}
+			// Parts held back by the batch policy belong to the latest
+			// transaction, which is then also acknowledged by the next batch.
+			var carriedTrans []*transaction.Tracked
+			if m.batcher.Count() > 0 && len(pendingTrans) > 0 {
+				splits := pendingTrans[len(pendingTrans)-1].Split(2)
+				pendingTrans[len(pendingTrans)-1] = splits[0]
+				carriedTrans = splits[1:]
+			}
 
-		go func(rChan chan error, upstreamTrans []*transaction.Tracked) {
+			resChan := make(chan error)
 			select {
+			case m.messagesOut <- message.NewTransaction(sendMsg, resChan):
 			case <-m.shutSig.CloseAtLeisureChan():
 				return
-			case res, open := <-rChan:
-				if !open {
+			}
+
+			go func(rChan chan error, upstreamTrans []*transaction.Tracked) {
+				select {
+				case <-m.shutSig.CloseAtLeisureChan():
 					return
-				}
-				closeAtLeisureCtx, done := m.shutSig.CloseAtLeisureCtx(context.Background())
-				for _, t := range upstreamTrans {
-					if err := t.Ack(closeAtLeisureCtx, res); err != nil {
-						done()
+				case res, open := <-rChan:
+					if !open {
 						return
 					}
+					closeAtLeisureCtx, done := m.shutSig.CloseAtLeisureCtx(context.Background())
+					for _, t := range upstreamTrans {
+						if err := t.Ack(closeAtLeisureCtx, res); err != nil {
+							done()
+							return
+						}
+					}
+					done()
 				}
-				done()
-			}
-		}(resChan, pendingTrans)
-		pendingTrans = nil
+			}(resChan, pendingTrans)
+			pendingTrans = carriedTrans
+			flushBatch = m.batcher.Triggered()
+		}
 	}
 }
 
@@ -179,6 +179,67 @@ func TestBatcherBasic(t *testing.T) {
 	wg.Wait()
 }
 
+func TestBatcherKey(t *testing.T) {
+	tInChan := make(chan message.Transaction)
+
+	policyConf := batchconfig.NewConfig()
+	policyConf.Key = `content().slice(0, 1)`
+	batchPol, err := policy.New(policyConf, mock.NewManager())
+	require.NoError(t, err)
+
+	out := &mock.OutputChanneled{}
+
+	b := batcher.New(batchPol, out, mock.NewManager())
+	require.NoError(t, b.Consume(tInChan))
+
+	// The second transaction is split across both batches.
+	inputs := [][][]byte{
+		{[]byte("a1")},
+		{[]byte("a2"), []byte("b1")},
+		{[]byte("b2")},
+	}
+	resChans := make([]chan error, len(inputs))
+	go func() {
+		for i, in := range inputs {
+			resChans[i] = make(chan error, 1)
+			select {
+			case tInChan <- message.NewTransaction(message.QuickBatch(in), resChans[i]):
+			case <-time.After(time.Second):
+				t.Error("timed out")
+			}
+		}
+		close(tInChan)
+	}()
+
+	firstErr := errors.New("first error")
+	for _, exp := range []struct {
+		batch [][]byte
+		err   error
+	}{
+		{batch: [][]byte{[]byte("a1"), []byte("a2")}, err: firstErr},
+		{batch: [][]byte{[]byte("b1"), []byte("b2")}},
+	} {
+		select {
+		case outTr := <-out.TChan:
+			assert.Equal(t, exp.batch, message.GetAllBytes(outTr.Payload))
+			require.NoError(t, outTr.Ack(context.Background(), exp.err))
+		case <-time.After(time.Second):
+			t.Fatal("Timed out waiting for message read")
+		}
+	}
+
+	for i, exp := range []error{firstErr, firstErr, nil} {
+		select {
+		case res := <-resChans[i]:
+			assert.Equal(t, exp, res, i)
+		case <-time.After(time.Second):
+			t.Fatal("Timed out waiting for response")
+		}
+	}
+
+	require.NoError(t, b.WaitForClose(time.Second*10))
+}
+
 func TestBatcherBatchError(t *testing.T) {
 	tCtx, done := context.WithTimeout(context.Background(), time.Second*20)
 	defer done()
@@ -312,3 +373,91 @@ func TestBatcherTimed(t *testing.T) {
 
 	close(resChan)
 }
+
+func TestBatcherKeyWithinTransaction(t *testing.T) {
+	for _, test := range []struct {
+		name  string
+		input []string
+		exp   [][]string
+	}{
+		{
+			name:  "distinct keys",
+			input: []string{"a1", "b1", "c1"},
+			exp:   [][]string{{"a1"}, {"b1"}, {"c1"}},
+		},
+		{
+			name:  "repeated keys",
+			input: []string{"a1", "b1", "b2", "a2"},
+			exp:   [][]string{{"a1"}, {"b1", "b2"}, {"a2"}},
+		},
+		{
+			name:  "runs",
+			input: []string{"a1", "a2", "b1", "c1", "c2", "d1"},
+			exp:   [][]string{{"a1", "a2"}, {"b1"}, {"c1", "c2"}, {"d1"}},
+		},
+	} {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			tInChan := make(chan message.Transaction)
+
+			policyConf := batchconfig.NewConfig()
+			policyConf.Key = `content().slice(0, 1)`
+			batchPol, err := policy.New(policyConf, mock.NewManager())
+			require.NoError(t, err)
+
+			out := &mock.OutputChanneled{}
+
+			b := batcher.New(batchPol, out, mock.NewManager())
+			require.NoError(t, b.Consume(tInChan))
+
+			var in [][]byte
+			for _, s := range test.input {
+				in = append(in, []byte(s))
+			}
+
+			resChan := make(chan error, 1)
+			select {
+			case tInChan <- message.NewTransaction(message.QuickBatch(in), resChan):
+			case <-time.After(time.Second):
+				t.Fatal("timed out")
+			}
+			close(tInChan)
+
+			firstErr := errors.New("first error")
+			for i, exp := range test.exp {
+				select {
+				case outTr := <-out.TChan:
+					var batch []string
+					for _, p := range message.GetAllBytes(outTr.Payload) {
+						assert.Equal(t, exp[0][0], p[0], "batch contains multiple keys")
+						batch = append(batch, string(p))
+					}
+					assert.Equal(t, exp, batch)
+
+					select {
+					case <-resChan:
+						t.Fatal("transaction acknowledged before all of its batches")
+					default:
+					}
+
+					var ackErr error
+					if i == 0 {
+						ackErr = firstErr
+					}
+					require.NoError(t, outTr.Ack(context.Background(), ackErr))
+				case <-time.After(time.Second):
+					t.Fatal("Timed out waiting for message read")
+				}
+			}
+
+			select {
+			case res := <-resChan:
+				assert.Equal(t, firstErr, res)
+			case <-time.After(time.Second):
+				t.Fatal("Timed out waiting for response")
+			}
+
+			require.NoError(t, b.WaitForClose(time.Second*10))
+		})
+	}
+}
@@ -5,6 +5,7 @@ import (
 
 	"gopkg.in/yaml.v3"
 
+	"github.com/benthosdev/benthos/v4/internal/component"
 	"github.com/benthosdev/benthos/v4/internal/component/processor"
 	"github.com/benthosdev/benthos/v4/internal/docs"
 )
@@ -40,6 +41,7 @@ type Config struct {
 	HDFS               HDFSConfig              `json:"hdfs" yaml:"hdfs"`
 	HTTPClient         HTTPClientConfig        `json:"http_client" yaml:"http_client"`
 	HTTPServer         HTTPServerConfig        `json:"http_server" yaml:"http_server"`
+	Idempotent         IdempotentConfig        `json:"idempotent" yaml:"idempotent"`
 	Inproc             string                  `json:"inproc" yaml:"inproc"`
 	Kafka              KafkaConfig             `json:"kafka" yaml:"kafka"`
 	MongoDB            MongoDBConfig           `json:"mongodb" yaml:"mongodb"`
@@ -56,6 +58,8 @@ type Config struct {
 	Reject             string                  `json:"reject" yaml:"reject"`
 	Resource           string                  `json:"resource" yaml:"resource"`
 	Retry              RetryConfig             `json:"retry" yaml:"retry"`
+	Router             RouterConfig            `json:"router" yaml:"router"`
+	Saga               SagaConfig              `json:"saga" yaml:"saga"`
 	SFTP               SFTPConfig              `json:"sftp" yaml:"sftp"`
 	STDOUT             STDOUTConfig            `json:"stdout" yaml:"stdout"`
 	Subprocess         SubprocessConfig        `json:"subprocess" yaml:"subprocess"`
@@ -64,6 +68,9 @@ type Config struct {
 	Socket             SocketConfig            `json:"socket" yaml:"socket"`
 	Websocket          WebsocketConfig         `json:"websocket" yaml:"websocket"`
 	Processors         []processor.Config      `json:"processors" yaml:"processors"`
+
+	ConnectBackoff *component.ConnectBackoffConfig `json:"connect_backoff,omitempty" yaml:"connect_backoff,omitempty"`
+	Journal        *JournalConfig                  `json:"journal,omitempty" yaml:"journal,omitempty"`
 }
 
 // NewConfig returns a configuration struct fully populated with default values.
@@ -98,6 +105,7 @@ func NewConfig() Config {
 		HDFS:               NewHDFSConfig(),
 		HTTPClient:         NewHTTPClientConfig(),
 		HTTPServer:         NewHTTPServerConfig(),
+		Idempotent:         NewIdempotentConfig(),
 		Inproc:             "",
 		Kafka:              NewKafkaConfig(),
 		MQTT:               NewMQTTConfig(),
@@ -114,6 +122,8 @@ func NewConfig() Config {
 		Reject:             "",
 		Resource:           "",
 		Retry:              NewRetryConfig(),
+		Router:             NewRouterConfig(),
+		Saga:               NewSagaConfig(),
 		SFTP:               NewSFTPConfig(),
 		STDOUT:             NewSTDOUTConfig(),
 		Subprocess:         NewSubprocessConfig(),
@@ -122,6 +132,9 @@ func NewConfig() Config {
 		Socket:             NewSocketConfig(),
 		Websocket:          NewWebsocketConfig(),
 		Processors:         []processor.Config{},
+
+		ConnectBackoff: nil,
+		Journal:        nil,
 	}
 }
 
@@ -26,6 +26,7 @@ type AMQPConfig struct {
 	ContentEncoding string                       `json:"content_encoding" yaml:"content_encoding"`
 	Metadata        metadata.ExcludeFilterConfig `json:"metadata" yaml:"metadata"`
 	Priority        string                       `json:"priority" yaml:"priority"`
+	Delay           string                       `json:"delay" yaml:"delay"`
 	Persistent      bool                         `json:"persistent" yaml:"persistent"`
 	Mandatory       bool                         `json:"mandatory" yaml:"mandatory"`
 	Immediate       bool                         `json:"immediate" yaml:"immediate"`
@@ -49,6 +50,7 @@ func NewAMQPConfig() AMQPConfig {
 		ContentEncoding: "",
 		Metadata:        metadata.NewExcludeFilterConfig(),
 		Priority:        "",
+		Delay:           "",
 		Persistent:      false,
 		Mandatory:       false,
 		Immediate:       false,
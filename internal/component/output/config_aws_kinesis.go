@@ -14,7 +14,24 @@ type KinesisConfig struct {
 	PartitionKey   string `json:"partition_key" yaml:"partition_key"`
 	MaxInFlight    int    `json:"max_in_flight" yaml:"max_in_flight"`
 	retries.Config `json:",inline" yaml:",inline"`
-	Batching       batchconfig.Config `json:"batching" yaml:"batching"`
+	Aggregation    KinesisAggregationConfig `json:"aggregation" yaml:"aggregation"`
+	Batching       batchconfig.Config       `json:"batching" yaml:"batching"`
+}
+
+// KinesisAggregationConfig contains configuration fields for the aggregation
+// of records within the Kinesis output type.
+type KinesisAggregationConfig struct {
+	Enabled  bool `json:"enabled" yaml:"enabled"`
+	MaxBytes int  `json:"max_bytes" yaml:"max_bytes"`
+}
+
+// NewKinesisAggregationConfig creates a new KinesisAggregationConfig with
+// default values.
+func NewKinesisAggregationConfig() KinesisAggregationConfig {
+	return KinesisAggregationConfig{
+		Enabled:  false,
+		MaxBytes: 51200,
+	}
 }
 
 // NewKinesisConfig creates a new Config with default values.
@@ -32,6 +49,7 @@ func NewKinesisConfig() KinesisConfig {
 		PartitionKey: "",
 		MaxInFlight:  64,
 		Config:       rConf,
+		Aggregation:  NewKinesisAggregationConfig(),
 		Batching:     batchconfig.NewConfig(),
 	}
 }
@@ -24,6 +24,12 @@ type AmazonS3Config struct {
 	Timeout                 string                       `json:"timeout" yaml:"timeout"`
 	KMSKeyID                string                       `json:"kms_key_id" yaml:"kms_key_id"`
 	ServerSideEncryption    string                       `json:"server_side_encryption" yaml:"server_side_encryption"`
+	ChecksumAlgorithm       string                       `json:"checksum_algorithm" yaml:"checksum_algorithm"`
+	Multipart               AmazonS3MultipartConfig      `json:"multipart" yaml:"multipart"`
+	Accumulate              AmazonS3AccumulateConfig     `json:"accumulate" yaml:"accumulate"`
+	PartitionBy             []AmazonS3PartitionConfig    `json:"partition_by" yaml:"partition_by"`
+	Parquet                 AmazonS3ParquetConfig        `json:"parquet" yaml:"parquet"`
+	ManifestPath            string                       `json:"manifest_path" yaml:"manifest_path"`
 	MaxInFlight             int                          `json:"max_in_flight" yaml:"max_in_flight"`
 	Batching                batchconfig.Config           `json:"batching" yaml:"batching"`
 }
@@ -47,7 +53,79 @@ func NewAmazonS3Config() AmazonS3Config {
 		Timeout:                 "5s",
 		KMSKeyID:                "",
 		ServerSideEncryption:    "",
+		ChecksumAlgorithm:       "",
+		Multipart:               NewAmazonS3MultipartConfig(),
+		Accumulate:              NewAmazonS3AccumulateConfig(),
+		PartitionBy:             []AmazonS3PartitionConfig{},
+		Parquet:                 NewAmazonS3ParquetConfig(),
+		ManifestPath:            "",
 		MaxInFlight:             64,
 		Batching:                batchconfig.NewConfig(),
 	}
 }
+
+// AmazonS3MultipartConfig contains configuration fields for tuning the
+// multipart uploads of the AmazonS3 output type.
+type AmazonS3MultipartConfig struct {
+	PartSize    int `json:"part_size" yaml:"part_size"`
+	Concurrency int `json:"concurrency" yaml:"concurrency"`
+}
+
+// NewAmazonS3MultipartConfig creates a new AmazonS3MultipartConfig with
+// default values.
+func NewAmazonS3MultipartConfig() AmazonS3MultipartConfig {
+	return AmazonS3MultipartConfig{
+		PartSize:    5 * 1024 * 1024,
+		Concurrency: 5,
+	}
+}
+
+// AmazonS3AccumulateConfig contains configuration fields for accumulating
+// messages across batches into larger objects within the AmazonS3 output
+// type.
+type AmazonS3AccumulateConfig struct {
+	Enabled   bool   `json:"enabled" yaml:"enabled"`
+	Partition string `json:"partition" yaml:"partition"`
+	MaxBytes  int    `json:"max_bytes" yaml:"max_bytes"`
+	Period    string `json:"period" yaml:"period"`
+	Separator string `json:"separator" yaml:"separator"`
+}
+
+// NewAmazonS3AccumulateConfig creates a new AmazonS3AccumulateConfig with
+// default values.
+func NewAmazonS3AccumulateConfig() AmazonS3AccumulateConfig {
+	return AmazonS3AccumulateConfig{
+		Enabled:   false,
+		Partition: "",
+		MaxBytes:  128 * 1024 * 1024,
+		Period:    "5m",
+		Separator: "\n",
+	}
+}
+
+// AmazonS3PartitionConfig contains configuration fields for a Hive-style
+// partition of the objects written by the AmazonS3 output type.
+type AmazonS3PartitionConfig struct {
+	Key   string `json:"key" yaml:"key"`
+	Value string `json:"value" yaml:"value"`
+}
+
+// AmazonS3ParquetConfig contains configuration fields for serializing the
+// objects of the AmazonS3 output type as Parquet files.
+type AmazonS3ParquetConfig struct {
+	Enabled     bool   `json:"enabled" yaml:"enabled"`
+	Schema      string `json:"schema" yaml:"schema"`
+	SchemaFile  string `json:"schema_file" yaml:"schema_file"`
+	Compression string `json:"compression" yaml:"compression"`
+}
+
+// NewAmazonS3ParquetConfig creates a new AmazonS3ParquetConfig with default
+// values.
+func NewAmazonS3ParquetConfig() AmazonS3ParquetConfig {
+	return AmazonS3ParquetConfig{
+		Enabled:     false,
+		Schema:      "",
+		SchemaFile:  "",
+		Compression: "snappy",
+	}
+}
@@ -13,6 +13,7 @@ type AmazonSQSConfig struct {
 	URL                    string                       `json:"url" yaml:"url"`
 	MessageGroupID         string                       `json:"message_group_id" yaml:"message_group_id"`
 	MessageDeduplicationID string                       `json:"message_deduplication_id" yaml:"message_deduplication_id"`
+	Delay                  string                       `json:"delay" yaml:"delay"`
 	Metadata               metadata.ExcludeFilterConfig `json:"metadata" yaml:"metadata"`
 	MaxInFlight            int                          `json:"max_in_flight" yaml:"max_in_flight"`
 	retries.Config         `json:",inline" yaml:",inline"`
@@ -33,6 +34,7 @@ func NewAmazonSQSConfig() AmazonSQSConfig {
 		URL:                    "",
 		MessageGroupID:         "",
 		MessageDeduplicationID: "",
+		Delay:                  "",
 		Metadata:               metadata.NewExcludeFilterConfig(),
 		MaxInFlight:            64,
 		Config:                 rConf,
@@ -9,6 +9,7 @@ type AzureQueueStorageConfig struct {
 	StorageConnectionString string             `json:"storage_connection_string" yaml:"storage_connection_string"`
 	QueueName               string             `json:"queue_name" yaml:"queue_name"`
 	TTL                     string             `json:"ttl" yaml:"ttl"`
+	Delay                   string             `json:"delay" yaml:"delay"`
 	MaxInFlight             int                `json:"max_in_flight" yaml:"max_in_flight"`
 	Batching                batchconfig.Config `json:"batching" yaml:"batching"`
 }
@@ -21,6 +22,7 @@ func NewAzureQueueStorageConfig() AzureQueueStorageConfig {
 		StorageConnectionString: "",
 		QueueName:               "",
 		TTL:                     "",
+		Delay:                   "",
 		MaxInFlight:             64,
 		Batching:                batchconfig.NewConfig(),
 	}
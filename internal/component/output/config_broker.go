@@ -1,21 +1,91 @@
 package output
 
-import "github.com/benthosdev/benthos/v4/internal/batch/policy/batchconfig"
+import (
+	"encoding/json"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/benthosdev/benthos/v4/internal/batch/policy/batchconfig"
+)
 
 // BrokerConfig contains configuration fields for the Broker output type.
 type BrokerConfig struct {
-	Copies   int                `json:"copies" yaml:"copies"`
-	Pattern  string             `json:"pattern" yaml:"pattern"`
-	Outputs  []Config           `json:"outputs" yaml:"outputs"`
-	Batching batchconfig.Config `json:"batching" yaml:"batching"`
+	Copies       int                `json:"copies" yaml:"copies"`
+	Pattern      string             `json:"pattern" yaml:"pattern"`
+	Outputs      []Config           `json:"outputs" yaml:"outputs"`
+	AckQuorum    int                `json:"ack_quorum" yaml:"ack_quorum"`
+	ChildTimeout string             `json:"child_timeout" yaml:"child_timeout"`
+	DeadLetter   *Config            `json:"dead_letter" yaml:"dead_letter"`
+	Batching     batchconfig.Config `json:"batching" yaml:"batching"`
 }
 
 // NewBrokerConfig creates a new BrokerConfig with default values.
 func NewBrokerConfig() BrokerConfig {
 	return BrokerConfig{
-		Copies:   1,
-		Pattern:  "fan_out",
-		Outputs:  []Config{},
-		Batching: batchconfig.NewConfig(),
+		Copies:       1,
+		Pattern:      "fan_out",
+		Outputs:      []Config{},
+		AckQuorum:    0,
+		ChildTimeout: "",
+		DeadLetter:   nil,
+		Batching:     batchconfig.NewConfig(),
+	}
+}
+
+type dummyBrokerConfig struct {
+	Copies       int                `json:"copies" yaml:"copies"`
+	Pattern      string             `json:"pattern" yaml:"pattern"`
+	Outputs      []Config           `json:"outputs" yaml:"outputs"`
+	AckQuorum    int                `json:"ack_quorum" yaml:"ack_quorum"`
+	ChildTimeout string             `json:"child_timeout" yaml:"child_timeout"`
+	DeadLetter   interface{}        `json:"dead_letter" yaml:"dead_letter"`
+	Batching     batchconfig.Config `json:"batching" yaml:"batching"`
+}
+
+func (b BrokerConfig) dummy() dummyBrokerConfig {
+	dummy := dummyBrokerConfig{
+		Copies:       b.Copies,
+		Pattern:      b.Pattern,
+		Outputs:      b.Outputs,
+		AckQuorum:    b.AckQuorum,
+		ChildTimeout: b.ChildTimeout,
+		DeadLetter:   b.DeadLetter,
+		Batching:     b.Batching,
+	}
+	if b.DeadLetter == nil {
+		dummy.DeadLetter = struct{}{}
 	}
+	return dummy
+}
+
+// MarshalJSON prints an empty object instead of nil.
+func (b BrokerConfig) MarshalJSON() ([]byte, error) {
+	return json.Marshal(b.dummy())
+}
+
+// MarshalYAML prints an empty object instead of nil.
+func (b BrokerConfig) MarshalYAML() (interface{}, error) {
+	return b.dummy(), nil
+}
+
+// UnmarshalYAML treats an empty dead letter output as nil.
+func (b *BrokerConfig) UnmarshalYAML(value *yaml.Node) error {
+	type brokerAlias BrokerConfig
+	aliased := brokerAlias(*b)
+
+	if err := value.Decode(&aliased); err != nil {
+		return err
+	}
+
+	for i := 0; i < len(value.Content)-1; i += 2 {
+		if value.Content[i].Value != "dead_letter" {
+			continue
+		}
+		if v := value.Content[i+1]; v.Kind == yaml.MappingNode && len(v.Content) == 0 {
+			aliased.DeadLetter = nil
+		}
+	}
+
+	*b = BrokerConfig(aliased)
+	return nil
 }
@@ -3,6 +3,8 @@ package output_test
 import (
 	"testing"
 
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"gopkg.in/yaml.v3"
 
 	"github.com/benthosdev/benthos/v4/internal/component/output"
@@ -74,3 +76,29 @@ func TestOutBrokerConfigDefaults(t *testing.T) {
 		t.Errorf("Unexpected value from config: %v != %v", exp, actual)
 	}
 }
+
+func TestOutBrokerConfigDeadLetter(t *testing.T) {
+	conf := output.NewConfig()
+	require.NoError(t, yaml.Unmarshal([]byte(`
+broker:
+  outputs: [ { drop: {} }, { drop: {} } ]
+  dead_letter: {}
+`), &conf))
+	assert.Nil(t, conf.Broker.DeadLetter)
+
+	conf = output.NewConfig()
+	require.NoError(t, yaml.Unmarshal([]byte(`
+broker:
+  outputs: [ { drop: {} }, { drop: {} } ]
+  dead_letter:
+    file:
+      path: ./dead.txt
+`), &conf))
+	require.NotNil(t, conf.Broker.DeadLetter)
+	assert.Equal(t, "file", conf.Broker.DeadLetter.Type)
+	assert.Equal(t, "./dead.txt", conf.Broker.DeadLetter.File.Path)
+
+	confBytes, err := yaml.Marshal(output.NewBrokerConfig())
+	require.NoError(t, err)
+	assert.Contains(t, string(confBytes), "dead_letter: {}")
+}
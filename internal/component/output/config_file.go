@@ -2,14 +2,18 @@ package output
 
 // FileConfig contains configuration fields for the file based output type.
 type FileConfig struct {
-	Path  string `json:"path" yaml:"path"`
-	Codec string `json:"codec" yaml:"codec"`
+	Path         string `json:"path" yaml:"path"`
+	Codec        string `json:"codec" yaml:"codec"`
+	Atomic       bool   `json:"atomic" yaml:"atomic"`
+	ManifestPath string `json:"manifest_path" yaml:"manifest_path"`
 }
 
 // NewFileConfig creates a new FileConfig with default values.
 func NewFileConfig() FileConfig {
 	return FileConfig{
-		Path:  "",
-		Codec: "lines",
+		Path:         "",
+		Codec:        "lines",
+		Atomic:       false,
+		ManifestPath: "",
 	}
 }
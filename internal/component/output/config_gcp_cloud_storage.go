@@ -1,6 +1,7 @@
 package output
 
 import (
+	"github.com/benthosdev/benthos/v4/internal/impl/gcp/auth"
 	"google.golang.org/api/googleapi"
 
 	"github.com/benthosdev/benthos/v4/internal/batch/policy/batchconfig"
@@ -31,6 +32,7 @@ type GCPCloudStorageConfig struct {
 	MaxInFlight     int                `json:"max_in_flight" yaml:"max_in_flight"`
 	Batching        batchconfig.Config `json:"batching" yaml:"batching"`
 	CollisionMode   string             `json:"collision_mode" yaml:"collision_mode"`
+	Credentials     auth.Config        `json:"credentials" yaml:"credentials"`
 }
 
 // NewGCPCloudStorageConfig creates a new Config with default values.
@@ -44,5 +46,6 @@ func NewGCPCloudStorageConfig() GCPCloudStorageConfig {
 		MaxInFlight:     64,
 		Batching:        batchconfig.NewConfig(),
 		CollisionMode:   GCPCloudStorageOverwriteCollisionMode,
+		Credentials:     auth.New(),
 	}
 }
@@ -1,6 +1,7 @@
 package output
 
 import (
+	"github.com/benthosdev/benthos/v4/internal/impl/gcp/auth"
 	"github.com/benthosdev/benthos/v4/internal/metadata"
 )
 
@@ -12,6 +13,16 @@ type GCPPubSubConfig struct {
 	PublishTimeout string                       `json:"publish_timeout" yaml:"publish_timeout"`
 	Metadata       metadata.ExcludeFilterConfig `json:"metadata" yaml:"metadata"`
 	OrderingKey    string                       `json:"ordering_key" yaml:"ordering_key"`
+	FlowControl    GCPPubSubFlowControlConfig   `json:"flow_control" yaml:"flow_control"`
+	Credentials    auth.Config                  `json:"credentials" yaml:"credentials"`
+}
+
+// GCPPubSubFlowControlConfig contains configuration fields for limiting the
+// number of messages pending publication by the GCPPubSub output type.
+type GCPPubSubFlowControlConfig struct {
+	MaxOutstandingMessages int    `json:"max_outstanding_messages" yaml:"max_outstanding_messages"`
+	MaxOutstandingBytes    int    `json:"max_outstanding_bytes" yaml:"max_outstanding_bytes"`
+	LimitExceededBehavior  string `json:"limit_exceeded_behavior" yaml:"limit_exceeded_behavior"`
 }
 
 // NewGCPPubSubConfig creates a new Config with default values.
@@ -23,5 +34,11 @@ func NewGCPPubSubConfig() GCPPubSubConfig {
 		PublishTimeout: "60s",
 		Metadata:       metadata.NewExcludeFilterConfig(),
 		OrderingKey:    "",
+		FlowControl: GCPPubSubFlowControlConfig{
+			MaxOutstandingMessages: 1000,
+			MaxOutstandingBytes:    -1,
+			LimitExceededBehavior:  "ignore",
+		},
+		Credentials: auth.New(),
 	}
 }
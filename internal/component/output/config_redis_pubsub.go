@@ -10,6 +10,7 @@ import (
 type RedisPubSubConfig struct {
 	bredis.Config `json:",inline" yaml:",inline"`
 	Channel       string             `json:"channel" yaml:"channel"`
+	Sharded       bool               `json:"sharded" yaml:"sharded"`
 	MaxInFlight   int                `json:"max_in_flight" yaml:"max_in_flight"`
 	Batching      batchconfig.Config `json:"batching" yaml:"batching"`
 }
@@ -19,6 +20,7 @@ func NewRedisPubSubConfig() RedisPubSubConfig {
 	return RedisPubSubConfig{
 		Config:      bredis.NewConfig(),
 		Channel:     "",
+		Sharded:     false,
 		MaxInFlight: 64,
 		Batching:    batchconfig.NewConfig(),
 	}
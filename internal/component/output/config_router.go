@@ -0,0 +1,19 @@
+package output
+
+// RouterConfig contains configuration fields for the router output type.
+type RouterConfig struct {
+	Route       string            `json:"route" yaml:"route"`
+	Templates   map[string]Config `json:"templates" yaml:"templates"`
+	MaxOutputs  int               `json:"max_outputs" yaml:"max_outputs"`
+	IdleTimeout string            `json:"idle_timeout" yaml:"idle_timeout"`
+}
+
+// NewRouterConfig creates a new RouterConfig with default values.
+func NewRouterConfig() RouterConfig {
+	return RouterConfig{
+		Route:       "",
+		Templates:   map[string]Config{},
+		MaxOutputs:  100,
+		IdleTimeout: "5m",
+	}
+}
@@ -0,0 +1,27 @@
+package output
+
+// SagaConfig contains configuration values for the Saga output type.
+type SagaConfig struct {
+	Steps []SagaStepConfig `json:"steps" yaml:"steps"`
+}
+
+// NewSagaConfig creates a new SagaConfig with default values.
+func NewSagaConfig() SagaConfig {
+	return SagaConfig{
+		Steps: []SagaStepConfig{},
+	}
+}
+
+// SagaStepConfig contains configuration fields for each step of a saga.
+type SagaStepConfig struct {
+	Output     Config  `json:"output" yaml:"output"`
+	Compensate *Config `json:"compensate,omitempty" yaml:"compensate,omitempty"`
+}
+
+// NewSagaStepConfig creates a new saga step config with default values.
+func NewSagaStepConfig() SagaStepConfig {
+	return SagaStepConfig{
+		Output:     NewConfig(),
+		Compensate: nil,
+	}
+}
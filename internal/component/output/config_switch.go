@@ -19,6 +19,7 @@ func NewSwitchConfig() SwitchConfig {
 // SwitchConfigCase contains configuration fields per output of a switch type.
 type SwitchConfigCase struct {
 	Check    string `json:"check" yaml:"check"`
+	CEL      string `json:"cel" yaml:"cel"`
 	Continue bool   `json:"continue" yaml:"continue"`
 	Output   Config `json:"output" yaml:"output"`
 }
@@ -27,6 +28,7 @@ type SwitchConfigCase struct {
 func NewSwitchConfigCase() SwitchConfigCase {
 	return SwitchConfigCase{
 		Check:    "",
+		CEL:      "",
 		Continue: false,
 		Output:   NewConfig(),
 	}
@@ -0,0 +1,257 @@
+package output
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// JournalConfig describes a write-ahead journal of the messages delivered by
+// an output, which is used in order to detect messages that are redelivered
+// after they were written but before they were acknowledged upstream.
+type JournalConfig struct {
+	Path         string `json:"path" yaml:"path"`
+	Key          string `json:"key" yaml:"key"`
+	OnRedelivery string `json:"on_redelivery" yaml:"on_redelivery"`
+	Retention    string `json:"retention" yaml:"retention"`
+	Sync         bool   `json:"sync" yaml:"sync"`
+}
+
+// NewJournalConfig returns a JournalConfig with default values.
+func NewJournalConfig() JournalConfig {
+	return JournalConfig{
+		Path:         "",
+		Key:          "",
+		OnRedelivery: "skip",
+		Retention:    "24h",
+		Sync:         false,
+	}
+}
+
+// UnmarshalYAML ensures that fields omitted from a config are given their
+// default values.
+func (c *JournalConfig) UnmarshalYAML(value *yaml.Node) error {
+	type confAlias JournalConfig
+	aliased := confAlias(NewJournalConfig())
+	if err := value.Decode(&aliased); err != nil {
+		return err
+	}
+	*c = JournalConfig(aliased)
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+const (
+	journalStatusDelivered = "delivered"
+	journalStatusAcked     = "acked"
+
+	// The minimum number of records appended to a journal before it is
+	// compacted.
+	journalCompactAfter = 10000
+)
+
+type journalRecord struct {
+	Key    string `json:"key"`
+	Status string `json:"status"`
+	Time   int64  `json:"time,omitempty"`
+}
+
+// journal is an append-only file of records of the keys of messages that were
+// delivered, and the keys of messages that were subsequently acknowledged
+// upstream. Keys that are delivered and not yet acknowledged are held in
+// memory, and the file is periodically rewritten with only those keys.
+type journal struct {
+	path      string
+	sync      bool
+	retention time.Duration
+
+	file    *os.File
+	unacked map[string]time.Time
+	appends int
+	mut     sync.Mutex
+}
+
+// openJournal opens a journal file, creating it if it does not exist, and
+// returns the journal along with the number of records that were unreadable.
+func openJournal(path string, retention time.Duration, sync bool) (*journal, int, error) {
+	j := &journal{
+		path:      path,
+		sync:      sync,
+		retention: retention,
+		unacked:   map[string]time.Time{},
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, 0, err
+	}
+	corrupt, err := j.replay()
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read journal: %w", err)
+	}
+	if err := j.compact(); err != nil {
+		return nil, 0, fmt.Errorf("failed to compact journal: %w", err)
+	}
+	return j, corrupt, nil
+}
+
+func (j *journal) replay() (corrupt int, err error) {
+	f, err := os.Open(j.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		var rec journalRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			// The final record is incomplete when a crash occurs part way
+			// through appending it.
+			corrupt++
+			continue
+		}
+		switch rec.Status {
+		case journalStatusDelivered:
+			j.unacked[rec.Key] = time.Unix(rec.Time, 0)
+		case journalStatusAcked:
+			delete(j.unacked, rec.Key)
+		default:
+			corrupt++
+		}
+	}
+	return corrupt, scanner.Err()
+}
+
+// compact rewrites the journal with only the keys that are delivered and not
+// acknowledged, excluding those that are older than the retention period.
+func (j *journal) compact() error {
+	if j.retention > 0 {
+		cutoff := time.Now().Add(-j.retention)
+		for k, t := range j.unacked {
+			if t.Before(cutoff) {
+				delete(j.unacked, k)
+			}
+		}
+	}
+
+	tmpPath := j.path + ".tmp"
+	tmp, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(tmp)
+	enc := json.NewEncoder(w)
+	for k, t := range j.unacked {
+		if err = enc.Encode(journalRecord{Key: k, Status: journalStatusDelivered, Time: t.Unix()}); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, j.path)
+	}
+	if err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+
+	if j.file != nil {
+		_ = j.file.Close()
+	}
+	if j.file, err = os.OpenFile(j.path, os.O_APPEND|os.O_WRONLY, 0o644); err != nil {
+		return err
+	}
+	j.appends = 0
+	return nil
+}
+
+// isUnacked returns whether a key was delivered and not yet acknowledged.
+func (j *journal) isUnacked(key string) bool {
+	j.mut.Lock()
+	_, exists := j.unacked[key]
+	j.mut.Unlock()
+	return exists
+}
+
+// record appends records of a status for a slice of keys to the journal.
+func (j *journal) record(status string, keys []string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, k := range keys {
+		rec := journalRecord{Key: k, Status: status}
+		if status == journalStatusDelivered {
+			rec.Time = now.Unix()
+		}
+		if err := enc.Encode(rec); err != nil {
+			return err
+		}
+	}
+
+	j.mut.Lock()
+	defer j.mut.Unlock()
+
+	if j.file == nil {
+		return errors.New("journal is closed")
+	}
+	if _, err := j.file.Write(buf.Bytes()); err != nil {
+		return err
+	}
+	if j.sync {
+		if err := j.file.Sync(); err != nil {
+			return err
+		}
+	}
+
+	for _, k := range keys {
+		if status == journalStatusDelivered {
+			j.unacked[k] = now
+		} else {
+			delete(j.unacked, k)
+		}
+	}
+
+	j.appends += len(keys)
+	if j.appends >= journalCompactAfter && j.appends > 2*len(j.unacked) {
+		return j.compact()
+	}
+	return nil
+}
+
+func (j *journal) close() error {
+	j.mut.Lock()
+	defer j.mut.Unlock()
+
+	if j.file == nil {
+		return nil
+	}
+	err := j.file.Close()
+	j.file = nil
+	return err
+}
@@ -0,0 +1,247 @@
+package output
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/internal/batch"
+	"github.com/benthosdev/benthos/v4/internal/bloblang/field"
+	"github.com/benthosdev/benthos/v4/internal/component"
+	"github.com/benthosdev/benthos/v4/internal/log"
+	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/shutdown"
+)
+
+// JournalRedeliveredMetaKey is the metadata key added to messages that are
+// sent despite being recorded as delivered when redeliveries are flagged.
+const JournalRedeliveredMetaKey = "journal_redelivered"
+
+// WithJournal is a type that wraps an output and records the keys of messages
+// that the output delivers within a journal, along with whether the delivery
+// was acknowledged upstream. Messages that are received with keys that were
+// delivered and not acknowledged, which happens when the pipeline restarts
+// before acknowledgements reach the input, are either skipped or flagged.
+type WithJournal struct {
+	out     Streamed
+	journal *journal
+	key     *field.Expression
+	flag    bool
+	log     log.Modular
+
+	transactionsIn  <-chan message.Transaction
+	transactionsOut chan message.Transaction
+
+	shutSig *shutdown.Signaller
+}
+
+// WrapWithJournal wraps an output with a journal described by a config, where
+// the key of each message is calculated with a provided expression.
+func WrapWithJournal(out Streamed, conf JournalConfig, key *field.Expression, mgr component.Observability) (*WithJournal, error) {
+	if conf.Path == "" {
+		return nil, errors.New("journal path must not be empty")
+	}
+
+	var flag bool
+	switch conf.OnRedelivery {
+	case "skip":
+	case "flag":
+		flag = true
+	default:
+		return nil, fmt.Errorf("unrecognised journal on_redelivery value: %v", conf.OnRedelivery)
+	}
+
+	var retention time.Duration
+	if conf.Retention != "" {
+		var err error
+		if retention, err = time.ParseDuration(conf.Retention); err != nil {
+			return nil, fmt.Errorf("failed to parse journal retention: %w", err)
+		}
+	}
+
+	j, corrupt, err := openJournal(conf.Path, retention, conf.Sync)
+	if err != nil {
+		return nil, err
+	}
+	if corrupt > 0 {
+		mgr.Logger().Warnf("Ignored %v unreadable records of journal '%v'\n", corrupt, conf.Path)
+	}
+
+	return &WithJournal{
+		out:             out,
+		journal:         j,
+		key:             key,
+		flag:            flag,
+		log:             mgr.Logger(),
+		transactionsOut: make(chan message.Transaction),
+		shutSig:         shutdown.NewSignaller(),
+	}, nil
+}
+
+//------------------------------------------------------------------------------
+
+// delivered returns the keys of sent parts that were delivered according to
+// the error returned by the wrapped output, along with the error to
+// acknowledge the source transaction with.
+func (w *WithJournal) delivered(group *message.SortGroup, sourceMsg *message.Batch, parts []*message.Part, keys []string, err error) ([]string, error) {
+	if err == nil {
+		return keys, nil
+	}
+
+	bErr, ok := err.(*batch.Error)
+	if !ok {
+		return nil, err
+	}
+
+	failedIndexes := map[int]struct{}{}
+	sourceErr := batch.NewError(sourceMsg, err)
+	bErr.WalkParts(func(i int, p *message.Part, e error) bool {
+		if e == nil {
+			return true
+		}
+		index := group.GetIndex(p)
+		if index == -1 {
+			ok = false
+			return false
+		}
+		failedIndexes[index] = struct{}{}
+		sourceErr.Failed(index, e)
+		return true
+	})
+	if !ok {
+		return nil, err
+	}
+
+	var deliveredKeys []string
+	for i, p := range parts {
+		if _, failed := failedIndexes[group.GetIndex(p)]; !failed {
+			deliveredKeys = append(deliveredKeys, keys[i])
+		}
+	}
+	return deliveredKeys, sourceErr
+}
+
+func (w *WithJournal) loop() {
+	defer func() {
+		close(w.transactionsOut)
+		w.out.CloseAsync()
+		_ = w.out.WaitForClose(shutdown.MaximumShutdownWait())
+		if err := w.journal.close(); err != nil {
+			w.log.Errorf("Failed to close journal: %v\n", err)
+		}
+		w.shutSig.ShutdownComplete()
+	}()
+
+	ctx, done := w.shutSig.CloseAtLeisureCtx(context.Background())
+	defer done()
+
+	for {
+		var ts message.Transaction
+		var open bool
+		select {
+		case ts, open = <-w.transactionsIn:
+			if !open {
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+
+		group, trackedMsg := message.NewSortGroup(ts.Payload)
+
+		var parts, redelivered []*message.Part
+		var keys, skippedKeys []string
+		_ = trackedMsg.Iter(func(i int, p *message.Part) error {
+			key := w.key.String(i, trackedMsg)
+			if w.journal.isUnacked(key) {
+				if !w.flag {
+					skippedKeys = append(skippedKeys, key)
+					return nil
+				}
+				redelivered = append(redelivered, p)
+			}
+			parts = append(parts, p)
+			keys = append(keys, key)
+			return nil
+		})
+		for _, p := range redelivered {
+			p.MetaSet(JournalRedeliveredMetaKey, "true")
+		}
+		if len(skippedKeys) > 0 {
+			w.log.Debugf("Skipping %v messages that were already delivered\n", len(skippedKeys))
+		}
+
+		if len(parts) == 0 {
+			if aerr := ts.Ack(ctx, nil); aerr != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				continue
+			}
+			if err := w.journal.record(journalStatusAcked, skippedKeys); err != nil {
+				w.log.Errorf("Failed to record acknowledgement within journal: %v\n", err)
+			}
+			continue
+		}
+
+		sendMsg := message.QuickBatch(nil)
+		sendMsg.SetAll(parts)
+
+		select {
+		case w.transactionsOut <- message.NewTransactionFunc(sendMsg, func(ackCtx context.Context, err error) error {
+			deliveredKeys, sourceErr := w.delivered(group, trackedMsg, parts, keys, err)
+			if jerr := w.journal.record(journalStatusDelivered, deliveredKeys); jerr != nil {
+				w.log.Errorf("Failed to record delivery within journal: %v\n", jerr)
+			}
+			if aerr := ts.Ack(ackCtx, sourceErr); aerr != nil || sourceErr != nil {
+				return aerr
+			}
+			if jerr := w.journal.record(journalStatusAcked, append(deliveredKeys, skippedKeys...)); jerr != nil {
+				w.log.Errorf("Failed to record acknowledgement within journal: %v\n", jerr)
+			}
+			return nil
+		}):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// Consume starts the type listening to a message channel from a
+// producer.
+func (w *WithJournal) Consume(ts <-chan message.Transaction) error {
+	if w.transactionsIn != nil {
+		return component.ErrAlreadyStarted
+	}
+	if err := w.out.Consume(w.transactionsOut); err != nil {
+		return err
+	}
+	w.transactionsIn = ts
+	go w.loop()
+	return nil
+}
+
+// Connected returns a boolean indicating whether this output is currently
+// connected to its target.
+func (w *WithJournal) Connected() bool {
+	return w.out.Connected()
+}
+
+// CloseAsync triggers a closure of this object but does not block.
+func (w *WithJournal) CloseAsync() {
+	w.shutSig.CloseAtLeisure()
+}
+
+// WaitForClose is a blocking call to wait until the object has finished closing
+// down and cleaning up resources.
+func (w *WithJournal) WaitForClose(timeout time.Duration) error {
+	select {
+	case <-w.shutSig.HasClosedChan():
+	case <-time.After(timeout):
+		return component.ErrTimeout
+	}
+	return nil
+}
@@ -0,0 +1,230 @@
+package output_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/batch"
+	"github.com/benthosdev/benthos/v4/internal/bloblang"
+	"github.com/benthosdev/benthos/v4/internal/component/output"
+	"github.com/benthosdev/benthos/v4/internal/manager/mock"
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+func testJournaled(t *testing.T, conf output.JournalConfig) (*output.WithJournal, *mockOutput, chan message.Transaction) {
+	t.Helper()
+
+	key, err := bloblang.GlobalEnvironment().NewField(`${! json("id") }`)
+	require.NoError(t, err)
+
+	mockOut := &mockOutput{}
+	j, err := output.WrapWithJournal(mockOut, conf, key, mock.NewManager())
+	require.NoError(t, err)
+
+	tChan := make(chan message.Transaction)
+	require.NoError(t, j.Consume(tChan))
+	return j, mockOut, tChan
+}
+
+func closeJournaled(t *testing.T, j *output.WithJournal, tChan chan message.Transaction) {
+	t.Helper()
+
+	close(tChan)
+	j.CloseAsync()
+	require.NoError(t, j.WaitForClose(time.Second*5))
+}
+
+func TestJournalSkipsRedeliveries(t *testing.T) {
+	conf := output.NewJournalConfig()
+	conf.Path = filepath.Join(t.TempDir(), "journal.jsonl")
+
+	// Messages delivered where the upstream acknowledgement fails, which is
+	// similar to a crash before the acknowledgement reaches the input.
+	j, mockOut, tChan := testJournaled(t, conf)
+
+	upstreamErr := errors.New("upstream nope")
+	resChan := make(chan error, 1)
+	go func() {
+		tChan <- message.NewTransactionFunc(message.QuickBatch([][]byte{
+			[]byte(`{"id":"a"}`), []byte(`{"id":"b"}`),
+		}), func(ctx context.Context, err error) error {
+			resChan <- err
+			return upstreamErr
+		})
+	}()
+
+	var ts message.Transaction
+	select {
+	case ts = <-mockOut.ts:
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out")
+	}
+	assert.Equal(t, 2, ts.Payload.Len())
+	require.Equal(t, upstreamErr, ts.Ack(context.Background(), nil))
+	require.NoError(t, <-resChan)
+
+	closeJournaled(t, j, tChan)
+
+	// After a restart the message a is redelivered along with a new message.
+	j, mockOut, tChan = testJournaled(t, conf)
+
+	go func() {
+		tChan <- message.NewTransactionFunc(message.QuickBatch([][]byte{
+			[]byte(`{"id":"a"}`), []byte(`{"id":"c"}`),
+		}), func(ctx context.Context, err error) error {
+			resChan <- err
+			return nil
+		})
+	}()
+
+	select {
+	case ts = <-mockOut.ts:
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out")
+	}
+	require.Equal(t, 1, ts.Payload.Len())
+	assert.Equal(t, `{"id":"c"}`, string(ts.Payload.Get(0).Get()))
+	require.NoError(t, ts.Ack(context.Background(), nil))
+	require.NoError(t, <-resChan)
+
+	// A batch where all messages were delivered is acknowledged without being
+	// sent.
+	go func() {
+		tChan <- message.NewTransactionFunc(message.QuickBatch([][]byte{
+			[]byte(`{"id":"b"}`),
+		}), func(ctx context.Context, err error) error {
+			resChan <- err
+			return errors.New("upstream nope")
+		})
+	}()
+
+	select {
+	case <-mockOut.ts:
+		t.Fatal("unexpected message")
+	case err := <-resChan:
+		require.NoError(t, err)
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out")
+	}
+
+	closeJournaled(t, j, tChan)
+
+	// The message b was never acknowledged and so is flagged.
+	conf.OnRedelivery = "flag"
+	j, mockOut, tChan = testJournaled(t, conf)
+
+	go func() {
+		tChan <- message.NewTransactionFunc(message.QuickBatch([][]byte{
+			[]byte(`{"id":"a"}`), []byte(`{"id":"b"}`),
+		}), func(ctx context.Context, err error) error {
+			resChan <- err
+			return nil
+		})
+	}()
+
+	select {
+	case ts = <-mockOut.ts:
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out")
+	}
+	require.Equal(t, 2, ts.Payload.Len())
+	assert.Equal(t, "", ts.Payload.Get(0).MetaGet(output.JournalRedeliveredMetaKey))
+	assert.Equal(t, "true", ts.Payload.Get(1).MetaGet(output.JournalRedeliveredMetaKey))
+	require.NoError(t, ts.Ack(context.Background(), nil))
+	require.NoError(t, <-resChan)
+
+	closeJournaled(t, j, tChan)
+
+	// Compacted on open, leaving no unacknowledged keys.
+	_, _, tChan = testJournaled(t, conf)
+	close(tChan)
+
+	b, err := os.ReadFile(conf.Path)
+	require.NoError(t, err)
+	assert.Empty(t, string(b))
+}
+
+func TestJournalBatchErrors(t *testing.T) {
+	conf := output.NewJournalConfig()
+	conf.Path = filepath.Join(t.TempDir(), "journal.jsonl")
+
+	j, mockOut, tChan := testJournaled(t, conf)
+
+	resChan := make(chan error, 1)
+	go func() {
+		tChan <- message.NewTransactionFunc(message.QuickBatch([][]byte{
+			[]byte(`{"id":"a"}`), []byte(`{"id":"b"}`), []byte(`{"id":"c"}`),
+		}), func(ctx context.Context, err error) error {
+			resChan <- err
+			return nil
+		})
+	}()
+
+	var ts message.Transaction
+	select {
+	case ts = <-mockOut.ts:
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out")
+	}
+	require.NoError(t, ts.Ack(context.Background(), batch.NewError(ts.Payload, errors.New("nope")).Failed(1, errors.New("b nope"))))
+
+	var bErr *batch.Error
+	require.ErrorAs(t, <-resChan, &bErr)
+	assert.Equal(t, 1, bErr.IndexedErrors())
+
+	closeJournaled(t, j, tChan)
+
+	// Messages a and c were delivered but the batch was rejected upstream, and
+	// so only b is delivered again.
+	j, mockOut, tChan = testJournaled(t, conf)
+
+	go func() {
+		tChan <- message.NewTransactionFunc(message.QuickBatch([][]byte{
+			[]byte(`{"id":"a"}`), []byte(`{"id":"b"}`), []byte(`{"id":"c"}`),
+		}), func(ctx context.Context, err error) error {
+			resChan <- err
+			return nil
+		})
+	}()
+
+	select {
+	case ts = <-mockOut.ts:
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out")
+	}
+	require.Equal(t, 1, ts.Payload.Len())
+	assert.Equal(t, `{"id":"b"}`, string(ts.Payload.Get(0).Get()))
+	require.NoError(t, ts.Ack(context.Background(), nil))
+	require.NoError(t, <-resChan)
+
+	closeJournaled(t, j, tChan)
+}
+
+func TestJournalUnreadableRecords(t *testing.T) {
+	conf := output.NewJournalConfig()
+	conf.Path = filepath.Join(t.TempDir(), "journal.jsonl")
+
+	require.NoError(t, os.WriteFile(conf.Path, []byte(`{"key":"a","status":"delivered","time":9999999999}
+{"key":"b","status":"delivered","time":9999999999}
+{"key":"b","status":"acked"}
+{"key":"c","status":"delivered","time":1}
+{"key":"d","stat`), 0o644))
+
+	_, _, tChan := testJournaled(t, conf)
+	close(tChan)
+
+	b, err := os.ReadFile(conf.Path)
+	require.NoError(t, err)
+	assert.Equal(t, `{"key":"a","status":"delivered","time":9999999999}`+"\n", string(b))
+
+	conf.OnRedelivery = "nope"
+	_, err = output.WrapWithJournal(&mockOutput{}, conf, nil, mock.NewManager())
+	require.EqualError(t, err, "unrecognised journal on_redelivery value: nope")
+}
@@ -1,17 +1,72 @@
 package processor
 
+import (
+	"encoding/json"
+)
+
 // BranchConfig contains configuration fields for the Branch processor.
 type BranchConfig struct {
-	RequestMap string   `json:"request_map" yaml:"request_map"`
-	Processors []Config `json:"processors" yaml:"processors"`
-	ResultMap  string   `json:"result_map" yaml:"result_map"`
+	Check      string            `json:"check" yaml:"check"`
+	RequestMap string            `json:"request_map" yaml:"request_map"`
+	Processors []Config          `json:"processors" yaml:"processors"`
+	ResultMap  string            `json:"result_map" yaml:"result_map"`
+	Timeout    string            `json:"timeout" yaml:"timeout"`
+	Retry      BranchRetryConfig `json:"retry" yaml:"retry"`
 }
 
 // NewBranchConfig returns a BranchConfig with default values.
 func NewBranchConfig() BranchConfig {
 	return BranchConfig{
+		Check:      "",
 		RequestMap: "",
 		Processors: []Config{},
 		ResultMap:  "",
+		Timeout:    "",
+		Retry:      NewBranchRetryConfig(),
+	}
+}
+
+// UnmarshalJSON ensures that when parsing configs that are in a map or slice
+// the default values are still applied.
+func (b *BranchConfig) UnmarshalJSON(bytes []byte) error {
+	type confAlias BranchConfig
+	aliased := confAlias(NewBranchConfig())
+
+	if err := json.Unmarshal(bytes, &aliased); err != nil {
+		return err
+	}
+
+	*b = BranchConfig(aliased)
+	return nil
+}
+
+// UnmarshalYAML ensures that when parsing configs that are in a map or slice
+// the default values are still applied.
+func (b *BranchConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type confAlias BranchConfig
+	aliased := confAlias(NewBranchConfig())
+
+	if err := unmarshal(&aliased); err != nil {
+		return err
+	}
+
+	*b = BranchConfig(aliased)
+	return nil
+}
+
+// BranchRetryConfig contains configuration fields for reattempting the child
+// processors of a branch.
+type BranchRetryConfig struct {
+	MaxRetries      int    `json:"max_retries" yaml:"max_retries"`
+	InitialInterval string `json:"initial_interval" yaml:"initial_interval"`
+	MaxInterval     string `json:"max_interval" yaml:"max_interval"`
+}
+
+// NewBranchRetryConfig returns a BranchRetryConfig with default values.
+func NewBranchRetryConfig() BranchRetryConfig {
+	return BranchRetryConfig{
+		MaxRetries:      0,
+		InitialInterval: "500ms",
+		MaxInterval:     "3s",
 	}
 }
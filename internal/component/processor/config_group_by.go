@@ -4,6 +4,7 @@ package processor
 // group specific processors.
 type GroupByElement struct {
 	Check      string   `json:"check" yaml:"check"`
+	CEL        string   `json:"cel" yaml:"cel"`
 	Processors []Config `json:"processors" yaml:"processors"`
 }
 
@@ -2,18 +2,24 @@ package processor
 
 // MetricConfig contains configuration fields for the Metric processor.
 type MetricConfig struct {
-	Type   string            `json:"type" yaml:"type"`
-	Name   string            `json:"name" yaml:"name"`
-	Labels map[string]string `json:"labels" yaml:"labels"`
-	Value  string            `json:"value" yaml:"value"`
+	Type                string            `json:"type" yaml:"type"`
+	Name                string            `json:"name" yaml:"name"`
+	Labels              map[string]string `json:"labels" yaml:"labels"`
+	Value               string            `json:"value" yaml:"value"`
+	Check               string            `json:"check" yaml:"check"`
+	Buckets             []float64         `json:"buckets" yaml:"buckets"`
+	MaxLabelCardinality int               `json:"max_label_cardinality" yaml:"max_label_cardinality"`
 }
 
 // NewMetricConfig returns a MetricConfig with default values.
 func NewMetricConfig() MetricConfig {
 	return MetricConfig{
-		Type:   "",
-		Name:   "",
-		Labels: map[string]string{},
-		Value:  "",
+		Type:                "",
+		Name:                "",
+		Labels:              map[string]string{},
+		Value:               "",
+		Check:               "",
+		Buckets:             []float64{},
+		MaxLabelCardinality: 0,
 	}
 }
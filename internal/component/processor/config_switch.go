@@ -8,6 +8,7 @@ import (
 // individual case in the Switch processor.
 type SwitchCaseConfig struct {
 	Check       string   `json:"check" yaml:"check"`
+	CEL         string   `json:"cel" yaml:"cel"`
 	Processors  []Config `json:"processors" yaml:"processors"`
 	Fallthrough bool     `json:"fallthrough" yaml:"fallthrough"`
 }
@@ -16,6 +17,7 @@ type SwitchCaseConfig struct {
 func NewSwitchCaseConfig() SwitchCaseConfig {
 	return SwitchCaseConfig{
 		Check:       "",
+		CEL:         "",
 		Processors:  []Config{},
 		Fallthrough: false,
 	}
@@ -7,6 +7,7 @@ type WorkflowConfig struct {
 	Order           [][]string              `json:"order" yaml:"order"`
 	BranchResources []string                `json:"branch_resources" yaml:"branch_resources"`
 	Branches        map[string]BranchConfig `json:"branches" yaml:"branches"`
+	TraceKey        string                  `json:"trace_metadata_key" yaml:"trace_metadata_key"`
 }
 
 // NewWorkflowConfig returns a default WorkflowConfig.
@@ -16,5 +17,6 @@ func NewWorkflowConfig() WorkflowConfig {
 		Order:           [][]string{},
 		BranchResources: []string{},
 		Branches:        map[string]BranchConfig{},
+		TraceKey:        "",
 	}
 }
@@ -6,6 +6,7 @@ import (
 
 	"github.com/benthosdev/benthos/v4/internal/component"
 	"github.com/benthosdev/benthos/v4/internal/component/metrics"
+	"github.com/benthosdev/benthos/v4/internal/log"
 	"github.com/benthosdev/benthos/v4/internal/message"
 	"github.com/benthosdev/benthos/v4/internal/shutdown"
 	"github.com/benthosdev/benthos/v4/internal/tracing"
@@ -84,6 +85,7 @@ func (a *v2ToV1Processor) ProcessMessage(msg *message.Batch) ([]*message.Batch,
 		if err != nil {
 			newPart := part.Copy()
 			a.mError.Incr(1)
+			log.ForMessage(a.mgr.Logger(), part).Debugf("Processor failed: %v", err)
 			MarkErr(newPart, span, err)
 			nextParts = append(nextParts, newPart)
 		}
@@ -167,6 +169,7 @@ func (a *v2BatchedToV1Processor) ProcessMessage(msg *message.Batch) ([]*message.
 	outputBatches, err := a.p.ProcessBatch(context.Background(), spans, msg)
 	if err != nil {
 		a.mError.Incr(1)
+		log.ForBatch(a.mgr.Logger(), msg).Debugf("Processor failed: %v", err)
 		outputBatch := msg.Copy()
 		_ = outputBatch.Iter(func(i int, p *message.Part) error {
 			MarkErr(p, spans[i], err)
@@ -1,11 +1,14 @@
 package tracer
 
+import "github.com/benthosdev/benthos/v4/internal/impl/gcp/auth"
+
 // CloudTraceConfig is config for the Google Cloud Trace tracer.
 type CloudTraceConfig struct {
 	Project       string            `json:"project" yaml:"project"`
 	SamplingRatio float64           `json:"sampling_ratio" yaml:"sampling_ratio"`
 	Tags          map[string]string `json:"tags" yaml:"tags"`
 	FlushInterval string            `json:"flush_interval" yaml:"flush_interval"`
+	Credentials   auth.Config       `json:"credentials" yaml:"credentials"`
 }
 
 // NewCloudTraceConfig creates an CloudTraceConfig struct with default values.
@@ -15,5 +18,6 @@ func NewCloudTraceConfig() CloudTraceConfig {
 		SamplingRatio: 1.0,
 		Tags:          map[string]string{},
 		FlushInterval: "",
+		Credentials:   auth.New(),
 	}
 }
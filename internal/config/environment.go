@@ -0,0 +1,105 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/benthosdev/benthos/v4/internal/bundle"
+	"github.com/benthosdev/benthos/v4/internal/bundle/accounting"
+	"github.com/benthosdev/benthos/v4/internal/bundle/flightrecorder"
+	"github.com/benthosdev/benthos/v4/internal/bundle/latency"
+	"github.com/benthosdev/benthos/v4/internal/bundle/lineage"
+	"github.com/benthosdev/benthos/v4/internal/bundle/logfields"
+	"github.com/benthosdev/benthos/v4/internal/bundle/metapolicy"
+	"github.com/benthosdev/benthos/v4/internal/bundle/quarantine"
+	"github.com/benthosdev/benthos/v4/internal/log"
+	"github.com/benthosdev/benthos/v4/internal/manager"
+)
+
+// WrapEnvironment returns a bundle environment modified according to the
+// top-level sections of a config that change the behaviour of all components,
+// such as lineage, metadata policies and latency tracking. Endpoints for
+// inspecting these features are registered with the provided API, including
+// the ring buffer of the logger when it has one. An optional hash of the
+// running config is added to the lineage of messages.
+//
+// The provided environment is returned unchanged when none of these sections
+// are enabled.
+func WrapEnvironment(env *bundle.Environment, conf Type, configHash string, logger log.Modular, apiReg manager.APIReg) (*bundle.Environment, error) {
+	if l, ok := logger.(*log.Logger); ok {
+		if ringBuffer := l.RingBuffer(); ringBuffer != nil {
+			apiReg.RegisterEndpoint(
+				"/debug/logs",
+				"DEBUG: Returns the most recent logs kept in memory by the logger ring buffer.",
+				ringBuffer.Handler(),
+			)
+		}
+	}
+
+	var err error
+
+	// Wrap inputs so that messages are stamped with lineage metadata.
+	if conf.Lineage.Enabled {
+		if env, err = lineage.StampedBundle(env, conf.Lineage, configHash); err != nil {
+			return nil, fmt.Errorf("failed to initialise lineage: %w", err)
+		}
+	}
+
+	// Wrap inputs so that messages carry fields to be added to related logs.
+	if len(conf.Logger.MessageFields) > 0 {
+		env = logfields.EnrichedBundle(env, conf.Logger.MessageFields)
+	}
+
+	// Wrap outputs so that the metadata policy is applied to all messages.
+	if conf.MetadataPolicy.IsSet() {
+		if env, err = metapolicy.PolicyBundle(env, conf.MetadataPolicy); err != nil {
+			return nil, fmt.Errorf("failed to initialise metadata policy: %w", err)
+		}
+	}
+
+	// Wrap inputs and outputs so that the end-to-end latency of messages is
+	// tracked. This is applied after the metadata policy so that the ingest
+	// time is read before it can be removed.
+	if conf.Latency.Enabled {
+		if env, err = latency.TrackedBundle(env, conf.Latency); err != nil {
+			return nil, fmt.Errorf("failed to initialise latency tracking: %w", err)
+		}
+	}
+
+	// Wrap processors so that their executions are recorded.
+	if conf.FlightRecorder.Enabled {
+		recorder := flightrecorder.NewRecorder(conf.FlightRecorder)
+		env = flightrecorder.RecordedBundle(env, recorder)
+		apiReg.RegisterEndpoint(
+			"/debug/flight_recorder",
+			"DEBUG: Returns the most recent processor executions captured by the flight recorder as JSON, a DELETE request discards them.",
+			recorder.Handler(),
+		)
+	}
+
+	// Wrap inputs so that messages that fail to be delivered are quarantined.
+	if conf.Quarantine.Enabled {
+		q, err := quarantine.New(conf.Quarantine)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialise quarantine: %w", err)
+		}
+		env = quarantine.QuarantinedBundle(env, q)
+		apiReg.RegisterEndpoint(
+			"/quarantine",
+			"Lists a page of quarantined messages starting from the sequence of the from query parameter and limited by the limit query parameter, or with an id query parameter returns the quarantined messages of that id. A DELETE request with an id removes the messages and a POST request re-injects them into the input they were consumed from.",
+			q.Handler(),
+		)
+	}
+
+	// Wrap components so that the resources they use are accounted for.
+	if conf.Accounting.Enabled {
+		accountant := accounting.NewAccountant(conf.Accounting)
+		env = accounting.AccountedBundle(env, accountant)
+		apiReg.RegisterEndpoint(
+			"/debug/accounting",
+			"DEBUG: Returns the resources used by each component as JSON, where the CPU time and approximate allocations of components are estimated from a sample of executions.",
+			accountant.Handler(),
+		)
+	}
+
+	return env, nil
+}
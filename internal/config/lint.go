@@ -36,17 +36,13 @@ func ReadFileLinted(path string, rejectDeprecated bool, config *Type) ([]string,
 // LintBytes attempts to report errors within a user config. Returns a slice of
 // lint results.
 func LintBytes(ctx docs.LintContext, rawBytes []byte) ([]string, error) {
-	if bytes.HasPrefix(rawBytes, []byte("# BENTHOS LINT DISABLE")) {
-		return nil, nil
-	}
-
-	var rawNode yaml.Node
-	if err := yaml.Unmarshal(rawBytes, &rawNode); err != nil {
+	lints, err := LintYAMLBytes(ctx, rawBytes)
+	if err != nil {
 		return nil, err
 	}
 
 	var lintStrs []string
-	for _, lint := range Spec().LintYAML(ctx, &rawNode) {
+	for _, lint := range lints {
 		if lint.Level == docs.LintError {
 			lintStrs = append(lintStrs, fmt.Sprintf("line %v: %v", lint.Line, lint.What))
 		}
@@ -54,6 +50,20 @@ func LintBytes(ctx docs.LintContext, rawBytes []byte) ([]string, error) {
 	return lintStrs, nil
 }
 
+// LintYAMLBytes attempts to report errors within a user config. Returns a
+// slice of structured lints of all levels.
+func LintYAMLBytes(ctx docs.LintContext, rawBytes []byte) ([]docs.Lint, error) {
+	if bytes.HasPrefix(rawBytes, []byte("# BENTHOS LINT DISABLE")) {
+		return nil, nil
+	}
+
+	var rawNode yaml.Node
+	if err := yaml.Unmarshal(rawBytes, &rawNode); err != nil {
+		return nil, err
+	}
+	return Spec().LintYAML(ctx, &rawNode), nil
+}
+
 // ReadFileEnvSwap reads a file and replaces any environment variable
 // interpolations before returning the contents. Linting errors are returned if
 // the file has an unexpected higher level format, such as invalid utf-8
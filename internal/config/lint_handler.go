@@ -0,0 +1,111 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/benthosdev/benthos/v4/internal/docs"
+)
+
+const lintHandlerMaxConfigBytes = 10 * 1024 * 1024
+
+// LintResult is a single structured lint reported by LintHandler.
+type LintResult struct {
+	Line   int    `json:"line"`
+	Column int    `json:"column,omitempty"`
+	Level  string `json:"level"`
+	Type   string `json:"type"`
+	What   string `json:"what"`
+}
+
+// LintResponse is the body of responses from LintHandler, where Valid is false
+// when the config could not be parsed or any lints are errors.
+type LintResponse struct {
+	Valid bool         `json:"valid"`
+	Error string       `json:"error,omitempty"`
+	Lints []LintResult `json:"lints"`
+}
+
+// lintConfigBytes parses and lints a candidate config. Deprecated fields and
+// components are reported as warnings unless rejectDeprecated is true, in
+// which case they are errors that render the config invalid.
+func lintConfigBytes(configBytes []byte, rejectDeprecated bool) LintResponse {
+	res := LintResponse{Lints: []LintResult{}}
+
+	if !utf8.Valid(configBytes) {
+		res.Lints = append(res.Lints, LintResult{
+			Level: "warning",
+			Type:  docs.LintCustom.String(),
+			What:  "Detected invalid utf-8 encoding in config, this may result in interpolation functions not working as expected",
+		})
+	}
+
+	conf := New()
+	if err := yaml.Unmarshal(configBytes, &conf); err != nil {
+		res.Error = err.Error()
+		return res
+	}
+
+	lintCtx := docs.NewLintContext()
+	lintCtx.RejectDeprecated = true
+	lints, err := LintYAMLBytes(lintCtx, configBytes)
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+
+	res.Valid = true
+	for _, l := range lints {
+		level := "error"
+		if l.Level == docs.LintWarning || (l.Type == docs.LintDeprecated && !rejectDeprecated) {
+			level = "warning"
+		}
+		if level == "error" {
+			res.Valid = false
+		}
+		res.Lints = append(res.Lints, LintResult{
+			Line:   l.Line,
+			Column: l.Column,
+			Level:  level,
+			Type:   l.Type.String(),
+			What:   l.What,
+		})
+	}
+	return res
+}
+
+// LintHandler returns a handler that accepts a candidate config as the body of
+// a POST request and responds with the structured results of linting it. The
+// query parameter `deprecated=true` causes deprecated fields to be errors.
+func LintHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		configBytes, err := io.ReadAll(http.MaxBytesReader(w, r.Body, lintHandlerMaxConfigBytes))
+		if err != nil {
+			if len(configBytes) >= lintHandlerMaxConfigBytes {
+				http.Error(w, fmt.Sprintf("config exceeds the maximum size of %v bytes", lintHandlerMaxConfigBytes), http.StatusRequestEntityTooLarge)
+				return
+			}
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		res := lintConfigBytes(configBytes, r.URL.Query().Get("deprecated") == "true")
+		resBytes, err := json.Marshal(res)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(resBytes)
+	}
+}
@@ -0,0 +1,134 @@
+package config_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/config"
+
+	_ "github.com/benthosdev/benthos/v4/public/components/pure"
+)
+
+func TestLintHandler(t *testing.T) {
+	type lintSummary struct {
+		Line  int
+		Level string
+		Type  string
+	}
+
+	tests := []struct {
+		name   string
+		config string
+		query  string
+		valid  bool
+		err    string
+		lints  []lintSummary
+	}{
+		{
+			name: "valid config",
+			config: `
+input:
+  generate:
+    mapping: 'root = "hello"'
+`,
+			valid: true,
+		},
+		{
+			name: "unknown field and bad interpolation",
+			config: `
+input:
+  generate:
+    mapping: 'root = "hello"'
+    nope: true
+pipeline:
+  processors:
+    - log:
+        message: '${! meta( }'
+`,
+			lints: []lintSummary{
+				{Line: 5, Level: "error", Type: "unknown_field"},
+				{Line: 9, Level: "error", Type: "bad_interpolation"},
+			},
+		},
+		{
+			name: "deprecated field warning",
+			config: `
+pipeline:
+  processors:
+    - log:
+        fields: { foo: bar }
+`,
+			valid: true,
+			lints: []lintSummary{
+				{Line: 5, Level: "warning", Type: "deprecated"},
+			},
+		},
+		{
+			name: "deprecated field rejected",
+			config: `
+pipeline:
+  processors:
+    - log:
+        fields: { foo: bar }
+`,
+			query: "?deprecated=true",
+			lints: []lintSummary{
+				{Line: 5, Level: "error", Type: "deprecated"},
+			},
+		},
+		{
+			name:   "parse error",
+			config: "input: [",
+			err:    "did not find expected node content",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/lint"+test.query, strings.NewReader(test.config))
+			rec := httptest.NewRecorder()
+			config.LintHandler()(rec, req)
+			require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+			var res config.LintResponse
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+			assert.Equal(t, test.valid, res.Valid)
+			if test.err != "" {
+				assert.Contains(t, res.Error, test.err)
+			} else {
+				assert.Empty(t, res.Error)
+			}
+
+			seen := map[lintSummary]struct{}{}
+			for _, l := range res.Lints {
+				seen[lintSummary{Line: l.Line, Level: l.Level, Type: l.Type}] = struct{}{}
+			}
+			assert.Len(t, seen, len(test.lints))
+			for _, l := range test.lints {
+				assert.Contains(t, seen, l)
+			}
+		})
+	}
+}
+
+func TestLintHandlerMethod(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/lint", nil)
+	rec := httptest.NewRecorder()
+	config.LintHandler()(rec, req)
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
+
+func TestLintHandlerTooLarge(t *testing.T) {
+	body := strings.Repeat("#", 10*1024*1024+1)
+	req := httptest.NewRequest(http.MethodPost, "/lint", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	config.LintHandler()(rec, req)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+}
@@ -2,12 +2,18 @@ package config
 
 import (
 	"github.com/benthosdev/benthos/v4/internal/api"
+	"github.com/benthosdev/benthos/v4/internal/bundle/accounting"
+	"github.com/benthosdev/benthos/v4/internal/bundle/flightrecorder"
+	"github.com/benthosdev/benthos/v4/internal/bundle/latency"
+	"github.com/benthosdev/benthos/v4/internal/bundle/lineage"
+	"github.com/benthosdev/benthos/v4/internal/bundle/quarantine"
 	tdocs "github.com/benthosdev/benthos/v4/internal/cli/test/docs"
 	"github.com/benthosdev/benthos/v4/internal/component/metrics"
 	"github.com/benthosdev/benthos/v4/internal/component/tracer"
 	"github.com/benthosdev/benthos/v4/internal/docs"
 	"github.com/benthosdev/benthos/v4/internal/log"
 	"github.com/benthosdev/benthos/v4/internal/manager"
+	"github.com/benthosdev/benthos/v4/internal/metadata"
 	"github.com/benthosdev/benthos/v4/internal/stream"
 )
 
@@ -16,11 +22,17 @@ type Type struct {
 	HTTP                   api.Config `json:"http" yaml:"http"`
 	stream.Config          `json:",inline" yaml:",inline"`
 	manager.ResourceConfig `json:",inline" yaml:",inline"`
-	Logger                 log.Config     `json:"logger" yaml:"logger"`
-	Metrics                metrics.Config `json:"metrics" yaml:"metrics"`
-	Tracer                 tracer.Config  `json:"tracer" yaml:"tracer"`
-	SystemCloseTimeout     string         `json:"shutdown_timeout" yaml:"shutdown_timeout"`
-	Tests                  []interface{}  `json:"tests,omitempty" yaml:"tests,omitempty"`
+	Logger                 log.Config            `json:"logger" yaml:"logger"`
+	Metrics                metrics.Config        `json:"metrics" yaml:"metrics"`
+	Tracer                 tracer.Config         `json:"tracer" yaml:"tracer"`
+	Lineage                lineage.Config        `json:"lineage" yaml:"lineage"`
+	Latency                latency.Config        `json:"latency" yaml:"latency"`
+	MetadataPolicy         metadata.PolicyConfig `json:"metadata_policy" yaml:"metadata_policy"`
+	FlightRecorder         flightrecorder.Config `json:"flight_recorder" yaml:"flight_recorder"`
+	Quarantine             quarantine.Config     `json:"quarantine" yaml:"quarantine"`
+	Accounting             accounting.Config     `json:"accounting" yaml:"accounting"`
+	SystemCloseTimeout     string                `json:"shutdown_timeout" yaml:"shutdown_timeout"`
+	Tests                  []interface{}         `json:"tests,omitempty" yaml:"tests,omitempty"`
 }
 
 // New returns a new configuration with default values.
@@ -32,6 +44,12 @@ func New() Type {
 		Logger:             log.NewConfig(),
 		Metrics:            metrics.NewConfig(),
 		Tracer:             tracer.NewConfig(),
+		Lineage:            lineage.NewConfig(),
+		Latency:            latency.NewConfig(),
+		MetadataPolicy:     metadata.NewPolicyConfig(),
+		FlightRecorder:     flightrecorder.NewConfig(),
+		Quarantine:         quarantine.NewConfig(),
+		Accounting:         accounting.NewConfig(),
 		SystemCloseTimeout: "20s",
 		Tests:              nil,
 	}
@@ -43,6 +61,12 @@ var observabilityFields = docs.FieldSpecs{
 	docs.FieldObject("logger", "Describes how operational logs should be emitted.").WithChildren(log.Spec()...),
 	docs.FieldMetrics("metrics", "A mechanism for exporting metrics.").Optional(),
 	docs.FieldTracer("tracer", "A mechanism for exporting traces.").Optional(),
+	docs.FieldObject("lineage", "Configures the stamping of messages with lineage metadata, describing the input that consumed them along with the offset or ID of the message within its source where it is known, the instance and the config. Lineage received from upstream systems within the metadata of messages is replaced.").WithChildren(lineage.Spec()...).Advanced(),
+	docs.FieldObject("latency", "Configures the tracking of the end-to-end latency of messages, from the time they are consumed by an input to the time they are delivered by an output, along with the burn rates of latency objectives.").WithChildren(latency.Spec()...).Advanced(),
+	docs.FieldObject("metadata_policy", "Rules applied to the metadata of all messages before they are written by any output, which can be used to ensure that internal metadata is never leaked to external systems. By default no rules are set and all metadata is written by outputs that support it, including metadata added by inputs such as message headers. Setting `allow_prefixes` or `allow_patterns` switches to an allow list, where only matching keys are written. Allow rules are applied first, followed by exclusions, redactions and finally the size limit.").WithChildren(metadata.PolicyFields()...).Advanced(),
+	docs.FieldObject("flight_recorder", "Configures a flight recorder that keeps snapshots of messages before and after each processor execution, along with timings, for the most recent executions. This is useful for debugging pipelines that are running in production.").WithChildren(flightrecorder.Spec()...).Advanced(),
+	docs.FieldObject("quarantine", "Configures a quarantine for messages that fail to be delivered after all retries, which are stored within a cache resource and can be browsed and re-injected via the HTTP endpoint `/quarantine`. This is useful as a generic dead letter queue for inputs that do not have one natively.").WithChildren(quarantine.Spec()...).Advanced(),
+	docs.FieldObject("accounting", "Configures the accounting of resources used by each component, where the CPU time and approximate allocations of processors, inputs and outputs are estimated from a sample of executions and the messages and bytes of inputs and outputs are counted. Usage is exposed as metrics and via the HTTP endpoint `/debug/accounting`, which is useful for attributing cost within pipelines that have many processors.").WithChildren(accounting.Spec()...).Advanced(),
 	docs.FieldString("shutdown_timeout", "The maximum period of time to wait for a clean shutdown. If this time is exceeded Benthos will forcefully close.").HasDefault("20s"),
 }
 
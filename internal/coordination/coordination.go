@@ -0,0 +1,254 @@
+// Package coordination distributes the partitions of a source, such as the
+// prefixes of a bucket, the shards of a stream or the ranges of a table, across
+// the instances of a group by claiming them within a shared backend.
+//
+// Each instance periodically balances its claims: it renews the claims it
+// holds, claims free partitions up to its fair share, steals a partition from
+// the most loaded instance when it holds too few, and releases partitions that
+// exceed its fair share. Instances are only aware of each other through the
+// partitions they hold, and therefore the claims of instances that stop
+// renewing them expire and are claimed by the remaining instances.
+package coordination
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+)
+
+// Errors returned by backends.
+var (
+	ErrNotClaimed = errors.New("partition is not claimed")
+	ErrClaimed    = errors.New("partition is already claimed")
+)
+
+// Backend stores the claims of partitions, where claims expire unless they are
+// renewed within their TTL.
+type Backend interface {
+	// Get returns the owner of a claim, or ErrNotClaimed if the partition is
+	// not claimed or its claim has expired.
+	Get(ctx context.Context, key string) (string, error)
+
+	// Create claims a partition for an owner, or returns ErrClaimed if it is
+	// already claimed.
+	Create(ctx context.Context, key, owner string, ttl time.Duration) error
+
+	// Update sets the owner of a claim and renews it.
+	Update(ctx context.Context, key, owner string, ttl time.Duration) error
+
+	// Delete releases a claim.
+	Delete(ctx context.Context, key string) error
+
+	// Close the backend and any connections it holds.
+	Close(ctx context.Context) error
+}
+
+// Changes are the changes to the partitions owned by an instance after a
+// round of balancing.
+type Changes struct {
+	// Assigned partitions have been claimed and should be consumed.
+	Assigned []string
+
+	// Lost partitions are now claimed by another instance and should no
+	// longer be consumed.
+	Lost []string
+
+	// Excess partitions exceed the fair share of the instance, and should no
+	// longer be consumed and then be released with Release.
+	Excess []string
+}
+
+// Coordinator balances the claims of an instance within a group.
+type Coordinator struct {
+	backend  Backend
+	group    string
+	identity string
+	ttl      time.Duration
+	owned    map[string]struct{}
+}
+
+// New returns a coordinator that claims partitions under the group for an
+// identity, which must be unique within the group.
+func New(backend Backend, group, identity string, ttl time.Duration) *Coordinator {
+	return &Coordinator{
+		backend:  backend,
+		group:    group,
+		identity: identity,
+		ttl:      ttl,
+		owned:    map[string]struct{}{},
+	}
+}
+
+func (c *Coordinator) key(partition string) string {
+	return c.group + "/" + partition
+}
+
+// Balance performs a round of balancing over the partitions of the group, and
+// should be called at an interval that is well within the TTL of claims.
+func (c *Coordinator) Balance(ctx context.Context, partitions []string) (changes Changes, err error) {
+	sorted := append([]string(nil), partitions...)
+	sort.Strings(sorted)
+
+	owners := make(map[string]string, len(sorted))
+	for _, p := range sorted {
+		owner, err := c.backend.Get(ctx, c.key(p))
+		if err != nil && !errors.Is(err, ErrNotClaimed) {
+			return changes, err
+		}
+		owners[p] = owner
+	}
+
+	// Renew our own claims, reclaiming those that have expired and adopting
+	// those left behind by a previous process with the same identity.
+	for _, p := range sorted {
+		if _, exists := c.owned[p]; !exists {
+			if owners[p] == c.identity {
+				if err := c.backend.Update(ctx, c.key(p), c.identity, c.ttl); err != nil && !errors.Is(err, ErrClaimed) {
+					return changes, err
+				} else if err == nil {
+					c.owned[p] = struct{}{}
+					changes.Assigned = append(changes.Assigned, p)
+				}
+			}
+			continue
+		}
+		switch owners[p] {
+		case c.identity:
+			err = c.backend.Update(ctx, c.key(p), c.identity, c.ttl)
+		case "":
+			if err = c.backend.Create(ctx, c.key(p), c.identity, c.ttl); err == nil {
+				owners[p] = c.identity
+			}
+		default:
+			err = ErrClaimed
+		}
+		if errors.Is(err, ErrClaimed) {
+			delete(c.owned, p)
+			changes.Lost = append(changes.Lost, p)
+			err = nil
+		}
+		if err != nil {
+			return changes, err
+		}
+	}
+
+	counts := map[string]int{c.identity: 0}
+	var free []string
+	for _, p := range sorted {
+		if owner := owners[p]; owner == c.identity {
+			if _, exists := c.owned[p]; !exists {
+				// Adopting the claim failed, and so it is left alone until it
+				// expires.
+				owners[p] = ""
+				continue
+			}
+			counts[owner]++
+		} else if owner != "" {
+			counts[owner]++
+		} else {
+			free = append(free, p)
+		}
+	}
+	maxShare := (len(sorted) + len(counts) - 1) / len(counts)
+
+	claim := func(p string) {
+		c.owned[p] = struct{}{}
+		if owner := owners[p]; owner != "" {
+			counts[owner]--
+		}
+		owners[p] = c.identity
+		counts[c.identity]++
+		changes.Assigned = append(changes.Assigned, p)
+	}
+
+	for _, p := range free {
+		if counts[c.identity] >= maxShare {
+			break
+		}
+		err := c.backend.Create(ctx, c.key(p), c.identity, c.ttl)
+		if errors.Is(err, ErrClaimed) {
+			continue
+		}
+		if err != nil {
+			return changes, err
+		}
+		claim(p)
+	}
+
+	// Instances without any claims are unknown to the others, and therefore a
+	// partition of the most loaded instance is stolen in order to announce
+	// ourselves, after which that instance releases its excess partitions.
+	if counts[c.identity] < maxShare {
+		var victim string
+		for owner, count := range counts {
+			if owner == c.identity {
+				continue
+			}
+			if count > counts[victim] || (count == counts[victim] && owner < victim) {
+				victim = owner
+			}
+		}
+		if victim != "" && counts[victim] >= counts[c.identity]+2 {
+			for i := len(sorted) - 1; i >= 0; i-- {
+				if p := sorted[i]; owners[p] == victim {
+					if err := c.backend.Update(ctx, c.key(p), c.identity, c.ttl); err != nil {
+						return changes, err
+					}
+					claim(p)
+					break
+				}
+			}
+		}
+	}
+
+	for i := len(sorted) - 1; i >= 0 && counts[c.identity] > maxShare; i-- {
+		if p := sorted[i]; owners[p] == c.identity {
+			changes.Excess = append(changes.Excess, p)
+			counts[c.identity]--
+		}
+	}
+	return changes, nil
+}
+
+// Release the claims of partitions that are no longer consumed, unless they
+// are now claimed by another instance.
+func (c *Coordinator) Release(ctx context.Context, partitions ...string) error {
+	for _, p := range partitions {
+		if _, exists := c.owned[p]; !exists {
+			continue
+		}
+		delete(c.owned, p)
+
+		owner, err := c.backend.Get(ctx, c.key(p))
+		if errors.Is(err, ErrNotClaimed) || (err == nil && owner != c.identity) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if err := c.backend.Delete(ctx, c.key(p)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Owned returns the partitions currently claimed.
+func (c *Coordinator) Owned() []string {
+	owned := make([]string, 0, len(c.owned))
+	for p := range c.owned {
+		owned = append(owned, p)
+	}
+	sort.Strings(owned)
+	return owned
+}
+
+// Abandon forgets all claims without releasing them, which should be done when
+// claims could not be renewed within their TTL and may therefore have been
+// claimed by other instances. Returns the partitions that were claimed.
+func (c *Coordinator) Abandon() []string {
+	owned := c.Owned()
+	c.owned = map[string]struct{}{}
+	return owned
+}
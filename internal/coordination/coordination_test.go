@@ -0,0 +1,173 @@
+package coordination
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memClaim struct {
+	owner   string
+	expires time.Time
+}
+
+type memBackend struct {
+	now    time.Time
+	claims map[string]memClaim
+}
+
+func newMemBackend() *memBackend {
+	return &memBackend{now: time.Unix(0, 0), claims: map[string]memClaim{}}
+}
+
+func (m *memBackend) Get(ctx context.Context, key string) (string, error) {
+	c, exists := m.claims[key]
+	if !exists || !m.now.Before(c.expires) {
+		return "", ErrNotClaimed
+	}
+	return c.owner, nil
+}
+
+func (m *memBackend) Create(ctx context.Context, key, owner string, ttl time.Duration) error {
+	if _, err := m.Get(ctx, key); err == nil {
+		return ErrClaimed
+	}
+	m.claims[key] = memClaim{owner: owner, expires: m.now.Add(ttl)}
+	return nil
+}
+
+func (m *memBackend) Update(ctx context.Context, key, owner string, ttl time.Duration) error {
+	m.claims[key] = memClaim{owner: owner, expires: m.now.Add(ttl)}
+	return nil
+}
+
+func (m *memBackend) Delete(ctx context.Context, key string) error {
+	delete(m.claims, key)
+	return nil
+}
+
+func (m *memBackend) Close(ctx context.Context) error {
+	return nil
+}
+
+func testPartitions(n int) []string {
+	partitions := make([]string, n)
+	for i := range partitions {
+		partitions[i] = fmt.Sprintf("p%02d", i)
+	}
+	return partitions
+}
+
+// balanceRounds performs rounds of balancing for each coordinator, releasing
+// excess partitions as an input would, until no claims change.
+func balanceRounds(t *testing.T, b *memBackend, partitions []string, coords ...*Coordinator) {
+	t.Helper()
+
+	for round := 0; round < 50; round++ {
+		changed := false
+		for _, c := range coords {
+			changes, err := c.Balance(context.Background(), partitions)
+			require.NoError(t, err)
+			require.NoError(t, c.Release(context.Background(), changes.Excess...))
+			if len(changes.Assigned) > 0 || len(changes.Lost) > 0 || len(changes.Excess) > 0 {
+				changed = true
+			}
+		}
+		b.now = b.now.Add(time.Second)
+		if !changed {
+			return
+		}
+	}
+	t.Fatal("claims did not converge")
+}
+
+func assertBalanced(t *testing.T, partitions []string, coords ...*Coordinator) {
+	t.Helper()
+
+	var all []string
+	for _, c := range coords {
+		owned := c.Owned()
+		assert.GreaterOrEqual(t, len(owned), len(partitions)/len(coords), c.identity)
+		assert.LessOrEqual(t, len(owned), (len(partitions)+len(coords)-1)/len(coords), c.identity)
+		all = append(all, owned...)
+	}
+	sort.Strings(all)
+	assert.Equal(t, partitions, all)
+}
+
+func TestCoordinatorConverges(t *testing.T) {
+	b := newMemBackend()
+	partitions := testPartitions(10)
+
+	a := New(b, "foo", "a", time.Second*10)
+	balanceRounds(t, b, partitions, a)
+	assertBalanced(t, partitions, a)
+
+	c1, c2 := New(b, "foo", "b", time.Second*10), New(b, "foo", "c", time.Second*10)
+	balanceRounds(t, b, partitions, a, c1, c2)
+	assertBalanced(t, partitions, a, c1, c2)
+}
+
+func TestCoordinatorMemberLeaves(t *testing.T) {
+	b := newMemBackend()
+	partitions := testPartitions(9)
+
+	a, c1, c2 := New(b, "foo", "a", time.Second*10), New(b, "foo", "b", time.Second*10), New(b, "foo", "c", time.Second*10)
+	balanceRounds(t, b, partitions, a, c1, c2)
+	assertBalanced(t, partitions, a, c1, c2)
+
+	// A member that shuts down releases its claims.
+	require.NoError(t, c2.Release(context.Background(), c2.Owned()...))
+	balanceRounds(t, b, partitions, a, c1)
+	assertBalanced(t, partitions, a, c1)
+
+	// A member that crashes stops renewing its claims, which then expire.
+	for i := 0; i < 10; i++ {
+		_, err := a.Balance(context.Background(), partitions)
+		require.NoError(t, err)
+		b.now = b.now.Add(time.Second)
+	}
+	balanceRounds(t, b, partitions, a)
+	assertBalanced(t, partitions, a)
+}
+
+func TestCoordinatorLost(t *testing.T) {
+	b := newMemBackend()
+	partitions := testPartitions(2)
+
+	a := New(b, "foo", "a", time.Second*10)
+	balanceRounds(t, b, partitions, a)
+	require.Equal(t, partitions, a.Owned())
+
+	// Claims that have been taken over while a member was unable to renew
+	// them are reported as lost.
+	require.NoError(t, b.Update(context.Background(), "foo/p01", "b", time.Second*10))
+
+	changes, err := a.Balance(context.Background(), partitions)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"p01"}, changes.Lost)
+	assert.Equal(t, []string{"p00"}, a.Owned())
+
+	assert.Equal(t, []string{"p00"}, a.Abandon())
+	assert.Empty(t, a.Owned())
+}
+
+func TestCoordinatorAdoptsClaims(t *testing.T) {
+	b := newMemBackend()
+	partitions := testPartitions(4)
+
+	a := New(b, "foo", "a", time.Second*10)
+	balanceRounds(t, b, partitions, a)
+
+	// A restarted member with the same identity resumes its claims.
+	restarted := New(b, "foo", "a", time.Second*10)
+	changes, err := restarted.Balance(context.Background(), partitions)
+	require.NoError(t, err)
+	assert.Equal(t, partitions, changes.Assigned)
+	assert.Equal(t, partitions, restarted.Owned())
+}
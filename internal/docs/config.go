@@ -47,13 +47,13 @@ var labelField = FieldString(
 	}
 	if err := ValidateLabel(l); err != nil {
 		return []Lint{
-			NewLintError(line, fmt.Sprintf("Invalid label '%v': %v", l, err)),
+			NewLintErrorOfType(line, LintInvalidLabel, fmt.Sprintf("Invalid label '%v': %v", l, err)),
 		}
 	}
 	prevLine, exists := ctx.LabelsToLine[l]
 	if exists {
 		return []Lint{
-			NewLintError(line, fmt.Sprintf("Label '%v' collides with a previously defined label at line %v", l, prevLine)),
+			NewLintErrorOfType(line, LintInvalidLabel, fmt.Sprintf("Label '%v' collides with a previously defined label at line %v", l, prevLine)),
 		}
 	}
 	ctx.LabelsToLine[l] = line
@@ -73,6 +73,17 @@ func ReservedFieldsByType(t Type) map[string]FieldSpec {
 			}
 			return "", false
 		})
+		m["connect_backoff"] = ConnectBackoffFieldSpec("connect_backoff")
+	}
+	if t == TypeInput {
+		m["throttle"] = InputThrottleFieldSpec("throttle")
+		m["labels"] = FieldString(
+			"labels", "A map of labels that are added to all metrics, log lines and tracing spans produced by the input and its processors. This makes it possible to tell apart the child inputs of a `broker` that feed a shared pipeline. Label names must consist of letters, numbers and underscores, and must not be `stream`, `label` or `path`.",
+			map[string]string{"source": "orders"},
+		).Map().Optional().Advanced()
+	}
+	if t == TypeOutput {
+		m["journal"] = OutputJournalFieldSpec("journal")
 	}
 	if t == TypeMetrics {
 		m["mapping"] = MetricsMappingFieldSpec("mapping")
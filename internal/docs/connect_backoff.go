@@ -0,0 +1,15 @@
+package docs
+
+// ConnectBackoffFieldSpec returns a field spec for the backoff policy applied
+// between the connection attempts of an input or output.
+func ConnectBackoffFieldSpec(name string) FieldSpec {
+	return FieldObject(
+		name, "An optional backoff policy applied between consecutive attempts to connect to the target of the component, overriding the default policy of the component. This applies to both the initial connection and reconnections after the connection is lost.",
+	).WithChildren(
+		FieldString("initial_interval", "The period to wait after the first failed attempt.", "100ms", "5s").HasDefault("500ms"),
+		FieldFloat("multiplier", "The factor by which the period to wait grows with each consecutive failed attempt, must be at least 1.").HasDefault(1.5),
+		FieldString("max_interval", "The maximum period to wait between attempts.", "1s", "1m").HasDefault("1s"),
+		FieldString("max_elapsed_time", "The maximum period of consecutive failed attempts after which the component gives up and shuts down, which also ends the stream that it belongs to. Set to `0s` in order to keep attempting indefinitely.", "0s", "10m").HasDefault("0s"),
+		FieldBool("fail_fast", "Whether to give up and shut down the component when the initial connection attempt fails, which is useful for detecting misconfigured components at deployment time. Reconnections after a successful connection continue to follow the backoff policy.").HasDefault(false),
+	).Optional().Advanced()
+}
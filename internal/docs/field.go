@@ -105,6 +105,10 @@ type FieldSpec struct {
 	// Bloblang indicates that a string field is a Bloblang mapping.
 	Bloblang bool `json:"bloblang,omitempty"`
 
+	// Tunable indicates that the field can be changed at runtime via the
+	// tunables endpoint of the HTTP server.
+	Tunable bool `json:"tunable,omitempty"`
+
 	// Examples is a slice of optional example values for a field.
 	Examples []interface{} `json:"examples,omitempty"`
 
@@ -140,6 +144,12 @@ func (f FieldSpec) IsBloblang() FieldSpec {
 	return f
 }
 
+// IsTunable indicates that the field can be changed at runtime.
+func (f FieldSpec) IsTunable() FieldSpec {
+	f.Tunable = true
+	return f
+}
+
 // HasType returns a new FieldSpec that specifies a specific type.
 func (f FieldSpec) HasType(t FieldType) FieldSpec {
 	f.Type = t
@@ -349,7 +359,7 @@ func (f FieldSpec) lintOptions() FieldSpec {
 				}
 			}
 		}
-		return []Lint{NewLintError(line, fmt.Sprintf("value %v is not a valid option for this field", str))}
+		return []Lint{NewLintErrorOfType(line, LintInvalidOption, fmt.Sprintf("value %v is not a valid option for this field", str))}
 	}
 	return f
 }
@@ -587,11 +597,54 @@ const (
 	LintWarning LintLevel = iota
 )
 
+// LintType categorises a linting issue by its cause.
+type LintType int
+
+// Lint types
+const (
+	LintCustom LintType = iota
+	LintUnknown
+	LintDeprecated
+	LintMissing
+	LintExpectedType
+	LintInvalidOption
+	LintInvalidLabel
+	LintShouldOmit
+	LintBadBloblang
+	LintBadInterpolation
+)
+
+// String returns a snake case name of the lint type.
+func (t LintType) String() string {
+	switch t {
+	case LintUnknown:
+		return "unknown_field"
+	case LintDeprecated:
+		return "deprecated"
+	case LintMissing:
+		return "missing_field"
+	case LintExpectedType:
+		return "wrong_type"
+	case LintInvalidOption:
+		return "invalid_option"
+	case LintInvalidLabel:
+		return "invalid_label"
+	case LintShouldOmit:
+		return "should_omit"
+	case LintBadBloblang:
+		return "bad_bloblang"
+	case LintBadInterpolation:
+		return "bad_interpolation"
+	}
+	return "custom"
+}
+
 // Lint describes a single linting issue found with a Benthos config.
 type Lint struct {
 	Line   int
 	Column int // Optional, omitted from lint report unless >= 1
 	Level  LintLevel
+	Type   LintType
 	What   string
 }
 
@@ -600,6 +653,11 @@ func NewLintError(line int, msg string) Lint {
 	return Lint{Line: line, Level: LintError, What: msg}
 }
 
+// NewLintErrorOfType returns an error lint of a given type.
+func NewLintErrorOfType(line int, t LintType, msg string) Lint {
+	return Lint{Line: line, Level: LintError, Type: t, What: msg}
+}
+
 // NewLintWarning returns a warning lint.
 func NewLintWarning(line int, msg string) Lint {
 	return Lint{Line: line, Level: LintWarning, What: msg}
@@ -37,6 +37,9 @@ func FieldsTemplate(lintableExamples bool) string {
 {{$field.Spec.Description}}
 {{if $field.Spec.Interpolated -}}
 This field supports [interpolation functions](/docs/configuration/interpolation#bloblang-queries).
+{{end -}}
+{{if $field.Spec.Tunable -}}
+This field can be [tuned at runtime](/docs/components/http/about#tunables).
 {{end}}
 
 Type: {{if eq $field.Spec.Kind "array"}}list of {{end}}{{if eq $field.Spec.Kind "map"}}map of {{end}}` + "`{{$field.Spec.Type}}`" + `  
@@ -0,0 +1,20 @@
+package docs
+
+// InputThrottleFieldSpec is a field spec that describes limits on the rate at
+// which an input is consumed, and scheduled windows during which it is not
+// consumed at all.
+func InputThrottleFieldSpec(name string) FieldSpec {
+	return FieldObject(
+		name, "Optional limits on the rate at which messages are consumed from the input, and recurring windows during which consumption is paused. The input is simply not read from whilst throttled or paused and therefore its connection to the source remains open.",
+	).WithChildren(
+		FieldFloat("max_messages_per_second", "The maximum number of messages to consume per second. Set to zero in order to disable the limit.").HasDefault(0),
+		FieldFloat("max_bytes_per_second", "The maximum number of message bytes to consume per second. Set to zero in order to disable the limit.").HasDefault(0),
+		FieldObject("pause_windows", "A list of recurring windows during which consumption is paused, such as periods of downstream maintenance.").WithChildren(
+			FieldString(
+				"schedule", "A cron expression describing when each window begins. Expressions are evaluated in UTC unless a time zone is specified with a `TZ=` prefix.",
+				"0 2 * * *", "TZ=Europe/London 30 23 * * FRI",
+			),
+			FieldString("duration", "The length of each window.", "30m", "2h"),
+		).Array().HasDefault([]interface{}{}),
+	).Optional().Advanced()
+}
@@ -0,0 +1,15 @@
+package docs
+
+// OutputJournalFieldSpec returns a field spec for the write-ahead journal of an
+// output.
+func OutputJournalFieldSpec(name string) FieldSpec {
+	return FieldObject(
+		name, "An optional write-ahead journal of the messages delivered by the output, which is used in order to detect messages that are delivered again after a restart of the pipeline interrupted their acknowledgement upstream. [Find out more](/docs/components/outputs/about#delivery-journal).",
+	).WithChildren(
+		FieldString("path", "The path of a file to write the journal to, which must not be shared with any other output.", "/var/lib/benthos/journals/my_output.jsonl"),
+		FieldString("key", "An interpolated string yielding a key that uniquely identifies each message, and is the same when the message is redelivered.", `${! meta("kafka_topic") }-${! meta("kafka_partition") }-${! meta("kafka_offset") }`, `${! json("id") }`).IsInterpolated(),
+		FieldString("on_redelivery", "What to do with messages that were delivered but not acknowledged upstream.").HasOptions("skip", "flag").HasDefault("skip"),
+		FieldString("retention", "The period after which keys of messages that were delivered and never acknowledged are removed from the journal. Set to an empty string in order to retain them indefinitely.", "24h", "168h").HasDefault("24h"),
+		FieldBool("sync", "Whether to flush the journal to disk after each write, which protects against the loss of records when the host crashes at the cost of throughput.").HasDefault(false),
+	).Optional().Advanced()
+}
@@ -391,7 +391,7 @@ func (f FieldSpecs) SanitiseYAML(node *yaml.Node, conf SanitiseConfig) error {
 func lintYAMLFromOmit(parentSpec FieldSpecs, lintTargetSpec FieldSpec, parent, node *yaml.Node) []Lint {
 	why, shouldOmit := lintTargetSpec.shouldOmitYAML(parentSpec, node, parent)
 	if shouldOmit {
-		return []Lint{NewLintError(node.Line, why)}
+		return []Lint{NewLintErrorOfType(node.Line, LintShouldOmit, why)}
 	}
 	return nil
 }
@@ -425,7 +425,7 @@ func LintYAML(ctx LintContext, cType Type, node *yaml.Node) []Lint {
 	if cType == "condition" {
 		if ctx.RejectDeprecated {
 			return []Lint{
-				NewLintError(node.Line, "condition components are deprecated, use bloblang mappings instead when `check` fields or other alternatives are available"),
+				NewLintErrorOfType(node.Line, LintDeprecated, "condition components are deprecated, use bloblang mappings instead when `check` fields or other alternatives are available"),
 			}
 		}
 		return nil
@@ -463,7 +463,7 @@ func LintYAML(ctx LintContext, cType Type, node *yaml.Node) []Lint {
 	}
 
 	if ctx.RejectDeprecated && cSpec.Status == StatusDeprecated {
-		lints = append(lints, NewLintError(node.Line, fmt.Sprintf("component %v is deprecated", cSpec.Name)))
+		lints = append(lints, NewLintErrorOfType(node.Line, LintDeprecated, fmt.Sprintf("component %v is deprecated", cSpec.Name)))
 	}
 
 	nameFound := false
@@ -492,8 +492,8 @@ func LintYAML(ctx LintContext, cType Type, node *yaml.Node) []Lint {
 			lints = append(lints, lintYAMLFromOmit(cSpec.Config.Children, spec, node, node.Content[i+1])...)
 			lints = append(lints, spec.LintYAML(ctx, node.Content[i+1])...)
 		} else {
-			lints = append(lints, NewLintError(
-				node.Content[i].Line,
+			lints = append(lints, NewLintErrorOfType(
+				node.Content[i].Line, LintUnknown,
 				fmt.Sprintf("field %v is invalid when the component type is %v (%v)", node.Content[i].Value, name, cType),
 			))
 		}
@@ -510,7 +510,7 @@ func (f FieldSpec) LintYAML(ctx LintContext, node *yaml.Node) []Lint {
 	var lints []Lint
 
 	if ctx.RejectDeprecated && f.IsDeprecated {
-		lints = append(lints, NewLintError(node.Line, fmt.Sprintf("field %v is deprecated", f.Name)))
+		lints = append(lints, NewLintErrorOfType(node.Line, LintDeprecated, fmt.Sprintf("field %v is deprecated", f.Name)))
 	}
 
 	// Execute custom linters, if the kind is non-scalar this means we execute
@@ -522,7 +522,7 @@ func (f FieldSpec) LintYAML(ctx LintContext, node *yaml.Node) []Lint {
 	switch f.Kind {
 	case Kind2DArray:
 		if node.Kind != yaml.SequenceNode {
-			lints = append(lints, NewLintError(node.Line, "expected array value"))
+			lints = append(lints, NewLintErrorOfType(node.Line, LintExpectedType, "expected array value"))
 			return lints
 		}
 		for i := 0; i < len(node.Content); i++ {
@@ -531,7 +531,7 @@ func (f FieldSpec) LintYAML(ctx LintContext, node *yaml.Node) []Lint {
 		return lints
 	case KindArray:
 		if node.Kind != yaml.SequenceNode {
-			lints = append(lints, NewLintError(node.Line, "expected array value"))
+			lints = append(lints, NewLintErrorOfType(node.Line, LintExpectedType, "expected array value"))
 			return lints
 		}
 		for i := 0; i < len(node.Content); i++ {
@@ -540,7 +540,7 @@ func (f FieldSpec) LintYAML(ctx LintContext, node *yaml.Node) []Lint {
 		return lints
 	case KindMap:
 		if node.Kind != yaml.MappingNode {
-			lints = append(lints, NewLintError(node.Line, "expected object value"))
+			lints = append(lints, NewLintErrorOfType(node.Line, LintExpectedType, "expected object value"))
 			return lints
 		}
 		for i := 0; i < len(node.Content)-1; i += 2 {
@@ -564,11 +564,11 @@ func (f FieldSpec) LintYAML(ctx LintContext, node *yaml.Node) []Lint {
 	// TODO: Do proper checking for bool and number types.
 	case FieldTypeBool, FieldTypeString, FieldTypeInt, FieldTypeFloat:
 		if node.Kind == yaml.MappingNode || node.Kind == yaml.SequenceNode {
-			lints = append(lints, NewLintError(node.Line, fmt.Sprintf("expected %v value", f.Type)))
+			lints = append(lints, NewLintErrorOfType(node.Line, LintExpectedType, fmt.Sprintf("expected %v value", f.Type)))
 		}
 	case FieldTypeObject:
 		if node.Kind != yaml.MappingNode && node.Kind != yaml.AliasNode {
-			lints = append(lints, NewLintError(node.Line, "expected object value"))
+			lints = append(lints, NewLintErrorOfType(node.Line, LintExpectedType, "expected object value"))
 		}
 	}
 	return lints
@@ -584,7 +584,7 @@ func (f FieldSpecs) LintYAML(ctx LintContext, node *yaml.Node) []Lint {
 			// TODO: Actually lint through aliases
 			return nil
 		}
-		lints = append(lints, NewLintError(node.Line, "expected object value"))
+		lints = append(lints, NewLintErrorOfType(node.Line, LintExpectedType, "expected object value"))
 		return lints
 	}
 
@@ -597,7 +597,7 @@ func (f FieldSpecs) LintYAML(ctx LintContext, node *yaml.Node) []Lint {
 		spec, exists := specNames[node.Content[i].Value]
 		if !exists {
 			if node.Content[i+1].Kind != yaml.AliasNode {
-				lints = append(lints, NewLintError(node.Content[i].Line, fmt.Sprintf("field %v not recognised", node.Content[i].Value)))
+				lints = append(lints, NewLintErrorOfType(node.Content[i].Line, LintUnknown, fmt.Sprintf("field %v not recognised", node.Content[i].Value)))
 			}
 			continue
 		}
@@ -613,7 +613,7 @@ func (f FieldSpecs) LintYAML(ctx LintContext, node *yaml.Node) []Lint {
 			!isCore &&
 			remaining.Kind == KindScalar &&
 			len(remaining.Children) == 0 {
-			lints = append(lints, NewLintError(node.Line, fmt.Sprintf("field %v is required", name)))
+			lints = append(lints, NewLintErrorOfType(node.Line, LintMissing, fmt.Sprintf("field %v is required", name)))
 		}
 	}
 	return lints
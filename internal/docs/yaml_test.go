@@ -668,7 +668,7 @@ testlintbarinput:
   bar1: hello world`,
 			rejectDeprecated: true,
 			res: []docs.Lint{
-				docs.NewLintError(2, "component testlintbarinput is deprecated"),
+				docs.NewLintErrorOfType(2, docs.LintDeprecated, "component testlintbarinput is deprecated"),
 			},
 		},
 		{
@@ -680,7 +680,7 @@ testlintfooinput:
   foo6: hello world`,
 			rejectDeprecated: true,
 			res: []docs.Lint{
-				docs.NewLintError(4, "field foo6 is deprecated"),
+				docs.NewLintErrorOfType(4, docs.LintDeprecated, "field foo6 is deprecated"),
 			},
 		},
 		{
@@ -702,7 +702,7 @@ testlintfooinput: &test-anchor
 processors:
   - testlintfooprocessor: *test-anchor`,
 			res: []docs.Lint{
-				docs.NewLintError(4, "field nope not recognised"),
+				docs.NewLintErrorOfType(4, docs.LintUnknown, "field nope not recognised"),
 			},
 		},
 		{
@@ -716,9 +716,9 @@ testlintfooinput:
   also_not_recognised: nah
 definitely_not_recognised: huh`,
 			res: []docs.Lint{
-				docs.NewLintError(4, "field not_recognised not recognised"),
-				docs.NewLintError(6, "field also_not_recognised not recognised"),
-				docs.NewLintError(7, "field definitely_not_recognised is invalid when the component type is testlintfooinput (input)"),
+				docs.NewLintErrorOfType(4, docs.LintUnknown, "field not_recognised not recognised"),
+				docs.NewLintErrorOfType(6, docs.LintUnknown, "field also_not_recognised not recognised"),
+				docs.NewLintErrorOfType(7, docs.LintUnknown, "field definitely_not_recognised is invalid when the component type is testlintfooinput (input)"),
 			},
 		},
 		{
@@ -732,8 +732,8 @@ processors:
   - testlintfooprocessor:
       also_not_recognised: nah`,
 			res: []docs.Lint{
-				docs.NewLintError(3, "field not_recognised not recognised"),
-				docs.NewLintError(7, "field also_not_recognised not recognised"),
+				docs.NewLintErrorOfType(3, docs.LintUnknown, "field not_recognised not recognised"),
+				docs.NewLintErrorOfType(7, docs.LintUnknown, "field also_not_recognised not recognised"),
 			},
 		},
 		{
@@ -749,7 +749,7 @@ processors:
   - label: foo
     testlintfooprocessor: {}`,
 			res: []docs.Lint{
-				docs.NewLintError(8, "Label 'foo' collides with a previously defined label at line 2"),
+				docs.NewLintErrorOfType(8, docs.LintInvalidLabel, "Label 'foo' collides with a previously defined label at line 2"),
 			},
 		},
 		{
@@ -760,7 +760,7 @@ testlintfooinput:
   foo1: hello world
 processors: []`,
 			res: []docs.Lint{
-				docs.NewLintError(4, "field processors is empty and can be removed"),
+				docs.NewLintErrorOfType(4, docs.LintShouldOmit, "field processors is empty and can be removed"),
 			},
 		},
 		{
@@ -771,7 +771,7 @@ testlintfooinput:
   foo1: hello world
   foo2: drop me`,
 			res: []docs.Lint{
-				docs.NewLintError(4, "because foo"),
+				docs.NewLintErrorOfType(4, docs.LintShouldOmit, "because foo"),
 			},
 		},
 		{
@@ -785,7 +785,7 @@ testlintfooinput:
         foo1: somevalue
         not_recognised: nah`,
 			res: []docs.Lint{
-				docs.NewLintError(4, "expected array value"),
+				docs.NewLintErrorOfType(4, docs.LintExpectedType, "expected array value"),
 			},
 		},
 		{
@@ -798,7 +798,7 @@ testlintfooinput:
       foo1: somevalue
       not_recognised: nah`,
 			res: []docs.Lint{
-				docs.NewLintError(6, "field not_recognised not recognised"),
+				docs.NewLintErrorOfType(6, docs.LintUnknown, "field not_recognised not recognised"),
 			},
 		},
 		{
@@ -811,7 +811,7 @@ testlintfooinput:
       foo1: [ somevalue ]
 `,
 			res: []docs.Lint{
-				docs.NewLintError(5, "expected string value"),
+				docs.NewLintErrorOfType(5, docs.LintExpectedType, "expected string value"),
 			},
 		},
 		{
@@ -825,7 +825,7 @@ testlintfooinput:
         foo1: somevalue
         not_recognised: nah`,
 			res: []docs.Lint{
-				docs.NewLintError(7, "field not_recognised not recognised"),
+				docs.NewLintErrorOfType(7, docs.LintUnknown, "field not_recognised not recognised"),
 			},
 		},
 		{
@@ -838,7 +838,7 @@ testlintfooinput:
         foo1: somevalue
         not_recognised: nah`,
 			res: []docs.Lint{
-				docs.NewLintError(4, "expected object value"),
+				docs.NewLintErrorOfType(4, docs.LintExpectedType, "expected object value"),
 			},
 		},
 		{
@@ -857,7 +857,7 @@ testlintfooinput:
   foo7:
    - wat: no`,
 			res: []docs.Lint{
-				docs.NewLintError(4, "field wat not recognised"),
+				docs.NewLintErrorOfType(4, docs.LintUnknown, "field wat not recognised"),
 			},
 		},
 		{
@@ -869,7 +869,7 @@ testlintfooinput:
     key1:
       wat: no`,
 			res: []docs.Lint{
-				docs.NewLintError(4, "expected array value"),
+				docs.NewLintErrorOfType(4, docs.LintExpectedType, "expected array value"),
 			},
 		},
 		{
@@ -890,7 +890,7 @@ testlintfooinput:
     key1:
       wat: nope`,
 			res: []docs.Lint{
-				docs.NewLintError(5, "field wat not recognised"),
+				docs.NewLintErrorOfType(5, docs.LintUnknown, "field wat not recognised"),
 			},
 		},
 		{
@@ -901,7 +901,7 @@ testlintfooinput:
   foo8:
     - wat: nope`,
 			res: []docs.Lint{
-				docs.NewLintError(4, "expected object value"),
+				docs.NewLintErrorOfType(4, docs.LintExpectedType, "expected object value"),
 			},
 		},
 		{
@@ -946,7 +946,7 @@ func TestYAMLLinting(t *testing.T) {
 			inputSpec: docs.FieldString("foo", ""),
 			inputConf: `["foo","bar"]`,
 			res: []docs.Lint{
-				docs.NewLintError(1, "expected string value"),
+				docs.NewLintErrorOfType(1, docs.LintExpectedType, "expected string value"),
 			},
 		},
 		{
@@ -954,7 +954,7 @@ func TestYAMLLinting(t *testing.T) {
 			inputSpec: docs.FieldString("foo", "").Array(),
 			inputConf: `"foo"`,
 			res: []docs.Lint{
-				docs.NewLintError(1, "expected array value"),
+				docs.NewLintErrorOfType(1, docs.LintExpectedType, "expected array value"),
 			},
 		},
 		{
@@ -964,7 +964,7 @@ func TestYAMLLinting(t *testing.T) {
 			),
 			inputConf: `"foo"`,
 			res: []docs.Lint{
-				docs.NewLintError(1, "expected object value"),
+				docs.NewLintErrorOfType(1, docs.LintExpectedType, "expected object value"),
 			},
 		},
 		{
@@ -974,7 +974,7 @@ func TestYAMLLinting(t *testing.T) {
 			),
 			inputConf: `bar: {}`,
 			res: []docs.Lint{
-				docs.NewLintError(1, "expected string value"),
+				docs.NewLintErrorOfType(1, docs.LintExpectedType, "expected string value"),
 			},
 		},
 		{
@@ -987,7 +987,7 @@ func TestYAMLLinting(t *testing.T) {
 			inputConf: `bar:
   baz: {}`,
 			res: []docs.Lint{
-				docs.NewLintError(2, "expected string value"),
+				docs.NewLintErrorOfType(2, docs.LintExpectedType, "expected string value"),
 			},
 		},
 		{
@@ -1000,7 +1000,7 @@ func TestYAMLLinting(t *testing.T) {
 			),
 			inputConf: `bev: hello world`,
 			res: []docs.Lint{
-				docs.NewLintError(1, "field baz is required"),
+				docs.NewLintErrorOfType(1, docs.LintMissing, "field baz is required"),
 			},
 		},
 	}
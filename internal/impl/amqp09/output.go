@@ -75,6 +75,7 @@ The fields 'key' and 'type' can be dynamically set using function interpolations
 			docs.FieldString("content_encoding", "The content encoding attribute to set for each message.").IsInterpolated().Advanced().HasDefault(""),
 			docs.FieldObject("metadata", "Specify criteria for which metadata values are attached to messages as headers.").WithChildren(metadata.ExcludeFilterFields()...).HasDefault(map[string]interface{}{}),
 			docs.FieldString("priority", "Set the priority of each message with a dynamic interpolated expression.", "0", `${! meta("amqp_priority") }`, `${! json("doc.priority") }`).IsInterpolated().Advanced().HasDefault(""),
+			docs.FieldString("delay", "An optional duration to delay the delivery of each message by, which is set as the header `x-delay` in milliseconds. Delays are only applied by exchanges of the type `x-delayed-message`, which are provided by the RabbitMQ delayed message exchange plugin.", "30s", `${! meta("delay") }`).IsInterpolated().Advanced().HasDefault(""),
 			docs.FieldInt("max_in_flight", "The maximum number of messages to have in flight at a given time. Increase this to improve throughput.").HasDefault(64),
 			docs.FieldBool("persistent", "Whether message delivery should be persistent (transient by default).").Advanced().HasDefault(false),
 			docs.FieldBool("mandatory", "Whether to set the mandatory flag on published messages. When set if a published message is routed to zero queues it is returned.").Advanced().HasDefault(false),
@@ -96,6 +97,7 @@ type amqp09Writer struct {
 	contentType     *field.Expression
 	contentEncoding *field.Expression
 	priority        *field.Expression
+	delay           *field.Expression
 	metaFilter      *metadata.ExcludeFilter
 
 	log log.Modular
@@ -138,6 +140,9 @@ func newAMQP09Writer(mgr bundle.NewManagement, conf output.AMQPConfig, log log.M
 	if a.priority, err = mgr.BloblEnvironment().NewField(conf.Priority); err != nil {
 		return nil, fmt.Errorf("failed to parse priority property expression: %w", err)
 	}
+	if a.delay, err = mgr.BloblEnvironment().NewField(conf.Delay); err != nil {
+		return nil, fmt.Errorf("failed to parse delay expression: %w", err)
+	}
 	if conf.Persistent {
 		a.deliveryMode = amqp.Persistent
 	}
@@ -257,6 +262,16 @@ func (a *amqp09Writer) WriteWithContext(ctx context.Context, msg *message.Batch)
 			headers[strings.ReplaceAll(k, "_", "-")] = v
 			return nil
 		})
+		if delayString := a.delay.String(i, msg); delayString != "" {
+			delay, err := time.ParseDuration(delayString)
+			if err != nil {
+				return fmt.Errorf("failed to parse duration from delay expression: %w", err)
+			}
+			if delay < 0 {
+				return fmt.Errorf("invalid delay parsed from expression, must be >= 0, got %v", delay)
+			}
+			headers["x-delay"] = delay.Milliseconds()
+		}
 
 		conf, err := amqpChan.PublishWithDeferredConfirm(
 			a.conf.Exchange,  // publish to an exchange
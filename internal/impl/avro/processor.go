@@ -3,9 +3,6 @@ package avro
 import (
 	"context"
 	"fmt"
-	"io"
-	"net/http"
-	"strings"
 
 	"github.com/linkedin/goavro/v2"
 
@@ -14,6 +11,7 @@ import (
 	"github.com/benthosdev/benthos/v4/internal/docs"
 	"github.com/benthosdev/benthos/v4/internal/log"
 	"github.com/benthosdev/benthos/v4/internal/message"
+	ischema "github.com/benthosdev/benthos/v4/internal/schema"
 )
 
 func init() {
@@ -154,28 +152,6 @@ func strToAvroOperator(opStr, encoding string, codec *goavro.Codec) (avroOperato
 	return nil, fmt.Errorf("operator not recognised: %v", opStr)
 }
 
-func loadSchema(schemaPath string) (string, error) {
-	t := &http.Transport{}
-	t.RegisterProtocol("file", http.NewFileTransport(http.Dir("/")))
-	c := &http.Client{Transport: t}
-
-	response, err := c.Get(schemaPath)
-
-	if err != nil {
-		return "", err
-	}
-
-	defer response.Body.Close()
-
-	body, err := io.ReadAll(response.Body)
-
-	if err != nil {
-		return "", err
-	}
-
-	return string(body), nil
-}
-
 //------------------------------------------------------------------------------
 
 type avro struct {
@@ -190,11 +166,7 @@ func newAvro(conf processor.AvroConfig, mgr bundle.NewManagement) (processor.V2,
 	var err error
 
 	if schemaPath := conf.SchemaPath; schemaPath != "" {
-		if !(strings.HasPrefix(schemaPath, "file://") || strings.HasPrefix(schemaPath, "http://")) {
-			return nil, fmt.Errorf("invalid schema_path provided, must start with file:// or http://")
-		}
-
-		schema, err = loadSchema(schemaPath)
+		schema, err = ischema.LoadAvro(schemaPath)
 		if err != nil {
 			return nil, fmt.Errorf("failed to load Avro schema definition: %v", err)
 		}
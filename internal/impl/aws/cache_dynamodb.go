@@ -111,7 +111,7 @@ func newDynamodbCacheFromConfig(conf *service.ParsedConfig) (*dynamodbCache, err
 		}
 		ttlKey = &ttlKeyTmp
 	}
-	sess, err := getSession(conf)
+	sess, err := GetSession(conf)
 	if err != nil {
 		return nil, err
 	}
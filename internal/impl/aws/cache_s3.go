@@ -75,7 +75,7 @@ func newS3CacheFromConfig(conf *service.ParsedConfig) (*s3Cache, error) {
 		return nil, err
 	}
 
-	sess, err := getSession(conf, func(c *aws.Config) {
+	sess, err := GetSession(conf, func(c *aws.Config) {
 		c.S3ForcePathStyle = aws.Bool(forcePathStyleURLs)
 	})
 	if err != nil {
@@ -0,0 +1,897 @@
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodbstreams"
+	"github.com/aws/aws-sdk-go/service/dynamodbstreams/dynamodbstreamsiface"
+	"github.com/cenkalti/backoff/v4"
+	"github.com/gofrs/uuid"
+
+	"github.com/benthosdev/benthos/v4/internal/checkpoint"
+	"github.com/benthosdev/benthos/v4/internal/component/input"
+	"github.com/benthosdev/benthos/v4/internal/shutdown"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func dynamoDBStreamsInputConfig() *service.ConfigSpec {
+	spec := service.NewConfigSpec().
+		Categories("Services", "AWS").
+		Version("4.3.0").
+		Summary("Consumes the change records of a DynamoDB table from its stream.").
+		Description(`
+Consumes the [stream](https://docs.aws.amazon.com/amazondynamodb/latest/developerguide/Streams.html) of a DynamoDB table, emitting a structured message for each item that is inserted, modified or removed. The shards of the stream are automatically balanced across other instances of this input consuming the same stream, and the latest sequence consumed from each shard is stored within a [DynamoDB table](#table-schema), which allows it to resume at the correct sequence of a shard during restarts.
+
+Benthos will not store a consumed sequence unless it is acknowledged at the output level, which ensures at-least-once delivery guarantees.
+
+### Message Structure
+
+Each change record is emitted as a structured message of the following form, where the images of the item are only present when the stream view type of the table includes them:
+
+`+"```json"+`
+{
+  "event_id": "c4ca4238a0b923820dcc509a6f75849b",
+  "event_name": "MODIFY",
+  "sequence_number": "111",
+  "approximate_creation_time": "2022-04-01T12:00:00Z",
+  "keys": { "id": "foo" },
+  "old_image": { "id": "foo", "count": 1 },
+  "new_image": { "id": "foo", "count": 2 }
+}
+`+"```"+`
+
+The `+"`event_name`"+` is one of `+"`INSERT`, `MODIFY` or `REMOVE`"+`. Attribute values are converted from their DynamoDB types, where numbers are emitted as numbers without a loss of precision, sets are emitted as arrays and binary values are emitted as raw bytes. Items removed by the time to live feature of the table also contain a field `+"`user_identity`"+` with the `+"`type` and `principal_id`"+` of the removal.
+
+### Ordering
+
+The records of a shard are emitted in the order in which they were written, and the shards of a stream are only consumed once the shard they were split from has been consumed in full, and so modifications of a given item are always emitted in order. However, the records of a shard can be processed in parallel up to a limit determined by the field `+"`checkpoint_limit`"+`, which must be set to 1 in order to process them in lock-step. When doing so it is recommended that you perform batching at this component for performance.
+
+### Table Schema
+
+It's possible to configure Benthos to create the DynamoDB table required for coordination if it does not already exist. However, if you wish to create this yourself (recommended) then create a table with a string HASH key `+"`StreamID`"+` and a string RANGE key `+"`ShardID`"+`. The same table can be shared with `+"[`aws_kinesis`](/docs/components/inputs/aws_kinesis)"+` inputs.
+
+### Metadata
+
+This input adds the following metadata fields to each message:
+
+`+"```text"+`
+- dynamodb_stream_arn
+- dynamodb_shard
+- dynamodb_sequence_number
+- dynamodb_event_name
+- dynamodb_event_id
+`+"```"+`
+
+You can access these metadata fields using [function interpolation](/docs/configuration/interpolation#bloblang-queries).`).
+		Field(service.NewStringField("table").
+			Description("The name of the table to consume the latest stream of.").
+			Example("orders").
+			Default("")).
+		Field(service.NewStringField("stream_arn").
+			Description("An explicit ARN of the stream to consume, which can be used instead of `table` in order to consume a stream other than the latest stream of a table.").
+			Default("").
+			Advanced()).
+		Field(service.NewObjectField("dynamodb",
+			service.NewStringField("table").
+				Description("The name of the table to access.").
+				Default(""),
+			service.NewBoolField("create").
+				Description("Whether, if the table does not exist, it should be created.").
+				Default(false),
+			service.NewStringEnumField("billing_mode", "PROVISIONED", "PAY_PER_REQUEST").
+				Description("When creating the table determines the billing mode.").
+				Default("PAY_PER_REQUEST").
+				Advanced(),
+			service.NewIntField("read_capacity_units").
+				Description("Set the provisioned read capacity when creating the table with a `billing_mode` of `PROVISIONED`.").
+				Default(0).
+				Advanced(),
+			service.NewIntField("write_capacity_units").
+				Description("Set the provisioned write capacity when creating the table with a `billing_mode` of `PROVISIONED`.").
+				Default(0).
+				Advanced(),
+		).Description("Determines the table used for storing and accessing the latest consumed sequence for shards, and for coordinating balanced consumers of the stream.")).
+		Field(service.NewIntField("checkpoint_limit").
+			Description("The maximum gap between the in flight sequence versus the latest acknowledged sequence of a shard at a given time. Increasing this limit enables parallel processing and batching at the output level. Any given sequence will not be committed unless all messages under that sequence are delivered in order to preserve at least once delivery guarantees.").
+			Default(1024)).
+		Field(service.NewDurationField("commit_period").
+			Description("The period of time between each update to the checkpoint table.").
+			Default("5s")).
+		Field(service.NewDurationField("rebalance_period").
+			Description("The period of time between each attempt to claim new shards and rebalance shards across clients.").
+			Default("30s").
+			Advanced()).
+		Field(service.NewDurationField("lease_period").
+			Description("The period of time after which a client that has failed to update a shard checkpoint is assumed to be inactive.").
+			Default("30s").
+			Advanced()).
+		Field(service.NewBoolField("start_from_oldest").
+			Description("Whether to consume from the oldest record of a shard when a sequence does not yet exist for it, otherwise only records written after the shard is claimed are consumed.").
+			Default(true)).
+		Field(service.NewBatchPolicyField("batching")).
+		Example(
+			"Change Data Capture",
+			"Stream the latest image of inserted and modified items of a table into a Kafka topic, keyed by the ID of the item, and drop the records of removed items:",
+			`
+input:
+  aws_dynamodb_streams:
+    table: orders
+    dynamodb:
+      table: benthos_checkpoints
+      create: true
+
+pipeline:
+  processors:
+    - bloblang: |
+        meta key = this.keys.id
+        root = if this.event_name == "REMOVE" { deleted() } else { this.new_image }
+
+output:
+  kafka:
+    addresses: [ localhost:9092 ]
+    topic: orders
+    key: ${! meta("key") }
+`,
+		)
+
+	for _, f := range sessionFields() {
+		spec = spec.Field(f)
+	}
+	return spec
+}
+
+func init() {
+	err := service.RegisterBatchInput("aws_dynamodb_streams", dynamoDBStreamsInputConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.BatchInput, error) {
+			rdr, err := newDynamoDBStreamsReaderFromConfig(conf, mgr)
+			if err != nil {
+				return nil, err
+			}
+			return service.AutoRetryNacksBatched(rdr), nil
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// dynamoDBStreamsShardEnd is stored as the sequence of shards that have been
+// consumed in full, which allows the shards split from them to be claimed.
+const dynamoDBStreamsShardEnd = "SHARD_END"
+
+type dynamoDBStreamsBatch struct {
+	batch service.MessageBatch
+	ackFn service.AckFunc
+}
+
+type dynamoDBStreamsReader struct {
+	table           string
+	streamARN       string
+	checkpointConf  input.DynamoDBCheckpointConfig
+	checkpointLimit int
+	commitPeriod    time.Duration
+	rebalancePeriod time.Duration
+	leasePeriod     time.Duration
+	startFromOldest bool
+	batchPolicy     service.BatchPolicy
+	clientID        string
+
+	sess         *session.Session
+	svc          dynamodbstreamsiface.DynamoDBStreamsAPI
+	checkpointer *awsKinesisCheckpointer
+
+	mgr *service.Resources
+	log *service.Logger
+
+	cMut    sync.Mutex
+	msgChan chan dynamoDBStreamsBatch
+	shutSig *shutdown.Signaller
+}
+
+func newDynamoDBStreamsReaderFromConfig(conf *service.ParsedConfig, mgr *service.Resources) (*dynamoDBStreamsReader, error) {
+	r := &dynamoDBStreamsReader{
+		mgr:     mgr,
+		log:     mgr.Logger(),
+		shutSig: shutdown.NewSignaller(),
+	}
+
+	var err error
+	if r.table, err = conf.FieldString("table"); err != nil {
+		return nil, err
+	}
+	if r.streamARN, err = conf.FieldString("stream_arn"); err != nil {
+		return nil, err
+	}
+	if r.table == "" && r.streamARN == "" {
+		return nil, errors.New("either a table or stream_arn must be specified")
+	}
+
+	r.checkpointConf = input.NewDynamoDBCheckpointConfig()
+	if r.checkpointConf.Table, err = conf.FieldString("dynamodb", "table"); err != nil {
+		return nil, err
+	}
+	if r.checkpointConf.Table == "" {
+		return nil, errors.New("a dynamodb table must be specified for storing checkpoints")
+	}
+	if r.checkpointConf.Create, err = conf.FieldBool("dynamodb", "create"); err != nil {
+		return nil, err
+	}
+	if r.checkpointConf.BillingMode, err = conf.FieldString("dynamodb", "billing_mode"); err != nil {
+		return nil, err
+	}
+	var readUnits, writeUnits int
+	if readUnits, err = conf.FieldInt("dynamodb", "read_capacity_units"); err != nil {
+		return nil, err
+	}
+	if writeUnits, err = conf.FieldInt("dynamodb", "write_capacity_units"); err != nil {
+		return nil, err
+	}
+	r.checkpointConf.ReadCapacityUnits = int64(readUnits)
+	r.checkpointConf.WriteCapacityUnits = int64(writeUnits)
+
+	if r.checkpointLimit, err = conf.FieldInt("checkpoint_limit"); err != nil {
+		return nil, err
+	}
+	if r.checkpointLimit < 1 {
+		return nil, errors.New("checkpoint_limit must be at least 1")
+	}
+	if r.commitPeriod, err = conf.FieldDuration("commit_period"); err != nil {
+		return nil, err
+	}
+	if r.rebalancePeriod, err = conf.FieldDuration("rebalance_period"); err != nil {
+		return nil, err
+	}
+	if r.leasePeriod, err = conf.FieldDuration("lease_period"); err != nil {
+		return nil, err
+	}
+	if r.startFromOldest, err = conf.FieldBool("start_from_oldest"); err != nil {
+		return nil, err
+	}
+	if r.batchPolicy, err = conf.FieldBatchPolicy("batching"); err != nil {
+		return nil, err
+	}
+	if r.batchPolicy.Key != "" {
+		// Records held back by a key would be checkpointed before they're
+		// dispatched.
+		return nil, errors.New("batching by key is not supported by this input")
+	}
+
+	u4, err := uuid.NewV4()
+	if err != nil {
+		return nil, err
+	}
+	r.clientID = u4.String()
+
+	if r.sess, err = GetSession(conf); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+//------------------------------------------------------------------------------
+
+func (r *dynamoDBStreamsReader) Connect(ctx context.Context) error {
+	r.cMut.Lock()
+	defer r.cMut.Unlock()
+	if r.msgChan != nil {
+		return nil
+	}
+
+	if r.streamARN == "" {
+		res, err := dynamodb.New(r.sess).DescribeTableWithContext(ctx, &dynamodb.DescribeTableInput{
+			TableName: aws.String(r.table),
+		})
+		if err != nil {
+			return err
+		}
+		if res.Table == nil || res.Table.LatestStreamArn == nil {
+			return fmt.Errorf("table %v does not have a stream enabled", r.table)
+		}
+		r.streamARN = *res.Table.LatestStreamArn
+	}
+
+	checkpointer, err := newAWSKinesisCheckpointer(r.sess, r.clientID, r.checkpointConf, r.leasePeriod, r.commitPeriod)
+	if err != nil {
+		return err
+	}
+
+	r.svc = dynamodbstreams.New(r.sess)
+	r.checkpointer = checkpointer
+	r.msgChan = make(chan dynamoDBStreamsBatch)
+
+	go r.runBalancedShards()
+	return nil
+}
+
+func (r *dynamoDBStreamsReader) ReadBatch(ctx context.Context) (service.MessageBatch, service.AckFunc, error) {
+	r.cMut.Lock()
+	msgChan := r.msgChan
+	r.cMut.Unlock()
+
+	if msgChan == nil {
+		return nil, nil, service.ErrNotConnected
+	}
+
+	select {
+	case b, open := <-msgChan:
+		if !open {
+			return nil, nil, service.ErrNotConnected
+		}
+		return b.batch, b.ackFn, nil
+	case <-ctx.Done():
+	}
+	return nil, nil, ctx.Err()
+}
+
+func (r *dynamoDBStreamsReader) Close(ctx context.Context) error {
+	go func() {
+		r.shutSig.CloseAtLeisure()
+		r.cMut.Lock()
+		if r.msgChan == nil {
+			// We were never connected and so there are no consumers to wait
+			// for.
+			r.shutSig.ShutdownComplete()
+		}
+		r.cMut.Unlock()
+	}()
+	select {
+	case <-r.shutSig.HasClosedChan():
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+func (r *dynamoDBStreamsReader) listShards(ctx context.Context) ([]*dynamodbstreams.Shard, error) {
+	var shards []*dynamodbstreams.Shard
+	req := &dynamodbstreams.DescribeStreamInput{
+		StreamArn: aws.String(r.streamARN),
+	}
+	for {
+		res, err := r.svc.DescribeStreamWithContext(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		if res.StreamDescription == nil {
+			return shards, nil
+		}
+		shards = append(shards, res.StreamDescription.Shards...)
+		if res.StreamDescription.LastEvaluatedShardId == nil {
+			return shards, nil
+		}
+		req.ExclusiveStartShardId = res.StreamDescription.LastEvaluatedShardId
+	}
+}
+
+// dynamoDBStreamsClaimable returns the IDs of shards that have not yet been
+// consumed in full and that can be consumed without breaking the order of
+// records, which is when the parent of a shard has either been consumed in full
+// or no longer exists.
+func dynamoDBStreamsClaimable(shards []*dynamodbstreams.Shard, finished map[string]struct{}) []string {
+	exists := make(map[string]struct{}, len(shards))
+	for _, s := range shards {
+		exists[aws.StringValue(s.ShardId)] = struct{}{}
+	}
+
+	var claimable []string
+	for _, s := range shards {
+		shardID := aws.StringValue(s.ShardId)
+		if _, done := finished[shardID]; done {
+			continue
+		}
+		if parentID := aws.StringValue(s.ParentShardId); parentID != "" {
+			_, parentExists := exists[parentID]
+			_, parentDone := finished[parentID]
+			if parentExists && !parentDone {
+				continue
+			}
+		}
+		claimable = append(claimable, shardID)
+	}
+	return claimable
+}
+
+func (r *dynamoDBStreamsReader) runBalancedShards() {
+	var wg sync.WaitGroup
+	defer func() {
+		wg.Wait()
+		r.cMut.Lock()
+		close(r.msgChan)
+		r.cMut.Unlock()
+		r.shutSig.ShutdownComplete()
+	}()
+
+	ctx, done := r.shutSig.CloseAtLeisureCtx(context.Background())
+	defer done()
+
+	for {
+		if err := r.rebalance(ctx, &wg); err != nil && ctx.Err() == nil {
+			r.log.Errorf("Failed to rebalance stream '%v' shards: %v", r.streamARN, err)
+		}
+		select {
+		case <-time.After(r.rebalancePeriod):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (r *dynamoDBStreamsReader) rebalance(ctx context.Context, wg *sync.WaitGroup) error {
+	shards, err := r.listShards(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to describe stream: %w", err)
+	}
+
+	finishedIDs, err := r.checkpointer.ShardsAtSequence(ctx, r.streamARN, dynamoDBStreamsShardEnd)
+	if err != nil {
+		return fmt.Errorf("failed to obtain finished shards: %w", err)
+	}
+
+	clientClaims, err := r.checkpointer.AllClaims(ctx, r.streamARN)
+	if err != nil {
+		return fmt.Errorf("failed to obtain claims: %w", err)
+	}
+
+	finished := make(map[string]struct{}, len(finishedIDs))
+	for _, shardID := range finishedIDs {
+		finished[shardID] = struct{}{}
+	}
+
+	// Shards are trimmed from the stream after 24 hours, at which point the
+	// checkpoints of finished shards are no longer needed.
+	exists := make(map[string]struct{}, len(shards))
+	for _, s := range shards {
+		exists[aws.StringValue(s.ShardId)] = struct{}{}
+	}
+	for shardID := range finished {
+		if _, ok := exists[shardID]; !ok {
+			if err := r.checkpointer.Delete(ctx, r.streamARN, shardID); err != nil {
+				r.log.Warnf("Failed to remove checkpoint of trimmed shard '%v': %v", shardID, err)
+			}
+		}
+	}
+
+	claimable := dynamoDBStreamsClaimable(shards, finished)
+	unclaimedShards := make(map[string]string, len(claimable))
+	for _, shardID := range claimable {
+		unclaimedShards[shardID] = ""
+	}
+	for clientID, claims := range clientClaims {
+		for _, claim := range claims {
+			if _, ok := unclaimedShards[claim.ShardID]; ok && time.Since(claim.LeaseTimeout) > r.leasePeriod*2 {
+				unclaimedShards[claim.ShardID] = clientID
+			} else {
+				delete(unclaimedShards, claim.ShardID)
+			}
+		}
+	}
+
+	// Have a go at grabbing any unclaimed shards
+	if len(unclaimedShards) > 0 {
+		for shardID, clientID := range unclaimedShards {
+			sequence, err := r.checkpointer.Claim(ctx, r.streamARN, shardID, clientID)
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil
+				}
+				if !errors.Is(err, ErrLeaseNotAcquired) {
+					r.log.Errorf("Failed to claim unclaimed shard '%v': %v", shardID, err)
+				}
+				continue
+			}
+			wg.Add(1)
+			go r.runConsumer(ctx, wg, shardID, sequence)
+		}
+
+		// If there are unclaimed shards then let's not resort to thievery
+		// just yet.
+		return nil
+	}
+
+	// There were no unclaimed shards, let's look for a shard to steal, using
+	// the same naive approach as the aws_kinesis input.
+	selfClaims := len(clientClaims[r.clientID])
+	for clientID, claims := range clientClaims {
+		if clientID == r.clientID || len(claims) <= (selfClaims+1) {
+			continue
+		}
+
+		randomShard := claims[(rand.Int() % len(claims))].ShardID
+		r.log.Debugf(
+			"Attempting to steal stream '%v' shard '%v' from client '%v' as client '%v'",
+			r.streamARN, randomShard, clientID, r.clientID,
+		)
+
+		sequence, err := r.checkpointer.Claim(ctx, r.streamARN, randomShard, clientID)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			if !errors.Is(err, ErrLeaseNotAcquired) {
+				r.log.Errorf("Failed to steal shard '%v': %v", randomShard, err)
+			}
+			continue
+		}
+
+		wg.Add(1)
+		go r.runConsumer(ctx, wg, randomShard, sequence)
+		break
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+func (r *dynamoDBStreamsReader) getIter(ctx context.Context, shardID, sequence string) (string, error) {
+	iterType := dynamodbstreams.ShardIteratorTypeTrimHorizon
+	if !r.startFromOldest {
+		iterType = dynamodbstreams.ShardIteratorTypeLatest
+	}
+	var startingSequence *string
+	if sequence != "" {
+		iterType = dynamodbstreams.ShardIteratorTypeAfterSequenceNumber
+		startingSequence = &sequence
+	}
+
+	res, err := r.svc.GetShardIteratorWithContext(ctx, &dynamodbstreams.GetShardIteratorInput{
+		StreamArn:         aws.String(r.streamARN),
+		ShardId:           &shardID,
+		SequenceNumber:    startingSequence,
+		ShardIteratorType: &iterType,
+	})
+	if aerr, ok := err.(awserr.Error); ok && startingSequence != nil && aerr.Code() == dynamodbstreams.ErrCodeTrimmedDataAccessException {
+		// The stored sequence has since been trimmed from the shard, and so
+		// the oldest remaining record is the closest we can get.
+		r.log.Warnf("Sequence of shard '%v' has been trimmed, consuming from the oldest record", shardID)
+		res, err = r.svc.GetShardIteratorWithContext(ctx, &dynamodbstreams.GetShardIteratorInput{
+			StreamArn:         aws.String(r.streamARN),
+			ShardId:           &shardID,
+			ShardIteratorType: aws.String(dynamodbstreams.ShardIteratorTypeTrimHorizon),
+		})
+	}
+	if err != nil {
+		return "", err
+	}
+	if res.ShardIterator == nil || *res.ShardIterator == "" {
+		return "", errors.New("failed to obtain shard iterator")
+	}
+	return *res.ShardIterator, nil
+}
+
+func awsErrCode(err error) string {
+	if aerr, ok := err.(awserr.Error); ok {
+		return aerr.Code()
+	}
+	return ""
+}
+
+// runConsumer reads the records of a claimed shard until the shard has been
+// consumed in full, the shard has been claimed by another client, or the input
+// is closing.
+func (r *dynamoDBStreamsReader) runConsumer(ctx context.Context, wg *sync.WaitGroup, shardID, startingSequence string) {
+	defer wg.Done()
+
+	var ackedMut sync.Mutex
+	var ackedWG sync.WaitGroup
+	ackedSequence := startingSequence
+	getSequence := func() string {
+		ackedMut.Lock()
+		defer ackedMut.Unlock()
+		return ackedSequence
+	}
+
+	state := awsKinesisConsumerClosing
+	defer func() {
+		reason := ""
+		switch state {
+		case awsKinesisConsumerFinished:
+			reason = " because the shard is closed"
+			acked := make(chan struct{})
+			go func() {
+				ackedWG.Wait()
+				close(acked)
+			}()
+			select {
+			case <-acked:
+				if _, err := r.checkpointer.Checkpoint(context.Background(), r.streamARN, shardID, dynamoDBStreamsShardEnd, true); err != nil {
+					r.log.Errorf("Failed to store final checkpoint for finished shard '%v': %v", shardID, err)
+				}
+			case <-ctx.Done():
+				if _, err := r.checkpointer.Checkpoint(context.Background(), r.streamARN, shardID, getSequence(), true); err != nil {
+					r.log.Errorf("Failed to store final checkpoint for shard '%v': %v", shardID, err)
+				}
+			}
+		case awsKinesisConsumerYielding:
+			reason = " because the shard has been claimed by another client"
+			if err := r.checkpointer.Yield(context.Background(), r.streamARN, shardID, getSequence()); err != nil {
+				r.log.Errorf("Failed to yield checkpoint for stolen shard '%v': %v", shardID, err)
+			}
+		case awsKinesisConsumerClosing:
+			reason = " because the pipeline is shutting down"
+			if _, err := r.checkpointer.Checkpoint(context.Background(), r.streamARN, shardID, getSequence(), true); err != nil {
+				r.log.Errorf("Failed to store final checkpoint for shard '%v': %v", shardID, err)
+			}
+		}
+		r.log.Debugf("Closing stream '%v' shard '%v' as client '%v'%v", r.streamARN, shardID, r.clientID, reason)
+	}()
+
+	if startingSequence == dynamoDBStreamsShardEnd {
+		state = awsKinesisConsumerFinished
+		return
+	}
+
+	r.log.Debugf("Consuming stream '%v' shard '%v' as client '%v'", r.streamARN, shardID, r.clientID)
+
+	batcher, err := r.batchPolicy.NewBatcher(r.mgr)
+	if err != nil {
+		r.log.Errorf("Failed to initialize batch policy for shard '%v': %v", shardID, err)
+		return
+	}
+	defer func() {
+		_ = batcher.Close(context.Background())
+	}()
+
+	iter, err := r.getIter(ctx, shardID, startingSequence)
+	if err != nil {
+		if awsErrCode(err) == dynamodbstreams.ErrCodeResourceNotFoundException {
+			state = awsKinesisConsumerFinished
+		} else if ctx.Err() == nil {
+			r.log.Errorf("Failed to obtain iterator for shard '%v': %v", shardID, err)
+		}
+		return
+	}
+
+	nextCommit := time.Now().Add(r.commitPeriod)
+	commit := func() bool {
+		nextCommit = time.Now().Add(r.commitPeriod)
+		stillOwned, err := r.checkpointer.Checkpoint(ctx, r.streamARN, shardID, getSequence(), false)
+		if err != nil {
+			if ctx.Err() == nil {
+				r.log.Errorf("Failed to store checkpoint for shard '%v': %v", shardID, err)
+			}
+			return ctx.Err() == nil
+		}
+		if !stillOwned {
+			state = awsKinesisConsumerYielding
+			return false
+		}
+		return true
+	}
+
+	// Tracks the sequence of the latest record added to the batcher, and
+	// dispatches batches once capacity is available whilst continuing to
+	// commit the latest acknowledged sequence.
+	capped := checkpoint.NewCapped(int64(r.checkpointLimit))
+	var batchedSequence string
+	flush := func() bool {
+		batch, err := batcher.Flush(ctx)
+		if err != nil {
+			r.log.Errorf("Failed to flush batch of shard '%v': %v", shardID, err)
+		}
+		if len(batch) == 0 {
+			return true
+		}
+
+		var resolveFn func() interface{}
+		for resolveFn == nil {
+			trackCtx, done := context.WithDeadline(ctx, nextCommit)
+			resolveFn, _ = capped.Track(trackCtx, batchedSequence, int64(len(batch)))
+			done()
+			if resolveFn == nil && (ctx.Err() != nil || !commit()) {
+				return false
+			}
+		}
+
+		ackedWG.Add(1)
+		var ackOnce sync.Once
+		b := dynamoDBStreamsBatch{
+			batch: batch,
+			ackFn: func(ctx context.Context, err error) error {
+				// Nacks are retried by the input wrapper, and so acks are
+				// always successful.
+				ackOnce.Do(func() {
+					if topSequence := resolveFn(); topSequence != nil {
+						ackedMut.Lock()
+						ackedSequence = topSequence.(string)
+						ackedMut.Unlock()
+					}
+					ackedWG.Done()
+				})
+				return nil
+			},
+		}
+		for {
+			select {
+			case r.msgChan <- b:
+				return true
+			case <-time.After(time.Until(nextCommit)):
+				if !commit() {
+					ackedWG.Done()
+					return false
+				}
+			case <-ctx.Done():
+				ackedWG.Done()
+				return false
+			}
+		}
+	}
+
+	boff := backoff.NewExponentialBackOff()
+	boff.InitialInterval = 300 * time.Millisecond
+	boff.MaxInterval = 5 * time.Second
+	boff.MaxElapsedTime = 0
+
+	for {
+		var wait time.Duration
+		res, err := r.svc.GetRecordsWithContext(ctx, &dynamodbstreams.GetRecordsInput{
+			ShardIterator: &iter,
+		})
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			wait = boff.NextBackOff()
+			if awsErrCode(err) == dynamodbstreams.ErrCodeExpiredIteratorException {
+				r.log.Warn("Shard iterator expired, attempting to refresh")
+				if iter, err = r.getIter(ctx, shardID, getSequence()); err != nil {
+					if ctx.Err() == nil {
+						r.log.Errorf("Failed to refresh iterator for shard '%v': %v", shardID, err)
+					}
+					return
+				}
+			} else {
+				r.log.Errorf("Failed to pull records from shard '%v': %v", shardID, err)
+			}
+		} else {
+			for _, rec := range res.Records {
+				if rec.Dynamodb != nil {
+					batchedSequence = aws.StringValue(rec.Dynamodb.SequenceNumber)
+				}
+				if batcher.Add(dynamoDBStreamsRecordToMessage(r.streamARN, shardID, rec)) && !flush() {
+					return
+				}
+			}
+
+			// An empty next iterator means that the shard is closed and all
+			// of its records have been read.
+			if res.NextShardIterator == nil || *res.NextShardIterator == "" {
+				if flush() {
+					state = awsKinesisConsumerFinished
+				}
+				return
+			}
+			iter = *res.NextShardIterator
+
+			if len(res.Records) == 0 {
+				wait = boff.NextBackOff()
+			} else {
+				boff.Reset()
+			}
+		}
+
+		if tNext, exists := batcher.UntilNext(); exists && tNext < wait {
+			wait = tNext
+		}
+		if wait > 0 {
+			select {
+			case <-time.After(wait):
+			case <-ctx.Done():
+				return
+			}
+		}
+		if tNext, exists := batcher.UntilNext(); exists && tNext <= 0 && !flush() {
+			return
+		}
+		if !time.Now().Before(nextCommit) && !commit() {
+			return
+		}
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// dynamoDBStreamsRecordToMessage converts a change record into a structured
+// message.
+func dynamoDBStreamsRecordToMessage(streamARN, shardID string, rec *dynamodbstreams.Record) *service.Message {
+	var sequence string
+	structured := map[string]interface{}{
+		"event_id":   aws.StringValue(rec.EventID),
+		"event_name": aws.StringValue(rec.EventName),
+	}
+	if d := rec.Dynamodb; d != nil {
+		sequence = aws.StringValue(d.SequenceNumber)
+		structured["sequence_number"] = sequence
+		if d.ApproximateCreationDateTime != nil {
+			structured["approximate_creation_time"] = d.ApproximateCreationDateTime.UTC().Format(time.RFC3339Nano)
+		}
+		if d.Keys != nil {
+			structured["keys"] = dynamoDBAttributeMapToValue(d.Keys)
+		}
+		if d.NewImage != nil {
+			structured["new_image"] = dynamoDBAttributeMapToValue(d.NewImage)
+		}
+		if d.OldImage != nil {
+			structured["old_image"] = dynamoDBAttributeMapToValue(d.OldImage)
+		}
+	}
+	if rec.UserIdentity != nil {
+		structured["user_identity"] = map[string]interface{}{
+			"type":         aws.StringValue(rec.UserIdentity.Type),
+			"principal_id": aws.StringValue(rec.UserIdentity.PrincipalId),
+		}
+	}
+
+	msg := service.NewMessage(nil)
+	msg.SetStructured(structured)
+	msg.MetaSet("dynamodb_stream_arn", streamARN)
+	msg.MetaSet("dynamodb_shard", shardID)
+	msg.MetaSet("dynamodb_sequence_number", sequence)
+	msg.MetaSet("dynamodb_event_name", aws.StringValue(rec.EventName))
+	msg.MetaSet("dynamodb_event_id", aws.StringValue(rec.EventID))
+	return msg
+}
+
+func dynamoDBAttributeMapToValue(m map[string]*dynamodb.AttributeValue) map[string]interface{} {
+	obj := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		obj[k] = dynamoDBAttributeToValue(v)
+	}
+	return obj
+}
+
+// dynamoDBAttributeToValue converts a DynamoDB attribute into a structured
+// value, and is the reverse of walkJSON.
+func dynamoDBAttributeToValue(v *dynamodb.AttributeValue) interface{} {
+	switch {
+	case v == nil:
+		return nil
+	case v.S != nil:
+		return *v.S
+	case v.N != nil:
+		return json.Number(*v.N)
+	case v.B != nil:
+		return v.B
+	case v.BOOL != nil:
+		return *v.BOOL
+	case v.M != nil:
+		return dynamoDBAttributeMapToValue(v.M)
+	case v.L != nil:
+		arr := make([]interface{}, len(v.L))
+		for i, e := range v.L {
+			arr[i] = dynamoDBAttributeToValue(e)
+		}
+		return arr
+	case v.SS != nil:
+		arr := make([]interface{}, len(v.SS))
+		for i, e := range v.SS {
+			arr[i] = aws.StringValue(e)
+		}
+		return arr
+	case v.NS != nil:
+		arr := make([]interface{}, len(v.NS))
+		for i, e := range v.NS {
+			arr[i] = json.Number(aws.StringValue(e))
+		}
+		return arr
+	case v.BS != nil:
+		arr := make([]interface{}, len(v.BS))
+		for i, e := range v.BS {
+			arr[i] = e
+		}
+		return arr
+	}
+	return nil
+}
@@ -0,0 +1,211 @@
+package aws
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodbstreams"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func TestDynamoDBStreamsRecordToMessage(t *testing.T) {
+	created := time.Date(2022, 4, 1, 12, 0, 0, 0, time.UTC)
+	msg := dynamoDBStreamsRecordToMessage("arn:foo", "shard-1", &dynamodbstreams.Record{
+		EventID:   aws.String("abc"),
+		EventName: aws.String("MODIFY"),
+		Dynamodb: &dynamodbstreams.StreamRecord{
+			ApproximateCreationDateTime: &created,
+			SequenceNumber:              aws.String("111"),
+			Keys: map[string]*dynamodb.AttributeValue{
+				"id": {S: aws.String("foo")},
+			},
+			OldImage: map[string]*dynamodb.AttributeValue{
+				"id":    {S: aws.String("foo")},
+				"count": {N: aws.String("1")},
+			},
+			NewImage: map[string]*dynamodb.AttributeValue{
+				"id":      {S: aws.String("foo")},
+				"count":   {N: aws.String("12345678901234567890")},
+				"active":  {BOOL: aws.Bool(true)},
+				"deleted": {NULL: aws.Bool(true)},
+				"tags":    {SS: []*string{aws.String("a"), aws.String("b")}},
+				"scores":  {NS: []*string{aws.String("1.5")}},
+				"nested": {M: map[string]*dynamodb.AttributeValue{
+					"list": {L: []*dynamodb.AttributeValue{
+						{S: aws.String("bar")},
+						{N: aws.String("2")},
+					}},
+				}},
+			},
+		},
+	})
+
+	structured, err := msg.AsStructured()
+	require.NoError(t, err)
+
+	jBytes, err := json.Marshal(structured)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+  "event_id": "abc",
+  "event_name": "MODIFY",
+  "sequence_number": "111",
+  "approximate_creation_time": "2022-04-01T12:00:00Z",
+  "keys": {"id": "foo"},
+  "old_image": {"id": "foo", "count": 1},
+  "new_image": {
+    "id": "foo",
+    "count": 12345678901234567890,
+    "active": true,
+    "deleted": null,
+    "tags": ["a", "b"],
+    "scores": [1.5],
+    "nested": {"list": ["bar", 2]}
+  }
+}`, string(jBytes))
+	assert.Contains(t, string(jBytes), `"count":12345678901234567890`)
+
+	for k, v := range map[string]string{
+		"dynamodb_stream_arn":      "arn:foo",
+		"dynamodb_shard":           "shard-1",
+		"dynamodb_sequence_number": "111",
+		"dynamodb_event_name":      "MODIFY",
+		"dynamodb_event_id":        "abc",
+	} {
+		actual, exists := msg.MetaGet(k)
+		assert.True(t, exists, k)
+		assert.Equal(t, v, actual, k)
+	}
+}
+
+func TestDynamoDBStreamsRecordUserIdentity(t *testing.T) {
+	msg := dynamoDBStreamsRecordToMessage("arn:foo", "shard-1", &dynamodbstreams.Record{
+		EventID:   aws.String("abc"),
+		EventName: aws.String("REMOVE"),
+		Dynamodb: &dynamodbstreams.StreamRecord{
+			SequenceNumber: aws.String("222"),
+			Keys: map[string]*dynamodb.AttributeValue{
+				"id": {S: aws.String("foo")},
+			},
+		},
+		UserIdentity: &dynamodbstreams.Identity{
+			Type:        aws.String("Service"),
+			PrincipalId: aws.String("dynamodb.amazonaws.com"),
+		},
+	})
+
+	structured, err := msg.AsStructured()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"event_id":        "abc",
+		"event_name":      "REMOVE",
+		"sequence_number": "222",
+		"keys":            map[string]interface{}{"id": "foo"},
+		"user_identity": map[string]interface{}{
+			"type":         "Service",
+			"principal_id": "dynamodb.amazonaws.com",
+		},
+	}, structured)
+}
+
+func TestDynamoDBStreamsClaimable(t *testing.T) {
+	shard := func(id, parent string) *dynamodbstreams.Shard {
+		s := &dynamodbstreams.Shard{ShardId: aws.String(id)}
+		if parent != "" {
+			s.ParentShardId = aws.String(parent)
+		}
+		return s
+	}
+
+	shards := []*dynamodbstreams.Shard{
+		shard("a", "trimmed"),
+		shard("b", "a"),
+		shard("c", "b"),
+		shard("d", ""),
+		shard("e", "d"),
+	}
+
+	assert.Equal(t, []string{"a", "d"}, dynamoDBStreamsClaimable(shards, map[string]struct{}{}))
+	assert.Equal(t, []string{"b", "d"}, dynamoDBStreamsClaimable(shards, map[string]struct{}{
+		"a": {},
+	}))
+	assert.Equal(t, []string{"c", "e"}, dynamoDBStreamsClaimable(shards, map[string]struct{}{
+		"a": {}, "b": {}, "d": {},
+	}))
+}
+
+func TestDynamoDBStreamsConfigErrors(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		config string
+		err    string
+	}{
+		{
+			name: "no stream",
+			config: `
+dynamodb:
+  table: foo
+`,
+			err: "either a table or stream_arn must be specified",
+		},
+		{
+			name: "no checkpoint table",
+			config: `
+table: foo
+`,
+			err: "a dynamodb table must be specified for storing checkpoints",
+		},
+		{
+			name: "bad checkpoint limit",
+			config: `
+table: foo
+checkpoint_limit: 0
+dynamodb:
+  table: bar
+`,
+			err: "checkpoint_limit must be at least 1",
+		},
+		{
+			name: "batching by key",
+			config: `
+table: foo
+dynamodb:
+  table: bar
+batching:
+  key: this.keys.id
+`,
+			err: "batching by key is not supported by this input",
+		},
+	} {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			conf, err := dynamoDBStreamsInputConfig().ParseYAML(test.config, nil)
+			require.NoError(t, err)
+
+			_, err = newDynamoDBStreamsReaderFromConfig(conf, service.MockResources())
+			require.EqualError(t, err, test.err)
+		})
+	}
+
+	conf, err := dynamoDBStreamsInputConfig().ParseYAML(`
+table: foo
+dynamodb:
+  table: bar
+  billing_mode: PROVISIONED
+  read_capacity_units: 5
+`, nil)
+	require.NoError(t, err)
+
+	r, err := newDynamoDBStreamsReaderFromConfig(conf, service.MockResources())
+	require.NoError(t, err)
+	assert.Equal(t, "bar", r.checkpointConf.Table)
+	assert.Equal(t, "PROVISIONED", r.checkpointConf.BillingMode)
+	assert.Equal(t, int64(5), r.checkpointConf.ReadCapacityUnits)
+	assert.Equal(t, 1024, r.checkpointLimit)
+	assert.Equal(t, 5*time.Second, r.commitPeriod)
+}
@@ -54,6 +54,12 @@ By default messages of a shard can be processed in parallel, up to a limit deter
 
 It's possible to configure Benthos to create the DynamoDB table required for coordination if it does not already exist. However, if you wish to create this yourself (recommended) then create a table with a string HASH key ` + "`StreamID`" + ` and a string RANGE key ` + "`ShardID`" + `. 
 
+### Enhanced Fan-Out
+
+By default records are polled from each shard, where the read throughput of a shard is shared by all of its consumers and each record is only received once it has been polled. When ` + "`enhanced_fan_out.enabled`" + ` is set to ` + "`true`" + ` a consumer is registered with each stream (or reused when it already exists) and the records of each shard are instead pushed to this input as soon as they are written, with a read throughput that is dedicated to the consumer.
+
+The sequences consumed are stored within the DynamoDB table and shards are balanced across instances exactly as they are when polling. However, only one subscription to a shard can be active per consumer, and so inputs of different pipelines that consume the same stream with enhanced fan-out must use different consumer names, which they do by default when they use different tables. Registering consumers requires the permissions ` + "`kinesis:DescribeStreamSummary`, `kinesis:DescribeStreamConsumer` and `kinesis:RegisterStreamConsumer`" + `, and subscribing requires ` + "`kinesis:SubscribeToShard`" + `. Consumers are not deregistered when the input closes, and are charged for while they exist.
+
 ### Batching
 
 Use the ` + "`batching`" + ` fields to configure an optional [batching policy](/docs/configuration/batching#batch-policy). Each stream shard will be batched separately in order to ensure that acknowledgements aren't contaminated.
@@ -69,6 +75,12 @@ Use the ` + "`batching`" + ` fields to configure an optional [batching policy](/
 				docs.FieldInt("read_capacity_units", "Set the provisioned read capacity when creating the table with a `billing_mode` of `PROVISIONED`.").Advanced(),
 				docs.FieldInt("write_capacity_units", "Set the provisioned write capacity when creating the table with a `billing_mode` of `PROVISIONED`.").Advanced(),
 			),
+			docs.FieldObject(
+				"enhanced_fan_out", "Determines whether shards are consumed with an [enhanced fan-out](#enhanced-fan-out) consumer, which pushes records to this input with a dedicated throughput rather than polling them.",
+			).WithChildren(
+				docs.FieldBool("enabled", "Whether to consume shards with an enhanced fan-out consumer."),
+				docs.FieldString("consumer_name", "The name of the consumer to register with each stream, which defaults to the name of the DynamoDB table when empty. Consumers are registered automatically when they do not yet exist."),
+			).AtVersion("4.3.0"),
 			docs.FieldInt(
 				"checkpoint_limit", "The maximum gap between the in flight sequence versus the latest acknowledged sequence at a given time. Increasing this limit enables parallel processing and batching at the output level to work on individual shards. Any given sequence will not be committed unless all messages under that offset are delivered in order to preserve at least once delivery guarantees.",
 			),
@@ -113,6 +125,10 @@ type kinesisReader struct {
 	svc          kinesisiface.KinesisAPI
 	checkpointer *awsKinesisCheckpointer
 
+	// consumerARNs are the enhanced fan-out consumers of each stream, which
+	// is empty when records are polled instead.
+	consumerARNs map[string]string
+
 	streamShards    map[string][]string
 	balancedStreams []string
 
@@ -314,7 +330,15 @@ func (k *kinesisReader) runConsumer(wg *sync.WaitGroup, streamID, shardID, start
 	// Stores consumed records that have yet to be added to the batcher.
 	var pending []*kinesis.Record
 	var iter string
-	if iter, initErr = k.getIter(streamID, shardID, startingSequence); initErr != nil {
+
+	// When consuming with enhanced fan-out records are pushed from a
+	// subscription rather than polled with an iterator.
+	var subRecordsChan <-chan []*kinesis.Record
+	subCtx, subDone := context.WithCancel(k.ctx)
+	if consumerARN, exists := k.consumerARNs[streamID]; exists {
+		subRecordsChan = k.subscribeShard(subCtx, consumerARN, shardID, startingSequence)
+	} else if iter, initErr = k.getIter(streamID, shardID, startingSequence); initErr != nil {
+		subDone()
 		return initErr
 	}
 
@@ -335,13 +359,21 @@ func (k *kinesisReader) runConsumer(wg *sync.WaitGroup, streamID, shardID, start
 	//    is nil when our current batched message is a zero value (we don't have
 	//    one prepared).
 	// 4. Next commit, is "done" when the next commit is due.
+	//
+	// When consuming with enhanced fan-out the record pulling is replaced with
+	// a channel of pushed records, which is nil while we have pending records.
 	var nextTimedBatchChan <-chan time.Time
 	var nextPullChan <-chan time.Time = unblockedChan
 	var nextFlushChan chan<- asyncMessage
+	var nextRecordsChan <-chan []*kinesis.Record
+	if subRecordsChan != nil {
+		nextPullChan = nil
+	}
 	commitCtx, commitCtxClose := context.WithTimeout(k.ctx, k.commitPeriod)
 
 	go func() {
 		defer func() {
+			subDone()
 			commitCtxClose()
 			recordBatcher.Close(state == awsKinesisConsumerFinished)
 			boff.Reset()
@@ -383,7 +415,12 @@ func (k *kinesisReader) runConsumer(wg *sync.WaitGroup, streamID, shardID, start
 
 		for {
 			var err error
-			if state == awsKinesisConsumerConsuming && len(pending) == 0 && nextPullChan == unblockedChan {
+			if subRecordsChan != nil {
+				nextRecordsChan = nil
+				if state == awsKinesisConsumerConsuming && len(pending) == 0 {
+					nextRecordsChan = subRecordsChan
+				}
+			} else if state == awsKinesisConsumerConsuming && len(pending) == 0 && nextPullChan == unblockedChan {
 				if pending, iter, err = k.getRecords(streamID, shardID, iter); err != nil {
 					if !awsErrIsTimeout(err) {
 						nextPullChan = time.After(boff.NextBackOff())
@@ -484,6 +521,15 @@ func (k *kinesisReader) runConsumer(wg *sync.WaitGroup, streamID, shardID, start
 				pendingMsg = asyncMessage{}
 			case <-nextPullChan:
 				nextPullChan = unblockedChan
+			case records, open := <-nextRecordsChan:
+				if !open {
+					if k.ctx.Err() != nil {
+						state = awsKinesisConsumerClosing
+						return
+					}
+					state = awsKinesisConsumerFinished
+				}
+				pending = records
 			case <-k.ctx.Done():
 				state = awsKinesisConsumerClosing
 				return
@@ -696,6 +742,12 @@ func (k *kinesisReader) ConnectWithContext(ctx context.Context) error {
 
 	k.svc = svc
 	k.checkpointer = checkpointer
+
+	if k.conf.EnhancedFanOut.Enabled {
+		if k.consumerARNs, err = k.registerConsumers(ctx); err != nil {
+			return err
+		}
+	}
 	k.msgChan = make(chan asyncMessage)
 
 	if len(k.streamShards) > 0 {
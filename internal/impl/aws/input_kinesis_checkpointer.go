@@ -403,3 +403,31 @@ func (k *awsKinesisCheckpointer) Delete(ctx context.Context, streamID, shardID s
 	})
 	return err
 }
+
+// ShardsAtSequence returns the IDs of all shards of a stream with a checkpoint
+// at a given sequence number, regardless of whether they are claimed.
+func (k *awsKinesisCheckpointer) ShardsAtSequence(ctx context.Context, streamID, sequenceNumber string) ([]string, error) {
+	var shardIDs []string
+	if err := k.svc.ScanPagesWithContext(ctx, &dynamodb.ScanInput{
+		TableName:        aws.String(k.conf.Table),
+		FilterExpression: aws.String("StreamID = :stream_id AND SequenceNumber = :sequence_number"),
+		ExpressionAttributeValues: map[string]*dynamodb.AttributeValue{
+			":stream_id": {
+				S: &streamID,
+			},
+			":sequence_number": {
+				S: &sequenceNumber,
+			},
+		},
+	}, func(page *dynamodb.ScanOutput, last bool) bool {
+		for _, i := range page.Items {
+			if s, ok := i["ShardID"]; ok && s.S != nil {
+				shardIDs = append(shardIDs, *s.S)
+			}
+		}
+		return true
+	}); err != nil {
+		return nil, err
+	}
+	return shardIDs, nil
+}
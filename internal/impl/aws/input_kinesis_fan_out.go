@@ -0,0 +1,159 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/kinesis"
+)
+
+// registerConsumers obtains the ARN of an enhanced fan-out consumer for each
+// stream consumed, registering consumers that do not yet exist and waiting for
+// them to become active.
+func (k *kinesisReader) registerConsumers(ctx context.Context) (map[string]string, error) {
+	consumerName := k.conf.EnhancedFanOut.ConsumerName
+	if consumerName == "" {
+		consumerName = k.conf.DynamoDB.Table
+	}
+
+	streams := append([]string{}, k.balancedStreams...)
+	for streamID := range k.streamShards {
+		streams = append(streams, streamID)
+	}
+
+	consumerARNs := make(map[string]string, len(streams))
+	for _, streamID := range streams {
+		summary, err := k.svc.DescribeStreamSummaryWithContext(ctx, &kinesis.DescribeStreamSummaryInput{
+			StreamName: aws.String(streamID),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe stream '%v': %w", streamID, err)
+		}
+		streamARN := summary.StreamDescriptionSummary.StreamARN
+
+		for {
+			var consumer *kinesis.ConsumerDescription
+			res, err := k.svc.DescribeStreamConsumerWithContext(ctx, &kinesis.DescribeStreamConsumerInput{
+				StreamARN:    streamARN,
+				ConsumerName: aws.String(consumerName),
+			})
+			if err == nil {
+				consumer = res.ConsumerDescription
+			} else if aerr, ok := err.(awserr.Error); ok && aerr.Code() == kinesis.ErrCodeResourceNotFoundException {
+				k.log.Infof("Registering consumer '%v' of stream '%v'\n", consumerName, streamID)
+				var regRes *kinesis.RegisterStreamConsumerOutput
+				if regRes, err = k.svc.RegisterStreamConsumerWithContext(ctx, &kinesis.RegisterStreamConsumerInput{
+					StreamARN:    streamARN,
+					ConsumerName: aws.String(consumerName),
+				}); err == nil {
+					consumer = &kinesis.ConsumerDescription{
+						ConsumerARN:    regRes.Consumer.ConsumerARN,
+						ConsumerStatus: regRes.Consumer.ConsumerStatus,
+					}
+				}
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to obtain consumer '%v' of stream '%v': %w", consumerName, streamID, err)
+			}
+
+			if aws.StringValue(consumer.ConsumerStatus) == kinesis.ConsumerStatusActive {
+				consumerARNs[streamID] = aws.StringValue(consumer.ConsumerARN)
+				break
+			}
+
+			k.log.Debugf("Waiting for consumer '%v' of stream '%v' to become active\n", consumerName, streamID)
+			select {
+			case <-time.After(time.Second):
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+	}
+	return consumerARNs, nil
+}
+
+// subscribeShard pushes the records of a shard from an enhanced fan-out
+// subscription, renewing subscriptions as they expire. The returned channel is
+// closed once the shard has ended or the context is cancelled.
+func (k *kinesisReader) subscribeShard(ctx context.Context, consumerARN, shardID, sequence string) <-chan []*kinesis.Record {
+	recordsChan := make(chan []*kinesis.Record)
+
+	go func() {
+		defer close(recordsChan)
+
+		boff := k.backoffCtor()
+		for {
+			position := &kinesis.StartingPosition{
+				Type: aws.String(kinesis.ShardIteratorTypeLatest),
+			}
+			if sequence != "" {
+				position.Type = aws.String(kinesis.ShardIteratorTypeAfterSequenceNumber)
+				position.SequenceNumber = aws.String(sequence)
+			} else if k.conf.StartFromOldest {
+				position.Type = aws.String(kinesis.ShardIteratorTypeTrimHorizon)
+			}
+
+			res, err := k.svc.SubscribeToShardWithContext(ctx, &kinesis.SubscribeToShardInput{
+				ConsumerARN:      aws.String(consumerARN),
+				ShardId:          aws.String(shardID),
+				StartingPosition: position,
+			})
+			if err == nil {
+				var ended bool
+				if ended, err = k.readSubscription(ctx, res.GetStream(), recordsChan, &sequence); ended {
+					return
+				}
+				if err == nil {
+					// Subscriptions expire after five minutes, at which point
+					// we subscribe again from where we left off.
+					boff.Reset()
+					continue
+				}
+			}
+			if ctx.Err() != nil {
+				return
+			}
+
+			// A subscription that has only just ended may still be active for
+			// a few seconds, in which case we also back off.
+			if aerr, ok := err.(awserr.Error); !ok || aerr.Code() != kinesis.ErrCodeResourceInUseException {
+				k.log.Errorf("Failed to subscribe to shard '%v': %v\n", shardID, err)
+			}
+			select {
+			case <-time.After(boff.NextBackOff()):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return recordsChan
+}
+
+// readSubscription pushes the records of a subscription until it expires, and
+// returns true when the shard has ended or the context is cancelled. The
+// sequence is updated to the continuation of each event pushed.
+func (k *kinesisReader) readSubscription(ctx context.Context, stream *kinesis.SubscribeToShardEventStream, recordsChan chan<- []*kinesis.Record, sequence *string) (bool, error) {
+	defer stream.Close()
+
+	for event := range stream.Events() {
+		e, ok := event.(*kinesis.SubscribeToShardEvent)
+		if !ok {
+			continue
+		}
+		if len(e.Records) > 0 {
+			select {
+			case recordsChan <- e.Records:
+			case <-ctx.Done():
+				return true, nil
+			}
+		}
+		if e.ContinuationSequenceNumber == nil || len(e.ChildShards) > 0 {
+			return true, nil
+		}
+		*sequence = *e.ContinuationSequenceNumber
+	}
+	return ctx.Err() != nil, stream.Err()
+}
@@ -0,0 +1,191 @@
+package aws
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/kinesis"
+	"github.com/aws/aws-sdk-go/service/kinesis/kinesisiface"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/component/input"
+	"github.com/benthosdev/benthos/v4/internal/manager/mock"
+)
+
+type mockKinesisFanOut struct {
+	kinesisiface.KinesisAPI
+
+	mut            sync.Mutex
+	consumerStatus string
+	registered     []string
+	subscriptions  []*kinesis.StartingPosition
+	events         [][]kinesis.SubscribeToShardEventStreamEvent
+}
+
+func (m *mockKinesisFanOut) DescribeStreamSummaryWithContext(ctx aws.Context, in *kinesis.DescribeStreamSummaryInput, opts ...request.Option) (*kinesis.DescribeStreamSummaryOutput, error) {
+	return &kinesis.DescribeStreamSummaryOutput{
+		StreamDescriptionSummary: &kinesis.StreamDescriptionSummary{
+			StreamARN: aws.String("arn:stream/" + *in.StreamName),
+		},
+	}, nil
+}
+
+func (m *mockKinesisFanOut) DescribeStreamConsumerWithContext(ctx aws.Context, in *kinesis.DescribeStreamConsumerInput, opts ...request.Option) (*kinesis.DescribeStreamConsumerOutput, error) {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+
+	if m.consumerStatus == "" {
+		return nil, awserr.New(kinesis.ErrCodeResourceNotFoundException, "not found", nil)
+	}
+	status := m.consumerStatus
+	m.consumerStatus = kinesis.ConsumerStatusActive
+	return &kinesis.DescribeStreamConsumerOutput{
+		ConsumerDescription: &kinesis.ConsumerDescription{
+			ConsumerARN:    aws.String(*in.StreamARN + "/consumer/" + *in.ConsumerName),
+			ConsumerStatus: aws.String(status),
+		},
+	}, nil
+}
+
+func (m *mockKinesisFanOut) RegisterStreamConsumerWithContext(ctx aws.Context, in *kinesis.RegisterStreamConsumerInput, opts ...request.Option) (*kinesis.RegisterStreamConsumerOutput, error) {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+
+	m.registered = append(m.registered, *in.ConsumerName)
+	m.consumerStatus = kinesis.ConsumerStatusCreating
+	return &kinesis.RegisterStreamConsumerOutput{
+		Consumer: &kinesis.Consumer{
+			ConsumerARN:    aws.String(*in.StreamARN + "/consumer/" + *in.ConsumerName),
+			ConsumerStatus: aws.String(kinesis.ConsumerStatusCreating),
+		},
+	}, nil
+}
+
+type mockShardEventReader struct {
+	events chan kinesis.SubscribeToShardEventStreamEvent
+}
+
+func (m *mockShardEventReader) Events() <-chan kinesis.SubscribeToShardEventStreamEvent {
+	return m.events
+}
+
+func (m *mockShardEventReader) Close() error {
+	return nil
+}
+
+func (m *mockShardEventReader) Err() error {
+	return nil
+}
+
+func (m *mockKinesisFanOut) SubscribeToShardWithContext(ctx aws.Context, in *kinesis.SubscribeToShardInput, opts ...request.Option) (*kinesis.SubscribeToShardOutput, error) {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+
+	m.subscriptions = append(m.subscriptions, in.StartingPosition)
+	if len(m.events) == 0 {
+		return nil, awserr.New(kinesis.ErrCodeResourceInUseException, "in use", nil)
+	}
+
+	reader := &mockShardEventReader{events: make(chan kinesis.SubscribeToShardEventStreamEvent, len(m.events[0]))}
+	for _, e := range m.events[0] {
+		reader.events <- e
+	}
+	close(reader.events)
+	m.events = m.events[1:]
+
+	return &kinesis.SubscribeToShardOutput{
+		EventStream: kinesis.NewSubscribeToShardEventStream(func(es *kinesis.SubscribeToShardEventStream) {
+			es.Reader = reader
+			es.StreamCloser = reader
+		}),
+	}, nil
+}
+
+func testFanOutReader(t *testing.T, svc kinesisiface.KinesisAPI) *kinesisReader {
+	t.Helper()
+
+	conf := input.NewAWSKinesisConfig()
+	conf.Streams = []string{"foo"}
+	conf.DynamoDB.Table = "bar"
+	conf.EnhancedFanOut.Enabled = true
+
+	k, err := newKinesisReader(conf, mock.NewManager())
+	require.NoError(t, err)
+	k.svc = svc
+	return k
+}
+
+func TestKinesisRegisterConsumers(t *testing.T) {
+	svc := &mockKinesisFanOut{}
+	k := testFanOutReader(t, svc)
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+
+	arns, err := k.registerConsumers(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"foo": "arn:stream/foo/consumer/bar"}, arns)
+	assert.Equal(t, []string{"bar"}, svc.registered)
+
+	// Existing consumers are reused.
+	arns, err = k.registerConsumers(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"foo": "arn:stream/foo/consumer/bar"}, arns)
+	assert.Equal(t, []string{"bar"}, svc.registered)
+}
+
+func TestKinesisSubscribeShard(t *testing.T) {
+	record := func(seq string) *kinesis.Record {
+		return &kinesis.Record{SequenceNumber: aws.String(seq), Data: []byte(seq)}
+	}
+
+	svc := &mockKinesisFanOut{
+		events: [][]kinesis.SubscribeToShardEventStreamEvent{
+			{
+				&kinesis.SubscribeToShardEvent{
+					Records:                    []*kinesis.Record{record("1"), record("2")},
+					ContinuationSequenceNumber: aws.String("2"),
+				},
+				&kinesis.SubscribeToShardEvent{
+					ContinuationSequenceNumber: aws.String("2"),
+				},
+			},
+			{
+				&kinesis.SubscribeToShardEvent{
+					Records:                    []*kinesis.Record{record("3")},
+					ContinuationSequenceNumber: aws.String("3"),
+				},
+				&kinesis.SubscribeToShardEvent{
+					Records:     []*kinesis.Record{record("4")},
+					ChildShards: []*kinesis.ChildShard{{ShardId: aws.String("baz")}},
+				},
+			},
+		},
+	}
+	k := testFanOutReader(t, svc)
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+
+	var seqs []string
+	for records := range k.subscribeShard(ctx, "arn:consumer", "0", "") {
+		for _, r := range records {
+			seqs = append(seqs, *r.SequenceNumber)
+		}
+	}
+	require.NoError(t, ctx.Err())
+	assert.Equal(t, []string{"1", "2", "3", "4"}, seqs)
+
+	// The expired subscription is renewed from the continuation of its last
+	// event.
+	require.Len(t, svc.subscriptions, 2)
+	assert.Equal(t, kinesis.ShardIteratorTypeTrimHorizon, *svc.subscriptions[0].Type)
+	assert.Equal(t, kinesis.ShardIteratorTypeAfterSequenceNumber, *svc.subscriptions[1].Type)
+	assert.Equal(t, "2", *svc.subscriptions[1].SequenceNumber)
+}
@@ -23,7 +23,10 @@ type awsKinesisRecordBatcher struct {
 
 	flushedMessage *message.Batch
 
-	batchedSequence string
+	// The sequence numbers of records added to the batch policy that are yet
+	// to be flushed, in the order that they were added.
+	pendingSequences []string
+	flushedSequence  string
 
 	ackedSequence string
 	ackedMut      sync.Mutex
@@ -54,14 +57,15 @@ func (a *awsKinesisRecordBatcher) AddRecord(r *kinesis.Record) bool {
 	}
 	p.MetaSet("kinesis_sequence_number", *r.SequenceNumber)
 
-	a.batchedSequence = *r.SequenceNumber
 	if a.flushedMessage != nil {
 		// Upstream shouldn't really be adding records if a prior flush was
 		// unsuccessful. However, we can still accommodate this by appending it
 		// to the flushed message.
 		a.flushedMessage.Append(p)
+		a.flushedSequence = *r.SequenceNumber
 		return true
 	}
+	a.pendingSequences = append(a.pendingSequences, *r.SequenceNumber)
 	return a.batchPolicy.Add(p)
 }
 
@@ -74,9 +78,14 @@ func (a *awsKinesisRecordBatcher) FlushMessage(ctx context.Context) (asyncMessag
 		if a.flushedMessage = a.batchPolicy.Flush(); a.flushedMessage == nil {
 			return asyncMessage{}, nil
 		}
+		// Records held back for the next batch are always the latest added,
+		// and the checkpoint must not move past them.
+		flushed := len(a.pendingSequences) - a.batchPolicy.Count()
+		a.flushedSequence = a.pendingSequences[flushed-1]
+		a.pendingSequences = append([]string(nil), a.pendingSequences[flushed:]...)
 	}
 
-	resolveFn, err := a.checkpointer.Track(ctx, a.batchedSequence, int64(a.flushedMessage.Len()))
+	resolveFn, err := a.checkpointer.Track(ctx, a.flushedSequence, int64(a.flushedMessage.Len()))
 	if err != nil {
 		if err == component.ErrTimeout {
 			err = nil
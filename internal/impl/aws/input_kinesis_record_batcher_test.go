@@ -0,0 +1,62 @@
+package aws
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/kinesis"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/batch/policy"
+	"github.com/benthosdev/benthos/v4/internal/batch/policy/batchconfig"
+	"github.com/benthosdev/benthos/v4/internal/checkpoint"
+	"github.com/benthosdev/benthos/v4/internal/manager/mock"
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+func TestKinesisRecordBatcherKeyCheckpoint(t *testing.T) {
+	conf := batchconfig.NewConfig()
+	conf.Key = `content().slice(0, 1)`
+	pol, err := policy.New(conf, mock.NewManager())
+	require.NoError(t, err)
+
+	a := &awsKinesisRecordBatcher{
+		batchPolicy:   pol,
+		checkpointer:  checkpoint.NewCapped(10),
+		ackedSequence: "0",
+	}
+
+	add := func(data, seq string) bool {
+		return a.AddRecord(&kinesis.Record{Data: []byte(data), SequenceNumber: aws.String(seq)})
+	}
+
+	flushAndAck := func(exp ...string) {
+		t.Helper()
+		aMsg, err := a.FlushMessage(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, exp, func() (s []string) {
+			for _, b := range message.GetAllBytes(aMsg.msg) {
+				s = append(s, string(b))
+			}
+			return
+		}())
+		require.NoError(t, aMsg.ackFn(context.Background(), nil))
+	}
+
+	assert.False(t, add("a1", "1"))
+	assert.False(t, add("a2", "2"))
+	assert.True(t, add("b1", "3"))
+
+	// The held back record must not be checkpointed.
+	flushAndAck("a1", "a2")
+	assert.Equal(t, "2", a.GetSequence())
+
+	assert.True(t, add("c1", "4"))
+	flushAndAck("b1")
+	assert.Equal(t, "3", a.GetSequence())
+
+	flushAndAck("c1")
+	assert.Equal(t, "4", a.GetSequence())
+}
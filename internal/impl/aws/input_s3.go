@@ -14,10 +14,12 @@ import (
 
 	"github.com/Jeffail/gabs/v2"
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/aws/aws-sdk-go/service/sqs"
 
+	"github.com/benthosdev/benthos/v4/internal/bloblang/mapping"
 	"github.com/benthosdev/benthos/v4/internal/bundle"
 	"github.com/benthosdev/benthos/v4/internal/codec"
 	"github.com/benthosdev/benthos/v4/internal/component"
@@ -55,7 +57,24 @@ A common pattern for consuming S3 objects is to emit upload notification events
 
 Benthos is able to follow this pattern when you configure an ` + "`sqs.url`" + `, where it consumes events from SQS and only downloads object keys received within those events. In order for this to work Benthos needs to know where within the event the key and bucket names can be found, specified as [dot paths](/docs/configuration/field_paths) with the fields ` + "`sqs.key_path` and `sqs.bucket_path`" + `. The default values for these fields should already be correct when following the guide above.
 
-If your notification events are being routed to SQS via an SNS topic then the events will be enveloped by SNS, in which case you also need to specify the field ` + "`sqs.envelope_path`" + `, which in the case of SNS to SQS will usually be ` + "`Message`" + `.
+If your notification events are being routed to SQS via an SNS topic then the events will be enveloped by SNS. When the field ` + "`sqs.envelope_path`" + ` is empty SNS notifications are detected and unwrapped automatically, otherwise the envelope is extracted from the configured path, which in the case of SNS to SQS will usually be ` + "`Message`" + `.
+
+Events can also be routed to SQS via [Amazon EventBridge](https://docs.aws.amazon.com/AmazonS3/latest/userguide/EventBridge.html), in which case the bucket and key are extracted from the ` + "`detail`" + ` of the event when they cannot be found at the configured paths. Note that, unlike the keys of notification events, keys within EventBridge events are not URL encoded. Test events, which are sent by S3 when notifications are first configured, are deleted from the queue automatically.
+
+Notifications can be filtered before objects are downloaded by the fields ` + "`prefix` and `suffix`" + `, which are matched against the keys of target objects, or with a [Bloblang query](/docs/guides/bloblang/about) specified with ` + "`sqs.key_filter`" + `. The query is executed for each target object against a document containing the fields ` + "`bucket`, `key`, `event_name` and `size`" + `, where the event name and size are null when they could not be found within the event, and objects are only downloaded when it resolves to ` + "`true`" + `. SQS messages where all targets are filtered out are deleted from the queue.
+
+### Cross-Account Buckets
+
+When notifications reference buckets owned by other accounts it's possible to specify a role to assume for each bucket with ` + "`sqs.bucket_roles`" + `, in which case objects of that bucket are downloaded (and deleted) using credentials of the assumed role:
+
+` + "```yaml" + `
+input:
+  aws_s3:
+    sqs:
+      url: https://sqs.us-east-1.amazonaws.com/123456789012/bucket-events
+      bucket_roles:
+        foo-bucket: arn:aws:iam::210987654321:role/benthos-reader
+` + "```" + `
 
 When using SQS please make sure you have sensible values for ` + "`sqs.max_messages`" + ` and also the visibility timeout of the queue itself. When Benthos consumes an S3 object the SQS message that triggered it is not deleted until the S3 object has been sent onwards. This ensures at-least-once crash resiliency, but also means that if the S3 object takes longer to process than the visibility timeout of your queue then the same objects might be processed multiple times.
 
@@ -63,6 +82,8 @@ When using SQS please make sure you have sensible values for ` + "`sqs.max_messa
 
 When downloading large files it's often necessary to process it in streamed parts in order to avoid loading the entire file in memory at a given time. In order to do this a ` + "[`codec`](#codec)" + ` can be specified that determines how to break the input into smaller individual messages.
 
+When the codec is ` + "`auto`" + ` the codec of each object is derived from its key and content encoding, where objects with a gzip content encoding or a ` + "`.gz`" + ` extension are decompressed, and the remaining extension selects how the contents are broken down, e.g. ` + "`.csv` objects are consumed with the `csv` codec and `.jsonl` objects with the `lines` codec" + `.
+
 ## Credentials
 
 By default Benthos will use a shared credentials file when connecting to AWS services. It's also possible to set them explicitly at the component level, allowing you to transfer data across accounts. You can find out more [in this document](/docs/guides/cloud/aws).
@@ -84,7 +105,8 @@ This input adds the following metadata fields to each message:
 You can access these metadata fields using [function interpolation](/docs/configuration/interpolation#metadata). Note that user defined metadata is case insensitive within AWS, and it is likely that the keys will be received in a capitalized form, if you wish to make them consistent you can map all metadata keys to lower or uppercase using a Bloblang mapping such as ` + "`meta = meta().map_each_key(key -> key.lowercase())`" + `.`,
 		Config: docs.FieldComponent().WithChildren(
 			docs.FieldString("bucket", "The bucket to consume from. If the field `sqs.url` is specified this field is optional."),
-			docs.FieldString("prefix", "An optional path prefix, if set only objects with the prefix are consumed when walking a bucket."),
+			docs.FieldString("prefix", "An optional path prefix, if set only objects with the prefix are consumed."),
+			docs.FieldString("suffix", "An optional path suffix, if set only objects with the suffix are consumed.", ".json", ".csv.gz").AtVersion("4.3.0"),
 		).WithChildren(sess.FieldSpecs()...).WithChildren(
 			docs.FieldBool("force_path_style_urls", "Forces the client API to use path style URLs for downloading keys, which is often required when connecting to custom endpoints.").Advanced(),
 			docs.FieldBool("delete_objects", "Whether to delete downloaded objects from the bucket once they are processed.").Advanced(),
@@ -95,6 +117,17 @@ You can access these metadata fields using [function interpolation](/docs/config
 				docs.FieldString("key_path", "A [dot path](/docs/configuration/field_paths) whereby object keys are found in SQS messages."),
 				docs.FieldString("bucket_path", "A [dot path](/docs/configuration/field_paths) whereby the bucket name can be found in SQS messages."),
 				docs.FieldString("envelope_path", "A [dot path](/docs/configuration/field_paths) of a field to extract an enveloped JSON payload for further extracting the key and bucket from SQS messages. This is specifically useful when subscribing an SQS queue to an SNS topic that receives bucket events.", "Message"),
+				docs.FieldBloblang(
+					"key_filter", "An optional [Bloblang query](/docs/guides/bloblang/about) that should return a boolean value indicating whether an object referenced by an SQS message should be downloaded.",
+					`this.key.has_suffix(".json")`,
+					`this.event_name.or("").has_prefix("ObjectCreated:") && this.size.or(1) > 0`,
+				).HasDefault(""),
+				docs.FieldString(
+					"bucket_roles", "An optional map of bucket names to role ARNs that should be assumed when accessing objects of those buckets.",
+					map[string]string{
+						"foo-bucket": "arn:aws:iam::210987654321:role/benthos-reader",
+					},
+				).Map().Advanced(),
 				docs.FieldString(
 					"delay_period",
 					"An optional period of time to wait from when a notification was originally sent to when the target key download is attempted.",
@@ -195,6 +228,9 @@ func newStaticTargetReader(
 		conf: conf,
 	}
 	for _, obj := range output.Contents {
+		if !strings.HasSuffix(*obj.Key, conf.Suffix) {
+			continue
+		}
 		ackFn := deleteS3ObjectAckFn(s3Client, conf.Bucket, *obj.Key, conf.DeleteObjects, nil)
 		staticKeys.pending = append(staticKeys.pending, newS3ObjectTarget(*obj.Key, conf.Bucket, time.Time{}, ackFn))
 	}
@@ -220,6 +256,9 @@ func (s *staticTargetReader) Pop(ctx context.Context) (*s3ObjectTarget, error) {
 			return nil, fmt.Errorf("failed to list objects: %v", err)
 		}
 		for _, obj := range output.Contents {
+			if !strings.HasSuffix(*obj.Key, s.conf.Suffix) {
+				continue
+			}
 			ackFn := deleteS3ObjectAckFn(s.s3, s.conf.Bucket, *obj.Key, s.conf.DeleteObjects, nil)
 			s.pending = append(s.pending, newS3ObjectTarget(*obj.Key, s.conf.Bucket, time.Time{}, ackFn))
 		}
@@ -242,10 +281,11 @@ func (s staticTargetReader) Close(context.Context) error {
 //------------------------------------------------------------------------------
 
 type sqsTargetReader struct {
-	conf input.AWSS3Config
-	log  log.Modular
-	sqs  *sqs.SQS
-	s3   *s3.S3
+	conf      input.AWSS3Config
+	log       log.Modular
+	sqs       *sqs.SQS
+	s3For     func(bucket string) *s3.S3
+	keyFilter *mapping.Executor
 
 	nextRequest time.Time
 
@@ -255,10 +295,11 @@ type sqsTargetReader struct {
 func newSQSTargetReader(
 	conf input.AWSS3Config,
 	log log.Modular,
-	s3 *s3.S3,
+	s3For func(bucket string) *s3.S3,
 	sqs *sqs.SQS,
+	keyFilter *mapping.Executor,
 ) *sqsTargetReader {
-	return &sqsTargetReader{conf, log, sqs, s3, time.Time{}, nil}
+	return &sqsTargetReader{conf, log, sqs, s3For, keyFilter, time.Time{}, nil}
 }
 
 func (s *sqsTargetReader) Pop(ctx context.Context) (*s3ObjectTarget, error) {
@@ -315,14 +356,49 @@ func digStrsFromSlices(slice []interface{}) []string {
 	return strs
 }
 
-func (s *sqsTargetReader) parseObjectPaths(sqsMsg *string) ([]s3ObjectTarget, error) {
+var errS3TestEvent = errors.New("received S3 test event")
+
+// isS3TestEvent returns true if an event is a test event, which S3 sends when
+// event notifications are first configured for a bucket.
+func isS3TestEvent(gObj *gabs.Container) bool {
+	event, _ := gObj.S("Event").Data().(string)
+	return event == "s3:TestEvent"
+}
+
+// isSNSNotification returns true if a message is an SNS notification, which
+// envelopes the original event as a string within the field Message.
+func isSNSNotification(gObj *gabs.Container) bool {
+	msgType, _ := gObj.S("Type").Data().(string)
+	_, isStr := gObj.S("Message").Data().(string)
+	return msgType == "Notification" && isStr && gObj.Exists("TopicArn")
+}
+
+// isS3EventBridgeEvent returns true if an event was routed via EventBridge.
+func isS3EventBridgeEvent(gObj *gabs.Container) bool {
+	source, _ := gObj.S("source").Data().(string)
+	return source == "aws.s3" && gObj.Exists("detail")
+}
+
+// s3EventTarget is an object referenced by an event.
+type s3EventTarget struct {
+	key       string
+	bucket    string
+	eventName interface{}
+	size      interface{}
+}
+
+func (s *sqsTargetReader) parseEventTargets(sqsMsg *string) ([]s3EventTarget, error) {
 	gObj, err := gabs.ParseJSON([]byte(*sqsMsg))
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse SQS message: %v", err)
 	}
 
-	if len(s.conf.SQS.EnvelopePath) > 0 {
-		d := gObj.Path(s.conf.SQS.EnvelopePath).Data()
+	envelopePath := s.conf.SQS.EnvelopePath
+	if envelopePath == "" && isSNSNotification(gObj) {
+		envelopePath = "Message"
+	}
+	if len(envelopePath) > 0 {
+		d := gObj.Path(envelopePath).Data()
 		if str, ok := d.(string); ok {
 			if gObj, err = gabs.ParseJSON([]byte(str)); err != nil {
 				return nil, fmt.Errorf("failed to parse enveloped message: %v", err)
@@ -332,6 +408,10 @@ func (s *sqsTargetReader) parseObjectPaths(sqsMsg *string) ([]s3ObjectTarget, er
 		}
 	}
 
+	if isS3TestEvent(gObj) {
+		return nil, errS3TestEvent
+	}
+
 	var keys []string
 	var buckets []string
 
@@ -341,6 +421,26 @@ func (s *sqsTargetReader) parseObjectPaths(sqsMsg *string) ([]s3ObjectTarget, er
 	case []interface{}:
 		keys = digStrsFromSlices(t)
 	}
+
+	if len(keys) == 0 && isS3EventBridgeEvent(gObj) {
+		key, _ := gObj.Path("detail.object.key").Data().(string)
+		bucket, _ := gObj.Path("detail.bucket.name").Data().(string)
+		if key == "" {
+			return nil, nil
+		}
+		if bucket == "" {
+			if bucket = s.conf.Bucket; bucket == "" {
+				return nil, errors.New("required bucket was not found in SQS message")
+			}
+		}
+		return []s3EventTarget{{
+			key:       key,
+			bucket:    bucket,
+			eventName: gObj.S("detail-type").Data(),
+			size:      gObj.Path("detail.object.size").Data(),
+		}}, nil
+	}
+
 	if len(s.conf.SQS.BucketPath) > 0 {
 		switch t := gObj.Path(s.conf.SQS.BucketPath).Data().(type) {
 		case string:
@@ -350,7 +450,15 @@ func (s *sqsTargetReader) parseObjectPaths(sqsMsg *string) ([]s3ObjectTarget, er
 		}
 	}
 
-	objects := make([]s3ObjectTarget, 0, len(keys))
+	// Event names and sizes are only known when the event follows the standard
+	// notification structure, and are ignored if they don't line up with the
+	// extracted keys.
+	records := gObj.S("Records").Children()
+	if len(records) != len(keys) {
+		records = nil
+	}
+
+	targets := make([]s3EventTarget, 0, len(keys))
 	for i, key := range keys {
 		if key, err = url.QueryUnescape(key); err != nil {
 			return nil, fmt.Errorf("failed to parse key from SQS message: %v", err)
@@ -362,13 +470,59 @@ func (s *sqsTargetReader) parseObjectPaths(sqsMsg *string) ([]s3ObjectTarget, er
 		if bucket == "" {
 			return nil, errors.New("required bucket was not found in SQS message")
 		}
-		objects = append(objects, s3ObjectTarget{
+		target := s3EventTarget{
 			key:    key,
 			bucket: bucket,
-		})
+		}
+		if records != nil {
+			target.eventName = records[i].S("eventName").Data()
+			target.size = records[i].Path("s3.object.size").Data()
+		}
+		targets = append(targets, target)
 	}
+	return targets, nil
+}
 
-	return objects, nil
+// parseObjectPaths extracts the objects referenced by an SQS message, returning
+// those that pass the key filter and the number of objects that were skipped.
+func (s *sqsTargetReader) parseObjectPaths(sqsMsg *string) ([]s3ObjectTarget, int, error) {
+	targets, err := s.parseEventTargets(sqsMsg)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	skipped := 0
+	objects := make([]s3ObjectTarget, 0, len(targets))
+	for _, t := range targets {
+		if !strings.HasPrefix(t.key, s.conf.Prefix) || !strings.HasSuffix(t.key, s.conf.Suffix) {
+			skipped++
+			continue
+		}
+		if s.keyFilter != nil {
+			part := message.NewPart(nil)
+			part.SetJSON(map[string]interface{}{
+				"bucket":     t.bucket,
+				"key":        t.key,
+				"event_name": t.eventName,
+				"size":       t.size,
+			})
+			msg := message.QuickBatch(nil)
+			msg.Append(part)
+			matched, err := s.keyFilter.QueryPart(0, msg)
+			if err != nil {
+				return nil, 0, fmt.Errorf("failed to execute key filter: %w", err)
+			}
+			if !matched {
+				skipped++
+				continue
+			}
+		}
+		objects = append(objects, s3ObjectTarget{
+			key:    t.key,
+			bucket: t.bucket,
+		})
+	}
+	return objects, skipped, nil
 }
 
 func (s *sqsTargetReader) readSQSEvents(ctx context.Context) ([]*s3ObjectTarget, error) {
@@ -410,12 +564,26 @@ func (s *sqsTargetReader) readSQSEvents(ctx context.Context) ([]*s3ObjectTarget,
 			continue
 		}
 
-		objects, err := s.parseObjectPaths(sqsMsg.Body)
+		objects, skipped, err := s.parseObjectPaths(sqsMsg.Body)
 		if err != nil {
+			if errors.Is(err, errS3TestEvent) {
+				s.log.Debugln("Deleting S3 test event from SQS")
+				if aerr := s.ackSQSMessage(ctx, sqsMsg); aerr != nil {
+					s.log.Errorf("Failed to delete S3 test event from SQS: %v\n", aerr)
+				}
+				continue
+			}
 			addDudFn(sqsMsg)
 			s.log.Errorf("SQS extract key error: %v\n", err)
 			continue
 		}
+		if len(objects) == 0 && skipped > 0 {
+			s.log.Tracef("All %v target keys of SQS message were filtered\n", skipped)
+			if aerr := s.ackSQSMessage(ctx, sqsMsg); aerr != nil {
+				s.log.Errorf("Failed to delete filtered SQS message: %v\n", aerr)
+			}
+			continue
+		}
 		if len(objects) == 0 {
 			addDudFn(sqsMsg)
 			s.log.Debugln("Extracted zero target keys from SQS message")
@@ -429,7 +597,7 @@ func (s *sqsTargetReader) readSQSEvents(ctx context.Context) ([]*s3ObjectTarget,
 			pendingObjects = append(pendingObjects, newS3ObjectTarget(
 				object.key, object.bucket, notificationAt,
 				deleteS3ObjectAckFn(
-					s.s3, object.bucket, object.key, s.conf.DeleteObjects,
+					s.s3For(object.bucket), object.bucket, object.key, s.conf.DeleteObjects,
 					func(ctx context.Context, err error) (aerr error) {
 						if err != nil {
 							nackOnce.Do(func() {
@@ -505,9 +673,12 @@ type awsS3Reader struct {
 	objectScannerCtor codec.ReaderConstructor
 	keyReader         s3ObjectTargetReader
 
-	session *session.Session
-	s3      *s3.S3
-	sqs     *sqs.SQS
+	keyFilter *mapping.Executor
+
+	session  *session.Session
+	s3       *s3.S3
+	bucketS3 map[string]*s3.S3
+	sqs      *sqs.SQS
 
 	gracePeriod time.Duration
 
@@ -529,9 +700,6 @@ func newAmazonS3Reader(conf input.AWSS3Config, nm bundle.NewManagement) (*awsS3R
 	if conf.Bucket == "" && conf.SQS.URL == "" {
 		return nil, errors.New("either a bucket or an sqs.url must be specified")
 	}
-	if conf.Prefix != "" && conf.SQS.URL != "" {
-		return nil, errors.New("cannot specify both a prefix and sqs.url")
-	}
 	s := &awsS3Reader{
 		conf: conf,
 		log:  nm.Logger(),
@@ -545,12 +713,27 @@ func newAmazonS3Reader(conf input.AWSS3Config, nm bundle.NewManagement) (*awsS3R
 			return nil, fmt.Errorf("failed to parse grace period: %w", err)
 		}
 	}
+	if conf.SQS.KeyFilter != "" {
+		if s.keyFilter, err = nm.BloblEnvironment().NewMapping(conf.SQS.KeyFilter); err != nil {
+			return nil, fmt.Errorf("failed to parse key filter: %w", err)
+		}
+	}
 	return s, nil
 }
 
+// s3ForBucket returns the client to use for accessing the objects of a bucket,
+// which uses the credentials of an assumed role when one is configured for the
+// bucket.
+func (a *awsS3Reader) s3ForBucket(bucket string) *s3.S3 {
+	if c, exists := a.bucketS3[bucket]; exists {
+		return c
+	}
+	return a.s3
+}
+
 func (a *awsS3Reader) getTargetReader(ctx context.Context) (s3ObjectTargetReader, error) {
 	if a.sqs != nil {
-		return newSQSTargetReader(a.conf, a.log, a.s3, a.sqs), nil
+		return newSQSTargetReader(a.conf, a.log, a.s3ForBucket, a.sqs, a.keyFilter), nil
 	}
 	return newStaticTargetReader(ctx, a.conf, a.log, a.s3)
 }
@@ -571,6 +754,12 @@ func (a *awsS3Reader) ConnectWithContext(ctx context.Context) error {
 
 	a.session = sess
 	a.s3 = s3.New(sess)
+	a.bucketS3 = make(map[string]*s3.S3, len(a.conf.SQS.BucketRoles))
+	for bucket, role := range a.conf.SQS.BucketRoles {
+		a.bucketS3[bucket] = s3.New(sess, &aws.Config{
+			Credentials: stscreds.NewCredentials(sess, role),
+		})
+	}
 	if a.conf.SQS.URL != "" {
 		sqsSess := sess.Copy()
 		if len(a.conf.SQS.Endpoint) > 0 {
@@ -641,7 +830,7 @@ func (a *awsS3Reader) getObjectTarget(ctx context.Context) (*s3PendingObject, er
 		}
 	}
 
-	obj, err := a.s3.GetObject(&s3.GetObjectInput{
+	obj, err := a.s3ForBucket(target.bucket).GetObject(&s3.GetObjectInput{
 		Bucket: aws.String(target.bucket),
 		Key:    aws.String(target.key),
 	})
@@ -654,7 +843,19 @@ func (a *awsS3Reader) getObjectTarget(ctx context.Context) (*s3PendingObject, er
 		target: target,
 		obj:    obj,
 	}
-	if object.scanner, err = a.objectScannerCtor(target.key, obj.Body, target.ackFn); err != nil {
+	scannerCtor := a.objectScannerCtor
+	if a.conf.Codec == "auto" {
+		var contentEncoding string
+		if obj.ContentEncoding != nil {
+			contentEncoding = *obj.ContentEncoding
+		}
+		if scannerCtor, err = codec.GetReader(codec.AutoCodecFor(target.key, contentEncoding), codec.NewReaderConfig()); err != nil {
+			obj.Body.Close()
+			_ = target.ackFn(ctx, err)
+			return nil, err
+		}
+	}
+	if object.scanner, err = scannerCtor(target.key, obj.Body, target.ackFn); err != nil {
 		// Warning: NEVER return io.EOF from a scanner constructor, as this will
 		// falsely indicate that we've reached the end of our list of object
 		// targets when running an SQS feed.
@@ -0,0 +1,135 @@
+package aws
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang/mapping"
+	"github.com/benthosdev/benthos/v4/internal/component/input"
+	"github.com/benthosdev/benthos/v4/internal/log"
+	"github.com/benthosdev/benthos/v4/internal/manager/mock"
+)
+
+func TestS3SQSParseObjectPaths(t *testing.T) {
+	tests := []struct {
+		name        string
+		envelope    string
+		prefix      string
+		suffix      string
+		filter      string
+		body        string
+		expected    []s3ObjectTarget
+		skipped     int
+		errContains string
+	}{
+		{
+			name: "notification event",
+			body: `{"Records":[
+  {"eventVersion":"2.2","eventName":"ObjectCreated:Put","s3":{"bucket":{"name":"foo"},"object":{"key":"a+b%2Fc.json","size":10}}},
+  {"eventVersion":"2.2","eventName":"ObjectCreated:Put","s3":{"bucket":{"name":"bar"},"object":{"key":"d.json","size":20}}}
+]}`,
+			expected: []s3ObjectTarget{
+				{key: "a b/c.json", bucket: "foo"},
+				{key: "d.json", bucket: "bar"},
+			},
+		},
+		{
+			name:     "sns wrapped notification event",
+			envelope: "Message",
+			body:     `{"Type":"Notification","Message":"{\"Records\":[{\"s3\":{\"bucket\":{\"name\":\"foo\"},\"object\":{\"key\":\"a.json\"}}}]}"}`,
+			expected: []s3ObjectTarget{
+				{key: "a.json", bucket: "foo"},
+			},
+		},
+		{
+			name: "sns wrapped notification event without envelope path",
+			body: `{"Type":"Notification","TopicArn":"arn:aws:sns:us-east-1:123456789012:foo","Message":"{\"Records\":[{\"s3\":{\"bucket\":{\"name\":\"foo\"},\"object\":{\"key\":\"a.json\"}}}]}"}`,
+			expected: []s3ObjectTarget{
+				{key: "a.json", bucket: "foo"},
+			},
+		},
+		{
+			name: "sns wrapped eventbridge event",
+			body: `{"Type":"Notification","TopicArn":"arn:aws:sns:us-east-1:123456789012:foo","Message":"{\"detail-type\":\"Object Created\",\"source\":\"aws.s3\",\"detail\":{\"bucket\":{\"name\":\"foo\"},\"object\":{\"key\":\"a.json\"}}}"}`,
+			expected: []s3ObjectTarget{
+				{key: "a.json", bucket: "foo"},
+			},
+		},
+		{
+			name: "eventbridge event",
+			body: `{"version":"0","detail-type":"Object Created","source":"aws.s3","detail":{"bucket":{"name":"foo"},"object":{"key":"a+b.json","size":5}}}`,
+			expected: []s3ObjectTarget{
+				{key: "a+b.json", bucket: "foo"},
+			},
+		},
+		{
+			name:        "test event",
+			body:        `{"Service":"Amazon S3","Event":"s3:TestEvent","Bucket":"foo"}`,
+			errContains: "test event",
+		},
+		{
+			name:   "filter by event name and size",
+			filter: `this.event_name.has_prefix("ObjectCreated:") && this.size.or(0) > 15`,
+			body: `{"Records":[
+  {"eventName":"ObjectCreated:Put","s3":{"bucket":{"name":"foo"},"object":{"key":"a.json","size":10}}},
+  {"eventName":"ObjectCreated:Put","s3":{"bucket":{"name":"foo"},"object":{"key":"b.json","size":20}}},
+  {"eventName":"ObjectRemoved:Delete","s3":{"bucket":{"name":"foo"},"object":{"key":"c.json"}}}
+]}`,
+			expected: []s3ObjectTarget{
+				{key: "b.json", bucket: "foo"},
+			},
+			skipped: 2,
+		},
+		{
+			name:     "filter eventbridge event",
+			filter:   `this.event_name == "Object Created" && this.key.has_suffix(".json")`,
+			body:     `{"detail-type":"Object Created","source":"aws.s3","detail":{"bucket":{"name":"foo"},"object":{"key":"a.txt"}}}`,
+			expected: []s3ObjectTarget{},
+			skipped:  1,
+		},
+		{
+			name:   "filter by prefix and suffix",
+			prefix: "logs/",
+			suffix: ".json.gz",
+			body: `{"Records":[
+  {"s3":{"bucket":{"name":"foo"},"object":{"key":"logs/a.json.gz"}}},
+  {"s3":{"bucket":{"name":"foo"},"object":{"key":"logs/b.csv"}}},
+  {"s3":{"bucket":{"name":"foo"},"object":{"key":"other/c.json.gz"}}}
+]}`,
+			expected: []s3ObjectTarget{
+				{key: "logs/a.json.gz", bucket: "foo"},
+			},
+			skipped: 2,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			conf := input.NewAWSS3Config()
+			conf.SQS.EnvelopePath = test.envelope
+			conf.Prefix = test.prefix
+			conf.Suffix = test.suffix
+
+			var filter *mapping.Executor
+			if test.filter != "" {
+				var err error
+				filter, err = mock.NewManager().BloblEnvironment().NewMapping(test.filter)
+				require.NoError(t, err)
+			}
+
+			r := newSQSTargetReader(conf, log.Noop(), nil, nil, filter)
+			objects, skipped, err := r.parseObjectPaths(&test.body)
+			if test.errContains != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.errContains)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, objects)
+			assert.Equal(t, test.skipped, skipped)
+		})
+	}
+}
@@ -53,6 +53,20 @@ fields can be dynamically set using function interpolations described
 [here](/docs/configuration/interpolation#bloblang-queries). When sending batched messages the
 interpolations are performed per message part.
 
+### Aggregation
+
+Sending lots of small messages as individual records can be costly, and quickly
+exhausts the record throughput of a shard. When `+"`aggregation.enabled`"+` is set
+the messages of each batch are packed into aggregated records using the format
+of the [Kinesis Producer Library (KPL)](https://docs.aws.amazon.com/streams/latest/dev/kinesis-kpl-concepts.html#kinesis-kpl-concepts-aggretation),
+which consumers such as the Kinesis Client Library deaggregate transparently.
+
+Only messages that share both a partition key and hash key are aggregated
+together, which ensures that they are routed to the same shard as they would
+have been without aggregation and that their ordering is preserved. Therefore
+aggregation is most effective when combined with batching and a partition key of
+low cardinality.
+
 ### Credentials
 
 By default Benthos will use a shared credentials file when connecting to AWS
@@ -64,6 +78,10 @@ allowing you to transfer data across accounts. You can find out more
 			docs.FieldString("partition_key", "A required key for partitioning messages.").IsInterpolated(),
 			docs.FieldString("hash_key", "A optional hash key for partitioning messages.").IsInterpolated().Advanced(),
 			docs.FieldInt("max_in_flight", "The maximum number of messages to have in flight at a given time. Increase this to improve throughput."),
+			docs.FieldObject("aggregation", "Pack the messages of a batch into KPL aggregated records.").WithChildren(
+				docs.FieldBool("enabled", "Whether to aggregate messages into records."),
+				docs.FieldInt("max_bytes", "The maximum size in bytes of an aggregated record, which must not exceed 1MiB."),
+			).Advanced(),
 			policy.FieldSpec(),
 		).WithChildren(sess.FieldSpecs()...).WithChildren(retries.FieldSpecs()...).ChildDefaultAndTypesFromStruct(output.NewKinesisConfig()),
 		Categories: []string{
@@ -110,6 +128,9 @@ func newKinesisWriter(conf output.KinesisConfig, mgr bundle.NewManagement) (*kin
 	if k.backoffCtor, err = conf.Config.GetCtor(); err != nil {
 		return nil, err
 	}
+	if conf.Aggregation.Enabled && (conf.Aggregation.MaxBytes <= 0 || conf.Aggregation.MaxBytes > mebibyte) {
+		return nil, fmt.Errorf("aggregation max bytes must be between 1 and %v", mebibyte)
+	}
 	return &k, nil
 }
 
@@ -139,8 +160,14 @@ func (a *kinesisWriter) toRecords(msg *message.Batch) ([]*kinesis.PutRecordsRequ
 		entries[i] = &entry
 		return nil
 	})
+	if err != nil {
+		return nil, err
+	}
 
-	return entries, err
+	if a.conf.Aggregation.Enabled {
+		entries = aggregateRecords(entries, a.conf.Aggregation.MaxBytes)
+	}
+	return entries, nil
 }
 
 func (a *kinesisWriter) ConnectWithContext(ctx context.Context) error {
@@ -0,0 +1,141 @@
+package aws
+
+import (
+	"crypto/md5"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/kinesis"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// kplMagic is the prefix of records aggregated in the format of the Kinesis
+// Producer Library, which allows consumers such as the Kinesis Client Library
+// to identify and deaggregate them.
+var kplMagic = []byte{0xF3, 0x89, 0x9A, 0xC2}
+
+// Field numbers of the AggregatedRecord and Record protobuf messages of the
+// KPL aggregation format.
+const (
+	kplAggPartitionKeyTable    protowire.Number = 1
+	kplAggExplicitHashKeyTable protowire.Number = 2
+	kplAggRecords              protowire.Number = 3
+
+	kplRecPartitionKeyIndex    protowire.Number = 1
+	kplRecExplicitHashKeyIndex protowire.Number = 2
+	kplRecData                 protowire.Number = 3
+)
+
+// kplAggregate is a group of records that share a partition key and explicit
+// hash key, and are therefore routed to the same shard.
+type kplAggregate struct {
+	partitionKey string
+	hashKey      *string
+	entries      []*kinesis.PutRecordsRequestEntry
+	size         int
+}
+
+func newKPLAggregate(entry *kinesis.PutRecordsRequestEntry) *kplAggregate {
+	a := &kplAggregate{
+		partitionKey: aws.StringValue(entry.PartitionKey),
+		hashKey:      entry.ExplicitHashKey,
+	}
+	a.size = len(kplMagic) + md5.Size
+	a.size += protowire.SizeTag(kplAggPartitionKeyTable) + protowire.SizeBytes(len(a.partitionKey))
+	if a.hashKey != nil {
+		a.size += protowire.SizeTag(kplAggExplicitHashKeyTable) + protowire.SizeBytes(len(*a.hashKey))
+	}
+	return a
+}
+
+func (a *kplAggregate) recordSize(data []byte) int {
+	inner := protowire.SizeTag(kplRecPartitionKeyIndex) + protowire.SizeVarint(0)
+	if a.hashKey != nil {
+		inner += protowire.SizeTag(kplRecExplicitHashKeyIndex) + protowire.SizeVarint(0)
+	}
+	inner += protowire.SizeTag(kplRecData) + protowire.SizeBytes(len(data))
+	return protowire.SizeTag(kplAggRecords) + protowire.SizeBytes(inner)
+}
+
+func (a *kplAggregate) add(entry *kinesis.PutRecordsRequestEntry) {
+	a.size += a.recordSize(entry.Data)
+	a.entries = append(a.entries, entry)
+}
+
+// toEntry encodes the aggregate as a single entry. Aggregates containing only
+// one record are not encoded, as deaggregating consumers accept both forms.
+func (a *kplAggregate) toEntry() *kinesis.PutRecordsRequestEntry {
+	if len(a.entries) == 1 {
+		return a.entries[0]
+	}
+
+	b := protowire.AppendTag(nil, kplAggPartitionKeyTable, protowire.BytesType)
+	b = protowire.AppendString(b, a.partitionKey)
+	if a.hashKey != nil {
+		b = protowire.AppendTag(b, kplAggExplicitHashKeyTable, protowire.BytesType)
+		b = protowire.AppendString(b, *a.hashKey)
+	}
+	for _, e := range a.entries {
+		r := protowire.AppendTag(nil, kplRecPartitionKeyIndex, protowire.VarintType)
+		r = protowire.AppendVarint(r, 0)
+		if a.hashKey != nil {
+			r = protowire.AppendTag(r, kplRecExplicitHashKeyIndex, protowire.VarintType)
+			r = protowire.AppendVarint(r, 0)
+		}
+		r = protowire.AppendTag(r, kplRecData, protowire.BytesType)
+		r = protowire.AppendBytes(r, e.Data)
+
+		b = protowire.AppendTag(b, kplAggRecords, protowire.BytesType)
+		b = protowire.AppendBytes(b, r)
+	}
+
+	sum := md5.Sum(b)
+	data := make([]byte, 0, len(kplMagic)+len(b)+len(sum))
+	data = append(data, kplMagic...)
+	data = append(data, b...)
+	data = append(data, sum[:]...)
+
+	return &kinesis.PutRecordsRequestEntry{
+		Data:            data,
+		PartitionKey:    aws.String(a.partitionKey),
+		ExplicitHashKey: a.hashKey,
+	}
+}
+
+// aggregateRecords packs records that share both a partition key and explicit
+// hash key into aggregated records of the KPL format, where each aggregated
+// record does not exceed maxBytes in size. Records are only ever aggregated
+// with others that would be routed to the same shard, and the order of records
+// within each partition is preserved.
+func aggregateRecords(entries []*kinesis.PutRecordsRequestEntry, maxBytes int) []*kinesis.PutRecordsRequestEntry {
+	var aggregated []*kinesis.PutRecordsRequestEntry
+	var aggregates []*kplAggregate
+	open := map[string]int{}
+
+	for _, entry := range entries {
+		key := aws.StringValue(entry.PartitionKey)
+		if entry.ExplicitHashKey != nil {
+			key += "\x00" + *entry.ExplicitHashKey
+		}
+
+		if i, exists := open[key]; exists {
+			agg := aggregates[i]
+			if agg.size+agg.recordSize(entry.Data) <= maxBytes {
+				agg.add(entry)
+				continue
+			}
+			// Seal the full aggregate in place and start a new one at the end
+			// in order to preserve ordering within the partition.
+			delete(open, key)
+		}
+
+		agg := newKPLAggregate(entry)
+		agg.add(entry)
+		open[key] = len(aggregates)
+		aggregates = append(aggregates, agg)
+	}
+
+	for _, agg := range aggregates {
+		aggregated = append(aggregated, agg.toEntry())
+	}
+	return aggregated
+}
@@ -0,0 +1,193 @@
+package aws
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/kinesis"
+	"github.com/cenkalti/backoff/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang"
+	"github.com/benthosdev/benthos/v4/internal/component/output"
+	"github.com/benthosdev/benthos/v4/internal/log"
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+type kplTestRecord struct {
+	partitionKey string
+	hashKey      string
+	data         string
+}
+
+// deaggregateKPL decodes a record in the KPL aggregation format, returning
+// the original record if it isn't aggregated.
+func deaggregateKPL(t *testing.T, entry *kinesis.PutRecordsRequestEntry) []kplTestRecord {
+	t.Helper()
+
+	if !bytes.HasPrefix(entry.Data, kplMagic) {
+		return []kplTestRecord{{
+			partitionKey: aws.StringValue(entry.PartitionKey),
+			hashKey:      aws.StringValue(entry.ExplicitHashKey),
+			data:         string(entry.Data),
+		}}
+	}
+
+	b := entry.Data[len(kplMagic) : len(entry.Data)-md5.Size]
+	sum := md5.Sum(b)
+	require.Equal(t, sum[:], entry.Data[len(entry.Data)-md5.Size:])
+
+	var pkTable, ehkTable []string
+	var records [][]byte
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		require.GreaterOrEqual(t, n, 0)
+		require.Equal(t, protowire.BytesType, typ)
+		b = b[n:]
+		v, n := protowire.ConsumeBytes(b)
+		require.GreaterOrEqual(t, n, 0)
+		b = b[n:]
+		switch num {
+		case kplAggPartitionKeyTable:
+			pkTable = append(pkTable, string(v))
+		case kplAggExplicitHashKeyTable:
+			ehkTable = append(ehkTable, string(v))
+		case kplAggRecords:
+			records = append(records, v)
+		}
+	}
+
+	var res []kplTestRecord
+	for _, r := range records {
+		var rec kplTestRecord
+		for len(r) > 0 {
+			num, typ, n := protowire.ConsumeTag(r)
+			require.GreaterOrEqual(t, n, 0)
+			r = r[n:]
+			switch typ {
+			case protowire.VarintType:
+				v, n := protowire.ConsumeVarint(r)
+				require.GreaterOrEqual(t, n, 0)
+				r = r[n:]
+				if num == kplRecPartitionKeyIndex {
+					rec.partitionKey = pkTable[v]
+				} else if num == kplRecExplicitHashKeyIndex {
+					rec.hashKey = ehkTable[v]
+				}
+			case protowire.BytesType:
+				v, n := protowire.ConsumeBytes(r)
+				require.GreaterOrEqual(t, n, 0)
+				r = r[n:]
+				rec.data = string(v)
+			}
+		}
+		res = append(res, rec)
+	}
+	return res
+}
+
+func TestKinesisAggregateRecords(t *testing.T) {
+	var entries []*kinesis.PutRecordsRequestEntry
+	var expected []kplTestRecord
+	for i := 0; i < 20; i++ {
+		pk := fmt.Sprintf("key%v", i%3)
+		data := fmt.Sprintf("record %v", i)
+		entry := &kinesis.PutRecordsRequestEntry{
+			Data:         []byte(data),
+			PartitionKey: aws.String(pk),
+		}
+		rec := kplTestRecord{partitionKey: pk, data: data}
+		if i%3 == 2 {
+			entry.ExplicitHashKey = aws.String("123")
+			rec.hashKey = "123"
+		}
+		entries = append(entries, entry)
+		expected = append(expected, rec)
+	}
+
+	aggregated := aggregateRecords(entries, 100)
+	assert.Greater(t, len(aggregated), 3)
+	assert.Less(t, len(aggregated), len(entries))
+
+	actual := map[string][]kplTestRecord{}
+	for _, entry := range aggregated {
+		assert.LessOrEqual(t, len(entry.Data), 100)
+		for _, rec := range deaggregateKPL(t, entry) {
+			assert.Equal(t, aws.StringValue(entry.PartitionKey), rec.partitionKey)
+			assert.Equal(t, aws.StringValue(entry.ExplicitHashKey), rec.hashKey)
+			actual[rec.partitionKey] = append(actual[rec.partitionKey], rec)
+		}
+	}
+
+	exp := map[string][]kplTestRecord{}
+	for _, rec := range expected {
+		exp[rec.partitionKey] = append(exp[rec.partitionKey], rec)
+	}
+	assert.Equal(t, exp, actual)
+}
+
+func TestKinesisAggregateRecordsOversized(t *testing.T) {
+	entries := []*kinesis.PutRecordsRequestEntry{
+		{Data: bytes.Repeat([]byte("a"), 200), PartitionKey: aws.String("foo")},
+		{Data: []byte("b"), PartitionKey: aws.String("foo")},
+		{Data: []byte("c"), PartitionKey: aws.String("foo")},
+	}
+
+	aggregated := aggregateRecords(entries, 100)
+	require.Len(t, aggregated, 2)
+	assert.Equal(t, entries[0], aggregated[0])
+	assert.Equal(t, []kplTestRecord{
+		{partitionKey: "foo", data: "b"},
+		{partitionKey: "foo", data: "c"},
+	}, deaggregateKPL(t, aggregated[1]))
+}
+
+func TestKinesisWriteAggregated(t *testing.T) {
+	conf := output.NewKinesisConfig()
+	conf.Aggregation.Enabled = true
+
+	var records []*kinesis.PutRecordsRequestEntry
+	k := kinesisWriter{
+		conf: conf,
+		backoffCtor: func() backoff.BackOff {
+			return backoff.NewExponentialBackOff()
+		},
+		session: session.Must(session.NewSession(&aws.Config{
+			Credentials: credentials.NewStaticCredentials("xxxxx", "xxxxx", "xxxxx"),
+		})),
+		kinesis: &mockKinesis{
+			fn: func(input *kinesis.PutRecordsInput) (*kinesis.PutRecordsOutput, error) {
+				records = append(records, input.Records...)
+				return &kinesis.PutRecordsOutput{}, nil
+			},
+		},
+		log: log.Noop(),
+	}
+
+	k.partitionKey, _ = bloblang.GlobalEnvironment().NewField(`${!json("id")}`)
+	k.hashKey, _ = bloblang.GlobalEnvironment().NewField("")
+
+	msg := message.QuickBatch([][]byte{
+		[]byte(`{"id":"a","n":1}`),
+		[]byte(`{"id":"b","n":2}`),
+		[]byte(`{"id":"a","n":3}`),
+	})
+	require.NoError(t, k.WriteWithContext(context.Background(), msg))
+
+	require.Len(t, records, 2)
+	assert.Equal(t, []kplTestRecord{
+		{partitionKey: "a", data: `{"id":"a","n":1}`},
+		{partitionKey: "a", data: `{"id":"a","n":3}`},
+	}, deaggregateKPL(t, records[0]))
+	assert.Equal(t, []kplTestRecord{
+		{partitionKey: "b", data: `{"id":"b","n":2}`},
+	}, deaggregateKPL(t, records[1]))
+}
@@ -3,7 +3,10 @@ package aws
 import (
 	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/url"
 	"sort"
 	"strings"
@@ -114,7 +117,103 @@ output:
       processors:
         - archive:
             format: json_array
-`+"```"+``),
+`+"```"+`
+
+### Accumulating Objects
+
+Batching at the output level produces one object per batch, which makes it
+difficult to target a specific object size when throughput varies. When
+`+"`accumulate.enabled`"+` is set messages are instead accumulated across batches
+into objects, grouped by an interpolated `+"`accumulate.partition`"+`, and each
+object is uploaded once it reaches `+"`accumulate.max_bytes`"+` in size or once
+`+"`accumulate.period`"+` has passed since its first message was added.
+
+The key of each accumulated object is the partition followed by the `+"`path`"+`
+field, where `+"`path`"+` and all other interpolated fields are resolved against
+the first message of the object. This makes it easy to produce Hive-style
+partitions with analytics-friendly object sizes:
+
+`+"```yaml"+`
+output:
+  aws_s3:
+    bucket: TODO
+    path: ${!uuid_v4()}.jsonl
+    max_in_flight: 1000
+    accumulate:
+      enabled: true
+      partition: year=${!now().ts_format("2006", "UTC")}/month=${!now().ts_format("01", "UTC")}/day=${!now().ts_format("02", "UTC")}
+      max_bytes: 134217728
+      period: 5m
+`+"```"+`
+
+Messages are not acknowledged until the object they were accumulated into has
+been uploaded, and therefore the number of messages that can be held in pending
+objects at any given time is limited by `+"`max_in_flight`"+` multiplied by the
+size of the batches being written. Make sure these are large enough for your
+thresholds to be reached, otherwise objects will only be uploaded at the end of
+each period.
+
+### Parquet
+
+Setting `+"`parquet.enabled`"+` serializes the messages written to each object as the
+rows of a Parquet file, where messages must be JSON documents. Without
+accumulation each batch is written as a single Parquet object per partition,
+and with accumulation each accumulated object is written as a Parquet file.
+
+When a schema isn't provided one is inferred from the messages of each object,
+where each top-level field of the documents becomes an optional column. Columns
+with only integer values are written as `+"`INT64`"+`, numbers as `+"`DOUBLE`"+`, booleans
+as `+"`BOOLEAN`"+` and everything else as `+"`UTF8`"+` strings, with nested values and
+fields of mixed types written as JSON encoded strings.
+
+Combined with `+"`partition_by`"+` this produces objects that can be queried directly
+by Athena or Spark:
+
+`+"```yaml"+`
+output:
+  aws_s3:
+    bucket: TODO
+    path: ${!uuid_v4()}.parquet
+    partition_by:
+      - key: dt
+        value: ${!now().ts_format("2006-01-02", "UTC")}
+      - key: hour
+        value: ${!now().ts_format("15", "UTC")}
+    parquet:
+      enabled: true
+      compression: zstd
+    batching:
+      count: 10000
+      period: 1m
+`+"```"+`
+
+Which writes objects with keys such as `+"`dt=2023-01-01/hour=12/<uuid>.parquet`"+`.
+Partition values are escaped the same way Hive escapes them, and empty values
+are written as `+"`__HIVE_DEFAULT_PARTITION__`"+`.
+
+### Manifests
+
+Objects are only visible in a bucket once their upload has completed, which for
+objects larger than `+"`multipart.part_size`"+` means once all parts of a multipart
+upload have been uploaded and the upload is completed, and failed multipart
+uploads are aborted. Messages are only acknowledged once the objects they were
+written to have been completed.
+
+However, when a batch spans multiple objects a consumer may observe some objects
+of the batch before the others have been uploaded. Setting `+"`manifest_path`"+`
+uploads a manifest object after all objects of a batch, or each accumulated
+object, have been uploaded successfully, and before the messages are
+acknowledged. The manifest is a JSON document of the form:
+
+`+"```json"+`
+{"bucket":"foo","objects":[{"key":"bar.json","size":1024,"count":10}],"finalised_at":"2022-03-02T10:01:00Z"}
+`+"```"+`
+
+Where `+"`size`"+` is the size of each object in bytes and `+"`count`"+` is the number of
+messages written to it. The manifest path is interpolated against the first
+message of the batch or object, and should be unique for each manifest, for
+example `+"`manifests/${!timestamp_unix_nano()}-${!uuid_v4()}.json`"+`. Downstream
+consumers can then discover complete sets of objects by listing manifests.`),
 		Config: docs.FieldComponent().WithChildren(
 			docs.FieldString("bucket", "The bucket to upload messages to."),
 			docs.FieldString(
@@ -142,6 +241,38 @@ output:
 			).IsInterpolated().Advanced(),
 			docs.FieldString("kms_key_id", "An optional server side encryption key.").Advanced(),
 			docs.FieldString("server_side_encryption", "An optional server side encryption algorithm.").AtVersion("3.63.0").Advanced(),
+			docs.FieldString("checksum_algorithm", "An optional algorithm used to calculate a checksum of each object, which is validated by S3 upon upload. When empty only a Content-MD5 header is sent with each upload request.").HasOptions(
+				"", "CRC32", "CRC32C", "SHA1", "SHA256",
+			).Advanced(),
+			docs.FieldObject("multipart", "Tuning parameters for multipart uploads, which are used for objects larger than the part size.").WithChildren(
+				docs.FieldInt("part_size", "The size in bytes of each part of a multipart upload, which must be at least 5MiB."),
+				docs.FieldInt("concurrency", "The number of parts of a single object to upload in parallel."),
+			).Advanced(),
+			docs.FieldObject("accumulate", "Accumulate messages across batches into objects per partition, which are uploaded once they reach a size or age threshold.").WithChildren(
+				docs.FieldBool("enabled", "Whether to accumulate messages into objects."),
+				docs.FieldString(
+					"partition", "An optional prefix used to group messages into separate objects, which is prepended to the `path` of each object.",
+					`year=${!now().ts_format("2006", "UTC")}/month=${!now().ts_format("01", "UTC")}`,
+					`${!meta("kafka_topic")}`,
+				).IsInterpolated(),
+				docs.FieldInt("max_bytes", "The size in bytes at which an accumulated object is uploaded."),
+				docs.FieldString("period", "The maximum period to wait after the first message of an object is added before it is uploaded. A period is required so that objects which never reach `max_bytes` are still uploaded.", "5m", "1h"),
+				docs.FieldString("separator", "A separator written between the messages of an object."),
+			).Advanced(),
+			docs.FieldObject("partition_by", "A list of Hive-style partition columns, which are resolved for each message and prepended to the `path` of its object as `key=value` segments in the order listed.").Array().WithChildren(
+				docs.FieldString("key", "The name of the partition column.", "dt", "hour").HasDefault(""),
+				docs.FieldString("value", "The value of the partition column, which is escaped the same way as Hive partition values.", `${!now().ts_format("2006-01-02", "UTC")}`, `${!meta("kafka_topic")}`).IsInterpolated().HasDefault(""),
+			).HasDefault([]interface{}{}).AtVersion("4.3.0"),
+			docs.FieldObject("parquet", "Serialize the messages of each object as rows of a Parquet file.").WithChildren(
+				docs.FieldBool("enabled", "Whether to write objects as Parquet files."),
+				docs.FieldString("schema", "An optional schema of the Parquet files written, in the JSON format of the [`parquet` processor](/docs/components/processors/parquet#defining-the-schema). When neither `schema` nor `schema_file` are set the schema is inferred from the messages of each object."),
+				docs.FieldString("schema_file", "An optional path of a file containing the schema of the Parquet files written.", "schemas/foo.json"),
+				docs.FieldString("compression", "The compression codec of the Parquet files written.").HasOptions("uncompressed", "snappy", "gzip", "zstd"),
+			).AtVersion("4.3.0"),
+			docs.FieldString(
+				"manifest_path", "An optional path of a manifest object to upload once all objects of a batch, or each accumulated object, have been uploaded successfully.",
+				`manifests/${!timestamp_unix_nano()}-${!uuid_v4()}.json`,
+			).IsInterpolated().Advanced(),
 			docs.FieldBool("force_path_style_urls", "Forces the client API to use path style URLs, which helps when connecting to custom endpoints.").Advanced(),
 			docs.FieldInt("max_in_flight", "The maximum number of messages to have in flight at a given time. Increase this to improve throughput."),
 			docs.FieldString("timeout", "The maximum period to wait on an upload before abandoning it and reattempting.").Advanced(),
@@ -176,6 +307,12 @@ type amazonS3Writer struct {
 	storageClass            *field.Expression
 	metaFilter              *metadata.ExcludeFilter
 
+	partition   s3Partitioner
+	accumulator *s3Accumulator
+	parquet     *s3ParquetEncoder
+
+	manifestPath *field.Expression
+
 	session  *session.Session
 	uploader *s3manager.Uploader
 	timeout  time.Duration
@@ -241,6 +378,64 @@ func newAmazonS3Writer(conf output.AmazonS3Config, mgr bundle.NewManagement) (*a
 		return a.tags[i].key < a.tags[j].key
 	})
 
+	if conf.Multipart.PartSize < int(s3manager.MinUploadPartSize) {
+		return nil, fmt.Errorf("multipart part size must be at least %v bytes", s3manager.MinUploadPartSize)
+	}
+	if conf.Multipart.Concurrency < 1 {
+		return nil, errors.New("multipart concurrency must be at least 1")
+	}
+
+	if conf.ManifestPath != "" {
+		if a.manifestPath, err = mgr.BloblEnvironment().NewField(conf.ManifestPath); err != nil {
+			return nil, fmt.Errorf("failed to parse manifest path expression: %v", err)
+		}
+	}
+
+	var hive *s3HivePartitioner
+	if len(conf.PartitionBy) > 0 {
+		hive = &s3HivePartitioner{}
+		for i, c := range conf.PartitionBy {
+			if c.Key == "" {
+				return nil, fmt.Errorf("partition_by %v requires a key", i)
+			}
+			vExpr, err := mgr.BloblEnvironment().NewField(c.Value)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse partition_by value expression for key '%v': %v", c.Key, err)
+			}
+			hive.columns = append(hive.columns, s3HiveColumn{key: c.Key, value: vExpr})
+		}
+		a.partition = hive
+	}
+
+	if conf.Parquet.Enabled {
+		if a.parquet, err = newS3ParquetEncoder(conf.Parquet); err != nil {
+			return nil, err
+		}
+	}
+
+	if conf.Accumulate.Enabled {
+		var partition *field.Expression
+		if partition, err = mgr.BloblEnvironment().NewField(conf.Accumulate.Partition); err != nil {
+			return nil, fmt.Errorf("failed to parse partition expression: %v", err)
+		}
+		if hive != nil {
+			hive.prefix = partition
+		} else {
+			a.partition = partition
+		}
+		var period time.Duration
+		if p := conf.Accumulate.Period; len(p) > 0 {
+			if period, err = time.ParseDuration(p); err != nil {
+				return nil, fmt.Errorf("failed to parse accumulate period string: %v", err)
+			}
+		}
+		// Without a period an object that never reaches max_bytes would never
+		// be uploaded, and its messages would never be acknowledged.
+		if period <= 0 {
+			return nil, errors.New("accumulate requires a period to be set")
+		}
+		a.accumulator = newS3Accumulator(a.partition, conf.Accumulate.MaxBytes, period, conf.Accumulate.Separator, a.uploadAccumulated)
+	}
 	return a, nil
 }
 
@@ -257,90 +452,241 @@ func (a *amazonS3Writer) ConnectWithContext(ctx context.Context) error {
 	}
 
 	a.session = sess
-	a.uploader = s3manager.NewUploader(sess)
+	a.uploader = s3manager.NewUploader(sess, func(u *s3manager.Uploader) {
+		u.PartSize = int64(a.conf.Multipart.PartSize)
+		u.Concurrency = a.conf.Multipart.Concurrency
+	})
 
 	a.log.Infof("Uploading message parts as objects to Amazon S3 bucket: %v\n", a.conf.Bucket)
 	return nil
 }
 
-func (a *amazonS3Writer) WriteWithContext(wctx context.Context, msg *message.Batch) error {
-	if a.session == nil {
-		return component.ErrNotConnected
-	}
+// uploadInput creates the upload input of an object from the message at
+// index i of a batch.
+func (a *amazonS3Writer) uploadInput(key string, body io.Reader, i int, msg *message.Batch) *s3manager.UploadInput {
+	p := msg.Get(i)
 
-	ctx, cancel := context.WithTimeout(
-		wctx, a.timeout,
-	)
-	defer cancel()
+	metadata := map[string]*string{}
+	_ = a.metaFilter.Iter(p, func(k, v string) error {
+		metadata[k] = aws.String(v)
+		return nil
+	})
 
-	return output.IterateBatchedSend(msg, func(i int, p *message.Part) error {
-		metadata := map[string]*string{}
-		_ = a.metaFilter.Iter(p, func(k, v string) error {
-			metadata[k] = aws.String(v)
-			return nil
-		})
+	var contentEncoding *string
+	if ce := a.contentEncoding.String(i, msg); len(ce) > 0 {
+		contentEncoding = aws.String(ce)
+	}
+	var cacheControl *string
+	if ce := a.cacheControl.String(i, msg); len(ce) > 0 {
+		cacheControl = aws.String(ce)
+	}
+	var contentDisposition *string
+	if ce := a.contentDisposition.String(i, msg); len(ce) > 0 {
+		contentDisposition = aws.String(ce)
+	}
+	var contentLanguage *string
+	if ce := a.contentLanguage.String(i, msg); len(ce) > 0 {
+		contentLanguage = aws.String(ce)
+	}
+	var websiteRedirectLocation *string
+	if ce := a.websiteRedirectLocation.String(i, msg); len(ce) > 0 {
+		websiteRedirectLocation = aws.String(ce)
+	}
 
-		var contentEncoding *string
-		if ce := a.contentEncoding.String(i, msg); len(ce) > 0 {
-			contentEncoding = aws.String(ce)
-		}
-		var cacheControl *string
-		if ce := a.cacheControl.String(i, msg); len(ce) > 0 {
-			cacheControl = aws.String(ce)
+	uploadInput := &s3manager.UploadInput{
+		Bucket:                  &a.conf.Bucket,
+		Key:                     aws.String(key),
+		Body:                    body,
+		ContentType:             aws.String(a.contentType.String(i, msg)),
+		ContentEncoding:         contentEncoding,
+		CacheControl:            cacheControl,
+		ContentDisposition:      contentDisposition,
+		ContentLanguage:         contentLanguage,
+		WebsiteRedirectLocation: websiteRedirectLocation,
+		StorageClass:            aws.String(a.storageClass.String(i, msg)),
+		Metadata:                metadata,
+	}
+
+	// Prepare tags, escaping keys and values to ensure they're valid query string parameters.
+	if len(a.tags) > 0 {
+		tags := make([]string, len(a.tags))
+		for j, pair := range a.tags {
+			tags[j] = url.QueryEscape(pair.key) + "=" + url.QueryEscape(pair.value.String(i, msg))
 		}
-		var contentDisposition *string
-		if ce := a.contentDisposition.String(i, msg); len(ce) > 0 {
-			contentDisposition = aws.String(ce)
+		uploadInput.Tagging = aws.String(strings.Join(tags, "&"))
+	}
+
+	if a.conf.KMSKeyID != "" {
+		uploadInput.ServerSideEncryption = aws.String("aws:kms")
+		uploadInput.SSEKMSKeyId = &a.conf.KMSKeyID
+	}
+
+	// NOTE: This overrides the ServerSideEncryption set above. We need this to preserve
+	// backwards compatibility, where it is allowed to only set kms_key_id in the config and
+	// the ServerSideEncryption value of "aws:kms" is implied.
+	if a.conf.ServerSideEncryption != "" {
+		uploadInput.ServerSideEncryption = &a.conf.ServerSideEncryption
+	}
+
+	if a.conf.ChecksumAlgorithm != "" {
+		uploadInput.ChecksumAlgorithm = &a.conf.ChecksumAlgorithm
+	}
+	return uploadInput
+}
+
+// partitionedKey returns the key of an object prefixed with its partition.
+func partitionedKey(partition, key string) string {
+	if partition == "" {
+		return key
+	}
+	return strings.TrimSuffix(partition, "/") + "/" + strings.TrimPrefix(key, "/")
+}
+
+// accumulatedKey returns the key of an accumulated object, which is the path
+// of its first message prefixed with its partition.
+func (a *amazonS3Writer) accumulatedKey(obj *s3AccumulatedObject) string {
+	return partitionedKey(obj.partition, a.path.String(0, obj.batch))
+}
+
+func (a *amazonS3Writer) uploadAccumulated(ctx context.Context, obj *s3AccumulatedObject) error {
+	ctx, cancel := context.WithTimeout(ctx, a.timeout)
+	defer cancel()
+
+	body := obj.body.Bytes()
+	if a.parquet != nil {
+		var err error
+		if body, err = a.parquet.encode(obj.batch); err != nil {
+			return err
 		}
-		var contentLanguage *string
-		if ce := a.contentLanguage.String(i, msg); len(ce) > 0 {
-			contentLanguage = aws.String(ce)
+	}
+
+	key := a.accumulatedKey(obj)
+	uploadInput := a.uploadInput(key, bytes.NewReader(body), 0, obj.batch)
+	if _, err := a.uploader.UploadWithContext(ctx, uploadInput); err != nil {
+		return err
+	}
+	return a.uploadManifest(ctx, obj.batch, []s3ManifestObject{
+		{Key: key, Size: int64(len(body)), Count: obj.batch.Len()},
+	})
+}
+
+// parquetObjects groups the messages of a batch by partition, preserving the
+// order in which partitions are first seen.
+func (a *amazonS3Writer) parquetObjects(msg *message.Batch) (partitions []string, batches []*message.Batch) {
+	indexes := map[string]int{}
+	_ = msg.Iter(func(i int, p *message.Part) error {
+		var partition string
+		if a.partition != nil {
+			partition = a.partition.String(i, msg)
 		}
-		var websiteRedirectLocation *string
-		if ce := a.websiteRedirectLocation.String(i, msg); len(ce) > 0 {
-			websiteRedirectLocation = aws.String(ce)
+		j, exists := indexes[partition]
+		if !exists {
+			j = len(partitions)
+			indexes[partition] = j
+			partitions = append(partitions, partition)
+			batches = append(batches, message.QuickBatch(nil))
 		}
+		batches[j].Append(p)
+		return nil
+	})
+	return
+}
 
-		uploadInput := &s3manager.UploadInput{
-			Bucket:                  &a.conf.Bucket,
-			Key:                     aws.String(a.path.String(i, msg)),
-			Body:                    bytes.NewReader(p.Get()),
-			ContentType:             aws.String(a.contentType.String(i, msg)),
-			ContentEncoding:         contentEncoding,
-			CacheControl:            cacheControl,
-			ContentDisposition:      contentDisposition,
-			ContentLanguage:         contentLanguage,
-			WebsiteRedirectLocation: websiteRedirectLocation,
-			StorageClass:            aws.String(a.storageClass.String(i, msg)),
-			Metadata:                metadata,
-		}
+// writeParquet uploads a Parquet object for each partition of a batch.
+func (a *amazonS3Writer) writeParquet(ctx context.Context, msg *message.Batch) error {
+	partitions, batches := a.parquetObjects(msg)
 
-		// Prepare tags, escaping keys and values to ensure they're valid query string parameters.
-		if len(a.tags) > 0 {
-			tags := make([]string, len(a.tags))
-			for j, pair := range a.tags {
-				tags[j] = url.QueryEscape(pair.key) + "=" + url.QueryEscape(pair.value.String(i, msg))
-			}
-			uploadInput.Tagging = aws.String(strings.Join(tags, "&"))
+	objects := make([]s3ManifestObject, 0, len(batches))
+	for i, batch := range batches {
+		body, err := a.parquet.encode(batch)
+		if err != nil {
+			return err
 		}
 
-		if a.conf.KMSKeyID != "" {
-			uploadInput.ServerSideEncryption = aws.String("aws:kms")
-			uploadInput.SSEKMSKeyId = &a.conf.KMSKeyID
+		key := partitionedKey(partitions[i], a.path.String(0, batch))
+		uploadInput := a.uploadInput(key, bytes.NewReader(body), 0, batch)
+		if _, err := a.uploader.UploadWithContext(ctx, uploadInput); err != nil {
+			return err
 		}
+		objects = append(objects, s3ManifestObject{Key: key, Size: int64(len(body)), Count: batch.Len()})
+	}
+	return a.uploadManifest(ctx, msg, objects)
+}
+
+type s3ManifestObject struct {
+	Key   string `json:"key"`
+	Size  int64  `json:"size"`
+	Count int    `json:"count"`
+}
+
+type s3Manifest struct {
+	Bucket      string             `json:"bucket"`
+	Objects     []s3ManifestObject `json:"objects"`
+	FinalisedAt string             `json:"finalised_at"`
+}
+
+// uploadManifest uploads a manifest object listing objects that have been
+// uploaded successfully, which is a noop when a manifest path isn't set.
+func (a *amazonS3Writer) uploadManifest(ctx context.Context, msg *message.Batch, objects []s3ManifestObject) error {
+	if a.manifestPath == nil || len(objects) == 0 {
+		return nil
+	}
+
+	manifestBytes, err := json.Marshal(s3Manifest{
+		Bucket:      a.conf.Bucket,
+		Objects:     objects,
+		FinalisedAt: time.Now().UTC().Format(time.RFC3339Nano),
+	})
+	if err != nil {
+		return err
+	}
+
+	uploadInput := a.uploadInput(a.manifestPath.String(0, msg), bytes.NewReader(manifestBytes), 0, msg)
+	uploadInput.ContentType = aws.String("application/json")
+	uploadInput.ContentEncoding = nil
+	if _, err := a.uploader.UploadWithContext(ctx, uploadInput); err != nil {
+		return fmt.Errorf("failed to upload manifest: %w", err)
+	}
+	return nil
+}
 
-		// NOTE: This overrides the ServerSideEncryption set above. We need this to preserve
-		// backwards compatibility, where it is allowed to only set kms_key_id in the config and
-		// the ServerSideEncryption value of "aws:kms" is implied.
-		if a.conf.ServerSideEncryption != "" {
-			uploadInput.ServerSideEncryption = &a.conf.ServerSideEncryption
+func (a *amazonS3Writer) WriteWithContext(wctx context.Context, msg *message.Batch) error {
+	if a.session == nil {
+		return component.ErrNotConnected
+	}
+
+	if a.accumulator != nil {
+		return a.accumulator.wait(wctx, a.accumulator.add(msg))
+	}
+
+	ctx, cancel := context.WithTimeout(
+		wctx, a.timeout,
+	)
+	defer cancel()
+
+	if a.parquet != nil {
+		return a.writeParquet(ctx, msg)
+	}
+
+	var objects []s3ManifestObject
+	if err := output.IterateBatchedSend(msg, func(i int, p *message.Part) error {
+		key := a.path.String(i, msg)
+		if a.partition != nil {
+			key = partitionedKey(a.partition.String(i, msg), key)
 		}
 
+		// Bodies are seekable so that checksums can be calculated.
+		uploadInput := a.uploadInput(key, bytes.NewReader(p.Get()), i, msg)
 		if _, err := a.uploader.UploadWithContext(ctx, uploadInput); err != nil {
 			return err
 		}
+
+		objects = append(objects, s3ManifestObject{Key: key, Size: int64(len(p.Get())), Count: 1})
 		return nil
-	})
+	}); err != nil {
+		return err
+	}
+	return a.uploadManifest(ctx, msg, objects)
 }
 
 func (a *amazonS3Writer) CloseAsync() {
@@ -0,0 +1,151 @@
+package aws
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+// s3AccumulatedObject is an object that is being accumulated from the messages of
+// potentially many batches, all of which are acknowledged once the object has
+// been uploaded.
+type s3AccumulatedObject struct {
+	partition string
+	batch     *message.Batch
+	body      bytes.Buffer
+	timer     *time.Timer
+
+	done chan struct{}
+	err  error
+}
+
+// s3Accumulator groups messages by an interpolated partition and accumulates
+// them into pending objects, which are uploaded once either a size threshold
+// or a period since the first message of the object is reached.
+type s3Accumulator struct {
+	partition s3Partitioner
+	maxBytes  int
+	period    time.Duration
+	separator []byte
+	upload    func(ctx context.Context, obj *s3AccumulatedObject) error
+
+	mut     sync.Mutex
+	pending map[string]*s3AccumulatedObject
+}
+
+func newS3Accumulator(
+	partition s3Partitioner,
+	maxBytes int,
+	period time.Duration,
+	separator string,
+	upload func(ctx context.Context, obj *s3AccumulatedObject) error,
+) *s3Accumulator {
+	return &s3Accumulator{
+		partition: partition,
+		maxBytes:  maxBytes,
+		period:    period,
+		separator: []byte(separator),
+		upload:    upload,
+		pending:   map[string]*s3AccumulatedObject{},
+	}
+}
+
+// add places the messages of a batch into the pending objects of their
+// partitions and returns the objects that the batch is now waiting on.
+func (a *s3Accumulator) add(msg *message.Batch) []*s3AccumulatedObject {
+	a.mut.Lock()
+	defer a.mut.Unlock()
+
+	var touched []*s3AccumulatedObject
+	var full []*s3AccumulatedObject
+	_ = msg.Iter(func(i int, p *message.Part) error {
+		partition := a.partition.String(i, msg)
+
+		obj, exists := a.pending[partition]
+		if !exists {
+			obj = &s3AccumulatedObject{
+				partition: partition,
+				batch:     message.QuickBatch(nil),
+				done:      make(chan struct{}),
+			}
+			if a.period > 0 {
+				obj.timer = time.AfterFunc(a.period, func() {
+					a.flush(obj)
+				})
+			}
+			a.pending[partition] = obj
+		}
+
+		if obj.body.Len() > 0 {
+			_, _ = obj.body.Write(a.separator)
+		}
+		_, _ = obj.body.Write(p.Get())
+		obj.batch.Append(p)
+
+		isTouched := false
+		for _, t := range touched {
+			if t == obj {
+				isTouched = true
+				break
+			}
+		}
+		if !isTouched {
+			touched = append(touched, obj)
+		}
+
+		if a.maxBytes > 0 && obj.body.Len() >= a.maxBytes {
+			delete(a.pending, partition)
+			full = append(full, obj)
+		}
+		return nil
+	})
+
+	for _, obj := range full {
+		go a.uploadObject(obj)
+	}
+	return touched
+}
+
+// flush uploads a pending object immediately unless it has already been
+// flushed.
+func (a *s3Accumulator) flush(obj *s3AccumulatedObject) {
+	a.mut.Lock()
+	if current, exists := a.pending[obj.partition]; !exists || current != obj {
+		a.mut.Unlock()
+		return
+	}
+	delete(a.pending, obj.partition)
+	a.mut.Unlock()
+
+	a.uploadObject(obj)
+}
+
+func (a *s3Accumulator) uploadObject(obj *s3AccumulatedObject) {
+	if obj.timer != nil {
+		obj.timer.Stop()
+	}
+	obj.err = a.upload(context.Background(), obj)
+	close(obj.done)
+}
+
+// wait blocks until all of the provided objects have been uploaded, returning
+// the first error encountered. If the context is cancelled, which happens when
+// the output is shutting down, the objects are flushed immediately.
+func (a *s3Accumulator) wait(ctx context.Context, objs []*s3AccumulatedObject) error {
+	var err error
+	for _, obj := range objs {
+		select {
+		case <-obj.done:
+		case <-ctx.Done():
+			a.flush(obj)
+			<-obj.done
+		}
+		if obj.err != nil && err == nil {
+			err = obj.err
+		}
+	}
+	return err
+}
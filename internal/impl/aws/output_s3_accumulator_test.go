@@ -0,0 +1,169 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang/field"
+	"github.com/benthosdev/benthos/v4/internal/component/output"
+	"github.com/benthosdev/benthos/v4/internal/manager/mock"
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+type uploadRecorder struct {
+	mut     sync.Mutex
+	uploads map[string][]string
+	err     error
+}
+
+func (u *uploadRecorder) upload(ctx context.Context, obj *s3AccumulatedObject) error {
+	u.mut.Lock()
+	defer u.mut.Unlock()
+	if u.uploads == nil {
+		u.uploads = map[string][]string{}
+	}
+	u.uploads[obj.partition] = append(u.uploads[obj.partition], obj.body.String())
+	return u.err
+}
+
+func (u *uploadRecorder) get() map[string][]string {
+	u.mut.Lock()
+	defer u.mut.Unlock()
+	res := map[string][]string{}
+	for k, v := range u.uploads {
+		res[k] = append([]string(nil), v...)
+	}
+	return res
+}
+
+func testPartitionExpr(t *testing.T, expr string) *field.Expression {
+	t.Helper()
+	e, err := mock.NewManager().BloblEnvironment().NewField(expr)
+	require.NoError(t, err)
+	return e
+}
+
+func TestS3AccumulatorMaxBytes(t *testing.T) {
+	rec := &uploadRecorder{}
+	acc := newS3Accumulator(testPartitionExpr(t, `${! meta("p") }`), 10, 0, "\n", rec.upload)
+
+	batch := func(p string, contents ...string) *message.Batch {
+		msg := message.QuickBatch(nil)
+		for _, c := range contents {
+			part := message.NewPart([]byte(c))
+			part.MetaSet("p", p)
+			msg.Append(part)
+		}
+		return msg
+	}
+
+	objsA := acc.add(batch("a", "foo", "bar"))
+	require.Len(t, objsA, 1)
+	objsB := acc.add(batch("b", "baz"))
+	require.Len(t, objsB, 1)
+
+	waitA := make(chan error, 1)
+	go func() {
+		waitA <- acc.wait(context.Background(), objsA)
+	}()
+
+	select {
+	case <-waitA:
+		t.Fatal("expected wait to block until threshold is reached")
+	case <-time.After(time.Millisecond * 50):
+	}
+
+	objsAB := acc.add(batch("a", "quz"))
+	require.Len(t, objsAB, 1)
+	assert.Equal(t, objsA[0], objsAB[0])
+	require.NoError(t, acc.wait(context.Background(), objsAB))
+	require.NoError(t, <-waitA)
+
+	assert.Equal(t, map[string][]string{
+		"a": {"foo\nbar\nquz"},
+	}, rec.get())
+}
+
+func TestS3AccumulatorPeriod(t *testing.T) {
+	rec := &uploadRecorder{}
+	acc := newS3Accumulator(testPartitionExpr(t, `${! content().slice(0, 1) }`), 0, time.Millisecond*50, ",", rec.upload)
+
+	objs := acc.add(message.QuickBatch([][]byte{
+		[]byte("afoo"), []byte("bfoo"), []byte("abar"),
+	}))
+	require.Len(t, objs, 2)
+	require.NoError(t, acc.wait(context.Background(), objs))
+
+	assert.Equal(t, map[string][]string{
+		"a": {"afoo,abar"},
+		"b": {"bfoo"},
+	}, rec.get())
+}
+
+func TestS3AccumulatorFlushOnCancel(t *testing.T) {
+	rec := &uploadRecorder{err: errors.New("nope")}
+	acc := newS3Accumulator(testPartitionExpr(t, ``), 0, time.Hour, "\n", rec.upload)
+
+	objs := acc.add(message.QuickBatch([][]byte{[]byte("foo")}))
+
+	ctx, done := context.WithCancel(context.Background())
+	done()
+	assert.EqualError(t, acc.wait(ctx, objs), "nope")
+
+	assert.Equal(t, map[string][]string{
+		"": {"foo"},
+	}, rec.get())
+}
+
+func TestS3AccumulatedKey(t *testing.T) {
+	conf := output.NewAmazonS3Config()
+	conf.Path = `${! meta("id") }.json`
+	conf.Accumulate.Enabled = true
+	conf.Accumulate.Partition = `year=${! meta("year") }/`
+
+	w, err := newAmazonS3Writer(conf, mock.NewManager())
+	require.NoError(t, err)
+
+	part := message.NewPart([]byte("foo"))
+	part.MetaSet("id", "first")
+	part.MetaSet("year", "2022")
+
+	objs := w.accumulator.add(message.QuickBatch(nil))
+	assert.Empty(t, objs)
+
+	msg := message.QuickBatch(nil)
+	msg.Append(part)
+	objs = w.accumulator.add(msg)
+	require.Len(t, objs, 1)
+	assert.Equal(t, "year=2022/first.json", w.accumulatedKey(objs[0]))
+	objs[0].timer.Stop()
+}
+
+func TestS3WriterConfigErrors(t *testing.T) {
+	conf := output.NewAmazonS3Config()
+	conf.Multipart.PartSize = 1024
+	_, err := newAmazonS3Writer(conf, mock.NewManager())
+	require.Error(t, err)
+
+	conf = output.NewAmazonS3Config()
+	conf.Accumulate.Enabled = true
+	conf.Accumulate.Period = ""
+	conf.Accumulate.MaxBytes = 0
+	_, err = newAmazonS3Writer(conf, mock.NewManager())
+	require.Error(t, err)
+
+	conf.Accumulate.MaxBytes = 1024
+	_, err = newAmazonS3Writer(conf, mock.NewManager())
+	require.EqualError(t, err, "accumulate requires a period to be set")
+
+	conf = output.NewAmazonS3Config()
+	conf.ManifestPath = `${! nope( }`
+	_, err = newAmazonS3Writer(conf, mock.NewManager())
+	require.Error(t, err)
+}
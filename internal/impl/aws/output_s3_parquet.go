@@ -0,0 +1,277 @@
+package aws
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/xitongsys/parquet-go-source/buffer"
+	"github.com/xitongsys/parquet-go/common"
+	"github.com/xitongsys/parquet-go/parquet"
+	"github.com/xitongsys/parquet-go/writer"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang/field"
+	"github.com/benthosdev/benthos/v4/internal/component/output"
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+// s3Partitioner resolves the partition of a message within a batch.
+type s3Partitioner interface {
+	String(index int, msg field.Message) string
+}
+
+type s3HiveColumn struct {
+	key   string
+	value *field.Expression
+}
+
+// s3HivePartitioner resolves Hive-style partition paths of the form
+// `key=value/key=value`, optionally following a prefix.
+type s3HivePartitioner struct {
+	prefix  s3Partitioner
+	columns []s3HiveColumn
+}
+
+func (h *s3HivePartitioner) String(index int, msg field.Message) string {
+	var segments []string
+	if h.prefix != nil {
+		if p := strings.Trim(h.prefix.String(index, msg), "/"); p != "" {
+			segments = append(segments, p)
+		}
+	}
+	for _, c := range h.columns {
+		segments = append(segments, hiveEscape(c.key)+"="+hiveEscape(c.value.String(index, msg)))
+	}
+	return strings.Join(segments, "/")
+}
+
+// hiveDefaultPartition is the value Hive uses for partitions with an empty
+// value.
+const hiveDefaultPartition = "__HIVE_DEFAULT_PARTITION__"
+
+// hiveEscape escapes the characters of a partition path segment the same way
+// that Hive does, so that values containing slashes or equals signs can be
+// read back by Athena or Spark.
+func hiveEscape(v string) string {
+	if v == "" {
+		return hiveDefaultPartition
+	}
+	var b strings.Builder
+	for i := 0; i < len(v); i++ {
+		c := v[i]
+		if c < 0x20 || c == 0x7F || strings.IndexByte("\"#%'*/:=?\\{[]^", c) >= 0 {
+			fmt.Fprintf(&b, "%%%02X", c)
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+//------------------------------------------------------------------------------
+
+// s3ParquetEncoder serializes the messages of an object as a Parquet file,
+// with either a configured schema or one inferred from the messages.
+type s3ParquetEncoder struct {
+	schema      string
+	compression parquet.CompressionCodec
+}
+
+func newS3ParquetEncoder(conf output.AmazonS3ParquetConfig) (*s3ParquetEncoder, error) {
+	e := &s3ParquetEncoder{schema: conf.Schema}
+	if conf.SchemaFile != "" {
+		if e.schema != "" {
+			return nil, errors.New("parquet schema and schema_file cannot both be set")
+		}
+		schemaBytes, err := os.ReadFile(conf.SchemaFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read parquet schema file: %w", err)
+		}
+		e.schema = string(schemaBytes)
+	}
+
+	switch conf.Compression {
+	case "uncompressed":
+		e.compression = parquet.CompressionCodec_UNCOMPRESSED
+	case "snappy":
+		e.compression = parquet.CompressionCodec_SNAPPY
+	case "gzip":
+		e.compression = parquet.CompressionCodec_GZIP
+	case "zstd":
+		e.compression = parquet.CompressionCodec_ZSTD
+	default:
+		return nil, fmt.Errorf("unknown parquet compression type: %v", conf.Compression)
+	}
+	return e, nil
+}
+
+// encode returns a Parquet file containing a row for each message of a batch.
+func (e *s3ParquetEncoder) encode(msg *message.Batch) ([]byte, error) {
+	schema := e.schema
+	rows := make([][]byte, msg.Len())
+	if schema == "" {
+		var err error
+		if schema, rows, err = inferParquetSchema(msg); err != nil {
+			return nil, err
+		}
+	} else {
+		_ = msg.Iter(func(i int, p *message.Part) error {
+			rows[i] = p.Get()
+			return nil
+		})
+	}
+
+	buf := buffer.NewBufferFile()
+	pw, err := writer.NewJSONWriter(schema, buf, 1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create parquet writer: %w", err)
+	}
+	pw.CompressionType = e.compression
+
+	for i, row := range rows {
+		if err := pw.Write(row); err != nil {
+			return nil, fmt.Errorf("failed to write message %v to parquet file: %w", i, err)
+		}
+	}
+	if err := pw.WriteStop(); err != nil {
+		return nil, fmt.Errorf("failed to close parquet writer: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+type parquetColumnKind int
+
+const (
+	parquetKindNull parquetColumnKind = iota
+	parquetKindBool
+	parquetKindInt
+	parquetKindFloat
+	parquetKindString
+)
+
+func parquetValueKind(v interface{}) parquetColumnKind {
+	switch t := v.(type) {
+	case nil:
+		return parquetKindNull
+	case bool:
+		return parquetKindBool
+	case json.Number:
+		if strings.ContainsAny(t.String(), ".eE") {
+			return parquetKindFloat
+		}
+		return parquetKindInt
+	}
+	return parquetKindString
+}
+
+// mergeParquetKinds returns the narrowest column kind able to represent the
+// values of both kinds.
+func mergeParquetKinds(a, b parquetColumnKind) parquetColumnKind {
+	switch {
+	case a == b || b == parquetKindNull:
+		return a
+	case a == parquetKindNull:
+		return b
+	case (a == parquetKindInt && b == parquetKindFloat) || (a == parquetKindFloat && b == parquetKindInt):
+		return parquetKindFloat
+	}
+	return parquetKindString
+}
+
+var parquetKindTags = map[parquetColumnKind]string{
+	parquetKindBool:   "type=BOOLEAN",
+	parquetKindInt:    "type=INT64",
+	parquetKindFloat:  "type=DOUBLE",
+	parquetKindString: "type=BYTE_ARRAY, convertedtype=UTF8",
+}
+
+type parquetSchemaItem struct {
+	Tag    string              `json:"Tag"`
+	Fields []parquetSchemaItem `json:"Fields,omitempty"`
+}
+
+// inferParquetSchema derives a flat schema of optional columns from the
+// top-level fields of a batch of JSON objects, and returns the messages
+// normalised to that schema. Fields with nested values or values of mixed
+// types are written as strings, where non-string values are JSON encoded.
+func inferParquetSchema(msg *message.Batch) (string, [][]byte, error) {
+	docs := make([]map[string]interface{}, msg.Len())
+	kinds := map[string]parquetColumnKind{}
+	if err := msg.Iter(func(i int, p *message.Part) error {
+		dec := json.NewDecoder(bytes.NewReader(p.Get()))
+		dec.UseNumber()
+		if err := dec.Decode(&docs[i]); err != nil || docs[i] == nil {
+			return fmt.Errorf("failed to infer parquet schema: message %v is not a JSON object", i)
+		}
+		for k, v := range docs[i] {
+			kind := parquetValueKind(v)
+			if existing, exists := kinds[k]; exists {
+				kind = mergeParquetKinds(existing, kind)
+			}
+			kinds[k] = kind
+		}
+		return nil
+	}); err != nil {
+		return "", nil, err
+	}
+
+	names := make([]string, 0, len(kinds))
+	for k := range kinds {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	// Columns are matched to the fields of documents by a sanitised form of
+	// their name, which must therefore be unique.
+	columns := map[string]string{}
+	schema := parquetSchemaItem{Tag: "name=root, repetitiontype=REQUIRED"}
+	for _, name := range names {
+		if name == "" || strings.ContainsAny(name, ",=") || strings.TrimSpace(name) != name {
+			return "", nil, fmt.Errorf("failed to infer parquet schema: field name '%v' cannot be used as a column", name)
+		}
+		varName := common.StringToVariableName(name)
+		if existing, exists := columns[varName]; exists {
+			return "", nil, fmt.Errorf("failed to infer parquet schema: field names '%v' and '%v' conflict", existing, name)
+		}
+		columns[varName] = name
+
+		kind := kinds[name]
+		if kind == parquetKindNull {
+			kind = parquetKindString
+			kinds[name] = kind
+		}
+		schema.Fields = append(schema.Fields, parquetSchemaItem{
+			Tag: fmt.Sprintf("name=%v, %v, repetitiontype=OPTIONAL", name, parquetKindTags[kind]),
+		})
+	}
+
+	schemaBytes, err := json.Marshal(schema)
+	if err != nil {
+		return "", nil, err
+	}
+
+	rows := make([][]byte, len(docs))
+	for i, doc := range docs {
+		for k, v := range doc {
+			if v == nil || kinds[k] != parquetKindString {
+				continue
+			}
+			if _, isStr := v.(string); isStr {
+				continue
+			}
+			vBytes, err := json.Marshal(v)
+			if err != nil {
+				return "", nil, err
+			}
+			doc[k] = string(vBytes)
+		}
+		if rows[i], err = json.Marshal(doc); err != nil {
+			return "", nil, err
+		}
+	}
+	return string(schemaBytes), rows, nil
+}
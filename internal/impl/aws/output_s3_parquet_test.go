@@ -0,0 +1,199 @@
+package aws
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xitongsys/parquet-go-source/buffer"
+	"github.com/xitongsys/parquet-go/reader"
+
+	"github.com/benthosdev/benthos/v4/internal/component/output"
+	"github.com/benthosdev/benthos/v4/internal/manager/mock"
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+func TestHiveEscape(t *testing.T) {
+	assert.Equal(t, "2023-01-01", hiveEscape("2023-01-01"))
+	assert.Equal(t, "a%2Fb%3Dc", hiveEscape("a/b=c"))
+	assert.Equal(t, "10%3A00", hiveEscape("10:00"))
+	assert.Equal(t, "__HIVE_DEFAULT_PARTITION__", hiveEscape(""))
+}
+
+func TestS3HivePartitioner(t *testing.T) {
+	h := &s3HivePartitioner{
+		prefix: testPartitionExpr(t, `${! meta("topic") }/`),
+		columns: []s3HiveColumn{
+			{key: "dt", value: testPartitionExpr(t, `${! meta("dt") }`)},
+			{key: "hour", value: testPartitionExpr(t, `${! meta("hour") }`)},
+		},
+	}
+
+	part := message.NewPart([]byte("foo"))
+	part.MetaSet("topic", "orders")
+	part.MetaSet("dt", "2023-01-01")
+	part.MetaSet("hour", "12")
+	msg := message.QuickBatch(nil)
+	msg.Append(part)
+
+	assert.Equal(t, "orders/dt=2023-01-01/hour=12", h.String(0, msg))
+	assert.Equal(t, "orders/dt=2023-01-01/hour=12/foo.parquet", partitionedKey(h.String(0, msg), "foo.parquet"))
+
+	h.prefix = nil
+	h.columns[1].value = testPartitionExpr(t, "")
+	assert.Equal(t, "dt=2023-01-01/hour=__HIVE_DEFAULT_PARTITION__", h.String(0, msg))
+}
+
+func readParquetRows(t *testing.T, schema string, b []byte) []map[string]interface{} {
+	t.Helper()
+
+	pr, err := reader.NewParquetReader(buffer.NewBufferFileFromBytes(b), schema, 1)
+	require.NoError(t, err)
+	defer pr.ReadStop()
+
+	res, err := pr.ReadByNumber(int(pr.GetNumRows()))
+	require.NoError(t, err)
+
+	resBytes, err := json.Marshal(res)
+	require.NoError(t, err)
+
+	var rows []map[string]interface{}
+	require.NoError(t, json.Unmarshal(resBytes, &rows))
+	return rows
+}
+
+func TestS3ParquetInferredSchema(t *testing.T) {
+	msg := message.QuickBatch([][]byte{
+		[]byte(`{"id":1,"name":"foo","score":1,"tags":["a"],"active":true}`),
+		[]byte(`{"id":2,"name":null,"score":2.5,"tags":"b","extra":{"a":1}}`),
+	})
+
+	schema, rows, err := inferParquetSchema(msg)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+  "Tag": "name=root, repetitiontype=REQUIRED",
+  "Fields": [
+    {"Tag": "name=active, type=BOOLEAN, repetitiontype=OPTIONAL"},
+    {"Tag": "name=extra, type=BYTE_ARRAY, convertedtype=UTF8, repetitiontype=OPTIONAL"},
+    {"Tag": "name=id, type=INT64, repetitiontype=OPTIONAL"},
+    {"Tag": "name=name, type=BYTE_ARRAY, convertedtype=UTF8, repetitiontype=OPTIONAL"},
+    {"Tag": "name=score, type=DOUBLE, repetitiontype=OPTIONAL"},
+    {"Tag": "name=tags, type=BYTE_ARRAY, convertedtype=UTF8, repetitiontype=OPTIONAL"}
+  ]
+}`, schema)
+	require.Len(t, rows, 2)
+	assert.JSONEq(t, `{"id":1,"name":"foo","score":1,"tags":"[\"a\"]","active":true}`, string(rows[0]))
+	assert.JSONEq(t, `{"id":2,"name":null,"score":2.5,"tags":"b","extra":"{\"a\":1}"}`, string(rows[1]))
+
+	enc, err := newS3ParquetEncoder(output.NewAmazonS3ParquetConfig())
+	require.NoError(t, err)
+
+	b, err := enc.encode(msg)
+	require.NoError(t, err)
+	assert.Equal(t, []map[string]interface{}{
+		{"Active": true, "Extra": nil, "Id": float64(1), "Name": "foo", "Score": float64(1), "Tags": `["a"]`},
+		{"Active": nil, "Extra": `{"a":1}`, "Id": float64(2), "Name": nil, "Score": 2.5, "Tags": "b"},
+	}, readParquetRows(t, schema, b))
+}
+
+func TestS3ParquetInferredSchemaErrors(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		content []string
+		err     string
+	}{
+		{
+			name:    "not an object",
+			content: []string{`{"a":1}`, `[1,2]`},
+			err:     "failed to infer parquet schema: message 1 is not a JSON object",
+		},
+		{
+			name:    "invalid name",
+			content: []string{`{"a,b":1}`},
+			err:     "failed to infer parquet schema: field name 'a,b' cannot be used as a column",
+		},
+		{
+			name:    "conflicting names",
+			content: []string{`{"a b":1,"a32b":2}`},
+			err:     "failed to infer parquet schema: field names 'a b' and 'a32b' conflict",
+		},
+	} {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			var parts [][]byte
+			for _, c := range test.content {
+				parts = append(parts, []byte(c))
+			}
+			_, _, err := inferParquetSchema(message.QuickBatch(parts))
+			require.EqualError(t, err, test.err)
+		})
+	}
+}
+
+func TestS3ParquetExplicitSchema(t *testing.T) {
+	conf := output.NewAmazonS3ParquetConfig()
+	conf.Compression = "zstd"
+	conf.Schema = `{
+  "Tag": "name=root, repetitiontype=REQUIRED",
+  "Fields": [
+    {"Tag": "name=name, type=BYTE_ARRAY, convertedtype=UTF8, repetitiontype=REQUIRED"},
+    {"Tag": "name=age, type=INT32, repetitiontype=REQUIRED"}
+  ]
+}`
+
+	enc, err := newS3ParquetEncoder(conf)
+	require.NoError(t, err)
+
+	b, err := enc.encode(message.QuickBatch([][]byte{
+		[]byte(`{"name":"foo","age":10}`),
+		[]byte(`{"name":"bar","age":20}`),
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, []map[string]interface{}{
+		{"Name": "foo", "Age": float64(10)},
+		{"Name": "bar", "Age": float64(20)},
+	}, readParquetRows(t, conf.Schema, b))
+}
+
+func TestS3ParquetObjects(t *testing.T) {
+	conf := output.NewAmazonS3Config()
+	conf.Path = `${! json("id") }.parquet`
+	conf.PartitionBy = []output.AmazonS3PartitionConfig{
+		{Key: "dt", Value: `${! json("dt") }`},
+	}
+	conf.Parquet.Enabled = true
+
+	w, err := newAmazonS3Writer(conf, mock.NewManager())
+	require.NoError(t, err)
+
+	partitions, batches := w.parquetObjects(message.QuickBatch([][]byte{
+		[]byte(`{"id":"a","dt":"2023-01-02"}`),
+		[]byte(`{"id":"b","dt":"2023-01-01"}`),
+		[]byte(`{"id":"c","dt":"2023-01-02"}`),
+	}))
+	assert.Equal(t, []string{"dt=2023-01-02", "dt=2023-01-01"}, partitions)
+	require.Len(t, batches, 2)
+	assert.Equal(t, 2, batches[0].Len())
+	assert.Equal(t, 1, batches[1].Len())
+	assert.Equal(t, "dt=2023-01-02/a.parquet", partitionedKey(partitions[0], w.path.String(0, batches[0])))
+}
+
+func TestS3ParquetConfigErrors(t *testing.T) {
+	conf := output.NewAmazonS3Config()
+	conf.Parquet.Enabled = true
+	conf.Parquet.Compression = "brotli"
+	_, err := newAmazonS3Writer(conf, mock.NewManager())
+	require.EqualError(t, err, "unknown parquet compression type: brotli")
+
+	conf.Parquet.Compression = "snappy"
+	conf.Parquet.Schema = "{}"
+	conf.Parquet.SchemaFile = "foo.json"
+	_, err = newAmazonS3Writer(conf, mock.NewManager())
+	require.EqualError(t, err, "parquet schema and schema_file cannot both be set")
+
+	conf = output.NewAmazonS3Config()
+	conf.PartitionBy = []output.AmazonS3PartitionConfig{{Value: "foo"}}
+	_, err = newAmazonS3Writer(conf, mock.NewManager())
+	require.EqualError(t, err, "partition_by 0 requires a key")
+}
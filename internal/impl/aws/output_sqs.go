@@ -2,6 +2,9 @@ package aws
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"regexp"
 	"sort"
@@ -48,11 +51,26 @@ String. If the number of metadata values in a message exceeds the message
 attribute limit (10) then the top ten keys ordered alphabetically will be
 selected.
 
-The fields `+"`message_group_id` and `message_deduplication_id`"+` can be
+The fields `+"`message_group_id`, `message_deduplication_id` and `delay`"+` can be
 set dynamically using
 [function interpolations](/docs/configuration/interpolation#bloblang-queries), which are
 resolved individually for each message of a batch.
 
+### FIFO Queues
+
+Queues with a URL ending in `+"`.fifo`"+` are treated as FIFO queues, which require
+a `+"`message_group_id`"+` to be set and do not support a `+"`delay`"+`. When a
+`+"`message_deduplication_id`"+` isn't set the queue is checked for content-based
+deduplication upon connecting, and if it isn't enabled the deduplication ID of
+each message is set to the SHA-256 hash of its contents, which matches the
+behaviour of content-based deduplication.
+
+The messages of a batch are sent in order, and when sending a message fails it
+is retried along with any other failed messages of the batch in their original
+order before the remaining messages of the batch are sent. However, ordering is
+only guaranteed within a batch, and therefore in order to preserve the order of
+messages of a group across batches `+"`max_in_flight`"+` must be set to `+"`1`"+`.
+
 ### Credentials
 
 By default Benthos will use a shared credentials file when connecting to AWS
@@ -61,8 +79,9 @@ allowing you to transfer data across accounts. You can find out more
 [in this document](/docs/guides/cloud/aws).`),
 		Config: docs.FieldComponent().WithChildren(
 			docs.FieldString("url", "The URL of the target SQS queue."),
-			docs.FieldString("message_group_id", "An optional group ID to set for messages.").IsInterpolated(),
-			docs.FieldString("message_deduplication_id", "An optional deduplication ID to set for messages.").IsInterpolated(),
+			docs.FieldString("message_group_id", "An optional group ID to set for messages, which is required when sending to a FIFO queue.").IsInterpolated(),
+			docs.FieldString("message_deduplication_id", "An optional deduplication ID to set for messages. When sending to a FIFO queue without content-based deduplication and this field is empty the SHA-256 hash of the contents of each message is used.").IsInterpolated(),
+			docs.FieldString("delay", "An optional duration to delay the delivery of each message by, which is rounded down to the second and must not exceed 15 minutes. Delays are not supported by FIFO queues.", "30s", `${! meta("delay") }`).IsInterpolated().Advanced(),
 			docs.FieldInt("max_in_flight", "The maximum number of messages to have in flight at a given time. Increase this to improve throughput."),
 			docs.FieldObject("metadata", "Specify criteria for which metadata values are sent as headers.").WithChildren(metadata.ExcludeFilterFields()...),
 			policy.FieldSpec(),
@@ -97,8 +116,12 @@ type sqsWriter struct {
 
 	groupID    *field.Expression
 	dedupeID   *field.Expression
+	delay      *field.Expression
 	metaFilter *metadata.ExcludeFilter
 
+	fifo          bool
+	contentDedupe bool
+
 	closer    sync.Once
 	closeChan chan struct{}
 
@@ -123,10 +146,27 @@ func newSQSWriter(conf output.AmazonSQSConfig, mgr bundle.NewManagement) (*sqsWr
 			return nil, fmt.Errorf("failed to parse dedupe ID expression: %v", err)
 		}
 	}
+	if d := conf.Delay; len(d) > 0 {
+		if s.delay, err = mgr.BloblEnvironment().NewField(d); err != nil {
+			return nil, fmt.Errorf("failed to parse delay expression: %v", err)
+		}
+	}
 	if s.metaFilter, err = conf.Metadata.Filter(); err != nil {
 		return nil, fmt.Errorf("failed to construct metadata filter: %w", err)
 	}
 
+	if s.fifo = strings.HasSuffix(conf.URL, ".fifo"); s.fifo {
+		if s.groupID == nil {
+			return nil, errors.New("a message_group_id is required when sending to a FIFO queue")
+		}
+		if s.delay != nil {
+			return nil, errors.New("a delay cannot be set when sending to a FIFO queue")
+		}
+		if conf.MaxInFlight > 1 {
+			s.log.Warnf("Messages of a group are only sent in order within a batch when max_in_flight is greater than 1, set it to 1 in order to preserve the order of all messages\n")
+		}
+	}
+
 	if s.backoffCtor, err = conf.Config.GetCtor(); err != nil {
 		return nil, err
 	}
@@ -144,24 +184,56 @@ func (a *sqsWriter) ConnectWithContext(ctx context.Context) error {
 	}
 
 	a.sqs = sqs.New(sess)
+	if err := a.checkContentDedupe(ctx); err != nil {
+		a.sqs = nil
+		return err
+	}
 	a.log.Infof("Sending messages to Amazon SQS URL: %v\n", a.conf.URL)
 	return nil
 }
 
+// checkContentDedupe determines whether deduplication IDs need to be derived
+// from the contents of messages, which is the case for FIFO queues without
+// content-based deduplication when a deduplication ID isn't configured.
+func (a *sqsWriter) checkContentDedupe(ctx context.Context) error {
+	if !a.fifo || a.dedupeID != nil {
+		return nil
+	}
+
+	res, err := a.sqs.GetQueueAttributesWithContext(ctx, &sqs.GetQueueAttributesInput{
+		QueueUrl:       aws.String(a.conf.URL),
+		AttributeNames: []*string{aws.String(sqs.QueueAttributeNameContentBasedDeduplication)},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get queue attributes: %w", err)
+	}
+	if aws.StringValue(res.Attributes[sqs.QueueAttributeNameContentBasedDeduplication]) == "true" {
+		a.contentDedupe = false
+		return nil
+	}
+	a.log.Infof("Content-based deduplication is not enabled for FIFO queue, deduplication IDs will be derived from the contents of messages\n")
+	a.contentDedupe = true
+	return nil
+}
+
 type sqsAttributes struct {
 	attrMap  map[string]*sqs.MessageAttributeValue
 	groupID  *string
 	dedupeID *string
+	delay    *int64
 	content  *string
 }
 
+// The maximum delay supported by SQS.
+const sqsMaxDelay = 15 * time.Minute
+
 var sqsAttributeKeyInvalidCharRegexp = regexp.MustCompile(`(^\.)|(\.\.)|(^aws\.)|(^amazon\.)|(\.$)|([^a-z0-9_\-.]+)`)
 
 func isValidSQSAttribute(k, v string) bool {
 	return len(sqsAttributeKeyInvalidCharRegexp.FindStringIndex(strings.ToLower(k))) == 0
 }
 
-func (a *sqsWriter) getSQSAttributes(msg *message.Batch, i int) sqsAttributes {
+func (a *sqsWriter) getSQSAttributes(msg *message.Batch, i int) (sqsAttributes, error) {
 	p := msg.Get(i)
 	keys := []string{}
 	_ = a.metaFilter.Iter(p, func(k, v string) error {
@@ -194,14 +266,32 @@ func (a *sqsWriter) getSQSAttributes(msg *message.Batch, i int) sqsAttributes {
 	}
 	if a.dedupeID != nil {
 		dedupeID = aws.String(a.dedupeID.String(i, msg))
+	} else if a.contentDedupe {
+		sum := sha256.Sum256(p.Get())
+		dedupeID = aws.String(hex.EncodeToString(sum[:]))
+	}
+
+	var delay *int64
+	if a.delay != nil {
+		if ds := a.delay.String(i, msg); ds != "" {
+			d, err := time.ParseDuration(ds)
+			if err != nil {
+				return sqsAttributes{}, fmt.Errorf("failed to parse delay: %w", err)
+			}
+			if d < 0 || d > sqsMaxDelay {
+				return sqsAttributes{}, fmt.Errorf("delay %v must be between 0 and %v", d, sqsMaxDelay)
+			}
+			delay = aws.Int64(int64(d / time.Second))
+		}
 	}
 
 	return sqsAttributes{
 		attrMap:  values,
 		groupID:  groupID,
 		dedupeID: dedupeID,
+		delay:    delay,
 		content:  aws.String(string(p.Get())),
-	}
+	}, nil
 }
 
 func (a *sqsWriter) WriteWithContext(ctx context.Context, msg *message.Batch) error {
@@ -213,9 +303,12 @@ func (a *sqsWriter) WriteWithContext(ctx context.Context, msg *message.Batch) er
 
 	entries := []*sqs.SendMessageBatchRequestEntry{}
 	attrMap := map[string]sqsAttributes{}
-	_ = msg.Iter(func(i int, p *message.Part) error {
+	if err := msg.Iter(func(i int, p *message.Part) error {
 		id := strconv.Itoa(i)
-		attrs := a.getSQSAttributes(msg, i)
+		attrs, err := a.getSQSAttributes(msg, i)
+		if err != nil {
+			return err
+		}
 		attrMap[id] = attrs
 
 		entries = append(entries, &sqs.SendMessageBatchRequestEntry{
@@ -224,9 +317,12 @@ func (a *sqsWriter) WriteWithContext(ctx context.Context, msg *message.Batch) er
 			MessageAttributes:      attrs.attrMap,
 			MessageGroupId:         attrs.groupID,
 			MessageDeduplicationId: attrs.dedupeID,
+			DelaySeconds:           attrs.delay,
 		})
 		return nil
-	})
+	}); err != nil {
+		return err
+	}
 
 	input := &sqs.SendMessageBatchInput{
 		QueueUrl: aws.String(a.conf.URL),
@@ -262,6 +358,13 @@ func (a *sqsWriter) WriteWithContext(ctx context.Context, msg *message.Batch) er
 		}
 
 		if unproc := batchResult.Failed; len(unproc) > 0 {
+			// Failed entries are retried in their original order so that the
+			// order of messages within a group is preserved.
+			sort.Slice(unproc, func(i, j int) bool {
+				iIndex, _ := strconv.Atoi(aws.StringValue(unproc[i].Id))
+				jIndex, _ := strconv.Atoi(aws.StringValue(unproc[j].Id))
+				return iIndex < jIndex
+			})
 			input.Entries = []*sqs.SendMessageBatchRequestEntry{}
 			for _, v := range unproc {
 				if *v.SenderFault {
@@ -276,6 +379,7 @@ func (a *sqsWriter) WriteWithContext(ctx context.Context, msg *message.Batch) er
 					MessageAttributes:      aMap.attrMap,
 					MessageGroupId:         aMap.groupID,
 					MessageDeduplicationId: aMap.dedupeID,
+					DelaySeconds:           aMap.delay,
 				})
 			}
 			err = fmt.Errorf("failed to send %v messages", len(unproc))
@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/service/sqs"
 	"github.com/aws/aws-sdk-go/service/sqs/sqsiface"
 	"github.com/stretchr/testify/assert"
@@ -111,7 +112,12 @@ func TestSQSHeaderCheck(t *testing.T) {
 
 type mockSqs struct {
 	sqsiface.SQSAPI
-	fn func(*sqs.SendMessageBatchInput) (*sqs.SendMessageBatchOutput, error)
+	fn    func(*sqs.SendMessageBatchInput) (*sqs.SendMessageBatchOutput, error)
+	attrs map[string]*string
+}
+
+func (m *mockSqs) GetQueueAttributesWithContext(ctx aws.Context, input *sqs.GetQueueAttributesInput, opts ...request.Option) (*sqs.GetQueueAttributesOutput, error) {
+	return &sqs.GetQueueAttributesOutput{Attributes: m.attrs}, nil
 }
 
 func (m *mockSqs) SendMessageBatch(input *sqs.SendMessageBatchInput) (*sqs.SendMessageBatchOutput, error) {
@@ -247,3 +253,155 @@ func TestSQSSendLimit(t *testing.T) {
 		},
 	}, in)
 }
+
+func TestSQSDelay(t *testing.T) {
+	tCtx := context.Background()
+
+	conf := output.NewAmazonSQSConfig()
+	conf.Delay = `${! meta("delay").or("") }`
+	w, err := newSQSWriter(conf, mock.NewManager())
+	require.NoError(t, err)
+
+	var delays []*int64
+	w.sqs = &mockSqs{
+		fn: func(smbi *sqs.SendMessageBatchInput) (*sqs.SendMessageBatchOutput, error) {
+			for _, entry := range smbi.Entries {
+				delays = append(delays, entry.DelaySeconds)
+			}
+			return &sqs.SendMessageBatchOutput{}, nil
+		},
+	}
+
+	inMsg := message.QuickBatch([][]byte{
+		[]byte("hello world 1"),
+		[]byte("hello world 2"),
+	})
+	inMsg.Get(0).MetaSet("delay", "1m30.5s")
+	require.NoError(t, w.WriteWithContext(tCtx, inMsg))
+	assert.Equal(t, []*int64{aws.Int64(90), nil}, delays)
+
+	inMsg.Get(1).MetaSet("delay", "16m")
+	require.EqualError(t, w.WriteWithContext(tCtx, inMsg), "delay 16m0s must be between 0 and 15m0s")
+
+	inMsg.Get(1).MetaSet("delay", "nope")
+	require.Error(t, w.WriteWithContext(tCtx, inMsg))
+}
+
+func TestSQSFIFOConfigErrors(t *testing.T) {
+	conf := output.NewAmazonSQSConfig()
+	conf.URL = "https://sqs.us-east-1.amazonaws.com/123/foo.fifo"
+	_, err := newSQSWriter(conf, mock.NewManager())
+	require.EqualError(t, err, "a message_group_id is required when sending to a FIFO queue")
+
+	conf.MessageGroupID = `${! meta("group") }`
+	conf.Delay = "10s"
+	_, err = newSQSWriter(conf, mock.NewManager())
+	require.EqualError(t, err, "a delay cannot be set when sending to a FIFO queue")
+
+	conf.Delay = ""
+	_, err = newSQSWriter(conf, mock.NewManager())
+	require.NoError(t, err)
+}
+
+func TestSQSFIFOContentDedupe(t *testing.T) {
+	tCtx := context.Background()
+
+	conf := output.NewAmazonSQSConfig()
+	conf.URL = "https://sqs.us-east-1.amazonaws.com/123/foo.fifo"
+	conf.MessageGroupID = "foo"
+
+	for _, test := range []struct {
+		name     string
+		attrs    map[string]*string
+		dedupeID *string
+	}{
+		{
+			name:     "content based deduplication disabled",
+			attrs:    map[string]*string{},
+			dedupeID: aws.String("b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9"),
+		},
+		{
+			name: "content based deduplication enabled",
+			attrs: map[string]*string{
+				sqs.QueueAttributeNameContentBasedDeduplication: aws.String("true"),
+			},
+		},
+	} {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			w, err := newSQSWriter(conf, mock.NewManager())
+			require.NoError(t, err)
+
+			var entries []*sqs.SendMessageBatchRequestEntry
+			w.sqs = &mockSqs{
+				attrs: test.attrs,
+				fn: func(smbi *sqs.SendMessageBatchInput) (*sqs.SendMessageBatchOutput, error) {
+					entries = append(entries, smbi.Entries...)
+					return &sqs.SendMessageBatchOutput{}, nil
+				},
+			}
+			require.NoError(t, w.checkContentDedupe(tCtx))
+			require.NoError(t, w.WriteWithContext(tCtx, message.QuickBatch([][]byte{
+				[]byte("hello world"),
+			})))
+
+			require.Len(t, entries, 1)
+			assert.Equal(t, "foo", *entries[0].MessageGroupId)
+			assert.Equal(t, test.dedupeID, entries[0].MessageDeduplicationId)
+		})
+	}
+}
+
+func TestSQSFIFORetryOrder(t *testing.T) {
+	tCtx := context.Background()
+
+	conf := output.NewAmazonSQSConfig()
+	conf.URL = "https://sqs.us-east-1.amazonaws.com/123/foo.fifo"
+	conf.MessageGroupID = "foo"
+	conf.MessageDeduplicationID = `${! content() }`
+	w, err := newSQSWriter(conf, mock.NewManager())
+	require.NoError(t, err)
+
+	failed := func(ids ...string) *sqs.SendMessageBatchOutput {
+		res := &sqs.SendMessageBatchOutput{}
+		for _, id := range ids {
+			res.Failed = append(res.Failed, &sqs.BatchResultErrorEntry{
+				Code:        aws.String("xx"),
+				Id:          aws.String(id),
+				Message:     aws.String("test error"),
+				SenderFault: aws.Bool(false),
+			})
+		}
+		return res
+	}
+
+	var in [][]string
+	out := []*sqs.SendMessageBatchOutput{failed("9", "2", "5"), {}, {}}
+	w.sqs = &mockSqs{
+		fn: func(smbi *sqs.SendMessageBatchInput) (*sqs.SendMessageBatchOutput, error) {
+			var ids []string
+			for _, entry := range smbi.Entries {
+				ids = append(ids, *entry.Id)
+			}
+			in = append(in, ids)
+
+			if len(out) == 0 {
+				return nil, errors.New("ran out of mock outputs")
+			}
+			outBatch := out[0]
+			out = out[1:]
+			return outBatch, nil
+		},
+	}
+
+	var parts [][]byte
+	for i := 0; i < 12; i++ {
+		parts = append(parts, []byte(fmt.Sprintf("hello world %v", i)))
+	}
+	require.NoError(t, w.WriteWithContext(tCtx, message.QuickBatch(parts)))
+
+	assert.Equal(t, [][]string{
+		{"0", "1", "2", "3", "4", "5", "6", "7", "8", "9"},
+		{"2", "5", "9", "10", "11"},
+	}, in)
+}
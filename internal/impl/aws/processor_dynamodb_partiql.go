@@ -47,7 +47,7 @@ pipeline:
 	err := service.RegisterBatchProcessor(
 		"aws_dynamodb_partiql", config,
 		func(conf *service.ParsedConfig, mgr *service.Resources) (service.BatchProcessor, error) {
-			sess, err := getSession(conf)
+			sess, err := GetSession(conf)
 			if err != nil {
 				return nil, err
 			}
@@ -2,6 +2,7 @@ package aws
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"sync"
@@ -52,6 +53,16 @@ output:
           resource: somewhere_else
 `+"```"+`
 
+### Batch Invocation
+
+When `+"`batch_invoke`"+` is set to `+"`true`"+` the function is invoked once for each batch of messages rather than once per message, which can dramatically reduce invocation costs for high volume enrichment. The payload of the request is a JSON array containing each message of the batch, and the function must respond with a JSON array of the same length, where each element becomes the new contents of the message at the same index.
+
+Elements of the response array that are objects containing an `+"`errorMessage`"+` field are treated as per-message failures, the contents of the corresponding message are updated with the element and it is flagged as having failed. If the invocation as a whole results in a function error then all messages of the batch are flagged as having failed with the reason, and the metadata field `+"`lambda_function_error`"+` is added to each of them.
+
+### Asynchronous Invocation
+
+When the `+"`invocation_type`"+` is set to `+"`Event`"+` the function is invoked asynchronously, Lambda queues the event and responds without waiting for the function to complete. In this mode the contents of messages are left unchanged, and failures within the function itself are not visible to Benthos.
+
 ### Credentials
 
 By default Benthos will use a shared credentials file when connecting to AWS services. It's also possible to set them explicitly at the component level, allowing you to transfer data across accounts. You can find out more [in this document](/docs/guides/cloud/aws).`).
@@ -69,13 +80,43 @@ pipeline:
         processors:
           - aws_lambda:
               function: trigger_user_update
+`,
+		).
+		Example(
+			"Batched Enrichment",
+			`
+This example invokes a function once per batch of up to 100 messages, where the function is expected to respond with an array of enriched documents in the same order as the array it received.`,
+			`
+pipeline:
+  processors:
+    - aws_lambda:
+        function: enrich_users
+        batch_invoke: true
+
+input:
+  kafka:
+    addresses: [ TODO ]
+    topics: [ users ]
+    consumer_group: enrichment
+    batching:
+      count: 100
+      period: 1s
 `,
 		).
 		Field(service.NewBoolField("parallel").
-			Description("Whether messages of a batch should be dispatched in parallel.").
+			Description("Whether messages of a batch should be dispatched in parallel. This field cannot be combined with `batch_invoke`.").
 			Default(false)).
 		Field(service.NewStringField("function").
 			Description("The function to invoke.")).
+		Field(service.NewBoolField("batch_invoke").
+			Description("Whether to invoke the function once per batch with a payload that is a JSON array of the messages, rather than once per message. The function must respond with a JSON array of the same length as the request.").
+			Version("4.3.0").
+			Default(false)).
+		Field(service.NewStringEnumField("invocation_type", lambda.InvocationTypeRequestResponse, lambda.InvocationTypeEvent).
+			Description("The type of invocation, `RequestResponse` invokes the function synchronously and replaces message contents with the response, `Event` invokes the function asynchronously and leaves message contents unchanged.").
+			Version("4.3.0").
+			Default(lambda.InvocationTypeRequestResponse).
+			Advanced()).
 		Field(service.NewStringField("rate_limit").
 			Description("An optional [`rate_limit`](/docs/components/rate_limits/about) to throttle invocations by.").
 			Default("").
@@ -97,7 +138,7 @@ pipeline:
 	err := service.RegisterBatchProcessor(
 		"aws_lambda", config,
 		func(conf *service.ParsedConfig, mgr *service.Resources) (service.BatchProcessor, error) {
-			sess, err := getSession(conf)
+			sess, err := GetSession(conf)
 			if err != nil {
 				return nil, err
 			}
@@ -112,6 +153,16 @@ pipeline:
 				return nil, err
 			}
 
+			batchInvoke, err := conf.FieldBool("batch_invoke")
+			if err != nil {
+				return nil, err
+			}
+
+			invocationType, err := conf.FieldString("invocation_type")
+			if err != nil {
+				return nil, err
+			}
+
 			numRetries, err := conf.FieldInt("retries")
 			if err != nil {
 				return nil, err
@@ -127,7 +178,7 @@ pipeline:
 				return nil, err
 			}
 
-			return newLambdaProc(lambda.New(sess), parallel, function, numRetries, rateLimit, timeout, mgr)
+			return newLambdaProc(lambda.New(sess), parallel, batchInvoke, function, invocationType, numRetries, rateLimit, timeout, mgr)
 		})
 	if err != nil {
 		panic(err)
@@ -137,8 +188,9 @@ pipeline:
 //------------------------------------------------------------------------------
 
 type lambdaProc struct {
-	client   *lambdaClient
-	parallel bool
+	client      *lambdaClient
+	parallel    bool
+	batchInvoke bool
 
 	functionName string
 	log          *service.Logger
@@ -147,19 +199,25 @@ type lambdaProc struct {
 func newLambdaProc(
 	lambda lambdaiface.LambdaAPI,
 	parallel bool,
+	batchInvoke bool,
 	function string,
+	invocationType string,
 	numRetries int,
 	rateLimit string,
 	timeout time.Duration,
 	mgr *service.Resources,
 ) (*lambdaProc, error) {
+	if parallel && batchInvoke {
+		return nil, errors.New("parallel cannot be combined with batch_invoke")
+	}
 	l := &lambdaProc{
 		functionName: function,
 		log:          mgr.Logger(),
 		parallel:     parallel,
+		batchInvoke:  batchInvoke,
 	}
 	var err error
-	if l.client, err = newLambdaClient(lambda, function, numRetries, rateLimit, timeout, mgr); err != nil {
+	if l.client, err = newLambdaClient(lambda, function, invocationType, numRetries, rateLimit, timeout, mgr); err != nil {
 		return nil, err
 	}
 	return l, nil
@@ -169,7 +227,15 @@ func newLambdaProc(
 
 func (l *lambdaProc) ProcessBatch(ctx context.Context, batch service.MessageBatch) ([]service.MessageBatch, error) {
 	var resultMsg service.MessageBatch
-	if !l.parallel || len(batch) == 1 {
+	if l.batchInvoke {
+		resultMsg = batch.Copy()
+		if err := l.client.InvokeBatch(resultMsg); err != nil {
+			l.log.Errorf("Lambda batch request to '%v' failed: %v\n", l.functionName, err)
+			for _, p := range resultMsg {
+				p.SetError(err)
+			}
+		}
+	} else if !l.parallel || len(batch) == 1 {
 		resultMsg = batch.Copy()
 		for _, p := range resultMsg {
 			if err := l.client.InvokeV2(p); err != nil {
@@ -216,28 +282,31 @@ type lambdaClient struct {
 	log *service.Logger
 	mgr *service.Resources
 
-	function  string
-	retries   int
-	rateLimit string
-	timeout   time.Duration
+	function       string
+	invocationType string
+	retries        int
+	rateLimit      string
+	timeout        time.Duration
 }
 
 func newLambdaClient(
 	lambda lambdaiface.LambdaAPI,
 	function string,
+	invocationType string,
 	numRetries int,
 	rateLimit string,
 	timeout time.Duration,
 	mgr *service.Resources,
 ) (*lambdaClient, error) {
 	l := lambdaClient{
-		lambda:    lambda,
-		log:       mgr.Logger(),
-		mgr:       mgr,
-		function:  function,
-		retries:   numRetries,
-		rateLimit: rateLimit,
-		timeout:   timeout,
+		lambda:         lambda,
+		log:            mgr.Logger(),
+		mgr:            mgr,
+		function:       function,
+		invocationType: invocationType,
+		retries:        numRetries,
+		rateLimit:      rateLimit,
+		timeout:        timeout,
 	}
 	if function == "" {
 		return nil, errors.New("lambda function must not be empty")
@@ -278,33 +347,118 @@ func (l *lambdaClient) waitForAccess(ctx context.Context) bool {
 	}
 }
 
-func (l *lambdaClient) InvokeV2(p *service.Message) error {
+func (l *lambdaClient) invoke(payload []byte) (*lambda.InvokeOutput, error) {
 	remainingRetries := l.retries
 	for {
 		l.waitForAccess(context.Background())
 
-		mBytes, err := p.AsBytes()
-		if err != nil {
-			return err
-		}
-
 		ctx, done := context.WithTimeout(context.Background(), l.timeout)
 		result, err := l.lambda.InvokeWithContext(ctx, &lambda.InvokeInput{
-			FunctionName: aws.String(l.function),
-			Payload:      mBytes,
+			FunctionName:   aws.String(l.function),
+			InvocationType: aws.String(l.invocationType),
+			Payload:        payload,
 		})
 		done()
 		if err == nil {
-			if result.FunctionError != nil {
-				p.MetaSet("lambda_function_error", *result.FunctionError)
-			}
-			p.SetBytes(result.Payload)
-			return nil
+			return result, nil
 		}
 
 		remainingRetries--
 		if remainingRetries < 0 {
+			return nil, err
+		}
+	}
+}
+
+func (l *lambdaClient) InvokeV2(p *service.Message) error {
+	mBytes, err := p.AsBytes()
+	if err != nil {
+		return err
+	}
+
+	result, err := l.invoke(mBytes)
+	if err != nil {
+		return err
+	}
+	if l.invocationType == lambda.InvocationTypeEvent {
+		return nil
+	}
+	if result.FunctionError != nil {
+		p.MetaSet("lambda_function_error", *result.FunctionError)
+	}
+	p.SetBytes(result.Payload)
+	return nil
+}
+
+type lambdaErrorPayload struct {
+	ErrorType    string `json:"errorType"`
+	ErrorMessage string `json:"errorMessage"`
+}
+
+func (e lambdaErrorPayload) Error() string {
+	if e.ErrorType == "" {
+		return fmt.Sprintf("function error: %v", e.ErrorMessage)
+	}
+	return fmt.Sprintf("function error %v: %v", e.ErrorType, e.ErrorMessage)
+}
+
+// InvokeBatch invokes the function once with a JSON array of the batch as the
+// payload, and maps each element of the response array back onto the message
+// at the same index. Elements describing an error are flagged as failed on
+// their respective message.
+func (l *lambdaClient) InvokeBatch(batch service.MessageBatch) error {
+	payload := make([]json.RawMessage, len(batch))
+	for i, p := range batch {
+		mBytes, err := p.AsBytes()
+		if err != nil {
 			return err
 		}
+		if !json.Valid(mBytes) {
+			return fmt.Errorf("message %v is not valid JSON", i)
+		}
+		payload[i] = mBytes
 	}
+
+	pBytes, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	result, err := l.invoke(pBytes)
+	if err != nil {
+		return err
+	}
+	if l.invocationType == lambda.InvocationTypeEvent {
+		return nil
+	}
+
+	if result.FunctionError != nil {
+		var ePayload lambdaErrorPayload
+		if err := json.Unmarshal(result.Payload, &ePayload); err != nil || ePayload.ErrorMessage == "" {
+			ePayload = lambdaErrorPayload{ErrorMessage: *result.FunctionError}
+		}
+		for _, p := range batch {
+			p.MetaSet("lambda_function_error", *result.FunctionError)
+			p.SetError(ePayload)
+		}
+		return nil
+	}
+
+	var results []json.RawMessage
+	if err := json.Unmarshal(result.Payload, &results); err != nil {
+		return fmt.Errorf("failed to parse response as a JSON array: %w", err)
+	}
+	if len(results) != len(batch) {
+		return fmt.Errorf("response array length %v does not match batch size %v", len(results), len(batch))
+	}
+
+	for i, p := range batch {
+		p.SetBytes(results[i])
+
+		var ePayload lambdaErrorPayload
+		if err := json.Unmarshal(results[i], &ePayload); err == nil && ePayload.ErrorMessage != "" {
+			p.SetError(ePayload)
+		}
+	}
+	return nil
 }
@@ -2,10 +2,12 @@ package aws
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"testing"
 	"time"
 
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/service/lambda"
 	"github.com/aws/aws-sdk-go/service/lambda/lambdaiface"
@@ -32,7 +34,7 @@ func TestLambdaErrors(t *testing.T) {
 		},
 	}
 
-	p, err := newLambdaProc(mock, false, "foofn", 3, "", time.Second, service.MockResources())
+	p, err := newLambdaProc(mock, false, false, "foofn", "RequestResponse", 3, "", time.Second, service.MockResources())
 	require.NoError(t, err)
 
 	bCtx := context.Background()
@@ -52,7 +54,7 @@ func TestLambdaErrors(t *testing.T) {
 	assert.EqualError(t, outBatches[0][1].GetError(), "meow bar")
 	assert.EqualError(t, outBatches[0][2].GetError(), "meow baz")
 
-	p, err = newLambdaProc(mock, true, "foofn", 3, "", time.Second, service.MockResources())
+	p, err = newLambdaProc(mock, true, false, "foofn", "RequestResponse", 3, "", time.Second, service.MockResources())
 	require.NoError(t, err)
 
 	outBatches, err = p.ProcessBatch(bCtx, inBatch)
@@ -76,7 +78,7 @@ func TestLambdaMutations(t *testing.T) {
 		},
 	}
 
-	p, err := newLambdaProc(mock, false, "foofn", 3, "", time.Second, service.MockResources())
+	p, err := newLambdaProc(mock, false, false, "foofn", "RequestResponse", 3, "", time.Second, service.MockResources())
 	require.NoError(t, err)
 
 	bCtx := context.Background()
@@ -107,7 +109,7 @@ func TestLambdaMutations(t *testing.T) {
 	b, _ = inBatch[2].AsBytes()
 	assert.Equal(t, "baz", string(b))
 
-	p, err = newLambdaProc(mock, true, "foofn", 3, "", time.Second, service.MockResources())
+	p, err = newLambdaProc(mock, true, false, "foofn", "RequestResponse", 3, "", time.Second, service.MockResources())
 	require.NoError(t, err)
 
 	outBatches, err = p.ProcessBatch(bCtx, inBatch)
@@ -131,3 +133,143 @@ func TestLambdaMutations(t *testing.T) {
 	b, _ = inBatch[2].AsBytes()
 	assert.Equal(t, "baz", string(b))
 }
+
+func TestLambdaBatchInvoke(t *testing.T) {
+	var invocations int
+	mock := &mockLambda{
+		fn: func(ii *lambda.InvokeInput) (*lambda.InvokeOutput, error) {
+			invocations++
+			assert.Equal(t, "RequestResponse", *ii.InvocationType)
+
+			var docs []map[string]interface{}
+			require.NoError(t, json.Unmarshal(ii.Payload, &docs))
+
+			var results []interface{}
+			for _, d := range docs {
+				if d["id"] == "bar" {
+					results = append(results, map[string]interface{}{
+						"errorType":    "NotFound",
+						"errorMessage": "bar does not exist",
+					})
+					continue
+				}
+				d["enriched"] = true
+				results = append(results, d)
+			}
+			rBytes, err := json.Marshal(results)
+			require.NoError(t, err)
+			return &lambda.InvokeOutput{Payload: rBytes}, nil
+		},
+	}
+
+	p, err := newLambdaProc(mock, false, true, "foofn", "RequestResponse", 3, "", time.Second, service.MockResources())
+	require.NoError(t, err)
+
+	inBatch := service.MessageBatch{
+		service.NewMessage([]byte(`{"id":"foo"}`)),
+		service.NewMessage([]byte(`{"id":"bar"}`)),
+		service.NewMessage([]byte(`{"id":"baz"}`)),
+	}
+
+	outBatches, err := p.ProcessBatch(context.Background(), inBatch)
+	require.NoError(t, err)
+	assert.Equal(t, 1, invocations)
+
+	require.Len(t, outBatches, 1)
+	require.Len(t, outBatches[0], 3)
+
+	b, _ := outBatches[0][0].AsBytes()
+	assert.JSONEq(t, `{"id":"foo","enriched":true}`, string(b))
+	assert.NoError(t, outBatches[0][0].GetError())
+
+	assert.EqualError(t, outBatches[0][1].GetError(), "function error NotFound: bar does not exist")
+
+	b, _ = outBatches[0][2].AsBytes()
+	assert.JSONEq(t, `{"id":"baz","enriched":true}`, string(b))
+	assert.NoError(t, outBatches[0][2].GetError())
+
+	// Ensure origin didn't change
+	b, _ = inBatch[0].AsBytes()
+	assert.Equal(t, `{"id":"foo"}`, string(b))
+}
+
+func TestLambdaBatchInvokeErrors(t *testing.T) {
+	var response *lambda.InvokeOutput
+	mock := &mockLambda{
+		fn: func(ii *lambda.InvokeInput) (*lambda.InvokeOutput, error) {
+			return response, nil
+		},
+	}
+
+	p, err := newLambdaProc(mock, false, true, "foofn", "RequestResponse", 3, "", time.Second, service.MockResources())
+	require.NoError(t, err)
+
+	inBatch := service.MessageBatch{
+		service.NewMessage([]byte(`{"id":"foo"}`)),
+		service.NewMessage([]byte(`{"id":"bar"}`)),
+	}
+
+	response = &lambda.InvokeOutput{
+		FunctionError: aws.String("Unhandled"),
+		Payload:       []byte(`{"errorType":"TypeError","errorMessage":"nope"}`),
+	}
+	outBatches, err := p.ProcessBatch(context.Background(), inBatch)
+	require.NoError(t, err)
+	require.Len(t, outBatches[0], 2)
+	for _, m := range outBatches[0] {
+		assert.EqualError(t, m.GetError(), "function error TypeError: nope")
+		v, _ := m.MetaGet("lambda_function_error")
+		assert.Equal(t, "Unhandled", v)
+	}
+
+	response = &lambda.InvokeOutput{Payload: []byte(`[{"id":"foo"}]`)}
+	outBatches, err = p.ProcessBatch(context.Background(), inBatch)
+	require.NoError(t, err)
+	for _, m := range outBatches[0] {
+		assert.EqualError(t, m.GetError(), "response array length 1 does not match batch size 2")
+	}
+
+	_, err = newLambdaProc(mock, true, true, "foofn", "RequestResponse", 3, "", time.Second, service.MockResources())
+	require.EqualError(t, err, "parallel cannot be combined with batch_invoke")
+}
+
+func TestLambdaAsyncInvoke(t *testing.T) {
+	var payloads []string
+	mock := &mockLambda{
+		fn: func(ii *lambda.InvokeInput) (*lambda.InvokeOutput, error) {
+			assert.Equal(t, "Event", *ii.InvocationType)
+			payloads = append(payloads, string(ii.Payload))
+			return &lambda.InvokeOutput{StatusCode: aws.Int64(202)}, nil
+		},
+	}
+
+	inBatch := service.MessageBatch{
+		service.NewMessage([]byte(`{"id":"foo"}`)),
+		service.NewMessage([]byte(`{"id":"bar"}`)),
+	}
+
+	p, err := newLambdaProc(mock, false, false, "foofn", "Event", 3, "", time.Second, service.MockResources())
+	require.NoError(t, err)
+
+	outBatches, err := p.ProcessBatch(context.Background(), inBatch)
+	require.NoError(t, err)
+	require.Len(t, outBatches[0], 2)
+	b, _ := outBatches[0][0].AsBytes()
+	assert.Equal(t, `{"id":"foo"}`, string(b))
+	assert.NoError(t, outBatches[0][0].GetError())
+
+	p, err = newLambdaProc(mock, false, true, "foofn", "Event", 3, "", time.Second, service.MockResources())
+	require.NoError(t, err)
+
+	outBatches, err = p.ProcessBatch(context.Background(), inBatch)
+	require.NoError(t, err)
+	require.Len(t, outBatches[0], 2)
+	b, _ = outBatches[0][1].AsBytes()
+	assert.Equal(t, `{"id":"bar"}`, string(b))
+
+	assert.Equal(t, []string{
+		`{"id":"foo"}`,
+		`{"id":"bar"}`,
+		`[{"id":"foo"},{"id":"bar"}]`,
+	}, payloads)
+}
@@ -1,11 +1,16 @@
 package aws
 
 import (
+	"fmt"
+	"sort"
+	"time"
+
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/credentials/ec2rolecreds"
 	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
 	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sts"
 
 	bsession "github.com/benthosdev/benthos/v4/internal/impl/aws/session"
 	"github.com/benthosdev/benthos/v4/public/service"
@@ -42,13 +47,28 @@ func sessionFields() []*service.ConfigField {
 				Default("").Advanced(),
 			service.NewStringField("role_external_id").
 				Description("An external ID to provide when assuming a role.").
+				Default("").Advanced(),
+			service.NewStringField("role_session_name").
+				Description("An optional session name to use when assuming a role, which is generated when left empty.").
+				Default("").Advanced(),
+			service.NewStringMapField("role_session_tags").
+				Description("Optional session tags to pass when assuming a role.").
+				Example(map[string]interface{}{
+					"team": "data-platform",
+				}).
+				Default(map[string]interface{}{}).Advanced(),
+			service.NewStringField("role_duration").
+				Description("An optional duration of the credentials of an assumed role, which defaults to 15 minutes when left empty.").
+				Example("1h").
 				Default("").Advanced()).
 			Advanced().
 			Description("Optional manual configuration of AWS credentials to use. More information can be found [in this document](/docs/guides/cloud/aws)."),
 	}
 }
 
-func getSession(parsedConf *service.ParsedConfig, opts ...func(*aws.Config)) (*session.Session, error) {
+// GetSession attempts to create an AWS session from a parsed config containing
+// the AWS session fields.
+func GetSession(parsedConf *service.ParsedConfig, opts ...func(*aws.Config)) (*session.Session, error) {
 	awsConf := aws.NewConfig()
 
 	if region, _ := parsedConf.FieldString("region"); region != "" {
@@ -78,24 +98,25 @@ func getSession(parsedConf *service.ParsedConfig, opts ...func(*aws.Config)) (*s
 		return nil, err
 	}
 
+	if useEC2, _ := parsedConf.FieldBool("credentials", "from_ec2_role"); useEC2 {
+		sess.Config = sess.Config.WithCredentials(ec2rolecreds.NewCredentials(sess))
+	}
+
 	if role, _ := parsedConf.FieldString("credentials", "role"); role != "" {
-		var opts []func(*stscreds.AssumeRoleProvider)
-		if externalID, _ := parsedConf.FieldString("credentials", "role_external_id"); externalID != "" {
-			opts = []func(*stscreds.AssumeRoleProvider){
-				func(p *stscreds.AssumeRoleProvider) {
-					p.ExternalID = &externalID
-				},
-			}
+		externalID, _ := parsedConf.FieldString("credentials", "role_external_id")
+		sessionName, _ := parsedConf.FieldString("credentials", "role_session_name")
+		sessionTags, _ := parsedConf.FieldStringMap("credentials", "role_session_tags")
+		duration, _ := parsedConf.FieldString("credentials", "role_duration")
+
+		opts, err := assumeRoleOptions(externalID, sessionName, sessionTags, duration)
+		if err != nil {
+			return nil, err
 		}
 		sess.Config = sess.Config.WithCredentials(
 			stscreds.NewCredentials(sess, role, opts...),
 		)
 	}
 
-	if useEC2, _ := parsedConf.FieldBool("from_ec2_role"); useEC2 {
-		sess.Config = sess.Config.WithCredentials(ec2rolecreds.NewCredentials(sess))
-	}
-
 	return sess, nil
 }
 
@@ -131,23 +152,70 @@ func GetSessionFromConf(c bsession.Config, opts ...func(*aws.Config)) (*session.
 		return nil, err
 	}
 
+	if c.Credentials.UseEC2Creds {
+		sess.Config = sess.Config.WithCredentials(ec2rolecreds.NewCredentials(sess))
+	}
+
 	if len(c.Credentials.Role) > 0 {
-		var opts []func(*stscreds.AssumeRoleProvider)
-		if len(c.Credentials.ExternalID) > 0 {
-			opts = []func(*stscreds.AssumeRoleProvider){
-				func(p *stscreds.AssumeRoleProvider) {
-					p.ExternalID = &c.Credentials.ExternalID
-				},
-			}
+		opts, err := assumeRoleOptions(
+			c.Credentials.ExternalID,
+			c.Credentials.SessionName,
+			c.Credentials.SessionTags,
+			c.Credentials.Duration,
+		)
+		if err != nil {
+			return nil, err
 		}
 		sess.Config = sess.Config.WithCredentials(
 			stscreds.NewCredentials(sess, c.Credentials.Role, opts...),
 		)
 	}
 
-	if c.Credentials.UseEC2Creds {
-		sess.Config = sess.Config.WithCredentials(ec2rolecreds.NewCredentials(sess))
-	}
-
 	return sess, nil
 }
+
+// assumeRoleOptions returns the options of a provider of credentials for an
+// assumed role. Each component creates its own provider from the credentials
+// of its own session, and therefore the credentials of assumed roles are
+// cached independently and never shared between components.
+func assumeRoleOptions(externalID, sessionName string, sessionTags map[string]string, duration string) ([]func(*stscreds.AssumeRoleProvider), error) {
+	var opts []func(*stscreds.AssumeRoleProvider)
+	if externalID != "" {
+		opts = append(opts, func(p *stscreds.AssumeRoleProvider) {
+			p.ExternalID = &externalID
+		})
+	}
+	if sessionName != "" {
+		opts = append(opts, func(p *stscreds.AssumeRoleProvider) {
+			p.RoleSessionName = sessionName
+		})
+	}
+	if len(sessionTags) > 0 {
+		keys := make([]string, 0, len(sessionTags))
+		for k := range sessionTags {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		tags := make([]*sts.Tag, 0, len(keys))
+		for _, k := range keys {
+			tags = append(tags, &sts.Tag{
+				Key:   aws.String(k),
+				Value: aws.String(sessionTags[k]),
+			})
+		}
+		opts = append(opts, func(p *stscreds.AssumeRoleProvider) {
+			p.Tags = tags
+		})
+	}
+	if duration != "" {
+		d, err := time.ParseDuration(duration)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse role duration: %w", err)
+		}
+		opts = append(opts, func(p *stscreds.AssumeRoleProvider) {
+			p.Duration = d
+		})
+	}
+	return opts, nil
+}
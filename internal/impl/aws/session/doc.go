@@ -17,6 +17,11 @@ func FieldSpecs() docs.FieldSpecs {
 				docs.FieldBool("from_ec2_role", "Use the credentials of a host EC2 machine configured to assume [an IAM role associated with the instance](https://docs.aws.amazon.com/IAM/latest/UserGuide/id_roles_use_switch-role-ec2.html).").HasDefault(false).AtVersion("4.2.0"),
 				docs.FieldString("role", "A role ARN to assume.").HasDefault(""),
 				docs.FieldString("role_external_id", "An external ID to provide when assuming a role.").HasDefault(""),
+				docs.FieldString("role_session_name", "An optional session name to use when assuming a role, which is generated when left empty.").HasDefault(""),
+				docs.FieldString("role_session_tags", "Optional session tags to pass when assuming a role.", map[string]string{
+					"team": "data-platform",
+				}).Map().HasDefault(map[string]string{}),
+				docs.FieldString("role_duration", "An optional duration of the credentials of an assumed role, which defaults to 15 minutes when left empty.", "1h").HasDefault(""),
 			),
 	}
 }
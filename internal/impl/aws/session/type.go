@@ -2,13 +2,16 @@ package session
 
 // CredentialsConfig contains configuration params for AWS credentials.
 type CredentialsConfig struct {
-	Profile     string `json:"profile" yaml:"profile"`
-	ID          string `json:"id" yaml:"id"`
-	Secret      string `json:"secret" yaml:"secret"`
-	Token       string `json:"token" yaml:"token"`
-	UseEC2Creds bool   `json:"from_ec2_role" yaml:"from_ec2_role"`
-	Role        string `json:"role" yaml:"role"`
-	ExternalID  string `json:"role_external_id" yaml:"role_external_id"`
+	Profile     string            `json:"profile" yaml:"profile"`
+	ID          string            `json:"id" yaml:"id"`
+	Secret      string            `json:"secret" yaml:"secret"`
+	Token       string            `json:"token" yaml:"token"`
+	UseEC2Creds bool              `json:"from_ec2_role" yaml:"from_ec2_role"`
+	Role        string            `json:"role" yaml:"role"`
+	ExternalID  string            `json:"role_external_id" yaml:"role_external_id"`
+	SessionName string            `json:"role_session_name" yaml:"role_session_name"`
+	SessionTags map[string]string `json:"role_session_tags" yaml:"role_session_tags"`
+	Duration    string            `json:"role_duration" yaml:"role_duration"`
 }
 
 // Config contains configuration fields for an AWS session. This config is
@@ -23,12 +26,15 @@ type Config struct {
 func NewConfig() Config {
 	return Config{
 		Credentials: CredentialsConfig{
-			Profile:    "",
-			ID:         "",
-			Secret:     "",
-			Token:      "",
-			Role:       "",
-			ExternalID: "",
+			Profile:     "",
+			ID:          "",
+			Secret:      "",
+			Token:       "",
+			Role:        "",
+			ExternalID:  "",
+			SessionName: "",
+			SessionTags: map[string]string{},
+			Duration:    "",
 		},
 		Endpoint: "",
 		Region:   "",
@@ -0,0 +1,69 @@
+package aws
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/aws/aws-sdk-go/service/sts"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	bsession "github.com/benthosdev/benthos/v4/internal/impl/aws/session"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func TestAssumeRoleOptions(t *testing.T) {
+	opts, err := assumeRoleOptions("foo", "bar", map[string]string{
+		"b": "2",
+		"a": "1",
+	}, "1h")
+	require.NoError(t, err)
+
+	var p stscreds.AssumeRoleProvider
+	for _, opt := range opts {
+		opt(&p)
+	}
+	assert.Equal(t, "foo", aws.StringValue(p.ExternalID))
+	assert.Equal(t, "bar", p.RoleSessionName)
+	assert.Equal(t, time.Hour, p.Duration)
+	assert.Equal(t, []*sts.Tag{
+		{Key: aws.String("a"), Value: aws.String("1")},
+		{Key: aws.String("b"), Value: aws.String("2")},
+	}, p.Tags)
+
+	opts, err = assumeRoleOptions("", "", nil, "")
+	require.NoError(t, err)
+	assert.Empty(t, opts)
+
+	_, err = assumeRoleOptions("", "", nil, "nope")
+	require.Error(t, err)
+}
+
+func TestSessionRoleDurationErrors(t *testing.T) {
+	conf := bsession.NewConfig()
+	conf.Region = "eu-west-1"
+	conf.Credentials.Role = "arn:aws:iam::123456789012:role/foo"
+	conf.Credentials.Duration = "nope"
+
+	_, err := GetSessionFromConf(conf)
+	require.Error(t, err)
+
+	spec := service.NewConfigSpec()
+	for _, f := range sessionFields() {
+		spec = spec.Field(f)
+	}
+	parsed, err := spec.ParseYAML(`
+region: eu-west-1
+credentials:
+  role: arn:aws:iam::123456789012:role/foo
+  role_session_tags:
+    team: foo
+  role_duration: nope
+`, nil)
+	require.NoError(t, err)
+
+	_, err = GetSession(parsed)
+	require.Error(t, err)
+}
@@ -0,0 +1,197 @@
+package azure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/Azure/go-amqp"
+	"github.com/gofrs/uuid"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func eventHubsConnectionFields() []*service.ConfigField {
+	return []*service.ConfigField{
+		service.NewStringField("connection_string").
+			Description("A connection string of the Event Hubs namespace or Event Hub, which must contain a shared access key.").
+			Example("Endpoint=sb://foo.servicebus.windows.net/;SharedAccessKeyName=RootManageSharedAccessKey;SharedAccessKey=bar;EntityPath=baz"),
+		service.NewStringField("event_hub").
+			Description("The name of the Event Hub, which can be omitted when the connection string contains an `EntityPath`.").
+			Default(""),
+	}
+}
+
+type eventHubsConfig struct {
+	host    string
+	keyName string
+	key     string
+	hub     string
+}
+
+func eventHubsConfigFromParsed(conf *service.ParsedConfig) (c eventHubsConfig, err error) {
+	var connStr string
+	if connStr, err = conf.FieldString("connection_string"); err != nil {
+		return
+	}
+	if c, err = parseEventHubsConnectionString(connStr); err != nil {
+		return
+	}
+
+	var hub string
+	if hub, err = conf.FieldString("event_hub"); err != nil {
+		return
+	}
+	if hub != "" {
+		c.hub = hub
+	}
+	if c.hub == "" {
+		err = errors.New("an event_hub must be specified when the connection string does not contain an EntityPath")
+	}
+	return
+}
+
+// parseEventHubsConnectionString extracts the namespace host, shared access key
+// and optional Event Hub name from a connection string.
+func parseEventHubsConnectionString(connStr string) (c eventHubsConfig, err error) {
+	for _, pair := range strings.Split(connStr, ";") {
+		if pair == "" {
+			continue
+		}
+		i := strings.IndexByte(pair, '=')
+		if i <= 0 {
+			return c, fmt.Errorf("invalid connection string segment: %q", pair)
+		}
+		value := strings.TrimSpace(pair[i+1:])
+		switch strings.ToLower(strings.TrimSpace(pair[:i])) {
+		case "endpoint":
+			u, perr := url.Parse(value)
+			if perr != nil {
+				return c, fmt.Errorf("invalid connection string endpoint: %w", perr)
+			}
+			c.host = u.Host
+		case "sharedaccesskeyname":
+			c.keyName = value
+		case "sharedaccesskey":
+			c.key = value
+		case "entitypath":
+			c.hub = value
+		}
+	}
+	if c.host == "" {
+		return c, errors.New("connection string is missing an Endpoint")
+	}
+	if c.keyName == "" || c.key == "" {
+		return c, errors.New("connection string is missing a SharedAccessKeyName and SharedAccessKey")
+	}
+	return c, nil
+}
+
+//------------------------------------------------------------------------------
+
+// eventHubsConn is an AMQP connection to an Event Hubs namespace.
+type eventHubsConn struct {
+	client  *amqp.Client
+	session *amqp.Session
+}
+
+func (c eventHubsConfig) dial() (*eventHubsConn, error) {
+	client, err := amqp.Dial("amqps://"+c.host, amqp.ConnSASLPlain(c.keyName, c.key))
+	if err != nil {
+		return nil, err
+	}
+	session, err := client.NewSession()
+	if err != nil {
+		_ = client.Close()
+		return nil, err
+	}
+	return &eventHubsConn{client: client, session: session}, nil
+}
+
+// partitionIDs requests the partition IDs of an Event Hub from the management
+// node of the namespace.
+func (c *eventHubsConn) partitionIDs(ctx context.Context, hub string) ([]string, error) {
+	id, err := uuid.NewV4()
+	if err != nil {
+		return nil, err
+	}
+	replyTo := "benthos-" + id.String()
+
+	sender, err := c.session.NewSender(amqp.LinkTargetAddress("$management"))
+	if err != nil {
+		return nil, err
+	}
+	defer sender.Close(ctx)
+
+	receiver, err := c.session.NewReceiver(
+		amqp.LinkSourceAddress("$management"),
+		amqp.LinkTargetAddress(replyTo),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer receiver.Close(ctx)
+
+	if err := sender.Send(ctx, &amqp.Message{
+		Properties: &amqp.MessageProperties{
+			MessageID: replyTo,
+			ReplyTo:   &replyTo,
+		},
+		ApplicationProperties: map[string]interface{}{
+			"operation": "READ",
+			"name":      hub,
+			"type":      "com.microsoft:eventhub",
+		},
+	}); err != nil {
+		return nil, err
+	}
+
+	res, err := receiver.Receive(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return eventHubsPartitionIDsFromResponse(res)
+}
+
+func eventHubsPartitionIDsFromResponse(res *amqp.Message) ([]string, error) {
+	status, ok := res.ApplicationProperties["status-code"].(int32)
+	if !ok {
+		status, _ = res.ApplicationProperties["statusCode"].(int32)
+	}
+	if status != 200 {
+		desc, ok := res.ApplicationProperties["status-description"]
+		if !ok {
+			desc = res.ApplicationProperties["statusDescription"]
+		}
+		return nil, fmt.Errorf("unsuccessful management request with status code %v: %v", status, desc)
+	}
+
+	values, ok := res.Value.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("missing value in management response")
+	}
+
+	var ids []string
+	switch v := values["partition_ids"].(type) {
+	case []string:
+		ids = v
+	case []interface{}:
+		for _, id := range v {
+			idStr, ok := id.(string)
+			if !ok {
+				return nil, fmt.Errorf("unexpected partition id type: %T", id)
+			}
+			ids = append(ids, idStr)
+		}
+	default:
+		return nil, errors.New("missing partition_ids in management response")
+	}
+	return ids, nil
+}
+
+func (c *eventHubsConn) close(ctx context.Context) {
+	_ = c.session.Close(ctx)
+	_ = c.client.Close()
+}
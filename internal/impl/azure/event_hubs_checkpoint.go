@@ -0,0 +1,257 @@
+package azure
+
+import (
+	"errors"
+	"math/rand"
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/storage"
+)
+
+// eventHubsOwnership is a claim by a consumer of a partition of an Event Hub
+// consumer group.
+type eventHubsOwnership struct {
+	partitionID  string
+	ownerID      string
+	lastModified time.Time
+	etag         string
+}
+
+// eventHubsCheckpoint is the position of a consumer group within a partition
+// of an Event Hub.
+type eventHubsCheckpoint struct {
+	partitionID    string
+	offset         string
+	sequenceNumber int64
+}
+
+// eventHubsCheckpointStore persists the partition ownership and checkpoints of
+// an Event Hub consumer group, allowing consumers to balance partitions
+// between them and to resume from where they left off.
+type eventHubsCheckpointStore interface {
+	listOwnership() ([]eventHubsOwnership, error)
+
+	// claimOwnership attempts to claim or renew ownership of partitions,
+	// returning the claims that were successful. A claim only succeeds when the
+	// ownership has not been modified since it was listed.
+	claimOwnership(claims []eventHubsOwnership) ([]eventHubsOwnership, error)
+
+	listCheckpoints() (map[string]eventHubsCheckpoint, error)
+	updateCheckpoint(cp eventHubsCheckpoint) error
+}
+
+//------------------------------------------------------------------------------
+
+// eventHubsBlobStore is a checkpoint store of Azure Blob Storage that uses the
+// same blob layout as the official Event Hubs SDKs, and is therefore
+// compatible with other consumers of the same consumer group.
+type eventHubsBlobStore struct {
+	container *storage.Container
+	prefix    string
+}
+
+func newEventHubsBlobStore(connStr, containerName string, conf eventHubsConfig, consumerGroup string) (*eventHubsBlobStore, error) {
+	var client storage.Client
+	var err error
+	if strings.Contains(connStr, "UseDevelopmentStorage=true;") {
+		client, err = storage.NewEmulatorClient()
+	} else {
+		client, err = storage.NewClientFromConnectionString(connStr)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	blobService := client.GetBlobService()
+	container := blobService.GetContainerReference(containerName)
+	if _, err := container.CreateIfNotExists(nil); err != nil {
+		return nil, err
+	}
+
+	return &eventHubsBlobStore{
+		container: container,
+		prefix:    strings.ToLower(path.Join(conf.host, conf.hub, consumerGroup)) + "/",
+	}, nil
+}
+
+func (s *eventHubsBlobStore) listBlobs(kind string) ([]storage.Blob, error) {
+	var blobs []storage.Blob
+	params := storage.ListBlobsParameters{
+		Prefix:  s.prefix + kind + "/",
+		Include: &storage.IncludeBlobDataset{Metadata: true},
+	}
+	for {
+		res, err := s.container.ListBlobs(params)
+		if err != nil {
+			return nil, err
+		}
+		blobs = append(blobs, res.Blobs...)
+		if res.NextMarker == "" {
+			return blobs, nil
+		}
+		params.Marker = res.NextMarker
+	}
+}
+
+func (s *eventHubsBlobStore) listOwnership() ([]eventHubsOwnership, error) {
+	blobs, err := s.listBlobs("ownership")
+	if err != nil {
+		return nil, err
+	}
+	ownerships := make([]eventHubsOwnership, 0, len(blobs))
+	for _, b := range blobs {
+		ownerships = append(ownerships, eventHubsOwnership{
+			partitionID:  path.Base(b.Name),
+			ownerID:      b.Metadata["ownerid"],
+			lastModified: time.Time(b.Properties.LastModified),
+			etag:         b.Properties.Etag,
+		})
+	}
+	return ownerships, nil
+}
+
+func (s *eventHubsBlobStore) claimOwnership(claims []eventHubsOwnership) ([]eventHubsOwnership, error) {
+	var claimed []eventHubsOwnership
+	for _, c := range claims {
+		blob := s.container.GetBlobReference(s.prefix + "ownership/" + c.partitionID)
+		blob.Metadata = storage.BlobMetadata{"ownerid": c.ownerID}
+
+		opts := &storage.PutBlobOptions{IfMatch: c.etag}
+		if c.etag == "" {
+			opts = &storage.PutBlobOptions{IfNoneMatch: "*"}
+		}
+		if err := blob.CreateBlockBlobFromReader(nil, opts); err != nil {
+			var serr storage.AzureStorageServiceError
+			if errors.As(err, &serr) && (serr.StatusCode == http.StatusPreconditionFailed || serr.StatusCode == http.StatusConflict) {
+				// Claimed by another consumer since it was listed.
+				continue
+			}
+			return claimed, err
+		}
+		c.lastModified = time.Now()
+		claimed = append(claimed, c)
+	}
+	return claimed, nil
+}
+
+func (s *eventHubsBlobStore) listCheckpoints() (map[string]eventHubsCheckpoint, error) {
+	blobs, err := s.listBlobs("checkpoint")
+	if err != nil {
+		return nil, err
+	}
+	checkpoints := make(map[string]eventHubsCheckpoint, len(blobs))
+	for _, b := range blobs {
+		cp := eventHubsCheckpoint{
+			partitionID: path.Base(b.Name),
+			offset:      b.Metadata["offset"],
+		}
+		if cp.offset == "" {
+			continue
+		}
+		cp.sequenceNumber, _ = strconv.ParseInt(b.Metadata["sequencenumber"], 10, 64)
+		checkpoints[cp.partitionID] = cp
+	}
+	return checkpoints, nil
+}
+
+func (s *eventHubsBlobStore) updateCheckpoint(cp eventHubsCheckpoint) error {
+	blob := s.container.GetBlobReference(s.prefix + "checkpoint/" + cp.partitionID)
+	blob.Metadata = storage.BlobMetadata{
+		"offset":         cp.offset,
+		"sequencenumber": strconv.FormatInt(cp.sequenceNumber, 10),
+	}
+	return blob.CreateBlockBlobFromReader(nil, nil)
+}
+
+//------------------------------------------------------------------------------
+
+// eventHubsPartitionsToClaim determines the partitions that a consumer should
+// claim in order to balance the partitions of an Event Hub evenly between the
+// active consumers of a consumer group. The partitions already owned by the
+// consumer are renewed, and at most one further partition is claimed, taking
+// an unowned (or expired) partition where possible and otherwise stealing one
+// from the consumer that owns the most partitions.
+func eventHubsPartitionsToClaim(ownerID string, partitionIDs []string, ownerships []eventHubsOwnership, now time.Time, expiry time.Duration) []eventHubsOwnership {
+	existing := map[string]eventHubsOwnership{}
+	owned := map[string][]eventHubsOwnership{ownerID: nil}
+	for _, o := range ownerships {
+		existing[o.partitionID] = o
+		if o.ownerID == "" || now.Sub(o.lastModified) >= expiry {
+			continue
+		}
+		owned[o.ownerID] = append(owned[o.ownerID], o)
+	}
+
+	var unowned []string
+	for _, id := range partitionIDs {
+		o, exists := existing[id]
+		if !exists || o.ownerID == "" || now.Sub(o.lastModified) >= expiry {
+			unowned = append(unowned, id)
+		}
+	}
+
+	claims := make([]eventHubsOwnership, 0, len(owned[ownerID])+1)
+	for _, o := range owned[ownerID] {
+		o.ownerID = ownerID
+		claims = append(claims, o)
+	}
+
+	minPer := len(partitionIDs) / len(owned)
+	remainder := len(partitionIDs) % len(owned)
+
+	mine := len(owned[ownerID])
+	if mine > minPer {
+		return claims
+	}
+	if mine == minPer {
+		if remainder == 0 {
+			return claims
+		}
+		// Only claim one of the remaining partitions when fewer consumers than
+		// the remainder have already done so.
+		var aboveMin int
+		for _, o := range owned {
+			if len(o) > minPer {
+				aboveMin++
+			}
+		}
+		if aboveMin >= remainder {
+			return claims
+		}
+	}
+
+	claim := func(id string) []eventHubsOwnership {
+		o := existing[id]
+		o.partitionID = id
+		o.ownerID = ownerID
+		return append(claims, o)
+	}
+
+	if len(unowned) > 0 {
+		return claim(unowned[rand.Intn(len(unowned))])
+	}
+
+	// Steal from the consumer with the most partitions, when taking one leaves
+	// it with at least as many as us.
+	owners := make([]string, 0, len(owned))
+	for id := range owned {
+		owners = append(owners, id)
+	}
+	sort.Strings(owners)
+
+	var busiest string
+	for _, id := range owners {
+		if busiest == "" || len(owned[id]) > len(owned[busiest]) {
+			busiest = id
+		}
+	}
+	if victims := owned[busiest]; busiest != ownerID && len(victims) > mine+1 {
+		return claim(victims[rand.Intn(len(victims))].partitionID)
+	}
+	return claims
+}
@@ -0,0 +1,272 @@
+package azure
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/Azure/go-amqp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func TestEventHubsConnectionString(t *testing.T) {
+	c, err := parseEventHubsConnectionString("Endpoint=sb://foo.servicebus.windows.net/;SharedAccessKeyName=RootManageSharedAccessKey;SharedAccessKey=a+b/c=;EntityPath=baz")
+	require.NoError(t, err)
+	assert.Equal(t, eventHubsConfig{
+		host:    "foo.servicebus.windows.net",
+		keyName: "RootManageSharedAccessKey",
+		key:     "a+b/c=",
+		hub:     "baz",
+	}, c)
+
+	_, err = parseEventHubsConnectionString("SharedAccessKeyName=foo;SharedAccessKey=bar")
+	require.EqualError(t, err, "connection string is missing an Endpoint")
+
+	_, err = parseEventHubsConnectionString("Endpoint=sb://foo.servicebus.windows.net/")
+	require.EqualError(t, err, "connection string is missing a SharedAccessKeyName and SharedAccessKey")
+
+	_, err = parseEventHubsConnectionString("Endpoint=sb://foo.servicebus.windows.net/;nope")
+	require.EqualError(t, err, `invalid connection string segment: "nope"`)
+}
+
+func TestEventHubsInputConfigErrors(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		config string
+		err    string
+	}{
+		{
+			name:   "no event hub",
+			config: `connection_string: Endpoint=sb://foo.servicebus.windows.net/;SharedAccessKeyName=foo;SharedAccessKey=bar`,
+			err:    "an event_hub must be specified when the connection string does not contain an EntityPath",
+		},
+		{
+			name: "expiry below interval",
+			config: `
+connection_string: Endpoint=sb://foo.servicebus.windows.net/;SharedAccessKeyName=foo;SharedAccessKey=bar
+event_hub: foo
+load_balancing_interval: 10s
+ownership_expiry: 5s
+`,
+			err: "ownership_expiry must be greater than load_balancing_interval",
+		},
+		{
+			name: "zero prefetch",
+			config: `
+connection_string: Endpoint=sb://foo.servicebus.windows.net/;SharedAccessKeyName=foo;SharedAccessKey=bar;EntityPath=foo
+prefetch_count: 0
+`,
+			err: "prefetch_count must be greater than zero",
+		},
+	} {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			conf, err := eventHubsInputConfig().ParseYAML(test.config, nil)
+			require.NoError(t, err)
+
+			_, err = newEventHubsReaderFromParsed(conf, nil)
+			require.EqualError(t, err, test.err)
+		})
+	}
+}
+
+func TestEventHubsPartitionIDsResponse(t *testing.T) {
+	ids, err := eventHubsPartitionIDsFromResponse(&amqp.Message{
+		ApplicationProperties: map[string]interface{}{"status-code": int32(200)},
+		Value: map[string]interface{}{
+			"name":          "foo",
+			"partition_ids": []string{"0", "1"},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"0", "1"}, ids)
+
+	_, err = eventHubsPartitionIDsFromResponse(&amqp.Message{
+		ApplicationProperties: map[string]interface{}{
+			"status-code":        int32(404),
+			"status-description": "not found",
+		},
+	})
+	require.EqualError(t, err, "unsuccessful management request with status code 404: not found")
+}
+
+func claimedIDs(claims []eventHubsOwnership) []string {
+	ids := make([]string, 0, len(claims))
+	for _, c := range claims {
+		ids = append(ids, c.partitionID)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func TestEventHubsPartitionsToClaim(t *testing.T) {
+	now := time.Now()
+	expiry := time.Minute
+	partitions := []string{"0", "1", "2", "3"}
+
+	owned := func(id, owner string, age time.Duration) eventHubsOwnership {
+		return eventHubsOwnership{partitionID: id, ownerID: owner, lastModified: now.Add(-age), etag: "etag" + id}
+	}
+
+	// A lone consumer claims one partition at a time.
+	claims := eventHubsPartitionsToClaim("a", partitions, nil, now, expiry)
+	require.Len(t, claims, 1)
+	assert.Equal(t, "a", claims[0].ownerID)
+	assert.Empty(t, claims[0].etag)
+
+	// Owned partitions are renewed, and expired ones are claimed with the etag
+	// of their ownership.
+	claims = eventHubsPartitionsToClaim("a", partitions, []eventHubsOwnership{
+		owned("0", "a", 0),
+		owned("1", "b", time.Hour),
+		owned("2", "b", time.Hour),
+		owned("3", "b", time.Hour),
+	}, now, expiry)
+	require.Len(t, claims, 2)
+	assert.Equal(t, "0", claims[0].partitionID)
+	assert.Contains(t, []string{"1", "2", "3"}, claims[1].partitionID)
+	assert.Equal(t, "etag"+claims[1].partitionID, claims[1].etag)
+
+	// A balanced consumer only renews.
+	claims = eventHubsPartitionsToClaim("a", partitions, []eventHubsOwnership{
+		owned("0", "a", 0),
+		owned("1", "a", 0),
+		owned("2", "b", 0),
+		owned("3", "b", 0),
+	}, now, expiry)
+	assert.Equal(t, []string{"0", "1"}, claimedIDs(claims))
+
+	// A new consumer steals from the busiest consumer.
+	claims = eventHubsPartitionsToClaim("c", partitions, []eventHubsOwnership{
+		owned("0", "a", 0),
+		owned("1", "a", 0),
+		owned("2", "a", 0),
+		owned("3", "b", 0),
+	}, now, expiry)
+	require.Len(t, claims, 1)
+	assert.Contains(t, []string{"0", "1", "2"}, claims[0].partitionID)
+	assert.Equal(t, "c", claims[0].ownerID)
+
+	// Released partitions are claimable.
+	claims = eventHubsPartitionsToClaim("b", partitions, []eventHubsOwnership{
+		owned("0", "a", 0),
+		owned("1", "a", 0),
+		owned("2", "", 0),
+		owned("3", "b", 0),
+	}, now, expiry)
+	assert.Equal(t, []string{"2", "3"}, claimedIDs(claims))
+
+	// The remainder is only taken by as many consumers as there are remaining
+	// partitions.
+	claims = eventHubsPartitionsToClaim("c", []string{"0", "1", "2", "3", "4"}, []eventHubsOwnership{
+		owned("0", "a", 0),
+		owned("1", "a", 0),
+		owned("2", "b", 0),
+		owned("3", "b", 0),
+		owned("4", "c", 0),
+	}, now, expiry)
+	assert.Equal(t, []string{"4"}, claimedIDs(claims))
+}
+
+func TestEventHubsMessageFromAMQP(t *testing.T) {
+	enqueued := time.Date(2022, 5, 6, 7, 8, 9, 0, time.UTC)
+	msg, cp := eventHubsMessageFromAMQP("3", &amqp.Message{
+		Data: [][]byte{[]byte("hello world")},
+		Annotations: amqp.Annotations{
+			"x-opt-offset":          "1024",
+			"x-opt-sequence-number": int64(12),
+			"x-opt-enqueued-time":   enqueued,
+			"x-opt-partition-key":   "foo",
+		},
+		ApplicationProperties: map[string]interface{}{
+			"bar": "baz",
+			"num": int64(5),
+		},
+	})
+
+	assert.Equal(t, eventHubsCheckpoint{partitionID: "3", offset: "1024", sequenceNumber: 12}, cp)
+
+	b, err := msg.AsBytes()
+	require.NoError(t, err)
+	assert.Equal(t, "hello world", string(b))
+
+	meta := map[string]string{}
+	_ = msg.MetaWalk(func(k, v string) error {
+		meta[k] = v
+		return nil
+	})
+	assert.Equal(t, map[string]string{
+		"event_hubs_partition_id":    "3",
+		"event_hubs_offset":          "1024",
+		"event_hubs_sequence_number": "12",
+		"event_hubs_enqueued_time":   "2022-05-06T07:08:09Z",
+		"event_hubs_partition_key":   "foo",
+		"bar":                        "baz",
+		"num":                        "5",
+	}, meta)
+}
+
+func TestEventHubsOutputBatches(t *testing.T) {
+	conf, err := eventHubsOutputConfig().ParseYAML(`
+connection_string: Endpoint=sb://foo.servicebus.windows.net/;SharedAccessKeyName=foo;SharedAccessKey=bar;EntityPath=foo
+partition_key: '${! json("user").or("") }'
+metadata:
+  include_prefixes: [ prop_ ]
+`, nil)
+	require.NoError(t, err)
+
+	w, err := newEventHubsWriterFromParsed(conf, nil)
+	require.NoError(t, err)
+
+	msgA := service.NewMessage([]byte(`{"user":"a","n":1}`))
+	msgA.MetaSet("prop_foo", "bar")
+	msgA.MetaSet("other", "nope")
+
+	batch := service.MessageBatch{
+		msgA,
+		service.NewMessage([]byte(`{"user":"b","n":2}`)),
+		service.NewMessage([]byte(`{"user":"a","n":3}`)),
+		service.NewMessage([]byte(`{"n":4}`)),
+	}
+
+	batches, err := w.toEventHubsBatches(batch, eventHubsDefaultMaxMessageSize)
+	require.NoError(t, err)
+	require.Len(t, batches, 3)
+
+	decode := func(b *amqp.Message) (key string, bodies []string, props []map[string]interface{}) {
+		assert.Equal(t, uint32(eventHubsBatchFormat), b.Format)
+		key, _ = b.Annotations["x-opt-partition-key"].(string)
+		for _, data := range b.Data {
+			var e amqp.Message
+			require.NoError(t, e.UnmarshalBinary(data))
+			bodies = append(bodies, string(e.GetData()))
+			props = append(props, e.ApplicationProperties)
+		}
+		return
+	}
+
+	key, bodies, props := decode(batches[0])
+	assert.Equal(t, "a", key)
+	assert.Equal(t, []string{`{"user":"a","n":1}`, `{"user":"a","n":3}`}, bodies)
+	assert.Equal(t, map[string]interface{}{"prop_foo": "bar"}, props[0])
+	assert.Nil(t, props[1])
+
+	key, bodies, _ = decode(batches[1])
+	assert.Equal(t, "b", key)
+	assert.Equal(t, []string{`{"user":"b","n":2}`}, bodies)
+
+	key, bodies, _ = decode(batches[2])
+	assert.Equal(t, "", key)
+	assert.Equal(t, []string{`{"n":4}`}, bodies)
+
+	// Batches that exceed the maximum size are split.
+	batches, err = w.toEventHubsBatches(batch[:3], eventHubsBatchOverhead+100)
+	require.NoError(t, err)
+	require.Len(t, batches, 3)
+
+	_, err = w.toEventHubsBatches(batch, eventHubsBatchOverhead+10)
+	require.EqualError(t, err, "message 0: size 86 exceeds the maximum message size of the event hub")
+}
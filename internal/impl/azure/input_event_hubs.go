@@ -0,0 +1,554 @@
+package azure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/Azure/go-amqp"
+	"github.com/gofrs/uuid"
+
+	"github.com/benthosdev/benthos/v4/internal/checkpoint"
+	"github.com/benthosdev/benthos/v4/internal/shutdown"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func eventHubsInputConfig() *service.ConfigSpec {
+	spec := service.NewConfigSpec().
+		Beta().
+		Categories("Services", "Azure").
+		Version("4.3.0").
+		Summary("Consumes events from the partitions of an Azure Event Hub with the native AMQP protocol.").
+		Description(`
+Events are consumed from all partitions of an Event Hub as a member of a consumer group. Messages of the same partition are processed in order, and the position of each partition is checkpointed once all messages up to that position have been delivered, which gives at-least-once delivery guarantees.
+
+### Checkpoint Store
+
+When a ` + "`checkpoint_store`" + ` is configured the partitions of the Event Hub are balanced between all consumers of the consumer group that share the same store, and the positions of partitions are periodically persisted within it so that consumption resumes from where it left off after a restart. Partition ownership and checkpoints are stored as blobs within an Azure Blob Storage container, using the same layout as the official Event Hubs SDKs, and therefore consumers written with those SDKs can share a consumer group with Benthos.
+
+Without a checkpoint store all partitions are consumed by this input and positions are not persisted, and so consumption starts according to ` + "`start_from_oldest`" + ` each time the input connects.
+
+### Metadata
+
+This input adds the following metadata fields to each message:
+
+` + "```" + `
+- event_hubs_partition_id
+- event_hubs_offset
+- event_hubs_sequence_number
+- event_hubs_enqueued_time
+- event_hubs_partition_key
+- All application properties
+` + "```" + `
+
+You can access these metadata fields using [function interpolation](/docs/configuration/interpolation#metadata).`)
+
+	for _, f := range eventHubsConnectionFields() {
+		spec = spec.Field(f)
+	}
+
+	return spec.
+		Field(service.NewStringField("consumer_group").
+			Description("The consumer group to consume as.").
+			Default("$Default")).
+		Field(service.NewObjectField("checkpoint_store",
+			service.NewStringField("storage_connection_string").
+				Description("A connection string of the Azure Storage account in which to store partition ownership and checkpoints."),
+			service.NewStringField("container").
+				Description("The name of a blob container in which to store partition ownership and checkpoints, which is created if it does not exist."),
+		).Description("An optional Azure Blob Storage checkpoint store, used in order to balance partitions between consumers and to persist the positions of partitions.").Optional()).
+		Field(service.NewBoolField("start_from_oldest").
+			Description("Whether to consume from the oldest available event of partitions without a checkpoint, otherwise only events that are enqueued after the input connects are consumed.").
+			Default(true)).
+		Field(service.NewIntField("checkpoint_limit").
+			Description("Determines how many messages of the same partition can be processed in parallel before applying back pressure. A checkpoint is only committed once all messages of prior positions have also been delivered, reducing the limit reduces the number of duplicates in the event of crashes.").
+			Advanced().
+			Default(1024)).
+		Field(service.NewDurationField("commit_period").
+			Description("The period of time between each commit of partition checkpoints to the checkpoint store.").
+			Advanced().
+			Default("5s")).
+		Field(service.NewDurationField("load_balancing_interval").
+			Description("The period of time between each attempt to renew and balance the ownership of partitions.").
+			Advanced().
+			Default("10s")).
+		Field(service.NewDurationField("ownership_expiry").
+			Description("The period of time after which the ownership of a partition that has not been renewed is considered expired, allowing other consumers to claim it. This should be a multiple of the `load_balancing_interval`.").
+			Advanced().
+			Default("60s")).
+		Field(service.NewIntField("prefetch_count").
+			Description("The maximum number of events to request from each partition at a time.").
+			Advanced().
+			Default(300))
+}
+
+func init() {
+	err := service.RegisterInput(
+		"azure_event_hubs", eventHubsInputConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Input, error) {
+			r, err := newEventHubsReaderFromParsed(conf, mgr.Logger())
+			if err != nil {
+				return nil, err
+			}
+			return service.AutoRetryNacks(r), nil
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type eventHubsPendingMsg struct {
+	msg   *service.Message
+	onAck func()
+}
+
+// eventHubsPartitionConsumer tracks the messages of a partition that are in
+// flight, and the last checkpoint committed for it.
+type eventHubsPartitionConsumer struct {
+	checkpoints *checkpoint.Capped
+	committed   *eventHubsCheckpoint
+
+	cancel func()
+	done   chan struct{}
+}
+
+type eventHubsReader struct {
+	conf            eventHubsConfig
+	consumerGroup   string
+	startFromOldest bool
+	checkpointLimit int
+	commitPeriod    time.Duration
+	balanceInterval time.Duration
+	ownershipExpiry time.Duration
+	prefetchCount   int
+	ownerID         string
+
+	// Opens the checkpoint store, which is nil when a store is not configured
+	// and is replaced in tests.
+	openStore func() (eventHubsCheckpointStore, error)
+
+	msgChan atomic.Value
+	log     *service.Logger
+	shutSig *shutdown.Signaller
+}
+
+func newEventHubsReaderFromParsed(conf *service.ParsedConfig, log *service.Logger) (*eventHubsReader, error) {
+	r := &eventHubsReader{
+		log:     log,
+		shutSig: shutdown.NewSignaller(),
+	}
+
+	var err error
+	if r.conf, err = eventHubsConfigFromParsed(conf); err != nil {
+		return nil, err
+	}
+	if r.consumerGroup, err = conf.FieldString("consumer_group"); err != nil {
+		return nil, err
+	}
+	if r.startFromOldest, err = conf.FieldBool("start_from_oldest"); err != nil {
+		return nil, err
+	}
+	if r.checkpointLimit, err = conf.FieldInt("checkpoint_limit"); err != nil {
+		return nil, err
+	}
+	if r.checkpointLimit < 1 {
+		return nil, errors.New("checkpoint_limit must be greater than zero")
+	}
+	if r.commitPeriod, err = conf.FieldDuration("commit_period"); err != nil {
+		return nil, err
+	}
+	if r.balanceInterval, err = conf.FieldDuration("load_balancing_interval"); err != nil {
+		return nil, err
+	}
+	if r.ownershipExpiry, err = conf.FieldDuration("ownership_expiry"); err != nil {
+		return nil, err
+	}
+	if r.ownershipExpiry <= r.balanceInterval {
+		return nil, errors.New("ownership_expiry must be greater than load_balancing_interval")
+	}
+	if r.prefetchCount, err = conf.FieldInt("prefetch_count"); err != nil {
+		return nil, err
+	}
+	if r.prefetchCount < 1 {
+		return nil, errors.New("prefetch_count must be greater than zero")
+	}
+
+	if conf.Contains("checkpoint_store") {
+		storeConf := conf.Namespace("checkpoint_store")
+		storageConnStr, err := storeConf.FieldString("storage_connection_string")
+		if err != nil {
+			return nil, err
+		}
+		container, err := storeConf.FieldString("container")
+		if err != nil {
+			return nil, err
+		}
+		r.openStore = func() (eventHubsCheckpointStore, error) {
+			return newEventHubsBlobStore(storageConnStr, container, r.conf, r.consumerGroup)
+		}
+	}
+
+	id, err := uuid.NewV4()
+	if err != nil {
+		return nil, err
+	}
+	r.ownerID = id.String()
+	return r, nil
+}
+
+func (r *eventHubsReader) getMsgChan() chan eventHubsPendingMsg {
+	c, _ := r.msgChan.Load().(chan eventHubsPendingMsg)
+	return c
+}
+
+func (r *eventHubsReader) storeMsgChan(c chan eventHubsPendingMsg) {
+	r.msgChan.Store(c)
+}
+
+//------------------------------------------------------------------------------
+
+func (r *eventHubsReader) Connect(ctx context.Context) error {
+	if r.getMsgChan() != nil {
+		return nil
+	}
+
+	if r.shutSig.ShouldCloseAtLeisure() {
+		r.shutSig.ShutdownComplete()
+		return service.ErrEndOfInput
+	}
+
+	var store eventHubsCheckpointStore
+	if r.openStore != nil {
+		var err error
+		if store, err = r.openStore(); err != nil {
+			return fmt.Errorf("failed to open checkpoint store: %w", err)
+		}
+	}
+
+	conn, err := r.conf.dial()
+	if err != nil {
+		return err
+	}
+
+	partitionIDs, err := conn.partitionIDs(ctx, r.conf.hub)
+	if err != nil {
+		conn.close(ctx)
+		return fmt.Errorf("failed to obtain partitions: %w", err)
+	}
+
+	msgChan := make(chan eventHubsPendingMsg)
+	go r.loop(conn, store, partitionIDs, msgChan)
+
+	r.storeMsgChan(msgChan)
+	r.log.Infof("Receiving events from Azure Event Hub: %v", r.conf.hub)
+	return nil
+}
+
+// loop manages the partitions consumed by this input, balancing them with
+// other consumers and committing their checkpoints when a checkpoint store is
+// configured, until the input is closed or a partition fails.
+func (r *eventHubsReader) loop(conn *eventHubsConn, store eventHubsCheckpointStore, partitionIDs []string, msgChan chan eventHubsPendingMsg) {
+	consumers := map[string]*eventHubsPartitionConsumer{}
+	errChan := make(chan error, 1)
+
+	commit := func(id string, c *eventHubsPartitionConsumer) {
+		if store == nil {
+			return
+		}
+		cp, ok := c.checkpoints.Highest().(eventHubsCheckpoint)
+		if !ok || (c.committed != nil && c.committed.sequenceNumber == cp.sequenceNumber) {
+			return
+		}
+		if err := store.updateCheckpoint(cp); err != nil {
+			r.log.Errorf("Failed to commit checkpoint of partition %v: %v", id, err)
+			return
+		}
+		c.committed = &cp
+	}
+
+	stop := func(id string) {
+		c := consumers[id]
+		c.cancel()
+		<-c.done
+		commit(id, c)
+		delete(consumers, id)
+	}
+
+	defer func() {
+		for id := range consumers {
+			stop(id)
+		}
+		if store != nil {
+			r.releaseOwnership(store)
+		}
+		ctx, done := context.WithTimeout(context.Background(), time.Second*5)
+		conn.close(ctx)
+		done()
+
+		r.storeMsgChan(nil)
+		close(msgChan)
+		if r.shutSig.ShouldCloseAtLeisure() {
+			r.shutSig.ShutdownComplete()
+		}
+	}()
+
+	closeCtx, done := r.shutSig.CloseAtLeisureCtx(context.Background())
+	defer done()
+
+	start := func(id string, from *eventHubsCheckpoint) {
+		ctx, cancel := context.WithCancel(closeCtx)
+		c := &eventHubsPartitionConsumer{
+			checkpoints: checkpoint.NewCapped(int64(r.checkpointLimit)),
+			committed:   from,
+			cancel:      cancel,
+			done:        make(chan struct{}),
+		}
+		consumers[id] = c
+		go func() {
+			defer close(c.done)
+			if err := r.consumePartition(ctx, conn, id, from, c.checkpoints, msgChan); err != nil && ctx.Err() == nil {
+				select {
+				case errChan <- fmt.Errorf("partition %v: %w", id, err):
+				default:
+				}
+			}
+		}()
+	}
+
+	balance := func() error {
+		if store == nil {
+			if len(consumers) == 0 {
+				for _, id := range partitionIDs {
+					start(id, nil)
+				}
+			}
+			return nil
+		}
+
+		ownerships, err := store.listOwnership()
+		if err != nil {
+			return fmt.Errorf("failed to list partition ownership: %w", err)
+		}
+		claims := eventHubsPartitionsToClaim(r.ownerID, partitionIDs, ownerships, time.Now(), r.ownershipExpiry)
+
+		claimed, err := store.claimOwnership(claims)
+		if err != nil {
+			r.log.Errorf("Failed to claim partition ownership: %v", err)
+		}
+
+		owned := map[string]struct{}{}
+		for _, o := range claimed {
+			owned[o.partitionID] = struct{}{}
+		}
+		for id := range consumers {
+			if _, exists := owned[id]; !exists {
+				r.log.Debugf("Releasing partition %v", id)
+				stop(id)
+			}
+		}
+
+		var checkpoints map[string]eventHubsCheckpoint
+		for id := range owned {
+			if _, exists := consumers[id]; exists {
+				continue
+			}
+			if checkpoints == nil {
+				if checkpoints, err = store.listCheckpoints(); err != nil {
+					return fmt.Errorf("failed to list checkpoints: %w", err)
+				}
+			}
+			r.log.Debugf("Claimed partition %v", id)
+			if cp, exists := checkpoints[id]; exists {
+				start(id, &cp)
+			} else {
+				start(id, nil)
+			}
+		}
+		return nil
+	}
+
+	if err := balance(); err != nil {
+		r.log.Errorf("Failed to balance partitions: %v", err)
+	}
+
+	var balanceChan, commitChan <-chan time.Time
+	if store != nil {
+		balanceTicker := time.NewTicker(r.balanceInterval)
+		defer balanceTicker.Stop()
+		balanceChan = balanceTicker.C
+
+		commitTicker := time.NewTicker(r.commitPeriod)
+		defer commitTicker.Stop()
+		commitChan = commitTicker.C
+	}
+
+	for {
+		select {
+		case <-balanceChan:
+			if err := balance(); err != nil {
+				r.log.Errorf("Failed to balance partitions: %v", err)
+			}
+		case <-commitChan:
+			for id, c := range consumers {
+				commit(id, c)
+			}
+		case err := <-errChan:
+			r.log.Errorf("Failed to receive events: %v", err)
+			return
+		case <-closeCtx.Done():
+			return
+		}
+	}
+}
+
+// releaseOwnership relinquishes the partitions owned by this input so that
+// other consumers are able to claim them without waiting for them to expire.
+func (r *eventHubsReader) releaseOwnership(store eventHubsCheckpointStore) {
+	ownerships, err := store.listOwnership()
+	if err != nil {
+		r.log.Errorf("Failed to release partition ownership: %v", err)
+		return
+	}
+	var releases []eventHubsOwnership
+	for _, o := range ownerships {
+		if o.ownerID == r.ownerID {
+			o.ownerID = ""
+			releases = append(releases, o)
+		}
+	}
+	if _, err := store.claimOwnership(releases); err != nil {
+		r.log.Errorf("Failed to release partition ownership: %v", err)
+	}
+}
+
+func (r *eventHubsReader) consumePartition(
+	ctx context.Context,
+	conn *eventHubsConn,
+	partitionID string,
+	from *eventHubsCheckpoint,
+	checkpoints *checkpoint.Capped,
+	msgChan chan<- eventHubsPendingMsg,
+) error {
+	offset := "@latest"
+	if from != nil {
+		offset = from.offset
+	} else if r.startFromOldest {
+		offset = "-1"
+	}
+
+	receiver, err := conn.session.NewReceiver(
+		amqp.LinkSourceAddress(fmt.Sprintf("%v/ConsumerGroups/%v/Partitions/%v", r.conf.hub, r.consumerGroup, partitionID)),
+		amqp.LinkSelectorFilter(fmt.Sprintf("amqp.annotation.x-opt-offset > '%v'", offset)),
+		amqp.LinkSenderSettle(amqp.ModeSettled),
+		amqp.LinkCredit(uint32(r.prefetchCount)),
+	)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		closeCtx, done := context.WithTimeout(context.Background(), time.Second*5)
+		_ = receiver.Close(closeCtx)
+		done()
+	}()
+
+	for {
+		amqpMsg, err := receiver.Receive(ctx)
+		if err != nil {
+			return err
+		}
+
+		msg, cp := eventHubsMessageFromAMQP(partitionID, amqpMsg)
+		release, err := checkpoints.Track(ctx, cp, 1)
+		if err != nil {
+			return err
+		}
+
+		select {
+		case msgChan <- eventHubsPendingMsg{
+			msg: msg,
+			onAck: func() {
+				_ = release()
+			},
+		}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func eventHubsMessageFromAMQP(partitionID string, amqpMsg *amqp.Message) (*service.Message, eventHubsCheckpoint) {
+	msg := service.NewMessage(amqpMsg.GetData())
+
+	for k, v := range amqpMsg.ApplicationProperties {
+		msg.MetaSet(k, fmt.Sprintf("%v", v))
+	}
+
+	cp := eventHubsCheckpoint{partitionID: partitionID}
+	msg.MetaSet("event_hubs_partition_id", partitionID)
+
+	switch offset := amqpMsg.Annotations["x-opt-offset"].(type) {
+	case string:
+		cp.offset = offset
+	case int64:
+		cp.offset = strconv.FormatInt(offset, 10)
+	}
+	msg.MetaSet("event_hubs_offset", cp.offset)
+
+	if seq, ok := amqpMsg.Annotations["x-opt-sequence-number"].(int64); ok {
+		cp.sequenceNumber = seq
+		msg.MetaSet("event_hubs_sequence_number", strconv.FormatInt(seq, 10))
+	}
+	if enqueued, ok := amqpMsg.Annotations["x-opt-enqueued-time"].(time.Time); ok {
+		msg.MetaSet("event_hubs_enqueued_time", enqueued.Format(time.RFC3339Nano))
+	}
+	if key, ok := amqpMsg.Annotations["x-opt-partition-key"].(string); ok {
+		msg.MetaSet("event_hubs_partition_key", key)
+	}
+	return msg, cp
+}
+
+func (r *eventHubsReader) Read(ctx context.Context) (*service.Message, service.AckFunc, error) {
+	msgChan := r.getMsgChan()
+	if msgChan == nil {
+		return nil, nil, service.ErrNotConnected
+	}
+
+	var pending eventHubsPendingMsg
+	var open bool
+	select {
+	case pending, open = <-msgChan:
+		if !open {
+			return nil, nil, service.ErrNotConnected
+		}
+	case <-ctx.Done():
+		return nil, nil, ctx.Err()
+	}
+
+	return pending.msg, func(ctx context.Context, res error) error {
+		// Res will always be nil because we initialize with service.AutoRetryNacks
+		pending.onAck()
+		return nil
+	}, nil
+}
+
+func (r *eventHubsReader) Close(ctx context.Context) error {
+	go func() {
+		r.shutSig.CloseAtLeisure()
+		if r.getMsgChan() == nil {
+			// If the message chan is already nil then we might've not been
+			// connected, so force the shutdown complete signal.
+			r.shutSig.ShutdownComplete()
+		}
+	}()
+	select {
+	case <-r.shutSig.HasClosedChan():
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return nil
+}
@@ -0,0 +1,481 @@
+package azure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/messaging/azservicebus"
+
+	"github.com/benthosdev/benthos/v4/internal/shutdown"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func serviceBusInputConfig() *service.ConfigSpec {
+	spec := service.NewConfigSpec().
+		Beta().
+		Categories("Services", "Azure").
+		Version("4.3.0").
+		Summary("Consumes messages from an Azure Service Bus queue or topic subscription.").
+		Description(`
+Messages are received in peek-lock mode, and are completed once they have been successfully delivered by Benthos. Messages that are rejected (nacked) are abandoned, which makes them available to be received again until the maximum delivery count of the entity is reached, at which point Service Bus moves them to the dead-letter queue. Alternatively, rejected messages can be moved to the dead-letter queue immediately by setting ` + "`dead_letter_on_nack`" + ` to ` + "`true`" + `.
+
+The locks of messages (or sessions) that are in flight are periodically renewed according to the field ` + "`lock_renewal_period`" + `, which prevents Service Bus from redelivering messages that take a long time to process.
+
+The messages of a dead-letter queue can be consumed by setting ` + "`dead_letter_queue`" + ` to ` + "`true`" + `, which is useful for reprocessing messages that previously failed.
+
+### Sessions
+
+Entities that require sessions can be consumed by setting ` + "`sessions.enabled`" + ` to ` + "`true`" + `. When a ` + "`sessions.session_id`" + ` is specified only the messages of that session are consumed, otherwise the next available session is accepted, and once no messages have been received from it for the duration of ` + "`sessions.idle_timeout`" + ` (and all of its in-flight messages are settled) it is released and the next available session is accepted.
+
+### Metadata
+
+This input adds the following metadata fields to each message:
+
+` + "```" + `
+- service_bus_message_id
+- service_bus_sequence_number
+- service_bus_enqueued_time
+- service_bus_delivery_count
+- service_bus_session_id
+- service_bus_correlation_id
+- service_bus_content_type
+- service_bus_subject
+- service_bus_dead_letter_reason
+- service_bus_dead_letter_description
+- All application properties
+` + "```" + `
+
+You can access these metadata fields using [function interpolation](/docs/configuration/interpolation#metadata).
+` + serviceBusAuthDescription)
+
+	for _, f := range serviceBusConnectionFields() {
+		spec = spec.Field(f)
+	}
+
+	return spec.
+		Field(service.NewStringField("queue").
+			Description("The name of a queue to consume from, either this or `topic` and `subscription` must be set.").
+			Default("")).
+		Field(service.NewStringField("topic").
+			Description("The name of a topic to consume from, which requires a `subscription`.").
+			Default("")).
+		Field(service.NewStringField("subscription").
+			Description("The name of the topic subscription to consume from.").
+			Default("")).
+		Field(service.NewBoolField("dead_letter_queue").
+			Description("Whether to consume from the dead-letter queue of the queue or subscription rather than the entity itself.").
+			Advanced().
+			Default(false)).
+		Field(service.NewObjectField("sessions",
+			service.NewBoolField("enabled").
+				Description("Whether the entity requires sessions.").
+				Default(false),
+			service.NewStringField("session_id").
+				Description("An optional session to consume from, when empty the next available session is accepted.").
+				Default(""),
+			service.NewDurationField("idle_timeout").
+				Description("The period of time without receiving any messages after which an accepted session is released in order to accept the next available session. Ignored when a `session_id` is specified.").
+				Default("30s"),
+		).Description("Settings for consuming from entities that require sessions.").Advanced()).
+		Field(service.NewIntField("prefetch_count").
+			Description("The maximum number of messages to request from Service Bus at a time.").
+			Default(10)).
+		Field(service.NewDurationField("lock_renewal_period").
+			Description("The period at which the locks of in-flight messages (or sessions) are renewed, which should be less than the lock duration of the entity. Set to `0s` in order to disable lock renewal.").
+			Advanced().
+			Default("30s")).
+		Field(service.NewBoolField("dead_letter_on_nack").
+			Description("Whether messages that are rejected should be moved to the dead-letter queue immediately rather than abandoned.").
+			Advanced().
+			Default(false))
+}
+
+func init() {
+	err := service.RegisterInput(
+		"azure_service_bus", serviceBusInputConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Input, error) {
+			return newServiceBusReaderFromParsed(conf, mgr.Logger())
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// serviceBusReceiver is implemented by both the receivers and session receivers
+// of the Service Bus client.
+type serviceBusReceiver interface {
+	ReceiveMessages(ctx context.Context, maxMessages int, options *azservicebus.ReceiveMessagesOptions) ([]*azservicebus.ReceivedMessage, error)
+	CompleteMessage(ctx context.Context, msg *azservicebus.ReceivedMessage) error
+	AbandonMessage(ctx context.Context, msg *azservicebus.ReceivedMessage, options *azservicebus.AbandonMessageOptions) error
+	DeadLetterMessage(ctx context.Context, msg *azservicebus.ReceivedMessage, options *azservicebus.DeadLetterOptions) error
+	RenewMessageLock(ctx context.Context, msg *azservicebus.ReceivedMessage) error
+	Close(ctx context.Context) error
+}
+
+type serviceBusSessionLocker interface {
+	RenewSessionLock(ctx context.Context) error
+}
+
+type serviceBusReader struct {
+	clientConf         serviceBusClientConfig
+	queue              string
+	topic              string
+	subscription       string
+	subQueue           azservicebus.SubQueue
+	sessions           bool
+	sessionID          string
+	sessionIdleTimeout time.Duration
+	prefetchCount      int
+	lockRenewalPeriod  time.Duration
+	deadLetterOnNack   bool
+
+	// Opens a receiver, which is replaced in tests.
+	openReceiver func(ctx context.Context) (serviceBusReceiver, error)
+
+	client   *azservicebus.Client
+	receiver serviceBusReceiver
+	pending  []*azservicebus.ReceivedMessage
+	inFlight map[*azservicebus.ReceivedMessage]serviceBusReceiver
+	mut      sync.Mutex
+
+	log     *service.Logger
+	shutSig *shutdown.Signaller
+}
+
+func newServiceBusReaderFromParsed(conf *service.ParsedConfig, log *service.Logger) (*serviceBusReader, error) {
+	r := &serviceBusReader{
+		inFlight: map[*azservicebus.ReceivedMessage]serviceBusReceiver{},
+		log:      log,
+		shutSig:  shutdown.NewSignaller(),
+	}
+	r.openReceiver = r.open
+
+	var err error
+	if r.clientConf, err = serviceBusClientConfigFromParsed(conf); err != nil {
+		return nil, err
+	}
+	if r.queue, r.topic, err = serviceBusEntityFromParsed(conf); err != nil {
+		return nil, err
+	}
+	if r.subscription, err = conf.FieldString("subscription"); err != nil {
+		return nil, err
+	}
+	if (r.topic == "") != (r.subscription == "") {
+		return nil, errors.New("a subscription must be specified when consuming from a topic, and only then")
+	}
+
+	deadLetterQueue, err := conf.FieldBool("dead_letter_queue")
+	if err != nil {
+		return nil, err
+	}
+	if deadLetterQueue {
+		r.subQueue = azservicebus.SubQueueDeadLetter
+	}
+
+	if r.sessions, err = conf.FieldBool("sessions", "enabled"); err != nil {
+		return nil, err
+	}
+	if r.sessionID, err = conf.FieldString("sessions", "session_id"); err != nil {
+		return nil, err
+	}
+	if r.sessionIdleTimeout, err = conf.FieldDuration("sessions", "idle_timeout"); err != nil {
+		return nil, err
+	}
+	if r.sessions && deadLetterQueue {
+		return nil, errors.New("dead-letter queues cannot be consumed with sessions")
+	}
+
+	if r.prefetchCount, err = conf.FieldInt("prefetch_count"); err != nil {
+		return nil, err
+	}
+	if r.prefetchCount < 1 {
+		return nil, errors.New("prefetch_count must be greater than zero")
+	}
+	if r.lockRenewalPeriod, err = conf.FieldDuration("lock_renewal_period"); err != nil {
+		return nil, err
+	}
+	if r.deadLetterOnNack, err = conf.FieldBool("dead_letter_on_nack"); err != nil {
+		return nil, err
+	}
+	if r.deadLetterOnNack && deadLetterQueue {
+		return nil, errors.New("messages consumed from a dead-letter queue cannot be dead-lettered on nack")
+	}
+
+	if r.lockRenewalPeriod > 0 {
+		go r.renewLoop()
+	}
+	return r, nil
+}
+
+func (r *serviceBusReader) open(ctx context.Context) (serviceBusReceiver, error) {
+	if r.client == nil {
+		client, err := r.clientConf.newClient()
+		if err != nil {
+			return nil, err
+		}
+		r.client = client
+	}
+
+	if !r.sessions {
+		opts := &azservicebus.ReceiverOptions{
+			ReceiveMode: azservicebus.ReceiveModePeekLock,
+			SubQueue:    r.subQueue,
+		}
+		if r.queue != "" {
+			return r.client.NewReceiverForQueue(r.queue, opts)
+		}
+		return r.client.NewReceiverForSubscription(r.topic, r.subscription, opts)
+	}
+
+	opts := &azservicebus.SessionReceiverOptions{
+		ReceiveMode: azservicebus.ReceiveModePeekLock,
+	}
+	switch {
+	case r.queue != "" && r.sessionID != "":
+		return r.client.AcceptSessionForQueue(ctx, r.queue, r.sessionID, opts)
+	case r.queue != "":
+		return r.client.AcceptNextSessionForQueue(ctx, r.queue, opts)
+	case r.sessionID != "":
+		return r.client.AcceptSessionForSubscription(ctx, r.topic, r.subscription, r.sessionID, opts)
+	}
+	return r.client.AcceptNextSessionForSubscription(ctx, r.topic, r.subscription, opts)
+}
+
+func (r *serviceBusReader) Connect(ctx context.Context) error {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	if r.receiver != nil {
+		return nil
+	}
+
+	recv, err := r.openReceiver(ctx)
+	if err != nil {
+		return err
+	}
+	r.receiver = recv
+
+	if s, ok := recv.(*azservicebus.SessionReceiver); ok {
+		r.log.Infof("Receiving Azure Service Bus messages from session: %v\n", s.SessionID())
+	} else {
+		r.log.Infof("Receiving Azure Service Bus messages from entity: %v\n", r.entityName())
+	}
+	return nil
+}
+
+func (r *serviceBusReader) entityName() string {
+	if r.queue != "" {
+		return r.queue
+	}
+	return r.topic + "/" + r.subscription
+}
+
+// releaseIdleSession closes the current receiver when it has no messages in
+// flight, which results in the next available session being accepted on
+// reconnect.
+func (r *serviceBusReader) releaseIdleSession(ctx context.Context, recv serviceBusReceiver) bool {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	for _, inFlightRecv := range r.inFlight {
+		if inFlightRecv == recv {
+			return false
+		}
+	}
+	if r.receiver == recv {
+		r.receiver = nil
+	}
+	if err := recv.Close(ctx); err != nil {
+		r.log.Debugf("Failed to close idle session: %v\n", err)
+	}
+	return true
+}
+
+// receive returns the next messages from a receiver, or an empty slice if
+// either the context is cancelled or, when consuming the next available
+// session, no messages were received within the idle timeout.
+func (r *serviceBusReader) receive(ctx context.Context, recv serviceBusReceiver) ([]*azservicebus.ReceivedMessage, error) {
+	if r.sessions && r.sessionID == "" {
+		var done func()
+		ctx, done = context.WithTimeout(ctx, r.sessionIdleTimeout)
+		defer done()
+	}
+	msgs, err := recv.ReceiveMessages(ctx, r.prefetchCount, nil)
+	if err != nil && ctx.Err() != nil {
+		return nil, nil
+	}
+	return msgs, err
+}
+
+func (r *serviceBusReader) Read(ctx context.Context) (*service.Message, service.AckFunc, error) {
+	r.mut.Lock()
+	recv := r.receiver
+	r.mut.Unlock()
+
+	if recv == nil {
+		return nil, nil, service.ErrNotConnected
+	}
+
+	for len(r.pending) == 0 {
+		msgs, err := r.receive(ctx, recv)
+		if err != nil {
+			r.log.Errorf("Failed to receive messages: %v\n", err)
+			r.mut.Lock()
+			if r.receiver == recv {
+				r.receiver = nil
+				_ = recv.Close(ctx)
+			}
+			r.mut.Unlock()
+			return nil, nil, service.ErrNotConnected
+		}
+		if len(msgs) > 0 {
+			r.pending = msgs
+			break
+		}
+		if ctx.Err() != nil {
+			return nil, nil, ctx.Err()
+		}
+		if r.sessions && r.sessionID == "" && r.releaseIdleSession(ctx, recv) {
+			return nil, nil, service.ErrNotConnected
+		}
+	}
+
+	sbMsg := r.pending[0]
+	r.pending = r.pending[1:]
+
+	body, err := sbMsg.Body()
+	if err != nil {
+		// Messages with unsupported bodies are left for lock expiry rather than
+		// completed, as they would otherwise be lost.
+		return nil, nil, fmt.Errorf("failed to read message body: %w", err)
+	}
+
+	r.mut.Lock()
+	r.inFlight[sbMsg] = recv
+	r.mut.Unlock()
+
+	return serviceBusMessage(body, sbMsg), r.ackFunc(recv, sbMsg), nil
+}
+
+func (r *serviceBusReader) ackFunc(recv serviceBusReceiver, sbMsg *azservicebus.ReceivedMessage) service.AckFunc {
+	return func(ctx context.Context, err error) error {
+		r.mut.Lock()
+		delete(r.inFlight, sbMsg)
+		r.mut.Unlock()
+
+		if err == nil {
+			return recv.CompleteMessage(ctx, sbMsg)
+		}
+		if r.deadLetterOnNack {
+			reason, desc := "Rejected", err.Error()
+			return recv.DeadLetterMessage(ctx, sbMsg, &azservicebus.DeadLetterOptions{
+				Reason:           &reason,
+				ErrorDescription: &desc,
+			})
+		}
+		return recv.AbandonMessage(ctx, sbMsg, nil)
+	}
+}
+
+func serviceBusMessage(body []byte, sbMsg *azservicebus.ReceivedMessage) *service.Message {
+	msg := service.NewMessage(body)
+
+	for k, v := range sbMsg.ApplicationProperties {
+		msg.MetaSet(k, fmt.Sprintf("%v", v))
+	}
+
+	msg.MetaSet("service_bus_message_id", sbMsg.MessageID)
+	msg.MetaSet("service_bus_delivery_count", strconv.FormatUint(uint64(sbMsg.DeliveryCount), 10))
+	if sbMsg.SequenceNumber != nil {
+		msg.MetaSet("service_bus_sequence_number", strconv.FormatInt(*sbMsg.SequenceNumber, 10))
+	}
+	if sbMsg.EnqueuedTime != nil {
+		msg.MetaSet("service_bus_enqueued_time", sbMsg.EnqueuedTime.Format(time.RFC3339Nano))
+	}
+	for k, v := range map[string]*string{
+		"service_bus_session_id":              sbMsg.SessionID,
+		"service_bus_correlation_id":          sbMsg.CorrelationID,
+		"service_bus_content_type":            sbMsg.ContentType,
+		"service_bus_subject":                 sbMsg.Subject,
+		"service_bus_dead_letter_reason":      sbMsg.DeadLetterReason,
+		"service_bus_dead_letter_description": sbMsg.DeadLetterErrorDescription,
+	} {
+		if v != nil {
+			msg.MetaSet(k, *v)
+		}
+	}
+	return msg
+}
+
+func (r *serviceBusReader) renewLocks(ctx context.Context) {
+	r.mut.Lock()
+	inFlight := make(map[*azservicebus.ReceivedMessage]serviceBusReceiver, len(r.inFlight))
+	for k, v := range r.inFlight {
+		inFlight[k] = v
+	}
+	current := r.receiver
+	r.mut.Unlock()
+
+	// The locks of messages received from a session are held by the session
+	// itself, which is renewed even whilst no messages are in flight.
+	sessions := map[serviceBusReceiver]struct{}{}
+	if _, isSession := current.(serviceBusSessionLocker); isSession {
+		sessions[current] = struct{}{}
+	}
+	for sbMsg, recv := range inFlight {
+		if _, isSession := recv.(serviceBusSessionLocker); isSession {
+			sessions[recv] = struct{}{}
+			continue
+		}
+		if err := recv.RenewMessageLock(ctx, sbMsg); err != nil {
+			r.log.Warnf("Failed to renew message lock: %v\n", err)
+		}
+	}
+	for recv := range sessions {
+		if err := recv.(serviceBusSessionLocker).RenewSessionLock(ctx); err != nil {
+			r.log.Warnf("Failed to renew session lock: %v\n", err)
+		}
+	}
+}
+
+func (r *serviceBusReader) renewLoop() {
+	ctx, done := r.shutSig.CloseAtLeisureCtx(context.Background())
+	defer done()
+
+	ticker := time.NewTicker(r.lockRenewalPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			r.renewLocks(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (r *serviceBusReader) Close(ctx context.Context) error {
+	r.shutSig.CloseNow()
+
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	if r.receiver != nil {
+		_ = r.receiver.Close(ctx)
+		r.receiver = nil
+	}
+	if r.client != nil {
+		if err := r.client.Close(ctx); err != nil {
+			return err
+		}
+		r.client = nil
+	}
+	return nil
+}
@@ -0,0 +1,269 @@
+package azure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/Azure/go-amqp"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	// The message format of a batch of Event Hubs events.
+	eventHubsBatchFormat = 0x80013700
+
+	// The maximum size of an Event Hubs message used when the link does not
+	// advertise one.
+	eventHubsDefaultMaxMessageSize = 1024 * 1024
+
+	// The number of bytes reserved within each batch for the envelope and the
+	// framing of events.
+	eventHubsBatchOverhead = 1024
+)
+
+func eventHubsOutputConfig() *service.ConfigSpec {
+	spec := service.NewConfigSpec().
+		Beta().
+		Categories("Services", "Azure").
+		Version("4.3.0").
+		Summary("Sends messages to an Azure Event Hub with the native AMQP protocol.").
+		Description(`
+The messages of a batch are sent as few Event Hubs batches as possible, where a batch that exceeds the maximum message size allowed by the Event Hub is split.
+
+When a ` + "`partition_key`" + ` is set the messages of a batch are grouped by their partition key, and Event Hubs ensures that all events with the same partition key are stored within the same partition, otherwise events are distributed between partitions.
+
+### Metadata
+
+Metadata fields can be added to messages as application properties by configuring the field ` + "`metadata`" + `.`)
+
+	for _, f := range eventHubsConnectionFields() {
+		spec = spec.Field(f)
+	}
+
+	return spec.
+		Field(service.NewInterpolatedStringField("partition_key").
+			Description("An optional key of each message that determines the partition that it is stored in. Messages where this results in an empty string are distributed between partitions.").
+			Example(`${! json("user.id") }`).
+			Optional()).
+		Field(service.NewMetadataFilterField("metadata").
+			Description("Determine which (if any) metadata values should be added to messages as application properties.").
+			Optional()).
+		Field(service.NewIntField("max_in_flight").
+			Description("The maximum number of batches to have in flight at a given time. Increase this to improve throughput.").
+			Default(64)).
+		Field(service.NewBatchPolicyField("batching"))
+}
+
+func init() {
+	err := service.RegisterBatchOutput(
+		"azure_event_hubs", eventHubsOutputConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (out service.BatchOutput, batchPol service.BatchPolicy, maxInFlight int, err error) {
+			if batchPol, err = conf.FieldBatchPolicy("batching"); err != nil {
+				return
+			}
+			if maxInFlight, err = conf.FieldInt("max_in_flight"); err != nil {
+				return
+			}
+			out, err = newEventHubsWriterFromParsed(conf, mgr.Logger())
+			return
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type eventHubsWriter struct {
+	conf         eventHubsConfig
+	partitionKey *service.InterpolatedString
+	metaFilter   *service.MetadataFilter
+
+	conn    *eventHubsConn
+	sender  *amqp.Sender
+	connMut sync.RWMutex
+
+	log *service.Logger
+}
+
+func newEventHubsWriterFromParsed(conf *service.ParsedConfig, log *service.Logger) (*eventHubsWriter, error) {
+	w := &eventHubsWriter{
+		log: log,
+	}
+
+	var err error
+	if w.conf, err = eventHubsConfigFromParsed(conf); err != nil {
+		return nil, err
+	}
+	if conf.Contains("partition_key") {
+		if w.partitionKey, err = conf.FieldInterpolatedString("partition_key"); err != nil {
+			return nil, err
+		}
+	}
+	if conf.Contains("metadata") {
+		if w.metaFilter, err = conf.FieldMetadataFilter("metadata"); err != nil {
+			return nil, err
+		}
+	}
+	return w, nil
+}
+
+func (w *eventHubsWriter) Connect(ctx context.Context) error {
+	w.connMut.Lock()
+	defer w.connMut.Unlock()
+
+	if w.sender != nil {
+		return nil
+	}
+
+	conn, err := w.conf.dial()
+	if err != nil {
+		return err
+	}
+
+	sender, err := conn.session.NewSender(amqp.LinkTargetAddress(w.conf.hub))
+	if err != nil {
+		conn.close(ctx)
+		return err
+	}
+
+	w.conn, w.sender = conn, sender
+	w.log.Infof("Sending Azure Event Hubs events to: %v\n", w.conf.hub)
+	return nil
+}
+
+// toEventHubsBatches converts a batch into Event Hubs batch messages, where
+// each batch message contains the events of a single partition key and does
+// not exceed a maximum size.
+func (w *eventHubsWriter) toEventHubsBatches(batch service.MessageBatch, maxSize int) ([]*amqp.Message, error) {
+	var keys []string
+	groups := map[string][]*amqp.Message{}
+	sizes := map[string]int{}
+
+	var batches []*amqp.Message
+	newBatch := func(key string, events []*amqp.Message) (*amqp.Message, error) {
+		b := &amqp.Message{Format: eventHubsBatchFormat}
+		if key != "" {
+			b.Annotations = amqp.Annotations{"x-opt-partition-key": key}
+		}
+		for _, e := range events {
+			data, err := e.MarshalBinary()
+			if err != nil {
+				return nil, err
+			}
+			b.Data = append(b.Data, data)
+		}
+		return b, nil
+	}
+
+	for i, msg := range batch {
+		body, err := msg.AsBytes()
+		if err != nil {
+			return nil, err
+		}
+
+		event := amqp.NewMessage(body)
+
+		var key string
+		if w.partitionKey != nil {
+			if key = batch.InterpolatedString(i, w.partitionKey); key != "" {
+				event.Annotations = amqp.Annotations{"x-opt-partition-key": key}
+			}
+		}
+		_ = w.metaFilter.Walk(msg, func(k, v string) error {
+			if event.ApplicationProperties == nil {
+				event.ApplicationProperties = map[string]interface{}{}
+			}
+			event.ApplicationProperties[k] = v
+			return nil
+		})
+
+		data, err := event.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		size := len(data)
+		if size+eventHubsBatchOverhead > maxSize {
+			return nil, fmt.Errorf("message %v: size %v exceeds the maximum message size of the event hub", i, size)
+		}
+
+		if _, exists := groups[key]; !exists {
+			keys = append(keys, key)
+		} else if sizes[key]+size+eventHubsBatchOverhead > maxSize {
+			b, err := newBatch(key, groups[key])
+			if err != nil {
+				return nil, err
+			}
+			batches = append(batches, b)
+			groups[key], sizes[key] = nil, 0
+		}
+		groups[key] = append(groups[key], event)
+		sizes[key] += size
+	}
+
+	for _, key := range keys {
+		if len(groups[key]) == 0 {
+			continue
+		}
+		b, err := newBatch(key, groups[key])
+		if err != nil {
+			return nil, err
+		}
+		batches = append(batches, b)
+	}
+	return batches, nil
+}
+
+func (w *eventHubsWriter) WriteBatch(ctx context.Context, batch service.MessageBatch) error {
+	w.connMut.RLock()
+	sender := w.sender
+	w.connMut.RUnlock()
+
+	if sender == nil {
+		return service.ErrNotConnected
+	}
+
+	maxSize := int(sender.MaxMessageSize())
+	if maxSize <= 0 || maxSize > eventHubsDefaultMaxMessageSize {
+		maxSize = eventHubsDefaultMaxMessageSize
+	}
+
+	batches, err := w.toEventHubsBatches(batch, maxSize)
+	if err != nil {
+		return err
+	}
+
+	for _, b := range batches {
+		if err := sender.Send(ctx, b); err != nil {
+			var dErr *amqp.DetachError
+			if errors.As(err, &dErr) && dErr.RemoteError != nil {
+				w.log.Errorf("Lost connection due to: %v\n", dErr.RemoteError)
+			} else if ctx.Err() == nil {
+				w.log.Errorf("Lost connection due to: %v\n", err)
+			} else {
+				return err
+			}
+			_ = w.Close(ctx)
+			return service.ErrNotConnected
+		}
+	}
+	return nil
+}
+
+func (w *eventHubsWriter) Close(ctx context.Context) error {
+	w.connMut.Lock()
+	defer w.connMut.Unlock()
+
+	if w.sender != nil {
+		_ = w.sender.Close(ctx)
+		w.sender = nil
+	}
+	if w.conn != nil {
+		w.conn.close(ctx)
+		w.conn = nil
+	}
+	return nil
+}
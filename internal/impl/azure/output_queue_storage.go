@@ -29,7 +29,7 @@ Sends messages to an Azure Storage Queue.`,
 		Description: output.Description(true, true, `
 Only one authentication method is required, `+"`storage_connection_string`"+` or `+"`storage_account` and `storage_access_key`"+`. If both are set then the `+"`storage_connection_string`"+` is given priority.
 
-In order to set the `+"`queue_name`"+` you can use function interpolations described [here](/docs/configuration/interpolation#bloblang-queries), which are calculated per message of a batch.`),
+In order to set the `+"`queue_name`, `ttl` and `delay`"+` you can use function interpolations described [here](/docs/configuration/interpolation#bloblang-queries), which are calculated per message of a batch.`),
 		Config: docs.FieldComponent().WithChildren(
 			docs.FieldString("storage_account", "The storage account to upload messages to. This field is ignored if `storage_connection_string` is set."),
 			docs.FieldString("storage_access_key", "The storage account access key. This field is ignored if `storage_connection_string` is set."),
@@ -39,6 +39,10 @@ In order to set the `+"`queue_name`"+` you can use function interpolations descr
 				"ttl", "The TTL of each individual message as a duration string. Defaults to 0, meaning no retention period is set",
 				"60s", "5m", "36h",
 			).IsInterpolated().Advanced(),
+			docs.FieldString(
+				"delay", "An optional duration to delay the delivery of each message by, during which it is not visible to consumers of the queue. Delays must not exceed 7 days.",
+				"30s", `${! meta("delay") }`,
+			).IsInterpolated().Advanced(),
 			docs.FieldInt("max_in_flight", "The maximum number of messages to have in flight at a given time. Increase this to improve throughput.").AtVersion("3.45.0"),
 			policy.FieldSpec(),
 		).ChildDefaultAndTypesFromStruct(output.NewAzureQueueStorageConfig()),
@@ -64,11 +68,15 @@ func newAzureQueueStorageOutput(conf output.Config, mgr bundle.NewManagement) (o
 	return batcher.NewFromConfig(conf.AzureQueueStorage.Batching, output.OnlySinglePayloads(w), mgr)
 }
 
+// The maximum delay supported by Azure Queue Storage.
+const queueStorageMaxDelay = 7 * 24 * time.Hour
+
 type azureQueueStorageWriter struct {
 	conf output.AzureQueueStorageConfig
 
 	queueName  *field.Expression
 	ttl        *field.Expression
+	delay      *field.Expression
 	serviceURL *azqueue.ServiceURL
 
 	log log.Modular
@@ -88,6 +96,9 @@ func newAzureQueueStorageWriter(conf output.AzureQueueStorageConfig, mgr bundle.
 	if s.ttl, err = mgr.BloblEnvironment().NewField(conf.TTL); err != nil {
 		return nil, fmt.Errorf("failed to parse ttl expression: %v", err)
 	}
+	if s.delay, err = mgr.BloblEnvironment().NewField(conf.Delay); err != nil {
+		return nil, fmt.Errorf("failed to parse delay expression: %v", err)
+	}
 
 	if s.queueName, err = mgr.BloblEnvironment().NewField(conf.QueueName); err != nil {
 		return nil, fmt.Errorf("failed to parse queue name expression: %v", err)
@@ -119,8 +130,19 @@ func (a *azureQueueStorageWriter) WriteWithContext(ctx context.Context, msg *mes
 			}
 			return 0
 		}()
+		var delay time.Duration
+		if delays := a.delay.String(i, msg); delays != "" {
+			var err error
+			if delay, err = time.ParseDuration(delays); err != nil {
+				a.log.Debugf("Delay must be a duration: %v\n", err)
+				return err
+			}
+			if delay < 0 || delay > queueStorageMaxDelay {
+				return fmt.Errorf("delay %v must be between 0 and %v", delay, queueStorageMaxDelay)
+			}
+		}
 		message := string(p.Get())
-		_, err := msgURL.Enqueue(ctx, message, 0, timeToLive)
+		_, err := msgURL.Enqueue(ctx, message, delay, timeToLive)
 		if err != nil {
 			if cerr, ok := err.(azqueue.StorageError); ok {
 				if cerr.ServiceCode() == azqueue.ServiceCodeQueueNotFound {
@@ -129,7 +151,7 @@ func (a *azureQueueStorageWriter) WriteWithContext(ctx context.Context, msg *mes
 					if err != nil {
 						return fmt.Errorf("error creating queue: %v", err)
 					}
-					_, err := msgURL.Enqueue(ctx, message, 0, 0)
+					_, err := msgURL.Enqueue(ctx, message, delay, timeToLive)
 					if err != nil {
 						return fmt.Errorf("error retrying to enqueue message: %v", err)
 					}
@@ -0,0 +1,34 @@
+package azure
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/component/output"
+	"github.com/benthosdev/benthos/v4/internal/manager/mock"
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+func TestQueueStorageDelayBounds(t *testing.T) {
+	conf := output.NewAzureQueueStorageConfig()
+	conf.StorageAccount = "foo"
+	conf.StorageAccessKey = "YmFy"
+	conf.QueueName = "baz"
+	conf.Delay = `${! meta("delay") }`
+
+	w, err := newAzureQueueStorageWriter(conf, mock.NewManager())
+	require.NoError(t, err)
+
+	inMsg := message.QuickBatch([][]byte{[]byte("hello world")})
+
+	inMsg.Get(0).MetaSet("delay", "-1s")
+	require.EqualError(t, w.WriteWithContext(context.Background(), inMsg), "delay -1s must be between 0 and 168h0m0s")
+
+	inMsg.Get(0).MetaSet("delay", "169h")
+	require.EqualError(t, w.WriteWithContext(context.Background(), inMsg), "delay 169h0m0s must be between 0 and 168h0m0s")
+
+	inMsg.Get(0).MetaSet("delay", "nope")
+	require.Error(t, w.WriteWithContext(context.Background(), inMsg))
+}
@@ -0,0 +1,324 @@
+package azure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/messaging/azservicebus"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func serviceBusOutputConfig() *service.ConfigSpec {
+	spec := service.NewConfigSpec().
+		Beta().
+		Categories("Services", "Azure").
+		Version("4.3.0").
+		Summary("Sends messages to an Azure Service Bus queue or topic.").
+		Description(`
+The messages of a batch are sent in as few Service Bus message batches as possible, where a batch that exceeds the maximum size allowed by the entity is split. Messages with a ` + "`scheduled_enqueue_time`" + ` are instead scheduled, and only become available to receivers at that time.
+
+Entities that require sessions can be written to by setting the field ` + "`session_id`" + `, which can be interpolated per message.
+
+### Metadata
+
+Metadata fields can be added to messages as application properties by configuring the field ` + "`metadata`" + `.
+` + serviceBusAuthDescription)
+
+	for _, f := range serviceBusConnectionFields() {
+		spec = spec.Field(f)
+	}
+
+	return spec.
+		Field(service.NewStringField("queue").
+			Description("The name of a queue to send messages to, either this or `topic` must be set.").
+			Default("")).
+		Field(service.NewStringField("topic").
+			Description("The name of a topic to send messages to.").
+			Default("")).
+		Field(service.NewInterpolatedStringField("message_id").
+			Description("An optional identifier of each message, which is used by Service Bus for duplicate detection.").
+			Example(`${! meta("id") }`).
+			Optional()).
+		Field(service.NewInterpolatedStringField("session_id").
+			Description("An optional session to send each message to, which is required by entities that have sessions enabled.").
+			Example(`${! json("user.id") }`).
+			Optional()).
+		Field(service.NewInterpolatedStringField("scheduled_enqueue_time").
+			Description("An optional time at which each message becomes available to receivers, either as an RFC 3339 timestamp or a unix timestamp in seconds. Messages where this results in an empty string are sent immediately.").
+			Example(`${! (timestamp_unix() + 600).string() }`).
+			Example(`${! meta("deliver_at").or("") }`).
+			Advanced().
+			Optional()).
+		Field(service.NewMetadataFilterField("metadata").
+			Description("Determine which (if any) metadata values should be added to messages as application properties.").
+			Optional()).
+		Field(service.NewIntField("max_in_flight").
+			Description("The maximum number of batches to have in flight at a given time. Increase this to improve throughput.").
+			Default(64)).
+		Field(service.NewBatchPolicyField("batching")).
+		Example("Ordered Sessions",
+			`
+Here we send events to a queue that requires sessions, where the session of each event is its user ID so that the events of a user are received in order:`,
+			`
+output:
+  azure_service_bus:
+    connection_string: '${SERVICE_BUS_CONNECTION_STRING}'
+    queue: events
+    session_id: '${! json("user.id") }'
+    metadata:
+      include_prefixes: [ event_ ]
+    batching:
+      count: 100
+      period: 1s
+`,
+		)
+}
+
+func init() {
+	err := service.RegisterBatchOutput(
+		"azure_service_bus", serviceBusOutputConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (out service.BatchOutput, batchPol service.BatchPolicy, maxInFlight int, err error) {
+			if batchPol, err = conf.FieldBatchPolicy("batching"); err != nil {
+				return
+			}
+			if maxInFlight, err = conf.FieldInt("max_in_flight"); err != nil {
+				return
+			}
+			out, err = newServiceBusWriterFromParsed(conf, mgr.Logger())
+			return
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type serviceBusWriter struct {
+	clientConf    serviceBusClientConfig
+	queue         string
+	topic         string
+	messageID     *service.InterpolatedString
+	sessionID     *service.InterpolatedString
+	scheduledTime *service.InterpolatedString
+	metaFilter    *service.MetadataFilter
+
+	client  *azservicebus.Client
+	sender  *azservicebus.Sender
+	connMut sync.RWMutex
+
+	log *service.Logger
+}
+
+func newServiceBusWriterFromParsed(conf *service.ParsedConfig, log *service.Logger) (*serviceBusWriter, error) {
+	w := &serviceBusWriter{
+		log: log,
+	}
+
+	var err error
+	if w.clientConf, err = serviceBusClientConfigFromParsed(conf); err != nil {
+		return nil, err
+	}
+	if w.queue, w.topic, err = serviceBusEntityFromParsed(conf); err != nil {
+		return nil, err
+	}
+	if conf.Contains("message_id") {
+		if w.messageID, err = conf.FieldInterpolatedString("message_id"); err != nil {
+			return nil, err
+		}
+	}
+	if conf.Contains("session_id") {
+		if w.sessionID, err = conf.FieldInterpolatedString("session_id"); err != nil {
+			return nil, err
+		}
+	}
+	if conf.Contains("scheduled_enqueue_time") {
+		if w.scheduledTime, err = conf.FieldInterpolatedString("scheduled_enqueue_time"); err != nil {
+			return nil, err
+		}
+	}
+	if conf.Contains("metadata") {
+		if w.metaFilter, err = conf.FieldMetadataFilter("metadata"); err != nil {
+			return nil, err
+		}
+	}
+	return w, nil
+}
+
+func (w *serviceBusWriter) Connect(ctx context.Context) error {
+	w.connMut.Lock()
+	defer w.connMut.Unlock()
+
+	if w.sender != nil {
+		return nil
+	}
+
+	client, err := w.clientConf.newClient()
+	if err != nil {
+		return err
+	}
+
+	entity := w.queue
+	if entity == "" {
+		entity = w.topic
+	}
+	sender, err := client.NewSender(entity, nil)
+	if err != nil {
+		_ = client.Close(ctx)
+		return err
+	}
+
+	w.client, w.sender = client, sender
+	w.log.Infof("Sending Azure Service Bus messages to entity: %v\n", entity)
+	return nil
+}
+
+// parseScheduledEnqueueTime parses either an RFC 3339 timestamp or a unix
+// timestamp in seconds.
+func parseScheduledEnqueueTime(s string) (time.Time, error) {
+	if secs, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(secs, 0), nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to parse scheduled enqueue time '%v': expected an RFC 3339 or unix timestamp", s)
+	}
+	return t, nil
+}
+
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// toServiceBusMessages converts a batch into Service Bus messages, where
+// messages with a scheduled enqueue time have the field ScheduledEnqueueTime
+// set.
+func (w *serviceBusWriter) toServiceBusMessages(batch service.MessageBatch) ([]*azservicebus.Message, error) {
+	sbMsgs := make([]*azservicebus.Message, len(batch))
+	for i, msg := range batch {
+		body, err := msg.AsBytes()
+		if err != nil {
+			return nil, err
+		}
+
+		sbMsg := &azservicebus.Message{Body: body}
+		if w.messageID != nil {
+			sbMsg.MessageID = optionalString(batch.InterpolatedString(i, w.messageID))
+		}
+		if w.sessionID != nil {
+			sbMsg.SessionID = optionalString(batch.InterpolatedString(i, w.sessionID))
+		}
+		if w.scheduledTime != nil {
+			if s := batch.InterpolatedString(i, w.scheduledTime); s != "" {
+				t, err := parseScheduledEnqueueTime(s)
+				if err != nil {
+					return nil, fmt.Errorf("message %v: %w", i, err)
+				}
+				sbMsg.ScheduledEnqueueTime = &t
+			}
+		}
+		_ = w.metaFilter.Walk(msg, func(key, value string) error {
+			if sbMsg.ApplicationProperties == nil {
+				sbMsg.ApplicationProperties = map[string]interface{}{}
+			}
+			sbMsg.ApplicationProperties[key] = value
+			return nil
+		})
+		sbMsgs[i] = sbMsg
+	}
+	return sbMsgs, nil
+}
+
+func (w *serviceBusWriter) WriteBatch(ctx context.Context, batch service.MessageBatch) error {
+	w.connMut.RLock()
+	sender := w.sender
+	w.connMut.RUnlock()
+
+	if sender == nil {
+		return service.ErrNotConnected
+	}
+
+	sbMsgs, err := w.toServiceBusMessages(batch)
+	if err != nil {
+		return err
+	}
+
+	var unscheduled []*azservicebus.Message
+	var scheduledTimes []time.Time
+	scheduled := map[time.Time][]*azservicebus.Message{}
+	for _, sbMsg := range sbMsgs {
+		if sbMsg.ScheduledEnqueueTime == nil {
+			unscheduled = append(unscheduled, sbMsg)
+			continue
+		}
+		t := *sbMsg.ScheduledEnqueueTime
+		if _, exists := scheduled[t]; !exists {
+			scheduledTimes = append(scheduledTimes, t)
+		}
+		scheduled[t] = append(scheduled[t], sbMsg)
+	}
+
+	if err := w.sendBatches(ctx, sender, unscheduled); err != nil {
+		return err
+	}
+	for _, t := range scheduledTimes {
+		if _, err := sender.ScheduleMessages(ctx, scheduled[t], t); err != nil {
+			return fmt.Errorf("failed to schedule messages: %w", err)
+		}
+	}
+	return nil
+}
+
+// sendBatches sends messages in as few message batches as the size limit of
+// the entity allows.
+func (w *serviceBusWriter) sendBatches(ctx context.Context, sender *azservicebus.Sender, sbMsgs []*azservicebus.Message) error {
+	if len(sbMsgs) == 0 {
+		return nil
+	}
+
+	sbBatch, err := sender.NewMessageBatch(ctx, nil)
+	if err != nil {
+		return err
+	}
+	for _, sbMsg := range sbMsgs {
+		err := sbBatch.AddMessage(sbMsg)
+		if errors.Is(err, azservicebus.ErrMessageTooLarge) && sbBatch.NumMessages() > 0 {
+			if err = sender.SendMessageBatch(ctx, sbBatch); err != nil {
+				return err
+			}
+			if sbBatch, err = sender.NewMessageBatch(ctx, nil); err != nil {
+				return err
+			}
+			err = sbBatch.AddMessage(sbMsg)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to add message to batch: %w", err)
+		}
+	}
+	return sender.SendMessageBatch(ctx, sbBatch)
+}
+
+func (w *serviceBusWriter) Close(ctx context.Context) error {
+	w.connMut.Lock()
+	defer w.connMut.Unlock()
+
+	if w.sender != nil {
+		_ = w.sender.Close(ctx)
+		w.sender = nil
+	}
+	if w.client != nil {
+		if err := w.client.Close(ctx); err != nil {
+			return err
+		}
+		w.client = nil
+	}
+	return nil
+}
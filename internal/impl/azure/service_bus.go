@@ -0,0 +1,73 @@
+package azure
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/Azure/azure-sdk-for-go/sdk/messaging/azservicebus"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const serviceBusAuthDescription = `
+### Authentication
+
+Either a connection string can be provided with the field ` + "`connection_string`" + `, or the fully qualified namespace of the Service Bus can be provided with the field ` + "`namespace`" + `, in which case Azure Active Directory credentials are obtained from the environment, a managed identity or the Azure CLI in that order. You can find out more about Azure Active Directory authentication [in the Azure documentation](https://docs.microsoft.com/en-us/azure/developer/go/azure-sdk-authentication).`
+
+func serviceBusConnectionFields() []*service.ConfigField {
+	return []*service.ConfigField{
+		service.NewStringField("connection_string").
+			Description("A Service Bus connection string, which takes precedence over `namespace` when set.").
+			Example("Endpoint=sb://foo.servicebus.windows.net/;SharedAccessKeyName=RootManageSharedAccessKey;SharedAccessKey=bar").
+			Default(""),
+		service.NewStringField("namespace").
+			Description("The fully qualified namespace of the Service Bus, which is authenticated against with Azure Active Directory credentials.").
+			Example("foo.servicebus.windows.net").
+			Default(""),
+	}
+}
+
+type serviceBusClientConfig struct {
+	connectionString string
+	namespace        string
+}
+
+func serviceBusClientConfigFromParsed(conf *service.ParsedConfig) (c serviceBusClientConfig, err error) {
+	if c.connectionString, err = conf.FieldString("connection_string"); err != nil {
+		return
+	}
+	if c.namespace, err = conf.FieldString("namespace"); err != nil {
+		return
+	}
+	if c.connectionString == "" && c.namespace == "" {
+		err = errors.New("either a connection_string or namespace must be specified")
+	}
+	return
+}
+
+func (c serviceBusClientConfig) newClient() (*azservicebus.Client, error) {
+	if c.connectionString != "" {
+		return azservicebus.NewClientFromConnectionString(c.connectionString, nil)
+	}
+	cred, err := azidentity.NewDefaultAzureCredential(nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to obtain azure active directory credentials: %w", err)
+	}
+	return azservicebus.NewClient(c.namespace, cred, nil)
+}
+
+// serviceBusEntityFromParsed returns the queue or topic names from a config,
+// where exactly one of them must be set.
+func serviceBusEntityFromParsed(conf *service.ParsedConfig) (queue, topic string, err error) {
+	if queue, err = conf.FieldString("queue"); err != nil {
+		return
+	}
+	if topic, err = conf.FieldString("topic"); err != nil {
+		return
+	}
+	if (queue == "") == (topic == "") {
+		err = errors.New("exactly one of queue or topic must be specified")
+	}
+	return
+}
@@ -0,0 +1,299 @@
+package azure
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/messaging/azservicebus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+type fakeServiceBusReceiver struct {
+	mut       sync.Mutex
+	completed []*azservicebus.ReceivedMessage
+	abandoned []*azservicebus.ReceivedMessage
+	deadLet   []*azservicebus.DeadLetterOptions
+	renewed   int
+	closed    bool
+}
+
+func (f *fakeServiceBusReceiver) ReceiveMessages(ctx context.Context, maxMessages int, options *azservicebus.ReceiveMessagesOptions) ([]*azservicebus.ReceivedMessage, error) {
+	<-ctx.Done()
+	return nil, nil
+}
+
+func (f *fakeServiceBusReceiver) CompleteMessage(ctx context.Context, msg *azservicebus.ReceivedMessage) error {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+	f.completed = append(f.completed, msg)
+	return nil
+}
+
+func (f *fakeServiceBusReceiver) AbandonMessage(ctx context.Context, msg *azservicebus.ReceivedMessage, options *azservicebus.AbandonMessageOptions) error {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+	f.abandoned = append(f.abandoned, msg)
+	return nil
+}
+
+func (f *fakeServiceBusReceiver) DeadLetterMessage(ctx context.Context, msg *azservicebus.ReceivedMessage, options *azservicebus.DeadLetterOptions) error {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+	f.deadLet = append(f.deadLet, options)
+	return nil
+}
+
+func (f *fakeServiceBusReceiver) RenewMessageLock(ctx context.Context, msg *azservicebus.ReceivedMessage) error {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+	f.renewed++
+	return nil
+}
+
+func (f *fakeServiceBusReceiver) Close(ctx context.Context) error {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+	f.closed = true
+	return nil
+}
+
+type fakeServiceBusSessionReceiver struct {
+	fakeServiceBusReceiver
+}
+
+func (f *fakeServiceBusSessionReceiver) RenewSessionLock(ctx context.Context) error {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+	f.renewed++
+	return nil
+}
+
+func TestServiceBusInputConfigErrors(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		config string
+		err    string
+	}{
+		{
+			name:   "no auth",
+			config: `queue: foo`,
+			err:    "either a connection_string or namespace must be specified",
+		},
+		{
+			name:   "no entity",
+			config: `namespace: foo.servicebus.windows.net`,
+			err:    "exactly one of queue or topic must be specified",
+		},
+		{
+			name: "topic without subscription",
+			config: `
+namespace: foo.servicebus.windows.net
+topic: foo
+`,
+			err: "a subscription must be specified when consuming from a topic, and only then",
+		},
+		{
+			name: "sessions with dead-letter queue",
+			config: `
+namespace: foo.servicebus.windows.net
+queue: foo
+dead_letter_queue: true
+sessions:
+  enabled: true
+`,
+			err: "dead-letter queues cannot be consumed with sessions",
+		},
+		{
+			name: "zero prefetch",
+			config: `
+namespace: foo.servicebus.windows.net
+queue: foo
+prefetch_count: 0
+`,
+			err: "prefetch_count must be greater than zero",
+		},
+	} {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			conf, err := serviceBusInputConfig().ParseYAML(test.config, nil)
+			require.NoError(t, err)
+
+			_, err = newServiceBusReaderFromParsed(conf, nil)
+			require.EqualError(t, err, test.err)
+		})
+	}
+}
+
+func testServiceBusReader(t *testing.T, config string, recvs ...serviceBusReceiver) *serviceBusReader {
+	t.Helper()
+
+	conf, err := serviceBusInputConfig().ParseYAML(config, nil)
+	require.NoError(t, err)
+
+	r, err := newServiceBusReaderFromParsed(conf, service.MockResources().Logger())
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = r.Close(context.Background())
+	})
+
+	r.openReceiver = func(ctx context.Context) (serviceBusReceiver, error) {
+		require.NotEmpty(t, recvs)
+		recv := recvs[0]
+		recvs = recvs[1:]
+		return recv, nil
+	}
+	return r
+}
+
+func TestServiceBusInputAck(t *testing.T) {
+	recv := &fakeServiceBusReceiver{}
+	r := testServiceBusReader(t, `
+connection_string: foo
+queue: foo
+lock_renewal_period: 0s
+`, recv)
+	require.NoError(t, r.Connect(context.Background()))
+
+	msgA, msgB := &azservicebus.ReceivedMessage{}, &azservicebus.ReceivedMessage{}
+	r.inFlight[msgA] = recv
+	r.inFlight[msgB] = recv
+
+	require.NoError(t, r.ackFunc(recv, msgA)(context.Background(), nil))
+	require.NoError(t, r.ackFunc(recv, msgB)(context.Background(), errors.New("nope")))
+
+	assert.Equal(t, []*azservicebus.ReceivedMessage{msgA}, recv.completed)
+	assert.Equal(t, []*azservicebus.ReceivedMessage{msgB}, recv.abandoned)
+	assert.Empty(t, r.inFlight)
+}
+
+func TestServiceBusInputDeadLetterOnNack(t *testing.T) {
+	recv := &fakeServiceBusReceiver{}
+	r := testServiceBusReader(t, `
+connection_string: foo
+queue: foo
+dead_letter_on_nack: true
+lock_renewal_period: 0s
+`, recv)
+	require.NoError(t, r.Connect(context.Background()))
+
+	msg := &azservicebus.ReceivedMessage{}
+	require.NoError(t, r.ackFunc(recv, msg)(context.Background(), errors.New("nope")))
+
+	require.Len(t, recv.deadLet, 1)
+	assert.Equal(t, "Rejected", *recv.deadLet[0].Reason)
+	assert.Equal(t, "nope", *recv.deadLet[0].ErrorDescription)
+	assert.Empty(t, recv.abandoned)
+}
+
+func TestServiceBusInputLockRenewal(t *testing.T) {
+	recv := &fakeServiceBusReceiver{}
+	r := testServiceBusReader(t, `
+connection_string: foo
+queue: foo
+lock_renewal_period: 0s
+`, recv)
+	require.NoError(t, r.Connect(context.Background()))
+
+	r.inFlight[&azservicebus.ReceivedMessage{}] = recv
+	r.inFlight[&azservicebus.ReceivedMessage{}] = recv
+	r.renewLocks(context.Background())
+	assert.Equal(t, 2, recv.renewed)
+
+	sessionRecv := &fakeServiceBusSessionReceiver{}
+	r.receiver = sessionRecv
+	r.inFlight = map[*azservicebus.ReceivedMessage]serviceBusReceiver{
+		{}: sessionRecv,
+		{}: sessionRecv,
+	}
+	r.renewLocks(context.Background())
+	assert.Equal(t, 1, sessionRecv.renewed)
+}
+
+func TestServiceBusInputIdleSessionRelease(t *testing.T) {
+	sessionA, sessionB := &fakeServiceBusSessionReceiver{}, &fakeServiceBusSessionReceiver{}
+	r := testServiceBusReader(t, `
+connection_string: foo
+queue: foo
+sessions:
+  enabled: true
+  idle_timeout: 10ms
+lock_renewal_period: 0s
+`, sessionA, sessionB)
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+
+	require.NoError(t, r.Connect(ctx))
+
+	// A session with messages in flight is not released.
+	inFlightMsg := &azservicebus.ReceivedMessage{}
+	r.inFlight[inFlightMsg] = sessionA
+	readCtx, readDone := context.WithTimeout(ctx, time.Millisecond*50)
+	_, _, err := r.Read(readCtx)
+	readDone()
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.False(t, sessionA.closed)
+
+	require.NoError(t, r.ackFunc(sessionA, inFlightMsg)(ctx, nil))
+
+	_, _, err = r.Read(ctx)
+	require.Equal(t, service.ErrNotConnected, err)
+	assert.True(t, sessionA.closed)
+
+	require.NoError(t, r.Connect(ctx))
+	assert.Equal(t, sessionB, r.receiver)
+}
+
+func TestServiceBusOutputMessages(t *testing.T) {
+	conf, err := serviceBusOutputConfig().ParseYAML(`
+connection_string: foo
+topic: foo
+message_id: '${! meta("id").or("") }'
+session_id: '${! json("user").or("") }'
+scheduled_enqueue_time: '${! meta("at").or("") }'
+metadata:
+  include_prefixes: [ prop_ ]
+`, nil)
+	require.NoError(t, err)
+
+	w, err := newServiceBusWriterFromParsed(conf, nil)
+	require.NoError(t, err)
+
+	msgA := service.NewMessage([]byte(`{"user":"a"}`))
+	msgA.MetaSet("id", "1")
+	msgA.MetaSet("prop_foo", "bar")
+	msgA.MetaSet("at", "2022-05-06T07:08:09Z")
+
+	msgB := service.NewMessage([]byte(`{"user":"b"}`))
+	msgB.MetaSet("at", "1651820889")
+
+	sbMsgs, err := w.toServiceBusMessages(service.MessageBatch{msgA, msgB, service.NewMessage([]byte(`{}`))})
+	require.NoError(t, err)
+	require.Len(t, sbMsgs, 3)
+
+	at := time.Date(2022, 5, 6, 7, 8, 9, 0, time.UTC)
+
+	assert.Equal(t, `{"user":"a"}`, string(sbMsgs[0].Body))
+	assert.Equal(t, "1", *sbMsgs[0].MessageID)
+	assert.Equal(t, "a", *sbMsgs[0].SessionID)
+	assert.True(t, at.Equal(*sbMsgs[0].ScheduledEnqueueTime))
+	assert.Equal(t, map[string]interface{}{"prop_foo": "bar"}, sbMsgs[0].ApplicationProperties)
+
+	assert.Nil(t, sbMsgs[1].MessageID)
+	assert.Equal(t, "b", *sbMsgs[1].SessionID)
+	assert.True(t, at.Equal(*sbMsgs[1].ScheduledEnqueueTime))
+	assert.Nil(t, sbMsgs[1].ApplicationProperties)
+
+	assert.Nil(t, sbMsgs[2].SessionID)
+	assert.Nil(t, sbMsgs[2].ScheduledEnqueueTime)
+
+	msgB.MetaSet("at", "tomorrow")
+	_, err = w.toServiceBusMessages(service.MessageBatch{msgA, msgB})
+	require.EqualError(t, err, "message 1: failed to parse scheduled enqueue time 'tomorrow': expected an RFC 3339 or unix timestamp")
+}
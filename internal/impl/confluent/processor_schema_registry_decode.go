@@ -4,13 +4,8 @@ import (
 	"context"
 	"crypto/tls"
 	"encoding/binary"
-	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
-	"net/http"
-	"net/url"
-	"path"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -29,7 +24,15 @@ func schemaRegistryDecoderConfig() *service.ConfigSpec {
 		Description(`
 Decodes messages automatically from a schema stored within a [Confluent Schema Registry service](https://docs.confluent.io/platform/current/schema-registry/index.html) by extracting a schema ID from the message and obtaining the associated schema from the registry. If a message fails to match against the schema then it will remain unchanged and the error can be caught using error handling methods outlined [here](/docs/configuration/error_handling).
 
-Currently only Avro schemas are supported.
+Avro, Protobuf and JSON schemas are supported, and schemas that reference other schemas, such as Protobuf schemas that import other files, are resolved by obtaining the referenced subject versions from the registry. References are not supported for Avro schemas.
+
+### Protobuf Format
+
+Messages encoded with Protobuf schemas are decoded into JSON documents following the [canonical JSON mapping](https://developers.google.com/protocol-buffers/docs/proto3#json) of Protobuf, and the message type within the schema is resolved from the message indexes that follow the schema ID.
+
+### JSON Schema Format
+
+Messages encoded with JSON schemas are validated against the schema and the JSON document that follows the schema ID is left unchanged.
 
 ### Avro JSON Format
 
@@ -65,11 +68,9 @@ func init() {
 //------------------------------------------------------------------------------
 
 type schemaRegistryDecoder struct {
-	client      *http.Client
+	*schemaRegistryClient
 	avroRawJSON bool
 
-	schemaRegistryBaseURL *url.URL
-
 	schemas    map[int]*cachedSchemaDecoder
 	cacheMut   sync.RWMutex
 	requestMut sync.Mutex
@@ -91,31 +92,17 @@ func newSchemaRegistryDecoderFromConfig(conf *service.ParsedConfig, logger *serv
 }
 
 func newSchemaRegistryDecoder(urlStr string, tlsConf *tls.Config, avroRawJSON bool, logger *service.Logger) (*schemaRegistryDecoder, error) {
-	u, err := url.Parse(urlStr)
+	client, err := newSchemaRegistryClient(urlStr, tlsConf, logger)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse url: %w", err)
+		return nil, err
 	}
 
 	s := &schemaRegistryDecoder{
-		avroRawJSON:           avroRawJSON,
-		schemaRegistryBaseURL: u,
-		schemas:               map[int]*cachedSchemaDecoder{},
-		shutSig:               shutdown.NewSignaller(),
-		logger:                logger,
-	}
-
-	s.client = http.DefaultClient
-	if tlsConf != nil {
-		s.client = &http.Client{}
-		if c, ok := http.DefaultTransport.(*http.Transport); ok {
-			cloned := c.Clone()
-			cloned.TLSClientConfig = tlsConf
-			s.client.Transport = cloned
-		} else {
-			s.client.Transport = &http.Transport{
-				TLSClientConfig: tlsConf,
-			}
-		}
+		schemaRegistryClient: client,
+		avroRawJSON:          avroRawJSON,
+		schemas:              map[int]*cachedSchemaDecoder{},
+		shutSig:              shutdown.NewSignaller(),
+		logger:               logger,
 	}
 
 	go func() {
@@ -243,73 +230,58 @@ func (s *schemaRegistryDecoder) getDecoder(id int) (schemaDecoder, error) {
 		return c.decoder, nil
 	}
 
-	ctx, done := context.WithTimeout(context.Background(), time.Second*5)
-	defer done()
+	ctx := context.Background()
 
-	reqURL := *s.schemaRegistryBaseURL
-	reqURL.Path = path.Join(reqURL.Path, fmt.Sprintf("/schemas/ids/%v", id))
-
-	req, err := http.NewRequestWithContext(ctx, "GET", reqURL.String(), http.NoBody)
+	info, err := s.getSchemaByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
-	req.Header.Add("Accept", "application/vnd.schemaregistry.v1+json")
-
-	var resBytes []byte
-	for i := 0; i < 3; i++ {
-		var res *http.Response
-		if res, err = s.client.Do(req); err != nil {
-			s.logger.Errorf("request failed for schema '%v': %v", id, err)
-			continue
-		}
 
-		if res.StatusCode == http.StatusNotFound {
-			err = fmt.Errorf("schema '%v' not found by registry", id)
-			s.logger.Errorf(err.Error())
-			break
-		}
-
-		if res.StatusCode != http.StatusOK {
-			err = fmt.Errorf("request failed for schema '%v'", id)
-			s.logger.Errorf(err.Error())
-			// TODO: Best attempt at parsing out the body
-			continue
-		}
-
-		if res.Body == nil {
-			s.logger.Errorf("request for schema '%v' returned an empty body", id)
-			err = errors.New("schema request returned an empty body")
-			continue
-		}
-
-		resBytes, err = io.ReadAll(res.Body)
-		res.Body.Close()
-		if err != nil {
-			s.logger.Errorf("failed to read response for schema '%v': %v", id, err)
-			continue
-		}
-
-		break
-	}
+	decoder, err := s.newDecoder(ctx, info)
 	if err != nil {
+		s.logger.Errorf("failed to parse response for schema '%v': %v", id, err)
 		return nil, err
 	}
 
-	resPayload := struct {
-		Schema string `json:"schema"`
-	}{}
-	if err = json.Unmarshal(resBytes, &resPayload); err != nil {
-		s.logger.Errorf("failed to parse response for schema '%v': %v", id, err)
-		return nil, err
+	s.cacheMut.Lock()
+	s.schemas[id] = &cachedSchemaDecoder{
+		lastUsedUnixSeconds: time.Now().Unix(),
+		decoder:             decoder,
 	}
+	s.cacheMut.Unlock()
 
-	var codec *goavro.Codec
-	if codec, err = goavro.NewCodecForStandardJSON(resPayload.Schema); err != nil {
-		s.logger.Errorf("failed to parse response for schema '%v': %v", id, err)
-		return nil, err
+	return decoder, nil
+}
+
+func (s *schemaRegistryDecoder) newDecoder(ctx context.Context, info schemaInfo) (schemaDecoder, error) {
+	switch info.Type {
+	case "", "AVRO":
+		if len(info.References) > 0 {
+			return nil, errors.New("references are not supported for Avro schemas")
+		}
+		codec, err := goavro.NewCodecForStandardJSON(info.Schema)
+		if err != nil {
+			return nil, err
+		}
+		return s.avroDecoder(codec), nil
+	case "PROTOBUF":
+		fd, err := parseProtobufSchema(ctx, s.schemaRegistryClient, info)
+		if err != nil {
+			return nil, err
+		}
+		return protobufDecoder(fd), nil
+	case "JSON":
+		schema, err := parseJSONSchema(ctx, s.schemaRegistryClient, info)
+		if err != nil {
+			return nil, err
+		}
+		return jsonSchemaValidator(schema), nil
 	}
+	return nil, fmt.Errorf("schema type %v is not supported", info.Type)
+}
 
-	decoder := func(m *service.Message) error {
+func (s *schemaRegistryDecoder) avroDecoder(codec *goavro.Codec) schemaDecoder {
+	return func(m *service.Message) error {
 		b, err := m.AsBytes()
 		if err != nil {
 			return err
@@ -333,13 +305,4 @@ func (s *schemaRegistryDecoder) getDecoder(id int) (schemaDecoder, error) {
 		}
 		return nil
 	}
-
-	s.cacheMut.Lock()
-	s.schemas[id] = &cachedSchemaDecoder{
-		lastUsedUnixSeconds: time.Now().Unix(),
-		decoder:             decoder,
-	}
-	s.cacheMut.Unlock()
-
-	return decoder, nil
 }
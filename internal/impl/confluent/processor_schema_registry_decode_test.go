@@ -285,3 +285,163 @@ func TestSchemaRegistryDecodeClearExpired(t *testing.T) {
 	}, decoder.schemas)
 	decoder.cacheMut.Unlock()
 }
+
+const testProtoCommonSchema = `
+syntax = "proto3";
+package common;
+
+message Money {
+  string currency = 1;
+  int64 units = 2;
+}
+`
+
+const testProtoSchema = `
+syntax = "proto3";
+package shop;
+
+import "common.proto";
+
+message Order {
+  string id = 1;
+  common.Money price = 2;
+
+  message Item {
+    string sku = 1;
+  }
+}
+
+message Refund {
+  string order_id = 1;
+}
+`
+
+const testJSONAddressSchema = `{
+  "type": "object",
+  "properties": {
+    "city": { "type": "string" }
+  },
+  "required": [ "city" ]
+}`
+
+const testJSONSchema = `{
+  "type": "object",
+  "properties": {
+    "name": { "type": "string" },
+    "address": { "$ref": "address.json" }
+  },
+  "required": [ "name" ]
+}`
+
+func runReferencesSchemaRegistryServer(t *testing.T) string {
+	t.Helper()
+
+	mustJSON := func(v interface{}) []byte {
+		b, err := json.Marshal(v)
+		require.NoError(t, err)
+		return b
+	}
+
+	protoPayload := mustJSON(schemaInfo{
+		ID:     10,
+		Type:   "PROTOBUF",
+		Schema: testProtoSchema,
+		References: []schemaReference{
+			{Name: "common.proto", Subject: "common", Version: 1},
+		},
+	})
+	jsonPayload := mustJSON(schemaInfo{
+		ID:     11,
+		Type:   "JSON",
+		Schema: testJSONSchema,
+		References: []schemaReference{
+			{Name: "address.json", Subject: "address", Version: 2},
+		},
+	})
+
+	return runSchemaRegistryServer(t, func(path string) ([]byte, error) {
+		switch path {
+		case "/schemas/ids/10", "/subjects/orders/versions/latest":
+			return protoPayload, nil
+		case "/schemas/ids/11", "/subjects/people/versions/latest":
+			return jsonPayload, nil
+		case "/schemas/ids/12":
+			return mustJSON(schemaInfo{Type: "XML", Schema: "<nope/>"}), nil
+		case "/subjects/common/versions/1":
+			return mustJSON(schemaInfo{ID: 20, Type: "PROTOBUF", Schema: testProtoCommonSchema}), nil
+		case "/subjects/address/versions/2":
+			return mustJSON(schemaInfo{ID: 21, Type: "JSON", Schema: testJSONAddressSchema}), nil
+		}
+		return nil, nil
+	})
+}
+
+func TestSchemaRegistryDecodeProtobufAndJSON(t *testing.T) {
+	urlStr := runReferencesSchemaRegistryServer(t)
+
+	decoder, err := newSchemaRegistryDecoder(urlStr, nil, true, nil)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		input       string
+		output      string
+		errContains string
+	}{
+		{
+			name:   "protobuf first message",
+			input:  "\x00\x00\x00\x00\x0a\x00\x0a\x01a\x12\x07\x0a\x03EUR\x10\x05",
+			output: `{"id":"a","price":{"currency":"EUR","units":"5"}}`,
+		},
+		{
+			name:   "protobuf second message",
+			input:  "\x00\x00\x00\x00\x0a\x02\x02\x0a\x01a",
+			output: `{"orderId":"a"}`,
+		},
+		{
+			name:   "protobuf nested message",
+			input:  "\x00\x00\x00\x00\x0a\x04\x00\x00\x0a\x01x",
+			output: `{"sku":"x"}`,
+		},
+		{
+			name:        "protobuf unknown message",
+			input:       "\x00\x00\x00\x00\x0a\x02\x0a\x0a\x01a",
+			errContains: "not found within schema",
+		},
+		{
+			name:   "json valid",
+			input:  "\x00\x00\x00\x00\x0b" + `{"name":"foo","address":{"city":"bar"}}`,
+			output: `{"name":"foo","address":{"city":"bar"}}`,
+		},
+		{
+			name:        "json invalid reference",
+			input:       "\x00\x00\x00\x00\x0b" + `{"name":"foo","address":{}}`,
+			errContains: "city is required",
+		},
+		{
+			name:        "unsupported schema type",
+			input:       "\x00\x00\x00\x00\x0c{}",
+			errContains: "schema type XML is not supported",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			outMsgs, err := decoder.Process(context.Background(), service.NewMessage([]byte(test.input)))
+			if test.errContains != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.errContains)
+			} else {
+				require.NoError(t, err)
+				require.Len(t, outMsgs, 1)
+
+				b, err := outMsgs[0].AsBytes()
+				require.NoError(t, err)
+				assert.JSONEq(t, test.output, string(b))
+			}
+		})
+	}
+
+	require.NoError(t, decoder.Close(context.Background()))
+}
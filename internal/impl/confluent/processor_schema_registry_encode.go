@@ -4,13 +4,8 @@ import (
 	"context"
 	"crypto/tls"
 	"encoding/binary"
-	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
-	"net/http"
-	"net/url"
-	"path"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -31,7 +26,15 @@ Encodes messages automatically from schemas obtains from a [Confluent Schema Reg
 
 If a message fails to encode under the schema then it will remain unchanged and the error can be caught using error handling methods outlined [here](/docs/configuration/error_handling).
 
-Currently only Avro schemas are supported.
+Avro, Protobuf and JSON schemas are supported, and schemas that reference other schemas, such as Protobuf schemas that import other files, are resolved by obtaining the referenced subject versions from the registry. References are not supported for Avro schemas.
+
+### Protobuf Format
+
+Messages encoded with Protobuf schemas must be JSON documents following the [canonical JSON mapping](https://developers.google.com/protocol-buffers/docs/proto3#json) of Protobuf, and are encoded as the first message type of the schema.
+
+### JSON Schema Format
+
+Messages encoded with JSON schemas must be JSON documents, which are validated against the schema and prefixed with the schema ID.
 
 ### Avro JSON Format
 
@@ -78,13 +81,11 @@ func init() {
 //------------------------------------------------------------------------------
 
 type schemaRegistryEncoder struct {
-	client             *http.Client
+	*schemaRegistryClient
 	subject            *service.InterpolatedString
 	avroRawJSON        bool
 	schemaRefreshAfter time.Duration
 
-	schemaRegistryBaseURL *url.URL
-
 	schemas    map[string]*cachedSchemaEncoder
 	cacheMut   sync.RWMutex
 	requestMut sync.Mutex
@@ -134,34 +135,20 @@ func newSchemaRegistryEncoder(
 	schemaRefreshAfter, schemaRefreshTicker time.Duration,
 	logger *service.Logger,
 ) (*schemaRegistryEncoder, error) {
-	u, err := url.Parse(urlStr)
+	client, err := newSchemaRegistryClient(urlStr, tlsConf, logger)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse url: %w", err)
+		return nil, err
 	}
 
 	s := &schemaRegistryEncoder{
-		schemaRegistryBaseURL: u,
-		subject:               subject,
-		avroRawJSON:           avroRawJSON,
-		schemaRefreshAfter:    schemaRefreshAfter,
-		schemas:               map[string]*cachedSchemaEncoder{},
-		shutSig:               shutdown.NewSignaller(),
-		logger:                logger,
-		nowFn:                 time.Now,
-	}
-
-	s.client = http.DefaultClient
-	if tlsConf != nil {
-		s.client = &http.Client{}
-		if c, ok := http.DefaultTransport.(*http.Transport); ok {
-			cloned := c.Clone()
-			cloned.TLSClientConfig = tlsConf
-			s.client.Transport = cloned
-		} else {
-			s.client.Transport = &http.Transport{
-				TLSClientConfig: tlsConf,
-			}
-		}
+		schemaRegistryClient: client,
+		subject:              subject,
+		avroRawJSON:          avroRawJSON,
+		schemaRefreshAfter:   schemaRefreshAfter,
+		schemas:              map[string]*cachedSchemaEncoder{},
+		shutSig:              shutdown.NewSignaller(),
+		logger:               logger,
+		nowFn:                time.Now,
 	}
 
 	go func() {
@@ -286,73 +273,49 @@ func (s *schemaRegistryEncoder) refreshEncoders() {
 }
 
 func (s *schemaRegistryEncoder) getLatestEncoder(subject string) (schemaEncoder, int, error) {
-	ctx, done := context.WithTimeout(context.Background(), time.Second*5)
-	defer done()
-
-	reqURL := *s.schemaRegistryBaseURL
-	reqURL.Path = path.Join(reqURL.Path, fmt.Sprintf("/subjects/%s/versions/latest", subject))
+	ctx := context.Background()
 
-	req, err := http.NewRequestWithContext(ctx, "GET", reqURL.String(), http.NoBody)
+	info, err := s.getSchemaBySubjectVersion(ctx, subject, "latest")
 	if err != nil {
 		return nil, 0, err
 	}
-	req.Header.Add("Accept", "application/vnd.schemaregistry.v1+json")
 
-	var resBytes []byte
-	for i := 0; i < 3; i++ {
-		var res *http.Response
-		if res, err = s.client.Do(req); err != nil {
-			s.logger.Errorf("request failed for schema subject '%v': %v", subject, err)
-			continue
-		}
+	encoder, err := s.newEncoder(ctx, info)
+	if err != nil {
+		s.logger.Errorf("failed to parse response for schema subject '%v': %v", subject, err)
+		return nil, 0, err
+	}
+	return encoder, info.ID, nil
+}
 
-		if res.StatusCode == http.StatusNotFound {
-			err = fmt.Errorf("schema subject '%v' not found by registry", subject)
-			s.logger.Errorf(err.Error())
-			break
+func (s *schemaRegistryEncoder) newEncoder(ctx context.Context, info schemaInfo) (schemaEncoder, error) {
+	switch info.Type {
+	case "", "AVRO":
+		if len(info.References) > 0 {
+			return nil, errors.New("references are not supported for Avro schemas")
 		}
-
-		if res.StatusCode != http.StatusOK {
-			err = fmt.Errorf("request failed for schema subject '%v'", subject)
-			s.logger.Errorf(err.Error())
-			// TODO: Best attempt at parsing out the body
-			continue
+		codec, err := goavro.NewCodecForStandardJSON(info.Schema)
+		if err != nil {
+			return nil, err
 		}
-
-		if res.Body == nil {
-			s.logger.Errorf("request for schema subject latest '%v' returned an empty body", subject)
-			err = errors.New("schema request returned an empty body")
-			continue
+		return s.avroEncoder(codec), nil
+	case "PROTOBUF":
+		fd, err := parseProtobufSchema(ctx, s.schemaRegistryClient, info)
+		if err != nil {
+			return nil, err
 		}
-
-		resBytes, err = io.ReadAll(res.Body)
-		res.Body.Close()
+		return protobufEncoder(fd)
+	case "JSON":
+		schema, err := parseJSONSchema(ctx, s.schemaRegistryClient, info)
 		if err != nil {
-			s.logger.Errorf("failed to read response for schema subject '%v': %v", subject, err)
-			continue
+			return nil, err
 		}
-
-		break
-	}
-	if err != nil {
-		return nil, 0, err
-	}
-
-	resPayload := struct {
-		Schema string `json:"schema"`
-		ID     int    `json:"id"`
-	}{}
-	if err = json.Unmarshal(resBytes, &resPayload); err != nil {
-		s.logger.Errorf("failed to parse response for schema subject '%v': %v", subject, err)
-		return nil, 0, err
-	}
-
-	var codec *goavro.Codec
-	if codec, err = goavro.NewCodecForStandardJSON(resPayload.Schema); err != nil {
-		s.logger.Errorf("failed to parse response for schema subject '%v': %v", subject, err)
-		return nil, 0, err
+		return jsonSchemaValidator(schema), nil
 	}
+	return nil, fmt.Errorf("schema type %v is not supported", info.Type)
+}
 
+func (s *schemaRegistryEncoder) avroEncoder(codec *goavro.Codec) schemaEncoder {
 	return func(m *service.Message) error {
 		var datum interface{}
 		if s.avroRawJSON {
@@ -364,8 +327,11 @@ func (s *schemaRegistryEncoder) getLatestEncoder(subject string) (schemaEncoder,
 			if datum, _, err = codec.NativeFromTextual(b); err != nil {
 				return err
 			}
-		} else if datum, err = m.AsStructured(); err != nil {
-			return err
+		} else {
+			var err error
+			if datum, err = m.AsStructured(); err != nil {
+				return err
+			}
 		}
 
 		binary, err := codec.BinaryFromNative(nil, datum)
@@ -375,7 +341,7 @@ func (s *schemaRegistryEncoder) getLatestEncoder(subject string) (schemaEncoder,
 
 		m.SetBytes(binary)
 		return nil
-	}, resPayload.ID, nil
+	}
 }
 
 func (s *schemaRegistryEncoder) getEncoder(subject string) (schemaEncoder, int, error) {
@@ -387,3 +387,73 @@ func TestSchemaRegistryEncodeRefresh(t *testing.T) {
 	assert.Equal(t, int32(1), atomic.LoadInt32(&fooReqs))
 	assert.Equal(t, int32(1), atomic.LoadInt32(&barReqs))
 }
+
+func TestSchemaRegistryEncodeProtobufAndJSON(t *testing.T) {
+	urlStr := runReferencesSchemaRegistryServer(t)
+
+	subj, err := service.NewInterpolatedString(`${! meta("subject") }`)
+	require.NoError(t, err)
+
+	encoder, err := newSchemaRegistryEncoder(urlStr, nil, subj, false, time.Minute*10, time.Minute, nil)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		subject     string
+		input       string
+		output      string
+		errContains string
+	}{
+		{
+			name:    "protobuf",
+			subject: "orders",
+			input:   `{"id":"a","price":{"currency":"EUR","units":"5"}}`,
+			output:  "\x00\x00\x00\x00\x0a\x00\x0a\x01a\x12\x07\x0a\x03EUR\x10\x05",
+		},
+		{
+			name:        "protobuf unknown field",
+			subject:     "orders",
+			input:       `{"nope":"a"}`,
+			errContains: "failed to unmarshal JSON into protobuf message",
+		},
+		{
+			name:    "json",
+			subject: "people",
+			input:   `{"name":"foo","address":{"city":"bar"}}`,
+			output:  "\x00\x00\x00\x00\x0b" + `{"name":"foo","address":{"city":"bar"}}`,
+		},
+		{
+			name:        "json invalid",
+			subject:     "people",
+			input:       `{"address":{"city":"bar"}}`,
+			errContains: "name is required",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			msg := service.NewMessage([]byte(test.input))
+			msg.MetaSet("subject", test.subject)
+
+			outBatches, err := encoder.ProcessBatch(context.Background(), service.MessageBatch{msg})
+			require.NoError(t, err)
+			require.Len(t, outBatches, 1)
+			require.Len(t, outBatches[0], 1)
+
+			err = outBatches[0][0].GetError()
+			if test.errContains != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.errContains)
+			} else {
+				require.NoError(t, err)
+
+				b, err := outBatches[0][0].AsBytes()
+				require.NoError(t, err)
+				assert.Equal(t, test.output, string(b))
+			}
+		})
+	}
+
+	require.NoError(t, encoder.Close(context.Background()))
+}
@@ -0,0 +1,178 @@
+package confluent
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+type schemaReference struct {
+	Name    string `json:"name"`
+	Subject string `json:"subject"`
+	Version int    `json:"version"`
+}
+
+// schemaInfo is a schema as returned by the registry, where an empty type
+// indicates an Avro schema.
+type schemaInfo struct {
+	ID         int               `json:"id"`
+	Type       string            `json:"schemaType"`
+	Schema     string            `json:"schema"`
+	References []schemaReference `json:"references"`
+}
+
+type schemaRegistryClient struct {
+	client                *http.Client
+	schemaRegistryBaseURL *url.URL
+	logger                *service.Logger
+}
+
+func newSchemaRegistryClient(urlStr string, tlsConf *tls.Config, logger *service.Logger) (*schemaRegistryClient, error) {
+	u, err := url.Parse(urlStr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse url: %w", err)
+	}
+
+	c := &schemaRegistryClient{
+		client:                http.DefaultClient,
+		schemaRegistryBaseURL: u,
+		logger:                logger,
+	}
+	if tlsConf != nil {
+		c.client = &http.Client{}
+		if t, ok := http.DefaultTransport.(*http.Transport); ok {
+			cloned := t.Clone()
+			cloned.TLSClientConfig = tlsConf
+			c.client.Transport = cloned
+		} else {
+			c.client.Transport = &http.Transport{
+				TLSClientConfig: tlsConf,
+			}
+		}
+	}
+	return c, nil
+}
+
+// getSchemaByID obtains the schema of an ID.
+func (c *schemaRegistryClient) getSchemaByID(ctx context.Context, id int) (schemaInfo, error) {
+	var info schemaInfo
+	if err := c.get(ctx, fmt.Sprintf("/schemas/ids/%v", id), fmt.Sprintf("schema '%v'", id), &info); err != nil {
+		return info, err
+	}
+	info.ID = id
+	return info, nil
+}
+
+// getSchemaBySubjectVersion obtains the schema of a subject version, where the
+// version can be "latest".
+func (c *schemaRegistryClient) getSchemaBySubjectVersion(ctx context.Context, subject, version string) (schemaInfo, error) {
+	var info schemaInfo
+	desc := fmt.Sprintf("schema subject '%v'", subject)
+	if version != "latest" {
+		desc = fmt.Sprintf("schema subject '%v' version '%v'", subject, version)
+	}
+	err := c.get(ctx, fmt.Sprintf("/subjects/%s/versions/%s", subject, version), desc, &info)
+	return info, err
+}
+
+// walkReferences calls fn with each schema referenced by a list of
+// references, including the references of referenced schemas, where schemas
+// are visited after the schemas they reference and each name is only visited
+// once.
+func (c *schemaRegistryClient) walkReferences(ctx context.Context, refs []schemaReference, fn func(name string, info schemaInfo) error) error {
+	seen := map[string]struct{}{}
+
+	var walk func(refs []schemaReference) error
+	walk = func(refs []schemaReference) error {
+		for _, ref := range refs {
+			if _, exists := seen[ref.Name]; exists {
+				continue
+			}
+			seen[ref.Name] = struct{}{}
+
+			info, err := c.getSchemaBySubjectVersion(ctx, ref.Subject, fmt.Sprintf("%v", ref.Version))
+			if err != nil {
+				return fmt.Errorf("failed to resolve reference '%v': %w", ref.Name, err)
+			}
+			if err := walk(info.References); err != nil {
+				return err
+			}
+			if err := fn(ref.Name, info); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return walk(refs)
+}
+
+func (c *schemaRegistryClient) get(ctx context.Context, reqPath, desc string, v interface{}) error {
+	ctx, done := context.WithTimeout(ctx, time.Second*5)
+	defer done()
+
+	reqURL := *c.schemaRegistryBaseURL
+	reqURL.Path = path.Join(reqURL.Path, reqPath)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", reqURL.String(), http.NoBody)
+	if err != nil {
+		return err
+	}
+	req.Header.Add("Accept", "application/vnd.schemaregistry.v1+json")
+
+	var resBytes []byte
+	for i := 0; i < 3; i++ {
+		var res *http.Response
+		if res, err = c.client.Do(req); err != nil {
+			c.logger.Errorf("request failed for %v: %v", desc, err)
+			continue
+		}
+
+		if res.StatusCode == http.StatusNotFound {
+			res.Body.Close()
+			err = fmt.Errorf("%v not found by registry", desc)
+			c.logger.Errorf(err.Error())
+			break
+		}
+
+		if res.StatusCode != http.StatusOK {
+			res.Body.Close()
+			err = fmt.Errorf("request failed for %v", desc)
+			c.logger.Errorf(err.Error())
+			// TODO: Best attempt at parsing out the body
+			continue
+		}
+
+		if res.Body == nil {
+			c.logger.Errorf("request for %v returned an empty body", desc)
+			err = errors.New("schema request returned an empty body")
+			continue
+		}
+
+		resBytes, err = io.ReadAll(res.Body)
+		res.Body.Close()
+		if err != nil {
+			c.logger.Errorf("failed to read response for %v: %v", desc, err)
+			continue
+		}
+
+		break
+	}
+	if err != nil {
+		return err
+	}
+
+	if err = json.Unmarshal(resBytes, v); err != nil {
+		c.logger.Errorf("failed to parse response for %v: %v", desc, err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package confluent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/xeipuuv/gojsonschema"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+// jsonSchemaBaseURL is the URL that the names of references are resolved
+// against, as schemas are identified by absolute URLs when compiled.
+const jsonSchemaBaseURL = "http://schema-registry/"
+
+func jsonSchemaURL(name string) string {
+	base, _ := url.Parse(jsonSchemaBaseURL)
+	ref, err := url.Parse(name)
+	if err != nil {
+		return name
+	}
+	return base.ResolveReference(ref).String()
+}
+
+// parseJSONSchema compiles a JSON schema along with the schemas it references,
+// which are added under the names of their references.
+func parseJSONSchema(ctx context.Context, client *schemaRegistryClient, info schemaInfo) (*gojsonschema.Schema, error) {
+	loader := gojsonschema.NewSchemaLoader()
+	if err := client.walkReferences(ctx, info.References, func(name string, ref schemaInfo) error {
+		return loader.AddSchema(jsonSchemaURL(name), gojsonschema.NewStringLoader(ref.Schema))
+	}); err != nil {
+		return nil, err
+	}
+
+	// The schema is added under a URL of its own so that relative references
+	// are resolved against the base URL.
+	rootURL := jsonSchemaURL(fmt.Sprintf("schema_registry_%v.json", info.ID))
+	if err := loader.AddSchema(rootURL, gojsonschema.NewStringLoader(info.Schema)); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON schema: %w", err)
+	}
+
+	schema, err := loader.Compile(gojsonschema.NewGoLoader(map[string]interface{}{
+		"$ref": rootURL,
+	}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse JSON schema: %w", err)
+	}
+	return schema, nil
+}
+
+// jsonSchemaValidator returns a coder that validates JSON documents against a
+// schema and leaves them unchanged, as JSON schema serialized messages consist
+// of the JSON document itself.
+func jsonSchemaValidator(schema *gojsonschema.Schema) func(m *service.Message) error {
+	return func(m *service.Message) error {
+		b, err := m.AsBytes()
+		if err != nil {
+			return err
+		}
+
+		result, err := schema.Validate(gojsonschema.NewBytesLoader(b))
+		if err != nil {
+			return err
+		}
+		if !result.Valid() {
+			var errStr string
+			for i, desc := range result.Errors() {
+				if i > 0 {
+					errStr += "\n"
+				}
+				description := strings.ToLower(desc.Description())
+				if property := desc.Details()["property"]; property != nil {
+					description = property.(string) + strings.TrimPrefix(description, strings.ToLower(property.(string)))
+				}
+				errStr += desc.Field() + " " + description
+			}
+			return errors.New(errStr)
+		}
+		return nil
+	}
+}
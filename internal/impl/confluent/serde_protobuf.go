@@ -0,0 +1,150 @@
+package confluent
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/desc/protoparse"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+// parseProtobufSchema parses a protobuf schema along with the schemas it
+// imports, which are resolved from its references.
+func parseProtobufSchema(ctx context.Context, client *schemaRegistryClient, info schemaInfo) (protoreflect.FileDescriptor, error) {
+	files := map[string]string{}
+	if err := client.walkReferences(ctx, info.References, func(name string, ref schemaInfo) error {
+		files[name] = ref.Schema
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	name := fmt.Sprintf("schema_registry_%v.proto", info.ID)
+	files[name] = info.Schema
+
+	parser := protoparse.Parser{
+		Accessor: protoparse.FileContentsFromMap(files),
+	}
+	fds, err := parser.ParseFiles(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse protobuf schema: %w", err)
+	}
+
+	registry, err := protodesc.NewFiles(desc.ToFileDescriptorSet(fds...))
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve protobuf schema: %w", err)
+	}
+	return registry.FindFileByPath(name)
+}
+
+// readMessageIndexes reads the indexes of the message type within a protobuf
+// schema that prefix the serialized message, where an empty list is a
+// shorthand for the first message of the schema.
+func readMessageIndexes(b []byte) (indexes []int, remaining []byte, err error) {
+	count, n := binary.Varint(b)
+	if n <= 0 {
+		return nil, nil, errors.New("failed to read message indexes")
+	}
+	b = b[n:]
+	if count == 0 {
+		return []int{0}, b, nil
+	}
+	if count < 0 || count > int64(len(b)) {
+		return nil, nil, fmt.Errorf("invalid message indexes count %v", count)
+	}
+
+	indexes = make([]int, count)
+	for i := range indexes {
+		index, n := binary.Varint(b)
+		if n <= 0 {
+			return nil, nil, errors.New("failed to read message indexes")
+		}
+		indexes[i] = int(index)
+		b = b[n:]
+	}
+	return indexes, b, nil
+}
+
+func messageByIndexes(fd protoreflect.FileDescriptor, indexes []int) (protoreflect.MessageDescriptor, error) {
+	msgs := fd.Messages()
+	var md protoreflect.MessageDescriptor
+	for _, index := range indexes {
+		if index < 0 || index >= msgs.Len() {
+			return nil, fmt.Errorf("message indexes %v not found within schema", indexes)
+		}
+		md = msgs.Get(index)
+		msgs = md.Messages()
+	}
+	if md == nil {
+		return nil, errors.New("message indexes are empty")
+	}
+	return md, nil
+}
+
+func protobufDecoder(fd protoreflect.FileDescriptor) schemaDecoder {
+	return func(m *service.Message) error {
+		b, err := m.AsBytes()
+		if err != nil {
+			return err
+		}
+
+		indexes, remaining, err := readMessageIndexes(b)
+		if err != nil {
+			return err
+		}
+		md, err := messageByIndexes(fd, indexes)
+		if err != nil {
+			return err
+		}
+
+		msg := dynamicpb.NewMessage(md)
+		if err := proto.Unmarshal(remaining, msg); err != nil {
+			return fmt.Errorf("failed to unmarshal protobuf message: %w", err)
+		}
+
+		jb, err := protojson.Marshal(msg)
+		if err != nil {
+			return err
+		}
+		m.SetBytes(jb)
+		return nil
+	}
+}
+
+// protobufEncoder returns an encoder for the first message of a protobuf
+// schema, which is the message that Confluent serializers assume by default.
+func protobufEncoder(fd protoreflect.FileDescriptor) (schemaEncoder, error) {
+	if fd.Messages().Len() == 0 {
+		return nil, errors.New("protobuf schema does not contain any messages")
+	}
+	md := fd.Messages().Get(0)
+
+	return func(m *service.Message) error {
+		b, err := m.AsBytes()
+		if err != nil {
+			return err
+		}
+
+		msg := dynamicpb.NewMessage(md)
+		if err := protojson.Unmarshal(b, msg); err != nil {
+			return fmt.Errorf("failed to unmarshal JSON into protobuf message: %w", err)
+		}
+
+		pb, err := proto.Marshal(msg)
+		if err != nil {
+			return err
+		}
+		// The indexes of the first message are encoded as a single zero byte.
+		m.SetBytes(append([]byte{0}, pb...))
+		return nil
+	}, nil
+}
@@ -0,0 +1,71 @@
+package dns
+
+import (
+	"sync"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+type cacheEntry struct {
+	records []dnsmessage.Resource
+	expires time.Time
+}
+
+// answerCache stores the records found by lookups until the TTL of their
+// answers expires, holding at most a fixed number of them.
+type answerCache struct {
+	mut     sync.Mutex
+	size    int
+	entries map[string]cacheEntry
+	now     func() time.Time
+}
+
+func newAnswerCache(size int) *answerCache {
+	return &answerCache{
+		size:    size,
+		entries: make(map[string]cacheEntry, size),
+		now:     time.Now,
+	}
+}
+
+func (c *answerCache) get(key string) ([]dnsmessage.Resource, bool) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	e, exists := c.entries[key]
+	if !exists {
+		return nil, false
+	}
+	if !c.now().Before(e.expires) {
+		delete(c.entries, key)
+		return nil, false
+	}
+	return e.records, true
+}
+
+func (c *answerCache) set(key string, records []dnsmessage.Resource, ttl time.Duration) {
+	if ttl <= 0 || c.size <= 0 {
+		return
+	}
+
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	now := c.now()
+	if _, exists := c.entries[key]; !exists && len(c.entries) >= c.size {
+		for k, e := range c.entries {
+			if !now.Before(e.expires) {
+				delete(c.entries, k)
+			}
+		}
+		// When no entries have expired an arbitrary one is evicted.
+		for k := range c.entries {
+			if len(c.entries) < c.size {
+				break
+			}
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = cacheEntry{records: records, expires: now.Add(ttl)}
+}
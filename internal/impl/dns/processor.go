@@ -0,0 +1,240 @@
+package dns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	dpFieldName       = "name"
+	dpFieldRecordType = "record_type"
+	dpFieldResolvers  = "resolvers"
+	dpFieldTimeout    = "timeout"
+	dpFieldCacheSize  = "cache_size"
+	dpFieldMaxTTL     = "max_ttl"
+)
+
+var recordTypes = map[string]dnsmessage.Type{
+	"A":    dnsmessage.TypeA,
+	"AAAA": dnsmessage.TypeAAAA,
+	"PTR":  dnsmessage.TypePTR,
+	"TXT":  dnsmessage.TypeTXT,
+	"MX":   dnsmessage.TypeMX,
+}
+
+func processorConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		// Stable(). TODO
+		Categories("Integration").
+		Summary("Performs a DNS lookup for each message and replaces its contents with an array of the records found.").
+		Description(`
+The records found are formatted according to their type:
+
+- `+"`A`"+` and `+"`AAAA`"+`: IP addresses, e.g. `+"`\"192.0.2.1\"`"+`.
+- `+"`PTR`"+`: host names without a trailing dot, e.g. `+"`\"www.example.com\"`"+`.
+- `+"`TXT`"+`: the text of each record, e.g. `+"`\"v=spf1 -all\"`"+`.
+- `+"`MX`"+`: objects containing the fields `+"`host`"+` and `+"`preference`"+`.
+
+For `+"`PTR`"+` lookups the name can be an IP address, which is converted into its reverse lookup name automatically. Names that do not exist result in an empty array rather than an error.
+
+Answers are cached in memory until their TTL expires, and answers of names that do not exist are cached according to the negative TTL of their zone. Queries are sent over UDP, falling back to TCP when a response is truncated, and each resolver is tried in turn until one responds.
+
+If the lookup fails the message will remain unchanged and the error can be caught using error handling methods outlined [here](/docs/configuration/error_handling).`).
+		Field(service.NewInterpolatedStringField(dpFieldName).
+			Description("The name to look up.").
+			Example(`${! this.client_ip }`).
+			Default("${! content() }")).
+		Field(service.NewStringEnumField(dpFieldRecordType, "A", "AAAA", "PTR", "TXT", "MX").
+			Description("The type of records to look up.").
+			Default("A")).
+		Field(service.NewStringListField(dpFieldResolvers).
+			Description("A list of addresses of resolvers to send queries to, where the port defaults to 53. When empty the name servers of `/etc/resolv.conf` are used.").
+			Example([]string{"1.1.1.1", "8.8.8.8:53"}).
+			Default([]string{})).
+		Field(service.NewDurationField(dpFieldTimeout).
+			Description("The maximum period to wait for a resolver to respond to a query before trying the next.").
+			Advanced().
+			Default("5s")).
+		Field(service.NewIntField(dpFieldCacheSize).
+			Description("The maximum number of answers to cache, where zero disables caching.").
+			Advanced().
+			Default(10000)).
+		Field(service.NewDurationField(dpFieldMaxTTL).
+			Description("The maximum period to cache an answer for, regardless of its TTL.").
+			Advanced().
+			Default("1h")).
+		Example("Reverse Lookup", `
+Here we add the host name of the client IP address of each event, if it has one, using a `+"[`branch` processor](/docs/components/processors/branch)"+`:`,
+			`
+pipeline:
+  processors:
+    - branch:
+        processors:
+          - dns:
+              name: ${! this.client_ip }
+              record_type: PTR
+        result_map: 'root.client_host = this.index(0).catch(null)'
+`)
+}
+
+func init() {
+	err := service.RegisterProcessor(
+		"dns", processorConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Processor, error) {
+			return newProcessorFromConfig(conf, mgr.Logger())
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type processor struct {
+	name     *service.InterpolatedString
+	typeName string
+	qtype    dnsmessage.Type
+	resolver *resolver
+	cache    *answerCache
+	maxTTL   time.Duration
+	log      *service.Logger
+}
+
+func newProcessorFromConfig(conf *service.ParsedConfig, log *service.Logger) (*processor, error) {
+	p := &processor{log: log, resolver: &resolver{}}
+
+	var err error
+	if p.name, err = conf.FieldInterpolatedString(dpFieldName); err != nil {
+		return nil, err
+	}
+
+	if p.typeName, err = conf.FieldString(dpFieldRecordType); err != nil {
+		return nil, err
+	}
+	var exists bool
+	if p.qtype, exists = recordTypes[p.typeName]; !exists {
+		return nil, fmt.Errorf("record type %v not recognised", p.typeName)
+	}
+
+	servers, err := conf.FieldStringList(dpFieldResolvers)
+	if err != nil {
+		return nil, err
+	}
+	if len(servers) == 0 {
+		servers = systemServers("/etc/resolv.conf")
+	}
+	for _, s := range servers {
+		p.resolver.servers = append(p.resolver.servers, serverAddress(s))
+	}
+
+	if p.resolver.timeout, err = conf.FieldDuration(dpFieldTimeout); err != nil {
+		return nil, err
+	}
+
+	cacheSize, err := conf.FieldInt(dpFieldCacheSize)
+	if err != nil {
+		return nil, err
+	}
+	p.cache = newAnswerCache(cacheSize)
+
+	if p.maxTTL, err = conf.FieldDuration(dpFieldMaxTTL); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// reverseName returns the name of PTR records of an IP address.
+func reverseName(ip net.IP) string {
+	var b strings.Builder
+	if ip4 := ip.To4(); ip4 != nil {
+		for i := len(ip4) - 1; i >= 0; i-- {
+			fmt.Fprintf(&b, "%d.", ip4[i])
+		}
+		b.WriteString("in-addr.arpa.")
+		return b.String()
+	}
+	const hexDigits = "0123456789abcdef"
+	for i := len(ip) - 1; i >= 0; i-- {
+		b.WriteByte(hexDigits[ip[i]&0x0F])
+		b.WriteByte('.')
+		b.WriteByte(hexDigits[ip[i]>>4])
+		b.WriteByte('.')
+	}
+	b.WriteString("ip6.arpa.")
+	return b.String()
+}
+
+func formatRecords(records []dnsmessage.Resource) []interface{} {
+	values := make([]interface{}, 0, len(records))
+	for _, res := range records {
+		switch body := res.Body.(type) {
+		case *dnsmessage.AResource:
+			values = append(values, net.IP(body.A[:]).String())
+		case *dnsmessage.AAAAResource:
+			values = append(values, net.IP(body.AAAA[:]).String())
+		case *dnsmessage.PTRResource:
+			values = append(values, strings.TrimSuffix(body.PTR.String(), "."))
+		case *dnsmessage.TXTResource:
+			values = append(values, strings.Join(body.TXT, ""))
+		case *dnsmessage.MXResource:
+			values = append(values, map[string]interface{}{
+				"host":       strings.TrimSuffix(body.MX.String(), "."),
+				"preference": int64(body.Pref),
+			})
+		}
+	}
+	return values
+}
+
+func (p *processor) lookup(ctx context.Context, name string) ([]interface{}, error) {
+	if p.qtype == dnsmessage.TypePTR {
+		if ip := net.ParseIP(name); ip != nil {
+			name = reverseName(ip)
+		}
+	}
+
+	key := p.typeName + " " + strings.ToLower(dnsName(name))
+	if records, exists := p.cache.get(key); exists {
+		return formatRecords(records), nil
+	}
+
+	a, err := p.resolver.lookup(ctx, name, p.qtype)
+	if err != nil {
+		return nil, err
+	}
+
+	ttl := a.ttl
+	if ttl > p.maxTTL {
+		ttl = p.maxTTL
+	}
+	p.cache.set(key, a.records, ttl)
+	return formatRecords(a.records), nil
+}
+
+func (p *processor) Process(ctx context.Context, msg *service.Message) (service.MessageBatch, error) {
+	name := strings.TrimSpace(p.name.String(msg))
+	if name == "" {
+		return nil, errors.New("name to look up is empty")
+	}
+
+	v, err := p.lookup(ctx, name)
+	if err != nil {
+		p.log.Debugf("Failed to look up %v: %v", name, err)
+		return nil, err
+	}
+
+	msg.SetStructured(v)
+	return service.MessageBatch{msg}, nil
+}
+
+func (p *processor) Close(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,344 @@
+package dns
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/dns/dnsmessage"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+// testServer responds to queries over UDP and TCP from a fixed zone, where
+// responses over UDP are truncated when they contain more than four records.
+type testServer struct {
+	mut     sync.Mutex
+	queries []string
+}
+
+func rr(name string, qtype dnsmessage.Type, ttl uint32, body dnsmessage.ResourceBody) dnsmessage.Resource {
+	return dnsmessage.Resource{
+		Header: dnsmessage.ResourceHeader{Name: dnsmessage.MustNewName(name), Type: qtype, Class: dnsmessage.ClassINET, TTL: ttl},
+		Body:   body,
+	}
+}
+
+var testZone = map[string][]dnsmessage.Resource{
+	"A example.com.": {
+		rr("example.com.", dnsmessage.TypeA, 60, &dnsmessage.AResource{A: [4]byte{192, 0, 2, 1}}),
+		rr("example.com.", dnsmessage.TypeA, 60, &dnsmessage.AResource{A: [4]byte{192, 0, 2, 2}}),
+	},
+	"A www.example.com.": {
+		rr("www.example.com.", dnsmessage.TypeCNAME, 30, &dnsmessage.CNAMEResource{CNAME: dnsmessage.MustNewName("example.com.")}),
+		rr("example.com.", dnsmessage.TypeA, 60, &dnsmessage.AResource{A: [4]byte{192, 0, 2, 1}}),
+	},
+	"AAAA example.com.": {
+		rr("example.com.", dnsmessage.TypeAAAA, 60, &dnsmessage.AAAAResource{AAAA: [16]byte{0x20, 0x01, 0x0d, 0xb8, 15: 1}}),
+	},
+	"PTR 1.2.0.192.in-addr.arpa.": {
+		rr("1.2.0.192.in-addr.arpa.", dnsmessage.TypePTR, 60, &dnsmessage.PTRResource{PTR: dnsmessage.MustNewName("host.example.com.")}),
+	},
+	"PTR 1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa.": {
+		rr("1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa.", dnsmessage.TypePTR, 60, &dnsmessage.PTRResource{PTR: dnsmessage.MustNewName("host6.example.com.")}),
+	},
+	"TXT example.com.": {
+		rr("example.com.", dnsmessage.TypeTXT, 60, &dnsmessage.TXTResource{TXT: []string{"v=spf1 ", "-all"}}),
+	},
+	"MX example.com.": {
+		rr("example.com.", dnsmessage.TypeMX, 60, &dnsmessage.MXResource{Pref: 10, MX: dnsmessage.MustNewName("mail.example.com.")}),
+	},
+	"A big.example.com.": {
+		rr("big.example.com.", dnsmessage.TypeA, 60, &dnsmessage.AResource{A: [4]byte{10, 0, 0, 1}}),
+		rr("big.example.com.", dnsmessage.TypeA, 60, &dnsmessage.AResource{A: [4]byte{10, 0, 0, 2}}),
+		rr("big.example.com.", dnsmessage.TypeA, 60, &dnsmessage.AResource{A: [4]byte{10, 0, 0, 3}}),
+		rr("big.example.com.", dnsmessage.TypeA, 60, &dnsmessage.AResource{A: [4]byte{10, 0, 0, 4}}),
+		rr("big.example.com.", dnsmessage.TypeA, 60, &dnsmessage.AResource{A: [4]byte{10, 0, 0, 5}}),
+	},
+}
+
+func (s *testServer) respond(network string, query []byte) []byte {
+	var msg dnsmessage.Message
+	if err := msg.Unpack(query); err != nil {
+		return nil
+	}
+	q := msg.Questions[0]
+	key := strings.TrimPrefix(q.Type.String(), "Type") + " " + strings.ToLower(q.Name.String())
+
+	s.mut.Lock()
+	s.queries = append(s.queries, network+" "+key)
+	s.mut.Unlock()
+
+	res := dnsmessage.Message{
+		Header:    dnsmessage.Header{ID: msg.ID, Response: true, RecursionDesired: true, RecursionAvailable: true},
+		Questions: msg.Questions,
+	}
+	switch {
+	case strings.HasPrefix(q.Name.String(), "fail."):
+		res.RCode = dnsmessage.RCodeServerFailure
+	case strings.HasPrefix(q.Name.String(), "missing."):
+		res.RCode = dnsmessage.RCodeNameError
+		res.Authorities = []dnsmessage.Resource{
+			rr("example.com.", dnsmessage.TypeSOA, 300, &dnsmessage.SOAResource{
+				NS:     dnsmessage.MustNewName("ns.example.com."),
+				MBox:   dnsmessage.MustNewName("admin.example.com."),
+				MinTTL: 30,
+			}),
+		}
+	default:
+		res.Answers = testZone[key]
+		if network == "udp" && len(res.Answers) > 4 {
+			res.Answers, res.Truncated = nil, true
+		}
+	}
+
+	b, _ := res.Pack()
+	return b
+}
+
+func newTestServer(t *testing.T) (*testServer, string) {
+	t.Helper()
+
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = pc.Close()
+	})
+
+	ln, err := net.Listen("tcp", pc.LocalAddr().String())
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = ln.Close()
+	})
+
+	s := &testServer{}
+	go func() {
+		buf := make([]byte, 65536)
+		for {
+			n, addr, err := pc.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			if res := s.respond("udp", buf[:n]); res != nil {
+				_, _ = pc.WriteTo(res, addr)
+			}
+		}
+	}()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				var l [2]byte
+				if _, err := io.ReadFull(conn, l[:]); err != nil {
+					return
+				}
+				query := make([]byte, binary.BigEndian.Uint16(l[:]))
+				if _, err := io.ReadFull(conn, query); err != nil {
+					return
+				}
+				res := s.respond("tcp", query)
+				binary.BigEndian.PutUint16(l[:], uint16(len(res)))
+				_, _ = conn.Write(append(l[:], res...))
+			}()
+		}
+	}()
+	return s, pc.LocalAddr().String()
+}
+
+func newTestProcessor(t *testing.T, conf string) *processor {
+	t.Helper()
+
+	parsed, err := processorConfig().ParseYAML(conf, nil)
+	require.NoError(t, err)
+
+	p, err := newProcessorFromConfig(parsed, nil)
+	require.NoError(t, err)
+	return p
+}
+
+func processName(t *testing.T, p *processor, name string) (interface{}, error) {
+	t.Helper()
+
+	res, err := p.Process(context.Background(), service.NewMessage([]byte(name)))
+	if err != nil {
+		return nil, err
+	}
+	require.Len(t, res, 1)
+	v, err := res[0].AsStructured()
+	require.NoError(t, err)
+	return v, nil
+}
+
+func TestProcessorRecordTypes(t *testing.T) {
+	_, addr := newTestServer(t)
+
+	tests := []struct {
+		recordType string
+		name       string
+		expected   interface{}
+	}{
+		{recordType: "A", name: "example.com", expected: []interface{}{"192.0.2.1", "192.0.2.2"}},
+		{recordType: "A", name: "WWW.example.com.", expected: []interface{}{"192.0.2.1"}},
+		{recordType: "A", name: "big.example.com", expected: []interface{}{"10.0.0.1", "10.0.0.2", "10.0.0.3", "10.0.0.4", "10.0.0.5"}},
+		{recordType: "A", name: "missing.example.com", expected: []interface{}{}},
+		{recordType: "AAAA", name: "example.com", expected: []interface{}{"2001:db8::1"}},
+		{recordType: "PTR", name: "192.0.2.1", expected: []interface{}{"host.example.com"}},
+		{recordType: "PTR", name: "2001:db8::1", expected: []interface{}{"host6.example.com"}},
+		{recordType: "PTR", name: "1.2.0.192.in-addr.arpa", expected: []interface{}{"host.example.com"}},
+		{recordType: "TXT", name: "example.com", expected: []interface{}{"v=spf1 -all"}},
+		{recordType: "MX", name: "example.com", expected: []interface{}{
+			map[string]interface{}{"host": "mail.example.com", "preference": int64(10)},
+		}},
+	}
+
+	for _, test := range tests {
+		p := newTestProcessor(t, `
+record_type: `+test.recordType+`
+resolvers: [ `+addr+` ]
+`)
+		v, err := processName(t, p, test.name)
+		require.NoError(t, err, test.name)
+		assert.Equal(t, test.expected, v, test.name)
+	}
+}
+
+func TestProcessorCache(t *testing.T) {
+	s, addr := newTestServer(t)
+
+	p := newTestProcessor(t, `
+name: ${! json("host") }
+resolvers: [ `+addr+` ]
+max_ttl: 45s
+`)
+	now := time.Now()
+	p.cache.now = func() time.Time { return now }
+
+	lookup := func(host string) {
+		t.Helper()
+		_, err := p.Process(context.Background(), service.NewMessage([]byte(`{"host":"`+host+`"}`)))
+		require.NoError(t, err)
+	}
+
+	lookup("example.com")
+	lookup("www.example.com")
+	lookup("missing.example.com")
+	lookup("Example.com")
+	lookup("www.example.com")
+	lookup("missing.example.com")
+
+	// The answer for www is cached for the TTL of its alias, and the answer
+	// for example.com is cached for the maximum TTL.
+	now = now.Add(31 * time.Second)
+	lookup("example.com")
+	lookup("www.example.com")
+	lookup("missing.example.com")
+
+	now = now.Add(15 * time.Second)
+	lookup("example.com")
+
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	assert.Equal(t, []string{
+		"udp A example.com.",
+		"udp A www.example.com.",
+		"udp A missing.example.com.",
+		"udp A www.example.com.",
+		"udp A missing.example.com.",
+		"udp A example.com.",
+	}, s.queries)
+}
+
+func TestProcessorTruncated(t *testing.T) {
+	s, addr := newTestServer(t)
+
+	p := newTestProcessor(t, `
+resolvers: [ `+addr+` ]
+cache_size: 0
+`)
+	_, err := processName(t, p, "big.example.com")
+	require.NoError(t, err)
+
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	assert.Equal(t, []string{"udp A big.example.com.", "tcp A big.example.com."}, s.queries)
+}
+
+func TestProcessorResolvers(t *testing.T) {
+	s, addr := newTestServer(t)
+
+	// Nothing listens on the address of a closed socket, and so queries sent
+	// to it are refused.
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	deadAddr := pc.LocalAddr().String()
+	require.NoError(t, pc.Close())
+
+	p := newTestProcessor(t, `
+resolvers: [ `+deadAddr+`, `+addr+` ]
+timeout: 1s
+`)
+	v, err := processName(t, p, "example.com")
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{"192.0.2.1", "192.0.2.2"}, v)
+
+	_, err = processName(t, p, "fail.example.com")
+	assert.EqualError(t, err, "server "+addr+" responded with ServerFailure")
+
+	_, err = processName(t, p, "")
+	assert.EqualError(t, err, "name to look up is empty")
+
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	assert.Equal(t, []string{"udp A example.com.", "udp A fail.example.com."}, s.queries)
+}
+
+func TestReverseName(t *testing.T) {
+	assert.Equal(t, "4.3.2.1.in-addr.arpa.", reverseName(net.ParseIP("1.2.3.4")))
+	assert.Equal(t, "1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa.", reverseName(net.ParseIP("2001:db8::1")))
+}
+
+func TestServerAddress(t *testing.T) {
+	assert.Equal(t, "1.1.1.1:53", serverAddress("1.1.1.1"))
+	assert.Equal(t, "1.1.1.1:5353", serverAddress("1.1.1.1:5353"))
+	assert.Equal(t, "[2606:4700::1111]:53", serverAddress("2606:4700::1111"))
+	assert.Equal(t, "[2606:4700::1111]:53", serverAddress("[2606:4700::1111]"))
+}
+
+func TestIsResponse(t *testing.T) {
+	q := dnsmessage.Question{Name: dnsmessage.MustNewName("example.com."), Type: dnsmessage.TypeA, Class: dnsmessage.ClassINET}
+	pack := func(id uint16, response bool, questions ...dnsmessage.Question) []byte {
+		b, err := (&dnsmessage.Message{
+			Header:    dnsmessage.Header{ID: id, Response: response},
+			Questions: questions,
+		}).Pack()
+		require.NoError(t, err)
+		return b
+	}
+
+	upper := q
+	upper.Name = dnsmessage.MustNewName("EXAMPLE.com.")
+	assert.True(t, isResponse(pack(10, true, q), 10, q))
+	assert.True(t, isResponse(pack(10, true, upper), 10, q))
+
+	other := q
+	other.Name = dnsmessage.MustNewName("evil.com.")
+	aaaa := q
+	aaaa.Type = dnsmessage.TypeAAAA
+	assert.False(t, isResponse(pack(11, true, q), 10, q))
+	assert.False(t, isResponse(pack(10, false, q), 10, q))
+	assert.False(t, isResponse(pack(10, true, other), 10, q))
+	assert.False(t, isResponse(pack(10, true, aaaa), 10, q))
+	assert.False(t, isResponse(pack(10, true), 10, q))
+	assert.False(t, isResponse([]byte{0, 10}, 10, q))
+}
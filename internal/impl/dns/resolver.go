@@ -0,0 +1,301 @@
+package dns
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// The UDP payload size advertised with EDNS, which avoids fragmentation on
+// most networks.
+const udpPayloadSize = 1232
+
+// rcodeError is returned when a server responds with a failure code.
+type rcodeError struct {
+	server string
+	rcode  dnsmessage.RCode
+}
+
+func (e *rcodeError) Error() string {
+	return fmt.Sprintf("server %v responded with %v", e.server, strings.TrimPrefix(e.rcode.String(), "RCode"))
+}
+
+// systemServers returns the name servers of /etc/resolv.conf, or the local
+// host when there are none.
+func systemServers(path string) []string {
+	var servers []string
+	if f, err := os.Open(path); err == nil {
+		defer f.Close()
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			fields := strings.Fields(scanner.Text())
+			if len(fields) == 2 && fields[0] == "nameserver" {
+				servers = append(servers, fields[1])
+			}
+		}
+	}
+	if len(servers) == 0 {
+		servers = []string{"127.0.0.1"}
+	}
+	return servers
+}
+
+// serverAddress adds the default port to the address of a server when it is
+// missing.
+func serverAddress(s string) string {
+	if _, _, err := net.SplitHostPort(s); err == nil {
+		return s
+	}
+	return net.JoinHostPort(strings.Trim(s, "[]"), "53")
+}
+
+// answer is the result of a query, where records are the resources of the
+// answer section matching the type queried, and ttl is the period that the
+// answer may be cached for. Queries of names that do not exist result in an
+// answer without records, cached for the negative TTL of the zone.
+type answer struct {
+	records []dnsmessage.Resource
+	ttl     time.Duration
+}
+
+// resolver sends queries to a list of servers, trying each in turn until one
+// responds.
+type resolver struct {
+	servers []string
+	timeout time.Duration
+}
+
+func (r *resolver) lookup(ctx context.Context, name string, qtype dnsmessage.Type) (answer, error) {
+	qname, err := dnsmessage.NewName(dnsName(name))
+	if err != nil {
+		return answer{}, fmt.Errorf("invalid name %q: %w", name, err)
+	}
+
+	var lastErr error
+	for _, server := range r.servers {
+		var a answer
+		if a, lastErr = r.exchange(ctx, server, qname, qtype); lastErr == nil {
+			return a, nil
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return answer{}, lastErr
+}
+
+func dnsName(name string) string {
+	if !strings.HasSuffix(name, ".") {
+		name += "."
+	}
+	return name
+}
+
+// newID returns a random query ID, which must be unpredictable in order to
+// prevent spoofed responses from being accepted.
+func newID() (uint16, error) {
+	var b [2]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint16(b[:]), nil
+}
+
+func (r *resolver) exchange(ctx context.Context, server string, qname dnsmessage.Name, qtype dnsmessage.Type) (answer, error) {
+	id, err := newID()
+	if err != nil {
+		return answer{}, err
+	}
+	q := dnsmessage.Question{Name: qname, Type: qtype, Class: dnsmessage.ClassINET}
+	query, err := buildQuery(id, q)
+	if err != nil {
+		return answer{}, err
+	}
+
+	res, err := r.roundTrip(ctx, "udp", server, id, q, query)
+	if err != nil {
+		return answer{}, err
+	}
+
+	var p dnsmessage.Parser
+	h, err := p.Start(res)
+	if err != nil {
+		return answer{}, err
+	}
+	if h.Truncated {
+		if res, err = r.roundTrip(ctx, "tcp", server, id, q, query); err != nil {
+			return answer{}, err
+		}
+		if h, err = p.Start(res); err != nil {
+			return answer{}, err
+		}
+	}
+	return parseAnswer(server, h, &p, qtype)
+}
+
+func buildQuery(id uint16, q dnsmessage.Question) ([]byte, error) {
+	b := dnsmessage.NewBuilder(make([]byte, 0, 512), dnsmessage.Header{ID: id, RecursionDesired: true})
+	b.EnableCompression()
+	if err := b.StartQuestions(); err != nil {
+		return nil, err
+	}
+	if err := b.Question(q); err != nil {
+		return nil, err
+	}
+	if err := b.StartAdditionals(); err != nil {
+		return nil, err
+	}
+	var opt dnsmessage.ResourceHeader
+	if err := opt.SetEDNS0(udpPayloadSize, dnsmessage.RCodeSuccess, false); err != nil {
+		return nil, err
+	}
+	if err := b.OPTResource(opt, dnsmessage.OPTResource{}); err != nil {
+		return nil, err
+	}
+	return b.Finish()
+}
+
+// isResponse returns whether a message is a response to a query, having the
+// same ID and echoing its question.
+func isResponse(b []byte, id uint16, q dnsmessage.Question) bool {
+	var p dnsmessage.Parser
+	h, err := p.Start(b)
+	if err != nil || h.ID != id || !h.Response {
+		return false
+	}
+	questions, err := p.AllQuestions()
+	if err != nil || len(questions) != 1 {
+		return false
+	}
+	return questions[0].Type == q.Type &&
+		questions[0].Class == q.Class &&
+		strings.EqualFold(questions[0].Name.String(), q.Name.String())
+}
+
+// roundTrip sends a query to a server and returns the first response to it,
+// where messages sent over TCP are prefixed with their length.
+func (r *resolver) roundTrip(ctx context.Context, network, server string, id uint16, q dnsmessage.Question, query []byte) ([]byte, error) {
+	dialer := net.Dialer{Timeout: r.timeout}
+	conn, err := dialer.DialContext(ctx, network, server)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	deadline := time.Now().Add(r.timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	_ = conn.SetDeadline(deadline)
+
+	if network == "tcp" {
+		framed := make([]byte, 2, len(query)+2)
+		binary.BigEndian.PutUint16(framed, uint16(len(query)))
+		if _, err := conn.Write(append(framed, query...)); err != nil {
+			return nil, err
+		}
+
+		var l [2]byte
+		if _, err := io.ReadFull(conn, l[:]); err != nil {
+			return nil, err
+		}
+		res := make([]byte, binary.BigEndian.Uint16(l[:]))
+		if _, err := io.ReadFull(conn, res); err != nil {
+			return nil, err
+		}
+		if !isResponse(res, id, q) {
+			return nil, errors.New("server sent a message that is not a response to the query")
+		}
+		return res, nil
+	}
+
+	if _, err := conn.Write(query); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, udpPayloadSize)
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			return nil, err
+		}
+		// Responses that do not match the query are ignored, as they are
+		// either late responses to earlier queries or spoofed.
+		if isResponse(buf[:n], id, q) {
+			return buf[:n], nil
+		}
+	}
+}
+
+func parseAnswer(server string, h dnsmessage.Header, p *dnsmessage.Parser, qtype dnsmessage.Type) (answer, error) {
+	if !h.Response {
+		return answer{}, errors.New("server sent a message that is not a response")
+	}
+	if err := p.SkipAllQuestions(); err != nil {
+		return answer{}, err
+	}
+
+	switch h.RCode {
+	case dnsmessage.RCodeSuccess:
+	case dnsmessage.RCodeNameError:
+		if err := p.SkipAllAnswers(); err != nil {
+			return answer{}, err
+		}
+		return answer{ttl: negativeTTL(p)}, nil
+	default:
+		return answer{}, &rcodeError{server: server, rcode: h.RCode}
+	}
+
+	resources, err := p.AllAnswers()
+	if err != nil {
+		return answer{}, err
+	}
+
+	var a answer
+	ttl := uint32(0)
+	for i, res := range resources {
+		// The TTL of an answer is the lowest of the records within it,
+		// including aliases of the name queried.
+		if i == 0 || res.Header.TTL < ttl {
+			ttl = res.Header.TTL
+		}
+		if res.Header.Type == qtype {
+			a.records = append(a.records, res)
+		}
+	}
+	if len(a.records) == 0 {
+		a.ttl = negativeTTL(p)
+		return a, nil
+	}
+	a.ttl = time.Duration(ttl) * time.Second
+	return a, nil
+}
+
+// negativeTTL returns the period that an answer without records may be cached
+// for, which is the lowest of the TTL and the minimum field of the SOA record
+// provided in the authority section, as per RFC 2308.
+func negativeTTL(p *dnsmessage.Parser) time.Duration {
+	authorities, err := p.AllAuthorities()
+	if err != nil {
+		return 0
+	}
+	for _, res := range authorities {
+		if soa, ok := res.Body.(*dnsmessage.SOAResource); ok {
+			ttl := res.Header.TTL
+			if soa.MinTTL < ttl {
+				ttl = soa.MinTTL
+			}
+			return time.Duration(ttl) * time.Second
+		}
+	}
+	return 0
+}
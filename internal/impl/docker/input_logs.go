@@ -0,0 +1,611 @@
+package docker
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	dlFieldHost                  = "host"
+	dlFieldTLS                   = "tls"
+	dlFieldLabels                = "labels"
+	dlFieldStdout                = "stdout"
+	dlFieldStderr                = "stderr"
+	dlFieldStartFromOldest       = "start_from_oldest"
+	dlFieldDiscoveryInterval     = "discovery_interval"
+	dlFieldMultiline             = "multiline"
+	dlFieldMultilineStartPattern = "start_pattern"
+	dlFieldMultilineMaxLines     = "max_lines"
+	dlFieldMultilineTimeout      = "timeout"
+)
+
+func logsInputConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		// Stable(). TODO
+		Categories("Services").
+		Version("4.3.0").
+		Summary("Tails the stdout and stderr logs of running containers with the Docker Engine API, discovering containers by their labels.").
+		Description(`
+Containers are discovered by listing the running containers that match all of the configured labels every `+"`discovery_interval`"+`, and the logs of each discovered container are followed until the container stops. Containers that are restarted are resumed from the last log line consumed, which allows this input to serve as a lightweight log shipper.
+
+The Docker Engine API is also served by Podman, and the address of the API can be configured with the field `+"`host`"+` or the standard `+"`DOCKER_HOST`, `DOCKER_API_VERSION`, `DOCKER_CERT_PATH` and `DOCKER_TLS_VERIFY`"+` environment variables. Containers that are managed by containerd directly rather than via the Docker Engine are not supported.
+
+Log lines are consumed as raw messages. When a `+"`multiline.start_pattern`"+` is set, lines that do not match the pattern are appended to the preceding line separated by a newline, which allows entries that span multiple lines such as stack traces to be consumed as single messages.
+
+Log lines are not acknowledged, and logs written while Benthos is not running are only consumed when `+"`start_from_oldest`"+` is enabled, in which case logs of containers that are still running may be consumed more than once.
+
+### Metadata
+
+This input adds the following metadata fields to each message:
+
+`+"```text"+`
+- docker_container_id
+- docker_container_name
+- docker_container_image
+- docker_stream
+- docker_timestamp
+- docker_label_* (for each label of the container)
+`+"```"+`
+
+You can access these metadata fields using [function interpolation](/docs/configuration/interpolation#metadata).`).
+		Field(service.NewStringField(dlFieldHost).
+			Description("The address of the Docker Engine API. When empty the address of the `DOCKER_HOST` environment variable is used, or the default socket of the platform otherwise.").
+			Example("unix:///var/run/docker.sock").
+			Example("tcp://localhost:2376").
+			Default("")).
+		Field(service.NewTLSToggledField(dlFieldTLS)).
+		Field(service.NewStringListField(dlFieldLabels).
+			Description("A list of labels that containers must have in order to be consumed from, either as a label name or a `name=value` pair. When empty the logs of all running containers are consumed.").
+			Example([]string{"logs.benthos.enabled=true"}).
+			Example([]string{"com.docker.compose.project=foo", "environment"}).
+			Default([]string{})).
+		Field(service.NewBoolField(dlFieldStdout).
+			Description("Whether to consume the stdout logs of containers.").
+			Default(true)).
+		Field(service.NewBoolField(dlFieldStderr).
+			Description("Whether to consume the stderr logs of containers.").
+			Default(true)).
+		Field(service.NewBoolField(dlFieldStartFromOldest).
+			Description("Whether to consume the logs that containers wrote before this input started. When false only logs written after this input started are consumed.").
+			Default(false)).
+		Field(service.NewDurationField(dlFieldDiscoveryInterval).
+			Description("The period between listing containers in order to discover containers that have started.").
+			Default("5s").
+			Advanced()).
+		Field(service.NewObjectField(dlFieldMultiline,
+			service.NewStringField(dlFieldMultilineStartPattern).
+				Description("A regular expression that matches the first line of each log entry. When empty each line is consumed as a message.").
+				Example(`^\d{4}-\d{2}-\d{2}`).
+				Example(`^\S`).
+				Default(""),
+			service.NewIntField(dlFieldMultilineMaxLines).
+				Description("The maximum number of lines of a merged log entry, after which subsequent lines begin a new entry.").
+				Default(500),
+			service.NewDurationField(dlFieldMultilineTimeout).
+				Description("The maximum period to wait for subsequent lines of a log entry before it is consumed.").
+				Default("1s"),
+		).
+			Description("Configures the merging of log entries that span multiple lines.").
+			Advanced()).
+		Example("Compose Project", "Consume the logs of all containers of a Docker Compose project, merging Java stack traces into the entries they belong to, and enrich them with the name of the service they were written by.", `
+input:
+  docker_logs:
+    labels: [ com.docker.compose.project=shop ]
+    multiline:
+      start_pattern: '^\d{4}-\d{2}-\d{2}'
+
+pipeline:
+  processors:
+    - bloblang: |
+        root.message = content().string()
+        root.service = meta("docker_label_com.docker.compose.service")
+        root.stream = meta("docker_stream")
+        root.timestamp = meta("docker_timestamp")
+`)
+}
+
+func init() {
+	err := service.RegisterInput(
+		"docker_logs", logsInputConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Input, error) {
+			return newLogsInputFromConfig(conf, mgr.Logger())
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type logsInput struct {
+	clientOpts        []client.Opt
+	filters           filters.Args
+	stdout            bool
+	stderr            bool
+	startFromOldest   bool
+	discoveryInterval time.Duration
+	merger            *multilineMerger
+	log               *service.Logger
+
+	cursorsMut sync.Mutex
+	since      time.Time
+	cursors    map[string]time.Time
+	tailing    map[string]struct{}
+
+	connMut  sync.Mutex
+	client   *client.Client
+	msgs     chan *service.Message
+	closeFn  func()
+	loopDone chan struct{}
+}
+
+func newLogsInputFromConfig(conf *service.ParsedConfig, log *service.Logger) (*logsInput, error) {
+	d := &logsInput{
+		clientOpts: []client.Opt{client.FromEnv, client.WithAPIVersionNegotiation()},
+		filters:    filters.NewArgs(),
+		merger:     &multilineMerger{},
+		log:        log,
+		cursors:    map[string]time.Time{},
+		tailing:    map[string]struct{}{},
+	}
+
+	host, err := conf.FieldString(dlFieldHost)
+	if err != nil {
+		return nil, err
+	}
+	if host != "" {
+		d.clientOpts = append(d.clientOpts, client.WithHost(host))
+	}
+
+	tlsConf, tlsEnabled, err := conf.FieldTLSToggled(dlFieldTLS)
+	if err != nil {
+		return nil, err
+	}
+	if tlsEnabled {
+		d.clientOpts = append(d.clientOpts, client.WithHTTPClient(&http.Client{
+			Transport: &http.Transport{TLSClientConfig: tlsConf},
+		}))
+	}
+
+	labels, err := conf.FieldStringList(dlFieldLabels)
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range labels {
+		d.filters.Add("label", l)
+	}
+
+	if d.stdout, err = conf.FieldBool(dlFieldStdout); err != nil {
+		return nil, err
+	}
+	if d.stderr, err = conf.FieldBool(dlFieldStderr); err != nil {
+		return nil, err
+	}
+	if !d.stdout && !d.stderr {
+		return nil, errors.New("at least one of stdout and stderr must be enabled")
+	}
+	if d.startFromOldest, err = conf.FieldBool(dlFieldStartFromOldest); err != nil {
+		return nil, err
+	}
+	if d.discoveryInterval, err = conf.FieldDuration(dlFieldDiscoveryInterval); err != nil {
+		return nil, err
+	}
+
+	mConf := conf.Namespace(dlFieldMultiline)
+	pattern, err := mConf.FieldString(dlFieldMultilineStartPattern)
+	if err != nil {
+		return nil, err
+	}
+	if pattern != "" {
+		if d.merger.startPattern, err = regexp.Compile(pattern); err != nil {
+			return nil, fmt.Errorf("failed to parse multiline start pattern: %w", err)
+		}
+	}
+	if d.merger.maxLines, err = mConf.FieldInt(dlFieldMultilineMaxLines); err != nil {
+		return nil, err
+	}
+	if d.merger.timeout, err = mConf.FieldDuration(dlFieldMultilineTimeout); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func (d *logsInput) Connect(ctx context.Context) error {
+	d.connMut.Lock()
+	defer d.connMut.Unlock()
+
+	if d.msgs != nil {
+		return nil
+	}
+
+	if d.client == nil {
+		c, err := client.NewClientWithOpts(d.clientOpts...)
+		if err != nil {
+			return err
+		}
+		d.client = c
+	}
+	if _, err := d.client.Ping(ctx); err != nil {
+		return err
+	}
+
+	d.cursorsMut.Lock()
+	if !d.startFromOldest && d.since.IsZero() {
+		d.since = time.Now()
+	}
+	d.cursorsMut.Unlock()
+
+	loopCtx, cancel := context.WithCancel(context.Background())
+	d.msgs = make(chan *service.Message)
+	d.closeFn = cancel
+	d.loopDone = make(chan struct{})
+	go d.discoverLoop(loopCtx, d.msgs, d.loopDone)
+	return nil
+}
+
+// discoverLoop periodically lists containers and tails the logs of those that
+// are running and not already being tailed.
+func (d *logsInput) discoverLoop(ctx context.Context, msgs chan<- *service.Message, done chan struct{}) {
+	var wg sync.WaitGroup
+	defer func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	ticker := time.NewTicker(d.discoveryInterval)
+	defer ticker.Stop()
+
+	for {
+		containers, err := d.client.ContainerList(ctx, types.ContainerListOptions{
+			All:     true,
+			Filters: d.filters,
+		})
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			d.log.Errorf("Failed to list containers: %v", err)
+		} else {
+			for _, id := range d.syncContainers(containers) {
+				wg.Add(1)
+				go func(id string) {
+					defer wg.Done()
+					if err := d.tail(ctx, id, msgs); err != nil && ctx.Err() == nil {
+						d.log.Errorf("Failed to tail logs of container %v: %v", id, err)
+					}
+					d.cursorsMut.Lock()
+					delete(d.tailing, id)
+					d.cursorsMut.Unlock()
+				}(id)
+			}
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// syncContainers returns the IDs of running containers that are not already
+// being tailed, marking them as tailed, and forgets the cursors of containers
+// that have been removed.
+func (d *logsInput) syncContainers(containers []types.Container) (ids []string) {
+	d.cursorsMut.Lock()
+	defer d.cursorsMut.Unlock()
+
+	exists := make(map[string]struct{}, len(containers))
+	for _, c := range containers {
+		exists[c.ID] = struct{}{}
+		if c.State != "running" {
+			continue
+		}
+		if _, tailing := d.tailing[c.ID]; tailing {
+			continue
+		}
+		d.tailing[c.ID] = struct{}{}
+		ids = append(ids, c.ID)
+	}
+	for id := range d.cursors {
+		if _, ok := exists[id]; !ok {
+			delete(d.cursors, id)
+		}
+	}
+	return
+}
+
+func (d *logsInput) cursor(id string) time.Time {
+	d.cursorsMut.Lock()
+	defer d.cursorsMut.Unlock()
+	if c, exists := d.cursors[id]; exists {
+		return c
+	}
+	return d.since
+}
+
+func (d *logsInput) setCursor(id string, t time.Time) {
+	d.cursorsMut.Lock()
+	if t.After(d.cursors[id]) {
+		d.cursors[id] = t
+	}
+	d.cursorsMut.Unlock()
+}
+
+// tail follows the logs of a container until it stops or the context is
+// cancelled.
+func (d *logsInput) tail(ctx context.Context, id string, msgs chan<- *service.Message) error {
+	info, err := d.client.ContainerInspect(ctx, id)
+	if err != nil {
+		return err
+	}
+	meta := containerMetadata(info)
+
+	var since string
+	if c := d.cursor(id); !c.IsZero() {
+		// Logs are returned from the since timestamp inclusive.
+		c = c.Add(time.Nanosecond)
+		since = fmt.Sprintf("%d.%09d", c.Unix(), c.Nanosecond())
+	}
+
+	rc, err := d.client.ContainerLogs(ctx, id, types.ContainerLogsOptions{
+		ShowStdout: d.stdout,
+		ShowStderr: d.stderr,
+		Follow:     true,
+		Timestamps: true,
+		Since:      since,
+	})
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	d.log.Debugf("Tailing logs of container %v\n", meta["docker_container_name"])
+
+	emit := func(l logLine) bool {
+		msg := service.NewMessage([]byte(l.text))
+		for k, v := range meta {
+			msg.MetaSet(k, v)
+		}
+		msg.MetaSet("docker_stream", l.stream)
+		msg.MetaSet("docker_timestamp", l.timestamp.Format(time.RFC3339Nano))
+
+		select {
+		case msgs <- msg:
+		case <-ctx.Done():
+			return false
+		}
+		d.setCursor(id, l.last)
+		return true
+	}
+
+	var wg sync.WaitGroup
+	readStream := func(r io.Reader, stream string) {
+		defer wg.Done()
+		lines := make(chan logLine)
+		go scanLines(ctx, r, stream, lines)
+		d.merger.merge(ctx, lines, emit)
+	}
+
+	if info.Config != nil && info.Config.Tty {
+		// The logs of containers with a TTY are not multiplexed.
+		wg.Add(1)
+		readStream(rc, "stdout")
+		return nil
+	}
+
+	outR, outW := io.Pipe()
+	errR, errW := io.Pipe()
+	defer outR.Close()
+	defer errR.Close()
+	go func() {
+		_, err := stdcopy.StdCopy(outW, errW, rc)
+		_ = outW.CloseWithError(err)
+		_ = errW.CloseWithError(err)
+	}()
+
+	wg.Add(2)
+	go readStream(outR, "stdout")
+	go readStream(errR, "stderr")
+	wg.Wait()
+	return nil
+}
+
+func containerMetadata(info types.ContainerJSON) map[string]string {
+	meta := map[string]string{}
+	if info.ContainerJSONBase != nil {
+		meta["docker_container_id"] = info.ID
+		meta["docker_container_name"] = strings.TrimPrefix(info.Name, "/")
+	}
+	if info.Config != nil {
+		meta["docker_container_image"] = info.Config.Image
+		for k, v := range info.Config.Labels {
+			meta["docker_label_"+k] = v
+		}
+	}
+	return meta
+}
+
+func (d *logsInput) Read(ctx context.Context) (*service.Message, service.AckFunc, error) {
+	d.connMut.Lock()
+	msgs, loopDone := d.msgs, d.loopDone
+	d.connMut.Unlock()
+
+	if msgs == nil {
+		return nil, nil, service.ErrNotConnected
+	}
+
+	select {
+	case msg := <-msgs:
+		return msg, func(context.Context, error) error {
+			return nil
+		}, nil
+	case <-loopDone:
+		return nil, nil, service.ErrNotConnected
+	case <-ctx.Done():
+		return nil, nil, ctx.Err()
+	}
+}
+
+func (d *logsInput) Close(ctx context.Context) error {
+	d.connMut.Lock()
+	closeFn, loopDone := d.closeFn, d.loopDone
+	d.msgs, d.closeFn, d.loopDone = nil, nil, nil
+	d.connMut.Unlock()
+
+	if closeFn != nil {
+		closeFn()
+		select {
+		case <-loopDone:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	d.connMut.Lock()
+	defer d.connMut.Unlock()
+	if d.client != nil {
+		err := d.client.Close()
+		d.client = nil
+		return err
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+// logLine is a log entry of a single stream, where timestamp is the timestamp
+// of its first line and last the timestamp of its last line.
+type logLine struct {
+	stream    string
+	text      string
+	timestamp time.Time
+	last      time.Time
+}
+
+// parseLogLine splits a log line into the timestamp prefix added by the Docker
+// Engine and its contents.
+func parseLogLine(stream, line string) logLine {
+	l := logLine{stream: stream, text: line}
+	if i := strings.IndexByte(line, ' '); i > 0 {
+		if t, err := time.Parse(time.RFC3339Nano, line[:i]); err == nil {
+			l.text = line[i+1:]
+			l.timestamp = t
+		}
+	}
+	l.last = l.timestamp
+	return l
+}
+
+// scanLines reads the lines of a stream until it ends or the context is
+// cancelled, closing the lines channel on return.
+func scanLines(ctx context.Context, r io.Reader, stream string, lines chan<- logLine) {
+	defer close(lines)
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		select {
+		case lines <- parseLogLine(stream, strings.TrimSuffix(scanner.Text(), "\r")):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// multilineMerger merges log lines that do not match a start pattern into the
+// preceding line.
+type multilineMerger struct {
+	startPattern *regexp.Regexp
+	maxLines     int
+	timeout      time.Duration
+}
+
+// merge reads lines until the channel is closed or the context is cancelled,
+// calling emit with each merged entry until it returns false.
+func (m *multilineMerger) merge(ctx context.Context, lines <-chan logLine, emit func(logLine) bool) {
+	if m.startPattern == nil {
+		for {
+			select {
+			case l, open := <-lines:
+				if !open || !emit(l) {
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+
+	var pending *logLine
+	var pendingLines int
+
+	timer := time.NewTimer(m.timeout)
+	defer timer.Stop()
+	resetTimer := func() {
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(m.timeout)
+	}
+
+	flush := func() bool {
+		if pending == nil {
+			return true
+		}
+		l := *pending
+		pending, pendingLines = nil, 0
+		return emit(l)
+	}
+
+	for {
+		var timeoutChan <-chan time.Time
+		if pending != nil {
+			timeoutChan = timer.C
+		}
+
+		select {
+		case l, open := <-lines:
+			if !open {
+				flush()
+				return
+			}
+			if pending != nil && !m.startPattern.MatchString(l.text) && (m.maxLines <= 0 || pendingLines < m.maxLines) {
+				pending.text += "\n" + l.text
+				pending.last = l.last
+				pendingLines++
+				resetTimer()
+				continue
+			}
+			if !flush() {
+				return
+			}
+			pending, pendingLines = &l, 1
+			resetTimer()
+		case <-timeoutChan:
+			if !flush() {
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package docker
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDockerLogsParseLine(t *testing.T) {
+	l := parseLogLine("stderr", "2022-01-02T15:04:05.123456789Z hello world")
+	assert.Equal(t, "stderr", l.stream)
+	assert.Equal(t, "hello world", l.text)
+	assert.Equal(t, time.Date(2022, 1, 2, 15, 4, 5, 123456789, time.UTC), l.timestamp)
+	assert.Equal(t, l.timestamp, l.last)
+
+	l = parseLogLine("stdout", "no timestamp here")
+	assert.Equal(t, "no timestamp here", l.text)
+	assert.True(t, l.timestamp.IsZero())
+}
+
+func TestDockerLogsScanLines(t *testing.T) {
+	lines := make(chan logLine)
+	go scanLines(context.Background(), strings.NewReader(
+		"2022-01-02T15:04:05Z foo\r\n2022-01-02T15:04:06Z bar\n",
+	), "stdout", lines)
+
+	var texts []string
+	for l := range lines {
+		texts = append(texts, l.text)
+	}
+	assert.Equal(t, []string{"foo", "bar"}, texts)
+}
+
+func mergeAll(t *testing.T, m *multilineMerger, texts ...string) []string {
+	t.Helper()
+
+	lines := make(chan logLine, len(texts))
+	for i, text := range texts {
+		lines <- logLine{
+			text:      text,
+			timestamp: time.Unix(int64(i), 0),
+			last:      time.Unix(int64(i), 0),
+		}
+	}
+	close(lines)
+
+	var res []string
+	m.merge(context.Background(), lines, func(l logLine) bool {
+		res = append(res, l.text)
+		return true
+	})
+	return res
+}
+
+func TestDockerLogsMergeDisabled(t *testing.T) {
+	assert.Equal(t, []string{"foo", "  bar", "baz"}, mergeAll(t, &multilineMerger{}, "foo", "  bar", "baz"))
+}
+
+func TestDockerLogsMergeStartPattern(t *testing.T) {
+	m := &multilineMerger{
+		startPattern: regexp.MustCompile(`^\S`),
+		maxLines:     3,
+		timeout:      time.Second,
+	}
+
+	assert.Equal(t, []string{
+		"  orphan",
+		"panic: oh no\n  at foo\n  at bar",
+		"  at baz",
+		"next",
+	}, mergeAll(t, m, "  orphan", "panic: oh no", "  at foo", "  at bar", "  at baz", "next"))
+}
+
+func TestDockerLogsMergeTimeout(t *testing.T) {
+	m := &multilineMerger{
+		startPattern: regexp.MustCompile(`^\S`),
+		timeout:      time.Millisecond * 10,
+	}
+
+	lines := make(chan logLine)
+	emitted := make(chan logLine)
+	go m.merge(context.Background(), lines, func(l logLine) bool {
+		emitted <- l
+		return true
+	})
+
+	lines <- logLine{text: "foo", last: time.Unix(1, 0)}
+	lines <- logLine{text: "  bar", last: time.Unix(2, 0)}
+
+	select {
+	case l := <-emitted:
+		assert.Equal(t, "foo\n  bar", l.text)
+		assert.Equal(t, time.Unix(2, 0), l.last)
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for merged line")
+	}
+	close(lines)
+}
+
+func TestDockerLogsConfig(t *testing.T) {
+	conf, err := logsInputConfig().ParseYAML(`
+labels: [ foo=bar ]
+stdout: false
+stderr: false
+`, nil)
+	require.NoError(t, err)
+
+	_, err = newLogsInputFromConfig(conf, nil)
+	require.EqualError(t, err, "at least one of stdout and stderr must be enabled")
+
+	conf, err = logsInputConfig().ParseYAML(`
+multiline:
+  start_pattern: '('
+`, nil)
+	require.NoError(t, err)
+
+	_, err = newLogsInputFromConfig(conf, nil)
+	require.Error(t, err)
+}
@@ -0,0 +1,79 @@
+package docker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ory/dockertest/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/integration"
+)
+
+func TestIntegrationDockerLogs(t *testing.T) {
+	integration.CheckSkip(t)
+	t.Parallel()
+
+	pool, err := dockertest.NewPool("")
+	require.NoError(t, err)
+
+	pool.MaxWait = time.Second * 30
+
+	conf, err := logsInputConfig().ParseYAML(`
+labels: [ benthos.test=docker_logs ]
+start_from_oldest: true
+discovery_interval: 100ms
+multiline:
+  start_pattern: '^\S'
+`, nil)
+	require.NoError(t, err)
+
+	in, err := newLogsInputFromConfig(conf, nil)
+	require.NoError(t, err)
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*30)
+	defer done()
+
+	require.NoError(t, in.Connect(ctx))
+	t.Cleanup(func() {
+		assert.NoError(t, in.Close(context.Background()))
+	})
+
+	resource, err := pool.RunWithOptions(&dockertest.RunOptions{
+		Repository: "busybox",
+		Tag:        "latest",
+		Labels:     map[string]string{"benthos.test": "docker_logs"},
+		Cmd:        []string{"sh", "-c", `echo foo; printf 'bar\n  baz\n'; echo oops >&2; sleep 600`},
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, pool.Purge(resource))
+	})
+
+	_ = resource.Expire(900)
+
+	byStream := map[string][]string{}
+	for i := 0; i < 3; i++ {
+		msg, _, err := in.Read(ctx)
+		require.NoError(t, err)
+
+		b, err := msg.AsBytes()
+		require.NoError(t, err)
+
+		stream, _ := msg.MetaGet("docker_stream")
+		byStream[stream] = append(byStream[stream], string(b))
+
+		id, _ := msg.MetaGet("docker_container_id")
+		assert.Equal(t, resource.Container.ID, id)
+
+		label, _ := msg.MetaGet("docker_label_benthos.test")
+		assert.Equal(t, "docker_logs", label)
+	}
+
+	assert.Equal(t, map[string][]string{
+		"stdout": {"foo", "bar\n  baz"},
+		"stderr": {"oops"},
+	}, byStream)
+}
@@ -0,0 +1,42 @@
+package gcp
+
+import (
+	"google.golang.org/api/option"
+
+	"github.com/benthosdev/benthos/v4/internal/impl/gcp/auth"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+// AuthFromParsedConfig attempts to extract an auth config from a ParsedConfig.
+func AuthFromParsedConfig(p *service.ParsedConfig) (c auth.Config, err error) {
+	c = auth.New()
+	if p.Contains("credentials_json") {
+		if c.CredentialsJSON, err = p.FieldString("credentials_json"); err != nil {
+			return
+		}
+	}
+	if p.Contains("credentials_file") {
+		if c.CredentialsFile, err = p.FieldString("credentials_file"); err != nil {
+			return
+		}
+	}
+	if p.Contains("impersonate_service_account") {
+		if c.ImpersonateServiceAccount, err = p.FieldString("impersonate_service_account"); err != nil {
+			return
+		}
+	}
+	if p.Contains("impersonate_delegates") {
+		if c.ImpersonateDelegates, err = p.FieldStringList("impersonate_delegates"); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func clientOptionsFromParsedConfig(p *service.ParsedConfig) ([]option.ClientOption, error) {
+	c, err := AuthFromParsedConfig(p.Namespace("credentials"))
+	if err != nil {
+		return nil, err
+	}
+	return auth.ClientOptions(c)
+}
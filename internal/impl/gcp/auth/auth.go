@@ -0,0 +1,46 @@
+package auth
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/api/impersonate"
+	"google.golang.org/api/option"
+)
+
+const cloudPlatformScope = "https://www.googleapis.com/auth/cloud-platform"
+
+// ClientOptions returns GCP client options for the auth fields. Each call
+// creates its own token source, and therefore tokens are cached independently
+// by each component rather than shared.
+func ClientOptions(conf Config) ([]option.ClientOption, error) {
+	if conf.CredentialsJSON != "" && conf.CredentialsFile != "" {
+		return nil, errors.New("cannot specify both credentials_json and credentials_file")
+	}
+
+	var opts []option.ClientOption
+	if conf.CredentialsJSON != "" {
+		opts = append(opts, option.WithCredentialsJSON([]byte(conf.CredentialsJSON)))
+	} else if conf.CredentialsFile != "" {
+		opts = append(opts, option.WithCredentialsFile(conf.CredentialsFile))
+	}
+
+	if conf.ImpersonateServiceAccount == "" {
+		if len(conf.ImpersonateDelegates) > 0 {
+			return nil, errors.New("impersonate_delegates requires impersonate_service_account to be set")
+		}
+		return opts, nil
+	}
+
+	// Token sources hold onto the context they were created with in order to
+	// refresh tokens, and therefore must outlive any connection attempt.
+	ts, err := impersonate.CredentialsTokenSource(context.Background(), impersonate.CredentialsConfig{
+		TargetPrincipal: conf.ImpersonateServiceAccount,
+		Scopes:          []string{cloudPlatformScope},
+		Delegates:       conf.ImpersonateDelegates,
+	}, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return []option.ClientOption{option.WithTokenSource(ts)}, nil
+}
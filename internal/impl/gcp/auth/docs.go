@@ -0,0 +1,13 @@
+package auth
+
+import "github.com/benthosdev/benthos/v4/internal/docs"
+
+// FieldSpec returns documentation authentication specs for GCP components
+func FieldSpec() docs.FieldSpec {
+	return docs.FieldObject("credentials", "Optional configuration of GCP credentials, by default Application Default Credentials are used.").WithChildren(
+		docs.FieldString("credentials_json", "The contents of a JSON key for a service account.").HasDefault(""),
+		docs.FieldString("credentials_file", "A path to a JSON key file for a service account.", "/var/secrets/google/key.json").HasDefault(""),
+		docs.FieldString("impersonate_service_account", "The email address of a service account to impersonate.", "benthos@my-project.iam.gserviceaccount.com").HasDefault(""),
+		docs.FieldString("impersonate_delegates", "An optional chain of service accounts to delegate impersonation through, where each account must be able to create tokens for the next.").Array().HasDefault([]interface{}{}),
+	).Advanced()
+}
@@ -0,0 +1,19 @@
+package auth
+
+// Config contains configuration params for GCP authentication.
+type Config struct {
+	CredentialsJSON           string   `json:"credentials_json" yaml:"credentials_json"`
+	CredentialsFile           string   `json:"credentials_file" yaml:"credentials_file"`
+	ImpersonateServiceAccount string   `json:"impersonate_service_account" yaml:"impersonate_service_account"`
+	ImpersonateDelegates      []string `json:"impersonate_delegates" yaml:"impersonate_delegates"`
+}
+
+// New creates a new Config instance
+func New() Config {
+	return Config{
+		CredentialsJSON:           "",
+		CredentialsFile:           "",
+		ImpersonateServiceAccount: "",
+		ImpersonateDelegates:      []string{},
+	}
+}
@@ -0,0 +1,52 @@
+package gcp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/impl/gcp/auth"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func TestAuthFromParsedConfig(t *testing.T) {
+	spec := service.NewConfigSpec().Field(service.NewInternalField(auth.FieldSpec()))
+
+	parsed, err := spec.ParseYAML(`
+credentials:
+  credentials_file: /foo/key.json
+  impersonate_service_account: foo@bar.iam.gserviceaccount.com
+  impersonate_delegates: [ baz@bar.iam.gserviceaccount.com ]
+`, nil)
+	require.NoError(t, err)
+
+	conf, err := AuthFromParsedConfig(parsed.Namespace("credentials"))
+	require.NoError(t, err)
+
+	exp := auth.New()
+	exp.CredentialsFile = "/foo/key.json"
+	exp.ImpersonateServiceAccount = "foo@bar.iam.gserviceaccount.com"
+	exp.ImpersonateDelegates = []string{"baz@bar.iam.gserviceaccount.com"}
+	assert.Equal(t, exp, conf)
+
+	parsed, err = spec.ParseYAML(`{}`, nil)
+	require.NoError(t, err)
+
+	opts, err := clientOptionsFromParsedConfig(parsed)
+	require.NoError(t, err)
+	assert.Empty(t, opts)
+}
+
+func TestAuthClientOptionsErrors(t *testing.T) {
+	conf := auth.New()
+	conf.CredentialsJSON = `{}`
+	conf.CredentialsFile = "/foo/key.json"
+	_, err := auth.ClientOptions(conf)
+	require.Error(t, err)
+
+	conf = auth.New()
+	conf.ImpersonateDelegates = []string{"baz@bar.iam.gserviceaccount.com"}
+	_, err = auth.ClientOptions(conf)
+	require.Error(t, err)
+}
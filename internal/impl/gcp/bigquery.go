@@ -77,7 +77,7 @@ type bqQueryBuilderOptions struct {
 	args       []interface{}
 }
 
-func buildBQQuery(client *bigquery.Client, options *bqQueryBuilderOptions) (*bigquery.Query, error) {
+func buildBQQueryString(options *bqQueryBuilderOptions) (string, []interface{}, error) {
 	queryParts := options.queryParts
 
 	builder := squirrel.
@@ -92,7 +92,11 @@ func buildBQQuery(client *bigquery.Client, options *bqQueryBuilderOptions) (*big
 		builder = builder.Suffix(queryParts.suffix)
 	}
 
-	qs, args, err := builder.PlaceholderFormat(squirrel.Question).ToSql()
+	return builder.PlaceholderFormat(squirrel.Question).ToSql()
+}
+
+func buildBQQuery(client *bigquery.Client, options *bqQueryBuilderOptions) (*bigquery.Query, error) {
+	qs, args, err := buildBQQueryString(options)
 	if err != nil {
 		return nil, fmt.Errorf("failed to build query string: %w", err)
 	}
@@ -7,6 +7,7 @@ import (
 
 	"cloud.google.com/go/storage"
 
+	"github.com/benthosdev/benthos/v4/internal/impl/gcp/auth"
 	"github.com/benthosdev/benthos/v4/public/service"
 )
 
@@ -15,7 +16,8 @@ func gcpCloudStorageCacheConfig() *service.ConfigSpec {
 		Summary(`Use a Google Cloud Storage bucket as a cache.`).
 		Description(`It is not possible to atomically upload cloud storage objects exclusively when the target does not already exist, therefore this cache is not suitable for deduplication.`).
 		Field(service.NewStringField("bucket").
-			Description("The Google Cloud Storage bucket to store items in."))
+			Description("The Google Cloud Storage bucket to store items in.")).
+		Field(service.NewInternalField(auth.FieldSpec()))
 
 	return spec
 }
@@ -38,7 +40,12 @@ func newGcpCloudStorageCacheFromConfig(parsedConf *service.ParsedConfig) (*gcpCl
 		return nil, err
 	}
 
-	client, err := storage.NewClient(context.Background())
+	opts, err := clientOptionsFromParsedConfig(parsedConf)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := storage.NewClient(context.Background(), opts...)
 	if err != nil {
 		return nil, err
 	}
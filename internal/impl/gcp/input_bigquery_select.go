@@ -7,7 +7,9 @@ import (
 
 	"cloud.google.com/go/bigquery"
 	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
 
+	"github.com/benthosdev/benthos/v4/internal/impl/gcp/auth"
 	"github.com/benthosdev/benthos/v4/internal/shutdown"
 	"github.com/benthosdev/benthos/v4/public/bloblang"
 	"github.com/benthosdev/benthos/v4/public/service"
@@ -18,6 +20,8 @@ type bigQuerySelectInputConfig struct {
 	queryParts  *bqQueryParts
 	argsMapping *bloblang.Executor
 	jobLabels   map[string]string
+
+	clientOptions []option.ClientOption
 }
 
 func bigQuerySelectInputConfigFromParsed(inConf *service.ParsedConfig) (conf bigQuerySelectInputConfig, err error) {
@@ -66,6 +70,10 @@ func bigQuerySelectInputConfigFromParsed(inConf *service.ParsedConfig) (conf big
 		}
 	}
 
+	if conf.clientOptions, err = clientOptionsFromParsedConfig(inConf); err != nil {
+		return
+	}
+
 	return
 }
 
@@ -96,6 +104,7 @@ func newBigQuerySelectInputConfig() *service.ConfigSpec {
 		Field(service.NewStringField("suffix").
 			Description("An optional suffix to append to the select query.").
 			Optional()).
+		Field(service.NewInternalField(auth.FieldSpec())).
 		Example("Word counts",
 			`
 Here we query the public corpus of Shakespeare's works to generate a stream of the top 10 words that are 3 or more characters long:`,
@@ -149,7 +158,7 @@ func (inp *bigQuerySelectInput) Connect(ctx context.Context) error {
 	jobctx, _ := inp.shutdownSig.CloseAtLeisureCtx(context.Background())
 
 	if inp.client == nil {
-		client, err := bigquery.NewClient(jobctx, inp.config.project)
+		client, err := bigquery.NewClient(jobctx, inp.config.project, inp.config.clientOptions...)
 		if err != nil {
 			return fmt.Errorf("failed to create bigquery client: %w", err)
 		}
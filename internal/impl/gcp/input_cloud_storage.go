@@ -20,6 +20,7 @@ import (
 	"github.com/benthosdev/benthos/v4/internal/component/input/processors"
 	"github.com/benthosdev/benthos/v4/internal/component/metrics"
 	"github.com/benthosdev/benthos/v4/internal/docs"
+	"github.com/benthosdev/benthos/v4/internal/impl/gcp/auth"
 	"github.com/benthosdev/benthos/v4/internal/log"
 	"github.com/benthosdev/benthos/v4/internal/message"
 )
@@ -63,12 +64,16 @@ You can access these metadata fields using [function interpolation](/docs/config
 ### Credentials
 
 By default Benthos will use a shared credentials file when connecting to GCP
-services. You can find out more [in this document](/docs/guides/cloud/gcp).`,
+services. It's also possible to set them explicitly at the component level with
+the ` + "`credentials`" + ` fields, including the impersonation of a service account,
+allowing you to access services across projects. You can find out more
+[in this document](/docs/guides/cloud/gcp).`,
 		Config: docs.FieldComponent().WithChildren(
 			docs.FieldString("bucket", "The name of the bucket from which to download objects."),
 			docs.FieldString("prefix", "An optional path prefix, if set only objects with the prefix are consumed."),
 			codec.ReaderDocs,
 			docs.FieldBool("delete_objects", "Whether to delete downloaded objects from the bucket once they are processed.").Advanced(),
+			auth.FieldSpec(),
 		).ChildDefaultAndTypesFromStruct(input.NewGCPCloudStorageConfig()),
 	})
 	if err != nil {
@@ -231,8 +236,11 @@ func newGCPCloudStorageInput(conf input.GCPCloudStorageConfig, log log.Modular,
 // ConnectWithContext attempts to establish a connection to the target Google
 // Cloud Storage bucket.
 func (g *gcpCloudStorageInput) ConnectWithContext(ctx context.Context) error {
-	var err error
-	g.client, err = storage.NewClient(context.Background())
+	opts, err := auth.ClientOptions(g.conf.Credentials)
+	if err != nil {
+		return err
+	}
+	g.client, err = storage.NewClient(context.Background(), opts...)
 	if err != nil {
 		return err
 	}
@@ -2,6 +2,8 @@ package gcp
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"strconv"
 	"sync"
 	"time"
@@ -14,6 +16,7 @@ import (
 	"github.com/benthosdev/benthos/v4/internal/component/input/processors"
 	"github.com/benthosdev/benthos/v4/internal/component/metrics"
 	"github.com/benthosdev/benthos/v4/internal/docs"
+	"github.com/benthosdev/benthos/v4/internal/impl/gcp/auth"
 	"github.com/benthosdev/benthos/v4/internal/log"
 	"github.com/benthosdev/benthos/v4/internal/message"
 )
@@ -34,11 +37,22 @@ This input adds the following metadata fields to each message:
 
 ` + "``` text" + `
 - gcp_pubsub_publish_time_unix
+- gcp_pubsub_ordering_key (when set)
 - All message attributes
 ` + "```" + `
 
 You can access these metadata fields using
-[function interpolation](/docs/configuration/interpolation#metadata).`,
+[function interpolation](/docs/configuration/interpolation#metadata).
+
+### Exactly-Once Delivery
+
+When consuming from a subscription with [exactly-once delivery](https://cloud.google.com/pubsub/docs/exactly-once-delivery) enabled the field ` + "`exactly_once`" + ` should be set to ` + "`true`" + `, in which case acknowledgements are only considered successful once confirmed by Pub/Sub, and failed confirmations are logged rather than silently ignored.
+
+### Ack Deadline Extension
+
+Whilst a message is being processed its ack deadline is automatically extended, which prevents Pub/Sub from redelivering messages that take a long time to process and deliver. The field ` + "`ack_extension.max_extension`" + ` determines the total length of time a message can be held before it is given up on and becomes eligible for redelivery, and the fields ` + "`ack_extension.min_extension_period` and `ack_extension.max_extension_period`" + ` bound the length of each individual extension.
+
+With exactly-once delivery an acknowledgement fails once the deadline of a message has expired, so ` + "`max_extension`" + ` should be larger than the longest time it might take for a message to be delivered, including retries.`,
 		Categories: []string{
 			"Services",
 			"GCP",
@@ -49,6 +63,13 @@ You can access these metadata fields using
 			docs.FieldBool("sync", "Enable synchronous pull mode."),
 			docs.FieldInt("max_outstanding_messages", "The maximum number of outstanding pending messages to be consumed at a given time."),
 			docs.FieldInt("max_outstanding_bytes", "The maximum number of outstanding pending messages to be consumed measured in bytes."),
+			docs.FieldBool("exactly_once", "Whether to wait for Pub/Sub to confirm each acknowledgement, which should be enabled when consuming from a subscription with exactly-once delivery.").Advanced(),
+			docs.FieldObject("ack_extension", "Determines how the ack deadlines of messages are extended whilst they are being processed.").WithChildren(
+				docs.FieldString("max_extension", "The maximum length of time for which the ack deadline of a message is extended. Set to zero in order to disable extensions.", "10m", "2h"),
+				docs.FieldString("max_extension_period", "The maximum length of each extension of an ack deadline, which must be between 10s and 600s. Set to zero in order to disable the limit.", "60s"),
+				docs.FieldString("min_extension_period", "The minimum length of each extension of an ack deadline, which must be between 10s and 600s. When set to zero the minimum is 10s, or 60s when consuming from a subscription with exactly-once delivery.", "30s"),
+			).Advanced().AtVersion("4.3.0"),
+			auth.FieldSpec(),
 		).ChildDefaultAndTypesFromStruct(input.NewGCPPubSubConfig()),
 	})
 	if err != nil {
@@ -68,6 +89,10 @@ func newGCPPubSubInput(conf input.Config, mgr bundle.NewManagement, log log.Modu
 type gcpPubSubReader struct {
 	conf input.GCPPubSubConfig
 
+	maxExtension       time.Duration
+	maxExtensionPeriod time.Duration
+	minExtensionPeriod time.Duration
+
 	subscription *pubsub.Subscription
 	msgsChan     chan *pubsub.Message
 	closeFunc    context.CancelFunc
@@ -79,15 +104,50 @@ type gcpPubSubReader struct {
 }
 
 func newGCPPubSubReader(conf input.GCPPubSubConfig, log log.Modular, stats metrics.Type) (*gcpPubSubReader, error) {
-	client, err := pubsub.NewClient(context.Background(), conf.ProjectID)
+	r := &gcpPubSubReader{
+		conf: conf,
+		log:  log,
+	}
+
+	var err error
+	if r.maxExtension, err = time.ParseDuration(conf.AckExtension.MaxExtension); err != nil {
+		return nil, fmt.Errorf("failed to parse ack_extension.max_extension: %w", err)
+	}
+	if r.maxExtension <= 0 {
+		// The client library only disables extensions when negative.
+		r.maxExtension = -1
+	}
+	if r.maxExtensionPeriod, err = parseExtensionPeriod(conf.AckExtension.MaxExtensionPeriod); err != nil {
+		return nil, fmt.Errorf("failed to parse ack_extension.max_extension_period: %w", err)
+	}
+	if r.minExtensionPeriod, err = parseExtensionPeriod(conf.AckExtension.MinExtensionPeriod); err != nil {
+		return nil, fmt.Errorf("failed to parse ack_extension.min_extension_period: %w", err)
+	}
+	if r.maxExtensionPeriod > 0 && r.minExtensionPeriod > r.maxExtensionPeriod {
+		return nil, errors.New("ack_extension.min_extension_period must not be greater than ack_extension.max_extension_period")
+	}
+
+	opts, err := auth.ClientOptions(conf.Credentials)
 	if err != nil {
 		return nil, err
 	}
-	return &gcpPubSubReader{
-		conf:   conf,
-		log:    log,
-		client: client,
-	}, nil
+	if r.client, err = pubsub.NewClient(context.Background(), conf.ProjectID, opts...); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// parseExtensionPeriod parses the duration of an ack deadline extension, where
+// zero is accepted as the absence of a limit.
+func parseExtensionPeriod(s string) (time.Duration, error) {
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, err
+	}
+	if d != 0 && (d < 10*time.Second || d > 600*time.Second) {
+		return 0, fmt.Errorf("duration %v must be zero or between 10s and 600s", d)
+	}
+	return d, nil
 }
 
 func (c *gcpPubSubReader) ConnectWithContext(ignored context.Context) error {
@@ -101,6 +161,9 @@ func (c *gcpPubSubReader) ConnectWithContext(ignored context.Context) error {
 	sub.ReceiveSettings.MaxOutstandingMessages = c.conf.MaxOutstandingMessages
 	sub.ReceiveSettings.MaxOutstandingBytes = c.conf.MaxOutstandingBytes
 	sub.ReceiveSettings.Synchronous = c.conf.Sync
+	sub.ReceiveSettings.MaxExtension = c.maxExtension
+	sub.ReceiveSettings.MaxExtensionPeriod = c.maxExtensionPeriod
+	sub.ReceiveSettings.MinExtensionPeriod = c.minExtensionPeriod
 
 	subCtx, cancel := context.WithCancel(context.Background())
 	msgsChan := make(chan *pubsub.Message, 1)
@@ -160,8 +223,30 @@ func (c *gcpPubSubReader) ReadWithContext(ctx context.Context) (*message.Batch,
 		part.MetaSet(k, v)
 	}
 	part.MetaSet("gcp_pubsub_publish_time_unix", strconv.FormatInt(gmsg.PublishTime.Unix(), 10))
+	if gmsg.OrderingKey != "" {
+		part.MetaSet("gcp_pubsub_ordering_key", gmsg.OrderingKey)
+	}
 	msg.Append(part)
 
+	if c.conf.ExactlyOnce {
+		return msg, func(ctx context.Context, res error) error {
+			var ackRes *pubsub.AckResult
+			if res != nil {
+				ackRes = gmsg.NackWithResult()
+			} else {
+				ackRes = gmsg.AckWithResult()
+			}
+			status, err := ackRes.Get(ctx)
+			if err == nil && status != pubsub.AcknowledgeStatusSuccess {
+				err = fmt.Errorf("status %v", ackStatusString(status))
+			}
+			if err != nil {
+				return fmt.Errorf("failed to confirm acknowledgement: %w", err)
+			}
+			return nil
+		}, nil
+	}
+
 	return msg, func(ctx context.Context, res error) error {
 		if res != nil {
 			gmsg.Nack()
@@ -172,6 +257,20 @@ func (c *gcpPubSubReader) ReadWithContext(ctx context.Context) (*message.Batch,
 	}, nil
 }
 
+func ackStatusString(status pubsub.AcknowledgeStatus) string {
+	switch status {
+	case pubsub.AcknowledgeStatusSuccess:
+		return "success"
+	case pubsub.AcknowledgeStatusPermissionDenied:
+		return "permission denied"
+	case pubsub.AcknowledgeStatusFailedPrecondition:
+		return "failed precondition"
+	case pubsub.AcknowledgeStatusInvalidAckID:
+		return "invalid ack ID"
+	}
+	return "other"
+}
+
 func (c *gcpPubSubReader) CloseAsync() {
 	c.subMut.Lock()
 	if c.closeFunc != nil {
@@ -0,0 +1,89 @@
+package gcp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/component/input"
+	"github.com/benthosdev/benthos/v4/internal/log"
+)
+
+func TestGCPPubSubInputAckExtension(t *testing.T) {
+	t.Setenv("PUBSUB_EMULATOR_HOST", "localhost:8085")
+
+	conf := input.NewGCPPubSubConfig()
+	conf.ProjectID = "foo"
+	conf.SubscriptionID = "bar"
+
+	r, err := newGCPPubSubReader(conf, log.Noop(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, time.Hour, r.maxExtension)
+	assert.Equal(t, time.Duration(0), r.maxExtensionPeriod)
+	assert.Equal(t, time.Duration(0), r.minExtensionPeriod)
+
+	conf.AckExtension.MaxExtension = "0s"
+	conf.AckExtension.MaxExtensionPeriod = "60s"
+	conf.AckExtension.MinExtensionPeriod = "30s"
+
+	r, err = newGCPPubSubReader(conf, log.Noop(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, time.Duration(-1), r.maxExtension)
+	assert.Equal(t, time.Minute, r.maxExtensionPeriod)
+	assert.Equal(t, 30*time.Second, r.minExtensionPeriod)
+}
+
+func TestGCPPubSubInputAckExtensionErrors(t *testing.T) {
+	t.Setenv("PUBSUB_EMULATOR_HOST", "localhost:8085")
+
+	for _, test := range []struct {
+		name      string
+		maxExt    string
+		maxPeriod string
+		minPeriod string
+		err       string
+	}{
+		{
+			name:   "bad max extension",
+			maxExt: "nope",
+			err:    `failed to parse ack_extension.max_extension: time: invalid duration "nope"`,
+		},
+		{
+			name:      "period too small",
+			maxPeriod: "5s",
+			err:       "failed to parse ack_extension.max_extension_period: duration 5s must be zero or between 10s and 600s",
+		},
+		{
+			name:      "period too large",
+			minPeriod: "11m",
+			err:       "failed to parse ack_extension.min_extension_period: duration 11m0s must be zero or between 10s and 600s",
+		},
+		{
+			name:      "min above max",
+			maxPeriod: "20s",
+			minPeriod: "30s",
+			err:       "ack_extension.min_extension_period must not be greater than ack_extension.max_extension_period",
+		},
+	} {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			conf := input.NewGCPPubSubConfig()
+			conf.ProjectID = "foo"
+			conf.SubscriptionID = "bar"
+			if test.maxExt != "" {
+				conf.AckExtension.MaxExtension = test.maxExt
+			}
+			if test.maxPeriod != "" {
+				conf.AckExtension.MaxExtensionPeriod = test.maxPeriod
+			}
+			if test.minPeriod != "" {
+				conf.AckExtension.MinExtensionPeriod = test.minPeriod
+			}
+
+			_, err := newGCPPubSubReader(conf, log.Noop(), nil)
+			require.EqualError(t, err, test.err)
+		})
+	}
+}
@@ -14,6 +14,7 @@ import (
 	"google.golang.org/api/option"
 
 	"github.com/benthosdev/benthos/v4/internal/component/output"
+	"github.com/benthosdev/benthos/v4/internal/impl/gcp/auth"
 	"github.com/benthosdev/benthos/v4/public/service"
 )
 
@@ -59,6 +60,7 @@ type gcpBigQueryOutputConfig struct {
 	IgnoreUnknownValues bool
 	MaxBadRecords       int
 	JobLabels           map[string]string
+	ClientOptions       []option.ClientOption
 
 	// CSV options
 	CSVOptions gcpBigQueryCSVConfig
@@ -101,14 +103,17 @@ func gcpBigQueryOutputConfigFromParsed(conf *service.ParsedConfig) (gconf gcpBig
 	if gconf.CSVOptions, err = gcpBigQueryCSVConfigFromParsed(conf.Namespace("csv")); err != nil {
 		return
 	}
+	if gconf.ClientOptions, err = clientOptionsFromParsedConfig(conf); err != nil {
+		return
+	}
 	return
 }
 
 type gcpBQClientURL string
 
-func (g gcpBQClientURL) NewClient(ctx context.Context, projectID string) (*bigquery.Client, error) {
+func (g gcpBQClientURL) NewClient(ctx context.Context, projectID string, opts ...option.ClientOption) (*bigquery.Client, error) {
 	if g == "" {
-		return bigquery.NewClient(ctx, projectID)
+		return bigquery.NewClient(ctx, projectID, opts...)
 	}
 	return bigquery.NewClient(ctx, projectID, option.WithoutAuthentication(), option.WithEndpoint(string(g)))
 }
@@ -209,6 +214,7 @@ For the CSV format when the field `+"`csv.header`"+` is specified a header row w
 				Advanced().
 				Default(1),
 		).Description("Specify how CSV data should be interpretted.")).
+		Field(service.NewInternalField(auth.FieldSpec())).
 		Field(service.NewBatchPolicyField("batching"))
 }
 
@@ -303,7 +309,7 @@ func (g *gcpBigQueryOutput) Connect(ctx context.Context) (err error) {
 	defer g.connMut.Unlock()
 
 	var client *bigquery.Client
-	if client, err = g.clientURL.NewClient(context.Background(), g.conf.ProjectID); err != nil {
+	if client, err = g.clientURL.NewClient(context.Background(), g.conf.ProjectID, g.conf.ClientOptions...); err != nil {
 		err = fmt.Errorf("error creating big query client: %w", err)
 		return
 	}
@@ -0,0 +1,584 @@
+package gcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+	"cloud.google.com/go/bigquery/storage/managedwriter"
+	"cloud.google.com/go/bigquery/storage/managedwriter/adapt"
+	"cloud.google.com/go/civil"
+	"google.golang.org/api/option"
+	storagepb "google.golang.org/genproto/googleapis/cloud/bigquery/storage/v1"
+	"google.golang.org/grpc/codes"
+	grpcstatus "google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/benthosdev/benthos/v4/internal/component/output"
+	"github.com/benthosdev/benthos/v4/internal/impl/gcp/auth"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	bqwStreamTypeDefault   = "default"
+	bqwStreamTypeCommitted = "committed"
+	bqwStreamTypePending   = "pending"
+)
+
+type gcpBQWriteAPIOutputConfig struct {
+	ProjectID           string
+	DatasetID           string
+	TableID             string
+	StreamType          string
+	IgnoreUnknownValues bool
+	ClientOptions       []option.ClientOption
+}
+
+func gcpBQWriteAPIOutputConfigFromParsed(conf *service.ParsedConfig) (gconf gcpBQWriteAPIOutputConfig, err error) {
+	if gconf.ProjectID, err = conf.FieldString("project"); err != nil {
+		return
+	}
+	if gconf.ProjectID == "" {
+		gconf.ProjectID = bigquery.DetectProjectID
+	}
+	if gconf.DatasetID, err = conf.FieldString("dataset"); err != nil {
+		return
+	}
+	if gconf.TableID, err = conf.FieldString("table"); err != nil {
+		return
+	}
+	if gconf.StreamType, err = conf.FieldString("stream_type"); err != nil {
+		return
+	}
+	if gconf.IgnoreUnknownValues, err = conf.FieldBool("ignore_unknown_values"); err != nil {
+		return
+	}
+	if gconf.ClientOptions, err = clientOptionsFromParsedConfig(conf); err != nil {
+		return
+	}
+	return
+}
+
+func gcpBQWriteAPIConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		Beta().
+		Categories("GCP", "Services").
+		Version("4.3.0").
+		Summary(`Sends messages as new rows to a Google Cloud BigQuery table using the BigQuery Storage Write API.`).
+		Description(output.Description(true, true, `
+Unlike the `+"[`gcp_bigquery`](/docs/components/outputs/gcp_bigquery)"+` output, which creates a load job for each batch, this output streams rows directly into a table with the [Storage Write API](https://cloud.google.com/bigquery/docs/write-api), which offers higher throughput and lower latency.
+
+## Credentials
+
+By default Benthos will use a shared credentials file when connecting to GCP services. You can find out more [in this document](/docs/guides/cloud/gcp).
+
+## Schema
+
+The table must already exist. When connecting the schema of the table is read and a protocol buffer schema is generated from it, which is used in order to encode rows. Each message must be a JSON object, and each field of the object is written to the column of the same name, where column names are matched case insensitively. Fields with a null value are written as nulls.
+
+Values are converted from JSON as follows:
+
+- `+"`TIMESTAMP`"+` columns accept RFC 3339 strings or integers of microseconds since the unix epoch.
+- `+"`DATE`"+` columns accept strings of the form `+"`2006-01-02`"+` or integers of days since the unix epoch.
+- `+"`DATETIME`"+` and `+"`TIME`"+` columns accept strings of the form `+"`2006-01-02 15:04:05.999999`"+` and `+"`15:04:05.999999`"+` respectively.
+- `+"`NUMERIC`"+` and `+"`BIGNUMERIC`"+` columns accept numbers or strings containing a decimal number.
+- `+"`BYTES`"+` columns accept base64 encoded strings.
+
+Changes to the schema of the table are picked up when the output reconnects.
+
+## Stream Types
+
+The field `+"`stream_type`"+` determines how rows are written to the table:
+
+- `+"`default`"+`: Rows are appended to the default stream of the table and are available for querying immediately. Delivery is at-least-once, and a batch that is retried after a failure may result in duplicate rows.
+- `+"`committed`"+`: Rows are appended to an application created stream and are available for querying immediately. Each append is given an explicit offset within the stream, which prevents a retried append within the same stream from being written twice. When an append fails the stream is discarded and a new stream is created for the next batch.
+- `+"`pending`"+`: A new stream is created for each batch and its rows are only made visible once all of them have been written, at which point the stream is committed atomically. This means a batch is either written in its entirety or not at all, and rows of a failed batch never become visible.`)).
+		Field(service.NewStringField("project").Description("The project ID of the dataset to insert data to. If not set, it will be inferred from the credentials or read from the GOOGLE_CLOUD_PROJECT environment variable.").Default("")).
+		Field(service.NewStringField("dataset").Description("The BigQuery Dataset ID.")).
+		Field(service.NewStringField("table").Description("The table to insert messages to.")).
+		Field(service.NewStringEnumField("stream_type", bqwStreamTypeDefault, bqwStreamTypeCommitted, bqwStreamTypePending).
+			Description("The type of write stream to use, see [stream types](#stream-types) for more information.").
+			Default(bqwStreamTypeDefault)).
+		Field(service.NewIntField("max_in_flight").
+			Description("The maximum number of message batches to have in flight at a given time. Increase this to improve throughput.").
+			Default(64)).
+		Field(service.NewBoolField("ignore_unknown_values").
+			Description("Whether fields of a message that do not match a column of the table are ignored. When set to `false` a message with an unknown field causes the whole batch to be rejected.").
+			Advanced().
+			Default(false)).
+		Field(service.NewInternalField(auth.FieldSpec())).
+		Field(service.NewBatchPolicyField("batching")).
+		Example("Exactly Once Batches", `
+Using pending streams each batch of rows is committed atomically, and therefore a batch of messages consumed from Kafka is either written in full or not at all:`, `
+input:
+  kafka:
+    addresses: [ localhost:9092 ]
+    topics: [ page_views ]
+    consumer_group: benthos_bigquery
+
+output:
+  gcp_bigquery_write_api:
+    project: foo
+    dataset: analytics
+    table: page_views
+    stream_type: pending
+    batching:
+      count: 500
+      period: 5s
+`)
+}
+
+func init() {
+	err := service.RegisterBatchOutput(
+		"gcp_bigquery_write_api", gcpBQWriteAPIConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (output service.BatchOutput, batchPol service.BatchPolicy, maxInFlight int, err error) {
+			if batchPol, err = conf.FieldBatchPolicy("batching"); err != nil {
+				return
+			}
+			if maxInFlight, err = conf.FieldInt("max_in_flight"); err != nil {
+				return
+			}
+			var gconf gcpBQWriteAPIOutputConfig
+			if gconf, err = gcpBQWriteAPIOutputConfigFromParsed(conf); err != nil {
+				return
+			}
+			output = newGCPBQWriteAPIOutput(gconf, mgr.Logger())
+			return
+		})
+
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type gcpBQWriteAPIOutput struct {
+	conf      gcpBQWriteAPIOutputConfig
+	clientURL gcpBQClientURL
+
+	client      *managedwriter.Client
+	tableParent string
+	encoder     *bqRowEncoder
+	connMut     sync.RWMutex
+
+	// The long lived stream used by the default and committed stream types,
+	// along with the offset of the next append for committed streams.
+	stream     *managedwriter.ManagedStream
+	nextOffset int64
+	streamMut  sync.Mutex
+
+	log *service.Logger
+}
+
+func newGCPBQWriteAPIOutput(conf gcpBQWriteAPIOutputConfig, log *service.Logger) *gcpBQWriteAPIOutput {
+	return &gcpBQWriteAPIOutput{
+		conf: conf,
+		log:  log,
+	}
+}
+
+func (g *gcpBQWriteAPIOutput) Connect(ctx context.Context) error {
+	g.connMut.Lock()
+	defer g.connMut.Unlock()
+
+	bqClient, err := g.clientURL.NewClient(context.Background(), g.conf.ProjectID, g.conf.ClientOptions...)
+	if err != nil {
+		return fmt.Errorf("error creating big query client: %w", err)
+	}
+	defer bqClient.Close()
+
+	projectID := bqClient.Project()
+	meta, err := bqClient.DatasetInProject(projectID, g.conf.DatasetID).Table(g.conf.TableID).Metadata(ctx)
+	if err != nil {
+		return fmt.Errorf("error reading table schema: %w", err)
+	}
+
+	encoder, err := newBQRowEncoder(meta.Schema, g.conf.IgnoreUnknownValues)
+	if err != nil {
+		return fmt.Errorf("error generating protobuf schema from table schema: %w", err)
+	}
+
+	client, err := managedwriter.NewClient(context.Background(), projectID, g.conf.ClientOptions...)
+	if err != nil {
+		return fmt.Errorf("error creating big query write client: %w", err)
+	}
+
+	// Any existing stream was created with the previous schema.
+	g.streamMut.Lock()
+	if g.stream != nil {
+		g.stream.Close()
+		g.stream = nil
+	}
+	g.streamMut.Unlock()
+
+	g.client = client
+	g.encoder = encoder
+	g.tableParent = fmt.Sprintf("projects/%v/datasets/%v/tables/%v", projectID, g.conf.DatasetID, g.conf.TableID)
+	g.log.Infof("Writing messages as rows to GCP BigQuery using %v streams: %v:%v:%v\n", g.conf.StreamType, projectID, g.conf.DatasetID, g.conf.TableID)
+	return nil
+}
+
+func (g *gcpBQWriteAPIOutput) newStream(ctx context.Context, client *managedwriter.Client, streamType managedwriter.StreamType) (*managedwriter.ManagedStream, error) {
+	return client.NewManagedStream(ctx,
+		managedwriter.WithDestinationTable(g.tableParent),
+		managedwriter.WithType(streamType),
+		managedwriter.WithSchemaDescriptor(g.encoder.descriptorProto),
+	)
+}
+
+func (g *gcpBQWriteAPIOutput) WriteBatch(ctx context.Context, batch service.MessageBatch) error {
+	g.connMut.RLock()
+	client, encoder := g.client, g.encoder
+	g.connMut.RUnlock()
+	if client == nil {
+		return service.ErrNotConnected
+	}
+
+	rows := make([][]byte, 0, len(batch))
+	for i, msg := range batch {
+		msgBytes, err := msg.AsBytes()
+		if err != nil {
+			return err
+		}
+		row, err := encoder.encode(msgBytes)
+		if err != nil {
+			return fmt.Errorf("failed to encode message %v as a row: %w", i, err)
+		}
+		rows = append(rows, row)
+	}
+
+	switch g.conf.StreamType {
+	case bqwStreamTypePending:
+		return g.writePending(ctx, client, rows)
+	case bqwStreamTypeCommitted:
+		return g.writeStream(ctx, client, managedwriter.CommittedStream, rows)
+	}
+	return g.writeStream(ctx, client, managedwriter.DefaultStream, rows)
+}
+
+// writeStream appends rows to the long lived stream, creating it if
+// necessary. For committed streams the offset of each append is reserved
+// up front so that batches can be in flight concurrently.
+func (g *gcpBQWriteAPIOutput) writeStream(ctx context.Context, client *managedwriter.Client, streamType managedwriter.StreamType, rows [][]byte) error {
+	g.streamMut.Lock()
+	if g.stream == nil {
+		stream, err := g.newStream(context.Background(), client, streamType)
+		if err != nil {
+			g.streamMut.Unlock()
+			return fmt.Errorf("failed to create write stream: %w", err)
+		}
+		g.stream, g.nextOffset = stream, 0
+	}
+	stream := g.stream
+
+	var opts []managedwriter.AppendOption
+	if streamType == managedwriter.CommittedStream {
+		opts = append(opts, managedwriter.WithOffset(g.nextOffset))
+		g.nextOffset += int64(len(rows))
+	}
+	g.streamMut.Unlock()
+
+	err := appendAndWait(ctx, stream, rows, opts...)
+	if err != nil && streamType == managedwriter.CommittedStream && grpcstatus.Code(err) == codes.AlreadyExists {
+		g.log.Debugf("Rows at the append offset already exist within stream %v, skipping", stream.StreamName())
+		return nil
+	}
+	if err != nil {
+		g.resetStream(stream)
+	}
+	return err
+}
+
+// resetStream discards a stream after a failed append, since all subsequent
+// offsets of a committed stream are invalid once an append is missing.
+func (g *gcpBQWriteAPIOutput) resetStream(stream *managedwriter.ManagedStream) {
+	g.streamMut.Lock()
+	defer g.streamMut.Unlock()
+
+	if g.stream != stream {
+		return
+	}
+	g.stream = nil
+	if err := stream.Close(); err != nil {
+		g.log.Debugf("Failed to close write stream: %v", err)
+	}
+}
+
+// writePending writes rows to a new pending stream which is then finalized
+// and committed, making the rows visible atomically.
+func (g *gcpBQWriteAPIOutput) writePending(ctx context.Context, client *managedwriter.Client, rows [][]byte) error {
+	stream, err := g.newStream(ctx, client, managedwriter.PendingStream)
+	if err != nil {
+		return fmt.Errorf("failed to create write stream: %w", err)
+	}
+	defer stream.Close()
+
+	if err := appendAndWait(ctx, stream, rows, managedwriter.WithOffset(0)); err != nil {
+		return err
+	}
+	if _, err := stream.Finalize(ctx); err != nil {
+		return fmt.Errorf("failed to finalize write stream: %w", err)
+	}
+
+	resp, err := client.BatchCommitWriteStreams(ctx, &storagepb.BatchCommitWriteStreamsRequest{
+		Parent:       g.tableParent,
+		WriteStreams: []string{stream.StreamName()},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to commit write stream: %w", err)
+	}
+	if errs := resp.GetStreamErrors(); len(errs) > 0 {
+		return fmt.Errorf("failed to commit write stream: %v", errs[0].GetErrorMessage())
+	}
+	return nil
+}
+
+func appendAndWait(ctx context.Context, stream *managedwriter.ManagedStream, rows [][]byte, opts ...managedwriter.AppendOption) error {
+	res, err := stream.AppendRows(ctx, rows, opts...)
+	if err != nil {
+		return fmt.Errorf("failed to append rows: %w", err)
+	}
+	if _, err = res.GetResult(ctx); err != nil {
+		return fmt.Errorf("failed to append rows: %w", err)
+	}
+	return nil
+}
+
+func (g *gcpBQWriteAPIOutput) Close(ctx context.Context) error {
+	g.streamMut.Lock()
+	if g.stream != nil {
+		g.stream.Close()
+		g.stream = nil
+	}
+	g.streamMut.Unlock()
+
+	g.connMut.Lock()
+	if g.client != nil {
+		g.client.Close()
+		g.client = nil
+	}
+	g.connMut.Unlock()
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+// bqRowEncoder converts JSON documents into serialised protocol buffer
+// messages matching the schema of a table.
+type bqRowEncoder struct {
+	schema          bigquery.Schema
+	ignoreUnknown   bool
+	descriptor      protoreflect.MessageDescriptor
+	descriptorProto *descriptorpb.DescriptorProto
+}
+
+func newBQRowEncoder(schema bigquery.Schema, ignoreUnknown bool) (*bqRowEncoder, error) {
+	storageSchema, err := adapt.BQSchemaToStorageTableSchema(schema)
+	if err != nil {
+		return nil, err
+	}
+	desc, err := adapt.StorageSchemaToProto2Descriptor(storageSchema, "root")
+	if err != nil {
+		return nil, err
+	}
+	msgDesc, ok := desc.(protoreflect.MessageDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("expected a message descriptor, got %T", desc)
+	}
+	descProto, err := adapt.NormalizeDescriptor(msgDesc)
+	if err != nil {
+		return nil, err
+	}
+	return &bqRowEncoder{
+		schema:          schema,
+		ignoreUnknown:   ignoreUnknown,
+		descriptor:      msgDesc,
+		descriptorProto: descProto,
+	}, nil
+}
+
+func (e *bqRowEncoder) encode(doc []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(doc))
+	dec.UseNumber()
+
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected an object, got %T", v)
+	}
+
+	row, err := e.normaliseRecord(e.schema, obj)
+	if err != nil {
+		return nil, err
+	}
+	rowBytes, err := json.Marshal(row)
+	if err != nil {
+		return nil, err
+	}
+
+	msg := dynamicpb.NewMessage(e.descriptor)
+	if err := protojson.Unmarshal(rowBytes, msg); err != nil {
+		return nil, err
+	}
+	return proto.Marshal(msg)
+}
+
+// normaliseRecord converts the fields of a JSON object into the JSON
+// representation of the protobuf message generated for a record, where field
+// names are lower cased and values of types that lack a natural JSON form are
+// converted into their protobuf encoding.
+func (e *bqRowEncoder) normaliseRecord(schema bigquery.Schema, obj map[string]interface{}) (map[string]interface{}, error) {
+	fields := make(map[string]*bigquery.FieldSchema, len(schema))
+	for _, f := range schema {
+		fields[strings.ToLower(f.Name)] = f
+	}
+
+	row := make(map[string]interface{}, len(obj))
+	for k, v := range obj {
+		name := strings.ToLower(k)
+		f, exists := fields[name]
+		if !exists {
+			if e.ignoreUnknown {
+				continue
+			}
+			return nil, fmt.Errorf("field %v does not exist in the table schema", k)
+		}
+		if v == nil {
+			continue
+		}
+
+		var err error
+		if f.Repeated {
+			arr, ok := v.([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("field %v: expected an array, got %T", k, v)
+			}
+			values := make([]interface{}, len(arr))
+			for i, ele := range arr {
+				if values[i], err = e.normaliseValue(f, ele); err != nil {
+					return nil, fmt.Errorf("field %v: %w", k, err)
+				}
+			}
+			row[name] = values
+			continue
+		}
+		if row[name], err = e.normaliseValue(f, v); err != nil {
+			return nil, fmt.Errorf("field %v: %w", k, err)
+		}
+	}
+	return row, nil
+}
+
+func (e *bqRowEncoder) normaliseValue(f *bigquery.FieldSchema, v interface{}) (interface{}, error) {
+	switch f.Type {
+	case bigquery.RecordFieldType:
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected an object, got %T", v)
+		}
+		return e.normaliseRecord(f.Schema, obj)
+	case bigquery.TimestampFieldType:
+		if s, ok := v.(string); ok {
+			t, err := time.Parse(time.RFC3339Nano, s)
+			if err != nil {
+				return nil, err
+			}
+			return t.UnixNano() / int64(time.Microsecond), nil
+		}
+	case bigquery.DateFieldType:
+		if s, ok := v.(string); ok {
+			d, err := civil.ParseDate(s)
+			if err != nil {
+				return nil, err
+			}
+			return d.DaysSince(civil.Date{Year: 1970, Month: time.January, Day: 1}), nil
+		}
+	case bigquery.DateTimeFieldType:
+		if s, ok := v.(string); ok {
+			dt, err := civil.ParseDateTime(strings.Replace(s, " ", "T", 1))
+			if err != nil {
+				return nil, err
+			}
+			return packedDateTimeMicros(dt), nil
+		}
+	case bigquery.TimeFieldType:
+		if s, ok := v.(string); ok {
+			t, err := civil.ParseTime(s)
+			if err != nil {
+				return nil, err
+			}
+			return packedTimeMicros(t), nil
+		}
+	case bigquery.NumericFieldType:
+		return numericBytes(v, 9)
+	case bigquery.BigNumericFieldType:
+		return numericBytes(v, 38)
+	}
+	return v, nil
+}
+
+// packedTimeMicros returns the packed encoding of a TIME value expected by
+// the Storage Write API, which is the hour, minute and second as bit fields
+// followed by 20 bits of microseconds.
+func packedTimeMicros(t civil.Time) int64 {
+	seconds := int64(t.Hour)<<12 | int64(t.Minute)<<6 | int64(t.Second)
+	return seconds<<20 | int64(t.Nanosecond/1000)
+}
+
+// packedDateTimeMicros returns the packed encoding of a DATETIME value
+// expected by the Storage Write API.
+func packedDateTimeMicros(dt civil.DateTime) int64 {
+	seconds := int64(dt.Date.Year)<<26 | int64(dt.Date.Month)<<22 | int64(dt.Date.Day)<<17 |
+		int64(dt.Time.Hour)<<12 | int64(dt.Time.Minute)<<6 | int64(dt.Time.Second)
+	return seconds<<20 | int64(dt.Time.Nanosecond/1000)
+}
+
+// numericBytes returns the base64 encoded little endian two's complement of
+// a decimal number scaled by the given number of decimal places, which is the
+// encoding of NUMERIC and BIGNUMERIC values expected by the Storage Write API.
+func numericBytes(v interface{}, scale int) (string, error) {
+	var s string
+	switch t := v.(type) {
+	case json.Number:
+		s = t.String()
+	case string:
+		s = t
+	default:
+		return "", fmt.Errorf("expected a number or string, got %T", v)
+	}
+
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return "", fmt.Errorf("failed to parse %q as a decimal number", s)
+	}
+	r.Mul(r, new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(scale)), nil)))
+	if !r.IsInt() {
+		return "", fmt.Errorf("value %v has more than %v decimal places", s, scale)
+	}
+
+	n := new(big.Int).Set(r.Num())
+	length := n.BitLen()/8 + 1
+	if n.Sign() < 0 {
+		n.Add(n, new(big.Int).Lsh(big.NewInt(1), uint(length*8)))
+	}
+	b := n.FillBytes(make([]byte, length))
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+	return base64.StdEncoding.EncodeToString(b), nil
+}
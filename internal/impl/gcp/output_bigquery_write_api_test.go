@@ -0,0 +1,188 @@
+package gcp
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"cloud.google.com/go/bigquery"
+	"cloud.google.com/go/civil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func gcpBQWriteAPIConfFromYAML(t *testing.T, yamlStr string) gcpBQWriteAPIOutputConfig {
+	t.Helper()
+	parsedConf, err := gcpBQWriteAPIConfig().ParseYAML(yamlStr, nil)
+	require.NoError(t, err)
+
+	conf, err := gcpBQWriteAPIOutputConfigFromParsed(parsedConf)
+	require.NoError(t, err)
+	return conf
+}
+
+func testBQRowEncoder(t *testing.T, ignoreUnknown bool) *bqRowEncoder {
+	t.Helper()
+	enc, err := newBQRowEncoder(bigquery.Schema{
+		{Name: "ID", Type: bigquery.IntegerFieldType, Required: true},
+		{Name: "name", Type: bigquery.StringFieldType},
+		{Name: "tags", Type: bigquery.StringFieldType, Repeated: true},
+		{Name: "created", Type: bigquery.TimestampFieldType},
+		{Name: "day", Type: bigquery.DateFieldType},
+		{Name: "price", Type: bigquery.NumericFieldType},
+		{Name: "address", Type: bigquery.RecordFieldType, Schema: bigquery.Schema{
+			{Name: "city", Type: bigquery.StringFieldType},
+		}},
+	}, ignoreUnknown)
+	require.NoError(t, err)
+	return enc
+}
+
+func decodeBQRow(t *testing.T, enc *bqRowEncoder, row []byte) string {
+	t.Helper()
+	msg := dynamicpb.NewMessage(enc.descriptor)
+	require.NoError(t, proto.Unmarshal(row, msg))
+
+	jBytes, err := protojson.MarshalOptions{UseProtoNames: true}.Marshal(msg)
+	require.NoError(t, err)
+	return string(jBytes)
+}
+
+func TestGCPBQWriteAPIConfig(t *testing.T) {
+	conf := gcpBQWriteAPIConfFromYAML(t, `
+dataset: foo
+table: bar
+stream_type: pending
+`)
+	assert.Equal(t, bigquery.DetectProjectID, conf.ProjectID)
+	assert.Equal(t, "foo", conf.DatasetID)
+	assert.Equal(t, "bar", conf.TableID)
+	assert.Equal(t, bqwStreamTypePending, conf.StreamType)
+	assert.False(t, conf.IgnoreUnknownValues)
+}
+
+func TestBQRowEncoder(t *testing.T) {
+	enc := testBQRowEncoder(t, false)
+
+	row, err := enc.encode([]byte(`{
+  "id": 12345678901234567,
+  "Name": "foo",
+  "tags": ["a", "b"],
+  "created": "2022-08-01T10:00:00.5Z",
+  "day": "1970-01-11",
+  "price": "-1.5",
+  "address": {"city": "bar"}
+}`))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+  "id": "12345678901234567",
+  "name": "foo",
+  "tags": ["a", "b"],
+  "created": "1659348000500000",
+  "day": 10,
+  "price": "ANGXpg==",
+  "address": {"city": "bar"}
+}`, decodeBQRow(t, enc, row))
+
+	row, err = enc.encode([]byte(`{"id":1,"name":null,"created":1659348000000000,"day":3}`))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"id":"1","created":"1659348000000000","day":3}`, decodeBQRow(t, enc, row))
+}
+
+func TestBQRowEncoderErrors(t *testing.T) {
+	enc := testBQRowEncoder(t, false)
+
+	for _, test := range []struct {
+		name string
+		doc  string
+		err  string
+	}{
+		{name: "not an object", doc: `[1]`, err: "expected an object, got []interface {}"},
+		{name: "unknown field", doc: `{"id":1,"nope":2}`, err: "field nope does not exist in the table schema"},
+		{name: "not an array", doc: `{"id":1,"tags":"a"}`, err: "field tags: expected an array, got string"},
+		{name: "bad numeric", doc: `{"id":1,"price":"1.0000000001"}`, err: "field price: value 1.0000000001 has more than 9 decimal places"},
+		{name: "missing required", doc: `{"name":"foo"}`},
+	} {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			_, err := enc.encode([]byte(test.doc))
+			require.Error(t, err)
+			if test.err != "" {
+				assert.EqualError(t, err, test.err)
+			}
+		})
+	}
+
+	enc = testBQRowEncoder(t, true)
+	row, err := enc.encode([]byte(`{"id":1,"nope":2}`))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"id":"1"}`, decodeBQRow(t, enc, row))
+}
+
+func TestBQNumericBytes(t *testing.T) {
+	for _, test := range []struct {
+		value interface{}
+		scale int
+		bytes []byte
+	}{
+		{value: "0", scale: 0, bytes: []byte{0x00}},
+		{value: "1", scale: 0, bytes: []byte{0x01}},
+		{value: "-1", scale: 0, bytes: []byte{0xff}},
+		{value: "128", scale: 0, bytes: []byte{0x80, 0x00}},
+		{value: "-128", scale: 0, bytes: []byte{0x80, 0xff}},
+		{value: "2.5", scale: 1, bytes: []byte{0x19}},
+	} {
+		res, err := numericBytes(test.value, test.scale)
+		require.NoError(t, err, test.value)
+		assert.Equal(t, test.bytes, mustDecodeBase64(t, res), test.value)
+	}
+
+	_, err := numericBytes(true, 9)
+	require.EqualError(t, err, "expected a number or string, got bool")
+}
+
+func TestBQPackedCivilTime(t *testing.T) {
+	assert.Equal(t, (int64(10)<<12|int64(15)<<6|int64(15))<<20|123, packedTimeMicros(civil.Time{Hour: 10, Minute: 15, Second: 15, Nanosecond: 123000}))
+	assert.Equal(t, int64(1<<20), packedTimeMicros(civil.Time{Second: 1}))
+
+	dt := civil.DateTime{
+		Date: civil.Date{Year: 2022, Month: 8, Day: 1},
+		Time: civil.Time{Hour: 1},
+	}
+	assert.Equal(t, (int64(2022)<<26|int64(8)<<22|int64(1)<<17|int64(1)<<12)<<20, packedDateTimeMicros(dt))
+}
+
+func TestGCPBQWriteAPIConnectTableMissing(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "not found", http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	out := newGCPBQWriteAPIOutput(gcpBQWriteAPIConfFromYAML(t, `
+project: foo
+dataset: bar
+table: baz
+`), nil)
+	out.clientURL = gcpBQClientURL(server.URL)
+
+	err := out.Connect(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "error reading table schema")
+
+	err = out.WriteBatch(context.Background(), service.MessageBatch{service.NewMessage([]byte(`{}`))})
+	require.Equal(t, service.ErrNotConnected, err)
+}
+
+func mustDecodeBase64(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := base64.StdEncoding.DecodeString(s)
+	require.NoError(t, err)
+	return b
+}
@@ -3,6 +3,7 @@ package gcp
 import (
 	"context"
 	"fmt"
+	"io"
 	"path"
 	"sync"
 	"time"
@@ -19,6 +20,7 @@ import (
 	"github.com/benthosdev/benthos/v4/internal/component/output/batcher"
 	"github.com/benthosdev/benthos/v4/internal/component/output/processors"
 	"github.com/benthosdev/benthos/v4/internal/docs"
+	"github.com/benthosdev/benthos/v4/internal/impl/gcp/auth"
 	"github.com/benthosdev/benthos/v4/internal/log"
 	"github.com/benthosdev/benthos/v4/internal/message"
 )
@@ -56,7 +58,10 @@ Metadata fields on messages will be sent as headers, in order to mutate these va
 ### Credentials
 
 By default Benthos will use a shared credentials file when connecting to GCP
-services. You can find out more [in this document](/docs/guides/cloud/gcp).
+services. It's also possible to set them explicitly at the component level with
+the `+"`credentials`"+` fields, including the impersonation of a service account,
+allowing you to access services across projects. You can find out more
+[in this document](/docs/guides/cloud/gcp).
 
 ### Batching
 
@@ -119,6 +124,7 @@ output:
 			docs.FieldInt("chunk_size", "An optional chunk size which controls the maximum number of bytes of the object that the Writer will attempt to send to the server in a single request. If ChunkSize is set to zero, chunking will be disabled.").Advanced(),
 			docs.FieldInt("max_in_flight", "The maximum number of message batches to have in flight at a given time. Increase this to improve throughput."),
 			policy.FieldSpec(),
+			auth.FieldSpec(),
 		).ChildDefaultAndTypesFromStruct(output.NewGCPCloudStorageConfig()),
 	})
 	if err != nil {
@@ -175,8 +181,11 @@ func (g *gcpCloudStorageOutput) ConnectWithContext(ctx context.Context) error {
 	g.connMut.Lock()
 	defer g.connMut.Unlock()
 
-	var err error
-	g.client, err = storage.NewClient(context.Background())
+	opts, err := auth.ClientOptions(g.conf.Credentials)
+	if err != nil {
+		return err
+	}
+	g.client, err = storage.NewClient(context.Background(), opts...)
 	if err != nil {
 		return err
 	}
@@ -241,7 +250,13 @@ func (g *gcpCloudStorageOutput) WriteWithContext(ctx context.Context, msg *messa
 		w.ContentType = g.contentType.String(i, msg)
 		w.ContentEncoding = g.contentEncoding.String(i, msg)
 		w.Metadata = metadata
-		if _, err = w.Write(p.Get()); err != nil {
+		body, err := p.BodyReader()
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(w, body)
+		body.Close()
+		if err != nil {
 			return err
 		}
 
@@ -16,6 +16,7 @@ import (
 	"github.com/benthosdev/benthos/v4/internal/component/output"
 	"github.com/benthosdev/benthos/v4/internal/component/output/processors"
 	"github.com/benthosdev/benthos/v4/internal/docs"
+	"github.com/benthosdev/benthos/v4/internal/impl/gcp/auth"
 	"github.com/benthosdev/benthos/v4/internal/log"
 	"github.com/benthosdev/benthos/v4/internal/message"
 	"github.com/benthosdev/benthos/v4/internal/metadata"
@@ -49,7 +50,15 @@ Or delete all keys with:
 pipeline:
   processors:
     - bloblang: meta = deleted()
-`+"```"+``),
+`+"```"+`
+
+### Ordering
+
+When an `+"`ordering_key`"+` is set messages that share a key are delivered to subscribers with message ordering enabled in the order that they were published. If a message fails to publish then subsequent messages of the same key are also rejected until the failed message is retried, which ensures that a message is never published out of order.
+
+### Flow Control
+
+The field `+"`flow_control`"+` limits the number of messages and bytes that may be pending publication at a given time, and determines whether publishing should block, fail or continue regardless once the limit is reached.`),
 		Config: docs.FieldComponent().WithChildren(
 			docs.FieldString("project", "The project ID of the topic to publish to."),
 			docs.FieldString("topic", "The topic to publish to.").IsInterpolated(),
@@ -57,6 +66,16 @@ pipeline:
 			docs.FieldString("publish_timeout", "The maximum length of time to wait before abandoning a publish attempt for a message.", "10s", "5m", "60m").Advanced(),
 			docs.FieldString("ordering_key", "The ordering key to use for publishing messages.").IsInterpolated().Advanced(),
 			docs.FieldObject("metadata", "Specify criteria for which metadata values are sent as attributes.").WithChildren(metadata.ExcludeFilterFields()...),
+			docs.FieldObject("flow_control", "Limits on the messages pending publication at a given time.").WithChildren(
+				docs.FieldInt("max_outstanding_messages", "The maximum number of messages pending publication. Set to zero or below in order to disable the limit."),
+				docs.FieldInt("max_outstanding_bytes", "The maximum number of bytes of messages pending publication. Set to zero or below in order to disable the limit."),
+				docs.FieldString("limit_exceeded_behavior", "The behaviour of publishing once a limit has been reached.").HasAnnotatedOptions(
+					"ignore", "Publish messages regardless of the limits.",
+					"block", "Wait until the pending messages are within the limits before publishing.",
+					"signal_error", "Fail to publish the message, in which case it is retried.",
+				),
+			).Advanced(),
+			auth.FieldSpec(),
 		).ChildDefaultAndTypesFromStruct(output.NewGCPPubSubConfig()),
 		Categories: []string{
 			"Services",
@@ -90,6 +109,8 @@ type gcpPubSubWriter struct {
 	orderingEnabled bool
 	orderingKey     *field.Expression
 
+	flowControl pubsub.FlowControlSettings
+
 	topicID  *field.Expression
 	topics   map[string]*pubsub.Topic
 	topicMut sync.Mutex
@@ -98,7 +119,11 @@ type gcpPubSubWriter struct {
 }
 
 func newGCPPubSubWriter(conf output.GCPPubSubConfig, mgr bundle.NewManagement, log log.Modular) (*gcpPubSubWriter, error) {
-	client, err := pubsub.NewClient(context.Background(), conf.ProjectID)
+	opts, err := auth.ClientOptions(conf.Credentials)
+	if err != nil {
+		return nil, err
+	}
+	client, err := pubsub.NewClient(context.Background(), conf.ProjectID, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -118,6 +143,20 @@ func newGCPPubSubWriter(conf output.GCPPubSubConfig, mgr bundle.NewManagement, l
 	if err != nil {
 		return nil, fmt.Errorf("failed to construct metadata filter: %w", err)
 	}
+	flowControl := pubsub.FlowControlSettings{
+		MaxOutstandingMessages: conf.FlowControl.MaxOutstandingMessages,
+		MaxOutstandingBytes:    conf.FlowControl.MaxOutstandingBytes,
+	}
+	switch conf.FlowControl.LimitExceededBehavior {
+	case "ignore":
+		flowControl.LimitExceededBehavior = pubsub.FlowControlIgnore
+	case "block":
+		flowControl.LimitExceededBehavior = pubsub.FlowControlBlock
+	case "signal_error":
+		flowControl.LimitExceededBehavior = pubsub.FlowControlSignalError
+	default:
+		return nil, fmt.Errorf("unrecognised flow control limit exceeded behavior: %v", conf.FlowControl.LimitExceededBehavior)
+	}
 	return &gcpPubSubWriter{
 		conf:            conf,
 		log:             log,
@@ -127,6 +166,7 @@ func newGCPPubSubWriter(conf output.GCPPubSubConfig, mgr bundle.NewManagement, l
 		topicID:         topic,
 		orderingKey:     orderingKey,
 		orderingEnabled: len(conf.OrderingKey) > 0,
+		flowControl:     flowControl,
 	}, nil
 }
 
@@ -161,6 +201,7 @@ func (c *gcpPubSubWriter) getTopic(ctx context.Context, t string) (*pubsub.Topic
 		return nil, fmt.Errorf("topic '%v' does not exist", t)
 	}
 	topic.PublishSettings.Timeout = c.publishTimeout
+	topic.PublishSettings.FlowControlSettings = c.flowControl
 	topic.EnableMessageOrdering = c.orderingEnabled
 	c.topics[t] = topic
 	return topic, nil
@@ -177,6 +218,7 @@ func (c *gcpPubSubWriter) WriteWithContext(ctx context.Context, msg *message.Bat
 	}
 
 	results := make([]*pubsub.PublishResult, msg.Len())
+	orderingKeys := make([]string, msg.Len())
 	_ = msg.Iter(func(i int, part *message.Part) error {
 		topic := topics[i]
 		attr := map[string]string{}
@@ -188,7 +230,8 @@ func (c *gcpPubSubWriter) WriteWithContext(ctx context.Context, msg *message.Bat
 			Data: part.Get(),
 		}
 		if c.orderingEnabled {
-			gmsg.OrderingKey = c.orderingKey.String(i, msg)
+			orderingKeys[i] = c.orderingKey.String(i, msg)
+			gmsg.OrderingKey = orderingKeys[i]
 		}
 		if len(attr) > 0 {
 			gmsg.Attributes = attr
@@ -200,6 +243,11 @@ func (c *gcpPubSubWriter) WriteWithContext(ctx context.Context, msg *message.Bat
 	var batchErr *batch.Error
 	for i, r := range results {
 		if _, err := r.Get(ctx); err != nil {
+			if orderingKeys[i] != "" {
+				// Publishing of an ordering key is paused after a failure until
+				// explicitly resumed, the failed message is retried after.
+				topics[i].ResumePublish(orderingKeys[i])
+			}
 			if batchErr == nil {
 				batchErr = batch.NewError(msg, err)
 			}
@@ -0,0 +1,37 @@
+package gcp
+
+import (
+	"testing"
+
+	"cloud.google.com/go/pubsub"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/component/output"
+	"github.com/benthosdev/benthos/v4/internal/log"
+	"github.com/benthosdev/benthos/v4/internal/manager/mock"
+)
+
+func TestGCPPubSubWriterFlowControl(t *testing.T) {
+	t.Setenv("PUBSUB_EMULATOR_HOST", "localhost:1")
+
+	conf := output.NewGCPPubSubConfig()
+	conf.ProjectID = "foo"
+	conf.TopicID = "bar"
+	conf.FlowControl.MaxOutstandingMessages = 10
+	conf.FlowControl.MaxOutstandingBytes = 1024
+	conf.FlowControl.LimitExceededBehavior = "block"
+
+	w, err := newGCPPubSubWriter(conf, mock.NewManager(), log.Noop())
+	require.NoError(t, err)
+	assert.Equal(t, pubsub.FlowControlSettings{
+		MaxOutstandingMessages: 10,
+		MaxOutstandingBytes:    1024,
+		LimitExceededBehavior:  pubsub.FlowControlBlock,
+	}, w.flowControl)
+
+	conf.FlowControl.LimitExceededBehavior = "nope"
+	_, err = newGCPPubSubWriter(conf, mock.NewManager(), log.Noop())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unrecognised flow control limit exceeded behavior")
+}
@@ -0,0 +1,263 @@
+package gcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"cloud.google.com/go/spanner"
+
+	"github.com/benthosdev/benthos/v4/public/bloblang"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	spannerOpInsert  = "insert"
+	spannerOpUpdate  = "update"
+	spannerOpUpsert  = "upsert"
+	spannerOpReplace = "replace"
+	spannerOpDelete  = "delete"
+)
+
+func gcpSpannerOutputConfig() *service.ConfigSpec {
+	spec := service.NewConfigSpec().
+		Beta().
+		Categories("GCP", "Services").
+		Version("4.3.0").
+		Summary("Writes messages to a Google Cloud Spanner table as mutations.").
+		Description(`
+Each message is converted into a mutation of a row by executing the ` + "`args_mapping`" + `, which must result in an object where each key is the name of a column. The kind of mutation is determined by the field ` + "`operation`" + `, which can be interpolated per message, and is one of:
+
+- ` + "`insert`" + `: Inserts a new row, failing if the row already exists.
+- ` + "`update`" + `: Updates the columns of an existing row, failing if the row does not exist.
+- ` + "`upsert`" + `: Inserts a new row or updates the columns of an existing row.
+- ` + "`replace`" + `: Inserts a new row or replaces an existing row, where columns that are not specified are set to null.
+- ` + "`delete`" + `: Deletes a row, where the row is identified by the columns listed in ` + "`key_columns`" + `.
+
+The mutations of a batch of messages are applied atomically in a single transaction, and therefore either all of the messages of a batch are written or none of them are. Spanner limits the number of mutations within a single transaction, where each column of a row counts as a mutation, and so the [batching policy](/docs/configuration/batching) should be sized accordingly.
+
+Objects and arrays that are not made up of a single type of scalar value are written as JSON.
+
+## Credentials
+
+By default Benthos will use a shared credentials file when connecting to GCP services. You can find out more [in this document](/docs/guides/cloud/gcp).`)
+
+	for _, f := range spannerDatabaseFields() {
+		spec = spec.Field(f)
+	}
+
+	return spec.
+		Field(service.NewStringField("table").
+			Description("The table to write to.")).
+		Field(service.NewInterpolatedStringField("operation").
+			Description("The kind of mutation to perform for each message, one of `insert`, `update`, `upsert`, `replace` or `delete`.").
+			Example(`upsert`).
+			Example(`${! meta("operation") }`).
+			Default(spannerOpUpsert)).
+		Field(service.NewBloblangField("args_mapping").
+			Description("A [Bloblang mapping](/docs/guides/bloblang/about) which should evaluate to an object of column names and their values.").
+			Example(`root = { "id": this.user.id, "name": this.user.name, "topic": meta("kafka_topic") }`)).
+		Field(service.NewStringListField("key_columns").
+			Description("The columns of the primary key of the table in order, which are read from the result of `args_mapping` in order to identify rows to delete. Required when deleting rows.").
+			Example([]string{"id"}).
+			Default([]string{})).
+		Field(service.NewIntField("max_in_flight").
+			Description("The maximum number of batches to have in flight at a given time. Increase this to improve throughput.").
+			Default(64)).
+		Field(service.NewBatchPolicyField("batching")).
+		Example("Change Data Capture",
+			`
+Here we apply a stream of change events to a table, where the kind of each change is read from a field of the event:`,
+			`
+output:
+  gcp_spanner:
+    project: foo
+    instance: bar
+    database: baz
+    table: users
+    operation: '${! json("op") }'
+    args_mapping: 'root = this.user'
+    key_columns: [ id ]
+    batching:
+      count: 100
+      period: 1s
+`,
+		)
+}
+
+func init() {
+	err := service.RegisterBatchOutput(
+		"gcp_spanner", gcpSpannerOutputConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (out service.BatchOutput, batchPol service.BatchPolicy, maxInFlight int, err error) {
+			if batchPol, err = conf.FieldBatchPolicy("batching"); err != nil {
+				return
+			}
+			if maxInFlight, err = conf.FieldInt("max_in_flight"); err != nil {
+				return
+			}
+			out, err = newGCPSpannerOutputFromParsed(conf, mgr)
+			return
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type gcpSpannerOutput struct {
+	dbConf      spannerDatabaseConfig
+	table       string
+	operation   *service.InterpolatedString
+	argsMapping *bloblang.Executor
+	keyColumns  []string
+
+	client  *spanner.Client
+	connMut sync.RWMutex
+
+	log *service.Logger
+}
+
+func newGCPSpannerOutputFromParsed(conf *service.ParsedConfig, mgr *service.Resources) (*gcpSpannerOutput, error) {
+	g := &gcpSpannerOutput{
+		log: mgr.Logger(),
+	}
+
+	var err error
+	if g.dbConf, err = spannerDatabaseConfigFromParsed(conf); err != nil {
+		return nil, err
+	}
+	if g.table, err = conf.FieldString("table"); err != nil {
+		return nil, err
+	}
+	if g.operation, err = conf.FieldInterpolatedString("operation"); err != nil {
+		return nil, err
+	}
+	if g.argsMapping, err = conf.FieldBloblang("args_mapping"); err != nil {
+		return nil, err
+	}
+	if g.keyColumns, err = conf.FieldStringList("key_columns"); err != nil {
+		return nil, err
+	}
+
+	// Operations that aren't interpolated can be validated up front.
+	if op, _ := conf.FieldString("operation"); !strings.Contains(op, "${!") {
+		if err := validateSpannerOperation(op); err != nil {
+			return nil, err
+		}
+		if op == spannerOpDelete && len(g.keyColumns) == 0 {
+			return nil, errors.New("key_columns must be set in order to delete rows")
+		}
+	}
+	return g, nil
+}
+
+func validateSpannerOperation(op string) error {
+	switch op {
+	case spannerOpInsert, spannerOpUpdate, spannerOpUpsert, spannerOpReplace, spannerOpDelete:
+		return nil
+	}
+	return fmt.Errorf("unrecognised operation: %v", op)
+}
+
+func (g *gcpSpannerOutput) Connect(ctx context.Context) error {
+	g.connMut.Lock()
+	defer g.connMut.Unlock()
+
+	if g.client != nil {
+		return nil
+	}
+
+	client, err := g.dbConf.newClient(context.Background())
+	if err != nil {
+		return fmt.Errorf("error creating spanner client: %w", err)
+	}
+	g.client = client
+	g.log.Infof("Writing messages as mutations to GCP Spanner table %v of database %v\n", g.table, g.dbConf.path)
+	return nil
+}
+
+func (g *gcpSpannerOutput) mutation(i int, batch service.MessageBatch) (*spanner.Mutation, error) {
+	op := batch.InterpolatedString(i, g.operation)
+	if err := validateSpannerOperation(op); err != nil {
+		return nil, err
+	}
+
+	resMsg, err := batch.BloblangQuery(i, g.argsMapping)
+	if err != nil {
+		return nil, fmt.Errorf("args mapping failed: %w", err)
+	}
+	if resMsg == nil {
+		return nil, errors.New("args mapping resulted in a deleted message")
+	}
+	res, err := resMsg.AsStructured()
+	if err != nil {
+		return nil, fmt.Errorf("args mapping returned non-structured result: %w", err)
+	}
+	obj, ok := res.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("args mapping returned non-object result: %T", res)
+	}
+
+	values := make(map[string]interface{}, len(obj))
+	for k, v := range obj {
+		if values[k], err = spannerValue(v); err != nil {
+			return nil, fmt.Errorf("column %v: %w", k, err)
+		}
+	}
+
+	switch op {
+	case spannerOpInsert:
+		return spanner.InsertMap(g.table, values), nil
+	case spannerOpUpdate:
+		return spanner.UpdateMap(g.table, values), nil
+	case spannerOpReplace:
+		return spanner.ReplaceMap(g.table, values), nil
+	case spannerOpDelete:
+		if len(g.keyColumns) == 0 {
+			return nil, errors.New("key_columns must be set in order to delete rows")
+		}
+		key := make(spanner.Key, len(g.keyColumns))
+		for j, c := range g.keyColumns {
+			v, exists := values[c]
+			if !exists {
+				return nil, fmt.Errorf("key column %v is missing from the args mapping result", c)
+			}
+			key[j] = v
+		}
+		return spanner.Delete(g.table, key), nil
+	}
+	return spanner.InsertOrUpdateMap(g.table, values), nil
+}
+
+func (g *gcpSpannerOutput) WriteBatch(ctx context.Context, batch service.MessageBatch) error {
+	g.connMut.RLock()
+	client := g.client
+	g.connMut.RUnlock()
+	if client == nil {
+		return service.ErrNotConnected
+	}
+
+	mutations := make([]*spanner.Mutation, len(batch))
+	for i := range batch {
+		var err error
+		if mutations[i], err = g.mutation(i, batch); err != nil {
+			return fmt.Errorf("message %v: %w", i, err)
+		}
+	}
+
+	_, err := client.Apply(ctx, mutations)
+	return err
+}
+
+func (g *gcpSpannerOutput) Close(ctx context.Context) error {
+	g.connMut.Lock()
+	if g.client != nil {
+		g.client.Close()
+		g.client = nil
+	}
+	g.connMut.Unlock()
+	return nil
+}
@@ -9,6 +9,8 @@ import (
 	"google.golang.org/api/iterator"
 	"google.golang.org/api/option"
 
+	"github.com/benthosdev/benthos/v4/internal/impl/gcp/auth"
+	"github.com/benthosdev/benthos/v4/internal/impl/sql/enrich"
 	"github.com/benthosdev/benthos/v4/public/bloblang"
 	"github.com/benthosdev/benthos/v4/public/service"
 )
@@ -19,6 +21,8 @@ type bigQuerySelectProcessorConfig struct {
 	queryParts  *bqQueryParts
 	jobLabels   map[string]string
 	argsMapping *bloblang.Executor
+
+	clientOptions []option.ClientOption
 }
 
 func bigQuerySelectProcessorConfigFromParsed(inConf *service.ParsedConfig) (conf bigQuerySelectProcessorConfig, err error) {
@@ -67,6 +71,10 @@ func bigQuerySelectProcessorConfigFromParsed(inConf *service.ParsedConfig) (conf
 		}
 	}
 
+	if conf.clientOptions, err = clientOptionsFromParsedConfig(inConf); err != nil {
+		return
+	}
+
 	return
 }
 
@@ -95,6 +103,8 @@ func newBigQuerySelectProcessorConfig() *service.ConfigSpec {
 		Field(service.NewStringField("suffix").
 			Description("An optional suffix to append to the select query.").
 			Optional()).
+		Field(enrich.ResultCacheField()).
+		Field(service.NewInternalField(auth.FieldSpec())).
 		Example("Word count",
 			`
 Given a stream of English terms, enrich the messages with the word count from Shakespeare's public works:`,
@@ -122,7 +132,8 @@ pipeline:
 }
 
 type bigQueryProcessorOptions struct {
-	logger *service.Logger
+	logger    *service.Logger
+	resources *service.Resources
 
 	// Allows passing additional to the underlying BigQuery client.
 	// Useful when writing tests.
@@ -133,6 +144,7 @@ type bigQuerySelectProcessor struct {
 	logger   *service.Logger
 	config   *bigQuerySelectProcessorConfig
 	client   bqClient
+	cache    *enrich.ResultCache
 	closeCtx context.Context
 	closeF   context.CancelFunc
 }
@@ -143,9 +155,15 @@ func newBigQuerySelectProcessor(inConf *service.ParsedConfig, options *bigQueryP
 		return nil, fmt.Errorf("failed to parse config: %w", err)
 	}
 
+	cache, err := enrich.ResultCacheFromParsed(inConf, options.resources)
+	if err != nil {
+		return nil, err
+	}
+
 	closeCtx, closeF := context.WithCancel(context.Background())
 
-	wrapped, err := bigquery.NewClient(closeCtx, conf.project, options.clientOptions...)
+	clientOptions := append(conf.clientOptions, options.clientOptions...)
+	wrapped, err := bigquery.NewClient(closeCtx, conf.project, clientOptions...)
 	if err != nil {
 		closeF()
 		return nil, fmt.Errorf("failed to create bigquery client: %w", err)
@@ -157,69 +175,82 @@ func newBigQuerySelectProcessor(inConf *service.ParsedConfig, options *bigQueryP
 		logger:   options.logger,
 		config:   &conf,
 		client:   client,
+		cache:    cache,
 		closeCtx: closeCtx,
 		closeF:   closeF,
 	}, nil
 }
 
-func (proc *bigQuerySelectProcessor) ProcessBatch(ctx context.Context, batch service.MessageBatch) ([]service.MessageBatch, error) {
-	argsMapping := proc.config.argsMapping
-
-	outBatch := make(service.MessageBatch, 0, len(batch))
-
-	for i, msg := range batch {
-		outBatch = append(outBatch, msg)
-
-		var args []interface{}
-		if argsMapping != nil {
-			resMsg, err := batch.BloblangQuery(i, argsMapping)
-			if err != nil {
-				msg.SetError(fmt.Errorf("failed to resolve args mapping: %w", err))
-				continue
-			}
-
-			iargs, err := resMsg.AsStructured()
-			if err != nil {
-				msg.SetError(fmt.Errorf("mapping returned non-structured result: %w", err))
-				continue
-			}
-
-			var ok bool
-			if args, ok = iargs.([]interface{}); !ok {
-				msg.SetError(fmt.Errorf("mapping returned non-array result: %T", iargs))
-				continue
-			}
+func (proc *bigQuerySelectProcessor) queryForMessage(batch service.MessageBatch, i int) (string, enrich.QueryFn, error) {
+	var args []interface{}
+	if argsMapping := proc.config.argsMapping; argsMapping != nil {
+		resMsg, err := batch.BloblangQuery(i, argsMapping)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to resolve args mapping: %w", err)
 		}
 
-		iter, err := proc.client.RunQuery(ctx, &bqQueryBuilderOptions{
-			queryParts: proc.config.queryParts,
-			jobLabels:  proc.config.jobLabels,
-			args:       args,
-		})
+		iargs, err := resMsg.AsStructured()
+		if err != nil {
+			return "", nil, fmt.Errorf("mapping returned non-structured result: %w", err)
+		}
+
+		var ok bool
+		if args, ok = iargs.([]interface{}); !ok {
+			return "", nil, fmt.Errorf("mapping returned non-array result: %T", iargs)
+		}
+	}
+
+	options := &bqQueryBuilderOptions{
+		queryParts: proc.config.queryParts,
+		jobLabels:  proc.config.jobLabels,
+		args:       args,
+	}
+
+	query, queryArgs, err := buildBQQueryString(options)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to build query string: %w", err)
+	}
+
+	key, err := enrich.Key(query, queryArgs)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return key, func(ctx context.Context) ([]byte, error) {
+		iter, err := proc.client.RunQuery(ctx, options)
 		if err != nil {
-			msg.SetError(err)
-			continue
+			return nil, err
 		}
 
 		rows, err := consumeIterator(iter)
 		if err != nil {
-			msg.SetError(fmt.Errorf("failed to read all rows: %w", err))
-			continue
+			return nil, fmt.Errorf("failed to read all rows: %w", err)
 		}
 
 		bs, err := json.Marshal(rows)
 		if err != nil {
-			msg.SetError(fmt.Errorf("failed to marshal rows to json: %w", err))
-			continue
+			return nil, fmt.Errorf("failed to marshal rows to json: %w", err)
 		}
+		return bs, nil
+	}, nil
+}
 
-		msg.SetBytes(bs)
-	}
+func (proc *bigQuerySelectProcessor) ProcessBatch(ctx context.Context, batch service.MessageBatch) ([]service.MessageBatch, error) {
+	outBatch := make(service.MessageBatch, len(batch))
+	copy(outBatch, batch)
 
+	enrich.ExecuteBatch(ctx, proc.cache, outBatch, func(i int) (string, enrich.QueryFn, error) {
+		return proc.queryForMessage(outBatch, i)
+	})
 	return []service.MessageBatch{outBatch}, nil
 }
 
 func (proc *bigQuerySelectProcessor) Close(ctx context.Context) error {
+	if proc.cache != nil {
+		if err := proc.cache.Close(ctx); err != nil {
+			return err
+		}
+	}
 	proc.closeF()
 	return nil
 }
@@ -248,7 +279,8 @@ func init() {
 		"gcp_bigquery_select", newBigQuerySelectProcessorConfig(),
 		func(conf *service.ParsedConfig, mgr *service.Resources) (service.BatchProcessor, error) {
 			return newBigQuerySelectProcessor(conf, &bigQueryProcessorOptions{
-				logger: mgr.Logger(),
+				logger:    mgr.Logger(),
+				resources: mgr,
 			})
 		})
 
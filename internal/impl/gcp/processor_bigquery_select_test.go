@@ -146,3 +146,48 @@ func TestGCPBigQuerySelectProcessor_IteratorError(t *testing.T) {
 
 	mockClient.AssertExpectations(t)
 }
+
+func TestGCPBigQuerySelectProcessor_ResultCache(t *testing.T) {
+	spec := newBigQuerySelectProcessorConfig()
+
+	parsed, err := spec.ParseYAML(testBQProcessorYAML+`
+result_cache:
+  resource: foocache
+`, nil)
+	require.NoError(t, err)
+
+	proc, err := newBigQuerySelectProcessor(parsed, &bigQueryProcessorOptions{
+		resources:     service.MockResources(service.MockResourcesOptAddCache("foocache")),
+		clientOptions: []option.ClientOption{option.WithoutAuthentication()},
+	})
+	require.NoError(t, err)
+
+	mockClient := &mockBQClient{}
+	proc.client = mockClient
+
+	mockClient.On("RunQuery", mock.Anything, mock.Anything).Return(&mockBQIterator{
+		rows: []string{`{"total_count": 25568, "word": "the"}`},
+	}, nil)
+
+	for i := 0; i < 2; i++ {
+		batches, err := proc.ProcessBatch(context.Background(), service.MessageBatch{
+			service.NewMessage([]byte(`{"term": "test1"}`)),
+			service.NewMessage([]byte(`{"term": "test1"}`)),
+		})
+		require.NoError(t, err)
+		require.Len(t, batches, 1)
+		require.Len(t, batches[0], 2)
+
+		for _, msg := range batches[0] {
+			require.NoError(t, msg.GetError())
+			b, err := msg.AsBytes()
+			require.NoError(t, err)
+			require.JSONEq(t, `[{"total_count": 25568, "word": "the"}]`, string(b))
+		}
+	}
+
+	// Identical queries within a batch are executed once and then cached.
+	mockClient.AssertNumberOfCalls(t, "RunQuery", 1)
+
+	require.NoError(t, proc.Close(context.Background()))
+}
@@ -0,0 +1,204 @@
+package gcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"cloud.google.com/go/spanner"
+	"google.golang.org/api/iterator"
+
+	"github.com/benthosdev/benthos/v4/public/bloblang"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func gcpSpannerQueryProcessorConfig() *service.ConfigSpec {
+	spec := service.NewConfigSpec().
+		Beta().
+		Categories("Integration").
+		Version("4.3.0").
+		Summary("Runs a SQL query against a Google Cloud Spanner database and (optionally) returns the result as an array of objects, one for each row returned.").
+		Description(`
+Arguments are provided to the query as named parameters, which are referenced within the query with the syntax ` + "`@name`" + `.
+
+Queries are executed as single-use, read-only transactions unless ` + "`exec_only`" + ` is set to ` + "`true`" + `, in which case the query is executed as a DML statement within a read-write transaction and the message contents remain unchanged.
+
+If the query fails to execute then the message will remain unchanged and the error can be caught using error handling methods outlined [here](/docs/configuration/error_handling).
+
+## Credentials
+
+By default Benthos will use a shared credentials file when connecting to GCP services. You can find out more [in this document](/docs/guides/cloud/gcp).`)
+
+	for _, f := range spannerDatabaseFields() {
+		spec = spec.Field(f)
+	}
+
+	return spec.
+		Field(service.NewStringField("query").
+			Description("The query to execute.").
+			Example("SELECT * FROM users WHERE id = @id").
+			Example("UPDATE users SET name = @name WHERE id = @id")).
+		Field(service.NewBloblangField("args_mapping").
+			Description("An optional [Bloblang mapping](/docs/guides/bloblang/about) which should evaluate to an object of values, where each key is the name of a parameter of the field `query`.").
+			Example(`root = { "id": this.user.id }`).
+			Example(`root.id = meta("user_id")`).
+			Optional()).
+		Field(service.NewBoolField("exec_only").
+			Description("Whether the query is a DML statement to be executed within a read-write transaction, in which case the message contents will remain unchanged.").
+			Default(false)).
+		Example(
+			"Table Query",
+			`Here we query a table for rows that share a `+"`user_id`"+` with the message field `+"`user.id`"+`. A `+"[`branch` processor](/docs/components/processors/branch)"+` is used in order to insert the resulting array into the original message at the path `+"`orders`"+`.`,
+			`
+pipeline:
+  processors:
+    - branch:
+        processors:
+          - gcp_spanner_query:
+              project: foo
+              instance: bar
+              database: baz
+              query: "SELECT * FROM orders WHERE user_id = @user_id"
+              args_mapping: 'root.user_id = this.user.id'
+        result_map: 'root.orders = this'
+`,
+		)
+}
+
+func init() {
+	err := service.RegisterBatchProcessor(
+		"gcp_spanner_query", gcpSpannerQueryProcessorConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.BatchProcessor, error) {
+			return newGCPSpannerQueryProcessorFromParsed(conf, mgr)
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type gcpSpannerQueryProcessor struct {
+	client *spanner.Client
+
+	query       string
+	argsMapping *bloblang.Executor
+	onlyExec    bool
+
+	log *service.Logger
+}
+
+func newGCPSpannerQueryProcessorFromParsed(conf *service.ParsedConfig, mgr *service.Resources) (*gcpSpannerQueryProcessor, error) {
+	g := &gcpSpannerQueryProcessor{
+		log: mgr.Logger(),
+	}
+
+	dbConf, err := spannerDatabaseConfigFromParsed(conf)
+	if err != nil {
+		return nil, err
+	}
+	if g.query, err = conf.FieldString("query"); err != nil {
+		return nil, err
+	}
+	if conf.Contains("args_mapping") {
+		if g.argsMapping, err = conf.FieldBloblang("args_mapping"); err != nil {
+			return nil, err
+		}
+	}
+	if g.onlyExec, err = conf.FieldBool("exec_only"); err != nil {
+		return nil, err
+	}
+
+	if g.client, err = dbConf.newClient(context.Background()); err != nil {
+		return nil, fmt.Errorf("error creating spanner client: %w", err)
+	}
+	return g, nil
+}
+
+func (g *gcpSpannerQueryProcessor) statement(i int, batch service.MessageBatch) (spanner.Statement, error) {
+	stmt := spanner.NewStatement(g.query)
+	if g.argsMapping == nil {
+		return stmt, nil
+	}
+
+	resMsg, err := batch.BloblangQuery(i, g.argsMapping)
+	if err != nil {
+		return stmt, fmt.Errorf("arguments mapping failed: %w", err)
+	}
+	if resMsg == nil {
+		return stmt, errors.New("arguments mapping resulted in a deleted message")
+	}
+
+	iargs, err := resMsg.AsStructured()
+	if err != nil {
+		return stmt, fmt.Errorf("mapping returned non-structured result: %w", err)
+	}
+	args, ok := iargs.(map[string]interface{})
+	if !ok {
+		return stmt, fmt.Errorf("mapping returned non-object result: %T", iargs)
+	}
+	for k, v := range args {
+		if stmt.Params[k], err = spannerValue(v); err != nil {
+			return stmt, fmt.Errorf("parameter %v: %w", k, err)
+		}
+	}
+	return stmt, nil
+}
+
+func (g *gcpSpannerQueryProcessor) queryRows(ctx context.Context, stmt spanner.Statement) ([]interface{}, error) {
+	iter := g.client.Single().Query(ctx, stmt)
+	defer iter.Stop()
+
+	rows := []interface{}{}
+	for {
+		row, err := iter.Next()
+		if errors.Is(err, iterator.Done) {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		obj, err := spannerRowToMap(row)
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, obj)
+	}
+}
+
+func (g *gcpSpannerQueryProcessor) ProcessBatch(ctx context.Context, batch service.MessageBatch) ([]service.MessageBatch, error) {
+	batch = batch.Copy()
+	for i, msg := range batch {
+		stmt, err := g.statement(i, batch)
+		if err != nil {
+			g.log.Debugf("Failed to prepare query: %v", err)
+			msg.SetError(err)
+			continue
+		}
+
+		if g.onlyExec {
+			if _, err := g.client.ReadWriteTransaction(ctx, func(ctx context.Context, txn *spanner.ReadWriteTransaction) error {
+				_, err := txn.Update(ctx, stmt)
+				return err
+			}); err != nil {
+				g.log.Debugf("Failed to run query: %v", err)
+				msg.SetError(err)
+			}
+			continue
+		}
+
+		rows, err := g.queryRows(ctx, stmt)
+		if err != nil {
+			g.log.Debugf("Failed to run query: %v", err)
+			msg.SetError(err)
+			continue
+		}
+		msg.SetStructured(rows)
+	}
+	return []service.MessageBatch{batch}, nil
+}
+
+func (g *gcpSpannerQueryProcessor) Close(ctx context.Context) error {
+	g.client.Close()
+	return nil
+}
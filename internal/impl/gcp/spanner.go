@@ -0,0 +1,211 @@
+package gcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+
+	"cloud.google.com/go/spanner"
+	"google.golang.org/api/option"
+	sppb "google.golang.org/genproto/googleapis/spanner/v1"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/benthosdev/benthos/v4/internal/impl/gcp/auth"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func spannerDatabaseFields() []*service.ConfigField {
+	return []*service.ConfigField{
+		service.NewStringField("project").Description("The project ID of the Spanner instance."),
+		service.NewStringField("instance").Description("The ID of the Spanner instance."),
+		service.NewStringField("database").Description("The ID of the database."),
+		service.NewInternalField(auth.FieldSpec()),
+	}
+}
+
+type spannerDatabaseConfig struct {
+	path          string
+	clientOptions []option.ClientOption
+}
+
+func spannerDatabaseConfigFromParsed(conf *service.ParsedConfig) (dconf spannerDatabaseConfig, err error) {
+	var project, instance, database string
+	if project, err = conf.FieldString("project"); err != nil {
+		return
+	}
+	if instance, err = conf.FieldString("instance"); err != nil {
+		return
+	}
+	if database, err = conf.FieldString("database"); err != nil {
+		return
+	}
+	dconf.path = fmt.Sprintf("projects/%v/instances/%v/databases/%v", project, instance, database)
+	if dconf.clientOptions, err = clientOptionsFromParsedConfig(conf); err != nil {
+		return
+	}
+	return
+}
+
+func (d spannerDatabaseConfig) newClient(ctx context.Context) (*spanner.Client, error) {
+	return spanner.NewClient(ctx, d.path, d.clientOptions...)
+}
+
+//------------------------------------------------------------------------------
+
+// spannerValue converts a structured value resulting from a Bloblang mapping
+// into a value that the Spanner client is able to encode. Objects, and arrays
+// that aren't made up of a single scalar type, are converted into JSON values.
+func spannerValue(v interface{}) (interface{}, error) {
+	switch t := v.(type) {
+	case json.Number:
+		if i, err := t.Int64(); err == nil {
+			return i, nil
+		}
+		return t.Float64()
+	case int:
+		return int64(t), nil
+	case uint64:
+		if t > math.MaxInt64 {
+			return nil, fmt.Errorf("value %v overflows an INT64", t)
+		}
+		return int64(t), nil
+	case map[string]interface{}:
+		return spanner.NullJSON{Value: t, Valid: true}, nil
+	case []interface{}:
+		return spannerArrayValue(t)
+	}
+	return v, nil
+}
+
+func spannerArrayValue(arr []interface{}) (interface{}, error) {
+	values := make([]interface{}, len(arr))
+	for i, ele := range arr {
+		var err error
+		if values[i], err = spannerValue(ele); err != nil {
+			return nil, err
+		}
+	}
+	if len(values) == 0 {
+		return []string{}, nil
+	}
+
+	switch values[0].(type) {
+	case string:
+		strs := make([]string, len(values))
+		for i, v := range values {
+			s, ok := v.(string)
+			if !ok {
+				return spanner.NullJSON{Value: arr, Valid: true}, nil
+			}
+			strs[i] = s
+		}
+		return strs, nil
+	case int64:
+		ints := make([]int64, len(values))
+		for i, v := range values {
+			n, ok := v.(int64)
+			if !ok {
+				return spanner.NullJSON{Value: arr, Valid: true}, nil
+			}
+			ints[i] = n
+		}
+		return ints, nil
+	case float64:
+		floats := make([]float64, len(values))
+		for i, v := range values {
+			switch n := v.(type) {
+			case float64:
+				floats[i] = n
+			case int64:
+				floats[i] = float64(n)
+			default:
+				return spanner.NullJSON{Value: arr, Valid: true}, nil
+			}
+		}
+		return floats, nil
+	case bool:
+		bools := make([]bool, len(values))
+		for i, v := range values {
+			b, ok := v.(bool)
+			if !ok {
+				return spanner.NullJSON{Value: arr, Valid: true}, nil
+			}
+			bools[i] = b
+		}
+		return bools, nil
+	}
+	return spanner.NullJSON{Value: arr, Valid: true}, nil
+}
+
+// spannerRowToMap converts a row returned by a query into an object keyed by
+// column names.
+func spannerRowToMap(row *spanner.Row) (map[string]interface{}, error) {
+	obj := make(map[string]interface{}, row.Size())
+	for i, name := range row.ColumnNames() {
+		var col spanner.GenericColumnValue
+		if err := row.Column(i, &col); err != nil {
+			return nil, err
+		}
+		v, err := spannerColumnValue(col.Type, col.Value)
+		if err != nil {
+			return nil, fmt.Errorf("column %v: %w", name, err)
+		}
+		obj[name] = v
+	}
+	return obj, nil
+}
+
+func spannerColumnValue(t *sppb.Type, v *structpb.Value) (interface{}, error) {
+	if _, isNull := v.GetKind().(*structpb.Value_NullValue); isNull {
+		return nil, nil
+	}
+
+	switch t.GetCode() {
+	case sppb.TypeCode_BOOL:
+		return v.GetBoolValue(), nil
+	case sppb.TypeCode_INT64:
+		return strconv.ParseInt(v.GetStringValue(), 10, 64)
+	case sppb.TypeCode_FLOAT64:
+		if s, isStr := v.GetKind().(*structpb.Value_StringValue); isStr {
+			// Non-finite values are encoded as strings.
+			return strconv.ParseFloat(s.StringValue, 64)
+		}
+		return v.GetNumberValue(), nil
+	case sppb.TypeCode_JSON:
+		var j interface{}
+		if err := json.Unmarshal([]byte(v.GetStringValue()), &j); err != nil {
+			return nil, err
+		}
+		return j, nil
+	case sppb.TypeCode_ARRAY:
+		values := v.GetListValue().GetValues()
+		arr := make([]interface{}, len(values))
+		for i, ele := range values {
+			var err error
+			if arr[i], err = spannerColumnValue(t.GetArrayElementType(), ele); err != nil {
+				return nil, err
+			}
+		}
+		return arr, nil
+	case sppb.TypeCode_STRUCT:
+		fields := t.GetStructType().GetFields()
+		values := v.GetListValue().GetValues()
+		if len(fields) != len(values) {
+			return nil, fmt.Errorf("struct has %v fields but %v values", len(fields), len(values))
+		}
+		obj := make(map[string]interface{}, len(fields))
+		for i, f := range fields {
+			var err error
+			if obj[f.GetName()], err = spannerColumnValue(f.GetType(), values[i]); err != nil {
+				return nil, err
+			}
+		}
+		return obj, nil
+	}
+
+	// Strings, bytes (base64 encoded), dates, timestamps and numerics are all
+	// represented as strings.
+	return v.GetStringValue(), nil
+}
@@ -0,0 +1,234 @@
+package gcp
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/spanner"
+	"cloud.google.com/go/spanner/spannertest"
+	"cloud.google.com/go/spanner/spansql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func TestSpannerValue(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		input  interface{}
+		output interface{}
+	}{
+		{name: "string", input: "foo", output: "foo"},
+		{name: "int number", input: json.Number("10"), output: int64(10)},
+		{name: "float number", input: json.Number("10.5"), output: 10.5},
+		{name: "int", input: 5, output: int64(5)},
+		{name: "bool", input: true, output: true},
+		{name: "empty array", input: []interface{}{}, output: []string{}},
+		{name: "string array", input: []interface{}{"a", "b"}, output: []string{"a", "b"}},
+		{name: "int array", input: []interface{}{json.Number("1"), 2}, output: []int64{1, 2}},
+		{name: "float array", input: []interface{}{1.5, json.Number("2")}, output: []float64{1.5, 2}},
+		{name: "bool array", input: []interface{}{true, false}, output: []bool{true, false}},
+		{
+			name:   "mixed array",
+			input:  []interface{}{"a", true},
+			output: spanner.NullJSON{Value: []interface{}{"a", true}, Valid: true},
+		},
+		{
+			name:   "object",
+			input:  map[string]interface{}{"a": "b"},
+			output: spanner.NullJSON{Value: map[string]interface{}{"a": "b"}, Valid: true},
+		},
+	} {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			v, err := spannerValue(test.input)
+			require.NoError(t, err)
+			assert.Equal(t, test.output, v)
+		})
+	}
+
+	_, err := spannerValue(uint64(1 << 63))
+	require.Error(t, err)
+}
+
+func spannerTestServer(t *testing.T) {
+	t.Helper()
+
+	srv, err := spannertest.NewServer("localhost:0")
+	require.NoError(t, err)
+	t.Cleanup(srv.Close)
+
+	ddl, err := spansql.ParseDDL("", `CREATE TABLE users (
+	id INT64 NOT NULL,
+	name STRING(MAX),
+	score FLOAT64,
+	tags ARRAY<STRING(MAX)>,
+) PRIMARY KEY (id)`)
+	require.NoError(t, err)
+	require.NoError(t, srv.UpdateDDL(ddl))
+
+	t.Setenv("SPANNER_EMULATOR_HOST", srv.Addr)
+}
+
+func spannerTestClient(ctx context.Context, t *testing.T) *spanner.Client {
+	t.Helper()
+
+	client, err := spanner.NewClient(ctx, "projects/foo/instances/bar/databases/baz")
+	require.NoError(t, err)
+	t.Cleanup(client.Close)
+	return client
+}
+
+const spannerTestDBConf = `
+project: foo
+instance: bar
+database: baz
+`
+
+func TestSpannerOutputAndQuery(t *testing.T) {
+	spannerTestServer(t)
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*30)
+	defer done()
+
+	outConf, err := gcpSpannerOutputConfig().ParseYAML(spannerTestDBConf+`
+table: users
+operation: '${! meta("op") }'
+args_mapping: 'root = this'
+key_columns: [ id ]
+`, nil)
+	require.NoError(t, err)
+
+	out, err := newGCPSpannerOutputFromParsed(outConf, service.MockResources())
+	require.NoError(t, err)
+	require.NoError(t, out.Connect(ctx))
+	t.Cleanup(func() {
+		_ = out.Close(context.Background())
+	})
+
+	newMsg := func(op, content string) *service.Message {
+		msg := service.NewMessage([]byte(content))
+		msg.MetaSet("op", op)
+		return msg
+	}
+
+	require.NoError(t, out.WriteBatch(ctx, service.MessageBatch{
+		newMsg("insert", `{"id":1,"name":"foo","score":1.5,"tags":["a","b"]}`),
+		newMsg("upsert", `{"id":2,"name":"bar"}`),
+		newMsg("insert", `{"id":3,"name":"baz"}`),
+	}))
+	require.NoError(t, out.WriteBatch(ctx, service.MessageBatch{
+		newMsg("update", `{"id":2,"name":"bar updated"}`),
+		newMsg("delete", `{"id":3}`),
+	}))
+
+	err = out.WriteBatch(ctx, service.MessageBatch{
+		newMsg("nope", `{"id":4}`),
+	})
+	require.EqualError(t, err, "message 0: unrecognised operation: nope")
+
+	procConf, err := gcpSpannerQueryProcessorConfig().ParseYAML(spannerTestDBConf+`
+query: 'SELECT id, name, score, tags FROM users WHERE id >= @min ORDER BY id'
+args_mapping: 'root.min = this.min.not_null()'
+`, nil)
+	require.NoError(t, err)
+
+	proc, err := newGCPSpannerQueryProcessorFromParsed(procConf, service.MockResources())
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = proc.Close(context.Background())
+	})
+
+	batches, err := proc.ProcessBatch(ctx, service.MessageBatch{
+		service.NewMessage([]byte(`{"min":0}`)),
+		service.NewMessage([]byte(`{"min":2}`)),
+		service.NewMessage([]byte(`{}`)),
+	})
+	require.NoError(t, err)
+	require.Len(t, batches, 1)
+	require.Len(t, batches[0], 3)
+
+	res, err := batches[0][0].AsStructured()
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"id": int64(1), "name": "foo", "score": 1.5, "tags": []interface{}{"a", "b"}},
+		map[string]interface{}{"id": int64(2), "name": "bar updated", "score": nil, "tags": nil},
+	}, res)
+
+	res, err = batches[0][1].AsStructured()
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"id": int64(2), "name": "bar updated", "score": nil, "tags": nil},
+	}, res)
+
+	require.Error(t, batches[0][2].GetError())
+}
+
+func TestSpannerQueryExecOnly(t *testing.T) {
+	spannerTestServer(t)
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*30)
+	defer done()
+
+	_, err := spannerTestClient(ctx, t).Apply(ctx, []*spanner.Mutation{
+		spanner.InsertMap("users", map[string]interface{}{"id": int64(1), "name": "foo"}),
+	})
+	require.NoError(t, err)
+
+	procConf, err := gcpSpannerQueryProcessorConfig().ParseYAML(spannerTestDBConf+`
+query: 'UPDATE users SET name = @name WHERE id = @id'
+args_mapping: 'root = this'
+exec_only: true
+`, nil)
+	require.NoError(t, err)
+
+	proc, err := newGCPSpannerQueryProcessorFromParsed(procConf, service.MockResources())
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = proc.Close(context.Background())
+	})
+
+	batches, err := proc.ProcessBatch(ctx, service.MessageBatch{
+		service.NewMessage([]byte(`{"id":1,"name":"bar"}`)),
+	})
+	require.NoError(t, err)
+	require.Len(t, batches, 1)
+	require.Len(t, batches[0], 1)
+	require.NoError(t, batches[0][0].GetError())
+
+	b, err := batches[0][0].AsBytes()
+	require.NoError(t, err)
+	assert.Equal(t, `{"id":1,"name":"bar"}`, string(b))
+
+	row, err := proc.client.Single().ReadRow(ctx, "users", spanner.Key{int64(1)}, []string{"name"})
+	require.NoError(t, err)
+
+	var name string
+	require.NoError(t, row.Column(0, &name))
+	assert.Equal(t, "bar", name)
+}
+
+func TestSpannerOutputDeleteRequiresKeyColumns(t *testing.T) {
+	conf, err := gcpSpannerOutputConfig().ParseYAML(spannerTestDBConf+`
+table: users
+operation: delete
+args_mapping: 'root = this'
+`, nil)
+	require.NoError(t, err)
+
+	_, err = newGCPSpannerOutputFromParsed(conf, service.MockResources())
+	require.EqualError(t, err, "key_columns must be set in order to delete rows")
+
+	conf, err = gcpSpannerOutputConfig().ParseYAML(spannerTestDBConf+`
+table: users
+operation: nope
+args_mapping: 'root = this'
+`, nil)
+	require.NoError(t, err)
+
+	_, err = newGCPSpannerOutputFromParsed(conf, service.MockResources())
+	require.EqualError(t, err, "unrecognised operation: nope")
+}
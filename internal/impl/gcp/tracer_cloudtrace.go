@@ -14,6 +14,7 @@ import (
 	"github.com/benthosdev/benthos/v4/internal/bundle"
 	"github.com/benthosdev/benthos/v4/internal/component/tracer"
 	"github.com/benthosdev/benthos/v4/internal/docs"
+	"github.com/benthosdev/benthos/v4/internal/impl/gcp/auth"
 )
 
 var _ gcptrace.Exporter
@@ -30,6 +31,7 @@ func init() {
 			docs.FieldFloat("sampling_ratio", "Sets the ratio of traces to sample. Tuning the sampling ratio is recommended for high-volume production workloads.", 1.0).HasDefault(1.0),
 			docs.FieldString("tags", "A map of tags to add to tracing spans.").Map().Advanced().HasDefault(map[string]interface{}{}),
 			docs.FieldString("flush_interval", "The period of time between each flush of tracing spans.").HasDefault(""),
+			auth.FieldSpec(),
 		),
 	})
 }
@@ -40,7 +42,15 @@ func init() {
 func NewCloudTrace(config tracer.Config, nm bundle.NewManagement) (trace.TracerProvider, error) {
 	sampler := tracesdk.ParentBased(tracesdk.TraceIDRatioBased(config.CloudTrace.SamplingRatio))
 
-	exp, err := gcptrace.New(gcptrace.WithProjectID(config.CloudTrace.Project))
+	opts, err := auth.ClientOptions(config.CloudTrace.Credentials)
+	if err != nil {
+		return nil, err
+	}
+
+	exp, err := gcptrace.New(
+		gcptrace.WithProjectID(config.CloudTrace.Project),
+		gcptrace.WithTraceClientOptions(opts),
+	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create cloud trace exporter: %w", err)
 	}
@@ -0,0 +1,199 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/benthosdev/benthos/v4/public/bloblang"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	gqlFieldURL       = "url"
+	gqlFieldQuery     = "query"
+	gqlFieldVariables = "variables"
+	gqlFieldHeaders   = "headers"
+	gqlFieldTimeout   = "timeout"
+	gqlFieldTLS       = "tls"
+)
+
+// clientFields adds the fields common to all GraphQL components to a spec.
+func clientFields(spec *service.ConfigSpec) *service.ConfigSpec {
+	return spec.
+		Field(service.NewStringField(gqlFieldURL).
+			Description("The URL of the GraphQL endpoint.").
+			Example("https://api.github.com/graphql")).
+		Field(service.NewStringField(gqlFieldQuery).
+			Description("The query or mutation document to execute.").
+			Example(`query ($login: String!) { user(login: $login) { name } }`)).
+		Field(service.NewBloblangField(gqlFieldVariables).
+			Description("An optional Bloblang mapping that returns an object of variables for each request.").
+			Example(`root.login = this.user`).
+			Optional()).
+		Field(service.NewInterpolatedStringMapField(gqlFieldHeaders).
+			Description("A map of headers to add to each request.").
+			Default(map[string]interface{}{}).
+			Example(map[string]interface{}{
+				"Authorization": `Bearer ${! env("API_TOKEN") }`,
+			})).
+		Field(service.NewDurationField(gqlFieldTimeout).
+			Description("The maximum period to wait for a response.").
+			Advanced().
+			Default("30s")).
+		Field(service.NewTLSToggledField(gqlFieldTLS))
+}
+
+// response is the body of a GraphQL response.
+type response struct {
+	Data       interface{}   `json:"data"`
+	Errors     []interface{} `json:"errors"`
+	Extensions interface{}   `json:"extensions"`
+}
+
+// err returns an error when a response contains errors and no data.
+func (r *response) err() error {
+	if len(r.Errors) == 0 || r.Data != nil {
+		return nil
+	}
+	if e, ok := r.Errors[0].(map[string]interface{}); ok {
+		if msg, ok := e["message"].(string); ok {
+			return fmt.Errorf("query failed: %v", msg)
+		}
+	}
+	return errors.New("query failed")
+}
+
+// setMetadata adds any errors and extensions of a response to a message as
+// metadata, encoded as JSON.
+func (r *response) setMetadata(msg *service.Message) {
+	if len(r.Errors) > 0 {
+		if b, err := json.Marshal(r.Errors); err == nil {
+			msg.MetaSet("graphql_errors", string(b))
+		}
+	}
+	if r.Extensions != nil {
+		if b, err := json.Marshal(r.Extensions); err == nil {
+			msg.MetaSet("graphql_extensions", string(b))
+		}
+	}
+}
+
+type client struct {
+	url       string
+	query     string
+	variables *bloblang.Executor
+	headers   map[string]*service.InterpolatedString
+	http      *http.Client
+}
+
+func newClientFromConfig(conf *service.ParsedConfig) (*client, error) {
+	c := &client{}
+
+	var err error
+	if c.url, err = conf.FieldString(gqlFieldURL); err != nil {
+		return nil, err
+	}
+	if c.query, err = conf.FieldString(gqlFieldQuery); err != nil {
+		return nil, err
+	}
+	if conf.Contains(gqlFieldVariables) {
+		if c.variables, err = conf.FieldBloblang(gqlFieldVariables); err != nil {
+			return nil, err
+		}
+	}
+	if c.headers, err = conf.FieldInterpolatedStringMap(gqlFieldHeaders); err != nil {
+		return nil, err
+	}
+
+	timeout, err := conf.FieldDuration(gqlFieldTimeout)
+	if err != nil {
+		return nil, err
+	}
+	c.http = &http.Client{Timeout: timeout}
+
+	tlsConf, tlsEnabled, err := conf.FieldTLSToggled(gqlFieldTLS)
+	if err != nil {
+		return nil, err
+	}
+	if tlsEnabled {
+		c.http.Transport = &http.Transport{TLSClientConfig: tlsConf.Clone()}
+	}
+	return c, nil
+}
+
+// resolveVariables executes the variables mapping against a message.
+func (c *client) resolveVariables(msg *service.Message) (map[string]interface{}, error) {
+	variables := map[string]interface{}{}
+	if c.variables == nil {
+		return variables, nil
+	}
+	res, err := msg.BloblangQuery(c.variables)
+	if err != nil {
+		return nil, fmt.Errorf("variables mapping failed: %w", err)
+	}
+	if res == nil {
+		return variables, nil
+	}
+	v, err := res.AsStructured()
+	if err != nil {
+		return nil, fmt.Errorf("variables mapping failed: %w", err)
+	}
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("variables mapping must return an object, got %T", v)
+	}
+	return obj, nil
+}
+
+// do executes the query with a set of variables, where headers are
+// interpolated from a message.
+func (c *client) do(ctx context.Context, msg *service.Message, variables map[string]interface{}) (*response, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"query":     c.query,
+		"variables": variables,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	for k, v := range c.headers {
+		req.Header.Set(k, v.String(msg))
+	}
+
+	res, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	resBytes, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var gqlRes response
+	if jErr := json.Unmarshal(resBytes, &gqlRes); jErr != nil {
+		if res.StatusCode < 200 || res.StatusCode > 299 {
+			return nil, fmt.Errorf("request failed with status %v", res.StatusCode)
+		}
+		return nil, fmt.Errorf("failed to parse response: %w", jErr)
+	}
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		if err := gqlRes.err(); err != nil {
+			return nil, fmt.Errorf("request failed with status %v: %w", res.StatusCode, err)
+		}
+		return nil, fmt.Errorf("request failed with status %v", res.StatusCode)
+	}
+	return &gqlRes, nil
+}
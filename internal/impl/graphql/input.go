@@ -0,0 +1,240 @@
+package graphql
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/Jeffail/gabs/v2"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	gqlFieldItemsPath      = "items_path"
+	gqlFieldPagination     = "pagination"
+	gqlFieldCursorVariable = "cursor_variable"
+	gqlFieldCursorPath     = "cursor_path"
+	gqlFieldHasNextPath    = "has_next_page_path"
+	gqlFieldInterval       = "interval"
+)
+
+func inputConfig() *service.ConfigSpec {
+	spec := service.NewConfigSpec().
+		// Stable(). TODO
+		Categories("Network").
+		Summary("Executes a GraphQL query and consumes the data of the response, following cursor based pagination.").
+		Description(`
+The variables mapping is executed against an empty message. When ` + "`pagination.cursor_path`" + ` is set the cursor found at that path of the response data is passed to the next request as the variable named by ` + "`pagination.cursor_variable`" + `, and pages are consumed until the cursor is empty or unchanged, or the field at ` + "`pagination.has_next_page_path`" + ` is false.
+
+Once all pages have been consumed the input closes unless ` + "`interval`" + ` is set, in which case the query is executed again from the first page after the interval.
+
+When ` + "`items_path`" + ` is set and refers to an array within the response data each element is consumed as an individual message, with the elements of a page forming a batch. Otherwise the data of each page is consumed as a single message.
+
+### Metadata
+
+Any errors of a response that also contains data are added to each message as the metadata field ` + "`graphql_errors`" + `, and extensions are added as ` + "`graphql_extensions`" + `, both encoded as JSON. Responses that contain errors and no data are treated as failed requests and retried.`)
+	return clientFields(spec).
+		Field(service.NewStringField(gqlFieldItemsPath).
+			Description("An optional [dot path](/docs/configuration/field_paths) within the response data of an array of items to consume as individual messages.").
+			Example("repository.issues.nodes").
+			Default("")).
+		Field(service.NewObjectField(gqlFieldPagination,
+			service.NewStringField(gqlFieldCursorVariable).
+				Description("The name of the variable to pass the cursor of the next page as.").
+				Default("cursor"),
+			service.NewStringField(gqlFieldCursorPath).
+				Description("A [dot path](/docs/configuration/field_paths) within the response data of the cursor of the next page. Pagination is disabled when this is empty.").
+				Example("repository.issues.pageInfo.endCursor").
+				Default(""),
+			service.NewStringField(gqlFieldHasNextPath).
+				Description("An optional [dot path](/docs/configuration/field_paths) within the response data of a boolean indicating whether there are more pages.").
+				Example("repository.issues.pageInfo.hasNextPage").
+				Default(""),
+		).Description("Configures cursor based pagination.")).
+		Field(service.NewStringField(gqlFieldInterval).
+			Description("An optional interval after which the query is executed again once all pages have been consumed. When empty the input closes once all pages have been consumed.").
+			Example("5m").
+			Default("")).
+		Example("Paginated issues", "Consume all issues of a GitHub repository.", `
+input:
+  graphql:
+    url: https://api.github.com/graphql
+    query: |
+      query ($cursor: String) {
+        repository(owner: "benthosdev", name: "benthos") {
+          issues(first: 100, after: $cursor) {
+            nodes { number title }
+            pageInfo { endCursor hasNextPage }
+          }
+        }
+      }
+    headers:
+      Authorization: 'bearer ${! env("GITHUB_TOKEN") }'
+    items_path: repository.issues.nodes
+    pagination:
+      cursor_path: repository.issues.pageInfo.endCursor
+      has_next_page_path: repository.issues.pageInfo.hasNextPage
+`)
+}
+
+func init() {
+	err := service.RegisterBatchInput(
+		"graphql", inputConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.BatchInput, error) {
+			i, err := newInputFromConfig(conf)
+			if err != nil {
+				return nil, err
+			}
+			return service.AutoRetryNacksBatched(i), nil
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type input struct {
+	client      *client
+	itemsPath   string
+	cursorVar   string
+	cursorPath  string
+	hasNextPath string
+	interval    time.Duration
+
+	cursor    interface{}
+	exhausted bool
+	lastRun   time.Time
+}
+
+func newInputFromConfig(conf *service.ParsedConfig) (*input, error) {
+	c, err := newClientFromConfig(conf)
+	if err != nil {
+		return nil, err
+	}
+	i := &input{client: c}
+
+	if i.itemsPath, err = conf.FieldString(gqlFieldItemsPath); err != nil {
+		return nil, err
+	}
+	if i.cursorVar, err = conf.FieldString(gqlFieldPagination, gqlFieldCursorVariable); err != nil {
+		return nil, err
+	}
+	if i.cursorPath, err = conf.FieldString(gqlFieldPagination, gqlFieldCursorPath); err != nil {
+		return nil, err
+	}
+	if i.hasNextPath, err = conf.FieldString(gqlFieldPagination, gqlFieldHasNextPath); err != nil {
+		return nil, err
+	}
+	if i.cursorPath != "" && i.cursorVar == "" {
+		return nil, fmt.Errorf("a %v must be specified when paginating", gqlFieldCursorVariable)
+	}
+
+	intervalStr, err := conf.FieldString(gqlFieldInterval)
+	if err != nil {
+		return nil, err
+	}
+	if intervalStr != "" {
+		if i.interval, err = time.ParseDuration(intervalStr); err != nil {
+			return nil, fmt.Errorf("failed to parse interval: %w", err)
+		}
+	}
+	return i, nil
+}
+
+func (i *input) Connect(ctx context.Context) error {
+	return nil
+}
+
+// nextCursor returns the cursor of the page after a response, and whether that
+// page exists.
+func (i *input) nextCursor(data *gabs.Container) (interface{}, bool) {
+	if i.cursorPath == "" {
+		return nil, false
+	}
+	cursor := data.Path(i.cursorPath).Data()
+	if cursor == nil || cursor == "" || reflect.DeepEqual(cursor, i.cursor) {
+		return nil, false
+	}
+	if i.hasNextPath != "" {
+		if hasNext, _ := data.Path(i.hasNextPath).Data().(bool); !hasNext {
+			return nil, false
+		}
+	}
+	return cursor, true
+}
+
+func (i *input) items(data *gabs.Container) []interface{} {
+	if i.itemsPath == "" {
+		return []interface{}{data.Data()}
+	}
+	items := data.Path(i.itemsPath).Data()
+	if arr, ok := items.([]interface{}); ok {
+		return arr
+	}
+	if items == nil {
+		return nil
+	}
+	return []interface{}{items}
+}
+
+func (i *input) ReadBatch(ctx context.Context) (service.MessageBatch, service.AckFunc, error) {
+	for {
+		if i.exhausted {
+			if i.interval <= 0 {
+				return nil, nil, service.ErrEndOfInput
+			}
+			select {
+			case <-time.After(time.Until(i.lastRun.Add(i.interval))):
+			case <-ctx.Done():
+				return nil, nil, ctx.Err()
+			}
+			i.cursor, i.exhausted = nil, false
+		}
+		if i.cursor == nil {
+			i.lastRun = time.Now()
+		}
+
+		variables, err := i.client.resolveVariables(service.NewMessage(nil))
+		if err != nil {
+			return nil, nil, err
+		}
+		if i.cursor != nil {
+			variables[i.cursorVar] = i.cursor
+		}
+
+		res, err := i.client.do(ctx, service.NewMessage(nil), variables)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := res.err(); err != nil {
+			return nil, nil, err
+		}
+
+		data := gabs.Wrap(res.Data)
+		var more bool
+		if i.cursor, more = i.nextCursor(data); !more {
+			i.exhausted = true
+		}
+
+		var batch service.MessageBatch
+		for _, item := range i.items(data) {
+			msg := service.NewMessage(nil)
+			msg.SetStructured(item)
+			res.setMetadata(msg)
+			batch = append(batch, msg)
+		}
+		if len(batch) == 0 {
+			continue
+		}
+		return batch, func(context.Context, error) error {
+			return nil
+		}, nil
+	}
+}
+
+func (i *input) Close(ctx context.Context) error {
+	return nil
+}
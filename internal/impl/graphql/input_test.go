@@ -0,0 +1,148 @@
+package graphql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func newTestInput(t *testing.T, conf string) *input {
+	t.Helper()
+
+	parsed, err := inputConfig().ParseYAML(conf, nil)
+	require.NoError(t, err)
+
+	i, err := newInputFromConfig(parsed)
+	require.NoError(t, err)
+	return i
+}
+
+func readBatchContents(t *testing.T, i *input) []string {
+	t.Helper()
+
+	batch, ackFn, err := i.ReadBatch(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, ackFn(context.Background(), nil))
+
+	var contents []string
+	for _, msg := range batch {
+		b, err := msg.AsBytes()
+		require.NoError(t, err)
+		contents = append(contents, string(b))
+	}
+	return contents
+}
+
+// pagedResponse returns a page of issues, where the cursor of each page is the
+// index of its first issue.
+func pagedResponse(pageSize, total int) func(req testRequest) (int, string) {
+	return func(req testRequest) (int, string) {
+		start := 0
+		if c, ok := req.Variables["cursor"].(string); ok {
+			_, _ = fmt.Sscanf(c, "%d", &start)
+		}
+		var nodes string
+		for n := start; n < start+pageSize && n < total; n++ {
+			if nodes != "" {
+				nodes += ","
+			}
+			nodes += fmt.Sprintf(`{"number":%v}`, n)
+		}
+		end := start + pageSize
+		return 200, fmt.Sprintf(
+			`{"data":{"issues":{"nodes":[%v],"pageInfo":{"endCursor":"%v","hasNextPage":%v}}}}`,
+			nodes, end, end < total,
+		)
+	}
+}
+
+func TestInputPagination(t *testing.T) {
+	srv := &testServer{respond: pagedResponse(2, 5)}
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	i := newTestInput(t, `
+url: `+ts.URL+`
+query: 'query ($cursor: String) { issues(after: $cursor) { nodes { number } pageInfo { endCursor hasNextPage } } }'
+variables: 'root.owner = "foo"'
+items_path: issues.nodes
+pagination:
+  cursor_path: issues.pageInfo.endCursor
+  has_next_page_path: issues.pageInfo.hasNextPage
+`)
+	require.NoError(t, i.Connect(context.Background()))
+
+	assert.Equal(t, []string{`{"number":0}`, `{"number":1}`}, readBatchContents(t, i))
+	assert.Equal(t, []string{`{"number":2}`, `{"number":3}`}, readBatchContents(t, i))
+	assert.Equal(t, []string{`{"number":4}`}, readBatchContents(t, i))
+
+	_, _, err := i.ReadBatch(context.Background())
+	assert.True(t, errors.Is(err, service.ErrEndOfInput), err)
+
+	require.Len(t, srv.requests, 3)
+	assert.Equal(t, map[string]interface{}{"owner": "foo"}, srv.requests[0].Variables)
+	assert.Equal(t, map[string]interface{}{"owner": "foo", "cursor": "2"}, srv.requests[1].Variables)
+	assert.Equal(t, map[string]interface{}{"owner": "foo", "cursor": "4"}, srv.requests[2].Variables)
+}
+
+func TestInputInterval(t *testing.T) {
+	srv := &testServer{respond: func(req testRequest) (int, string) {
+		return 200, `{"data":{"status":"ok"},"extensions":{"cost":2}}`
+	}}
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	i := newTestInput(t, `
+url: `+ts.URL+`
+query: '{ status }'
+interval: 10ms
+`)
+
+	for n := 0; n < 3; n++ {
+		batch, _, err := i.ReadBatch(context.Background())
+		require.NoError(t, err)
+		require.Len(t, batch, 1)
+
+		b, _ := batch[0].AsBytes()
+		assert.Equal(t, `{"status":"ok"}`, string(b))
+		ext, _ := batch[0].MetaGet("graphql_extensions")
+		assert.Equal(t, `{"cost":2}`, ext)
+	}
+
+	ctx, done := context.WithTimeout(context.Background(), time.Millisecond)
+	defer done()
+	_, _, err := i.ReadBatch(ctx)
+	assert.Error(t, err)
+}
+
+func TestInputErrors(t *testing.T) {
+	srv := &testServer{respond: func(req testRequest) (int, string) {
+		return 200, `{"data":null,"errors":[{"message":"rate limited"}]}`
+	}}
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	i := newTestInput(t, `
+url: `+ts.URL+`
+query: '{ status }'
+`)
+	_, _, err := i.ReadBatch(context.Background())
+	assert.EqualError(t, err, "query failed: rate limited")
+
+	parsed, err := inputConfig().ParseYAML(`
+url: `+ts.URL+`
+query: '{ status }'
+interval: nope
+`, nil)
+	require.NoError(t, err)
+	_, err = newInputFromConfig(parsed)
+	assert.Error(t, err)
+}
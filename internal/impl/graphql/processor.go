@@ -0,0 +1,77 @@
+package graphql
+
+import (
+	"context"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func processorConfig() *service.ConfigSpec {
+	spec := service.NewConfigSpec().
+		// Stable(). TODO
+		Categories("Integration").
+		Summary("Executes a GraphQL query or mutation for each message, replacing its contents with the data of the response.").
+		Description(`
+The variables of each request are resolved from the message with a [Bloblang mapping](/docs/guides/bloblang/about), which makes it possible to enrich messages with queries or to apply mutations for each message of a stream. In order to preserve the original contents of messages use this processor within a ` + "[`branch`](/docs/components/processors/branch)" + ` processor.
+
+### Errors
+
+When a response contains errors as well as data the errors are added to the resulting message as the metadata field ` + "`graphql_errors`" + `, encoded as a JSON array. When a response contains errors and no data the message is flagged as having failed, which can be handled with [error handling patterns](/docs/configuration/error_handling), and the errors are also added as metadata. Any extensions of a response are added as the metadata field ` + "`graphql_extensions`" + `, encoded as JSON.`)
+	return clientFields(spec).
+		Example("Enrich users", "Fetch the profile of each user with the GitHub API and add it to the message.", `
+pipeline:
+  processors:
+    - branch:
+        processors:
+          - graphql:
+              url: https://api.github.com/graphql
+              query: 'query ($login: String!) { user(login: $login) { name company } }'
+              variables: 'root.login = this.login'
+              headers:
+                Authorization: 'bearer ${! env("GITHUB_TOKEN") }'
+        result_map: 'root.profile = this.user'
+`)
+}
+
+func init() {
+	err := service.RegisterProcessor(
+		"graphql", processorConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Processor, error) {
+			c, err := newClientFromConfig(conf)
+			if err != nil {
+				return nil, err
+			}
+			return &processor{client: c}, nil
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+type processor struct {
+	client *client
+}
+
+func (p *processor) Process(ctx context.Context, msg *service.Message) (service.MessageBatch, error) {
+	variables, err := p.client.resolveVariables(msg)
+	if err != nil {
+		return nil, err
+	}
+	res, err := p.client.do(ctx, msg, variables)
+	if err != nil {
+		return nil, err
+	}
+
+	newMsg := msg.Copy()
+	res.setMetadata(newMsg)
+	if err := res.err(); err != nil {
+		newMsg.SetError(err)
+		return service.MessageBatch{newMsg}, nil
+	}
+	newMsg.SetStructured(res.Data)
+	return service.MessageBatch{newMsg}, nil
+}
+
+func (p *processor) Close(ctx context.Context) error {
+	return nil
+}
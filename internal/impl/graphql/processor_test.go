@@ -0,0 +1,132 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+type testRequest struct {
+	Query     string                 `json:"query"`
+	Variables map[string]interface{} `json:"variables"`
+	Auth      string                 `json:"-"`
+}
+
+// testServer responds to GraphQL requests with the result of a function.
+type testServer struct {
+	mut      sync.Mutex
+	requests []testRequest
+	respond  func(req testRequest) (int, string)
+}
+
+func (s *testServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	var req testRequest
+	_ = json.NewDecoder(r.Body).Decode(&req)
+	req.Auth = r.Header.Get("Authorization")
+	s.requests = append(s.requests, req)
+
+	status, body := s.respond(req)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write([]byte(body))
+}
+
+func newTestProcessor(t *testing.T, conf string) *processor {
+	t.Helper()
+
+	parsed, err := processorConfig().ParseYAML(conf, nil)
+	require.NoError(t, err)
+
+	c, err := newClientFromConfig(parsed)
+	require.NoError(t, err)
+	return &processor{client: c}
+}
+
+func TestProcessorQuery(t *testing.T) {
+	srv := &testServer{respond: func(req testRequest) (int, string) {
+		return 200, `{"data":{"user":{"name":"` + req.Variables["login"].(string) + `"}},"extensions":{"cost":1}}`
+	}}
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	p := newTestProcessor(t, `
+url: `+ts.URL+`
+query: 'query ($login: String!) { user(login: $login) { name } }'
+variables: 'root.login = this.login'
+headers:
+  Authorization: Bearer ${! meta("token") }
+`)
+
+	msg := service.NewMessage([]byte(`{"login":"foo"}`))
+	msg.MetaSet("token", "abc")
+	batch, err := p.Process(context.Background(), msg)
+	require.NoError(t, err)
+	require.Len(t, batch, 1)
+
+	b, err := batch[0].AsBytes()
+	require.NoError(t, err)
+	assert.Equal(t, `{"user":{"name":"foo"}}`, string(b))
+	require.NoError(t, batch[0].GetError())
+
+	ext, _ := batch[0].MetaGet("graphql_extensions")
+	assert.Equal(t, `{"cost":1}`, ext)
+	_, exists := batch[0].MetaGet("graphql_errors")
+	assert.False(t, exists)
+
+	require.Len(t, srv.requests, 1)
+	assert.Equal(t, "query ($login: String!) { user(login: $login) { name } }", srv.requests[0].Query)
+	assert.Equal(t, map[string]interface{}{"login": "foo"}, srv.requests[0].Variables)
+	assert.Equal(t, "Bearer abc", srv.requests[0].Auth)
+}
+
+func TestProcessorErrors(t *testing.T) {
+	srv := &testServer{respond: func(req testRequest) (int, string) {
+		switch req.Variables["id"] {
+		case "partial":
+			return 200, `{"data":{"a":1,"b":null},"errors":[{"message":"b is unavailable","path":["b"]}]}`
+		case "failed":
+			return 200, `{"data":null,"errors":[{"message":"not authorised"}]}`
+		}
+		return 500, `internal error`
+	}}
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	p := newTestProcessor(t, `
+url: `+ts.URL+`
+query: 'query ($id: ID!) { a b }'
+variables: 'root.id = content().string()'
+`)
+
+	batch, err := p.Process(context.Background(), service.NewMessage([]byte("partial")))
+	require.NoError(t, err)
+	require.Len(t, batch, 1)
+	require.NoError(t, batch[0].GetError())
+	b, _ := batch[0].AsBytes()
+	assert.Equal(t, `{"a":1,"b":null}`, string(b))
+	errs, _ := batch[0].MetaGet("graphql_errors")
+	assert.Equal(t, `[{"message":"b is unavailable","path":["b"]}]`, errs)
+
+	batch, err = p.Process(context.Background(), service.NewMessage([]byte("failed")))
+	require.NoError(t, err)
+	require.Len(t, batch, 1)
+	assert.EqualError(t, batch[0].GetError(), "query failed: not authorised")
+	b, _ = batch[0].AsBytes()
+	assert.Equal(t, "failed", string(b))
+	errs, _ = batch[0].MetaGet("graphql_errors")
+	assert.Equal(t, `[{"message":"not authorised"}]`, errs)
+
+	_, err = p.Process(context.Background(), service.NewMessage([]byte("nope")))
+	assert.EqualError(t, err, "request failed with status 500")
+}
@@ -0,0 +1,653 @@
+package io
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Jeffail/gabs/v2"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	rpFieldURL              = "url"
+	rpFieldVerb             = "verb"
+	rpFieldHeaders          = "headers"
+	rpFieldQueryParams      = "query_params"
+	rpFieldItemsPath        = "items_path"
+	rpFieldPagination       = "pagination"
+	rpFieldStrategy         = "strategy"
+	rpFieldCursorPath       = "cursor_path"
+	rpFieldCursorParam      = "cursor_param"
+	rpFieldPageParam        = "page_param"
+	rpFieldStartPage        = "start_page"
+	rpFieldOffsetParam      = "offset_param"
+	rpFieldPageSizeParam    = "page_size_param"
+	rpFieldPageSize         = "page_size"
+	rpFieldWatermark        = "watermark"
+	rpFieldWatermarkPath    = "path"
+	rpFieldWatermarkParam   = "param"
+	rpFieldWatermarkInitial = "initial"
+	rpFieldWatermarkKey     = "key"
+	rpFieldCheckpoint       = "checkpoint"
+	rpFieldRateLimit        = "rate_limit"
+	rpFieldInterval         = "interval"
+	rpFieldTimeout          = "timeout"
+	rpFieldTLS              = "tls"
+
+	rpStrategyNone       = "none"
+	rpStrategyCursor     = "cursor"
+	rpStrategyLinkHeader = "link_header"
+	rpStrategyPage       = "page"
+	rpStrategyOffset     = "offset"
+)
+
+func restPollInputConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		// Stable(). TODO
+		Categories("Network").
+		Summary("Periodically polls a REST API for JSON items, following pagination and consuming only items that are newer than a watermark.").
+		Description(`
+Each poll requests pages from the API until the last page is reached, where the items of each page are consumed as a batch of messages. Once a poll has completed the next poll begins after `+"`interval`"+` has passed since the start of the previous one.
+
+### Pagination
+
+The strategy used to request the next page is configured with `+"`pagination.strategy`"+`:
+
+- `+"`none`"+`: Each poll requests a single page.
+- `+"`cursor`"+`: The cursor found at `+"`cursor_path`"+` within the response body is passed as the query parameter `+"`cursor_param`"+` of the next request, until the cursor is empty.
+- `+"`link_header`"+`: The URL with the relation `+"`next`"+` within the `+"`Link`"+` header of the response is requested, until the header no longer contains it.
+- `+"`page`"+`: The query parameter `+"`page_param`"+` is incremented from `+"`start_page`"+`, until a page contains fewer items than `+"`page_size`"+`.
+- `+"`offset`"+`: The query parameter `+"`offset_param`"+` is incremented by the number of items read, until a page contains fewer items than `+"`page_size`"+`.
+
+A [rate limit resource](/docs/components/rate_limits/about) can be specified with `+"`rate_limit`"+`, which is accessed before each page is requested.
+
+### Incremental Sync
+
+When `+"`watermark.path`"+` is set the value at that path within each item is tracked, and the greatest value consumed is passed as the query parameter `+"`watermark.param`"+` of the first request of each poll, so that only new or updated items are requested. Values are compared as numbers when both are numeric, and otherwise as strings, which orders timestamps of a consistent format such as RFC 3339.
+
+When `+"`watermark.checkpoint`"+` is set the watermark is persisted once the messages it belongs to, and all messages prior to them, have been acknowledged, and is restored when the input is restarted.`).
+		Field(service.NewStringField(rpFieldURL).
+			Description("The URL of the first page to request.").
+			Example("https://api.example.com/v1/orders")).
+		Field(service.NewStringField(rpFieldVerb).
+			Description("The HTTP verb of each request.").
+			Advanced().
+			Default("GET")).
+		Field(service.NewInterpolatedStringMapField(rpFieldHeaders).
+			Description("A map of headers to add to each request.").
+			Default(map[string]interface{}{}).
+			Example(map[string]interface{}{
+				"Authorization": `Bearer ${! env("API_TOKEN") }`,
+			})).
+		Field(service.NewStringMapField(rpFieldQueryParams).
+			Description("A map of query parameters to add to the first request of each poll, in addition to those of the URL.").
+			Default(map[string]interface{}{}).
+			Example(map[string]interface{}{
+				"status": "complete",
+			})).
+		Field(service.NewStringField(rpFieldItemsPath).
+			Description("A [dot path](/docs/configuration/field_paths) within each response body of the array of items to consume. When empty the response body itself must be an array, or is otherwise consumed as a single item.").
+			Example("data.orders").
+			Default("")).
+		Field(service.NewObjectField(rpFieldPagination,
+			service.NewStringEnumField(rpFieldStrategy, rpStrategyNone, rpStrategyCursor, rpStrategyLinkHeader, rpStrategyPage, rpStrategyOffset).
+				Description("The pagination strategy of the API.").
+				Default(rpStrategyNone),
+			service.NewStringField(rpFieldCursorPath).
+				Description("A [dot path](/docs/configuration/field_paths) within each response body of the cursor of the next page, used by the `cursor` strategy.").
+				Example("meta.next_cursor").
+				Default(""),
+			service.NewStringField(rpFieldCursorParam).
+				Description("The query parameter to pass the cursor of the next page as, used by the `cursor` strategy.").
+				Default("cursor"),
+			service.NewStringField(rpFieldPageParam).
+				Description("The query parameter of the page number, used by the `page` strategy.").
+				Default("page"),
+			service.NewIntField(rpFieldStartPage).
+				Description("The number of the first page, used by the `page` strategy.").
+				Default(1),
+			service.NewStringField(rpFieldOffsetParam).
+				Description("The query parameter of the offset of the first item of a page, used by the `offset` strategy.").
+				Default("offset"),
+			service.NewStringField(rpFieldPageSizeParam).
+				Description("An optional query parameter to pass the page size as.").
+				Example("limit").
+				Default(""),
+			service.NewIntField(rpFieldPageSize).
+				Description("The number of items of a full page, used by the `page` and `offset` strategies in order to detect the last page.").
+				Default(100),
+		).Description("Configures how the pages of a poll are requested.")).
+		Field(service.NewObjectField(rpFieldWatermark,
+			service.NewStringField(rpFieldWatermarkPath).
+				Description("A [dot path](/docs/configuration/field_paths) within each item of the value to track as the watermark. Incremental sync is disabled when this is empty.").
+				Example("updated_at").
+				Default(""),
+			service.NewStringField(rpFieldWatermarkParam).
+				Description("The query parameter to pass the watermark as.").
+				Example("updated_since").
+				Default(""),
+			service.NewStringField(rpFieldWatermarkInitial).
+				Description("The watermark to use when none has been stored.").
+				Example("2022-01-01T00:00:00Z").
+				Default(""),
+			service.NewStringField(rpFieldWatermarkKey).
+				Description("The key to store the watermark under within the checkpoint store, which defaults to the URL.").
+				Default(""),
+			service.NewCheckpointStoreField(rpFieldCheckpoint).
+				Optional(),
+		).Description("Configures incremental sync.")).
+		Field(service.NewStringField(rpFieldRateLimit).
+			Description("An optional [rate limit](/docs/components/rate_limits/about) to throttle the requests of pages by.").
+			Default("")).
+		Field(service.NewDurationField(rpFieldInterval).
+			Description("The period between the start of each poll.").
+			Default("1m")).
+		Field(service.NewDurationField(rpFieldTimeout).
+			Description("The maximum period to wait for a response.").
+			Advanced().
+			Default("30s")).
+		Field(service.NewTLSToggledField(rpFieldTLS)).
+		Example("Incremental sync", "Poll an API for orders that have been updated since the last poll, following cursors between pages and storing the watermark in a cache.", `
+input:
+  rest_poll:
+    url: https://api.example.com/v1/orders
+    headers:
+      Authorization: 'Bearer ${! env("API_TOKEN") }'
+    items_path: data
+    pagination:
+      strategy: cursor
+      cursor_path: meta.next_cursor
+    watermark:
+      path: updated_at
+      param: updated_since
+      checkpoint:
+        cache: checkpoints
+    interval: 5m
+
+cache_resources:
+  - label: checkpoints
+    file:
+      directory: ./checkpoints
+`)
+}
+
+func init() {
+	err := service.RegisterBatchInput(
+		"rest_poll", restPollInputConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.BatchInput, error) {
+			i, err := newRestPollInputFromConfig(conf, mgr)
+			if err != nil {
+				return nil, err
+			}
+			return service.AutoRetryNacksBatched(i), nil
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// restPollPage describes the next page of a poll to request.
+type restPollPage struct {
+	url    string
+	page   int
+	offset int
+}
+
+type restPollInput struct {
+	url         string
+	verb        string
+	headers     map[string]*service.InterpolatedString
+	queryParams map[string]string
+	itemsPath   string
+
+	strategy      string
+	cursorPath    string
+	cursorParam   string
+	pageParam     string
+	startPage     int
+	offsetParam   string
+	pageSizeParam string
+	pageSize      int
+
+	wmPath    string
+	wmParam   string
+	wmKey     string
+	storeConf *service.CheckpointStoreConfig
+
+	rateLimit string
+	interval  time.Duration
+	client    *http.Client
+
+	res *service.Resources
+	log *service.Logger
+
+	store     *service.CheckpointStore
+	committer *service.CheckpointCommitter
+	watermark string
+	next      *restPollPage
+	lastPoll  time.Time
+}
+
+func newRestPollInputFromConfig(conf *service.ParsedConfig, res *service.Resources) (*restPollInput, error) {
+	r := &restPollInput{res: res, log: res.Logger()}
+
+	var err error
+	if r.url, err = conf.FieldString(rpFieldURL); err != nil {
+		return nil, err
+	}
+	if _, err = url.Parse(r.url); err != nil {
+		return nil, fmt.Errorf("failed to parse url: %w", err)
+	}
+	if r.verb, err = conf.FieldString(rpFieldVerb); err != nil {
+		return nil, err
+	}
+	if r.headers, err = conf.FieldInterpolatedStringMap(rpFieldHeaders); err != nil {
+		return nil, err
+	}
+	if r.queryParams, err = conf.FieldStringMap(rpFieldQueryParams); err != nil {
+		return nil, err
+	}
+	if r.itemsPath, err = conf.FieldString(rpFieldItemsPath); err != nil {
+		return nil, err
+	}
+
+	pConf := conf.Namespace(rpFieldPagination)
+	if r.strategy, err = pConf.FieldString(rpFieldStrategy); err != nil {
+		return nil, err
+	}
+	if r.cursorPath, err = pConf.FieldString(rpFieldCursorPath); err != nil {
+		return nil, err
+	}
+	if r.cursorParam, err = pConf.FieldString(rpFieldCursorParam); err != nil {
+		return nil, err
+	}
+	if r.pageParam, err = pConf.FieldString(rpFieldPageParam); err != nil {
+		return nil, err
+	}
+	if r.startPage, err = pConf.FieldInt(rpFieldStartPage); err != nil {
+		return nil, err
+	}
+	if r.offsetParam, err = pConf.FieldString(rpFieldOffsetParam); err != nil {
+		return nil, err
+	}
+	if r.pageSizeParam, err = pConf.FieldString(rpFieldPageSizeParam); err != nil {
+		return nil, err
+	}
+	if r.pageSize, err = pConf.FieldInt(rpFieldPageSize); err != nil {
+		return nil, err
+	}
+	switch r.strategy {
+	case rpStrategyCursor:
+		if r.cursorPath == "" || r.cursorParam == "" {
+			return nil, errors.New("a cursor path and param must be specified for the cursor pagination strategy")
+		}
+	case rpStrategyPage, rpStrategyOffset:
+		if r.pageSize <= 0 {
+			return nil, fmt.Errorf("a page size must be specified for the %v pagination strategy", r.strategy)
+		}
+	}
+
+	wConf := conf.Namespace(rpFieldWatermark)
+	if r.wmPath, err = wConf.FieldString(rpFieldWatermarkPath); err != nil {
+		return nil, err
+	}
+	if r.wmParam, err = wConf.FieldString(rpFieldWatermarkParam); err != nil {
+		return nil, err
+	}
+	if r.watermark, err = wConf.FieldString(rpFieldWatermarkInitial); err != nil {
+		return nil, err
+	}
+	if r.wmKey, err = wConf.FieldString(rpFieldWatermarkKey); err != nil {
+		return nil, err
+	}
+	if r.wmKey == "" {
+		r.wmKey = r.url
+	}
+	// The checkpoint fields have defaults and are therefore always present, so
+	// the store is only enabled when a cache or table has been configured.
+	checkpointCache, _ := wConf.FieldString(rpFieldCheckpoint, "cache")
+	if checkpointCache != "" || wConf.Contains(rpFieldCheckpoint, "sql") {
+		if r.wmPath == "" {
+			return nil, errors.New("a watermark path must be specified in order to checkpoint the watermark")
+		}
+		storeConf, err := wConf.FieldCheckpointStore(rpFieldCheckpoint)
+		if err != nil {
+			return nil, err
+		}
+		r.storeConf = &storeConf
+	}
+
+	if r.rateLimit, err = conf.FieldString(rpFieldRateLimit); err != nil {
+		return nil, err
+	}
+	if r.rateLimit != "" && !res.HasRateLimit(r.rateLimit) {
+		return nil, fmt.Errorf("rate limit resource '%v' was not found", r.rateLimit)
+	}
+	if r.interval, err = conf.FieldDuration(rpFieldInterval); err != nil {
+		return nil, err
+	}
+
+	timeout, err := conf.FieldDuration(rpFieldTimeout)
+	if err != nil {
+		return nil, err
+	}
+	r.client = &http.Client{Timeout: timeout}
+
+	tlsConf, tlsEnabled, err := conf.FieldTLSToggled(rpFieldTLS)
+	if err != nil {
+		return nil, err
+	}
+	if tlsEnabled {
+		r.client.Transport = &http.Transport{TLSClientConfig: tlsConf}
+	}
+	return r, nil
+}
+
+func (r *restPollInput) Connect(ctx context.Context) error {
+	if r.storeConf == nil || r.store != nil {
+		return nil
+	}
+
+	store, err := r.storeConf.NewStore(ctx, r.res)
+	if err != nil {
+		return err
+	}
+	stored, err := store.Get(ctx, r.wmKey)
+	if err != nil && !errors.Is(err, service.ErrKeyNotFound) {
+		_ = store.Close(ctx)
+		return err
+	}
+	if stored != "" {
+		r.watermark = stored
+	}
+	r.store = store
+	r.committer = store.NewCommitter(r.wmKey)
+	return nil
+}
+
+// firstPage returns the first page of a new poll.
+func (r *restPollInput) firstPage() (*restPollPage, error) {
+	u, err := url.Parse(r.url)
+	if err != nil {
+		return nil, err
+	}
+	query := u.Query()
+	for k, v := range r.queryParams {
+		query.Set(k, v)
+	}
+	if r.wmParam != "" && r.watermark != "" {
+		query.Set(r.wmParam, r.watermark)
+	}
+	u.RawQuery = query.Encode()
+	return &restPollPage{url: u.String(), page: r.startPage}, nil
+}
+
+// pageURL returns the URL of a page with the parameters of the pagination
+// strategy added.
+func (r *restPollInput) pageURL(p *restPollPage) (string, error) {
+	if r.strategy != rpStrategyPage && r.strategy != rpStrategyOffset && r.pageSizeParam == "" {
+		return p.url, nil
+	}
+	u, err := url.Parse(p.url)
+	if err != nil {
+		return "", err
+	}
+	query := u.Query()
+	switch r.strategy {
+	case rpStrategyPage:
+		query.Set(r.pageParam, strconv.Itoa(p.page))
+	case rpStrategyOffset:
+		query.Set(r.offsetParam, strconv.Itoa(p.offset))
+	}
+	if r.pageSizeParam != "" {
+		query.Set(r.pageSizeParam, strconv.Itoa(r.pageSize))
+	}
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// nextLink returns the URL with the relation next within a Link header.
+func nextLink(base *url.URL, header string) string {
+	for _, link := range strings.Split(header, ",") {
+		segments := strings.Split(link, ";")
+		target := strings.TrimSpace(segments[0])
+		if !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+			continue
+		}
+		for _, param := range segments[1:] {
+			kv := strings.SplitN(strings.TrimSpace(param), "=", 2)
+			if len(kv) != 2 || !strings.EqualFold(kv[0], "rel") {
+				continue
+			}
+			for _, rel := range strings.Fields(strings.Trim(kv[1], `"`)) {
+				if !strings.EqualFold(rel, "next") {
+					continue
+				}
+				u, err := base.Parse(strings.Trim(target, "<>"))
+				if err != nil {
+					return ""
+				}
+				return u.String()
+			}
+		}
+	}
+	return ""
+}
+
+// nextPage returns the page after the one that has been read, or nil if it was
+// the last page of the poll.
+func (r *restPollInput) nextPage(p *restPollPage, res *http.Response, body *gabs.Container, items int) (*restPollPage, error) {
+	switch r.strategy {
+	case rpStrategyCursor:
+		cursor := body.Path(r.cursorPath).Data()
+		if cursor == nil || cursor == "" {
+			return nil, nil
+		}
+		u, err := url.Parse(p.url)
+		if err != nil {
+			return nil, err
+		}
+		query := u.Query()
+		query.Set(r.cursorParam, fmt.Sprintf("%v", cursor))
+		u.RawQuery = query.Encode()
+		if u.String() == p.url {
+			return nil, nil
+		}
+		return &restPollPage{url: u.String()}, nil
+	case rpStrategyLinkHeader:
+		if next := nextLink(res.Request.URL, res.Header.Get("Link")); next != "" {
+			return &restPollPage{url: next}, nil
+		}
+		return nil, nil
+	case rpStrategyPage, rpStrategyOffset:
+		if items < r.pageSize {
+			return nil, nil
+		}
+		return &restPollPage{url: p.url, page: p.page + 1, offset: p.offset + items}, nil
+	}
+	return nil, nil
+}
+
+func (r *restPollInput) waitForRateLimit(ctx context.Context) error {
+	if r.rateLimit == "" {
+		return nil
+	}
+	for {
+		var wait time.Duration
+		var err error
+		if aerr := r.res.AccessRateLimit(ctx, r.rateLimit, func(rl service.RateLimit) {
+			wait, err = rl.Access(ctx)
+		}); aerr != nil {
+			return aerr
+		}
+		if err != nil {
+			return err
+		}
+		if wait <= 0 {
+			return nil
+		}
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// fetch requests a page and returns its items along with the next page.
+func (r *restPollInput) fetch(ctx context.Context, p *restPollPage) ([]interface{}, *restPollPage, error) {
+	pageURL, err := r.pageURL(p)
+	if err != nil {
+		return nil, nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, r.verb, pageURL, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	emptyMsg := service.NewMessage(nil)
+	for k, v := range r.headers {
+		req.Header.Set(k, v.String(emptyMsg))
+	}
+
+	res, err := r.client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer res.Body.Close()
+
+	resBytes, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return nil, nil, fmt.Errorf("request to %v failed with status %v", pageURL, res.StatusCode)
+	}
+
+	var bodyData interface{}
+	if len(resBytes) > 0 {
+		if err := json.Unmarshal(resBytes, &bodyData); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse response: %w", err)
+		}
+	}
+	body := gabs.Wrap(bodyData)
+
+	itemsData := bodyData
+	if r.itemsPath != "" {
+		itemsData = body.Path(r.itemsPath).Data()
+	}
+	var items []interface{}
+	switch t := itemsData.(type) {
+	case []interface{}:
+		items = t
+	case nil:
+	default:
+		items = []interface{}{t}
+	}
+
+	next, err := r.nextPage(p, res, body, len(items))
+	if err != nil {
+		return nil, nil, err
+	}
+	return items, next, nil
+}
+
+// watermarkGreater returns whether watermark a is greater than b.
+func watermarkGreater(a, b string) bool {
+	if b == "" {
+		return a != ""
+	}
+	af, aErr := strconv.ParseFloat(a, 64)
+	bf, bErr := strconv.ParseFloat(b, 64)
+	if aErr == nil && bErr == nil {
+		return af > bf
+	}
+	return strings.Compare(a, b) > 0
+}
+
+func watermarkString(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	case json.Number:
+		return t.String()
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+func (r *restPollInput) ReadBatch(ctx context.Context) (service.MessageBatch, service.AckFunc, error) {
+	for {
+		if r.next == nil {
+			if !r.lastPoll.IsZero() {
+				select {
+				case <-time.After(time.Until(r.lastPoll.Add(r.interval))):
+				case <-ctx.Done():
+					return nil, nil, ctx.Err()
+				}
+			}
+			first, err := r.firstPage()
+			if err != nil {
+				return nil, nil, err
+			}
+			r.next = first
+			r.lastPoll = time.Now()
+		}
+
+		if err := r.waitForRateLimit(ctx); err != nil {
+			return nil, nil, err
+		}
+		items, next, err := r.fetch(ctx, r.next)
+		if err != nil {
+			return nil, nil, err
+		}
+		r.next = next
+		if len(items) == 0 {
+			continue
+		}
+
+		batch := make(service.MessageBatch, 0, len(items))
+		for _, item := range items {
+			if r.wmPath != "" {
+				if wm := watermarkString(gabs.Wrap(item).Path(r.wmPath).Data()); watermarkGreater(wm, r.watermark) {
+					r.watermark = wm
+				}
+			}
+			msg := service.NewMessage(nil)
+			msg.SetStructured(item)
+			batch = append(batch, msg)
+		}
+
+		var commit func(context.Context) error
+		if r.committer != nil && r.watermark != "" {
+			commit = r.committer.Track(r.watermark)
+		}
+		return batch, func(ctx context.Context, err error) error {
+			if err != nil || commit == nil {
+				return nil
+			}
+			return commit(ctx)
+		}, nil
+	}
+}
+
+func (r *restPollInput) Close(ctx context.Context) error {
+	if r.store != nil {
+		return r.store.Close(ctx)
+	}
+	return nil
+}
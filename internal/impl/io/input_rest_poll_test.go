@@ -0,0 +1,296 @@
+package io
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+// restPollTestServer serves a sorted list of items with an updated_at field,
+// filtered by the query parameter since and paginated according to the query.
+type restPollTestServer struct {
+	mut     sync.Mutex
+	queries []url.Values
+	total   int
+	handler func(w http.ResponseWriter, r *http.Request, items []string)
+}
+
+func (s *restPollTestServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mut.Lock()
+	s.queries = append(s.queries, r.URL.Query())
+	s.mut.Unlock()
+
+	since, _ := strconv.Atoi(r.URL.Query().Get("since"))
+	var items []string
+	for n := since + 1; n <= s.total; n++ {
+		items = append(items, fmt.Sprintf(`{"id":%v,"updated_at":%v}`, n, n))
+	}
+	s.handler(w, r, items)
+}
+
+func (s *restPollTestServer) query(i int) url.Values {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	return s.queries[i]
+}
+
+func writeItems(w http.ResponseWriter, items []string, extra string) {
+	body := "["
+	for i, item := range items {
+		if i > 0 {
+			body += ","
+		}
+		body += item
+	}
+	body += "]"
+	if extra != "" {
+		body = `{"data":` + body + `,` + extra + `}`
+	}
+	_, _ = w.Write([]byte(body))
+}
+
+func pageOf(items []string, offset, size int) []string {
+	if offset >= len(items) {
+		return nil
+	}
+	end := offset + size
+	if end > len(items) {
+		end = len(items)
+	}
+	return items[offset:end]
+}
+
+func newRestPollTestInput(t *testing.T, res *service.Resources, conf string) *restPollInput {
+	t.Helper()
+
+	parsed, err := restPollInputConfig().ParseYAML(conf, nil)
+	require.NoError(t, err)
+
+	i, err := newRestPollInputFromConfig(parsed, res)
+	require.NoError(t, err)
+	require.NoError(t, i.Connect(context.Background()))
+	return i
+}
+
+func readRestPollIDs(t *testing.T, i *restPollInput) ([]string, service.AckFunc) {
+	t.Helper()
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*5)
+	defer done()
+
+	batch, ackFn, err := i.ReadBatch(ctx)
+	require.NoError(t, err)
+
+	var ids []string
+	for _, msg := range batch {
+		v, err := msg.AsStructured()
+		require.NoError(t, err)
+		ids = append(ids, fmt.Sprintf("%v", v.(map[string]interface{})["id"]))
+	}
+	return ids, ackFn
+}
+
+func TestRestPollPaginationStrategies(t *testing.T) {
+	tests := []struct {
+		name       string
+		pagination string
+		handler    func(w http.ResponseWriter, r *http.Request, items []string)
+		itemsPath  string
+	}{
+		{
+			name: "page",
+			pagination: `
+  strategy: page
+  page_size: 2
+  page_size_param: limit
+`,
+			handler: func(w http.ResponseWriter, r *http.Request, items []string) {
+				page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+				limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+				writeItems(w, pageOf(items, (page-1)*limit, limit), "")
+			},
+		},
+		{
+			name: "offset",
+			pagination: `
+  strategy: offset
+  page_size: 2
+`,
+			handler: func(w http.ResponseWriter, r *http.Request, items []string) {
+				offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+				writeItems(w, pageOf(items, offset, 2), "")
+			},
+		},
+		{
+			name: "cursor",
+			pagination: `
+  strategy: cursor
+  cursor_path: meta.next
+  cursor_param: after
+`,
+			itemsPath: "data",
+			handler: func(w http.ResponseWriter, r *http.Request, items []string) {
+				offset, _ := strconv.Atoi(r.URL.Query().Get("after"))
+				next := `null`
+				if offset+2 < len(items) {
+					next = strconv.Itoa(offset + 2)
+				}
+				writeItems(w, pageOf(items, offset, 2), `"meta":{"next":`+next+`}`)
+			},
+		},
+		{
+			name: "link header",
+			pagination: `
+  strategy: link_header
+`,
+			handler: func(w http.ResponseWriter, r *http.Request, items []string) {
+				offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+				if offset+2 < len(items) {
+					q := r.URL.Query()
+					q.Set("offset", strconv.Itoa(offset+2))
+					w.Header().Set("Link", `<https://example.com/prev>; rel="prev", <`+r.URL.Path+"?"+q.Encode()+`>; rel="next"`)
+				}
+				writeItems(w, pageOf(items, offset, 2), "")
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			srv := &restPollTestServer{total: 5, handler: test.handler}
+			ts := httptest.NewServer(srv)
+			defer ts.Close()
+
+			i := newRestPollTestInput(t, service.MockResources(), `
+url: `+ts.URL+`/items?sort=asc
+items_path: "`+test.itemsPath+`"
+pagination:`+test.pagination+`
+interval: 1h
+`)
+
+			var ids []string
+			for len(ids) < 5 {
+				page, _ := readRestPollIDs(t, i)
+				ids = append(ids, page...)
+			}
+			assert.Equal(t, []string{"1", "2", "3", "4", "5"}, ids)
+			assert.Nil(t, i.next)
+			assert.Equal(t, "asc", srv.query(0).Get("sort"))
+		})
+	}
+}
+
+func TestRestPollWatermark(t *testing.T) {
+	srv := &restPollTestServer{total: 3, handler: func(w http.ResponseWriter, r *http.Request, items []string) {
+		writeItems(w, items, "")
+	}}
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	var accessed int
+	res := service.MockResources(
+		service.MockResourcesOptAddCache("checkpoints"),
+		service.MockResourcesOptAddRateLimit("limiter", func(context.Context) (time.Duration, error) {
+			accessed++
+			return 0, nil
+		}),
+	)
+
+	conf := `
+url: ` + ts.URL + `
+watermark:
+  path: updated_at
+  param: since
+  checkpoint:
+    cache: checkpoints
+rate_limit: limiter
+interval: 10ms
+`
+	i := newRestPollTestInput(t, res, conf)
+
+	ids, ackFn := readRestPollIDs(t, i)
+	assert.Equal(t, []string{"1", "2", "3"}, ids)
+	assert.Equal(t, "", srv.query(0).Get("since"))
+
+	srv.mut.Lock()
+	srv.total = 5
+	srv.mut.Unlock()
+
+	ids, _ = readRestPollIDs(t, i)
+	assert.Equal(t, []string{"4", "5"}, ids)
+	assert.Equal(t, "3", srv.query(1).Get("since"))
+	assert.Equal(t, 2, accessed)
+
+	// Only the watermark of acknowledged messages is committed.
+	require.NoError(t, ackFn(context.Background(), nil))
+	require.NoError(t, i.Close(context.Background()))
+
+	i = newRestPollTestInput(t, res, conf)
+	ids, _ = readRestPollIDs(t, i)
+	assert.Equal(t, []string{"4", "5"}, ids)
+	assert.Equal(t, "3", srv.query(2).Get("since"))
+}
+
+func TestRestPollErrors(t *testing.T) {
+	srv := &restPollTestServer{handler: func(w http.ResponseWriter, r *http.Request, items []string) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}}
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	i := newRestPollTestInput(t, service.MockResources(), `url: `+ts.URL)
+	_, _, err := i.ReadBatch(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed with status 503")
+
+	for _, conf := range []string{
+		`
+url: http://localhost
+pagination:
+  strategy: cursor
+`,
+		`
+url: http://localhost
+rate_limit: nope
+`,
+		`
+url: http://localhost
+watermark:
+  checkpoint:
+    cache: foo
+`,
+	} {
+		parsed, err := restPollInputConfig().ParseYAML(conf, nil)
+		require.NoError(t, err, conf)
+		_, err = newRestPollInputFromConfig(parsed, service.MockResources())
+		assert.Error(t, err, conf)
+	}
+
+}
+
+func TestRestPollNextLink(t *testing.T) {
+	base, err := url.Parse("https://api.example.com/v1/items?page=1")
+	require.NoError(t, err)
+
+	for header, expected := range map[string]string{
+		`<https://api.example.com/v1/items?page=2>; rel="next"`:                         "https://api.example.com/v1/items?page=2",
+		`</v1/items?page=1>; rel="prev first", </v1/items?page=3>; rel="next"`:          "https://api.example.com/v1/items?page=3",
+		`<https://api.example.com/v1/items?page=9>; rel=last`:                           "",
+		`<https://api.example.com/v1/items?page=2>; title="next page"; rel="next last"`: "https://api.example.com/v1/items?page=2",
+		``: "",
+	} {
+		assert.Equal(t, expected, nextLink(base, header), header)
+	}
+}
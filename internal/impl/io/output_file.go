@@ -1,13 +1,19 @@
 package io
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"sync"
 	"time"
 
+	"github.com/gofrs/uuid"
+
 	"github.com/benthosdev/benthos/v4/internal/bloblang/field"
 	"github.com/benthosdev/benthos/v4/internal/bundle"
 	"github.com/benthosdev/benthos/v4/internal/codec"
@@ -22,7 +28,7 @@ import (
 
 func init() {
 	err := bundle.AllOutputs.Add(processors.WrapConstructor(func(conf output.Config, nm bundle.NewManagement) (output.Streamed, error) {
-		f, err := newFileWriter(conf.File.Path, conf.File.Codec, nm)
+		f, err := newFileWriter(conf.File, nm)
 		if err != nil {
 			return nil, err
 		}
@@ -38,7 +44,21 @@ func init() {
 		Name: "file",
 		Summary: `
 Writes messages to files on disk based on a chosen codec.`,
-		Description: `Messages can be written to different files by using [interpolation functions](/docs/configuration/interpolation#bloblang-queries) in the path field. However, only one file is ever open at a given time, and therefore when the path changes the previously open file is closed.`,
+		Description: `Messages can be written to different files by using [interpolation functions](/docs/configuration/interpolation#bloblang-queries) in the path field. However, only one file is ever open at a given time, and therefore when the path changes the previously open file is closed.
+
+### Atomic Writes
+
+By default messages are written directly to the file at their path, and therefore a consumer reading the file, or the file left behind after a crash, may contain a partially written message. When ` + "`atomic`" + ` is set to ` + "`true`" + ` the messages of each batch are instead written to a temporary file alongside their path, named ` + "`.<file name>.<random>.tmp`" + `, which is synced to disk and renamed over the path once all of its messages have been written. Renaming a file is atomic on POSIX filesystems, and therefore the file at a given path is always either its previous contents or its contents with the whole batch written. Messages are only acknowledged once their files have been renamed.
+
+When the codec appends to files, such as ` + "`lines`" + `, the existing contents of a file are copied into each temporary file before the batch is written, which makes atomic writes expensive for large files that are written to many times. It is therefore recommended to combine atomic writes with a path that changes regularly, or with a codec that replaces files such as ` + "`all-bytes`" + `. Temporary files are removed when a write fails, but may be left behind in the event of a crash and can be safely deleted.
+
+When a ` + "`manifest_path`" + ` is set, which requires ` + "`atomic`" + ` writes, a line is appended to the manifest file for each file that has been renamed into place, after all files of the batch have been renamed and before the batch is acknowledged. Each line is a JSON object of the form:
+
+` + "```json" + `
+{"path":"/tmp/data.txt","size":1024,"count":10,"finalised_at":"2022-03-02T10:01:00Z"}
+` + "```" + `
+
+Where ` + "`size`" + ` is the size of the file in bytes and ` + "`count`" + ` is the number of messages written to it. Downstream consumers can follow the manifest in order to only process files that have been finalised. If a batch is retried after its files were renamed but before its manifest lines were written then its messages may be written again, and therefore consumers should still tolerate duplicate messages.`,
 		Config: docs.FieldComponent().WithChildren(
 			docs.FieldString(
 				"path", "The file to write to, if the file does not yet exist it will be created.",
@@ -47,6 +67,11 @@ Writes messages to files on disk based on a chosen codec.`,
 				`/tmp/${! json("document.id") }.json`,
 			).IsInterpolated().AtVersion("3.33.0"),
 			codec.WriterDocs.AtVersion("3.33.0"),
+			docs.FieldBool("atomic", "Whether to write the messages of each batch to a temporary file that is renamed to the path once fully written, ensuring that partially written files are never visible at the path.").Advanced(),
+			docs.FieldString(
+				"manifest_path", "An optional path of a manifest file, to which a line is appended for each file that is finalised by an atomic write. Requires `atomic` to be enabled.",
+				"/tmp/manifest.jsonl",
+			).Advanced(),
 		).ChildDefaultAndTypesFromStruct(output.NewFileConfig()),
 		Categories: []string{
 			"Local",
@@ -66,6 +91,9 @@ type fileWriter struct {
 	codec     codec.WriterConstructor
 	codecConf codec.WriterConfig
 
+	atomic       bool
+	manifestPath string
+
 	handleMut  sync.Mutex
 	handlePath string
 	handle     codec.Writer
@@ -73,21 +101,26 @@ type fileWriter struct {
 	shutSig *shutdown.Signaller
 }
 
-func newFileWriter(pathStr, codecStr string, mgr bundle.NewManagement) (*fileWriter, error) {
-	codec, codecConf, err := codec.GetWriter(codecStr)
+func newFileWriter(conf output.FileConfig, mgr bundle.NewManagement) (*fileWriter, error) {
+	codec, codecConf, err := codec.GetWriter(conf.Codec)
 	if err != nil {
 		return nil, err
 	}
-	path, err := mgr.BloblEnvironment().NewField(pathStr)
+	path, err := mgr.BloblEnvironment().NewField(conf.Path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse path expression: %w", err)
 	}
+	if conf.ManifestPath != "" && !conf.Atomic {
+		return nil, errors.New("a manifest_path can only be used with atomic writes")
+	}
 	return &fileWriter{
-		codec:     codec,
-		codecConf: codecConf,
-		path:      path,
-		log:       mgr.Logger(),
-		shutSig:   shutdown.NewSignaller(),
+		codec:        codec,
+		codecConf:    codecConf,
+		path:         path,
+		atomic:       conf.Atomic,
+		manifestPath: conf.ManifestPath,
+		log:          mgr.Logger(),
+		shutSig:      shutdown.NewSignaller(),
 	}, nil
 }
 
@@ -98,6 +131,12 @@ func (w *fileWriter) ConnectWithContext(ctx context.Context) error {
 }
 
 func (w *fileWriter) WriteWithContext(ctx context.Context, msg *message.Batch) error {
+	if w.atomic {
+		w.handleMut.Lock()
+		defer w.handleMut.Unlock()
+		return w.writeAtomic(ctx, msg)
+	}
+
 	err := output.IterateBatchedSend(msg, func(i int, p *message.Part) error {
 		path := filepath.Clean(w.path.String(i, msg))
 
@@ -154,6 +193,169 @@ func (w *fileWriter) WriteWithContext(ctx context.Context, msg *message.Batch) e
 	return nil
 }
 
+//------------------------------------------------------------------------------
+
+type fileManifestEntry struct {
+	Path        string `json:"path"`
+	Size        int64  `json:"size"`
+	Count       int    `json:"count"`
+	FinalisedAt string `json:"finalised_at"`
+}
+
+// syncedFile is a file that is synced to disk before being closed.
+type syncedFile struct {
+	*os.File
+}
+
+func (s syncedFile) Close() error {
+	if err := s.File.Sync(); err != nil {
+		_ = s.File.Close()
+		return err
+	}
+	return s.File.Close()
+}
+
+// writeAtomic writes the messages of a batch grouped by their paths, where
+// each file is written to a temporary file and renamed to its path once
+// complete, followed by appending the finalised files to the manifest.
+func (w *fileWriter) writeAtomic(ctx context.Context, msg *message.Batch) error {
+	var paths []string
+	pathIndexes := map[string][]int{}
+	_ = msg.Iter(func(i int, p *message.Part) error {
+		path := filepath.Clean(w.path.String(i, msg))
+		if _, exists := pathIndexes[path]; !exists {
+			paths = append(paths, path)
+		}
+		pathIndexes[path] = append(pathIndexes[path], i)
+		return nil
+	})
+
+	entries := make([]fileManifestEntry, 0, len(paths))
+	for _, path := range paths {
+		size, err := w.writeFileAtomic(ctx, path, pathIndexes[path], msg)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, fileManifestEntry{
+			Path:        path,
+			Size:        size,
+			Count:       len(pathIndexes[path]),
+			FinalisedAt: time.Now().UTC().Format(time.RFC3339Nano),
+		})
+	}
+
+	if w.manifestPath == "" {
+		return nil
+	}
+	return w.appendManifest(entries)
+}
+
+func (w *fileWriter) writeFileAtomic(ctx context.Context, path string, indexes []int, msg *message.Batch) (size int64, err error) {
+	dir := filepath.Dir(path)
+	if err = os.MkdirAll(dir, os.FileMode(0o777)); err != nil {
+		return
+	}
+
+	tmpPath := filepath.Join(dir, fmt.Sprintf(".%v.%v.tmp", filepath.Base(path), uuid.Must(uuid.NewV4()).String()))
+	file, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, os.FileMode(0o666))
+	if err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			_ = os.Remove(tmpPath)
+		}
+	}()
+
+	// Codecs that append to files need the existing contents of the file to
+	// be carried over, otherwise the rename would discard them.
+	if w.codecConf.Append && !w.codecConf.Truncate {
+		if err = copyFileContents(file, path); err != nil {
+			_ = file.Close()
+			return
+		}
+	}
+
+	handle, err := w.codec(syncedFile{File: file})
+	if err != nil {
+		_ = file.Close()
+		return
+	}
+	for _, i := range indexes {
+		if err = handle.Write(ctx, msg.Get(i)); err != nil {
+			_ = handle.Close(ctx)
+			return
+		}
+	}
+	if err = handle.Close(ctx); err != nil {
+		return
+	}
+
+	info, err := os.Stat(tmpPath)
+	if err != nil {
+		return
+	}
+	if err = os.Rename(tmpPath, path); err != nil {
+		return
+	}
+	if dirErr := syncDir(dir); dirErr != nil {
+		w.log.Warnf("Failed to sync directory '%v' after rename: %v\n", dir, dirErr)
+	}
+	return info.Size(), nil
+}
+
+func (w *fileWriter) appendManifest(entries []fileManifestEntry) error {
+	var buf bytes.Buffer
+	for _, e := range entries {
+		entryBytes, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		buf.Write(entryBytes)
+		buf.WriteByte('\n')
+	}
+
+	if err := os.MkdirAll(filepath.Dir(w.manifestPath), os.FileMode(0o777)); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(w.manifestPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, os.FileMode(0o666))
+	if err != nil {
+		return err
+	}
+
+	// Entries are written with a single write call so that a reader never
+	// observes the entries of a batch partially.
+	manifest := syncedFile{File: file}
+	if _, err = manifest.Write(buf.Bytes()); err != nil {
+		_ = manifest.File.Close()
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	return manifest.Close()
+}
+
+func copyFileContents(dst io.Writer, path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer src.Close()
+
+	_, err = io.Copy(dst, src)
+	return err
+}
+
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
+
 func (w *fileWriter) CloseAsync() {
 	go func() {
 		w.handleMut.Lock()
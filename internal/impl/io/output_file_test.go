@@ -0,0 +1,108 @@
+package io_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/component/output"
+	"github.com/benthosdev/benthos/v4/internal/manager/mock"
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+func writeFileTestBatch(t *testing.T, tChan chan<- message.Transaction, contents ...string) error {
+	t.Helper()
+
+	msg := message.QuickBatch(nil)
+	for _, c := range contents {
+		msg.Append(message.NewPart([]byte(c)))
+	}
+
+	resChan := make(chan error)
+	select {
+	case tChan <- message.NewTransaction(msg, resChan):
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out")
+	}
+	select {
+	case err := <-resChan:
+		return err
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out")
+	}
+	return nil
+}
+
+func TestFileOutputAtomic(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	conf := output.NewConfig()
+	conf.Type = "file"
+	conf.File.Path = filepath.Join(tmpDir, `${! content().string().split(":").index(0) }.txt`)
+	conf.File.Atomic = true
+	conf.File.ManifestPath = filepath.Join(tmpDir, "manifest.jsonl")
+
+	o, err := mock.NewManager().NewOutput(conf)
+	require.NoError(t, err)
+
+	tChan := make(chan message.Transaction)
+	require.NoError(t, o.Consume(tChan))
+
+	require.NoError(t, writeFileTestBatch(t, tChan, "a:1", "b:1", "a:2"))
+	require.NoError(t, writeFileTestBatch(t, tChan, "a:3"))
+
+	o.CloseAsync()
+	require.NoError(t, o.WaitForClose(time.Second*5))
+
+	aBytes, err := os.ReadFile(filepath.Join(tmpDir, "a.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "a:1\na:2\na:3\n", string(aBytes))
+
+	bBytes, err := os.ReadFile(filepath.Join(tmpDir, "b.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "b:1\n", string(bBytes))
+
+	entries, err := os.ReadDir(tmpDir)
+	require.NoError(t, err)
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	assert.ElementsMatch(t, []string{"a.txt", "b.txt", "manifest.jsonl"}, names)
+
+	manifestBytes, err := os.ReadFile(conf.File.ManifestPath)
+	require.NoError(t, err)
+
+	type manifestEntry struct {
+		Path  string `json:"path"`
+		Size  int64  `json:"size"`
+		Count int    `json:"count"`
+	}
+	var manifest []manifestEntry
+	for _, line := range strings.Split(strings.TrimSpace(string(manifestBytes)), "\n") {
+		var e manifestEntry
+		require.NoError(t, json.Unmarshal([]byte(line), &e))
+		manifest = append(manifest, e)
+	}
+	assert.Equal(t, []manifestEntry{
+		{Path: filepath.Join(tmpDir, "a.txt"), Size: 8, Count: 2},
+		{Path: filepath.Join(tmpDir, "b.txt"), Size: 4, Count: 1},
+		{Path: filepath.Join(tmpDir, "a.txt"), Size: 12, Count: 1},
+	}, manifest)
+}
+
+func TestFileOutputManifestRequiresAtomic(t *testing.T) {
+	conf := output.NewConfig()
+	conf.Type = "file"
+	conf.File.Path = "/tmp/foo.txt"
+	conf.File.ManifestPath = "/tmp/manifest.jsonl"
+
+	_, err := mock.NewManager().NewOutput(conf)
+	assert.Error(t, err)
+}
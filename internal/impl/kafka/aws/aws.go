@@ -0,0 +1,107 @@
+package aws
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	v4 "github.com/aws/aws-sdk-go/aws/signer/v4"
+	"github.com/twmb/franz-go/pkg/sasl"
+	awssasl "github.com/twmb/franz-go/pkg/sasl/aws"
+
+	baws "github.com/benthosdev/benthos/v4/internal/impl/aws"
+	sess "github.com/benthosdev/benthos/v4/internal/impl/aws/session"
+	"github.com/benthosdev/benthos/v4/internal/impl/kafka"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func init() {
+	kafka.AWSSASLFromConfigFn = func(c *service.ParsedConfig) (sasl.Mechanism, error) {
+		awsSess, err := baws.GetSession(c.Namespace("aws"))
+		if err != nil {
+			return nil, err
+		}
+		creds := awsSess.Config.Credentials
+		return awssasl.ManagedStreamingIAM(func(ctx context.Context) (awssasl.Auth, error) {
+			v, err := creds.GetWithContext(ctx)
+			if err != nil {
+				return awssasl.Auth{}, err
+			}
+			return awssasl.Auth{
+				AccessKey:    v.AccessKeyID,
+				SecretKey:    v.SecretAccessKey,
+				SessionToken: v.SessionToken,
+			}, nil
+		}), nil
+	}
+
+	kafka.AWSTokenProviderFn = func(c sess.Config) (sarama.AccessTokenProvider, error) {
+		awsSess, err := baws.GetSessionFromConf(c)
+		if err != nil {
+			return nil, err
+		}
+		region := aws.StringValue(awsSess.Config.Region)
+		if region == "" {
+			return nil, errors.New("a region must be specified for AWS_MSK_IAM authentication")
+		}
+		return newIAMTokenProvider(awsSess.Config.Credentials, region), nil
+	}
+}
+
+//------------------------------------------------------------------------------
+
+const (
+	iamTokenService   = "kafka-cluster"
+	iamTokenAction    = "kafka-cluster:Connect"
+	iamTokenExpiry    = time.Minute * 15
+	iamTokenUserAgent = "benthos"
+)
+
+// iamTokenProvider provides OAUTHBEARER access tokens for the IAM access
+// control of Amazon MSK, which are presigned URLs of a connect action.
+type iamTokenProvider struct {
+	signer *v4.Signer
+	region string
+	nowFn  func() time.Time
+}
+
+func newIAMTokenProvider(creds *credentials.Credentials, region string) *iamTokenProvider {
+	return &iamTokenProvider{
+		signer: v4.NewSigner(creds),
+		region: region,
+		nowFn:  time.Now,
+	}
+}
+
+func (p *iamTokenProvider) Token() (*sarama.AccessToken, error) {
+	u := url.URL{
+		Scheme:   "https",
+		Host:     fmt.Sprintf("kafka.%v.amazonaws.com", p.region),
+		Path:     "/",
+		RawQuery: url.Values{"Action": []string{iamTokenAction}}.Encode(),
+	}
+	req, err := http.NewRequest("GET", u.String(), http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.signer.Presign(req, nil, iamTokenService, p.region, iamTokenExpiry, p.nowFn()); err != nil {
+		return nil, fmt.Errorf("failed to sign AWS_MSK_IAM token: %w", err)
+	}
+
+	// The user agent is added after signing as it is not part of the
+	// signature.
+	query := req.URL.Query()
+	query.Set("User-Agent", iamTokenUserAgent)
+	req.URL.RawQuery = query.Encode()
+
+	return &sarama.AccessToken{
+		Token: base64.RawURLEncoding.EncodeToString([]byte(req.URL.String())),
+	}, nil
+}
@@ -0,0 +1,41 @@
+package aws
+
+import (
+	"encoding/base64"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIAMTokenProvider(t *testing.T) {
+	p := newIAMTokenProvider(credentials.NewStaticCredentials("foo", "bar", "baz"), "eu-west-1")
+	p.nowFn = func() time.Time {
+		return time.Date(2022, 1, 2, 15, 4, 5, 0, time.UTC)
+	}
+
+	tok, err := p.Token()
+	require.NoError(t, err)
+
+	rawURL, err := base64.RawURLEncoding.DecodeString(tok.Token)
+	require.NoError(t, err)
+
+	u, err := url.Parse(string(rawURL))
+	require.NoError(t, err)
+	assert.Equal(t, "https", u.Scheme)
+	assert.Equal(t, "kafka.eu-west-1.amazonaws.com", u.Host)
+
+	query := u.Query()
+	assert.Equal(t, "kafka-cluster:Connect", query.Get("Action"))
+	assert.Equal(t, "AWS4-HMAC-SHA256", query.Get("X-Amz-Algorithm"))
+	assert.Equal(t, "foo/20220102/eu-west-1/kafka-cluster/aws4_request", query.Get("X-Amz-Credential"))
+	assert.Equal(t, "20220102T150405Z", query.Get("X-Amz-Date"))
+	assert.Equal(t, "900", query.Get("X-Amz-Expires"))
+	assert.Equal(t, "baz", query.Get("X-Amz-Security-Token"))
+	assert.Equal(t, "host", query.Get("X-Amz-SignedHeaders"))
+	assert.NotEmpty(t, query.Get("X-Amz-Signature"))
+	assert.Equal(t, "benthos", query.Get("User-Agent"))
+}
@@ -46,6 +46,12 @@ This input adds the following metadata fields to each message:
 - kafka_timestamp_unix
 - All record headers
 ` + "```" + `
+
+### Exactly-Once Delivery
+
+When consuming topics that are written to transactionally the field ` + "`isolation_level`" + ` can be set to ` + "`read_committed`" + ` in order to only consume records of committed transactions.
+
+In combination with a ` + "[`kafka_franz` output](/docs/components/outputs/kafka_franz)" + ` configured with a ` + "`transactional_id` and `consumer_group`" + ` this input can be configured with ` + "`commit_offsets: false`" + `, in which case the offsets of consumed messages are committed within the transactions of the output rather than by this input, resulting in exactly-once delivery between Kafka topics.
 `).
 		Field(service.NewStringListField("seed_brokers").
 			Description("A list of broker addresses to connect to in order to establish connections. If an item of the list contains commas it will be expanded into multiple addresses.").
@@ -63,6 +69,16 @@ This input adds the following metadata fields to each message:
 			Description("Determines how many messages of the same partition can be processed in parallel before applying back pressure. When a message of a given offset is delivered to the output the offset is only allowed to be committed when all messages of prior offsets have also been delivered, this ensures at-least-once delivery guarantees. However, this mechanism also increases the likelihood of duplicates in the event of crashes or server faults, reducing the checkpoint limit will mitigate this.").
 			Default(1024).
 			Advanced()).
+		Field(service.NewStringEnumField("isolation_level", "read_uncommitted", "read_committed").
+			Description("Determines which records of transactions are consumed. With `read_committed` only records of committed transactions are consumed, and records of aborted transactions are skipped.").
+			Version("4.3.0").
+			Default("read_uncommitted").
+			Advanced()).
+		Field(service.NewBoolField("commit_offsets").
+			Description("Whether the offsets of delivered messages are committed by this input. Set this to `false` when offsets are instead committed within the transactions of a `kafka_franz` output.").
+			Version("4.3.0").
+			Default(true).
+			Advanced()).
 		Field(service.NewTLSToggledField("tls")).
 		Field(saslField)
 }
@@ -97,6 +113,8 @@ type franzKafkaReader struct {
 	saslConfs       []sasl.Mechanism
 	checkpointLimit int
 	regexPattern    bool
+	readCommitted   bool
+	commitOffsets   bool
 
 	msgChan atomic.Value
 	log     *service.Logger
@@ -146,6 +164,16 @@ func newFranzKafkaReaderFromConfig(conf *service.ParsedConfig, log *service.Logg
 		return nil, err
 	}
 
+	isolationLevel, err := conf.FieldString("isolation_level")
+	if err != nil {
+		return nil, err
+	}
+	f.readCommitted = isolationLevel == "read_committed"
+
+	if f.commitOffsets, err = conf.FieldBool("commit_offsets"); err != nil {
+		return nil, err
+	}
+
 	tlsConf, tlsEnabled, err := conf.FieldTLSToggled("tls")
 	if err != nil {
 		return nil, err
@@ -269,6 +297,11 @@ func (f *franzKafkaReader) Connect(ctx context.Context) error {
 		kgo.ConsumeTopics(f.topics...),
 		kgo.SASL(f.saslConfs...),
 		kgo.OnPartitionsRevoked(func(rctx context.Context, c *kgo.Client, m map[string][]int32) {
+			if !f.commitOffsets {
+				checkpoints.removeTopicPartitions(m)
+				return
+			}
+
 			// Note: this is a best attempt, there's a chance of duplicates if
 			// the checkpoint limit is borked with slow moving pending messages,
 			// but we can't block here, so work with that we have.
@@ -298,10 +331,22 @@ func (f *franzKafkaReader) Connect(ctx context.Context) error {
 			// No point trying to commit our offsets, just clean up our topic map
 			checkpoints.removeTopicPartitions(m)
 		}),
-		kgo.AutoCommitMarks(),
 		kgo.WithLogger(&kgoLogger{f.log}),
 	}
 
+	if f.commitOffsets {
+		clientOpts = append(clientOpts, kgo.AutoCommitMarks())
+	} else {
+		clientOpts = append(clientOpts, kgo.DisableAutoCommit())
+	}
+
+	if f.readCommitted {
+		clientOpts = append(clientOpts,
+			kgo.FetchIsolationLevel(kgo.ReadCommitted()),
+			kgo.RequireStableFetchOffsets(),
+		)
+	}
+
 	if f.tlsConf != nil {
 		clientOpts = append(clientOpts, kgo.DialTLSConfig(f.tlsConf))
 	}
@@ -387,7 +432,7 @@ func (f *franzKafkaReader) Connect(ctx context.Context) error {
 				case msgChan <- msgWithAckFn{
 					msg: msg,
 					onAck: func() {
-						if maxRec := releaseFn(); maxRec != nil {
+						if maxRec := releaseFn(); maxRec != nil && f.commitOffsets {
 							cl.MarkCommitRecords(maxRec)
 						}
 					},
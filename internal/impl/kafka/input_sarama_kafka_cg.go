@@ -77,7 +77,12 @@ func (k *kafkaReader) ConsumeClaim(sess sarama.ConsumerGroupSession, claim saram
 
 			if batchPolicy.Add(part) {
 				nextTimedBatchChan = nil
-				if !flushBatch(sess.Context(), k.msgChan, batchPolicy.Flush(), latestOffset+1) {
+				flushedMsg, nextOffset := batchPolicy.Flush(), latestOffset+1
+				if batchPolicy.Count() > 0 {
+					// The latest message was held back for the next batch.
+					nextOffset = latestOffset
+				}
+				if !flushBatch(sess.Context(), k.msgChan, flushedMsg, nextOffset) {
 					return nil
 				}
 			}
@@ -80,7 +80,12 @@ partMsgLoop:
 
 			if batchPolicy.Add(part) {
 				nextTimedBatchChan = nil
-				if !flushBatch(ctx, k.msgChan, batchPolicy.Flush(), latestOffset+1) {
+				flushedMsg, nextOffset := batchPolicy.Flush(), latestOffset+1
+				if batchPolicy.Count() > 0 {
+					// The latest message was held back for the next batch.
+					nextOffset = latestOffset
+				}
+				if !flushBatch(ctx, k.msgChan, flushedMsg, nextOffset) {
 					break partMsgLoop
 				}
 			}
@@ -3,12 +3,19 @@ package kafka
 import (
 	"context"
 	"crypto/tls"
+	"errors"
 	"fmt"
+	"hash/fnv"
 	"math"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/dustin/go-humanize"
+	"github.com/twmb/franz-go/pkg/kerr"
 	"github.com/twmb/franz-go/pkg/kgo"
+	"github.com/twmb/franz-go/pkg/kmsg"
 	"github.com/twmb/franz-go/pkg/sasl"
 
 	"github.com/benthosdev/benthos/v4/internal/shutdown"
@@ -24,11 +31,19 @@ func franzKafkaOutputConfig() *service.ConfigSpec {
 		Description(`
 Writes a batch of messages to Kafka brokers and waits for acknowledgement before propagating it back to the input.
 
-This output is new and experimental, and the existing ` + "`kafka`" + ` input is not going anywhere, but here's some reasons why it might be worth trying this one out:
+This output is new and experimental, and the existing `+"`kafka`"+` input is not going anywhere, but here's some reasons why it might be worth trying this one out:
 
 - You like shiny new stuff
-- You are experiencing issues with the existing ` + "`kafka`" + ` output
+- You are experiencing issues with the existing `+"`kafka`"+` output
 - Someone told you to
+
+### Transactions
+
+When a `+"`transactional_id`"+` is set each batch is written within a Kafka transaction, and consumers of the topic with the isolation level `+"`read_committed`"+` only observe batches that have been written in full.
+
+By also setting `+"`consumer_group`"+` the offsets of messages consumed with a `+"[`kafka_franz` input](/docs/components/inputs/kafka_franz)"+` of that group are committed within the same transaction, using the metadata fields `+"`kafka_topic`, `kafka_partition` and `kafka_offset`"+`, which results in exactly-once delivery from the input topics to the output topic. In this case the input should be configured with `+"`commit_offsets: false`"+` and `+"`isolation_level: read_committed`"+`, and as offsets are committed in the order that batches are written `+"`max_in_flight`"+` must be `+"`1`"+` and the pipeline must not reorder messages, i.e. `+"`pipeline.threads`"+` should be `+"`1`"+`.
+
+Offsets committed by transactions are not fenced by the generation of the consumer group, therefore messages that are in flight when their partitions are rebalanced to another consumer can still be delivered more than once.
 `).
 		Field(service.NewStringListField("seed_brokers").
 			Description("A list of broker addresses to connect to in order to establish connections. If an item of the list contains commas it will be expanded into multiple addresses.").
@@ -40,11 +55,19 @@ This output is new and experimental, and the existing ` + "`kafka`" + ` input is
 		Field(service.NewInterpolatedStringField("key").
 			Description("An optional key to populate for each message.").Optional()).
 		Field(service.NewStringAnnotatedEnumField("partitioner", map[string]string{
+			"murmur2_hash": "Kafka's default hash algorithm that uses a 32-bit murmur2 hash of the key to compute which partition the record will be on, resulting in the same partitions as the default partitioner of the Java client. Records without a key are written to a partition that changes with each batch.",
+			"fnv1a_hash":   "Uses a 32-bit FNV-1a hash of the key to compute which partition the record will be on, resulting in the same partitions as the `fnv1a_hash` partitioner of the `kafka` output. Records without a key are written to a partition that changes with each batch.",
 			"round_robin":  "Round-robin's messages through all available partitions. This algorithm has lower throughput and causes higher CPU load on brokers, but can be useful if you want to ensure an even distribution of records to partitions.",
 			"least_backup": "Chooses the least backed up partition (the partition with the fewest amount of buffered records). Partitions are selected per batch.",
+			"manual":       "Manually select a partition for each message, requires the field `partition` to be specified.",
 		}).
 			Description("Override the default murmur2 hashing partitioner.").
 			Advanced().Optional()).
+		Field(service.NewInterpolatedStringField("partition").
+			Description("The manually-specified partition to publish messages to, relevant only when the field `partitioner` is set to `manual`. Must be able to parse as a 32-bit integer.").
+			Example(`${! meta("partition") }`).
+			Version("4.3.0").
+			Advanced().Optional()).
 		Field(service.NewMetadataFilterField("metadata").
 			Description("Determine which (if any) metadata values should be added to messages as headers.").
 			Optional()).
@@ -62,8 +85,43 @@ This output is new and experimental, and the existing ` + "`kafka`" + ` input is
 			Description("Optionally set an explicit compression type. The default preference is to use snappy when the broker supports it, and fall back to none if not.").
 			Optional().
 			Advanced()).
+		Field(service.NewStringField("transactional_id").
+			Description("An optional transactional ID, which when set results in each batch being written within a transaction. The ID must be unique to this output, but stable across restarts, so that transactions left open by prior instances are aborted.").
+			Version("4.3.0").
+			Optional()).
+		Field(service.NewStringField("consumer_group").
+			Description("The consumer group of a `kafka_franz` input whose offsets are committed within each transaction. Only applies when a `transactional_id` is set.").
+			Version("4.3.0").
+			Optional()).
+		Field(service.NewDurationField("transaction_timeout").
+			Description("The maximum period a transaction may remain open before it is aborted by the broker. Consumers with the isolation level `read_committed` may be blocked by open transactions for up to this period.").
+			Version("4.3.0").
+			Default("10s").
+			Advanced()).
 		Field(service.NewTLSToggledField("tls")).
-		Field(saslField)
+		Field(saslField).
+		Example("Exactly-Once Delivery", "Consume from a topic, transform messages and write them to another topic, where the offsets of the consumed messages are committed within the transaction of the written messages.", `
+input:
+  kafka_franz:
+    seed_brokers: [ localhost:9092 ]
+    topics: [ orders ]
+    consumer_group: order_enricher
+    commit_offsets: false
+    isolation_level: read_committed
+
+pipeline:
+  threads: 1
+  processors:
+    - bloblang: 'root = this.merge({"enriched": true})'
+
+output:
+  kafka_franz:
+    seed_brokers: [ localhost:9092 ]
+    topic: enriched_orders
+    transactional_id: order_enricher_0
+    consumer_group: order_enricher
+    max_in_flight: 1
+`)
 }
 
 func init() {
@@ -80,7 +138,15 @@ func init() {
 			if batchPolicy, err = conf.FieldBatchPolicy("batching"); err != nil {
 				return
 			}
-			output, err = newFranzKafkaWriterFromConfig(conf, mgr.Logger())
+			var w *franzKafkaWriter
+			if w, err = newFranzKafkaWriterFromConfig(conf, mgr.Logger()); err != nil {
+				return
+			}
+			if w.transactionalID != "" && maxInFlight != 1 {
+				err = errors.New("max_in_flight must be 1 when a transactional_id is set")
+				return
+			}
+			output = w
 			return
 		})
 
@@ -100,9 +166,15 @@ type franzKafkaWriter struct {
 	saslConfs        []sasl.Mechanism
 	metaFilter       *service.MetadataFilter
 	partitioner      kgo.Partitioner
+	partition        *service.InterpolatedString
 	produceMaxBytes  int32
 	compressionPrefs []kgo.CompressionCodec
 
+	transactionalID    string
+	txnConsumerGroup   string
+	transactionTimeout time.Duration
+	txnMut             sync.Mutex
+
 	client *kgo.Client
 
 	log     *service.Logger
@@ -178,6 +250,12 @@ func newFranzKafkaWriterFromConfig(conf *service.ParsedConfig, log *service.Logg
 			return nil, err
 		}
 		switch partStr {
+		case "murmur2_hash":
+			f.partitioner = kgo.StickyKeyPartitioner(nil)
+		case "fnv1a_hash":
+			f.partitioner = kgo.StickyKeyPartitioner(fnv1aSaramaHasher)
+		case "manual":
+			f.partitioner = kgo.ManualPartitioner()
 		case "round_robin":
 			f.partitioner = kgo.RoundRobinPartitioner()
 		case "least_backup":
@@ -185,6 +263,17 @@ func newFranzKafkaWriterFromConfig(conf *service.ParsedConfig, log *service.Logg
 		default:
 			return nil, fmt.Errorf("unknown partitioner: %v", partStr)
 		}
+		if partStr == "manual" && !conf.Contains("partition") {
+			return nil, errors.New("partition field required for 'manual' partitioner")
+		}
+	}
+	if conf.Contains("partition") {
+		if f.partition, err = conf.FieldInterpolatedString("partition"); err != nil {
+			return nil, err
+		}
+		if partStr, _ := conf.FieldString("partitioner"); partStr != "manual" {
+			return nil, errors.New("partition field can only be specified for 'manual' partitioner")
+		}
 	}
 
 	if conf.Contains("metadata") {
@@ -193,6 +282,20 @@ func newFranzKafkaWriterFromConfig(conf *service.ParsedConfig, log *service.Logg
 		}
 	}
 
+	if conf.Contains("transactional_id") {
+		if f.transactionalID, err = conf.FieldString("transactional_id"); err != nil {
+			return nil, err
+		}
+	}
+	if conf.Contains("consumer_group") {
+		if f.txnConsumerGroup, err = conf.FieldString("consumer_group"); err != nil {
+			return nil, err
+		}
+	}
+	if f.transactionTimeout, err = conf.FieldDuration("transaction_timeout"); err != nil {
+		return nil, err
+	}
+
 	tlsConf, tlsEnabled, err := conf.FieldTLSToggled("tls")
 	if err != nil {
 		return nil, err
@@ -230,6 +333,12 @@ func (f *franzKafkaWriter) Connect(ctx context.Context) error {
 	if len(f.compressionPrefs) > 0 {
 		clientOpts = append(clientOpts, kgo.ProducerBatchCompression(f.compressionPrefs...))
 	}
+	if f.transactionalID != "" {
+		clientOpts = append(clientOpts,
+			kgo.TransactionalID(f.transactionalID),
+			kgo.TransactionTimeout(f.transactionTimeout),
+		)
+	}
 
 	cl, err := kgo.NewClient(clientOpts...)
 	if err != nil {
@@ -255,6 +364,14 @@ func (f *franzKafkaWriter) WriteBatch(ctx context.Context, b service.MessageBatc
 		if f.key != nil {
 			record.Key = b.InterpolatedBytes(i, f.key)
 		}
+		if f.partition != nil {
+			partStr := b.InterpolatedString(i, f.partition)
+			partInt, err := strconv.ParseInt(partStr, 10, 32)
+			if err != nil {
+				return fmt.Errorf("failed to parse valid integer from partition expression: %w", err)
+			}
+			record.Partition = int32(partInt)
+		}
 		_ = f.metaFilter.Walk(msg, func(key, value string) error {
 			record.Headers = append(record.Headers, kgo.RecordHeader{
 				Key:   key,
@@ -265,12 +382,125 @@ func (f *franzKafkaWriter) WriteBatch(ctx context.Context, b service.MessageBatc
 		records = append(records, record)
 	}
 
+	if f.transactionalID != "" {
+		return f.writeTransaction(ctx, b, records)
+	}
+
 	// TODO: This is very cool and allows us to easily return granular errors,
 	// so we should honor travis by doing it.
 	err = f.client.ProduceSync(ctx, records...).FirstErr()
 	return
 }
 
+// writeTransaction produces records, and commits the offsets of the messages
+// they were consumed from when a consumer group is configured, within a single
+// transaction. The transaction is aborted when any stage fails.
+func (f *franzKafkaWriter) writeTransaction(ctx context.Context, b service.MessageBatch, records []*kgo.Record) error {
+	f.txnMut.Lock()
+	defer f.txnMut.Unlock()
+
+	if err := f.client.BeginTransaction(); err != nil {
+		return err
+	}
+
+	err := f.client.ProduceSync(ctx, records...).FirstErr()
+	if err == nil && f.txnConsumerGroup != "" && len(records) > 0 {
+		err = f.commitTxnOffsets(ctx, records[0].ProducerID, records[0].ProducerEpoch, batchOffsets(b))
+	}
+	if err != nil {
+		if abortErr := f.client.EndTransaction(ctx, kgo.TryAbort); abortErr != nil {
+			f.log.Errorf("Failed to abort transaction: %v", abortErr)
+		}
+		return err
+	}
+	return f.client.EndTransaction(ctx, kgo.TryCommit)
+}
+
+// batchOffsets returns the offsets to commit for the topic partitions that the
+// messages of a batch were consumed from, which is the offset following the
+// highest of each partition.
+func batchOffsets(b service.MessageBatch) map[string]map[int32]int64 {
+	offsets := map[string]map[int32]int64{}
+	for _, msg := range b {
+		topic, _ := msg.MetaGet("kafka_topic")
+		partStr, _ := msg.MetaGet("kafka_partition")
+		offsetStr, _ := msg.MetaGet("kafka_offset")
+		if topic == "" || partStr == "" || offsetStr == "" {
+			continue
+		}
+		partition, err := strconv.ParseInt(partStr, 10, 32)
+		if err != nil {
+			continue
+		}
+		offset, err := strconv.ParseInt(offsetStr, 10, 64)
+		if err != nil {
+			continue
+		}
+		parts := offsets[topic]
+		if parts == nil {
+			parts = map[int32]int64{}
+			offsets[topic] = parts
+		}
+		if current, exists := parts[int32(partition)]; !exists || offset+1 > current {
+			parts[int32(partition)] = offset + 1
+		}
+	}
+	return offsets
+}
+
+// commitTxnOffsets adds the offsets of the consumer group to the current
+// transaction.
+func (f *franzKafkaWriter) commitTxnOffsets(ctx context.Context, producerID int64, producerEpoch int16, offsets map[string]map[int32]int64) error {
+	if len(offsets) == 0 {
+		return nil
+	}
+
+	addReq := kmsg.NewPtrAddOffsetsToTxnRequest()
+	addReq.TransactionalID = f.transactionalID
+	addReq.ProducerID = producerID
+	addReq.ProducerEpoch = producerEpoch
+	addReq.Group = f.txnConsumerGroup
+
+	addRes, err := addReq.RequestWith(ctx, f.client)
+	if err != nil {
+		return fmt.Errorf("failed to add offsets to transaction: %w", err)
+	}
+	if err := kerr.ErrorForCode(addRes.ErrorCode); err != nil {
+		return fmt.Errorf("failed to add offsets to transaction: %w", err)
+	}
+
+	commitReq := kmsg.NewPtrTxnOffsetCommitRequest()
+	commitReq.TransactionalID = f.transactionalID
+	commitReq.Group = f.txnConsumerGroup
+	commitReq.ProducerID = producerID
+	commitReq.ProducerEpoch = producerEpoch
+	commitReq.Generation = -1
+	for topic, parts := range offsets {
+		reqTopic := kmsg.NewTxnOffsetCommitRequestTopic()
+		reqTopic.Topic = topic
+		for partition, offset := range parts {
+			reqPart := kmsg.NewTxnOffsetCommitRequestTopicPartition()
+			reqPart.Partition = partition
+			reqPart.Offset = offset
+			reqTopic.Partitions = append(reqTopic.Partitions, reqPart)
+		}
+		commitReq.Topics = append(commitReq.Topics, reqTopic)
+	}
+
+	commitRes, err := commitReq.RequestWith(ctx, f.client)
+	if err != nil {
+		return fmt.Errorf("failed to commit offsets within transaction: %w", err)
+	}
+	for _, t := range commitRes.Topics {
+		for _, p := range t.Partitions {
+			if err := kerr.ErrorForCode(p.ErrorCode); err != nil {
+				return fmt.Errorf("failed to commit offset of topic %v partition %v within transaction: %w", t.Topic, p.Partition, err)
+			}
+		}
+	}
+	return nil
+}
+
 func (f *franzKafkaWriter) disconnect() {
 	if f.client == nil {
 		return
@@ -283,3 +513,15 @@ func (f *franzKafkaWriter) Close(ctx context.Context) error {
 	f.disconnect()
 	return nil
 }
+
+// fnv1aSaramaHasher hashes keys with FNV-1a and mirrors how Sarama converts
+// hashes to partitions, negating rather than masking negative hashes.
+func fnv1aSaramaHasher(key []byte, n int) int {
+	h := fnv.New32a()
+	_, _ = h.Write(key)
+	partition := int32(h.Sum32()) % int32(n)
+	if partition < 0 {
+		partition = -partition
+	}
+	return int(partition)
+}
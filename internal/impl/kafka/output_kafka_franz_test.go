@@ -0,0 +1,121 @@
+package kafka
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/twmb/franz-go/pkg/kgo"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func TestFranzKafkaBatchOffsets(t *testing.T) {
+	newMsg := func(topic, partition, offset string) *service.Message {
+		msg := service.NewMessage(nil)
+		if topic != "" {
+			msg.MetaSet("kafka_topic", topic)
+		}
+		if partition != "" {
+			msg.MetaSet("kafka_partition", partition)
+		}
+		if offset != "" {
+			msg.MetaSet("kafka_offset", offset)
+		}
+		return msg
+	}
+
+	assert.Equal(t, map[string]map[int32]int64{
+		"foo": {0: 6, 1: 3},
+		"bar": {0: 11},
+	}, batchOffsets(service.MessageBatch{
+		newMsg("foo", "0", "4"),
+		newMsg("foo", "0", "5"),
+		newMsg("foo", "1", "2"),
+		newMsg("bar", "0", "10"),
+		newMsg("foo", "0", "3"),
+		newMsg("baz", "", "1"),
+		newMsg("baz", "0", "nope"),
+		newMsg("", "", ""),
+	}))
+}
+
+func TestFranzKafkaPartitionerJavaCompatible(t *testing.T) {
+	// Hashes produced by the Java client, taken from the tests of
+	// org.apache.kafka.common.utils.Utils.murmur2.
+	javaHashes := map[string]int32{
+		"21":                         -973932308,
+		"foobar":                     -790332482,
+		"a-little-bit-long-string":   -985981536,
+		"a-little-bit-longer-string": -1486304829,
+		"lkjh234lh9fiuh90y23oiuhsafujhadof229phr9h19h89h8": -58897971,
+		"abc": 479470107,
+	}
+
+	partitioner := kgo.StickyKeyPartitioner(nil).ForTopic("foo")
+	for key, hash := range javaHashes {
+		for _, n := range []int{1, 3, 7, 64} {
+			exp := int(hash&0x7fffffff) % n
+			assert.Equal(t, exp, partitioner.Partition(&kgo.Record{Key: []byte(key)}, n), key)
+		}
+	}
+}
+
+func TestFranzKafkaPartitionerSaramaCompatible(t *testing.T) {
+	for _, name := range []string{"murmur2_hash", "fnv1a_hash"} {
+		pConf, err := franzKafkaOutputConfig().ParseYAML(`
+seed_brokers: [ localhost:9092 ]
+topic: foo
+partitioner: `+name+`
+`, nil)
+		require.NoError(t, err)
+
+		w, err := newFranzKafkaWriterFromConfig(pConf, nil)
+		require.NoError(t, err)
+		franzPartitioner := w.partitioner.ForTopic("foo")
+
+		saramaCtor, err := strToPartitioner(name)
+		require.NoError(t, err)
+		saramaPartitioner := saramaCtor("foo")
+
+		for i := 0; i < 1000; i++ {
+			key := []byte(fmt.Sprintf("key-%v", i))
+			for _, n := range []int{3, 7, 64} {
+				exp, err := saramaPartitioner.Partition(&sarama.ProducerMessage{Key: sarama.ByteEncoder(key)}, int32(n))
+				require.NoError(t, err)
+				assert.Equal(t, int(exp), franzPartitioner.Partition(&kgo.Record{Key: key}, n), "%v: %s", name, key)
+			}
+		}
+	}
+}
+
+func TestFranzKafkaManualPartitionConfig(t *testing.T) {
+	for conf, errContains := range map[string]string{
+		`partitioner: manual`: "partition field required",
+		`partition: '1'`:      "partition field can only be specified",
+		`
+partitioner: round_robin
+partition: '1'
+`: "partition field can only be specified",
+		`
+partitioner: manual
+partition: ${! meta("partition") }
+`: "",
+	} {
+		pConf, err := franzKafkaOutputConfig().ParseYAML(`
+seed_brokers: [ localhost:9092 ]
+topic: foo
+`+conf, nil)
+		require.NoError(t, err)
+
+		_, err = newFranzKafkaWriterFromConfig(pConf, nil)
+		if errContains == "" {
+			assert.NoError(t, err, conf)
+		} else {
+			require.Error(t, err, conf)
+			assert.Contains(t, err.Error(), errContains, conf)
+		}
+	}
+}
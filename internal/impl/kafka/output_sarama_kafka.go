@@ -65,7 +65,13 @@ Unfortunately this error message will appear for a wide range of connection prob
 			docs.FieldString("target_version", "The version of the Kafka protocol to use. This limits the capabilities used by the client and should ideally match the version of your brokers."),
 			docs.FieldString("rack_id", "A rack identifier for this client.").Advanced(),
 			docs.FieldString("key", "The key to publish messages with.").IsInterpolated(),
-			docs.FieldString("partitioner", "The partitioning algorithm to use.").HasOptions("fnv1a_hash", "murmur2_hash", "random", "round_robin", "manual"),
+			docs.FieldString("partitioner", "The partitioning algorithm to use.").HasAnnotatedOptions(
+				"fnv1a_hash", "Uses a 32-bit FNV-1a hash of the key to compute which partition the message will be on. Messages without a key are written to a random partition.",
+				"murmur2_hash", "Kafka's default hash algorithm that uses a 32-bit murmur2 hash of the key to compute which partition the message will be on, resulting in the same partitions as the default partitioner of the Java client. Messages without a key are written to a random partition.",
+				"random", "Writes each message to a random partition.",
+				"round_robin", "Round-robin's messages through all available partitions.",
+				"manual", "Manually select a partition for each message, requires the field `partition` to be specified.",
+			),
 			docs.FieldString("partition", "The manually-specified partition to publish messages to, relevant only when the field `partitioner` is set to `manual`. Must be able to parse as a 32-bit integer.").IsInterpolated().Advanced(),
 			docs.FieldString("compression", "The compression algorithm to use.").HasOptions("none", "snappy", "lz4", "gzip", "zstd"),
 			docs.FieldString("static_headers", "An optional map of static headers that should be added to messages in addition to metadata.", map[string]string{"first-static-header": "value-1", "second-static-header": "value-2"}).Map(),
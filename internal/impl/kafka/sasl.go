@@ -9,6 +9,8 @@ import (
 
 	"github.com/benthosdev/benthos/v4/internal/bundle"
 	"github.com/benthosdev/benthos/v4/internal/component/cache"
+	"github.com/benthosdev/benthos/v4/internal/docs"
+	sess "github.com/benthosdev/benthos/v4/internal/impl/aws/session"
 	ksasl "github.com/benthosdev/benthos/v4/internal/impl/kafka/sasl"
 	"github.com/benthosdev/benthos/v4/public/service"
 
@@ -24,6 +26,7 @@ var saslField = service.NewObjectListField("sasl",
 		"OAUTHBEARER":   "OAuth Bearer based authentication.",
 		"SCRAM-SHA-256": "SCRAM based authentication as specified in RFC5802.",
 		"SCRAM-SHA-512": "SCRAM based authentication as specified in RFC5802.",
+		"AWS_MSK_IAM":   "Authentication with the IAM access control of Amazon MSK, using the credentials configured with the `aws` fields. Requires TLS to be enabled.",
 	}).
 		Description("The SASL mechanism to use."),
 	service.NewStringField("username").
@@ -38,6 +41,11 @@ var saslField = service.NewObjectListField("sasl",
 	service.NewStringMapField("extensions").
 		Description("Key/value pairs to add to OAUTHBEARER authentication requests.").
 		Optional(),
+	service.NewInternalField(
+		docs.FieldObject("aws", "The AWS region and credentials to use for AWS_MSK_IAM authentication. When no credentials are configured the default credential chain of the AWS SDK is used.").
+			WithChildren(sess.FieldSpecs()...).
+			AtVersion("4.3.0"),
+	).Optional(),
 ).
 	Description("Specify one or more methods of SASL authentication. SASL is tried in order; if the broker supports the first mechanism, all connections will use that mechanism. If the first mechanism fails, the client will pick the first supported mechanism. If the broker does not support any client mechanisms, connections will fail.").
 	Advanced().Optional().
@@ -51,6 +59,13 @@ var saslField = service.NewObjectListField("sasl",
 		},
 	)
 
+func notImportedAWSSASLFromConfigFn(c *service.ParsedConfig) (sasl.Mechanism, error) {
+	return nil, errors.New("unable to configure AWS_MSK_IAM authentication as this binary does not import components/aws")
+}
+
+// AWSSASLFromConfigFn is populated with the child `aws` package when imported.
+var AWSSASLFromConfigFn = notImportedAWSSASLFromConfigFn
+
 func saslMechanismsFromConfig(c *service.ParsedConfig) ([]sasl.Mechanism, error) {
 	if !c.Contains("sasl") {
 		return nil, nil
@@ -74,6 +89,8 @@ func saslMechanismsFromConfig(c *service.ParsedConfig) ([]sasl.Mechanism, error)
 				mechanisms[i], err = scram256SaslFromConfig(mConf)
 			case "SCRAM-SHA-512":
 				mechanisms[i], err = scram512SaslFromConfig(mConf)
+			case "AWS_MSK_IAM":
+				mechanisms[i], err = AWSSASLFromConfigFn(mConf)
 			default:
 				err = fmt.Errorf("unknown mechanism: %v", mechStr)
 			}
@@ -166,9 +183,28 @@ var (
 	ErrUnsupportedSASLMechanism = errors.New("unsupported SASL mechanism")
 )
 
+func notImportedAWSTokenProviderFn(c sess.Config) (sarama.AccessTokenProvider, error) {
+	return nil, errors.New("unable to configure AWS_MSK_IAM authentication as this binary does not import components/aws")
+}
+
+// AWSTokenProviderFn is populated with the child `aws` package when imported.
+var AWSTokenProviderFn = notImportedAWSTokenProviderFn
+
 // ApplySASLConfig applies a SASL config to a sarama config.
 func ApplySASLConfig(s ksasl.Config, mgr bundle.NewManagement, conf *sarama.Config) error {
 	switch s.Mechanism {
+	case "AWS_MSK_IAM":
+		// Sarama does not support the AWS_MSK_IAM mechanism, and therefore
+		// signed IAM tokens are provided with the OAUTHBEARER mechanism
+		// instead, which is also accepted by brokers with IAM enabled.
+		tp, err := AWSTokenProviderFn(s.AWS)
+		if err != nil {
+			return err
+		}
+		conf.Net.SASL.Enable = true
+		conf.Net.SASL.Mechanism = sarama.SASLTypeOAuth
+		conf.Net.SASL.TokenProvider = tp
+		return nil
 	case sarama.SASLTypeOAuth:
 		var tp sarama.AccessTokenProvider
 		var err error
@@ -129,6 +129,36 @@ cache_resources:
 	}
 }
 
+func TestApplyAWSMSKIAM(t *testing.T) {
+	conf := &sarama.Config{}
+
+	saslConf := sasl.NewConfig()
+	saslConf.Mechanism = "AWS_MSK_IAM"
+	saslConf.AWS.Region = "eu-west-1"
+	saslConf.AWS.Credentials.ID = "foo"
+	saslConf.AWS.Credentials.Secret = "bar"
+
+	require.NoError(t, kafka.ApplySASLConfig(saslConf, mock.NewManager(), conf))
+
+	if !conf.Net.SASL.Enable {
+		t.Errorf("SASL not enabled")
+	}
+
+	if conf.Net.SASL.Mechanism != sarama.SASLTypeOAuth {
+		t.Errorf("Wrong SASL mechanism: %v != %v", conf.Net.SASL.Mechanism, sarama.SASLTypeOAuth)
+	}
+
+	token, err := conf.Net.SASL.TokenProvider.Token()
+	require.NoError(t, err)
+	require.NotEmpty(t, token.Token)
+
+	// Test with missing region
+	saslConf.AWS.Region = ""
+	t.Setenv("AWS_REGION", "")
+	t.Setenv("AWS_DEFAULT_REGION", "")
+	require.Error(t, kafka.ApplySASLConfig(saslConf, mock.NewManager(), conf))
+}
+
 func TestApplyUnknownMechanism(t *testing.T) {
 	conf := &sarama.Config{}
 
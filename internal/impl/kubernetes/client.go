@@ -0,0 +1,68 @@
+package kubernetes
+
+import (
+	"errors"
+	"os"
+	"strings"
+
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	kiFieldKubeconfig = "kubeconfig"
+	kiFieldContext    = "context"
+
+	// serviceAccountNamespacePath is the file that the namespace of a pod is
+	// mounted at along with the token of its service account.
+	serviceAccountNamespacePath = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+)
+
+func kubeconfigField() *service.ConfigField {
+	return service.NewStringField(kiFieldKubeconfig).
+		Description("The path of a kubeconfig file to authenticate with. When empty the service account of the pod is used when running within a cluster, or the default kubeconfig otherwise.").
+		Example("~/.kube/config").
+		Default("")
+}
+
+func contextField() *service.ConfigField {
+	return service.NewStringField(kiFieldContext).
+		Description("The context of the kubeconfig to use, which defaults to the current context.").
+		Default("").
+		Advanced()
+}
+
+// restConfig loads the config of the API client, preferring the service
+// account of the pod when no kubeconfig has been specified.
+func restConfig(kubeconfig, context string) (*rest.Config, error) {
+	if kubeconfig == "" && context == "" {
+		conf, err := rest.InClusterConfig()
+		if err == nil {
+			return conf, nil
+		}
+		if !errors.Is(err, rest.ErrNotInCluster) {
+			return nil, err
+		}
+	}
+
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	if kubeconfig != "" {
+		rules.ExplicitPath = kubeconfig
+	}
+	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, &clientcmd.ConfigOverrides{
+		CurrentContext: context,
+	}).ClientConfig()
+}
+
+// podNamespace returns the namespace of the pod when running within a cluster,
+// or the default namespace otherwise.
+func podNamespace() string {
+	if b, err := os.ReadFile(serviceAccountNamespacePath); err == nil {
+		if ns := strings.TrimSpace(string(b)); ns != "" {
+			return ns
+		}
+	}
+	return "default"
+}
@@ -0,0 +1,182 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"regexp"
+	"strings"
+	"time"
+
+	coordv1 "k8s.io/api/coordination/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8s "k8s.io/client-go/kubernetes"
+	coordinationv1 "k8s.io/client-go/kubernetes/typed/coordination/v1"
+
+	"github.com/benthosdev/benthos/v4/internal/coordination"
+	"github.com/benthosdev/benthos/v4/internal/impl/pure"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func init() {
+	pure.KubernetesCoordinationFn = func(conf *service.ParsedConfig) (coordination.Backend, error) {
+		kubeconfig, err := conf.FieldString(kiFieldKubeconfig)
+		if err != nil {
+			return nil, err
+		}
+		kubeContext, err := conf.FieldString(kiFieldContext)
+		if err != nil {
+			return nil, err
+		}
+		namespace, err := conf.FieldString("namespace")
+		if err != nil {
+			return nil, err
+		}
+		if namespace == "" {
+			namespace = podNamespace()
+		}
+
+		restConf, err := restConfig(kubeconfig, kubeContext)
+		if err != nil {
+			return nil, err
+		}
+		client, err := k8s.NewForConfig(restConf)
+		if err != nil {
+			return nil, err
+		}
+		return newLeaseBackend(client.CoordinationV1().Leases(namespace)), nil
+	}
+}
+
+//------------------------------------------------------------------------------
+
+var invalidLeaseNameChars = regexp.MustCompile(`[^a-z0-9.-]+`)
+
+// leaseName converts a key into the name of a lease, where a hash of the key is
+// appended in order to keep names unique after removing invalid characters.
+func leaseName(key string) string {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+
+	name := invalidLeaseNameChars.ReplaceAllString(strings.ToLower(key), "-")
+	if len(name) > 200 {
+		name = name[:200]
+	}
+	name = strings.Trim(name, ".-")
+	return fmt.Sprintf("%v-%08x", name, h.Sum32())
+}
+
+// leaseBackend claims partitions with a lease for each, where updates to a
+// lease are guarded by its resource version.
+type leaseBackend struct {
+	leases coordinationv1.LeaseInterface
+	now    func() time.Time
+}
+
+func newLeaseBackend(leases coordinationv1.LeaseInterface) *leaseBackend {
+	return &leaseBackend{leases: leases, now: time.Now}
+}
+
+func (l *leaseBackend) holder(lease *coordv1.Lease) string {
+	spec := lease.Spec
+	if spec.HolderIdentity == nil || spec.RenewTime == nil || spec.LeaseDurationSeconds == nil {
+		return ""
+	}
+	expires := spec.RenewTime.Add(time.Duration(*spec.LeaseDurationSeconds) * time.Second)
+	if !l.now().Before(expires) {
+		return ""
+	}
+	return *spec.HolderIdentity
+}
+
+func (l *leaseBackend) setHolder(lease *coordv1.Lease, owner string, ttl time.Duration) {
+	now := metav1.NewMicroTime(l.now())
+	if lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity != owner {
+		if lease.Spec.HolderIdentity != nil && *lease.Spec.HolderIdentity != "" {
+			transitions := int32(1)
+			if lease.Spec.LeaseTransitions != nil {
+				transitions += *lease.Spec.LeaseTransitions
+			}
+			lease.Spec.LeaseTransitions = &transitions
+		}
+		lease.Spec.AcquireTime = &now
+	}
+	seconds := int32((ttl + time.Second - 1) / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+	lease.Spec.HolderIdentity = &owner
+	lease.Spec.LeaseDurationSeconds = &seconds
+	lease.Spec.RenewTime = &now
+}
+
+func (l *leaseBackend) Get(ctx context.Context, key string) (string, error) {
+	lease, err := l.leases.Get(ctx, leaseName(key), metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return "", coordination.ErrNotClaimed
+	}
+	if err != nil {
+		return "", err
+	}
+	if owner := l.holder(lease); owner != "" {
+		return owner, nil
+	}
+	return "", coordination.ErrNotClaimed
+}
+
+func (l *leaseBackend) Create(ctx context.Context, key, owner string, ttl time.Duration) error {
+	lease, err := l.leases.Get(ctx, leaseName(key), metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		lease = &coordv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{Name: leaseName(key)},
+		}
+		l.setHolder(lease, owner, ttl)
+		_, err = l.leases.Create(ctx, lease, metav1.CreateOptions{})
+		if apierrors.IsAlreadyExists(err) {
+			return coordination.ErrClaimed
+		}
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	if l.holder(lease) != "" {
+		return coordination.ErrClaimed
+	}
+	return l.update(ctx, lease, owner, ttl)
+}
+
+func (l *leaseBackend) Update(ctx context.Context, key, owner string, ttl time.Duration) error {
+	lease, err := l.leases.Get(ctx, leaseName(key), metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return coordination.ErrClaimed
+	}
+	if err != nil {
+		return err
+	}
+	return l.update(ctx, lease, owner, ttl)
+}
+
+// update sets the holder of a lease, failing with ErrClaimed when the lease
+// was modified since it was read.
+func (l *leaseBackend) update(ctx context.Context, lease *coordv1.Lease, owner string, ttl time.Duration) error {
+	l.setHolder(lease, owner, ttl)
+	_, err := l.leases.Update(ctx, lease, metav1.UpdateOptions{})
+	if apierrors.IsConflict(err) {
+		return coordination.ErrClaimed
+	}
+	return err
+}
+
+func (l *leaseBackend) Delete(ctx context.Context, key string) error {
+	err := l.leases.Delete(ctx, leaseName(key), metav1.DeleteOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+func (l *leaseBackend) Close(ctx context.Context) error {
+	return nil
+}
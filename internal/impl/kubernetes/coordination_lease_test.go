@@ -0,0 +1,69 @@
+package kubernetes
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/benthosdev/benthos/v4/internal/coordination"
+)
+
+func TestLeaseName(t *testing.T) {
+	assert.Regexp(t, `^orders-backfill-orders-0-[0-9a-f]{8}$`, leaseName("orders-backfill/orders-0"))
+	assert.Regexp(t, `^foo-bar-2021-[0-9a-f]{8}$`, leaseName("Foo_Bar/2021/"))
+	assert.NotEqual(t, leaseName("foo/a_b"), leaseName("foo/a-b"))
+	assert.LessOrEqual(t, len(leaseName("foo/"+strings.Repeat("a", 300))), 253)
+}
+
+func TestLeaseBackend(t *testing.T) {
+	ctx := context.Background()
+	leases := fake.NewSimpleClientset().CoordinationV1().Leases("default")
+
+	now := time.Unix(1000, 0)
+	b := newLeaseBackend(leases)
+	b.now = func() time.Time { return now }
+
+	_, err := b.Get(ctx, "foo/a")
+	require.ErrorIs(t, err, coordination.ErrNotClaimed)
+
+	require.NoError(t, b.Create(ctx, "foo/a", "x", time.Second*10))
+	require.ErrorIs(t, b.Create(ctx, "foo/a", "y", time.Second*10), coordination.ErrClaimed)
+
+	owner, err := b.Get(ctx, "foo/a")
+	require.NoError(t, err)
+	assert.Equal(t, "x", owner)
+
+	// Claims expire once they have not been renewed within their TTL, at which
+	// point they can be claimed by another owner.
+	now = now.Add(time.Second * 10)
+	_, err = b.Get(ctx, "foo/a")
+	require.ErrorIs(t, err, coordination.ErrNotClaimed)
+
+	require.NoError(t, b.Create(ctx, "foo/a", "y", time.Second*10))
+	owner, err = b.Get(ctx, "foo/a")
+	require.NoError(t, err)
+	assert.Equal(t, "y", owner)
+
+	lease, err := leases.Get(ctx, leaseName("foo/a"), metav1.GetOptions{})
+	require.NoError(t, err)
+	require.NotNil(t, lease.Spec.LeaseTransitions)
+	assert.Equal(t, int32(1), *lease.Spec.LeaseTransitions)
+	assert.Equal(t, int32(10), *lease.Spec.LeaseDurationSeconds)
+
+	require.NoError(t, b.Update(ctx, "foo/a", "x", time.Second*10))
+	owner, err = b.Get(ctx, "foo/a")
+	require.NoError(t, err)
+	assert.Equal(t, "x", owner)
+
+	require.NoError(t, b.Delete(ctx, "foo/a"))
+	require.NoError(t, b.Delete(ctx, "foo/a"))
+	_, err = b.Get(ctx, "foo/a")
+	require.ErrorIs(t, err, coordination.ErrNotClaimed)
+	require.ErrorIs(t, b.Update(ctx, "foo/a", "x", time.Second*10), coordination.ErrClaimed)
+}
@@ -0,0 +1,626 @@
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/restmapper"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	kiFieldResources       = "resources"
+	kiFieldResourceGroup   = "group"
+	kiFieldResourceVersion = "version"
+	kiFieldResourceKind    = "kind"
+	kiFieldNamespaces      = "namespaces"
+	kiFieldLabelSelector   = "label_selector"
+	kiFieldFieldSelector   = "field_selector"
+	kiFieldIncludeExisting = "include_existing"
+	kiFieldCheckpoint      = "checkpoint"
+	kiFieldCheckpointKey   = "checkpoint_key"
+	kiFieldRetryPeriod     = "retry_period"
+)
+
+func inputConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		// Stable(). TODO
+		Categories("Services").
+		Version("4.3.0").
+		Summary("Watches the Kubernetes API for changes to resources, such as events, pods or custom resources, and emits each change as a structured message.").
+		Description(`
+Each configured resource is listed and then watched within each of the configured namespaces, or across all namespaces when none are configured. The objects of each resource are cached in memory, which allows changes to be emitted along with the prior state of the object and objects that were deleted while a watch was interrupted to be detected.
+
+Messages are structured documents of the following form, where `+"`type`"+` is one of `+"`ADDED`, `MODIFIED` or `DELETED`"+`, and `+"`old_object`"+` is only present when the prior state of a modified object is cached:
+
+`+"```json"+`
+{
+  "type": "MODIFIED",
+  "object": {"apiVersion": "v1", "kind": "Pod", "metadata": {"name": "foo"}},
+  "old_object": {"apiVersion": "v1", "kind": "Pod", "metadata": {"name": "foo"}}
+}
+`+"```"+`
+
+When `+"`include_existing`"+` is disabled the objects that exist when the input starts are cached without being emitted, and only subsequent changes are consumed.
+
+### Resuming
+
+When a `+"`checkpoint`"+` store is configured the resource version of each watch is persisted once the messages it belongs to, and all messages prior to them, have been acknowledged. When the input is restarted each watch resumes from its stored resource version, and therefore changes made while Benthos was not running are consumed without the existing objects being emitted again. Since the cache is empty at that point the first changes to each object are emitted without an `+"`old_object`"+`.
+
+The Kubernetes API only retains changes for a limited window, and when a stored resource version has expired the resource is listed again, in which case each existing object is emitted as `+"`ADDED`"+`.
+
+### Authentication
+
+When `+"`kubeconfig`"+` is empty and Benthos runs within a pod the service account of the pod is used, otherwise the kubeconfig is loaded from the `+"`KUBECONFIG`"+` environment variable or `+"`~/.kube/config`"+`. The service account requires the permissions to `+"`list` and `watch`"+` each of the configured resources.
+
+### Metadata
+
+This input adds the following metadata fields to each message:
+
+`+"```text"+`
+- kubernetes_event_type
+- kubernetes_kind
+- kubernetes_namespace
+- kubernetes_name
+- kubernetes_resource_version
+`+"```"+`
+
+You can access these metadata fields using [function interpolation](/docs/configuration/interpolation#metadata).`).
+		Field(kubeconfigField()).
+		Field(contextField()).
+		Field(service.NewObjectListField(kiFieldResources,
+			service.NewStringField(kiFieldResourceGroup).
+				Description("The API group of the resource, which is empty for core resources.").
+				Example("apps").
+				Default(""),
+			service.NewStringField(kiFieldResourceVersion).
+				Description("The API version of the resource.").
+				Default("v1"),
+			service.NewStringField(kiFieldResourceKind).
+				Description("The kind of the resource.").
+				Example("Pod").
+				Example("Deployment"),
+		).
+			Description("The resources to watch, identified by their group, version and kind.").
+			Default([]interface{}{
+				map[string]interface{}{
+					kiFieldResourceGroup:   "",
+					kiFieldResourceVersion: "v1",
+					kiFieldResourceKind:    "Event",
+				},
+			})).
+		Field(service.NewStringListField(kiFieldNamespaces).
+			Description("The namespaces to watch resources within. When empty resources are watched across all namespaces. Resources that are not namespaced are always watched across the cluster.").
+			Example([]string{"default", "kube-system"}).
+			Default([]string{})).
+		Field(service.NewStringField(kiFieldLabelSelector).
+			Description("A [label selector](https://kubernetes.io/docs/concepts/overview/working-with-objects/labels/#label-selectors) that objects must match in order to be consumed.").
+			Example("app=shop,environment in (production)").
+			Default("")).
+		Field(service.NewStringField(kiFieldFieldSelector).
+			Description("A [field selector](https://kubernetes.io/docs/concepts/overview/working-with-objects/field-selectors/) that objects must match in order to be consumed.").
+			Example("status.phase=Running").
+			Example("type=Warning").
+			Default("")).
+		Field(service.NewBoolField(kiFieldIncludeExisting).
+			Description("Whether to emit the objects that exist when the input starts as `ADDED` changes.").
+			Default(true)).
+		Field(service.NewCheckpointStoreField(kiFieldCheckpoint).
+			Optional()).
+		Field(service.NewStringField(kiFieldCheckpointKey).
+			Description("A prefix of the keys that resource versions are stored under within the checkpoint store, which is followed by the resource and namespace of each watch.").
+			Default("kubernetes").
+			Advanced()).
+		Field(service.NewDurationField(kiFieldRetryPeriod).
+			Description("The period to wait before listing or watching a resource again after a failure.").
+			Default("5s").
+			Advanced()).
+		Example("Warning Events", "Consume the warning events of a namespace and reduce them to a summary of the object they concern.", `
+input:
+  kubernetes:
+    namespaces: [ shop ]
+    field_selector: type=Warning
+    include_existing: false
+
+pipeline:
+  processors:
+    - bloblang: |
+        root.object = "%s/%s".format(this.object.involvedObject.kind, this.object.involvedObject.name)
+        root.reason = this.object.reason
+        root.message = this.object.message
+`).
+		Example("Resumable Deployment Changes", "Consume the changes to deployments across the cluster, persisting the resource version of the watch in a cache so that changes made while Benthos is not running are consumed after a restart.", `
+input:
+  kubernetes:
+    resources:
+      - group: apps
+        version: v1
+        kind: Deployment
+    label_selector: team=payments
+    checkpoint:
+      cache: checkpoints
+
+cache_resources:
+  - label: checkpoints
+    file:
+      directory: ./checkpoints
+`)
+}
+
+func init() {
+	err := service.RegisterInput(
+		"kubernetes", inputConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Input, error) {
+			i, err := newInputFromConfig(conf, mgr)
+			if err != nil {
+				return nil, err
+			}
+			return service.AutoRetryNacks(i), nil
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// watchTarget is a resource to list and watch within a single namespace, or
+// across all namespaces when the namespace is empty.
+type watchTarget struct {
+	resource  dynamic.ResourceInterface
+	key       string
+	committer *service.CheckpointCommitter
+
+	// resourceVersion is the version to resume watching from, which is empty
+	// when the resource must be listed first.
+	resourceVersion string
+}
+
+type kubernetesInput struct {
+	kubeconfig      string
+	context         string
+	gvks            []schema.GroupVersionKind
+	namespaces      []string
+	listOpts        metav1.ListOptions
+	includeExisting bool
+	checkpointKey   string
+	retryPeriod     time.Duration
+	storeConf       *service.CheckpointStoreConfig
+	res             *service.Resources
+	log             *service.Logger
+
+	// newTargets resolves the targets to watch, and is replaced in tests.
+	newTargets func(ctx context.Context) ([]*watchTarget, error)
+
+	connMut  sync.Mutex
+	store    *service.CheckpointStore
+	msgs     chan kubernetesMessage
+	closeFn  func()
+	loopDone chan struct{}
+}
+
+type kubernetesMessage struct {
+	msg    *service.Message
+	commit func(context.Context) error
+}
+
+func newInputFromConfig(conf *service.ParsedConfig, res *service.Resources) (*kubernetesInput, error) {
+	k := &kubernetesInput{
+		res: res,
+		log: res.Logger(),
+	}
+	k.newTargets = k.discoverTargets
+
+	var err error
+	if k.kubeconfig, err = conf.FieldString(kiFieldKubeconfig); err != nil {
+		return nil, err
+	}
+	if k.context, err = conf.FieldString(kiFieldContext); err != nil {
+		return nil, err
+	}
+
+	resConfs, err := conf.FieldObjectList(kiFieldResources)
+	if err != nil {
+		return nil, err
+	}
+	if len(resConfs) == 0 {
+		return nil, errors.New("at least one resource must be specified")
+	}
+	for _, rConf := range resConfs {
+		var gvk schema.GroupVersionKind
+		if gvk.Group, err = rConf.FieldString(kiFieldResourceGroup); err != nil {
+			return nil, err
+		}
+		if gvk.Version, err = rConf.FieldString(kiFieldResourceVersion); err != nil {
+			return nil, err
+		}
+		if gvk.Kind, err = rConf.FieldString(kiFieldResourceKind); err != nil {
+			return nil, err
+		}
+		if gvk.Kind == "" {
+			return nil, errors.New("the kind of each resource must be specified")
+		}
+		k.gvks = append(k.gvks, gvk)
+	}
+
+	if k.namespaces, err = conf.FieldStringList(kiFieldNamespaces); err != nil {
+		return nil, err
+	}
+	if k.listOpts.LabelSelector, err = conf.FieldString(kiFieldLabelSelector); err != nil {
+		return nil, err
+	}
+	if k.listOpts.FieldSelector, err = conf.FieldString(kiFieldFieldSelector); err != nil {
+		return nil, err
+	}
+	if k.includeExisting, err = conf.FieldBool(kiFieldIncludeExisting); err != nil {
+		return nil, err
+	}
+	if k.checkpointKey, err = conf.FieldString(kiFieldCheckpointKey); err != nil {
+		return nil, err
+	}
+	if k.retryPeriod, err = conf.FieldDuration(kiFieldRetryPeriod); err != nil {
+		return nil, err
+	}
+
+	// The checkpoint fields have defaults and are therefore always present, so
+	// the store is only enabled when a cache or table has been configured.
+	checkpointCache, _ := conf.FieldString(kiFieldCheckpoint, "cache")
+	if checkpointCache != "" || conf.Contains(kiFieldCheckpoint, "sql") {
+		storeConf, err := conf.FieldCheckpointStore(kiFieldCheckpoint)
+		if err != nil {
+			return nil, err
+		}
+		k.storeConf = &storeConf
+	}
+	return k, nil
+}
+
+// discoverTargets maps the configured kinds to resources with the discovery
+// API and returns a target for each resource and namespace.
+func (k *kubernetesInput) discoverTargets(ctx context.Context) ([]*watchTarget, error) {
+	conf, err := restConfig(k.kubeconfig, k.context)
+	if err != nil {
+		return nil, err
+	}
+	client, err := dynamic.NewForConfig(conf)
+	if err != nil {
+		return nil, err
+	}
+	disco, err := discovery.NewDiscoveryClientForConfig(conf)
+	if err != nil {
+		return nil, err
+	}
+	groupResources, err := restmapper.GetAPIGroupResources(disco)
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover resources: %w", err)
+	}
+	mapper := restmapper.NewDiscoveryRESTMapper(groupResources)
+
+	var targets []*watchTarget
+	for _, gvk := range k.gvks {
+		mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve resource %v: %w", gvk, err)
+		}
+		if mapping.Scope.Name() != meta.RESTScopeNameNamespace || len(k.namespaces) == 0 {
+			targets = append(targets, k.newTarget(client.Resource(mapping.Resource), mapping.Resource, ""))
+			continue
+		}
+		for _, ns := range k.namespaces {
+			targets = append(targets, k.newTarget(client.Resource(mapping.Resource).Namespace(ns), mapping.Resource, ns))
+		}
+	}
+	return targets, nil
+}
+
+func (k *kubernetesInput) newTarget(resource dynamic.ResourceInterface, gvr schema.GroupVersionResource, namespace string) *watchTarget {
+	return &watchTarget{
+		resource: resource,
+		key:      strings.Join([]string{k.checkpointKey, gvr.Group, gvr.Version, gvr.Resource, namespace}, "/"),
+	}
+}
+
+func (k *kubernetesInput) Connect(ctx context.Context) error {
+	k.connMut.Lock()
+	defer k.connMut.Unlock()
+
+	if k.msgs != nil {
+		return nil
+	}
+
+	targets, err := k.newTargets(ctx)
+	if err != nil {
+		return err
+	}
+
+	if k.storeConf != nil && k.store == nil {
+		if k.store, err = k.storeConf.NewStore(ctx, k.res); err != nil {
+			return err
+		}
+	}
+	if k.store != nil {
+		for _, t := range targets {
+			stored, err := k.store.Get(ctx, t.key)
+			if err != nil && !errors.Is(err, service.ErrKeyNotFound) {
+				return err
+			}
+			t.resourceVersion = stored
+			t.committer = k.store.NewCommitter(t.key)
+		}
+	}
+
+	loopCtx, cancel := context.WithCancel(context.Background())
+	k.msgs = make(chan kubernetesMessage)
+	k.closeFn = cancel
+	k.loopDone = make(chan struct{})
+
+	var wg sync.WaitGroup
+	for _, t := range targets {
+		wg.Add(1)
+		go func(t *watchTarget) {
+			defer wg.Done()
+			k.watchLoop(loopCtx, t, k.msgs)
+		}(t)
+	}
+	go func(done chan struct{}) {
+		wg.Wait()
+		close(done)
+	}(k.loopDone)
+	return nil
+}
+
+// watchLoop lists and watches a target until the context is cancelled,
+// resuming watches from the latest resource version and listing the target
+// again when that version has expired.
+func (k *kubernetesInput) watchLoop(ctx context.Context, t *watchTarget, msgs chan<- kubernetesMessage) {
+	c := &objectCache{objects: map[string]*unstructured.Unstructured{}}
+	emit := func(eventType watch.EventType, obj, old *unstructured.Unstructured, resourceVersion string) bool {
+		m := kubernetesMessage{msg: newMessage(eventType, obj, old)}
+		if t.committer != nil && resourceVersion != "" {
+			m.commit = t.committer.Track(resourceVersion)
+		}
+		select {
+		case msgs <- m:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	rv, listed := t.resourceVersion, false
+	for {
+		var err error
+		if rv == "" {
+			var list *unstructured.UnstructuredList
+			if list, err = t.resource.List(ctx, k.listOpts); err == nil {
+				rv = list.GetResourceVersion()
+				if !listed && !k.includeExisting {
+					c.replace(list.Items, nil)
+				} else if !c.replace(list.Items, func(eventType watch.EventType, obj, old *unstructured.Unstructured) bool {
+					return emit(eventType, obj, old, rv)
+				}) {
+					return
+				}
+				listed = true
+			}
+		}
+		if err == nil {
+			rv, err = k.watch(ctx, t, rv, c, emit)
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			if apierrors.IsResourceExpired(err) || apierrors.IsGone(err) {
+				k.log.Warnf("Resource version of %v has expired, listing objects again", t.key)
+				rv = ""
+				continue
+			}
+			k.log.Errorf("Failed to watch %v: %v", t.key, err)
+			select {
+			case <-time.After(k.retryPeriod):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+// watch consumes the changes of a target from a resource version until the
+// watch ends, and returns the latest resource version observed.
+func (k *kubernetesInput) watch(
+	ctx context.Context,
+	t *watchTarget,
+	rv string,
+	c *objectCache,
+	emit func(eventType watch.EventType, obj, old *unstructured.Unstructured, resourceVersion string) bool,
+) (string, error) {
+	opts := k.listOpts
+	opts.ResourceVersion = rv
+	opts.AllowWatchBookmarks = true
+
+	w, err := t.resource.Watch(ctx, opts)
+	if err != nil {
+		return rv, err
+	}
+	defer w.Stop()
+
+	for {
+		var event watch.Event
+		var open bool
+		select {
+		case event, open = <-w.ResultChan():
+		case <-ctx.Done():
+			return rv, ctx.Err()
+		}
+		if !open {
+			return rv, nil
+		}
+
+		switch event.Type {
+		case watch.Error:
+			return rv, apierrors.FromObject(event.Object)
+		case watch.Bookmark:
+			if obj, ok := event.Object.(*unstructured.Unstructured); ok {
+				rv = obj.GetResourceVersion()
+			}
+		case watch.Added, watch.Modified, watch.Deleted:
+			obj, ok := event.Object.(*unstructured.Unstructured)
+			if !ok {
+				return rv, fmt.Errorf("unexpected object type %T", event.Object)
+			}
+			if v := obj.GetResourceVersion(); v != "" {
+				rv = v
+			}
+			if !emit(event.Type, obj, c.apply(event.Type, obj), rv) {
+				return rv, ctx.Err()
+			}
+		}
+	}
+}
+
+func (k *kubernetesInput) Read(ctx context.Context) (*service.Message, service.AckFunc, error) {
+	k.connMut.Lock()
+	msgs, loopDone := k.msgs, k.loopDone
+	k.connMut.Unlock()
+
+	if msgs == nil {
+		return nil, nil, service.ErrNotConnected
+	}
+
+	select {
+	case m := <-msgs:
+		return m.msg, func(ctx context.Context, err error) error {
+			if err != nil || m.commit == nil {
+				return nil
+			}
+			return m.commit(ctx)
+		}, nil
+	case <-loopDone:
+		return nil, nil, service.ErrNotConnected
+	case <-ctx.Done():
+		return nil, nil, ctx.Err()
+	}
+}
+
+func (k *kubernetesInput) Close(ctx context.Context) error {
+	k.connMut.Lock()
+	closeFn, loopDone := k.closeFn, k.loopDone
+	k.msgs, k.closeFn, k.loopDone = nil, nil, nil
+	k.connMut.Unlock()
+
+	if closeFn != nil {
+		closeFn()
+		select {
+		case <-loopDone:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	k.connMut.Lock()
+	defer k.connMut.Unlock()
+	if k.store != nil {
+		err := k.store.Close(ctx)
+		k.store = nil
+		return err
+	}
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+func newMessage(eventType watch.EventType, obj, old *unstructured.Unstructured) *service.Message {
+	body := map[string]interface{}{
+		"type":   string(eventType),
+		"object": obj.Object,
+	}
+	if old != nil && eventType == watch.Modified {
+		body["old_object"] = old.Object
+	}
+
+	msg := service.NewMessage(nil)
+	msg.SetStructured(body)
+	msg.MetaSet("kubernetes_event_type", string(eventType))
+	msg.MetaSet("kubernetes_kind", obj.GetKind())
+	msg.MetaSet("kubernetes_namespace", obj.GetNamespace())
+	msg.MetaSet("kubernetes_name", obj.GetName())
+	msg.MetaSet("kubernetes_resource_version", obj.GetResourceVersion())
+	return msg
+}
+
+// objectCache holds the latest known state of the objects of a target, keyed
+// by their namespace and name.
+type objectCache struct {
+	objects map[string]*unstructured.Unstructured
+}
+
+func objectKey(obj *unstructured.Unstructured) string {
+	if ns := obj.GetNamespace(); ns != "" {
+		return ns + "/" + obj.GetName()
+	}
+	return obj.GetName()
+}
+
+// apply updates the cache with a change and returns the prior state of the
+// object, or nil if it was not cached.
+func (c *objectCache) apply(eventType watch.EventType, obj *unstructured.Unstructured) *unstructured.Unstructured {
+	key := objectKey(obj)
+	old := c.objects[key]
+	if eventType == watch.Deleted {
+		delete(c.objects, key)
+	} else {
+		c.objects[key] = obj
+	}
+	return old
+}
+
+// replace updates the cache with the result of a list and calls fn, when not
+// nil, with the changes between the cached and listed objects. Returns false
+// if fn returned false, in which case the cache is left partially updated.
+func (c *objectCache) replace(items []unstructured.Unstructured, fn func(eventType watch.EventType, obj, old *unstructured.Unstructured) bool) bool {
+	listed := make(map[string]struct{}, len(items))
+	for i := range items {
+		obj := &items[i]
+		key := objectKey(obj)
+		listed[key] = struct{}{}
+
+		old, exists := c.objects[key]
+		c.objects[key] = obj
+		if fn == nil {
+			continue
+		}
+		if !exists {
+			if !fn(watch.Added, obj, nil) {
+				return false
+			}
+		} else if old.GetResourceVersion() != obj.GetResourceVersion() {
+			if !fn(watch.Modified, obj, old) {
+				return false
+			}
+		}
+	}
+	for key, obj := range c.objects {
+		if _, exists := listed[key]; exists {
+			continue
+		}
+		delete(c.objects, key)
+		if fn != nil && !fn(watch.Deleted, obj, nil) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,351 @@
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"time"
+
+	k8s "k8s.io/client-go/kubernetes"
+	coordinationv1 "k8s.io/client-go/kubernetes/typed/coordination/v1"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	leFieldLeaseName      = "lease_name"
+	leFieldLeaseNamespace = "lease_namespace"
+	leFieldIdentity       = "identity"
+	leFieldLeaseDuration  = "lease_duration"
+	leFieldRenewDeadline  = "renew_deadline"
+	leFieldRetryPeriod    = "retry_period"
+	leFieldInput          = "input"
+)
+
+func leaderElectionInputConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		// Stable(). TODO
+		Categories("Utility").
+		Version("4.3.0").
+		Summary("Consumes from a child input only while holding the leadership of a Kubernetes lease, allowing inputs that must run as singletons to be deployed with multiple replicas that fail over automatically.").
+		Description(`
+Each replica of a deployment campaigns for the leadership of the same [Lease](https://kubernetes.io/docs/concepts/architecture/leases/) object, and only the replica that holds the lease creates the child input and consumes from it. Replicas that are not leading do not create the child input at all, and therefore do not connect to the source it consumes from.
+
+The leader renews the lease every `+"`retry_period`"+`, and when it fails to renew it within the `+"`renew_deadline`"+` it closes the child input and campaigns again. When the leader is shut down the lease is released so that another replica takes over immediately, otherwise a replica takes over once the lease has not been renewed for the `+"`lease_duration`"+`.
+
+This is useful for inputs such as change data capture streams, pollers and `+"[`generate`](/docs/components/inputs/generate)"+` inputs with a cron schedule, which would otherwise consume duplicate data when running with multiple replicas. However, leader election relies on the clocks of replicas and a replica that loses contact with the API may continue to consume until its renew deadline passes, and therefore brief overlaps between leaders are possible, especially while the child input of a former leader is closing.
+
+### Authentication
+
+When `+"`kubeconfig`"+` is empty and Benthos runs within a pod the service account of the pod is used, otherwise the kubeconfig is loaded from the `+"`KUBECONFIG`"+` environment variable or `+"`~/.kube/config`"+`. The service account requires the permissions to `+"`get`, `create` and `update`"+` leases of the `+"`coordination.k8s.io`"+` API group within the namespace of the lease.`).
+		Field(kubeconfigField()).
+		Field(contextField()).
+		Field(service.NewStringField(leFieldLeaseName).
+			Description("The name of the lease to hold while consuming, which must be shared by all replicas.").
+			Example("benthos-orders-cdc")).
+		Field(service.NewStringField(leFieldLeaseNamespace).
+			Description("The namespace of the lease. When empty the namespace of the pod is used when running within a cluster, or `default` otherwise.").
+			Default("")).
+		Field(service.NewStringField(leFieldIdentity).
+			Description("A unique identity of this replica within the lease. When empty the hostname is used, which is the name of the pod when running within a cluster.").
+			Default("").
+			Advanced()).
+		Field(service.NewDurationField(leFieldLeaseDuration).
+			Description("The period after which the lease may be taken over by another replica when it has not been renewed.").
+			Default("15s").
+			Advanced()).
+		Field(service.NewDurationField(leFieldRenewDeadline).
+			Description("The period within which the leader must renew the lease before it stops consuming, which must be less than the `lease_duration`.").
+			Default("10s").
+			Advanced()).
+		Field(service.NewDurationField(leFieldRetryPeriod).
+			Description("The period between attempts to acquire or renew the lease.").
+			Default("2s").
+			Advanced()).
+		Field(service.NewInputField(leFieldInput).
+			Description("The child input to consume from while holding the lease.")).
+		Example("Singleton Poller", "Poll an API every five minutes from a single replica of a deployment at a time.", `
+input:
+  kubernetes_leader_election:
+    lease_name: benthos-orders-poller
+    input:
+      generate:
+        interval: '@every 5m'
+        mapping: root = {}
+      processors:
+        - http:
+            url: https://api.example.com/v1/orders
+            verb: GET
+`)
+}
+
+func init() {
+	err := service.RegisterBatchInput(
+		"kubernetes_leader_election", leaderElectionInputConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.BatchInput, error) {
+			return newLeaderElectionInputFromConfig(conf, mgr.Logger())
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// leadership is a child input along with a context that is cancelled once the
+// leadership it was created for has been lost.
+type leadership struct {
+	ctx   context.Context
+	input *service.OwnedInput
+}
+
+type leaderElectionInput struct {
+	kubeconfig   string
+	context      string
+	lock         *resourcelock.LeaseLock
+	elector      *leaderelection.LeaderElector
+	retryPeriod  time.Duration
+	closeTimeout time.Duration
+	newInput     func() (*service.OwnedInput, error)
+	startedChan  chan context.Context
+	log          *service.Logger
+
+	// newClient creates the client of leases, and is replaced in tests.
+	newClient func() (coordinationv1.LeasesGetter, error)
+
+	leaderMut     sync.Mutex
+	leader        *leadership
+	leaderChanged chan struct{}
+
+	connMut  sync.Mutex
+	closeFn  func()
+	loopDone chan struct{}
+}
+
+func newLeaderElectionInputFromConfig(conf *service.ParsedConfig, log *service.Logger) (*leaderElectionInput, error) {
+	l := &leaderElectionInput{
+		lock:          &resourcelock.LeaseLock{},
+		startedChan:   make(chan context.Context, 1),
+		log:           log,
+		leaderChanged: make(chan struct{}),
+	}
+	l.newClient = l.leasesClient
+
+	var err error
+	if l.kubeconfig, err = conf.FieldString(kiFieldKubeconfig); err != nil {
+		return nil, err
+	}
+	if l.context, err = conf.FieldString(kiFieldContext); err != nil {
+		return nil, err
+	}
+
+	if l.lock.LeaseMeta.Name, err = conf.FieldString(leFieldLeaseName); err != nil {
+		return nil, err
+	}
+	if l.lock.LeaseMeta.Name == "" {
+		return nil, errors.New("a lease name must be specified")
+	}
+	if l.lock.LeaseMeta.Namespace, err = conf.FieldString(leFieldLeaseNamespace); err != nil {
+		return nil, err
+	}
+	if l.lock.LeaseMeta.Namespace == "" {
+		l.lock.LeaseMeta.Namespace = podNamespace()
+	}
+	if l.lock.LockConfig.Identity, err = conf.FieldString(leFieldIdentity); err != nil {
+		return nil, err
+	}
+	if l.lock.LockConfig.Identity == "" {
+		if l.lock.LockConfig.Identity, err = os.Hostname(); err != nil {
+			return nil, err
+		}
+	}
+
+	leConf := leaderelection.LeaderElectionConfig{
+		Lock:            l.lock,
+		ReleaseOnCancel: true,
+		Name:            l.lock.LeaseMeta.Name,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(ctx context.Context) {
+				l.startedChan <- ctx
+			},
+			OnStoppedLeading: func() {},
+		},
+	}
+	if leConf.LeaseDuration, err = conf.FieldDuration(leFieldLeaseDuration); err != nil {
+		return nil, err
+	}
+	if leConf.RenewDeadline, err = conf.FieldDuration(leFieldRenewDeadline); err != nil {
+		return nil, err
+	}
+	if leConf.RetryPeriod, err = conf.FieldDuration(leFieldRetryPeriod); err != nil {
+		return nil, err
+	}
+	l.retryPeriod = leConf.RetryPeriod
+	l.closeTimeout = leConf.LeaseDuration
+
+	// The elector validates the durations, and the client of the lock is only
+	// created once the input connects.
+	if l.elector, err = leaderelection.NewLeaderElector(leConf); err != nil {
+		return nil, err
+	}
+
+	if l.newInput, err = conf.FieldInputConstructor(leFieldInput); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+func (l *leaderElectionInput) leasesClient() (coordinationv1.LeasesGetter, error) {
+	conf, err := restConfig(l.kubeconfig, l.context)
+	if err != nil {
+		return nil, err
+	}
+	client, err := k8s.NewForConfig(conf)
+	if err != nil {
+		return nil, err
+	}
+	return client.CoordinationV1(), nil
+}
+
+func (l *leaderElectionInput) Connect(ctx context.Context) error {
+	l.connMut.Lock()
+	defer l.connMut.Unlock()
+
+	if l.closeFn != nil {
+		return nil
+	}
+
+	client, err := l.newClient()
+	if err != nil {
+		return err
+	}
+	l.lock.Client = client
+
+	loopCtx, cancel := context.WithCancel(context.Background())
+	l.closeFn = cancel
+	l.loopDone = make(chan struct{})
+	go l.electLoop(loopCtx, l.loopDone)
+	return nil
+}
+
+// electLoop campaigns for the leadership of the lease until the context is
+// cancelled, consuming from a new child input each time it is acquired.
+func (l *leaderElectionInput) electLoop(ctx context.Context, done chan struct{}) {
+	defer close(done)
+
+	for {
+		runCtx, runCancel := context.WithCancel(ctx)
+		runDone := make(chan struct{})
+		go func() {
+			defer close(runDone)
+			l.elector.Run(runCtx)
+		}()
+
+		select {
+		case leaderCtx := <-l.startedChan:
+			if err := l.lead(leaderCtx); err != nil {
+				l.log.Errorf("Failed to create child input, releasing lease: %v", err)
+				runCancel()
+				<-runDone
+				select {
+				case <-time.After(l.retryPeriod):
+				case <-ctx.Done():
+				}
+			}
+		case <-runDone:
+		}
+		runCancel()
+		<-runDone
+
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// lead creates a child input and makes it available for reading until the
+// leadership is lost, at which point the child input is closed.
+func (l *leaderElectionInput) lead(ctx context.Context) error {
+	in, err := l.newInput()
+	if err != nil {
+		return err
+	}
+
+	l.log.Infof("Acquired leadership of lease %v/%v as %v", l.lock.LeaseMeta.Namespace, l.lock.LeaseMeta.Name, l.lock.Identity())
+	l.setLeader(&leadership{ctx: ctx, input: in})
+
+	<-ctx.Done()
+
+	l.setLeader(nil)
+	l.log.Infof("Lost leadership of lease %v/%v", l.lock.LeaseMeta.Namespace, l.lock.LeaseMeta.Name)
+
+	closeCtx, done := context.WithTimeout(context.Background(), l.closeTimeout)
+	defer done()
+	if err := in.Close(closeCtx); err != nil {
+		l.log.Errorf("Failed to close child input: %v", err)
+	}
+	return nil
+}
+
+func (l *leaderElectionInput) setLeader(leader *leadership) {
+	l.leaderMut.Lock()
+	l.leader = leader
+	close(l.leaderChanged)
+	l.leaderChanged = make(chan struct{})
+	l.leaderMut.Unlock()
+}
+
+func (l *leaderElectionInput) ReadBatch(ctx context.Context) (service.MessageBatch, service.AckFunc, error) {
+	for {
+		l.leaderMut.Lock()
+		leader, changed := l.leader, l.leaderChanged
+		l.leaderMut.Unlock()
+
+		if leader == nil {
+			select {
+			case <-changed:
+				continue
+			case <-ctx.Done():
+				return nil, nil, ctx.Err()
+			}
+		}
+
+		readCtx, done := context.WithCancel(ctx)
+		go func() {
+			select {
+			case <-leader.ctx.Done():
+			case <-readCtx.Done():
+			}
+			done()
+		}()
+		batch, aFn, err := leader.input.ReadBatch(readCtx)
+		done()
+
+		// When the leadership was lost during the read the child input is
+		// closed, and we wait for the leadership to be acquired again.
+		if err != nil && leader.ctx.Err() != nil && ctx.Err() == nil {
+			continue
+		}
+		return batch, aFn, err
+	}
+}
+
+func (l *leaderElectionInput) Close(ctx context.Context) error {
+	l.connMut.Lock()
+	closeFn, loopDone := l.closeFn, l.loopDone
+	l.closeFn, l.loopDone = nil, nil
+	l.connMut.Unlock()
+
+	if closeFn != nil {
+		closeFn()
+		select {
+		case <-loopDone:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
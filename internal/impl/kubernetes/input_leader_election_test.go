@@ -0,0 +1,138 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	coordinationv1 "k8s.io/client-go/kubernetes/typed/coordination/v1"
+
+	_ "github.com/benthosdev/benthos/v4/internal/impl/pure"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func testLeaderElectionInput(t *testing.T, client *fake.Clientset, identity string) *leaderElectionInput {
+	t.Helper()
+
+	conf, err := leaderElectionInputConfig().ParseYAML(fmt.Sprintf(`
+lease_name: foo
+lease_namespace: default
+identity: %v
+lease_duration: 1s
+renew_deadline: 500ms
+retry_period: 50ms
+input:
+  generate:
+    interval: 10ms
+    mapping: 'root = "%v"'
+`, identity, identity), nil)
+	require.NoError(t, err)
+
+	l, err := newLeaderElectionInputFromConfig(conf, service.MockResources().Logger())
+	require.NoError(t, err)
+
+	l.newClient = func() (coordinationv1.LeasesGetter, error) {
+		return client.CoordinationV1(), nil
+	}
+	return l
+}
+
+func TestLeaderElectionInputConfigErrors(t *testing.T) {
+	for _, test := range []struct {
+		name        string
+		config      string
+		errContains string
+	}{
+		{
+			name: "no lease name",
+			config: `
+lease_name: ""
+input:
+  generate:
+    mapping: 'root = "foo"'
+`,
+			errContains: "a lease name must be specified",
+		},
+		{
+			name: "renew deadline exceeds lease duration",
+			config: `
+lease_name: foo
+lease_duration: 5s
+renew_deadline: 10s
+input:
+  generate:
+    mapping: 'root = "foo"'
+`,
+			errContains: "leaseDuration must be greater than renewDeadline",
+		},
+	} {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			conf, err := leaderElectionInputConfig().ParseYAML(test.config, nil)
+			require.NoError(t, err)
+
+			_, err = newLeaderElectionInputFromConfig(conf, service.MockResources().Logger())
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), test.errContains)
+		})
+	}
+}
+
+func readLeaderBatch(ctx context.Context, l *leaderElectionInput) (string, error) {
+	batch, aFn, err := l.ReadBatch(ctx)
+	if err != nil {
+		return "", err
+	}
+	if err := aFn(ctx, nil); err != nil {
+		return "", err
+	}
+	b, err := batch[0].AsBytes()
+	return string(b), err
+}
+
+func TestLeaderElectionInputFailover(t *testing.T) {
+	client := fake.NewSimpleClientset()
+
+	a := testLeaderElectionInput(t, client, "a")
+	require.NoError(t, a.Connect(context.Background()))
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*5)
+	defer done()
+
+	v, err := readLeaderBatch(ctx, a)
+	require.NoError(t, err)
+	assert.Equal(t, "a", v)
+
+	b := testLeaderElectionInput(t, client, "b")
+	require.NoError(t, b.Connect(context.Background()))
+	t.Cleanup(func() {
+		_ = b.Close(context.Background())
+	})
+
+	// The second replica does not consume while the first holds the lease.
+	shortCtx, shortDone := context.WithTimeout(ctx, time.Millisecond*300)
+	_, err = readLeaderBatch(shortCtx, b)
+	shortDone()
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	v, err = readLeaderBatch(ctx, a)
+	require.NoError(t, err)
+	assert.Equal(t, "a", v)
+
+	// Closing the first replica releases the lease to the second.
+	require.NoError(t, a.Close(ctx))
+
+	v, err = readLeaderBatch(ctx, b)
+	require.NoError(t, err)
+	assert.Equal(t, "b", v)
+
+	lease, err := client.CoordinationV1().Leases("default").Get(ctx, "foo", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.NotNil(t, lease.Spec.HolderIdentity)
+	assert.Equal(t, "b", *lease.Spec.HolderIdentity)
+}
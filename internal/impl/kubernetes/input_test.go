@@ -0,0 +1,218 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+var podsGVR = schema.GroupVersionResource{Version: "v1", Resource: "pods"}
+
+func testPod(name, resourceVersion string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion("v1")
+	obj.SetKind("Pod")
+	obj.SetNamespace("default")
+	obj.SetName(name)
+	obj.SetResourceVersion(resourceVersion)
+	return obj
+}
+
+func TestKubernetesObjectCacheReplace(t *testing.T) {
+	c := &objectCache{objects: map[string]*unstructured.Unstructured{}}
+	c.replace([]unstructured.Unstructured{*testPod("a", "1"), *testPod("b", "2")}, nil)
+	assert.Len(t, c.objects, 2)
+
+	type change struct {
+		eventType watch.EventType
+		name      string
+		oldRV     string
+	}
+	var changes []change
+	require.True(t, c.replace([]unstructured.Unstructured{
+		*testPod("a", "1"),
+		*testPod("c", "3"),
+		*testPod("b", "4"),
+	}, func(eventType watch.EventType, obj, old *unstructured.Unstructured) bool {
+		ch := change{eventType: eventType, name: obj.GetName()}
+		if old != nil {
+			ch.oldRV = old.GetResourceVersion()
+		}
+		changes = append(changes, ch)
+		return true
+	}))
+	assert.Equal(t, []change{
+		{eventType: watch.Added, name: "c"},
+		{eventType: watch.Modified, name: "b", oldRV: "2"},
+	}, changes)
+
+	changes = nil
+	require.True(t, c.replace([]unstructured.Unstructured{*testPod("c", "3")}, func(eventType watch.EventType, obj, old *unstructured.Unstructured) bool {
+		changes = append(changes, change{eventType: eventType, name: obj.GetName()})
+		return true
+	}))
+	assert.ElementsMatch(t, []change{
+		{eventType: watch.Deleted, name: "a"},
+		{eventType: watch.Deleted, name: "b"},
+	}, changes)
+	assert.Len(t, c.objects, 1)
+}
+
+func testInput(t *testing.T, confStr string, res *service.Resources, client *fake.FakeDynamicClient) *kubernetesInput {
+	t.Helper()
+
+	conf, err := inputConfig().ParseYAML(confStr, nil)
+	require.NoError(t, err)
+
+	k, err := newInputFromConfig(conf, res)
+	require.NoError(t, err)
+
+	k.newTargets = func(ctx context.Context) ([]*watchTarget, error) {
+		return []*watchTarget{
+			k.newTarget(client.Resource(podsGVR).Namespace("default"), podsGVR, "default"),
+		}, nil
+	}
+	return k
+}
+
+func readMessage(t *testing.T, k *kubernetesInput) (map[string]interface{}, *service.Message, service.AckFunc) {
+	t.Helper()
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*5)
+	defer done()
+
+	msg, ackFn, err := k.Read(ctx)
+	require.NoError(t, err)
+
+	v, err := msg.AsStructured()
+	require.NoError(t, err)
+	return v.(map[string]interface{}), msg, ackFn
+}
+
+func newFakeClient(watcher *watch.FakeWatcher, objs ...runtime.Object) *fake.FakeDynamicClient {
+	client := fake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		podsGVR: "PodList",
+	}, objs...)
+	client.PrependWatchReactor("pods", func(action k8stesting.Action) (bool, watch.Interface, error) {
+		return true, watcher, nil
+	})
+	return client
+}
+
+func TestKubernetesInputWatch(t *testing.T) {
+	watcher := watch.NewFake()
+	client := newFakeClient(watcher, testPod("a", "1"))
+
+	k := testInput(t, `
+resources:
+  - kind: Pod
+`, service.MockResources(), client)
+	require.NoError(t, k.Connect(context.Background()))
+	t.Cleanup(func() {
+		_ = k.Close(context.Background())
+	})
+
+	v, msg, _ := readMessage(t, k)
+	assert.Equal(t, "ADDED", v["type"])
+	assert.NotContains(t, v, "old_object")
+	for k, exp := range map[string]string{
+		"kubernetes_event_type":       "ADDED",
+		"kubernetes_kind":             "Pod",
+		"kubernetes_namespace":        "default",
+		"kubernetes_name":             "a",
+		"kubernetes_resource_version": "1",
+	} {
+		act, _ := msg.MetaGet(k)
+		assert.Equal(t, exp, act, k)
+	}
+
+	watcher.Add(testPod("b", "2"))
+	v, _, _ = readMessage(t, k)
+	assert.Equal(t, "ADDED", v["type"])
+	assert.Equal(t, "b", v["object"].(map[string]interface{})["metadata"].(map[string]interface{})["name"])
+
+	watcher.Modify(testPod("a", "3"))
+	v, _, _ = readMessage(t, k)
+	assert.Equal(t, "MODIFIED", v["type"])
+	assert.Equal(t, "3", v["object"].(map[string]interface{})["metadata"].(map[string]interface{})["resourceVersion"])
+	assert.Equal(t, "1", v["old_object"].(map[string]interface{})["metadata"].(map[string]interface{})["resourceVersion"])
+
+	watcher.Delete(testPod("b", "4"))
+	v, _, _ = readMessage(t, k)
+	assert.Equal(t, "DELETED", v["type"])
+	assert.NotContains(t, v, "old_object")
+}
+
+func TestKubernetesInputExcludeExisting(t *testing.T) {
+	watcher := watch.NewFake()
+	client := newFakeClient(watcher, testPod("a", "1"))
+
+	k := testInput(t, `
+resources:
+  - kind: Pod
+include_existing: false
+`, service.MockResources(), client)
+	require.NoError(t, k.Connect(context.Background()))
+	t.Cleanup(func() {
+		_ = k.Close(context.Background())
+	})
+
+	watcher.Modify(testPod("a", "2"))
+	v, _, _ := readMessage(t, k)
+	assert.Equal(t, "MODIFIED", v["type"])
+	assert.Equal(t, "1", v["old_object"].(map[string]interface{})["metadata"].(map[string]interface{})["resourceVersion"])
+}
+
+func TestKubernetesInputCheckpoint(t *testing.T) {
+	res := service.MockResources(service.MockResourcesOptAddCache("checkpoints"))
+	conf := `
+resources:
+  - kind: Pod
+checkpoint:
+  cache: checkpoints
+`
+
+	watcher := watch.NewFake()
+	k := testInput(t, conf, res, newFakeClient(watcher))
+	require.NoError(t, k.Connect(context.Background()))
+
+	watcher.Add(testPod("a", "5"))
+	_, _, ackFn := readMessage(t, k)
+	require.NoError(t, ackFn(context.Background(), nil))
+	require.NoError(t, k.Close(context.Background()))
+
+	// The input resumes watching from the stored resource version rather than
+	// listing the pods again.
+	watcher = watch.NewFake()
+	client := newFakeClient(watcher, testPod("a", "5"))
+	k = testInput(t, conf, res, client)
+	require.NoError(t, k.Connect(context.Background()))
+	t.Cleanup(func() {
+		_ = k.Close(context.Background())
+	})
+
+	watcher.Modify(testPod("a", "6"))
+	v, _, _ := readMessage(t, k)
+	assert.Equal(t, "MODIFIED", v["type"])
+	assert.NotContains(t, v, "old_object")
+
+	var verbs []string
+	for _, action := range client.Actions() {
+		verbs = append(verbs, action.GetVerb())
+		if wa, ok := action.(k8stesting.WatchAction); ok {
+			assert.Equal(t, "5", wa.GetWatchRestrictions().ResourceVersion)
+		}
+	}
+	assert.Equal(t, []string{"watch"}, verbs)
+}
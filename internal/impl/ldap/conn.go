@@ -0,0 +1,152 @@
+package ldap
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"net"
+	"net/url"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// structured returns an entry as an object containing the field dn and a field
+// for each attribute with an array of its values, where values that are not
+// valid UTF-8 are base64 encoded.
+func structured(e *ldap.Entry) map[string]interface{} {
+	obj := make(map[string]interface{}, len(e.Attributes)+1)
+	for _, attr := range e.Attributes {
+		vs := make([]interface{}, len(attr.ByteValues))
+		for i, v := range attr.ByteValues {
+			if utf8.Valid(v) {
+				vs[i] = string(v)
+			} else {
+				vs[i] = base64.StdEncoding.EncodeToString(v)
+			}
+		}
+		obj[attr.Name] = vs
+	}
+	obj["dn"] = e.DN
+	return obj
+}
+
+// dialConn connects to a server by URL, where ldaps URLs are connected to
+// with TLS and ldap URLs are optionally upgraded with StartTLS.
+func dialConn(u *url.URL, tlsConf *tls.Config, startTLS bool, timeout time.Duration) (*ldap.Conn, error) {
+	if tlsConf == nil {
+		tlsConf = &tls.Config{}
+	}
+	if tlsConf.ServerName == "" && !tlsConf.InsecureSkipVerify {
+		tlsConf = tlsConf.Clone()
+		tlsConf.ServerName = u.Hostname()
+	}
+
+	c, err := ldap.DialURL(u.String(),
+		ldap.DialWithDialer(&net.Dialer{Timeout: timeout}),
+		ldap.DialWithTLSConfig(tlsConf))
+	if err != nil {
+		return nil, err
+	}
+	c.SetTimeout(timeout)
+
+	if startTLS && u.Scheme != "ldaps" {
+		if err := c.StartTLS(tlsConf); err != nil {
+			c.Close()
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// bind authenticates a connection with a simple bind, which is anonymous when
+// the DN is empty.
+func bind(c *ldap.Conn, dn, password string) error {
+	_, err := c.SimpleBind(&ldap.SimpleBindRequest{
+		Username:           dn,
+		Password:           password,
+		AllowEmptyPassword: dn == "",
+	})
+	return err
+}
+
+// search returns the entries matching a search. Searches of base DNs that do
+// not exist return no entries, and searches that exceed the size limit return
+// the entries received up to the limit.
+func search(c *ldap.Conn, req *ldap.SearchRequest) ([]*ldap.Entry, error) {
+	res, err := c.Search(req)
+	switch {
+	case err == nil:
+		return res.Entries, nil
+	case ldap.IsErrorWithCode(err, ldap.LDAPResultSizeLimitExceeded) && res != nil:
+		return res.Entries, nil
+	case ldap.IsErrorWithCode(err, ldap.LDAPResultNoSuchObject):
+		return nil, nil
+	}
+	return nil, err
+}
+
+//------------------------------------------------------------------------------
+
+// connPool lends bound connections, opening at most a fixed number of them.
+type connPool struct {
+	open  func(ctx context.Context) (*ldap.Conn, error)
+	idle  chan *ldap.Conn
+	slots chan struct{}
+}
+
+func newConnPool(size int, open func(ctx context.Context) (*ldap.Conn, error)) *connPool {
+	return &connPool{
+		open:  open,
+		idle:  make(chan *ldap.Conn, size),
+		slots: make(chan struct{}, size),
+	}
+}
+
+func (p *connPool) get(ctx context.Context) (*ldap.Conn, error) {
+	select {
+	case c := <-p.idle:
+		return c, nil
+	default:
+	}
+
+	select {
+	case c := <-p.idle:
+		return c, nil
+	case p.slots <- struct{}{}:
+		c, err := p.open(ctx)
+		if err != nil {
+			<-p.slots
+			return nil, err
+		}
+		return c, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// put returns a connection to the pool, closing it instead when it is closing
+// or an error other than a result of an operation occurred while using it.
+func (p *connPool) put(c *ldap.Conn, err error) {
+	var resErr *ldap.Error
+	if c.IsClosing() || (err != nil && (!errors.As(err, &resErr) || resErr.ResultCode >= ldap.ErrorNetwork)) {
+		c.Close()
+		<-p.slots
+		return
+	}
+	p.idle <- c
+}
+
+func (p *connPool) close() {
+	for {
+		select {
+		case c := <-p.idle:
+			c.Close()
+			<-p.slots
+		default:
+			return
+		}
+	}
+}
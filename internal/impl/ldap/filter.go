@@ -0,0 +1,44 @@
+package ldap
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// normaliseFilter validates a search filter in its string representation, as
+// per RFC 4515, and returns it with outer parentheses, which are commonly
+// omitted.
+func normaliseFilter(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "(") {
+		s = "(" + s + ")"
+	}
+	if _, err := ldap.CompileFilter(s); err != nil {
+		return "", fmt.Errorf("invalid filter: %w", err)
+	}
+	return s, nil
+}
+
+// bindFilterArgs replaces each ? placeholder of a filter with an escaped
+// argument.
+func bindFilterArgs(filter string, args []interface{}) (string, error) {
+	var b strings.Builder
+	n := 0
+	for i := 0; i < len(filter); i++ {
+		if filter[i] != '?' {
+			b.WriteByte(filter[i])
+			continue
+		}
+		if n >= len(args) {
+			return "", fmt.Errorf("filter contains more placeholders than the %v arguments provided", len(args))
+		}
+		b.WriteString(ldap.EscapeFilter(fmt.Sprintf("%v", args[n])))
+		n++
+	}
+	if n != len(args) {
+		return "", fmt.Errorf("filter contains %v placeholders but %v arguments were provided", n, len(args))
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,47 @@
+package ldap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormaliseFilter(t *testing.T) {
+	for input, exp := range map[string]string{
+		"(cn=foo)":                        "(cn=foo)",
+		" cn=foo ":                        "(cn=foo)",
+		`(cn=a\2ab\28\29\5c)`:             `(cn=a\2ab\28\29\5c)`,
+		"(&(uid>=5)(uid<=9)(!(cn~=bar)))": "(&(uid>=5)(uid<=9)(!(cn~=bar)))",
+	} {
+		f, err := normaliseFilter(input)
+		require.NoError(t, err, input)
+		assert.Equal(t, exp, f, input)
+	}
+
+	for _, filter := range []string{
+		"",
+		"(cn=foo",
+		"(cn=foo))",
+		"(&(cn=foo)",
+		`(cn=\zz)`,
+	} {
+		_, err := normaliseFilter(filter)
+		assert.Error(t, err, filter)
+	}
+}
+
+func TestBindFilterArgs(t *testing.T) {
+	f, err := bindFilterArgs("(&(uid=?)(uidNumber=?))", []interface{}{"*)(cn=*", 10})
+	require.NoError(t, err)
+	assert.Equal(t, `(&(uid=\2a\29\28cn=\2a)(uidNumber=10))`, f)
+
+	_, err = normaliseFilter(f)
+	require.NoError(t, err)
+
+	_, err = bindFilterArgs("(uid=?)", nil)
+	assert.EqualError(t, err, "filter contains more placeholders than the 0 arguments provided")
+
+	_, err = bindFilterArgs("(uid=?)", []interface{}{"a", "b"})
+	assert.EqualError(t, err, "filter contains 1 placeholders but 2 arguments were provided")
+}
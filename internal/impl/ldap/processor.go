@@ -0,0 +1,389 @@
+package ldap
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+
+	"github.com/benthosdev/benthos/v4/public/bloblang"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	lpFieldURL            = "url"
+	lpFieldStartTLS       = "start_tls"
+	lpFieldTLS            = "tls"
+	lpFieldBindDN         = "bind_dn"
+	lpFieldBindPassword   = "bind_password"
+	lpFieldBaseDN         = "base_dn"
+	lpFieldScope          = "scope"
+	lpFieldFilter         = "filter"
+	lpFieldArgsMapping    = "args_mapping"
+	lpFieldAttributes     = "attributes"
+	lpFieldSizeLimit      = "size_limit"
+	lpFieldTimeout        = "timeout"
+	lpFieldMaxConnections = "max_connections"
+	lpFieldCache          = "cache"
+	lpFieldCacheTTL       = "cache_ttl"
+)
+
+var searchScopes = map[string]int{
+	"base": ldap.ScopeBaseObject,
+	"one":  ldap.ScopeSingleLevel,
+	"sub":  ldap.ScopeWholeSubtree,
+}
+
+func processorConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		// Stable(). TODO
+		Categories("Integration").
+		Summary("Searches an LDAP directory, such as Active Directory or OpenLDAP, and returns the result as an array of objects, one for each entry found.").
+		Description(`
+Each entry is an object containing the field `+"`dn`"+` and a field for each attribute returned with an array of its values. Values that are not valid UTF-8, such as the `+"`objectGUID`"+` attribute of Active Directory, are base64 encoded. Searches of a base DN that does not exist return an empty array.
+
+Placeholder arguments of the filter are populated with the `+"`args_mapping`"+` field, and are escaped so that values of messages cannot alter the structure of the filter. Therefore values of messages should always be added to the filter with placeholders rather than interpolation.
+
+Connections are bound with the configured credentials, or anonymously when `+"`bind_dn`"+` is empty, and are reused across searches. When a `+"`cache`"+` is configured the results of searches are stored within it, keyed by the base DN, scope, filter and attributes, and searches with a cached result are not sent to the server.
+
+If the search fails the message will remain unchanged and the error can be caught using error handling methods outlined [here](/docs/configuration/error_handling).`).
+		Field(service.NewStringField(lpFieldURL).
+			Description("The URL of the server, where the scheme `ldaps` connects with TLS.").
+			Example("ldap://localhost:389").
+			Example("ldaps://dc01.example.com")).
+		Field(service.NewBoolField(lpFieldStartTLS).
+			Description("Whether to upgrade connections of `ldap` URLs to TLS with StartTLS.").
+			Default(false)).
+		Field(service.NewTLSField(lpFieldTLS).
+			Description("TLS settings used by `ldaps` URLs and StartTLS.")).
+		Field(service.NewStringField(lpFieldBindDN).
+			Description("The DN to bind connections with. Connections are bound anonymously when empty.").
+			Example("cn=benthos,ou=services,dc=example,dc=com").
+			Default("")).
+		Field(service.NewStringField(lpFieldBindPassword).
+			Description("The password to bind connections with.").
+			Default("")).
+		Field(service.NewInterpolatedStringField(lpFieldBaseDN).
+			Description("The DN to search from.").
+			Example("ou=users,dc=example,dc=com")).
+		Field(service.NewStringEnumField(lpFieldScope, "base", "one", "sub").
+			Description("The scope of the search, either only the base DN itself, its direct children, or its entire subtree.").
+			Default("sub")).
+		Field(service.NewStringField(lpFieldFilter).
+			Description("The search filter, as per RFC 4515. Placeholder arguments are populated with the `args_mapping` field, and should always be question marks.").
+			Example("(&(objectClass=user)(sAMAccountName=?))").
+			Example("(|(mail=?)(proxyAddresses=smtp:?))").
+			Default("(objectClass=*)")).
+		Field(service.NewBloblangField(lpFieldArgsMapping).
+			Description("An optional [Bloblang mapping](/docs/guides/bloblang/about) which should evaluate to an array of values matching in size to the number of placeholder arguments in the field `filter`.").
+			Example("root = [ this.user.name ]").
+			Optional()).
+		Field(service.NewStringListField(lpFieldAttributes).
+			Description("A list of attributes to return for each entry. All user attributes are returned when empty.").
+			Example([]string{"cn", "mail", "memberOf"}).
+			Default([]string{})).
+		Field(service.NewIntField(lpFieldSizeLimit).
+			Description("The maximum number of entries to return from each search, where zero means no limit. Searches that exceed the limit return the entries received up to it.").
+			Default(0)).
+		Field(service.NewDurationField(lpFieldTimeout).
+			Description("The maximum period to wait for a server to connect or respond to an operation.").
+			Advanced().
+			Default("10s")).
+		Field(service.NewIntField(lpFieldMaxConnections).
+			Description("The maximum number of connections to open to the server, where searches wait for a connection to become available once the limit is reached.").
+			Advanced().
+			Default(4)).
+		Field(service.NewStringField(lpFieldCache).
+			Description("An optional [cache resource](/docs/components/caches/about) to store the results of searches within.").
+			Optional()).
+		Field(service.NewStringField(lpFieldCacheTTL).
+			Description("An optional TTL of cached results, which otherwise expire according to the default TTL of the cache.").
+			Example("10m").
+			Optional().
+			Advanced()).
+		Example("Identity Enrichment", `
+Here we look up the user of each audit event within Active Directory, caching results for ten minutes. A `+"[`branch` processor](/docs/components/processors/branch)"+` is used in order to add the display name and groups of the user to the original message:`,
+			`
+pipeline:
+  processors:
+    - branch:
+        processors:
+          - ldap:
+              url: ldaps://dc01.example.com
+              bind_dn: cn=benthos,ou=services,dc=example,dc=com
+              bind_password: ${LDAP_PASSWORD}
+              base_dn: ou=users,dc=example,dc=com
+              filter: (&(objectClass=user)(sAMAccountName=?))
+              args_mapping: 'root = [ this.user ]'
+              attributes: [ displayName, memberOf ]
+              size_limit: 1
+              cache: ldap_cache
+              cache_ttl: 10m
+        result_map: |
+          root.user_name = this.index(0).displayName.index(0)
+          root.user_groups = this.index(0).memberOf
+
+cache_resources:
+  - label: ldap_cache
+    memory: {}
+`)
+}
+
+func init() {
+	err := service.RegisterBatchProcessor(
+		"ldap", processorConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.BatchProcessor, error) {
+			return newProcessorFromConfig(conf, mgr)
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type processor struct {
+	baseDN      *service.InterpolatedString
+	scope       int
+	filter      string
+	argsMapping *bloblang.Executor
+	attributes  []string
+	sizeLimit   int
+	timeLimit   int
+	cache       string
+	cacheTTL    *time.Duration
+
+	pool *connPool
+	log  *service.Logger
+	res  *service.Resources
+}
+
+func newProcessorFromConfig(conf *service.ParsedConfig, res *service.Resources) (*processor, error) {
+	p := &processor{log: res.Logger(), res: res}
+
+	urlStr, err := conf.FieldString(lpFieldURL)
+	if err != nil {
+		return nil, err
+	}
+	u, err := url.Parse(urlStr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse url: %w", err)
+	}
+	if u.Scheme != "ldap" && u.Scheme != "ldaps" {
+		return nil, fmt.Errorf("url scheme %v not recognised, expected ldap or ldaps", u.Scheme)
+	}
+
+	startTLS, err := conf.FieldBool(lpFieldStartTLS)
+	if err != nil {
+		return nil, err
+	}
+	var tlsConf *tls.Config
+	if u.Scheme == "ldaps" || startTLS {
+		if tlsConf, err = conf.FieldTLS(lpFieldTLS); err != nil {
+			return nil, err
+		}
+	}
+
+	bindDN, err := conf.FieldString(lpFieldBindDN)
+	if err != nil {
+		return nil, err
+	}
+	bindPassword, err := conf.FieldString(lpFieldBindPassword)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.baseDN, err = conf.FieldInterpolatedString(lpFieldBaseDN); err != nil {
+		return nil, err
+	}
+
+	scope, err := conf.FieldString(lpFieldScope)
+	if err != nil {
+		return nil, err
+	}
+	var exists bool
+	if p.scope, exists = searchScopes[scope]; !exists {
+		return nil, fmt.Errorf("scope %v not recognised", scope)
+	}
+
+	if p.filter, err = conf.FieldString(lpFieldFilter); err != nil {
+		return nil, err
+	}
+	if conf.Contains(lpFieldArgsMapping) {
+		if p.argsMapping, err = conf.FieldBloblang(lpFieldArgsMapping); err != nil {
+			return nil, err
+		}
+	} else if _, err := normaliseFilter(p.filter); err != nil {
+		return nil, err
+	}
+
+	if p.attributes, err = conf.FieldStringList(lpFieldAttributes); err != nil {
+		return nil, err
+	}
+	if p.sizeLimit, err = conf.FieldInt(lpFieldSizeLimit); err != nil {
+		return nil, err
+	}
+
+	timeout, err := conf.FieldDuration(lpFieldTimeout)
+	if err != nil {
+		return nil, err
+	}
+	maxConns, err := conf.FieldInt(lpFieldMaxConnections)
+	if err != nil {
+		return nil, err
+	}
+	if maxConns < 1 {
+		return nil, errors.New("max_connections must be at least 1")
+	}
+	p.timeLimit = int(timeout / time.Second)
+
+	if conf.Contains(lpFieldCache) {
+		if p.cache, err = conf.FieldString(lpFieldCache); err != nil {
+			return nil, err
+		}
+		if !res.HasCache(p.cache) {
+			return nil, fmt.Errorf("cache resource '%v' was not found", p.cache)
+		}
+	}
+	if conf.Contains(lpFieldCacheTTL) {
+		ttlStr, err := conf.FieldString(lpFieldCacheTTL)
+		if err != nil {
+			return nil, err
+		}
+		ttl, err := time.ParseDuration(ttlStr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse cache_ttl: %w", err)
+		}
+		p.cacheTTL = &ttl
+	}
+
+	p.pool = newConnPool(maxConns, func(ctx context.Context) (*ldap.Conn, error) {
+		c, err := dialConn(u, tlsConf, startTLS, timeout)
+		if err != nil {
+			return nil, err
+		}
+		if err := bind(c, bindDN, bindPassword); err != nil {
+			c.Close()
+			return nil, fmt.Errorf("failed to bind: %w", err)
+		}
+		return c, nil
+	})
+	return p, nil
+}
+
+func (p *processor) cacheKey(req *ldap.SearchRequest) string {
+	return strings.Join([]string{req.BaseDN, fmt.Sprint(req.Scope), req.Filter, strings.Join(req.Attributes, ",")}, "\x00")
+}
+
+func (p *processor) search(ctx context.Context, req *ldap.SearchRequest) (interface{}, error) {
+	var key string
+	if p.cache != "" {
+		key = p.cacheKey(req)
+
+		var cached []byte
+		if err := p.res.AccessCache(ctx, p.cache, func(c service.Cache) {
+			cached, _ = c.Get(ctx, key)
+		}); err != nil {
+			p.log.Debugf("Failed to access cache: %v", err)
+		}
+		if cached != nil {
+			var result []interface{}
+			if err := json.Unmarshal(cached, &result); err == nil {
+				return result, nil
+			}
+		}
+	}
+
+	c, err := p.pool.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := search(c, req)
+	p.pool.put(c, err)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]interface{}, len(entries))
+	for i, e := range entries {
+		result[i] = structured(e)
+	}
+
+	if p.cache != "" {
+		if b, err := json.Marshal(result); err == nil {
+			if err := p.res.AccessCache(ctx, p.cache, func(c service.Cache) {
+				if err := c.Set(ctx, key, b, p.cacheTTL); err != nil {
+					p.log.Debugf("Failed to cache result: %v", err)
+				}
+			}); err != nil {
+				p.log.Debugf("Failed to access cache: %v", err)
+			}
+		}
+	}
+	return result, nil
+}
+
+func (p *processor) ProcessBatch(ctx context.Context, batch service.MessageBatch) ([]service.MessageBatch, error) {
+	batch = batch.Copy()
+
+	for i, msg := range batch {
+		var args []interface{}
+		if p.argsMapping != nil {
+			resMsg, err := batch.BloblangQuery(i, p.argsMapping)
+			if err != nil {
+				p.log.Debugf("Arguments mapping failed: %v", err)
+				msg.SetError(err)
+				continue
+			}
+
+			iargs, err := resMsg.AsStructured()
+			if err != nil {
+				p.log.Debugf("Mapping returned non-structured result: %v", err)
+				msg.SetError(fmt.Errorf("mapping returned non-structured result: %w", err))
+				continue
+			}
+
+			var ok bool
+			if args, ok = iargs.([]interface{}); !ok {
+				p.log.Debugf("Mapping returned non-array result: %T", iargs)
+				msg.SetError(fmt.Errorf("mapping returned non-array result: %T", iargs))
+				continue
+			}
+		}
+
+		filter, err := bindFilterArgs(p.filter, args)
+		if err != nil {
+			msg.SetError(err)
+			continue
+		}
+
+		if filter, err = normaliseFilter(filter); err != nil {
+			msg.SetError(err)
+			continue
+		}
+
+		req := ldap.NewSearchRequest(
+			batch.InterpolatedString(i, p.baseDN), p.scope, ldap.NeverDerefAliases,
+			p.sizeLimit, p.timeLimit, false, filter, p.attributes, nil,
+		)
+		result, err := p.search(ctx, req)
+		if err != nil {
+			p.log.Debugf("Failed to search: %v", err)
+			msg.SetError(err)
+			continue
+		}
+		msg.SetStructured(result)
+	}
+	return []service.MessageBatch{batch}, nil
+}
+
+func (p *processor) Close(ctx context.Context) error {
+	p.pool.close()
+	return nil
+}
@@ -0,0 +1,314 @@
+package ldap
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+	"github.com/go-ldap/ldap/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+// testServer is a directory of users keyed by uid, which responds to
+// searches with an equality filter on the uid attribute.
+type testServer struct {
+	mut      sync.Mutex
+	conns    int
+	binds    []string
+	searches []string
+	users    map[string]map[string][]string
+}
+
+func (s *testServer) result(op ber.Tag, code int64, msg string) *ber.Packet {
+	p := ber.Encode(ber.ClassApplication, ber.TypeConstructed, op, nil, "")
+	p.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, code, ""))
+	p.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", ""))
+	p.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, msg, ""))
+	return p
+}
+
+func (s *testServer) search(op *ber.Packet) []*ber.Packet {
+	baseDN := string(op.Children[0].ByteValue)
+	if baseDN != "ou=users,dc=example,dc=com" {
+		return []*ber.Packet{s.result(ldap.ApplicationSearchResultDone, ldap.LDAPResultNoSuchObject, "")}
+	}
+	sizeLimit, _ := ber.ParseInt64(op.Children[3].ByteValue)
+	uid := op.Children[6].Children[1].Data.String()
+
+	s.mut.Lock()
+	s.searches = append(s.searches, uid)
+	user, exists := s.users[uid]
+	s.mut.Unlock()
+
+	var res []*ber.Packet
+	if exists {
+		for i := 0; i < 2; i++ {
+			if sizeLimit > 0 && int64(i) >= sizeLimit {
+				return append(res, s.result(ldap.ApplicationSearchResultDone, ldap.LDAPResultSizeLimitExceeded, ""))
+			}
+			dn := "uid=" + uid + ",ou=users,dc=example,dc=com"
+			if i > 0 {
+				dn = "uid=" + uid + ",ou=archive,dc=example,dc=com"
+			}
+
+			attrs := ber.NewSequence("")
+			for _, a := range op.Children[7].Children {
+				name := string(a.ByteValue)
+				if len(user[name]) == 0 {
+					continue
+				}
+				values := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "")
+				for _, v := range user[name] {
+					values.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, v, ""))
+				}
+				attr := ber.NewSequence("")
+				attr.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, name, ""))
+				attr.AppendChild(values)
+				attrs.AppendChild(attr)
+			}
+
+			e := ber.Encode(ber.ClassApplication, ber.TypeConstructed, ldap.ApplicationSearchResultEntry, nil, "")
+			e.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, dn, ""))
+			e.AppendChild(attrs)
+			res = append(res, e)
+		}
+	}
+	return append(res, s.result(ldap.ApplicationSearchResultDone, ldap.LDAPResultSuccess, ""))
+}
+
+func (s *testServer) serve(nc net.Conn) {
+	defer nc.Close()
+	for {
+		msg, err := ber.ReadPacket(nc)
+		if err != nil || len(msg.Children) < 2 {
+			return
+		}
+		id, _ := ber.ParseInt64(msg.Children[0].ByteValue)
+
+		var res []*ber.Packet
+		switch op := msg.Children[1]; op.Tag {
+		case ldap.ApplicationBindRequest:
+			dn, password := string(op.Children[1].ByteValue), op.Children[2].Data.String()
+			s.mut.Lock()
+			s.binds = append(s.binds, dn)
+			s.mut.Unlock()
+			if dn == "cn=admin" && password != "secret" {
+				res = []*ber.Packet{s.result(ldap.ApplicationBindResponse, ldap.LDAPResultInvalidCredentials, "bad password")}
+			} else {
+				res = []*ber.Packet{s.result(ldap.ApplicationBindResponse, ldap.LDAPResultSuccess, "")}
+			}
+		case ldap.ApplicationSearchRequest:
+			res = s.search(op)
+		case ldap.ApplicationUnbindRequest:
+			return
+		}
+		for _, op := range res {
+			p := ber.NewSequence("")
+			p.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, id, ""))
+			p.AppendChild(op)
+			if _, err := nc.Write(p.Bytes()); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func newTestServer(t *testing.T) (*testServer, string) {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = ln.Close()
+	})
+
+	s := &testServer{users: map[string]map[string][]string{
+		"alice": {"cn": {"Alice"}, "memberOf": {"cn=admins", "cn=users"}, "objectGUID": {"\xff\xfe"}},
+		"bob":   {"cn": {"Bob"}, "memberOf": {"cn=users"}},
+	}}
+	go func() {
+		for {
+			nc, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			s.mut.Lock()
+			s.conns++
+			s.mut.Unlock()
+			go s.serve(nc)
+		}
+	}()
+	return s, "ldap://" + ln.Addr().String()
+}
+
+func newTestProcessor(t *testing.T, res *service.Resources, conf string) *processor {
+	t.Helper()
+
+	parsed, err := processorConfig().ParseYAML(conf, nil)
+	require.NoError(t, err)
+
+	p, err := newProcessorFromConfig(parsed, res)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = p.Close(context.Background())
+	})
+	return p
+}
+
+func TestProcessorSearch(t *testing.T) {
+	s, u := newTestServer(t)
+
+	p := newTestProcessor(t, service.MockResources(), `
+url: `+u+`
+bind_dn: cn=admin
+bind_password: secret
+base_dn: ${! meta("ou") }
+filter: (uid=?)
+args_mapping: root = [ this.user ]
+attributes: [ cn, memberOf, objectGUID ]
+size_limit: 1
+max_connections: 1
+`)
+
+	batch := service.MessageBatch{
+		service.NewMessage([]byte(`{"user":"alice"}`)),
+		service.NewMessage([]byte(`{"user":"bob"}`)),
+		service.NewMessage([]byte(`{"user":"carol"}`)),
+		service.NewMessage([]byte(`{"user":"alice"}`)),
+		service.NewMessage([]byte(`not json`)),
+	}
+	for i, ou := range []string{"users", "users", "users", "missing", "users"} {
+		batch[i].MetaSet("ou", "ou="+ou+",dc=example,dc=com")
+	}
+
+	res, err := p.ProcessBatch(context.Background(), batch)
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	require.Len(t, res[0], 5)
+
+	for i, exp := range []interface{}{
+		[]interface{}{map[string]interface{}{
+			"dn":         "uid=alice,ou=users,dc=example,dc=com",
+			"cn":         []interface{}{"Alice"},
+			"memberOf":   []interface{}{"cn=admins", "cn=users"},
+			"objectGUID": []interface{}{"//4="},
+		}},
+		[]interface{}{map[string]interface{}{
+			"dn":       "uid=bob,ou=users,dc=example,dc=com",
+			"cn":       []interface{}{"Bob"},
+			"memberOf": []interface{}{"cn=users"},
+		}},
+		[]interface{}{},
+		[]interface{}{},
+	} {
+		require.NoError(t, res[0][i].GetError(), i)
+		v, err := res[0][i].AsStructured()
+		require.NoError(t, err, i)
+		assert.Equal(t, exp, v, i)
+	}
+
+	assert.Error(t, res[0][4].GetError())
+	b, err := res[0][4].AsBytes()
+	require.NoError(t, err)
+	assert.Equal(t, `not json`, string(b))
+
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	assert.Equal(t, 1, s.conns)
+	assert.Equal(t, []string{"cn=admin"}, s.binds)
+	assert.Equal(t, []string{"alice", "bob", "carol"}, s.searches)
+}
+
+func TestProcessorCache(t *testing.T) {
+	s, u := newTestServer(t)
+
+	p := newTestProcessor(t, service.MockResources(service.MockResourcesOptAddCache("foo")), `
+url: `+u+`
+base_dn: ou=users,dc=example,dc=com
+filter: (uid=?)
+args_mapping: root = [ this.user ]
+attributes: [ cn ]
+cache: foo
+cache_ttl: 1m
+`)
+
+	for i := 0; i < 3; i++ {
+		res, err := p.ProcessBatch(context.Background(), service.MessageBatch{
+			service.NewMessage([]byte(`{"user":"bob"}`)),
+		})
+		require.NoError(t, err)
+		require.NoError(t, res[0][0].GetError())
+
+		v, err := res[0][0].AsStructured()
+		require.NoError(t, err)
+		assert.Equal(t, []interface{}{
+			map[string]interface{}{"dn": "uid=bob,ou=users,dc=example,dc=com", "cn": []interface{}{"Bob"}},
+			map[string]interface{}{"dn": "uid=bob,ou=archive,dc=example,dc=com", "cn": []interface{}{"Bob"}},
+		}, v)
+	}
+
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	assert.Equal(t, []string{"bob"}, s.searches)
+	assert.Equal(t, []string{""}, s.binds)
+}
+
+func TestProcessorBindFailure(t *testing.T) {
+	s, u := newTestServer(t)
+
+	p := newTestProcessor(t, service.MockResources(), `
+url: `+u+`
+bind_dn: cn=admin
+bind_password: nope
+base_dn: ou=users,dc=example,dc=com
+filter: (uid=alice)
+max_connections: 1
+`)
+
+	for i := 0; i < 2; i++ {
+		res, err := p.ProcessBatch(context.Background(), service.MessageBatch{
+			service.NewMessage([]byte(`hello`)),
+		})
+		require.NoError(t, err)
+		assert.EqualError(t, res[0][0].GetError(), `failed to bind: LDAP Result Code 49 "Invalid Credentials": bad password`)
+	}
+
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	assert.Equal(t, 2, s.conns)
+	assert.Empty(t, s.searches)
+}
+
+func TestProcessorConfigErrors(t *testing.T) {
+	for _, conf := range []string{
+		`
+url: http://localhost
+base_dn: dc=example,dc=com
+`,
+		`
+url: ldap://localhost
+base_dn: dc=example,dc=com
+filter: (uid=foo
+`,
+		`
+url: ldap://localhost
+base_dn: dc=example,dc=com
+cache: nope
+`,
+		`
+url: ldap://localhost
+base_dn: dc=example,dc=com
+max_connections: 0
+`,
+	} {
+		parsed, err := processorConfig().ParseYAML(conf, nil)
+		require.NoError(t, err, conf)
+		_, err = newProcessorFromConfig(parsed, service.MockResources())
+		assert.Error(t, err, conf)
+	}
+}
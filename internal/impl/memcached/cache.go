@@ -3,11 +3,11 @@ package memcached
 import (
 	"context"
 	"errors"
+	"fmt"
 	"strings"
 	"sync"
 	"time"
 
-	"github.com/bradfitz/gomemcache/memcache"
 	"github.com/cenkalti/backoff/v4"
 
 	"github.com/benthosdev/benthos/v4/public/service"
@@ -22,6 +22,14 @@ func memcachedConfig() *service.ConfigSpec {
 	spec := service.NewConfigSpec().
 		Stable().
 		Summary(`Connects to a cluster of memcached services, a prefix can be specified to allow multiple cache types to share a memcached cluster under different namespaces.`).
+		Description(`
+### Protocols
+
+By default commands are sent with the classic text protocol. Servers running memcached v1.6 or later also support the `+"`meta`"+` protocol, which allows keys that contain whitespace or binary data to be stored by encoding them as base64. The `+"`binary`"+` protocol is deprecated by memcached but is required for SASL authentication, which is used by some managed memcached offerings.
+
+### Sharding
+
+Keys are spread across the servers listed in `+"`addresses`"+` by the client. With the default `+"`modulo`"+` hashing adding or removing a server remaps most keys to a different server, whereas with `+"`consistent`"+` hashing only the keys of the server being added or removed are remapped.`).
 		Field(service.NewStringListField("addresses").
 			Description("A list of addresses of memcached servers to use.")).
 		Field(service.NewStringField("prefix").
@@ -30,8 +38,58 @@ func memcachedConfig() *service.ConfigSpec {
 		Field(service.NewDurationField("default_ttl").
 			Description("A default TTL to set for items, calculated from the moment the item is cached.").
 			Default("300s")).
+		Field(service.NewStringAnnotatedEnumField("protocol", map[string]string{
+			"text":   "The classic text protocol.",
+			"meta":   "The meta text protocol, which requires memcached v1.6 or later.",
+			"binary": "The binary protocol, which is required for SASL authentication.",
+		}).
+			Description("The protocol to send commands with.").
+			Default("text").
+			Advanced()).
+		Field(service.NewStringAnnotatedEnumField("hashing", map[string]string{
+			"modulo":     "Keys are assigned to servers by their checksum modulo the number of servers.",
+			"consistent": "Keys are assigned to servers with a consistent hash ring, which minimises the keys that are remapped when servers are added or removed.",
+		}).
+			Description("The method of assigning keys to servers when multiple addresses are specified.").
+			Default("modulo").
+			Advanced()).
+		Field(service.NewTLSToggledField("tls")).
+		Field(service.NewObjectField("sasl",
+			service.NewStringField("username").
+				Description("A username to authenticate with, SASL authentication is disabled when this is empty.").
+				Default(""),
+			service.NewStringField("password").
+				Description("A password to authenticate with.").
+				Default(""),
+		).
+			Description("Authenticate each connection with the SASL PLAIN mechanism, which requires the `binary` protocol.").
+			Advanced()).
+		Field(service.NewDurationField("timeout").
+			Description("The maximum period of time to wait for a connection to be established, including a TLS handshake and authentication, or for a command to complete.").
+			Default("500ms").
+			Advanced()).
 		Field(service.NewBackOffField("retries", false, retriesDefaults).
-			Advanced())
+			Advanced()).
+		LintRule(`
+root = if this.sasl.username.or("") != "" && this.protocol.or("text") != "binary" {
+  [ "sasl authentication requires the binary protocol" ]
+}
+`).
+		Example("Managed Memcached",
+			`Managed memcached offerings often require TLS connections and consist of multiple nodes, here we connect to a cluster over TLS with the meta protocol and spread keys across nodes with consistent hashing:`,
+			`
+cache_resources:
+  - label: foocache
+    memcached:
+      addresses:
+        - node1.example.com:11211
+        - node2.example.com:11211
+        - node3.example.com:11211
+      protocol: meta
+      hashing: consistent
+      tls:
+        enabled: true
+`)
 
 	return spec
 }
@@ -70,7 +128,69 @@ func newMemcachedFromConfig(conf *service.ParsedConfig) (*memcachedCache, error)
 	if err != nil {
 		return nil, err
 	}
-	return newMemcachedCache(addresses, prefix, ttl, backOff)
+
+	c, err := clientFromConfig(conf, addresses)
+	if err != nil {
+		return nil, err
+	}
+	return newMemcachedCache(c, prefix, ttl, backOff)
+}
+
+func clientFromConfig(conf *service.ParsedConfig, inAddresses []string) (*client, error) {
+	addresses := []string{}
+	for _, addr := range inAddresses {
+		for _, splitAddr := range strings.Split(addr, ",") {
+			if len(splitAddr) > 0 {
+				addresses = append(addresses, splitAddr)
+			}
+		}
+	}
+
+	c := &client{}
+
+	protoStr, err := conf.FieldString("protocol")
+	if err != nil {
+		return nil, err
+	}
+	if c.proto, err = newProtocol(protoStr); err != nil {
+		return nil, err
+	}
+
+	hashing, err := conf.FieldString("hashing")
+	if err != nil {
+		return nil, err
+	}
+	switch hashing {
+	case "modulo":
+		c.selector = moduloSelector{addrs: addresses}
+	case "consistent":
+		c.selector = newConsistentSelector(addresses)
+	default:
+		return nil, fmt.Errorf("hashing method not recognised: %v", hashing)
+	}
+
+	tlsConf, tlsEnabled, err := conf.FieldTLSToggled("tls")
+	if err != nil {
+		return nil, err
+	}
+	if tlsEnabled {
+		c.tlsConf = tlsConf
+	}
+
+	if c.saslUsername, err = conf.FieldString("sasl", "username"); err != nil {
+		return nil, err
+	}
+	if c.saslPassword, err = conf.FieldString("sasl", "password"); err != nil {
+		return nil, err
+	}
+	if c.saslUsername != "" && protoStr != "binary" {
+		return nil, errors.New("sasl authentication requires the binary protocol")
+	}
+
+	if c.timeout, err = conf.FieldDuration("timeout"); err != nil {
+		return nil, err
+	}
+	return c, nil
 }
 
 //------------------------------------------------------------------------------
@@ -79,26 +199,18 @@ type memcachedCache struct {
 	prefix     string
 	defaultTTL time.Duration
 
-	mc       *memcache.Client
+	mc       *client
 	boffPool sync.Pool
 }
 
 func newMemcachedCache(
-	inAddresses []string,
+	mc *client,
 	prefix string,
 	defaultTTL time.Duration,
 	backOff *backoff.ExponentialBackOff,
 ) (*memcachedCache, error) {
-	addresses := []string{}
-	for _, addr := range inAddresses {
-		for _, splitAddr := range strings.Split(addr, ",") {
-			if len(splitAddr) > 0 {
-				addresses = append(addresses, splitAddr)
-			}
-		}
-	}
 	return &memcachedCache{
-		mc:         memcache.New(addresses...),
+		mc:         mc,
 		prefix:     prefix,
 		defaultTTL: defaultTTL,
 		boffPool: sync.Pool{
@@ -111,18 +223,20 @@ func newMemcachedCache(
 	}, nil
 }
 
-func (m *memcachedCache) getItemFor(key string, value []byte, ttl *time.Duration) *memcache.Item {
-	var expiration int32
+// Memcached interprets expiration times longer than thirty days as a unix
+// timestamp.
+const maxRelativeExpiration = 60 * 60 * 24 * 30
+
+func (m *memcachedCache) expirationFor(ttl *time.Duration) int32 {
+	d := m.defaultTTL
 	if ttl != nil {
-		expiration = int32(ttl.Milliseconds() / 1000)
-	} else {
-		expiration = int32(m.defaultTTL.Milliseconds() / 1000)
+		d = *ttl
 	}
-	return &memcache.Item{
-		Key:        m.prefix + key,
-		Value:      value,
-		Expiration: expiration,
+	secs := int64(d / time.Second)
+	if secs > maxRelativeExpiration {
+		return int32(time.Now().Add(d).Unix())
 	}
+	return int32(secs)
 }
 
 func (m *memcachedCache) Get(ctx context.Context, key string) ([]byte, error) {
@@ -133,13 +247,16 @@ func (m *memcachedCache) Get(ctx context.Context, key string) ([]byte, error) {
 	}()
 
 	for {
-		item, err := m.mc.Get(m.prefix + key)
+		value, err := m.mc.get(ctx, m.prefix+key)
 		if err == nil {
-			return item.Value, nil
+			return value, nil
 		}
-		if errors.Is(err, memcache.ErrCacheMiss) {
+		if errors.Is(err, errCacheMiss) {
 			return nil, service.ErrKeyNotFound
 		}
+		if errors.Is(err, errMalformedKey) {
+			return nil, err
+		}
 
 		wait := boff.NextBackOff()
 		if wait == backoff.Stop {
@@ -161,10 +278,13 @@ func (m *memcachedCache) Set(ctx context.Context, key string, value []byte, ttl
 	}()
 
 	for {
-		err := m.mc.Set(m.getItemFor(key, value, ttl))
+		err := m.mc.store(ctx, storeSet, m.prefix+key, value, m.expirationFor(ttl))
 		if err == nil {
 			return nil
 		}
+		if errors.Is(err, errMalformedKey) {
+			return err
+		}
 
 		wait := boff.NextBackOff()
 		if wait == backoff.Stop {
@@ -188,13 +308,16 @@ func (m *memcachedCache) Add(ctx context.Context, key string, value []byte, ttl
 	}()
 
 	for {
-		err := m.mc.Add(m.getItemFor(key, value, ttl))
+		err := m.mc.store(ctx, storeAdd, m.prefix+key, value, m.expirationFor(ttl))
 		if err == nil {
 			return nil
 		}
-		if errors.Is(err, memcache.ErrNotStored) {
+		if errors.Is(err, errNotStored) {
 			return service.ErrKeyAlreadyExists
 		}
+		if errors.Is(err, errMalformedKey) {
+			return err
+		}
 
 		wait := boff.NextBackOff()
 		if wait == backoff.Stop {
@@ -217,10 +340,13 @@ func (m *memcachedCache) Delete(ctx context.Context, key string) error {
 	}()
 
 	for {
-		err := m.mc.Delete(m.prefix + key)
-		if err == memcache.ErrCacheMiss {
+		err := m.mc.delete(ctx, m.prefix+key)
+		if err == nil || errors.Is(err, errCacheMiss) {
 			return nil
 		}
+		if errors.Is(err, errMalformedKey) {
+			return err
+		}
 
 		wait := boff.NextBackOff()
 		if wait == backoff.Stop {
@@ -235,5 +361,6 @@ func (m *memcachedCache) Delete(ctx context.Context, key string) error {
 }
 
 func (m *memcachedCache) Close(ctx context.Context) error {
+	m.mc.close()
 	return nil
 }
@@ -51,16 +51,24 @@ cache_resources:
     memcached:
       addresses: [ localhost:$PORT ]
       prefix: $ID
+      protocol: $VAR1
 `
-	suite := integration.CacheTests(
-		integration.CacheTestOpenClose(),
-		integration.CacheTestMissingKey(),
-		integration.CacheTestDoubleAdd(),
-		integration.CacheTestDelete(),
-		integration.CacheTestGetAndSet(50),
-	)
-	suite.Run(
-		t, template,
-		integration.CacheTestOptPort(resource.GetPort("11211/tcp")),
-	)
+	for _, protocol := range []string{"text", "meta", "binary"} {
+		protocol := protocol
+		t.Run(protocol, func(t *testing.T) {
+			t.Parallel()
+			suite := integration.CacheTests(
+				integration.CacheTestOpenClose(),
+				integration.CacheTestMissingKey(),
+				integration.CacheTestDoubleAdd(),
+				integration.CacheTestDelete(),
+				integration.CacheTestGetAndSet(50),
+			)
+			suite.Run(
+				t, template,
+				integration.CacheTestOptPort(resource.GetPort("11211/tcp")),
+				integration.CacheTestOptVarOne(protocol),
+			)
+		})
+	}
 }
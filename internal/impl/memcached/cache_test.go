@@ -0,0 +1,142 @@
+package memcached
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+// fakeTextServer serves the get, set, add and delete commands of the text
+// protocol from a map.
+func fakeTextServer(t *testing.T) (addr string, conns func() int) {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		ln.Close()
+	})
+
+	var mut sync.Mutex
+	items := map[string]string{}
+	accepted := 0
+
+	handle := func(c net.Conn) {
+		defer c.Close()
+		r := bufio.NewReader(c)
+		for {
+			line, err := readLine(r)
+			if err != nil {
+				return
+			}
+			fields := strings.Fields(line)
+
+			mut.Lock()
+			switch fields[0] {
+			case "get":
+				if v, exists := items[fields[1]]; exists {
+					fmt.Fprintf(c, "VALUE %v 0 %v\r\n%v\r\n", fields[1], len(v), v)
+				}
+				fmt.Fprint(c, "END\r\n")
+			case "set", "add":
+				size, _ := strconv.Atoi(fields[4])
+				value, _ := readValue(r, size)
+				if _, exists := items[fields[1]]; exists && fields[0] == "add" {
+					fmt.Fprint(c, "NOT_STORED\r\n")
+				} else {
+					items[fields[1]] = string(value)
+					fmt.Fprint(c, "STORED\r\n")
+				}
+			case "delete":
+				if _, exists := items[fields[1]]; exists {
+					delete(items, fields[1])
+					fmt.Fprint(c, "DELETED\r\n")
+				} else {
+					fmt.Fprint(c, "NOT_FOUND\r\n")
+				}
+			default:
+				fmt.Fprint(c, "ERROR\r\n")
+			}
+			mut.Unlock()
+		}
+	}
+
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			mut.Lock()
+			accepted++
+			mut.Unlock()
+			go handle(c)
+		}
+	}()
+
+	return ln.Addr().String(), func() int {
+		mut.Lock()
+		defer mut.Unlock()
+		return accepted
+	}
+}
+
+func TestMemcachedCacheFakeServer(t *testing.T) {
+	addr, conns := fakeTextServer(t)
+
+	conf, err := memcachedConfig().ParseYAML(fmt.Sprintf(`
+addresses: [ %v ]
+prefix: foo_
+hashing: consistent
+`, addr), nil)
+	require.NoError(t, err)
+
+	c, err := newMemcachedFromConfig(conf)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	_, err = c.Get(ctx, "bar")
+	assert.Equal(t, service.ErrKeyNotFound, err)
+
+	require.NoError(t, c.Set(ctx, "bar", []byte("hello world"), nil))
+
+	value, err := c.Get(ctx, "bar")
+	require.NoError(t, err)
+	assert.Equal(t, "hello world", string(value))
+
+	assert.Equal(t, service.ErrKeyAlreadyExists, c.Add(ctx, "bar", []byte("nope"), nil))
+	require.NoError(t, c.Delete(ctx, "bar"))
+	require.NoError(t, c.Delete(ctx, "bar"))
+	require.NoError(t, c.Add(ctx, "bar", []byte("again"), nil))
+
+	assert.Equal(t, errMalformedKey, c.Set(ctx, "bar baz", []byte("nope"), nil))
+
+	// Sequential commands reuse a single connection.
+	assert.Equal(t, 1, conns())
+
+	require.NoError(t, c.Close(ctx))
+}
+
+func TestMemcachedCacheSASLRequiresBinary(t *testing.T) {
+	conf, err := memcachedConfig().ParseYAML(`
+addresses: [ localhost:11211 ]
+sasl:
+  username: foo
+  password: bar
+`, nil)
+	require.NoError(t, err)
+
+	_, err = newMemcachedFromConfig(conf)
+	require.EqualError(t, err, "sasl authentication requires the binary protocol")
+}
@@ -0,0 +1,157 @@
+package memcached
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The maximum number of idle connections kept open for each server.
+const maxIdleConnsPerServer = 4
+
+type conn struct {
+	nc net.Conn
+	rw *bufio.ReadWriter
+}
+
+// client executes commands against a list of memcached servers, keeping a pool
+// of idle connections for each server.
+type client struct {
+	selector serverSelector
+	proto    protocol
+	tlsConf  *tls.Config
+	timeout  time.Duration
+
+	saslUsername string
+	saslPassword string
+
+	mut    sync.Mutex
+	idle   map[string][]*conn
+	closed bool
+}
+
+func (c *client) dial(ctx context.Context, addr string) (*conn, error) {
+	network := "tcp"
+	if strings.Contains(addr, "/") {
+		network = "unix"
+	}
+
+	dialer := &net.Dialer{Timeout: c.timeout}
+	var nc net.Conn
+	var err error
+	if c.tlsConf != nil {
+		nc, err = (&tls.Dialer{NetDialer: dialer, Config: c.tlsConf}).DialContext(ctx, network, addr)
+	} else {
+		nc, err = dialer.DialContext(ctx, network, addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	cn := &conn{
+		nc: nc,
+		rw: bufio.NewReadWriter(bufio.NewReader(nc), bufio.NewWriter(nc)),
+	}
+	if c.saslUsername != "" {
+		_ = nc.SetDeadline(time.Now().Add(c.timeout))
+		if err := saslPlainAuth(cn.rw, c.saslUsername, c.saslPassword); err != nil {
+			nc.Close()
+			return nil, err
+		}
+	}
+	return cn, nil
+}
+
+func (c *client) getConn(ctx context.Context, addr string) (*conn, error) {
+	c.mut.Lock()
+	if c.closed {
+		c.mut.Unlock()
+		return nil, errors.New("memcached: client is closed")
+	}
+	if conns := c.idle[addr]; len(conns) > 0 {
+		cn := conns[len(conns)-1]
+		c.idle[addr] = conns[:len(conns)-1]
+		c.mut.Unlock()
+		return cn, nil
+	}
+	c.mut.Unlock()
+	return c.dial(ctx, addr)
+}
+
+func (c *client) putConn(addr string, cn *conn) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	if c.closed || len(c.idle[addr]) >= maxIdleConnsPerServer {
+		cn.nc.Close()
+		return
+	}
+	if c.idle == nil {
+		c.idle = map[string][]*conn{}
+	}
+	c.idle[addr] = append(c.idle[addr], cn)
+}
+
+// do executes a command on a connection to the server of a key. Connections
+// are only reused when the command succeeded or failed with a response that
+// leaves the connection in a known state.
+func (c *client) do(ctx context.Context, key string, fn func(rw *bufio.ReadWriter) error) error {
+	addr, err := c.selector.pick(key)
+	if err != nil {
+		return err
+	}
+
+	cn, err := c.getConn(ctx, addr)
+	if err != nil {
+		return err
+	}
+
+	deadline := time.Now().Add(c.timeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	_ = cn.nc.SetDeadline(deadline)
+
+	if err = fn(cn.rw); err == nil || errors.Is(err, errCacheMiss) || errors.Is(err, errNotStored) || errors.Is(err, errMalformedKey) {
+		c.putConn(addr, cn)
+	} else {
+		cn.nc.Close()
+	}
+	return err
+}
+
+func (c *client) get(ctx context.Context, key string) (value []byte, err error) {
+	err = c.do(ctx, key, func(rw *bufio.ReadWriter) (err error) {
+		value, err = c.proto.get(rw, key)
+		return
+	})
+	return
+}
+
+func (c *client) store(ctx context.Context, mode storeMode, key string, value []byte, exp int32) error {
+	return c.do(ctx, key, func(rw *bufio.ReadWriter) error {
+		return c.proto.store(rw, mode, key, value, exp)
+	})
+}
+
+func (c *client) delete(ctx context.Context, key string) error {
+	return c.do(ctx, key, func(rw *bufio.ReadWriter) error {
+		return c.proto.delete(rw, key)
+	})
+}
+
+func (c *client) close() {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	c.closed = true
+	for _, conns := range c.idle {
+		for _, cn := range conns {
+			cn.nc.Close()
+		}
+	}
+	c.idle = nil
+}
@@ -0,0 +1,433 @@
+package memcached
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+var (
+	errCacheMiss    = errors.New("memcached: cache miss")
+	errNotStored    = errors.New("memcached: item not stored")
+	errMalformedKey = errors.New("memcached: key is too long or contains invalid characters")
+)
+
+// responseError is an error reported by a server, after which the state of the
+// connection is unknown.
+type responseError struct {
+	msg string
+}
+
+func (e *responseError) Error() string {
+	return "memcached: " + e.msg
+}
+
+type storeMode int
+
+const (
+	storeSet storeMode = iota
+	storeAdd
+)
+
+// protocol implements the commands of the cache with one of the protocols
+// supported by memcached.
+type protocol interface {
+	get(rw *bufio.ReadWriter, key string) ([]byte, error)
+	store(rw *bufio.ReadWriter, mode storeMode, key string, value []byte, exp int32) error
+	delete(rw *bufio.ReadWriter, key string) error
+}
+
+func newProtocol(name string) (protocol, error) {
+	switch name {
+	case "text":
+		return textProtocol{}, nil
+	case "meta":
+		return metaProtocol{}, nil
+	case "binary":
+		return binaryProtocol{}, nil
+	}
+	return nil, fmt.Errorf("protocol not recognised: %v", name)
+}
+
+func legalKey(key string) bool {
+	if len(key) > 250 {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] <= ' ' || key[i] == 0x7f {
+			return false
+		}
+	}
+	return true
+}
+
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadSlice('\n')
+	if err != nil {
+		return "", err
+	}
+	return string(bytes.TrimSuffix(line, []byte("\r\n"))), nil
+}
+
+// readValue reads a data block of a known size followed by a line ending.
+func readValue(r *bufio.Reader, size int) ([]byte, error) {
+	value := make([]byte, size+2)
+	if _, err := io.ReadFull(r, value); err != nil {
+		return nil, err
+	}
+	if !bytes.HasSuffix(value, []byte("\r\n")) {
+		return nil, &responseError{msg: "corrupt data block"}
+	}
+	return value[:size], nil
+}
+
+func writeCommand(rw *bufio.ReadWriter, value []byte, format string, args ...interface{}) error {
+	if _, err := fmt.Fprintf(rw, format, args...); err != nil {
+		return err
+	}
+	if value != nil {
+		if _, err := rw.Write(value); err != nil {
+			return err
+		}
+		if _, err := rw.WriteString("\r\n"); err != nil {
+			return err
+		}
+	}
+	return rw.Flush()
+}
+
+func unexpectedLine(line string) error {
+	return &responseError{msg: line}
+}
+
+//------------------------------------------------------------------------------
+
+// textProtocol implements the classic text protocol.
+type textProtocol struct{}
+
+func (textProtocol) get(rw *bufio.ReadWriter, key string) ([]byte, error) {
+	if !legalKey(key) {
+		return nil, errMalformedKey
+	}
+	if err := writeCommand(rw, nil, "get %s\r\n", key); err != nil {
+		return nil, err
+	}
+
+	line, err := readLine(rw.Reader)
+	if err != nil {
+		return nil, err
+	}
+	if line == "END" {
+		return nil, errCacheMiss
+	}
+
+	// VALUE <key> <flags> <bytes>
+	fields := strings.Fields(line)
+	if len(fields) < 4 || fields[0] != "VALUE" {
+		return nil, unexpectedLine(line)
+	}
+	size, err := strconv.Atoi(fields[3])
+	if err != nil {
+		return nil, unexpectedLine(line)
+	}
+
+	value, err := readValue(rw.Reader, size)
+	if err != nil {
+		return nil, err
+	}
+	if line, err = readLine(rw.Reader); err != nil {
+		return nil, err
+	}
+	if line != "END" {
+		return nil, unexpectedLine(line)
+	}
+	return value, nil
+}
+
+func (textProtocol) store(rw *bufio.ReadWriter, mode storeMode, key string, value []byte, exp int32) error {
+	if !legalKey(key) {
+		return errMalformedKey
+	}
+	verb := "set"
+	if mode == storeAdd {
+		verb = "add"
+	}
+	if err := writeCommand(rw, value, "%s %s 0 %d %d\r\n", verb, key, exp, len(value)); err != nil {
+		return err
+	}
+
+	line, err := readLine(rw.Reader)
+	if err != nil {
+		return err
+	}
+	switch line {
+	case "STORED":
+		return nil
+	case "NOT_STORED":
+		return errNotStored
+	}
+	return unexpectedLine(line)
+}
+
+func (textProtocol) delete(rw *bufio.ReadWriter, key string) error {
+	if !legalKey(key) {
+		return errMalformedKey
+	}
+	if err := writeCommand(rw, nil, "delete %s\r\n", key); err != nil {
+		return err
+	}
+
+	line, err := readLine(rw.Reader)
+	if err != nil {
+		return err
+	}
+	switch line {
+	case "DELETED":
+		return nil
+	case "NOT_FOUND":
+		return errCacheMiss
+	}
+	return unexpectedLine(line)
+}
+
+//------------------------------------------------------------------------------
+
+// metaProtocol implements the meta text protocol of memcached v1.6 and later,
+// which also supports keys containing whitespace or binary data by encoding
+// them as base64.
+type metaProtocol struct{}
+
+func metaKey(key string) (string, string, error) {
+	if legalKey(key) {
+		return key, "", nil
+	}
+	encoded := base64.StdEncoding.EncodeToString([]byte(key))
+	if len(encoded) > 250 {
+		return "", "", errMalformedKey
+	}
+	return encoded, " b", nil
+}
+
+func (metaProtocol) get(rw *bufio.ReadWriter, key string) ([]byte, error) {
+	key, flags, err := metaKey(key)
+	if err != nil {
+		return nil, err
+	}
+	if err := writeCommand(rw, nil, "mg %s%s v\r\n", key, flags); err != nil {
+		return nil, err
+	}
+
+	line, err := readLine(rw.Reader)
+	if err != nil {
+		return nil, err
+	}
+	if line == "EN" {
+		return nil, errCacheMiss
+	}
+
+	// VA <size> <flags>*
+	fields := strings.Fields(line)
+	if len(fields) < 2 || fields[0] != "VA" {
+		return nil, unexpectedLine(line)
+	}
+	size, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return nil, unexpectedLine(line)
+	}
+	return readValue(rw.Reader, size)
+}
+
+func (metaProtocol) store(rw *bufio.ReadWriter, mode storeMode, key string, value []byte, exp int32) error {
+	key, flags, err := metaKey(key)
+	if err != nil {
+		return err
+	}
+	if mode == storeAdd {
+		flags += " ME"
+	}
+	if err := writeCommand(rw, value, "ms %s %d T%d%s\r\n", key, len(value), exp, flags); err != nil {
+		return err
+	}
+
+	line, err := readLine(rw.Reader)
+	if err != nil {
+		return err
+	}
+	switch line {
+	case "HD":
+		return nil
+	case "NS":
+		return errNotStored
+	}
+	return unexpectedLine(line)
+}
+
+func (metaProtocol) delete(rw *bufio.ReadWriter, key string) error {
+	key, flags, err := metaKey(key)
+	if err != nil {
+		return err
+	}
+	if err := writeCommand(rw, nil, "md %s%s\r\n", key, flags); err != nil {
+		return err
+	}
+
+	line, err := readLine(rw.Reader)
+	if err != nil {
+		return err
+	}
+	switch line {
+	case "HD":
+		return nil
+	case "NF":
+		return errCacheMiss
+	}
+	return unexpectedLine(line)
+}
+
+//------------------------------------------------------------------------------
+
+const (
+	binReqMagic = 0x80
+	binResMagic = 0x81
+
+	binOpGet      = 0x00
+	binOpSet      = 0x01
+	binOpAdd      = 0x02
+	binOpDelete   = 0x04
+	binOpSASLAuth = 0x21
+
+	binStatusOK         = 0x0000
+	binStatusNotFound   = 0x0001
+	binStatusExists     = 0x0002
+	binStatusNotStored  = 0x0005
+	binStatusAuthFailed = 0x0020
+)
+
+// binaryProtocol implements the binary protocol, which is required for SASL
+// authentication.
+type binaryProtocol struct{}
+
+type binResponse struct {
+	status uint16
+	extras []byte
+	key    []byte
+	value  []byte
+}
+
+func binRequest(rw *bufio.ReadWriter, opcode byte, extras, key, value []byte) (*binResponse, error) {
+	var header [24]byte
+	header[0] = binReqMagic
+	header[1] = opcode
+	binary.BigEndian.PutUint16(header[2:], uint16(len(key)))
+	header[4] = byte(len(extras))
+	binary.BigEndian.PutUint32(header[8:], uint32(len(extras)+len(key)+len(value)))
+
+	for _, b := range [][]byte{header[:], extras, key, value} {
+		if _, err := rw.Write(b); err != nil {
+			return nil, err
+		}
+	}
+	if err := rw.Flush(); err != nil {
+		return nil, err
+	}
+
+	if _, err := io.ReadFull(rw, header[:]); err != nil {
+		return nil, err
+	}
+	if header[0] != binResMagic || header[1] != opcode {
+		return nil, &responseError{msg: "corrupt binary response header"}
+	}
+
+	keyLen := int(binary.BigEndian.Uint16(header[2:]))
+	extrasLen := int(header[4])
+	bodyLen := int(binary.BigEndian.Uint32(header[8:]))
+	if keyLen+extrasLen > bodyLen {
+		return nil, &responseError{msg: "corrupt binary response header"}
+	}
+
+	body := make([]byte, bodyLen)
+	if _, err := io.ReadFull(rw, body); err != nil {
+		return nil, err
+	}
+	return &binResponse{
+		status: binary.BigEndian.Uint16(header[6:]),
+		extras: body[:extrasLen],
+		key:    body[extrasLen : extrasLen+keyLen],
+		value:  body[extrasLen+keyLen:],
+	}, nil
+}
+
+func (r *binResponse) err() error {
+	switch r.status {
+	case binStatusOK:
+		return nil
+	case binStatusNotFound:
+		return errCacheMiss
+	case binStatusExists, binStatusNotStored:
+		return errNotStored
+	}
+	return &responseError{msg: fmt.Sprintf("status %#04x: %s", r.status, r.value)}
+}
+
+func (binaryProtocol) get(rw *bufio.ReadWriter, key string) ([]byte, error) {
+	if len(key) > 250 {
+		return nil, errMalformedKey
+	}
+	res, err := binRequest(rw, binOpGet, nil, []byte(key), nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := res.err(); err != nil {
+		return nil, err
+	}
+	return res.value, nil
+}
+
+func (binaryProtocol) store(rw *bufio.ReadWriter, mode storeMode, key string, value []byte, exp int32) error {
+	if len(key) > 250 {
+		return errMalformedKey
+	}
+	opcode := byte(binOpSet)
+	if mode == storeAdd {
+		opcode = binOpAdd
+	}
+
+	// Flags followed by the expiration.
+	var extras [8]byte
+	binary.BigEndian.PutUint32(extras[4:], uint32(exp))
+
+	res, err := binRequest(rw, opcode, extras[:], []byte(key), value)
+	if err != nil {
+		return err
+	}
+	return res.err()
+}
+
+func (binaryProtocol) delete(rw *bufio.ReadWriter, key string) error {
+	if len(key) > 250 {
+		return errMalformedKey
+	}
+	res, err := binRequest(rw, binOpDelete, nil, []byte(key), nil)
+	if err != nil {
+		return err
+	}
+	return res.err()
+}
+
+// saslPlainAuth authenticates a connection with the SASL PLAIN mechanism.
+func saslPlainAuth(rw *bufio.ReadWriter, username, password string) error {
+	res, err := binRequest(rw, binOpSASLAuth, nil, []byte("PLAIN"), []byte("\x00"+username+"\x00"+password))
+	if err != nil {
+		return err
+	}
+	if res.status == binStatusAuthFailed {
+		return errors.New("memcached: sasl authentication failed")
+	}
+	return res.err()
+}
@@ -0,0 +1,155 @@
+package memcached
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func cannedRW(response string) (*bufio.ReadWriter, *bytes.Buffer) {
+	var written bytes.Buffer
+	return bufio.NewReadWriter(
+		bufio.NewReader(strings.NewReader(response)),
+		bufio.NewWriter(&written),
+	), &written
+}
+
+func TestProtocolText(t *testing.T) {
+	p := textProtocol{}
+
+	rw, written := cannedRW("VALUE foo 0 3\r\nbar\r\nEND\r\n")
+	value, err := p.get(rw, "foo")
+	require.NoError(t, err)
+	assert.Equal(t, "bar", string(value))
+	assert.Equal(t, "get foo\r\n", written.String())
+
+	rw, _ = cannedRW("END\r\n")
+	_, err = p.get(rw, "foo")
+	assert.Equal(t, errCacheMiss, err)
+
+	rw, written = cannedRW("STORED\r\n")
+	require.NoError(t, p.store(rw, storeSet, "foo", []byte("bar"), 10))
+	assert.Equal(t, "set foo 0 10 3\r\nbar\r\n", written.String())
+
+	rw, written = cannedRW("NOT_STORED\r\n")
+	assert.Equal(t, errNotStored, p.store(rw, storeAdd, "foo", []byte("bar"), 0))
+	assert.Equal(t, "add foo 0 0 3\r\nbar\r\n", written.String())
+
+	rw, written = cannedRW("NOT_FOUND\r\n")
+	assert.Equal(t, errCacheMiss, p.delete(rw, "foo"))
+	assert.Equal(t, "delete foo\r\n", written.String())
+
+	rw, _ = cannedRW("SERVER_ERROR out of memory storing object\r\n")
+	assert.EqualError(t, p.store(rw, storeSet, "foo", []byte("bar"), 0), "memcached: SERVER_ERROR out of memory storing object")
+
+	rw, written = cannedRW("")
+	assert.Equal(t, errMalformedKey, p.store(rw, storeSet, "foo bar", []byte("bar"), 0))
+	assert.Equal(t, "", written.String())
+}
+
+func TestProtocolMeta(t *testing.T) {
+	p := metaProtocol{}
+
+	rw, written := cannedRW("VA 3\r\nbar\r\n")
+	value, err := p.get(rw, "foo")
+	require.NoError(t, err)
+	assert.Equal(t, "bar", string(value))
+	assert.Equal(t, "mg foo v\r\n", written.String())
+
+	rw, _ = cannedRW("EN\r\n")
+	_, err = p.get(rw, "foo")
+	assert.Equal(t, errCacheMiss, err)
+
+	rw, written = cannedRW("HD\r\n")
+	require.NoError(t, p.store(rw, storeSet, "foo", []byte("bar"), 10))
+	assert.Equal(t, "ms foo 3 T10\r\nbar\r\n", written.String())
+
+	rw, written = cannedRW("NS\r\n")
+	assert.Equal(t, errNotStored, p.store(rw, storeAdd, "foo", []byte("bar"), 0))
+	assert.Equal(t, "ms foo 3 T0 ME\r\nbar\r\n", written.String())
+
+	rw, written = cannedRW("HD\r\n")
+	require.NoError(t, p.delete(rw, "foo bar"))
+	assert.Equal(t, "md Zm9vIGJhcg== b\r\n", written.String())
+
+	rw, _ = cannedRW("NF\r\n")
+	assert.Equal(t, errCacheMiss, p.delete(rw, "foo"))
+}
+
+func binResponseBytes(opcode byte, status uint16, extras, value string) string {
+	header := []byte{
+		binResMagic, opcode, 0, 0, byte(len(extras)), 0, byte(status >> 8), byte(status),
+		0, 0, 0, byte(len(extras) + len(value)),
+	}
+	header = append(header, make([]byte, 12)...)
+	return string(header) + extras + value
+}
+
+func TestProtocolBinary(t *testing.T) {
+	p := binaryProtocol{}
+
+	rw, written := cannedRW(binResponseBytes(binOpGet, binStatusOK, "\x00\x00\x00\x00", "bar"))
+	value, err := p.get(rw, "foo")
+	require.NoError(t, err)
+	assert.Equal(t, "bar", string(value))
+	assert.Equal(t, fmt.Sprintf("\x80\x00\x00\x03%s\x00\x00\x00\x03%sfoo", strings.Repeat("\x00", 4), strings.Repeat("\x00", 12)), written.String())
+
+	rw, _ = cannedRW(binResponseBytes(binOpGet, binStatusNotFound, "", "Not found"))
+	_, err = p.get(rw, "foo")
+	assert.Equal(t, errCacheMiss, err)
+
+	rw, written = cannedRW(binResponseBytes(binOpSet, binStatusOK, "", ""))
+	require.NoError(t, p.store(rw, storeSet, "foo", []byte("bar"), 10))
+	assert.Equal(t, byte(binOpSet), written.Bytes()[1])
+	assert.Equal(t, "\x00\x00\x00\x00\x00\x00\x00\x0afoobar", string(written.Bytes()[24:]))
+
+	rw, _ = cannedRW(binResponseBytes(binOpAdd, binStatusExists, "", "Data exists for key."))
+	assert.Equal(t, errNotStored, p.store(rw, storeAdd, "foo", []byte("bar"), 0))
+
+	rw, _ = cannedRW(binResponseBytes(binOpDelete, binStatusOK, "", ""))
+	require.NoError(t, p.delete(rw, "foo"))
+
+	rw, written = cannedRW(binResponseBytes(binOpSASLAuth, binStatusOK, "", "Authenticated"))
+	require.NoError(t, saslPlainAuth(rw, "foo", "bar"))
+	assert.Equal(t, "PLAIN\x00foo\x00bar", string(written.Bytes()[24:]))
+
+	rw, _ = cannedRW(binResponseBytes(binOpSASLAuth, binStatusAuthFailed, "", "Auth failure"))
+	assert.EqualError(t, saslPlainAuth(rw, "foo", "baz"), "memcached: sasl authentication failed")
+}
+
+func TestConsistentSelector(t *testing.T) {
+	addrs := []string{"foo:11211", "bar:11211", "baz:11211"}
+	before := newConsistentSelector(addrs)
+	after := newConsistentSelector(append(addrs, "buz:11211"))
+
+	counts := map[string]int{}
+	remapped := 0
+	for i := 0; i < 10000; i++ {
+		key := fmt.Sprintf("key%v", i)
+
+		addrBefore, err := before.pick(key)
+		require.NoError(t, err)
+		counts[addrBefore]++
+
+		addrAfter, err := after.pick(key)
+		require.NoError(t, err)
+		if addrBefore != addrAfter {
+			assert.Equal(t, "buz:11211", addrAfter)
+			remapped++
+		}
+	}
+
+	for _, addr := range addrs {
+		assert.Greater(t, counts[addr], 2000, addr)
+	}
+	assert.Greater(t, remapped, 1500)
+	assert.Less(t, remapped, 3500)
+
+	_, err := newConsistentSelector(nil).pick("foo")
+	assert.Equal(t, errNoServers, err)
+}
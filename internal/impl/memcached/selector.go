@@ -0,0 +1,86 @@
+package memcached
+
+import (
+	"crypto/md5"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"sort"
+)
+
+var errNoServers = errors.New("no memcached servers configured")
+
+// serverSelector chooses the server that a key is stored within.
+type serverSelector interface {
+	pick(key string) (string, error)
+}
+
+// moduloSelector picks servers by the CRC32 checksum of keys modulo the number
+// of servers, which matches the key distribution of the previous client and
+// therefore remains the default. Changing the list of servers remaps most keys.
+type moduloSelector struct {
+	addrs []string
+}
+
+func (s moduloSelector) pick(key string) (string, error) {
+	switch len(s.addrs) {
+	case 0:
+		return "", errNoServers
+	case 1:
+		return s.addrs[0], nil
+	}
+	return s.addrs[crc32.ChecksumIEEE([]byte(key))%uint32(len(s.addrs))], nil
+}
+
+//------------------------------------------------------------------------------
+
+// The number of points that each server occupies on the hash ring.
+const consistentPointsPerServer = 160
+
+type ringPoint struct {
+	hash uint32
+	addr string
+}
+
+// consistentSelector picks servers with a ketama style hash ring, where each
+// server occupies a number of points on the ring and keys are stored within
+// the server of the next point. Adding or removing a server only remaps the
+// keys of the points it occupies.
+type consistentSelector struct {
+	ring []ringPoint
+}
+
+func newConsistentSelector(addrs []string) *consistentSelector {
+	s := &consistentSelector{}
+	for _, addr := range addrs {
+		for i := 0; i < consistentPointsPerServer/4; i++ {
+			sum := md5.Sum([]byte(fmt.Sprintf("%v-%v", addr, i)))
+			for j := 0; j < 4; j++ {
+				s.ring = append(s.ring, ringPoint{
+					hash: binary.LittleEndian.Uint32(sum[j*4:]),
+					addr: addr,
+				})
+			}
+		}
+	}
+	sort.Slice(s.ring, func(i, j int) bool {
+		return s.ring[i].hash < s.ring[j].hash
+	})
+	return s
+}
+
+func (s *consistentSelector) pick(key string) (string, error) {
+	if len(s.ring) == 0 {
+		return "", errNoServers
+	}
+	sum := md5.Sum([]byte(key))
+	h := binary.LittleEndian.Uint32(sum[:4])
+	i := sort.Search(len(s.ring), func(i int) bool {
+		return s.ring[i].hash >= h
+	})
+	if i == len(s.ring) {
+		i = 0
+	}
+	return s.ring[i].addr, nil
+}
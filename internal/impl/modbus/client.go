@@ -0,0 +1,244 @@
+package modbus
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Function codes of the read requests supported by the client.
+const (
+	fnReadCoils            byte = 0x01
+	fnReadDiscreteInputs   byte = 0x02
+	fnReadHoldingRegisters byte = 0x03
+	fnReadInputRegisters   byte = 0x04
+)
+
+var exceptionNames = map[byte]string{
+	0x01: "illegal function",
+	0x02: "illegal data address",
+	0x03: "illegal data value",
+	0x04: "server device failure",
+	0x05: "acknowledge",
+	0x06: "server device busy",
+	0x08: "memory parity error",
+	0x0A: "gateway path unavailable",
+	0x0B: "gateway target device failed to respond",
+}
+
+// exceptionError is returned when a device responds to a request with an
+// exception.
+type exceptionError struct {
+	function byte
+	code     byte
+}
+
+func (e *exceptionError) Error() string {
+	name, exists := exceptionNames[e.code]
+	if !exists {
+		name = "unknown exception"
+	}
+	return fmt.Sprintf("device responded to function %#02x with exception %#02x (%v)", e.function, e.code, name)
+}
+
+// transport sends a request PDU to a device and returns the response PDU,
+// framed according to a Modbus variant.
+type transport interface {
+	send(unitID byte, pdu []byte) ([]byte, error)
+	close() error
+}
+
+type deadliner interface {
+	SetDeadline(t time.Time) error
+}
+
+func setDeadline(conn io.ReadWriter, timeout time.Duration) {
+	if d, ok := conn.(deadliner); ok && timeout > 0 {
+		_ = d.SetDeadline(time.Now().Add(timeout))
+	}
+}
+
+// dialTransport opens a transport to a device. TCP transports expect the
+// address host:port. RTU transports either open a serial device by its path,
+// or connect to an RTU over TCP gateway when the address is prefixed with
+// tcp://.
+func dialTransport(mode, address string, timeout time.Duration) (transport, error) {
+	switch mode {
+	case "tcp":
+		conn, err := net.DialTimeout("tcp", address, timeout)
+		if err != nil {
+			return nil, err
+		}
+		return &tcpTransport{conn: conn, timeout: timeout}, nil
+	case "rtu":
+		if gateway := strings.TrimPrefix(address, "tcp://"); gateway != address {
+			conn, err := net.DialTimeout("tcp", gateway, timeout)
+			if err != nil {
+				return nil, err
+			}
+			return &rtuTransport{conn: conn, timeout: timeout}, nil
+		}
+		f, err := os.OpenFile(address, os.O_RDWR, 0)
+		if err != nil {
+			return nil, err
+		}
+		return &rtuTransport{conn: f, timeout: timeout}, nil
+	}
+	return nil, fmt.Errorf("transport %v not recognised", mode)
+}
+
+//------------------------------------------------------------------------------
+
+// tcpTransport frames requests with an MBAP header.
+type tcpTransport struct {
+	mut     sync.Mutex
+	conn    io.ReadWriteCloser
+	timeout time.Duration
+	txID    uint16
+}
+
+func (t *tcpTransport) send(unitID byte, pdu []byte) ([]byte, error) {
+	t.mut.Lock()
+	defer t.mut.Unlock()
+
+	t.txID++
+	req := make([]byte, 7, 7+len(pdu))
+	binary.BigEndian.PutUint16(req[0:], t.txID)
+	binary.BigEndian.PutUint16(req[4:], uint16(len(pdu)+1))
+	req[6] = unitID
+	req = append(req, pdu...)
+
+	setDeadline(t.conn, t.timeout)
+	if _, err := t.conn.Write(req); err != nil {
+		return nil, err
+	}
+
+	for {
+		header := make([]byte, 7)
+		if _, err := io.ReadFull(t.conn, header); err != nil {
+			return nil, err
+		}
+		length := binary.BigEndian.Uint16(header[4:])
+		if length < 2 || length > 254 {
+			return nil, fmt.Errorf("invalid response length: %v", length)
+		}
+		res := make([]byte, length-1)
+		if _, err := io.ReadFull(t.conn, res); err != nil {
+			return nil, err
+		}
+		// Responses to earlier requests that timed out are discarded.
+		if binary.BigEndian.Uint16(header[0:]) == t.txID {
+			return res, nil
+		}
+	}
+}
+
+func (t *tcpTransport) close() error {
+	return t.conn.Close()
+}
+
+//------------------------------------------------------------------------------
+
+// rtuTransport frames requests with the unit ID and a CRC, and only supports
+// the read functions, whose responses are prefixed with their length.
+type rtuTransport struct {
+	mut     sync.Mutex
+	conn    io.ReadWriteCloser
+	timeout time.Duration
+}
+
+func crc16(b []byte) uint16 {
+	crc := uint16(0xFFFF)
+	for _, v := range b {
+		crc ^= uint16(v)
+		for i := 0; i < 8; i++ {
+			if crc&1 != 0 {
+				crc = (crc >> 1) ^ 0xA001
+			} else {
+				crc >>= 1
+			}
+		}
+	}
+	return crc
+}
+
+func appendCRC(frame []byte) []byte {
+	crc := crc16(frame)
+	return append(frame, byte(crc), byte(crc>>8))
+}
+
+func (t *rtuTransport) send(unitID byte, pdu []byte) ([]byte, error) {
+	t.mut.Lock()
+	defer t.mut.Unlock()
+
+	setDeadline(t.conn, t.timeout)
+	if _, err := t.conn.Write(appendCRC(append([]byte{unitID}, pdu...))); err != nil {
+		return nil, err
+	}
+
+	frame := make([]byte, 3)
+	if _, err := io.ReadFull(t.conn, frame); err != nil {
+		return nil, err
+	}
+	remaining := int(frame[2]) + 2
+	if frame[1]&0x80 != 0 {
+		// Exception responses consist of the exception code and CRC only.
+		remaining = 2
+	}
+	frame = append(frame, make([]byte, remaining)...)
+	if _, err := io.ReadFull(t.conn, frame[3:]); err != nil {
+		return nil, err
+	}
+
+	body := frame[:len(frame)-2]
+	if binary.LittleEndian.Uint16(frame[len(frame)-2:]) != crc16(body) {
+		return nil, errors.New("response failed CRC check")
+	}
+	if body[0] != unitID {
+		return nil, fmt.Errorf("response from unexpected unit %v", body[0])
+	}
+	return body[1:], nil
+}
+
+func (t *rtuTransport) close() error {
+	return t.conn.Close()
+}
+
+//------------------------------------------------------------------------------
+
+// read executes a read function for quantity coils or registers starting from
+// an address, and returns the data bytes of the response.
+func read(t transport, unitID, function byte, address, quantity uint16) ([]byte, error) {
+	pdu := []byte{function, 0, 0, 0, 0}
+	binary.BigEndian.PutUint16(pdu[1:], address)
+	binary.BigEndian.PutUint16(pdu[3:], quantity)
+
+	res, err := t.send(unitID, pdu)
+	if err != nil {
+		return nil, err
+	}
+	if len(res) < 2 {
+		return nil, errors.New("response too short")
+	}
+	if res[0] == function|0x80 {
+		return nil, &exceptionError{function: function, code: res[1]}
+	}
+	if res[0] != function {
+		return nil, fmt.Errorf("response to unexpected function %#02x", res[0])
+	}
+
+	expected := int(quantity) * 2
+	if function == fnReadCoils || function == fnReadDiscreteInputs {
+		expected = (int(quantity) + 7) / 8
+	}
+	if int(res[1]) != expected || len(res) != expected+2 {
+		return nil, fmt.Errorf("expected %v bytes of data, received %v", expected, len(res)-2)
+	}
+	return res[2:], nil
+}
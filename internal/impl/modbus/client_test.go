@@ -0,0 +1,146 @@
+package modbus
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testDevice responds to read requests from its register tables, returning an
+// illegal data address exception for registers that do not exist.
+type testDevice struct {
+	mut       sync.Mutex
+	registers map[byte]map[uint16]uint16
+	coils     map[byte]map[uint16]bool
+}
+
+func (d *testDevice) respond(pdu []byte) []byte {
+	d.mut.Lock()
+	defer d.mut.Unlock()
+
+	function := pdu[0]
+	address := binary.BigEndian.Uint16(pdu[1:])
+	quantity := binary.BigEndian.Uint16(pdu[3:])
+
+	var data []byte
+	switch function {
+	case fnReadCoils, fnReadDiscreteInputs:
+		data = make([]byte, (quantity+7)/8)
+		for n := uint16(0); n < quantity; n++ {
+			v, exists := d.coils[function][address+n]
+			if !exists {
+				return []byte{function | 0x80, 0x02}
+			}
+			if v {
+				data[n/8] |= 1 << (n % 8)
+			}
+		}
+	default:
+		for n := uint16(0); n < quantity; n++ {
+			v, exists := d.registers[function][address+n]
+			if !exists {
+				return []byte{function | 0x80, 0x02}
+			}
+			data = append(data, byte(v>>8), byte(v))
+		}
+	}
+	return append([]byte{function, byte(len(data))}, data...)
+}
+
+// serveTCP serves requests with MBAP framing until the listener is closed.
+func (d *testDevice) serveTCP(t *testing.T, ln net.Listener) {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			defer conn.Close()
+			for {
+				header := make([]byte, 7)
+				if _, err := io.ReadFull(conn, header); err != nil {
+					return
+				}
+				pdu := make([]byte, binary.BigEndian.Uint16(header[4:])-1)
+				if _, err := io.ReadFull(conn, pdu); err != nil {
+					return
+				}
+				res := d.respond(pdu)
+				binary.BigEndian.PutUint16(header[4:], uint16(len(res)+1))
+				if _, err := conn.Write(append(header, res...)); err != nil {
+					return
+				}
+			}
+		}()
+	}
+}
+
+func TestCRC16(t *testing.T) {
+	// Read holding registers 0x006B to 0x006D of unit 17.
+	frame := appendCRC([]byte{0x11, 0x03, 0x00, 0x6B, 0x00, 0x03})
+	assert.Equal(t, []byte{0x11, 0x03, 0x00, 0x6B, 0x00, 0x03, 0x76, 0x87}, frame)
+}
+
+func TestTCPTransport(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+
+	d := &testDevice{registers: map[byte]map[uint16]uint16{
+		fnReadHoldingRegisters: {10: 0x1234, 11: 0x5678},
+	}}
+	go d.serveTCP(t, ln)
+
+	tr, err := dialTransport("tcp", ln.Addr().String(), 0)
+	require.NoError(t, err)
+	defer tr.close()
+
+	data, err := read(tr, 1, fnReadHoldingRegisters, 10, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0x12, 0x34, 0x56, 0x78}, data)
+
+	_, err = read(tr, 1, fnReadHoldingRegisters, 11, 2)
+	assert.EqualError(t, err, "device responded to function 0x03 with exception 0x02 (illegal data address)")
+}
+
+func TestRTUTransport(t *testing.T) {
+	client, server := net.Pipe()
+	defer server.Close()
+
+	d := &testDevice{coils: map[byte]map[uint16]bool{
+		fnReadCoils: {0: true, 1: false, 2: true},
+	}}
+	go func() {
+		for {
+			req := make([]byte, 8)
+			if _, err := io.ReadFull(server, req); err != nil {
+				return
+			}
+			res := appendCRC(append([]byte{req[0]}, d.respond(req[1:6])...))
+			if req[0] == 9 {
+				res[len(res)-1] ^= 0xFF
+			}
+			if _, err := server.Write(res); err != nil {
+				return
+			}
+		}
+	}()
+
+	tr := &rtuTransport{conn: client}
+	defer tr.close()
+
+	data, err := read(tr, 2, fnReadCoils, 0, 3)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0x05}, data)
+
+	_, err = read(tr, 2, fnReadCoils, 2, 2)
+	assert.EqualError(t, err, "device responded to function 0x01 with exception 0x02 (illegal data address)")
+
+	_, err = read(tr, 9, fnReadCoils, 0, 1)
+	assert.EqualError(t, err, "response failed CRC check")
+}
@@ -0,0 +1,436 @@
+package modbus
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	mbFieldTransport = "transport"
+	mbFieldAddress   = "address"
+	mbFieldUnitID    = "unit_id"
+	mbFieldRegisters = "registers"
+	mbFieldInterval  = "interval"
+	mbFieldTimeout   = "timeout"
+
+	mbFieldRegName      = "name"
+	mbFieldRegTable     = "table"
+	mbFieldRegAddress   = "address"
+	mbFieldRegType      = "type"
+	mbFieldRegByteOrder = "byte_order"
+	mbFieldRegWordOrder = "word_order"
+	mbFieldRegScale     = "scale"
+)
+
+func inputConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		// Stable(). TODO
+		Categories("Network").
+		Summary("Polls a Modbus device for a map of coils and registers on an interval, decoding them into the fields of a structured message.").
+		Description(`
+Each poll reads every register of the map from the device and emits a single message containing an object with a field for each register, keyed by its name. When any register of a poll fails to be read the poll is retried, and the input reconnects to the device if the connection was lost.
+
+Devices are reached either over Modbus TCP, or with RTU framing via a serial device or an RTU over TCP gateway. Serial line settings such as the baud rate and parity are not configured by this input, and must be set on the device beforehand, e.g. with `+"`stty`"+`.
+
+### Data Types
+
+Registers are 16 bits wide, and the types `+"`int32`, `uint32` and `float32`"+` span two consecutive registers whereas `+"`int64`, `uint64` and `float64`"+` span four. The order of bytes within each register is determined by `+"`byte_order`"+` and the order of registers within a value by `+"`word_order`"+`, both of which default to big endian as per the Modbus specification. Coils and discrete inputs are always decoded as booleans.
+
+When `+"`scale`"+` is set to a value other than 1 numeric values are multiplied by it and emitted as floating point numbers.
+
+### Metadata
+
+This input adds the following metadata fields to each message:
+
+`+"```"+`
+- modbus_address
+- modbus_unit_id
+`+"```"+`
+
+You can access these metadata fields using [function interpolation](/docs/configuration/interpolation#metadata).`).
+		Field(service.NewStringEnumField(mbFieldTransport, "tcp", "rtu").
+			Description("The framing used to communicate with the device.").
+			Default("tcp")).
+		Field(service.NewStringField(mbFieldAddress).
+			Description("The address of the device. For the `tcp` transport this is a host and port, and for the `rtu` transport either the path of a serial device or a `tcp://` URL of an RTU over TCP gateway.").
+			Example("localhost:502").
+			Example("/dev/ttyUSB0").
+			Example("tcp://gateway:4001")).
+		Field(service.NewIntField(mbFieldUnitID).
+			Description("The unit ID, also known as the slave ID, of the device.").
+			Default(1)).
+		Field(service.NewObjectListField(mbFieldRegisters,
+			service.NewStringField(mbFieldRegName).
+				Description("The name of the field of the message to store the value in."),
+			service.NewStringAnnotatedEnumField(mbFieldRegTable, map[string]string{
+				"holding":  "Read-write 16 bit holding registers.",
+				"input":    "Read-only 16 bit input registers.",
+				"coil":     "Read-write single bit coils.",
+				"discrete": "Read-only single bit discrete inputs.",
+			}).
+				Description("The table to read the value from.").
+				Default("holding"),
+			service.NewIntField(mbFieldRegAddress).
+				Description("The zero based address of the first coil or register of the value."),
+			service.NewStringEnumField(mbFieldRegType, "bool", "int16", "uint16", "int32", "uint32", "float32", "int64", "uint64", "float64").
+				Description("The type to decode the value as. Values of the `coil` and `discrete` tables are always decoded as `bool`, and `bool` values of registers are true when the register is non-zero.").
+				Default("uint16"),
+			service.NewStringEnumField(mbFieldRegByteOrder, "big", "little").
+				Description("The order of the bytes within each register.").
+				Advanced().
+				Default("big"),
+			service.NewStringEnumField(mbFieldRegWordOrder, "big", "little").
+				Description("The order of the registers of values spanning multiple registers.").
+				Advanced().
+				Default("big"),
+			service.NewFloatField(mbFieldRegScale).
+				Description("A factor to multiply numeric values by.").
+				Advanced().
+				Default(1.0),
+		).Description("The map of coils and registers to read from the device on each poll.")).
+		Field(service.NewDurationField(mbFieldInterval).
+			Description("The interval at which to poll the device.").
+			Default("10s")).
+		Field(service.NewDurationField(mbFieldTimeout).
+			Description("The maximum period to wait for the device to connect or respond to a request.").
+			Advanced().
+			Default("5s")).
+		Example("Energy meter", "Poll the voltage and total energy of a meter every five seconds.", `
+input:
+  modbus:
+    address: meter.local:502
+    unit_id: 3
+    interval: 5s
+    registers:
+      - name: voltage
+        table: input
+        address: 0
+        type: float32
+      - name: energy_kwh
+        table: input
+        address: 72
+        type: uint32
+        word_order: little
+        scale: 0.1
+      - name: relay_closed
+        table: coil
+        address: 0
+`)
+}
+
+func init() {
+	err := service.RegisterInput(
+		"modbus", inputConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Input, error) {
+			i, err := newInputFromConfig(conf, mgr.Logger())
+			if err != nil {
+				return nil, err
+			}
+			return service.AutoRetryNacks(i), nil
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// register describes a value to read from a device and how to decode it.
+type register struct {
+	name      string
+	function  byte
+	address   uint16
+	quantity  uint16
+	dataType  string
+	byteOrder binary.ByteOrder
+	wordOrder binary.ByteOrder
+	scale     float64
+}
+
+var registerFunctions = map[string]byte{
+	"holding":  fnReadHoldingRegisters,
+	"input":    fnReadInputRegisters,
+	"coil":     fnReadCoils,
+	"discrete": fnReadDiscreteInputs,
+}
+
+var typeRegisters = map[string]uint16{
+	"bool":    1,
+	"int16":   1,
+	"uint16":  1,
+	"int32":   2,
+	"uint32":  2,
+	"float32": 2,
+	"int64":   4,
+	"uint64":  4,
+	"float64": 4,
+}
+
+func byteOrder(name string) binary.ByteOrder {
+	if name == "little" {
+		return binary.LittleEndian
+	}
+	return binary.BigEndian
+}
+
+func registerFromConfig(conf *service.ParsedConfig) (r register, err error) {
+	if r.name, err = conf.FieldString(mbFieldRegName); err != nil {
+		return
+	}
+
+	var table string
+	if table, err = conf.FieldString(mbFieldRegTable); err != nil {
+		return
+	}
+	var exists bool
+	if r.function, exists = registerFunctions[table]; !exists {
+		err = fmt.Errorf("table %v not recognised", table)
+		return
+	}
+
+	var address int
+	if address, err = conf.FieldInt(mbFieldRegAddress); err != nil {
+		return
+	}
+	if address < 0 || address > math.MaxUint16 {
+		err = fmt.Errorf("address %v is out of range", address)
+		return
+	}
+	r.address = uint16(address)
+
+	if r.dataType, err = conf.FieldString(mbFieldRegType); err != nil {
+		return
+	}
+	if r.function == fnReadCoils || r.function == fnReadDiscreteInputs {
+		r.dataType = "bool"
+	}
+	if r.quantity, exists = typeRegisters[r.dataType]; !exists {
+		err = fmt.Errorf("type %v not recognised", r.dataType)
+		return
+	}
+
+	var order string
+	if order, err = conf.FieldString(mbFieldRegByteOrder); err != nil {
+		return
+	}
+	r.byteOrder = byteOrder(order)
+	if order, err = conf.FieldString(mbFieldRegWordOrder); err != nil {
+		return
+	}
+	r.wordOrder = byteOrder(order)
+
+	r.scale, err = conf.FieldFloat(mbFieldRegScale)
+	return
+}
+
+// decode converts the data of a read response into the value of the register.
+func (r *register) decode(data []byte) (interface{}, error) {
+	if r.function == fnReadCoils || r.function == fnReadDiscreteInputs {
+		if len(data) < 1 {
+			return nil, errors.New("response contains no data")
+		}
+		return data[0]&1 == 1, nil
+	}
+	if len(data) != int(r.quantity)*2 {
+		return nil, fmt.Errorf("expected %v registers, received %v", r.quantity, len(data)/2)
+	}
+
+	// Normalise the registers into a big endian byte sequence.
+	words := make([]uint16, r.quantity)
+	for i := range words {
+		words[i] = r.byteOrder.Uint16(data[i*2:])
+	}
+	if r.wordOrder == binary.LittleEndian {
+		for i, j := 0, len(words)-1; i < j; i, j = i+1, j-1 {
+			words[i], words[j] = words[j], words[i]
+		}
+	}
+	b := make([]byte, len(data))
+	for i, w := range words {
+		binary.BigEndian.PutUint16(b[i*2:], w)
+	}
+
+	var v interface{}
+	switch r.dataType {
+	case "bool":
+		return words[0] != 0, nil
+	case "int16":
+		v = int64(int16(binary.BigEndian.Uint16(b)))
+	case "uint16":
+		v = uint64(binary.BigEndian.Uint16(b))
+	case "int32":
+		v = int64(int32(binary.BigEndian.Uint32(b)))
+	case "uint32":
+		v = uint64(binary.BigEndian.Uint32(b))
+	case "float32":
+		v = float64(math.Float32frombits(binary.BigEndian.Uint32(b)))
+	case "int64":
+		v = int64(binary.BigEndian.Uint64(b))
+	case "uint64":
+		v = binary.BigEndian.Uint64(b)
+	case "float64":
+		v = math.Float64frombits(binary.BigEndian.Uint64(b))
+	}
+
+	if r.scale == 1 {
+		return v, nil
+	}
+	switch t := v.(type) {
+	case int64:
+		return float64(t) * r.scale, nil
+	case uint64:
+		return float64(t) * r.scale, nil
+	case float64:
+		return t * r.scale, nil
+	}
+	return v, nil
+}
+
+//------------------------------------------------------------------------------
+
+type input struct {
+	mode      string
+	address   string
+	unitID    byte
+	registers []register
+	interval  time.Duration
+	timeout   time.Duration
+
+	log *service.Logger
+
+	connMut  sync.Mutex
+	conn     transport
+	lastPoll time.Time
+}
+
+func newInputFromConfig(conf *service.ParsedConfig, log *service.Logger) (*input, error) {
+	i := &input{log: log}
+
+	var err error
+	if i.mode, err = conf.FieldString(mbFieldTransport); err != nil {
+		return nil, err
+	}
+	if i.address, err = conf.FieldString(mbFieldAddress); err != nil {
+		return nil, err
+	}
+
+	unitID, err := conf.FieldInt(mbFieldUnitID)
+	if err != nil {
+		return nil, err
+	}
+	if unitID < 0 || unitID > 255 {
+		return nil, fmt.Errorf("unit ID %v is out of range", unitID)
+	}
+	i.unitID = byte(unitID)
+
+	regConfs, err := conf.FieldObjectList(mbFieldRegisters)
+	if err != nil {
+		return nil, err
+	}
+	if len(regConfs) == 0 {
+		return nil, errors.New("at least one register must be specified")
+	}
+	names := map[string]struct{}{}
+	for n, rConf := range regConfs {
+		r, err := registerFromConfig(rConf)
+		if err != nil {
+			return nil, fmt.Errorf("register %v: %w", n, err)
+		}
+		if _, exists := names[r.name]; exists {
+			return nil, fmt.Errorf("register %v: name %v is not unique", n, r.name)
+		}
+		names[r.name] = struct{}{}
+		i.registers = append(i.registers, r)
+	}
+
+	if i.interval, err = conf.FieldDuration(mbFieldInterval); err != nil {
+		return nil, err
+	}
+	if i.timeout, err = conf.FieldDuration(mbFieldTimeout); err != nil {
+		return nil, err
+	}
+	return i, nil
+}
+
+func (i *input) Connect(ctx context.Context) error {
+	i.connMut.Lock()
+	defer i.connMut.Unlock()
+
+	if i.conn != nil {
+		return nil
+	}
+	conn, err := dialTransport(i.mode, i.address, i.timeout)
+	if err != nil {
+		return err
+	}
+	i.conn = conn
+	return nil
+}
+
+// disconnect closes the transport after an error that could have left it out
+// of sync with the device.
+func (i *input) disconnect() {
+	i.connMut.Lock()
+	defer i.connMut.Unlock()
+
+	if i.conn != nil {
+		_ = i.conn.close()
+		i.conn = nil
+	}
+}
+
+func (i *input) Read(ctx context.Context) (*service.Message, service.AckFunc, error) {
+	if !i.lastPoll.IsZero() {
+		select {
+		case <-time.After(time.Until(i.lastPoll.Add(i.interval))):
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		}
+	}
+	i.lastPoll = time.Now()
+
+	i.connMut.Lock()
+	conn := i.conn
+	i.connMut.Unlock()
+	if conn == nil {
+		return nil, nil, service.ErrNotConnected
+	}
+
+	values := make(map[string]interface{}, len(i.registers))
+	for _, r := range i.registers {
+		data, err := read(conn, i.unitID, r.function, r.address, r.quantity)
+		if err != nil {
+			var exErr *exceptionError
+			if errors.As(err, &exErr) {
+				return nil, nil, fmt.Errorf("failed to read register %v: %w", r.name, err)
+			}
+			i.log.Errorf("Failed to read register %v: %v\n", r.name, err)
+			i.disconnect()
+			return nil, nil, service.ErrNotConnected
+		}
+		if values[r.name], err = r.decode(data); err != nil {
+			return nil, nil, fmt.Errorf("failed to decode register %v: %w", r.name, err)
+		}
+	}
+
+	msg := service.NewMessage(nil)
+	msg.SetStructured(values)
+	msg.MetaSet("modbus_address", i.address)
+	msg.MetaSet("modbus_unit_id", strconv.Itoa(int(i.unitID)))
+	return msg, func(context.Context, error) error {
+		return nil
+	}, nil
+}
+
+func (i *input) Close(ctx context.Context) error {
+	i.disconnect()
+	return nil
+}
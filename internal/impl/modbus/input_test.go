@@ -0,0 +1,202 @@
+package modbus
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRegister(t *testing.T, conf string) register {
+	t.Helper()
+
+	parsed, err := inputConfig().ParseYAML(`
+address: localhost:502
+registers:
+  - `+conf, nil)
+	require.NoError(t, err)
+
+	regs, err := parsed.FieldObjectList(mbFieldRegisters)
+	require.NoError(t, err)
+
+	r, err := registerFromConfig(regs[0])
+	require.NoError(t, err)
+	return r
+}
+
+func TestRegisterDecode(t *testing.T) {
+	tests := []struct {
+		conf     string
+		data     []byte
+		expected interface{}
+	}{
+		{
+			conf:     `{ name: a, address: 0, type: int16 }`,
+			data:     []byte{0xFF, 0xFE},
+			expected: int64(-2),
+		},
+		{
+			conf:     `{ name: a, address: 0, type: uint16, byte_order: little }`,
+			data:     []byte{0x34, 0x12},
+			expected: uint64(0x1234),
+		},
+		{
+			conf:     `{ name: a, address: 0, type: float32 }`,
+			data:     []byte{0x43, 0x66, 0x80, 0x00},
+			expected: float64(230.5),
+		},
+		{
+			conf:     `{ name: a, address: 0, type: float32, word_order: little }`,
+			data:     []byte{0x80, 0x00, 0x43, 0x66},
+			expected: float64(230.5),
+		},
+		{
+			conf:     `{ name: a, address: 0, type: float32, byte_order: little, word_order: little }`,
+			data:     []byte{0x00, 0x80, 0x66, 0x43},
+			expected: float64(230.5),
+		},
+		{
+			conf:     `{ name: a, address: 0, type: int32 }`,
+			data:     []byte{0xFF, 0xFF, 0xFF, 0x9C},
+			expected: int64(-100),
+		},
+		{
+			conf:     `{ name: a, address: 0, type: uint32, scale: 0.5 }`,
+			data:     []byte{0x00, 0x01, 0x00, 0x00},
+			expected: float64(32768),
+		},
+		{
+			conf:     `{ name: a, address: 0, type: uint64, word_order: little }`,
+			data:     []byte{0x00, 0x04, 0x00, 0x03, 0x00, 0x02, 0x00, 0x01},
+			expected: uint64(0x0001000200030004),
+		},
+		{
+			conf:     `{ name: a, address: 0, type: float64 }`,
+			data:     []byte{0x40, 0x09, 0x21, 0xFB, 0x54, 0x44, 0x2D, 0x18},
+			expected: 3.141592653589793,
+		},
+		{
+			conf:     `{ name: a, address: 0, type: bool }`,
+			data:     []byte{0x00, 0x02},
+			expected: true,
+		},
+		{
+			conf:     `{ name: a, table: coil, address: 0, type: float32 }`,
+			data:     []byte{0x01},
+			expected: true,
+		},
+	}
+
+	for _, test := range tests {
+		r := newTestRegister(t, test.conf)
+		v, err := r.decode(test.data)
+		require.NoError(t, err, test.conf)
+		assert.Equal(t, test.expected, v, test.conf)
+	}
+}
+
+func TestInputPoll(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+
+	d := &testDevice{
+		registers: map[byte]map[uint16]uint16{
+			fnReadInputRegisters:   {0: 0x4366, 1: 0x8000, 72: 0x04D2, 73: 0x0000},
+			fnReadHoldingRegisters: {5: 0xFFFF},
+		},
+		coils: map[byte]map[uint16]bool{
+			fnReadCoils: {0: true},
+		},
+	}
+	go d.serveTCP(t, ln)
+
+	parsed, err := inputConfig().ParseYAML(`
+address: `+ln.Addr().String()+`
+unit_id: 3
+interval: 10ms
+registers:
+  - name: voltage
+    table: input
+    address: 0
+    type: float32
+  - name: energy_kwh
+    table: input
+    address: 72
+    type: uint32
+    word_order: little
+    scale: 0.5
+  - name: setpoint
+    address: 5
+    type: int16
+  - name: relay_closed
+    table: coil
+    address: 0
+`, nil)
+	require.NoError(t, err)
+
+	i, err := newInputFromConfig(parsed, nil)
+	require.NoError(t, err)
+	require.NoError(t, i.Connect(context.Background()))
+	defer i.Close(context.Background())
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*5)
+	defer done()
+
+	for n := 0; n < 2; n++ {
+		msg, _, err := i.Read(ctx)
+		require.NoError(t, err)
+
+		v, err := msg.AsStructured()
+		require.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{
+			"voltage":      float64(230.5),
+			"energy_kwh":   float64(617),
+			"setpoint":     int64(-1),
+			"relay_closed": true,
+		}, v)
+
+		unitID, _ := msg.MetaGet("modbus_unit_id")
+		assert.Equal(t, "3", unitID)
+	}
+
+	d.mut.Lock()
+	d.registers[fnReadHoldingRegisters] = nil
+	d.mut.Unlock()
+	_, _, err = i.Read(ctx)
+	assert.EqualError(t, err, "failed to read register setpoint: device responded to function 0x03 with exception 0x02 (illegal data address)")
+}
+
+func TestInputConfigErrors(t *testing.T) {
+	for _, conf := range []string{
+		`
+address: localhost:502
+registers: []
+`,
+		`
+address: localhost:502
+unit_id: 300
+registers:
+  - { name: a, address: 0 }
+`,
+		`
+address: localhost:502
+registers:
+  - { name: a, address: 0 }
+  - { name: a, address: 1 }
+`,
+		`
+address: localhost:502
+registers:
+  - { name: a, address: 70000 }
+`,
+	} {
+		parsed, err := inputConfig().ParseYAML(conf, nil)
+		require.NoError(t, err, conf)
+		_, err = newInputFromConfig(parsed, nil)
+		assert.Error(t, err, conf)
+	}
+}
@@ -0,0 +1,37 @@
+package nats
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func TestInputObjectStoreConfigParse(t *testing.T) {
+	spec := natsObjectStoreInputConfig()
+	env := service.NewEnvironment()
+
+	inputConfig := `
+urls: [ url1, url2 ]
+bucket: testbucket
+watch: true
+auth:
+  nkey_file: test auth n key file
+  user_credentials_file: test auth user creds file
+`
+
+	conf, err := spec.ParseYAML(inputConfig, env)
+	require.NoError(t, err)
+
+	e, err := newObjectStoreReaderFromConfig(conf, nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, "url1,url2", e.urls)
+	assert.Equal(t, "testbucket", e.bucket)
+	assert.True(t, e.watch)
+	assert.False(t, e.deleteObjects)
+	assert.Equal(t, "test auth n key file", e.authConf.NKeyFile)
+	assert.Equal(t, "test auth user creds file", e.authConf.UserCredentialsFile)
+}
@@ -0,0 +1,136 @@
+package nats
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/ory/dockertest/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/integration"
+)
+
+func TestIntegrationNatsKVCache(t *testing.T) {
+	integration.CheckSkip(t)
+	t.Parallel()
+
+	pool, err := dockertest.NewPool("")
+	require.NoError(t, err)
+
+	pool.MaxWait = time.Second * 30
+	resource, err := pool.RunWithOptions(&dockertest.RunOptions{
+		Repository: "nats",
+		Tag:        "latest",
+		Cmd:        []string{"--js"},
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, pool.Purge(resource))
+	})
+
+	var natsConn *nats.Conn
+	_ = resource.Expire(900)
+	require.NoError(t, pool.Retry(func() error {
+		natsConn, err = nats.Connect(fmt.Sprintf("tcp://localhost:%v", resource.GetPort("4222/tcp")))
+		return err
+	}))
+	t.Cleanup(func() {
+		natsConn.Close()
+	})
+
+	template := `
+cache_resources:
+  - label: testcache
+    nats_kv:
+      urls: [ nats://localhost:$PORT ]
+      bucket: bucket-$ID
+`
+	suite := integration.CacheTests(
+		integration.CacheTestOpenClose(),
+		integration.CacheTestMissingKey(),
+		integration.CacheTestDoubleAdd(),
+		integration.CacheTestDelete(),
+		integration.CacheTestGetAndSet(50),
+	)
+	suite.Run(
+		t, template,
+		integration.CacheTestOptPreTest(func(t testing.TB, ctx context.Context, testID string, vars *integration.CacheTestConfigVars) {
+			js, err := natsConn.JetStream()
+			require.NoError(t, err)
+
+			_, err = js.CreateKeyValue(&nats.KeyValueConfig{
+				Bucket: "bucket-" + testID,
+			})
+			require.NoError(t, err)
+		}),
+		integration.CacheTestOptPort(resource.GetPort("4222/tcp")),
+	)
+}
+
+func TestIntegrationNatsObjectStore(t *testing.T) {
+	integration.CheckSkip(t)
+	t.Parallel()
+
+	pool, err := dockertest.NewPool("")
+	require.NoError(t, err)
+
+	pool.MaxWait = time.Second * 30
+	resource, err := pool.RunWithOptions(&dockertest.RunOptions{
+		Repository: "nats",
+		Tag:        "latest",
+		Cmd:        []string{"--js"},
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, pool.Purge(resource))
+	})
+
+	var natsConn *nats.Conn
+	_ = resource.Expire(900)
+	require.NoError(t, pool.Retry(func() error {
+		natsConn, err = nats.Connect(fmt.Sprintf("tcp://localhost:%v", resource.GetPort("4222/tcp")))
+		return err
+	}))
+	t.Cleanup(func() {
+		natsConn.Close()
+	})
+
+	template := `
+output:
+  nats_object_store:
+    urls: [ nats://localhost:$PORT ]
+    bucket: bucket-$ID
+    name: ${! uuid_v4() }
+
+input:
+  nats_object_store:
+    urls: [ nats://localhost:$PORT ]
+    bucket: bucket-$ID
+    watch: true
+`
+	suite := integration.StreamTests(
+		integration.StreamTestOpenClose(),
+		integration.StreamTestSendBatch(10),
+		integration.StreamTestStreamParallel(100),
+		integration.StreamTestStreamSequential(100),
+	)
+	suite.Run(
+		t, template,
+		integration.StreamTestOptPreTest(func(t testing.TB, ctx context.Context, testID string, vars *integration.StreamTestConfigVars) {
+			js, err := natsConn.JetStream()
+			require.NoError(t, err)
+
+			_, err = js.CreateObjectStore(&nats.ObjectStoreConfig{
+				Bucket: "bucket-" + testID,
+			})
+			require.NoError(t, err)
+		}),
+		integration.StreamTestOptSleepAfterInput(100*time.Millisecond),
+		integration.StreamTestOptSleepAfterOutput(100*time.Millisecond),
+		integration.StreamTestOptPort(resource.GetPort("4222/tcp")),
+	)
+}
@@ -0,0 +1,41 @@
+package nats
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func TestOutputObjectStoreConfigParse(t *testing.T) {
+	spec := natsObjectStoreOutputConfig()
+	env := service.NewEnvironment()
+
+	outputConfig := `
+urls: [ url1, url2 ]
+bucket: testbucket
+name: ${! meta("path") }
+headers:
+  Content-Type: application/json
+auth:
+  nkey_file: test auth n key file
+  user_credentials_file: test auth user creds file
+`
+
+	conf, err := spec.ParseYAML(outputConfig, env)
+	require.NoError(t, err)
+
+	e, err := newObjectStoreWriterFromConfig(conf, nil)
+	require.NoError(t, err)
+
+	msg := service.NewMessage(nil)
+	msg.MetaSet("path", "foo/bar.json")
+	assert.Equal(t, "url1,url2", e.urls)
+	assert.Equal(t, "testbucket", e.bucket)
+	assert.Equal(t, "foo/bar.json", e.name.String(msg))
+	assert.Equal(t, "application/json", e.headers["Content-Type"].String(msg))
+	assert.Equal(t, "test auth n key file", e.authConf.NKeyFile)
+	assert.Equal(t, "test auth user creds file", e.authConf.UserCredentialsFile)
+}
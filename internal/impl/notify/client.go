@@ -0,0 +1,179 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/transaction"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	nfFieldMaxRetries  = "max_retries"
+	nfFieldMaxInFlight = "max_in_flight"
+)
+
+func maxRetriesField() *service.ConfigField {
+	return service.NewIntField(nfFieldMaxRetries).
+		Description("The maximum number of times a request is retried after being rate limited or rejected by an unavailable service or gateway. Other errors are not retried as the request might already have been processed. Rate limited requests are retried after the period requested by the service.").
+		Advanced().
+		Default(3)
+}
+
+func maxInFlightField() *service.ConfigField {
+	return service.NewIntField(nfFieldMaxInFlight).
+		Description("The maximum number of messages to have in flight at a given time. Increase this to improve throughput.").
+		Default(1)
+}
+
+// apiError is returned when a request is rejected by a service.
+type apiError struct {
+	status int
+	body   string
+}
+
+func (e *apiError) Error() string {
+	body := e.body
+	if len(body) > 256 {
+		body = body[:256] + "..."
+	}
+	return fmt.Sprintf("request failed with status %v: %v", e.status, body)
+}
+
+// apiClient sends requests to the HTTP APIs of notification services, where
+// requests that are rate limited or rejected by an unavailable service are
+// retried.
+type apiClient struct {
+	client     *http.Client
+	maxRetries int
+	backoff    time.Duration
+	log        *service.Logger
+}
+
+func newAPIClient(maxRetries int, log *service.Logger) *apiClient {
+	return &apiClient{
+		client:     &http.Client{Timeout: time.Second * 30},
+		maxRetries: maxRetries,
+		backoff:    time.Second,
+		log:        log,
+	}
+}
+
+// retryableStatus returns whether a response status indicates that a request
+// was not processed and can be retried safely. Other server errors are not
+// retried, as a request might have been processed before failing, and
+// retrying it could result in duplicate notifications.
+func retryableStatus(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable:
+		return true
+	}
+	return false
+}
+
+// retryAfter returns the period to wait before retrying a request, preferring
+// the period requested by the service.
+func (c *apiClient) retryAfter(res *http.Response, attempt int) time.Duration {
+	for _, h := range []string{"Retry-After", "X-RateLimit-Reset-After"} {
+		if v := res.Header.Get(h); v != "" {
+			if secs, err := strconv.ParseFloat(v, 64); err == nil && secs >= 0 {
+				return time.Duration(secs * float64(time.Second))
+			}
+		}
+	}
+	return c.backoff << attempt
+}
+
+// send executes a request created by newReq, returning the body of a
+// successful response.
+func (c *apiClient) send(ctx context.Context, newReq func(ctx context.Context) (*http.Request, error)) ([]byte, error) {
+	for attempt := 0; ; attempt++ {
+		req, err := newReq(ctx)
+		if err != nil {
+			return nil, err
+		}
+		res, err := c.client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		resBytes, err := io.ReadAll(res.Body)
+		res.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		if retryableStatus(res.StatusCode) {
+			if attempt >= c.maxRetries {
+				return nil, &apiError{status: res.StatusCode, body: string(resBytes)}
+			}
+			wait := c.retryAfter(res, attempt)
+			c.log.Debugf("Request to %v failed with status %v, retrying in %v", req.URL.Host, res.StatusCode, wait)
+			select {
+			case <-time.After(wait):
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+			continue
+		}
+		if res.StatusCode < 200 || res.StatusCode > 299 {
+			return nil, &apiError{status: res.StatusCode, body: string(resBytes)}
+		}
+		return resBytes, nil
+	}
+}
+
+// sendJSON sends a JSON document and parses the JSON response into res when it
+// is not nil.
+func (c *apiClient) sendJSON(ctx context.Context, method, url string, headers map[string]string, body, res interface{}) error {
+	bodyBytes, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	resBytes, err := c.send(ctx, func(ctx context.Context) (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(bodyBytes))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		return req, nil
+	})
+	if err != nil || res == nil || len(resBytes) == 0 {
+		return err
+	}
+	return json.Unmarshal(resBytes, res)
+}
+
+// propagateResponse adds a copy of a message with additional metadata to the
+// result store of the message, when it has one, so that the result of sending
+// it is propagated back to inputs that support synchronous responses.
+func propagateResponse(msg *service.Message, meta map[string]string, log *service.Logger) {
+	msgBytes, err := msg.AsBytes()
+	if err != nil {
+		return
+	}
+	part := message.NewPart(msgBytes)
+	_ = msg.MetaWalk(func(k, v string) error {
+		part.MetaSet(k, v)
+		return nil
+	})
+	for k, v := range meta {
+		part.MetaSet(k, v)
+	}
+
+	batch := message.QuickBatch(nil)
+	batch.Append(message.WithContext(msg.Context(), part))
+	if err := transaction.SetAsResponse(batch); err != nil && !errors.Is(err, transaction.ErrNoStore) {
+		log.Warnf("Unable to propagate response to input: %v", err)
+	}
+}
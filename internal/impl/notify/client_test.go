@@ -0,0 +1,80 @@
+package notify
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testAPIServer struct {
+	mut        sync.Mutex
+	requests   []map[string]interface{}
+	paths      []string
+	authHeader string
+
+	// Responses to return for the requests received, where the last response
+	// is repeated.
+	responses []testAPIResponse
+}
+
+type testAPIResponse struct {
+	status  int
+	headers map[string]string
+	body    string
+}
+
+func (s *testAPIServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	var body map[string]interface{}
+	if r.Header.Get("Content-Type") == "application/x-www-form-urlencoded" {
+		_ = r.ParseForm()
+		body = map[string]interface{}{}
+		for k := range r.PostForm {
+			body[k] = r.PostForm.Get(k)
+		}
+	} else {
+		_ = json.NewDecoder(r.Body).Decode(&body)
+	}
+	s.requests = append(s.requests, body)
+	s.paths = append(s.paths, r.URL.RequestURI())
+	s.authHeader = r.Header.Get("Authorization")
+
+	res := s.responses[0]
+	if len(s.responses) > 1 {
+		s.responses = s.responses[1:]
+	}
+	for k, v := range res.headers {
+		w.Header().Set(k, v)
+	}
+	w.WriteHeader(res.status)
+	_, _ = w.Write([]byte(res.body))
+}
+
+func TestAPIClientRetryAfter(t *testing.T) {
+	c := newAPIClient(3, nil)
+
+	for _, test := range []struct {
+		headers  map[string]string
+		attempt  int
+		expected time.Duration
+	}{
+		{map[string]string{"Retry-After": "2"}, 0, time.Second * 2},
+		{map[string]string{"Retry-After": "0.5"}, 2, time.Millisecond * 500},
+		{map[string]string{"X-RateLimit-Reset-After": "1.25"}, 0, time.Millisecond * 1250},
+		{map[string]string{"Retry-After": "Wed, 21 Oct 2015 07:28:00 GMT"}, 1, time.Second * 2},
+		{nil, 0, time.Second},
+		{nil, 2, time.Second * 4},
+	} {
+		res := &http.Response{Header: http.Header{}}
+		for k, v := range test.headers {
+			res.Header.Set(k, v)
+		}
+		assert.Equal(t, test.expected, c.retryAfter(res, test.attempt), test.headers)
+	}
+}
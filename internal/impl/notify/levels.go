@@ -0,0 +1,58 @@
+package notify
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+// levelMapper resolves an interpolated value, such as a severity or action,
+// into one of a fixed set of values accepted by a service, where values can be
+// translated with a map of aliases.
+type levelMapper struct {
+	name    string
+	value   *service.InterpolatedString
+	aliases map[string]string
+	allowed []string
+}
+
+func newLevelMapper(conf *service.ParsedConfig, field, mapField string, allowed ...string) (*levelMapper, error) {
+	l := &levelMapper{name: field, allowed: allowed}
+
+	var err error
+	if l.value, err = conf.FieldInterpolatedString(field); err != nil {
+		return nil, err
+	}
+	if mapField != "" {
+		if l.aliases, err = conf.FieldStringMap(mapField); err != nil {
+			return nil, err
+		}
+	}
+	for k, v := range l.aliases {
+		if !l.isAllowed(v) {
+			return nil, fmt.Errorf("%v maps %v to %v, expected one of: %v", mapField, k, v, strings.Join(allowed, ", "))
+		}
+	}
+	return l, nil
+}
+
+func (l *levelMapper) isAllowed(v string) bool {
+	for _, a := range l.allowed {
+		if a == v {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *levelMapper) resolve(msg *service.Message) (string, error) {
+	v := l.value.String(msg)
+	if alias, exists := l.aliases[v]; exists {
+		v = alias
+	}
+	if !l.isAllowed(v) {
+		return "", fmt.Errorf("%v resolved to %q, expected one of: %v", l.name, v, strings.Join(l.allowed, ", "))
+	}
+	return v, nil
+}
@@ -0,0 +1,315 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/benthosdev/benthos/v4/public/bloblang"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	dcFieldBotToken   = "bot_token"
+	dcFieldChannelID  = "channel_id"
+	dcFieldContent    = "content"
+	dcFieldEmbed      = "embed"
+	dcFieldEmbedTitle = "title"
+	dcFieldEmbedDesc  = "description"
+	dcFieldEmbedURL   = "url"
+	dcFieldEmbedColor = "color"
+	dcFieldEmbedField = "fields"
+	dcFieldEmbeds     = "embeds"
+	dcFieldReplyTo    = "reply_to"
+	dcFieldAPIURL     = "api_url"
+)
+
+func discordOutputConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		// Stable(). TODO
+		Categories("Services").
+		Summary("Posts messages to a Discord channel using the HTTP API.").
+		Description(`
+Each message is posted to a channel with the [create message](https://discord.com/developers/docs/resources/channel#create-message) endpoint, authenticated with the token of a bot that has permission to send messages within the channel. Messages are posted to threads by setting the channel ID to the ID of the thread.
+
+### Formatting
+
+By default the contents of each message are posted as the content of the Discord message. Messages can also include an embed, either constructed with the field ` + "`embed`" + `, or by providing a [Bloblang mapping](/docs/guides/bloblang/about) with the field ` + "`embeds`" + ` that returns an array of embed objects.
+
+### Rate Limits
+
+Requests that are rate limited are retried after the period specified by the ` + "`Retry-After`" + ` header of the response, up to the number of attempts specified by ` + "`max_retries`" + `.`).
+		Field(service.NewStringField(dcFieldBotToken).
+			Description("A bot token used to authenticate requests.")).
+		Field(service.NewInterpolatedStringField(dcFieldChannelID).
+			Description("The ID of the channel or thread to post messages to.").
+			Example("1000000000000000000").
+			Example(`${! meta("discord_channel") }`)).
+		Field(service.NewInterpolatedStringField(dcFieldContent).
+			Description("The content of each message, which may contain Discord markdown. Messages without an embed must have content.").
+			Default(`${! content() }`)).
+		Field(service.NewObjectField(dcFieldEmbed,
+			service.NewInterpolatedStringField(dcFieldEmbedTitle).
+				Description("The title of the embed.").
+				Optional(),
+			service.NewInterpolatedStringField(dcFieldEmbedDesc).
+				Description("The description of the embed.").
+				Optional(),
+			service.NewInterpolatedStringField(dcFieldEmbedURL).
+				Description("A URL that the title of the embed links to.").
+				Optional(),
+			service.NewIntField(dcFieldEmbedColor).
+				Description("The color of the embed as an integer RGB value.").
+				Example(0xff0000).
+				Optional(),
+			service.NewInterpolatedStringMapField(dcFieldEmbedField).
+				Description("A map of inline fields to add to the embed, sorted by their key.").
+				Example(map[string]interface{}{
+					"Severity": `${! json("severity") }`,
+				}).
+				Optional(),
+		).
+			Description("An optional embed to add to each message, which is omitted when all of its fields resolve to empty strings.").
+			Optional()).
+		Field(service.NewBloblangField(dcFieldEmbeds).
+			Description("An optional Bloblang mapping that returns an array of embed objects for each message, which takes precedence over the field `embed`.").
+			Example(`root = [{"title": this.service, "description": this.summary, "color": 16711680}]`).
+			Optional()).
+		Field(service.NewInterpolatedStringField(dcFieldReplyTo).
+			Description("An optional ID of a message within the channel, in which case messages are posted as replies to it. Messages are posted without a reference when this resolves to an empty string.").
+			Example(`${! meta("discord_message_id").or("") }`).
+			Optional()).
+		Field(service.NewStringField(dcFieldAPIURL).
+			Description("The base URL of the Discord HTTP API.").
+			Advanced().
+			Default("https://discord.com/api/v10")).
+		Field(maxRetriesField()).
+		Field(maxInFlightField())
+}
+
+func init() {
+	err := service.RegisterOutput(
+		"discord", discordOutputConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Output, int, error) {
+			maxInFlight, err := conf.FieldInt(nfFieldMaxInFlight)
+			if err != nil {
+				return nil, 0, err
+			}
+			w, err := newDiscordWriterFromConfig(conf, mgr.Logger())
+			return w, maxInFlight, err
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type discordEmbed struct {
+	title       *service.InterpolatedString
+	description *service.InterpolatedString
+	url         *service.InterpolatedString
+	color       int
+	fields      map[string]*service.InterpolatedString
+}
+
+func discordEmbedFromConfig(conf *service.ParsedConfig) (*discordEmbed, error) {
+	e := &discordEmbed{}
+
+	var err error
+	for _, f := range []struct {
+		name string
+		dst  **service.InterpolatedString
+	}{
+		{dcFieldEmbedTitle, &e.title},
+		{dcFieldEmbedDesc, &e.description},
+		{dcFieldEmbedURL, &e.url},
+	} {
+		if conf.Contains(f.name) {
+			if *f.dst, err = conf.FieldInterpolatedString(f.name); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if conf.Contains(dcFieldEmbedColor) {
+		if e.color, err = conf.FieldInt(dcFieldEmbedColor); err != nil {
+			return nil, err
+		}
+	}
+	if conf.Contains(dcFieldEmbedField) {
+		if e.fields, err = conf.FieldInterpolatedStringMap(dcFieldEmbedField); err != nil {
+			return nil, err
+		}
+	}
+	return e, nil
+}
+
+// build returns the embed of a message, or nil if all of its fields are empty.
+func (e *discordEmbed) build(msg *service.Message) map[string]interface{} {
+	embed := map[string]interface{}{}
+	for k, v := range map[string]*service.InterpolatedString{
+		"title":       e.title,
+		"description": e.description,
+		"url":         e.url,
+	} {
+		if v == nil {
+			continue
+		}
+		if s := v.String(msg); s != "" {
+			embed[k] = s
+		}
+	}
+
+	keys := make([]string, 0, len(e.fields))
+	for k := range e.fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var fields []interface{}
+	for _, k := range keys {
+		if v := e.fields[k].String(msg); v != "" {
+			fields = append(fields, map[string]interface{}{
+				"name":   k,
+				"value":  v,
+				"inline": true,
+			})
+		}
+	}
+	if len(fields) > 0 {
+		embed["fields"] = fields
+	}
+
+	if len(embed) == 0 {
+		return nil
+	}
+	if e.color != 0 {
+		embed["color"] = e.color
+	}
+	return embed
+}
+
+type discordWriter struct {
+	token     string
+	channelID *service.InterpolatedString
+	content   *service.InterpolatedString
+	embed     *discordEmbed
+	embeds    *bloblang.Executor
+	replyTo   *service.InterpolatedString
+	apiURL    string
+
+	client *apiClient
+}
+
+func newDiscordWriterFromConfig(conf *service.ParsedConfig, log *service.Logger) (*discordWriter, error) {
+	d := &discordWriter{}
+
+	var err error
+	if d.token, err = conf.FieldString(dcFieldBotToken); err != nil {
+		return nil, err
+	}
+	if d.token == "" {
+		return nil, errors.New("a bot token must be specified")
+	}
+	if d.channelID, err = conf.FieldInterpolatedString(dcFieldChannelID); err != nil {
+		return nil, err
+	}
+	if d.content, err = conf.FieldInterpolatedString(dcFieldContent); err != nil {
+		return nil, err
+	}
+	if conf.Contains(dcFieldEmbed) {
+		if d.embed, err = discordEmbedFromConfig(conf.Namespace(dcFieldEmbed)); err != nil {
+			return nil, err
+		}
+	}
+	if conf.Contains(dcFieldEmbeds) {
+		if d.embeds, err = conf.FieldBloblang(dcFieldEmbeds); err != nil {
+			return nil, err
+		}
+	}
+	if conf.Contains(dcFieldReplyTo) {
+		if d.replyTo, err = conf.FieldInterpolatedString(dcFieldReplyTo); err != nil {
+			return nil, err
+		}
+	}
+	if d.apiURL, err = conf.FieldString(dcFieldAPIURL); err != nil {
+		return nil, err
+	}
+	d.apiURL = strings.TrimSuffix(d.apiURL, "/")
+
+	maxRetries, err := conf.FieldInt(nfFieldMaxRetries)
+	if err != nil {
+		return nil, err
+	}
+	d.client = newAPIClient(maxRetries, log)
+	return d, nil
+}
+
+func (d *discordWriter) Connect(ctx context.Context) error {
+	return nil
+}
+
+func (d *discordWriter) buildEmbeds(msg *service.Message) ([]interface{}, error) {
+	if d.embeds != nil {
+		res, err := msg.BloblangQuery(d.embeds)
+		if err != nil {
+			return nil, fmt.Errorf("embeds mapping failed: %w", err)
+		}
+		v, err := res.AsStructured()
+		if err != nil {
+			return nil, fmt.Errorf("embeds mapping failed: %w", err)
+		}
+		embeds, ok := v.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("embeds mapping must return an array, got %T", v)
+		}
+		return embeds, nil
+	}
+	if d.embed != nil {
+		if embed := d.embed.build(msg); embed != nil {
+			return []interface{}{embed}, nil
+		}
+	}
+	return nil, nil
+}
+
+func (d *discordWriter) Write(ctx context.Context, msg *service.Message) error {
+	channelID := d.channelID.String(msg)
+	if channelID == "" {
+		return errors.New("channel ID resolved to an empty string")
+	}
+
+	body := map[string]interface{}{}
+	if content := d.content.String(msg); content != "" {
+		body["content"] = content
+	}
+	embeds, err := d.buildEmbeds(msg)
+	if err != nil {
+		return err
+	}
+	if len(embeds) > 0 {
+		body["embeds"] = embeds
+	}
+	if len(body) == 0 {
+		return errors.New("message has neither content nor embeds")
+	}
+	if d.replyTo != nil {
+		if id := d.replyTo.String(msg); id != "" {
+			body["message_reference"] = map[string]interface{}{
+				"message_id":         id,
+				"fail_if_not_exists": false,
+			}
+		}
+	}
+
+	return d.client.sendJSON(ctx, "POST", d.apiURL+"/channels/"+url.PathEscape(channelID)+"/messages", map[string]string{
+		"Authorization": "Bot " + d.token,
+	}, body, nil)
+}
+
+func (d *discordWriter) Close(ctx context.Context) error {
+	return nil
+}
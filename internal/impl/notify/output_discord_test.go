@@ -0,0 +1,185 @@
+package notify
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func newDiscordTestWriter(t *testing.T, apiURL, conf string) *discordWriter {
+	t.Helper()
+
+	parsed, err := discordOutputConfig().ParseYAML(conf+"\napi_url: "+apiURL+"\n", nil)
+	require.NoError(t, err)
+
+	w, err := newDiscordWriterFromConfig(parsed, nil)
+	require.NoError(t, err)
+
+	w.client.backoff = time.Millisecond
+	return w
+}
+
+func TestDiscordOutputContent(t *testing.T) {
+	srv := &testAPIServer{responses: []testAPIResponse{{status: 200, body: `{"id":"1"}`}}}
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	w := newDiscordTestWriter(t, ts.URL, `
+bot_token: foo
+channel_id: ${! meta("channel").or("") }
+reply_to: ${! meta("reply_to").or("") }
+`)
+
+	msg := service.NewMessage([]byte("hello world"))
+	msg.MetaSet("channel", "123")
+	require.NoError(t, w.Write(context.Background(), msg))
+
+	msg = service.NewMessage([]byte("a reply"))
+	msg.MetaSet("channel", "456")
+	msg.MetaSet("reply_to", "789")
+	require.NoError(t, w.Write(context.Background(), msg))
+
+	assert.Equal(t, "Bot foo", srv.authHeader)
+	assert.Equal(t, []string{"/channels/123/messages", "/channels/456/messages"}, srv.paths)
+	assert.Equal(t, []map[string]interface{}{
+		{"content": "hello world"},
+		{"content": "a reply", "message_reference": map[string]interface{}{
+			"message_id": "789", "fail_if_not_exists": false,
+		}},
+	}, srv.requests)
+}
+
+func TestDiscordOutputEmbed(t *testing.T) {
+	srv := &testAPIServer{responses: []testAPIResponse{{status: 200, body: `{"id":"1"}`}}}
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	w := newDiscordTestWriter(t, ts.URL, `
+bot_token: foo
+channel_id: "123"
+content: ""
+embed:
+  title: ${! json("name") }
+  description: ${! json("summary") }
+  color: 16711680
+  fields:
+    Severity: ${! json("severity") }
+    Host: ${! json("host") }
+`)
+
+	require.NoError(t, w.Write(context.Background(), service.NewMessage([]byte(
+		`{"name":"disk full","summary":"disk is full","severity":"high","host":"a"}`,
+	))))
+
+	require.Len(t, srv.requests, 1)
+	assert.Equal(t, map[string]interface{}{
+		"embeds": []interface{}{
+			map[string]interface{}{
+				"title":       "disk full",
+				"description": "disk is full",
+				"color":       float64(16711680),
+				"fields": []interface{}{
+					map[string]interface{}{"name": "Host", "value": "a", "inline": true},
+					map[string]interface{}{"name": "Severity", "value": "high", "inline": true},
+				},
+			},
+		},
+	}, srv.requests[0])
+
+	// An embed where every field is empty is omitted, and the message is
+	// rejected as it has no content either.
+	w = newDiscordTestWriter(t, ts.URL, `
+bot_token: foo
+channel_id: "123"
+content: ""
+embed:
+  title: ${! meta("title").or("") }
+  color: 16711680
+`)
+	err := w.Write(context.Background(), service.NewMessage([]byte(`{}`)))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "neither content nor embeds")
+	assert.Len(t, srv.requests, 1)
+}
+
+func TestDiscordOutputEmbedsMapping(t *testing.T) {
+	srv := &testAPIServer{responses: []testAPIResponse{{status: 200, body: `{"id":"1"}`}}}
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	w := newDiscordTestWriter(t, ts.URL, `
+bot_token: foo
+channel_id: "123"
+content: ""
+embeds: 'root = [{"title": this.name}]'
+`)
+	require.NoError(t, w.Write(context.Background(), service.NewMessage([]byte(`{"name":"foo"}`))))
+
+	require.Len(t, srv.requests, 1)
+	assert.Equal(t, map[string]interface{}{
+		"embeds": []interface{}{map[string]interface{}{"title": "foo"}},
+	}, srv.requests[0])
+}
+
+func TestDiscordOutputRateLimited(t *testing.T) {
+	srv := &testAPIServer{responses: []testAPIResponse{
+		{status: 429, headers: map[string]string{"Retry-After": "0.001"}, body: `{"message":"You are being rate limited.","retry_after":0.001}`},
+		{status: 502, body: `bad gateway`},
+		{status: 200, body: `{"id":"1"}`},
+	}}
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	w := newDiscordTestWriter(t, ts.URL, `
+bot_token: foo
+channel_id: "123"
+`)
+	require.NoError(t, w.Write(context.Background(), service.NewMessage([]byte("hello"))))
+	assert.Len(t, srv.requests, 3)
+}
+
+func TestDiscordOutputServerErrorNotRetried(t *testing.T) {
+	srv := &testAPIServer{responses: []testAPIResponse{
+		{status: 500, body: `internal error`},
+		{status: 200, body: `{"id":"1"}`},
+	}}
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	w := newDiscordTestWriter(t, ts.URL, `
+bot_token: foo
+channel_id: "123"
+`)
+	err := w.Write(context.Background(), service.NewMessage([]byte("hello")))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "status 500")
+	assert.Len(t, srv.requests, 1)
+}
+
+func TestDiscordOutputErrors(t *testing.T) {
+	srv := &testAPIServer{responses: []testAPIResponse{{status: 403, body: `{"message":"Missing Access","code":50001}`}}}
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	w := newDiscordTestWriter(t, ts.URL, `
+bot_token: foo
+channel_id: ${! meta("channel").or("") }
+`)
+
+	err := w.Write(context.Background(), service.NewMessage([]byte("hello")))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "channel ID resolved to an empty string")
+
+	msg := service.NewMessage([]byte("hello"))
+	msg.MetaSet("channel", "123")
+	err = w.Write(context.Background(), msg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Missing Access")
+	assert.Len(t, srv.requests, 1)
+}
@@ -0,0 +1,295 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/benthosdev/benthos/v4/public/bloblang"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	ogFieldAPIKey      = "api_key"
+	ogFieldAction      = "action"
+	ogFieldAlias       = "alias"
+	ogFieldMessage     = "message"
+	ogFieldDescription = "description"
+	ogFieldPriority    = "priority"
+	ogFieldPriorityMap = "priority_map"
+	ogFieldTags        = "tags"
+	ogFieldEntity      = "entity"
+	ogFieldSource      = "source"
+	ogFieldDetails     = "details"
+	ogFieldNote        = "note"
+	ogFieldAPIURL      = "api_url"
+)
+
+func opsgenieOutputConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		// Stable(). TODO
+		Categories("Services").
+		Summary("Creates, acknowledges and closes Opsgenie alerts.").
+		Description(`
+Each message either creates, acknowledges or closes an alert with the [Alert API](https://docs.opsgenie.com/docs/alert-api), authenticated with the API key of an integration. Alerts are identified by their alias, which Opsgenie uses to deduplicate alerts that are created while an alert with the same alias is open. This allows incident automation, such as closing alerts once a monitored condition recovers, to be expressed within a pipeline.
+
+### Priority
+
+The priority of created alerts must be one of ` + "`P1`, `P2`, `P3`, `P4` or `P5`" + `. Severities used by upstream systems can be translated into these with the field ` + "`priority_map`" + `, and messages with any other priority are rejected.
+
+Requests are processed asynchronously by Opsgenie. Once a request has been accepted a copy of the message is [propagated back](/docs/guides/sync_responses) to the input with the ID of the request added as the metadata field ` + "`opsgenie_request_id`" + `, which is only usable by inputs that support synchronous responses.
+
+### Rate Limits
+
+Requests that are rate limited are retried after the period specified by the ` + "`Retry-After`" + ` header of the response, up to the number of attempts specified by ` + "`max_retries`" + `.`).
+		Field(service.NewStringField(ogFieldAPIKey).
+			Description("The API key of an integration used to authenticate requests.")).
+		Field(service.NewInterpolatedStringField(ogFieldAction).
+			Description("The action of each message, which must resolve to `create`, `acknowledge` or `close`.").
+			Default("create").
+			Example(`${! if this.status == "resolved" { "close" } else { "create" } }`)).
+		Field(service.NewInterpolatedStringField(ogFieldAlias).
+			Description("An optional alias that identifies the alert of each message, which is required in order to acknowledge or close alerts.").
+			Example(`${! json("alert_id") }`).
+			Optional()).
+		Field(service.NewInterpolatedStringField(ogFieldMessage).
+			Description("The message of created alerts, which is truncated by Opsgenie to 130 characters.").
+			Default(`${! content() }`)).
+		Field(service.NewInterpolatedStringField(ogFieldDescription).
+			Description("An optional description of created alerts.").
+			Optional()).
+		Field(service.NewInterpolatedStringField(ogFieldPriority).
+			Description("The priority of created alerts.").
+			Default("P3").
+			Example(`${! json("severity") }`)).
+		Field(service.NewStringMapField(ogFieldPriorityMap).
+			Description("A map of priorities to translate into those accepted by Opsgenie.").
+			Default(map[string]interface{}{}).
+			Example(map[string]interface{}{
+				"critical": "P1",
+				"error":    "P2",
+				"warning":  "P3",
+			})).
+		Field(service.NewStringListField(ogFieldTags).
+			Description("A list of tags to add to created alerts.").
+			Default([]string{})).
+		Field(service.NewInterpolatedStringField(ogFieldEntity).
+			Description("An optional entity that created alerts are related to.").
+			Optional()).
+		Field(service.NewInterpolatedStringField(ogFieldSource).
+			Description("The source of each action.").
+			Default("benthos")).
+		Field(service.NewBloblangField(ogFieldDetails).
+			Description("An optional Bloblang mapping that returns an object of additional properties for created alerts, where values are converted into strings.").
+			Example(`root.host = meta("host")`).
+			Optional()).
+		Field(service.NewInterpolatedStringField(ogFieldNote).
+			Description("An optional note added to the alert with each action.").
+			Optional()).
+		Field(service.NewStringField(ogFieldAPIURL).
+			Description("The base URL of the Opsgenie API.").
+			Advanced().
+			Default("https://api.opsgenie.com").
+			Example("https://api.eu.opsgenie.com")).
+		Field(maxRetriesField()).
+		Field(maxInFlightField())
+}
+
+func init() {
+	err := service.RegisterOutput(
+		"opsgenie", opsgenieOutputConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Output, int, error) {
+			maxInFlight, err := conf.FieldInt(nfFieldMaxInFlight)
+			if err != nil {
+				return nil, 0, err
+			}
+			w, err := newOpsgenieWriterFromConfig(conf, mgr.Logger())
+			return w, maxInFlight, err
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type opsgenieWriter struct {
+	apiKey      string
+	action      *levelMapper
+	alias       *service.InterpolatedString
+	message     *service.InterpolatedString
+	description *service.InterpolatedString
+	priority    *levelMapper
+	tags        []string
+	entity      *service.InterpolatedString
+	source      *service.InterpolatedString
+	details     *bloblang.Executor
+	note        *service.InterpolatedString
+	apiURL      string
+
+	client *apiClient
+	log    *service.Logger
+}
+
+func newOpsgenieWriterFromConfig(conf *service.ParsedConfig, log *service.Logger) (*opsgenieWriter, error) {
+	o := &opsgenieWriter{log: log}
+
+	var err error
+	if o.apiKey, err = conf.FieldString(ogFieldAPIKey); err != nil {
+		return nil, err
+	}
+	if o.apiKey == "" {
+		return nil, errors.New("an API key must be specified")
+	}
+	if o.action, err = newLevelMapper(conf, ogFieldAction, "", "create", "acknowledge", "close"); err != nil {
+		return nil, err
+	}
+	if o.message, err = conf.FieldInterpolatedString(ogFieldMessage); err != nil {
+		return nil, err
+	}
+	if o.priority, err = newLevelMapper(conf, ogFieldPriority, ogFieldPriorityMap, "P1", "P2", "P3", "P4", "P5"); err != nil {
+		return nil, err
+	}
+	if o.tags, err = conf.FieldStringList(ogFieldTags); err != nil {
+		return nil, err
+	}
+	if o.source, err = conf.FieldInterpolatedString(ogFieldSource); err != nil {
+		return nil, err
+	}
+	for _, f := range []struct {
+		name string
+		dst  **service.InterpolatedString
+	}{
+		{ogFieldAlias, &o.alias},
+		{ogFieldDescription, &o.description},
+		{ogFieldEntity, &o.entity},
+		{ogFieldNote, &o.note},
+	} {
+		if conf.Contains(f.name) {
+			if *f.dst, err = conf.FieldInterpolatedString(f.name); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if conf.Contains(ogFieldDetails) {
+		if o.details, err = conf.FieldBloblang(ogFieldDetails); err != nil {
+			return nil, err
+		}
+	}
+	if o.apiURL, err = conf.FieldString(ogFieldAPIURL); err != nil {
+		return nil, err
+	}
+	o.apiURL = strings.TrimSuffix(o.apiURL, "/")
+
+	maxRetries, err := conf.FieldInt(nfFieldMaxRetries)
+	if err != nil {
+		return nil, err
+	}
+	o.client = newAPIClient(maxRetries, log)
+	return o, nil
+}
+
+func (o *opsgenieWriter) Connect(ctx context.Context) error {
+	return nil
+}
+
+func optionalString(s *service.InterpolatedString, msg *service.Message) string {
+	if s == nil {
+		return ""
+	}
+	return s.String(msg)
+}
+
+func (o *opsgenieWriter) createBody(msg *service.Message) (map[string]interface{}, error) {
+	priority, err := o.priority.resolve(msg)
+	if err != nil {
+		return nil, err
+	}
+	body := map[string]interface{}{
+		"message":  o.message.String(msg),
+		"priority": priority,
+	}
+	if len(o.tags) > 0 {
+		body["tags"] = o.tags
+	}
+	for k, v := range map[string]*service.InterpolatedString{
+		"alias":       o.alias,
+		"description": o.description,
+		"entity":      o.entity,
+	} {
+		if s := optionalString(v, msg); s != "" {
+			body[k] = s
+		}
+	}
+	if o.details != nil {
+		res, err := msg.BloblangQuery(o.details)
+		if err != nil {
+			return nil, fmt.Errorf("details mapping failed: %w", err)
+		}
+		v, err := res.AsStructured()
+		if err != nil {
+			return nil, fmt.Errorf("details mapping failed: %w", err)
+		}
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("details mapping must return an object, got %T", v)
+		}
+		details := make(map[string]string, len(obj))
+		for k, v := range obj {
+			if s, ok := v.(string); ok {
+				details[k] = s
+			} else {
+				details[k] = fmt.Sprintf("%v", v)
+			}
+		}
+		body["details"] = details
+	}
+	return body, nil
+}
+
+func (o *opsgenieWriter) Write(ctx context.Context, msg *service.Message) error {
+	action, err := o.action.resolve(msg)
+	if err != nil {
+		return err
+	}
+
+	var endpoint string
+	var body map[string]interface{}
+	if action == "create" {
+		endpoint = o.apiURL + "/v2/alerts"
+		if body, err = o.createBody(msg); err != nil {
+			return err
+		}
+	} else {
+		alias := optionalString(o.alias, msg)
+		if alias == "" {
+			return fmt.Errorf("an alias is required in order to %v an alert", action)
+		}
+		endpoint = o.apiURL + "/v2/alerts/" + url.PathEscape(alias) + "/" + action + "?identifierType=alias"
+		body = map[string]interface{}{}
+	}
+	body["source"] = o.source.String(msg)
+	if note := optionalString(o.note, msg); note != "" {
+		body["note"] = note
+	}
+
+	var res struct {
+		Result    string `json:"result"`
+		RequestID string `json:"requestId"`
+	}
+	if err := o.client.sendJSON(ctx, "POST", endpoint, map[string]string{
+		"Authorization": "GenieKey " + o.apiKey,
+	}, body, &res); err != nil {
+		return err
+	}
+	propagateResponse(msg, map[string]string{
+		"opsgenie_request_id": res.RequestID,
+	}, o.log)
+	return nil
+}
+
+func (o *opsgenieWriter) Close(ctx context.Context) error {
+	return nil
+}
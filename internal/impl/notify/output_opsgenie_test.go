@@ -0,0 +1,105 @@
+package notify
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func newOpsgenieTestWriter(t *testing.T, apiURL, conf string) *opsgenieWriter {
+	t.Helper()
+
+	parsed, err := opsgenieOutputConfig().ParseYAML(conf+"\napi_url: "+apiURL+"\n", nil)
+	require.NoError(t, err)
+
+	w, err := newOpsgenieWriterFromConfig(parsed, nil)
+	require.NoError(t, err)
+
+	w.client.backoff = time.Millisecond
+	return w
+}
+
+func TestOpsgenieOutputActions(t *testing.T) {
+	srv := &testAPIServer{responses: []testAPIResponse{
+		{status: 202, body: `{"result":"Request will be processed","took":0.1,"requestId":"r1"}`},
+	}}
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	w := newOpsgenieTestWriter(t, ts.URL, `
+api_key: foo
+action: ${! json("action") }
+alias: ${! json("id") }
+message: ${! json("summary") }
+priority: ${! json("level") }
+priority_map:
+  critical: P1
+tags: [ benthos, disk ]
+details: 'root = {"host": "db-1", "count": 3}'
+note: ${! json("note").or("") }
+`)
+
+	for _, doc := range []string{
+		`{"action":"create","id":"a/1","summary":"disk full","level":"critical"}`,
+		`{"action":"acknowledge","id":"a/1","note":"looking"}`,
+		`{"action":"close","id":"a/1"}`,
+	} {
+		require.NoError(t, w.Write(context.Background(), service.NewMessage([]byte(doc))))
+	}
+
+	assert.Equal(t, "GenieKey foo", srv.authHeader)
+	assert.Equal(t, []string{
+		"/v2/alerts",
+		"/v2/alerts/a%2F1/acknowledge?identifierType=alias",
+		"/v2/alerts/a%2F1/close?identifierType=alias",
+	}, srv.paths)
+	assert.Equal(t, []map[string]interface{}{
+		{
+			"message":  "disk full",
+			"alias":    "a/1",
+			"priority": "P1",
+			"tags":     []interface{}{"benthos", "disk"},
+			"details":  map[string]interface{}{"host": "db-1", "count": "3"},
+			"source":   "benthos",
+		},
+		{"source": "benthos", "note": "looking"},
+		{"source": "benthos"},
+	}, srv.requests)
+}
+
+func TestOpsgenieOutputErrors(t *testing.T) {
+	srv := &testAPIServer{responses: []testAPIResponse{
+		{status: 422, body: `{"message":"Request body is not processable","took":0.0}`},
+	}}
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	w := newOpsgenieTestWriter(t, ts.URL, `
+api_key: foo
+action: ${! meta("action") }
+priority: ${! meta("priority") }
+`)
+
+	for _, test := range []struct {
+		action, priority, err string
+	}{
+		{"resolve", "P1", `action resolved to "resolve", expected one of: create, acknowledge, close`},
+		{"create", "P0", `priority resolved to "P0", expected one of: P1, P2, P3, P4, P5`},
+		{"close", "P1", "an alias is required in order to close an alert"},
+		{"create", "P1", "request failed with status 422"},
+	} {
+		msg := service.NewMessage([]byte("hello"))
+		msg.MetaSet("action", test.action)
+		msg.MetaSet("priority", test.priority)
+		err := w.Write(context.Background(), msg)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), test.err)
+	}
+	assert.Len(t, srv.requests, 1)
+}
@@ -0,0 +1,270 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/public/bloblang"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	pdFieldRoutingKey    = "routing_key"
+	pdFieldAction        = "action"
+	pdFieldDedupKey      = "dedup_key"
+	pdFieldSummary       = "summary"
+	pdFieldSource        = "source"
+	pdFieldSeverity      = "severity"
+	pdFieldSeverityMap   = "severity_map"
+	pdFieldComponent     = "component"
+	pdFieldGroup         = "group"
+	pdFieldClass         = "class"
+	pdFieldCustomDetails = "custom_details"
+	pdFieldAPIURL        = "api_url"
+)
+
+func pagerDutyOutputConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		// Stable(). TODO
+		Categories("Services").
+		Summary("Sends messages as events to PagerDuty using the Events API v2.").
+		Description(`
+Each message is sent as an event to the service of an integration, which either triggers, acknowledges or resolves the alert identified by the deduplication key of the event. This allows incident automation, such as resolving alerts once a monitored condition recovers, to be expressed within a pipeline.
+
+### Severity
+
+The severity of triggered alerts must be one of ` + "`critical`, `error`, `warning` or `info`" + `. Severities used by upstream systems can be translated into these with the field ` + "`severity_map`" + `, and messages with any other severity are rejected.
+
+### Deduplication Keys
+
+When ` + "`dedup_key`" + ` is not set PagerDuty generates a key for each triggered alert. Once an event has been accepted a copy of the message is [propagated back](/docs/guides/sync_responses) to the input with the key added as the metadata field ` + "`pagerduty_dedup_key`" + `, which is only usable by inputs that support synchronous responses.
+
+### Rate Limits
+
+Requests that are rate limited are retried after the period specified by the ` + "`Retry-After`" + ` header of the response, up to the number of attempts specified by ` + "`max_retries`" + `.`).
+		Field(service.NewStringField(pdFieldRoutingKey).
+			Description("The integration key of the service to send events to.")).
+		Field(service.NewInterpolatedStringField(pdFieldAction).
+			Description("The action of each event, which must resolve to `trigger`, `acknowledge` or `resolve`.").
+			Default("trigger").
+			Example(`${! if this.status == "resolved" { "resolve" } else { "trigger" } }`)).
+		Field(service.NewInterpolatedStringField(pdFieldDedupKey).
+			Description("An optional key that identifies the alert of each event, which is required in order to acknowledge or resolve alerts.").
+			Example(`${! json("alert_id") }`).
+			Optional()).
+		Field(service.NewInterpolatedStringField(pdFieldSummary).
+			Description("A summary of the alert, which is only sent with triggered events.").
+			Default(`${! content() }`)).
+		Field(service.NewInterpolatedStringField(pdFieldSource).
+			Description("The location of the affected system, such as its hostname.").
+			Default("benthos").
+			Example(`${! meta("host") }`)).
+		Field(service.NewInterpolatedStringField(pdFieldSeverity).
+			Description("The severity of the alert.").
+			Default("error").
+			Example(`${! json("level") }`)).
+		Field(service.NewStringMapField(pdFieldSeverityMap).
+			Description("A map of severities to translate into those accepted by PagerDuty.").
+			Default(map[string]interface{}{}).
+			Example(map[string]interface{}{
+				"P1":   "critical",
+				"P2":   "error",
+				"high": "warning",
+			})).
+		Field(service.NewInterpolatedStringField(pdFieldComponent).
+			Description("An optional component of the affected system.").
+			Optional()).
+		Field(service.NewInterpolatedStringField(pdFieldGroup).
+			Description("An optional logical grouping of components.").
+			Optional()).
+		Field(service.NewInterpolatedStringField(pdFieldClass).
+			Description("An optional class or type of the event.").
+			Optional()).
+		Field(service.NewBloblangField(pdFieldCustomDetails).
+			Description("An optional Bloblang mapping that returns additional details of the alert.").
+			Example(`root = this.without("summary")`).
+			Optional()).
+		Field(service.NewStringField(pdFieldAPIURL).
+			Description("The base URL of the Events API.").
+			Advanced().
+			Default("https://events.pagerduty.com")).
+		Field(maxRetriesField()).
+		Field(maxInFlightField())
+}
+
+func init() {
+	err := service.RegisterOutput(
+		"pagerduty", pagerDutyOutputConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Output, int, error) {
+			maxInFlight, err := conf.FieldInt(nfFieldMaxInFlight)
+			if err != nil {
+				return nil, 0, err
+			}
+			w, err := newPagerDutyWriterFromConfig(conf, mgr.Logger())
+			return w, maxInFlight, err
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type pagerDutyWriter struct {
+	routingKey    string
+	action        *levelMapper
+	dedupKey      *service.InterpolatedString
+	summary       *service.InterpolatedString
+	source        *service.InterpolatedString
+	severity      *levelMapper
+	component     *service.InterpolatedString
+	group         *service.InterpolatedString
+	class         *service.InterpolatedString
+	customDetails *bloblang.Executor
+	apiURL        string
+
+	client *apiClient
+	log    *service.Logger
+}
+
+func newPagerDutyWriterFromConfig(conf *service.ParsedConfig, log *service.Logger) (*pagerDutyWriter, error) {
+	p := &pagerDutyWriter{log: log}
+
+	var err error
+	if p.routingKey, err = conf.FieldString(pdFieldRoutingKey); err != nil {
+		return nil, err
+	}
+	if p.routingKey == "" {
+		return nil, errors.New("a routing key must be specified")
+	}
+	if p.action, err = newLevelMapper(conf, pdFieldAction, "", "trigger", "acknowledge", "resolve"); err != nil {
+		return nil, err
+	}
+	if conf.Contains(pdFieldDedupKey) {
+		if p.dedupKey, err = conf.FieldInterpolatedString(pdFieldDedupKey); err != nil {
+			return nil, err
+		}
+	}
+	if p.summary, err = conf.FieldInterpolatedString(pdFieldSummary); err != nil {
+		return nil, err
+	}
+	if p.source, err = conf.FieldInterpolatedString(pdFieldSource); err != nil {
+		return nil, err
+	}
+	if p.severity, err = newLevelMapper(conf, pdFieldSeverity, pdFieldSeverityMap, "critical", "error", "warning", "info"); err != nil {
+		return nil, err
+	}
+	for _, f := range []struct {
+		name string
+		dst  **service.InterpolatedString
+	}{
+		{pdFieldComponent, &p.component},
+		{pdFieldGroup, &p.group},
+		{pdFieldClass, &p.class},
+	} {
+		if conf.Contains(f.name) {
+			if *f.dst, err = conf.FieldInterpolatedString(f.name); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if conf.Contains(pdFieldCustomDetails) {
+		if p.customDetails, err = conf.FieldBloblang(pdFieldCustomDetails); err != nil {
+			return nil, err
+		}
+	}
+	if p.apiURL, err = conf.FieldString(pdFieldAPIURL); err != nil {
+		return nil, err
+	}
+	p.apiURL = strings.TrimSuffix(p.apiURL, "/")
+
+	maxRetries, err := conf.FieldInt(nfFieldMaxRetries)
+	if err != nil {
+		return nil, err
+	}
+	p.client = newAPIClient(maxRetries, log)
+	return p, nil
+}
+
+func (p *pagerDutyWriter) Connect(ctx context.Context) error {
+	return nil
+}
+
+func (p *pagerDutyWriter) triggerPayload(msg *service.Message) (map[string]interface{}, error) {
+	severity, err := p.severity.resolve(msg)
+	if err != nil {
+		return nil, err
+	}
+	payload := map[string]interface{}{
+		"summary":   p.summary.String(msg),
+		"source":    p.source.String(msg),
+		"severity":  severity,
+		"timestamp": time.Now().UTC().Format(time.RFC3339Nano),
+	}
+	for k, v := range map[string]*service.InterpolatedString{
+		"component": p.component,
+		"group":     p.group,
+		"class":     p.class,
+	} {
+		if v == nil {
+			continue
+		}
+		if s := v.String(msg); s != "" {
+			payload[k] = s
+		}
+	}
+	if p.customDetails != nil {
+		res, err := msg.BloblangQuery(p.customDetails)
+		if err != nil {
+			return nil, fmt.Errorf("custom details mapping failed: %w", err)
+		}
+		if payload["custom_details"], err = res.AsStructured(); err != nil {
+			return nil, fmt.Errorf("custom details mapping failed: %w", err)
+		}
+	}
+	return payload, nil
+}
+
+func (p *pagerDutyWriter) Write(ctx context.Context, msg *service.Message) error {
+	action, err := p.action.resolve(msg)
+	if err != nil {
+		return err
+	}
+
+	body := map[string]interface{}{
+		"routing_key":  p.routingKey,
+		"event_action": action,
+	}
+	if p.dedupKey != nil {
+		if key := p.dedupKey.String(msg); key != "" {
+			body["dedup_key"] = key
+		}
+	}
+	if action == "trigger" {
+		if body["payload"], err = p.triggerPayload(msg); err != nil {
+			return err
+		}
+	} else if _, exists := body["dedup_key"]; !exists {
+		return fmt.Errorf("a dedup key is required in order to %v an alert", action)
+	}
+
+	var res struct {
+		Status   string `json:"status"`
+		Message  string `json:"message"`
+		DedupKey string `json:"dedup_key"`
+	}
+	if err := p.client.sendJSON(ctx, "POST", p.apiURL+"/v2/enqueue", nil, body, &res); err != nil {
+		return err
+	}
+	propagateResponse(msg, map[string]string{
+		"pagerduty_dedup_key": res.DedupKey,
+	}, p.log)
+	return nil
+}
+
+func (p *pagerDutyWriter) Close(ctx context.Context) error {
+	return nil
+}
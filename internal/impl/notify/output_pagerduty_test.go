@@ -0,0 +1,128 @@
+package notify
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/transaction"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func newPagerDutyTestWriter(t *testing.T, apiURL, conf string) *pagerDutyWriter {
+	t.Helper()
+
+	parsed, err := pagerDutyOutputConfig().ParseYAML(conf+"\napi_url: "+apiURL+"\n", nil)
+	require.NoError(t, err)
+
+	w, err := newPagerDutyWriterFromConfig(parsed, nil)
+	require.NoError(t, err)
+
+	w.client.backoff = time.Millisecond
+	return w
+}
+
+func TestPagerDutyOutputActions(t *testing.T) {
+	srv := &testAPIServer{responses: []testAPIResponse{
+		{status: 202, body: `{"status":"success","message":"Event processed","dedup_key":"a1"}`},
+	}}
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	w := newPagerDutyTestWriter(t, ts.URL, `
+routing_key: foo
+action: ${! if this.status == "resolved" { "resolve" } else { "trigger" } }
+dedup_key: ${! json("id") }
+summary: ${! json("summary") }
+source: ${! meta("host") }
+severity: ${! json("level") }
+severity_map:
+  P1: critical
+component: db
+custom_details: 'root.summary = this.summary'
+`)
+
+	store := transaction.NewResultStore()
+	msg := service.NewMessage([]byte(`{"id":"a1","summary":"disk full","level":"P1","status":"firing"}`))
+	msg.MetaSet("host", "db-1")
+	msg = msg.WithContext(context.WithValue(context.Background(), transaction.ResultStoreKey, store))
+	require.NoError(t, w.Write(context.Background(), msg))
+
+	require.NoError(t, w.Write(context.Background(), service.NewMessage([]byte(
+		`{"id":"a1","summary":"disk full","level":"P1","status":"resolved"}`,
+	))))
+
+	assert.Equal(t, []string{"/v2/enqueue", "/v2/enqueue"}, srv.paths)
+	require.Len(t, srv.requests, 2)
+
+	payload, ok := srv.requests[0]["payload"].(map[string]interface{})
+	require.True(t, ok)
+	assert.NotEmpty(t, payload["timestamp"])
+	delete(payload, "timestamp")
+	assert.Equal(t, map[string]interface{}{
+		"routing_key":  "foo",
+		"event_action": "trigger",
+		"dedup_key":    "a1",
+		"payload": map[string]interface{}{
+			"summary":        "disk full",
+			"source":         "db-1",
+			"severity":       "critical",
+			"component":      "db",
+			"custom_details": map[string]interface{}{"summary": "disk full"},
+		},
+	}, srv.requests[0])
+
+	assert.Equal(t, map[string]interface{}{
+		"routing_key":  "foo",
+		"event_action": "resolve",
+		"dedup_key":    "a1",
+	}, srv.requests[1])
+
+	results := store.Get()
+	require.Len(t, results, 1)
+	assert.Equal(t, "a1", results[0].Get(0).MetaGet("pagerduty_dedup_key"))
+}
+
+func TestPagerDutyOutputErrors(t *testing.T) {
+	srv := &testAPIServer{responses: []testAPIResponse{
+		{status: 400, body: `{"status":"invalid event","message":"Event object is invalid"}`},
+	}}
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	w := newPagerDutyTestWriter(t, ts.URL, `
+routing_key: foo
+action: ${! meta("action") }
+severity: ${! meta("severity") }
+`)
+
+	for _, test := range []struct {
+		action, severity, err string
+	}{
+		{"nope", "error", `action resolved to "nope", expected one of: trigger, acknowledge, resolve`},
+		{"trigger", "high", `severity resolved to "high", expected one of: critical, error, warning, info`},
+		{"resolve", "error", "a dedup key is required in order to resolve an alert"},
+		{"trigger", "error", "request failed with status 400"},
+	} {
+		msg := service.NewMessage([]byte("hello"))
+		msg.MetaSet("action", test.action)
+		msg.MetaSet("severity", test.severity)
+		err := w.Write(context.Background(), msg)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), test.err)
+	}
+	assert.Len(t, srv.requests, 1)
+
+	parsed, err := pagerDutyOutputConfig().ParseYAML(`
+routing_key: foo
+severity_map:
+  P1: urgent
+`, nil)
+	require.NoError(t, err)
+	_, err = newPagerDutyWriterFromConfig(parsed, nil)
+	assert.EqualError(t, err, "severity_map maps P1 to urgent, expected one of: critical, error, warning, info")
+}
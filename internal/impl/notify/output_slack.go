@@ -0,0 +1,263 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/benthosdev/benthos/v4/public/bloblang"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	slFieldBotToken = "bot_token"
+	slFieldChannel  = "channel"
+	slFieldText     = "text"
+	slFieldTitle    = "title"
+	slFieldFields   = "fields"
+	slFieldBlocks   = "blocks"
+	slFieldThreadTS = "thread_ts"
+	slFieldAPIURL   = "api_url"
+
+	// Slack limits the number of fields within a single section block.
+	slackMaxSectionFields = 10
+)
+
+func slackOutputConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		// Stable(). TODO
+		Categories("Services").
+		Summary("Posts messages to a Slack channel using the Web API.").
+		Description(`
+Each message is posted to a channel with the [chat.postMessage](https://api.slack.com/methods/chat.postMessage) method, authenticated with a bot token that has the ` + "`chat:write`" + ` scope.
+
+### Formatting
+
+By default the contents of each message are posted as the text of the Slack message. Messages can instead be formatted with [Block Kit](https://api.slack.com/block-kit) blocks, either constructed from the fields ` + "`title` and `fields`" + `, where the title becomes a header block and the fields are rendered as a section, or by providing a [Bloblang mapping](/docs/guides/bloblang/about) with the field ` + "`blocks`" + ` that returns an array of blocks. When blocks are posted the text is used as a fallback for notifications.
+
+### Rate Limits
+
+Requests that are rate limited are retried after the period specified by the ` + "`Retry-After`" + ` header of the response, up to the number of attempts specified by ` + "`max_retries`" + `.`).
+		Field(service.NewStringField(slFieldBotToken).
+			Description("A bot token used to authenticate requests.")).
+		Field(service.NewInterpolatedStringField(slFieldChannel).
+			Description("The ID or name of the channel to post messages to.").
+			Example("C0123456789").
+			Example(`${! meta("slack_channel") }`)).
+		Field(service.NewInterpolatedStringField(slFieldText).
+			Description("The text of each message, which may contain Slack markdown.").
+			Default(`${! content() }`)).
+		Field(service.NewInterpolatedStringField(slFieldTitle).
+			Description("An optional title, which is added to each message as a header block.").
+			Example(`Alert: ${! json("name") }`).
+			Optional()).
+		Field(service.NewInterpolatedStringMapField(slFieldFields).
+			Description("An optional map of fields, which are added to each message as a section block with the key of each field in bold above its value. Fields are sorted by their key.").
+			Example(map[string]interface{}{
+				"Severity": `${! json("severity") }`,
+				"Host":     `${! meta("host") }`,
+			}).
+			Optional()).
+		Field(service.NewBloblangField(slFieldBlocks).
+			Description("An optional Bloblang mapping that returns an array of Block Kit blocks for each message, which takes precedence over the fields `title` and `fields`.").
+			Example(`root = [{"type": "section", "text": {"type": "mrkdwn", "text": "*%s* is down".format(this.service)}}]`).
+			Optional()).
+		Field(service.NewInterpolatedStringField(slFieldThreadTS).
+			Description("An optional timestamp of a parent message, in which case messages are posted as replies within its thread. Messages are posted to the channel when this resolves to an empty string.").
+			Example(`${! meta("thread_ts").or("") }`).
+			Optional()).
+		Field(service.NewStringField(slFieldAPIURL).
+			Description("The base URL of the Slack Web API.").
+			Advanced().
+			Default("https://slack.com/api")).
+		Field(maxRetriesField()).
+		Field(maxInFlightField())
+}
+
+func init() {
+	err := service.RegisterOutput(
+		"slack", slackOutputConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Output, int, error) {
+			maxInFlight, err := conf.FieldInt(nfFieldMaxInFlight)
+			if err != nil {
+				return nil, 0, err
+			}
+			w, err := newSlackWriterFromConfig(conf, mgr.Logger())
+			return w, maxInFlight, err
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type slackWriter struct {
+	token    string
+	channel  *service.InterpolatedString
+	text     *service.InterpolatedString
+	title    *service.InterpolatedString
+	fields   map[string]*service.InterpolatedString
+	blocks   *bloblang.Executor
+	threadTS *service.InterpolatedString
+	apiURL   string
+
+	client *apiClient
+}
+
+func newSlackWriterFromConfig(conf *service.ParsedConfig, log *service.Logger) (*slackWriter, error) {
+	s := &slackWriter{}
+
+	var err error
+	if s.token, err = conf.FieldString(slFieldBotToken); err != nil {
+		return nil, err
+	}
+	if s.token == "" {
+		return nil, errors.New("a bot token must be specified")
+	}
+	if s.channel, err = conf.FieldInterpolatedString(slFieldChannel); err != nil {
+		return nil, err
+	}
+	if s.text, err = conf.FieldInterpolatedString(slFieldText); err != nil {
+		return nil, err
+	}
+	if conf.Contains(slFieldTitle) {
+		if s.title, err = conf.FieldInterpolatedString(slFieldTitle); err != nil {
+			return nil, err
+		}
+	}
+	if conf.Contains(slFieldFields) {
+		if s.fields, err = conf.FieldInterpolatedStringMap(slFieldFields); err != nil {
+			return nil, err
+		}
+	}
+	if conf.Contains(slFieldBlocks) {
+		if s.blocks, err = conf.FieldBloblang(slFieldBlocks); err != nil {
+			return nil, err
+		}
+	}
+	if conf.Contains(slFieldThreadTS) {
+		if s.threadTS, err = conf.FieldInterpolatedString(slFieldThreadTS); err != nil {
+			return nil, err
+		}
+	}
+	if s.apiURL, err = conf.FieldString(slFieldAPIURL); err != nil {
+		return nil, err
+	}
+	s.apiURL = strings.TrimSuffix(s.apiURL, "/")
+
+	maxRetries, err := conf.FieldInt(nfFieldMaxRetries)
+	if err != nil {
+		return nil, err
+	}
+	s.client = newAPIClient(maxRetries, log)
+	return s, nil
+}
+
+func (s *slackWriter) Connect(ctx context.Context) error {
+	return nil
+}
+
+// buildBlocks returns the Block Kit blocks of a message, or nil if the message
+// is posted as plain text.
+func (s *slackWriter) buildBlocks(msg *service.Message) (interface{}, error) {
+	if s.blocks != nil {
+		res, err := msg.BloblangQuery(s.blocks)
+		if err != nil {
+			return nil, fmt.Errorf("blocks mapping failed: %w", err)
+		}
+		v, err := res.AsStructured()
+		if err != nil {
+			return nil, fmt.Errorf("blocks mapping failed: %w", err)
+		}
+		blocks, ok := v.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("blocks mapping must return an array, got %T", v)
+		}
+		return blocks, nil
+	}
+
+	var blocks []interface{}
+	if s.title != nil {
+		if title := s.title.String(msg); title != "" {
+			blocks = append(blocks, map[string]interface{}{
+				"type": "header",
+				"text": map[string]interface{}{
+					"type": "plain_text",
+					"text": title,
+				},
+			})
+		}
+	}
+
+	keys := make([]string, 0, len(s.fields))
+	for k := range s.fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var sectionFields []interface{}
+	for _, k := range keys {
+		sectionFields = append(sectionFields, map[string]interface{}{
+			"type": "mrkdwn",
+			"text": fmt.Sprintf("*%v*\n%v", k, s.fields[k].String(msg)),
+		})
+	}
+	for len(sectionFields) > 0 {
+		n := len(sectionFields)
+		if n > slackMaxSectionFields {
+			n = slackMaxSectionFields
+		}
+		blocks = append(blocks, map[string]interface{}{
+			"type":   "section",
+			"fields": sectionFields[:n],
+		})
+		sectionFields = sectionFields[n:]
+	}
+
+	if len(blocks) == 0 {
+		return nil, nil
+	}
+	return blocks, nil
+}
+
+func (s *slackWriter) Write(ctx context.Context, msg *service.Message) error {
+	body := map[string]interface{}{
+		"channel": s.channel.String(msg),
+		"text":    s.text.String(msg),
+	}
+	blocks, err := s.buildBlocks(msg)
+	if err != nil {
+		return err
+	}
+	if blocks != nil {
+		body["blocks"] = blocks
+	}
+	if s.threadTS != nil {
+		if ts := s.threadTS.String(msg); ts != "" {
+			body["thread_ts"] = ts
+		}
+	}
+
+	// The Web API responds with a status of 200 for most failed requests, and
+	// reports the error within the body instead.
+	var res struct {
+		OK    bool   `json:"ok"`
+		Error string `json:"error"`
+	}
+	if err := s.client.sendJSON(ctx, "POST", s.apiURL+"/chat.postMessage", map[string]string{
+		"Authorization": "Bearer " + s.token,
+	}, body, &res); err != nil {
+		return err
+	}
+	if !res.OK {
+		return fmt.Errorf("failed to post message: %v", res.Error)
+	}
+	return nil
+}
+
+func (s *slackWriter) Close(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,158 @@
+package notify
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func newSlackTestWriter(t *testing.T, apiURL, conf string) *slackWriter {
+	t.Helper()
+
+	parsed, err := slackOutputConfig().ParseYAML(conf+"\napi_url: "+apiURL+"\n", nil)
+	require.NoError(t, err)
+
+	w, err := newSlackWriterFromConfig(parsed, nil)
+	require.NoError(t, err)
+
+	w.client.backoff = time.Millisecond
+	return w
+}
+
+func TestSlackOutputText(t *testing.T) {
+	srv := &testAPIServer{responses: []testAPIResponse{{status: 200, body: `{"ok":true}`}}}
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	w := newSlackTestWriter(t, ts.URL, `
+bot_token: xoxb-foo
+channel: ${! meta("channel") }
+thread_ts: ${! meta("thread").or("") }
+`)
+
+	msg := service.NewMessage([]byte("hello world"))
+	msg.MetaSet("channel", "C123")
+	require.NoError(t, w.Write(context.Background(), msg))
+
+	msg = service.NewMessage([]byte("a reply"))
+	msg.MetaSet("channel", "C456")
+	msg.MetaSet("thread", "1234.5678")
+	require.NoError(t, w.Write(context.Background(), msg))
+
+	assert.Equal(t, "Bearer xoxb-foo", srv.authHeader)
+	assert.Equal(t, []string{"/chat.postMessage", "/chat.postMessage"}, srv.paths)
+	assert.Equal(t, []map[string]interface{}{
+		{"channel": "C123", "text": "hello world"},
+		{"channel": "C456", "text": "a reply", "thread_ts": "1234.5678"},
+	}, srv.requests)
+}
+
+func TestSlackOutputBlocks(t *testing.T) {
+	srv := &testAPIServer{responses: []testAPIResponse{{status: 200, body: `{"ok":true}`}}}
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	w := newSlackTestWriter(t, ts.URL, `
+bot_token: xoxb-foo
+channel: C123
+text: ${! json("summary") }
+title: 'Alert: ${! json("name") }'
+fields:
+  Severity: ${! json("severity") }
+  Host: ${! json("host") }
+`)
+
+	require.NoError(t, w.Write(context.Background(), service.NewMessage([]byte(
+		`{"name":"disk full","summary":"disk is full","severity":"high","host":"a"}`,
+	))))
+
+	require.Len(t, srv.requests, 1)
+	assert.Equal(t, map[string]interface{}{
+		"channel": "C123",
+		"text":    "disk is full",
+		"blocks": []interface{}{
+			map[string]interface{}{
+				"type": "header",
+				"text": map[string]interface{}{"type": "plain_text", "text": "Alert: disk full"},
+			},
+			map[string]interface{}{
+				"type": "section",
+				"fields": []interface{}{
+					map[string]interface{}{"type": "mrkdwn", "text": "*Host*\na"},
+					map[string]interface{}{"type": "mrkdwn", "text": "*Severity*\nhigh"},
+				},
+			},
+		},
+	}, srv.requests[0])
+}
+
+func TestSlackOutputBlocksMapping(t *testing.T) {
+	srv := &testAPIServer{responses: []testAPIResponse{{status: 200, body: `{"ok":true}`}}}
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	w := newSlackTestWriter(t, ts.URL, `
+bot_token: xoxb-foo
+channel: C123
+title: ignored
+blocks: 'root = [{"type": "divider"}]'
+`)
+	require.NoError(t, w.Write(context.Background(), service.NewMessage([]byte(`{}`))))
+
+	require.Len(t, srv.requests, 1)
+	assert.Equal(t, []interface{}{map[string]interface{}{"type": "divider"}}, srv.requests[0]["blocks"])
+
+	w = newSlackTestWriter(t, ts.URL, `
+bot_token: xoxb-foo
+channel: C123
+blocks: 'root = {"type": "divider"}'
+`)
+	assert.EqualError(t, w.Write(context.Background(), service.NewMessage([]byte(`{}`))), "blocks mapping must return an array, got map[string]interface {}")
+}
+
+func TestSlackOutputRateLimited(t *testing.T) {
+	srv := &testAPIServer{responses: []testAPIResponse{
+		{status: 429, headers: map[string]string{"Retry-After": "0"}, body: `{"ok":false,"error":"ratelimited"}`},
+		{status: 429, headers: map[string]string{"Retry-After": "0"}, body: `{"ok":false,"error":"ratelimited"}`},
+		{status: 200, body: `{"ok":true}`},
+	}}
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	w := newSlackTestWriter(t, ts.URL, `
+bot_token: xoxb-foo
+channel: C123
+`)
+	require.NoError(t, w.Write(context.Background(), service.NewMessage([]byte("hello"))))
+	assert.Len(t, srv.requests, 3)
+}
+
+func TestSlackOutputErrors(t *testing.T) {
+	srv := &testAPIServer{responses: []testAPIResponse{
+		{status: 200, body: `{"ok":false,"error":"channel_not_found"}`},
+		{status: 429, body: `{"ok":false,"error":"ratelimited"}`},
+	}}
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	w := newSlackTestWriter(t, ts.URL, `
+bot_token: xoxb-foo
+channel: C123
+max_retries: 2
+`)
+	assert.EqualError(t, w.Write(context.Background(), service.NewMessage([]byte("hello"))), "failed to post message: channel_not_found")
+
+	err := w.Write(context.Background(), service.NewMessage([]byte("hello")))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "status 429")
+	assert.Len(t, srv.requests, 4)
+
+	_, err = slackOutputConfig().ParseYAML(`channel: C123`, nil)
+	require.Error(t, err)
+}
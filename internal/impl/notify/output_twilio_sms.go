@@ -0,0 +1,293 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	twFieldAccountSID          = "account_sid"
+	twFieldAuthToken           = "auth_token"
+	twFieldFrom                = "from"
+	twFieldMessagingServiceSID = "messaging_service_sid"
+	twFieldTo                  = "to"
+	twFieldBody                = "body"
+	twFieldStatusCallback      = "status_callback"
+	twFieldDestInterval        = "destination_interval"
+	twFieldAPIURL              = "api_url"
+)
+
+func twilioSMSOutputConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		// Stable(). TODO
+		Categories("Services").
+		Summary("Sends messages as SMS notifications using the Twilio API.").
+		Description(`
+Each message is sent as an SMS to a recipient with the [Messages](https://www.twilio.com/docs/sms/api/message-resource) resource of the Twilio API, authenticated with an account SID and auth token.
+
+### Delivery Status
+
+Once a message has been accepted by Twilio a copy of it is [propagated back](/docs/guides/sync_responses) to the input with the metadata fields ` + "`twilio_sid` and `twilio_status`" + ` added, containing the SID and initial status of the SMS. Only inputs that support synchronous responses are able to make use of these responses.
+
+Twilio reports changes to the delivery status of an SMS by sending form encoded requests to the URL specified by ` + "`status_callback`" + `, which can be consumed with an ` + "[`http_server`](/docs/components/inputs/http_server)" + ` input. Query parameters of the callback URL are added as metadata to the messages of that input, and can therefore be used to correlate status updates with the messages that triggered them.
+
+### Rate Limits
+
+Requests that are rate limited are retried after the period specified by the ` + "`Retry-After`" + ` header of the response, up to the number of attempts specified by ` + "`max_retries`" + `. In order to avoid flooding recipients during an incident the field ` + "`destination_interval`" + ` sets a minimum period between messages sent to the same recipient, where messages are delayed until the period has passed.`).
+		Field(service.NewStringField(twFieldAccountSID).
+			Description("The SID of the Twilio account to send messages with.")).
+		Field(service.NewStringField(twFieldAuthToken).
+			Description("The auth token of the Twilio account.")).
+		Field(service.NewStringField(twFieldFrom).
+			Description("The phone number to send messages from, in E.164 format. Either this field or `messaging_service_sid` must be specified.").
+			Example("+15557122661").
+			Default("")).
+		Field(service.NewStringField(twFieldMessagingServiceSID).
+			Description("The SID of a messaging service to send messages with, in which case the sender is selected by Twilio.").
+			Default("")).
+		Field(service.NewInterpolatedStringField(twFieldTo).
+			Description("The phone number of the recipient of each message, in E.164 format.").
+			Example("+15558675310").
+			Example(`${! meta("on_call_number") }`)).
+		Field(service.NewInterpolatedStringField(twFieldBody).
+			Description("The body of each SMS.").
+			Default(`${! content() }`).
+			Example(`[${! json("severity").uppercase() }] ${! json("summary") }`)).
+		Field(service.NewInterpolatedStringField(twFieldStatusCallback).
+			Description("An optional URL that Twilio sends delivery status updates of each SMS to.").
+			Example(`https://example.com/sms/status?alert_id=${! json("id") }`).
+			Optional()).
+		Field(service.NewDurationField(twFieldDestInterval).
+			Description("The minimum period between messages sent to the same recipient, or zero to send messages without delay.").
+			Example("1m").
+			Default("0s")).
+		Field(service.NewStringField(twFieldAPIURL).
+			Description("The base URL of the Twilio API.").
+			Advanced().
+			Default("https://api.twilio.com")).
+		Field(maxRetriesField()).
+		Field(maxInFlightField())
+}
+
+func init() {
+	err := service.RegisterOutput(
+		"twilio_sms", twilioSMSOutputConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Output, int, error) {
+			maxInFlight, err := conf.FieldInt(nfFieldMaxInFlight)
+			if err != nil {
+				return nil, 0, err
+			}
+			w, err := newTwilioSMSWriterFromConfig(conf, mgr.Logger())
+			return w, maxInFlight, err
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// destinationLimiter enforces a minimum interval between messages sent to the
+// same destination.
+type destinationLimiter struct {
+	interval time.Duration
+
+	mut  sync.Mutex
+	next map[string]time.Time
+}
+
+func newDestinationLimiter(interval time.Duration) *destinationLimiter {
+	return &destinationLimiter{
+		interval: interval,
+		next:     map[string]time.Time{},
+	}
+}
+
+// reserve returns the time at which a message may be sent to a destination,
+// reserving the slot for the caller.
+func (d *destinationLimiter) reserve(dest string, now time.Time) time.Time {
+	d.mut.Lock()
+	defer d.mut.Unlock()
+
+	at := now
+	if next, exists := d.next[dest]; exists && next.After(now) {
+		at = next
+	}
+	d.next[dest] = at.Add(d.interval)
+
+	// Destinations that could be sent to immediately are forgotten so that the
+	// map does not grow unbounded.
+	if len(d.next) > 1024 {
+		for k, v := range d.next {
+			if !v.After(now) {
+				delete(d.next, k)
+			}
+		}
+	}
+	return at
+}
+
+// wait blocks until a message may be sent to a destination.
+func (d *destinationLimiter) wait(ctx context.Context, dest string) error {
+	if d.interval <= 0 {
+		return nil
+	}
+	wait := time.Until(d.reserve(dest, time.Now()))
+	if wait <= 0 {
+		return nil
+	}
+	select {
+	case <-time.After(wait):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+type twilioSMSWriter struct {
+	accountSID          string
+	authToken           string
+	from                string
+	messagingServiceSID string
+	to                  *service.InterpolatedString
+	body                *service.InterpolatedString
+	statusCallback      *service.InterpolatedString
+	apiURL              string
+
+	limiter *destinationLimiter
+	client  *apiClient
+	log     *service.Logger
+}
+
+func newTwilioSMSWriterFromConfig(conf *service.ParsedConfig, log *service.Logger) (*twilioSMSWriter, error) {
+	w := &twilioSMSWriter{log: log}
+
+	var err error
+	if w.accountSID, err = conf.FieldString(twFieldAccountSID); err != nil {
+		return nil, err
+	}
+	if w.authToken, err = conf.FieldString(twFieldAuthToken); err != nil {
+		return nil, err
+	}
+	if w.accountSID == "" || w.authToken == "" {
+		return nil, errors.New("an account SID and auth token must be specified")
+	}
+	if w.from, err = conf.FieldString(twFieldFrom); err != nil {
+		return nil, err
+	}
+	if w.messagingServiceSID, err = conf.FieldString(twFieldMessagingServiceSID); err != nil {
+		return nil, err
+	}
+	if w.from == "" && w.messagingServiceSID == "" {
+		return nil, fmt.Errorf("either %v or %v must be specified", twFieldFrom, twFieldMessagingServiceSID)
+	}
+	if w.to, err = conf.FieldInterpolatedString(twFieldTo); err != nil {
+		return nil, err
+	}
+	if w.body, err = conf.FieldInterpolatedString(twFieldBody); err != nil {
+		return nil, err
+	}
+	if conf.Contains(twFieldStatusCallback) {
+		if w.statusCallback, err = conf.FieldInterpolatedString(twFieldStatusCallback); err != nil {
+			return nil, err
+		}
+	}
+
+	interval, err := conf.FieldDuration(twFieldDestInterval)
+	if err != nil {
+		return nil, err
+	}
+	w.limiter = newDestinationLimiter(interval)
+
+	if w.apiURL, err = conf.FieldString(twFieldAPIURL); err != nil {
+		return nil, err
+	}
+	w.apiURL = strings.TrimSuffix(w.apiURL, "/")
+
+	maxRetries, err := conf.FieldInt(nfFieldMaxRetries)
+	if err != nil {
+		return nil, err
+	}
+	w.client = newAPIClient(maxRetries, log)
+	return w, nil
+}
+
+func (w *twilioSMSWriter) Connect(ctx context.Context) error {
+	return nil
+}
+
+type twilioMessage struct {
+	SID          string `json:"sid"`
+	Status       string `json:"status"`
+	ErrorCode    *int   `json:"error_code"`
+	ErrorMessage string `json:"error_message"`
+}
+
+func (w *twilioSMSWriter) Write(ctx context.Context, msg *service.Message) error {
+	to := w.to.String(msg)
+	if to == "" {
+		return errors.New("recipient resolved to an empty string")
+	}
+
+	form := url.Values{}
+	form.Set("To", to)
+	form.Set("Body", w.body.String(msg))
+	if w.messagingServiceSID != "" {
+		form.Set("MessagingServiceSid", w.messagingServiceSID)
+	} else {
+		form.Set("From", w.from)
+	}
+	if w.statusCallback != nil {
+		if cb := w.statusCallback.String(msg); cb != "" {
+			form.Set("StatusCallback", cb)
+		}
+	}
+
+	if err := w.limiter.wait(ctx, to); err != nil {
+		return err
+	}
+
+	endpoint := w.apiURL + "/2010-04-01/Accounts/" + url.PathEscape(w.accountSID) + "/Messages.json"
+	encoded := form.Encode()
+	resBytes, err := w.client.send(ctx, func(ctx context.Context) (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "POST", endpoint, strings.NewReader(encoded))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.SetBasicAuth(w.accountSID, w.authToken)
+		return req, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	var res twilioMessage
+	if err := json.Unmarshal(resBytes, &res); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	if res.Status == "failed" {
+		if res.ErrorCode != nil {
+			return fmt.Errorf("failed to send SMS: %v (%v)", res.ErrorMessage, *res.ErrorCode)
+		}
+		return errors.New("failed to send SMS")
+	}
+	propagateResponse(msg, map[string]string{
+		"twilio_sid":    res.SID,
+		"twilio_status": res.Status,
+	}, w.log)
+	return nil
+}
+
+func (w *twilioSMSWriter) Close(ctx context.Context) error {
+	return nil
+}
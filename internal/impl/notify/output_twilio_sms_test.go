@@ -0,0 +1,152 @@
+package notify
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/transaction"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func newTwilioTestWriter(t *testing.T, apiURL, conf string) *twilioSMSWriter {
+	t.Helper()
+
+	parsed, err := twilioSMSOutputConfig().ParseYAML(conf+"\napi_url: "+apiURL+"\n", nil)
+	require.NoError(t, err)
+
+	w, err := newTwilioSMSWriterFromConfig(parsed, nil)
+	require.NoError(t, err)
+
+	w.client.backoff = time.Millisecond
+	return w
+}
+
+func TestTwilioSMSOutput(t *testing.T) {
+	srv := &testAPIServer{responses: []testAPIResponse{
+		{status: 429, headers: map[string]string{"Retry-After": "0"}, body: `{"code":20429,"message":"Too Many Requests"}`},
+		{status: 201, body: `{"sid":"SM123","status":"queued","error_code":null}`},
+	}}
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	w := newTwilioTestWriter(t, ts.URL, `
+account_sid: AC123
+auth_token: secret
+from: "+15557122661"
+to: ${! meta("to") }
+body: 'Alert: ${! json("summary") }'
+status_callback: https://example.com/status?alert_id=${! json("id") }
+`)
+
+	store := transaction.NewResultStore()
+	msg := service.NewMessage([]byte(`{"id":"a1","summary":"disk full"}`))
+	msg.MetaSet("to", "+15558675310")
+	msg = msg.WithContext(context.WithValue(context.Background(), transaction.ResultStoreKey, store))
+
+	require.NoError(t, w.Write(context.Background(), msg))
+
+	assert.Equal(t, "Basic QUMxMjM6c2VjcmV0", srv.authHeader)
+	assert.Equal(t, []string{
+		"/2010-04-01/Accounts/AC123/Messages.json",
+		"/2010-04-01/Accounts/AC123/Messages.json",
+	}, srv.paths)
+	require.Len(t, srv.requests, 2)
+	assert.Equal(t, map[string]interface{}{
+		"To":             "+15558675310",
+		"From":           "+15557122661",
+		"Body":           "Alert: disk full",
+		"StatusCallback": "https://example.com/status?alert_id=a1",
+	}, srv.requests[1])
+
+	results := store.Get()
+	require.Len(t, results, 1)
+	require.Equal(t, 1, results[0].Len())
+	res := results[0].Get(0)
+	assert.Equal(t, `{"id":"a1","summary":"disk full"}`, string(res.Get()))
+	assert.Equal(t, "+15558675310", res.MetaGet("to"))
+	assert.Equal(t, "SM123", res.MetaGet("twilio_sid"))
+	assert.Equal(t, "queued", res.MetaGet("twilio_status"))
+}
+
+func TestTwilioSMSOutputMessagingService(t *testing.T) {
+	srv := &testAPIServer{responses: []testAPIResponse{{status: 201, body: `{"sid":"SM123","status":"accepted"}`}}}
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	w := newTwilioTestWriter(t, ts.URL, `
+account_sid: AC123
+auth_token: secret
+messaging_service_sid: MG123
+to: "+15558675310"
+`)
+	require.NoError(t, w.Write(context.Background(), service.NewMessage([]byte("hello"))))
+
+	require.Len(t, srv.requests, 1)
+	assert.Equal(t, map[string]interface{}{
+		"To":                  "+15558675310",
+		"MessagingServiceSid": "MG123",
+		"Body":                "hello",
+	}, srv.requests[0])
+}
+
+func TestTwilioSMSOutputErrors(t *testing.T) {
+	srv := &testAPIServer{responses: []testAPIResponse{
+		{status: 400, body: `{"code":21211,"message":"The 'To' number is not a valid phone number."}`},
+		{status: 201, body: `{"sid":"SM123","status":"failed","error_code":30003,"error_message":"Unreachable destination handset"}`},
+	}}
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	w := newTwilioTestWriter(t, ts.URL, `
+account_sid: AC123
+auth_token: secret
+from: "+15557122661"
+to: "+1"
+`)
+
+	err := w.Write(context.Background(), service.NewMessage([]byte("hello")))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not a valid phone number")
+
+	err = w.Write(context.Background(), service.NewMessage([]byte("hello")))
+	require.EqualError(t, err, "failed to send SMS: Unreachable destination handset (30003)")
+
+	for _, conf := range []string{
+		`
+account_sid: AC123
+auth_token: secret
+to: "+15558675310"
+`,
+		`
+account_sid: AC123
+from: "+15557122661"
+to: "+15558675310"
+`,
+	} {
+		parsed, err := twilioSMSOutputConfig().ParseYAML(conf, nil)
+		if err == nil {
+			_, err = newTwilioSMSWriterFromConfig(parsed, nil)
+		}
+		assert.Error(t, err, conf)
+	}
+}
+
+func TestDestinationLimiter(t *testing.T) {
+	l := newDestinationLimiter(time.Minute)
+	now := time.Now()
+
+	assert.Equal(t, now, l.reserve("a", now))
+	assert.Equal(t, now, l.reserve("b", now))
+	assert.Equal(t, now.Add(time.Minute), l.reserve("a", now))
+	assert.Equal(t, now.Add(time.Minute*2), l.reserve("a", now.Add(time.Second)))
+	assert.Equal(t, now.Add(time.Minute*5), l.reserve("a", now.Add(time.Minute*5)))
+
+	ctx, done := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer done()
+	assert.Error(t, l.wait(ctx, "b"))
+}
@@ -0,0 +1,366 @@
+package opa
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/open-policy-agent/opa/rego"
+
+	"github.com/benthosdev/benthos/v4/public/bloblang"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	opaFieldQuery       = "query"
+	opaFieldPolicy      = "policy"
+	opaFieldPolicyFiles = "policy_files"
+	opaFieldURL         = "url"
+	opaFieldHeaders     = "headers"
+	opaFieldTimeout     = "timeout"
+	opaFieldTLS         = "tls"
+	opaFieldInput       = "input"
+	opaFieldAction      = "action"
+
+	decisionMetaKey = "opa_decision"
+)
+
+func processorConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		// Stable(). TODO
+		Categories("Utility").
+		Version("4.3.0").
+		Summary("Evaluates an [Open Policy Agent](https://www.openpolicyagent.org/) Rego policy against each message, annotating messages with the decision or blocking messages that are denied.").
+		Description(`
+Policies are either embedded, in which case they are specified with the fields `+"`policy` or `policy_files`"+` and evaluated within Benthos, or evaluated by a remote OPA server specified with the field `+"`url`"+` via its [Data API](https://www.openpolicyagent.org/docs/latest/rest-api/#data-api), which allows data governance rules to be managed centrally.
+
+The input document of each evaluation is the structured contents of the message, or the result of the `+"`input`"+` mapping when specified.
+
+### Actions
+
+With the action `+"`annotate`"+` the decision is added to each message as the metadata field `+"`opa_decision`"+`, encoded as JSON, and messages are otherwise unchanged. Decisions that are undefined do not add the metadata field.
+
+With the actions `+"`drop` and `reject`"+` the query must result in a boolean, and messages where the decision is not `+"`true`"+` are either dropped or flagged as having failed respectively, where failed messages can be handled with [error handling patterns](/docs/configuration/error_handling). Undefined decisions are treated as denials.`).
+		Field(service.NewStringField(opaFieldQuery).
+			Description("The query to evaluate, which usually references a rule of the policy. When evaluating remotely the query must be a reference to a document within `data`.").
+			Example("data.benthos.allow").
+			Example("data.governance.pii.redact")).
+		Field(service.NewStringField(opaFieldPolicy).
+			Description("A Rego policy module to evaluate within Benthos.").
+			Example(`package benthos
+
+default allow = false
+
+allow {
+  input.classification != "restricted"
+}`).
+			Optional()).
+		Field(service.NewStringListField(opaFieldPolicyFiles).
+			Description("A list of paths of Rego policy files, or directories containing policy and data files, to evaluate within Benthos.").
+			Example([]string{"./policies"}).
+			Default([]string{})).
+		Field(service.NewStringField(opaFieldURL).
+			Description("The base URL of a remote OPA server to evaluate the query with, which is used instead of embedded policies.").
+			Example("http://localhost:8181").
+			Optional()).
+		Field(service.NewInterpolatedStringMapField(opaFieldHeaders).
+			Description("A map of headers to add to requests made to a remote OPA server.").
+			Default(map[string]interface{}{}).
+			Example(map[string]interface{}{
+				"Authorization": `Bearer ${! env("OPA_TOKEN") }`,
+			}).
+			Advanced()).
+		Field(service.NewDurationField(opaFieldTimeout).
+			Description("The maximum period to wait for a response from a remote OPA server.").
+			Default("5s").
+			Advanced()).
+		Field(service.NewTLSToggledField(opaFieldTLS)).
+		Field(service.NewBloblangField(opaFieldInput).
+			Description("An optional [Bloblang mapping](/docs/guides/bloblang/about) that returns the input document of each evaluation. By default the structured contents of the message are used.").
+			Example(`root = this
+root.topic = meta("kafka_topic")`).
+			Optional()).
+		Field(service.NewStringEnumField(opaFieldAction, "annotate", "drop", "reject").
+			Description("The action to take with the decision of each message.").
+			Default("annotate")).
+		Example("Block restricted data", "Drop messages that are denied by an embedded policy.", `
+pipeline:
+  processors:
+    - opa:
+        query: data.benthos.allow
+        action: drop
+        policy: |
+          package benthos
+
+          default allow = false
+
+          allow {
+            input.classification != "restricted"
+          }
+`).
+		Example("Remote decisions", "Annotate messages with decisions evaluated by a central OPA server, and route them based on the decision.", `
+pipeline:
+  processors:
+    - opa:
+        url: http://opa.internal:8181
+        query: data.governance.decision
+        input: |
+          root.document = this
+          root.source = meta("kafka_topic")
+    - bloblang: |
+        root = this
+        meta destination = meta("opa_decision").parse_json().destination
+`)
+}
+
+func init() {
+	err := service.RegisterProcessor(
+		"opa", processorConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Processor, error) {
+			return newProcessorFromConfig(context.Background(), conf)
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+// evaluator evaluates a query with an input document resolved from a message,
+// returning whether the decision is defined.
+type evaluator interface {
+	eval(ctx context.Context, msg *service.Message, input interface{}) (result interface{}, defined bool, err error)
+}
+
+type processor struct {
+	eval   evaluator
+	input  *bloblang.Executor
+	action string
+}
+
+func newProcessorFromConfig(ctx context.Context, conf *service.ParsedConfig) (*processor, error) {
+	p := &processor{}
+
+	query, err := conf.FieldString(opaFieldQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	var policy string
+	if conf.Contains(opaFieldPolicy) {
+		if policy, err = conf.FieldString(opaFieldPolicy); err != nil {
+			return nil, err
+		}
+	}
+	policyFiles, err := conf.FieldStringList(opaFieldPolicyFiles)
+	if err != nil {
+		return nil, err
+	}
+	embedded := policy != "" || len(policyFiles) > 0
+
+	if conf.Contains(opaFieldURL) {
+		if embedded {
+			return nil, errors.New("cannot specify both a url and embedded policies")
+		}
+		if p.eval, err = newRemoteEvaluatorFromConfig(conf, query); err != nil {
+			return nil, err
+		}
+	} else {
+		if !embedded {
+			return nil, errors.New("either a url or embedded policies must be specified")
+		}
+		if p.eval, err = newEmbeddedEvaluator(ctx, query, policy, policyFiles); err != nil {
+			return nil, err
+		}
+	}
+
+	if conf.Contains(opaFieldInput) {
+		if p.input, err = conf.FieldBloblang(opaFieldInput); err != nil {
+			return nil, err
+		}
+	}
+	if p.action, err = conf.FieldString(opaFieldAction); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *processor) resolveInput(msg *service.Message) (interface{}, error) {
+	if p.input == nil {
+		return msg.AsStructured()
+	}
+	res, err := msg.BloblangQuery(p.input)
+	if err != nil {
+		return nil, fmt.Errorf("input mapping failed: %w", err)
+	}
+	if res == nil {
+		return nil, errors.New("input mapping resulted in a deleted message")
+	}
+	return res.AsStructured()
+}
+
+func (p *processor) Process(ctx context.Context, msg *service.Message) (service.MessageBatch, error) {
+	input, err := p.resolveInput(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	result, defined, err := p.eval.eval(ctx, msg, input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to evaluate policy: %w", err)
+	}
+
+	if p.action == "annotate" {
+		if !defined {
+			return service.MessageBatch{msg}, nil
+		}
+		b, err := json.Marshal(result)
+		if err != nil {
+			return nil, err
+		}
+		msg.MetaSet(decisionMetaKey, string(b))
+		return service.MessageBatch{msg}, nil
+	}
+
+	var allowed bool
+	if defined {
+		var isBool bool
+		if allowed, isBool = result.(bool); !isBool {
+			return nil, fmt.Errorf("expected policy decision to be a boolean, got %T", result)
+		}
+	}
+	if allowed {
+		return service.MessageBatch{msg}, nil
+	}
+	if p.action == "drop" {
+		return nil, nil
+	}
+	return nil, errors.New("message denied by policy")
+}
+
+func (p *processor) Close(ctx context.Context) error {
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+type embeddedEvaluator struct {
+	query rego.PreparedEvalQuery
+}
+
+func newEmbeddedEvaluator(ctx context.Context, query, policy string, policyFiles []string) (*embeddedEvaluator, error) {
+	opts := []func(*rego.Rego){rego.Query(query)}
+	if policy != "" {
+		opts = append(opts, rego.Module("policy.rego", policy))
+	}
+	if len(policyFiles) > 0 {
+		opts = append(opts, rego.Load(policyFiles, nil))
+	}
+
+	q, err := rego.New(opts...).PrepareForEval(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare policy: %w", err)
+	}
+	return &embeddedEvaluator{query: q}, nil
+}
+
+func (e *embeddedEvaluator) eval(ctx context.Context, _ *service.Message, input interface{}) (interface{}, bool, error) {
+	rs, err := e.query.Eval(ctx, rego.EvalInput(input))
+	if err != nil {
+		return nil, false, err
+	}
+	if len(rs) == 0 || len(rs[0].Expressions) == 0 {
+		return nil, false, nil
+	}
+	return rs[0].Expressions[0].Value, true, nil
+}
+
+//------------------------------------------------------------------------------
+
+type remoteEvaluator struct {
+	url     string
+	headers map[string]*service.InterpolatedString
+	http    *http.Client
+}
+
+func newRemoteEvaluatorFromConfig(conf *service.ParsedConfig, query string) (*remoteEvaluator, error) {
+	if !strings.HasPrefix(query, "data.") {
+		return nil, fmt.Errorf("remote query must be a reference within data, got %v", query)
+	}
+
+	baseURL, err := conf.FieldString(opaFieldURL)
+	if err != nil {
+		return nil, err
+	}
+
+	e := &remoteEvaluator{
+		url: strings.TrimSuffix(baseURL, "/") + "/v1/data/" + strings.ReplaceAll(strings.TrimPrefix(query, "data."), ".", "/"),
+	}
+	if e.headers, err = conf.FieldInterpolatedStringMap(opaFieldHeaders); err != nil {
+		return nil, err
+	}
+
+	timeout, err := conf.FieldDuration(opaFieldTimeout)
+	if err != nil {
+		return nil, err
+	}
+	e.http = &http.Client{Timeout: timeout}
+
+	tlsConf, tlsEnabled, err := conf.FieldTLSToggled(opaFieldTLS)
+	if err != nil {
+		return nil, err
+	}
+	if tlsEnabled {
+		e.http.Transport = &http.Transport{TLSClientConfig: tlsConf.Clone()}
+	}
+	return e, nil
+}
+
+// eval executes the query with the Data API of the remote server, where headers
+// are interpolated from the message.
+func (e *remoteEvaluator) eval(ctx context.Context, msg *service.Message, input interface{}) (interface{}, bool, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"input": input,
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", e.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	for k, v := range e.headers {
+		req.Header.Set(k, v.String(msg))
+	}
+
+	res, err := e.http.Do(req)
+	if err != nil {
+		return nil, false, err
+	}
+	defer res.Body.Close()
+
+	resBytes, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, false, err
+	}
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return nil, false, fmt.Errorf("request failed with status %v: %s", res.StatusCode, resBytes)
+	}
+
+	var dataRes struct {
+		Result *interface{} `json:"result"`
+	}
+	if err := json.Unmarshal(resBytes, &dataRes); err != nil {
+		return nil, false, fmt.Errorf("failed to parse response: %w", err)
+	}
+	if dataRes.Result == nil {
+		return nil, false, nil
+	}
+	return *dataRes.Result, true, nil
+}
@@ -0,0 +1,199 @@
+package opa
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const testPolicy = `
+package benthos
+
+default allow = false
+
+allow {
+  input.classification != "restricted"
+}
+
+decision = {"allow": allow, "owner": input.owner}
+`
+
+func newTestProcessor(t *testing.T, conf string) *processor {
+	t.Helper()
+
+	parsed, err := processorConfig().ParseYAML(conf, nil)
+	require.NoError(t, err)
+
+	p, err := newProcessorFromConfig(context.Background(), parsed)
+	require.NoError(t, err)
+	return p
+}
+
+func policyConf(query, action string) string {
+	b, _ := json.Marshal(testPolicy)
+	return `
+query: ` + query + `
+action: ` + action + `
+policy: ` + string(b) + `
+`
+}
+
+func TestProcessorEmbeddedAnnotate(t *testing.T) {
+	p := newTestProcessor(t, policyConf("data.benthos.decision", "annotate"))
+
+	batch, err := p.Process(context.Background(), service.NewMessage([]byte(`{"classification":"public","owner":"foo"}`)))
+	require.NoError(t, err)
+	require.Len(t, batch, 1)
+
+	v, exists := batch[0].MetaGet(decisionMetaKey)
+	require.True(t, exists)
+	assert.JSONEq(t, `{"allow":true,"owner":"foo"}`, v)
+
+	b, err := batch[0].AsBytes()
+	require.NoError(t, err)
+	assert.Equal(t, `{"classification":"public","owner":"foo"}`, string(b))
+
+	// The decision is undefined as the owner is missing.
+	batch, err = p.Process(context.Background(), service.NewMessage([]byte(`{"classification":"public"}`)))
+	require.NoError(t, err)
+	require.Len(t, batch, 1)
+
+	_, exists = batch[0].MetaGet(decisionMetaKey)
+	assert.False(t, exists)
+}
+
+func TestProcessorEmbeddedBlock(t *testing.T) {
+	drop := newTestProcessor(t, policyConf("data.benthos.allow", "drop"))
+	reject := newTestProcessor(t, policyConf("data.benthos.allow", "reject"))
+
+	for _, p := range []*processor{drop, reject} {
+		batch, err := p.Process(context.Background(), service.NewMessage([]byte(`{"classification":"public"}`)))
+		require.NoError(t, err)
+		require.Len(t, batch, 1)
+	}
+
+	batch, err := drop.Process(context.Background(), service.NewMessage([]byte(`{"classification":"restricted"}`)))
+	require.NoError(t, err)
+	assert.Empty(t, batch)
+
+	_, err = reject.Process(context.Background(), service.NewMessage([]byte(`{"classification":"restricted"}`)))
+	require.EqualError(t, err, "message denied by policy")
+
+	nonBool := newTestProcessor(t, policyConf("data.benthos.decision", "drop"))
+	_, err = nonBool.Process(context.Background(), service.NewMessage([]byte(`{"classification":"public","owner":"foo"}`)))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "expected policy decision to be a boolean")
+}
+
+func TestProcessorEmbeddedInputMapping(t *testing.T) {
+	b, _ := json.Marshal(testPolicy)
+	p := newTestProcessor(t, `
+query: data.benthos.allow
+action: drop
+policy: `+string(b)+`
+input: 'root.classification = meta("classification")'
+`)
+
+	msg := service.NewMessage([]byte(`not json`))
+	msg.MetaSet("classification", "restricted")
+
+	batch, err := p.Process(context.Background(), msg)
+	require.NoError(t, err)
+	assert.Empty(t, batch)
+}
+
+func TestProcessorEmbeddedPolicyFiles(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "policy.rego"), []byte(testPolicy), 0o600))
+
+	p := newTestProcessor(t, `
+query: data.benthos.allow
+action: drop
+policy_files: [ `+dir+` ]
+`)
+
+	batch, err := p.Process(context.Background(), service.NewMessage([]byte(`{"classification":"restricted"}`)))
+	require.NoError(t, err)
+	assert.Empty(t, batch)
+}
+
+func TestProcessorConfigErrors(t *testing.T) {
+	for _, conf := range []string{
+		`query: data.benthos.allow`,
+		`
+query: data.benthos.allow
+url: http://localhost:8181
+policy: 'package benthos'
+`,
+		`
+query: input.foo
+url: http://localhost:8181
+`,
+		`
+query: data.benthos.allow
+policy: 'not rego'
+`,
+	} {
+		parsed, err := processorConfig().ParseYAML(conf, nil)
+		require.NoError(t, err)
+
+		_, err = newProcessorFromConfig(context.Background(), parsed)
+		assert.Error(t, err, conf)
+	}
+}
+
+func TestProcessorRemote(t *testing.T) {
+	var paths, auths []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		auths = append(auths, r.Header.Get("Authorization"))
+
+		var req struct {
+			Input map[string]interface{} `json:"input"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+
+		switch req.Input["classification"] {
+		case "public":
+			_, _ = w.Write([]byte(`{"result":true}`))
+		case "restricted":
+			_, _ = w.Write([]byte(`{"result":false}`))
+		default:
+			_, _ = w.Write([]byte(`{}`))
+		}
+	}))
+	defer ts.Close()
+
+	p := newTestProcessor(t, `
+url: `+ts.URL+`/
+query: data.benthos.allow
+action: reject
+headers:
+  Authorization: Bearer ${! meta("token") }
+`)
+
+	msg := service.NewMessage([]byte(`{"classification":"public"}`))
+	msg.MetaSet("token", "abc")
+
+	batch, err := p.Process(context.Background(), msg)
+	require.NoError(t, err)
+	require.Len(t, batch, 1)
+
+	_, err = p.Process(context.Background(), service.NewMessage([]byte(`{"classification":"restricted"}`)))
+	require.Error(t, err)
+
+	_, err = p.Process(context.Background(), service.NewMessage([]byte(`{}`)))
+	require.Error(t, err)
+
+	assert.Equal(t, []string{"/v1/data/benthos/allow", "/v1/data/benthos/allow", "/v1/data/benthos/allow"}, paths)
+	assert.Equal(t, "Bearer abc", auths[0])
+}
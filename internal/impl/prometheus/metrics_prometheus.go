@@ -95,6 +95,14 @@ func (p *promTiming) Timing(val int64) {
 	p.sum.Observe(vFloat)
 }
 
+type promHistogram struct {
+	obs prometheus.Observer
+}
+
+func (p *promHistogram) Observe(value float64) {
+	p.obs.Observe(value)
+}
+
 //------------------------------------------------------------------------------
 
 type promCounterVec struct {
@@ -131,6 +139,28 @@ func (p *promTimingHistVec) With(labelValues ...string) metrics.StatTimer {
 	}
 }
 
+type promHistogramVec struct {
+	obs   *prometheus.HistogramVec
+	count int
+}
+
+func (p *promHistogramVec) With(labelValues ...string) metrics.StatHistogram {
+	return &promHistogram{
+		obs: p.obs.WithLabelValues(labelValues...),
+	}
+}
+
+type promSummaryVec struct {
+	obs   *prometheus.SummaryVec
+	count int
+}
+
+func (p *promSummaryVec) With(labelValues ...string) metrics.StatHistogram {
+	return &promHistogram{
+		obs: p.obs.WithLabelValues(labelValues...),
+	}
+}
+
 type promGaugeVec struct {
 	ctr   *prometheus.GaugeVec
 	count int
@@ -161,6 +191,8 @@ type prometheusMetrics struct {
 	gauges     map[string]*promGaugeVec
 	timers     map[string]*promTimingVec
 	timersHist map[string]*promTimingHistVec
+	histograms map[string]*promHistogramVec
+	summaries  map[string]*promSummaryVec
 
 	mut sync.Mutex
 }
@@ -178,6 +210,8 @@ func newPrometheus(config metrics.Config, nm bundle.NewManagement) (metrics.Type
 		gauges:             map[string]*promGaugeVec{},
 		timers:             map[string]*promTimingVec{},
 		timersHist:         map[string]*promTimingHistVec{},
+		histograms:         map[string]*promHistogramVec{},
+		summaries:          map[string]*promSummaryVec{},
 	}
 
 	if len(p.histogramBuckets) == 0 {
@@ -346,6 +380,83 @@ func (p *prometheusMetrics) getTimerHistVec(path string, labelNames ...string) m
 	return pv
 }
 
+// GetHistogramVec returns a histogram of arbitrary values, where the buckets
+// default to DefBuckets when empty.
+func (p *prometheusMetrics) GetHistogramVec(path string, buckets []float64, labelNames ...string) metrics.StatHistogramVec {
+	if !model.IsValidMetricName(model.LabelValue(path)) {
+		p.log.Errorf("Ignoring metric '%v' due to invalid name", path)
+		return metrics.FakeHistogramVec(func(l ...string) metrics.StatHistogram {
+			return metrics.DudStat{}
+		})
+	}
+
+	if len(buckets) == 0 {
+		buckets = prometheus.DefBuckets
+	}
+
+	var pv *promHistogramVec
+
+	p.mut.Lock()
+	var exists bool
+	if pv, exists = p.histograms[path]; !exists {
+		hist := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    path,
+			Help:    "Benthos Histogram metric",
+			Buckets: buckets,
+		}, labelNames)
+		p.reg.MustRegister(hist)
+
+		pv = &promHistogramVec{
+			obs:   hist,
+			count: len(labelNames),
+		}
+		p.histograms[path] = pv
+	}
+	p.mut.Unlock()
+
+	if pv.count != len(labelNames) {
+		p.log.Errorf("Metrics label mismatch %v versus %v %v for name '%v', skipping metric", pv.count, len(labelNames), labelNames, path)
+		return metrics.Noop().GetHistogramVec(path, buckets, labelNames...)
+	}
+	return pv
+}
+
+// GetSummaryVec returns a summary of arbitrary values.
+func (p *prometheusMetrics) GetSummaryVec(path string, labelNames ...string) metrics.StatHistogramVec {
+	if !model.IsValidMetricName(model.LabelValue(path)) {
+		p.log.Errorf("Ignoring metric '%v' due to invalid name", path)
+		return metrics.FakeHistogramVec(func(l ...string) metrics.StatHistogram {
+			return metrics.DudStat{}
+		})
+	}
+
+	var pv *promSummaryVec
+
+	p.mut.Lock()
+	var exists bool
+	if pv, exists = p.summaries[path]; !exists {
+		sum := prometheus.NewSummaryVec(prometheus.SummaryOpts{
+			Name:       path,
+			Help:       "Benthos Summary metric",
+			Objectives: map[float64]float64{0.5: 0.05, 0.9: 0.01, 0.99: 0.001},
+		}, labelNames)
+		p.reg.MustRegister(sum)
+
+		pv = &promSummaryVec{
+			obs:   sum,
+			count: len(labelNames),
+		}
+		p.summaries[path] = pv
+	}
+	p.mut.Unlock()
+
+	if pv.count != len(labelNames) {
+		p.log.Errorf("Metrics label mismatch %v versus %v %v for name '%v', skipping metric", pv.count, len(labelNames), labelNames, path)
+		return metrics.Noop().GetSummaryVec(path, labelNames...)
+	}
+	return pv
+}
+
 func (p *prometheusMetrics) GetGauge(path string) metrics.StatGauge {
 	return p.GetGaugeVec(path).With()
 }
@@ -167,6 +167,31 @@ func TestPrometheusHistMetrics(t *testing.T) {
 	assert.Contains(t, body, "\ntimertwo_sum{label3=\"value4\",label4=\"value5\"} 1.4e-08")
 }
 
+func TestPrometheusHistogramAndSummaryMetrics(t *testing.T) {
+	nm, err := newPrometheus(metrics.NewConfig(), mock.NewManager())
+	require.NoError(t, err)
+
+	ns := metrics.NewNamespaced(nm).WithLabels("stream", "foo")
+
+	hist := ns.GetHistogramVec("histone", []float64{1, 10}, "label1")
+	hist.With("value1").Observe(0.5)
+	hist.With("value1").Observe(5.5)
+	hist.With("value1").Observe(50)
+
+	sum := ns.GetSummaryVec("sumone")
+	sum.With().Observe(2.5)
+	sum.With().Observe(3.5)
+
+	body := getPage(t, nm.HandlerFunc())
+
+	assert.Contains(t, body, "\nhistone_bucket{label1=\"value1\",stream=\"foo\",le=\"1\"} 1")
+	assert.Contains(t, body, "\nhistone_bucket{label1=\"value1\",stream=\"foo\",le=\"10\"} 2")
+	assert.Contains(t, body, "\nhistone_bucket{label1=\"value1\",stream=\"foo\",le=\"+Inf\"} 3")
+	assert.Contains(t, body, "\nhistone_sum{label1=\"value1\",stream=\"foo\"} 56")
+	assert.Contains(t, body, "\nsumone_sum{stream=\"foo\"} 6")
+	assert.Contains(t, body, "\nsumone_count{stream=\"foo\"} 2")
+}
+
 func TestPrometheusWithFileOutputPath(t *testing.T) {
 	config := metrics.NewConfig()
 	config.Prometheus.FileOutputPath = os.TempDir() + "/benthos_metrics.prom"
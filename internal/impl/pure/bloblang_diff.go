@@ -0,0 +1,468 @@
+package pure
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
+	"github.com/benthosdev/benthos/v4/public/bloblang"
+)
+
+func init() {
+	// Note: The examples are run and tested from within
+	// ./internal/bloblang/query/parsed_test.go
+
+	diffSpec := bloblang.NewPluginSpec().
+		Beta().
+		Category(query.MethodCategoryObjectAndArray).
+		Description(`Computes the differences between the target value and another, returning a document that describes how to transform the target into the other value. The result can be applied to a value with the `+"[`patch`](#patch)"+` method.
+
+By default the result is a [JSON Patch](https://datatracker.ietf.org/doc/html/rfc6902) array of operations. When the format `+"`merge_patch`"+` is chosen the result is instead a [JSON Merge Patch](https://datatracker.ietf.org/doc/html/rfc7386) object, which is more compact but is unable to describe changes to array elements individually or fields being set to `+"`null`"+`.`).
+		Param(bloblang.NewAnyParam("other").Description("The value to compare against.")).
+		Param(bloblang.NewStringParam("format").Description("The format of the resulting document, either `json_patch` or `merge_patch`.").Default("json_patch")).
+		Version("4.3.0").
+		Example("",
+			`root = this.before.diff(this.after)`,
+			[2]string{
+				`{"before":{"id":"foo","tags":["a","b"],"age":10},"after":{"id":"foo","tags":["a","c","d"],"name":"bar"}}`,
+				`[{"op":"remove","path":"/age"},{"op":"add","path":"/name","value":"bar"},{"op":"replace","path":"/tags/1","value":"c"},{"op":"add","path":"/tags/2","value":"d"}]`,
+			}).
+		Example("",
+			`root = this.before.diff(this.after, "merge_patch")`,
+			[2]string{
+				`{"before":{"id":"foo","tags":["a","b"],"age":10},"after":{"id":"foo","tags":["a","c","d"],"name":"bar"}}`,
+				`{"age":null,"name":"bar","tags":["a","c","d"]}`,
+			})
+
+	diffCtor := func(args *bloblang.ParsedParams) (bloblang.Method, error) {
+		other, err := args.Get("other")
+		if err != nil {
+			return nil, err
+		}
+		format, err := args.GetString("format")
+		if err != nil {
+			return nil, err
+		}
+		switch format {
+		case "json_patch":
+			return func(v interface{}) (interface{}, error) {
+				return jsonPatchDiff("", v, other, []interface{}{}), nil
+			}, nil
+		case "merge_patch":
+			return func(v interface{}) (interface{}, error) {
+				return mergePatchDiff(v, other), nil
+			}, nil
+		}
+		return nil, fmt.Errorf("unrecognised diff format: %v", format)
+	}
+
+	if err := bloblang.RegisterMethodV2("diff", diffSpec, diffCtor); err != nil {
+		panic(err)
+	}
+
+	patchSpec := bloblang.NewPluginSpec().
+		Beta().
+		Category(query.MethodCategoryObjectAndArray).
+		Description(`Applies a patch document to the target value and returns the result. The patch can either be a [JSON Patch](https://datatracker.ietf.org/doc/html/rfc6902) array of operations or a [JSON Merge Patch](https://datatracker.ietf.org/doc/html/rfc7386) object, and is usually produced by the `+"[`diff`](#diff)"+` method.
+
+JSON Patch documents support the operations `+"`add`, `remove`, `replace`, `move`, `copy` and `test`"+`. If any operation fails, including a `+"`test`"+` operation, then an error is returned and the target is left unchanged.`).
+		Param(bloblang.NewAnyParam("patch").Description("The patch document to apply.")).
+		Version("4.3.0").
+		Example("",
+			`root = this.doc.patch(this.ops)`,
+			[2]string{
+				`{"doc":{"id":"foo","tags":["a"]},"ops":[{"op":"add","path":"/tags/-","value":"b"},{"op":"move","from":"/id","path":"/name"}]}`,
+				`{"name":"foo","tags":["a","b"]}`,
+			}).
+		Example("",
+			`root = this.doc.patch({"status":"done","draft":null})`,
+			[2]string{
+				`{"doc":{"id":"foo","status":"pending","draft":true}}`,
+				`{"id":"foo","status":"done"}`,
+			})
+
+	patchCtor := func(args *bloblang.ParsedParams) (bloblang.Method, error) {
+		patch, err := args.Get("patch")
+		if err != nil {
+			return nil, err
+		}
+		switch t := patch.(type) {
+		case []interface{}:
+			return func(v interface{}) (interface{}, error) {
+				return applyJSONPatch(v, t)
+			}, nil
+		case map[string]interface{}:
+			return func(v interface{}) (interface{}, error) {
+				return applyMergePatch(query.IClone(v), t), nil
+			}, nil
+		}
+		return nil, fmt.Errorf("expected patch to be an array or object, got %v", query.ITypeOf(patch))
+	}
+
+	if err := bloblang.RegisterMethodV2("patch", patchSpec, patchCtor); err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+var jsonPointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+func jsonPatchOp(op, path string, value ...interface{}) map[string]interface{} {
+	obj := map[string]interface{}{
+		"op":   op,
+		"path": path,
+	}
+	if len(value) > 0 {
+		obj["value"] = query.IClone(value[0])
+	}
+	return obj
+}
+
+func jsonPatchDiff(path string, from, to interface{}, ops []interface{}) []interface{} {
+	switch fromT := from.(type) {
+	case map[string]interface{}:
+		toT, ok := to.(map[string]interface{})
+		if !ok {
+			break
+		}
+		for _, k := range sortedKeys(fromT) {
+			if _, exists := toT[k]; !exists {
+				ops = append(ops, jsonPatchOp("remove", path+"/"+jsonPointerEscaper.Replace(k)))
+			}
+		}
+		for _, k := range sortedKeys(toT) {
+			keyPath := path + "/" + jsonPointerEscaper.Replace(k)
+			if fromV, exists := fromT[k]; exists {
+				ops = jsonPatchDiff(keyPath, fromV, toT[k], ops)
+			} else {
+				ops = append(ops, jsonPatchOp("add", keyPath, toT[k]))
+			}
+		}
+		return ops
+	case []interface{}:
+		toT, ok := to.([]interface{})
+		if !ok {
+			break
+		}
+		i := 0
+		for ; i < len(fromT) && i < len(toT); i++ {
+			ops = jsonPatchDiff(path+"/"+strconv.Itoa(i), fromT[i], toT[i], ops)
+		}
+		for j := i; j < len(toT); j++ {
+			ops = append(ops, jsonPatchOp("add", path+"/"+strconv.Itoa(j), toT[j]))
+		}
+		for j := len(fromT) - 1; j >= i; j-- {
+			ops = append(ops, jsonPatchOp("remove", path+"/"+strconv.Itoa(j)))
+		}
+		return ops
+	}
+	if !query.ICompare(from, to) {
+		ops = append(ops, jsonPatchOp("replace", path, to))
+	}
+	return ops
+}
+
+func mergePatchDiff(from, to interface{}) interface{} {
+	fromT, fromIsObj := from.(map[string]interface{})
+	toT, toIsObj := to.(map[string]interface{})
+	if !fromIsObj || !toIsObj {
+		return query.IClone(to)
+	}
+
+	patch := map[string]interface{}{}
+	for k := range fromT {
+		if _, exists := toT[k]; !exists {
+			patch[k] = nil
+		}
+	}
+	for k, toV := range toT {
+		fromV, exists := fromT[k]
+		if !exists {
+			patch[k] = query.IClone(toV)
+			continue
+		}
+		_, fromVIsObj := fromV.(map[string]interface{})
+		_, toVIsObj := toV.(map[string]interface{})
+		if fromVIsObj && toVIsObj {
+			if sub := mergePatchDiff(fromV, toV).(map[string]interface{}); len(sub) > 0 {
+				patch[k] = sub
+			}
+		} else if !query.ICompare(fromV, toV) {
+			patch[k] = query.IClone(toV)
+		}
+	}
+	return patch
+}
+
+//------------------------------------------------------------------------------
+
+func applyMergePatch(target, patch interface{}) interface{} {
+	patchT, ok := patch.(map[string]interface{})
+	if !ok {
+		return query.IClone(patch)
+	}
+	targetT, ok := target.(map[string]interface{})
+	if !ok {
+		targetT = map[string]interface{}{}
+	}
+	for k, v := range patchT {
+		if v == nil {
+			delete(targetT, k)
+		} else {
+			targetT[k] = applyMergePatch(targetT[k], v)
+		}
+	}
+	return targetT
+}
+
+func jsonPointerTokens(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if pointer[0] != '/' {
+		return nil, fmt.Errorf("invalid path %q: must begin with a slash", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, t := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(t, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+func jsonPatchIndex(token string, length int) (int, error) {
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+	if i >= length {
+		return 0, fmt.Errorf("array index %v out of bounds", i)
+	}
+	return i, nil
+}
+
+func jsonPatchGet(node interface{}, tokens []string) (interface{}, error) {
+	for _, token := range tokens {
+		switch t := node.(type) {
+		case map[string]interface{}:
+			v, exists := t[token]
+			if !exists {
+				return nil, fmt.Errorf("field %q not found", token)
+			}
+			node = v
+		case []interface{}:
+			i, err := jsonPatchIndex(token, len(t))
+			if err != nil {
+				return nil, err
+			}
+			node = t[i]
+		default:
+			return nil, fmt.Errorf("expected object or array at %q, got %v", token, query.ITypeOf(node))
+		}
+	}
+	return node, nil
+}
+
+// jsonPatchModify walks to the parent of the final token and replaces it with
+// the result of fn, which is given the parent container and final token.
+func jsonPatchModify(node interface{}, tokens []string, fn func(parent interface{}, key string) (interface{}, error)) (interface{}, error) {
+	if len(tokens) == 1 {
+		return fn(node, tokens[0])
+	}
+	switch t := node.(type) {
+	case map[string]interface{}:
+		child, exists := t[tokens[0]]
+		if !exists {
+			return nil, fmt.Errorf("field %q not found", tokens[0])
+		}
+		newChild, err := jsonPatchModify(child, tokens[1:], fn)
+		if err != nil {
+			return nil, err
+		}
+		t[tokens[0]] = newChild
+		return t, nil
+	case []interface{}:
+		i, err := jsonPatchIndex(tokens[0], len(t))
+		if err != nil {
+			return nil, err
+		}
+		newChild, err := jsonPatchModify(t[i], tokens[1:], fn)
+		if err != nil {
+			return nil, err
+		}
+		t[i] = newChild
+		return t, nil
+	}
+	return nil, fmt.Errorf("expected object or array at %q, got %v", tokens[0], query.ITypeOf(node))
+}
+
+func jsonPatchAdd(root interface{}, tokens []string, value interface{}) (interface{}, error) {
+	if len(tokens) == 0 {
+		return value, nil
+	}
+	return jsonPatchModify(root, tokens, func(parent interface{}, key string) (interface{}, error) {
+		switch t := parent.(type) {
+		case map[string]interface{}:
+			t[key] = value
+			return t, nil
+		case []interface{}:
+			if key == "-" {
+				return append(t, value), nil
+			}
+			i, err := jsonPatchIndex(key, len(t)+1)
+			if err != nil {
+				return nil, err
+			}
+			t = append(t, nil)
+			copy(t[i+1:], t[i:])
+			t[i] = value
+			return t, nil
+		}
+		return nil, fmt.Errorf("expected object or array at %q, got %v", key, query.ITypeOf(parent))
+	})
+}
+
+func jsonPatchReplace(root interface{}, tokens []string, value interface{}) (interface{}, error) {
+	if len(tokens) == 0 {
+		return value, nil
+	}
+	return jsonPatchModify(root, tokens, func(parent interface{}, key string) (interface{}, error) {
+		switch t := parent.(type) {
+		case map[string]interface{}:
+			if _, exists := t[key]; !exists {
+				return nil, fmt.Errorf("field %q not found", key)
+			}
+			t[key] = value
+			return t, nil
+		case []interface{}:
+			i, err := jsonPatchIndex(key, len(t))
+			if err != nil {
+				return nil, err
+			}
+			t[i] = value
+			return t, nil
+		}
+		return nil, fmt.Errorf("expected object or array at %q, got %v", key, query.ITypeOf(parent))
+	})
+}
+
+func jsonPatchRemove(root interface{}, tokens []string) (newRoot, removed interface{}, err error) {
+	if len(tokens) == 0 {
+		return nil, nil, errors.New("cannot remove the root of the document")
+	}
+	newRoot, err = jsonPatchModify(root, tokens, func(parent interface{}, key string) (interface{}, error) {
+		switch t := parent.(type) {
+		case map[string]interface{}:
+			v, exists := t[key]
+			if !exists {
+				return nil, fmt.Errorf("field %q not found", key)
+			}
+			removed = v
+			delete(t, key)
+			return t, nil
+		case []interface{}:
+			i, err := jsonPatchIndex(key, len(t))
+			if err != nil {
+				return nil, err
+			}
+			removed = t[i]
+			return append(t[:i], t[i+1:]...), nil
+		}
+		return nil, fmt.Errorf("expected object or array at %q, got %v", key, query.ITypeOf(parent))
+	})
+	return
+}
+
+func applyJSONPatch(root interface{}, ops []interface{}) (interface{}, error) {
+	root = query.IClone(root)
+	for i, opV := range ops {
+		opObj, ok := opV.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("operation %v: expected object, got %v", i, query.ITypeOf(opV))
+		}
+		opStr, _ := opObj["op"].(string)
+		pathStr, ok := opObj["path"].(string)
+		if !ok {
+			return nil, fmt.Errorf("operation %v (%v): missing path field", i, opStr)
+		}
+		tokens, err := jsonPointerTokens(pathStr)
+		if err != nil {
+			return nil, fmt.Errorf("operation %v (%v): %w", i, opStr, err)
+		}
+
+		getFrom := func() ([]string, error) {
+			fromStr, ok := opObj["from"].(string)
+			if !ok {
+				return nil, errors.New("missing from field")
+			}
+			return jsonPointerTokens(fromStr)
+		}
+		getValue := func() (interface{}, error) {
+			v, exists := opObj["value"]
+			if !exists {
+				return nil, errors.New("missing value field")
+			}
+			return query.IClone(v), nil
+		}
+
+		switch opStr {
+		case "add":
+			var v interface{}
+			if v, err = getValue(); err == nil {
+				root, err = jsonPatchAdd(root, tokens, v)
+			}
+		case "remove":
+			root, _, err = jsonPatchRemove(root, tokens)
+		case "replace":
+			var v interface{}
+			if v, err = getValue(); err == nil {
+				root, err = jsonPatchReplace(root, tokens, v)
+			}
+		case "move":
+			var from []string
+			if from, err = getFrom(); err == nil {
+				if len(tokens) > len(from) && strings.HasPrefix(pathStr, opObj["from"].(string)+"/") {
+					err = errors.New("cannot move a value into one of its children")
+				} else {
+					var v interface{}
+					if root, v, err = jsonPatchRemove(root, from); err == nil {
+						root, err = jsonPatchAdd(root, tokens, v)
+					}
+				}
+			}
+		case "copy":
+			var from []string
+			if from, err = getFrom(); err == nil {
+				var v interface{}
+				if v, err = jsonPatchGet(root, from); err == nil {
+					root, err = jsonPatchAdd(root, tokens, query.IClone(v))
+				}
+			}
+		case "test":
+			var expected, v interface{}
+			if expected, err = getValue(); err == nil {
+				if v, err = jsonPatchGet(root, tokens); err == nil && !query.ICompare(v, expected) {
+					err = fmt.Errorf("value at %q does not match", pathStr)
+				}
+			}
+		default:
+			err = errors.New("unrecognised operation")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("operation %v (%v): %w", i, opStr, err)
+		}
+	}
+	return root, nil
+}
@@ -0,0 +1,134 @@
+package pure
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/bloblang"
+)
+
+func TestDiffPatchMethods(t *testing.T) {
+	tests := []struct {
+		name               string
+		mapping            string
+		input              interface{}
+		output             interface{}
+		parseErrorContains string
+		execErrorContains  string
+	}{
+		{
+			name:    "diff escapes paths",
+			mapping: `root = {"a/b":{"c~":1}}.diff({"a/b":{"c~":2}})`,
+			output: []interface{}{
+				map[string]interface{}{"op": "replace", "path": "/a~1b/c~0", "value": int64(2)},
+			},
+		},
+		{
+			name:    "diff shrinking array",
+			mapping: `root = [1,2,3].diff([1])`,
+			output: []interface{}{
+				map[string]interface{}{"op": "remove", "path": "/2"},
+				map[string]interface{}{"op": "remove", "path": "/1"},
+			},
+		},
+		{
+			name:    "diff different types",
+			mapping: `root = [1,2].diff({"a":1})`,
+			output: []interface{}{
+				map[string]interface{}{"op": "replace", "path": "", "value": map[string]interface{}{"a": int64(1)}},
+			},
+		},
+		{
+			name:    "diff equal numbers of differing types",
+			mapping: `root = {"a":1}.diff({"a":1.0})`,
+			output:  []interface{}{},
+		},
+		{
+			name:               "diff bad format",
+			mapping:            `root = this.diff({}, "nope")`,
+			parseErrorContains: "unrecognised diff format: nope",
+		},
+		{
+			name:    "diff and patch round trip",
+			mapping: `root = this.a.patch(this.a.diff(this.b)) == this.b`,
+			input: map[string]interface{}{
+				"a": map[string]interface{}{"x": []interface{}{1.0, 2.0, 3.0}, "y": map[string]interface{}{"z": "foo"}},
+				"b": map[string]interface{}{"x": []interface{}{2.0}, "y": "bar", "w": true},
+			},
+			output: true,
+		},
+		{
+			name:    "merge diff and patch round trip",
+			mapping: `root = this.a.patch(this.a.diff(this.b, "merge_patch")) == this.b`,
+			input: map[string]interface{}{
+				"a": map[string]interface{}{"x": []interface{}{1.0, 2.0, 3.0}, "y": map[string]interface{}{"z": "foo", "q": 1.0}},
+				"b": map[string]interface{}{"x": []interface{}{2.0}, "y": map[string]interface{}{"z": "foo"}, "w": true},
+			},
+			output: true,
+		},
+		{
+			name:    "patch does not mutate target",
+			mapping: `root = [ this.patch([{"op":"remove","path":"/a/0"}]), this ]`,
+			input:   map[string]interface{}{"a": []interface{}{"x", "y"}},
+			output: []interface{}{
+				map[string]interface{}{"a": []interface{}{"y"}},
+				map[string]interface{}{"a": []interface{}{"x", "y"}},
+			},
+		},
+		{
+			name:    "patch insert and copy",
+			mapping: `root = this.patch([{"op":"add","path":"/a/0","value":"w"},{"op":"copy","from":"/a","path":"/b"},{"op":"test","path":"/b/2","value":"y"}])`,
+			input:   map[string]interface{}{"a": []interface{}{"x", "y"}},
+			output: map[string]interface{}{
+				"a": []interface{}{"w", "x", "y"},
+				"b": []interface{}{"w", "x", "y"},
+			},
+		},
+		{
+			name:              "patch failed test",
+			mapping:           `root = this.patch([{"op":"test","path":"/a/0","value":"y"}])`,
+			input:             map[string]interface{}{"a": []interface{}{"x", "y"}},
+			execErrorContains: `operation 0 (test): value at "/a/0" does not match`,
+		},
+		{
+			name:              "patch replace missing field",
+			mapping:           `root = this.patch([{"op":"replace","path":"/b","value":"y"}])`,
+			input:             map[string]interface{}{"a": "x"},
+			execErrorContains: `operation 0 (replace): field "b" not found`,
+		},
+		{
+			name:              "patch index out of bounds",
+			mapping:           `root = this.patch([{"op":"add","path":"/a/3","value":"y"}])`,
+			input:             map[string]interface{}{"a": []interface{}{"x", "y"}},
+			execErrorContains: "array index 3 out of bounds",
+		},
+		{
+			name:               "patch bad type",
+			mapping:            `root = this.patch("nope")`,
+			parseErrorContains: "expected patch to be an array or object, got string",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			m, err := bloblang.Parse(test.mapping)
+			if test.parseErrorContains != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.parseErrorContains)
+			} else {
+				require.NoError(t, err)
+				v, err := m.Query(test.input)
+				if test.execErrorContains != "" {
+					require.Error(t, err)
+					assert.Contains(t, err.Error(), test.execErrorContains)
+				} else {
+					require.NoError(t, err)
+					assert.Equal(t, test.output, v)
+				}
+			}
+		})
+	}
+}
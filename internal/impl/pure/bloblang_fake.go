@@ -0,0 +1,258 @@
+package pure
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
+	"github.com/benthosdev/benthos/v4/public/bloblang"
+)
+
+func init() {
+	kinds := make([]string, 0, len(fakeGenerators))
+	for k := range fakeGenerators {
+		kinds = append(kinds, "`"+k+"`")
+	}
+	sort.Strings(kinds)
+
+	// Note: The examples are run and tested from within
+	// ./internal/bloblang/query/parsed_test.go
+
+	fakeSpec := bloblang.NewPluginSpec().
+		Beta().
+		Category(query.FunctionCategoryGeneral).
+		Description(`Generates a random value of a given kind, which is useful for producing realistic synthetic data for load testing and demos, usually within the `+"[`generate`](/docs/components/inputs/generate)"+` input. The values produced are plausible looking but entirely fabricated.
+
+Supported kinds are `+strings.Join(kinds, ", ")+`.
+
+When a `+"`seed`"+` is provided the sequence of values produced by the function is deterministic, which is useful for reproducible test data. Each occurrence of the function within a mapping maintains its own sequence.`).
+		Param(bloblang.NewStringParam("kind").Description("The kind of value to generate.")).
+		Param(bloblang.NewInt64Param("seed").Description("An optional seed for producing a deterministic sequence of values.").Optional()).
+		Version("4.3.0").
+		Example("", `root.user = {
+  "name": fake("name"),
+  "email": fake("email"),
+  "ip": fake("ipv4"),
+}`).
+		Example("A seed can be provided in order to produce the same sequence of values each time the mapping is executed from scratch.", `root.name = fake("name", 42)`,
+			[2]string{
+				`{}`,
+				`{"name":"Sofia Costa"}`,
+			})
+
+	fakeCtor := func(args *bloblang.ParsedParams) (bloblang.Function, error) {
+		kind, err := args.GetString("kind")
+		if err != nil {
+			return nil, err
+		}
+		gen, exists := fakeGenerators[kind]
+		if !exists {
+			return nil, fmt.Errorf("unrecognised fake kind: %v", kind)
+		}
+
+		seed, err := args.GetOptionalInt64("seed")
+		if err != nil {
+			return nil, err
+		}
+		if seed == nil {
+			s := time.Now().UnixNano()
+			seed = &s
+		}
+
+		var mut sync.Mutex
+		rng := rand.New(rand.NewSource(*seed))
+		return func() (interface{}, error) {
+			mut.Lock()
+			defer mut.Unlock()
+			return gen(rng), nil
+		}, nil
+	}
+
+	if err := bloblang.RegisterFunctionV2("fake", fakeSpec, fakeCtor); err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+var (
+	fakeFirstNames = []string{
+		"Ada", "Alice", "Amir", "Ana", "Arjun", "Ben", "Bruno", "Chen", "Chloe",
+		"Daniel", "Diego", "Elena", "Emma", "Fatima", "Finn", "Grace", "Hana",
+		"Hugo", "Isla", "Ivan", "Jack", "James", "Julia", "Kai", "Leila", "Liam",
+		"Lucas", "Maya", "Mei", "Mohammed", "Nina", "Noah", "Olivia", "Omar",
+		"Priya", "Rosa", "Sam", "Sofia", "Tom", "Yuki", "Zara",
+	}
+	fakeLastNames = []string{
+		"Anderson", "Brown", "Chen", "Costa", "Davies", "Dubois", "Evans",
+		"Fischer", "Garcia", "Gonzalez", "Hansen", "Ito", "Jones", "Kim",
+		"Kowalski", "Lee", "Lopez", "Martin", "Meyer", "Miller", "Nguyen",
+		"Novak", "Okafor", "Patel", "Rivera", "Rossi", "Santos", "Schmidt",
+		"Silva", "Singh", "Smith", "Tanaka", "Taylor", "Walker", "Wang",
+		"Williams", "Wilson", "Yilmaz",
+	}
+	fakeWords = []string{
+		"alpha", "amber", "anchor", "apple", "arrow", "autumn", "breeze",
+		"bridge", "canyon", "cedar", "cloud", "comet", "coral", "delta",
+		"ember", "falcon", "forest", "galaxy", "garden", "glacier", "harbor",
+		"island", "jade", "lantern", "maple", "meadow", "meteor", "nebula",
+		"ocean", "orbit", "pebble", "pine", "prism", "quartz", "river",
+		"shadow", "signal", "silver", "spark", "stone", "summit", "thunder",
+		"timber", "valley", "willow", "zephyr",
+	}
+	fakeDomainSuffixes = []string{"com", "net", "org", "io", "dev", "co.uk", "de"}
+	fakeEmailDomains   = []string{"example.com", "example.net", "example.org"}
+	fakeCompanySuffix  = []string{"Inc", "LLC", "Ltd", "Group", "Labs", "Systems", "Industries"}
+	fakeStreetSuffix   = []string{"Street", "Avenue", "Road", "Lane", "Drive", "Way", "Court", "Place"}
+	fakeCities         = []string{
+		"Amsterdam", "Austin", "Berlin", "Bogota", "Cairo", "Cape Town",
+		"Chicago", "Dublin", "Helsinki", "Istanbul", "Lagos", "Lisbon",
+		"London", "Madrid", "Melbourne", "Mumbai", "Nairobi", "Osaka", "Paris",
+		"Seoul", "Singapore", "Stockholm", "Toronto", "Vancouver", "Warsaw",
+	}
+	fakeCountries = [][2]string{
+		{"Argentina", "AR"}, {"Australia", "AU"}, {"Brazil", "BR"},
+		{"Canada", "CA"}, {"China", "CN"}, {"Egypt", "EG"}, {"France", "FR"},
+		{"Germany", "DE"}, {"India", "IN"}, {"Ireland", "IE"}, {"Italy", "IT"},
+		{"Japan", "JP"}, {"Kenya", "KE"}, {"Mexico", "MX"},
+		{"Netherlands", "NL"}, {"Nigeria", "NG"}, {"Poland", "PL"},
+		{"Portugal", "PT"}, {"South Korea", "KR"}, {"Spain", "ES"},
+		{"Sweden", "SE"}, {"Turkey", "TR"}, {"United Kingdom", "GB"},
+		{"United States", "US"},
+	}
+	fakeUserAgents = []string{
+		"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/100.0.4896.127 Safari/537.36",
+		"Mozilla/5.0 (Macintosh; Intel Mac OS X 12_3_1) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/15.4 Safari/605.1.15",
+		"Mozilla/5.0 (X11; Linux x86_64; rv:99.0) Gecko/20100101 Firefox/99.0",
+		"Mozilla/5.0 (iPhone; CPU iPhone OS 15_4 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/15.4 Mobile/15E148 Safari/604.1",
+		"Mozilla/5.0 (Linux; Android 12; Pixel 6) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/100.0.4896.127 Mobile Safari/537.36",
+	}
+)
+
+func fakePick(r *rand.Rand, from []string) string {
+	return from[r.Intn(len(from))]
+}
+
+func fakeDigits(r *rand.Rand, n int) string {
+	var b strings.Builder
+	for i := 0; i < n; i++ {
+		b.WriteByte(byte('0' + r.Intn(10)))
+	}
+	return b.String()
+}
+
+func fakeSentence(r *rand.Rand) string {
+	words := make([]string, 4+r.Intn(8))
+	for i := range words {
+		words[i] = fakePick(r, fakeWords)
+	}
+	return strings.ToUpper(words[0][:1]) + strings.Join(words, " ")[1:] + "."
+}
+
+func fakeUsername(r *rand.Rand) string {
+	return strings.ToLower(fakePick(r, fakeFirstNames)) + "_" + fakePick(r, fakeWords) + strconv.Itoa(r.Intn(100))
+}
+
+func fakeDomain(r *rand.Rand) string {
+	return fakePick(r, fakeWords) + fakePick(r, fakeWords) + "." + fakePick(r, fakeDomainSuffixes)
+}
+
+var fakeGenerators = map[string]func(r *rand.Rand) interface{}{
+	"name": func(r *rand.Rand) interface{} {
+		return fakePick(r, fakeFirstNames) + " " + fakePick(r, fakeLastNames)
+	},
+	"first_name": func(r *rand.Rand) interface{} {
+		return fakePick(r, fakeFirstNames)
+	},
+	"last_name": func(r *rand.Rand) interface{} {
+		return fakePick(r, fakeLastNames)
+	},
+	"username": func(r *rand.Rand) interface{} {
+		return fakeUsername(r)
+	},
+	"email": func(r *rand.Rand) interface{} {
+		return strings.ToLower(fakePick(r, fakeFirstNames)+"."+fakePick(r, fakeLastNames)) + "@" + fakePick(r, fakeEmailDomains)
+	},
+	"phone_number": func(r *rand.Rand) interface{} {
+		return fmt.Sprintf("+1-%v-555-%v", 200+r.Intn(800), fakeDigits(r, 4))
+	},
+	"company": func(r *rand.Rand) interface{} {
+		w := fakePick(r, fakeWords)
+		return strings.ToUpper(w[:1]) + w[1:] + " " + fakePick(r, fakeCompanySuffix)
+	},
+	"street_address": func(r *rand.Rand) interface{} {
+		w := fakePick(r, fakeWords)
+		return fmt.Sprintf("%v %v%v %v", 1+r.Intn(9999), strings.ToUpper(w[:1]), w[1:], fakePick(r, fakeStreetSuffix))
+	},
+	"city": func(r *rand.Rand) interface{} {
+		return fakePick(r, fakeCities)
+	},
+	"country": func(r *rand.Rand) interface{} {
+		return fakeCountries[r.Intn(len(fakeCountries))][0]
+	},
+	"country_code": func(r *rand.Rand) interface{} {
+		return fakeCountries[r.Intn(len(fakeCountries))][1]
+	},
+	"zip_code": func(r *rand.Rand) interface{} {
+		return fakeDigits(r, 5)
+	},
+	"latitude": func(r *rand.Rand) interface{} {
+		return float64(int64((r.Float64()*180-90)*1e6)) / 1e6
+	},
+	"longitude": func(r *rand.Rand) interface{} {
+		return float64(int64((r.Float64()*360-180)*1e6)) / 1e6
+	},
+	"ipv4": func(r *rand.Rand) interface{} {
+		return fmt.Sprintf("%v.%v.%v.%v", 1+r.Intn(223), r.Intn(256), r.Intn(256), 1+r.Intn(254))
+	},
+	"ipv6": func(r *rand.Rand) interface{} {
+		groups := make([]string, 8)
+		for i := range groups {
+			groups[i] = strconv.FormatInt(int64(r.Intn(0x10000)), 16)
+		}
+		return strings.Join(groups, ":")
+	},
+	"mac_address": func(r *rand.Rand) interface{} {
+		octets := make([]string, 6)
+		for i := range octets {
+			octets[i] = fmt.Sprintf("%02x", r.Intn(256))
+		}
+		return strings.Join(octets, ":")
+	},
+	"domain": func(r *rand.Rand) interface{} {
+		return fakeDomain(r)
+	},
+	"url": func(r *rand.Rand) interface{} {
+		return "https://" + fakeDomain(r) + "/" + fakePick(r, fakeWords) + "/" + fakePick(r, fakeWords)
+	},
+	"user_agent": func(r *rand.Rand) interface{} {
+		return fakePick(r, fakeUserAgents)
+	},
+	"word": func(r *rand.Rand) interface{} {
+		return fakePick(r, fakeWords)
+	},
+	"sentence": func(r *rand.Rand) interface{} {
+		return fakeSentence(r)
+	},
+	"uuid": func(r *rand.Rand) interface{} {
+		var b [16]byte
+		_, _ = r.Read(b[:])
+		b[6] = (b[6] & 0x0f) | 0x40
+		b[8] = (b[8] & 0x3f) | 0x80
+		return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+	},
+	"hex_color": func(r *rand.Rand) interface{} {
+		return fmt.Sprintf("#%06x", r.Intn(0x1000000))
+	},
+	"timestamp": func(r *rand.Rand) interface{} {
+		// A random time within the last ten years of the fixed epoch below,
+		// which keeps seeded sequences stable regardless of the current time.
+		epoch := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+		return epoch.Add(-time.Duration(r.Int63n(int64(10 * 365 * 24 * time.Hour)))).Format(time.RFC3339)
+	},
+}
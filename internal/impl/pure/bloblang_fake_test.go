@@ -0,0 +1,49 @@
+package pure
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/bloblang"
+)
+
+func TestFakeFunctionKinds(t *testing.T) {
+	for kind := range fakeGenerators {
+		kind := kind
+		t.Run(kind, func(t *testing.T) {
+			m, err := bloblang.Parse(`root = fake("` + kind + `")`)
+			require.NoError(t, err)
+
+			v, err := m.Query(nil)
+			require.NoError(t, err)
+			assert.NotEmpty(t, v)
+		})
+	}
+}
+
+func TestFakeFunctionSeeded(t *testing.T) {
+	mapping := `root = [ fake("name", 10), fake("ipv4", 10), fake("uuid", 20) ]`
+
+	var results []interface{}
+	for i := 0; i < 2; i++ {
+		m, err := bloblang.Parse(mapping)
+		require.NoError(t, err)
+
+		for j := 0; j < 3; j++ {
+			v, err := m.Query(nil)
+			require.NoError(t, err)
+			results = append(results, v)
+		}
+	}
+
+	assert.Equal(t, results[:3], results[3:])
+	assert.NotEqual(t, results[0], results[1])
+}
+
+func TestFakeFunctionBadKind(t *testing.T) {
+	_, err := bloblang.Parse(`root = fake("nope")`)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unrecognised fake kind: nope")
+}
@@ -0,0 +1,383 @@
+package pure
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
+	"github.com/benthosdev/benthos/v4/public/bloblang"
+)
+
+const earthRadiusMetres = 6371008.8
+
+var geoDistanceUnits = map[string]float64{
+	"m":   1,
+	"km":  1000,
+	"mi":  1609.344,
+	"nmi": 1852,
+}
+
+func init() {
+	// Note: The examples are run and tested from within
+	// ./internal/bloblang/query/parsed_test.go
+
+	haversineSpec := bloblang.NewPluginSpec().
+		Beta().
+		Category(query.MethodCategoryGeo).
+		Description("Calculates the great-circle distance between two points using the haversine formula. Points can either be objects with the fields `lat` and `lon`, or GeoJSON `Point` geometries.").
+		Param(bloblang.NewAnyParam("other").Description("The point to measure the distance to.")).
+		Param(bloblang.NewStringParam("unit").Description("The unit of the result, one of `m`, `km`, `mi` or `nmi`.").Default("m")).
+		Version("4.3.0").
+		Example("",
+			`root.distance = this.from.haversine_distance(this.to, "km").round()`,
+			[2]string{
+				`{"from":{"lat":51.5007,"lon":-0.1246},"to":{"lat":40.6892,"lon":-74.0445}}`,
+				`{"distance":5575}`,
+			})
+
+	haversineCtor := func(args *bloblang.ParsedParams) (bloblang.Method, error) {
+		other, err := args.Get("other")
+		if err != nil {
+			return nil, err
+		}
+		unitStr, err := args.GetString("unit")
+		if err != nil {
+			return nil, err
+		}
+		unit, exists := geoDistanceUnits[unitStr]
+		if !exists {
+			return nil, fmt.Errorf("unrecognised distance unit: %v", unitStr)
+		}
+		toLat, toLon, err := geoPointFromValue(other)
+		if err != nil {
+			return nil, fmt.Errorf("other: %w", err)
+		}
+		return func(v interface{}) (interface{}, error) {
+			fromLat, fromLon, err := geoPointFromValue(v)
+			if err != nil {
+				return nil, err
+			}
+			return haversineDistance(fromLat, fromLon, toLat, toLon) / unit, nil
+		}, nil
+	}
+
+	if err := bloblang.RegisterMethodV2("haversine_distance", haversineSpec, haversineCtor); err != nil {
+		panic(err)
+	}
+
+	//--------------------------------------------------------------------------
+
+	geohashEncodeSpec := bloblang.NewPluginSpec().
+		Beta().
+		Category(query.MethodCategoryGeo).
+		Description("Encodes a point as a [geohash](https://en.wikipedia.org/wiki/Geohash) string. Points can either be objects with the fields `lat` and `lon`, or GeoJSON `Point` geometries.").
+		Param(bloblang.NewInt64Param("precision").Description("The number of characters of the resulting geohash, between 1 and 12.").Default(12)).
+		Version("4.3.0").
+		Example("",
+			`root.hash = this.location.geohash_encode(7)`,
+			[2]string{
+				`{"location":{"lat":57.64911,"lon":10.40744}}`,
+				`{"hash":"u4pruyd"}`,
+			})
+
+	geohashEncodeCtor := func(args *bloblang.ParsedParams) (bloblang.Method, error) {
+		precision, err := args.GetInt64("precision")
+		if err != nil {
+			return nil, err
+		}
+		if precision < 1 || precision > 12 {
+			return nil, fmt.Errorf("precision must be between 1 and 12, got %v", precision)
+		}
+		return func(v interface{}) (interface{}, error) {
+			lat, lon, err := geoPointFromValue(v)
+			if err != nil {
+				return nil, err
+			}
+			return geohashEncode(lat, lon, int(precision)), nil
+		}, nil
+	}
+
+	if err := bloblang.RegisterMethodV2("geohash_encode", geohashEncodeSpec, geohashEncodeCtor); err != nil {
+		panic(err)
+	}
+
+	geohashDecodeSpec := bloblang.NewPluginSpec().
+		Beta().
+		Category(query.MethodCategoryGeo).
+		Description("Decodes a [geohash](https://en.wikipedia.org/wiki/Geohash) string into an object containing the `lat` and `lon` of the center of the area it describes.").
+		Version("4.3.0").
+		Example("",
+			`root.location = this.hash.geohash_decode().map_each(p -> p.value.round())`,
+			[2]string{
+				`{"hash":"u4pruyd"}`,
+				`{"location":{"lat":58,"lon":10}}`,
+			})
+
+	geohashDecodeCtor := func(args *bloblang.ParsedParams) (bloblang.Method, error) {
+		return bloblang.StringMethod(func(s string) (interface{}, error) {
+			lat, lon, err := geohashDecode(s)
+			if err != nil {
+				return nil, err
+			}
+			return map[string]interface{}{
+				"lat": lat,
+				"lon": lon,
+			}, nil
+		}), nil
+	}
+
+	if err := bloblang.RegisterMethodV2("geohash_decode", geohashDecodeSpec, geohashDecodeCtor); err != nil {
+		panic(err)
+	}
+
+	//--------------------------------------------------------------------------
+
+	geoContainsSpec := bloblang.NewPluginSpec().
+		Beta().
+		Category(query.MethodCategoryGeo).
+		Description("Checks whether a point lies within a GeoJSON `Polygon` or `MultiPolygon` geometry, which can also be provided wrapped within a `Feature`. Holes within polygons are respected. Points can either be objects with the fields `lat` and `lon`, or GeoJSON `Point` geometries.").
+		Param(bloblang.NewAnyParam("point").Description("The point to check.")).
+		Version("4.3.0").
+		Example("",
+			`root.in_zone = this.zone.geo_contains(this.location)`,
+			[2]string{
+				`{"zone":{"type":"Polygon","coordinates":[[[0,0],[10,0],[10,10],[0,10],[0,0]]]},"location":{"lat":5,"lon":5}}`,
+				`{"in_zone":true}`,
+			},
+			[2]string{
+				`{"zone":{"type":"Polygon","coordinates":[[[0,0],[10,0],[10,10],[0,10],[0,0]]]},"location":{"lat":15,"lon":5}}`,
+				`{"in_zone":false}`,
+			})
+
+	geoContainsCtor := func(args *bloblang.ParsedParams) (bloblang.Method, error) {
+		point, err := args.Get("point")
+		if err != nil {
+			return nil, err
+		}
+		lat, lon, err := geoPointFromValue(point)
+		if err != nil {
+			return nil, fmt.Errorf("point: %w", err)
+		}
+		return bloblang.ObjectMethod(func(obj map[string]interface{}) (interface{}, error) {
+			polygons, err := geoJSONPolygons(obj)
+			if err != nil {
+				return nil, err
+			}
+			for _, poly := range polygons {
+				if poly.contains(lon, lat) {
+					return true, nil
+				}
+			}
+			return false, nil
+		}), nil
+	}
+
+	if err := bloblang.RegisterMethodV2("geo_contains", geoContainsSpec, geoContainsCtor); err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+func geoPointFromValue(v interface{}) (lat, lon float64, err error) {
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		return 0, 0, fmt.Errorf("expected point object, got %v", query.ITypeOf(v))
+	}
+	if t, _ := obj["type"].(string); t == "Point" {
+		coords, ok := obj["coordinates"].([]interface{})
+		if !ok || len(coords) < 2 {
+			return 0, 0, errors.New("expected GeoJSON point to contain an array of coordinates")
+		}
+		if lon, err = query.IGetNumber(coords[0]); err != nil {
+			return
+		}
+		lat, err = query.IGetNumber(coords[1])
+		return
+	}
+	if lat, err = query.IGetNumber(obj["lat"]); err != nil {
+		return 0, 0, fmt.Errorf("field lat: %w", err)
+	}
+	if lon, err = query.IGetNumber(obj["lon"]); err != nil {
+		return 0, 0, fmt.Errorf("field lon: %w", err)
+	}
+	return
+}
+
+func haversineDistance(fromLat, fromLon, toLat, toLon float64) float64 {
+	toRad := func(deg float64) float64 { return deg * math.Pi / 180 }
+
+	dLat := toRad(toLat - fromLat)
+	dLon := toRad(toLon - fromLon)
+
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRad(fromLat))*math.Cos(toRad(toLat))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusMetres * math.Asin(math.Sqrt(a))
+}
+
+//------------------------------------------------------------------------------
+
+const geohashAlphabet = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+func geohashEncode(lat, lon float64, precision int) string {
+	latRange := [2]float64{-90, 90}
+	lonRange := [2]float64{-180, 180}
+
+	var hash strings.Builder
+	var bit, ch int
+	even := true
+	for hash.Len() < precision {
+		if even {
+			if mid := (lonRange[0] + lonRange[1]) / 2; lon >= mid {
+				ch |= 1 << (4 - bit)
+				lonRange[0] = mid
+			} else {
+				lonRange[1] = mid
+			}
+		} else {
+			if mid := (latRange[0] + latRange[1]) / 2; lat >= mid {
+				ch |= 1 << (4 - bit)
+				latRange[0] = mid
+			} else {
+				latRange[1] = mid
+			}
+		}
+		even = !even
+		if bit < 4 {
+			bit++
+		} else {
+			hash.WriteByte(geohashAlphabet[ch])
+			bit, ch = 0, 0
+		}
+	}
+	return hash.String()
+}
+
+func geohashDecode(hash string) (lat, lon float64, err error) {
+	if hash == "" {
+		return 0, 0, errors.New("geohash is empty")
+	}
+
+	latRange := [2]float64{-90, 90}
+	lonRange := [2]float64{-180, 180}
+
+	even := true
+	for i := 0; i < len(hash); i++ {
+		ch := strings.IndexByte(geohashAlphabet, hash[i])
+		if ch < 0 {
+			return 0, 0, fmt.Errorf("invalid geohash character %q at index %v", hash[i], i)
+		}
+		for bit := 4; bit >= 0; bit-- {
+			r := &latRange
+			if even {
+				r = &lonRange
+			}
+			mid := (r[0] + r[1]) / 2
+			if ch&(1<<bit) != 0 {
+				r[0] = mid
+			} else {
+				r[1] = mid
+			}
+			even = !even
+		}
+	}
+	return (latRange[0] + latRange[1]) / 2, (lonRange[0] + lonRange[1]) / 2, nil
+}
+
+//------------------------------------------------------------------------------
+
+// geoPolygon is a polygon where the first ring is the exterior and all
+// subsequent rings are holes, coordinates are [lon, lat] pairs.
+type geoPolygon [][][2]float64
+
+func (p geoPolygon) contains(x, y float64) bool {
+	if len(p) == 0 || !ringContains(p[0], x, y) {
+		return false
+	}
+	for _, hole := range p[1:] {
+		if ringContains(hole, x, y) {
+			return false
+		}
+	}
+	return true
+}
+
+// ringContains performs a ray casting test of a point against a ring.
+func ringContains(ring [][2]float64, x, y float64) bool {
+	inside := false
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		xi, yi := ring[i][0], ring[i][1]
+		xj, yj := ring[j][0], ring[j][1]
+		if (yi > y) != (yj > y) && x < (xj-xi)*(y-yi)/(yj-yi)+xi {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+func geoJSONPolygons(obj map[string]interface{}) ([]geoPolygon, error) {
+	gType, _ := obj["type"].(string)
+	switch gType {
+	case "Feature":
+		geometry, ok := obj["geometry"].(map[string]interface{})
+		if !ok {
+			return nil, errors.New("expected GeoJSON feature to contain a geometry object")
+		}
+		return geoJSONPolygons(geometry)
+	case "Polygon":
+		poly, err := geoJSONPolygon(obj["coordinates"])
+		if err != nil {
+			return nil, err
+		}
+		return []geoPolygon{poly}, nil
+	case "MultiPolygon":
+		coords, ok := obj["coordinates"].([]interface{})
+		if !ok {
+			return nil, errors.New("expected GeoJSON multi polygon to contain an array of coordinates")
+		}
+		polys := make([]geoPolygon, 0, len(coords))
+		for i, c := range coords {
+			poly, err := geoJSONPolygon(c)
+			if err != nil {
+				return nil, fmt.Errorf("polygon %v: %w", i, err)
+			}
+			polys = append(polys, poly)
+		}
+		return polys, nil
+	}
+	return nil, fmt.Errorf("expected GeoJSON Polygon, MultiPolygon or Feature, got type: %q", gType)
+}
+
+func geoJSONPolygon(v interface{}) (geoPolygon, error) {
+	rings, ok := v.([]interface{})
+	if !ok {
+		return nil, errors.New("expected GeoJSON polygon coordinates to be an array of rings")
+	}
+	poly := make(geoPolygon, 0, len(rings))
+	for i, r := range rings {
+		positions, ok := r.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("ring %v: expected array of positions", i)
+		}
+		ring := make([][2]float64, 0, len(positions))
+		for j, p := range positions {
+			pos, ok := p.([]interface{})
+			if !ok || len(pos) < 2 {
+				return nil, fmt.Errorf("ring %v position %v: expected array of coordinates", i, j)
+			}
+			x, err := query.IGetNumber(pos[0])
+			if err != nil {
+				return nil, fmt.Errorf("ring %v position %v: %w", i, j, err)
+			}
+			y, err := query.IGetNumber(pos[1])
+			if err != nil {
+				return nil, fmt.Errorf("ring %v position %v: %w", i, j, err)
+			}
+			ring = append(ring, [2]float64{x, y})
+		}
+		poly = append(poly, ring)
+	}
+	return poly, nil
+}
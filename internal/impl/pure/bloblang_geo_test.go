@@ -0,0 +1,125 @@
+package pure
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/bloblang"
+)
+
+func TestGeoMethods(t *testing.T) {
+	squareWithHole := map[string]interface{}{
+		"type": "Polygon",
+		"coordinates": []interface{}{
+			[]interface{}{
+				[]interface{}{0.0, 0.0}, []interface{}{10.0, 0.0}, []interface{}{10.0, 10.0}, []interface{}{0.0, 10.0}, []interface{}{0.0, 0.0},
+			},
+			[]interface{}{
+				[]interface{}{4.0, 4.0}, []interface{}{6.0, 4.0}, []interface{}{6.0, 6.0}, []interface{}{4.0, 6.0}, []interface{}{4.0, 4.0},
+			},
+		},
+	}
+
+	tests := []struct {
+		name               string
+		mapping            string
+		input              interface{}
+		output             interface{}
+		parseErrorContains string
+		execErrorContains  string
+	}{
+		{
+			name:    "haversine same point",
+			mapping: `root = {"lat":10,"lon":10}.haversine_distance({"lat":10,"lon":10})`,
+			output:  float64(0),
+		},
+		{
+			name:    "haversine geojson points",
+			mapping: `root = {"type":"Point","coordinates":[0,0]}.haversine_distance({"type":"Point","coordinates":[0,1]}, "km").round()`,
+			output:  int64(111),
+		},
+		{
+			name:               "haversine bad unit",
+			mapping:            `root = this.haversine_distance({"lat":10,"lon":10}, "furlongs")`,
+			parseErrorContains: "unrecognised distance unit: furlongs",
+		},
+		{
+			name:              "haversine missing field",
+			mapping:           `root = this.haversine_distance({"lat":10,"lon":10})`,
+			input:             map[string]interface{}{"lat": 10.0},
+			execErrorContains: "field lon",
+		},
+		{
+			name:    "geohash encode default precision",
+			mapping: `root = {"lat":57.64911,"lon":10.40744}.geohash_encode()`,
+			output:  "u4pruydqqvj8",
+		},
+		{
+			name:    "geohash round trip",
+			mapping: `root = {"lat":-33.8688,"lon":151.2093}.geohash_encode().geohash_decode().map_each(p -> (p.value * 1000).round())`,
+			output: map[string]interface{}{
+				"lat": int64(-33869),
+				"lon": int64(151209),
+			},
+		},
+		{
+			name:              "geohash decode invalid",
+			mapping:           `root = this.geohash_decode()`,
+			input:             "u4pa",
+			execErrorContains: "invalid geohash character 'a' at index 3",
+		},
+		{
+			name:    "geo_contains inside",
+			mapping: `root = this.geo_contains({"lat":2,"lon":2})`,
+			input:   squareWithHole,
+			output:  true,
+		},
+		{
+			name:    "geo_contains within hole",
+			mapping: `root = this.geo_contains({"lat":5,"lon":5})`,
+			input:   squareWithHole,
+			output:  false,
+		},
+		{
+			name:    "geo_contains feature",
+			mapping: `root = {"type":"Feature","geometry":this}.geo_contains({"type":"Point","coordinates":[8,8]})`,
+			input:   squareWithHole,
+			output:  true,
+		},
+		{
+			name:    "geo_contains multi polygon",
+			mapping: `root = {"type":"MultiPolygon","coordinates":[[[[20,20],[30,20],[30,30],[20,30],[20,20]]],this.coordinates]}.geo_contains({"lat":25,"lon":25})`,
+			input:   squareWithHole,
+			output:  true,
+		},
+		{
+			name:              "geo_contains bad geometry",
+			mapping:           `root = this.geo_contains({"lat":5,"lon":5})`,
+			input:             map[string]interface{}{"type": "LineString"},
+			execErrorContains: `got type: "LineString"`,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			m, err := bloblang.Parse(test.mapping)
+			if test.parseErrorContains != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.parseErrorContains)
+			} else {
+				require.NoError(t, err)
+				v, err := m.Query(test.input)
+				if test.execErrorContains != "" {
+					require.Error(t, err)
+					assert.Contains(t, err.Error(), test.execErrorContains)
+				} else {
+					require.NoError(t, err)
+					assert.Equal(t, test.output, v)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package pure
+
+import (
+	"strings"
+
+	"github.com/nyaruka/phonenumbers"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
+	"github.com/benthosdev/benthos/v4/public/bloblang"
+)
+
+var phoneNumberTypes = map[phonenumbers.PhoneNumberType]string{
+	phonenumbers.FIXED_LINE:           "fixed_line",
+	phonenumbers.MOBILE:               "mobile",
+	phonenumbers.FIXED_LINE_OR_MOBILE: "fixed_line_or_mobile",
+	phonenumbers.TOLL_FREE:            "toll_free",
+	phonenumbers.PREMIUM_RATE:         "premium_rate",
+	phonenumbers.SHARED_COST:          "shared_cost",
+	phonenumbers.VOIP:                 "voip",
+	phonenumbers.PERSONAL_NUMBER:      "personal_number",
+	phonenumbers.PAGER:                "pager",
+	phonenumbers.UAN:                  "uan",
+	phonenumbers.VOICEMAIL:            "voicemail",
+}
+
+func init() {
+	// Note: The examples are run and tested from within
+	// ./internal/bloblang/query/parsed_test.go
+
+	parsePhoneSpec := bloblang.NewPluginSpec().
+		Beta().
+		Category(query.MethodCategoryParsing).
+		Description(`Attempts to parse a string as a phone number using the metadata of [libphonenumber](https://github.com/google/libphonenumber), returning an object describing the number. Numbers written in national format require a `+"`region`"+` in order to be interpreted, whereas numbers in international format (beginning with a `+"`+`"+`) can be parsed without one.
+
+The resulting object contains the fields `+"`e164`, `international`, `national`, `country_code`, `region`, `type`, `valid` and `possible`"+`. The `+"`type`"+` field is one of `+"`fixed_line`, `mobile`, `fixed_line_or_mobile`, `toll_free`, `premium_rate`, `shared_cost`, `voip`, `personal_number`, `pager`, `uan`, `voicemail` or `unknown`"+`.
+
+An error is returned when the string cannot be interpreted as a phone number at all, but a number that parses and yet is not valid for its region is returned with the field `+"`valid`"+` set to `+"`false`"+`.`).
+		Param(bloblang.NewStringParam("region").Description("An optional two letter ISO 3166-1 region code used to interpret numbers written in national format.").Default("")).
+		Version("4.3.0").
+		Example("",
+			`root.phone = this.phone.parse_phone_number("US")`,
+			[2]string{
+				`{"phone":"(650) 253-0000"}`,
+				`{"phone":{"country_code":1,"e164":"+16502530000","international":"+1 650-253-0000","national":"(650) 253-0000","possible":true,"region":"US","type":"fixed_line_or_mobile","valid":true}}`,
+			}).
+		Example("Numbers in international format are normalised regardless of the region provided.",
+			`root.phone = this.phone.parse_phone_number().e164`,
+			[2]string{
+				`{"phone":"+44 7400 123456"}`,
+				`{"phone":"+447400123456"}`,
+			})
+
+	parsePhoneCtor := func(args *bloblang.ParsedParams) (bloblang.Method, error) {
+		region, err := args.GetString("region")
+		if err != nil {
+			return nil, err
+		}
+		region = strings.ToUpper(region)
+		return bloblang.StringMethod(func(s string) (interface{}, error) {
+			num, err := phonenumbers.Parse(s, region)
+			if err != nil {
+				return nil, err
+			}
+			numType, exists := phoneNumberTypes[phonenumbers.GetNumberType(num)]
+			if !exists {
+				numType = "unknown"
+			}
+			return map[string]interface{}{
+				"e164":          phonenumbers.Format(num, phonenumbers.E164),
+				"international": phonenumbers.Format(num, phonenumbers.INTERNATIONAL),
+				"national":      phonenumbers.Format(num, phonenumbers.NATIONAL),
+				"country_code":  int64(num.GetCountryCode()),
+				"region":        phonenumbers.GetRegionCodeForNumber(num),
+				"type":          numType,
+				"valid":         phonenumbers.IsValidNumber(num),
+				"possible":      phonenumbers.IsPossibleNumber(num),
+			}, nil
+		}), nil
+	}
+
+	if err := bloblang.RegisterMethodV2("parse_phone_number", parsePhoneSpec, parsePhoneCtor); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,89 @@
+package pure
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/bloblang"
+)
+
+func TestParsePhoneNumber(t *testing.T) {
+	tests := []struct {
+		name              string
+		mapping           string
+		input             interface{}
+		output            interface{}
+		execErrorContains string
+	}{
+		{
+			name:    "national format with region",
+			mapping: `root = this.parse_phone_number("GB")`,
+			input:   "020 7946 0958",
+			output: map[string]interface{}{
+				"e164":          "+442079460958",
+				"international": "+44 20 7946 0958",
+				"national":      "020 7946 0958",
+				"country_code":  int64(44),
+				"region":        "GB",
+				"type":          "fixed_line",
+				"valid":         true,
+				"possible":      true,
+			},
+		},
+		{
+			name:    "lowercase region",
+			mapping: `root = this.parse_phone_number("gb").e164`,
+			input:   "07400 123456",
+			output:  "+447400123456",
+		},
+		{
+			name:    "mobile type",
+			mapping: `root = this.parse_phone_number().type`,
+			input:   "+44 7400 123456",
+			output:  "mobile",
+		},
+		{
+			name:    "invalid number",
+			mapping: `root = this.parse_phone_number("US").without("e164", "international", "national")`,
+			input:   "123",
+			output: map[string]interface{}{
+				"country_code": int64(1),
+				"region":       "",
+				"type":         "unknown",
+				"valid":        false,
+				"possible":     false,
+			},
+		},
+		{
+			name:              "national format without region",
+			mapping:           `root = this.parse_phone_number()`,
+			input:             "650 253 0000",
+			execErrorContains: "invalid country code",
+		},
+		{
+			name:              "not a number",
+			mapping:           `root = this.parse_phone_number("US")`,
+			input:             "nope",
+			execErrorContains: "not a number",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			m, err := bloblang.Parse(test.mapping)
+			require.NoError(t, err)
+
+			v, err := m.Query(test.input)
+			if test.execErrorContains != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.execErrorContains)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, test.output, v)
+			}
+		})
+	}
+}
@@ -0,0 +1,425 @@
+package pure
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
+	"github.com/benthosdev/benthos/v4/public/bloblang"
+)
+
+func init() {
+	// Note: The examples are run and tested from within
+	// ./internal/bloblang/query/parsed_test.go
+
+	parseSemverSpec := bloblang.NewPluginSpec().
+		Beta().
+		Category(query.MethodCategoryParsing).
+		Description("Attempts to parse a string as a [semantic version](https://semver.org/) and returns an object containing the fields `major`, `minor`, `patch`, `prerelease` and `build`. A leading `v` is permitted.").
+		Version("4.3.0").
+		Example("",
+			`root.version = this.version.parse_semver()`,
+			[2]string{
+				`{"version":"v1.4.2-rc.1+build.5"}`,
+				`{"version":{"build":"build.5","major":1,"minor":4,"patch":2,"prerelease":"rc.1"}}`,
+			})
+
+	parseSemverCtor := func(args *bloblang.ParsedParams) (bloblang.Method, error) {
+		return bloblang.StringMethod(func(s string) (interface{}, error) {
+			v, err := parseSemver(s)
+			if err != nil {
+				return nil, err
+			}
+			return map[string]interface{}{
+				"major":      v.major,
+				"minor":      v.minor,
+				"patch":      v.patch,
+				"prerelease": strings.Join(v.prerelease, "."),
+				"build":      v.build,
+			}, nil
+		}), nil
+	}
+
+	if err := bloblang.RegisterMethodV2("parse_semver", parseSemverSpec, parseSemverCtor); err != nil {
+		panic(err)
+	}
+
+	semverCompareSpec := bloblang.NewPluginSpec().
+		Beta().
+		Category(query.MethodCategoryStrings).
+		Description("Compares a semantic version string against another following the precedence rules of the [semantic versioning specification](https://semver.org/#spec-item-11), returning `-1` when the target is lower, `0` when they are equal and `1` when the target is greater. Build metadata is ignored.").
+		Param(bloblang.NewStringParam("other").Description("The version to compare against.")).
+		Version("4.3.0").
+		Example("",
+			`root.comparison = this.version.semver_compare("1.10.0")`,
+			[2]string{
+				`{"version":"1.9.3"}`,
+				`{"comparison":-1}`,
+			},
+			[2]string{
+				`{"version":"1.10.0-beta"}`,
+				`{"comparison":-1}`,
+			},
+			[2]string{
+				`{"version":"v1.10.0"}`,
+				`{"comparison":0}`,
+			})
+
+	semverCompareCtor := func(args *bloblang.ParsedParams) (bloblang.Method, error) {
+		otherStr, err := args.GetString("other")
+		if err != nil {
+			return nil, err
+		}
+		other, err := parseSemver(otherStr)
+		if err != nil {
+			return nil, fmt.Errorf("other: %w", err)
+		}
+		return bloblang.StringMethod(func(s string) (interface{}, error) {
+			v, err := parseSemver(s)
+			if err != nil {
+				return nil, err
+			}
+			return int64(v.compare(other)), nil
+		}), nil
+	}
+
+	if err := bloblang.RegisterMethodV2("semver_compare", semverCompareSpec, semverCompareCtor); err != nil {
+		panic(err)
+	}
+
+	semverSatisfiesSpec := bloblang.NewPluginSpec().
+		Beta().
+		Category(query.MethodCategoryStrings).
+		Description(`Checks whether a semantic version string satisfies a constraint. Constraints consist of one or more comparisons separated by spaces, all of which must pass, and alternative sets of comparisons can be separated with `+"`||`"+`.
+
+Supported comparison operators are `+"`=`, `!=`, `>`, `>=`, `<` and `<=`"+`. The operator `+"`^`"+` allows changes that do not modify the left-most non-zero version component, and `+"`~`"+` allows patch level changes if a minor version is specified or minor level changes if not. Partial versions such as `+"`1.2`"+` and wildcards such as `+"`1.x`"+` or `+"`*`"+` match any value in the missing components.`).
+		Param(bloblang.NewStringParam("constraint").Description("The constraint to check against.")).
+		Version("4.3.0").
+		Example("",
+			`root.supported = this.version.semver_satisfies("^1.2.0")`,
+			[2]string{
+				`{"version":"1.8.3"}`,
+				`{"supported":true}`,
+			},
+			[2]string{
+				`{"version":"2.0.0"}`,
+				`{"supported":false}`,
+			}).
+		Example("",
+			`root.supported = this.version.semver_satisfies(">=1.2.0 <1.5.0 || 2.x")`,
+			[2]string{
+				`{"version":"1.4.9"}`,
+				`{"supported":true}`,
+			},
+			[2]string{
+				`{"version":"2.3.0"}`,
+				`{"supported":true}`,
+			},
+			[2]string{
+				`{"version":"1.5.0"}`,
+				`{"supported":false}`,
+			})
+
+	semverSatisfiesCtor := func(args *bloblang.ParsedParams) (bloblang.Method, error) {
+		constraintStr, err := args.GetString("constraint")
+		if err != nil {
+			return nil, err
+		}
+		constraint, err := parseSemverConstraint(constraintStr)
+		if err != nil {
+			return nil, err
+		}
+		return bloblang.StringMethod(func(s string) (interface{}, error) {
+			v, err := parseSemver(s)
+			if err != nil {
+				return nil, err
+			}
+			return constraint.check(v), nil
+		}), nil
+	}
+
+	if err := bloblang.RegisterMethodV2("semver_satisfies", semverSatisfiesSpec, semverSatisfiesCtor); err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type semver struct {
+	major, minor, patch int64
+	prerelease          []string
+	build               string
+}
+
+func parseSemver(s string) (semver, error) {
+	var v semver
+
+	str := strings.TrimPrefix(strings.TrimSpace(s), "v")
+	if i := strings.IndexByte(str, '+'); i >= 0 {
+		v.build = str[i+1:]
+		if v.build == "" {
+			return v, fmt.Errorf("invalid semantic version %q: empty build metadata", s)
+		}
+		str = str[:i]
+	}
+	if i := strings.IndexByte(str, '-'); i >= 0 {
+		v.prerelease = strings.Split(str[i+1:], ".")
+		for _, id := range v.prerelease {
+			if id == "" {
+				return v, fmt.Errorf("invalid semantic version %q: empty prerelease identifier", s)
+			}
+		}
+		str = str[:i]
+	}
+
+	parts := strings.Split(str, ".")
+	if len(parts) != 3 {
+		return v, fmt.Errorf("invalid semantic version %q: expected three version components", s)
+	}
+	for i, target := range []*int64{&v.major, &v.minor, &v.patch} {
+		n, err := strconv.ParseInt(parts[i], 10, 64)
+		if err != nil || n < 0 {
+			return v, fmt.Errorf("invalid semantic version %q: component %q is not a valid number", s, parts[i])
+		}
+		*target = n
+	}
+	return v, nil
+}
+
+func compareInt64(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func (v semver) compare(o semver) int {
+	if c := compareInt64(v.major, o.major); c != 0 {
+		return c
+	}
+	if c := compareInt64(v.minor, o.minor); c != 0 {
+		return c
+	}
+	if c := compareInt64(v.patch, o.patch); c != 0 {
+		return c
+	}
+
+	// A version without a prerelease has a higher precedence than one with.
+	switch {
+	case len(v.prerelease) == 0 && len(o.prerelease) == 0:
+		return 0
+	case len(v.prerelease) == 0:
+		return 1
+	case len(o.prerelease) == 0:
+		return -1
+	}
+
+	for i := 0; i < len(v.prerelease) && i < len(o.prerelease); i++ {
+		a, b := v.prerelease[i], o.prerelease[i]
+		aNum, aErr := strconv.ParseInt(a, 10, 64)
+		bNum, bErr := strconv.ParseInt(b, 10, 64)
+		switch {
+		case aErr == nil && bErr == nil:
+			if c := compareInt64(aNum, bNum); c != 0 {
+				return c
+			}
+		case aErr == nil:
+			return -1
+		case bErr == nil:
+			return 1
+		default:
+			if c := strings.Compare(a, b); c != 0 {
+				return c
+			}
+		}
+	}
+	return compareInt64(int64(len(v.prerelease)), int64(len(o.prerelease)))
+}
+
+//------------------------------------------------------------------------------
+
+type semverComparator struct {
+	op string
+	v  semver
+}
+
+func (c semverComparator) check(v semver) bool {
+	cmp := v.compare(c.v)
+	switch c.op {
+	case "=":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	}
+	return false
+}
+
+// semverConstraint is a set of alternatives, each of which is a set of
+// comparators that must all pass.
+type semverConstraint [][]semverComparator
+
+func (c semverConstraint) check(v semver) bool {
+	for _, all := range c {
+		passed := true
+		for _, comp := range all {
+			if !comp.check(v) {
+				passed = false
+				break
+			}
+		}
+		if passed {
+			return true
+		}
+	}
+	return false
+}
+
+func parseSemverConstraint(s string) (semverConstraint, error) {
+	var constraint semverConstraint
+	for _, alt := range strings.Split(s, "||") {
+		terms := strings.Fields(alt)
+		if len(terms) == 0 {
+			return nil, fmt.Errorf("invalid constraint %q: empty comparison set", s)
+		}
+		comps := []semverComparator{}
+		for _, term := range terms {
+			termComps, err := parseSemverTerm(term)
+			if err != nil {
+				return nil, fmt.Errorf("invalid constraint %q: %w", s, err)
+			}
+			comps = append(comps, termComps...)
+		}
+		constraint = append(constraint, comps)
+	}
+	return constraint, nil
+}
+
+// parsePartialSemver parses a version where trailing components may be
+// missing or wildcards, returning the number of components specified.
+func parsePartialSemver(s string) (semver, int, error) {
+	var v semver
+
+	str := strings.TrimPrefix(s, "v")
+	if i := strings.IndexByte(str, '+'); i >= 0 {
+		str = str[:i]
+	}
+	if i := strings.IndexByte(str, '-'); i >= 0 {
+		v.prerelease = strings.Split(str[i+1:], ".")
+		str = str[:i]
+	}
+
+	parts := strings.Split(str, ".")
+	if len(parts) > 3 {
+		return v, 0, fmt.Errorf("version %q has too many components", s)
+	}
+	specified := 0
+	for i, target := range []*int64{&v.major, &v.minor, &v.patch} {
+		if i >= len(parts) {
+			break
+		}
+		p := parts[i]
+		if p == "*" || p == "x" || p == "X" {
+			break
+		}
+		n, err := strconv.ParseInt(p, 10, 64)
+		if err != nil || n < 0 {
+			return v, 0, fmt.Errorf("version component %q is not a valid number", p)
+		}
+		*target = n
+		specified++
+	}
+	if specified < 3 && len(v.prerelease) > 0 {
+		return v, 0, fmt.Errorf("version %q has a prerelease but is not complete", s)
+	}
+	return v, specified, nil
+}
+
+func parseSemverTerm(term string) ([]semverComparator, error) {
+	op := ""
+	for _, candidate := range []string{">=", "<=", "!=", ">", "<", "=", "^", "~"} {
+		if strings.HasPrefix(term, candidate) {
+			op = candidate
+			break
+		}
+	}
+
+	v, specified, err := parsePartialSemver(term[len(op):])
+	if err != nil {
+		return nil, err
+	}
+
+	// The upper bound (exclusive) of a partial version, e.g. 1.2 -> 1.3.0
+	nextPartial := func() semver {
+		switch specified {
+		case 1:
+			return semver{major: v.major + 1}
+		case 2:
+			return semver{major: v.major, minor: v.minor + 1}
+		}
+		return semver{}
+	}
+
+	if specified == 0 {
+		switch op {
+		case "", "=", ">=", "<=", "^", "~":
+			return nil, nil
+		}
+		return []semverComparator{{op: "<", v: semver{}}}, nil
+	}
+
+	switch op {
+	case "", "=":
+		if specified == 3 {
+			return []semverComparator{{op: "=", v: v}}, nil
+		}
+		return []semverComparator{{op: ">=", v: v}, {op: "<", v: nextPartial()}}, nil
+	case "!=":
+		if specified != 3 {
+			return nil, errors.New("the != operator requires a complete version")
+		}
+		return []semverComparator{{op: "!=", v: v}}, nil
+	case ">":
+		if specified == 3 {
+			return []semverComparator{{op: ">", v: v}}, nil
+		}
+		return []semverComparator{{op: ">=", v: nextPartial()}}, nil
+	case "<=":
+		if specified == 3 {
+			return []semverComparator{{op: "<=", v: v}}, nil
+		}
+		return []semverComparator{{op: "<", v: nextPartial()}}, nil
+	case ">=", "<":
+		return []semverComparator{{op: op, v: v}}, nil
+	case "~":
+		upper := semver{major: v.major, minor: v.minor + 1}
+		if specified == 1 {
+			upper = semver{major: v.major + 1}
+		}
+		return []semverComparator{{op: ">=", v: v}, {op: "<", v: upper}}, nil
+	case "^":
+		var upper semver
+		switch {
+		case v.major > 0 || specified == 1:
+			upper = semver{major: v.major + 1}
+		case v.minor > 0 || specified == 2:
+			upper = semver{minor: v.minor + 1}
+		default:
+			upper = semver{patch: v.patch + 1}
+		}
+		return []semverComparator{{op: ">=", v: v}, {op: "<", v: upper}}, nil
+	}
+	return nil, fmt.Errorf("unrecognised operator in %q", term)
+}
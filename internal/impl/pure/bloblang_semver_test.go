@@ -0,0 +1,137 @@
+package pure
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/bloblang"
+)
+
+func TestSemverMethods(t *testing.T) {
+	tests := []struct {
+		name               string
+		mapping            string
+		input              interface{}
+		output             interface{}
+		parseErrorContains string
+		execErrorContains  string
+	}{
+		{
+			name:    "parse simple version",
+			mapping: `root = this.parse_semver()`,
+			input:   "2.0.11",
+			output: map[string]interface{}{
+				"major":      int64(2),
+				"minor":      int64(0),
+				"patch":      int64(11),
+				"prerelease": "",
+				"build":      "",
+			},
+		},
+		{
+			name:              "parse partial version",
+			mapping:           `root = this.parse_semver()`,
+			input:             "2.0",
+			execErrorContains: "expected three version components",
+		},
+		{
+			name:              "parse empty prerelease identifier",
+			mapping:           `root = this.parse_semver()`,
+			input:             "1.0.0-alpha..1",
+			execErrorContains: "empty prerelease identifier",
+		},
+		{
+			name:    "compare prerelease numeric identifiers",
+			mapping: `root = this.semver_compare("1.0.0-beta.11")`,
+			input:   "1.0.0-beta.2",
+			output:  int64(-1),
+		},
+		{
+			name:    "compare alphanumeric above numeric",
+			mapping: `root = this.semver_compare("1.0.0-alpha.1")`,
+			input:   "1.0.0-alpha.beta",
+			output:  int64(1),
+		},
+		{
+			name:    "compare ignores build metadata",
+			mapping: `root = this.semver_compare("1.0.0+abc")`,
+			input:   "1.0.0+def",
+			output:  int64(0),
+		},
+		{
+			name:               "compare invalid other",
+			mapping:            `root = this.semver_compare("nope")`,
+			parseErrorContains: `other: invalid semantic version "nope"`,
+		},
+		{
+			name:    "caret zero major",
+			mapping: `root = [ "0.2.9".semver_satisfies(this), "0.3.0".semver_satisfies(this) ]`,
+			input:   "^0.2.3",
+			output:  []interface{}{true, false},
+		},
+		{
+			name:    "caret zero minor",
+			mapping: `root = [ "0.0.3".semver_satisfies(this), "0.0.4".semver_satisfies(this) ]`,
+			input:   "^0.0.3",
+			output:  []interface{}{true, false},
+		},
+		{
+			name:    "tilde",
+			mapping: `root = [ "1.2.9".semver_satisfies(this), "1.3.0".semver_satisfies(this) ]`,
+			input:   "~1.2.3",
+			output:  []interface{}{true, false},
+		},
+		{
+			name:    "partial versions",
+			mapping: `root = [ "1.2.7".semver_satisfies(this), "1.3.0".semver_satisfies(this) ]`,
+			input:   "1.2",
+			output:  []interface{}{true, false},
+		},
+		{
+			name:    "wildcard",
+			mapping: `root = this.semver_satisfies("*")`,
+			input:   "5.1.0",
+			output:  true,
+		},
+		{
+			name:    "prerelease below release",
+			mapping: `root = this.semver_satisfies(">=1.0.0")`,
+			input:   "1.0.0-rc.1",
+			output:  false,
+		},
+		{
+			name:               "empty constraint set",
+			mapping:            `root = this.semver_satisfies("^1.2.3 ||")`,
+			parseErrorContains: "empty comparison set",
+		},
+		{
+			name:              "invalid target version",
+			mapping:           `root = this.semver_satisfies("^1.2.3")`,
+			input:             "latest",
+			execErrorContains: `invalid semantic version "latest"`,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			m, err := bloblang.Parse(test.mapping)
+			if test.parseErrorContains != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.parseErrorContains)
+			} else {
+				require.NoError(t, err)
+				v, err := m.Query(test.input)
+				if test.execErrorContains != "" {
+					require.Error(t, err)
+					assert.Contains(t, err.Error(), test.execErrorContains)
+				} else {
+					require.NoError(t, err)
+					assert.Equal(t, test.output, v)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,461 @@
+package pure
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
+	"github.com/benthosdev/benthos/v4/public/bloblang"
+)
+
+func init() {
+	// Note: The examples are run and tested from within
+	// ./internal/bloblang/query/parsed_test.go
+
+	levenshteinSpec := bloblang.NewPluginSpec().
+		Beta().
+		Category(query.MethodCategoryStrings).
+		Description("Returns the [Levenshtein distance](https://en.wikipedia.org/wiki/Levenshtein_distance) between two strings, which is the minimum number of single character edits (insertions, deletions or substitutions) required to change one string into the other.").
+		Param(bloblang.NewStringParam("other").Description("The string to compare against.")).
+		Version("4.3.0").
+		Example("",
+			`root.distance = this.a.levenshtein(this.b)`,
+			[2]string{
+				`{"a":"kitten","b":"sitting"}`,
+				`{"distance":3}`,
+			})
+
+	levenshteinCtor := func(args *bloblang.ParsedParams) (bloblang.Method, error) {
+		other, err := args.GetString("other")
+		if err != nil {
+			return nil, err
+		}
+		otherRunes := []rune(other)
+		return bloblang.StringMethod(func(s string) (interface{}, error) {
+			return int64(levenshteinDistance([]rune(s), otherRunes)), nil
+		}), nil
+	}
+
+	if err := bloblang.RegisterMethodV2("levenshtein", levenshteinSpec, levenshteinCtor); err != nil {
+		panic(err)
+	}
+
+	jaroWinklerSpec := bloblang.NewPluginSpec().
+		Beta().
+		Category(query.MethodCategoryStrings).
+		Description("Returns the [Jaro-Winkler similarity](https://en.wikipedia.org/wiki/Jaro%E2%80%93Winkler_distance) between two strings as a number between 0 (no similarity) and 1 (an exact match). Strings that share a common prefix are given a higher score.").
+		Param(bloblang.NewStringParam("other").Description("The string to compare against.")).
+		Version("4.3.0").
+		Example("",
+			`root.similarity = (this.a.jaro_winkler(this.b) * 100).round()`,
+			[2]string{
+				`{"a":"martha","b":"marhta"}`,
+				`{"similarity":96}`,
+			})
+
+	jaroWinklerCtor := func(args *bloblang.ParsedParams) (bloblang.Method, error) {
+		other, err := args.GetString("other")
+		if err != nil {
+			return nil, err
+		}
+		otherRunes := []rune(other)
+		return bloblang.StringMethod(func(s string) (interface{}, error) {
+			return jaroWinklerSimilarity([]rune(s), otherRunes), nil
+		}), nil
+	}
+
+	if err := bloblang.RegisterMethodV2("jaro_winkler", jaroWinklerSpec, jaroWinklerCtor); err != nil {
+		panic(err)
+	}
+
+	ngramSpec := bloblang.NewPluginSpec().
+		Beta().
+		Category(query.MethodCategoryStrings).
+		Description("Returns the similarity between two strings as a number between 0 and 1, calculated as the [Sørensen–Dice coefficient](https://en.wikipedia.org/wiki/S%C3%B8rensen%E2%80%93Dice_coefficient) of the sets of n-grams (contiguous sequences of n characters) found within each string.").
+		Param(bloblang.NewStringParam("other").Description("The string to compare against.")).
+		Param(bloblang.NewInt64Param("n").Description("The size of each n-gram.").Default(2)).
+		Version("4.3.0").
+		Example("",
+			`root.similarity = this.a.ngram_similarity(this.b)`,
+			[2]string{
+				`{"a":"night","b":"nacht"}`,
+				`{"similarity":0.25}`,
+			})
+
+	ngramCtor := func(args *bloblang.ParsedParams) (bloblang.Method, error) {
+		other, err := args.GetString("other")
+		if err != nil {
+			return nil, err
+		}
+		n, err := args.GetInt64("n")
+		if err != nil {
+			return nil, err
+		}
+		if n < 1 {
+			return nil, errors.New("n must be greater than zero")
+		}
+		otherGrams := ngramSet([]rune(other), int(n))
+		return bloblang.StringMethod(func(s string) (interface{}, error) {
+			return ngramSimilarity(ngramSet([]rune(s), int(n)), otherGrams), nil
+		}), nil
+	}
+
+	if err := bloblang.RegisterMethodV2("ngram_similarity", ngramSpec, ngramCtor); err != nil {
+		panic(err)
+	}
+
+	//--------------------------------------------------------------------------
+
+	soundexSpec := bloblang.NewPluginSpec().
+		Beta().
+		Category(query.MethodCategoryStrings).
+		Description("Returns the American [Soundex](https://en.wikipedia.org/wiki/Soundex) code of a string, a phonetic encoding where names that sound alike in English share the same code. Characters that are not ASCII letters are ignored.").
+		Version("4.3.0").
+		Example("",
+			`root.codes = [this.a.soundex(), this.b.soundex()]`,
+			[2]string{
+				`{"a":"Robert","b":"Rupert"}`,
+				`{"codes":["R163","R163"]}`,
+			})
+
+	soundexCtor := func(args *bloblang.ParsedParams) (bloblang.Method, error) {
+		return bloblang.StringMethod(func(s string) (interface{}, error) {
+			return soundex(s), nil
+		}), nil
+	}
+
+	if err := bloblang.RegisterMethodV2("soundex", soundexSpec, soundexCtor); err != nil {
+		panic(err)
+	}
+
+	metaphoneSpec := bloblang.NewPluginSpec().
+		Beta().
+		Category(query.MethodCategoryStrings).
+		Description("Returns the [Metaphone](https://en.wikipedia.org/wiki/Metaphone) code of a string, a phonetic encoding that improves upon Soundex by using knowledge of English spelling and pronunciation rules. Characters that are not ASCII letters are ignored.").
+		Version("4.3.0").
+		Example("",
+			`root.codes = [this.a.metaphone(), this.b.metaphone()]`,
+			[2]string{
+				`{"a":"Smith","b":"Smyth"}`,
+				`{"codes":["SM0","SM0"]}`,
+			})
+
+	metaphoneCtor := func(args *bloblang.ParsedParams) (bloblang.Method, error) {
+		return bloblang.StringMethod(func(s string) (interface{}, error) {
+			return metaphone(s), nil
+		}), nil
+	}
+
+	if err := bloblang.RegisterMethodV2("metaphone", metaphoneSpec, metaphoneCtor); err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+func levenshteinDistance(a, b []rune) int {
+	if len(a) == 0 {
+		return len(b)
+	}
+	if len(b) == 0 {
+		return len(a)
+	}
+
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = minInt(minInt(prev[j]+1, curr[j-1]+1), prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+func jaroSimilarity(a, b []rune) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 1
+	}
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+
+	matchRange := maxInt(len(a), len(b))/2 - 1
+	if matchRange < 0 {
+		matchRange = 0
+	}
+
+	aMatches := make([]bool, len(a))
+	bMatches := make([]bool, len(b))
+
+	matches := 0
+	for i := range a {
+		start, end := maxInt(0, i-matchRange), minInt(len(b), i+matchRange+1)
+		for j := start; j < end; j++ {
+			if bMatches[j] || a[i] != b[j] {
+				continue
+			}
+			aMatches[i], bMatches[j] = true, true
+			matches++
+			break
+		}
+	}
+	if matches == 0 {
+		return 0
+	}
+
+	transpositions, k := 0, 0
+	for i := range a {
+		if !aMatches[i] {
+			continue
+		}
+		for !bMatches[k] {
+			k++
+		}
+		if a[i] != b[k] {
+			transpositions++
+		}
+		k++
+	}
+
+	m := float64(matches)
+	return (m/float64(len(a)) + m/float64(len(b)) + (m-float64(transpositions)/2)/m) / 3
+}
+
+func jaroWinklerSimilarity(a, b []rune) float64 {
+	sim := jaroSimilarity(a, b)
+
+	prefix := 0
+	for prefix < minInt(4, minInt(len(a), len(b))) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	return sim + float64(prefix)*0.1*(1-sim)
+}
+
+func ngramSet(s []rune, n int) map[string]struct{} {
+	grams := map[string]struct{}{}
+	if len(s) < n {
+		if len(s) > 0 {
+			grams[string(s)] = struct{}{}
+		}
+		return grams
+	}
+	for i := 0; i+n <= len(s); i++ {
+		grams[string(s[i:i+n])] = struct{}{}
+	}
+	return grams
+}
+
+func ngramSimilarity(a, b map[string]struct{}) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 1
+	}
+	shared := 0
+	for k := range a {
+		if _, exists := b[k]; exists {
+			shared++
+		}
+	}
+	return 2 * float64(shared) / float64(len(a)+len(b))
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+//------------------------------------------------------------------------------
+
+// asciiLettersUpper returns the ASCII letters of a string in upper case,
+// discarding all other characters.
+func asciiLettersUpper(s string) []byte {
+	letters := make([]byte, 0, len(s))
+	for _, r := range s {
+		if r > unicode.MaxASCII || !unicode.IsLetter(r) {
+			continue
+		}
+		letters = append(letters, byte(unicode.ToUpper(r)))
+	}
+	return letters
+}
+
+var soundexCodes = [26]byte{
+	//A  B    C    D    E  F    G    H  I  J    K    L    M    N    O  P    Q    R    S    T    U  V    W  X    Y  Z
+	0, '1', '2', '3', 0, '1', '2', 0, 0, '2', '2', '4', '5', '5', 0, '1', '2', '6', '2', '3', 0, '1', 0, '2', 0, '2',
+}
+
+func soundex(s string) string {
+	letters := asciiLettersUpper(s)
+	if len(letters) == 0 {
+		return ""
+	}
+
+	code := []byte{letters[0]}
+	last := soundexCodes[letters[0]-'A']
+	for _, l := range letters[1:] {
+		c := soundexCodes[l-'A']
+		if c != 0 && c != last {
+			code = append(code, c)
+			if len(code) == 4 {
+				break
+			}
+		}
+		// H and W do not separate letters with the same code, but vowels do.
+		if l != 'H' && l != 'W' {
+			last = c
+		}
+	}
+	for len(code) < 4 {
+		code = append(code, '0')
+	}
+	return string(code)
+}
+
+func isMetaphoneVowel(c byte) bool {
+	return strings.IndexByte("AEIOU", c) >= 0
+}
+
+func metaphone(s string) string {
+	w := asciiLettersUpper(s)
+	if len(w) == 0 {
+		return ""
+	}
+
+	// Drop duplicate adjacent letters, except for C.
+	deduped := w[:1]
+	for i := 1; i < len(w); i++ {
+		if w[i] != w[i-1] || w[i] == 'C' {
+			deduped = append(deduped, w[i])
+		}
+	}
+	w = deduped
+
+	// Initial letter exceptions.
+	if len(w) > 1 {
+		switch string(w[:2]) {
+		case "KN", "GN", "PN", "AE", "WR":
+			w = w[1:]
+		}
+	}
+	if w[0] == 'X' {
+		w[0] = 'S'
+	}
+	if len(w) > 1 && w[0] == 'W' && w[1] == 'H' {
+		w = append([]byte{'W'}, w[2:]...)
+	}
+
+	at := func(i int) byte {
+		if i < 0 || i >= len(w) {
+			return 0
+		}
+		return w[i]
+	}
+	followedBy := func(i int, seq string) bool {
+		return i+1+len(seq) <= len(w) && string(w[i+1:i+1+len(seq)]) == seq
+	}
+
+	var code strings.Builder
+	for i := 0; i < len(w); i++ {
+		c := w[i]
+		switch c {
+		case 'A', 'E', 'I', 'O', 'U':
+			if i == 0 {
+				code.WriteByte(c)
+			}
+		case 'B':
+			if !(i == len(w)-1 && at(i-1) == 'M') {
+				code.WriteByte('B')
+			}
+		case 'C':
+			switch {
+			case followedBy(i, "IA") || (at(i+1) == 'H' && at(i-1) != 'S'):
+				code.WriteByte('X')
+			case at(i+1) == 'H':
+				code.WriteByte('K')
+			case at(i+1) == 'I' || at(i+1) == 'E' || at(i+1) == 'Y':
+				if at(i-1) != 'S' {
+					code.WriteByte('S')
+				}
+			default:
+				code.WriteByte('K')
+			}
+		case 'D':
+			if followedBy(i, "GE") || followedBy(i, "GY") || followedBy(i, "GI") {
+				code.WriteByte('J')
+			} else {
+				code.WriteByte('T')
+			}
+		case 'G':
+			switch {
+			case at(i+1) == 'H' && i+2 < len(w) && !isMetaphoneVowel(at(i+2)):
+			case at(i+1) == 'N' && (i+2 == len(w) || (followedBy(i, "NED") && i+4 == len(w))):
+			case (at(i+1) == 'I' || at(i+1) == 'E' || at(i+1) == 'Y') && at(i-1) != 'G':
+				code.WriteByte('J')
+			default:
+				code.WriteByte('K')
+			}
+		case 'H':
+			if isMetaphoneVowel(at(i+1)) && strings.IndexByte("CSPTG", at(i-1)) < 0 {
+				code.WriteByte('H')
+			}
+		case 'K':
+			if at(i-1) != 'C' {
+				code.WriteByte('K')
+			}
+		case 'P':
+			if at(i+1) == 'H' {
+				code.WriteByte('F')
+			} else {
+				code.WriteByte('P')
+			}
+		case 'Q':
+			code.WriteByte('K')
+		case 'S':
+			if at(i+1) == 'H' || followedBy(i, "IO") || followedBy(i, "IA") {
+				code.WriteByte('X')
+			} else {
+				code.WriteByte('S')
+			}
+		case 'T':
+			switch {
+			case followedBy(i, "IA") || followedBy(i, "IO"):
+				code.WriteByte('X')
+			case at(i+1) == 'H':
+				code.WriteByte('0')
+			case !followedBy(i, "CH"):
+				code.WriteByte('T')
+			}
+		case 'V':
+			code.WriteByte('F')
+		case 'W', 'Y':
+			if isMetaphoneVowel(at(i + 1)) {
+				code.WriteByte(c)
+			}
+		case 'X':
+			code.WriteString("KS")
+		case 'Z':
+			code.WriteByte('S')
+		default:
+			code.WriteByte(c)
+		}
+	}
+	return code.String()
+}
@@ -0,0 +1,58 @@
+package pure
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLevenshteinDistance(t *testing.T) {
+	for _, test := range []struct {
+		a, b string
+		exp  int
+	}{
+		{"", "", 0},
+		{"", "abc", 3},
+		{"abc", "", 3},
+		{"flaw", "lawn", 2},
+		{"kitten", "sitting", 3},
+		{"héllo", "hello", 1},
+	} {
+		assert.Equal(t, test.exp, levenshteinDistance([]rune(test.a), []rune(test.b)), "%v vs %v", test.a, test.b)
+	}
+}
+
+func TestJaroWinklerSimilarity(t *testing.T) {
+	for _, test := range []struct {
+		a, b string
+		exp  float64
+	}{
+		{"", "", 1},
+		{"abc", "", 0},
+		{"abc", "abc", 1},
+		{"abc", "xyz", 0},
+		{"dixon", "dicksonx", 0.8133},
+		{"dwayne", "duane", 0.84},
+	} {
+		assert.InDelta(t, test.exp, jaroWinklerSimilarity([]rune(test.a), []rune(test.b)), 0.0001, "%v vs %v", test.a, test.b)
+	}
+}
+
+func TestPhoneticCodes(t *testing.T) {
+	for _, test := range []struct {
+		input     string
+		soundex   string
+		metaphone string
+	}{
+		{input: "", soundex: "", metaphone: ""},
+		{input: "Tymczak", soundex: "T522", metaphone: "TMKSK"},
+		{input: "Ashcraft", soundex: "A261", metaphone: "AXKRFT"},
+		{input: "Pfister", soundex: "P236", metaphone: "PFSTR"},
+		{input: "Knight", soundex: "K523", metaphone: "NT"},
+		{input: "Thumb", soundex: "T510", metaphone: "0M"},
+		{input: "Xavier", soundex: "X160", metaphone: "SFR"},
+	} {
+		assert.Equal(t, test.soundex, soundex(test.input), test.input)
+		assert.Equal(t, test.metaphone, metaphone(test.input), test.input)
+	}
+}
@@ -2,10 +2,14 @@ package pure
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/OneOfOne/xxhash"
+	"github.com/dustin/go-humanize"
 
 	"github.com/benthosdev/benthos/v4/public/service"
 )
@@ -14,7 +18,7 @@ func memCacheConfig() *service.ConfigSpec {
 	spec := service.NewConfigSpec().
 		Stable().
 		Summary(`Stores key/value pairs in a map held in memory. This cache is therefore reset every time the service restarts. Each item in the cache has a TTL set from the moment it was last edited, after which it will be removed during the next compaction.`).
-		Description(`The compaction interval determines how often the cache is cleared of expired items, and this process is only triggered on writes to the cache. Access to the cache is blocked during this process.
+		Description(`The compaction interval determines the resolution at which expired items are removed from the cache. Expiry times are tracked within a timing wheel for each shard, and therefore the cost of a compaction is proportional to the number of items that have expired rather than the total number of items. Compactions are only triggered on writes to a shard, and access to the shard is blocked during this process.
 
 Item expiry can be disabled entirely by either setting the ` + "`compaction_interval`" + ` to an empty string.
 
@@ -29,7 +33,13 @@ cache_resources:
         foo: bar
 ` + "```" + `
 
-These values can be overridden during execution, at which point the configured TTL is respected as usual.`).
+These values can be overridden during execution, at which point the configured TTL is respected as usual.
+
+### Eviction
+
+The size of the cache can be bounded by setting ` + "`max_memory`" + `, in which case items are evicted according to the ` + "`eviction_policy`" + ` when a write would exceed it. The memory used by an item is estimated from the size of its key and value plus a fixed overhead, and the limit is split evenly across shards.
+
+Similar to Redis the eviction policies are approximated, when an item needs to be evicted a small sample of items of the shard is taken and the least recently used (` + "`lru`" + `) or least frequently used (` + "`lfu`" + `) item of the sample is evicted, with expired items always being evicted first.`).
 		Field(service.NewDurationField("default_ttl").
 			Description("The default TTL of each item. After this period an item will be eligible for removal during the next compaction.").
 			Default("5m")).
@@ -45,8 +55,18 @@ These values can be overridden during execution, at which point the configured T
 				"The Human League": "1977",
 			})).
 		Field(service.NewIntField("shards").
-			Description("A number of logical shards to spread keys across, increasing the shards can have a performance benefit when processing a large number of keys.").
+			Description("A number of logical shards to spread keys across, where each shard has its own lock and timing wheel. Increasing the shards can have a performance benefit when processing a large number of keys.").
 			Default(1).
+			Advanced()).
+		Field(service.NewStringField("max_memory").
+			Description("An optional maximum amount of memory that the items of the cache may occupy, once reached items are evicted according to the `eviction_policy`. Leave empty in order to keep items regardless of the memory they occupy.").
+			Default("").
+			Example("512MB").
+			Example("2GiB").
+			Advanced()).
+		Field(service.NewStringEnumField("eviction_policy", "lru", "lfu").
+			Description("The policy for choosing which items to evict when `max_memory` is reached, either the least recently used (`lru`) or the least frequently used (`lfu`) items.").
+			Default("lru").
 			Advanced())
 	return spec
 }
@@ -90,48 +110,156 @@ func newMemCacheFromConfig(conf *service.ParsedConfig) (*memoryCache, error) {
 		return nil, err
 	}
 
-	return newMemCache(ttl, compInterval, nShards, initValues), nil
+	var maxMemory uint64
+	if maxMemoryStr, _ := conf.FieldString("max_memory"); maxMemoryStr != "" {
+		if maxMemory, err = humanize.ParseBytes(maxMemoryStr); err != nil {
+			return nil, fmt.Errorf("failed to parse max_memory: %w", err)
+		}
+	}
+
+	policyStr, err := conf.FieldString("eviction_policy")
+	if err != nil {
+		return nil, err
+	}
+
+	m := newMemCache(ttl, compInterval, nShards, initValues)
+	if maxMemory > 0 {
+		if err := m.setEviction(int64(maxMemory), policyStr == "lfu"); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
 }
 
 //------------------------------------------------------------------------------
 
-type item struct {
+// The minimum resolution of expiry, which prevents the timing wheel from
+// cascading overly often with tiny compaction intervals.
+const memMinExpiryTick = time.Millisecond
+
+// An estimate of the memory occupied by an item on top of its key and value.
+const memEntryOverhead = 128
+
+// The number of items sampled when choosing an item to evict.
+const memEvictionSamples = 8
+
+var errMemEntryTooLarge = errors.New("item exceeds the max_memory of the cache")
+
+type memEntry struct {
+	// Updated atomically by reads holding a read lock on the shard, and kept
+	// first for 64-bit alignment.
+	lastAccess int64
+	hits       uint32
+
+	key     string
 	value   []byte
 	expires time.Time
+
+	// Scheduling of the entry within the timing wheel of the shard.
+	tick       uint64
+	bucket     *wheelBucket
+	prev, next *memEntry
+}
+
+func (e *memEntry) size() int64 {
+	return int64(len(e.key)+len(e.value)) + memEntryOverhead
+}
+
+func (e *memEntry) touch(now time.Time) {
+	atomic.StoreInt64(&e.lastAccess, now.UnixNano())
+	if atomic.LoadUint32(&e.hits) < ^uint32(0) {
+		atomic.AddUint32(&e.hits, 1)
+	}
 }
 
 type shard struct {
-	items map[string]item
+	items map[string]*memEntry
+
+	// Nil when expiry is disabled.
+	wheel *timingWheel
 
-	compInterval   time.Duration
-	lastCompaction time.Time
+	// Zero when eviction is disabled.
+	maxBytes  int64
+	usedBytes int64
+	lfu       bool
 
 	sync.RWMutex
 }
 
-func (s *shard) isExpired(i item) bool {
-	if s.compInterval == 0 {
+func (s *shard) isExpired(e *memEntry, now time.Time) bool {
+	if s.wheel == nil {
 		return false
 	}
-	if i.expires.IsZero() {
+	if e.expires.IsZero() {
 		return false
 	}
-	return i.expires.Before(time.Now())
+	return e.expires.Before(now)
 }
 
-func (s *shard) compaction() {
-	if s.compInterval == 0 {
+func (s *shard) compaction(now time.Time) {
+	if s.wheel == nil {
 		return
 	}
-	if time.Since(s.lastCompaction) < s.compInterval {
-		return
+	s.wheel.advance(now, s.remove)
+}
+
+func (s *shard) remove(e *memEntry) {
+	if e.bucket != nil {
+		s.wheel.unschedule(e)
+	}
+	delete(s.items, e.key)
+	s.usedBytes -= e.size()
+}
+
+// evictionCandidate returns the item to evict from a small sample of the items
+// of the shard, preferring expired items.
+func (s *shard) evictionCandidate(now time.Time) *memEntry {
+	var victim *memEntry
+	sampled := 0
+	for _, e := range s.items {
+		if s.isExpired(e, now) {
+			return e
+		}
+		if victim == nil || s.evictBefore(e, victim) {
+			victim = e
+		}
+		if sampled++; sampled >= memEvictionSamples {
+			break
+		}
 	}
-	for k, v := range s.items {
-		if s.isExpired(v) {
-			delete(s.items, k)
+	return victim
+}
+
+func (s *shard) evictBefore(a, b *memEntry) bool {
+	if s.lfu {
+		aHits, bHits := atomic.LoadUint32(&a.hits), atomic.LoadUint32(&b.hits)
+		if aHits != bHits {
+			return aHits < bHits
 		}
 	}
-	s.lastCompaction = time.Now()
+	return atomic.LoadInt64(&a.lastAccess) < atomic.LoadInt64(&b.lastAccess)
+}
+
+// put adds an item to the shard, replacing any existing item of the same key
+// and evicting other items when required.
+func (s *shard) put(e *memEntry, now time.Time) error {
+	if s.maxBytes > 0 && e.size() > s.maxBytes {
+		return errMemEntryTooLarge
+	}
+	if existing, exists := s.items[e.key]; exists {
+		s.remove(existing)
+	}
+	for s.maxBytes > 0 && s.usedBytes+e.size() > s.maxBytes && len(s.items) > 0 {
+		s.remove(s.evictionCandidate(now))
+	}
+
+	e.touch(now)
+	s.items[e.key] = e
+	s.usedBytes += e.size()
+	if s.wheel != nil && !e.expires.IsZero() {
+		s.wheel.schedule(e)
+	}
+	return nil
 }
 
 //------------------------------------------------------------------------------
@@ -141,29 +269,30 @@ func newMemCache(ttl, compInterval time.Duration, nShards int, initValues map[st
 		defaultTTL: ttl,
 	}
 
-	if nShards <= 1 {
-		m.shards = []*shard{
-			{
-				items:          map[string]item{},
-				compInterval:   compInterval,
-				lastCompaction: time.Now(),
-			},
+	if nShards < 1 {
+		nShards = 1
+	}
+
+	now := time.Now()
+	for i := 0; i < nShards; i++ {
+		s := &shard{
+			items: map[string]*memEntry{},
 		}
-	} else {
-		for i := 0; i < nShards; i++ {
-			m.shards = append(m.shards, &shard{
-				items:          map[string]item{},
-				compInterval:   compInterval,
-				lastCompaction: time.Now(),
-			})
+		if compInterval > 0 {
+			tick := compInterval
+			if tick < memMinExpiryTick {
+				tick = memMinExpiryTick
+			}
+			s.wheel = newTimingWheel(tick, now)
 		}
+		m.shards = append(m.shards, s)
 	}
 
 	for k, v := range initValues {
-		m.getShard(k).items[k] = item{
-			value:   []byte(v),
-			expires: time.Time{},
-		}
+		_ = m.getShard(k).put(&memEntry{
+			key:   k,
+			value: []byte(v),
+		}, now)
 	}
 
 	return m
@@ -174,6 +303,27 @@ type memoryCache struct {
 	defaultTTL time.Duration
 }
 
+// setEviction bounds the memory occupied by the items of the cache, which is
+// split evenly across shards.
+func (m *memoryCache) setEviction(maxBytes int64, lfu bool) error {
+	perShard := maxBytes / int64(len(m.shards))
+	if perShard < memEntryOverhead {
+		return fmt.Errorf("max_memory of %v bytes is too small to hold any items across %v shards", maxBytes, len(m.shards))
+	}
+
+	now := time.Now()
+	for _, s := range m.shards {
+		s.Lock()
+		s.maxBytes = perShard
+		s.lfu = lfu
+		for s.usedBytes > s.maxBytes && len(s.items) > 0 {
+			s.remove(s.evictionCandidate(now))
+		}
+		s.Unlock()
+	}
+	return nil
+}
+
 func (m *memoryCache) getShard(key string) *shard {
 	if len(m.shards) == 1 {
 		return m.shards[0]
@@ -183,61 +333,74 @@ func (m *memoryCache) getShard(key string) *shard {
 	return m.shards[h.Sum64()%uint64(len(m.shards))]
 }
 
+func (m *memoryCache) expiry(ttl *time.Duration, now time.Time) time.Time {
+	if ttl != nil {
+		return now.Add(*ttl)
+	}
+	return now.Add(m.defaultTTL)
+}
+
 func (m *memoryCache) Get(_ context.Context, key string) ([]byte, error) {
+	now := time.Now()
 	shard := m.getShard(key)
 	shard.RLock()
-	k, exists := shard.items[key]
-	shard.RUnlock()
+	defer shard.RUnlock()
+
+	e, exists := shard.items[key]
 	if !exists {
 		return nil, service.ErrKeyNotFound
 	}
 	// Simulate compaction by returning ErrKeyNotFound if ttl expired.
-	if shard.isExpired(k) {
+	if shard.isExpired(e, now) {
 		return nil, service.ErrKeyNotFound
 	}
-	return k.value, nil
+	if shard.maxBytes > 0 {
+		e.touch(now)
+	}
+	return e.value, nil
 }
 
 func (m *memoryCache) Set(_ context.Context, key string, value []byte, ttl *time.Duration) error {
-	var expires time.Time
-	if ttl != nil {
-		expires = time.Now().Add(*ttl)
-	} else {
-		expires = time.Now().Add(m.defaultTTL)
-	}
+	now := time.Now()
 	shard := m.getShard(key)
 	shard.Lock()
-	shard.compaction()
-	shard.items[key] = item{value: value, expires: expires}
-	shard.Unlock()
-	return nil
+	defer shard.Unlock()
+
+	shard.compaction(now)
+	return shard.put(&memEntry{
+		key:     key,
+		value:   value,
+		expires: m.expiry(ttl, now),
+	}, now)
 }
 
 func (m *memoryCache) Add(_ context.Context, key string, value []byte, ttl *time.Duration) error {
-	var expires time.Time
-	if ttl != nil {
-		expires = time.Now().Add(*ttl)
-	} else {
-		expires = time.Now().Add(m.defaultTTL)
-	}
+	now := time.Now()
 	shard := m.getShard(key)
 	shard.Lock()
-	if _, exists := shard.items[key]; exists {
-		shard.Unlock()
+	defer shard.Unlock()
+
+	if e, exists := shard.items[key]; exists && !shard.isExpired(e, now) {
 		return service.ErrKeyAlreadyExists
 	}
-	shard.compaction()
-	shard.items[key] = item{value: value, expires: expires}
-	shard.Unlock()
-	return nil
+	shard.compaction(now)
+	return shard.put(&memEntry{
+		key:     key,
+		value:   value,
+		expires: m.expiry(ttl, now),
+	}, now)
 }
 
 func (m *memoryCache) Delete(_ context.Context, key string) error {
+	now := time.Now()
 	shard := m.getShard(key)
 	shard.Lock()
-	shard.compaction()
-	delete(shard.items, key)
-	shard.Unlock()
+	defer shard.Unlock()
+
+	shard.compaction(now)
+	if e, exists := shard.items[key]; exists {
+		shard.remove(e)
+	}
 	return nil
 }
 
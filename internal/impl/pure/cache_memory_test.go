@@ -175,6 +175,151 @@ compaction_interval: 1ns
 	}
 }
 
+func TestMemoryCacheCompactionRemovesExpired(t *testing.T) {
+	defConf, err := memCacheConfig().ParseYAML(`
+default_ttl: 10ms
+compaction_interval: 1ms
+shards: 4
+`, nil)
+	require.NoError(t, err)
+
+	c, err := newMemCacheFromConfig(defConf)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	longTTL := time.Hour
+	for i := 0; i < 100; i++ {
+		require.NoError(t, c.Set(ctx, fmt.Sprintf("short%v", i), []byte("foo"), nil))
+		require.NoError(t, c.Set(ctx, fmt.Sprintf("long%v", i), []byte("bar"), &longTTL))
+	}
+
+	<-time.After(time.Millisecond * 50)
+
+	// Writes to each shard trigger compaction.
+	for i := 0; i < 100; i++ {
+		require.NoError(t, c.Delete(ctx, fmt.Sprintf("nope%v", i)))
+	}
+
+	var total int
+	for _, s := range c.shards {
+		total += len(s.items)
+		for k := range s.items {
+			assert.Contains(t, k, "long")
+		}
+	}
+	assert.Equal(t, 100, total)
+}
+
+func TestMemoryCacheEvictionLRU(t *testing.T) {
+	defConf, err := memCacheConfig().ParseYAML(fmt.Sprintf(`
+compaction_interval: ""
+max_memory: %v
+eviction_policy: lru
+`, 4*(memEntryOverhead+4)), nil)
+	require.NoError(t, err)
+
+	c, err := newMemCacheFromConfig(defConf)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	for _, k := range []string{"foo0", "foo1", "foo2", "foo3"} {
+		require.NoError(t, c.Set(ctx, k, nil, nil))
+		<-time.After(time.Millisecond)
+	}
+
+	_, err = c.Get(ctx, "foo0")
+	require.NoError(t, err)
+
+	require.NoError(t, c.Set(ctx, "foo4", nil, nil))
+
+	_, err = c.Get(ctx, "foo1")
+	assert.Equal(t, service.ErrKeyNotFound, err)
+
+	for _, k := range []string{"foo0", "foo2", "foo3", "foo4"} {
+		_, err = c.Get(ctx, k)
+		assert.NoError(t, err, k)
+	}
+	assert.Equal(t, int64(4*(memEntryOverhead+4)), c.shards[0].usedBytes)
+}
+
+func TestMemoryCacheEvictionLFU(t *testing.T) {
+	defConf, err := memCacheConfig().ParseYAML(fmt.Sprintf(`
+compaction_interval: ""
+max_memory: %v
+eviction_policy: lfu
+`, 3*(memEntryOverhead+4)), nil)
+	require.NoError(t, err)
+
+	c, err := newMemCacheFromConfig(defConf)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+
+	for _, k := range []string{"foo0", "foo1", "foo2"} {
+		require.NoError(t, c.Set(ctx, k, nil, nil))
+	}
+	for i := 0; i < 3; i++ {
+		for _, k := range []string{"foo0", "foo2"} {
+			_, err = c.Get(ctx, k)
+			require.NoError(t, err)
+		}
+	}
+
+	require.NoError(t, c.Set(ctx, "foo3", nil, nil))
+
+	_, err = c.Get(ctx, "foo1")
+	assert.Equal(t, service.ErrKeyNotFound, err)
+
+	assert.Equal(t, errMemEntryTooLarge, c.Set(ctx, "foo4", make([]byte, 3*memEntryOverhead), nil))
+}
+
+func TestTimingWheel(t *testing.T) {
+	origin := time.Unix(0, 0)
+	w := newTimingWheel(time.Millisecond, origin)
+
+	var entries []*memEntry
+	for _, d := range []time.Duration{
+		0,
+		time.Millisecond / 2,
+		time.Millisecond * 63,
+		time.Millisecond * 64,
+		time.Millisecond * 65,
+		time.Second * 5,
+		time.Minute * 10,
+		time.Hour * 3,
+		time.Hour * 24 * 30,
+	} {
+		e := &memEntry{key: d.String(), expires: origin.Add(d)}
+		w.schedule(e)
+		entries = append(entries, e)
+	}
+
+	expired := map[string]time.Time{}
+	now := origin
+	for _, step := range []time.Duration{
+		time.Millisecond / 4, time.Millisecond, time.Millisecond * 62, time.Millisecond,
+		time.Millisecond, time.Second, time.Second * 5, time.Minute * 10,
+		time.Hour, time.Hour * 3, time.Hour * 24 * 29, time.Hour * 24,
+	} {
+		now = now.Add(step)
+		w.advance(now, func(e *memEntry) {
+			assert.False(t, now.Before(e.expires), "expired %v early at %v", e.key, now.Sub(origin))
+			expired[e.key] = now
+		})
+		for _, e := range entries {
+			if _, exists := expired[e.key]; !exists {
+				assert.True(t, now.Before(e.expires), "%v not expired at %v", e.key, now.Sub(origin))
+			} else {
+				assert.Nil(t, e.bucket)
+			}
+		}
+	}
+	assert.Len(t, expired, len(entries))
+	assert.Equal(t, [wheelLevels]int{}, w.counts)
+}
+
 //------------------------------------------------------------------------------
 
 func BenchmarkMemoryShards1(b *testing.B) {
@@ -0,0 +1,162 @@
+package pure
+
+import (
+	"time"
+)
+
+const (
+	wheelBits   = 6
+	wheelSlots  = 1 << wheelBits
+	wheelMask   = wheelSlots - 1
+	wheelLevels = 4
+	wheelSpan   = uint64(1) << (wheelBits * wheelLevels)
+)
+
+// wheelBucket is an intrusive doubly linked list of the entries scheduled
+// within a slot of a timing wheel.
+type wheelBucket struct {
+	level int
+	head  *memEntry
+}
+
+func (b *wheelBucket) push(e *memEntry) {
+	e.bucket = b
+	e.prev = nil
+	e.next = b.head
+	if b.head != nil {
+		b.head.prev = e
+	}
+	b.head = e
+}
+
+func (b *wheelBucket) remove(e *memEntry) {
+	if e.prev != nil {
+		e.prev.next = e.next
+	} else {
+		b.head = e.next
+	}
+	if e.next != nil {
+		e.next.prev = e.prev
+	}
+	e.bucket, e.prev, e.next = nil, nil, nil
+}
+
+// timingWheel schedules the expiry of entries within a hierarchy of wheels,
+// where each slot of a level spans all slots of the level below it. Entries
+// are cascaded down into lower levels as their expiry approaches, which means
+// scheduling, unscheduling and expiring an entry takes constant time
+// regardless of the number of entries scheduled.
+//
+// Entries expiring beyond the span of the top level are kept within its last
+// slot and rescheduled once it's reached.
+type timingWheel struct {
+	tick   time.Duration
+	origin time.Time
+
+	// The next tick to be processed.
+	current uint64
+
+	levels [wheelLevels][wheelSlots]wheelBucket
+	counts [wheelLevels]int
+}
+
+func newTimingWheel(tick time.Duration, now time.Time) *timingWheel {
+	w := &timingWheel{
+		tick:   tick,
+		origin: now,
+	}
+	for l := range w.levels {
+		for i := range w.levels[l] {
+			w.levels[l][i].level = l
+		}
+	}
+	return w
+}
+
+// schedule adds an entry to the wheel according to its expiry time, rounded up
+// to the next tick so that it's never expired early.
+func (w *timingWheel) schedule(e *memEntry) {
+	e.tick = 0
+	if d := e.expires.Sub(w.origin); d > 0 {
+		e.tick = uint64((d + w.tick - 1) / w.tick)
+	}
+	w.place(e)
+}
+
+func (w *timingWheel) place(e *memEntry) {
+	t := e.tick
+	if t < w.current {
+		t = w.current
+	}
+
+	delta := t - w.current
+	if delta >= wheelSpan {
+		t = w.current + wheelSpan - 1
+		delta = wheelSpan - 1
+	}
+
+	level := 0
+	for level < wheelLevels-1 && delta >= uint64(1)<<(wheelBits*(level+1)) {
+		level++
+	}
+	w.levels[level][(t>>(wheelBits*level))&wheelMask].push(e)
+	w.counts[level]++
+}
+
+// unschedule removes an entry from the wheel.
+func (w *timingWheel) unschedule(e *memEntry) {
+	w.counts[e.bucket.level]--
+	e.bucket.remove(e)
+}
+
+// advance processes all ticks up to the provided time, calling expire with each
+// entry that has expired after removing it from the wheel. Ticks where there
+// is nothing to expire or cascade are skipped, and therefore advancing after a
+// long period of inactivity is cheap.
+func (w *timingWheel) advance(now time.Time, expire func(e *memEntry)) {
+	if now.Before(w.origin) {
+		return
+	}
+	target := uint64(now.Sub(w.origin) / w.tick)
+
+	for w.current <= target {
+		empty := 0
+		for empty < wheelLevels && w.counts[empty] == 0 {
+			empty++
+		}
+		if empty == wheelLevels {
+			w.current = target + 1
+			return
+		}
+		if empty > 0 {
+			// Nothing can happen until the next cascade of the lowest level
+			// that isn't empty.
+			span := uint64(1) << (wheelBits * empty)
+			next := (w.current + span - 1) &^ (span - 1)
+			if next > target {
+				w.current = target + 1
+				return
+			}
+			w.current = next
+		}
+		w.process(expire)
+	}
+}
+
+func (w *timingWheel) process(expire func(e *memEntry)) {
+	c := w.current
+	for level := 1; level < wheelLevels && c&(uint64(1)<<(wheelBits*level)-1) == 0; level++ {
+		b := &w.levels[level][(c>>(wheelBits*level))&wheelMask]
+		for e := b.head; e != nil; e = b.head {
+			w.unschedule(e)
+			w.place(e)
+		}
+	}
+
+	b := &w.levels[0][c&wheelMask]
+	for e := b.head; e != nil; e = b.head {
+		w.unschedule(e)
+		expire(e)
+	}
+	w.current++
+}
@@ -40,6 +40,10 @@ input:
           consumer_tag: benthos-consumer
           queue: benthos-queue
 
+        # Optional labels added to the metrics, logs and traces of this input
+        labels:
+          source: rabbitmq
+
         # Optional list of input specific processing steps
         processors:
           - bloblang: |
@@ -53,6 +57,8 @@ input:
           client_id: benthos_kafka_input
           consumer_group: benthos_consumer_group
           topics: [ benthos_stream:0 ]
+        labels:
+          source: kafka
 ` + "```" + `
 
 If the number of copies is greater than zero the list will be copied that number
@@ -72,7 +78,11 @@ It is possible to configure [processors](/docs/components/processors/about) at
 the broker level, where they will be applied to _all_ child inputs, as well as
 on the individual child inputs. If you have processors at both the broker level
 _and_ on child inputs then the broker processors will be applied _after_ the
-child nodes processors.`,
+child nodes processors.
+
+### Labels
+
+Each child input can be given a map of ` + "`labels`" + `, which are added to all metrics, log lines and tracing spans produced by the child input and its processors. When many sources feed a single pipeline this makes it possible to tell them apart, for example in order to find which source is producing errors or lagging behind. The [` + "`label`" + ` field](/docs/components/metrics/about#label) of a child input can also be used for this purpose, but as labels must be unique it cannot be shared by multiple children, or used when ` + "`copies`" + ` is greater than one.`,
 		Categories: []string{
 			"Utility",
 		},
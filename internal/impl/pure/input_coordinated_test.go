@@ -0,0 +1,297 @@
+package pure
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/coordination"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func TestCoordinatedInputConfigErrors(t *testing.T) {
+	for _, test := range []struct {
+		name        string
+		config      string
+		errContains string
+	}{
+		{
+			name: "no backend",
+			config: `
+group: foo
+partitions:
+  - id: a
+    input:
+      generate:
+        mapping: 'root = "a"'
+`,
+			errContains: "either a cache or kubernetes must be specified",
+		},
+		{
+			name: "both backends",
+			config: `
+group: foo
+cache: foocache
+kubernetes:
+  enabled: true
+partitions:
+  - id: a
+    input:
+      generate:
+        mapping: 'root = "a"'
+`,
+			errContains: "only one of cache and kubernetes may be specified",
+		},
+		{
+			name: "missing cache",
+			config: `
+group: foo
+cache: barcache
+partitions:
+  - id: a
+    input:
+      generate:
+        mapping: 'root = "a"'
+`,
+			errContains: "cache resource 'barcache' was not found",
+		},
+		{
+			name: "duplicate partitions",
+			config: `
+group: foo
+cache: foocache
+partitions:
+  - id: a
+    input:
+      generate:
+        mapping: 'root = "a"'
+  - id: a
+    input:
+      generate:
+        mapping: 'root = "a"'
+`,
+			errContains: "id 'a' is not unique",
+		},
+		{
+			name: "balance interval exceeds ttl",
+			config: `
+group: foo
+cache: foocache
+claim_ttl: 1s
+balance_interval: 2s
+partitions:
+  - id: a
+    input:
+      generate:
+        mapping: 'root = "a"'
+`,
+			errContains: "must be greater than zero and less than the claim TTL",
+		},
+	} {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			conf, err := coordinatedInputConfig().ParseYAML(test.config, nil)
+			require.NoError(t, err)
+
+			_, err = newCoordinatedInputFromConfig(conf, service.MockResources(service.MockResourcesOptAddCache("foocache")))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), test.errContains)
+		})
+	}
+}
+
+func TestCoordinatedInputCacheBackend(t *testing.T) {
+	ctx := context.Background()
+	b := &cacheCoordinationBackend{
+		name: "foocache",
+		mgr:  service.MockResources(service.MockResourcesOptAddCache("foocache")),
+	}
+
+	_, err := b.Get(ctx, "foo/a")
+	require.ErrorIs(t, err, coordination.ErrNotClaimed)
+
+	require.NoError(t, b.Create(ctx, "foo/a", "x", time.Minute))
+	require.ErrorIs(t, b.Create(ctx, "foo/a", "y", time.Minute), coordination.ErrClaimed)
+
+	owner, err := b.Get(ctx, "foo/a")
+	require.NoError(t, err)
+	assert.Equal(t, "x", owner)
+
+	require.NoError(t, b.Update(ctx, "foo/a", "y", time.Minute))
+	owner, err = b.Get(ctx, "foo/a")
+	require.NoError(t, err)
+	assert.Equal(t, "y", owner)
+
+	require.NoError(t, b.Delete(ctx, "foo/a"))
+	_, err = b.Get(ctx, "foo/a")
+	require.ErrorIs(t, err, coordination.ErrNotClaimed)
+}
+
+// lockedBackend is a backend shared by inputs of a test, where claims never
+// expire.
+type lockedBackend struct {
+	mut    sync.Mutex
+	claims map[string]string
+}
+
+func (l *lockedBackend) Get(ctx context.Context, key string) (string, error) {
+	l.mut.Lock()
+	defer l.mut.Unlock()
+	if owner, exists := l.claims[key]; exists {
+		return owner, nil
+	}
+	return "", coordination.ErrNotClaimed
+}
+
+func (l *lockedBackend) Create(ctx context.Context, key, owner string, ttl time.Duration) error {
+	l.mut.Lock()
+	defer l.mut.Unlock()
+	if _, exists := l.claims[key]; exists {
+		return coordination.ErrClaimed
+	}
+	l.claims[key] = owner
+	return nil
+}
+
+func (l *lockedBackend) Update(ctx context.Context, key, owner string, ttl time.Duration) error {
+	l.mut.Lock()
+	defer l.mut.Unlock()
+	l.claims[key] = owner
+	return nil
+}
+
+func (l *lockedBackend) Delete(ctx context.Context, key string) error {
+	l.mut.Lock()
+	defer l.mut.Unlock()
+	delete(l.claims, key)
+	return nil
+}
+
+func (l *lockedBackend) Close(ctx context.Context) error {
+	return nil
+}
+
+func testCoordinatedInput(t *testing.T, backend coordination.Backend, identity string) *coordinatedInput {
+	t.Helper()
+
+	confStr := fmt.Sprintf(`
+group: foo
+identity: %v
+cache: foocache
+claim_ttl: 1s
+balance_interval: 20ms
+partitions:
+`, identity)
+	for _, id := range []string{"a", "b", "c", "d"} {
+		confStr += fmt.Sprintf(`
+  - id: %v
+    input:
+      generate:
+        interval: 5ms
+        mapping: 'root = "%v"'
+`, id, id)
+	}
+
+	conf, err := coordinatedInputConfig().ParseYAML(confStr, nil)
+	require.NoError(t, err)
+
+	c, err := newCoordinatedInputFromConfig(conf, service.MockResources(service.MockResourcesOptAddCache("foocache")))
+	require.NoError(t, err)
+
+	c.newBackend = func() (coordination.Backend, error) {
+		return backend, nil
+	}
+	return c
+}
+
+// readPartitions reads from an input for a period and returns the partitions
+// that were read from.
+func readPartitions(t *testing.T, c *coordinatedInput, period time.Duration) []string {
+	t.Helper()
+
+	ctx, done := context.WithTimeout(context.Background(), period)
+	defer done()
+
+	seen := map[string]struct{}{}
+	for {
+		batch, aFn, err := c.ReadBatch(ctx)
+		if err != nil {
+			require.ErrorIs(t, err, context.DeadlineExceeded)
+			break
+		}
+		require.NoError(t, aFn(context.Background(), nil))
+
+		p, _ := batch[0].MetaGet("coordinated_partition")
+		b, err := batch[0].AsBytes()
+		require.NoError(t, err)
+		assert.Equal(t, p, string(b))
+		seen[p] = struct{}{}
+	}
+
+	var partitions []string
+	for p := range seen {
+		partitions = append(partitions, p)
+	}
+	sort.Strings(partitions)
+	return partitions
+}
+
+func TestCoordinatedInputRebalance(t *testing.T) {
+	backend := &lockedBackend{claims: map[string]string{}}
+
+	x := testCoordinatedInput(t, backend, "x")
+	require.NoError(t, x.Connect(context.Background()))
+	t.Cleanup(func() {
+		_ = x.Close(context.Background())
+	})
+
+	assert.Equal(t, []string{"a", "b", "c", "d"}, readPartitions(t, x, time.Millisecond*300))
+
+	y := testCoordinatedInput(t, backend, "y")
+	require.NoError(t, y.Connect(context.Background()))
+	t.Cleanup(func() {
+		_ = y.Close(context.Background())
+	})
+
+	// Allow the partitions to be rebalanced before reading from both.
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		readPartitions(t, x, time.Millisecond*500)
+	}()
+	go func() {
+		defer wg.Done()
+		readPartitions(t, y, time.Millisecond*500)
+	}()
+	wg.Wait()
+
+	var xParts, yParts []string
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		xParts = readPartitions(t, x, time.Millisecond*300)
+	}()
+	go func() {
+		defer wg.Done()
+		yParts = readPartitions(t, y, time.Millisecond*300)
+	}()
+	wg.Wait()
+
+	assert.Len(t, xParts, 2)
+	assert.Len(t, yParts, 2)
+	all := append(append([]string{}, xParts...), yParts...)
+	sort.Strings(all)
+	assert.Equal(t, []string{"a", "b", "c", "d"}, all)
+
+	// Closing an input releases its partitions to the remaining input.
+	require.NoError(t, x.Close(context.Background()))
+	readPartitions(t, y, time.Millisecond*200)
+	assert.Equal(t, []string{"a", "b", "c", "d"}, readPartitions(t, y, time.Millisecond*300))
+}
@@ -0,0 +1,494 @@
+package pure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang/field"
+	"github.com/benthosdev/benthos/v4/internal/bundle"
+	"github.com/benthosdev/benthos/v4/internal/component"
+	"github.com/benthosdev/benthos/v4/internal/component/input"
+	"github.com/benthosdev/benthos/v4/internal/component/input/processors"
+	"github.com/benthosdev/benthos/v4/internal/docs"
+	"github.com/benthosdev/benthos/v4/internal/log"
+	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/shutdown"
+)
+
+func init() {
+	err := bundle.AllInputs.Add(processors.WrapConstructor(func(c input.Config, nm bundle.NewManagement) (input.Streamed, error) {
+		return newJoinInput(c.Join, nm)
+	}), docs.ComponentSpec{
+		Name:   "join",
+		Status: docs.StatusExperimental,
+		Summary: `
+Consumes messages from two or more inputs and correlates them by a key within a window of time, emitting a single merged message for each key.`,
+		Description: `
+Messages from each input are buffered in memory by their key until a message of the same key has been consumed from every input, at which point the messages are merged into a single message and emitted. Each message must be a structured object, and fields are merged in the order of the inputs, where the values of later inputs replace those of earlier inputs. Messages that are not structured objects or yield an empty key are emitted immediately and flagged as having failed, and can be handled with [standard error handling patterns](/docs/configuration/error_handling).
+
+Only the most recent message of each input is retained for a given key, and earlier messages of the same input and key are acknowledged and discarded. Messages are only acknowledged once the merged message they contributed to has been delivered, and therefore the child inputs must support having multiple messages in flight.
+
+### Join Types
+
+With an ` + "`inner`" + ` join the messages of keys that have not been consumed from every input by the end of their window are acknowledged and discarded. With a ` + "`left`" + ` join the messages of incomplete keys are merged and emitted at the end of their window provided that a message from the first input was consumed, otherwise they are discarded.
+
+### Late Arrivals
+
+Keys that reach the end of their window without being matched are remembered for the length of another window, and messages consumed for those keys during that time are considered late. The field ` + "`late_arrivals`" + ` determines whether late messages are discarded or emitted on their own.
+
+### Metadata
+
+The metadata of messages are merged along with their contents, and emitted messages also have a metadata field ` + "`join_status`" + ` which is ` + "`matched`" + ` for keys that were consumed from every input, ` + "`partial`" + ` for incomplete keys emitted by a ` + "`left`" + ` join, and ` + "`late`" + ` for late arrivals.`,
+		Categories: []string{
+			"Utility",
+		},
+		Examples: []docs.AnnotatedExample{
+			{
+				Title:   "Enriching Orders With Payments",
+				Summary: "Here we join orders and payments consumed from separate Kafka topics by their order ID, emitting orders even when no payment has been consumed within five minutes.",
+				Config: `
+input:
+  join:
+    key: ${! json("order_id") }
+    window: 5m
+    type: left
+    inputs:
+      - kafka:
+          addresses: [ localhost:9092 ]
+          topics: [ orders ]
+          consumer_group: order_joiner
+      - kafka:
+          addresses: [ localhost:9092 ]
+          topics: [ payments ]
+          consumer_group: order_joiner
+        processors:
+          - bloblang: 'root = { "order_id": this.order_id, "payment": this }'
+`,
+			},
+		},
+		Config: docs.FieldComponent().WithChildren(
+			docs.FieldInput("inputs", "A list of two or more inputs to join messages from. When performing a `left` join the first input is the left side of the join.").Array().HasDefault([]interface{}{}),
+			docs.FieldString("key", "An interpolated string yielding the key to correlate messages by.", `${! json("id") }`, `${! meta("kafka_key") }`).IsInterpolated().HasDefault(""),
+			docs.FieldString("window", "The maximum period of time to wait for a key to be consumed from every input, starting from when the first message of the key is consumed.", "30s", "1h").HasDefault("1m"),
+			docs.FieldString("type", "The type of join to perform.").HasAnnotatedOptions(
+				"inner", "Only emit messages for keys consumed from every input.",
+				"left", "Also emit incomplete keys at the end of their window when they were consumed from the first input.",
+			).HasDefault("inner"),
+			docs.FieldString("late_arrivals", "What to do with messages that arrive after the window of their key has ended without a match.").HasAnnotatedOptions(
+				"drop", "Acknowledge and discard late messages.",
+				"emit", "Emit late messages on their own.",
+			).HasDefault("drop").Advanced(),
+		),
+	})
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// joinSource tracks a transaction consumed from a child input, which is
+// acknowledged once all of its messages have been resolved.
+type joinSource struct {
+	tran message.Transaction
+
+	mut     sync.Mutex
+	pending int
+	err     error
+}
+
+func (s *joinSource) resolve(ctx context.Context, err error) error {
+	s.mut.Lock()
+	if err != nil && s.err == nil {
+		s.err = err
+	}
+	s.pending--
+	done, err := s.pending == 0, s.err
+	s.mut.Unlock()
+
+	if !done {
+		return nil
+	}
+	return s.tran.Ack(ctx, err)
+}
+
+type joinPart struct {
+	part   *message.Part
+	obj    map[string]interface{}
+	source *joinSource
+}
+
+type joinEntry struct {
+	parts   []*joinPart
+	expires time.Time
+}
+
+func (e *joinEntry) complete() bool {
+	for _, p := range e.parts {
+		if p == nil {
+			return false
+		}
+	}
+	return true
+}
+
+type joinRead struct {
+	index int
+	tran  message.Transaction
+}
+
+type joinInput struct {
+	log log.Modular
+
+	inputs   []input.Streamed
+	key      *field.Expression
+	window   time.Duration
+	leftJoin bool
+	emitLate bool
+
+	pending map[string]*joinEntry
+	expired map[string]time.Time
+
+	transactions chan message.Transaction
+
+	shutSig *shutdown.Signaller
+}
+
+func newJoinInput(conf input.JoinConfig, mgr bundle.NewManagement) (*joinInput, error) {
+	if len(conf.Inputs) < 2 {
+		return nil, errors.New("a join input requires at least two child inputs")
+	}
+	if conf.Key == "" {
+		return nil, errors.New("a join key must be specified")
+	}
+
+	j := &joinInput{
+		log:          mgr.Logger(),
+		pending:      map[string]*joinEntry{},
+		expired:      map[string]time.Time{},
+		transactions: make(chan message.Transaction),
+		shutSig:      shutdown.NewSignaller(),
+	}
+
+	var err error
+	if j.key, err = mgr.BloblEnvironment().NewField(conf.Key); err != nil {
+		return nil, fmt.Errorf("failed to parse key expression: %v", err)
+	}
+	if j.window, err = time.ParseDuration(conf.Window); err != nil {
+		return nil, fmt.Errorf("failed to parse window duration: %w", err)
+	}
+	if j.window <= 0 {
+		return nil, errors.New("join window must be greater than zero")
+	}
+
+	switch conf.Type {
+	case "inner":
+	case "left":
+		j.leftJoin = true
+	default:
+		return nil, fmt.Errorf("join type '%v' was not recognized", conf.Type)
+	}
+
+	switch conf.LateArrivals {
+	case "drop":
+	case "emit":
+		j.emitLate = true
+	default:
+		return nil, fmt.Errorf("late arrivals option '%v' was not recognized", conf.LateArrivals)
+	}
+
+	for i, iConf := range conf.Inputs {
+		iMgr := mgr.IntoPath("join", "inputs", strconv.Itoa(i))
+		in, err := iMgr.NewInput(iConf)
+		if err != nil {
+			for _, prev := range j.inputs {
+				prev.CloseAsync()
+			}
+			return nil, err
+		}
+		j.inputs = append(j.inputs, in)
+	}
+
+	go j.loop()
+	return j, nil
+}
+
+//------------------------------------------------------------------------------
+
+func joinMergeInto(dst, src map[string]interface{}) {
+	for k, v := range src {
+		if vObj, ok := v.(map[string]interface{}); ok {
+			dObj, ok := dst[k].(map[string]interface{})
+			if !ok {
+				dObj = map[string]interface{}{}
+				dst[k] = dObj
+			}
+			joinMergeInto(dObj, vObj)
+			continue
+		}
+		dst[k] = v
+	}
+}
+
+// emit sends a message merged from the provided parts downstream, where the
+// sources of the parts are resolved once it has been acknowledged. Returns
+// false if the input was closed before the message could be sent.
+func (j *joinInput) emit(ctx context.Context, parts []*joinPart, status string) bool {
+	merged := map[string]interface{}{}
+	part := message.NewPart(nil)
+	var sourceParts []*joinPart
+	for _, p := range parts {
+		if p == nil {
+			continue
+		}
+		joinMergeInto(merged, p.obj)
+		_ = p.part.MetaIter(func(k, v string) error {
+			part.MetaSet(k, v)
+			return nil
+		})
+		sourceParts = append(sourceParts, p)
+	}
+	part.SetJSON(merged)
+	part.MetaSet("join_status", status)
+
+	return j.send(ctx, part, sourceParts)
+}
+
+func (j *joinInput) send(ctx context.Context, part *message.Part, sourceParts []*joinPart) bool {
+	msg := message.QuickBatch(nil)
+	msg.Append(part)
+
+	select {
+	case j.transactions <- message.NewTransactionFunc(msg, func(ctx context.Context, err error) error {
+		var ackErr error
+		for _, p := range sourceParts {
+			if rerr := p.source.resolve(ctx, err); rerr != nil && ackErr == nil {
+				ackErr = rerr
+			}
+		}
+		return ackErr
+	}):
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (j *joinInput) add(ctx context.Context, read joinRead, now time.Time) bool {
+	source := &joinSource{
+		tran:    read.tran,
+		pending: read.tran.Payload.Len(),
+	}
+	if source.pending == 0 {
+		_ = read.tran.Ack(ctx, nil)
+		return true
+	}
+
+	return read.tran.Payload.Iter(func(i int, p *message.Part) error {
+		jp := &joinPart{part: p, source: source}
+
+		key := j.key.String(i, read.tran.Payload)
+		if key == "" {
+			p = p.Copy()
+			p.ErrorSet(errors.New("join key resolved to an empty string"))
+			if !j.send(ctx, p, []*joinPart{jp}) {
+				return ctx.Err()
+			}
+			return nil
+		}
+
+		v, err := p.JSON()
+		if err == nil {
+			var isObj bool
+			if jp.obj, isObj = v.(map[string]interface{}); !isObj {
+				err = fmt.Errorf("expected object value, got %T", v)
+			}
+		}
+		if err != nil {
+			p = p.Copy()
+			p.ErrorSet(fmt.Errorf("failed to parse message for join: %w", err))
+			if !j.send(ctx, p, []*joinPart{jp}) {
+				return ctx.Err()
+			}
+			return nil
+		}
+
+		if until, exists := j.expired[key]; exists && now.Before(until) {
+			if !j.emitLate {
+				j.log.Debugf("Discarding late message of join key '%v'\n", key)
+				_ = source.resolve(ctx, nil)
+				return nil
+			}
+			if !j.emit(ctx, []*joinPart{jp}, "late") {
+				return ctx.Err()
+			}
+			return nil
+		}
+
+		entry, exists := j.pending[key]
+		if !exists {
+			entry = &joinEntry{
+				parts:   make([]*joinPart, len(j.inputs)),
+				expires: now.Add(j.window),
+			}
+			j.pending[key] = entry
+		}
+		if prev := entry.parts[read.index]; prev != nil {
+			// Superseded by a more recent message of the same input.
+			_ = prev.source.resolve(ctx, nil)
+		}
+		entry.parts[read.index] = jp
+
+		if !entry.complete() {
+			return nil
+		}
+		delete(j.pending, key)
+		delete(j.expired, key)
+		if !j.emit(ctx, entry.parts, "matched") {
+			return ctx.Err()
+		}
+		return nil
+	}) == nil
+}
+
+// expire ends the windows of keys that have expired by the provided time,
+// where incomplete keys are either emitted or discarded depending on the join
+// type. When flush is true all pending keys are ended.
+func (j *joinInput) expire(ctx context.Context, now time.Time, flush bool) bool {
+	for key, until := range j.expired {
+		if !now.Before(until) {
+			delete(j.expired, key)
+		}
+	}
+	for key, entry := range j.pending {
+		if !flush && now.Before(entry.expires) {
+			continue
+		}
+		delete(j.pending, key)
+		j.expired[key] = now.Add(j.window)
+
+		if j.leftJoin && entry.parts[0] != nil {
+			if !j.emit(ctx, entry.parts, "partial") {
+				return false
+			}
+			continue
+		}
+		j.log.Debugf("Discarding unmatched messages of join key '%v'\n", key)
+		for _, p := range entry.parts {
+			if p != nil {
+				_ = p.source.resolve(ctx, nil)
+			}
+		}
+	}
+	return true
+}
+
+func (j *joinInput) loop() {
+	ctx, done := j.shutSig.CloseAtLeisureCtx(context.Background())
+	defer done()
+
+	reads := make(chan joinRead)
+	var wg sync.WaitGroup
+	for i, in := range j.inputs {
+		wg.Add(1)
+		go func(index int, in input.Streamed) {
+			defer wg.Done()
+			for {
+				select {
+				case tran, open := <-in.TransactionChan():
+					if !open {
+						return
+					}
+					select {
+					case reads <- joinRead{index: index, tran: tran}:
+					case <-ctx.Done():
+						return
+					}
+				case <-ctx.Done():
+					return
+				}
+			}
+		}(i, in)
+	}
+
+	inputsClosed := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(inputsClosed)
+	}()
+
+	defer func() {
+		for _, in := range j.inputs {
+			in.CloseAsync()
+		}
+		for _, in := range j.inputs {
+			for err := in.WaitForClose(time.Second); err != nil; err = in.WaitForClose(time.Second) {
+			}
+		}
+		wg.Wait()
+
+		close(j.transactions)
+		j.shutSig.ShutdownComplete()
+	}()
+
+	tick := j.window / 10
+	if tick < time.Millisecond {
+		tick = time.Millisecond
+	}
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case read := <-reads:
+			if !j.add(ctx, read, time.Now()) {
+				return
+			}
+		case <-ticker.C:
+			if !j.expire(ctx, time.Now(), false) {
+				return
+			}
+		case <-inputsClosed:
+			_ = j.expire(ctx, time.Now(), true)
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// TransactionChan returns a transactions channel for consuming messages from
+// this input type.
+func (j *joinInput) TransactionChan() <-chan message.Transaction {
+	return j.transactions
+}
+
+// Connected returns a boolean indicating whether this input is currently
+// connected to its target.
+func (j *joinInput) Connected() bool {
+	for _, in := range j.inputs {
+		if !in.Connected() {
+			return false
+		}
+	}
+	return true
+}
+
+// CloseAsync shuts down the join input and stops processing requests.
+func (j *joinInput) CloseAsync() {
+	j.shutSig.CloseAtLeisure()
+}
+
+// WaitForClose blocks until the join input has closed down.
+func (j *joinInput) WaitForClose(timeout time.Duration) error {
+	select {
+	case <-j.shutSig.HasClosedChan():
+	case <-time.After(timeout):
+		return component.ErrTimeout
+	}
+	return nil
+}
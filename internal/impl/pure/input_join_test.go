@@ -0,0 +1,161 @@
+package pure_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/component/input"
+	"github.com/benthosdev/benthos/v4/internal/manager/mock"
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+func joinTestInput(t *testing.T, joinType, lateArrivals string) (input.Streamed, []chan message.Transaction) {
+	t.Helper()
+
+	mgr := mock.NewManager()
+
+	conf := input.NewConfig()
+	conf.Type = "join"
+	conf.Join.Key = `${! json("id") }`
+	conf.Join.Window = "100ms"
+	conf.Join.Type = joinType
+	conf.Join.LateArrivals = lateArrivals
+
+	var tChans []chan message.Transaction
+	for _, name := range []string{"a", "b"} {
+		tChan := make(chan message.Transaction)
+		mgr.Inputs[name] = &mock.Input{TChan: tChan}
+		tChans = append(tChans, tChan)
+
+		iConf := input.NewConfig()
+		iConf.Type = "resource"
+		iConf.Resource = name
+		conf.Join.Inputs = append(conf.Join.Inputs, iConf)
+	}
+
+	in, err := mgr.NewInput(conf)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		in.CloseAsync()
+		require.NoError(t, in.WaitForClose(time.Second*5))
+	})
+	return in, tChans
+}
+
+func joinTestSend(t *testing.T, tChan chan<- message.Transaction, content string, meta map[string]string) <-chan error {
+	t.Helper()
+
+	part := message.NewPart([]byte(content))
+	for k, v := range meta {
+		part.MetaSet(k, v)
+	}
+	msg := message.QuickBatch(nil)
+	msg.Append(part)
+
+	resChan := make(chan error, 1)
+	select {
+	case tChan <- message.NewTransaction(msg, resChan):
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out")
+	}
+	return resChan
+}
+
+func joinTestRead(t *testing.T, in input.Streamed) message.Transaction {
+	t.Helper()
+
+	select {
+	case tran, open := <-in.TransactionChan():
+		require.True(t, open)
+		return tran
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out")
+	}
+	return message.Transaction{}
+}
+
+func joinTestRes(t *testing.T, resChan <-chan error) error {
+	t.Helper()
+
+	select {
+	case err := <-resChan:
+		return err
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out")
+	}
+	return nil
+}
+
+func TestJoinInputInner(t *testing.T) {
+	in, tChans := joinTestInput(t, "inner", "drop")
+	ctx := context.Background()
+
+	resA := joinTestSend(t, tChans[0], `{"id":"1","a":"foo","shared":{"x":1}}`, map[string]string{"from": "a"})
+	resB := joinTestSend(t, tChans[1], `{"id":"1","b":"bar","shared":{"y":2}}`, map[string]string{"from": "b", "b_meta": "yes"})
+
+	tran := joinTestRead(t, in)
+	require.Equal(t, 1, tran.Payload.Len())
+	assert.JSONEq(t, `{"id":"1","a":"foo","b":"bar","shared":{"x":1,"y":2}}`, string(tran.Payload.Get(0).Get()))
+	assert.Equal(t, "matched", tran.Payload.Get(0).MetaGet("join_status"))
+	assert.Equal(t, "b", tran.Payload.Get(0).MetaGet("from"))
+	assert.Equal(t, "yes", tran.Payload.Get(0).MetaGet("b_meta"))
+
+	require.NoError(t, tran.Ack(ctx, errors.New("nope")))
+	assert.EqualError(t, joinTestRes(t, resA), "nope")
+	assert.EqualError(t, joinTestRes(t, resB), "nope")
+
+	// Unmatched keys are discarded once their window ends.
+	resA = joinTestSend(t, tChans[0], `{"id":"2","a":"foo"}`, nil)
+	assert.NoError(t, joinTestRes(t, resA))
+
+	// And late arrivals of the key are also discarded.
+	resB = joinTestSend(t, tChans[1], `{"id":"2","b":"bar"}`, nil)
+	assert.NoError(t, joinTestRes(t, resB))
+
+	// Messages that can't be joined are flagged as errors.
+	resA = joinTestSend(t, tChans[0], `not structured`, nil)
+	tran = joinTestRead(t, in)
+	assert.Equal(t, "not structured", string(tran.Payload.Get(0).Get()))
+	assert.Error(t, tran.Payload.Get(0).ErrorGet())
+	require.NoError(t, tran.Ack(ctx, nil))
+	assert.NoError(t, joinTestRes(t, resA))
+}
+
+func TestJoinInputLeft(t *testing.T) {
+	in, tChans := joinTestInput(t, "left", "emit")
+	ctx := context.Background()
+
+	// Incomplete keys without a left message are discarded.
+	resB := joinTestSend(t, tChans[1], `{"id":"1","b":"bar"}`, nil)
+	assert.NoError(t, joinTestRes(t, resB))
+
+	resA := joinTestSend(t, tChans[0], `{"id":"2","a":"foo"}`, nil)
+
+	tran := joinTestRead(t, in)
+	assert.JSONEq(t, `{"id":"2","a":"foo"}`, string(tran.Payload.Get(0).Get()))
+	assert.Equal(t, "partial", tran.Payload.Get(0).MetaGet("join_status"))
+	require.NoError(t, tran.Ack(ctx, nil))
+	assert.NoError(t, joinTestRes(t, resA))
+
+	resB = joinTestSend(t, tChans[1], `{"id":"2","b":"bar"}`, nil)
+
+	tran = joinTestRead(t, in)
+	assert.JSONEq(t, `{"id":"2","b":"bar"}`, string(tran.Payload.Get(0).Get()))
+	assert.Equal(t, "late", tran.Payload.Get(0).MetaGet("join_status"))
+	require.NoError(t, tran.Ack(ctx, nil))
+	assert.NoError(t, joinTestRes(t, resB))
+}
+
+func TestJoinInputConfigErrors(t *testing.T) {
+	conf := input.NewConfig()
+	conf.Type = "join"
+	conf.Join.Key = `${! json("id") }`
+
+	_, err := mock.NewManager().NewInput(conf)
+	assert.Error(t, err)
+}
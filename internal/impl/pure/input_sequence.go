@@ -33,6 +33,34 @@ that input gracefully terminates starts consuming from the next, and so on.`,
 This input is useful for consuming from inputs that have an explicit end but
 must not be consumed in parallel.`,
 		Examples: []docs.AnnotatedExample{
+			{
+				Title:   "Backfill Then Tail",
+				Summary: "A common pattern is to bootstrap a stream from an archive of historic data before tailing live data. Here we read archived orders from S3 and then consume new orders from Kafka, where the Kafka consumer group starts from an offset that precedes the end of the archive. Orders that were delivered from the archive are dropped when they are consumed again from Kafka.",
+				Config: `
+input:
+  sequence:
+    handover:
+      enabled: true
+      watermark: root = this.created_at.ts_unix()
+      key: ${! json("order_id") }
+      cache: handover
+    inputs:
+      - aws_s3:
+          bucket: orders-archive
+          prefix: orders/
+          codec: lines
+      - kafka:
+          addresses: [ TODO ]
+          topics: [ orders ]
+          consumer_group: orders
+          start_from_oldest: true
+
+cache_resources:
+  - label: handover
+    redis:
+      url: TODO
+`,
+			},
 			{
 				Title:   "End of Stream Message",
 				Summary: "A common use case for sequence might be to generate a message at the end of our main input. With the following config once the records within `./dataset.csv` are exhausted our final payload `{\"status\":\"finished\"}` will be routed through the pipeline.",
@@ -157,6 +185,25 @@ Each message must be structured (JSON or otherwise processed into a structured f
 					"The chosen strategy to use when a data join would otherwise result in a collision of field values. The strategy `array` means non-array colliding values are placed into an array and colliding arrays are merged. The strategy `replace` replaces old values with new values. The strategy `keep` keeps the old value.",
 				).HasOptions("array", "replace", "keep"),
 			).AtVersion("3.40.0").Advanced(),
+			docs.FieldObject(
+				"handover",
+				`Provides a way to backfill historic data from one or more inputs before handing over to a final live input, where the messages of the live input that overlap with the backfill are dropped.
+
+A watermark, such as an offset or a timestamp, is extracted from each message and the highest watermark of all delivered backfill messages becomes the boundary of the handover once the backfill inputs are exhausted. Messages of the live input with a watermark at or below the boundary are dropped when their key was stored by a delivered backfill message, or dropped unconditionally when a key isn't specified.
+
+The boundary is stored within the cache so that once a backfill has been completed subsequent runs consume from the live input straight away. A backfill that is interrupted before completion is started from the beginning on the next run.`,
+			).WithChildren(
+				docs.FieldBool("enabled", "Whether to hand over from the backfill inputs to the final live input."),
+				docs.FieldBloblang(
+					"watermark", "A [Bloblang mapping](/docs/guides/bloblang/about) that extracts a numerical watermark from each message. Messages without a watermark are always delivered.",
+					`root = this.updated_at.ts_unix()`,
+					`root = meta("kafka_offset").number()`,
+				),
+				docs.FieldString("key", "An optional key that identifies each message, which is stored for each delivered backfill message in order to deduplicate messages of the live input at or below the boundary.", `${! json("id") }`).IsInterpolated(),
+				docs.FieldString("cache", "A [cache resource](/docs/components/caches/about) to store the keys of backfill messages and the boundary within."),
+				docs.FieldString("ttl", "An optional TTL of the keys stored for backfill messages, which should exceed the period of the overlap.", "24h").Advanced(),
+				docs.FieldString("boundary_key", "The key under which the boundary is stored within the cache.").Advanced(),
+			).AtVersion("4.3.0").Advanced(),
 			docs.FieldInput("inputs", "An array of inputs to read from sequentially.").Array(),
 		).ChildDefaultAndTypesFromStruct(input.NewSequenceConfig()),
 		Categories: []string{
@@ -317,6 +364,9 @@ type sequenceInput struct {
 
 	joiner *messageJoiner
 
+	handover   *sequenceHandover
+	handedOver bool
+
 	mgr bundle.NewManagement
 	log log.Modular
 
@@ -358,6 +408,25 @@ func newSequenceInput(conf input.Config, mgr bundle.NewManagement, log log.Modul
 		return nil, fmt.Errorf("invalid sharded join config: %w", err)
 	}
 
+	if rdr.conf.Handover.Enabled {
+		if len(targets) < 2 {
+			return nil, errors.New("a handover requires at least two child inputs")
+		}
+		if rdr.joiner != nil {
+			return nil, errors.New("a handover cannot be combined with a sharded join")
+		}
+		if rdr.handover, err = newSequenceHandover(rdr.conf.Handover, mgr, log); err != nil {
+			return nil, fmt.Errorf("invalid handover config: %w", err)
+		}
+		if rdr.handedOver, err = rdr.handover.loadBoundary(context.Background()); err != nil {
+			return nil, fmt.Errorf("failed to read handover boundary: %w", err)
+		}
+		if rdr.handedOver {
+			log.Infof("Backfill inputs were exhausted by a previous run with a handover boundary of %v, consuming from the live input.\n", rdr.handover.boundary)
+			rdr.remaining = targets[len(targets)-1:]
+		}
+	}
+
 	if target, _, err := rdr.createNextTarget(); err != nil {
 		return nil, err
 	} else if target == nil {
@@ -435,6 +504,12 @@ func (r *sequenceInput) createNextTarget() (input.Streamed, bool, error) {
 	return target, final, err
 }
 
+func (r *sequenceInput) nextIsFinal() bool {
+	r.targetMut.Lock()
+	defer r.targetMut.Unlock()
+	return len(r.remaining) == 1
+}
+
 func (r *sequenceInput) resetTargets() {
 	r.targetMut.Lock()
 	r.remaining = r.spent
@@ -503,6 +578,25 @@ func (r *sequenceInput) loop() {
 
 runLoop:
 	for {
+		if target == nil && r.handover != nil && !r.handedOver && r.nextIsFinal() {
+			// Messages of the live input can only be deduplicated once all
+			// backfill messages have been delivered.
+			boundary, err := r.handover.complete(shutNowCtx)
+			if err != nil {
+				if shutNowCtx.Err() != nil {
+					return
+				}
+				r.log.Errorf("Failed to store handover boundary: %v\n", err)
+				select {
+				case <-time.After(time.Second):
+				case <-r.shutSig.CloseAtLeisureChan():
+					return
+				}
+				continue runLoop
+			}
+			r.log.Infof("Exhausted backfill inputs with a handover boundary of %v, consuming from the live input.\n", boundary)
+			r.handedOver = true
+		}
 		if target == nil {
 			var err error
 			if target, finalInSequence, err = r.createNextTarget(); err != nil {
@@ -560,6 +654,13 @@ runLoop:
 				return
 			}
 		} else {
+			if r.handover != nil {
+				if !finalInSequence {
+					tran = r.handover.trackBackfill(tran)
+				} else if tran, open = r.handover.filterLive(shutNowCtx, tran); !open {
+					continue runLoop
+				}
+			}
 			select {
 			case r.transactions <- tran:
 			case <-r.shutSig.CloseNowChan():
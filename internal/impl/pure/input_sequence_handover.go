@@ -0,0 +1,278 @@
+package pure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang/field"
+	"github.com/benthosdev/benthos/v4/internal/bloblang/mapping"
+	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
+	"github.com/benthosdev/benthos/v4/internal/bundle"
+	"github.com/benthosdev/benthos/v4/internal/component"
+	"github.com/benthosdev/benthos/v4/internal/component/cache"
+	"github.com/benthosdev/benthos/v4/internal/component/input"
+	"github.com/benthosdev/benthos/v4/internal/log"
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+// sequenceHandover tracks the watermarks of messages delivered from backfill
+// inputs, and drops the messages of the live input that overlap with them.
+type sequenceHandover struct {
+	watermark   *mapping.Executor
+	key         *field.Expression
+	cacheName   string
+	ttl         *time.Duration
+	boundaryKey string
+
+	mgr bundle.NewManagement
+	log log.Modular
+
+	pending  sync.WaitGroup
+	mut      sync.Mutex
+	boundary float64
+}
+
+func newSequenceHandover(conf input.SequenceHandoverConfig, mgr bundle.NewManagement, log log.Modular) (*sequenceHandover, error) {
+	if conf.Cache == "" {
+		return nil, errors.New("a cache must be specified")
+	}
+	if !mgr.ProbeCache(conf.Cache) {
+		return nil, fmt.Errorf("cache resource '%v' was not found", conf.Cache)
+	}
+	if conf.BoundaryKey == "" {
+		return nil, errors.New("the boundary key must not be empty")
+	}
+
+	h := &sequenceHandover{
+		cacheName:   conf.Cache,
+		boundaryKey: conf.BoundaryKey,
+		mgr:         mgr,
+		log:         log,
+		boundary:    math.Inf(-1),
+	}
+
+	var err error
+	if conf.Watermark == "" {
+		return nil, errors.New("a watermark mapping is required")
+	}
+	if h.watermark, err = mgr.BloblEnvironment().NewMapping(conf.Watermark); err != nil {
+		return nil, fmt.Errorf("failed to parse watermark mapping: %w", err)
+	}
+	if conf.Key != "" {
+		if h.key, err = mgr.BloblEnvironment().NewField(conf.Key); err != nil {
+			return nil, fmt.Errorf("failed to parse key expression: %w", err)
+		}
+	}
+	if conf.TTL != "" {
+		ttl, err := time.ParseDuration(conf.TTL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse ttl: %w", err)
+		}
+		h.ttl = &ttl
+	}
+	return h, nil
+}
+
+// loadBoundary reads the boundary of a previous handover from the cache, and
+// returns true if the backfill inputs have already been exhausted.
+func (h *sequenceHandover) loadBoundary(ctx context.Context) (bool, error) {
+	var value []byte
+	var err error
+	if cerr := h.mgr.AccessCache(ctx, h.cacheName, func(c cache.V1) {
+		value, err = c.Get(ctx, h.boundaryKey)
+	}); cerr != nil {
+		return false, cerr
+	}
+	if errors.Is(err, component.ErrKeyNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	boundary, err := strconv.ParseFloat(string(value), 64)
+	if err != nil {
+		return false, fmt.Errorf("failed to parse boundary: %w", err)
+	}
+
+	h.mut.Lock()
+	h.boundary = boundary
+	h.mut.Unlock()
+	return true, nil
+}
+
+// watermarkOf returns the watermark of a message within a batch, and false if
+// the watermark could not be extracted.
+func (h *sequenceHandover) watermarkOf(i int, msg *message.Batch) (float64, bool) {
+	v, err := h.watermark.Exec(query.FunctionContext{
+		Maps:     map[string]query.Function{},
+		Vars:     map[string]interface{}{},
+		Index:    i,
+		MsgBatch: msg,
+	}.WithValueFunc(func() *interface{} {
+		jObj, err := msg.Get(i).JSON()
+		if err != nil {
+			return nil
+		}
+		return &jObj
+	}))
+	if err == nil {
+		var wm float64
+		if wm, err = query.IGetNumber(v); err == nil {
+			return wm, true
+		}
+	}
+	h.log.Debugf("Failed to extract watermark from message: %v\n", err)
+	return 0, false
+}
+
+func (h *sequenceHandover) setKeys(ctx context.Context, keys []string) error {
+	var err error
+	if cerr := h.mgr.AccessCache(ctx, h.cacheName, func(c cache.V1) {
+		for _, k := range keys {
+			if err = c.Set(ctx, k, []byte{'t'}, h.ttl); err != nil {
+				return
+			}
+		}
+	}); cerr != nil {
+		return cerr
+	}
+	return err
+}
+
+// trackBackfill wraps a transaction of a backfill input so that the boundary
+// is raised to its watermarks, and its keys stored, once it is delivered.
+func (h *sequenceHandover) trackBackfill(tran message.Transaction) message.Transaction {
+	// Watermarks and keys are extracted before the messages are processed,
+	// which may modify them.
+	var highest float64
+	var hasWatermark bool
+	var keys []string
+	_ = tran.Payload.Iter(func(i int, p *message.Part) error {
+		if wm, ok := h.watermarkOf(i, tran.Payload); ok && (!hasWatermark || wm > highest) {
+			highest, hasWatermark = wm, true
+		}
+		if h.key != nil {
+			keys = append(keys, h.key.String(i, tran.Payload))
+		}
+		return nil
+	})
+
+	// Nacked transactions are resent by the input as new transactions, which
+	// are tracked again.
+	h.pending.Add(1)
+	tracked := message.NewTransactionFunc(tran.Payload, func(ctx context.Context, err error) error {
+		defer h.pending.Done()
+		if err == nil {
+			if kerr := h.setKeys(ctx, keys); kerr != nil {
+				h.log.Errorf("Failed to store handover keys: %v\n", kerr)
+			}
+			if hasWatermark {
+				h.mut.Lock()
+				if highest > h.boundary {
+					h.boundary = highest
+				}
+				h.mut.Unlock()
+			}
+		}
+		return tran.Ack(ctx, err)
+	})
+	return *tracked.WithContext(tran.Context())
+}
+
+// waitPending blocks until all tracked backfill transactions have been
+// delivered, or the context is cancelled.
+func (h *sequenceHandover) waitPending(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		h.pending.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// complete stores the boundary within the cache once all backfill
+// transactions have been delivered.
+func (h *sequenceHandover) complete(ctx context.Context) (float64, error) {
+	if err := h.waitPending(ctx); err != nil {
+		return 0, err
+	}
+
+	h.mut.Lock()
+	boundary := h.boundary
+	h.mut.Unlock()
+
+	var err error
+	if cerr := h.mgr.AccessCache(ctx, h.cacheName, func(c cache.V1) {
+		err = c.Set(ctx, h.boundaryKey, []byte(strconv.FormatFloat(boundary, 'f', -1, 64)), nil)
+	}); cerr != nil {
+		return 0, cerr
+	}
+	return boundary, err
+}
+
+// isDuplicate returns true if a message of the live input is at or below the
+// boundary and was already delivered by a backfill input.
+func (h *sequenceHandover) isDuplicate(ctx context.Context, i int, msg *message.Batch) bool {
+	wm, ok := h.watermarkOf(i, msg)
+	if !ok {
+		return false
+	}
+
+	h.mut.Lock()
+	boundary := h.boundary
+	h.mut.Unlock()
+	if wm > boundary {
+		return false
+	}
+	if h.key == nil {
+		return true
+	}
+
+	var err error
+	if cerr := h.mgr.AccessCache(ctx, h.cacheName, func(c cache.V1) {
+		_, err = c.Get(ctx, h.key.String(i, msg))
+	}); cerr != nil {
+		err = cerr
+	}
+	if err != nil && !errors.Is(err, component.ErrKeyNotFound) {
+		h.log.Errorf("Failed to check handover key: %v\n", err)
+	}
+	return err == nil
+}
+
+// filterLive removes the messages of a transaction from the live input that
+// overlap with the backfill, and returns false if no messages remain, in which
+// case the transaction has been acknowledged.
+func (h *sequenceHandover) filterLive(ctx context.Context, tran message.Transaction) (message.Transaction, bool) {
+	filtered := message.QuickBatch(nil)
+	_ = tran.Payload.Iter(func(i int, p *message.Part) error {
+		if !h.isDuplicate(ctx, i, tran.Payload) {
+			filtered.Append(p)
+		}
+		return nil
+	})
+
+	if filtered.Len() == tran.Payload.Len() {
+		return tran, true
+	}
+	if filtered.Len() == 0 {
+		if err := tran.Ack(ctx, nil); err != nil {
+			h.log.Errorf("Failed to acknowledge overlapping message: %v\n", err)
+		}
+		return tran, false
+	}
+
+	tracked := message.NewTransactionFunc(filtered, tran.Ack)
+	return *tracked.WithContext(tran.Context()), true
+}
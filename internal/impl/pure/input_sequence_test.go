@@ -465,3 +465,122 @@ func TestSequenceEarlyTermination(t *testing.T) {
 	rdr.CloseAsync()
 	assert.NoError(t, rdr.WaitForClose(time.Second*5))
 }
+
+func TestSequenceHandover(t *testing.T) {
+	tCtx, done := context.WithTimeout(context.Background(), time.Minute)
+	defer done()
+
+	t.Parallel()
+
+	tmpDir := t.TempDir()
+
+	writeFiles(t, tmpDir, map[string]string{
+		"backfill": `{"id":"a","ts":1}
+{"id":"b","ts":2}
+{"id":"c","ts":3}
+`,
+		"live": `{"id":"b","ts":2}
+{"id":"x","ts":2}
+{"id":"c","ts":3}
+{"id":"d","ts":4}
+`,
+	})
+
+	conf := input.NewConfig()
+	conf.Type = "sequence"
+	conf.Sequence.Handover.Enabled = true
+	conf.Sequence.Handover.Watermark = `root = this.ts`
+	conf.Sequence.Handover.Key = `${! json("id") }`
+	conf.Sequence.Handover.Cache = "foocache"
+
+	for _, k := range []string{"backfill", "live"} {
+		inConf := input.NewConfig()
+		inConf.Type = "file"
+		inConf.File.Paths = []string{filepath.Join(tmpDir, k)}
+		conf.Sequence.Inputs = append(conf.Sequence.Inputs, inConf)
+	}
+
+	mgr := bmock.NewManager()
+	mgr.Caches["foocache"] = map[string]bmock.CacheItem{}
+
+	readAll := func() (act []string) {
+		rdr, err := mgr.NewInput(conf)
+		require.NoError(t, err)
+
+	consumeLoop:
+		for {
+			select {
+			case tran, open := <-rdr.TransactionChan():
+				if !open {
+					break consumeLoop
+				}
+				act = append(act, string(tran.Payload.Get(0).Get()))
+				require.NoError(t, tran.Ack(tCtx, nil))
+			case <-time.After(time.Minute):
+				t.Fatalf("Failed to consume message after: %v", act)
+			}
+		}
+
+		rdr.CloseAsync()
+		assert.NoError(t, rdr.WaitForClose(time.Second))
+		return
+	}
+
+	assert.Equal(t, []string{
+		`{"id":"a","ts":1}`,
+		`{"id":"b","ts":2}`,
+		`{"id":"c","ts":3}`,
+		`{"id":"x","ts":2}`,
+		`{"id":"d","ts":4}`,
+	}, readAll())
+	assert.Equal(t, "3", mgr.Caches["foocache"]["sequence_handover_boundary"].Value)
+
+	// Once the backfill has completed subsequent runs only consume from the
+	// live input.
+	assert.Equal(t, []string{
+		`{"id":"x","ts":2}`,
+		`{"id":"d","ts":4}`,
+	}, readAll())
+
+	// Without a key all live messages at or below the boundary are dropped.
+	conf.Sequence.Handover.Key = ""
+	assert.Equal(t, []string{
+		`{"id":"d","ts":4}`,
+	}, readAll())
+}
+
+func TestSequenceHandoverConfigErrors(t *testing.T) {
+	mgr := bmock.NewManager()
+	mgr.Caches["foocache"] = map[string]bmock.CacheItem{}
+
+	newConf := func(inputs int) input.Config {
+		conf := input.NewConfig()
+		conf.Type = "sequence"
+		conf.Sequence.Handover.Enabled = true
+		conf.Sequence.Handover.Watermark = `root = this.ts`
+		conf.Sequence.Handover.Cache = "foocache"
+		for i := 0; i < inputs; i++ {
+			inConf := input.NewConfig()
+			inConf.Type = "generate"
+			inConf.Generate.Mapping = `root = {"ts":1}`
+			conf.Sequence.Inputs = append(conf.Sequence.Inputs, inConf)
+		}
+		return conf
+	}
+
+	_, err := mgr.NewInput(newConf(1))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "a handover requires at least two child inputs")
+
+	conf := newConf(2)
+	conf.Sequence.Handover.Cache = "barcache"
+	_, err = mgr.NewInput(conf)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "cache resource 'barcache' was not found")
+
+	conf = newConf(2)
+	conf.Sequence.Handover.Watermark = ""
+	_, err = mgr.NewInput(conf)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "a watermark mapping is required")
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"strconv"
+	"time"
 
 	"github.com/benthosdev/benthos/v4/internal/batch/policy"
 	"github.com/benthosdev/benthos/v4/internal/bundle"
@@ -64,6 +65,25 @@ Sometimes it is useful to disable the back pressure or retries of certain fan
 out outputs and instead drop messages that have failed or were blocked. In this
 case you can wrap outputs with a ` + "[`drop_on` output](/docs/components/outputs/drop_on)" + `.
 
+### Fan Out Barriers
+
+By default the fan out pattern only acknowledges a message once all outputs have
+confirmed delivery of it. The field ` + "`ack_quorum`" + ` can be used in order to
+instead acknowledge a message once a minimum number of outputs have confirmed
+delivery, while delivery to the remaining outputs continues in the background.
+
+The field ` + "`child_timeout`" + ` sets a period of time within which each output
+must accept and confirm delivery of a message, an output that fails to do so is
+considered a laggard. If a ` + "`dead_letter`" + ` output is configured then the
+messages of a laggard are routed to it, and a confirmed delivery to the dead
+letter output counts towards the quorum. Otherwise the laggard is considered to
+have failed, and if the quorum can no longer be reached the message is rejected
+upstream. Note that a laggard may still eventually deliver the messages, and
+therefore messages can be delivered to both a laggard and the dead letter output.
+
+These fields are also honoured when only a single output is configured, and an
+` + "`ack_quorum`" + ` that exceeds the number of outputs results in a config error.
+
 ### ` + "`fan_out_sequential`" + `
 
 Similar to the fan out pattern except outputs are written to sequentially,
@@ -91,6 +111,9 @@ outputs.`,
 				"fan_out", "fan_out_sequential", "round_robin", "greedy",
 			).HasDefault("fan_out"),
 			docs.FieldOutput("outputs", "A list of child outputs to broker.").Array().HasDefault([]interface{}{}),
+			docs.FieldInt("ack_quorum", "When using the `fan_out` pattern, the number of child outputs that must confirm delivery of a message before it is acknowledged upstream. Set to zero in order to require confirmation from all outputs. See [fan out barriers](#fan-out-barriers) for more information.").Advanced().HasDefault(0),
+			docs.FieldString("child_timeout", "When using the `fan_out` pattern, an optional period of time within which each child output must confirm delivery of a message, after which the output is considered a laggard. See [fan out barriers](#fan-out-barriers) for more information.", "10s", "1m").Advanced().HasDefault(""),
+			docs.FieldOutput("dead_letter", "When using the `fan_out` pattern, an optional output to route the messages of laggard child outputs to. See [fan out barriers](#fan-out-barriers) for more information.").Advanced().Optional(),
 			policy.FieldSpec(),
 		),
 		Categories: []string{
@@ -114,7 +137,18 @@ func newBroker(conf output.Config, mgr bundle.NewManagement, pipelines ...proces
 	if lOutputs <= 0 {
 		return nil, ErrBrokerNoOutputs
 	}
-	if lOutputs == 1 {
+
+	hasBarrier := conf.Broker.AckQuorum > 0 || conf.Broker.ChildTimeout != "" || conf.Broker.DeadLetter != nil
+	if hasBarrier && conf.Broker.Pattern != "fan_out" {
+		return nil, fmt.Errorf("fields ack_quorum, child_timeout and dead_letter are only supported by the fan_out pattern, not %v", conf.Broker.Pattern)
+	}
+	if conf.Broker.AckQuorum > lOutputs {
+		return nil, fmt.Errorf("ack_quorum of %v exceeds the number of outputs (%v)", conf.Broker.AckQuorum, lOutputs)
+	}
+
+	// A single output is used directly unless a barrier is required in order
+	// to enforce a child timeout or route laggards to a dead letter output.
+	if lOutputs == 1 && !hasBarrier {
 		b, err := mgr.NewOutput(outputConfs[0], pipelines...)
 		if err != nil {
 			return nil, err
@@ -161,7 +195,11 @@ func newBroker(conf output.Config, mgr bundle.NewManagement, pipelines ...proces
 	var b output.Streamed
 	switch conf.Broker.Pattern {
 	case "fan_out":
-		b, err = newFanOutOutputBroker(outputs)
+		if !hasBarrier {
+			b, err = newFanOutOutputBroker(outputs)
+			break
+		}
+		b, err = newFanOutBarrierFromConfig(conf.Broker, mgr, outputs)
 	case "fan_out_sequential":
 		b, err = newFanOutSequentialOutputBroker(outputs)
 	case "round_robin":
@@ -184,3 +222,26 @@ func newBroker(conf output.Config, mgr bundle.NewManagement, pipelines ...proces
 	}
 	return b, err
 }
+
+func newFanOutBarrierFromConfig(conf output.BrokerConfig, mgr bundle.NewManagement, outputs []output.Streamed) (output.Streamed, error) {
+	var childTimeout time.Duration
+	if conf.ChildTimeout != "" {
+		var err error
+		if childTimeout, err = time.ParseDuration(conf.ChildTimeout); err != nil {
+			return nil, fmt.Errorf("failed to parse child_timeout: %w", err)
+		}
+	}
+
+	var deadLetter output.Streamed
+	if conf.DeadLetter != nil {
+		var err error
+		if deadLetter, err = mgr.IntoPath("broker", "dead_letter").NewOutput(*conf.DeadLetter); err != nil {
+			return nil, err
+		}
+		if deadLetter, err = RetryOutputIndefinitely(mgr, deadLetter); err != nil {
+			return nil, err
+		}
+	}
+
+	return newFanOutBarrierOutputBroker(outputs, conf.AckQuorum, childTimeout, deadLetter)
+}
@@ -0,0 +1,253 @@
+package pure
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/internal/component"
+	"github.com/benthosdev/benthos/v4/internal/component/output"
+	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/shutdown"
+)
+
+// fanOutBarrier tracks the responses of child outputs to a single transaction
+// and acknowledges it upstream once a quorum of children have succeeded, or
+// once a quorum can no longer be reached.
+type fanOutBarrier struct {
+	ts     message.Transaction
+	quorum int
+	total  int
+	onDone func()
+
+	mut       sync.Mutex
+	succeeded int
+	failed    int
+	done      bool
+}
+
+func (b *fanOutBarrier) resolve(ctx context.Context, err error) error {
+	b.mut.Lock()
+	if b.done {
+		b.mut.Unlock()
+		return nil
+	}
+	if err == nil {
+		b.succeeded++
+	} else {
+		b.failed++
+	}
+
+	var ackErr error
+	switch {
+	case b.succeeded >= b.quorum:
+		ackErr = nil
+	case b.total-b.failed < b.quorum:
+		ackErr = err
+	default:
+		b.mut.Unlock()
+		return nil
+	}
+	b.done = true
+	b.mut.Unlock()
+
+	defer b.onDone()
+	return b.ts.Ack(ctx, ackErr)
+}
+
+//------------------------------------------------------------------------------
+
+// fanOutBarrierOutputBroker is a fan out broker that acknowledges upstream once
+// a quorum of child outputs have confirmed delivery. Children that fail to
+// accept or confirm delivery within a timeout are considered laggards, and
+// their copy of the messages is routed to an optional dead letter output.
+type fanOutBarrierOutputBroker struct {
+	transactions <-chan message.Transaction
+
+	quorum       int
+	childTimeout time.Duration
+
+	outputTSChans []chan message.Transaction
+	outputs       []output.Streamed
+
+	deadLetterTSChan chan message.Transaction
+	deadLetter       output.Streamed
+
+	shutSig *shutdown.Signaller
+}
+
+func newFanOutBarrierOutputBroker(outputs []output.Streamed, quorum int, childTimeout time.Duration, deadLetter output.Streamed) (*fanOutBarrierOutputBroker, error) {
+	if quorum <= 0 || quorum > len(outputs) {
+		quorum = len(outputs)
+	}
+
+	o := &fanOutBarrierOutputBroker{
+		quorum:       quorum,
+		childTimeout: childTimeout,
+		outputs:      outputs,
+		deadLetter:   deadLetter,
+		shutSig:      shutdown.NewSignaller(),
+	}
+
+	o.outputTSChans = make([]chan message.Transaction, len(o.outputs))
+	for i := range o.outputTSChans {
+		o.outputTSChans[i] = make(chan message.Transaction)
+		if err := o.outputs[i].Consume(o.outputTSChans[i]); err != nil {
+			return nil, err
+		}
+	}
+	if o.deadLetter != nil {
+		o.deadLetterTSChan = make(chan message.Transaction)
+		if err := o.deadLetter.Consume(o.deadLetterTSChan); err != nil {
+			return nil, err
+		}
+	}
+	return o, nil
+}
+
+func (o *fanOutBarrierOutputBroker) Consume(transactions <-chan message.Transaction) error {
+	if o.transactions != nil {
+		return component.ErrAlreadyStarted
+	}
+	o.transactions = transactions
+
+	go o.loop()
+	return nil
+}
+
+func (o *fanOutBarrierOutputBroker) Connected() bool {
+	for _, out := range o.outputs {
+		if !out.Connected() {
+			return false
+		}
+	}
+	return true
+}
+
+// laggard resolves the response of a child output that failed to accept or
+// confirm delivery in time, by routing its copy of the messages to the dead
+// letter output when one is configured.
+func (o *fanOutBarrierOutputBroker) laggard(ctx context.Context, b *fanOutBarrier, index int, msg *message.Batch) {
+	err := fmt.Errorf("output %v timed out", index)
+	if o.deadLetterTSChan == nil {
+		_ = b.resolve(ctx, err)
+		return
+	}
+
+	select {
+	case o.deadLetterTSChan <- message.NewTransactionFunc(msg.Copy(), b.resolve):
+	case <-o.shutSig.CloseAtLeisureChan():
+		_ = b.resolve(ctx, err)
+	}
+}
+
+func (o *fanOutBarrierOutputBroker) loop() {
+	ackInterruptChan := make(chan struct{})
+	var ackPending int64
+	var laggardsWG sync.WaitGroup
+
+	defer func() {
+		// Wait for pending acks to be resolved, or forceful termination
+	ackWaitLoop:
+		for atomic.LoadInt64(&ackPending) > 0 {
+			select {
+			case <-ackInterruptChan:
+			case <-time.After(time.Millisecond * 100):
+				// Just incase an interrupt doesn't arrive.
+			case <-o.shutSig.CloseAtLeisureChan():
+				break ackWaitLoop
+			}
+		}
+		laggardsWG.Wait()
+		for _, c := range o.outputTSChans {
+			close(c)
+		}
+		closeAllOutputs(o.outputs)
+		if o.deadLetter != nil {
+			close(o.deadLetterTSChan)
+			closeAllOutputs([]output.Streamed{o.deadLetter})
+		}
+		o.shutSig.ShutdownComplete()
+	}()
+
+	for {
+		var ts message.Transaction
+		var open bool
+		select {
+		case ts, open = <-o.transactions:
+			if !open {
+				return
+			}
+		case <-o.shutSig.CloseAtLeisureChan():
+			return
+		}
+
+		_ = atomic.AddInt64(&ackPending, 1)
+		b := &fanOutBarrier{
+			ts:     ts,
+			quorum: o.quorum,
+			total:  len(o.outputTSChans),
+			onDone: func() {
+				_ = atomic.AddInt64(&ackPending, -1)
+				select {
+				case ackInterruptChan <- struct{}{}:
+				default:
+				}
+			},
+		}
+
+		for target := range o.outputTSChans {
+			msgCopy, i := ts.Payload.Copy(), target
+
+			// Each child response is resolved exactly once, either by the child
+			// itself or by the laggard timeout, which begins as soon as we
+			// attempt to send to the child.
+			var resolved int32
+			var timer *time.Timer
+			var timedOutChan chan struct{}
+			if o.childTimeout > 0 {
+				timedOutChan = make(chan struct{})
+				laggardsWG.Add(1)
+				timer = time.AfterFunc(o.childTimeout, func() {
+					defer laggardsWG.Done()
+					close(timedOutChan)
+					if atomic.CompareAndSwapInt32(&resolved, 0, 1) {
+						o.laggard(context.Background(), b, i, msgCopy)
+					}
+				})
+			}
+
+			tran := message.NewTransactionFunc(msgCopy, func(ctx context.Context, err error) error {
+				if !atomic.CompareAndSwapInt32(&resolved, 0, 1) {
+					return nil
+				}
+				if timer != nil && timer.Stop() {
+					laggardsWG.Done()
+				}
+				return b.resolve(ctx, err)
+			})
+
+			select {
+			case o.outputTSChans[i] <- tran:
+			case <-timedOutChan:
+			case <-o.shutSig.CloseAtLeisureChan():
+				return
+			}
+		}
+	}
+}
+
+func (o *fanOutBarrierOutputBroker) CloseAsync() {
+	o.shutSig.CloseAtLeisure()
+}
+
+func (o *fanOutBarrierOutputBroker) WaitForClose(timeout time.Duration) error {
+	select {
+	case <-o.shutSig.HasClosedChan():
+	case <-time.After(timeout):
+		return component.ErrTimeout
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+package pure
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/component/output"
+	"github.com/benthosdev/benthos/v4/internal/manager/mock"
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+var _ output.Streamed = &fanOutBarrierOutputBroker{}
+
+func readMockTran(t *testing.T, o *mock.OutputChanneled) message.Transaction {
+	t.Helper()
+
+	select {
+	case ts := <-o.TChan:
+		return ts
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for broker propagate")
+	}
+	return message.Transaction{}
+}
+
+func readBrokerRes(t *testing.T, resChan <-chan error) error {
+	t.Helper()
+
+	select {
+	case res := <-resChan:
+		return res
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for broker response")
+	}
+	return nil
+}
+
+func TestFanOutBarrierQuorum(t *testing.T) {
+	tCtx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+
+	mockOutputs := []*mock.OutputChanneled{{}, {}, {}}
+	outputs := []output.Streamed{mockOutputs[0], mockOutputs[1], mockOutputs[2]}
+
+	readChan := make(chan message.Transaction)
+	resChan := make(chan error, 1)
+
+	oTM, err := newFanOutBarrierOutputBroker(outputs, 2, 0, nil)
+	require.NoError(t, err)
+	require.NoError(t, oTM.Consume(readChan))
+
+	select {
+	case readChan <- message.NewTransaction(message.QuickBatch([][]byte{[]byte("hello world")}), resChan):
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for broker send")
+	}
+
+	var trans []message.Transaction
+	for _, o := range mockOutputs {
+		ts := readMockTran(t, o)
+		assert.Equal(t, "hello world", string(ts.Payload.Get(0).Get()))
+		trans = append(trans, ts)
+	}
+
+	require.NoError(t, trans[0].Ack(tCtx, errors.New("nope")))
+	require.NoError(t, trans[1].Ack(tCtx, nil))
+	select {
+	case <-resChan:
+		t.Fatal("Unexpected response before quorum")
+	case <-time.After(time.Millisecond * 50):
+	}
+
+	require.NoError(t, trans[2].Ack(tCtx, nil))
+	require.NoError(t, readBrokerRes(t, resChan))
+
+	oTM.CloseAsync()
+	require.NoError(t, oTM.WaitForClose(time.Second*5))
+}
+
+func TestFanOutBarrierQuorumUnreachable(t *testing.T) {
+	tCtx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+
+	mockOutputs := []*mock.OutputChanneled{{}, {}, {}}
+	outputs := []output.Streamed{mockOutputs[0], mockOutputs[1], mockOutputs[2]}
+
+	readChan := make(chan message.Transaction)
+	resChan := make(chan error, 1)
+
+	oTM, err := newFanOutBarrierOutputBroker(outputs, 2, 0, nil)
+	require.NoError(t, err)
+	require.NoError(t, oTM.Consume(readChan))
+
+	select {
+	case readChan <- message.NewTransaction(message.QuickBatch([][]byte{[]byte("hello world")}), resChan):
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for broker send")
+	}
+
+	var trans []message.Transaction
+	for _, o := range mockOutputs {
+		trans = append(trans, readMockTran(t, o))
+	}
+
+	require.NoError(t, trans[0].Ack(tCtx, nil))
+	require.NoError(t, trans[1].Ack(tCtx, errors.New("nope")))
+	require.NoError(t, trans[2].Ack(tCtx, errors.New("also nope")))
+	require.EqualError(t, readBrokerRes(t, resChan), "also nope")
+
+	oTM.CloseAsync()
+	require.NoError(t, oTM.WaitForClose(time.Second*5))
+}
+
+func TestFanOutBarrierDeadLetter(t *testing.T) {
+	tCtx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+
+	mockOutputs := []*mock.OutputChanneled{{}, {}}
+	outputs := []output.Streamed{mockOutputs[0], mockOutputs[1]}
+	mockDeadLetter := &mock.OutputChanneled{}
+
+	readChan := make(chan message.Transaction)
+	resChan := make(chan error, 1)
+
+	oTM, err := newFanOutBarrierOutputBroker(outputs, 0, time.Millisecond*50, mockDeadLetter)
+	require.NoError(t, err)
+	require.NoError(t, oTM.Consume(readChan))
+
+	select {
+	case readChan <- message.NewTransaction(message.QuickBatch([][]byte{[]byte("hello world")}), resChan):
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for broker send")
+	}
+
+	// The second output never reads the message and is therefore a laggard.
+	firstTran := readMockTran(t, mockOutputs[0])
+	require.NoError(t, firstTran.Ack(tCtx, nil))
+
+	ts := readMockTran(t, mockDeadLetter)
+	assert.Equal(t, "hello world", string(ts.Payload.Get(0).Get()))
+	select {
+	case <-resChan:
+		t.Fatal("Unexpected response before dead letter delivery")
+	case <-time.After(time.Millisecond * 50):
+	}
+
+	require.NoError(t, ts.Ack(tCtx, nil))
+	require.NoError(t, readBrokerRes(t, resChan))
+
+	oTM.CloseAsync()
+	require.NoError(t, oTM.WaitForClose(time.Second*5))
+}
+
+func TestFanOutBarrierTimeout(t *testing.T) {
+	tCtx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+
+	mockOutputs := []*mock.OutputChanneled{{}, {}}
+	outputs := []output.Streamed{mockOutputs[0], mockOutputs[1]}
+
+	readChan := make(chan message.Transaction)
+	resChan := make(chan error, 1)
+
+	oTM, err := newFanOutBarrierOutputBroker(outputs, 0, time.Millisecond*50, nil)
+	require.NoError(t, err)
+	require.NoError(t, oTM.Consume(readChan))
+
+	select {
+	case readChan <- message.NewTransaction(message.QuickBatch([][]byte{[]byte("hello world")}), resChan):
+	case <-time.After(time.Second):
+		t.Fatal("Timed out waiting for broker send")
+	}
+
+	firstTran := readMockTran(t, mockOutputs[0])
+	require.NoError(t, firstTran.Ack(tCtx, nil))
+
+	// The second output accepts the message but never confirms delivery.
+	lagTran := readMockTran(t, mockOutputs[1])
+	require.EqualError(t, readBrokerRes(t, resChan), "output 1 timed out")
+
+	// A late confirmation is ignored.
+	require.NoError(t, lagTran.Ack(tCtx, nil))
+
+	oTM.CloseAsync()
+	require.NoError(t, oTM.WaitForClose(time.Second*5))
+}
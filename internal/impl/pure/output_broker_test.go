@@ -6,6 +6,9 @@ import (
 	"testing"
 	"time"
 
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
 	"github.com/benthosdev/benthos/v4/internal/bundle"
 	"github.com/benthosdev/benthos/v4/internal/component/output"
 	"github.com/benthosdev/benthos/v4/internal/component/processor"
@@ -268,3 +271,34 @@ func TestGreedyBroker(t *testing.T) {
 		}
 	}
 }
+
+func TestBrokerBarrierConfig(t *testing.T) {
+	dropConf := output.NewConfig()
+	dropConf.Type = "drop"
+
+	conf := output.NewConfig()
+	conf.Type = "broker"
+	conf.Broker.Pattern = "fan_out"
+	conf.Broker.ChildTimeout = "10s"
+	conf.Broker.Outputs = append(conf.Broker.Outputs, dropConf)
+
+	s, err := bundle.AllOutputs.Init(conf, bmock.NewManager())
+	require.NoError(t, err)
+	_, isBarrier := s.(*fanOutBarrierOutputBroker)
+	assert.True(t, isBarrier, "a single output should be wrapped with a barrier")
+	require.NoError(t, s.Consume(make(chan message.Transaction)))
+	s.CloseAsync()
+	require.NoError(t, s.WaitForClose(time.Second*5))
+
+	conf.Broker.ChildTimeout = ""
+	conf.Broker.AckQuorum = 2
+	_, err = bundle.AllOutputs.Init(conf, bmock.NewManager())
+	require.EqualError(t, err, "failed to init output <no label>: ack_quorum of 2 exceeds the number of outputs (1)")
+
+	conf.Broker.AckQuorum = 0
+	conf.Broker.Pattern = "round_robin"
+	conf.Broker.ChildTimeout = "10s"
+	_, err = bundle.AllOutputs.Init(conf, bmock.NewManager())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "only supported by the fan_out pattern")
+}
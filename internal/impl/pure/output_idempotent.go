@@ -0,0 +1,319 @@
+package pure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/internal/batch"
+	"github.com/benthosdev/benthos/v4/internal/bloblang/field"
+	"github.com/benthosdev/benthos/v4/internal/bundle"
+	"github.com/benthosdev/benthos/v4/internal/component"
+	"github.com/benthosdev/benthos/v4/internal/component/cache"
+	"github.com/benthosdev/benthos/v4/internal/component/output"
+	"github.com/benthosdev/benthos/v4/internal/component/output/processors"
+	"github.com/benthosdev/benthos/v4/internal/docs"
+	"github.com/benthosdev/benthos/v4/internal/log"
+	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/shutdown"
+)
+
+var (
+	idempotentPending   = []byte("pending")
+	idempotentDelivered = []byte("delivered")
+)
+
+func init() {
+	err := bundle.AllOutputs.Add(processors.WrapConstructor(func(c output.Config, nm bundle.NewManagement) (output.Streamed, error) {
+		if c.Idempotent.Output == nil {
+			return nil, errors.New("cannot create an idempotent output without a child")
+		}
+		wrapped, err := nm.NewOutput(*c.Idempotent.Output)
+		if err != nil {
+			return nil, err
+		}
+		return newIdempotentOutput(c.Idempotent, wrapped, nm)
+	}), docs.ComponentSpec{
+		Name:    "idempotent",
+		Summary: `Writes messages to a child output at most once for each idempotency key, where keys of delivered messages are recorded within a cache resource.`,
+		Description: `
+Before a batch is written to the child output the idempotency key of each message is looked up within the cache. Messages with keys that are already marked as delivered are acknowledged without being sent, and the keys of the remaining messages are recorded as pending. Once the child output acknowledges the messages their keys are marked as delivered, and the keys of messages that failed are removed so that they are attempted again.
+
+Provided the cache persists across restarts, such as a ` + "`redis`" + ` cache, this gives effectively-once delivery to sinks that are unable to deduplicate messages themselves, even when messages are redelivered by the input after a restart.
+
+### Delivery Guarantees
+
+Keys are only marked as delivered after the child output has acknowledged them, and therefore a crash between a message being written and the acknowledgement being recorded results in the message being delivered again. Messages that are in flight at the same time and share a key may also both be delivered. Cache errors when looking up keys result in the batch being rejected, and therefore at-least-once delivery is preserved.
+
+Caches must be configured as resources, for more information check out the [cache documentation here](/docs/components/caches/about).`,
+		Categories: []string{
+			"Utility",
+		},
+		Config: docs.FieldComponent().WithChildren(
+			docs.FieldString("cache", "The [`cache` resource](/docs/components/caches/about) to record idempotency keys within."),
+			docs.FieldString("key", "An interpolated string yielding the idempotency key of each message.", `${! meta("kafka_key") }`, `${! json("id") }`, `${! content().hash("xxhash64") }`).IsInterpolated(),
+			docs.FieldString("ttl", "An optional duration after which recorded keys expire, where supported by the cache. Keys must be retained for at least as long as duplicate messages are expected to arrive.", "24h", "168h"),
+			docs.FieldOutput("output", "A child output.").HasDefault(nil),
+		).ChildDefaultAndTypesFromStruct(output.NewIdempotentConfig()),
+		Examples: []docs.AnnotatedExample{
+			{
+				Title:   "Effectively-once HTTP Delivery",
+				Summary: "Here we forward orders consumed from Kafka to an HTTP endpoint that has no deduplication of its own. Orders redelivered after a restart or rebalance are skipped if they've already been sent within the last week.",
+				Config: `
+output:
+  idempotent:
+    cache: delivered_orders
+    key: ${! json("order_id") }
+    ttl: 168h
+    output:
+      http_client:
+        url: http://example.com/orders
+        verb: POST
+
+cache_resources:
+  - label: delivered_orders
+    redis:
+      url: tcp://localhost:6379
+      prefix: orders_
+`,
+			},
+		},
+	})
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type idempotentOutput struct {
+	log log.Modular
+	mgr bundle.NewManagement
+
+	cacheName string
+	key       *field.Expression
+	ttl       *time.Duration
+	wrapped   output.Streamed
+
+	transactionsIn  <-chan message.Transaction
+	transactionsOut chan message.Transaction
+
+	shutSig *shutdown.Signaller
+}
+
+func newIdempotentOutput(conf output.IdempotentConfig, wrapped output.Streamed, mgr bundle.NewManagement) (*idempotentOutput, error) {
+	if conf.Key == "" {
+		return nil, errors.New("idempotent key must not be empty")
+	}
+	key, err := mgr.BloblEnvironment().NewField(conf.Key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse key expression: %v", err)
+	}
+
+	if !mgr.ProbeCache(conf.Cache) {
+		return nil, fmt.Errorf("cache resource '%v' was not found", conf.Cache)
+	}
+
+	var ttl *time.Duration
+	if conf.TTL != "" {
+		tmp, err := time.ParseDuration(conf.TTL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse ttl duration: %w", err)
+		}
+		ttl = &tmp
+	}
+
+	return &idempotentOutput{
+		log:             mgr.Logger(),
+		mgr:             mgr,
+		cacheName:       conf.Cache,
+		key:             key,
+		ttl:             ttl,
+		wrapped:         wrapped,
+		transactionsOut: make(chan message.Transaction),
+		shutSig:         shutdown.NewSignaller(),
+	}, nil
+}
+
+// filter returns the parts of a batch that have not yet been delivered along
+// with their keys. Parts sharing a key within the batch are only sent once.
+func (o *idempotentOutput) filter(ctx context.Context, msg *message.Batch) (parts []*message.Part, keys []string, err error) {
+	seen := map[string]struct{}{}
+	if cerr := o.mgr.AccessCache(ctx, o.cacheName, func(c cache.V1) {
+		err = msg.Iter(func(i int, p *message.Part) error {
+			key := o.key.String(i, msg)
+			if _, exists := seen[key]; exists {
+				return nil
+			}
+			seen[key] = struct{}{}
+
+			v, gerr := c.Get(ctx, key)
+			if gerr == nil && string(v) == string(idempotentDelivered) {
+				return nil
+			}
+			if gerr != nil && !errors.Is(gerr, component.ErrKeyNotFound) {
+				return fmt.Errorf("failed to look up idempotency key: %w", gerr)
+			}
+			parts = append(parts, p)
+			keys = append(keys, key)
+			return nil
+		})
+		if err != nil || len(keys) == 0 {
+			return
+		}
+
+		items := make(map[string]cache.TTLItem, len(keys))
+		for _, key := range keys {
+			items[key] = cache.TTLItem{Value: idempotentPending, TTL: o.ttl}
+		}
+		if serr := c.SetMulti(ctx, items); serr != nil {
+			err = fmt.Errorf("failed to record pending idempotency keys: %w", serr)
+		}
+	}); cerr != nil {
+		err = cerr
+	}
+	return
+}
+
+// complete records the outcome of a delivery attempt, where keys of parts that
+// were delivered are marked as such and the keys of failed parts are removed.
+// Returns the error to acknowledge the source transaction with.
+func (o *idempotentOutput) complete(ctx context.Context, group *message.SortGroup, sourceMsg *message.Batch, parts []*message.Part, keys []string, err error) error {
+	failed := make([]bool, len(parts))
+	if err != nil {
+		failedIndexes := map[int]struct{}{}
+		bErr, ok := err.(*batch.Error)
+		if ok {
+			sourceErr := batch.NewError(sourceMsg, err)
+			bErr.WalkParts(func(i int, p *message.Part, e error) bool {
+				if e == nil {
+					return true
+				}
+				index := group.GetIndex(p)
+				if index == -1 {
+					ok = false
+					return false
+				}
+				failedIndexes[index] = struct{}{}
+				sourceErr.Failed(index, e)
+				return true
+			})
+			if ok {
+				err = sourceErr
+			}
+		}
+		for i, p := range parts {
+			if _, exists := failedIndexes[group.GetIndex(p)]; exists || !ok {
+				failed[i] = true
+			}
+		}
+	}
+
+	if cerr := o.mgr.AccessCache(ctx, o.cacheName, func(c cache.V1) {
+		delivered := map[string]cache.TTLItem{}
+		for i, key := range keys {
+			if failed[i] {
+				if derr := c.Delete(ctx, key); derr != nil {
+					o.log.Errorf("Failed to remove idempotency key of failed message: %v", derr)
+				}
+				continue
+			}
+			delivered[key] = cache.TTLItem{Value: idempotentDelivered, TTL: o.ttl}
+		}
+		if len(delivered) == 0 {
+			return
+		}
+		if serr := c.SetMulti(ctx, delivered); serr != nil {
+			o.log.Errorf("Failed to mark idempotency keys as delivered: %v", serr)
+		}
+	}); cerr != nil {
+		o.log.Errorf("Failed to access idempotency cache: %v", cerr)
+	}
+	return err
+}
+
+func (o *idempotentOutput) loop() {
+	defer func() {
+		close(o.transactionsOut)
+		o.wrapped.CloseAsync()
+		_ = o.wrapped.WaitForClose(shutdown.MaximumShutdownWait())
+		o.shutSig.ShutdownComplete()
+	}()
+
+	ctx, done := o.shutSig.CloseAtLeisureCtx(context.Background())
+	defer done()
+
+	for {
+		var ts message.Transaction
+		var open bool
+		select {
+		case ts, open = <-o.transactionsIn:
+			if !open {
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+
+		group, trackedMsg := message.NewSortGroup(ts.Payload)
+
+		parts, keys, err := o.filter(ctx, trackedMsg)
+		if err != nil {
+			o.log.Errorf("%v", err)
+			if aerr := ts.Ack(ctx, err); aerr != nil && ctx.Err() != nil {
+				return
+			}
+			continue
+		}
+		if len(parts) == 0 {
+			if aerr := ts.Ack(ctx, nil); aerr != nil && ctx.Err() != nil {
+				return
+			}
+			continue
+		}
+		if skipped := trackedMsg.Len() - len(parts); skipped > 0 {
+			o.log.Debugf("Skipping %v messages that have already been delivered", skipped)
+		}
+
+		sendMsg := message.QuickBatch(nil)
+		sendMsg.SetAll(parts)
+
+		select {
+		case o.transactionsOut <- message.NewTransactionFunc(sendMsg, func(ackCtx context.Context, err error) error {
+			return ts.Ack(ackCtx, o.complete(ackCtx, group, trackedMsg, parts, keys, err))
+		}):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (o *idempotentOutput) Consume(ts <-chan message.Transaction) error {
+	if o.transactionsIn != nil {
+		return component.ErrAlreadyStarted
+	}
+	if err := o.wrapped.Consume(o.transactionsOut); err != nil {
+		return err
+	}
+	o.transactionsIn = ts
+	go o.loop()
+	return nil
+}
+
+func (o *idempotentOutput) Connected() bool {
+	return o.wrapped.Connected()
+}
+
+func (o *idempotentOutput) CloseAsync() {
+	o.shutSig.CloseAtLeisure()
+}
+
+func (o *idempotentOutput) WaitForClose(timeout time.Duration) error {
+	select {
+	case <-o.shutSig.HasClosedChan():
+	case <-time.After(timeout):
+		return component.ErrTimeout
+	}
+	return nil
+}
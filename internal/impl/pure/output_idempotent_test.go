@@ -0,0 +1,152 @@
+package pure_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/batch"
+	"github.com/benthosdev/benthos/v4/internal/component/output"
+	"github.com/benthosdev/benthos/v4/internal/manager/mock"
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+func idempotentTestOutput(t *testing.T, mgr *mock.Manager) output.Streamed {
+	t.Helper()
+
+	childConf := output.NewConfig()
+	childConf.Type = "resource"
+	childConf.Resource = "foo"
+
+	conf := output.NewConfig()
+	conf.Type = "idempotent"
+	conf.Idempotent.Cache = "keys"
+	conf.Idempotent.Key = `${! json("id") }`
+	conf.Idempotent.Output = &childConf
+
+	o, err := mgr.NewOutput(conf)
+	require.NoError(t, err)
+	return o
+}
+
+func TestIdempotentOutputSkipsDelivered(t *testing.T) {
+	var outMut sync.Mutex
+	var outParts []string
+
+	mgr := mock.NewManager()
+	mgr.Caches["keys"] = map[string]mock.CacheItem{}
+	mgr.Outputs["foo"] = func(c context.Context, t message.Transaction) error {
+		outMut.Lock()
+		_ = t.Payload.Iter(func(i int, p *message.Part) error {
+			outParts = append(outParts, string(p.Get()))
+			return nil
+		})
+		outMut.Unlock()
+		return t.Ack(c, nil)
+	}
+
+	o := idempotentTestOutput(t, mgr)
+
+	tChan := make(chan message.Transaction)
+	require.NoError(t, o.Consume(tChan))
+
+	require.NoError(t, sendTestBatch(t, tChan, `{"id":"a"}`, `{"id":"b"}`, `{"id":"b"}`))
+	require.NoError(t, sendTestBatch(t, tChan, `{"id":"a"}`, `{"id":"c"}`))
+	require.NoError(t, sendTestBatch(t, tChan, `{"id":"c"}`))
+
+	outMut.Lock()
+	assert.Equal(t, []string{`{"id":"a"}`, `{"id":"b"}`, `{"id":"c"}`}, outParts)
+	outMut.Unlock()
+
+	assert.Equal(t, map[string]mock.CacheItem{
+		"a": {Value: "delivered"},
+		"b": {Value: "delivered"},
+		"c": {Value: "delivered"},
+	}, mgr.Caches["keys"])
+
+	o.CloseAsync()
+	require.NoError(t, o.WaitForClose(time.Second*5))
+}
+
+func TestIdempotentOutputFailures(t *testing.T) {
+	mgr := mock.NewManager()
+	mgr.Caches["keys"] = map[string]mock.CacheItem{
+		"a": {Value: "delivered"},
+	}
+	mgr.Outputs["foo"] = func(c context.Context, t message.Transaction) error {
+		bErr := batch.NewError(t.Payload, errors.New("nope"))
+		_ = t.Payload.Iter(func(i int, p *message.Part) error {
+			if string(p.Get()) == `{"id":"c"}` {
+				bErr.Failed(i, errors.New("nope"))
+			}
+			return nil
+		})
+		return t.Ack(c, bErr)
+	}
+
+	o := idempotentTestOutput(t, mgr)
+
+	tChan := make(chan message.Transaction)
+	require.NoError(t, o.Consume(tChan))
+
+	err := sendTestBatch(t, tChan, `{"id":"a"}`, `{"id":"b"}`, `{"id":"c"}`)
+	require.Error(t, err)
+
+	var bErr *batch.Error
+	require.True(t, errors.As(err, &bErr))
+
+	var failed []int
+	bErr.WalkParts(func(i int, p *message.Part, e error) bool {
+		if e != nil {
+			failed = append(failed, i)
+		}
+		return true
+	})
+	assert.Equal(t, []int{2}, failed)
+
+	assert.Equal(t, map[string]mock.CacheItem{
+		"a": {Value: "delivered"},
+		"b": {Value: "delivered"},
+	}, mgr.Caches["keys"])
+
+	o.CloseAsync()
+	require.NoError(t, o.WaitForClose(time.Second*5))
+}
+
+func TestIdempotentOutputConfigErrors(t *testing.T) {
+	mgr := mock.NewManager()
+	mgr.Caches["keys"] = map[string]mock.CacheItem{}
+
+	childConf := output.NewConfig()
+	childConf.Type = "drop"
+
+	conf := output.NewConfig()
+	conf.Type = "idempotent"
+	conf.Idempotent.Cache = "keys"
+	conf.Idempotent.Key = `${! json("id") }`
+
+	_, err := mgr.NewOutput(conf)
+	assert.Error(t, err)
+
+	conf.Idempotent.Output = &childConf
+	conf.Idempotent.Cache = "nope"
+	_, err = mgr.NewOutput(conf)
+	assert.Error(t, err)
+
+	conf.Idempotent.Cache = "keys"
+	conf.Idempotent.TTL = "nope"
+	_, err = mgr.NewOutput(conf)
+	assert.Error(t, err)
+
+	conf.Idempotent.TTL = "1h"
+	o, err := mgr.NewOutput(conf)
+	require.NoError(t, err)
+	require.NoError(t, o.Consume(make(chan message.Transaction)))
+	o.CloseAsync()
+	require.NoError(t, o.WaitForClose(time.Second*5))
+}
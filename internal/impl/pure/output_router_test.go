@@ -0,0 +1,216 @@
+package pure_test
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/component/output"
+	"github.com/benthosdev/benthos/v4/internal/manager/mock"
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+type routerTestSinks struct {
+	mut      sync.Mutex
+	received map[string][]string
+}
+
+func newRouterTestManager(names ...string) (*mock.Manager, *routerTestSinks) {
+	sinks := &routerTestSinks{received: map[string][]string{}}
+
+	mgr := mock.NewManager()
+	for _, name := range names {
+		name := name
+		mgr.Outputs[name] = func(c context.Context, t message.Transaction) error {
+			sinks.mut.Lock()
+			_ = t.Payload.Iter(func(i int, p *message.Part) error {
+				sinks.received[name] = append(sinks.received[name], string(p.Get()))
+				return nil
+			})
+			sinks.mut.Unlock()
+			return t.Ack(c, nil)
+		}
+	}
+	return mgr, sinks
+}
+
+func (s *routerTestSinks) get(name string) []string {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	res := append([]string{}, s.received[name]...)
+	sort.Strings(res)
+	return res
+}
+
+func routerTestConfig(route string, templates ...string) output.Config {
+	conf := output.NewConfig()
+	conf.Type = "router"
+	conf.Router.Route = route
+	for _, name := range templates {
+		tConf := output.NewConfig()
+		tConf.Type = "resource"
+		tConf.Resource = name + "_{{destination}}"
+		conf.Router.Templates[name] = tConf
+	}
+	return conf
+}
+
+func sendTestBatch(t *testing.T, tChan chan<- message.Transaction, parts ...string) error {
+	t.Helper()
+
+	var raw [][]byte
+	for _, p := range parts {
+		raw = append(raw, []byte(p))
+	}
+
+	resChan := make(chan error)
+	select {
+	case tChan <- message.NewTransaction(message.QuickBatch(raw), resChan):
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out")
+	}
+	select {
+	case err := <-resChan:
+		return err
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out")
+	}
+	return nil
+}
+
+func TestRouterOutputDestinations(t *testing.T) {
+	mgr, sinks := newRouterTestManager("foo_a", "foo_b", "bar_a")
+
+	o, err := mgr.NewOutput(routerTestConfig(`
+root = if this.drop == true { deleted() } else { this.dest }
+`, "foo"))
+	require.NoError(t, err)
+
+	tChan := make(chan message.Transaction)
+	require.NoError(t, o.Consume(tChan))
+
+	require.NoError(t, sendTestBatch(t, tChan,
+		`{"dest":"a","id":1}`,
+		`{"dest":"b","id":2}`,
+		`{"dest":"a","id":3}`,
+		`{"dest":"a","id":4,"drop":true}`,
+	))
+	require.NoError(t, sendTestBatch(t, tChan, `{"dest":"b","id":5}`))
+
+	assert.Equal(t, []string{`{"dest":"a","id":1}`, `{"dest":"a","id":3}`}, sinks.get("foo_a"))
+	assert.Equal(t, []string{`{"dest":"b","id":2}`, `{"dest":"b","id":5}`}, sinks.get("foo_b"))
+
+	o.CloseAsync()
+	require.NoError(t, o.WaitForClose(time.Second*5))
+}
+
+func TestRouterOutputMultipleTemplates(t *testing.T) {
+	mgr, sinks := newRouterTestManager("foo_a", "bar_a", "bar_b")
+
+	o, err := mgr.NewOutput(routerTestConfig(`
+root.template = this.kind
+root.destination = this.dest
+`, "foo", "bar"))
+	require.NoError(t, err)
+
+	tChan := make(chan message.Transaction)
+	require.NoError(t, o.Consume(tChan))
+
+	require.NoError(t, sendTestBatch(t, tChan,
+		`{"kind":"foo","dest":"a"}`,
+		`{"kind":"bar","dest":"a"}`,
+		`{"kind":"bar","dest":"b"}`,
+	))
+
+	assert.Equal(t, []string{`{"kind":"foo","dest":"a"}`}, sinks.get("foo_a"))
+	assert.Equal(t, []string{`{"kind":"bar","dest":"a"}`}, sinks.get("bar_a"))
+	assert.Equal(t, []string{`{"kind":"bar","dest":"b"}`}, sinks.get("bar_b"))
+
+	// Routes that fail to resolve reject the whole transaction.
+	require.Error(t, sendTestBatch(t, tChan,
+		`{"kind":"foo","dest":"a"}`,
+		`{"kind":"baz","dest":"a"}`,
+	))
+	require.Error(t, sendTestBatch(t, tChan, `{"dest":"a"}`))
+	assert.Equal(t, []string{`{"kind":"foo","dest":"a"}`}, sinks.get("foo_a"))
+
+	o.CloseAsync()
+	require.NoError(t, o.WaitForClose(time.Second*5))
+}
+
+func TestRouterOutputChildErrors(t *testing.T) {
+	mgr, sinks := newRouterTestManager("foo_a")
+	mgr.Outputs["foo_b"] = func(c context.Context, t message.Transaction) error {
+		return t.Ack(c, errors.New("nope"))
+	}
+
+	o, err := mgr.NewOutput(routerTestConfig(`root = this.dest`, "foo"))
+	require.NoError(t, err)
+
+	tChan := make(chan message.Transaction)
+	require.NoError(t, o.Consume(tChan))
+
+	require.Error(t, sendTestBatch(t, tChan, `{"dest":"a"}`, `{"dest":"b"}`))
+	assert.Equal(t, []string{`{"dest":"a"}`}, sinks.get("foo_a"))
+
+	// Outputs that cannot be created reject the whole transaction.
+	require.Error(t, sendTestBatch(t, tChan, `{"dest":"a"}`, `{"dest":"c"}`))
+	assert.Equal(t, []string{`{"dest":"a"}`}, sinks.get("foo_a"))
+
+	o.CloseAsync()
+	require.NoError(t, o.WaitForClose(time.Second*5))
+}
+
+func TestRouterOutputEviction(t *testing.T) {
+	mgr, sinks := newRouterTestManager("foo_a", "foo_b", "foo_c")
+
+	conf := routerTestConfig(`root = this.dest`, "foo")
+	conf.Router.MaxOutputs = 2
+	conf.Router.IdleTimeout = "50ms"
+
+	o, err := mgr.NewOutput(conf)
+	require.NoError(t, err)
+
+	tChan := make(chan message.Transaction)
+	require.NoError(t, o.Consume(tChan))
+
+	for _, dest := range []string{"a", "b", "c", "a"} {
+		require.NoError(t, sendTestBatch(t, tChan, `{"dest":"`+dest+`"}`))
+	}
+
+	assert.Equal(t, []string{`{"dest":"a"}`, `{"dest":"a"}`}, sinks.get("foo_a"))
+	assert.Equal(t, []string{`{"dest":"b"}`}, sinks.get("foo_b"))
+	assert.Equal(t, []string{`{"dest":"c"}`}, sinks.get("foo_c"))
+
+	// Once idle all outputs are closed, and are recreated on demand.
+	time.Sleep(time.Millisecond * 200)
+	require.NoError(t, sendTestBatch(t, tChan, `{"dest":"b"}`))
+	assert.Equal(t, []string{`{"dest":"b"}`, `{"dest":"b"}`}, sinks.get("foo_b"))
+
+	o.CloseAsync()
+	require.NoError(t, o.WaitForClose(time.Second*5))
+}
+
+func TestRouterOutputConfigErrors(t *testing.T) {
+	mgr := mock.NewManager()
+
+	_, err := mgr.NewOutput(routerTestConfig(``, "foo"))
+	assert.Error(t, err)
+
+	_, err = mgr.NewOutput(routerTestConfig(`root = this.dest`))
+	assert.Error(t, err)
+
+	_, err = mgr.NewOutput(routerTestConfig(`root = this.dest.`, "foo"))
+	assert.Error(t, err)
+
+	conf := routerTestConfig(`root = this.dest`, "foo")
+	conf.Router.IdleTimeout = "nope"
+	_, err = mgr.NewOutput(conf)
+	assert.Error(t, err)
+}
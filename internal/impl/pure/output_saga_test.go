@@ -0,0 +1,136 @@
+package pure_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/component/output"
+	"github.com/benthosdev/benthos/v4/internal/manager/mock"
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+type sagaTestSink struct {
+	mut    sync.Mutex
+	events []string
+	fail   map[string]bool
+}
+
+func (s *sagaTestSink) output(name string) func(context.Context, message.Transaction) error {
+	return func(c context.Context, t message.Transaction) error {
+		s.mut.Lock()
+		_ = t.Payload.Iter(func(i int, p *message.Part) error {
+			event := name + ":" + string(p.Get())
+			if step := p.MetaGet("saga_failed_step"); step != "" {
+				event += ":" + step + ":" + p.MetaGet("saga_error")
+			}
+			s.events = append(s.events, event)
+			return nil
+		})
+		fail := s.fail[name]
+		s.mut.Unlock()
+		if fail {
+			return t.Ack(c, errors.New(name+" failed"))
+		}
+		return t.Ack(c, nil)
+	}
+}
+
+func (s *sagaTestSink) reset() []string {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	events := s.events
+	s.events = nil
+	return events
+}
+
+func sagaTestOutput(t *testing.T, sink *sagaTestSink, steps ...[2]string) output.Streamed {
+	t.Helper()
+
+	mgr := mock.NewManager()
+
+	conf := output.NewConfig()
+	conf.Type = "saga"
+	for _, s := range steps {
+		step := output.NewSagaStepConfig()
+		step.Output.Type = "resource"
+		step.Output.Resource = s[0]
+		mgr.Outputs[s[0]] = sink.output(s[0])
+		if s[1] != "" {
+			compConf := output.NewConfig()
+			compConf.Type = "resource"
+			compConf.Resource = s[1]
+			mgr.Outputs[s[1]] = sink.output(s[1])
+			step.Compensate = &compConf
+		}
+		conf.Saga.Steps = append(conf.Saga.Steps, step)
+	}
+
+	o, err := mgr.NewOutput(conf)
+	require.NoError(t, err)
+	return o
+}
+
+func TestSagaOutputCompensation(t *testing.T) {
+	sink := &sagaTestSink{fail: map[string]bool{}}
+
+	o := sagaTestOutput(t, sink,
+		[2]string{"a", "undo_a"},
+		[2]string{"b", ""},
+		[2]string{"c", "undo_c"},
+		[2]string{"d", "undo_d"},
+	)
+
+	tChan := make(chan message.Transaction)
+	require.NoError(t, o.Consume(tChan))
+
+	require.NoError(t, sendTestBatch(t, tChan, "foo"))
+	assert.Equal(t, []string{"a:foo", "b:foo", "c:foo", "d:foo"}, sink.reset())
+
+	sink.mut.Lock()
+	sink.fail["d"] = true
+	sink.mut.Unlock()
+
+	require.EqualError(t, sendTestBatch(t, tChan, "bar"), "d failed")
+	assert.Equal(t, []string{
+		"a:bar", "b:bar", "c:bar", "d:bar",
+		"undo_c:bar:3:d failed",
+		"undo_a:bar:3:d failed",
+	}, sink.reset())
+
+	sink.mut.Lock()
+	sink.fail["a"] = true
+	sink.fail["undo_c"] = true
+	sink.mut.Unlock()
+
+	require.EqualError(t, sendTestBatch(t, tChan, "baz"), "a failed")
+	assert.Equal(t, []string{"a:baz"}, sink.reset())
+
+	sink.mut.Lock()
+	sink.fail["a"] = false
+	sink.mut.Unlock()
+
+	// Failed compensations do not prevent earlier steps being compensated.
+	require.EqualError(t, sendTestBatch(t, tChan, "buz"), "d failed")
+	assert.Equal(t, []string{
+		"a:buz", "b:buz", "c:buz", "d:buz",
+		"undo_c:buz:3:d failed",
+		"undo_a:buz:3:d failed",
+	}, sink.reset())
+
+	o.CloseAsync()
+	require.NoError(t, o.WaitForClose(time.Second*5))
+}
+
+func TestSagaOutputConfigErrors(t *testing.T) {
+	conf := output.NewConfig()
+	conf.Type = "saga"
+
+	_, err := mock.NewManager().NewOutput(conf)
+	assert.Error(t, err)
+}
@@ -12,8 +12,8 @@ import (
 	"github.com/Jeffail/gabs/v2"
 
 	"github.com/benthosdev/benthos/v4/internal/batch"
-	"github.com/benthosdev/benthos/v4/internal/bloblang/mapping"
 	"github.com/benthosdev/benthos/v4/internal/bundle"
+	"github.com/benthosdev/benthos/v4/internal/cel"
 	"github.com/benthosdev/benthos/v4/internal/component"
 	"github.com/benthosdev/benthos/v4/internal/component/output"
 	"github.com/benthosdev/benthos/v4/internal/component/output/processors"
@@ -90,6 +90,11 @@ behavior is false, which will drop the message.`,
 					`this.type == "foo"`,
 					`this.contents.urls.contains("https://benthos.dev/")`,
 				).HasDefault(""),
+				cel.FieldSpec(
+					"cel",
+					"A [CEL expression](https://github.com/google/cel-spec) that should return a boolean value indicating whether a message should be routed to the case output, which can be used instead of `check`.",
+					`this.type == "foo" && meta.topic.startsWith("events")`,
+				),
 				docs.FieldOutput(
 					"output", "An [output](/docs/components/outputs/about/) for messages that pass the check to be routed to.",
 				).HasDefault(map[string]interface{}{}),
@@ -193,7 +198,7 @@ type switchOutput struct {
 	strictMode    bool
 	outputTSChans []chan message.Transaction
 	outputs       []output.Streamed
-	checks        []*mapping.Executor
+	checks        []caseCheck
 	continues     []bool
 	fallthroughs  []bool
 
@@ -214,7 +219,7 @@ func newSwitchOutput(conf output.SwitchConfig, mgr bundle.NewManagement) (output
 	}
 	if lCases > 0 {
 		o.outputs = make([]output.Streamed, lCases)
-		o.checks = make([]*mapping.Executor, lCases)
+		o.checks = make([]caseCheck, lCases)
 		o.continues = make([]bool, lCases)
 		o.fallthroughs = make([]bool, lCases)
 	}
@@ -230,10 +235,8 @@ func newSwitchOutput(conf output.SwitchConfig, mgr bundle.NewManagement) (output
 				return nil, fmt.Errorf("failed to create case '%v' output type '%v': %v", i, cConf.Output.Type, err)
 			}
 		}
-		if len(cConf.Check) > 0 {
-			if o.checks[i], err = mgr.BloblEnvironment().NewMapping(cConf.Check); err != nil {
-				return nil, fmt.Errorf("failed to parse case '%v' check mapping: %v", i, err)
-			}
+		if o.checks[i], err = newCaseCheck(mgr, cConf.Check, cConf.CEL); err != nil {
+			return nil, fmt.Errorf("failed to parse case '%v' check mapping: %v", i, err)
 		}
 		o.continues[i] = cConf.Continue
 	}
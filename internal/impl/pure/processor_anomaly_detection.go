@@ -0,0 +1,408 @@
+package pure
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
+	"github.com/benthosdev/benthos/v4/public/bloblang"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func anomalyDetectionProcConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		Beta().
+		Categories("Utility").
+		Version("4.3.0").
+		Summary("Maintains running statistics of a numerical value per key and flags messages with values that deviate from them.").
+		Description(`
+For each message the value provided by the `+"`value_mapping`"+` is compared against an exponentially weighted moving average (EWMA) and standard deviation of the previous values of the same key, and the message is flagged as anomalous when its [z-score](https://en.wikipedia.org/wiki/Standard_score), the number of standard deviations it lies from the average, exceeds the `+"`z_threshold`"+`. The statistics of the key are then updated with the value, where the `+"`alpha`"+` determines how quickly they adapt to new values.
+
+With a `+"`mode`"+` of `+"`delta` or `rate`"+` the statistics are instead computed over the change in the value since the previous message of the same key, or that change per second, which makes it possible to detect sudden spikes or drops in counters and gauges that trend over time.
+
+This processor never drops or modifies the contents of messages, instead it adds the following metadata fields to each message once the value has been obtained:
+
+- `+"`anomaly`"+`: Either `+"`true` or `false`"+`.
+- `+"`anomaly_value`"+`: The value compared, which is the change in value when the mode is `+"`delta` or `rate`"+`.
+- `+"`anomaly_score`"+`: The z-score of the value.
+- `+"`anomaly_mean`"+`: The moving average of previous values.
+- `+"`anomaly_stddev`"+`: The moving standard deviation of previous values.
+
+The score, mean and standard deviation are only added once a key has seen `+"`min_samples`"+` values, and until then messages are never flagged. Anomalies can be routed with a `+"[`switch` output](/docs/components/outputs/switch)"+`, or counted with the metric `+"`anomalies_detected`"+` emitted by this processor.
+
+Messages where the value or timestamp cannot be obtained are flagged as having failed, and can be handled with [standard error handling patterns](/docs/configuration/error_handling).
+
+### Performance
+
+The statistics are held in memory by each instance of this processor, and are therefore lost on restart and not shared across pipeline threads. In order to compare all values of a key against the same statistics either run the pipeline with a single thread, or route messages of the same key to the same thread. The number of keys tracked is capped by `+"`max_keys`"+`, where the least recently seen key is evicted when the cap is reached.`).
+		Field(service.NewInterpolatedStringField("key").
+			Description("An interpolated string yielding the key that statistics are maintained for. When empty all messages share the same statistics.").
+			Example(`${! json("host") }`).
+			Example(`${! meta("kafka_key") }`).
+			Default("")).
+		Field(service.NewBloblangField("value_mapping").
+			Description("A [Bloblang mapping](/docs/guides/bloblang/about) applied to each message that provides the numerical value to observe.").
+			Example("root = this.latency_ms").
+			Example(`root = meta("queue_depth").number()`)).
+		Field(service.NewStringAnnotatedEnumField("mode", map[string]string{
+			"value": "Compare the value itself.",
+			"delta": "Compare the difference between the value and the previous value of the key.",
+			"rate":  "Compare the difference between the value and the previous value of the key per second.",
+		}).
+			Description("Determines what is compared against the statistics of a key.").
+			Default("value")).
+		Field(service.NewBloblangField("timestamp_mapping").
+			Description("An optional [Bloblang mapping](/docs/guides/bloblang/about) applied to each message that provides the event time of the message when the mode is `rate`. The timestamp value assigned to `root` must either be a numerical unix time in seconds (with up to nanosecond precision via decimals), or a string in ISO 8601 format. When empty the time at which the message is processed is used instead.").
+			Example("root = this.created_at").
+			Optional()).
+		Field(service.NewFloatField("alpha").
+			Description("The smoothing factor of the moving average and standard deviation, between 0 and 1, where higher values give more weight to recent values.").
+			Default(0.1)).
+		Field(service.NewFloatField("z_threshold").
+			Description("The z-score beyond which a value is flagged as anomalous.").
+			Default(3.0)).
+		Field(service.NewStringEnumField("direction", "both", "above", "below").
+			Description("Whether values are flagged when they deviate above the average, below it, or in both directions.").
+			Default("both").
+			Advanced()).
+		Field(service.NewIntField("min_samples").
+			Description("The number of values a key must have seen before its values can be flagged.").
+			Default(10).
+			Advanced()).
+		Field(service.NewBoolField("exclude_anomalies").
+			Description("Whether values flagged as anomalous are excluded from the statistics of a key, which prevents a sustained anomaly from becoming the new normal but also prevents the statistics from adapting to a genuine change in behaviour.").
+			Default(false).
+			Advanced()).
+		Field(service.NewIntField("max_keys").
+			Description("The maximum number of keys to maintain statistics for, after which the least recently seen key is evicted.").
+			Default(10000).
+			Advanced()).
+		Example("Latency Spikes", `
+Given a stream of request logs of the form:
+
+`+"```json"+`
+{"host":"foo","path":"/login","latency_ms":21}
+`+"```"+`
+
+We can flag requests with unusually high latencies for their host, and route them to a separate topic, with the following config:`, `
+pipeline:
+  processors:
+    - anomaly_detection:
+        key: ${! json("host") }
+        value_mapping: root = this.latency_ms
+        direction: above
+
+output:
+  switch:
+    cases:
+      - check: meta("anomaly") == "true"
+        output:
+          kafka:
+            addresses: [ localhost:9092 ]
+            topic: latency_alerts
+      - output:
+          kafka:
+            addresses: [ localhost:9092 ]
+            topic: requests
+`).
+		Example("Counter Rates", `
+In order to detect sudden changes in the rate at which a monotonic counter increases, such as the total bytes sent by an interface, we can compare the change in the counter per second:`, `
+pipeline:
+  processors:
+    - anomaly_detection:
+        key: ${! json("interface") }
+        value_mapping: root = this.bytes_sent
+        mode: rate
+        timestamp_mapping: root = this.collected_at
+        z_threshold: 4
+`)
+}
+
+func init() {
+	err := service.RegisterBatchProcessor(
+		"anomaly_detection", anomalyDetectionProcConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.BatchProcessor, error) {
+			return newAnomalyDetectionFromParsed(conf, mgr)
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// anomalyStats holds the running statistics of a key.
+type anomalyStats struct {
+	key      string
+	samples  int
+	mean     float64
+	variance float64
+
+	// The previous raw value and its time, used when comparing changes.
+	hasPrev  bool
+	prev     float64
+	prevTime time.Time
+}
+
+// update adds a value to the exponentially weighted moving average and
+// variance of the key.
+func (s *anomalyStats) update(alpha, v float64) {
+	if s.samples == 0 {
+		s.mean = v
+		s.samples++
+		return
+	}
+	diff := v - s.mean
+	incr := alpha * diff
+	s.mean += incr
+	s.variance = (1 - alpha) * (s.variance + diff*incr)
+	s.samples++
+}
+
+type anomalyDetection struct {
+	key              *service.InterpolatedString
+	valueMapping     *bloblang.Executor
+	tsMapping        *bloblang.Executor
+	mode             string
+	alpha            float64
+	zThreshold       float64
+	direction        string
+	minSamples       int
+	excludeAnomalies bool
+	maxKeys          int
+
+	log       *service.Logger
+	anomalies *service.MetricCounter
+	nowFn     func() time.Time
+
+	mut   sync.Mutex
+	keys  map[string]*list.Element
+	order *list.List
+}
+
+func newAnomalyDetectionFromParsed(conf *service.ParsedConfig, mgr *service.Resources) (*anomalyDetection, error) {
+	a := &anomalyDetection{
+		log:       mgr.Logger(),
+		anomalies: mgr.Metrics().NewCounter("anomalies_detected"),
+		nowFn:     time.Now,
+		keys:      map[string]*list.Element{},
+		order:     list.New(),
+	}
+
+	var err error
+	if a.key, err = conf.FieldInterpolatedString("key"); err != nil {
+		return nil, err
+	}
+	if a.valueMapping, err = conf.FieldBloblang("value_mapping"); err != nil {
+		return nil, err
+	}
+	if a.mode, err = conf.FieldString("mode"); err != nil {
+		return nil, err
+	}
+	if conf.Contains("timestamp_mapping") {
+		if a.mode != "rate" {
+			return nil, errors.New("a timestamp_mapping can only be set when the mode is rate")
+		}
+		if a.tsMapping, err = conf.FieldBloblang("timestamp_mapping"); err != nil {
+			return nil, err
+		}
+	}
+	if a.alpha, err = conf.FieldFloat("alpha"); err != nil {
+		return nil, err
+	}
+	if a.alpha <= 0 || a.alpha > 1 {
+		return nil, errors.New("alpha must be greater than 0 and no greater than 1")
+	}
+	if a.zThreshold, err = conf.FieldFloat("z_threshold"); err != nil {
+		return nil, err
+	}
+	if a.zThreshold <= 0 {
+		return nil, errors.New("z_threshold must be greater than 0")
+	}
+	if a.direction, err = conf.FieldString("direction"); err != nil {
+		return nil, err
+	}
+	if a.minSamples, err = conf.FieldInt("min_samples"); err != nil {
+		return nil, err
+	}
+	if a.minSamples < 2 {
+		return nil, errors.New("min_samples must be at least 2")
+	}
+	if a.excludeAnomalies, err = conf.FieldBool("exclude_anomalies"); err != nil {
+		return nil, err
+	}
+	if a.maxKeys, err = conf.FieldInt("max_keys"); err != nil {
+		return nil, err
+	}
+	if a.maxKeys < 1 {
+		return nil, errors.New("max_keys must be at least 1")
+	}
+	return a, nil
+}
+
+func (a *anomalyDetection) getValue(i int, batch service.MessageBatch) (float64, error) {
+	vMsg, err := batch.BloblangQuery(i, a.valueMapping)
+	if err != nil {
+		return 0, fmt.Errorf("value mapping failed: %w", err)
+	}
+	if vMsg == nil {
+		return 0, errors.New("value mapping resulted in a deleted message")
+	}
+
+	var v interface{}
+	if v, err = vMsg.AsStructured(); err != nil {
+		return 0, fmt.Errorf("unable to parse result of value mapping as structured value: %w", err)
+	}
+	f, err := query.IGetNumber(v)
+	if err != nil {
+		return 0, fmt.Errorf("unable to parse result of value mapping as number: %w", err)
+	}
+	return f, nil
+}
+
+func (a *anomalyDetection) getTimestamp(i int, batch service.MessageBatch) (time.Time, error) {
+	if a.tsMapping == nil {
+		return a.nowFn(), nil
+	}
+
+	tsValueMsg, err := batch.BloblangQuery(i, a.tsMapping)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("timestamp mapping failed: %w", err)
+	}
+	if tsValueMsg == nil {
+		return time.Time{}, errors.New("timestamp mapping resulted in a deleted message")
+	}
+
+	var tsValue interface{}
+	if tsValue, err = tsValueMsg.AsStructured(); err != nil {
+		if tsBytes, _ := tsValueMsg.AsBytes(); len(tsBytes) > 0 {
+			tsValue = string(tsBytes)
+			err = nil
+		}
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("unable to parse result of timestamp mapping as structured value: %w", err)
+	}
+
+	ts, err := query.IGetTimestamp(tsValue)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("unable to parse result of timestamp mapping as timestamp: %w", err)
+	}
+	return ts, nil
+}
+
+// statsFor returns the statistics of a key, creating them and evicting the
+// least recently seen key when necessary. Must be called whilst holding mut.
+func (a *anomalyDetection) statsFor(key string) *anomalyStats {
+	if e, exists := a.keys[key]; exists {
+		a.order.MoveToFront(e)
+		return e.Value.(*anomalyStats)
+	}
+	if a.order.Len() >= a.maxKeys {
+		oldest := a.order.Back()
+		a.order.Remove(oldest)
+		delete(a.keys, oldest.Value.(*anomalyStats).key)
+	}
+	s := &anomalyStats{key: key}
+	a.keys[key] = a.order.PushFront(s)
+	return s
+}
+
+// observe compares a raw value against the statistics of its key, flags the
+// message with the result, and then updates the statistics.
+func (a *anomalyDetection) observe(msg *service.Message, s *anomalyStats, raw float64, ts time.Time) {
+	v := raw
+	if a.mode != "value" {
+		hasPrev, prev, prevTime := s.hasPrev, s.prev, s.prevTime
+		s.hasPrev, s.prev, s.prevTime = true, raw, ts
+		if !hasPrev {
+			// The first value of a key has no change to compare.
+			return
+		}
+		if v = raw - prev; a.mode == "rate" {
+			elapsed := ts.Sub(prevTime).Seconds()
+			if elapsed <= 0 {
+				// A rate cannot be computed for values of the same or an
+				// earlier time, and so we only keep the latest value.
+				return
+			}
+			v /= elapsed
+		}
+	}
+	msg.MetaSet("anomaly_value", formatAnomalyFloat(v))
+
+	anomalous := false
+	if s.samples >= a.minSamples {
+		stddev := math.Sqrt(s.variance)
+
+		var score float64
+		switch {
+		case stddev > 0:
+			score = (v - s.mean) / stddev
+		case v > s.mean:
+			score = math.Inf(1)
+		case v < s.mean:
+			score = math.Inf(-1)
+		}
+
+		switch a.direction {
+		case "above":
+			anomalous = score > a.zThreshold
+		case "below":
+			anomalous = score < -a.zThreshold
+		default:
+			anomalous = math.Abs(score) > a.zThreshold
+		}
+
+		msg.MetaSet("anomaly_score", formatAnomalyFloat(score))
+		msg.MetaSet("anomaly_mean", formatAnomalyFloat(s.mean))
+		msg.MetaSet("anomaly_stddev", formatAnomalyFloat(stddev))
+	}
+	msg.MetaSet("anomaly", strconv.FormatBool(anomalous))
+
+	if anomalous {
+		a.anomalies.Incr(1)
+		if a.excludeAnomalies {
+			return
+		}
+	}
+	s.update(a.alpha, v)
+}
+
+func formatAnomalyFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func (a *anomalyDetection) ProcessBatch(ctx context.Context, batch service.MessageBatch) ([]service.MessageBatch, error) {
+	if len(batch) == 0 {
+		return nil, nil
+	}
+
+	a.mut.Lock()
+	defer a.mut.Unlock()
+
+	for i, msg := range batch {
+		v, err := a.getValue(i, batch)
+		var ts time.Time
+		if err == nil && a.mode == "rate" {
+			ts, err = a.getTimestamp(i, batch)
+		}
+		if err != nil {
+			a.log.Debugf("Failed to obtain value for anomaly detection: %v", err)
+			msg.SetError(err)
+			continue
+		}
+		a.observe(msg, a.statsFor(batch.InterpolatedString(i, a.key)), v, ts)
+	}
+	return []service.MessageBatch{batch}, nil
+}
+
+func (a *anomalyDetection) Close(ctx context.Context) error {
+	return nil
+}
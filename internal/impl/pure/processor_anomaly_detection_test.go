@@ -0,0 +1,209 @@
+package pure
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func newAnomalyDetectionFromYAML(t *testing.T, confStr string) *anomalyDetection {
+	t.Helper()
+
+	conf, err := anomalyDetectionProcConfig().ParseYAML(confStr, nil)
+	require.NoError(t, err)
+
+	proc, err := newAnomalyDetectionFromParsed(conf, service.MockResources())
+	require.NoError(t, err)
+	return proc
+}
+
+func anomalyMeta(t *testing.T, msg *service.Message, key string) string {
+	t.Helper()
+	v, _ := msg.MetaGet(key)
+	return v
+}
+
+func TestAnomalyDetectionValue(t *testing.T) {
+	proc := newAnomalyDetectionFromYAML(t, `
+key: ${! json("host") }
+value_mapping: root = this.latency
+min_samples: 5
+`)
+
+	var batch service.MessageBatch
+	for i := 0; i < 10; i++ {
+		batch = append(batch, service.NewMessage([]byte(fmt.Sprintf(`{"host":"foo","latency":%v}`, 9+(i%2)*2))))
+	}
+	batch = append(batch,
+		service.NewMessage([]byte(`{"host":"foo","latency":30}`)),
+		service.NewMessage([]byte(`{"host":"bar","latency":30}`)),
+		service.NewMessage([]byte(`{"host":"foo","latency":10}`)),
+	)
+
+	res, err := proc.ProcessBatch(context.Background(), batch)
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	require.Len(t, res[0], 13)
+
+	// The first values of a key are never flagged.
+	assert.Equal(t, "false", anomalyMeta(t, res[0][0], "anomaly"))
+	assert.Equal(t, "9", anomalyMeta(t, res[0][0], "anomaly_value"))
+	assert.Equal(t, "", anomalyMeta(t, res[0][0], "anomaly_score"))
+
+	assert.Equal(t, "false", anomalyMeta(t, res[0][9], "anomaly"))
+	assert.NotEqual(t, "", anomalyMeta(t, res[0][9], "anomaly_score"))
+
+	assert.Equal(t, "true", anomalyMeta(t, res[0][10], "anomaly"))
+	assert.Equal(t, "30", anomalyMeta(t, res[0][10], "anomaly_value"))
+
+	// Keys maintain separate statistics.
+	assert.Equal(t, "false", anomalyMeta(t, res[0][11], "anomaly"))
+	assert.Equal(t, "", anomalyMeta(t, res[0][11], "anomaly_score"))
+
+	assert.Equal(t, "false", anomalyMeta(t, res[0][12], "anomaly"))
+
+	// Statistics are kept across batches.
+	res, err = proc.ProcessBatch(context.Background(), service.MessageBatch{
+		service.NewMessage([]byte(`{"host":"foo","latency":100}`)),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "true", anomalyMeta(t, res[0][0], "anomaly"))
+}
+
+func TestAnomalyDetectionDirection(t *testing.T) {
+	for _, test := range []struct {
+		direction string
+		high, low string
+	}{
+		{direction: "both", high: "true", low: "true"},
+		{direction: "above", high: "true", low: "false"},
+		{direction: "below", high: "false", low: "true"},
+	} {
+		test := test
+		t.Run(test.direction, func(t *testing.T) {
+			proc := newAnomalyDetectionFromYAML(t, fmt.Sprintf(`
+value_mapping: root = this
+min_samples: 4
+direction: %v
+exclude_anomalies: true
+`, test.direction))
+
+			var batch service.MessageBatch
+			for _, v := range []string{"9", "11", "9", "11", "9", "11", "100", "-80"} {
+				batch = append(batch, service.NewMessage([]byte(v)))
+			}
+			res, err := proc.ProcessBatch(context.Background(), batch)
+			require.NoError(t, err)
+			assert.Equal(t, test.high, anomalyMeta(t, res[0][6], "anomaly"))
+			assert.Equal(t, test.low, anomalyMeta(t, res[0][7], "anomaly"))
+		})
+	}
+}
+
+func TestAnomalyDetectionRate(t *testing.T) {
+	proc := newAnomalyDetectionFromYAML(t, `
+key: ${! json("iface") }
+value_mapping: root = this.bytes
+mode: rate
+timestamp_mapping: root = this.ts
+min_samples: 4
+`)
+
+	var batch service.MessageBatch
+	for i := 0; i < 8; i++ {
+		batch = append(batch, service.NewMessage([]byte(fmt.Sprintf(`{"iface":"eth0","bytes":%v,"ts":%v}`, 1000+i*200+(i%2)*10, 100+i*2))))
+	}
+	batch = append(batch, service.NewMessage([]byte(`{"iface":"eth0","bytes":100000,"ts":118}`)))
+
+	res, err := proc.ProcessBatch(context.Background(), batch)
+	require.NoError(t, err)
+
+	// The first value of a key has no rate.
+	assert.Equal(t, "", anomalyMeta(t, res[0][0], "anomaly"))
+	assert.Equal(t, "", anomalyMeta(t, res[0][0], "anomaly_value"))
+
+	assert.Equal(t, "105", anomalyMeta(t, res[0][1], "anomaly_value"))
+	assert.Equal(t, "95", anomalyMeta(t, res[0][2], "anomaly_value"))
+	assert.Equal(t, "false", anomalyMeta(t, res[0][7], "anomaly"))
+	assert.Equal(t, "true", anomalyMeta(t, res[0][8], "anomaly"))
+}
+
+func TestAnomalyDetectionErrors(t *testing.T) {
+	proc := newAnomalyDetectionFromYAML(t, `
+value_mapping: root = this.value
+`)
+
+	res, err := proc.ProcessBatch(context.Background(), service.MessageBatch{
+		service.NewMessage([]byte(`{"value":"nope"}`)),
+		service.NewMessage([]byte(`{"value":5}`)),
+	})
+	require.NoError(t, err)
+	require.Len(t, res[0], 2)
+	assert.Error(t, res[0][0].GetError())
+	assert.Equal(t, "", anomalyMeta(t, res[0][0], "anomaly"))
+	assert.NoError(t, res[0][1].GetError())
+	assert.Equal(t, "false", anomalyMeta(t, res[0][1], "anomaly"))
+}
+
+func TestAnomalyDetectionMaxKeys(t *testing.T) {
+	proc := newAnomalyDetectionFromYAML(t, `
+key: ${! content() }
+value_mapping: root = 1
+max_keys: 2
+`)
+
+	for _, k := range []string{"a", "b", "a", "c"} {
+		_, err := proc.ProcessBatch(context.Background(), service.MessageBatch{
+			service.NewMessage([]byte(k)),
+		})
+		require.NoError(t, err)
+	}
+
+	assert.Len(t, proc.keys, 2)
+	assert.Contains(t, proc.keys, "a")
+	assert.Contains(t, proc.keys, "c")
+	assert.Equal(t, 2, proc.keys["a"].Value.(*anomalyStats).samples)
+}
+
+func TestAnomalyDetectionConfigErrors(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		config string
+		err    string
+	}{
+		{
+			name:   "bad alpha",
+			config: "value_mapping: root = this\nalpha: 0",
+			err:    "alpha must be greater than 0 and no greater than 1",
+		},
+		{
+			name:   "bad threshold",
+			config: "value_mapping: root = this\nz_threshold: -1",
+			err:    "z_threshold must be greater than 0",
+		},
+		{
+			name:   "bad min samples",
+			config: "value_mapping: root = this\nmin_samples: 1",
+			err:    "min_samples must be at least 2",
+		},
+		{
+			name:   "timestamp without rate",
+			config: "value_mapping: root = this\ntimestamp_mapping: root = now()",
+			err:    "a timestamp_mapping can only be set when the mode is rate",
+		},
+	} {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			conf, err := anomalyDetectionProcConfig().ParseYAML(test.config, nil)
+			require.NoError(t, err)
+
+			_, err = newAnomalyDetectionFromParsed(conf, service.MockResources())
+			require.EqualError(t, err, test.err)
+		})
+	}
+}
@@ -4,11 +4,14 @@ import (
 	"archive/tar"
 	"archive/zip"
 	"bytes"
+	"compress/gzip"
 	"context"
 	"fmt"
 	"os"
 	"time"
 
+	"github.com/linkedin/goavro/v2"
+
 	"github.com/benthosdev/benthos/v4/internal/batch"
 	"github.com/benthosdev/benthos/v4/internal/message"
 	"github.com/benthosdev/benthos/v4/public/service"
@@ -28,16 +31,32 @@ The functionality of this processor depends on being applied across messages tha
 		Field(service.NewStringAnnotatedEnumField("format", map[string]string{
 			`concatenate`: `Join the raw contents of each message into a single binary message.`,
 			`tar`:         `Archive messages to a unix standard tape archive.`,
+			`tar_gzip`:    `Archive messages to a unix standard tape archive compressed with gzip.`,
 			`zip`:         `Archive messages to a zip file.`,
 			`binary`:      `Archive messages to a [binary blob format](https://github.com/benthosdev/benthos/blob/main/internal/message/message.go#L96).`,
 			`lines`:       `Join the raw contents of each message and insert a line break between each one.`,
 			`json_array`:  `Attempt to parse each message as a JSON document and append the result to an array, which becomes the contents of the resulting message.`,
+			`avro_ocf`:    "Attempt to parse each message as a JSON document and write it as a record of an Avro object container file with the schema `avro_schema`.",
 		}).Description("The archiving format to apply.")).
 		Field(service.NewInterpolatedStringField("path").
 			Description("The path to set for each message in the archive (when applicable).").
 			Example("${!count(\"files\")}-${!timestamp_unix_nano()}.txt").
 			Example("${!meta(\"kafka_key\")}-${!json(\"id\")}.json").
 			Default("")).
+		Field(service.NewInterpolatedStringField("header").
+			Description("An optional header to write at the start of the archive with the formats `lines` and `concatenate`, resolved from the first message of the batch. With the format `lines` the header is written as a line of its own.").
+			Example(`{"batch_start":"${!timestamp_unix()}"}`).
+			Optional().
+			Advanced()).
+		Field(service.NewInterpolatedStringField("footer").
+			Description("An optional footer to write at the end of the archive with the formats `lines` and `concatenate`, resolved from the last message of the batch. With the format `lines` the footer is written as a line of its own.").
+			Example(`{"batch_count":${!batch_size()}}`).
+			Optional().
+			Advanced()).
+		Field(service.NewStringField("avro_schema").
+			Description("The Avro schema of the records of the format `avro_ocf`, where each message must be a JSON document in the [Avro JSON encoding](https://avro.apache.org/docs/current/spec.html#json_encoding) of the schema.").
+			Optional().
+			Advanced()).
 		Example("Tar Archive", `
 If we had JSON messages in a batch each of the form:
 
@@ -52,6 +71,16 @@ pipeline:
     - archive:
         format: tar
         path: ${!json("doc.id")}.json
+`).
+		Example("Newline Delimited JSON with Headers", `
+In order to write a batch of JSON documents as a newline delimited file with a summary line at the end, which can then be written to object storage by an output, our config might look like this:`, `
+pipeline:
+  processors:
+    - archive:
+        format: lines
+        footer: '{"count":${!batch_size()}}'
+    - compress:
+        algorithm: gzip
 `)
 }
 
@@ -146,31 +175,117 @@ func binaryArchive(hFunc headerFunc, msg service.MessageBatch) (*service.Message
 	return newPart, nil
 }
 
-func linesArchive(hFunc headerFunc, msg service.MessageBatch) (*service.Message, error) {
-	tmpParts := make([][]byte, len(msg))
-	for i, part := range msg {
-		var err error
-		if tmpParts[i], err = part.AsBytes(); err != nil {
-			return nil, err
-		}
+func tarGzipArchive(hFunc headerFunc, msg service.MessageBatch) (*service.Message, error) {
+	newPart, err := tarArchive(hFunc, msg)
+	if err != nil {
+		return nil, err
 	}
-	newPart := msg[0].Copy()
-	newPart.SetBytes(bytes.Join(tmpParts, []byte("\n")))
+	tarBytes, err := newPart.AsBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	buf := &bytes.Buffer{}
+	zw := gzip.NewWriter(buf)
+	if _, err := zw.Write(tarBytes); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	newPart.SetBytes(buf.Bytes())
 	return newPart, nil
 }
 
-func concatenateArchive(hFunc headerFunc, msg service.MessageBatch) (*service.Message, error) {
-	var buf bytes.Buffer
-	for _, part := range msg {
-		pBytes, err := part.AsBytes()
+// headerFooter returns the resolved header and footer of a batch, which are
+// nil when not configured.
+func (o archiveOpts) headerFooter(msg service.MessageBatch) (header, footer []byte) {
+	if o.header != nil {
+		header = []byte(msg.InterpolatedString(0, o.header))
+	}
+	if o.footer != nil {
+		footer = []byte(msg.InterpolatedString(len(msg)-1, o.footer))
+	}
+	return
+}
+
+func linesArchiver(opts archiveOpts) archiveFunc {
+	return func(hFunc headerFunc, msg service.MessageBatch) (*service.Message, error) {
+		header, footer := opts.headerFooter(msg)
+
+		tmpParts := make([][]byte, 0, len(msg)+2)
+		if header != nil {
+			tmpParts = append(tmpParts, header)
+		}
+		for _, part := range msg {
+			pBytes, err := part.AsBytes()
+			if err != nil {
+				return nil, err
+			}
+			tmpParts = append(tmpParts, pBytes)
+		}
+		if footer != nil {
+			tmpParts = append(tmpParts, footer)
+		}
+		newPart := msg[0].Copy()
+		newPart.SetBytes(bytes.Join(tmpParts, []byte("\n")))
+		return newPart, nil
+	}
+}
+
+func concatenateArchiver(opts archiveOpts) archiveFunc {
+	return func(hFunc headerFunc, msg service.MessageBatch) (*service.Message, error) {
+		header, footer := opts.headerFooter(msg)
+
+		var buf bytes.Buffer
+		_, _ = buf.Write(header)
+		for _, part := range msg {
+			pBytes, err := part.AsBytes()
+			if err != nil {
+				return nil, err
+			}
+			_, _ = buf.Write(pBytes)
+		}
+		_, _ = buf.Write(footer)
+		newPart := msg[0].Copy()
+		newPart.SetBytes(buf.Bytes())
+		return newPart, nil
+	}
+}
+
+func avroOCFArchiver(opts archiveOpts) (archiveFunc, error) {
+	if opts.avroSchema == "" {
+		return nil, fmt.Errorf("field avro_schema is required by the format avro_ocf")
+	}
+	codec, err := goavro.NewCodec(opts.avroSchema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse avro_schema: %v", err)
+	}
+	return func(hFunc headerFunc, msg service.MessageBatch) (*service.Message, error) {
+		buf := &bytes.Buffer{}
+		w, err := goavro.NewOCFWriter(goavro.OCFConfig{W: buf, Codec: codec})
 		if err != nil {
 			return nil, err
 		}
-		_, _ = buf.Write(pBytes)
-	}
-	newPart := msg[0].Copy()
-	newPart.SetBytes(buf.Bytes())
-	return newPart, nil
+
+		records := make([]interface{}, len(msg))
+		for i, part := range msg {
+			pBytes, err := part.AsBytes()
+			if err != nil {
+				return nil, err
+			}
+			if records[i], _, err = codec.NativeFromTextual(pBytes); err != nil {
+				return nil, fmt.Errorf("failed to convert JSON to Avro schema: %v", err)
+			}
+		}
+		if err := w.Append(records); err != nil {
+			return nil, err
+		}
+
+		newPart := msg[0].Copy()
+		newPart.SetBytes(buf.Bytes())
+		return newPart, nil
+	}, nil
 }
 
 func jsonArrayArchive(hFunc headerFunc, msg service.MessageBatch) (*service.Message, error) {
@@ -189,20 +304,31 @@ func jsonArrayArchive(hFunc headerFunc, msg service.MessageBatch) (*service.Mess
 	return newPart, nil
 }
 
-func strToArchiver(str string) (archiveFunc, error) {
+// archiveOpts contains the fields that only apply to some archive formats.
+type archiveOpts struct {
+	header     *service.InterpolatedString
+	footer     *service.InterpolatedString
+	avroSchema string
+}
+
+func strToArchiver(str string, opts archiveOpts) (archiveFunc, error) {
 	switch str {
 	case "tar":
 		return tarArchive, nil
+	case "tar_gzip":
+		return tarGzipArchive, nil
 	case "zip":
 		return zipArchive, nil
 	case "binary":
 		return binaryArchive, nil
 	case "lines":
-		return linesArchive, nil
+		return linesArchiver(opts), nil
 	case "json_array":
 		return jsonArrayArchive, nil
 	case "concatenate":
-		return concatenateArchive, nil
+		return concatenateArchiver(opts), nil
+	case "avro_ocf":
+		return avroOCFArchiver(opts)
 	}
 	return nil, fmt.Errorf("archive format not recognised: %v", str)
 }
@@ -224,11 +350,28 @@ func newArchiveFromParsed(conf *service.ParsedConfig, mgr *service.Resources) (*
 	if err != nil {
 		return nil, err
 	}
-	return newArchive(mgr, formatStr, pathStr)
+
+	var opts archiveOpts
+	if conf.Contains("header") {
+		if opts.header, err = conf.FieldInterpolatedString("header"); err != nil {
+			return nil, err
+		}
+	}
+	if conf.Contains("footer") {
+		if opts.footer, err = conf.FieldInterpolatedString("footer"); err != nil {
+			return nil, err
+		}
+	}
+	if conf.Contains("avro_schema") {
+		if opts.avroSchema, err = conf.FieldString("avro_schema"); err != nil {
+			return nil, err
+		}
+	}
+	return newArchive(mgr, formatStr, pathStr, opts)
 }
 
-func newArchive(nm *service.Resources, format string, path *service.InterpolatedString) (*archive, error) {
-	archiver, err := strToArchiver(format)
+func newArchive(nm *service.Resources, format string, path *service.InterpolatedString, opts archiveOpts) (*archive, error) {
+	archiver, err := strToArchiver(format, opts)
 	if err != nil {
 		return nil, err
 	}
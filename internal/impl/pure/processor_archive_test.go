@@ -262,3 +262,107 @@ format: json_array
 	require.NoError(t, err)
 	require.Len(t, batches, 0)
 }
+
+func TestArchiveLinesHeaderFooter(t *testing.T) {
+	conf, err := archiveProcConfig().ParseYAML(`
+format: lines
+header: 'start ${!content()}'
+footer: '{"count":${!batch_size()},"last":"${!content()}"}'
+`, nil)
+	require.NoError(t, err)
+
+	proc, err := newArchiveFromParsed(conf, service.MockResources())
+	require.NoError(t, err)
+
+	batches, err := proc.ProcessBatch(context.Background(), service.MessageBatch{
+		service.NewMessage([]byte(`foo`)),
+		service.NewMessage([]byte(`bar`)),
+		service.NewMessage([]byte(`baz`)),
+	})
+	require.NoError(t, err)
+	require.Len(t, batches, 1)
+	require.Len(t, batches[0], 1)
+
+	bBytes, err := batches[0][0].AsBytes()
+	require.NoError(t, err)
+	assert.Equal(t, `start foo
+foo
+bar
+baz
+{"count":3,"last":"baz"}`, string(bBytes))
+}
+
+func TestArchiveRoundTrip(t *testing.T) {
+	tests := map[string]string{
+		"tar_gzip": `
+format: tar_gzip
+path: 'foo-${!batch_index()}'
+`,
+		"avro_ocf": `
+format: avro_ocf
+avro_schema: '{"type":"record","name":"doc","fields":[{"name":"id","type":"string"},{"name":"n","type":["null","long"]}]}'
+`,
+	}
+
+	for format, archiveConf := range tests {
+		format := format
+		t.Run(format, func(t *testing.T) {
+			conf, err := archiveProcConfig().ParseYAML(archiveConf, nil)
+			require.NoError(t, err)
+
+			proc, err := newArchiveFromParsed(conf, service.MockResources())
+			require.NoError(t, err)
+
+			exp := []string{
+				`{"id":"foo","n":{"long":1}}`,
+				`{"id":"bar","n":null}`,
+			}
+			var msg service.MessageBatch
+			for _, e := range exp {
+				msg = append(msg, service.NewMessage([]byte(e)))
+			}
+
+			batches, err := proc.ProcessBatch(context.Background(), msg)
+			require.NoError(t, err)
+			require.Len(t, batches, 1)
+			require.Len(t, batches[0], 1)
+
+			unarchive, err := newUnarchive(service.MockResources(), format)
+			require.NoError(t, err)
+
+			res, err := unarchive.Process(context.Background(), batches[0][0])
+			require.NoError(t, err)
+			require.Len(t, res, len(exp))
+			for i, e := range exp {
+				bBytes, err := res[i].AsBytes()
+				require.NoError(t, err)
+				// Avro records are decoded as maps, so fields aren't ordered.
+				assert.JSONEq(t, e, string(bBytes))
+			}
+		})
+	}
+}
+
+func TestArchiveAvroOCFErrors(t *testing.T) {
+	conf, err := archiveProcConfig().ParseYAML(`
+format: avro_ocf
+`, nil)
+	require.NoError(t, err)
+
+	_, err = newArchiveFromParsed(conf, service.MockResources())
+	assert.EqualError(t, err, "field avro_schema is required by the format avro_ocf")
+
+	conf, err = archiveProcConfig().ParseYAML(`
+format: avro_ocf
+avro_schema: '{"type":"record","name":"doc","fields":[{"name":"id","type":"string"}]}'
+`, nil)
+	require.NoError(t, err)
+
+	proc, err := newArchiveFromParsed(conf, service.MockResources())
+	require.NoError(t, err)
+
+	_, err = proc.ProcessBatch(context.Background(), service.MessageBatch{
+		service.NewMessage([]byte(`{"id":5}`)),
+	})
+	assert.Error(t, err)
+}
@@ -150,7 +150,7 @@ func (b *bloblangProc) ProcessBatch(ctx context.Context, spans []*tracing.Span,
 		p, err := b.exec.MapPart(i, msg)
 		if err != nil {
 			p = part.Copy()
-			b.log.Errorf("%v\n", err)
+			log.ForMessage(b.log, part).Errorf("%v\n", err)
 			processor.MarkErr(p, spans[i], err)
 		}
 		if p != nil {
@@ -7,6 +7,7 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/cenkalti/backoff/v4"
 	"go.opentelemetry.io/otel/trace"
 
 	"github.com/benthosdev/benthos/v4/internal/bloblang/mapping"
@@ -17,10 +18,17 @@ import (
 	"github.com/benthosdev/benthos/v4/internal/docs"
 	"github.com/benthosdev/benthos/v4/internal/log"
 	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/old/util/retries"
 	"github.com/benthosdev/benthos/v4/internal/tracing"
 )
 
 var branchFields = docs.FieldSpecs{
+	docs.FieldBloblang(
+		"check",
+		"An optional [Bloblang query](/docs/guides/bloblang/about) that should return a boolean value indicating whether a message should be processed by the branch. Messages that fail the check skip the branch and remain unchanged, and if the query throws an error the message will be flagged as having failed the branch.",
+		`this.type == "foo"`,
+		`meta("kafka_topic") != "internal"`,
+	).HasDefault("").Advanced(),
 	docs.FieldBloblang(
 		"request_map",
 		"A [Bloblang mapping](/docs/guides/bloblang/about) that describes how to create a request payload suitable for the child processors of this branch. If left empty then the branch will begin with an exact copy of the origin message (including metadata).",
@@ -53,6 +61,19 @@ root.bar.id = this.user.id`,
 	this
 }`,
 	).HasDefault(""),
+	docs.FieldString(
+		"timeout",
+		"An optional maximum period to wait for the child processors to complete each attempt, after which the attempt fails for all messages of the request. Processors that exceed the timeout are abandoned and left to complete in the background.",
+		"5s", "1m",
+	).HasDefault("").Advanced(),
+	docs.FieldObject(
+		"retry",
+		"Determines how messages that fail the child processors, or attempts that exceed the `timeout`, are reattempted. Only the messages that failed are sent to the child processors again.",
+	).WithChildren(
+		docs.FieldInt("max_retries", "The maximum number of times to reattempt failed messages. If set to zero messages are not reattempted.").HasDefault(0),
+		docs.FieldString("initial_interval", "The initial period to wait between attempts, which increases exponentially for subsequent attempts.").HasDefault("500ms"),
+		docs.FieldString("max_interval", "The maximum period to wait between attempts.").HasDefault("3s"),
+	).Advanced(),
 }
 
 func init() {
@@ -195,10 +216,14 @@ type Branch struct {
 	log    log.Modular
 	tracer trace.TracerProvider
 
+	check      *mapping.Executor
 	requestMap *mapping.Executor
 	resultMap  *mapping.Executor
 	children   []processor.V1
 
+	timeout   time.Duration
+	retryCtor func() backoff.BackOff
+
 	// Metrics
 	mReceived      metrics.StatCounter
 	mBatchReceived metrics.StatCounter
@@ -237,6 +262,11 @@ func newBranch(conf processor.BranchConfig, mgr bundle.NewManagement) (*Branch,
 	}
 
 	var err error
+	if len(conf.Check) > 0 {
+		if b.check, err = mgr.BloblEnvironment().NewMapping(conf.Check); err != nil {
+			return nil, fmt.Errorf("failed to parse check: %w", err)
+		}
+	}
 	if len(conf.RequestMap) > 0 {
 		if b.requestMap, err = mgr.BloblEnvironment().NewMapping(conf.RequestMap); err != nil {
 			return nil, fmt.Errorf("failed to parse request mapping: %w", err)
@@ -247,6 +277,23 @@ func newBranch(conf processor.BranchConfig, mgr bundle.NewManagement) (*Branch,
 			return nil, fmt.Errorf("failed to parse result mapping: %w", err)
 		}
 	}
+	if len(conf.Timeout) > 0 {
+		if b.timeout, err = time.ParseDuration(conf.Timeout); err != nil {
+			return nil, fmt.Errorf("failed to parse timeout: %w", err)
+		}
+	}
+	if conf.Retry.MaxRetries < 0 {
+		return nil, errors.New("retry max_retries must not be negative")
+	}
+	if conf.Retry.MaxRetries > 0 {
+		rConf := retries.NewConfig()
+		rConf.MaxRetries = uint64(conf.Retry.MaxRetries)
+		rConf.Backoff.InitialInterval = conf.Retry.InitialInterval
+		rConf.Backoff.MaxInterval = conf.Retry.MaxInterval
+		if b.retryCtor, err = rConf.GetCtor(); err != nil {
+			return nil, err
+		}
+	}
 
 	return b, nil
 }
@@ -329,7 +376,7 @@ func (b *Branch) ProcessMessage(msg *message.Batch) ([]*message.Batch, error) {
 		return nil
 	})
 
-	resultParts, mapErrs, err := b.createResult(parts, msg)
+	resultParts, _, mapErrs, err := b.createResult(parts, msg)
 	if err != nil {
 		result := msg.Copy()
 		// Add general error to all messages.
@@ -348,7 +395,7 @@ func (b *Branch) ProcessMessage(msg *message.Batch) ([]*message.Batch, error) {
 	result := msg.DeepCopy()
 	for _, e := range mapErrs {
 		result.Get(e.index).ErrorSet(e.err)
-		b.log.Errorf("Branch error: %v", e.err)
+		log.ForMessage(b.log, result.Get(e.index)).Errorf("Branch error: %v", e.err)
 	}
 
 	if mapErrs, err = b.overlayResult(result, resultParts); err != nil {
@@ -361,7 +408,7 @@ func (b *Branch) ProcessMessage(msg *message.Batch) ([]*message.Batch, error) {
 	}
 	for _, e := range mapErrs {
 		result.Get(e.index).ErrorSet(e.err)
-		b.log.Errorf("Branch error: %v", e.err)
+		log.ForMessage(b.log, result.Get(e.index)).Errorf("Branch error: %v", e.err)
 	}
 
 	b.mLatency.Timing(time.Since(startedAt).Nanoseconds())
@@ -384,12 +431,14 @@ func newBranchMapError(index int, err error) branchMapError {
 // createResult performs reduction and child processors to a payload. The size
 // of the payload will remain unchanged, where reduced indexes are nil. This
 // result can be overlayed onto the original message in order to complete the
-// map.
-func (b *Branch) createResult(parts []*message.Part, referenceMsg *message.Batch) ([]*message.Part, []branchMapError, error) {
+// map. The number of attempts made for each message is also returned, where
+// skipped messages have zero attempts.
+func (b *Branch) createResult(parts []*message.Part, referenceMsg *message.Batch) ([]*message.Part, []int, []branchMapError, error) {
 	originalLen := len(parts)
+	attempts := make([]int, originalLen)
 
 	// Create request payloads
-	var skipped, failed []int
+	var skipped, failed, requested []int
 	var mapErrs []branchMapError
 
 	newParts := make([]*message.Part, 0, len(parts))
@@ -399,6 +448,21 @@ func (b *Branch) createResult(parts []*message.Part, referenceMsg *message.Batch
 			skipped = append(skipped, i)
 			continue
 		}
+		if b.check != nil {
+			passed, err := b.check.QueryPart(i, referenceMsg)
+			if err != nil {
+				b.mError.Incr(1)
+				b.log.Debugf("Failed to test check '%v': %v\n", i, err)
+
+				failed = append(failed, i)
+				mapErrs = append(mapErrs, newBranchMapError(i, fmt.Errorf("check failed: %w", err)))
+				continue
+			}
+			if !passed {
+				skipped = append(skipped, i)
+				continue
+			}
+		}
 		if b.requestMap != nil {
 			_ = parts[i].Set(nil)
 			newPart, err := b.requestMap.MapOnto(parts[i], i, referenceMsg)
@@ -414,9 +478,11 @@ func (b *Branch) createResult(parts []*message.Part, referenceMsg *message.Batch
 				skipped = append(skipped, i)
 			} else {
 				newParts = append(newParts, newPart)
+				requested = append(requested, i)
 			}
 		} else {
 			newParts = append(newParts, parts[i])
+			requested = append(requested, i)
 		}
 	}
 	parts = newParts
@@ -425,20 +491,20 @@ func (b *Branch) createResult(parts []*message.Part, referenceMsg *message.Batch
 	var procResults []*message.Batch
 	var err error
 	if len(parts) > 0 {
-		var res error
-		msg := message.QuickBatch(nil)
-		msg.SetAll(parts)
-		if procResults, res = processor.ExecuteAll(b.children, msg); res != nil {
-			err = fmt.Errorf("child processors failed: %v", res)
-		}
-		if len(procResults) == 0 {
-			err = errors.New("child processors resulted in zero messages")
+		var resultParts []*message.Part
+		var partAttempts []int
+		resultParts, partAttempts, err = b.executeChildren(parts)
+		for j, i := range requested {
+			attempts[i] = partAttempts[j]
 		}
 		if err != nil {
 			b.mError.Incr(1)
 			b.log.Errorf("Child processors failed: %v\n", err)
-			return nil, mapErrs, err
+			return nil, attempts, mapErrs, err
 		}
+		msg := message.QuickBatch(nil)
+		msg.SetAll(resultParts)
+		procResults = []*message.Batch{msg}
 	}
 
 	// Re-align processor results with original message indexes
@@ -446,7 +512,7 @@ func (b *Branch) createResult(parts []*message.Part, referenceMsg *message.Batch
 	if alignedResult, err = alignBranchResult(originalLen, skipped, failed, procResults); err != nil {
 		b.mError.Incr(1)
 		b.log.Errorf("Failed to align branch result: %v. Avoid using filters or archive/unarchive processors within your branch, or anything that increases or reduces the number of messages. These processors should instead be applied before or after the branch processor.\n", err)
-		return nil, mapErrs, err
+		return nil, attempts, mapErrs, err
 	}
 
 	for i, p := range alignedResult {
@@ -459,7 +525,110 @@ func (b *Branch) createResult(parts []*message.Part, referenceMsg *message.Batch
 		}
 	}
 
-	return alignedResult, mapErrs, nil
+	return alignedResult, attempts, mapErrs, nil
+}
+
+// executeChildren applies the child processors to request messages, where
+// messages that fail are reattempted according to the retry policy of the
+// branch. The resulting messages are aligned with the requests, and the number
+// of attempts made for each request is also returned.
+func (b *Branch) executeChildren(parts []*message.Part) ([]*message.Part, []int, error) {
+	results := make([]*message.Part, len(parts))
+	attempts := make([]int, len(parts))
+
+	pending := make([]int, len(parts))
+	for i := range pending {
+		pending[i] = i
+	}
+
+	var boff backoff.BackOff
+	for {
+		msg := message.QuickBatch(nil)
+		for _, i := range pending {
+			msg.Append(parts[i].Copy())
+			attempts[i]++
+		}
+
+		resParts, err := b.executeAttempt(msg)
+		if err == nil && len(resParts) != len(pending) && len(pending) == len(parts) {
+			// Diverged results can't be reattempted, and are returned as they
+			// are in order to be rejected during alignment.
+			return resParts, attempts, nil
+		}
+		if err == nil && len(resParts) != len(pending) {
+			return nil, attempts, fmt.Errorf(
+				"message count from branch processors does not match request, started with %v messages, finished with %v",
+				len(pending), len(resParts),
+			)
+		}
+
+		var retry []int
+		if err != nil {
+			retry = pending
+		} else {
+			for j, i := range pending {
+				results[i] = resParts[j]
+				if resParts[j].ErrorGet() != nil {
+					retry = append(retry, i)
+				}
+			}
+		}
+		if len(retry) == 0 || b.retryCtor == nil {
+			return results, attempts, err
+		}
+
+		if boff == nil {
+			boff = b.retryCtor()
+		}
+		next := boff.NextBackOff()
+		if next == backoff.Stop {
+			return results, attempts, err
+		}
+		b.log.Debugf("Reattempting %v failed messages after %v\n", len(retry), next)
+		time.Sleep(next)
+		pending = retry
+	}
+}
+
+// executeAttempt applies the child processors to a batch within the timeout
+// of the branch, returning the resulting messages.
+func (b *Branch) executeAttempt(msg *message.Batch) ([]*message.Part, error) {
+	var msgs []*message.Batch
+	var err error
+	if b.timeout > 0 {
+		type result struct {
+			msgs []*message.Batch
+			err  error
+		}
+		resChan := make(chan result, 1)
+		go func() {
+			msgs, err := processor.ExecuteAll(b.children, msg)
+			resChan <- result{msgs, err}
+		}()
+		select {
+		case res := <-resChan:
+			msgs, err = res.msgs, res.err
+		case <-time.After(b.timeout):
+			return nil, fmt.Errorf("child processors exceeded timeout of %v", b.timeout)
+		}
+	} else {
+		msgs, err = processor.ExecuteAll(b.children, msg)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("child processors failed: %v", err)
+	}
+	if len(msgs) == 0 {
+		return nil, errors.New("child processors resulted in zero messages")
+	}
+
+	var parts []*message.Part
+	for _, m := range msgs {
+		_ = m.Iter(func(i int, p *message.Part) error {
+			parts = append(parts, p)
+			return nil
+		})
+	}
+	return parts, nil
 }
 
 // overlayResult attempts to merge the result of a process_map with the original
@@ -7,6 +7,7 @@ import (
 	"compress/zlib"
 	"context"
 	"fmt"
+	"io"
 
 	"github.com/golang/snappy"
 	"github.com/pierrec/lz4/v4"
@@ -34,7 +35,9 @@ func init() {
 Compresses messages according to the selected algorithm. Supported compression
 algorithms are: gzip, zlib, flate, snappy, lz4.`,
 		Description: `
-The 'level' field might not apply to all algorithms.`,
+The 'level' field might not apply to all algorithms.
+
+Messages with spooled payloads, such as those consumed with the ` + "`spool`" + ` codec, are compressed into a new temporary file without loading the payload into memory. This applies to all algorithms except snappy.`,
 		Config: docs.FieldComponent().WithChildren(
 			docs.FieldString("algorithm", "The compression algorithm to use.").HasOptions("gzip", "zlib", "flate", "snappy", "lz4"),
 			docs.FieldInt("level", "The level of compression to use. May not be applicable to all algorithms."),
@@ -137,10 +140,41 @@ func strToCompressor(str string) (compressFunc, error) {
 	return nil, fmt.Errorf("compression type not recognised: %v", str)
 }
 
+type compressWriterFunc func(level int, w io.Writer) (io.WriteCloser, error)
+
+func strToStreamCompressor(str string) compressWriterFunc {
+	switch str {
+	case "gzip":
+		return func(level int, w io.Writer) (io.WriteCloser, error) {
+			return gzip.NewWriterLevel(w, level)
+		}
+	case "zlib":
+		return func(level int, w io.Writer) (io.WriteCloser, error) {
+			return zlib.NewWriterLevel(w, level)
+		}
+	case "flate":
+		return func(level int, w io.Writer) (io.WriteCloser, error) {
+			return flate.NewWriter(w, level)
+		}
+	case "lz4":
+		return func(level int, w io.Writer) (io.WriteCloser, error) {
+			lw := lz4.NewWriter(w)
+			if level > 0 {
+				if err := lw.Apply(lz4.CompressionLevelOption(lz4.CompressionLevel(1 << (8 + level)))); err != nil {
+					return nil, err
+				}
+			}
+			return lw, nil
+		}
+	}
+	return nil
+}
+
 type compressProc struct {
-	level int
-	comp  compressFunc
-	log   log.Modular
+	level      int
+	comp       compressFunc
+	compStream compressWriterFunc
+	log        log.Modular
 }
 
 func newCompress(conf processor.CompressConfig, mgr bundle.NewManagement) (*compressProc, error) {
@@ -149,16 +183,48 @@ func newCompress(conf processor.CompressConfig, mgr bundle.NewManagement) (*comp
 		return nil, err
 	}
 	return &compressProc{
-		level: conf.Level,
-		comp:  cor,
-		log:   mgr.Logger(),
+		level:      conf.Level,
+		comp:       cor,
+		compStream: strToStreamCompressor(conf.Algorithm),
+		log:        mgr.Logger(),
 	}, nil
 }
 
+func (c *compressProc) compressSpool(spool *message.Spool) (*message.Spool, error) {
+	r, err := spool.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	return spool.Derive(func(w io.Writer) error {
+		cw, err := c.compStream(c.level, w)
+		if err != nil {
+			return err
+		}
+		if _, err = io.Copy(cw, r); err != nil {
+			cw.Close()
+			return err
+		}
+		return cw.Close()
+	})
+}
+
 func (c *compressProc) Process(ctx context.Context, msg *message.Part) ([]*message.Part, error) {
+	if spool := msg.Spool(); spool != nil && c.compStream != nil {
+		newSpool, err := c.compressSpool(spool)
+		if err != nil {
+			log.ForMessage(c.log, msg).Errorf("Failed to compress message: %v\n", err)
+			return nil, err
+		}
+		newMsg := msg.Copy()
+		newMsg.SetSpool(newSpool)
+		return []*message.Part{newMsg}, nil
+	}
+
 	newBytes, err := c.comp(c.level, msg.Get())
 	if err != nil {
-		c.log.Errorf("Failed to compress message: %v\n", err)
+		log.ForMessage(c.log, msg).Errorf("Failed to compress message: %v\n", err)
 		return nil, err
 	}
 	newMsg := msg.Copy()
@@ -5,11 +5,14 @@ import (
 	"compress/flate"
 	"compress/gzip"
 	"compress/zlib"
+	"path/filepath"
 	"reflect"
 	"testing"
 
 	"github.com/golang/snappy"
 	"github.com/pierrec/lz4/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/benthosdev/benthos/v4/internal/component/processor"
 	"github.com/benthosdev/benthos/v4/internal/manager/mock"
@@ -252,3 +255,58 @@ func TestCompressLZ4(t *testing.T) {
 		t.Errorf("Unexpected output: %s != %s", act, exp)
 	}
 }
+
+func TestCompressDecompressSpooled(t *testing.T) {
+	input := bytes.Repeat([]byte("hello world spooled part "), 1000)
+
+	for _, algo := range []string{"gzip", "zlib", "flate", "lz4", "snappy"} {
+		algo := algo
+		t.Run(algo, func(t *testing.T) {
+			dir := t.TempDir()
+
+			spool, err := message.NewSpoolFromReader(dir, bytes.NewReader(input))
+			require.NoError(t, err)
+
+			compConf := processor.NewConfig()
+			compConf.Type = "compress"
+			compConf.Compress.Algorithm = algo
+
+			comp, err := mock.NewManager().NewProcessor(compConf)
+			require.NoError(t, err)
+
+			decompConf := processor.NewConfig()
+			decompConf.Type = "decompress"
+			decompConf.Decompress.Algorithm = algo
+
+			decomp, err := mock.NewManager().NewProcessor(decompConf)
+			require.NoError(t, err)
+
+			inMsg := message.QuickBatch(nil)
+			inMsg.Append(message.NewSpooledPart(spool))
+
+			msgs, res := comp.ProcessMessage(inMsg)
+			require.Nil(t, res)
+			require.Len(t, msgs, 1)
+
+			compressed := msgs[0].Get(0)
+			if algo != "snappy" {
+				require.NotNil(t, compressed.Spool())
+				assert.Equal(t, dir, filepath.Dir(compressed.Spool().Path()))
+				assert.Less(t, compressed.BodySize(), int64(len(input)))
+			}
+
+			msgs, res = decomp.ProcessMessage(msgs[0])
+			require.Nil(t, res)
+			require.Len(t, msgs, 1)
+
+			decompressed := msgs[0].Get(0)
+			if algo != "snappy" {
+				require.NotNil(t, decompressed.Spool())
+			}
+			assert.Equal(t, input, decompressed.Get())
+
+			// The original spool must remain intact
+			assert.Equal(t, input, inMsg.Get(0).Get())
+		})
+	}
+}
@@ -35,6 +35,8 @@ func init() {
 		Summary: `
 Decompresses messages according to the selected algorithm. Supported
 decompression types are: gzip, zlib, bzip2, flate, snappy, lz4.`,
+		Description: `
+Messages with spooled payloads, such as those consumed with the ` + "`spool`" + ` codec, are decompressed into a new temporary file without loading the payload into memory. This applies to all algorithms except snappy.`,
 		Config: docs.FieldComponent().WithChildren(
 			docs.FieldString("algorithm", "The decompression algorithm to use.").HasOptions("gzip", "zlib", "bzip2", "flate", "snappy", "lz4"),
 		).ChildDefaultAndTypesFromStruct(processor.NewDecompressConfig()),
@@ -132,9 +134,36 @@ func strToDecompressor(str string) (decompressFunc, error) {
 	return nil, fmt.Errorf("decompression type not recognised: %v", str)
 }
 
+type decompressReaderFunc func(r io.Reader) (io.ReadCloser, error)
+
+func strToStreamDecompressor(str string) decompressReaderFunc {
+	switch str {
+	case "gzip":
+		return func(r io.Reader) (io.ReadCloser, error) {
+			return gzip.NewReader(r)
+		}
+	case "zlib":
+		return zlib.NewReader
+	case "flate":
+		return func(r io.Reader) (io.ReadCloser, error) {
+			return flate.NewReader(r), nil
+		}
+	case "bzip2":
+		return func(r io.Reader) (io.ReadCloser, error) {
+			return io.NopCloser(bzip2.NewReader(r)), nil
+		}
+	case "lz4":
+		return func(r io.Reader) (io.ReadCloser, error) {
+			return io.NopCloser(lz4.NewReader(r)), nil
+		}
+	}
+	return nil
+}
+
 type decompressProc struct {
-	decomp decompressFunc
-	log    log.Modular
+	decomp       decompressFunc
+	decompStream decompressReaderFunc
+	log          log.Modular
 }
 
 func newDecompress(conf processor.DecompressConfig, mgr bundle.NewManagement) (*decompressProc, error) {
@@ -143,15 +172,43 @@ func newDecompress(conf processor.DecompressConfig, mgr bundle.NewManagement) (*
 		return nil, err
 	}
 	return &decompressProc{
-		decomp: dcor,
-		log:    mgr.Logger(),
+		decomp:       dcor,
+		decompStream: strToStreamDecompressor(conf.Algorithm),
+		log:          mgr.Logger(),
 	}, nil
 }
 
+func (d *decompressProc) decompressSpool(spool *message.Spool) (*message.Spool, error) {
+	r, err := spool.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	dr, err := d.decompStream(r)
+	if err != nil {
+		return nil, err
+	}
+	defer dr.Close()
+
+	return spool.DeriveFromReader(dr)
+}
+
 func (d *decompressProc) Process(ctx context.Context, msg *message.Part) ([]*message.Part, error) {
+	if spool := msg.Spool(); spool != nil && d.decompStream != nil {
+		newSpool, err := d.decompressSpool(spool)
+		if err != nil {
+			log.ForMessage(d.log, msg).Errorf("Failed to decompress message part: %v\n", err)
+			return nil, err
+		}
+		newMsg := msg.Copy()
+		newMsg.SetSpool(newSpool)
+		return []*message.Part{newMsg}, nil
+	}
+
 	newBytes, err := d.decomp(msg.Get())
 	if err != nil {
-		d.log.Errorf("Failed to decompress message part: %v\n", err)
+		log.ForMessage(d.log, msg).Errorf("Failed to decompress message part: %v\n", err)
 		return nil, err
 	}
 
@@ -7,8 +7,8 @@ import (
 	"strconv"
 	"time"
 
-	"github.com/benthosdev/benthos/v4/internal/bloblang/mapping"
 	"github.com/benthosdev/benthos/v4/internal/bundle"
+	"github.com/benthosdev/benthos/v4/internal/cel"
 	"github.com/benthosdev/benthos/v4/internal/component/processor"
 	"github.com/benthosdev/benthos/v4/internal/docs"
 	"github.com/benthosdev/benthos/v4/internal/log"
@@ -73,6 +73,11 @@ output:
 				`this.contents.urls.contains("https://benthos.dev/")`,
 				`true`,
 			).HasDefault(""),
+			cel.FieldSpec(
+				"cel",
+				"A [CEL expression](https://github.com/google/cel-spec) that should return a boolean value indicating whether a message belongs to a given group, which can be used instead of `check`.",
+				`this.type == "foo"`,
+			),
 			docs.FieldProcessor(
 				"processors",
 				"A list of [processors](/docs/components/processors/about/) to execute on the newly formed group.",
@@ -85,7 +90,7 @@ output:
 }
 
 type group struct {
-	Check      *mapping.Executor
+	Check      caseCheck
 	Processors []processor.V1
 }
 
@@ -99,11 +104,10 @@ func newGroupBy(conf processor.GroupByConfig, mgr bundle.NewManagement) (process
 	groups := make([]group, len(conf))
 
 	for i, gConf := range conf {
-		if len(gConf.Check) > 0 {
-			if groups[i].Check, err = mgr.BloblEnvironment().NewMapping(gConf.Check); err != nil {
-				return nil, fmt.Errorf("failed to parse check for group '%v': %v", i, err)
-			}
-		} else {
+		if groups[i].Check, err = newCaseCheck(mgr, gConf.Check, gConf.CEL); err != nil {
+			return nil, fmt.Errorf("failed to parse check for group '%v': %v", i, err)
+		}
+		if groups[i].Check == nil {
 			return nil, errors.New("a group definition must have a check query")
 		}
 
@@ -0,0 +1,214 @@
+package pure
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
+	"github.com/benthosdev/benthos/v4/public/bloblang"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func groupBySessionProcConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		Beta().
+		Categories("Composition").
+		Summary("Splits a batch of messages into sessions, where each session is a batch of messages sharing a key without a gap of inactivity between them longer than a given duration.").
+		Description(`
+Messages are grouped by their key and ordered by the timestamp provided by the `+"`timestamp_mapping`"+`, and a new session is started for a key whenever the time since the previous message of the key exceeds the `+"`gap`"+`, or when the session would otherwise exceed the `+"`max_duration`"+`. Each session is emitted as a batch of its own, ordered by the first appearance of their key within the batch and then by the start of the session.
+
+Each message of a session has the metadata fields `+"`session_key`, `session_start` and `session_end`"+` added to it, where the start and end are the timestamps of the first and last messages of the session as RFC3339 strings. Messages where the timestamp mapping fails are emitted in a batch of their own and flagged as having failed, and can be handled with [standard error handling patterns](/docs/configuration/error_handling).
+
+The functionality of this processor depends on being applied across messages that are batched, and sessions are only ever formed from the messages of a single batch. In order to accumulate messages over time use either a [batching policy](/docs/configuration/batching) with a `+"`period`"+`, or a [`+"`system_window`"+` buffer](/docs/components/buffers/system_window) with a size that is larger than the expected duration of sessions.`).
+		Field(service.NewInterpolatedStringField("key").
+			Description("An interpolated string yielding the key that sessions are grouped by.").
+			Example(`${! json("user_id") }`).
+			Example(`${! meta("kafka_key") }`)).
+		Field(service.NewBloblangField("timestamp_mapping").
+			Description("A [Bloblang mapping](/docs/guides/bloblang/about) applied to each message that provides the event time of the message. The timestamp value assigned to `root` must either be a numerical unix time in seconds (with up to nanosecond precision via decimals), or a string in ISO 8601 format.").
+			Example("root = this.created_at").
+			Example(`root = meta("kafka_timestamp_unix").number()`)).
+		Field(service.NewStringField("gap").
+			Description("The maximum period of inactivity between two messages of a session, after which a new session is started.").
+			Example("30m").Example("10s")).
+		Field(service.NewStringField("max_duration").
+			Description("An optional maximum period of time that a session may span, after which a new session is started regardless of activity.").
+			Example("24h").
+			Default("")).
+		Example("User Sessions", `
+Given a stream of page view events of the form:
+
+`+"```json"+`
+{"user_id":"foo","page":"/home","viewed_at":"2022-03-02T10:01:00Z"}
+`+"```"+`
+
+We can collect events into five minute windows, split them into sessions of each user that are separated by more than thirty minutes of inactivity, and reduce each session to a single summary with the following config:`, `
+buffer:
+  system_window:
+    timestamp_mapping: root = this.viewed_at
+    size: 5m
+
+pipeline:
+  processors:
+    - group_by_session:
+        key: ${! json("user_id") }
+        timestamp_mapping: root = this.viewed_at
+        gap: 30m
+    - bloblang: |
+        root = if batch_index() == 0 {
+          {
+            "user_id": this.user_id,
+            "started_at": meta("session_start"),
+            "ended_at": meta("session_end"),
+            "pages": json("page").from_all(),
+          }
+        } else { deleted() }
+`)
+}
+
+func init() {
+	err := service.RegisterBatchProcessor(
+		"group_by_session", groupBySessionProcConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.BatchProcessor, error) {
+			return newGroupBySessionFromParsed(conf, mgr)
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type groupBySession struct {
+	key         *service.InterpolatedString
+	tsMapping   *bloblang.Executor
+	gap         time.Duration
+	maxDuration time.Duration
+	log         *service.Logger
+}
+
+func newGroupBySessionFromParsed(conf *service.ParsedConfig, mgr *service.Resources) (*groupBySession, error) {
+	g := &groupBySession{log: mgr.Logger()}
+
+	var err error
+	if g.key, err = conf.FieldInterpolatedString("key"); err != nil {
+		return nil, err
+	}
+	if g.tsMapping, err = conf.FieldBloblang("timestamp_mapping"); err != nil {
+		return nil, err
+	}
+	if g.gap, err = getDuration(conf, true, "gap"); err != nil {
+		return nil, err
+	}
+	if g.gap <= 0 {
+		return nil, errors.New("session gap must be greater than zero")
+	}
+	if g.maxDuration, err = getDuration(conf, false, "max_duration"); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+func (g *groupBySession) getTimestamp(i int, batch service.MessageBatch) (time.Time, error) {
+	tsValueMsg, err := batch.BloblangQuery(i, g.tsMapping)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("timestamp mapping failed: %w", err)
+	}
+
+	var tsValue interface{}
+	if tsValue, err = tsValueMsg.AsStructured(); err != nil {
+		if tsBytes, _ := tsValueMsg.AsBytes(); len(tsBytes) > 0 {
+			tsValue = string(tsBytes)
+			err = nil
+		}
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("unable to parse result of timestamp mapping as structured value: %w", err)
+	}
+
+	ts, err := query.IGetTimestamp(tsValue)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("unable to parse result of timestamp mapping as timestamp: %w", err)
+	}
+	return ts, nil
+}
+
+type sessionMessage struct {
+	ts  time.Time
+	msg *service.Message
+}
+
+func (g *groupBySession) sessions(key string, msgs []sessionMessage) []service.MessageBatch {
+	sort.SliceStable(msgs, func(i, j int) bool {
+		return msgs[i].ts.Before(msgs[j].ts)
+	})
+
+	var batches []service.MessageBatch
+	flush := func(session []sessionMessage) {
+		start := session[0].ts.UTC().Format(time.RFC3339Nano)
+		end := session[len(session)-1].ts.UTC().Format(time.RFC3339Nano)
+
+		batch := make(service.MessageBatch, 0, len(session))
+		for _, m := range session {
+			m.msg.MetaSet("session_key", key)
+			m.msg.MetaSet("session_start", start)
+			m.msg.MetaSet("session_end", end)
+			batch = append(batch, m.msg)
+		}
+		batches = append(batches, batch)
+	}
+
+	sessionStart := 0
+	for i := 1; i < len(msgs); i++ {
+		gapExceeded := msgs[i].ts.Sub(msgs[i-1].ts) > g.gap
+		durationExceeded := g.maxDuration > 0 && msgs[i].ts.Sub(msgs[sessionStart].ts) > g.maxDuration
+		if gapExceeded || durationExceeded {
+			flush(msgs[sessionStart:i])
+			sessionStart = i
+		}
+	}
+	flush(msgs[sessionStart:])
+	return batches
+}
+
+func (g *groupBySession) ProcessBatch(ctx context.Context, batch service.MessageBatch) ([]service.MessageBatch, error) {
+	if len(batch) == 0 {
+		return nil, nil
+	}
+
+	var keys []string
+	keyed := map[string][]sessionMessage{}
+	var failed service.MessageBatch
+
+	for i, msg := range batch {
+		ts, err := g.getTimestamp(i, batch)
+		if err != nil {
+			g.log.Debugf("Failed to obtain session timestamp: %v", err)
+			msg.SetError(err)
+			failed = append(failed, msg)
+			continue
+		}
+
+		key := batch.InterpolatedString(i, g.key)
+		if _, exists := keyed[key]; !exists {
+			keys = append(keys, key)
+		}
+		keyed[key] = append(keyed[key], sessionMessage{ts: ts, msg: msg})
+	}
+
+	var batches []service.MessageBatch
+	for _, key := range keys {
+		batches = append(batches, g.sessions(key, keyed[key])...)
+	}
+	if len(failed) > 0 {
+		batches = append(batches, failed)
+	}
+	return batches, nil
+}
+
+func (g *groupBySession) Close(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,93 @@
+package pure
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func TestGroupBySessionBadGap(t *testing.T) {
+	conf, err := groupBySessionProcConfig().ParseYAML(`
+key: ${! json("user") }
+timestamp_mapping: root = this.ts
+gap: 0s
+`, nil)
+	require.NoError(t, err)
+
+	_, err = newGroupBySessionFromParsed(conf, service.MockResources())
+	assert.EqualError(t, err, "session gap must be greater than zero")
+}
+
+func TestGroupBySession(t *testing.T) {
+	conf, err := groupBySessionProcConfig().ParseYAML(`
+key: ${! json("user") }
+timestamp_mapping: root = this.ts
+gap: 10s
+max_duration: 30s
+`, nil)
+	require.NoError(t, err)
+
+	proc, err := newGroupBySessionFromParsed(conf, service.MockResources())
+	require.NoError(t, err)
+
+	var batch service.MessageBatch
+	for _, c := range []string{
+		`{"user":"a","id":"1","ts":"2022-03-02T10:00:00Z"}`,
+		`{"user":"b","id":"2","ts":"2022-03-02T10:00:05Z"}`,
+		`{"user":"a","id":"3","ts":"2022-03-02T10:00:08Z"}`,
+		`{"user":"a","id":"4","ts":"2022-03-02T10:00:30Z"}`,
+		`{"user":"b","id":"5","ts":"2022-03-02T10:00:01Z"}`,
+		`{"user":"a","id":"6","no_ts":true}`,
+		`{"user":"b","id":"7","ts":"2022-03-02T10:00:14Z"}`,
+		`{"user":"b","id":"8","ts":"2022-03-02T10:00:23Z"}`,
+		`{"user":"b","id":"9","ts":"2022-03-02T10:00:32Z"}`,
+	} {
+		batch = append(batch, service.NewMessage([]byte(c)))
+	}
+
+	batches, err := proc.ProcessBatch(context.Background(), batch)
+	require.NoError(t, err)
+
+	type sessionMeta struct {
+		Key, Start, End string
+	}
+
+	var ids [][]string
+	var metas []sessionMeta
+	for _, b := range batches {
+		var batchIDs []string
+		for _, m := range b {
+			v, err := m.AsStructured()
+			require.NoError(t, err)
+			batchIDs = append(batchIDs, v.(map[string]interface{})["id"].(string))
+		}
+		ids = append(ids, batchIDs)
+
+		key, _ := b[0].MetaGet("session_key")
+		start, _ := b[0].MetaGet("session_start")
+		end, _ := b[0].MetaGet("session_end")
+		metas = append(metas, sessionMeta{Key: key, Start: start, End: end})
+	}
+
+	assert.Equal(t, [][]string{
+		{"1", "3"},
+		{"4"},
+		{"5", "2", "7", "8"},
+		{"9"},
+		{"6"},
+	}, ids)
+
+	assert.Equal(t, []sessionMeta{
+		{Key: "a", Start: "2022-03-02T10:00:00Z", End: "2022-03-02T10:00:08Z"},
+		{Key: "a", Start: "2022-03-02T10:00:30Z", End: "2022-03-02T10:00:30Z"},
+		{Key: "b", Start: "2022-03-02T10:00:01Z", End: "2022-03-02T10:00:23Z"},
+		{Key: "b", Start: "2022-03-02T10:00:32Z", End: "2022-03-02T10:00:32Z"},
+		{},
+	}, metas)
+
+	assert.Error(t, batches[4][0].GetError())
+}
@@ -135,7 +135,7 @@ func (l *logProcessor) levelToLogFn(level string) (func(logger log.Modular, msg
 
 func (l *logProcessor) ProcessBatch(ctx context.Context, spans []*tracing.Span, msg *message.Batch) ([]*message.Batch, error) {
 	_ = msg.Iter(func(i int, _ *message.Part) error {
-		targetLog := l.logger
+		targetLog := log.ForMessage(l.logger, msg.Get(i))
 		if l.fieldsMapping != nil {
 			v, err := l.fieldsMapping.Exec(query.FunctionContext{
 				Maps:     map[string]query.Function{},
@@ -150,13 +150,13 @@ func (l *logProcessor) ProcessBatch(ctx context.Context, spans []*tracing.Span,
 				return &jObj
 			}))
 			if err != nil {
-				l.logger.Errorf("Failed to execute fields mapping: %v", err)
+				targetLog.Errorf("Failed to execute fields mapping: %v", err)
 				return nil
 			}
 
 			vObj, ok := v.(map[string]interface{})
 			if !ok {
-				l.logger.Errorf("Fields mapping yielded a non-object result: %T", v)
+				targetLog.Errorf("Fields mapping yielded a non-object result: %T", v)
 				return nil
 			}
 
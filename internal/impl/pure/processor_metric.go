@@ -6,9 +6,11 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/benthosdev/benthos/v4/internal/bloblang/field"
+	"github.com/benthosdev/benthos/v4/internal/bloblang/mapping"
 	"github.com/benthosdev/benthos/v4/internal/bundle"
 	"github.com/benthosdev/benthos/v4/internal/component/metrics"
 	"github.com/benthosdev/benthos/v4/internal/component/processor"
@@ -27,7 +29,9 @@ func init() {
 		},
 		Summary: "Emit custom metrics by extracting values from messages.",
 		Description: `
-This processor works by evaluating an [interpolated field ` + "`value`" + `](/docs/configuration/interpolation#bloblang-queries) for each message and updating a emitted metric according to the [type](#types).
+This processor works by evaluating an [interpolated field ` + "`value`" + `](/docs/configuration/interpolation#bloblang-queries) for each message and updating a emitted metric according to the [type](#types). When a ` + "`check`" + ` is configured only the messages that pass it update the metric.
+
+Label values are also interpolated for each message, and therefore labels derived from the contents of messages can result in an unbounded number of metric series. The field ` + "`max_label_cardinality`" + ` guards against this by limiting the number of distinct combinations of label values emitted, where messages that would exceed the limit update a series with every label value set to ` + "`overflow`" + ` instead.
 
 Custom metrics such as these are emitted along with Benthos internal metrics, where you can customize where metrics are sent, which metric names are emitted and rename them as/when appropriate. For more information check out the [metrics docs here](/docs/components/metrics/about).`,
 		Config: docs.FieldComponent().WithChildren(
@@ -36,6 +40,8 @@ Custom metrics such as these are emitted along with Benthos internal metrics, wh
 				"counter_by",
 				"gauge",
 				"timing",
+				"histogram",
+				"summary",
 			),
 			docs.FieldString("name", "The name of the metric to create, this must be unique across all Benthos components otherwise it will overwrite those other metrics."),
 			docs.FieldString(
@@ -46,6 +52,12 @@ Custom metrics such as these are emitted along with Benthos internal metrics, wh
 				},
 			).IsInterpolated().Map(),
 			docs.FieldString("value", "For some metric types specifies a value to set, increment.").IsInterpolated(),
+			docs.FieldBloblang(
+				"check", "An optional [Bloblang query](/docs/guides/bloblang/about/) that should return a boolean value indicating whether a message should update the metric. If the query fails the metric is not updated and the error is logged.",
+				`this.status == "completed"`,
+			).AtVersion("4.3.0"),
+			docs.FieldFloat("buckets", "For the `histogram` type specifies the upper bounds of the buckets that values are counted within. When empty the default buckets of the metrics exporter are used.").Array().AtVersion("4.3.0"),
+			docs.FieldInt("max_label_cardinality", "The maximum number of distinct combinations of label values to emit, where zero means no limit.").Advanced().AtVersion("4.3.0"),
 		).ChildDefaultAndTypesFromStruct(processor.NewMetricConfig()),
 		Examples: []docs.AnnotatedExample{
 			{
@@ -89,6 +101,33 @@ pipeline:
 metrics:
   mapping: 'if this != "FooSize" { deleted() }'
   prometheus: {}
+`,
+			},
+			{
+				Title:   "Business Metrics",
+				Summary: "In this example we emit a counter of completed orders and a histogram of their totals, both labelled with the region of the order. Only completed orders update the metrics, and the number of regions emitted is limited in case the field contains unexpected values.",
+				Config: `
+pipeline:
+  processors:
+    - metric:
+        name: orders_completed
+        type: counter
+        check: this.status == "completed"
+        labels:
+          region: ${! this.region.or("unknown") }
+        max_label_cardinality: 50
+    - metric:
+        name: order_total
+        type: histogram
+        check: this.status == "completed"
+        labels:
+          region: ${! this.region.or("unknown") }
+        max_label_cardinality: 50
+        value: ${! this.total }
+        buckets: [ 10, 50, 100, 500, 1000 ]
+
+metrics:
+  prometheus: {}
 `,
 			},
 		},
@@ -136,7 +175,17 @@ pipeline:
 
 ### ` + "`timing`" + `
 
-Equivalent to ` + "`gauge`" + ` where instead the metric is a timing. It is recommended that timing values are recorded in nanoseconds in order to be consistent with standard Benthos timing metrics, as in some cases these values are automatically converted into other units such as when exporting timings as histograms with Prometheus metrics.`,
+Equivalent to ` + "`gauge`" + ` where instead the metric is a timing. It is recommended that timing values are recorded in nanoseconds in order to be consistent with standard Benthos timing metrics, as in some cases these values are automatically converted into other units such as when exporting timings as histograms with Prometheus metrics.
+
+### ` + "`histogram`" + `
+
+If the contents of ` + "`value`" + ` can be parsed as a number then it is counted within the histogram buckets configured with ` + "`buckets`" + `. Unlike timings the value can be fractional or negative, and is recorded as it is without any conversion of units.
+
+### ` + "`summary`" + `
+
+Equivalent to ` + "`histogram`" + ` where instead the metric is a summary, which tracks the 0.5, 0.9 and 0.99 quantiles of the values.
+
+Histograms and summaries are supported natively by the ` + "`prometheus`" + ` metrics exporter, other exporters record them as timings with their values truncated to integers.`,
 	})
 	if err != nil {
 		panic(err)
@@ -150,6 +199,11 @@ type metricProcessor struct {
 
 	value  *field.Expression
 	labels labels
+	check  *mapping.Executor
+
+	maxCardinality int
+	seenMut        sync.Mutex
+	seen           map[string]struct{}
 
 	mCounter metrics.StatCounter
 	mGauge   metrics.StatGauge
@@ -159,6 +213,8 @@ type metricProcessor struct {
 	mGaugeVec   metrics.StatGaugeVec
 	mTimerVec   metrics.StatTimerVec
 
+	mHistogramVec metrics.StatHistogramVec
+
 	handler func(string, int, *message.Batch) error
 }
 
@@ -195,10 +251,18 @@ func newMetricProcessor(conf processor.Config, mgr bundle.NewManagement, log log
 	}
 
 	m := &metricProcessor{
-		conf:  conf,
-		log:   log,
-		stats: stats,
-		value: value,
+		conf:           conf,
+		log:            log,
+		stats:          stats,
+		value:          value,
+		maxCardinality: conf.Metric.MaxLabelCardinality,
+		seen:           map[string]struct{}{},
+	}
+
+	if conf.Metric.Check != "" {
+		if m.check, err = mgr.BloblEnvironment().NewMapping(conf.Metric.Check); err != nil {
+			return nil, fmt.Errorf("failed to parse check: %v", err)
+		}
 	}
 
 	name := conf.Metric.Name
@@ -252,6 +316,12 @@ func newMetricProcessor(conf processor.Config, mgr bundle.NewManagement, log log
 			m.mTimer = stats.GetTimer(name)
 		}
 		m.handler = m.handleTimer
+	case "histogram":
+		m.mHistogramVec = metrics.GetHistogramVec(stats, name, conf.Metric.Buckets, m.labels.names()...)
+		m.handler = m.handleHistogram
+	case "summary":
+		m.mHistogramVec = metrics.GetSummaryVec(stats, name, m.labels.names()...)
+		m.handler = m.handleHistogram
 	default:
 		return nil, fmt.Errorf("metric type unrecognised: %v", conf.Metric.Type)
 	}
@@ -259,9 +329,36 @@ func newMetricProcessor(conf processor.Config, mgr bundle.NewManagement, log log
 	return m, nil
 }
 
+// labelValues returns the label values of a message, replacing them with the
+// overflow series when they form a new combination beyond the cardinality
+// limit.
+func (m *metricProcessor) labelValues(index int, msg *message.Batch) []string {
+	values := m.labels.values(index, msg)
+	if m.maxCardinality <= 0 {
+		return values
+	}
+
+	key := strings.Join(values, "\x00")
+
+	m.seenMut.Lock()
+	defer m.seenMut.Unlock()
+
+	if _, exists := m.seen[key]; exists {
+		return values
+	}
+	if len(m.seen) < m.maxCardinality {
+		m.seen[key] = struct{}{}
+		return values
+	}
+	for i := range values {
+		values[i] = "overflow"
+	}
+	return values
+}
+
 func (m *metricProcessor) handleCounter(val string, index int, msg *message.Batch) error {
 	if len(m.labels) > 0 {
-		m.mCounterVec.With(m.labels.values(index, msg)...).Incr(1)
+		m.mCounterVec.With(m.labelValues(index, msg)...).Incr(1)
 	} else {
 		m.mCounter.Incr(1)
 	}
@@ -277,7 +374,7 @@ func (m *metricProcessor) handleCounterBy(val string, index int, msg *message.Ba
 		return errors.New("value is negative")
 	}
 	if len(m.labels) > 0 {
-		m.mCounterVec.With(m.labels.values(index, msg)...).Incr(i)
+		m.mCounterVec.With(m.labelValues(index, msg)...).Incr(i)
 	} else {
 		m.mCounter.Incr(i)
 	}
@@ -293,7 +390,7 @@ func (m *metricProcessor) handleGauge(val string, index int, msg *message.Batch)
 		return errors.New("value is negative")
 	}
 	if len(m.labels) > 0 {
-		m.mGaugeVec.With(m.labels.values(index, msg)...).Set(i)
+		m.mGaugeVec.With(m.labelValues(index, msg)...).Set(i)
 	} else {
 		m.mGauge.Set(i)
 	}
@@ -309,15 +406,34 @@ func (m *metricProcessor) handleTimer(val string, index int, msg *message.Batch)
 		return errors.New("value is negative")
 	}
 	if len(m.labels) > 0 {
-		m.mTimerVec.With(m.labels.values(index, msg)...).Timing(i)
+		m.mTimerVec.With(m.labelValues(index, msg)...).Timing(i)
 	} else {
 		m.mTimer.Timing(i)
 	}
 	return nil
 }
 
+func (m *metricProcessor) handleHistogram(val string, index int, msg *message.Batch) error {
+	f, err := strconv.ParseFloat(val, 64)
+	if err != nil {
+		return err
+	}
+	m.mHistogramVec.With(m.labelValues(index, msg)...).Observe(f)
+	return nil
+}
+
 func (m *metricProcessor) ProcessMessage(msg *message.Batch) ([]*message.Batch, error) {
 	_ = msg.Iter(func(i int, p *message.Part) error {
+		if m.check != nil {
+			pass, err := m.check.QueryPart(i, msg)
+			if err != nil {
+				m.log.Errorf("Check error: %v\n", err)
+				return nil
+			}
+			if !pass {
+				return nil
+			}
+		}
 		value := m.value.String(i, msg)
 		if err := m.handler(value, i, msg); err != nil {
 			m.log.Errorf("Handler error: %v\n", err)
@@ -1,6 +1,8 @@
 package pure_test
 
 import (
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -226,3 +228,115 @@ func TestMetricTiming(t *testing.T) {
 
 	assert.Equal(t, expTimingAvgs, actTimingAvgs)
 }
+
+// histogramRecorder is a metrics type that records the values observed by
+// histograms and summaries by their labelled path.
+type histogramRecorder struct {
+	metrics.DudType
+
+	mut      sync.Mutex
+	buckets  map[string][]float64
+	observed map[string][]float64
+}
+
+type recordedHistogram struct {
+	r    *histogramRecorder
+	path string
+}
+
+func (h recordedHistogram) Observe(value float64) {
+	h.r.mut.Lock()
+	h.r.observed[h.path] = append(h.r.observed[h.path], value)
+	h.r.mut.Unlock()
+}
+
+func (r *histogramRecorder) GetHistogramVec(path string, buckets []float64, labelNames ...string) metrics.StatHistogramVec {
+	r.buckets[path] = buckets
+	return r.GetSummaryVec(path, labelNames...)
+}
+
+func (r *histogramRecorder) GetSummaryVec(path string, labelNames ...string) metrics.StatHistogramVec {
+	return metrics.FakeHistogramVec(func(labelValues ...string) metrics.StatHistogram {
+		return recordedHistogram{r: r, path: path + "{" + strings.Join(labelValues, ",") + "}"}
+	})
+}
+
+func TestMetricHistogram(t *testing.T) {
+	for _, typ := range []string{"histogram", "summary"} {
+		conf := processor.NewConfig()
+		conf.Type = "metric"
+		conf.Metric.Type = typ
+		conf.Metric.Name = "foo.bar"
+		conf.Metric.Value = "${!json(\"foo.bar\")}"
+		conf.Metric.Labels = map[string]string{"region": "${!json(\"region\")}"}
+		conf.Metric.Check = `this.status == "done"`
+		conf.Metric.Buckets = []float64{1, 10}
+
+		mockMetrics := &histogramRecorder{
+			buckets:  map[string][]float64{},
+			observed: map[string][]float64{},
+		}
+
+		mgr := mock.NewManager()
+		mgr.M = mockMetrics
+
+		proc, err := mgr.NewProcessor(conf)
+		require.NoError(t, err)
+
+		msg, res := proc.ProcessMessage(message.QuickBatch([][]byte{
+			[]byte(`{"status":"done","region":"eu","foo":{"bar":2.5}}`),
+			[]byte(`{"status":"pending","region":"eu","foo":{"bar":3}}`),
+			[]byte(`{"status":"done","region":"us","foo":{"bar":-4}}`),
+			[]byte(`{"status":"done","region":"eu","foo":{"bar":"nope"}}`),
+			[]byte(`{"region":"eu","foo":{"bar":5}}`),
+			[]byte(`not even json`),
+			[]byte(`{"status":"done","region":"eu","foo":{"bar":6}}`),
+		}))
+		assert.Len(t, msg, 1)
+		assert.Nil(t, res)
+
+		assert.Equal(t, map[string][]float64{
+			"foo.bar{eu}": {2.5, 6},
+			"foo.bar{us}": {-4},
+		}, mockMetrics.observed, typ)
+		if typ == "histogram" {
+			assert.Equal(t, map[string][]float64{"foo.bar": {1, 10}}, mockMetrics.buckets)
+		}
+	}
+}
+
+func TestMetricLabelCardinality(t *testing.T) {
+	conf := processor.NewConfig()
+	conf.Type = "metric"
+	conf.Metric.Type = "counter"
+	conf.Metric.Name = "foo.bar"
+	conf.Metric.Labels = map[string]string{
+		"region": "${!json(\"region\")}",
+		"type":   "${!json(\"type\")}",
+	}
+	conf.Metric.MaxLabelCardinality = 2
+
+	mockMetrics := metrics.NewLocal()
+
+	mgr := mock.NewManager()
+	mgr.M = mockMetrics
+
+	proc, err := mgr.NewProcessor(conf)
+	require.NoError(t, err)
+
+	msg, res := proc.ProcessMessage(message.QuickBatch([][]byte{
+		[]byte(`{"region":"eu","type":"a"}`),
+		[]byte(`{"region":"us","type":"a"}`),
+		[]byte(`{"region":"eu","type":"b"}`),
+		[]byte(`{"region":"eu","type":"a"}`),
+		[]byte(`{"region":"ap","type":"a"}`),
+	}))
+	assert.Len(t, msg, 1)
+	assert.Nil(t, res)
+
+	assert.Equal(t, map[string]int64{
+		`foo.bar{region="eu",type="a"}`:              2,
+		`foo.bar{region="us",type="a"}`:              1,
+		`foo.bar{region="overflow",type="overflow"}`: 2,
+	}, mockMetrics.FlushCounters())
+}
@@ -4,14 +4,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"os"
-	"path/filepath"
 
 	"github.com/benthosdev/benthos/v4/internal/bundle"
 	"github.com/benthosdev/benthos/v4/internal/component/processor"
 	"github.com/benthosdev/benthos/v4/internal/docs"
 	"github.com/benthosdev/benthos/v4/internal/log"
 	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/schema"
 
 	// nolint:staticcheck // Ignore SA1019 deprecation warning until we can switch to "google.golang.org/protobuf/types/dynamicpb"
 	"github.com/golang/protobuf/jsonpb"
@@ -19,7 +18,6 @@ import (
 	"github.com/golang/protobuf/proto"
 
 	"github.com/jhump/protoreflect/desc"
-	"github.com/jhump/protoreflect/desc/protoparse"
 	"github.com/jhump/protoreflect/dynamic"
 )
 
@@ -167,7 +165,7 @@ func newProtobufToJSONOperator(msg string, importPaths []string) (protobufOperat
 		return nil, errors.New("message field must not be empty")
 	}
 
-	descriptors, err := loadDescriptors(importPaths)
+	descriptors, err := schema.LoadProtoDescriptors(importPaths)
 	if err != nil {
 		return nil, err
 	}
@@ -202,7 +200,7 @@ func newProtobufFromJSONOperator(msg string, importPaths []string) (protobufOper
 		return nil, errors.New("message field must not be empty")
 	}
 
-	descriptors, err := loadDescriptors(importPaths)
+	descriptors, err := schema.LoadProtoDescriptors(importPaths)
 	if err != nil {
 		return nil, err
 	}
@@ -242,44 +240,6 @@ func strToProtobufOperator(opStr, message string, importPaths []string) (protobu
 	return nil, fmt.Errorf("operator not recognised: %v", opStr)
 }
 
-func loadDescriptors(importPaths []string) ([]*desc.FileDescriptor, error) {
-	var parser protoparse.Parser
-	if len(importPaths) == 0 {
-		importPaths = []string{"."}
-	} else {
-		parser.ImportPaths = importPaths
-	}
-
-	var files []string
-	for _, importPath := range importPaths {
-		if err := filepath.Walk(importPath, func(path string, info os.FileInfo, ferr error) error {
-			if ferr != nil || info.IsDir() {
-				return ferr
-			}
-			if filepath.Ext(info.Name()) == ".proto" {
-				rPath, ferr := filepath.Rel(importPath, path)
-				if ferr != nil {
-					return fmt.Errorf("failed to get relative path: %v", ferr)
-				}
-				files = append(files, rPath)
-			}
-			return nil
-		}); err != nil {
-			return nil, err
-		}
-	}
-
-	fds, err := parser.ParseFiles(files...)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse .proto file: %v", err)
-	}
-	if len(fds) == 0 {
-		return nil, fmt.Errorf("no .proto files were found in the paths '%v'", importPaths)
-	}
-
-	return fds, err
-}
-
 func getMessageFromDescriptors(message string, fds []*desc.FileDescriptor) *desc.MessageDescriptor {
 	var msg *desc.MessageDescriptor
 	for _, fd := range fds {
@@ -0,0 +1,255 @@
+package pure
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/benthosdev/benthos/v4/public/bloblang"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func qualityProcConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		Beta().
+		Categories("Utility").
+		Version("4.3.0").
+		Summary("Evaluates a set of named [Bloblang](/docs/guides/bloblang/about) assertions against each message and emits the pass and fail counts and pass ratio of each rule as metrics.").
+		Description(`
+Each rule has a `+"`check`"+` mapping that must result in a boolean, where `+"`true`"+` means the message passes the rule. A mapping that fails, for example due to a missing field, or that results in a value other than a boolean is counted as a failure of the rule. This processor never drops or modifies the contents of messages, making it possible to measure the quality of a stream of data without affecting it.
+
+### Metrics
+
+The following metrics are emitted, each labelled with the `+"`rule`"+` name:
+
+- `+"`quality_checks_passed`"+`: A counter of messages that passed the rule.
+- `+"`quality_checks_failed`"+`: A counter of messages that failed the rule.
+- `+"`quality_pass_percent`"+`: A gauge of the percentage of the most recent `+"`ratio_window`"+` messages that passed the rule, from 0 to 100.
+
+### Annotations
+
+When `+"`annotate`"+` is set to `+"`true`"+` the following metadata fields are added to each message:
+
+- `+"`quality_passed`"+`: Either `+"`true` or `false`"+` depending on whether the message passed all rules.
+- `+"`quality_failures`"+`: When any rules failed, a JSON array of the names of the failed rules.
+
+This allows you to route or reject messages of poor quality with the [`+"`switch`"+` output](/docs/components/outputs/switch) or [`+"`switch`"+` processor](/docs/components/processors/switch).`).
+		Field(service.NewObjectListField("rules",
+			service.NewStringField("name").
+				Description("A unique name of the rule, which is used to label its metrics."),
+			service.NewBloblangField("check").
+				Description("A [Bloblang mapping](/docs/guides/bloblang/about) that results in a boolean indicating whether a message passes the rule."),
+		).
+			Description("The rules to evaluate against each message.")).
+		Field(service.NewBoolField("annotate").
+			Description("Whether to add metadata fields to each message describing the rules it failed.").
+			Default(false)).
+		Field(service.NewIntField("ratio_window").
+			Description("The number of most recent evaluations of a rule that its pass percentage is calculated from.").
+			Default(1000).
+			Advanced()).
+		Example("Order Quality", `
+In order to track the quality of orders we can assert that each has an ID, a positive total and a currency we support. The metrics of each rule can be graphed directly, and orders that fail any rule are sent to a separate topic for inspection:`, `
+pipeline:
+  processors:
+    - quality:
+        annotate: true
+        rules:
+          - name: has_id
+            check: root = this.id.or("") != ""
+          - name: positive_total
+            check: root = this.total > 0
+          - name: known_currency
+            check: root = [ "EUR", "GBP", "USD" ].contains(this.currency)
+
+output:
+  switch:
+    cases:
+      - check: meta("quality_passed") == "false"
+        output:
+          kafka:
+            addresses: [ localhost:9092 ]
+            topic: orders_quarantine
+      - output:
+          kafka:
+            addresses: [ localhost:9092 ]
+            topic: orders_clean
+`)
+}
+
+func init() {
+	err := service.RegisterBatchProcessor(
+		"quality", qualityProcConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.BatchProcessor, error) {
+			return newQualityFromParsed(conf, mgr)
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// qualityRule is a named check along with a ring buffer of its most recent
+// results, which the pass percentage is calculated from.
+type qualityRule struct {
+	name  string
+	check *bloblang.Executor
+
+	results []bool
+	next    int
+	filled  bool
+	passes  int
+}
+
+func (r *qualityRule) record(passed bool) {
+	if r.filled && r.results[r.next] {
+		r.passes--
+	}
+	r.results[r.next] = passed
+	if passed {
+		r.passes++
+	}
+	if r.next++; r.next == len(r.results) {
+		r.next = 0
+		r.filled = true
+	}
+}
+
+func (r *qualityRule) passPercent() int64 {
+	total := r.next
+	if r.filled {
+		total = len(r.results)
+	}
+	if total == 0 {
+		return 0
+	}
+	return int64(r.passes * 100 / total)
+}
+
+type quality struct {
+	rules    []*qualityRule
+	annotate bool
+
+	log     *service.Logger
+	mPassed *service.MetricCounter
+	mFailed *service.MetricCounter
+	mRatio  *service.MetricGauge
+
+	mut sync.Mutex
+}
+
+func newQualityFromParsed(conf *service.ParsedConfig, mgr *service.Resources) (*quality, error) {
+	q := &quality{
+		log:     mgr.Logger(),
+		mPassed: mgr.Metrics().NewCounter("quality_checks_passed", "rule"),
+		mFailed: mgr.Metrics().NewCounter("quality_checks_failed", "rule"),
+		mRatio:  mgr.Metrics().NewGauge("quality_pass_percent", "rule"),
+	}
+
+	var err error
+	if q.annotate, err = conf.FieldBool("annotate"); err != nil {
+		return nil, err
+	}
+
+	window, err := conf.FieldInt("ratio_window")
+	if err != nil {
+		return nil, err
+	}
+	if window < 1 {
+		return nil, errors.New("ratio_window must be at least 1")
+	}
+
+	ruleConfs, err := conf.FieldObjectList("rules")
+	if err != nil {
+		return nil, err
+	}
+	if len(ruleConfs) == 0 {
+		return nil, errors.New("at least one rule must be specified")
+	}
+
+	names := map[string]struct{}{}
+	for i, rConf := range ruleConfs {
+		r := &qualityRule{results: make([]bool, window)}
+		if r.name, err = rConf.FieldString("name"); err != nil {
+			return nil, err
+		}
+		if r.name == "" {
+			return nil, fmt.Errorf("rule %v must have a name", i)
+		}
+		if _, exists := names[r.name]; exists {
+			return nil, fmt.Errorf("rule name %v is not unique", r.name)
+		}
+		names[r.name] = struct{}{}
+		if r.check, err = rConf.FieldBloblang("check"); err != nil {
+			return nil, err
+		}
+		q.rules = append(q.rules, r)
+	}
+	return q, nil
+}
+
+func (q *quality) evaluate(r *qualityRule, i int, batch service.MessageBatch) error {
+	res, err := batch.BloblangQuery(i, r.check)
+	if err != nil {
+		return err
+	}
+	if res == nil {
+		return errors.New("check resulted in a deleted message")
+	}
+
+	v, err := res.AsStructured()
+	if err != nil {
+		return err
+	}
+	passed, ok := v.(bool)
+	if !ok {
+		return fmt.Errorf("expected check to result in a boolean, got %T", v)
+	}
+	if !passed {
+		return errors.New("check returned false")
+	}
+	return nil
+}
+
+func (q *quality) ProcessBatch(ctx context.Context, batch service.MessageBatch) ([]service.MessageBatch, error) {
+	q.mut.Lock()
+	defer q.mut.Unlock()
+
+	for i, msg := range batch {
+		var failures []string
+		for _, r := range q.rules {
+			err := q.evaluate(r, i, batch)
+			r.record(err == nil)
+			if err == nil {
+				q.mPassed.Incr(1, r.name)
+				continue
+			}
+			q.log.Debugf("Message failed quality rule %v: %v", r.name, err)
+			q.mFailed.Incr(1, r.name)
+			failures = append(failures, r.name)
+		}
+
+		if !q.annotate {
+			continue
+		}
+		if len(failures) == 0 {
+			msg.MetaSet("quality_passed", "true")
+			continue
+		}
+		msg.MetaSet("quality_passed", "false")
+		fBytes, _ := json.Marshal(failures)
+		msg.MetaSet("quality_failures", string(fBytes))
+	}
+
+	for _, r := range q.rules {
+		q.mRatio.Set(r.passPercent(), r.name)
+	}
+	return []service.MessageBatch{batch}, nil
+}
+
+func (q *quality) Close(ctx context.Context) error {
+	return nil
+}
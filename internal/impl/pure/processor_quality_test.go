@@ -0,0 +1,145 @@
+package pure
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func newQualityFromYAML(t *testing.T, confStr string) *quality {
+	t.Helper()
+
+	conf, err := qualityProcConfig().ParseYAML(confStr, nil)
+	require.NoError(t, err)
+
+	proc, err := newQualityFromParsed(conf, service.MockResources())
+	require.NoError(t, err)
+	return proc
+}
+
+func TestQualityAnnotate(t *testing.T) {
+	proc := newQualityFromYAML(t, `
+annotate: true
+rules:
+  - name: has_id
+    check: root = this.id.or("") != ""
+  - name: positive_total
+    check: root = this.total > 0
+`)
+
+	res, err := proc.ProcessBatch(context.Background(), service.MessageBatch{
+		service.NewMessage([]byte(`{"id":"foo","total":10}`)),
+		service.NewMessage([]byte(`{"id":"bar","total":-1}`)),
+		service.NewMessage([]byte(`{"total":"nope"}`)),
+		service.NewMessage([]byte(`not json`)),
+	})
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	require.Len(t, res[0], 4)
+
+	for i, exp := range []struct {
+		passed   string
+		failures string
+	}{
+		{passed: "true"},
+		{passed: "false", failures: `["positive_total"]`},
+		{passed: "false", failures: `["has_id","positive_total"]`},
+		{passed: "false", failures: `["has_id","positive_total"]`},
+	} {
+		v, _ := res[0][i].MetaGet("quality_passed")
+		assert.Equal(t, exp.passed, v, i)
+		v, _ = res[0][i].MetaGet("quality_failures")
+		assert.Equal(t, exp.failures, v, i)
+		assert.NoError(t, res[0][i].GetError(), i)
+	}
+
+	b, err := res[0][3].AsBytes()
+	require.NoError(t, err)
+	assert.Equal(t, "not json", string(b))
+
+	assert.Equal(t, int64(50), proc.rules[0].passPercent())
+	assert.Equal(t, int64(25), proc.rules[1].passPercent())
+}
+
+func TestQualityNoAnnotate(t *testing.T) {
+	proc := newQualityFromYAML(t, `
+rules:
+  - name: is_string
+    check: root = this.type() == "string"
+`)
+
+	res, err := proc.ProcessBatch(context.Background(), service.MessageBatch{
+		service.NewMessage([]byte(`5`)),
+	})
+	require.NoError(t, err)
+	require.Len(t, res[0], 1)
+
+	_, exists := res[0][0].MetaGet("quality_passed")
+	assert.False(t, exists)
+	assert.Equal(t, int64(0), proc.rules[0].passPercent())
+}
+
+func TestQualityRatioWindow(t *testing.T) {
+	proc := newQualityFromYAML(t, `
+ratio_window: 4
+rules:
+  - name: is_even
+    check: root = this % 2 == 0
+`)
+
+	for _, v := range []string{"1", "1", "1", "1", "2", "2", "2"} {
+		_, err := proc.ProcessBatch(context.Background(), service.MessageBatch{
+			service.NewMessage([]byte(v)),
+		})
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int64(75), proc.rules[0].passPercent())
+}
+
+func TestQualityConfigErrors(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		config string
+		err    string
+	}{
+		{
+			name:   "no rules",
+			config: `rules: []`,
+			err:    "at least one rule must be specified",
+		},
+		{
+			name: "duplicate names",
+			config: `
+rules:
+  - name: foo
+    check: root = true
+  - name: foo
+    check: root = false
+`,
+			err: "rule name foo is not unique",
+		},
+		{
+			name: "bad window",
+			config: `
+ratio_window: 0
+rules:
+  - name: foo
+    check: root = true
+`,
+			err: "ratio_window must be at least 1",
+		},
+	} {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			conf, err := qualityProcConfig().ParseYAML(test.config, nil)
+			require.NoError(t, err)
+
+			_, err = newQualityFromParsed(conf, service.MockResources())
+			require.EqualError(t, err, test.err)
+		})
+	}
+}
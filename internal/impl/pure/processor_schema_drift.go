@@ -0,0 +1,511 @@
+package pure
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func schemaDriftProcConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		Beta().
+		Categories("Utility").
+		Version("4.3.0").
+		Summary("Infers the schema of JSON messages over a sliding sample of each stream, and flags messages that introduce new fields or change the type of existing fields.").
+		Description(`
+The structure of each message is broken down into the paths of its fields and the types of their values, and the schema of a stream is inferred from the paths and types seen within its most recent `+"`sample_size`"+` messages. A message drifts from the schema when it contains a path that isn't part of the schema, or a value of a type that hasn't been seen for the path. Drift is only flagged once a stream has seen at least `+"`min_samples`"+` messages, which gives the schema a chance to establish itself.
+
+Paths are expressed in the same form as [Bloblang](/docs/guides/bloblang/about) paths, where the message itself is `+"`this`"+` and the elements of arrays share the path segment `+"`*`"+`, and the types of values are one of `+"`object`, `array`, `string`, `number`, `boolean` or `null`"+`. Null values are ignored when detecting type changes, so that optional fields don't cause drift whenever they are set or unset.
+
+This processor never drops or modifies the contents of messages, instead it adds the following metadata fields to each message once the stream has seen enough messages:
+
+- `+"`schema_drift`"+`: Either `+"`true` or `false`"+`.
+- `+"`schema_drift_changes`"+`: When drift is detected, a JSON array describing each change, of the form `+"`{\"path\":\"user.age\",\"change\":\"type_changed\",\"type\":\"string\",\"previous_types\":[\"number\"]}`"+`, where the change is either `+"`field_added` or `type_changed`"+`.
+
+Each change is also logged at the warn level and counted with the metric `+"`schema_drift`"+`, which is labelled with the kind of change. Messages that cannot be parsed as JSON are flagged as having failed, and can be handled with [standard error handling patterns](/docs/configuration/error_handling).
+
+### Publishing Schemas
+
+When a `+"`cache`"+` is configured the schema of a stream is written to it as a [JSON Schema](https://json-schema.org/) document whenever the sample introduces or drops a path or type, under the key `+"`key_prefix`"+` followed by the stream. This makes it possible to share the inferred schemas with other systems through any cache resource, such as Redis or DynamoDB.
+
+### Performance
+
+The samples are held in memory by each instance of this processor, and are therefore lost on restart and not shared across pipeline threads. The number of streams tracked is capped by `+"`max_streams`"+`, where the least recently seen stream is evicted when the cap is reached.`).
+		Field(service.NewInterpolatedStringField("stream").
+			Description("An interpolated string yielding the logical stream that a message belongs to, where each stream has a schema of its own. When empty all messages share the same schema.").
+			Example(`${! meta("kafka_topic") }`).
+			Example(`${! json("event_type") }`).
+			Default("")).
+		Field(service.NewIntField("sample_size").
+			Description("The number of most recent messages of a stream that its schema is inferred from. Paths and types that have not been seen within the sample are dropped from the schema.").
+			Default(1000)).
+		Field(service.NewIntField("min_samples").
+			Description("The number of messages a stream must have seen before drift is flagged.").
+			Default(10).
+			Advanced()).
+		Field(service.NewStringField("cache").
+			Description("An optional cache resource to publish the inferred schema of each stream to.").
+			Default("")).
+		Field(service.NewStringField("key_prefix").
+			Description("A prefix to add to the stream in order to form the key that its schema is published under.").
+			Default("schema_").
+			Advanced()).
+		Field(service.NewIntField("max_streams").
+			Description("The maximum number of streams to maintain schemas for, after which the least recently seen stream is evicted.").
+			Default(1000).
+			Advanced()).
+		Example("Contract Breaks", `
+In order to be warned when producers of the topics we consume change the shape of their events we can flag drift per topic, publish the inferred schemas to Redis, and send a summary of each drift to an alerts topic, with the following config:`, `
+input:
+  kafka:
+    addresses: [ localhost:9092 ]
+    topics: [ orders, payments ]
+    consumer_group: benthos_drift
+
+pipeline:
+  processors:
+    - schema_drift:
+        stream: ${! meta("kafka_topic") }
+        cache: schemas
+
+output:
+  switch:
+    cases:
+      - check: meta("schema_drift") == "true"
+        output:
+          kafka:
+            addresses: [ localhost:9092 ]
+            topic: schema_alerts
+          processors:
+            - bloblang: |
+                root.topic = meta("kafka_topic")
+                root.changes = meta("schema_drift_changes").parse_json()
+      - output:
+          drop: {}
+
+cache_resources:
+  - label: schemas
+    redis:
+      url: tcp://localhost:6379
+`)
+}
+
+func init() {
+	err := service.RegisterProcessor(
+		"schema_drift", schemaDriftProcConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Processor, error) {
+			return newSchemaDriftFromParsed(conf, mgr)
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+// schemaField is a path and the type of a value found at it, where each segment
+// of the path is either a field name or an array element.
+type schemaField struct {
+	segments []schemaSegment
+	path     string
+	kind     string
+}
+
+type schemaSegment struct {
+	name string
+	item bool
+}
+
+func (f schemaField) id() string {
+	return f.path + ":" + f.kind
+}
+
+func schemaPathSegment(name string) string {
+	if name == "" || strings.ContainsAny(name, ".\"* \t\n") {
+		return strconv.Quote(name)
+	}
+	return name
+}
+
+func schemaValueKind(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	}
+	return "number"
+}
+
+// schemaFieldsOf walks a structured value and returns the unique paths and
+// types of all values within it, including the root value at the path `this`.
+func schemaFieldsOf(root interface{}) []schemaField {
+	seen := map[string]struct{}{}
+	var fields []schemaField
+
+	var walk func(segments []schemaSegment, path string, v interface{})
+	walk = func(segments []schemaSegment, path string, v interface{}) {
+		f := schemaField{segments: segments, path: path, kind: schemaValueKind(v)}
+		if _, exists := seen[f.id()]; !exists {
+			seen[f.id()] = struct{}{}
+			fields = append(fields, f)
+		}
+
+		child := func(seg schemaSegment, segStr string, cv interface{}) {
+			childSegments := make([]schemaSegment, len(segments), len(segments)+1)
+			copy(childSegments, segments)
+			childSegments = append(childSegments, seg)
+			childPath := segStr
+			if len(segments) > 0 {
+				childPath = path + "." + segStr
+			}
+			walk(childSegments, childPath, cv)
+		}
+
+		switch t := v.(type) {
+		case map[string]interface{}:
+			for k, cv := range t {
+				child(schemaSegment{name: k}, schemaPathSegment(k), cv)
+			}
+		case []interface{}:
+			for _, cv := range t {
+				child(schemaSegment{item: true}, "*", cv)
+			}
+		}
+	}
+	walk(nil, "this", root)
+
+	sort.Slice(fields, func(i, j int) bool {
+		if fields[i].path == fields[j].path {
+			return fields[i].kind < fields[j].kind
+		}
+		return fields[i].path < fields[j].path
+	})
+	return fields
+}
+
+//------------------------------------------------------------------------------
+
+type schemaFieldCount struct {
+	field schemaField
+	count int
+}
+
+// schemaStream holds the sliding sample of a stream as the fields of each
+// message, along with the number of messages within the sample each field
+// appears in.
+type schemaStream struct {
+	name    string
+	seen    int
+	sample  [][]schemaField
+	next    int
+	counts  map[string]*schemaFieldCount
+	byPath  map[string]map[string]struct{}
+	changed bool
+}
+
+func newSchemaStream(name string) *schemaStream {
+	return &schemaStream{
+		name:   name,
+		counts: map[string]*schemaFieldCount{},
+		byPath: map[string]map[string]struct{}{},
+	}
+}
+
+func (s *schemaStream) add(f schemaField) {
+	c, exists := s.counts[f.id()]
+	if !exists {
+		c = &schemaFieldCount{field: f}
+		s.counts[f.id()] = c
+		kinds, exists := s.byPath[f.path]
+		if !exists {
+			kinds = map[string]struct{}{}
+			s.byPath[f.path] = kinds
+		}
+		kinds[f.kind] = struct{}{}
+		s.changed = true
+	}
+	c.count++
+}
+
+func (s *schemaStream) remove(f schemaField) {
+	c := s.counts[f.id()]
+	if c.count--; c.count > 0 {
+		return
+	}
+	delete(s.counts, f.id())
+	kinds := s.byPath[f.path]
+	delete(kinds, f.kind)
+	if len(kinds) == 0 {
+		delete(s.byPath, f.path)
+	}
+	s.changed = true
+}
+
+// schemaChange describes a difference between the fields of a message and the
+// schema of its stream.
+type schemaChange struct {
+	Path          string   `json:"path"`
+	Change        string   `json:"change"`
+	Type          string   `json:"type"`
+	PreviousTypes []string `json:"previous_types,omitempty"`
+}
+
+// diff returns the fields of a message that aren't part of the schema.
+func (s *schemaStream) diff(fields []schemaField) []schemaChange {
+	var changes []schemaChange
+	for _, f := range fields {
+		kinds, exists := s.byPath[f.path]
+		if !exists {
+			changes = append(changes, schemaChange{Path: f.path, Change: "field_added", Type: f.kind})
+			continue
+		}
+		if _, seen := kinds[f.kind]; seen || f.kind == "null" {
+			continue
+		}
+		previous := make([]string, 0, len(kinds))
+		for k := range kinds {
+			if k != "null" {
+				previous = append(previous, k)
+			}
+		}
+		if len(previous) == 0 {
+			// A path that has only ever been null is yet to show its type.
+			continue
+		}
+		sort.Strings(previous)
+		changes = append(changes, schemaChange{Path: f.path, Change: "type_changed", Type: f.kind, PreviousTypes: previous})
+	}
+	return changes
+}
+
+// observe adds the fields of a message to the sample, evicting the fields of
+// the oldest message once the sample is full.
+func (s *schemaStream) observe(fields []schemaField, sampleSize int) {
+	s.seen++
+	if len(s.sample) < sampleSize {
+		s.sample = append(s.sample, fields)
+	} else {
+		for _, f := range s.sample[s.next] {
+			s.remove(f)
+		}
+		s.sample[s.next] = fields
+		s.next = (s.next + 1) % sampleSize
+	}
+	for _, f := range fields {
+		s.add(f)
+	}
+}
+
+type schemaNode struct {
+	kinds map[string]struct{}
+	props map[string]*schemaNode
+	items *schemaNode
+}
+
+func (n *schemaNode) child(seg schemaSegment) *schemaNode {
+	if seg.item {
+		if n.items == nil {
+			n.items = &schemaNode{}
+		}
+		return n.items
+	}
+	if n.props == nil {
+		n.props = map[string]*schemaNode{}
+	}
+	c, exists := n.props[seg.name]
+	if !exists {
+		c = &schemaNode{}
+		n.props[seg.name] = c
+	}
+	return c
+}
+
+func (n *schemaNode) jsonSchema() map[string]interface{} {
+	obj := map[string]interface{}{}
+	kinds := make([]string, 0, len(n.kinds))
+	for k := range n.kinds {
+		kinds = append(kinds, k)
+	}
+	sort.Strings(kinds)
+	if len(kinds) == 1 {
+		obj["type"] = kinds[0]
+	} else if len(kinds) > 1 {
+		obj["type"] = kinds
+	}
+	if n.props != nil {
+		props := make(map[string]interface{}, len(n.props))
+		for k, c := range n.props {
+			props[k] = c.jsonSchema()
+		}
+		obj["properties"] = props
+	}
+	if n.items != nil {
+		obj["items"] = n.items.jsonSchema()
+	}
+	return obj
+}
+
+// jsonSchema returns the schema of the stream as a JSON Schema document.
+func (s *schemaStream) jsonSchema() ([]byte, error) {
+	root := &schemaNode{}
+	for _, c := range s.counts {
+		n := root
+		for _, seg := range c.field.segments {
+			n = n.child(seg)
+		}
+		if n.kinds == nil {
+			n.kinds = map[string]struct{}{}
+		}
+		n.kinds[c.field.kind] = struct{}{}
+	}
+	doc := root.jsonSchema()
+	doc["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	return json.Marshal(doc)
+}
+
+//------------------------------------------------------------------------------
+
+type schemaDrift struct {
+	stream     *service.InterpolatedString
+	sampleSize int
+	minSamples int
+	cacheName  string
+	keyPrefix  string
+	maxStreams int
+
+	mgr        *service.Resources
+	log        *service.Logger
+	mDriftKind *service.MetricCounter
+
+	mut     sync.Mutex
+	streams map[string]*list.Element
+	order   *list.List
+}
+
+func newSchemaDriftFromParsed(conf *service.ParsedConfig, mgr *service.Resources) (*schemaDrift, error) {
+	s := &schemaDrift{
+		mgr:        mgr,
+		log:        mgr.Logger(),
+		mDriftKind: mgr.Metrics().NewCounter("schema_drift", "change"),
+		streams:    map[string]*list.Element{},
+		order:      list.New(),
+	}
+
+	var err error
+	if s.stream, err = conf.FieldInterpolatedString("stream"); err != nil {
+		return nil, err
+	}
+	if s.sampleSize, err = conf.FieldInt("sample_size"); err != nil {
+		return nil, err
+	}
+	if s.sampleSize < 1 {
+		return nil, errors.New("sample_size must be at least 1")
+	}
+	if s.minSamples, err = conf.FieldInt("min_samples"); err != nil {
+		return nil, err
+	}
+	if s.minSamples < 1 {
+		return nil, errors.New("min_samples must be at least 1")
+	}
+	if s.cacheName, err = conf.FieldString("cache"); err != nil {
+		return nil, err
+	}
+	if s.cacheName != "" && !mgr.HasCache(s.cacheName) {
+		return nil, fmt.Errorf("cache named %v not found", s.cacheName)
+	}
+	if s.keyPrefix, err = conf.FieldString("key_prefix"); err != nil {
+		return nil, err
+	}
+	if s.maxStreams, err = conf.FieldInt("max_streams"); err != nil {
+		return nil, err
+	}
+	if s.maxStreams < 1 {
+		return nil, errors.New("max_streams must be at least 1")
+	}
+	return s, nil
+}
+
+// streamFor returns the sample of a stream, creating it and evicting the least
+// recently seen stream when necessary. Must be called whilst holding mut.
+func (s *schemaDrift) streamFor(name string) *schemaStream {
+	if e, exists := s.streams[name]; exists {
+		s.order.MoveToFront(e)
+		return e.Value.(*schemaStream)
+	}
+	if s.order.Len() >= s.maxStreams {
+		oldest := s.order.Back()
+		s.order.Remove(oldest)
+		delete(s.streams, oldest.Value.(*schemaStream).name)
+	}
+	st := newSchemaStream(name)
+	s.streams[name] = s.order.PushFront(st)
+	return st
+}
+
+func (s *schemaDrift) publish(ctx context.Context, st *schemaStream) {
+	schemaBytes, err := st.jsonSchema()
+	if err == nil {
+		if cerr := s.mgr.AccessCache(ctx, s.cacheName, func(c service.Cache) {
+			err = c.Set(ctx, s.keyPrefix+st.name, schemaBytes, nil)
+		}); cerr != nil {
+			err = cerr
+		}
+	}
+	if err != nil {
+		s.log.Errorf("Failed to publish schema of stream '%v': %v", st.name, err)
+	}
+}
+
+func (s *schemaDrift) Process(ctx context.Context, msg *service.Message) (service.MessageBatch, error) {
+	structured, err := msg.AsStructured()
+	if err != nil {
+		s.log.Debugf("Failed to parse message for schema inference: %v", err)
+		msg.SetError(fmt.Errorf("failed to parse message as JSON: %w", err))
+		return service.MessageBatch{msg}, nil
+	}
+	fields := schemaFieldsOf(structured)
+	name := s.stream.String(msg)
+
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	st := s.streamFor(name)
+	if st.seen >= s.minSamples {
+		changes := st.diff(fields)
+		msg.MetaSet("schema_drift", strconv.FormatBool(len(changes) > 0))
+		if len(changes) > 0 {
+			changesBytes, _ := json.Marshal(changes)
+			msg.MetaSet("schema_drift_changes", string(changesBytes))
+			for _, c := range changes {
+				s.mDriftKind.Incr(1, c.Change)
+				s.log.Warnf("Schema drift in stream '%v': %v at path '%v' with type %v", name, c.Change, c.Path, c.Type)
+			}
+		}
+	}
+
+	st.changed = false
+	st.observe(fields, s.sampleSize)
+	if st.changed && s.cacheName != "" {
+		s.publish(ctx, st)
+	}
+	return service.MessageBatch{msg}, nil
+}
+
+func (s *schemaDrift) Close(ctx context.Context) error {
+	return nil
+}
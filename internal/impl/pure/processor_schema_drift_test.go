@@ -0,0 +1,175 @@
+package pure
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func newSchemaDriftFromYAML(t *testing.T, confStr string, res *service.Resources) *schemaDrift {
+	t.Helper()
+
+	conf, err := schemaDriftProcConfig().ParseYAML(confStr, nil)
+	require.NoError(t, err)
+
+	proc, err := newSchemaDriftFromParsed(conf, res)
+	require.NoError(t, err)
+	return proc
+}
+
+func schemaDriftProcess(t *testing.T, proc *schemaDrift, content string) *service.Message {
+	t.Helper()
+
+	res, err := proc.Process(context.Background(), service.NewMessage([]byte(content)))
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	return res[0]
+}
+
+func TestSchemaFieldsOf(t *testing.T) {
+	var paths []string
+	for _, f := range schemaFieldsOf(map[string]interface{}{
+		"id":      1.0,
+		"a.b":     "foo",
+		"tags":    []interface{}{"a", "b", 1.0},
+		"user":    map[string]interface{}{"name": "foo", "age": nil},
+		"enabled": true,
+	}) {
+		paths = append(paths, f.id())
+	}
+	assert.Equal(t, []string{
+		`"a.b":string`,
+		`enabled:boolean`,
+		`id:number`,
+		`tags:array`,
+		`tags.*:number`,
+		`tags.*:string`,
+		`this:object`,
+		`user:object`,
+		`user.age:null`,
+		`user.name:string`,
+	}, paths)
+}
+
+func TestSchemaDriftDetection(t *testing.T) {
+	proc := newSchemaDriftFromYAML(t, `
+stream: ${! meta("topic") }
+min_samples: 2
+`, service.MockResources())
+
+	msg := schemaDriftProcess(t, proc, `{"id":1,"name":"foo"}`)
+	_, exists := msg.MetaGet("schema_drift")
+	assert.False(t, exists)
+
+	schemaDriftProcess(t, proc, `{"id":2,"name":"bar","email":null}`)
+
+	msg = schemaDriftProcess(t, proc, `{"id":3,"name":"baz","email":"a@b.c"}`)
+	v, _ := msg.MetaGet("schema_drift")
+	assert.Equal(t, "false", v)
+
+	msg = schemaDriftProcess(t, proc, `{"id":"4","name":"baz","address":{"city":"foo"}}`)
+	v, _ = msg.MetaGet("schema_drift")
+	assert.Equal(t, "true", v)
+	v, _ = msg.MetaGet("schema_drift_changes")
+	assert.JSONEq(t, `[
+  {"path":"address","change":"field_added","type":"object"},
+  {"path":"address.city","change":"field_added","type":"string"},
+  {"path":"id","change":"type_changed","type":"string","previous_types":["number"]}
+]`, v)
+
+	// Changes are learned once seen.
+	msg = schemaDriftProcess(t, proc, `{"id":"5","name":"baz","address":{"city":"bar"}}`)
+	v, _ = msg.MetaGet("schema_drift")
+	assert.Equal(t, "false", v)
+}
+
+func TestSchemaDriftSlidingSample(t *testing.T) {
+	proc := newSchemaDriftFromYAML(t, `
+sample_size: 2
+min_samples: 1
+`, service.MockResources())
+
+	schemaDriftProcess(t, proc, `{"a":1}`)
+	schemaDriftProcess(t, proc, `{"b":1}`)
+	schemaDriftProcess(t, proc, `{"b":2}`)
+
+	// The field a has been evicted from the sample.
+	msg := schemaDriftProcess(t, proc, `{"a":1}`)
+	v, _ := msg.MetaGet("schema_drift")
+	assert.Equal(t, "true", v)
+}
+
+func TestSchemaDriftStreams(t *testing.T) {
+	proc := newSchemaDriftFromYAML(t, `
+stream: ${! json("type") }
+min_samples: 1
+max_streams: 1
+`, service.MockResources())
+
+	schemaDriftProcess(t, proc, `{"type":"foo","a":1}`)
+	msg := schemaDriftProcess(t, proc, `{"type":"foo","a":2}`)
+	v, _ := msg.MetaGet("schema_drift")
+	assert.Equal(t, "false", v)
+
+	msg = schemaDriftProcess(t, proc, `{"type":"bar","b":1}`)
+	_, exists := msg.MetaGet("schema_drift")
+	assert.False(t, exists)
+
+	// The stream foo was evicted when bar was seen.
+	msg = schemaDriftProcess(t, proc, `{"type":"foo","a":1}`)
+	_, exists = msg.MetaGet("schema_drift")
+	assert.False(t, exists)
+	assert.Len(t, proc.streams, 1)
+}
+
+func TestSchemaDriftPublish(t *testing.T) {
+	res := service.MockResources(service.MockResourcesOptAddCache("foocache"))
+	proc := newSchemaDriftFromYAML(t, `
+stream: ${! json("type") }
+cache: foocache
+`, res)
+
+	schemaDriftProcess(t, proc, `{"type":"orders","id":1,"items":[{"sku":"a"}]}`)
+	schemaDriftProcess(t, proc, `{"type":"orders","id":"2","items":[]}`)
+
+	var schemaBytes []byte
+	var err error
+	require.NoError(t, res.AccessCache(context.Background(), "foocache", func(c service.Cache) {
+		schemaBytes, err = c.Get(context.Background(), "schema_orders")
+	}))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "type": "object",
+  "properties": {
+    "type": {"type": "string"},
+    "id": {"type": ["number", "string"]},
+    "items": {
+      "type": "array",
+      "items": {
+        "type": "object",
+        "properties": {
+          "sku": {"type": "string"}
+        }
+      }
+    }
+  }
+}`, string(schemaBytes))
+}
+
+func TestSchemaDriftErrors(t *testing.T) {
+	proc := newSchemaDriftFromYAML(t, `min_samples: 1`, service.MockResources())
+
+	msg := schemaDriftProcess(t, proc, `not json`)
+	assert.Error(t, msg.GetError())
+
+	conf, err := schemaDriftProcConfig().ParseYAML(`cache: nope`, nil)
+	require.NoError(t, err)
+
+	_, err = newSchemaDriftFromParsed(conf, service.MockResources())
+	require.EqualError(t, err, "cache named nope not found")
+}
@@ -2,6 +2,7 @@ package pure
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sort"
 	"strconv"
@@ -9,6 +10,7 @@ import (
 
 	"github.com/benthosdev/benthos/v4/internal/bloblang/mapping"
 	"github.com/benthosdev/benthos/v4/internal/bundle"
+	"github.com/benthosdev/benthos/v4/internal/cel"
 	"github.com/benthosdev/benthos/v4/internal/component/processor"
 	"github.com/benthosdev/benthos/v4/internal/docs"
 	"github.com/benthosdev/benthos/v4/internal/log"
@@ -31,7 +33,9 @@ func init() {
 		Summary: `
 Conditionally processes messages based on their contents.`,
 		Description: `
-For each switch case a [Bloblang query](/docs/guides/bloblang/about/) is checked and, if the result is true (or the check is empty) the child processors are executed on the message.`,
+For each switch case a [Bloblang query](/docs/guides/bloblang/about/) is checked and, if the result is true (or the check is empty) the child processors are executed on the message.
+
+Cases can alternatively be checked with a [CEL expression](https://github.com/google/cel-spec) specified with the field ` + "`cel`" + `, which is useful when policy expressions are shared with other systems that standardise on CEL.`,
 		Footnotes: `
 ## Batching
 
@@ -45,6 +49,11 @@ At the end of switch processing the resulting batch will follow the same orderin
 				`this.type == "foo"`,
 				`this.contents.urls.contains("https://benthos.dev/")`,
 			).HasDefault(""),
+			cel.FieldSpec(
+				"cel",
+				"A [CEL expression](https://github.com/google/cel-spec) that should return a boolean value indicating whether a message should have the processors of this case executed on it, which can be used instead of `check`.",
+				`this.type == "foo" && meta.topic.startsWith("events")`,
+			),
 			docs.FieldProcessor(
 				"processors",
 				"A list of [processors](/docs/components/processors/about/) to execute on a message.",
@@ -86,10 +95,35 @@ pipeline:
 	}
 }
 
+// caseCheck is a condition tested against individual messages of a batch,
+// which is implemented by both Bloblang queries and CEL predicates.
+type caseCheck interface {
+	QueryPart(index int, msg mapping.Message) (bool, error)
+}
+
+// newCaseCheck returns a check from either a Bloblang query or a CEL
+// expression, or nil when neither is specified.
+func newCaseCheck(mgr bundle.NewManagement, check, celExpr string) (caseCheck, error) {
+	if check != "" && celExpr != "" {
+		return nil, errors.New("cannot specify both a check and a cel expression")
+	}
+	if check != "" {
+		return mgr.BloblEnvironment().NewMapping(check)
+	}
+	if celExpr != "" {
+		p, err := cel.NewPredicate(celExpr)
+		if err != nil {
+			return nil, fmt.Errorf("cel: %w", err)
+		}
+		return p, nil
+	}
+	return nil, nil
+}
+
 // switchCase contains a condition, processors and other fields for an
 // individual case in the Switch processor.
 type switchCase struct {
-	check       *mapping.Executor
+	check       caseCheck
 	processors  []processor.V1
 	fallThrough bool
 }
@@ -103,13 +137,11 @@ func newSwitchProc(conf processor.SwitchConfig, mgr bundle.NewManagement) (*swit
 	var cases []switchCase
 	for i, caseConf := range conf {
 		var err error
-		var check *mapping.Executor
+		var check caseCheck
 		var procs []processor.V1
 
-		if len(caseConf.Check) > 0 {
-			if check, err = mgr.BloblEnvironment().NewMapping(caseConf.Check); err != nil {
-				return nil, fmt.Errorf("failed to parse case %v check: %w", i, err)
-			}
+		if check, err = newCaseCheck(mgr, caseConf.Check, caseConf.CEL); err != nil {
+			return nil, fmt.Errorf("failed to parse case %v check: %w", i, err)
 		}
 
 		if len(caseConf.Processors) == 0 {
@@ -190,6 +190,76 @@ func TestSwitchError(t *testing.T) {
 	}, resStrs)
 }
 
+func TestSwitchCEL(t *testing.T) {
+	conf := processor.NewConfig()
+	conf.Type = "switch"
+
+	procConf := processor.NewConfig()
+	procConf.Type = "bloblang"
+	procConf.Bloblang = `root = "Hit case 0: " + content().string()`
+
+	conf.Switch = append(conf.Switch, processor.SwitchCaseConfig{
+		CEL:        `this.id == "foo" && meta.topic == "bar"`,
+		Processors: []processor.Config{procConf},
+	})
+
+	procConf = processor.NewConfig()
+	procConf.Type = "bloblang"
+	procConf.Bloblang = `root = "Hit case 1: " + content().string()`
+
+	conf.Switch = append(conf.Switch, processor.SwitchCaseConfig{
+		Check:      `this.id == "baz"`,
+		Processors: []processor.Config{procConf},
+	})
+
+	c, err := mock.NewManager().NewProcessor(conf)
+	require.NoError(t, err)
+
+	defer func() {
+		c.CloseAsync()
+		assert.NoError(t, c.WaitForClose(time.Second))
+	}()
+
+	msg := message.QuickBatch(nil)
+	msg.Append(message.NewPart([]byte(`{"id":"foo"}`)))
+	msg.Get(0).MetaSet("topic", "bar")
+	msg.Append(message.NewPart([]byte(`{"id":"foo"}`)))
+	msg.Get(1).MetaSet("topic", "buz")
+	msg.Append(message.NewPart([]byte(`{"id":"baz"}`)))
+	msg.Append(message.NewPart([]byte(`not json`)))
+
+	msgs, res := c.ProcessMessage(msg)
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+
+	resStrs := []string{}
+	for _, b := range message.GetAllBytes(msgs[0]) {
+		resStrs = append(resStrs, string(b))
+	}
+
+	assert.Equal(t, []string{
+		`Hit case 0: {"id":"foo"}`,
+		`{"id":"foo"}`,
+		`Hit case 1: {"id":"baz"}`,
+		`not json`,
+	}, resStrs)
+	assert.Error(t, msgs[0].Get(3).ErrorGet())
+}
+
+func TestSwitchCheckAndCEL(t *testing.T) {
+	conf := processor.NewConfig()
+	conf.Type = "switch"
+	conf.Switch = append(conf.Switch, processor.SwitchCaseConfig{
+		Check:      `this.id == "foo"`,
+		CEL:        `this.id == "foo"`,
+		Processors: []processor.Config{processor.NewConfig()},
+	})
+
+	_, err := mock.NewManager().NewProcessor(conf)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "cannot specify both")
+}
+
 func BenchmarkSwitch10(b *testing.B) {
 	conf := processor.NewConfig()
 	conf.Type = "switch"
@@ -4,6 +4,7 @@ import (
 	"archive/tar"
 	"archive/zip"
 	"bytes"
+	"compress/gzip"
 	"context"
 	"encoding/csv"
 	"encoding/json"
@@ -11,6 +12,8 @@ import (
 	"fmt"
 	"io"
 
+	"github.com/linkedin/goavro/v2"
+
 	"github.com/benthosdev/benthos/v4/internal/message"
 	"github.com/benthosdev/benthos/v4/public/service"
 )
@@ -23,10 +26,11 @@ func unarchiveProcConfig() *service.ConfigSpec {
 		Description(`
 When a message is unarchived the new messages replace the original message in the batch. Messages that are selected but fail to unarchive (invalid format) will remain unchanged in the message batch but will be flagged as having failed, allowing you to [error handle them](/docs/configuration/error_handling).
 
-For the unarchive formats that contain file information (tar, zip), a metadata field is added to each message called ` + "`archive_filename`" + ` with the extracted filename.
+For the unarchive formats that contain file information (tar, tar_gzip, zip), a metadata field is added to each message called ` + "`archive_filename`" + ` with the extracted filename.
 `).
 		Field(service.NewStringAnnotatedEnumField("format", map[string]string{
 			`tar`:            `Extract messages from a unix standard tape archive.`,
+			`tar_gzip`:       `Extract messages from a unix standard tape archive compressed with gzip.`,
 			`zip`:            `Extract messages from a zip file.`,
 			`binary`:         `Extract messages from a [binary blob format](https://github.com/benthosdev/benthos/blob/main/internal/message/message.go#L96).`,
 			`lines`:          `Extract the lines of a message each into their own message.`,
@@ -34,6 +38,7 @@ For the unarchive formats that contain file information (tar, zip), a metadata f
 			`json_array`:     `Attempt to parse a message as a JSON array, and extract each element into its own message.`,
 			`json_map`:       `Attempt to parse the message as a JSON map and for each element of the map expands its contents into a new message. A metadata field is added to each message called ` + "`archive_key`" + ` with the relevant key from the top-level map.`,
 			`csv`:            `Attempt to parse the message as a csv file (header required) and for each row in the file expands its contents into a json object in a new message.`,
+			`avro_ocf`:       "Attempt to parse the message as an Avro object container file and expand each record into a new message as a JSON document in the [Avro JSON encoding](https://avro.apache.org/docs/current/spec.html#json_encoding) of the schema of the file.",
 		}).Description("The unarchiving format to apply."))
 }
 
@@ -86,6 +91,26 @@ func tarUnarchive(part *service.Message) (service.MessageBatch, error) {
 	return newParts, nil
 }
 
+func tarGzipUnarchive(part *service.Message) (service.MessageBatch, error) {
+	pBytes, err := part.AsBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	zr, err := gzip.NewReader(bytes.NewReader(pBytes))
+	if err != nil {
+		return nil, err
+	}
+	tarBytes, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, err
+	}
+
+	tarPart := part.Copy()
+	tarPart.SetBytes(tarBytes)
+	return tarUnarchive(tarPart)
+}
+
 func zipUnarchive(part *service.Message) (service.MessageBatch, error) {
 	pBytes, err := part.AsBytes()
 	if err != nil {
@@ -275,10 +300,44 @@ func csvUnarchive(part *service.Message) (service.MessageBatch, error) {
 	return newParts, nil
 }
 
+func avroOCFUnarchive(part *service.Message) (service.MessageBatch, error) {
+	pBytes, err := part.AsBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := goavro.NewOCFReader(bytes.NewReader(pBytes))
+	if err != nil {
+		return nil, err
+	}
+	codec := r.Codec()
+
+	var newParts service.MessageBatch
+	for r.Scan() {
+		record, err := r.Read()
+		if err != nil {
+			return nil, err
+		}
+		jBytes, err := codec.TextualFromNative(nil, record)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert Avro record to JSON: %v", err)
+		}
+		newPart := part.Copy()
+		newPart.SetBytes(jBytes)
+		newParts = append(newParts, newPart)
+	}
+	if err := r.Err(); err != nil {
+		return nil, err
+	}
+	return newParts, nil
+}
+
 func strToUnarchiver(str string) (unarchiveFunc, error) {
 	switch str {
 	case "tar":
 		return tarUnarchive, nil
+	case "tar_gzip":
+		return tarGzipUnarchive, nil
 	case "zip":
 		return zipUnarchive, nil
 	case "binary":
@@ -293,6 +352,8 @@ func strToUnarchiver(str string) (unarchiveFunc, error) {
 		return jsonMapUnarchive, nil
 	case "csv":
 		return csvUnarchive, nil
+	case "avro_ocf":
+		return avroOCFUnarchive, nil
 	}
 	return nil, fmt.Errorf("archive format not recognised: %v", str)
 }
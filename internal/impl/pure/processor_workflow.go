@@ -1,6 +1,7 @@
 package pure
 
 import (
+	"encoding/json"
 	"fmt"
 	"sort"
 	"sync"
@@ -90,6 +91,43 @@ The previous meta object will also be preserved in the field ` + "`<meta_path>.p
 
 If a field ` + "`<meta_path>.apply`" + ` exists in the meta object for a message and is an array then it will be used as an explicit list of stages to apply, all other stages will be skipped.
 
+## Execution Trace
+
+When the field ` + "`trace_metadata_key`" + ` is non-empty the workflow processor stores a JSON array within that metadata key of each message, describing the execution of each branch in the order that they were executed. This is useful for debugging complex workflows, and is of the following form:
+
+` + "```json" + `
+[
+	{ "branch": "foo", "tier": 0, "status": "succeeded", "attempts": 1, "duration": "12ms" },
+	{ "branch": "bar", "tier": 0, "status": "skipped", "attempts": 0, "duration": "1ms" },
+	{ "branch": "baz", "tier": 1, "status": "failed", "attempts": 3, "duration": "2.5s", "error": "the error message from the branch" }
+]
+` + "```" + `
+
+The ` + "`tier`" + ` of a branch is the index of the layer of the DAG it was executed within, where branches of the same tier are executed in parallel, and the ` + "`duration`" + ` is the time taken to execute the branch for the whole batch.
+
+## Conditions, Retries and Timeouts
+
+Each branch of a workflow can be given a ` + "`check`" + ` query that determines whether a message should be processed by it, which is simpler than conditionally deleting the result of a ` + "`request_map`" + `. Branches can also be given a ` + "`timeout`" + ` for the execution of their processors, and a ` + "`retry`" + ` policy that reattempts messages that fail the processors of a branch with an exponential backoff:
+
+` + "```yaml" + `
+pipeline:
+  processors:
+    - workflow:
+        trace_metadata_key: workflow_trace
+        branches:
+          enrich:
+            check: this.type == "order"
+            timeout: 5s
+            retry:
+              max_retries: 3
+              initial_interval: 100ms
+            request_map: 'root.id = this.order_id'
+            processors:
+              - http:
+                  url: http://example.com/orders
+            result_map: 'root.order = this'
+` + "```" + `
+
 ## Resources
 
 It's common to configure processors (and other components) [as resources][configuration.resources] in order to keep the pipeline configuration cleaner. With the workflow processor you can include branch processors configured as resources within your workflow either by specifying them by name in the field ` + "`order`" + `, if Benthos doesn't find a branch within the workflow configuration of that name it'll refer to the resources.
@@ -256,6 +294,11 @@ processor_resources:
 				"branches",
 				"An object of named [`branch` processors](/docs/components/processors/branch) that make up the workflow. The order and parallelism in which branches are executed can either be made explicit with the field `order`, or if omitted an attempt is made to automatically resolve an ordering based on the mappings of each branch.",
 			).Map().WithChildren(branchFields...).HasDefault(map[string]interface{}{}),
+			docs.FieldString(
+				"trace_metadata_key",
+				"An optional metadata key to store an [execution trace](#execution-trace) of the workflow within for each message. When empty no trace is recorded.",
+				"workflow_trace",
+			).Advanced().HasDefault(""),
 		),
 	})
 	if err != nil {
@@ -275,6 +318,7 @@ type Workflow struct {
 	children  *workflowBranchMap
 	allStages map[string]struct{}
 	metaPath  []string
+	traceKey  string
 
 	// Metrics
 	mReceived      metrics.StatCounter
@@ -294,6 +338,7 @@ func NewWorkflow(conf processor.WorkflowConfig, mgr bundle.NewManagement) (*Work
 
 		metaPath:  nil,
 		allStages: map[string]struct{}{},
+		traceKey:  conf.TraceKey,
 
 		mReceived:      stats.GetCounter("processor_received"),
 		mBatchReceived: stats.GetCounter("processor_batch_received"),
@@ -362,6 +407,20 @@ func (r *resultTracker) Failed(k, why string) {
 	r.Unlock()
 }
 
+// Status returns the outcome of a branch, along with the error message of the
+// branch when it failed.
+func (r *resultTracker) Status(k string) (status, why string) {
+	r.Lock()
+	defer r.Unlock()
+	if why, failed := r.failed[k]; failed {
+		return "failed", why
+	}
+	if _, skipped := r.skipped[k]; skipped {
+		return "skipped", ""
+	}
+	return "succeeded", ""
+}
+
 func (r *resultTracker) ToObject() map[string]interface{} {
 	succeeded := make([]interface{}, 0, len(r.succeeded))
 	skipped := make([]interface{}, 0, len(r.skipped))
@@ -488,14 +547,24 @@ func (w *Workflow) ProcessMessage(msg *message.Batch) ([]*message.Batch, error)
 		records[i] = trackerFromTree(dag)
 	}
 
-	for _, layer := range dag {
+	type branchExecution struct {
+		id       string
+		tier     int
+		attempts []int
+		duration time.Duration
+	}
+	var executions []branchExecution
+
+	for tier, layer := range dag {
 		results := make([][]*message.Part, len(layer))
 		errors := make([]error, len(layer))
+		layerExecutions := make([]branchExecution, len(layer))
 
 		wg := sync.WaitGroup{}
 		wg.Add(len(layer))
 		for i, eid := range layer {
 			go func(id string, index int) {
+				branchStartedAt := time.Now()
 				branchMsg, branchSpans := tracing.WithChildSpans(w.tracer, id, propMsg.Copy())
 
 				branchParts := make([]*message.Part, branchMsg.Len())
@@ -510,7 +579,8 @@ func (w *Workflow) ProcessMessage(msg *message.Batch) ([]*message.Batch, error)
 				})
 
 				var mapErrs []branchMapError
-				results[index], mapErrs, errors[index] = children[id].createResult(branchParts, propMsg)
+				var attempts []int
+				results[index], attempts, mapErrs, errors[index] = children[id].createResult(branchParts, propMsg)
 				for _, s := range branchSpans {
 					s.Finish()
 				}
@@ -522,10 +592,17 @@ func (w *Workflow) ProcessMessage(msg *message.Batch) ([]*message.Batch, error)
 				for _, e := range mapErrs {
 					records[e.index].Failed(id, e.err.Error())
 				}
+				layerExecutions[index] = branchExecution{
+					id:       id,
+					tier:     tier,
+					attempts: attempts,
+					duration: time.Since(branchStartedAt),
+				}
 				wg.Done()
 			}(eid, i)
 		}
 		wg.Wait()
+		executions = append(executions, layerExecutions...)
 
 		for i, id := range layer {
 			var failed []branchMapError
@@ -547,6 +624,33 @@ func (w *Workflow) ProcessMessage(msg *message.Batch) ([]*message.Batch, error)
 		}
 	}
 
+	if w.traceKey != "" {
+		_ = payload.Iter(func(i int, p *message.Part) error {
+			trace := make([]interface{}, 0, len(executions))
+			for _, e := range executions {
+				status, why := records[i].Status(e.id)
+				attempts := 0
+				if i < len(e.attempts) {
+					attempts = e.attempts[i]
+				}
+				entry := map[string]interface{}{
+					"branch":   e.id,
+					"tier":     e.tier,
+					"status":   status,
+					"attempts": attempts,
+					"duration": e.duration.String(),
+				}
+				if why != "" {
+					entry["error"] = why
+				}
+				trace = append(trace, entry)
+			}
+			traceBytes, _ := json.Marshal(trace)
+			p.MetaSet(w.traceKey, string(traceBytes))
+			return nil
+		})
+	}
+
 	// Finally, set the meta records of each document.
 	if len(w.metaPath) > 0 {
 		_ = payload.Iter(func(i int, p *message.Part) error {
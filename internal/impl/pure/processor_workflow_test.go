@@ -1,6 +1,7 @@
 package pure_test
 
 import (
+	"encoding/json"
 	"errors"
 	"sort"
 	"strconv"
@@ -10,6 +11,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	yaml "gopkg.in/yaml.v3"
 
 	"github.com/benthosdev/benthos/v4/internal/bundle"
 	"github.com/benthosdev/benthos/v4/internal/component/processor"
@@ -922,3 +924,74 @@ func TestWorkflowsWithOrderResources(t *testing.T) {
 		})
 	}
 }
+
+func TestWorkflowBranchPolicies(t *testing.T) {
+	conf := processor.NewConfig()
+	require.NoError(t, yaml.Unmarshal([]byte(`
+workflow:
+  order: [ [ a, b ], [ c ] ]
+  trace_metadata_key: trace
+  branches:
+    a:
+      check: this.type == "a"
+      processors:
+        - bloblang: 'root = "A"'
+      result_map: 'root.a = content().string()'
+    b:
+      retry:
+        max_retries: 2
+        initial_interval: 1ms
+      processors:
+        - bloblang: |
+            root = if count("workflow_branch_policies_test") % 2 == 1 { throw("nope") } else { "B" }
+      result_map: 'root.b = content().string()'
+    c:
+      timeout: 10ms
+      processors:
+        - sleep:
+            duration: 200ms
+      result_map: 'root.c = this'
+`), &conf))
+
+	p, err := mock.NewManager().NewProcessor(conf)
+	require.NoError(t, err)
+
+	msgs, res := p.ProcessMessage(message.QuickBatch([][]byte{
+		[]byte(`{"type":"a"}`),
+		[]byte(`{"type":"b"}`),
+	}))
+	require.Nil(t, res)
+	require.Len(t, msgs, 1)
+
+	assert.Equal(t, []string{
+		`{"a":"A","b":"B","meta":{"workflow":{"failed":{"c":"child processors exceeded timeout of 10ms"},"succeeded":["a","b"]}},"type":"a"}`,
+		`{"b":"B","meta":{"workflow":{"failed":{"c":"child processors exceeded timeout of 10ms"},"skipped":["a"],"succeeded":["b"]}},"type":"b"}`,
+	}, []string{
+		string(msgs[0].Get(0).Get()),
+		string(msgs[0].Get(1).Get()),
+	})
+
+	traceFor := func(index int) []interface{} {
+		var trace []interface{}
+		require.NoError(t, json.Unmarshal([]byte(msgs[0].Get(index).MetaGet("trace")), &trace))
+		for _, e := range trace {
+			delete(e.(map[string]interface{}), "duration")
+		}
+		return trace
+	}
+
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"branch": "a", "tier": 0.0, "status": "succeeded", "attempts": 1.0},
+		map[string]interface{}{"branch": "b", "tier": 0.0, "status": "succeeded", "attempts": 3.0},
+		map[string]interface{}{"branch": "c", "tier": 1.0, "status": "failed", "attempts": 1.0, "error": "child processors exceeded timeout of 10ms"},
+	}, traceFor(0))
+
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"branch": "a", "tier": 0.0, "status": "skipped", "attempts": 0.0},
+		map[string]interface{}{"branch": "b", "tier": 0.0, "status": "succeeded", "attempts": 1.0},
+		map[string]interface{}{"branch": "c", "tier": 1.0, "status": "failed", "attempts": 1.0, "error": "child processors exceeded timeout of 10ms"},
+	}, traceFor(1))
+
+	p.CloseAsync()
+	assert.NoError(t, p.WaitForClose(time.Second))
+}
@@ -3,6 +3,7 @@ package pure
 import (
 	"context"
 	"errors"
+	"strconv"
 	"sync"
 	"time"
 
@@ -15,9 +16,11 @@ func localRatelimitConfig() *service.ConfigSpec {
 		Summary(`The local rate limit is a simple X every Y type rate limit that can be shared across any number of components within the pipeline but does not support distributed rate limits across multiple running instances of Benthos.`).
 		Field(service.NewIntField("count").
 			Description("The maximum number of requests to allow for a given period of time.").
+			Tunable().
 			Default(1000)).
 		Field(service.NewDurationField("interval").
 			Description("The time window to limit requests by.").
+			Tunable().
 			Default("1s"))
 
 	return spec
@@ -27,7 +30,12 @@ func init() {
 	err := service.RegisterRateLimit(
 		"local", localRatelimitConfig(),
 		func(conf *service.ParsedConfig, mgr *service.Resources) (service.RateLimit, error) {
-			return newLocalRatelimitFromConfig(conf)
+			r, err := newLocalRatelimitFromConfig(conf)
+			if err != nil {
+				return nil, err
+			}
+			r.registerTunables(mgr)
+			return r, nil
 		})
 
 	if err != nil {
@@ -56,6 +64,8 @@ type localRatelimit struct {
 
 	size   int
 	period time.Duration
+
+	unregister []func()
 }
 
 func newLocalRatelimit(count int, interval time.Duration) (*localRatelimit, error) {
@@ -70,6 +80,36 @@ func newLocalRatelimit(count int, interval time.Duration) (*localRatelimit, erro
 	}, nil
 }
 
+func (r *localRatelimit) registerTunables(mgr *service.Resources) {
+	unregisterCount := mgr.RegisterTunable("count", "The maximum number of requests to allow for a given period of time.", strconv.Itoa(r.size), func(value string) error {
+		count, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		if count <= 0 {
+			return errors.New("count must be larger than zero")
+		}
+		r.mut.Lock()
+		r.size = count
+		if r.bucket > count {
+			r.bucket = count
+		}
+		r.mut.Unlock()
+		return nil
+	})
+	unregisterInterval := mgr.RegisterTunable("interval", "The time window to limit requests by.", r.period.String(), func(value string) error {
+		interval, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		r.mut.Lock()
+		r.period = interval
+		r.mut.Unlock()
+		return nil
+	})
+	r.unregister = append(r.unregister, unregisterCount, unregisterInterval)
+}
+
 func (r *localRatelimit) Access(ctx context.Context) (time.Duration, error) {
 	r.mut.Lock()
 	r.bucket--
@@ -90,5 +130,9 @@ func (r *localRatelimit) Access(ctx context.Context) (time.Duration, error) {
 }
 
 func (r *localRatelimit) Close(ctx context.Context) error {
+	for _, fn := range r.unregister {
+		fn()
+	}
+	r.unregister = nil
 	return nil
 }
@@ -8,6 +8,9 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/manager/mock"
+	"github.com/benthosdev/benthos/v4/public/service"
 )
 
 func TestLocalRateLimitConfErrors(t *testing.T) {
@@ -48,6 +51,45 @@ interval: 1s
 	}
 }
 
+func TestLocalRateLimitTunables(t *testing.T) {
+	conf, err := localRatelimitConfig().ParseYAML(`
+count: 10
+interval: 1h
+`, nil)
+	require.NoError(t, err)
+
+	rl, err := newLocalRatelimitFromConfig(conf)
+	require.NoError(t, err)
+
+	tunables := map[string]func(string) error{}
+	rl.registerTunables(service.MockResources(func(m *mock.Manager) {
+		m.OnRegisterTunable = func(field, value string, set func(string) error) {
+			tunables[field+"="+value] = set
+		}
+	}))
+
+	setCount, setInterval := tunables["count=10"], tunables["interval=1h0m0s"]
+	require.NotNil(t, setCount)
+	require.NotNil(t, setInterval)
+
+	assert.Error(t, setCount("0"))
+	assert.Error(t, setInterval("nope"))
+
+	require.NoError(t, setCount("2"))
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		period, _ := rl.Access(ctx)
+		assert.Equal(t, time.Duration(0), period)
+	}
+	period, _ := rl.Access(ctx)
+	assert.Greater(t, period, time.Minute)
+
+	require.NoError(t, setInterval("1ms"))
+	<-time.After(time.Millisecond * 5)
+	period, _ = rl.Access(ctx)
+	assert.Equal(t, time.Duration(0), period)
+}
+
 func TestLocalRateLimitRefresh(t *testing.T) {
 	conf, err := localRatelimitConfig().ParseYAML(`
 count: 10
@@ -2,6 +2,8 @@ package redis
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"sync"
 	"time"
 
@@ -29,6 +31,19 @@ func redisCacheConfig() *service.ConfigSpec {
 		Field(service.NewStringField("prefix").
 			Description("An optional string to prefix item keys with in order to prevent collisions with similar services.").
 			Optional()).
+		Field(service.NewObjectField("json",
+			service.NewBoolField("enabled").
+				Description("Whether to store and retrieve items as RedisJSON documents with the commands `JSON.SET` and `JSON.GET`, which requires the RedisJSON module to be loaded by the server. Items must be valid JSON documents.").
+				Default(false),
+			service.NewStringField("path").
+				Description("The path within each document to set, get and delete items at. When the path is the root `$` or `.` items are whole documents, otherwise the document must already exist when an item is set. When the path uses JSONPath syntax `JSON.GET` returns an array of the matched values.").
+				Example(".profile").
+				Example("$.profile").
+				Default("."),
+		).
+			Description("Allows items to be cached as queryable JSON documents instead of opaque strings. When a TTL is set it applies to the whole document.").
+			Advanced().
+			Version("4.3.0")).
 		Field(service.NewDurationField("default_ttl").
 			Description("An optional default TTL to set for items, calculated from the moment the item is cached.").
 			Optional().
@@ -77,7 +92,25 @@ func newRedisCacheFromConfig(conf *service.ParsedConfig) (*redisCache, error) {
 	if err != nil {
 		return nil, err
 	}
-	return newRedisCache(ttl, prefix, client, backOff)
+
+	c, err := newRedisCache(ttl, prefix, client, backOff)
+	if err != nil {
+		return nil, err
+	}
+
+	jsonEnabled, err := conf.FieldBool("json", "enabled")
+	if err != nil {
+		return nil, err
+	}
+	if jsonEnabled {
+		if c.jsonPath, err = conf.FieldString("json", "path"); err != nil {
+			return nil, err
+		}
+		if c.jsonPath == "" {
+			return nil, errors.New("json path must not be empty")
+		}
+	}
+	return c, nil
 }
 
 //------------------------------------------------------------------------------
@@ -87,6 +120,9 @@ type redisCache struct {
 	defaultTTL time.Duration
 	prefix     string
 
+	// When non-empty items are stored as RedisJSON documents at this path.
+	jsonPath string
+
 	boffPool sync.Pool
 }
 
@@ -119,7 +155,7 @@ func (r *redisCache) Get(ctx context.Context, key string) ([]byte, error) {
 
 	key = r.prefix + key
 	for {
-		res, err := r.client.Get(key).Result()
+		res, err := r.get(key)
 		if err == nil {
 			return []byte(res), nil
 		}
@@ -155,8 +191,12 @@ func (r *redisCache) Set(ctx context.Context, key string, value []byte, ttl *tim
 		t = r.defaultTTL
 	}
 
+	if r.jsonPath != "" && !json.Valid(value) {
+		return errors.New("value is not a valid JSON document")
+	}
+
 	for {
-		err := r.client.Set(key, value, t).Err()
+		err := r.set(key, value, t)
 		if err == nil {
 			return nil
 		}
@@ -189,8 +229,12 @@ func (r *redisCache) Add(ctx context.Context, key string, value []byte, ttl *tim
 		t = r.defaultTTL
 	}
 
+	if r.jsonPath != "" && !json.Valid(value) {
+		return errors.New("value is not a valid JSON document")
+	}
+
 	for {
-		set, err := r.client.SetNX(key, value, t).Result()
+		set, err := r.add(key, value, t)
 		if err == nil {
 			if !set {
 				return service.ErrKeyAlreadyExists
@@ -220,7 +264,7 @@ func (r *redisCache) Delete(ctx context.Context, key string) error {
 	key = r.prefix + key
 
 	for {
-		_, err := r.client.Del(key).Result()
+		err := r.del(key)
 		if err == nil {
 			return nil
 		}
@@ -237,6 +281,53 @@ func (r *redisCache) Delete(ctx context.Context, key string) error {
 	}
 }
 
+func (r *redisCache) get(key string) (string, error) {
+	if r.jsonPath == "" {
+		return r.client.Get(key).Result()
+	}
+	return r.client.Do("JSON.GET", key, r.jsonPath).Text()
+}
+
+func (r *redisCache) set(key string, value []byte, ttl time.Duration) error {
+	if r.jsonPath == "" {
+		return r.client.Set(key, value, ttl).Err()
+	}
+	_, err := r.client.TxPipelined(func(pipe redis.Pipeliner) error {
+		pipe.Do("JSON.SET", key, r.jsonPath, value)
+		if ttl > 0 {
+			pipe.Expire(key, ttl)
+		}
+		return nil
+	})
+	return err
+}
+
+func (r *redisCache) add(key string, value []byte, ttl time.Duration) (bool, error) {
+	if r.jsonPath == "" {
+		return r.client.SetNX(key, value, ttl).Result()
+	}
+	// JSON.SET with NX replies with a nil when the path already exists.
+	if err := r.client.Do("JSON.SET", key, r.jsonPath, value, "NX").Err(); err != nil {
+		if err == redis.Nil {
+			return false, nil
+		}
+		return false, err
+	}
+	if ttl > 0 {
+		if err := r.client.Expire(key, ttl).Err(); err != nil {
+			return true, err
+		}
+	}
+	return true, nil
+}
+
+func (r *redisCache) del(key string) error {
+	if r.jsonPath == "" {
+		return r.client.Del(key).Err()
+	}
+	return r.client.Do("JSON.DEL", key, r.jsonPath).Err()
+}
+
 func (r *redisCache) Close(ctx context.Context) error {
 	return r.client.Close()
 }
@@ -14,6 +14,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/benthosdev/benthos/v4/internal/integration"
+	"github.com/benthosdev/benthos/v4/public/service"
 )
 
 func TestIntegrationRedisCache(t *testing.T) {
@@ -68,6 +69,84 @@ cache_resources:
 	)
 }
 
+func TestIntegrationRedisJSONCache(t *testing.T) {
+	integration.CheckSkip(t)
+	t.Parallel()
+
+	pool, err := dockertest.NewPool("")
+	require.NoError(t, err)
+
+	pool.MaxWait = time.Second * 30
+
+	resource, err := pool.Run("redislabs/rejson", "latest", nil)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, pool.Purge(resource))
+	})
+
+	_ = resource.Expire(900)
+
+	newCache := func(path string) (*redisCache, error) {
+		pConf, err := redisCacheConfig().ParseYAML(fmt.Sprintf(`
+url: tcp://localhost:%v
+json:
+  enabled: true
+  path: %v
+`, resource.GetPort("6379/tcp"), path), nil)
+		if err != nil {
+			return nil, err
+		}
+		return newRedisCacheFromConfig(pConf)
+	}
+
+	var r *redisCache
+	require.NoError(t, pool.Retry(func() error {
+		var cErr error
+		if r, cErr = newCache("."); cErr != nil {
+			return cErr
+		}
+		return r.Set(context.Background(), "benthos_test_redis_connect", []byte(`{"foo":"bar"}`), nil)
+	}))
+
+	ctx := context.Background()
+
+	_, err = r.Get(ctx, "doc")
+	assert.Equal(t, service.ErrKeyNotFound, err)
+
+	require.Error(t, r.Set(ctx, "doc", []byte(`not json`), nil))
+
+	require.NoError(t, r.Add(ctx, "doc", []byte(`{"id":"foo","profile":{"name":"bar"}}`), nil))
+	assert.Equal(t, service.ErrKeyAlreadyExists, r.Add(ctx, "doc", []byte(`{}`), nil))
+
+	res, err := r.Get(ctx, "doc")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"id":"foo","profile":{"name":"bar"}}`, string(res))
+
+	profile, err := newCache(".profile")
+	require.NoError(t, err)
+
+	require.NoError(t, profile.Set(ctx, "doc", []byte(`{"name":"baz"}`), nil))
+
+	res, err = profile.Get(ctx, "doc")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"name":"baz"}`, string(res))
+
+	res, err = r.Get(ctx, "doc")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"id":"foo","profile":{"name":"baz"}}`, string(res))
+
+	require.NoError(t, profile.Delete(ctx, "doc"))
+
+	res, err = r.Get(ctx, "doc")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"id":"foo"}`, string(res))
+
+	require.NoError(t, r.Delete(ctx, "doc"))
+
+	_, err = r.Get(ctx, "doc")
+	assert.Equal(t, service.ErrKeyNotFound, err)
+}
+
 func TestIntegrationRedisClusterCache(t *testing.T) {
 	t.Skip("Skipping as networking often fails for this test")
 
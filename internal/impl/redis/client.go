@@ -5,21 +5,16 @@ import (
 	"fmt"
 	"net/url"
 	"strings"
+	"time"
 
 	"github.com/go-redis/redis/v7"
 
 	"github.com/benthosdev/benthos/v4/internal/impl/redis/old"
+	btls "github.com/benthosdev/benthos/v4/internal/tls"
 	"github.com/benthosdev/benthos/v4/public/service"
 )
 
 func clientFields() []*service.ConfigField {
-	tlsField := service.NewTLSToggledField("tls").
-		Description(`Custom TLS settings can be used to override system defaults.
-
-**Troubleshooting**
-
-Some cloud hosted instances of Redis (such as Azure Cache) might need some hand holding in order to establish stable connections. Unfortunately, it is often the case that TLS issues will manifest as generic error messages such as "i/o timeout". If you're using TLS and are seeing connectivity problems consider setting ` + "`enable_renegotiation` to `true`" + `, and ensuring that the server supports at least TLS version 1.2.`)
-
 	return []*service.ConfigField{
 		service.NewStringField("url").
 			Description("The URL of the target Redis server. Database is optional and is supplied as the URL path.").
@@ -38,7 +33,7 @@ Some cloud hosted instances of Redis (such as Azure Cache) might need some hand
 			Default("").
 			Example("mymaster").
 			Advanced(),
-		tlsField,
+		service.NewInternalField(old.TLSFieldSpec()),
 	}
 }
 
@@ -65,6 +60,39 @@ func getClient(parsedConf *service.ParsedConfig) (redis.UniversalClient, error)
 	if !tlsEnabled {
 		tlsConf = nil
 	}
+	if tlsConf != nil {
+		reloadInterval, err := parsedConf.FieldString("tls", "reload_interval")
+		if err != nil {
+			return nil, err
+		}
+
+		certObjs, err := parsedConf.FieldObjectList("tls", "client_certs")
+		if err != nil {
+			return nil, err
+		}
+
+		var certs []btls.ClientCertConfig
+		for _, c := range certObjs {
+			var cert btls.ClientCertConfig
+			if cert.CertFile, err = c.FieldString("cert_file"); err != nil {
+				return nil, err
+			}
+			if cert.KeyFile, err = c.FieldString("key_file"); err != nil {
+				return nil, err
+			}
+			if cert.Cert, err = c.FieldString("cert"); err != nil {
+				return nil, err
+			}
+			if cert.Key, err = c.FieldString("key"); err != nil {
+				return nil, err
+			}
+			certs = append(certs, cert)
+		}
+
+		if err := watchClientCerts(tlsConf, certs, reloadInterval); err != nil {
+			return nil, err
+		}
+	}
 
 	// We default to Redis DB 0 for backward compatibility
 	var redisDB int
@@ -148,6 +176,11 @@ func clientFromConfig(r old.Config) (redis.UniversalClient, error) {
 		if tlsConf, err = r.TLS.Get(); err != nil {
 			return nil, err
 		}
+		if tlsConf != nil {
+			if err = watchClientCerts(tlsConf, r.TLS.ClientCertificates, r.TLS.ReloadInterval); err != nil {
+				return nil, err
+			}
+		}
 	}
 
 	var client redis.UniversalClient
@@ -174,3 +207,16 @@ func clientFromConfig(r old.Config) (redis.UniversalClient, error) {
 
 	return client, err
 }
+
+// watchClientCerts enables the reloading of the client certificates of a TLS
+// config when a reload interval is specified.
+func watchClientCerts(tlsConf *tls.Config, certs []btls.ClientCertConfig, intervalStr string) error {
+	if intervalStr == "" || len(certs) == 0 {
+		return nil
+	}
+	interval, err := time.ParseDuration(intervalStr)
+	if err != nil {
+		return fmt.Errorf("failed to parse tls reload interval: %w", err)
+	}
+	return btls.WatchClientCerts(tlsConf, certs, interval)
+}
@@ -0,0 +1,82 @@
+package redis
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/go-redis/redis/v7"
+)
+
+const clusterSlotCount = 16384
+
+// keySlot returns the cluster hash slot of a key, which is the CRC16 checksum
+// of the key modulo 16384. When a key contains a non-empty hash tag between
+// the first { and the following } only the hash tag is hashed.
+func keySlot(key string) int {
+	if start := strings.IndexByte(key, '{'); start >= 0 {
+		if end := strings.IndexByte(key[start+1:], '}'); end > 0 {
+			key = key[start+1 : start+1+end]
+		}
+	}
+	return int(crc16(key) % clusterSlotCount)
+}
+
+// crc16 implements the CRC16-CCITT (XMODEM) checksum used for cluster slots.
+func crc16(key string) uint16 {
+	var crc uint16
+	for i := 0; i < len(key); i++ {
+		crc ^= uint16(key[i]) << 8
+		for j := 0; j < 8; j++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+// clusterSlots maps the hash slots of a cluster to the clients of their master
+// nodes. This is needed for commands that go-redis is unable to route by
+// itself, such as SPUBLISH, which would otherwise be sent to a random node and
+// redirected.
+type clusterSlots struct {
+	masters [clusterSlotCount]*redis.Client
+}
+
+func loadClusterSlots(client *redis.ClusterClient) (*clusterSlots, error) {
+	var mut sync.Mutex
+	clients := map[string]*redis.Client{}
+	if err := client.ForEachMaster(func(c *redis.Client) error {
+		mut.Lock()
+		clients[c.Options().Addr] = c
+		mut.Unlock()
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	slots, err := client.ClusterSlots().Result()
+	if err != nil {
+		return nil, err
+	}
+
+	s := &clusterSlots{}
+	for _, slot := range slots {
+		if len(slot.Nodes) == 0 {
+			continue
+		}
+		master := clients[slot.Nodes[0].Addr]
+		for i := slot.Start; i <= slot.End && i < clusterSlotCount; i++ {
+			s.masters[i] = master
+		}
+	}
+	return s, nil
+}
+
+// master returns the client of the master node that owns the slot of a key, or
+// nil if the owner isn't known.
+func (s *clusterSlots) master(key string) *redis.Client {
+	return s.masters[keySlot(key)]
+}
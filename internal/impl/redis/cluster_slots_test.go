@@ -0,0 +1,23 @@
+package redis
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeySlot(t *testing.T) {
+	for key, slot := range map[string]int{
+		"":                     0,
+		"foo":                  12182,
+		"bar":                  5061,
+		"123456789":            12739,
+		"{user1000}.following": 3443,
+		"{user1000}.followers": 3443,
+		"foo{}{bar}":           8363,
+		"foo{{bar}}zap":        4015,
+		"foo{bar}{zap}":        5061,
+	} {
+		assert.Equal(t, slot, keySlot(key), key)
+	}
+}
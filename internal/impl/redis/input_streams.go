@@ -2,6 +2,7 @@ package redis
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"sync"
@@ -28,7 +29,22 @@ Pulls messages from Redis (v5.0+) streams with the XREADGROUP command. The
 		Description: `
 Redis stream entries are key/value pairs, as such it is necessary to specify the
 key that contains the body of the message. All other keys/value pairs are saved
-as metadata fields.`,
+as metadata fields.
+
+### Claiming Pending Entries
+
+Entries that are delivered to a consumer remain pending within the consumer
+group until they are acknowledged, and therefore entries delivered to a consumer
+that crashes or is removed are never delivered again. Setting
+` + "`auto_claim.enabled` to `true`" + ` causes the input to periodically claim
+entries that have been pending for longer than ` + "`auto_claim.min_idle_time`" + `
+with the XAUTOCLAIM command (Redis v6.2+), and consume them as any other entry.
+
+Entries that repeatedly fail to be processed can be routed to a dead letter
+stream by setting ` + "`auto_claim.max_retries`" + `, once an entry has been
+redelivered more times than this it is added to the stream
+` + "`auto_claim.dead_letter_stream`" + ` and acknowledged instead of being
+consumed.`,
 		Config: docs.FieldComponent().WithChildren(old.ConfigDocs()...).WithChildren(
 			docs.FieldString("body_key", "The field key to extract the raw message from. All other keys will be stored in the message as metadata."),
 			docs.FieldString("streams", "A list of streams to consume from.").Array(),
@@ -39,6 +55,13 @@ as metadata fields.`,
 			docs.FieldBool("start_from_oldest", "If an offset is not found for a stream, determines whether to consume from the oldest available offset, otherwise messages are consumed from the latest offset.").Advanced(),
 			docs.FieldString("commit_period", "The period of time between each commit of the current offset. Offsets are always committed during shutdown.").Advanced(),
 			docs.FieldString("timeout", "The length of time to poll for new messages before reattempting.").Advanced(),
+			docs.FieldObject("auto_claim", "Options for claiming entries that have been pending within the consumer group for longer than a minimum idle time, such as entries delivered to consumers that have crashed.").WithChildren(
+				docs.FieldBool("enabled", "Whether to claim stale pending entries with the XAUTOCLAIM command, which requires Redis v6.2 or later."),
+				docs.FieldString("min_idle_time", "The minimum period of time that an entry must have been pending for before it is claimed.", "1m", "1h"),
+				docs.FieldString("period", "The period of time to wait after all pending entries of the consumer group have been scanned before scanning them again."),
+				docs.FieldInt("max_retries", "The maximum number of times that an entry can be redelivered before it is added to the `dead_letter_stream` and acknowledged rather than consumed. Set to zero in order to claim entries indefinitely."),
+				docs.FieldString("dead_letter_stream", "A stream to add entries to once they have exceeded `max_retries`, which is required when `max_retries` is greater than zero. Dead lettered entries keep their original fields and have the fields `redis_stream_source`, `redis_stream_id` and `redis_stream_delivery_count` added."),
+			).Advanced(),
 		).ChildDefaultAndTypesFromStruct(input.NewRedisStreamsConfig()),
 		Categories: []string{
 			"Services",
@@ -74,6 +97,11 @@ type redisStreamsReader struct {
 	timeout      time.Duration
 	commitPeriod time.Duration
 
+	minIdleTime  time.Duration
+	claimPeriod  time.Duration
+	nextClaim    time.Time
+	claimCursors map[string]string
+
 	conf input.RedisStreamsConfig
 
 	backlogs map[string]string
@@ -120,6 +148,20 @@ func newRedisStreamsReader(conf input.RedisStreamsConfig, log log.Modular) (*red
 		}
 	}
 
+	if conf.AutoClaim.Enabled {
+		var err error
+		if r.minIdleTime, err = time.ParseDuration(conf.AutoClaim.MinIdleTime); err != nil {
+			return nil, fmt.Errorf("failed to parse auto claim min idle time string: %v", err)
+		}
+		if r.claimPeriod, err = time.ParseDuration(conf.AutoClaim.Period); err != nil {
+			return nil, fmt.Errorf("failed to parse auto claim period string: %v", err)
+		}
+		if conf.AutoClaim.MaxRetries > 0 && conf.AutoClaim.DeadLetterStream == "" {
+			return nil, errors.New("an auto claim dead letter stream must be specified when max retries is greater than zero")
+		}
+		r.claimCursors = map[string]string{}
+	}
+
 	go r.loop()
 	return r, nil
 }
@@ -248,6 +290,15 @@ func (r *redisStreamsReader) read() (pendingRedisStreamMsg, error) {
 		return msg, nil
 	}
 
+	claimed, err := r.claim(client)
+	if err != nil {
+		r.log.Errorf("Failed to claim pending entries: %v\n", err)
+	}
+	if len(claimed) > 0 {
+		msg, r.pendingMsgs = claimed[0], claimed[1:]
+		return msg, nil
+	}
+
 	strs := make([]string, len(r.conf.Streams)*2)
 	for i, str := range r.conf.Streams {
 		strs[i] = str
@@ -285,35 +336,10 @@ func (r *redisStreamsReader) read() (pendingRedisStreamMsg, error) {
 			}
 		}
 		for _, xmsg := range strRes.Messages {
-			body, exists := xmsg.Values[r.conf.BodyKey]
-			if !exists {
-				continue
-			}
-			delete(xmsg.Values, r.conf.BodyKey)
-
-			var bodyBytes []byte
-			switch t := body.(type) {
-			case string:
-				bodyBytes = []byte(t)
-			case []byte:
-				bodyBytes = t
-			}
-			if bodyBytes == nil {
+			nextMsg, ok := r.entryToMsg(strRes.Stream, xmsg)
+			if !ok {
 				continue
 			}
-
-			part := message.NewPart(bodyBytes)
-			part.MetaSet("redis_stream", xmsg.ID)
-			for k, v := range xmsg.Values {
-				part.MetaSet(k, fmt.Sprintf("%v", v))
-			}
-
-			nextMsg := pendingRedisStreamMsg{
-				payload: message.QuickBatch(nil),
-				stream:  strRes.Stream,
-				id:      xmsg.ID,
-			}
-			nextMsg.payload.Append(part)
 			if msg.payload == nil {
 				msg = nextMsg
 			} else {
@@ -329,6 +355,196 @@ func (r *redisStreamsReader) read() (pendingRedisStreamMsg, error) {
 	return msg, nil
 }
 
+func (r *redisStreamsReader) entryToMsg(stream string, xmsg redis.XMessage) (pendingRedisStreamMsg, bool) {
+	body, exists := xmsg.Values[r.conf.BodyKey]
+	if !exists {
+		return pendingRedisStreamMsg{}, false
+	}
+	delete(xmsg.Values, r.conf.BodyKey)
+
+	var bodyBytes []byte
+	switch t := body.(type) {
+	case string:
+		bodyBytes = []byte(t)
+	case []byte:
+		bodyBytes = t
+	}
+	if bodyBytes == nil {
+		return pendingRedisStreamMsg{}, false
+	}
+
+	part := message.NewPart(bodyBytes)
+	part.MetaSet("redis_stream", xmsg.ID)
+	for k, v := range xmsg.Values {
+		part.MetaSet(k, fmt.Sprintf("%v", v))
+	}
+
+	msg := pendingRedisStreamMsg{
+		payload: message.QuickBatch(nil),
+		stream:  stream,
+		id:      xmsg.ID,
+	}
+	msg.payload.Append(part)
+	return msg, true
+}
+
+// claim takes ownership of entries that have been pending within the consumer
+// group for longer than the minimum idle time. Each call continues scanning
+// the pending entries of each stream from where the previous call left off,
+// and once all streams have been scanned fully the next scan begins after the
+// claim period.
+func (r *redisStreamsReader) claim(client redis.UniversalClient) ([]pendingRedisStreamMsg, error) {
+	if !r.conf.AutoClaim.Enabled {
+		return nil, nil
+	}
+	if len(r.claimCursors) == 0 {
+		if time.Now().Before(r.nextClaim) {
+			return nil, nil
+		}
+		for _, str := range r.conf.Streams {
+			r.claimCursors[str] = "0-0"
+		}
+	}
+
+	var msgs []pendingRedisStreamMsg
+	for _, str := range r.conf.Streams {
+		cursor, exists := r.claimCursors[str]
+		if !exists {
+			continue
+		}
+
+		res, err := client.Do(
+			"xautoclaim", str, r.conf.ConsumerGroup, r.conf.ClientID,
+			r.minIdleTime.Milliseconds(), cursor, "count", r.conf.Limit,
+		).Result()
+		if err != nil {
+			return msgs, fmt.Errorf("stream %v: %w", str, err)
+		}
+
+		nextCursor, entries, err := parseAutoClaimResult(res)
+		if err != nil {
+			return msgs, fmt.Errorf("stream %v: %w", str, err)
+		}
+		if nextCursor == "0-0" {
+			delete(r.claimCursors, str)
+		} else {
+			r.claimCursors[str] = nextCursor
+		}
+
+		if entries, err = r.deadLetter(client, str, entries); err != nil {
+			return msgs, fmt.Errorf("stream %v: %w", str, err)
+		}
+		for _, xmsg := range entries {
+			if msg, ok := r.entryToMsg(str, xmsg); ok {
+				msgs = append(msgs, msg)
+			}
+		}
+	}
+
+	if len(r.claimCursors) == 0 {
+		r.nextClaim = time.Now().Add(r.claimPeriod)
+	}
+	if len(msgs) > 0 {
+		r.log.Debugf("Claimed %v pending entries\n", len(msgs))
+	}
+	return msgs, nil
+}
+
+// deadLetter moves claimed entries that have exceeded the maximum number of
+// redeliveries into the dead letter stream, and returns the remaining entries.
+func (r *redisStreamsReader) deadLetter(client redis.UniversalClient, stream string, entries []redis.XMessage) ([]redis.XMessage, error) {
+	if r.conf.AutoClaim.MaxRetries <= 0 || len(entries) == 0 {
+		return entries, nil
+	}
+
+	pending, err := client.XPendingExt(&redis.XPendingExtArgs{
+		Stream:   stream,
+		Group:    r.conf.ConsumerGroup,
+		Start:    entries[0].ID,
+		End:      entries[len(entries)-1].ID,
+		Count:    int64(len(entries)),
+		Consumer: r.conf.ClientID,
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	deliveries := make(map[string]int64, len(pending))
+	for _, p := range pending {
+		deliveries[p.ID] = p.RetryCount
+	}
+
+	remaining := entries[:0]
+	for _, xmsg := range entries {
+		// The delivery count includes the first delivery of the entry as well
+		// as the claim that has just been made.
+		count := deliveries[xmsg.ID]
+		if count-1 <= r.conf.AutoClaim.MaxRetries {
+			remaining = append(remaining, xmsg)
+			continue
+		}
+
+		values := make(map[string]interface{}, len(xmsg.Values)+3)
+		for k, v := range xmsg.Values {
+			values[k] = v
+		}
+		values["redis_stream_source"] = stream
+		values["redis_stream_id"] = xmsg.ID
+		values["redis_stream_delivery_count"] = count
+
+		if err := client.XAdd(&redis.XAddArgs{
+			Stream: r.conf.AutoClaim.DeadLetterStream,
+			Values: values,
+		}).Err(); err != nil {
+			return nil, fmt.Errorf("failed to add entry %v to dead letter stream: %w", xmsg.ID, err)
+		}
+		if err := client.XAck(stream, r.conf.ConsumerGroup, xmsg.ID).Err(); err != nil {
+			return nil, fmt.Errorf("failed to ack dead lettered entry %v: %w", xmsg.ID, err)
+		}
+		r.log.Warnf("Entry %v of stream %v exceeded %v retries and was added to dead letter stream %v\n", xmsg.ID, stream, r.conf.AutoClaim.MaxRetries, r.conf.AutoClaim.DeadLetterStream)
+	}
+	return remaining, nil
+}
+
+// parseAutoClaimResult parses the reply of an XAUTOCLAIM command, which
+// consists of the cursor for the next call and the claimed entries, where
+// entries that no longer exist are nil. Redis v7 also appends a list of the
+// IDs of deleted entries, which is ignored.
+func parseAutoClaimResult(res interface{}) (cursor string, entries []redis.XMessage, err error) {
+	parts, ok := res.([]interface{})
+	if !ok || len(parts) < 2 {
+		return "", nil, fmt.Errorf("unexpected autoclaim result: %T", res)
+	}
+	if cursor, ok = parts[0].(string); !ok {
+		return "", nil, fmt.Errorf("unexpected autoclaim cursor: %T", parts[0])
+	}
+
+	rawEntries, ok := parts[1].([]interface{})
+	if !ok {
+		return "", nil, fmt.Errorf("unexpected autoclaim entries: %T", parts[1])
+	}
+	for _, rawEntry := range rawEntries {
+		entry, ok := rawEntry.([]interface{})
+		if !ok || len(entry) != 2 {
+			continue
+		}
+		id, _ := entry[0].(string)
+		fields, _ := entry[1].([]interface{})
+		if id == "" || fields == nil {
+			continue
+		}
+
+		values := make(map[string]interface{}, len(fields)/2)
+		for i := 0; i < len(fields)-1; i += 2 {
+			if k, ok := fields[i].(string); ok {
+				values[k] = fields[i+1]
+			}
+		}
+		entries = append(entries, redis.XMessage{ID: id, Values: values})
+	}
+	return cursor, entries, nil
+}
+
 func (r *redisStreamsReader) ReadWithContext(ctx context.Context) (*message.Batch, input.AsyncAckFn, error) {
 	msg, err := r.read()
 	if err != nil {
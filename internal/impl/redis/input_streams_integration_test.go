@@ -0,0 +1,136 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+	"github.com/ory/dockertest/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/component/input"
+	"github.com/benthosdev/benthos/v4/internal/integration"
+	"github.com/benthosdev/benthos/v4/internal/log"
+)
+
+func TestIntegrationRedisStreamsAutoClaim(t *testing.T) {
+	integration.CheckSkip(t)
+
+	pool, err := dockertest.NewPool("")
+	if err != nil {
+		t.Skipf("Could not connect to docker: %s", err)
+	}
+	pool.MaxWait = time.Second * 30
+
+	resource, err := pool.Run("redis", "latest", nil)
+	if err != nil {
+		t.Fatalf("Could not start resource: %s", err)
+	}
+
+	urlStr := fmt.Sprintf("tcp://localhost:%v", resource.GetPort("6379/tcp"))
+	uri, err := url.Parse(urlStr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := redis.NewClient(&redis.Options{
+		Addr:    uri.Host,
+		Network: uri.Scheme,
+	})
+
+	if err = pool.Retry(func() error {
+		return client.Ping().Err()
+	}); err != nil {
+		t.Fatalf("Could not connect to docker resource: %s", err)
+	}
+
+	defer func() {
+		if err = pool.Purge(resource); err != nil {
+			t.Logf("Failed to clean up docker resource: %v", err)
+		}
+	}()
+
+	defer client.Close()
+
+	require.NoError(t, client.XGroupCreateMkStream("foo", "bar", "$").Err())
+	for _, body := range []string{"first", "second"} {
+		require.NoError(t, client.XAdd(&redis.XAddArgs{
+			Stream: "foo",
+			Values: map[string]interface{}{"body": body},
+		}).Err())
+	}
+
+	// Deliver both entries to a consumer that never acknowledges them, and
+	// redeliver the second entry in order to exceed the retry limit.
+	res, err := client.XReadGroup(&redis.XReadGroupArgs{
+		Group:    "bar",
+		Consumer: "crashed",
+		Streams:  []string{"foo", ">"},
+		Count:    2,
+	}).Result()
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	require.Len(t, res[0].Messages, 2)
+
+	secondID := res[0].Messages[1].ID
+	require.NoError(t, client.XClaim(&redis.XClaimArgs{
+		Stream:   "foo",
+		Group:    "bar",
+		Consumer: "crashed",
+		Messages: []string{secondID},
+	}).Err())
+
+	conf := input.NewRedisStreamsConfig()
+	conf.URL = urlStr
+	conf.Streams = []string{"foo"}
+	conf.ConsumerGroup = "bar"
+	conf.ClientID = "baz"
+	conf.AutoClaim.Enabled = true
+	conf.AutoClaim.MinIdleTime = "1ms"
+	conf.AutoClaim.MaxRetries = 1
+	conf.AutoClaim.DeadLetterStream = "foo_dlq"
+
+	r, err := newRedisStreamsReader(conf, log.Noop())
+	require.NoError(t, err)
+	defer func() {
+		r.CloseAsync()
+		assert.NoError(t, r.WaitForClose(time.Second*5))
+	}()
+
+	<-time.After(time.Millisecond * 10)
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*10)
+	defer done()
+
+	require.NoError(t, r.ConnectWithContext(ctx))
+
+	msg, ackFn, err := r.ReadWithContext(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 1, msg.Len())
+	assert.Equal(t, "first", string(msg.Get(0).Get()))
+	require.NoError(t, ackFn(ctx, nil))
+
+	dlq, err := client.XRange("foo_dlq", "-", "+").Result()
+	require.NoError(t, err)
+	require.Len(t, dlq, 1)
+	assert.Equal(t, map[string]interface{}{
+		"body":                        "second",
+		"redis_stream_source":         "foo",
+		"redis_stream_id":             secondID,
+		"redis_stream_delivery_count": "3",
+	}, dlq[0].Values)
+
+	pending, err := client.XPendingExt(&redis.XPendingExtArgs{
+		Stream: "foo",
+		Group:  "bar",
+		Start:  secondID,
+		End:    secondID,
+		Count:  1,
+	}).Result()
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+}
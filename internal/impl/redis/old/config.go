@@ -5,12 +5,18 @@ import (
 	btls "github.com/benthosdev/benthos/v4/internal/tls"
 )
 
+// TLSConfig contains TLS settings for a redis connection.
+type TLSConfig struct {
+	btls.Config    `json:",inline" yaml:",inline"`
+	ReloadInterval string `json:"reload_interval" yaml:"reload_interval"`
+}
+
 // Config is a config struct for a redis connection.
 type Config struct {
-	URL    string      `json:"url" yaml:"url"`
-	Kind   string      `json:"kind" yaml:"kind"`
-	Master string      `json:"master" yaml:"master"`
-	TLS    btls.Config `json:"tls" yaml:"tls"`
+	URL    string    `json:"url" yaml:"url"`
+	Kind   string    `json:"kind" yaml:"kind"`
+	Master string    `json:"master" yaml:"master"`
+	TLS    TLSConfig `json:"tls" yaml:"tls"`
 }
 
 // NewConfig returns a Config with default values.
@@ -18,18 +24,30 @@ func NewConfig() Config {
 	return Config{
 		URL:  "",
 		Kind: "simple",
-		TLS:  btls.NewConfig(),
+		TLS: TLSConfig{
+			Config:         btls.NewConfig(),
+			ReloadInterval: "",
+		},
 	}
 }
 
-// ConfigDocs returns a documentation field spec for fields within a Config.
-func ConfigDocs() docs.FieldSpecs {
+// TLSFieldSpec returns a documentation field spec for the TLS settings of a
+// redis connection.
+func TLSFieldSpec() docs.FieldSpec {
 	tlsSpec := btls.FieldSpec()
 	tlsSpec.Description = tlsSpec.Description + `
 
 **Troubleshooting**
 
 Some cloud hosted instances of Redis (such as Azure Cache) might need some hand holding in order to establish stable connections. Unfortunately, it is often the case that TLS issues will manifest as generic error messages such as "i/o timeout". If you're using TLS and are seeing connectivity problems consider setting ` + "`enable_renegotiation` to `true`" + `, and ensuring that the server supports at least TLS version 1.2.`
+	tlsSpec.Children = append(tlsSpec.Children, docs.FieldString(
+		"reload_interval", "An optional interval at which the files of client certificates specified with `cert_file` and `key_file` are checked for modifications, where modified certificates are reloaded and used by subsequent connections. This allows certificates to be rotated without restarting the pipeline. When empty certificates are loaded only once.", "1m", "30s",
+	).HasDefault("").AtVersion("4.3.0"))
+	return tlsSpec
+}
+
+// ConfigDocs returns a documentation field spec for fields within a Config.
+func ConfigDocs() docs.FieldSpecs {
 	return docs.FieldSpecs{
 		docs.FieldString(
 			"url", "The URL of the target Redis server. Database is optional and is supplied as the URL path. The scheme `tcp` is equivalent to `redis`.",
@@ -42,6 +60,6 @@ Some cloud hosted instances of Redis (such as Azure Cache) might need some hand
 		).HasDefault(""),
 		docs.FieldString("kind", "Specifies a simple, cluster-aware, or failover-aware redis client.", "simple", "cluster", "failover").HasDefault("simple").Advanced(),
 		docs.FieldString("master", "Name of the redis master when `kind` is `failover`", "mymaster").HasDefault("").Advanced(),
-		tlsSpec,
+		TLSFieldSpec(),
 	}
 }
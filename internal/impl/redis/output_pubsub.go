@@ -2,7 +2,9 @@ package redis
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 
@@ -30,9 +32,22 @@ Publishes messages through the Redis PubSub model. It is not possible to
 guarantee that messages have been received.`,
 		Description: output.Description(true, true, `
 This output will interpolate functions within the channel field, you
-can find a list of functions [here](/docs/configuration/interpolation#bloblang-queries).`),
+can find a list of functions [here](/docs/configuration/interpolation#bloblang-queries).
+
+### Sharded Channels
+
+When `+"`sharded`"+` is set to `+"`true`"+` messages are published to sharded
+channels with the SPUBLISH command, which requires Redis v7.0 or later. With
+regular channels a cluster broadcasts every message to every node, whereas a
+message of a sharded channel is only propagated within the shard that owns the
+slot of the channel. When connected to a cluster, messages are grouped by the
+master node of the slot of their channel and sent directly to that node.
+
+Messages published to sharded channels can only be received by subscribers
+using SSUBSCRIBE.`),
 		Config: docs.FieldComponent().WithChildren(old.ConfigDocs()...).WithChildren(
 			docs.FieldString("channel", "The channel to publish messages to.").IsInterpolated(),
+			docs.FieldBool("sharded", "Whether to publish messages to sharded channels with SPUBLISH rather than PUBLISH, which requires Redis v7.0 or later.").Advanced(),
 			docs.FieldInt("max_in_flight", "The maximum number of messages to have in flight at a given time. Increase this to improve throughput."),
 			policy.FieldSpec(),
 		).ChildDefaultAndTypesFromStruct(output.NewRedisPubSubConfig()),
@@ -64,6 +79,7 @@ type redisPubSubWriter struct {
 	channelStr *field.Expression
 
 	client  redis.UniversalClient
+	slots   *clusterSlots
 	connMut sync.RWMutex
 }
 
@@ -97,6 +113,98 @@ func (r *redisPubSubWriter) ConnectWithContext(ctx context.Context) error {
 	r.log.Infof("Pushing messages to Redis channel: %v\n", r.conf.Channel)
 
 	r.client = client
+	r.slots = nil
+	return nil
+}
+
+// getSlots returns the slots of the cluster that the client is connected to,
+// loading them if they haven't been loaded since connecting or since they were
+// invalidated, or nil if the client isn't a cluster client.
+func (r *redisPubSubWriter) getSlots(client redis.UniversalClient) *clusterSlots {
+	cluster, ok := client.(*redis.ClusterClient)
+	if !ok {
+		return nil
+	}
+
+	r.connMut.Lock()
+	defer r.connMut.Unlock()
+
+	if r.slots == nil {
+		slots, err := loadClusterSlots(cluster)
+		if err != nil {
+			r.log.Warnf("Failed to load cluster slots, messages will be redirected by the cluster: %v\n", err)
+			return nil
+		}
+		r.slots = slots
+	}
+	return r.slots
+}
+
+func (r *redisPubSubWriter) invalidateSlots() {
+	r.connMut.Lock()
+	r.slots = nil
+	r.connMut.Unlock()
+}
+
+// writeSharded publishes messages to sharded channels, where messages are sent
+// within a pipeline for each master node that owns the slots of their channels
+// when connected to a cluster.
+func (r *redisPubSubWriter) writeSharded(client redis.UniversalClient, msg *message.Batch) error {
+	slots := r.getSlots(client)
+
+	channels := make([]string, msg.Len())
+	var nodes []redis.UniversalClient
+	groups := map[redis.UniversalClient][]int{}
+	for i := range channels {
+		channels[i] = r.channelStr.String(i, msg)
+
+		var node redis.UniversalClient = client
+		if slots != nil {
+			if master := slots.master(channels[i]); master != nil {
+				node = master
+			}
+		}
+		if _, exists := groups[node]; !exists {
+			nodes = append(nodes, node)
+		}
+		groups[node] = append(groups[node], i)
+	}
+
+	var batchErr *ibatch.Error
+	for _, node := range nodes {
+		indexes := groups[node]
+
+		pipe := node.Pipeline()
+		cmds := make([]*redis.Cmd, len(indexes))
+		for j, i := range indexes {
+			cmds[j] = pipe.Do("spublish", channels[i], msg.Get(i).Get())
+		}
+		_, _ = pipe.Exec()
+
+		for j, cmd := range cmds {
+			err := cmd.Err()
+			if err == nil {
+				continue
+			}
+
+			var rErr redis.Error
+			if !errors.As(err, &rErr) {
+				_ = r.disconnect()
+				r.log.Errorf("Error from redis: %v\n", err)
+				return component.ErrNotConnected
+			}
+			if errStr := err.Error(); strings.HasPrefix(errStr, "MOVED ") || strings.HasPrefix(errStr, "ASK ") {
+				r.invalidateSlots()
+			}
+			if batchErr == nil {
+				batchErr = ibatch.NewError(msg, err)
+			}
+			batchErr.Failed(indexes[j], err)
+		}
+	}
+	if batchErr != nil {
+		return batchErr
+	}
 	return nil
 }
 
@@ -109,6 +217,10 @@ func (r *redisPubSubWriter) WriteWithContext(ctx context.Context, msg *message.B
 		return component.ErrNotConnected
 	}
 
+	if r.conf.Sharded {
+		return r.writeSharded(client, msg)
+	}
+
 	if msg.Len() == 1 {
 		channel := r.channelStr.String(0, msg)
 		if err := client.Publish(channel, msg.Get(0).Get()).Err(); err != nil {
@@ -152,6 +264,7 @@ func (r *redisPubSubWriter) disconnect() error {
 	if r.client != nil {
 		err := r.client.Close()
 		r.client = nil
+		r.slots = nil
 		return err
 	}
 	return nil
@@ -0,0 +1,407 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
+	"github.com/benthosdev/benthos/v4/internal/component/output"
+	"github.com/benthosdev/benthos/v4/public/bloblang"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func redisTimeSeriesOutputConfig() *service.ConfigSpec {
+	spec := service.NewConfigSpec().
+		Beta().
+		Categories("Services").
+		Version("4.3.0").
+		Summary(`Adds samples to [RedisTimeSeries](https://redis.io/docs/stack/timeseries/) keys using the commands TS.ADD and TS.MADD.`).
+		Description(output.Description(true, true, `
+The key, timestamp, value and labels of the sample of each message are resolved with [Bloblang mappings](/docs/guides/bloblang/about). Batches of messages are added with a single TS.MADD command, or with pipelined TS.ADD commands when using a `+"`cluster`"+` client as the keys of a batch might belong to different hash slots.
+
+When `+"`create.enabled`"+` is `+"`true`"+` each key is created with TS.CREATE the first time it is written to, using the retention period, duplicate policy and labels configured, where keys that already exist are left unchanged. Keys that have been created are remembered for the lifetime of the output.`))
+
+	for _, f := range clientFields() {
+		spec = spec.Field(f)
+	}
+
+	return spec.
+		Field(service.NewBloblangField("key").
+			Description("A [Bloblang mapping](/docs/guides/bloblang/about) which should evaluate to the key of the time series to add the sample of each message to.").
+			Example(`root = "temperature:" + this.sensor_id`).
+			Example(`root = meta("metric_name")`)).
+		Field(service.NewBloblangField("timestamp").
+			Description("An optional [Bloblang mapping](/docs/guides/bloblang/about) which should evaluate to the timestamp of each sample. Numbers are interpreted as unix timestamps in milliseconds, and strings are parsed as RFC3339 timestamps. When omitted the sample is timestamped by the server.").
+			Example(`root = this.timestamp_ms`).
+			Example(`root = this.time.ts_parse("2006-01-02T15:04:05Z07:00")`).
+			Optional()).
+		Field(service.NewBloblangField("value").
+			Description("A [Bloblang mapping](/docs/guides/bloblang/about) which should evaluate to the numeric value of each sample.").
+			Example(`root = this.temperature`)).
+		Field(service.NewBloblangField("labels").
+			Description("An optional [Bloblang mapping](/docs/guides/bloblang/about) which should evaluate to an object of labels to create keys with. Labels are only applied when a key is created.").
+			Example(`root.sensor = this.sensor_id
+root.region = meta("region")`).
+			Optional()).
+		Field(service.NewObjectField("create",
+			service.NewBoolField("enabled").
+				Description("Whether to create keys with TS.CREATE before adding samples to them.").
+				Default(true),
+			service.NewDurationField("retention").
+				Description("The maximum age of samples of created keys, compared to the latest sample. When zero samples are retained indefinitely.").
+				Example("24h").
+				Default("0s"),
+			service.NewStringEnumField("duplicate_policy", "", "block", "first", "last", "min", "max", "sum").
+				Description("The policy for handling samples with a timestamp that already exists within created keys. When empty the default policy of the server is used.").
+				Default(""),
+		).
+			Description("Options for creating keys when they are first written to.").
+			Advanced()).
+		Field(service.NewIntField("max_in_flight").
+			Description("The maximum number of batches to have in flight at a given time. Increase this to improve throughput.").
+			Default(64)).
+		Field(service.NewBatchPolicyField("batching")).
+		Example("Sensor Readings", "Store temperature readings of sensors in a time series per sensor, retaining samples for a week.", `
+output:
+  redis_timeseries:
+    url: tcp://localhost:6379
+    key: 'root = "temperature:" + this.sensor_id'
+    timestamp: 'root = this.timestamp_ms'
+    value: 'root = this.celsius'
+    labels: |
+      root.sensor = this.sensor_id
+      root.metric = "temperature"
+    create:
+      retention: 168h
+      duplicate_policy: last
+    batching:
+      count: 100
+      period: 1s
+`)
+}
+
+func init() {
+	err := service.RegisterBatchOutput(
+		"redis_timeseries", redisTimeSeriesOutputConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (out service.BatchOutput, batchPolicy service.BatchPolicy, maxInFlight int, err error) {
+			if batchPolicy, err = conf.FieldBatchPolicy("batching"); err != nil {
+				return
+			}
+			if maxInFlight, err = conf.FieldInt("max_in_flight"); err != nil {
+				return
+			}
+			out, err = newRedisTimeSeriesOutputFromConfig(conf, mgr.Logger())
+			return
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type tsSample struct {
+	key       string
+	timestamp string
+	value     float64
+	labels    map[string]string
+}
+
+type redisTimeSeriesOutput struct {
+	log  *service.Logger
+	conf *service.ParsedConfig
+
+	key       *bloblang.Executor
+	timestamp *bloblang.Executor
+	value     *bloblang.Executor
+	labels    *bloblang.Executor
+
+	create          bool
+	retention       time.Duration
+	duplicatePolicy string
+
+	createdMut sync.Mutex
+	created    map[string]struct{}
+
+	client  redis.UniversalClient
+	connMut sync.RWMutex
+}
+
+func newRedisTimeSeriesOutputFromConfig(conf *service.ParsedConfig, log *service.Logger) (*redisTimeSeriesOutput, error) {
+	r := &redisTimeSeriesOutput{
+		log:     log,
+		conf:    conf,
+		created: map[string]struct{}{},
+	}
+
+	var err error
+	if r.key, err = conf.FieldBloblang("key"); err != nil {
+		return nil, err
+	}
+	if r.value, err = conf.FieldBloblang("value"); err != nil {
+		return nil, err
+	}
+	if conf.Contains("timestamp") {
+		if r.timestamp, err = conf.FieldBloblang("timestamp"); err != nil {
+			return nil, err
+		}
+	}
+	if conf.Contains("labels") {
+		if r.labels, err = conf.FieldBloblang("labels"); err != nil {
+			return nil, err
+		}
+	}
+	if r.create, err = conf.FieldBool("create", "enabled"); err != nil {
+		return nil, err
+	}
+	if r.retention, err = conf.FieldDuration("create", "retention"); err != nil {
+		return nil, err
+	}
+	if r.duplicatePolicy, err = conf.FieldString("create", "duplicate_policy"); err != nil {
+		return nil, err
+	}
+
+	if _, err := getClient(conf); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *redisTimeSeriesOutput) Connect(ctx context.Context) error {
+	r.connMut.Lock()
+	defer r.connMut.Unlock()
+
+	client, err := getClient(r.conf)
+	if err != nil {
+		return err
+	}
+	if _, err = client.Ping().Result(); err != nil {
+		return err
+	}
+
+	r.log.Infof("Adding samples to RedisTimeSeries")
+
+	r.client = client
+	return nil
+}
+
+//------------------------------------------------------------------------------
+
+func (r *redisTimeSeriesOutput) sampleFromMessage(batch service.MessageBatch, i int) (s tsSample, err error) {
+	var v interface{}
+	if v, err = queryStructured(batch, i, r.key); err != nil {
+		return s, fmt.Errorf("key mapping: %w", err)
+	}
+	if s.key = query.IToString(v); s.key == "" {
+		return s, errors.New("key mapping: resulted in an empty key")
+	}
+
+	if v, err = queryStructured(batch, i, r.value); err != nil {
+		return s, fmt.Errorf("value mapping: %w", err)
+	}
+	if s.value, err = query.IGetNumber(v); err != nil {
+		return s, fmt.Errorf("value mapping: %w", err)
+	}
+
+	s.timestamp = "*"
+	if r.timestamp != nil {
+		if v, err = queryStructured(batch, i, r.timestamp); err != nil {
+			return s, fmt.Errorf("timestamp mapping: %w", err)
+		}
+		var ms int64
+		if ms, err = timestampMillis(v); err != nil {
+			return s, fmt.Errorf("timestamp mapping: %w", err)
+		}
+		s.timestamp = strconv.FormatInt(ms, 10)
+	}
+
+	if r.labels != nil && r.create {
+		if v, err = queryStructured(batch, i, r.labels); err != nil {
+			return s, fmt.Errorf("labels mapping: %w", err)
+		}
+		obj, isObj := v.(map[string]interface{})
+		if !isObj {
+			return s, fmt.Errorf("labels mapping: expected object, got %T", v)
+		}
+		s.labels = make(map[string]string, len(obj))
+		for k, lv := range obj {
+			s.labels[k] = query.IToString(lv)
+		}
+	}
+	return s, nil
+}
+
+func queryStructured(batch service.MessageBatch, i int, exec *bloblang.Executor) (interface{}, error) {
+	res, err := batch.BloblangQuery(i, exec)
+	if err != nil {
+		return nil, err
+	}
+	if res == nil {
+		return nil, errors.New("mapping resulted in a deleted message")
+	}
+	// Mappings that result in strings are stored as raw bytes, which can't be
+	// parsed as structured values.
+	if v, err := res.AsStructured(); err == nil {
+		return v, nil
+	}
+	b, err := res.AsBytes()
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// timestampMillis converts a mapped timestamp into a unix timestamp in
+// milliseconds, where numbers are already assumed to be in milliseconds.
+func timestampMillis(v interface{}) (int64, error) {
+	if query.ITypeOf(v) == query.ValueNumber {
+		return query.IGetInt(v)
+	}
+	t, err := query.IGetTimestamp(v)
+	if err != nil {
+		return 0, err
+	}
+	return t.UnixNano() / int64(time.Millisecond), nil
+}
+
+// createKey creates a key with TS.CREATE unless it has already been created
+// by this output.
+func (r *redisTimeSeriesOutput) createKey(client redis.UniversalClient, s tsSample) error {
+	r.createdMut.Lock()
+	_, exists := r.created[s.key]
+	r.createdMut.Unlock()
+	if exists {
+		return nil
+	}
+
+	args := []interface{}{"TS.CREATE", s.key}
+	if r.retention > 0 {
+		args = append(args, "RETENTION", r.retention.Milliseconds())
+	}
+	if r.duplicatePolicy != "" {
+		args = append(args, "DUPLICATE_POLICY", strings.ToUpper(r.duplicatePolicy))
+	}
+	if len(s.labels) > 0 {
+		labelKeys := make([]string, 0, len(s.labels))
+		for k := range s.labels {
+			labelKeys = append(labelKeys, k)
+		}
+		sort.Strings(labelKeys)
+
+		args = append(args, "LABELS")
+		for _, k := range labelKeys {
+			args = append(args, k, s.labels[k])
+		}
+	}
+
+	if err := client.Do(args...).Err(); err != nil && !strings.Contains(err.Error(), "key already exists") {
+		return err
+	}
+
+	r.createdMut.Lock()
+	r.created[s.key] = struct{}{}
+	r.createdMut.Unlock()
+	return nil
+}
+
+func (r *redisTimeSeriesOutput) WriteBatch(ctx context.Context, batch service.MessageBatch) error {
+	r.connMut.RLock()
+	client := r.client
+	r.connMut.RUnlock()
+
+	if client == nil {
+		return service.ErrNotConnected
+	}
+
+	samples := make([]tsSample, len(batch))
+	for i := range batch {
+		var err error
+		if samples[i], err = r.sampleFromMessage(batch, i); err != nil {
+			return err
+		}
+	}
+
+	if r.create {
+		for _, s := range samples {
+			if err := r.createKey(client, s); err != nil {
+				return r.handleErr(err)
+			}
+		}
+	}
+
+	var results []interface{}
+	if _, isCluster := client.(*redis.ClusterClient); isCluster || len(samples) == 1 {
+		cmds, err := client.Pipelined(func(pipe redis.Pipeliner) error {
+			for _, s := range samples {
+				pipe.Do("TS.ADD", s.key, s.timestamp, s.value)
+			}
+			return nil
+		})
+		if err != nil && len(cmds) == 0 {
+			return r.handleErr(err)
+		}
+		for _, cmd := range cmds {
+			res, err := cmd.(*redis.Cmd).Result()
+			if err != nil {
+				results = append(results, err)
+			} else {
+				results = append(results, res)
+			}
+		}
+	} else {
+		args := make([]interface{}, 0, 1+len(samples)*3)
+		args = append(args, "TS.MADD")
+		for _, s := range samples {
+			args = append(args, s.key, s.timestamp, s.value)
+		}
+		res, err := client.Do(args...).Result()
+		if err != nil {
+			return r.handleErr(err)
+		}
+		var isSlice bool
+		if results, isSlice = res.([]interface{}); !isSlice {
+			return fmt.Errorf("unexpected TS.MADD reply type: %T", res)
+		}
+	}
+
+	for i, res := range results {
+		if err, isErr := res.(error); isErr {
+			return fmt.Errorf("failed to add sample of message %v to key %v: %w", i, samples[i].key, err)
+		}
+	}
+	return nil
+}
+
+// handleErr disconnects the client when an error is not a reply from redis,
+// which indicates that the connection has been lost.
+func (r *redisTimeSeriesOutput) handleErr(err error) error {
+	if _, isReplyErr := err.(redis.Error); isReplyErr {
+		return err
+	}
+	r.log.Errorf("Error from redis: %v\n", err)
+	_ = r.disconnect()
+	return service.ErrNotConnected
+}
+
+func (r *redisTimeSeriesOutput) disconnect() error {
+	r.connMut.Lock()
+	defer r.connMut.Unlock()
+	if r.client != nil {
+		err := r.client.Close()
+		r.client = nil
+		return err
+	}
+	return nil
+}
+
+func (r *redisTimeSeriesOutput) Close(ctx context.Context) error {
+	return r.disconnect()
+}
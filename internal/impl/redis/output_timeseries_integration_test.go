@@ -0,0 +1,82 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+	"github.com/ory/dockertest/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/integration"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func TestIntegrationRedisTimeSeriesOutput(t *testing.T) {
+	integration.CheckSkip(t)
+	t.Parallel()
+
+	pool, err := dockertest.NewPool("")
+	require.NoError(t, err)
+
+	pool.MaxWait = time.Second * 30
+
+	resource, err := pool.Run("redislabs/redistimeseries", "latest", nil)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, pool.Purge(resource))
+	})
+
+	_ = resource.Expire(900)
+
+	client := redis.NewClient(&redis.Options{
+		Addr: fmt.Sprintf("localhost:%v", resource.GetPort("6379/tcp")),
+	})
+	require.NoError(t, pool.Retry(func() error {
+		return client.Ping().Err()
+	}))
+
+	pConf, err := redisTimeSeriesOutputConfig().ParseYAML(fmt.Sprintf(`
+url: tcp://localhost:%v
+key: 'root = "temperature:" + this.sensor'
+timestamp: 'root = this.ts'
+value: 'root = this.celsius'
+labels: 'root.sensor = this.sensor'
+create:
+  retention: 1h
+  duplicate_policy: last
+`, resource.GetPort("6379/tcp")), nil)
+	require.NoError(t, err)
+
+	out, err := newRedisTimeSeriesOutputFromConfig(pConf, nil)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	require.NoError(t, out.Connect(ctx))
+	t.Cleanup(func() {
+		_ = out.Close(ctx)
+	})
+
+	require.NoError(t, out.WriteBatch(ctx, service.MessageBatch{
+		service.NewMessage([]byte(`{"sensor":"a","ts":1000,"celsius":20}`)),
+		service.NewMessage([]byte(`{"sensor":"b","ts":1000,"celsius":30}`)),
+		service.NewMessage([]byte(`{"sensor":"a","ts":2000,"celsius":21}`)),
+	}))
+	require.NoError(t, out.WriteBatch(ctx, service.MessageBatch{
+		service.NewMessage([]byte(`{"sensor":"a","ts":2000,"celsius":22}`)),
+	}))
+
+	res, err := client.Do("TS.RANGE", "temperature:a", "-", "+").Result()
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{
+		[]interface{}{int64(1000), "20"},
+		[]interface{}{int64(2000), "22"},
+	}, res)
+
+	res, err = client.Do("TS.QUERYINDEX", "sensor=b").Result()
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{"temperature:b"}, res)
+}
@@ -0,0 +1,73 @@
+package redis
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func TestRedisTimeSeriesSamples(t *testing.T) {
+	pConf, err := redisTimeSeriesOutputConfig().ParseYAML(`
+url: tcp://localhost:6379
+key: 'root = "temperature:" + this.sensor'
+timestamp: 'root = this.ts'
+value: 'root = this.celsius'
+labels: |
+  root.sensor = this.sensor
+  root.floor = this.floor
+`, nil)
+	require.NoError(t, err)
+
+	r, err := newRedisTimeSeriesOutputFromConfig(pConf, nil)
+	require.NoError(t, err)
+
+	batch := service.MessageBatch{
+		service.NewMessage([]byte(`{"sensor":"a","ts":1600000000123,"celsius":21.5,"floor":2}`)),
+		service.NewMessage([]byte(`{"sensor":"b","ts":"2020-09-13T12:26:40.5Z","celsius":"19"}`)),
+		service.NewMessage([]byte(`{"sensor":"c","ts":1600000000123,"celsius":"hot"}`)),
+		service.NewMessage([]byte(`{"ts":1600000000123,"celsius":1}`)),
+	}
+
+	s, err := r.sampleFromMessage(batch, 0)
+	require.NoError(t, err)
+	assert.Equal(t, tsSample{
+		key:       "temperature:a",
+		timestamp: "1600000000123",
+		value:     21.5,
+		labels:    map[string]string{"sensor": "a", "floor": "2"},
+	}, s)
+
+	s, err = r.sampleFromMessage(batch, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "1600000000500", s.timestamp)
+	assert.Equal(t, 19.0, s.value)
+
+	_, err = r.sampleFromMessage(batch, 2)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "value mapping")
+
+	_, err = r.sampleFromMessage(batch, 3)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "key mapping")
+}
+
+func TestRedisTimeSeriesServerTimestamp(t *testing.T) {
+	pConf, err := redisTimeSeriesOutputConfig().ParseYAML(`
+url: tcp://localhost:6379
+key: 'root = "foo"'
+value: 'root = this.v'
+`, nil)
+	require.NoError(t, err)
+
+	r, err := newRedisTimeSeriesOutputFromConfig(pConf, nil)
+	require.NoError(t, err)
+
+	s, err := r.sampleFromMessage(service.MessageBatch{
+		service.NewMessage([]byte(`{"v":10}`)),
+	}, 0)
+	require.NoError(t, err)
+	assert.Equal(t, tsSample{key: "foo", timestamp: "*", value: 10}, s)
+}
@@ -3,10 +3,14 @@ package redis
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/cenkalti/backoff/v4"
 	"github.com/go-redis/redis/v7"
 
 	"github.com/benthosdev/benthos/v4/public/bloblang"
@@ -55,14 +59,57 @@ performed for each message and the message contents are replaced with the result
 			Description("The maximum number of retries before abandoning a request.").
 			Default(3).
 			Advanced()).
-		Field(service.NewIntField("retry_period").
-			Description("The time to wait before consecutive retry attempts.").
+		Field(service.NewDurationField("retry_period").
+			Description("The time to wait before consecutive retry attempts. This field is ignored when `backoff` is enabled.").
 			Default("500ms").
 			Advanced()).
+		Field(service.NewObjectField("backoff",
+			service.NewBoolField("enabled").
+				Description("Whether to time retry attempts with this back off policy rather than the constant `retry_period`.").
+				Default(false),
+			service.NewDurationField("initial_interval").
+				Description("The initial period to wait between retry attempts.").
+				Default("500ms").Example("50ms").Example("1s"),
+			service.NewDurationField("max_interval").
+				Description("The maximum period to wait between retry attempts.").
+				Default("10s").Example("5s").Example("1m"),
+			service.NewFloatField("multiplier").
+				Description("The factor by which the period to wait is increased after each retry attempt.").
+				Default(1.5),
+			service.NewDurationField("max_elapsed_time").
+				Description("The maximum overall period of time to spend on retry attempts before the request is abandoned. Setting this value to a zeroed duration (such as `0s`) results in retries being limited by `retries` only.").
+				Default("0s").Example("1m").Example("1h"),
+		).
+			Description("An exponential back off policy for timing retry attempts, which replaces the constant `retry_period` when enabled. Regardless of the policy only errors that might be resolved by retrying, such as connection errors or Redis loading its dataset, are retried, whereas errors such as `WRONGTYPE` fail immediately.").
+			Advanced()).
+		Field(service.NewBoolField("pipeline").
+			Description("Whether to send the commands of all messages of a batch through a single Redis pipeline, which reduces the number of round trips to one per batch. The result of each command is mapped back to the message it was created from. Only supported with the `command` field.").
+			Default(false).
+			Advanced()).
+		Field(service.NewBoolField("transaction").
+			Description("Whether to wrap the pipelined commands of a batch within a MULTI/EXEC transaction so that they are executed atomically, this implies `pipeline`. When using a `cluster` client all keys of a batch must belong to the same hash slot.").
+			Default(false).
+			Advanced()).
 		LintRule(`
 root = if this.contains("operator") && this.contains("command") {
   [ "only one of 'operator' (old style) or 'command' (new style) fields should be specified" ]
+} else if this.contains("operator") && (this.pipeline.or(false) || this.transaction.or(false)) {
+  [ "the 'pipeline' and 'transaction' fields are only supported with the 'command' field" ]
 }
+`).
+		Example("Pipelined Lookups",
+			`When enriching large batches of messages the latency of a round trip for each message can add up quickly. By setting `+"`pipeline`"+` to `+"`true`"+` the commands of a batch are sent together, here we fetch a value for each message of a batch in a single round trip:`,
+			`
+pipeline:
+  processors:
+    - branch:
+        processors:
+          - redis:
+              url: TODO
+              command: hget
+              args_mapping: 'root = [ "users", this.user_id ]'
+              pipeline: true
+        result_map: 'root.user_name = this'
 `).
 		Example("Querying Cardinality",
 			`If given payloads containing a metadata field `+"`set_key`"+` it's possible to query and store the cardinality of the set for each message using a `+"[`branch` processor](/docs/components/processors/branch)"+` in order to augment rather than replace the message contents:`,
@@ -132,9 +179,12 @@ type redisProc struct {
 	command     *service.InterpolatedString
 	argsMapping *bloblang.Executor
 
-	client      redis.UniversalClient
-	retries     int
-	retryPeriod time.Duration
+	client   redis.UniversalClient
+	retries  int
+	boffPool sync.Pool
+
+	pipeline    bool
+	transaction bool
 }
 
 func newRedisProcFromConfig(conf *service.ParsedConfig, res *service.Resources) (*redisProc, error) {
@@ -148,7 +198,7 @@ func newRedisProcFromConfig(conf *service.ParsedConfig, res *service.Resources)
 		return nil, err
 	}
 
-	retryPeriod, err := conf.FieldDuration("retry_period")
+	boff, err := retryBackOffFromConfig(conf)
 	if err != nil {
 		return nil, err
 	}
@@ -165,15 +215,34 @@ func newRedisProcFromConfig(conf *service.ParsedConfig, res *service.Resources)
 		}
 	}
 
+	transaction, err := conf.FieldBool("transaction")
+	if err != nil {
+		return nil, err
+	}
+
+	pipeline, err := conf.FieldBool("pipeline")
+	if err != nil {
+		return nil, err
+	}
+
 	r := &redisProc{
 		log: res.Logger(),
 
 		command:     command,
 		argsMapping: argsMapping,
 
-		retries:     retries,
-		retryPeriod: retryPeriod,
-		client:      client,
+		retries: retries,
+		boffPool: sync.Pool{
+			New: func() interface{} {
+				bo := *boff
+				bo.Reset()
+				return &bo
+			},
+		},
+		client: client,
+
+		pipeline:    pipeline || transaction,
+		transaction: transaction,
 	}
 
 	if conf.Contains("key") {
@@ -190,22 +259,115 @@ func newRedisProcFromConfig(conf *service.ParsedConfig, res *service.Resources)
 		if r.operator, err = getRedisOperator(operatorStr); err != nil {
 			return nil, err
 		}
+		if r.pipeline {
+			return nil, errors.New("the pipeline and transaction fields are only supported with the command field")
+		}
 	}
 
 	return r, nil
 }
 
-type redisOperator func(r *redisProc, key string, part *service.Message) error
+// retryBackOffFromConfig returns the back off policy of the backoff field when
+// it's enabled, or a constant policy of the retry_period otherwise.
+func retryBackOffFromConfig(conf *service.ParsedConfig) (*backoff.ExponentialBackOff, error) {
+	enabled, err := conf.FieldBool("backoff", "enabled")
+	if err != nil {
+		return nil, err
+	}
 
-func newRedisKeysOperator() redisOperator {
-	return func(r *redisProc, key string, part *service.Message) error {
-		res, err := r.client.Keys(key).Result()
+	boff := backoff.NewExponentialBackOff()
+	if !enabled {
+		retryPeriod, err := conf.FieldDuration("retry_period")
+		if err != nil {
+			return nil, err
+		}
+		boff.InitialInterval = retryPeriod
+		boff.MaxInterval = retryPeriod
+		boff.Multiplier = 1
+		boff.RandomizationFactor = 0
+		boff.MaxElapsedTime = 0
+		boff.Reset()
+		return boff, nil
+	}
 
-		for i := 0; i <= r.retries && err != nil; i++ {
-			r.log.Errorf("Keys command failed: %v\n", err)
-			<-time.After(r.retryPeriod)
-			res, err = r.client.Keys(key).Result()
+	if boff.InitialInterval, err = conf.FieldDuration("backoff", "initial_interval"); err != nil {
+		return nil, err
+	}
+	if boff.MaxInterval, err = conf.FieldDuration("backoff", "max_interval"); err != nil {
+		return nil, err
+	}
+	if boff.Multiplier, err = conf.FieldFloat("backoff", "multiplier"); err != nil {
+		return nil, err
+	}
+	if boff.Multiplier < 1 {
+		return nil, fmt.Errorf("backoff multiplier must be at least 1, got %v", boff.Multiplier)
+	}
+	if boff.MaxElapsedTime, err = conf.FieldDuration("backoff", "max_elapsed_time"); err != nil {
+		return nil, err
+	}
+	boff.Reset()
+	return boff, nil
+}
+
+// isRetryableError returns true for errors that might be resolved by retrying
+// a command, such as network errors or errors returned by Redis whilst it's
+// temporarily unable to serve commands. Other errors returned by Redis, such as
+// WRONGTYPE errors, are permanent.
+func isRetryableError(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var rErr redis.Error
+	if !errors.As(err, &rErr) {
+		return true
+	}
+	msg := rErr.Error()
+	if msg == "ERR max number of clients reached" {
+		return true
+	}
+	for _, prefix := range []string{"LOADING ", "BUSY ", "TRYAGAIN ", "CLUSTERDOWN ", "MASTERDOWN ", "READONLY "} {
+		if strings.HasPrefix(msg, prefix) {
+			return true
 		}
+	}
+	return false
+}
+
+// retry calls fn until it succeeds, fails with an error that isn't retryable,
+// or the retries or back off policy are exhausted.
+func (r *redisProc) retry(ctx context.Context, name string, fn func() error) error {
+	boff := r.boffPool.Get().(backoff.BackOff)
+	defer func() {
+		boff.Reset()
+		r.boffPool.Put(boff)
+	}()
+
+	err := fn()
+	for i := 0; i < r.retries && isRetryableError(err); i++ {
+		wait := boff.NextBackOff()
+		if wait == backoff.Stop {
+			break
+		}
+		r.log.Errorf("%v command failed: %v\n", name, err)
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		err = fn()
+	}
+	return err
+}
+
+type redisOperator func(ctx context.Context, r *redisProc, key string, part *service.Message) error
+
+func newRedisKeysOperator() redisOperator {
+	return func(ctx context.Context, r *redisProc, key string, part *service.Message) error {
+		var res []string
+		err := r.retry(ctx, "Keys", func() (err error) {
+			res, err = r.client.Keys(key).Result()
+			return
+		})
 		if err != nil {
 			return err
 		}
@@ -220,14 +382,12 @@ func newRedisKeysOperator() redisOperator {
 }
 
 func newRedisSCardOperator() redisOperator {
-	return func(r *redisProc, key string, part *service.Message) error {
-		res, err := r.client.SCard(key).Result()
-
-		for i := 0; i <= r.retries && err != nil; i++ {
-			r.log.Errorf("SCard command failed: %v\n", err)
-			<-time.After(r.retryPeriod)
+	return func(ctx context.Context, r *redisProc, key string, part *service.Message) error {
+		var res int64
+		err := r.retry(ctx, "SCard", func() (err error) {
 			res, err = r.client.SCard(key).Result()
-		}
+			return
+		})
 		if err != nil {
 			return err
 		}
@@ -238,19 +398,17 @@ func newRedisSCardOperator() redisOperator {
 }
 
 func newRedisSAddOperator() redisOperator {
-	return func(r *redisProc, key string, part *service.Message) error {
+	return func(ctx context.Context, r *redisProc, key string, part *service.Message) error {
 		mBytes, err := part.AsBytes()
 		if err != nil {
 			return err
 		}
 
-		res, err := r.client.SAdd(key, mBytes).Result()
-
-		for i := 0; i <= r.retries && err != nil; i++ {
-			r.log.Errorf("SAdd command failed: %v\n", err)
-			<-time.After(r.retryPeriod)
+		var res int64
+		err = r.retry(ctx, "SAdd", func() (err error) {
 			res, err = r.client.SAdd(key, mBytes).Result()
-		}
+			return
+		})
 		if err != nil {
 			return err
 		}
@@ -261,7 +419,7 @@ func newRedisSAddOperator() redisOperator {
 }
 
 func newRedisIncrByOperator() redisOperator {
-	return func(r *redisProc, key string, part *service.Message) error {
+	return func(ctx context.Context, r *redisProc, key string, part *service.Message) error {
 		mBytes, err := part.AsBytes()
 		if err != nil {
 			return err
@@ -271,13 +429,11 @@ func newRedisIncrByOperator() redisOperator {
 		if err != nil {
 			return err
 		}
-		res, err := r.client.IncrBy(key, int64(valueInt)).Result()
-
-		for i := 0; i <= r.retries && err != nil; i++ {
-			r.log.Errorf("incrby command failed: %v\n", err)
-			<-time.After(r.retryPeriod)
+		var res int64
+		err = r.retry(ctx, "incrby", func() (err error) {
 			res, err = r.client.IncrBy(key, int64(valueInt)).Result()
-		}
+			return
+		})
 		if err != nil {
 			return err
 		}
@@ -301,20 +457,22 @@ func getRedisOperator(opStr string) (redisOperator, error) {
 	return nil, fmt.Errorf("operator not recognised: %v", opStr)
 }
 
-func (r *redisProc) execRaw(ctx context.Context, index int, inBatch service.MessageBatch, msg *service.Message) error {
-	resMsg, err := inBatch.BloblangQuery(index, r.argsMapping)
+// mappingArgs executes a mapping that should result in an array of command
+// arguments for the message at an index of a batch.
+func mappingArgs(index int, inBatch service.MessageBatch, mapping *bloblang.Executor) ([]interface{}, error) {
+	resMsg, err := inBatch.BloblangQuery(index, mapping)
 	if err != nil {
-		return fmt.Errorf("args mapping failed: %v", err)
+		return nil, err
 	}
 
 	iargs, err := resMsg.AsStructured()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	args, ok := iargs.([]interface{})
 	if !ok {
-		return fmt.Errorf("mapping returned non-array result: %T", iargs)
+		return nil, fmt.Errorf("mapping returned non-array result: %T", iargs)
 	}
 	for i, v := range args {
 		n, isN := v.(json.Number)
@@ -328,16 +486,32 @@ func (r *redisProc) execRaw(ctx context.Context, index int, inBatch service.Mess
 			}
 		}
 	}
+	return args, nil
+}
+
+// commandArgs returns the command and its arguments for the message at an
+// index of a batch.
+func (r *redisProc) commandArgs(index int, inBatch service.MessageBatch) ([]interface{}, error) {
+	args, err := mappingArgs(index, inBatch, r.argsMapping)
+	if err != nil {
+		return nil, fmt.Errorf("args mapping failed: %v", err)
+	}
 
 	command := inBatch.InterpolatedString(index, r.command)
-	args = append([]interface{}{command}, args...)
+	return append([]interface{}{command}, args...), nil
+}
 
-	res, err := r.client.DoContext(ctx, args...).Result()
-	for i := 0; i <= r.retries && err != nil; i++ {
-		r.log.Errorf("%v command failed: %v", command, err)
-		<-time.After(r.retryPeriod)
-		res, err = r.client.DoContext(ctx, args...).Result()
+func (r *redisProc) execRaw(ctx context.Context, index int, inBatch service.MessageBatch, msg *service.Message) error {
+	args, err := r.commandArgs(index, inBatch)
+	if err != nil {
+		return err
 	}
+
+	var res interface{}
+	err = r.retry(ctx, fmt.Sprintf("%v", args[0]), func() (err error) {
+		res, err = r.client.DoContext(ctx, args...).Result()
+		return
+	})
 	if err != nil {
 		return err
 	}
@@ -346,12 +520,98 @@ func (r *redisProc) execRaw(ctx context.Context, index int, inBatch service.Mess
 	return nil
 }
 
+// execPipeline executes the commands of all messages of a batch within a single
+// pipeline. Commands that fail with a retryable error, such as when the
+// connection is lost, are retried within a new pipeline, whereas permanent
+// errors are set on the message of the command.
+func (r *redisProc) execPipeline(ctx context.Context, inBatch, outBatch service.MessageBatch) {
+	var indexes []int
+	var cmdArgs [][]interface{}
+	for i := range outBatch {
+		args, err := r.commandArgs(i, inBatch)
+		if err != nil {
+			r.log.Debugf("Args mapping failed: %v", err)
+			outBatch[i].SetError(err)
+			continue
+		}
+		indexes = append(indexes, i)
+		cmdArgs = append(cmdArgs, args)
+	}
+
+	boff := r.boffPool.Get().(backoff.BackOff)
+	defer func() {
+		boff.Reset()
+		r.boffPool.Put(boff)
+	}()
+
+	for attempt := 0; len(indexes) > 0; attempt++ {
+		var pipe redis.Pipeliner
+		if r.transaction {
+			pipe = r.client.TxPipeline()
+		} else {
+			pipe = r.client.Pipeline()
+		}
+
+		cmds := make([]*redis.Cmd, len(cmdArgs))
+		for i, args := range cmdArgs {
+			cmds[i] = pipe.Do(args...)
+		}
+
+		// Errors are obtained from each individual command.
+		_, _ = pipe.ExecContext(ctx)
+
+		var retryIndexes []int
+		var retryArgs [][]interface{}
+		var retryErrs []error
+		for i, cmd := range cmds {
+			res, err := cmd.Result()
+			if err == nil {
+				outBatch[indexes[i]].SetStructured(res)
+				continue
+			}
+
+			if attempt < r.retries && isRetryableError(err) {
+				retryIndexes = append(retryIndexes, indexes[i])
+				retryArgs = append(retryArgs, cmdArgs[i])
+				retryErrs = append(retryErrs, err)
+				continue
+			}
+			r.log.Debugf("%v command failed: %v", cmdArgs[i][0], err)
+			outBatch[indexes[i]].SetError(err)
+		}
+
+		if len(retryIndexes) > 0 {
+			wait := boff.NextBackOff()
+			if wait == backoff.Stop {
+				for i, index := range retryIndexes {
+					outBatch[index].SetError(retryErrs[i])
+				}
+				return
+			}
+			r.log.Errorf("%v pipelined commands failed: %v", len(retryIndexes), retryErrs[0])
+			select {
+			case <-time.After(wait):
+			case <-ctx.Done():
+				for _, i := range retryIndexes {
+					outBatch[i].SetError(ctx.Err())
+				}
+				return
+			}
+		}
+		indexes, cmdArgs = retryIndexes, retryArgs
+	}
+}
+
 func (r *redisProc) ProcessBatch(ctx context.Context, inBatch service.MessageBatch) ([]service.MessageBatch, error) {
 	newMsg := inBatch.Copy()
+	if r.pipeline {
+		r.execPipeline(ctx, inBatch, newMsg)
+		return []service.MessageBatch{newMsg}, nil
+	}
 	for index, part := range newMsg {
 		if r.operator != nil {
 			key := inBatch.InterpolatedString(index, r.key)
-			if err := r.operator(r, key, part); err != nil {
+			if err := r.operator(ctx, r, key, part); err != nil {
 				r.log.Debugf("Operator failed for key '%s': %v", key, err)
 				part.SetError(fmt.Errorf("redis operator failed: %w", err))
 			}
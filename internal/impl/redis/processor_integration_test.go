@@ -68,6 +68,12 @@ func TestIntegrationRedisProcessor(t *testing.T) {
 	t.Run("testRedisIncrby", func(t *testing.T) {
 		testRedisIncrby(t, client, urlStr)
 	})
+	t.Run("testRedisPipelineIncrby", func(t *testing.T) {
+		testRedisPipelineIncrby(t, client, urlStr, "pipeline")
+	})
+	t.Run("testRedisTransactionIncrby", func(t *testing.T) {
+		testRedisPipelineIncrby(t, client, urlStr, "transaction")
+	})
 
 	require.NoError(t, client.FlushAll().Err())
 
@@ -275,6 +281,52 @@ args_mapping: 'root = [ "incrby", this.number() ]'
 	}
 }
 
+func testRedisPipelineIncrby(t *testing.T, client *redis.Client, url, mode string) {
+	conf, err := redisProcConfig().ParseYAML(fmt.Sprintf(`
+url: %v
+command: incrby
+args_mapping: 'root = [ "%v_incrby", this.number() ]'
+%v: true
+`, url, mode, mode), nil)
+	require.NoError(t, err)
+
+	r, err := newRedisProcFromConfig(conf, service.MockResources())
+	require.NoError(t, err)
+
+	msg := service.MessageBatch{
+		service.NewMessage([]byte(`2`)),
+		service.NewMessage([]byte(`1`)),
+		service.NewMessage([]byte(`not a number`)),
+		service.NewMessage([]byte(`5`)),
+		service.NewMessage([]byte(`-10`)),
+	}
+
+	resMsgs, response := r.ProcessBatch(context.Background(), msg)
+	require.NoError(t, response)
+
+	exp := []string{
+		`2`,
+		`3`,
+		``,
+		`8`,
+		`-2`,
+	}
+
+	require.Len(t, resMsgs, 1)
+	require.Len(t, resMsgs[0], len(exp))
+
+	for i, e := range exp {
+		if e == "" {
+			require.Error(t, resMsgs[0][i].GetError())
+			continue
+		}
+		require.NoError(t, resMsgs[0][i].GetError())
+		act, err := resMsgs[0][i].AsBytes()
+		require.NoError(t, err)
+		assert.Equal(t, e, string(act))
+	}
+}
+
 func testRedisDeprecatedKeys(t *testing.T, client *redis.Client, url string) {
 	conf, err := redisProcConfig().ParseYAML(fmt.Sprintf(`
 url: %v
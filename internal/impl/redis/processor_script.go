@@ -0,0 +1,246 @@
+package redis
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+
+	"github.com/benthosdev/benthos/v4/public/bloblang"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func redisScriptProcConfig() *service.ConfigSpec {
+	spec := service.NewConfigSpec().
+		Beta().
+		Summary(`Executes a Lua script against Redis for each message and replaces the message contents with the result.`).
+		Description(`
+Scripts are executed atomically by Redis, which makes it possible to perform operations spanning multiple keys, or operations that depend on the current value of a key, that can't be expressed safely with the ` + "[`redis` processor](/docs/components/processors/redis)" + `.
+
+The script is loaded once with ` + "`SCRIPT LOAD`" + ` and each message then executes it with ` + "`EVALSHA`" + `, if Redis no longer knows of the script (for example after a restart or a ` + "`SCRIPT FLUSH`" + `) it is executed with ` + "`EVAL`" + ` instead, which also loads it again.
+
+Within the script the keys provided by ` + "`keys_mapping`" + ` are available as the table ` + "`KEYS`" + ` and the arguments provided by ` + "`args_mapping`" + ` are available as the table ` + "`ARGV`" + `. When using a ` + "`cluster`" + ` client all keys of a script must belong to the same hash slot.
+
+In order to merge the result into the original message compose this processor within a ` + "[`branch` processor](/docs/components/processors/branch)" + `.`).
+		Categories("Integration")
+
+	for _, f := range clientFields() {
+		spec = spec.Field(f)
+	}
+
+	return spec.
+		Field(service.NewStringField("script").
+			Description("The Lua script to execute.").
+			Example(`return redis.call('set', KEYS[1], ARGV[1])`)).
+		Field(service.NewBloblangField("keys_mapping").
+			Description("A [Bloblang mapping](/docs/guides/bloblang/about) which should evaluate to an array of the keys accessed by the script, which are available within the script as `KEYS`.").
+			Example("root = [ this.key ]").
+			Example(`root = [ meta("kafka_key"), "totals" ]`).
+			Default(``)).
+		Field(service.NewBloblangField("args_mapping").
+			Description("A [Bloblang mapping](/docs/guides/bloblang/about) which should evaluate to an array of arguments for the script, which are available within the script as `ARGV`.").
+			Example("root = [ this.count ]").
+			Default(``)).
+		Field(service.NewIntField("retries").
+			Description("The maximum number of retries before abandoning a request.").
+			Default(3).
+			Advanced()).
+		Field(service.NewDurationField("retry_period").
+			Description("The time to wait before consecutive retry attempts.").
+			Default("500ms").
+			Advanced()).
+		Example("Conditional Update",
+			`Given JSON documents containing a version, we can store the latest document of each ID and discard stale documents arriving out of order by comparing the versions within a script, which would otherwise be subject to races between the read and the write:`,
+			`
+pipeline:
+  processors:
+    - branch:
+        processors:
+          - redis_script:
+              url: TODO
+              script: |
+                local current = tonumber(redis.call('hget', KEYS[1], 'version'))
+                if current and current >= tonumber(ARGV[1]) then
+                  return 0
+                end
+                redis.call('hset', KEYS[1], 'version', ARGV[1], 'doc', ARGV[2])
+                return 1
+              keys_mapping: 'root = [ "docs:" + this.id ]'
+              args_mapping: 'root = [ this.version, content().string() ]'
+        result_map: 'root = if this == 0 { deleted() }'
+`)
+}
+
+func init() {
+	err := service.RegisterBatchProcessor(
+		"redis_script", redisScriptProcConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.BatchProcessor, error) {
+			return newRedisScriptProcFromConfig(conf, mgr)
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type redisScriptProc struct {
+	log *service.Logger
+
+	script      string
+	sha         string
+	keysMapping *bloblang.Executor
+	argsMapping *bloblang.Executor
+
+	loadedMut sync.Mutex
+	loaded    bool
+
+	client      redis.UniversalClient
+	retries     int
+	retryPeriod time.Duration
+}
+
+func newRedisScriptProcFromConfig(conf *service.ParsedConfig, res *service.Resources) (*redisScriptProc, error) {
+	script, err := conf.FieldString("script")
+	if err != nil {
+		return nil, err
+	}
+	if script == "" {
+		return nil, errors.New("a script must be specified")
+	}
+
+	retries, err := conf.FieldInt("retries")
+	if err != nil {
+		return nil, err
+	}
+
+	retryPeriod, err := conf.FieldDuration("retry_period")
+	if err != nil {
+		return nil, err
+	}
+
+	var keysMapping, argsMapping *bloblang.Executor
+	if testStr, _ := conf.FieldString("keys_mapping"); testStr != "" {
+		if keysMapping, err = conf.FieldBloblang("keys_mapping"); err != nil {
+			return nil, err
+		}
+	}
+	if testStr, _ := conf.FieldString("args_mapping"); testStr != "" {
+		if argsMapping, err = conf.FieldBloblang("args_mapping"); err != nil {
+			return nil, err
+		}
+	}
+
+	client, err := getClient(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	hash := sha1.Sum([]byte(script))
+	return &redisScriptProc{
+		log: res.Logger(),
+
+		script:      script,
+		sha:         hex.EncodeToString(hash[:]),
+		keysMapping: keysMapping,
+		argsMapping: argsMapping,
+
+		client:      client,
+		retries:     retries,
+		retryPeriod: retryPeriod,
+	}, nil
+}
+
+// loadScript loads the script into the script cache of Redis unless it has
+// already been loaded. Failing to load the script isn't fatal as messages fall
+// back to EVAL, and loading is attempted again with the next batch.
+func (r *redisScriptProc) loadScript(ctx context.Context) {
+	r.loadedMut.Lock()
+	defer r.loadedMut.Unlock()
+
+	if r.loaded {
+		return
+	}
+	if err := r.client.DoContext(ctx, "script", "load", r.script).Err(); err != nil {
+		r.log.Warnf("Failed to load script: %v", err)
+		return
+	}
+	r.loaded = true
+}
+
+func (r *redisScriptProc) scriptArgs(index int, inBatch service.MessageBatch) ([]interface{}, error) {
+	var keys, args []interface{}
+	var err error
+	if r.keysMapping != nil {
+		if keys, err = mappingArgs(index, inBatch, r.keysMapping); err != nil {
+			return nil, fmt.Errorf("keys mapping failed: %v", err)
+		}
+	}
+	if r.argsMapping != nil {
+		if args, err = mappingArgs(index, inBatch, r.argsMapping); err != nil {
+			return nil, fmt.Errorf("args mapping failed: %v", err)
+		}
+	}
+
+	scriptArgs := make([]interface{}, 0, len(keys)+len(args)+1)
+	scriptArgs = append(scriptArgs, len(keys))
+	scriptArgs = append(scriptArgs, keys...)
+	return append(scriptArgs, args...), nil
+}
+
+func (r *redisScriptProc) eval(ctx context.Context, scriptArgs []interface{}) (interface{}, error) {
+	res, err := r.client.DoContext(ctx, append([]interface{}{"evalsha", r.sha}, scriptArgs...)...).Result()
+	if err != nil && strings.HasPrefix(err.Error(), "NOSCRIPT ") {
+		res, err = r.client.DoContext(ctx, append([]interface{}{"eval", r.script}, scriptArgs...)...).Result()
+	}
+	return res, err
+}
+
+func (r *redisScriptProc) exec(ctx context.Context, index int, inBatch service.MessageBatch, msg *service.Message) error {
+	scriptArgs, err := r.scriptArgs(index, inBatch)
+	if err != nil {
+		return err
+	}
+
+	res, err := r.eval(ctx, scriptArgs)
+	// Errors returned by Redis, such as those raised by the script, won't be
+	// resolved by retrying.
+	for i := 0; i < r.retries && isRetryableError(err); i++ {
+		r.log.Errorf("Script execution failed: %v", err)
+		select {
+		case <-time.After(r.retryPeriod):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		res, err = r.eval(ctx, scriptArgs)
+	}
+	if err != nil {
+		return err
+	}
+
+	msg.SetStructured(res)
+	return nil
+}
+
+func (r *redisScriptProc) ProcessBatch(ctx context.Context, inBatch service.MessageBatch) ([]service.MessageBatch, error) {
+	r.loadScript(ctx)
+
+	newMsg := inBatch.Copy()
+	for index, part := range newMsg {
+		if err := r.exec(ctx, index, inBatch, part); err != nil {
+			r.log.Debugf("Script execution failed: %v", err)
+			part.SetError(err)
+		}
+	}
+	return []service.MessageBatch{newMsg}, nil
+}
+
+func (r *redisScriptProc) Close(ctx context.Context) error {
+	return r.client.Close()
+}
@@ -0,0 +1,108 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+	"github.com/ory/dockertest/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/integration"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func TestIntegrationRedisScriptProcessor(t *testing.T) {
+	integration.CheckSkip(t)
+
+	pool, err := dockertest.NewPool("")
+	if err != nil {
+		t.Skipf("Could not connect to docker: %s", err)
+	}
+	pool.MaxWait = time.Second * 30
+
+	resource, err := pool.Run("redis", "latest", nil)
+	if err != nil {
+		t.Fatalf("Could not start resource: %s", err)
+	}
+
+	urlStr := fmt.Sprintf("tcp://localhost:%v", resource.GetPort("6379/tcp"))
+	uri, err := url.Parse(urlStr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := redis.NewClient(&redis.Options{
+		Addr:    uri.Host,
+		Network: uri.Scheme,
+	})
+
+	if err = pool.Retry(func() error {
+		return client.Ping().Err()
+	}); err != nil {
+		t.Fatalf("Could not connect to docker resource: %s", err)
+	}
+
+	defer func() {
+		if err = pool.Purge(resource); err != nil {
+			t.Logf("Failed to clean up docker resource: %v", err)
+		}
+	}()
+
+	defer client.Close()
+
+	conf, err := redisScriptProcConfig().ParseYAML(fmt.Sprintf(`
+url: %v
+script: |
+  local total = redis.call('incrby', KEYS[1], ARGV[1])
+  redis.call('sadd', KEYS[2], KEYS[1])
+  return total
+keys_mapping: 'root = [ this.name, "names" ]'
+args_mapping: 'root = [ this.count ]'
+`, urlStr), nil)
+	require.NoError(t, err)
+
+	r, err := newRedisScriptProcFromConfig(conf, service.MockResources())
+	require.NoError(t, err)
+
+	resMsgs, err := r.ProcessBatch(context.Background(), service.MessageBatch{
+		service.NewMessage([]byte(`{"name":"ash","count":10}`)),
+		service.NewMessage([]byte(`{"name":"ash","count":-2}`)),
+		service.NewMessage([]byte(`{"name":"bob","count":3}`)),
+		service.NewMessage([]byte(`{"name":"bob","count":"nope"}`)),
+	})
+	require.NoError(t, err)
+	require.Len(t, resMsgs, 1)
+	require.Len(t, resMsgs[0], 4)
+
+	for i, exp := range []string{`10`, `8`, `3`} {
+		require.NoError(t, resMsgs[0][i].GetError())
+		act, err := resMsgs[0][i].AsBytes()
+		require.NoError(t, err)
+		assert.Equal(t, exp, string(act))
+	}
+	assert.Error(t, resMsgs[0][3].GetError())
+
+	members, err := client.SMembers("names").Result()
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"ash", "bob"}, members)
+
+	// Flushing the script cache should result in a fallback to EVAL.
+	require.NoError(t, client.ScriptFlush().Err())
+
+	resMsgs, err = r.ProcessBatch(context.Background(), service.MessageBatch{
+		service.NewMessage([]byte(`{"name":"bob","count":1}`)),
+	})
+	require.NoError(t, err)
+	require.Len(t, resMsgs, 1)
+	require.NoError(t, resMsgs[0][0].GetError())
+	act, err := resMsgs[0][0].AsBytes()
+	require.NoError(t, err)
+	assert.Equal(t, `4`, string(act))
+
+	require.NoError(t, r.Close(context.Background()))
+}
@@ -0,0 +1,86 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/go-redis/redis/v7"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type redisReplyErr string
+
+func (e redisReplyErr) Error() string { return string(e) }
+func (e redisReplyErr) RedisError()   {}
+
+func TestRedisIsRetryableError(t *testing.T) {
+	for _, test := range []struct {
+		err       error
+		retryable bool
+	}{
+		{err: nil, retryable: false},
+		{err: io.EOF, retryable: true},
+		{err: errors.New("dial tcp: connection refused"), retryable: true},
+		{err: context.Canceled, retryable: false},
+		{err: context.DeadlineExceeded, retryable: false},
+		{err: redis.Nil, retryable: false},
+		{err: redisReplyErr("WRONGTYPE Operation against a key holding the wrong kind of value"), retryable: false},
+		{err: redisReplyErr("ERR unknown command 'foo'"), retryable: false},
+		{err: redisReplyErr("LOADING Redis is loading the dataset in memory"), retryable: true},
+		{err: redisReplyErr("TRYAGAIN Multiple keys request during rehashing of slot"), retryable: true},
+		{err: redisReplyErr("ERR max number of clients reached"), retryable: true},
+	} {
+		assert.Equal(t, test.retryable, isRetryableError(test.err), "%v", test.err)
+	}
+}
+
+func TestRedisProcRetryBackOff(t *testing.T) {
+	conf, err := redisProcConfig().ParseYAML(`
+url: tcp://localhost:6379
+command: get
+retry_period: 200ms
+`, nil)
+	require.NoError(t, err)
+
+	boff, err := retryBackOffFromConfig(conf)
+	require.NoError(t, err)
+	for i := 0; i < 5; i++ {
+		assert.Equal(t, time.Millisecond*200, boff.NextBackOff())
+	}
+
+	conf, err = redisProcConfig().ParseYAML(`
+url: tcp://localhost:6379
+command: get
+retry_period: 200ms
+backoff:
+  enabled: true
+  initial_interval: 1s
+  max_interval: 10s
+  multiplier: 2
+  max_elapsed_time: 1m
+`, nil)
+	require.NoError(t, err)
+
+	boff, err = retryBackOffFromConfig(conf)
+	require.NoError(t, err)
+	assert.Equal(t, time.Second, boff.InitialInterval)
+	assert.Equal(t, time.Second*10, boff.MaxInterval)
+	assert.Equal(t, 2.0, boff.Multiplier)
+	assert.Equal(t, time.Minute, boff.MaxElapsedTime)
+
+	conf, err = redisProcConfig().ParseYAML(`
+url: tcp://localhost:6379
+command: get
+backoff:
+  enabled: true
+  multiplier: 0.5
+`, nil)
+	require.NoError(t, err)
+
+	_, err = retryBackOffFromConfig(conf)
+	require.EqualError(t, err, "backoff multiplier must be at least 1, got 0.5")
+}
@@ -0,0 +1,322 @@
+package snmp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gosnmp/gosnmp"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	spFieldAddress        = "address"
+	spFieldVersion        = "version"
+	spFieldCommunity      = "community"
+	spFieldGet            = "get"
+	spFieldWalk           = "walk"
+	spFieldMaxRepetitions = "max_repetitions"
+	spFieldInterval       = "interval"
+	spFieldTimeout        = "timeout"
+	spFieldRetries        = "retries"
+)
+
+func pollInputConfig() *service.ConfigSpec {
+	spec := service.NewConfigSpec().
+		// Stable(). TODO
+		Categories("Network").
+		Summary("Polls an SNMP agent for the values of OIDs on an interval.").
+		Description(`
+Each poll gets the OIDs listed in ` + "`get`" + ` and walks the subtrees of the OIDs listed in ` + "`walk`" + `, and emits a single message containing an object with a field for each value received, keyed by the name of its OID translated with the configured MIBs, e.g. ` + "`ifDescr.2`" + `. OIDs that the agent does not have a value for are omitted.
+
+OIDs can be configured either in their numeric form or by name, optionally prefixed with their module, e.g. ` + "`IF-MIB::ifDescr`" + `, as long as the name is known from the configured MIBs. Walks use GetBulk requests with SNMPv2c and GetNext requests with SNMPv1. SNMPv3 is not supported.
+
+Octet strings are emitted as strings when they contain printable text, and as colon separated hex otherwise.
+
+### Metadata
+
+This input adds the following metadata fields to each message:
+
+` + "```" + `
+- snmp_agent
+` + "```" + `
+
+You can access these metadata fields using [function interpolation](/docs/configuration/interpolation#metadata).`).
+		Field(service.NewStringField(spFieldAddress).
+			Description("The address of the agent, where the port defaults to 161.").
+			Example("router.local:161")).
+		Field(service.NewStringEnumField(spFieldVersion, "v1", "v2c").
+			Description("The version of SNMP to use.").
+			Default("v2c")).
+		Field(service.NewStringField(spFieldCommunity).
+			Description("The community to authenticate with.").
+			Default("public")).
+		Field(service.NewStringListField(spFieldGet).
+			Description("A list of OIDs to get the values of.").
+			Example([]string{"sysUpTime.0", "1.3.6.1.2.1.1.5.0"}).
+			Default([]string{})).
+		Field(service.NewStringListField(spFieldWalk).
+			Description("A list of OIDs to walk the subtrees of.").
+			Example([]string{"IF-MIB::ifInOctets", "IF-MIB::ifOutOctets"}).
+			Default([]string{})).
+		Field(service.NewIntField(spFieldMaxRepetitions).
+			Description("The maximum number of values to request with each GetBulk request of a walk.").
+			Advanced().
+			Default(10)).
+		Field(service.NewDurationField(spFieldInterval).
+			Description("The interval at which to poll the agent.").
+			Default("1m")).
+		Field(service.NewDurationField(spFieldTimeout).
+			Description("The maximum period to wait for a response to a request before retrying it.").
+			Advanced().
+			Default("5s")).
+		Field(service.NewIntField(spFieldRetries).
+			Description("The maximum number of times to retry a request that was not responded to.").
+			Advanced().
+			Default(2))
+	return mibFields(spec).
+		Example("Interface counters", "Poll the name and traffic counters of all interfaces of a router every 30 seconds.", `
+input:
+  snmp_poll:
+    address: router.local
+    community: ${SNMP_COMMUNITY}
+    interval: 30s
+    get: [ sysUpTime.0 ]
+    walk: [ ifDescr, ifInOctets, ifOutOctets ]
+`)
+}
+
+func init() {
+	err := service.RegisterInput(
+		"snmp_poll", pollInputConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Input, error) {
+			i, err := newPollInputFromConfig(conf)
+			if err != nil {
+				return nil, err
+			}
+			return service.AutoRetryNacks(i), nil
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type pollInput struct {
+	address        string
+	host           string
+	port           uint16
+	version        gosnmp.SnmpVersion
+	community      string
+	get            []string
+	walk           []string
+	maxRepetitions int
+	interval       time.Duration
+	timeout        time.Duration
+	retries        int
+	mib            *mib
+
+	clientMut sync.Mutex
+	client    *gosnmp.GoSNMP
+	lastPoll  time.Time
+}
+
+func newPollInputFromConfig(conf *service.ParsedConfig) (*pollInput, error) {
+	p := &pollInput{}
+
+	var err error
+	if p.address, err = conf.FieldString(spFieldAddress); err != nil {
+		return nil, err
+	}
+	if _, _, err := net.SplitHostPort(p.address); err != nil {
+		p.address = net.JoinHostPort(p.address, "161")
+	}
+	host, portStr, err := net.SplitHostPort(p.address)
+	if err != nil {
+		return nil, err
+	}
+	port, err := strconv.ParseUint(portStr, 10, 16)
+	if err != nil {
+		return nil, fmt.Errorf("invalid port %v: %w", portStr, err)
+	}
+	p.host, p.port = host, uint16(port)
+
+	version, err := conf.FieldString(spFieldVersion)
+	if err != nil {
+		return nil, err
+	}
+	p.version = gosnmp.Version2c
+	if version == "v1" {
+		p.version = gosnmp.Version1
+	}
+
+	if p.community, err = conf.FieldString(spFieldCommunity); err != nil {
+		return nil, err
+	}
+	if p.mib, err = mibFromConfig(conf); err != nil {
+		return nil, err
+	}
+
+	resolveAll := func(field string) ([]string, error) {
+		names, err := conf.FieldStringList(field)
+		if err != nil {
+			return nil, err
+		}
+		oids := make([]string, len(names))
+		for i, name := range names {
+			if oids[i], err = p.mib.resolve(name); err != nil {
+				return nil, err
+			}
+		}
+		return oids, nil
+	}
+	if p.get, err = resolveAll(spFieldGet); err != nil {
+		return nil, err
+	}
+	if p.walk, err = resolveAll(spFieldWalk); err != nil {
+		return nil, err
+	}
+	if len(p.get) == 0 && len(p.walk) == 0 {
+		return nil, errors.New("at least one oid must be specified to get or walk")
+	}
+
+	if p.maxRepetitions, err = conf.FieldInt(spFieldMaxRepetitions); err != nil {
+		return nil, err
+	}
+	if p.interval, err = conf.FieldDuration(spFieldInterval); err != nil {
+		return nil, err
+	}
+	if p.timeout, err = conf.FieldDuration(spFieldTimeout); err != nil {
+		return nil, err
+	}
+	if p.retries, err = conf.FieldInt(spFieldRetries); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *pollInput) Connect(ctx context.Context) error {
+	p.clientMut.Lock()
+	defer p.clientMut.Unlock()
+
+	if p.client != nil {
+		return nil
+	}
+	c := &gosnmp.GoSNMP{
+		Target:         p.host,
+		Port:           p.port,
+		Community:      p.community,
+		Version:        p.version,
+		Timeout:        p.timeout,
+		Retries:        p.retries,
+		MaxOids:        gosnmp.MaxOids,
+		MaxRepetitions: uint32(p.maxRepetitions),
+	}
+	if err := c.Connect(); err != nil {
+		return err
+	}
+	p.client = c
+	return nil
+}
+
+// getValues returns the values of the OIDs to get, where OIDs that do not
+// exist are omitted.
+func (p *pollInput) getValues(c *gosnmp.GoSNMP) ([]gosnmp.SnmpPDU, error) {
+	var pdus []gosnmp.SnmpPDU
+	for i := 0; i < len(p.get); i += c.MaxOids {
+		end := i + c.MaxOids
+		if end > len(p.get) {
+			end = len(p.get)
+		}
+		res, err := c.Get(p.get[i:end])
+		if err != nil {
+			return nil, err
+		}
+		if res.Error != gosnmp.NoError {
+			if i := int(res.ErrorIndex) - 1; i >= 0 && i < len(res.Variables) {
+				return nil, fmt.Errorf("agent responded with error status %v for oid %v", res.Error, trimOID(res.Variables[i].Name))
+			}
+			return nil, fmt.Errorf("agent responded with error status %v", res.Error)
+		}
+		for _, pdu := range res.Variables {
+			switch pdu.Type {
+			case gosnmp.NoSuchObject, gosnmp.NoSuchInstance, gosnmp.EndOfMibView:
+				continue
+			}
+			pdus = append(pdus, pdu)
+		}
+	}
+	return pdus, nil
+}
+
+func (p *pollInput) Read(ctx context.Context) (*service.Message, service.AckFunc, error) {
+	if !p.lastPoll.IsZero() {
+		select {
+		case <-time.After(time.Until(p.lastPoll.Add(p.interval))):
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		}
+	}
+	p.lastPoll = time.Now()
+
+	p.clientMut.Lock()
+	defer p.clientMut.Unlock()
+
+	c := p.client
+	if c == nil {
+		return nil, nil, service.ErrNotConnected
+	}
+	c.Context = ctx
+
+	var pdus []gosnmp.SnmpPDU
+	if len(p.get) > 0 {
+		res, err := p.getValues(c)
+		if err != nil {
+			return nil, nil, err
+		}
+		pdus = append(pdus, res...)
+	}
+	for _, root := range p.walk {
+		// Walks use GetBulk requests with SNMPv2c, which SNMPv1 does not
+		// support.
+		walkFn := c.BulkWalkAll
+		if p.version == gosnmp.Version1 {
+			walkFn = c.WalkAll
+		}
+		res, err := walkFn(root)
+		if err != nil {
+			return nil, nil, err
+		}
+		pdus = append(pdus, res...)
+	}
+
+	values := make(map[string]interface{}, len(pdus))
+	for _, pdu := range pdus {
+		values[p.mib.translate(trimOID(pdu.Name))] = p.mib.value(pdu)
+	}
+
+	msg := service.NewMessage(nil)
+	msg.SetStructured(values)
+	msg.MetaSet("snmp_agent", p.address)
+	return msg, func(context.Context, error) error {
+		return nil
+	}, nil
+}
+
+func (p *pollInput) Close(ctx context.Context) error {
+	p.clientMut.Lock()
+	defer p.clientMut.Unlock()
+
+	if p.client == nil {
+		return nil
+	}
+	err := p.client.Conn.Close()
+	p.client = nil
+	return err
+}
@@ -0,0 +1,259 @@
+package snmp
+
+import (
+	"context"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gosnmp/gosnmp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// compareOIDs compares two numeric OIDs arc by arc, returning -1, 0 or 1.
+func compareOIDs(a, b string) int {
+	as, bs := strings.Split(trimOID(a), "."), strings.Split(trimOID(b), ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		av, _ := strconv.ParseUint(as[i], 10, 64)
+		bv, _ := strconv.ParseUint(bs[i], 10, 64)
+		if av != bv {
+			if av < bv {
+				return -1
+			}
+			return 1
+		}
+	}
+	switch {
+	case len(as) < len(bs):
+		return -1
+	case len(as) > len(bs):
+		return 1
+	}
+	return 0
+}
+
+// testAgent responds to get, get next and get bulk requests from a fixed set
+// of values, dropping the first request it receives when lossy.
+type testAgent struct {
+	mut      sync.Mutex
+	values   map[string]gosnmp.SnmpPDU
+	requests []*gosnmp.SnmpPacket
+	lossy    bool
+}
+
+func (a *testAgent) sorted() []string {
+	oids := make([]string, 0, len(a.values))
+	for oid := range a.values {
+		oids = append(oids, oid)
+	}
+	sort.Slice(oids, func(i, j int) bool {
+		return compareOIDs(oids[i], oids[j]) < 0
+	})
+	return oids
+}
+
+func (a *testAgent) next(oid string) (gosnmp.SnmpPDU, bool) {
+	for _, o := range a.sorted() {
+		if compareOIDs(o, oid) > 0 {
+			return a.values[o], true
+		}
+	}
+	return gosnmp.SnmpPDU{}, false
+}
+
+func (a *testAgent) respond(p *gosnmp.SnmpPacket) *gosnmp.SnmpPacket {
+	a.mut.Lock()
+	defer a.mut.Unlock()
+
+	a.requests = append(a.requests, p)
+	if a.lossy {
+		a.lossy = false
+		return nil
+	}
+
+	res := &gosnmp.SnmpPacket{
+		Version:   p.Version,
+		Community: p.Community,
+		PDUType:   gosnmp.GetResponse,
+		RequestID: p.RequestID,
+	}
+	switch p.PDUType {
+	case gosnmp.GetRequest:
+		for _, pdu := range p.Variables {
+			v, exists := a.values[trimOID(pdu.Name)]
+			if !exists {
+				v = gosnmp.SnmpPDU{Name: pdu.Name, Type: gosnmp.NoSuchObject}
+			}
+			res.Variables = append(res.Variables, v)
+		}
+	case gosnmp.GetNextRequest:
+		v, exists := a.next(p.Variables[0].Name)
+		if !exists {
+			res.Error, res.ErrorIndex = gosnmp.NoSuchName, 1
+			res.Variables = []gosnmp.SnmpPDU{{Name: p.Variables[0].Name, Type: gosnmp.Null}}
+			break
+		}
+		res.Variables = []gosnmp.SnmpPDU{v}
+	case gosnmp.GetBulkRequest:
+		// The max repetitions of requests are not decoded by gosnmp, and
+		// therefore a fixed number of values are returned.
+		oid := p.Variables[0].Name
+		for n := 0; n < 2; n++ {
+			v, exists := a.next(oid)
+			if !exists {
+				res.Variables = append(res.Variables, gosnmp.SnmpPDU{Name: oid, Type: gosnmp.EndOfMibView})
+				break
+			}
+			res.Variables = append(res.Variables, v)
+			oid = v.Name
+		}
+	}
+	return res
+}
+
+func (a *testAgent) serve(conn net.PacketConn) {
+	decoder := &gosnmp.GoSNMP{}
+	buf := make([]byte, 65536)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		p, err := decoder.SnmpDecodePacket(buf[:n])
+		if err != nil {
+			continue
+		}
+		if res := a.respond(p); res != nil {
+			b, _ := res.MarshalMsg()
+			_, _ = conn.WriteTo(b, addr)
+		}
+	}
+}
+
+func newTestAgent(t *testing.T, lossy bool) (*testAgent, string) {
+	t.Helper()
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = conn.Close()
+	})
+
+	a := &testAgent{values: map[string]gosnmp.SnmpPDU{}, lossy: lossy}
+	for _, pdu := range []gosnmp.SnmpPDU{
+		{Name: ".1.3.6.1.2.1.1.3.0", Type: gosnmp.TimeTicks, Value: uint32(1000)},
+		{Name: ".1.3.6.1.2.1.1.5.0", Type: gosnmp.OctetString, Value: []byte("router")},
+		{Name: ".1.3.6.1.2.1.2.2.1.2.1", Type: gosnmp.OctetString, Value: []byte("lo")},
+		{Name: ".1.3.6.1.2.1.2.2.1.2.2", Type: gosnmp.OctetString, Value: []byte("eth0")},
+		{Name: ".1.3.6.1.2.1.2.2.1.2.10", Type: gosnmp.OctetString, Value: []byte("eth1")},
+		{Name: ".1.3.6.1.2.1.2.2.1.10.1", Type: gosnmp.Counter32, Value: uint(10)},
+		{Name: ".1.3.6.1.2.1.2.2.1.10.2", Type: gosnmp.Counter32, Value: uint(20)},
+		{Name: ".1.3.6.1.2.1.2.2.1.10.10", Type: gosnmp.Counter32, Value: uint(30)},
+	} {
+		a.values[trimOID(pdu.Name)] = pdu
+	}
+	go a.serve(conn)
+	return a, conn.LocalAddr().String()
+}
+
+func TestPollInput(t *testing.T) {
+	for _, version := range []string{"v1", "v2c"} {
+		version := version
+		t.Run(version, func(t *testing.T) {
+			a, addr := newTestAgent(t, true)
+
+			parsed, err := pollInputConfig().ParseYAML(`
+address: `+addr+`
+version: `+version+`
+get: [ sysUpTime.0, sysName.0, sysContact.0 ]
+walk: [ IF-MIB::ifDescr, 1.3.6.1.2.1.2.2.1.10 ]
+max_repetitions: 2
+interval: 10ms
+timeout: 100ms
+`, nil)
+			require.NoError(t, err)
+
+			i, err := newPollInputFromConfig(parsed)
+			require.NoError(t, err)
+			require.NoError(t, i.Connect(context.Background()))
+			defer i.Close(context.Background())
+
+			ctx, done := context.WithTimeout(context.Background(), time.Second*5)
+			defer done()
+
+			for n := 0; n < 2; n++ {
+				msg, _, err := i.Read(ctx)
+				require.NoError(t, err)
+
+				v, err := msg.AsStructured()
+				require.NoError(t, err)
+				assert.Equal(t, map[string]interface{}{
+					"sysUpTime.0":   uint64(1000),
+					"sysName.0":     "router",
+					"ifDescr.1":     "lo",
+					"ifDescr.2":     "eth0",
+					"ifDescr.10":    "eth1",
+					"ifInOctets.1":  uint64(10),
+					"ifInOctets.2":  uint64(20),
+					"ifInOctets.10": uint64(30),
+				}, v)
+			}
+
+			a.mut.Lock()
+			defer a.mut.Unlock()
+			assert.Equal(t, a.requests[0].Variables, a.requests[1].Variables, "lost requests are retried")
+			for _, req := range a.requests[1:] {
+				if version == "v1" {
+					assert.NotEqual(t, gosnmp.GetBulkRequest, req.PDUType)
+				} else {
+					assert.NotEqual(t, gosnmp.GetNextRequest, req.PDUType)
+				}
+			}
+		})
+	}
+}
+
+func TestPollInputErrors(t *testing.T) {
+	for _, conf := range []string{
+		`address: localhost`,
+		`
+address: localhost
+get: [ nope.0 ]
+`,
+		`
+address: localhost
+walk: [ 1 ]
+`,
+	} {
+		parsed, err := pollInputConfig().ParseYAML(conf, nil)
+		require.NoError(t, err, conf)
+		_, err = newPollInputFromConfig(parsed)
+		assert.Error(t, err, conf)
+	}
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	parsed, err := pollInputConfig().ParseYAML(`
+address: `+conn.LocalAddr().String()+`
+get: [ sysName.0 ]
+timeout: 10ms
+retries: 1
+`, nil)
+	require.NoError(t, err)
+
+	i, err := newPollInputFromConfig(parsed)
+	require.NoError(t, err)
+	require.NoError(t, i.Connect(context.Background()))
+	defer i.Close(context.Background())
+
+	_, _, err = i.Read(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "request timeout")
+}
@@ -0,0 +1,321 @@
+package snmp
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"sync"
+
+	"github.com/gosnmp/gosnmp"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	stFieldAddress     = "address"
+	stFieldCommunities = "communities"
+)
+
+const (
+	oidSysUpTime   = "1.3.6.1.2.1.1.3.0"
+	oidSnmpTrapOID = "1.3.6.1.6.3.1.1.4.1.0"
+)
+
+func trapInputConfig() *service.ConfigSpec {
+	spec := service.NewConfigSpec().
+		// Stable(). TODO
+		Categories("Network").
+		Summary("Receives SNMPv1 and SNMPv2c traps and informs over UDP.").
+		Description(`
+Each trap or inform is consumed as a structured message of the following form, where the names of the trap and its variables are translated from their OIDs with the configured MIBs:
+
+` + "```json" + `
+{
+  "version": "v2c",
+  "type": "trap",
+  "community": "public",
+  "agent_address": "10.0.0.1",
+  "uptime": 123456,
+  "trap_oid": "1.3.6.1.6.3.1.1.5.3",
+  "trap_name": "linkDown",
+  "variables": {
+    "ifIndex.2": 2,
+    "ifAdminStatus.2": 1,
+    "ifOperStatus.2": 2
+  }
+}
+` + "```" + `
+
+SNMPv1 traps are converted into the notification OID of the equivalent SNMPv2c trap as per RFC 3584, and also contain the fields ` + "`enterprise`, `generic_trap` and `specific_trap`" + `. Octet strings are emitted as strings when they contain printable text, and as colon separated hex otherwise.
+
+Informs are only responded to once the message has been acknowledged, and are therefore delivered at least once as the sender retransmits informs that are not responded to. SNMPv3 is not supported.
+
+### Metadata
+
+This input adds the following metadata fields to each message:
+
+` + "```" + `
+- snmp_source
+- snmp_community
+- snmp_trap_oid
+` + "```" + `
+
+You can access these metadata fields using [function interpolation](/docs/configuration/interpolation#metadata).`).
+		Field(service.NewStringField(stFieldAddress).
+			Description("The address to listen for traps on.").
+			Default("0.0.0.0:162")).
+		Field(service.NewStringListField(stFieldCommunities).
+			Description("An optional list of communities to accept traps from, where traps of other communities are dropped. When empty traps of all communities are accepted.").
+			Example([]string{"public"}).
+			Default([]string{}))
+	return mibFields(spec)
+}
+
+func init() {
+	err := service.RegisterInput(
+		"snmp_trap", trapInputConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Input, error) {
+			return newTrapInputFromConfig(conf, mgr.Logger())
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type trapPacket struct {
+	p    *gosnmp.SnmpPacket
+	addr net.Addr
+}
+
+type trapInput struct {
+	address     string
+	communities map[string]struct{}
+	mib         *mib
+	log         *service.Logger
+
+	connMut sync.Mutex
+	conn    net.PacketConn
+	packets chan trapPacket
+	closed  chan struct{}
+}
+
+func newTrapInputFromConfig(conf *service.ParsedConfig, log *service.Logger) (*trapInput, error) {
+	t := &trapInput{log: log}
+
+	var err error
+	if t.address, err = conf.FieldString(stFieldAddress); err != nil {
+		return nil, err
+	}
+
+	communities, err := conf.FieldStringList(stFieldCommunities)
+	if err != nil {
+		return nil, err
+	}
+	if len(communities) > 0 {
+		t.communities = map[string]struct{}{}
+		for _, c := range communities {
+			t.communities[c] = struct{}{}
+		}
+	}
+
+	if t.mib, err = mibFromConfig(conf); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func (t *trapInput) Connect(ctx context.Context) error {
+	t.connMut.Lock()
+	defer t.connMut.Unlock()
+
+	if t.conn != nil {
+		return nil
+	}
+	conn, err := net.ListenPacket("udp", t.address)
+	if err != nil {
+		return err
+	}
+	t.conn = conn
+	t.packets = make(chan trapPacket)
+	t.closed = make(chan struct{})
+	go t.loop(conn, t.packets, t.closed)
+	return nil
+}
+
+func (t *trapInput) loop(conn net.PacketConn, packets chan<- trapPacket, closed chan struct{}) {
+	defer close(packets)
+
+	decoder := &gosnmp.GoSNMP{}
+	buf := make([]byte, 65536)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			select {
+			case <-closed:
+			default:
+				t.log.Errorf("Failed to read trap: %v\n", err)
+			}
+			return
+		}
+
+		p, err := decoder.SnmpDecodePacket(buf[:n])
+		if err != nil {
+			t.log.Debugf("Dropping invalid packet from %v: %v\n", addr, err)
+			continue
+		}
+		if p.Version == gosnmp.Version3 {
+			t.log.Debugf("Dropping unsupported SNMPv3 packet from %v\n", addr)
+			continue
+		}
+		if p.PDUType != gosnmp.Trap && p.PDUType != gosnmp.SNMPv2Trap && p.PDUType != gosnmp.InformRequest {
+			t.log.Debugf("Dropping unexpected PDU type %#02x from %v\n", byte(p.PDUType), addr)
+			continue
+		}
+		if t.communities != nil {
+			if _, exists := t.communities[p.Community]; !exists {
+				t.log.Debugf("Dropping trap of unrecognised community from %v\n", addr)
+				continue
+			}
+		}
+
+		select {
+		case packets <- trapPacket{p: p, addr: addr}:
+		case <-closed:
+			return
+		}
+	}
+}
+
+// trapOID returns the notification OID of a trap, converting SNMPv1 traps as
+// per RFC 3584.
+func trapOID(p *gosnmp.SnmpPacket) string {
+	if p.PDUType == gosnmp.Trap {
+		if p.GenericTrap >= 0 && p.GenericTrap < 6 {
+			return "1.3.6.1.6.3.1.1.5." + strconv.Itoa(p.GenericTrap+1)
+		}
+		return trimOID(p.Enterprise) + ".0." + strconv.Itoa(p.SpecificTrap)
+	}
+	for _, pdu := range p.Variables {
+		if trimOID(pdu.Name) == oidSnmpTrapOID {
+			oid, _ := pdu.Value.(string)
+			return trimOID(oid)
+		}
+	}
+	return ""
+}
+
+func (t *trapInput) message(tp trapPacket) *service.Message {
+	p := tp.p
+	source := tp.addr.String()
+	if udpAddr, ok := tp.addr.(*net.UDPAddr); ok {
+		source = udpAddr.IP.String()
+	}
+
+	oid := trapOID(p)
+	obj := map[string]interface{}{
+		"community":     p.Community,
+		"agent_address": source,
+		"trap_oid":      oid,
+		"trap_name":     t.mib.translate(oid),
+	}
+
+	variables := map[string]interface{}{}
+	if p.PDUType == gosnmp.Trap {
+		obj["version"] = "v1"
+		obj["type"] = "trap"
+		obj["agent_address"] = p.AgentAddress
+		obj["enterprise"] = trimOID(p.Enterprise)
+		obj["generic_trap"] = int64(p.GenericTrap)
+		obj["specific_trap"] = int64(p.SpecificTrap)
+		obj["uptime"] = uint64(p.Timestamp)
+	} else {
+		obj["version"] = "v2c"
+		obj["type"] = "trap"
+		if p.PDUType == gosnmp.InformRequest {
+			obj["type"] = "inform"
+		}
+	}
+	for _, pdu := range p.Variables {
+		oid := trimOID(pdu.Name)
+		if p.PDUType != gosnmp.Trap {
+			// The uptime and trap OID are the first two variables of
+			// SNMPv2c traps, and are added as fields of their own.
+			switch oid {
+			case oidSysUpTime:
+				obj["uptime"] = t.mib.value(pdu)
+				continue
+			case oidSnmpTrapOID:
+				continue
+			}
+		}
+		variables[t.mib.translate(oid)] = t.mib.value(pdu)
+	}
+	obj["variables"] = variables
+
+	msg := service.NewMessage(nil)
+	msg.SetStructured(obj)
+	msg.MetaSet("snmp_source", tp.addr.String())
+	msg.MetaSet("snmp_community", p.Community)
+	msg.MetaSet("snmp_trap_oid", oid)
+	return msg
+}
+
+func (t *trapInput) Read(ctx context.Context) (*service.Message, service.AckFunc, error) {
+	t.connMut.Lock()
+	conn, packets := t.conn, t.packets
+	t.connMut.Unlock()
+	if conn == nil {
+		return nil, nil, service.ErrNotConnected
+	}
+
+	var tp trapPacket
+	var open bool
+	select {
+	case tp, open = <-packets:
+	case <-ctx.Done():
+		return nil, nil, ctx.Err()
+	}
+	if !open {
+		_ = t.disconnect(conn)
+		return nil, nil, service.ErrNotConnected
+	}
+
+	return t.message(tp), func(ctx context.Context, err error) error {
+		if err != nil || tp.p.PDUType != gosnmp.InformRequest {
+			return nil
+		}
+		res := *tp.p
+		res.PDUType = gosnmp.GetResponse
+		res.Error = gosnmp.NoError
+		res.ErrorIndex = 0
+		b, err := res.MarshalMsg()
+		if err != nil {
+			return err
+		}
+		_, err = conn.WriteTo(b, tp.addr)
+		return err
+	}, nil
+}
+
+// disconnect closes a connection unless it has already been replaced.
+func (t *trapInput) disconnect(conn net.PacketConn) error {
+	t.connMut.Lock()
+	defer t.connMut.Unlock()
+
+	if t.conn == nil || t.conn != conn {
+		return nil
+	}
+	close(t.closed)
+	err := t.conn.Close()
+	t.conn = nil
+	return err
+}
+
+func (t *trapInput) Close(ctx context.Context) error {
+	t.connMut.Lock()
+	conn := t.conn
+	t.connMut.Unlock()
+	return t.disconnect(conn)
+}
@@ -0,0 +1,162 @@
+package snmp
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/gosnmp/gosnmp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestTrapInput(t *testing.T, conf string) (*trapInput, net.Conn) {
+	t.Helper()
+
+	parsed, err := trapInputConfig().ParseYAML(conf, nil)
+	require.NoError(t, err)
+
+	i, err := newTrapInputFromConfig(parsed, nil)
+	require.NoError(t, err)
+	require.NoError(t, i.Connect(context.Background()))
+	t.Cleanup(func() {
+		_ = i.Close(context.Background())
+	})
+
+	sender, err := net.Dial("udp", i.conn.LocalAddr().String())
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = sender.Close()
+	})
+	return i, sender
+}
+
+func sendPacket(t *testing.T, conn net.Conn, p *gosnmp.SnmpPacket) {
+	t.Helper()
+
+	b, err := p.MarshalMsg()
+	require.NoError(t, err)
+	_, err = conn.Write(b)
+	require.NoError(t, err)
+}
+
+func TestTrapInput(t *testing.T) {
+	i, sender := newTestTrapInput(t, `
+address: 127.0.0.1:0
+communities: [ public ]
+`)
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*5)
+	defer done()
+
+	// Traps of other communities are dropped.
+	sendPacket(t, sender, &gosnmp.SnmpPacket{Version: gosnmp.Version2c, Community: "private", PDUType: gosnmp.SNMPv2Trap})
+
+	sendPacket(t, sender, &gosnmp.SnmpPacket{
+		Version:   gosnmp.Version2c,
+		Community: "public",
+		PDUType:   gosnmp.SNMPv2Trap,
+		Variables: []gosnmp.SnmpPDU{
+			{Name: oidSysUpTime, Type: gosnmp.TimeTicks, Value: uint32(123456)},
+			{Name: oidSnmpTrapOID, Type: gosnmp.ObjectIdentifier, Value: "1.3.6.1.6.3.1.1.5.3"},
+			{Name: "1.3.6.1.2.1.2.2.1.1.2", Type: gosnmp.Integer, Value: 2},
+			{Name: "1.3.6.1.2.1.2.2.1.2.2", Type: gosnmp.OctetString, Value: []byte("eth1")},
+		},
+	})
+
+	msg, ackFn, err := i.Read(ctx)
+	require.NoError(t, err)
+	require.NoError(t, ackFn(ctx, nil))
+
+	v, err := msg.AsStructured()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"version":       "v2c",
+		"type":          "trap",
+		"community":     "public",
+		"agent_address": "127.0.0.1",
+		"uptime":        uint64(123456),
+		"trap_oid":      "1.3.6.1.6.3.1.1.5.3",
+		"trap_name":     "linkDown",
+		"variables": map[string]interface{}{
+			"ifIndex.2": int64(2),
+			"ifDescr.2": "eth1",
+		},
+	}, v)
+	oid, _ := msg.MetaGet("snmp_trap_oid")
+	assert.Equal(t, "1.3.6.1.6.3.1.1.5.3", oid)
+
+	sendPacket(t, sender, &gosnmp.SnmpPacket{
+		Version:   gosnmp.Version1,
+		Community: "public",
+		PDUType:   gosnmp.Trap,
+		SnmpTrap: gosnmp.SnmpTrap{
+			Enterprise:   "1.3.6.1.4.1.9",
+			AgentAddress: "10.0.0.1",
+			GenericTrap:  6,
+			SpecificTrap: 17,
+			Timestamp:    42,
+		},
+	})
+
+	msg, _, err = i.Read(ctx)
+	require.NoError(t, err)
+	v, err = msg.AsStructured()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"version":       "v1",
+		"type":          "trap",
+		"community":     "public",
+		"agent_address": "10.0.0.1",
+		"enterprise":    "1.3.6.1.4.1.9",
+		"generic_trap":  int64(6),
+		"specific_trap": int64(17),
+		"uptime":        uint64(42),
+		"trap_oid":      "1.3.6.1.4.1.9.0.17",
+		"trap_name":     "enterprises.9.0.17",
+		"variables":     map[string]interface{}{},
+	}, v)
+}
+
+func TestTrapInputInform(t *testing.T) {
+	i, sender := newTestTrapInput(t, `address: 127.0.0.1:0`)
+
+	ctx, done := context.WithTimeout(context.Background(), time.Second*5)
+	defer done()
+
+	inform := &gosnmp.SnmpPacket{
+		Version:   gosnmp.Version2c,
+		Community: "public",
+		PDUType:   gosnmp.InformRequest,
+		RequestID: 7,
+		Variables: []gosnmp.SnmpPDU{
+			{Name: oidSysUpTime, Type: gosnmp.TimeTicks, Value: uint32(1)},
+			{Name: oidSnmpTrapOID, Type: gosnmp.ObjectIdentifier, Value: "1.3.6.1.6.3.1.1.5.1"},
+		},
+	}
+	sendPacket(t, sender, inform)
+
+	msg, ackFn, err := i.Read(ctx)
+	require.NoError(t, err)
+	v, err := msg.AsStructured()
+	require.NoError(t, err)
+	assert.Equal(t, "inform", v.(map[string]interface{})["type"])
+	assert.Equal(t, "coldStart", v.(map[string]interface{})["trap_name"])
+
+	// Informs are only responded to once acknowledged.
+	require.NoError(t, ackFn(ctx, nil))
+
+	require.NoError(t, sender.SetReadDeadline(time.Now().Add(time.Second*5)))
+	buf := make([]byte, 65536)
+	n, err := sender.Read(buf)
+	require.NoError(t, err)
+
+	res, err := (&gosnmp.GoSNMP{}).SnmpDecodePacket(buf[:n])
+	require.NoError(t, err)
+	assert.Equal(t, gosnmp.GetResponse, res.PDUType)
+	assert.Equal(t, uint32(7), res.RequestID)
+	require.Len(t, res.Variables, 2)
+	assert.Equal(t, "."+oidSnmpTrapOID, res.Variables[1].Name)
+	assert.Equal(t, ".1.3.6.1.6.3.1.1.5.1", res.Variables[1].Value)
+}
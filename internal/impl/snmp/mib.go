@@ -0,0 +1,453 @@
+package snmp
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gosnmp/gosnmp"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	snmpFieldMIBs     = "mibs"
+	snmpFieldOIDNames = "oid_names"
+)
+
+// mibFields adds the fields for translating OIDs to names to a spec.
+func mibFields(spec *service.ConfigSpec) *service.ConfigSpec {
+	return spec.
+		Field(service.NewStringListField(snmpFieldMIBs).
+			Description("A list of paths of MIB files, or directories of MIB files, from which the names of OIDs are parsed. The names of the core objects and notifications of SNMPv2-MIB and IF-MIB are known without any MIB files.").
+			Example([]string{"/usr/share/snmp/mibs"}).
+			Default([]string{})).
+		Field(service.NewStringMapField(snmpFieldOIDNames).
+			Description("A map of OIDs to names, which take precedence over names parsed from MIB files.").
+			Example(map[string]interface{}{
+				"1.3.6.1.4.1.9.9.41.2.0.1": "clogMessageGenerated",
+			}).
+			Advanced().
+			Default(map[string]interface{}{}))
+}
+
+// coreNames are the OIDs known without loading any MIB files.
+var coreNames = map[string]string{
+	"1":                    "iso",
+	"1.3":                  "org",
+	"1.3.6":                "dod",
+	"1.3.6.1":              "internet",
+	"1.3.6.1.1":            "directory",
+	"1.3.6.1.2":            "mgmt",
+	"1.3.6.1.2.1":          "mib-2",
+	"1.3.6.1.2.1.1":        "system",
+	"1.3.6.1.2.1.1.1":      "sysDescr",
+	"1.3.6.1.2.1.1.2":      "sysObjectID",
+	"1.3.6.1.2.1.1.3":      "sysUpTime",
+	"1.3.6.1.2.1.1.4":      "sysContact",
+	"1.3.6.1.2.1.1.5":      "sysName",
+	"1.3.6.1.2.1.1.6":      "sysLocation",
+	"1.3.6.1.2.1.1.7":      "sysServices",
+	"1.3.6.1.2.1.2":        "interfaces",
+	"1.3.6.1.2.1.2.1":      "ifNumber",
+	"1.3.6.1.2.1.2.2":      "ifTable",
+	"1.3.6.1.2.1.2.2.1":    "ifEntry",
+	"1.3.6.1.2.1.2.2.1.1":  "ifIndex",
+	"1.3.6.1.2.1.2.2.1.2":  "ifDescr",
+	"1.3.6.1.2.1.2.2.1.3":  "ifType",
+	"1.3.6.1.2.1.2.2.1.4":  "ifMtu",
+	"1.3.6.1.2.1.2.2.1.5":  "ifSpeed",
+	"1.3.6.1.2.1.2.2.1.6":  "ifPhysAddress",
+	"1.3.6.1.2.1.2.2.1.7":  "ifAdminStatus",
+	"1.3.6.1.2.1.2.2.1.8":  "ifOperStatus",
+	"1.3.6.1.2.1.2.2.1.9":  "ifLastChange",
+	"1.3.6.1.2.1.2.2.1.10": "ifInOctets",
+	"1.3.6.1.2.1.2.2.1.11": "ifInUcastPkts",
+	"1.3.6.1.2.1.2.2.1.13": "ifInDiscards",
+	"1.3.6.1.2.1.2.2.1.14": "ifInErrors",
+	"1.3.6.1.2.1.2.2.1.16": "ifOutOctets",
+	"1.3.6.1.2.1.2.2.1.17": "ifOutUcastPkts",
+	"1.3.6.1.2.1.2.2.1.19": "ifOutDiscards",
+	"1.3.6.1.2.1.2.2.1.20": "ifOutErrors",
+	"1.3.6.1.3":            "experimental",
+	"1.3.6.1.4":            "private",
+	"1.3.6.1.4.1":          "enterprises",
+	"1.3.6.1.6":            "snmpV2",
+	"1.3.6.1.6.3":          "snmpModules",
+	"1.3.6.1.6.3.1":        "snmpMIB",
+	"1.3.6.1.6.3.1.1":      "snmpMIBObjects",
+	"1.3.6.1.6.3.1.1.4":    "snmpTrap",
+	"1.3.6.1.6.3.1.1.4.1":  "snmpTrapOID",
+	"1.3.6.1.6.3.1.1.4.3":  "snmpTrapEnterprise",
+	"1.3.6.1.6.3.1.1.5":    "snmpTraps",
+	"1.3.6.1.6.3.1.1.5.1":  "coldStart",
+	"1.3.6.1.6.3.1.1.5.2":  "warmStart",
+	"1.3.6.1.6.3.1.1.5.3":  "linkDown",
+	"1.3.6.1.6.3.1.1.5.4":  "linkUp",
+	"1.3.6.1.6.3.1.1.5.5":  "authenticationFailure",
+}
+
+// mib translates between numeric OIDs and their names.
+type mib struct {
+	names map[string]string
+	oids  map[string]string
+}
+
+func newMIB() *mib {
+	m := &mib{
+		names: map[string]string{},
+		oids:  map[string]string{},
+	}
+	for oid, name := range coreNames {
+		m.add(oid, name)
+	}
+	return m
+}
+
+func (m *mib) add(oid, name string) {
+	m.names[oid] = name
+	m.oids[name] = oid
+}
+
+func mibFromConfig(conf *service.ParsedConfig) (*mib, error) {
+	m := newMIB()
+
+	paths, err := conf.FieldStringList(snmpFieldMIBs)
+	if err != nil {
+		return nil, err
+	}
+	p := newMIBParser()
+	for _, path := range paths {
+		if err := p.load(path); err != nil {
+			return nil, err
+		}
+	}
+	for oid, name := range p.resolve(m.oids) {
+		m.add(oid, name)
+	}
+
+	names, err := conf.FieldStringMap(snmpFieldOIDNames)
+	if err != nil {
+		return nil, err
+	}
+	for oid, name := range names {
+		if err := validateOID(oid); err != nil {
+			return nil, err
+		}
+		m.add(trimOID(oid), name)
+	}
+	return m, nil
+}
+
+// validateOID checks that a numeric OID has at least two arcs, each of which
+// is an unsigned integer.
+func validateOID(s string) error {
+	parts := strings.Split(trimOID(s), ".")
+	if len(parts) < 2 {
+		return fmt.Errorf("oid %v must have at least two arcs", s)
+	}
+	for _, p := range parts {
+		if _, err := strconv.ParseUint(p, 10, 32); err != nil {
+			return fmt.Errorf("oid %v is invalid: %w", s, err)
+		}
+	}
+	return nil
+}
+
+// trimOID removes the leading dot of OIDs as formatted by gosnmp.
+func trimOID(oid string) string {
+	return strings.TrimPrefix(oid, ".")
+}
+
+// translate returns the name of an OID, formed from the name of its longest
+// known prefix followed by the remaining arcs, e.g. ifDescr.2. OIDs without a
+// known prefix are returned as they are.
+func (m *mib) translate(oid string) string {
+	for prefix := oid; prefix != ""; {
+		if name, exists := m.names[prefix]; exists {
+			return name + strings.TrimPrefix(oid, prefix)
+		}
+		i := strings.LastIndexByte(prefix, '.')
+		if i < 0 {
+			break
+		}
+		prefix = prefix[:i]
+	}
+	return oid
+}
+
+// resolve returns the numeric form of an OID that is either numeric or a name
+// optionally prefixed with a module and followed by arcs, e.g.
+// IF-MIB::ifDescr.2.
+func (m *mib) resolve(s string) (string, error) {
+	s = strings.TrimPrefix(s, ".")
+	if s != "" && s[0] >= '0' && s[0] <= '9' {
+		if err := validateOID(s); err != nil {
+			return "", err
+		}
+		return s, nil
+	}
+	if i := strings.Index(s, "::"); i >= 0 {
+		s = s[i+2:]
+	}
+	name, suffix := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		name, suffix = s[:i], s[i:]
+	}
+	oid, exists := m.oids[name]
+	if !exists {
+		return "", fmt.Errorf("oid name %v not recognised", name)
+	}
+	return oid + suffix, nil
+}
+
+//------------------------------------------------------------------------------
+
+// mibDefinition is an object defined by a MIB relative to a parent object.
+type mibDefinition struct {
+	parent string
+	arcs   []string
+}
+
+// mibParser extracts the OID assignments of MIB files, ignoring everything
+// else. Definitions are only resolved once all files are loaded, as they may
+// refer to objects of other files.
+type mibParser struct {
+	defs map[string]mibDefinition
+}
+
+func newMIBParser() *mibParser {
+	return &mibParser{defs: map[string]mibDefinition{}}
+}
+
+var mibNameArcRegexp = regexp.MustCompile(`^([a-zA-Z][\w-]*)\((\d+)\)$`)
+
+// tokenizeMIB splits the source of a MIB into tokens, dropping comments and
+// replacing quoted strings with an empty string token.
+func tokenizeMIB(src string) []string {
+	var tokens []string
+	for i := 0; i < len(src); {
+		switch c := src[i]; {
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+			i++
+		case c == '"':
+			end := strings.IndexByte(src[i+1:], '"')
+			if end < 0 {
+				return tokens
+			}
+			tokens = append(tokens, `""`)
+			i += end + 2
+		case strings.HasPrefix(src[i:], "--"):
+			// Comments end at either the end of the line or another --.
+			i += 2
+			for i < len(src) && src[i] != '\n' && !strings.HasPrefix(src[i:], "--") {
+				i++
+			}
+			if strings.HasPrefix(src[i:], "--") {
+				i += 2
+			}
+		case strings.HasPrefix(src[i:], "::="):
+			tokens = append(tokens, "::=")
+			i += 3
+		case strings.IndexByte("{}(),;", c) >= 0:
+			tokens = append(tokens, string(c))
+			i++
+		default:
+			start := i
+			for i < len(src) && strings.IndexByte(" \t\r\n{}(),;\"", src[i]) < 0 && !strings.HasPrefix(src[i:], "--") {
+				i++
+			}
+			tokens = append(tokens, src[start:i])
+		}
+	}
+	return tokens
+}
+
+var mibMacros = map[string]struct{}{
+	"OBJECT-TYPE":        {},
+	"OBJECT-IDENTITY":    {},
+	"MODULE-IDENTITY":    {},
+	"NOTIFICATION-TYPE":  {},
+	"OBJECT-GROUP":       {},
+	"NOTIFICATION-GROUP": {},
+	"MODULE-COMPLIANCE":  {},
+	"AGENT-CAPABILITIES": {},
+}
+
+func isMIBIdentifier(tok string) bool {
+	return tok != "" && tok[0] >= 'a' && tok[0] <= 'z'
+}
+
+func (p *mibParser) load(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return p.parseFile(path)
+	}
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if e.IsDir() || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		if err := p.parseFile(filepath.Join(path, e.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *mibParser) parseFile(path string) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := p.parse(string(b)); err != nil {
+		return fmt.Errorf("failed to parse mib %v: %w", path, err)
+	}
+	return nil
+}
+
+func (p *mibParser) parse(src string) error {
+	tokens := tokenizeMIB(src)
+
+	// Rewrite name(number) components, which are split by the tokenizer.
+	var joined []string
+	for i := 0; i < len(tokens); i++ {
+		if i+3 < len(tokens) && tokens[i+1] == "(" && tokens[i+3] == ")" && isMIBIdentifier(tokens[i]) {
+			joined = append(joined, tokens[i]+"("+tokens[i+2]+")")
+			i += 3
+			continue
+		}
+		joined = append(joined, tokens[i])
+	}
+	tokens = joined
+
+	var current string
+	for i := 0; i < len(tokens); i++ {
+		tok := tokens[i]
+		if isMIBIdentifier(tok) && i+1 < len(tokens) {
+			if _, isMacro := mibMacros[tokens[i+1]]; isMacro {
+				current = tok
+				continue
+			}
+			if i+2 < len(tokens) && tokens[i+1] == "OBJECT" && tokens[i+2] == "IDENTIFIER" && i+3 < len(tokens) && tokens[i+3] == "::=" {
+				current = tok
+				continue
+			}
+		}
+		if tok != "::=" || current == "" || i+1 >= len(tokens) || tokens[i+1] != "{" {
+			continue
+		}
+
+		var components []string
+		for i += 2; i < len(tokens) && tokens[i] != "}"; i++ {
+			components = append(components, tokens[i])
+		}
+		if len(components) < 2 {
+			return fmt.Errorf("invalid oid assignment of %v", current)
+		}
+
+		def := mibDefinition{parent: components[0]}
+		if m := mibNameArcRegexp.FindStringSubmatch(def.parent); m != nil {
+			def.parent = m[1]
+		}
+		for _, c := range components[1:] {
+			if m := mibNameArcRegexp.FindStringSubmatch(c); m != nil {
+				c = m[2]
+			}
+			if _, err := strconv.ParseUint(c, 10, 64); err != nil {
+				return fmt.Errorf("invalid oid assignment of %v", current)
+			}
+			def.arcs = append(def.arcs, c)
+		}
+		p.defs[current] = def
+		current = ""
+	}
+	return nil
+}
+
+// resolve returns the numeric OIDs of all definitions whose ancestors are
+// either known or defined, mapped to their names.
+func (p *mibParser) resolve(known map[string]string) map[string]string {
+	resolved := map[string]string{}
+	for name, oid := range known {
+		resolved[name] = oid
+	}
+
+	var resolveName func(name string, depth int) (string, bool)
+	resolveName = func(name string, depth int) (string, bool) {
+		if oid, exists := resolved[name]; exists {
+			return oid, true
+		}
+		def, exists := p.defs[name]
+		if !exists || depth > 128 {
+			return "", false
+		}
+		parent, ok := resolveName(def.parent, depth+1)
+		if !ok {
+			return "", false
+		}
+		oid := parent + "." + strings.Join(def.arcs, ".")
+		resolved[name] = oid
+		return oid, true
+	}
+
+	names := map[string]string{}
+	for name := range p.defs {
+		if oid, ok := resolveName(name, 0); ok {
+			names[oid] = name
+		}
+	}
+	return names
+}
+
+// value converts the value of a variable into a structured value, where octet
+// strings become strings when they are printable text and colon separated hex
+// otherwise, OIDs are translated into names, and integers are widened to int64
+// and unsigned integers to uint64.
+func (m *mib) value(pdu gosnmp.SnmpPDU) interface{} {
+	switch v := pdu.Value.(type) {
+	case []byte:
+		if isPrintable(v) {
+			return string(v)
+		}
+		parts := make([]string, len(v))
+		for i, c := range v {
+			parts[i] = fmt.Sprintf("%02x", c)
+		}
+		return strings.Join(parts, ":")
+	case string:
+		if pdu.Type == gosnmp.ObjectIdentifier {
+			return m.translate(trimOID(v))
+		}
+	case int:
+		return int64(v)
+	case uint:
+		return uint64(v)
+	case uint32:
+		return uint64(v)
+	}
+	return pdu.Value
+}
+
+func isPrintable(b []byte) bool {
+	if !utf8.Valid(b) {
+		return false
+	}
+	for _, r := range string(b) {
+		if !unicode.IsPrint(r) && !unicode.IsSpace(r) {
+			return false
+		}
+	}
+	return true
+}
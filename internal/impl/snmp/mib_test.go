@@ -0,0 +1,104 @@
+package snmp
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gosnmp/gosnmp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const testMIB = `
+ACME-MIB DEFINITIONS ::= BEGIN
+
+IMPORTS
+    MODULE-IDENTITY, OBJECT-TYPE, NOTIFICATION-TYPE, enterprises
+        FROM SNMPv2-SMI;
+
+acme MODULE-IDENTITY
+    LAST-UPDATED "202001010000Z"
+    ORGANIZATION "Acme"
+    DESCRIPTION
+        "The acme OBJECT-TYPE ::= { nope 1 } MIB -- not a comment"
+    ::= { enterprises 99999 }
+
+acmeObjects OBJECT IDENTIFIER ::= { acme 1 }
+acmeTraps   OBJECT IDENTIFIER ::= { acme 0 }
+
+-- acmeIgnored OBJECT IDENTIFIER ::= { acme 9 }
+
+acmeTemperature OBJECT-TYPE
+    SYNTAX      Integer32
+    MAX-ACCESS  read-only
+    STATUS      current
+    DESCRIPTION "The temperature."
+    ::= { acmeObjects 1 }
+
+acmeOverheat NOTIFICATION-TYPE
+    OBJECTS { acmeTemperature }
+    STATUS  current
+    DESCRIPTION "Too hot."
+    ::= { acmeTraps 1 }
+
+acmeLegacy OBJECT IDENTIFIER ::= { iso org(3) dod(6) internet(1) private(4) 2 }
+
+END
+`
+
+func TestMIBTranslate(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "ACME-MIB.txt"), []byte(testMIB), 0o644))
+
+	spec := mibFields(service.NewConfigSpec())
+	parsed, err := spec.ParseYAML(`
+mibs: [ `+dir+` ]
+oid_names:
+  1.3.6.1.4.1.99999.1.2: acmeHumidity
+`, nil)
+	require.NoError(t, err)
+
+	m, err := mibFromConfig(parsed)
+	require.NoError(t, err)
+
+	for oid, name := range map[string]string{
+		"1.3.6.1.4.1.99999.1.1.0": "acmeTemperature.0",
+		"1.3.6.1.4.1.99999.0.1":   "acmeOverheat",
+		"1.3.6.1.4.1.99999.1.2":   "acmeHumidity",
+		"1.3.6.1.4.1.99999.9":     "acme.9",
+		"1.3.6.1.4.2.5":           "acmeLegacy.5",
+		"1.3.6.1.2.1.2.2.1.2.10":  "ifDescr.10",
+		"1.3.6.1.6.3.1.1.5.3":     "linkDown",
+		"2.5.4":                   "2.5.4",
+	} {
+		assert.Equal(t, name, m.translate(oid), oid)
+	}
+
+	for name, oid := range map[string]string{
+		"ACME-MIB::acmeTemperature.0": "1.3.6.1.4.1.99999.1.1.0",
+		"acmeHumidity":                "1.3.6.1.4.1.99999.1.2",
+		".1.3.6.1.2.1.1.5.0":          "1.3.6.1.2.1.1.5.0",
+		"sysName.0":                   "1.3.6.1.2.1.1.5.0",
+	} {
+		resolved, err := m.resolve(name)
+		require.NoError(t, err, name)
+		assert.Equal(t, oid, resolved, name)
+	}
+
+	_, err = m.resolve("acmeIgnored")
+	assert.EqualError(t, err, "oid name acmeIgnored not recognised")
+}
+
+func TestMIBValue(t *testing.T) {
+	m := newMIB()
+	assert.Equal(t, "eth0", m.value(gosnmp.SnmpPDU{Type: gosnmp.OctetString, Value: []byte("eth0")}))
+	assert.Equal(t, "00:1a:2b:ff", m.value(gosnmp.SnmpPDU{Type: gosnmp.OctetString, Value: []byte{0x00, 0x1A, 0x2B, 0xFF}}))
+	assert.Equal(t, "linkUp", m.value(gosnmp.SnmpPDU{Type: gosnmp.ObjectIdentifier, Value: ".1.3.6.1.6.3.1.1.5.4"}))
+	assert.Equal(t, "10.0.0.1", m.value(gosnmp.SnmpPDU{Type: gosnmp.IPAddress, Value: "10.0.0.1"}))
+	assert.Equal(t, uint64(5), m.value(gosnmp.SnmpPDU{Type: gosnmp.Counter32, Value: uint(5)}))
+	assert.Equal(t, uint64(7), m.value(gosnmp.SnmpPDU{Type: gosnmp.TimeTicks, Value: uint32(7)}))
+	assert.Equal(t, int64(-3), m.value(gosnmp.SnmpPDU{Type: gosnmp.Integer, Value: -3}))
+}
@@ -0,0 +1,183 @@
+package sql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// clickhouseHTTPClient executes queries with the HTTP interface of ClickHouse,
+// which isn't supported by the native driver.
+type clickhouseHTTPClient struct {
+	client   *http.Client
+	endpoint *url.URL
+	username string
+	password string
+}
+
+func newClickhouseHTTPClient(dsn string) (*clickhouseHTTPClient, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &clickhouseHTTPClient{client: &http.Client{}}
+	if u.User != nil {
+		c.username = u.User.Username()
+		c.password, _ = u.User.Password()
+		u.User = nil
+	}
+
+	// Settings such as the database are provided as URL parameters and apply to
+	// each query.
+	uq := u.Query()
+	uq.Set("default_format", "JSON")
+	uq.Set("output_format_json_quote_64bit_integers", "0")
+	u.RawQuery = uq.Encode()
+
+	c.endpoint = u
+	return c, nil
+}
+
+// clickhouseParam returns the ClickHouse type and value of a query argument.
+func clickhouseParam(arg interface{}) (typeStr, value string, err error) {
+	switch t := arg.(type) {
+	case nil:
+		return "Nullable(String)", `\N`, nil
+	case string:
+		return "String", t, nil
+	case []byte:
+		return "String", string(t), nil
+	case bool:
+		return "Bool", strconv.FormatBool(t), nil
+	case int:
+		return "Int64", strconv.Itoa(t), nil
+	case int32:
+		return "Int64", strconv.FormatInt(int64(t), 10), nil
+	case int64:
+		return "Int64", strconv.FormatInt(t, 10), nil
+	case uint64:
+		return "UInt64", strconv.FormatUint(t, 10), nil
+	case float64:
+		return "Float64", strconv.FormatFloat(t, 'g', -1, 64), nil
+	case json.Number:
+		if _, err := t.Int64(); err == nil {
+			return "Int64", t.String(), nil
+		}
+		return "Float64", t.String(), nil
+	}
+	return "", "", fmt.Errorf("unsupported argument type: %T", arg)
+}
+
+// clickhouseBindParams replaces the question mark placeholders of a query with typed
+// query parameters, where a double question mark is an escaped question mark.
+func clickhouseBindParams(query string, args []interface{}) (string, url.Values, error) {
+	var buf strings.Builder
+	params := url.Values{}
+
+	n := 0
+	for {
+		i := strings.IndexByte(query, '?')
+		if i < 0 {
+			buf.WriteString(query)
+			break
+		}
+		buf.WriteString(query[:i])
+		if strings.HasPrefix(query[i:], "??") {
+			buf.WriteByte('?')
+			query = query[i+2:]
+			continue
+		}
+		if n >= len(args) {
+			return "", nil, fmt.Errorf("query has more placeholders than the %v arguments provided", len(args))
+		}
+
+		typeStr, value, err := clickhouseParam(args[n])
+		if err != nil {
+			return "", nil, err
+		}
+		name := "p" + strconv.Itoa(n)
+		fmt.Fprintf(&buf, "{%v:%v}", name, typeStr)
+		params.Set("param_"+name, value)
+
+		n++
+		query = query[i+1:]
+	}
+	if n != len(args) {
+		return "", nil, fmt.Errorf("query has %v placeholders but %v arguments were provided", n, len(args))
+	}
+	return buf.String(), params, nil
+}
+
+func (c *clickhouseHTTPClient) query(ctx context.Context, query string, args []interface{}) ([]interface{}, error) {
+	query, params, err := clickhouseBindParams(query, args)
+	if err != nil {
+		return nil, err
+	}
+
+	u := *c.endpoint
+	uq := u.Query()
+	for k, v := range params {
+		uq[k] = v
+	}
+	u.RawQuery = uq.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), strings.NewReader(query))
+	if err != nil {
+		return nil, err
+	}
+	if c.username != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+
+	res, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(res.Body)
+		return nil, fmt.Errorf("clickhouse: %s", strings.TrimSpace(string(body)))
+	}
+
+	var result struct {
+		Data []map[string]interface{} `json:"data"`
+	}
+	dec := json.NewDecoder(res.Body)
+	dec.UseNumber()
+	if err := dec.Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	jArray := make([]interface{}, 0, len(result.Data))
+	for _, row := range result.Data {
+		for k, v := range row {
+			if n, ok := v.(json.Number); ok {
+				row[k] = clickhouseNumber(n)
+			}
+		}
+		jArray = append(jArray, row)
+	}
+	return jArray, nil
+}
+
+func clickhouseNumber(n json.Number) interface{} {
+	if i, err := n.Int64(); err == nil {
+		return i
+	}
+	if f, err := n.Float64(); err == nil {
+		return f
+	}
+	return n
+}
+
+func (c *clickhouseHTTPClient) close() error {
+	c.client.CloseIdleConnections()
+	return nil
+}
@@ -0,0 +1,107 @@
+package sql
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func TestClickhouseBindParams(t *testing.T) {
+	query, params, err := clickhouseBindParams("SELECT * FROM foo WHERE a = ? AND b = ? AND c ?? 'x' AND d = ?", []interface{}{"bar", int64(10), nil})
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT * FROM foo WHERE a = {p0:String} AND b = {p1:Int64} AND c ? 'x' AND d = {p2:Nullable(String)}", query)
+	assert.Equal(t, url.Values{
+		"param_p0": []string{"bar"},
+		"param_p1": []string{"10"},
+		"param_p2": []string{`\N`},
+	}, params)
+
+	_, _, err = clickhouseBindParams("SELECT * FROM foo WHERE a = ?", nil)
+	require.EqualError(t, err, "query has more placeholders than the 0 arguments provided")
+
+	_, _, err = clickhouseBindParams("SELECT * FROM foo", []interface{}{"bar"})
+	require.EqualError(t, err, "query has 0 placeholders but 1 arguments were provided")
+
+	_, _, err = clickhouseBindParams("SELECT * FROM foo WHERE a = ?", []interface{}{[]interface{}{}})
+	require.EqualError(t, err, "unsupported argument type: []interface {}")
+}
+
+func TestClickhouseSelectProcessorHTTP(t *testing.T) {
+	var requests int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+
+		user, pass, _ := r.BasicAuth()
+		assert.Equal(t, "foouser", user)
+		assert.Equal(t, "foopass", pass)
+		assert.Equal(t, "analytics", r.URL.Query().Get("database"))
+		assert.Equal(t, "JSON", r.URL.Query().Get("default_format"))
+
+		query, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		assert.Equal(t, "SELECT user_id, count() AS total FROM events WHERE user_id = {p0:String} GROUP BY user_id", string(query))
+
+		switch r.URL.Query().Get("param_p0") {
+		case "bar":
+			_, _ = w.Write([]byte(`{"meta":[{"name":"user_id","type":"String"},{"name":"total","type":"UInt64"}],"data":[{"user_id":"bar","total":3}],"rows":1}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte("Code: 60. DB::Exception: Table analytics.events doesn't exist.\n"))
+		}
+	}))
+	t.Cleanup(ts.Close)
+
+	u, err := url.Parse(ts.URL)
+	require.NoError(t, err)
+	u.User = url.UserPassword("foouser", "foopass")
+	u.RawQuery = "database=analytics"
+
+	conf, err := ClickhouseSelectProcessorConfig().ParseYAML(`
+dsn: `+u.String()+`
+table: events
+columns: [ user_id, count() AS total ]
+where: user_id = ?
+suffix: GROUP BY user_id
+args_mapping: 'root = [ this.user ]'
+result_cache:
+  resource: foocache
+`, nil)
+	require.NoError(t, err)
+
+	proc, err := newClickhouseSelectProcessorFromConfig(conf, service.MockResources(service.MockResourcesOptAddCache("foocache")))
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		batches, err := proc.ProcessBatch(ctx, service.MessageBatch{
+			service.NewMessage([]byte(`{"user":"bar"}`)),
+			service.NewMessage([]byte(`{"user":"bar"}`)),
+			service.NewMessage([]byte(`{"user":"baz"}`)),
+		})
+		require.NoError(t, err)
+		require.Len(t, batches, 1)
+		require.Len(t, batches[0], 3)
+
+		for _, msg := range batches[0][:2] {
+			require.NoError(t, msg.GetError())
+			b, err := msg.AsBytes()
+			require.NoError(t, err)
+			assert.Equal(t, `[{"total":3,"user_id":"bar"}]`, string(b))
+		}
+		assert.EqualError(t, batches[0][2].GetError(), "clickhouse: Code: 60. DB::Exception: Table analytics.events doesn't exist.")
+	}
+
+	// The result of bar is cached whereas the failed query of baz isn't.
+	assert.Equal(t, int32(3), atomic.LoadInt32(&requests))
+
+	require.NoError(t, proc.Close(ctx))
+}
@@ -0,0 +1,51 @@
+package enrich
+
+import (
+	"context"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+// QueryForMessage returns a key that identifies the query to execute for a
+// message of a batch, along with a function that executes it.
+type QueryForMessage func(i int) (key string, fn QueryFn, err error)
+
+// ExecuteBatch replaces the contents of each message of a batch with the
+// result of its query, where messages that share a query key are enriched with
+// a single execution of it. When a result cache is provided results are read
+// from and written to it. Errors are set on the messages they apply to.
+func ExecuteBatch(ctx context.Context, cache *ResultCache, batch service.MessageBatch, queryFor QueryForMessage) {
+	var keys []string
+	fns := map[string]QueryFn{}
+	indexes := map[string][]int{}
+
+	for i, msg := range batch {
+		key, fn, err := queryFor(i)
+		if err != nil {
+			msg.SetError(err)
+			continue
+		}
+		if _, exists := fns[key]; !exists {
+			keys = append(keys, key)
+			fns[key] = fn
+		}
+		indexes[key] = append(indexes[key], i)
+	}
+
+	for _, key := range keys {
+		var result []byte
+		var err error
+		if cache != nil {
+			result, err = cache.Query(ctx, key, fns[key])
+		} else {
+			result, err = fns[key](ctx)
+		}
+		for _, i := range indexes[key] {
+			if err != nil {
+				batch[i].SetError(err)
+				continue
+			}
+			batch[i].SetBytes(result)
+		}
+	}
+}
@@ -0,0 +1,235 @@
+// Package enrich contains a result cache shared by processors that enrich
+// messages with the results of queries against slow analytical stores.
+package enrich
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/benthosdev/benthos/v4/internal/shutdown"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	fieldResultCache = "result_cache"
+	fieldResource    = "resource"
+	fieldTTL         = "ttl"
+	fieldRefreshAge  = "refresh_after"
+
+	// The version of the format of cached results, which is a version byte
+	// followed by the unix nano timestamp of when the result was obtained and
+	// then the result itself.
+	cachedResultVersion = 1
+	cachedResultHeader  = 9
+)
+
+// ResultCacheField returns the config field spec of a result cache.
+func ResultCacheField() *service.ConfigField {
+	return service.NewObjectField(fieldResultCache,
+		service.NewStringField(fieldResource).
+			Description("The name of a [cache resource](/docs/components/caches/about) to store query results in. Results are cached under a hash of the query and its arguments. When empty results are not cached.").
+			Default(""),
+		service.NewDurationField(fieldTTL).
+			Description("The period of time after which a cached result is considered stale and the query is executed again.").
+			Default("5m"),
+		service.NewDurationField(fieldRefreshAge).
+			Description("An optional age after which a cached result is still used but is also refreshed in the background, so that frequently queried results are prefetched before they become stale. Must be less than the `ttl` in order to take effect. When empty results are only refreshed once stale.").
+			Default("").
+			Example("4m"),
+	).
+		Description("Allows the results of queries to be cached, which is useful when enriching messages with aggregates that are expensive to compute but only change periodically. Messages of a batch that share the same query and arguments are also enriched with a single execution of the query.").
+		Advanced()
+}
+
+// QueryFn executes a query and returns its result serialised as JSON.
+type QueryFn func(ctx context.Context) ([]byte, error)
+
+// ResultCache caches the results of queries within a cache resource and
+// refreshes them asynchronously ahead of them becoming stale.
+type ResultCache struct {
+	mgr          *service.Resources
+	log          *service.Logger
+	resource     string
+	ttl          time.Duration
+	refreshAfter time.Duration
+	nowFn        func() time.Time
+
+	refreshMut sync.Mutex
+	refreshing map[string]struct{}
+	refreshWG  sync.WaitGroup
+
+	shutSig *shutdown.Signaller
+}
+
+// ResultCacheFromParsed attempts to create a result cache from a parsed
+// config, if a cache resource is not configured then nil is returned instead.
+func ResultCacheFromParsed(conf *service.ParsedConfig, mgr *service.Resources) (*ResultCache, error) {
+	conf = conf.Namespace(fieldResultCache)
+
+	resource, err := conf.FieldString(fieldResource)
+	if err != nil || resource == "" {
+		return nil, err
+	}
+	if !mgr.HasCache(resource) {
+		return nil, fmt.Errorf("cache resource %v not found", resource)
+	}
+
+	c := &ResultCache{
+		mgr:        mgr,
+		log:        mgr.Logger(),
+		resource:   resource,
+		nowFn:      time.Now,
+		refreshing: map[string]struct{}{},
+		shutSig:    shutdown.NewSignaller(),
+	}
+	if c.ttl, err = conf.FieldDuration(fieldTTL); err != nil {
+		return nil, err
+	}
+
+	refreshStr, err := conf.FieldString(fieldRefreshAge)
+	if err != nil {
+		return nil, err
+	}
+	if refreshStr != "" {
+		if c.refreshAfter, err = time.ParseDuration(refreshStr); err != nil {
+			return nil, fmt.Errorf("failed to parse refresh_after: %w", err)
+		}
+	}
+	return c, nil
+}
+
+// Key returns a cache key for a query and its arguments.
+func Key(query string, args []interface{}) (string, error) {
+	argBytes, err := json.Marshal(args)
+	if err != nil {
+		return "", fmt.Errorf("failed to serialise arguments: %w", err)
+	}
+	h := sha256.New()
+	_, _ = h.Write([]byte(query))
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write(argBytes)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Query returns the result of a query, which is read from the cache when a
+// result exists that isn't stale and is otherwise obtained by calling fn and
+// written to the cache. Failures to access the cache are logged and result in
+// the query being executed.
+func (c *ResultCache) Query(ctx context.Context, key string, fn QueryFn) ([]byte, error) {
+	if result, fetchedAt, ok := c.get(ctx, key); ok {
+		if age := c.nowFn().Sub(fetchedAt); age < c.ttl {
+			if c.refreshAfter > 0 && age >= c.refreshAfter {
+				c.refresh(key, fn)
+			}
+			return result, nil
+		}
+	}
+
+	result, err := fn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.set(ctx, key, result)
+	return result, nil
+}
+
+func (c *ResultCache) get(ctx context.Context, key string) (result []byte, fetchedAt time.Time, ok bool) {
+	var cached []byte
+	var err error
+	if cerr := c.mgr.AccessCache(ctx, c.resource, func(cache service.Cache) {
+		cached, err = cache.Get(ctx, key)
+	}); cerr != nil {
+		err = cerr
+	}
+	if err != nil {
+		if !errors.Is(err, service.ErrKeyNotFound) {
+			c.log.Errorf("Failed to read cached query result: %v", err)
+		}
+		return
+	}
+
+	if len(cached) < cachedResultHeader || cached[0] != cachedResultVersion {
+		c.log.Errorf("Failed to parse cached query result, this indicates the data was not set by this processor")
+		return
+	}
+	fetchedAt = time.Unix(0, int64(binary.BigEndian.Uint64(cached[1:cachedResultHeader])))
+	return cached[cachedResultHeader:], fetchedAt, true
+}
+
+func (c *ResultCache) set(ctx context.Context, key string, result []byte) {
+	cached := make([]byte, cachedResultHeader, cachedResultHeader+len(result))
+	cached[0] = cachedResultVersion
+	binary.BigEndian.PutUint64(cached[1:], uint64(c.nowFn().UnixNano()))
+	cached = append(cached, result...)
+
+	// Some caches only support a general TTL, in which case entries might live
+	// longer than ours, which is fine as we also check the age on read.
+	ttl := c.ttl
+	var err error
+	if cerr := c.mgr.AccessCache(ctx, c.resource, func(cache service.Cache) {
+		err = cache.Set(ctx, key, cached, &ttl)
+	}); cerr != nil {
+		err = cerr
+	}
+	if err != nil {
+		c.log.Errorf("Failed to write query result to cache: %v", err)
+	}
+}
+
+// refresh executes a query in the background and caches its result, unless a
+// refresh of the same key is already in progress.
+func (c *ResultCache) refresh(key string, fn QueryFn) {
+	c.refreshMut.Lock()
+	if _, exists := c.refreshing[key]; exists || c.shutSig.ShouldCloseAtLeisure() {
+		c.refreshMut.Unlock()
+		return
+	}
+	c.refreshing[key] = struct{}{}
+	c.refreshWG.Add(1)
+	c.refreshMut.Unlock()
+
+	go func() {
+		defer func() {
+			c.refreshMut.Lock()
+			delete(c.refreshing, key)
+			c.refreshMut.Unlock()
+			c.refreshWG.Done()
+		}()
+
+		ctx, done := c.shutSig.CloseAtLeisureCtx(context.Background())
+		defer done()
+
+		result, err := fn(ctx)
+		if err != nil {
+			c.log.Warnf("Failed to refresh cached query result: %v", err)
+			return
+		}
+		c.set(ctx, key, result)
+	}()
+}
+
+// Close cancels any refreshes in progress and waits for them to finish.
+func (c *ResultCache) Close(ctx context.Context) error {
+	c.refreshMut.Lock()
+	c.shutSig.CloseAtLeisure()
+	c.refreshMut.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		c.refreshWG.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return nil
+}
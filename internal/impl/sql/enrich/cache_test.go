@@ -0,0 +1,159 @@
+package enrich
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func testResultCache(t *testing.T, conf string) *ResultCache {
+	t.Helper()
+
+	spec := service.NewConfigSpec().Field(ResultCacheField())
+	parsed, err := spec.ParseYAML(conf, nil)
+	require.NoError(t, err)
+
+	c, err := ResultCacheFromParsed(parsed, service.MockResources(service.MockResourcesOptAddCache("foo")))
+	require.NoError(t, err)
+	return c
+}
+
+func TestResultCacheDisabled(t *testing.T) {
+	spec := service.NewConfigSpec().Field(ResultCacheField())
+	parsed, err := spec.ParseYAML(`{}`, nil)
+	require.NoError(t, err)
+
+	c, err := ResultCacheFromParsed(parsed, service.MockResources())
+	require.NoError(t, err)
+	assert.Nil(t, c)
+
+	parsed, err = spec.ParseYAML(`result_cache: { resource: nope }`, nil)
+	require.NoError(t, err)
+
+	_, err = ResultCacheFromParsed(parsed, service.MockResources())
+	require.EqualError(t, err, "cache resource nope not found")
+}
+
+func TestResultCacheTTL(t *testing.T) {
+	c := testResultCache(t, `
+result_cache:
+  resource: foo
+  ttl: 1m
+`)
+
+	now := time.Unix(1000, 0)
+	c.nowFn = func() time.Time { return now }
+
+	var calls int
+	fn := func(ctx context.Context) ([]byte, error) {
+		calls++
+		return []byte(`[{"total":10}]`), nil
+	}
+
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		result, err := c.Query(ctx, "bar", fn)
+		require.NoError(t, err)
+		assert.Equal(t, `[{"total":10}]`, string(result))
+	}
+	assert.Equal(t, 1, calls)
+
+	now = now.Add(time.Minute)
+	_, err := c.Query(ctx, "bar", fn)
+	require.NoError(t, err)
+	assert.Equal(t, 2, calls)
+
+	_, err = c.Query(ctx, "baz", func(ctx context.Context) ([]byte, error) {
+		return nil, errors.New("nope")
+	})
+	require.EqualError(t, err, "nope")
+
+	require.NoError(t, c.Close(ctx))
+}
+
+func TestResultCacheRefresh(t *testing.T) {
+	c := testResultCache(t, `
+result_cache:
+  resource: foo
+  ttl: 1m
+  refresh_after: 30s
+`)
+
+	var nowMut sync.Mutex
+	now := time.Unix(1000, 0)
+	c.nowFn = func() time.Time {
+		nowMut.Lock()
+		defer nowMut.Unlock()
+		return now
+	}
+
+	var calls int32
+	fn := func(ctx context.Context) ([]byte, error) {
+		n := atomic.AddInt32(&calls, 1)
+		return []byte{'0' + byte(n)}, nil
+	}
+
+	ctx := context.Background()
+	result, err := c.Query(ctx, "bar", fn)
+	require.NoError(t, err)
+	assert.Equal(t, "1", string(result))
+
+	nowMut.Lock()
+	now = now.Add(45 * time.Second)
+	nowMut.Unlock()
+
+	// The cached result is returned whilst being refreshed in the background.
+	result, err = c.Query(ctx, "bar", fn)
+	require.NoError(t, err)
+	assert.Equal(t, "1", string(result))
+
+	// The mock cache isn't safe for concurrent use so we wait for the refresh.
+	c.refreshWG.Wait()
+
+	result, err = c.Query(ctx, "bar", fn)
+	require.NoError(t, err)
+	assert.Equal(t, "2", string(result))
+
+	require.NoError(t, c.Close(ctx))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestExecuteBatch(t *testing.T) {
+	batch := service.MessageBatch{
+		service.NewMessage([]byte("a")),
+		service.NewMessage([]byte("b")),
+		service.NewMessage([]byte("a")),
+		service.NewMessage([]byte("c")),
+	}
+
+	calls := map[string]int{}
+	ExecuteBatch(context.Background(), nil, batch, func(i int) (string, QueryFn, error) {
+		key, err := batch[i].AsBytes()
+		require.NoError(t, err)
+		if string(key) == "c" {
+			return "", nil, errors.New("bad args")
+		}
+		return string(key), func(ctx context.Context) ([]byte, error) {
+			calls[string(key)]++
+			return []byte("result " + string(key)), nil
+		}, nil
+	})
+
+	assert.Equal(t, map[string]int{"a": 1, "b": 1}, calls)
+
+	for i, exp := range []string{"result a", "result b", "result a"} {
+		b, err := batch[i].AsBytes()
+		require.NoError(t, err)
+		assert.Equal(t, exp, string(b))
+		assert.NoError(t, batch[i].GetError())
+	}
+	assert.EqualError(t, batch[3].GetError(), "bad args")
+}
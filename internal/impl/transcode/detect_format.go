@@ -0,0 +1,284 @@
+package transcode
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"unicode/utf8"
+
+	"github.com/linkedin/goavro/v2"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	ixml "github.com/benthosdev/benthos/v4/internal/impl/xml"
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func detectFormatConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		// Stable(). TODO
+		Categories("Parsing", "Utility").
+		Summary("Detects the format of the contents of messages and adds it as a metadata field, optionally parsing messages of formats that do not require a schema.").
+		Description(`
+The format of each message is detected by inspecting its contents and is one of `+"`gzip`, `avro_ocf`, `json`, `xml`, `csv`, `protobuf` or `unknown`"+`, checked in that order. JSON is only detected for objects and arrays, and CSV is only detected for documents containing at least two rows with the same number of columns, where there are at least two columns.
+
+Protobuf messages are detected by parsing them as the protobuf wire format, which is ambiguous and should only be relied upon for binary messages that are known not to be of the other formats.
+
+When `+"`parse`"+` is enabled messages are replaced according to their format:
+
+- `+"`gzip`"+`: The contents are decompressed.
+- `+"`avro_ocf`"+`: The records are parsed into an array of documents in the [Avro JSON encoding](https://avro.apache.org/docs/current/spec.html#json_encoding) of the schema of the file.
+- `+"`json`"+`: The contents are parsed as a JSON document.
+- `+"`xml`"+`: The contents are parsed into a document following the rules of the `+"[`xml` processor](/docs/components/processors/xml)"+`.
+- `+"`csv`"+`: The rows are parsed into an array of objects keyed by the first row.
+
+Messages of the formats `+"`protobuf` and `unknown`"+` are left unchanged. This processor can be followed by a `+"[`switch` processor](/docs/components/processors/switch)"+` in order to handle each format differently.`).
+		Field(service.NewStringField("metadata_key").
+			Description("The metadata key to add the detected format to.").
+			Default("format")).
+		Field(service.NewBoolField("parse").
+			Description("Whether to parse messages according to their detected format.").
+			Default(false)).
+		Example("Heterogeneous Feeds", `
+Here we receive a feed of JSON, XML and CSV documents, which are parsed and then routed to a different set of processors based on the format they were received in:`, `
+pipeline:
+  processors:
+    - detect_format:
+        parse: true
+    - switch:
+        - check: 'meta("format") == "csv"'
+          processors:
+            - unarchive:
+                format: json_array
+        - check: 'meta("format") == "unknown"'
+          processors:
+            - log:
+                message: 'Received message of unknown format'
+`)
+}
+
+func init() {
+	err := service.RegisterProcessor(
+		"detect_format", detectFormatConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.Processor, error) {
+			return newDetectFormatFromParsed(conf, mgr)
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+var (
+	gzipMagic    = []byte{0x1f, 0x8b}
+	avroOCFMagic = []byte{'O', 'b', 'j', 0x01}
+)
+
+// detectFormat returns the format of a message body.
+func detectFormat(b []byte) string {
+	switch {
+	case bytes.HasPrefix(b, gzipMagic):
+		return "gzip"
+	case bytes.HasPrefix(b, avroOCFMagic):
+		return "avro_ocf"
+	case isJSON(b):
+		return "json"
+	case isXML(b):
+		return "xml"
+	case isCSV(b):
+		return "csv"
+	case isProtobuf(b):
+		return "protobuf"
+	}
+	return "unknown"
+}
+
+func isJSON(b []byte) bool {
+	b = bytes.TrimSpace(b)
+	if len(b) == 0 || (b[0] != '{' && b[0] != '[') {
+		return false
+	}
+	return json.Valid(b)
+}
+
+func isXML(b []byte) bool {
+	b = bytes.TrimSpace(b)
+	if len(b) == 0 || b[0] != '<' {
+		return false
+	}
+	dec := xml.NewDecoder(bytes.NewReader(b))
+	elements := 0
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			return elements > 0
+		}
+		if err != nil {
+			return false
+		}
+		if _, ok := tok.(xml.StartElement); ok {
+			elements++
+		}
+	}
+}
+
+func isCSV(b []byte) bool {
+	if !utf8.Valid(b) {
+		return false
+	}
+	r := csv.NewReader(bytes.NewReader(b))
+	rows, err := r.ReadAll()
+	return err == nil && len(rows) >= 2 && len(rows[0]) >= 2
+}
+
+// isProtobuf returns whether a message is a sequence of valid fields of the
+// protobuf wire format, which is the most that can be known without a schema.
+func isProtobuf(b []byte) bool {
+	if len(b) == 0 {
+		return false
+	}
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 || num < 1 || typ == protowire.StartGroupType || typ == protowire.EndGroupType {
+			return false
+		}
+		b = b[n:]
+		if n = protowire.ConsumeFieldValue(num, typ, b); n < 0 {
+			return false
+		}
+		b = b[n:]
+	}
+	return true
+}
+
+//------------------------------------------------------------------------------
+
+func parseGzipFormat(b []byte) ([]byte, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(zr)
+}
+
+func parseAvroOCFFormat(b []byte) (interface{}, error) {
+	r, err := goavro.NewOCFReader(bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	codec := r.Codec()
+
+	records := []interface{}{}
+	for r.Scan() {
+		record, err := r.Read()
+		if err != nil {
+			return nil, err
+		}
+		jBytes, err := codec.TextualFromNative(nil, record)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert Avro record to JSON: %v", err)
+		}
+		doc, err := jsonFormat{}.decode(jBytes)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, doc)
+	}
+	if err := r.Err(); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+func parseCSVFormat(b []byte) (interface{}, error) {
+	rows, err := csv.NewReader(bytes.NewReader(b)).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	docs := make([]interface{}, 0, len(rows)-1)
+	for _, row := range rows[1:] {
+		doc := make(map[string]interface{}, len(row))
+		for i, v := range row {
+			doc[rows[0][i]] = v
+		}
+		docs = append(docs, doc)
+	}
+	return docs, nil
+}
+
+//------------------------------------------------------------------------------
+
+type detectFormatProc struct {
+	metaKey string
+	parse   bool
+	log     *service.Logger
+}
+
+func newDetectFormatFromParsed(conf *service.ParsedConfig, mgr *service.Resources) (*detectFormatProc, error) {
+	d := &detectFormatProc{log: mgr.Logger()}
+
+	var err error
+	if d.metaKey, err = conf.FieldString("metadata_key"); err != nil {
+		return nil, err
+	}
+	if d.parse, err = conf.FieldBool("parse"); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func (d *detectFormatProc) Process(ctx context.Context, msg *service.Message) (service.MessageBatch, error) {
+	b, err := msg.AsBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	format := detectFormat(b)
+	msg.MetaSet(d.metaKey, format)
+	if !d.parse {
+		return service.MessageBatch{msg}, nil
+	}
+
+	switch format {
+	case "gzip":
+		var decompressed []byte
+		if decompressed, err = parseGzipFormat(b); err == nil {
+			msg.SetBytes(decompressed)
+		}
+	case "avro_ocf":
+		var records interface{}
+		if records, err = parseAvroOCFFormat(b); err == nil {
+			msg.SetStructured(records)
+		}
+	case "json":
+		var doc interface{}
+		if doc, err = msg.AsStructured(); err == nil {
+			msg.SetStructured(doc)
+		}
+	case "xml":
+		var doc map[string]interface{}
+		if doc, err = ixml.ToMap(b, false); err == nil {
+			msg.SetStructured(doc)
+		}
+	case "csv":
+		var docs interface{}
+		if docs, err = parseCSVFormat(b); err == nil {
+			msg.SetStructured(docs)
+		}
+	}
+	if err != nil {
+		d.log.Debugf("Failed to parse message as %v: %v", format, err)
+		return nil, fmt.Errorf("failed to parse message as %v: %w", format, err)
+	}
+	return service.MessageBatch{msg}, nil
+}
+
+func (d *detectFormatProc) Close(context.Context) error {
+	return nil
+}
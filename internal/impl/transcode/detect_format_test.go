@@ -0,0 +1,101 @@
+package transcode
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/linkedin/goavro/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+func TestDetectFormat(t *testing.T) {
+	var gzipped bytes.Buffer
+	zw := gzip.NewWriter(&gzipped)
+	_, _ = zw.Write([]byte(`hello world`))
+	require.NoError(t, zw.Close())
+
+	codec, err := goavro.NewCodec(testAvroSchema)
+	require.NoError(t, err)
+	var ocf bytes.Buffer
+	w, err := goavro.NewOCFWriter(goavro.OCFConfig{W: &ocf, Codec: codec})
+	require.NoError(t, err)
+	require.NoError(t, w.Append([]interface{}{
+		map[string]interface{}{"first_name": "foo", "age": 10, "email": goavro.Union("string", "foo@example.com")},
+	}))
+
+	var pb []byte
+	pb = protowire.AppendTag(pb, 1, protowire.BytesType)
+	pb = protowire.AppendString(pb, "\x00\x01foo")
+	pb = protowire.AppendTag(pb, 2, protowire.VarintType)
+	pb = protowire.AppendVarint(pb, 300)
+
+	for _, test := range []struct {
+		input    []byte
+		format   string
+		expected interface{}
+	}{
+		{input: gzipped.Bytes(), format: "gzip", expected: "hello world"},
+		{input: ocf.Bytes(), format: "avro_ocf", expected: []interface{}{
+			map[string]interface{}{"first_name": "foo", "age": json.Number("10"), "email": map[string]interface{}{"string": "foo@example.com"}},
+		}},
+		{input: []byte(` {"foo":[1,2]}`), format: "json", expected: map[string]interface{}{"foo": []interface{}{json.Number("1"), json.Number("2")}}},
+		{input: []byte(`<foo><bar>baz</bar></foo>`), format: "xml", expected: map[string]interface{}{"foo": map[string]interface{}{"bar": "baz"}}},
+		{input: []byte("a,b\n1,2\n3,4\n"), format: "csv", expected: []interface{}{
+			map[string]interface{}{"a": "1", "b": "2"},
+			map[string]interface{}{"a": "3", "b": "4"},
+		}},
+		{input: pb, format: "protobuf", expected: string(pb)},
+		{input: []byte(`hello world`), format: "unknown", expected: "hello world"},
+		{input: []byte(`{"foo":`), format: "unknown", expected: `{"foo":`},
+		{input: []byte(`5`), format: "unknown", expected: `5`},
+		{input: []byte(`<foo>`), format: "unknown", expected: `<foo>`},
+		{input: []byte{}, format: "unknown", expected: ""},
+	} {
+		conf, err := detectFormatConfig().ParseYAML(`parse: true`, nil)
+		require.NoError(t, err)
+		proc, err := newDetectFormatFromParsed(conf, service.MockResources())
+		require.NoError(t, err)
+
+		res, err := proc.Process(context.Background(), service.NewMessage(test.input))
+		require.NoError(t, err, test.format)
+		require.Len(t, res, 1)
+
+		format, _ := res[0].MetaGet("format")
+		assert.Equal(t, test.format, format, string(test.input))
+
+		if s, ok := test.expected.(string); ok {
+			b, err := res[0].AsBytes()
+			require.NoError(t, err)
+			assert.Equal(t, s, string(b), test.format)
+		} else {
+			v, err := res[0].AsStructured()
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, v, test.format)
+		}
+	}
+}
+
+func TestDetectFormatNoParse(t *testing.T) {
+	conf, err := detectFormatConfig().ParseYAML(`metadata_key: content_format`, nil)
+	require.NoError(t, err)
+	proc, err := newDetectFormatFromParsed(conf, service.MockResources())
+	require.NoError(t, err)
+
+	res, err := proc.Process(context.Background(), service.NewMessage([]byte(`{"foo":"bar"}`)))
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+
+	format, _ := res[0].MetaGet("content_format")
+	assert.Equal(t, "json", format)
+
+	b, err := res[0].AsBytes()
+	require.NoError(t, err)
+	assert.Equal(t, `{"foo":"bar"}`, string(b))
+}
@@ -0,0 +1,123 @@
+package transcode
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// format is a serialisation format with any schemas already compiled, from
+// which coders can be created.
+type format interface {
+	// newCoder returns a coder for processing a single batch. Coders are not
+	// safe for concurrent use, which allows them to reuse buffers and
+	// messages across all documents of the batch.
+	newCoder() coder
+}
+
+// coder converts documents between a serialisation format and a generic
+// structured form.
+type coder interface {
+	decode(b []byte) (interface{}, error)
+	encode(v interface{}) ([]byte, error)
+}
+
+// jsonFromCoder is implemented by coders that are able to encode raw JSON
+// documents directly, without first parsing them into a structured form.
+type jsonFromCoder interface {
+	fromJSON(b []byte) ([]byte, error)
+}
+
+// jsonToCoder is implemented by coders that are able to decode documents
+// directly into raw JSON.
+type jsonToCoder interface {
+	toJSON(b []byte) ([]byte, error)
+}
+
+//------------------------------------------------------------------------------
+
+type jsonFormat struct{}
+
+func (jsonFormat) newCoder() coder {
+	return jsonFormat{}
+}
+
+func (jsonFormat) decode(b []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, fmt.Errorf("failed to parse message as JSON: %v", err)
+	}
+	return v, nil
+}
+
+func (jsonFormat) encode(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+//------------------------------------------------------------------------------
+
+type msgpackFormat struct{}
+
+func (msgpackFormat) newCoder() coder {
+	c := &msgpackCoder{}
+	c.enc = msgpack.NewEncoder(&c.buf)
+	c.dec = msgpack.NewDecoder(nil)
+	return c
+}
+
+type msgpackCoder struct {
+	buf bytes.Buffer
+	enc *msgpack.Encoder
+	dec *msgpack.Decoder
+}
+
+func (m *msgpackCoder) decode(b []byte) (interface{}, error) {
+	m.dec.Reset(bytes.NewReader(b))
+
+	var v interface{}
+	if err := m.dec.Decode(&v); err != nil {
+		return nil, fmt.Errorf("failed to decode MessagePack document: %v", err)
+	}
+	return v, nil
+}
+
+func (m *msgpackCoder) encode(v interface{}) ([]byte, error) {
+	m.buf.Reset()
+	if err := m.enc.Encode(normaliseNumbers(v)); err != nil {
+		return nil, fmt.Errorf("failed to encode MessagePack document: %v", err)
+	}
+	return append([]byte(nil), m.buf.Bytes()...), nil
+}
+
+// normaliseNumbers replaces json.Number values with the most appropriate
+// native number type, as formats other than JSON have no equivalent.
+func normaliseNumbers(v interface{}) interface{} {
+	switch t := v.(type) {
+	case json.Number:
+		if i, err := strconv.ParseInt(t.String(), 10, 64); err == nil {
+			return i
+		}
+		if u, err := strconv.ParseUint(t.String(), 10, 64); err == nil {
+			return u
+		}
+		if f, err := t.Float64(); err == nil {
+			return f
+		}
+		return t.String()
+	case map[string]interface{}:
+		for k, e := range t {
+			t[k] = normaliseNumbers(e)
+		}
+	case []interface{}:
+		for i, e := range t {
+			t[i] = normaliseNumbers(e)
+		}
+	}
+	return v
+}
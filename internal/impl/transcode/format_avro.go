@@ -0,0 +1,331 @@
+package transcode
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/linkedin/goavro/v2"
+
+	ischema "github.com/benthosdev/benthos/v4/internal/schema"
+)
+
+type avroFormat struct {
+	codec    *goavro.Codec
+	encoding string
+	unwrap   *avroUnwrapper
+}
+
+func newAvroFormat(schema, schemaPath, encoding string) (*avroFormat, error) {
+	if schemaPath != "" {
+		var err error
+		if schema, err = ischema.LoadAvro(schemaPath); err != nil {
+			return nil, fmt.Errorf("failed to load Avro schema definition: %v", err)
+		}
+	}
+	if schema == "" {
+		return nil, fmt.Errorf("an avro schema must be provided with either the schema or schema_path fields")
+	}
+
+	switch encoding {
+	case "binary", "single":
+	default:
+		return nil, fmt.Errorf("avro encoding '%v' not recognised", encoding)
+	}
+
+	codec, err := goavro.NewCodecForStandardJSON(schema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse avro schema: %v", err)
+	}
+
+	unwrap, err := newAvroUnwrapper(schema)
+	if err != nil {
+		return nil, err
+	}
+	return &avroFormat{codec: codec, encoding: encoding, unwrap: unwrap}, nil
+}
+
+// Avro codecs are stateless and therefore shared across batches.
+func (a *avroFormat) newCoder() coder {
+	return a
+}
+
+func (a *avroFormat) decode(b []byte) (interface{}, error) {
+	var native interface{}
+	var err error
+	if a.encoding == "single" {
+		native, _, err = a.codec.NativeFromSingle(b)
+	} else {
+		native, _, err = a.codec.NativeFromBinary(b)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode avro document: %v", err)
+	}
+	return a.unwrap.unwrap(native), nil
+}
+
+func (a *avroFormat) encodeNative(native interface{}) ([]byte, error) {
+	var b []byte
+	var err error
+	if a.encoding == "single" {
+		b, err = a.codec.SingleFromNative(nil, native)
+	} else {
+		b, err = a.codec.BinaryFromNative(nil, native)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode avro document: %v", err)
+	}
+	return b, nil
+}
+
+// Unions are only accepted in their standard JSON form from textual
+// documents, and therefore structured values are encoded via JSON. Fields of
+// structured values that do not exist within the schema are dropped, as these
+// values are most likely decoded from a format with a different schema.
+func (a *avroFormat) encode(v interface{}) ([]byte, error) {
+	jBytes, err := json.Marshal(a.unwrap.prune(v))
+	if err != nil {
+		return nil, err
+	}
+	return a.fromJSON(jBytes)
+}
+
+func (a *avroFormat) fromJSON(b []byte) ([]byte, error) {
+	native, _, err := a.codec.NativeFromTextual(b)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse document against avro schema: %v", err)
+	}
+	return a.encodeNative(native)
+}
+
+//------------------------------------------------------------------------------
+
+// avroUnwrapper walks structured values alongside an Avro schema. Decoded
+// documents are unwrapped by replacing union values, which are decoded as
+// single key objects naming the union branch, with the value of the branch
+// itself. Documents to be encoded are pruned of fields missing from the schema.
+type avroUnwrapper struct {
+	schema interface{}
+	named  map[string]interface{}
+}
+
+func newAvroUnwrapper(schema string) (*avroUnwrapper, error) {
+	u := &avroUnwrapper{named: map[string]interface{}{}}
+	if err := json.Unmarshal([]byte(schema), &u.schema); err != nil {
+		return nil, fmt.Errorf("failed to parse avro schema: %v", err)
+	}
+	u.register(u.schema, "")
+	return u, nil
+}
+
+func avroFullName(name, namespace string) string {
+	if strings.Contains(name, ".") || namespace == "" {
+		return name
+	}
+	return namespace + "." + name
+}
+
+func (u *avroUnwrapper) register(schema interface{}, namespace string) {
+	switch t := schema.(type) {
+	case []interface{}:
+		for _, s := range t {
+			u.register(s, namespace)
+		}
+	case map[string]interface{}:
+		if ns, ok := t["namespace"].(string); ok {
+			namespace = ns
+		}
+		if name, ok := t["name"].(string); ok {
+			fullName := avroFullName(name, namespace)
+			u.named[fullName] = t
+			u.named[name] = t
+			if i := strings.LastIndex(fullName, "."); i > 0 {
+				namespace = fullName[:i]
+			}
+		}
+		switch t["type"] {
+		case "record", "error":
+			fields, _ := t["fields"].([]interface{})
+			for _, f := range fields {
+				if fObj, ok := f.(map[string]interface{}); ok {
+					u.register(fObj["type"], namespace)
+				}
+			}
+		case "array":
+			u.register(t["items"], namespace)
+		case "map":
+			u.register(t["values"], namespace)
+		}
+	}
+}
+
+func (u *avroUnwrapper) unwrap(v interface{}) interface{} {
+	return u.unwrapWith(u.schema, v)
+}
+
+func (u *avroUnwrapper) unwrapWith(schema, v interface{}) interface{} {
+	switch t := schema.(type) {
+	case string:
+		if named, exists := u.named[t]; exists {
+			return u.unwrapWith(named, v)
+		}
+	case []interface{}:
+		obj, ok := v.(map[string]interface{})
+		if !ok || len(obj) != 1 {
+			return v
+		}
+		for branch, inner := range obj {
+			for _, s := range t {
+				if avroTypeName(s) == branch || avroShortName(avroTypeName(s)) == avroShortName(branch) {
+					return u.unwrapWith(s, inner)
+				}
+			}
+			return inner
+		}
+	case map[string]interface{}:
+		switch t["type"] {
+		case "record", "error":
+			obj, ok := v.(map[string]interface{})
+			if !ok {
+				return v
+			}
+			fields, _ := t["fields"].([]interface{})
+			for _, f := range fields {
+				fObj, ok := f.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				name, _ := fObj["name"].(string)
+				if fv, exists := obj[name]; exists {
+					obj[name] = u.unwrapWith(fObj["type"], fv)
+				}
+			}
+			return obj
+		case "array":
+			arr, ok := v.([]interface{})
+			if !ok {
+				return v
+			}
+			for i, e := range arr {
+				arr[i] = u.unwrapWith(t["items"], e)
+			}
+			return arr
+		case "map":
+			obj, ok := v.(map[string]interface{})
+			if !ok {
+				return v
+			}
+			for k, e := range obj {
+				obj[k] = u.unwrapWith(t["values"], e)
+			}
+			return obj
+		}
+	}
+	return v
+}
+
+// prune removes fields from records that are not present within the schema.
+func (u *avroUnwrapper) prune(v interface{}) interface{} {
+	return u.pruneWith(u.schema, v)
+}
+
+func (u *avroUnwrapper) pruneWith(schema, v interface{}) interface{} {
+	switch t := schema.(type) {
+	case string:
+		if named, exists := u.named[t]; exists {
+			return u.pruneWith(named, v)
+		}
+	case []interface{}:
+		if v == nil {
+			return v
+		}
+		// Prune against the first record branch, which is the only case
+		// where fields can be dropped.
+		for _, s := range t {
+			if s = u.resolve(s); avroIsRecord(s) {
+				if _, ok := v.(map[string]interface{}); ok {
+					return u.pruneWith(s, v)
+				}
+			}
+		}
+	case map[string]interface{}:
+		switch t["type"] {
+		case "record", "error":
+			obj, ok := v.(map[string]interface{})
+			if !ok {
+				return v
+			}
+			fields, _ := t["fields"].([]interface{})
+			pruned := make(map[string]interface{}, len(fields))
+			for _, f := range fields {
+				fObj, ok := f.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				name, _ := fObj["name"].(string)
+				if fv, exists := obj[name]; exists {
+					pruned[name] = u.pruneWith(fObj["type"], fv)
+				}
+			}
+			return pruned
+		case "array":
+			arr, ok := v.([]interface{})
+			if !ok {
+				return v
+			}
+			for i, e := range arr {
+				arr[i] = u.pruneWith(t["items"], e)
+			}
+			return arr
+		case "map":
+			obj, ok := v.(map[string]interface{})
+			if !ok {
+				return v
+			}
+			for k, e := range obj {
+				obj[k] = u.pruneWith(t["values"], e)
+			}
+			return obj
+		}
+	}
+	return v
+}
+
+func (u *avroUnwrapper) resolve(schema interface{}) interface{} {
+	if name, ok := schema.(string); ok {
+		if named, exists := u.named[name]; exists {
+			return named
+		}
+	}
+	return schema
+}
+
+func avroIsRecord(schema interface{}) bool {
+	obj, ok := schema.(map[string]interface{})
+	return ok && (obj["type"] == "record" || obj["type"] == "error")
+}
+
+func avroTypeName(schema interface{}) string {
+	switch t := schema.(type) {
+	case string:
+		return t
+	case map[string]interface{}:
+		if name, ok := t["name"].(string); ok {
+			if ns, ok := t["namespace"].(string); ok {
+				return avroFullName(name, ns)
+			}
+			return name
+		}
+		if typ, ok := t["type"].(string); ok {
+			return typ
+		}
+	}
+	return ""
+}
+
+func avroShortName(name string) string {
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		return name[i+1:]
+	}
+	return name
+}
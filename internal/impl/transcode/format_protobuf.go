@@ -0,0 +1,423 @@
+package transcode
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/jhump/protoreflect/desc"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/benthosdev/benthos/v4/internal/schema"
+)
+
+type protobufFormat struct {
+	desc protoreflect.MessageDescriptor
+}
+
+func newProtobufFormat(message string, importPaths []string) (*protobufFormat, error) {
+	if message == "" {
+		return nil, errors.New("a protobuf message must be provided with the message field")
+	}
+
+	fds, err := schema.LoadProtoDescriptors(importPaths)
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := protodesc.NewFiles(desc.ToFileDescriptorSet(fds...))
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve .proto files: %v", err)
+	}
+
+	d, err := files.FindDescriptorByName(protoreflect.FullName(message))
+	if err != nil {
+		return nil, fmt.Errorf("unable to find message '%v' definition within '%v'", message, importPaths)
+	}
+	md, ok := d.(protoreflect.MessageDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("definition '%v' is not a message", message)
+	}
+	return &protobufFormat{desc: md}, nil
+}
+
+func (p *protobufFormat) newCoder() coder {
+	return &protobufCoder{
+		msg: dynamicpb.NewMessage(p.desc),
+		marshalJSON: protojson.MarshalOptions{
+			UseProtoNames:   true,
+			EmitUnpopulated: true,
+		},
+	}
+}
+
+// protobufCoder reuses a single dynamic message for all documents of a batch.
+type protobufCoder struct {
+	msg         *dynamicpb.Message
+	marshalJSON protojson.MarshalOptions
+}
+
+func (p *protobufCoder) decode(b []byte) (interface{}, error) {
+	if err := proto.Unmarshal(b, p.msg); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal protobuf message: %w", err)
+	}
+	return protoToStructured(p.msg), nil
+}
+
+func (p *protobufCoder) encode(v interface{}) ([]byte, error) {
+	p.msg.Reset()
+	if err := structuredToProto(v, p.msg); err != nil {
+		return nil, err
+	}
+	return proto.Marshal(p.msg)
+}
+
+func (p *protobufCoder) toJSON(b []byte) ([]byte, error) {
+	if err := proto.Unmarshal(b, p.msg); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal protobuf message: %w", err)
+	}
+	return p.marshalJSON.Marshal(p.msg)
+}
+
+func (p *protobufCoder) fromJSON(b []byte) ([]byte, error) {
+	if err := protojson.Unmarshal(b, p.msg); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal JSON message: %w", err)
+	}
+	return proto.Marshal(p.msg)
+}
+
+//------------------------------------------------------------------------------
+
+// protoToStructured converts a protobuf message into a generic structured
+// form keyed by field names. Unlike the canonical JSON mapping 64-bit integers
+// remain numbers and bytes remain raw, as the target format is free to
+// represent them natively.
+func protoToStructured(m protoreflect.Message) map[string]interface{} {
+	fields := m.Descriptor().Fields()
+	obj := make(map[string]interface{}, fields.Len())
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		if (fd.ContainingOneof() != nil || fd.Kind() == protoreflect.MessageKind || fd.Kind() == protoreflect.GroupKind) &&
+			!fd.IsList() && !fd.IsMap() && !m.Has(fd) {
+			continue
+		}
+		v := m.Get(fd)
+		switch {
+		case fd.IsList():
+			l := v.List()
+			arr := make([]interface{}, l.Len())
+			for j := 0; j < l.Len(); j++ {
+				arr[j] = protoValueToStructured(fd, l.Get(j))
+			}
+			obj[string(fd.Name())] = arr
+		case fd.IsMap():
+			mObj := map[string]interface{}{}
+			v.Map().Range(func(k protoreflect.MapKey, mv protoreflect.Value) bool {
+				mObj[k.String()] = protoValueToStructured(fd.MapValue(), mv)
+				return true
+			})
+			obj[string(fd.Name())] = mObj
+		default:
+			obj[string(fd.Name())] = protoValueToStructured(fd, v)
+		}
+	}
+	return obj
+}
+
+func protoValueToStructured(fd protoreflect.FieldDescriptor, v protoreflect.Value) interface{} {
+	switch fd.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return protoToStructured(v.Message())
+	case protoreflect.EnumKind:
+		if ev := fd.Enum().Values().ByNumber(v.Enum()); ev != nil {
+			return string(ev.Name())
+		}
+		return int32(v.Enum())
+	}
+	return v.Interface()
+}
+
+// structuredToProto populates a protobuf message from a generic structured
+// form, where fields are referenced by either their name or JSON name.
+func structuredToProto(v interface{}, m protoreflect.Message) error {
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("expected object value for message %v, got %T", m.Descriptor().FullName(), v)
+	}
+
+	fields := m.Descriptor().Fields()
+	for k, fv := range obj {
+		fd := fields.ByName(protoreflect.Name(k))
+		if fd == nil {
+			if fd = fields.ByJSONName(k); fd == nil {
+				return fmt.Errorf("field %v not found in message %v", k, m.Descriptor().FullName())
+			}
+		}
+		if fv == nil {
+			continue
+		}
+
+		var err error
+		switch {
+		case fd.IsList():
+			err = structuredToProtoList(fd, fv, m.Mutable(fd).List())
+		case fd.IsMap():
+			err = structuredToProtoMap(fd, fv, m.Mutable(fd).Map())
+		case fd.Kind() == protoreflect.MessageKind || fd.Kind() == protoreflect.GroupKind:
+			err = structuredToProto(fv, m.Mutable(fd).Message())
+		default:
+			var pv protoreflect.Value
+			if pv, err = structuredToProtoScalar(fd, fv); err == nil {
+				m.Set(fd, pv)
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("field %v: %w", k, err)
+		}
+	}
+	return nil
+}
+
+func structuredToProtoList(fd protoreflect.FieldDescriptor, v interface{}, l protoreflect.List) error {
+	arr, ok := v.([]interface{})
+	if !ok {
+		return fmt.Errorf("expected array value, got %T", v)
+	}
+	for _, e := range arr {
+		if fd.Kind() == protoreflect.MessageKind || fd.Kind() == protoreflect.GroupKind {
+			ev := l.NewElement()
+			if err := structuredToProto(e, ev.Message()); err != nil {
+				return err
+			}
+			l.Append(ev)
+			continue
+		}
+		ev, err := structuredToProtoScalar(fd, e)
+		if err != nil {
+			return err
+		}
+		l.Append(ev)
+	}
+	return nil
+}
+
+func structuredToProtoMap(fd protoreflect.FieldDescriptor, v interface{}, mp protoreflect.Map) error {
+	obj, ok := v.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("expected object value, got %T", v)
+	}
+	for k, e := range obj {
+		kv, err := structuredToProtoScalar(fd.MapKey(), k)
+		if err != nil {
+			return err
+		}
+		vd := fd.MapValue()
+		if vd.Kind() == protoreflect.MessageKind || vd.Kind() == protoreflect.GroupKind {
+			ev := mp.NewValue()
+			if err := structuredToProto(e, ev.Message()); err != nil {
+				return err
+			}
+			mp.Set(kv.MapKey(), ev)
+			continue
+		}
+		ev, err := structuredToProtoScalar(vd, e)
+		if err != nil {
+			return err
+		}
+		mp.Set(kv.MapKey(), ev)
+	}
+	return nil
+}
+
+func structuredToProtoScalar(fd protoreflect.FieldDescriptor, v interface{}) (protoreflect.Value, error) {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		switch t := v.(type) {
+		case bool:
+			return protoreflect.ValueOfBool(t), nil
+		case string:
+			b, err := strconv.ParseBool(t)
+			if err != nil {
+				return protoreflect.Value{}, err
+			}
+			return protoreflect.ValueOfBool(b), nil
+		}
+	case protoreflect.StringKind:
+		switch t := v.(type) {
+		case string:
+			return protoreflect.ValueOfString(t), nil
+		case []byte:
+			return protoreflect.ValueOfString(string(t)), nil
+		}
+	case protoreflect.BytesKind:
+		switch t := v.(type) {
+		case []byte:
+			return protoreflect.ValueOfBytes(t), nil
+		case string:
+			b, err := base64.StdEncoding.DecodeString(t)
+			if err != nil {
+				return protoreflect.Value{}, fmt.Errorf("failed to decode base64 bytes: %w", err)
+			}
+			return protoreflect.ValueOfBytes(b), nil
+		}
+	case protoreflect.EnumKind:
+		if s, ok := v.(string); ok {
+			ev := fd.Enum().Values().ByName(protoreflect.Name(s))
+			if ev == nil {
+				return protoreflect.Value{}, fmt.Errorf("enum value %v not found in %v", s, fd.Enum().FullName())
+			}
+			return protoreflect.ValueOfEnum(ev.Number()), nil
+		}
+		i, err := toInt64(v, math.MinInt32, math.MaxInt32)
+		if err != nil {
+			return protoreflect.Value{}, err
+		}
+		return protoreflect.ValueOfEnum(protoreflect.EnumNumber(i)), nil
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		i, err := toInt64(v, math.MinInt32, math.MaxInt32)
+		if err != nil {
+			return protoreflect.Value{}, err
+		}
+		return protoreflect.ValueOfInt32(int32(i)), nil
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		i, err := toInt64(v, math.MinInt64, math.MaxInt64)
+		if err != nil {
+			return protoreflect.Value{}, err
+		}
+		return protoreflect.ValueOfInt64(i), nil
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		u, err := toUint64(v, math.MaxUint32)
+		if err != nil {
+			return protoreflect.Value{}, err
+		}
+		return protoreflect.ValueOfUint32(uint32(u)), nil
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		u, err := toUint64(v, math.MaxUint64)
+		if err != nil {
+			return protoreflect.Value{}, err
+		}
+		return protoreflect.ValueOfUint64(u), nil
+	case protoreflect.FloatKind:
+		f, err := toFloat64(v)
+		if err != nil {
+			return protoreflect.Value{}, err
+		}
+		return protoreflect.ValueOfFloat32(float32(f)), nil
+	case protoreflect.DoubleKind:
+		f, err := toFloat64(v)
+		if err != nil {
+			return protoreflect.Value{}, err
+		}
+		return protoreflect.ValueOfFloat64(f), nil
+	}
+	return protoreflect.Value{}, fmt.Errorf("cannot convert %T to %v", v, fd.Kind())
+}
+
+func toInt64(v interface{}, min, max int64) (int64, error) {
+	var i int64
+	switch t := v.(type) {
+	case int:
+		i = int64(t)
+	case int8:
+		i = int64(t)
+	case int16:
+		i = int64(t)
+	case int32:
+		i = int64(t)
+	case int64:
+		i = t
+	case uint:
+		if uint64(t) > math.MaxInt64 {
+			return 0, fmt.Errorf("value %v overflows integer", t)
+		}
+		i = int64(t)
+	case uint8:
+		i = int64(t)
+	case uint16:
+		i = int64(t)
+	case uint32:
+		i = int64(t)
+	case uint64:
+		if t > math.MaxInt64 {
+			return 0, fmt.Errorf("value %v overflows integer", t)
+		}
+		i = int64(t)
+	case float32, float64, json.Number, string:
+		s := fmt.Sprintf("%v", t)
+		var err error
+		if i, err = strconv.ParseInt(s, 10, 64); err != nil {
+			f, ferr := strconv.ParseFloat(s, 64)
+			if ferr != nil || f != math.Trunc(f) || f < math.MinInt64 || f > math.MaxInt64 {
+				return 0, fmt.Errorf("expected integer value, got %v", s)
+			}
+			i = int64(f)
+		}
+	default:
+		return 0, fmt.Errorf("expected integer value, got %T", v)
+	}
+	if i < min || i > max {
+		return 0, fmt.Errorf("value %v overflows integer", i)
+	}
+	return i, nil
+}
+
+func toUint64(v interface{}, max uint64) (uint64, error) {
+	var u uint64
+	switch t := v.(type) {
+	case uint:
+		u = uint64(t)
+	case uint8:
+		u = uint64(t)
+	case uint16:
+		u = uint64(t)
+	case uint32:
+		u = uint64(t)
+	case uint64:
+		u = t
+	case float32, float64, json.Number, string:
+		s := fmt.Sprintf("%v", t)
+		var err error
+		if u, err = strconv.ParseUint(s, 10, 64); err != nil {
+			f, ferr := strconv.ParseFloat(s, 64)
+			if ferr != nil || f != math.Trunc(f) || f < 0 || f > math.MaxUint64 {
+				return 0, fmt.Errorf("expected unsigned integer value, got %v", s)
+			}
+			u = uint64(f)
+		}
+	default:
+		i, err := toInt64(v, 0, math.MaxInt64)
+		if err != nil {
+			return 0, err
+		}
+		u = uint64(i)
+	}
+	if u > max {
+		return 0, fmt.Errorf("value %v overflows integer", u)
+	}
+	return u, nil
+}
+
+func toFloat64(v interface{}) (float64, error) {
+	switch t := v.(type) {
+	case float64:
+		return t, nil
+	case float32:
+		return float64(t), nil
+	case json.Number:
+		return t.Float64()
+	case string:
+		return strconv.ParseFloat(t, 64)
+	}
+	i, err := toInt64(v, math.MinInt64, math.MaxInt64)
+	if err != nil {
+		return 0, fmt.Errorf("expected number value, got %T", v)
+	}
+	return float64(i), nil
+}
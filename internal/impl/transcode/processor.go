@@ -0,0 +1,227 @@
+package transcode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const (
+	tcFieldFrom                = "from"
+	tcFieldTo                  = "to"
+	tcFieldAvro                = "avro"
+	tcFieldAvroSchema          = "schema"
+	tcFieldAvroSchemaPath      = "schema_path"
+	tcFieldAvroEncoding        = "encoding"
+	tcFieldProtobuf            = "protobuf"
+	tcFieldProtobufMessage     = "message"
+	tcFieldProtobufImportPaths = "import_paths"
+)
+
+var tcFormats = map[string]string{
+	"json":     "JSON documents.",
+	"avro":     "Avro documents described by the schema of the `avro` field.",
+	"protobuf": "Protobuf messages described by the message of the `protobuf` field.",
+	"msgpack":  "[MessagePack](https://msgpack.org/) documents.",
+}
+
+func processorConfig() *service.ConfigSpec {
+	return service.NewConfigSpec().
+		Beta().
+		Categories("Parsing").
+		Summary("Converts batches of messages between serialisation formats, where the schemas of the formats are compiled once and reused for every message.").
+		Description(`
+Messages are converted from the format of the field `+"`from`"+` to the format of the field `+"`to`"+`. Schema based formats require a schema for the processor to be created, and therefore the `+"`avro`"+` field must be set when converting to or from Avro, and the `+"`protobuf`"+` field must be set when converting to or from Protobuf.
+
+Each batch is converted with a single set of encoders and decoders, where Protobuf messages and MessagePack buffers are reused across the messages of the batch rather than allocated for each. Conversions from JSON to Protobuf or Avro, and from Protobuf to JSON, are made directly without an intermediate structured form.
+
+### Field Names
+
+Protobuf fields are referenced by their names as written in the `+"`.proto`"+` file rather than the lowerCamelCase form of the canonical JSON mapping, which means Avro records and Protobuf messages with matching field names can be converted between each other. JSON and MessagePack documents may reference fields by either form. Fields that do not exist within the Avro schema are dropped when converting to Avro from formats other than JSON.
+
+### Error Handling
+
+Messages that fail to be converted remain unchanged and are flagged as having failed, allowing you to use [standard processor error handling patterns](/docs/configuration/error_handling).`).
+		Field(service.NewStringAnnotatedEnumField(tcFieldFrom, tcFormats).
+			Description("The format to convert messages from.")).
+		Field(service.NewStringAnnotatedEnumField(tcFieldTo, tcFormats).
+			Description("The format to convert messages to.")).
+		Field(service.NewObjectField(tcFieldAvro,
+			service.NewStringField(tcFieldAvroSchema).
+				Description("A full Avro schema to use.").
+				Default(""),
+			service.NewStringField(tcFieldAvroSchemaPath).
+				Description("The path of a schema document to apply. Use either this or the `schema` field.").
+				Example("file://path/to/spec.avsc").
+				Example("http://localhost:8081/path/to/spec/versions/1").
+				Default(""),
+			service.NewStringEnumField(tcFieldAvroEncoding, "binary", "single").
+				Description("The Avro encoding of documents, where `single` is the single-object encoding that prefixes documents with a fingerprint of their schema.").
+				Advanced().
+				Default("binary"),
+		).Description("The schema of Avro documents.").Optional()).
+		Field(service.NewObjectField(tcFieldProtobuf,
+			service.NewStringField(tcFieldProtobufMessage).
+				Description("The fully qualified name of the protobuf message to convert to/from.").
+				Example("testing.Person"),
+			service.NewStringListField(tcFieldProtobufImportPaths).
+				Description("A list of directories containing .proto files, including all definitions required for parsing the target message. If left empty the current directory is used. Each directory listed will be walked with all found .proto files imported.").
+				Default([]string{}),
+		).Description("The schema of Protobuf messages.").Optional()).
+		Example("Avro to Protobuf", `
+Given a stream of binary Avro documents, we can convert them into Protobuf messages of a type with matching field names:`, `
+pipeline:
+  processors:
+    - transcode:
+        from: avro
+        to: protobuf
+        avro:
+          schema_path: file://schemas/person.avsc
+        protobuf:
+          message: testing.Person
+          import_paths: [ schemas ]
+`).
+		Example("JSON to MessagePack", `
+Formats without schemas require no additional fields:`, `
+pipeline:
+  processors:
+    - transcode:
+        from: json
+        to: msgpack
+`)
+}
+
+func init() {
+	err := service.RegisterBatchProcessor(
+		"transcode", processorConfig(),
+		func(conf *service.ParsedConfig, mgr *service.Resources) (service.BatchProcessor, error) {
+			return newProcessorFromConfig(conf, mgr.Logger())
+		})
+	if err != nil {
+		panic(err)
+	}
+}
+
+//------------------------------------------------------------------------------
+
+type processor struct {
+	from, to format
+	fromJSON bool
+	toJSON   bool
+	log      *service.Logger
+}
+
+func newProcessorFromConfig(conf *service.ParsedConfig, log *service.Logger) (*processor, error) {
+	fromStr, err := conf.FieldString(tcFieldFrom)
+	if err != nil {
+		return nil, err
+	}
+	toStr, err := conf.FieldString(tcFieldTo)
+	if err != nil {
+		return nil, err
+	}
+	if fromStr == toStr {
+		return nil, fmt.Errorf("the from and to formats must differ, both are %v", fromStr)
+	}
+
+	p := &processor{
+		fromJSON: fromStr == "json",
+		toJSON:   toStr == "json",
+		log:      log,
+	}
+	if p.from, err = formatFromConfig(fromStr, conf); err != nil {
+		return nil, err
+	}
+	if p.to, err = formatFromConfig(toStr, conf); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func formatFromConfig(name string, conf *service.ParsedConfig) (format, error) {
+	switch name {
+	case "json":
+		return jsonFormat{}, nil
+	case "msgpack":
+		return msgpackFormat{}, nil
+	case "avro":
+		if !conf.Contains(tcFieldAvro) {
+			return nil, errors.New("the avro field must be set when converting to or from avro")
+		}
+		aConf := conf.Namespace(tcFieldAvro)
+		schema, err := aConf.FieldString(tcFieldAvroSchema)
+		if err != nil {
+			return nil, err
+		}
+		schemaPath, err := aConf.FieldString(tcFieldAvroSchemaPath)
+		if err != nil {
+			return nil, err
+		}
+		encoding, err := aConf.FieldString(tcFieldAvroEncoding)
+		if err != nil {
+			return nil, err
+		}
+		return newAvroFormat(schema, schemaPath, encoding)
+	case "protobuf":
+		if !conf.Contains(tcFieldProtobuf) {
+			return nil, errors.New("the protobuf field must be set when converting to or from protobuf")
+		}
+		pConf := conf.Namespace(tcFieldProtobuf)
+		message, err := pConf.FieldString(tcFieldProtobufMessage)
+		if err != nil {
+			return nil, err
+		}
+		importPaths, err := pConf.FieldStringList(tcFieldProtobufImportPaths)
+		if err != nil {
+			return nil, err
+		}
+		return newProtobufFormat(message, importPaths)
+	}
+	return nil, fmt.Errorf("format not recognised: %v", name)
+}
+
+// newConverter returns a function that converts documents for the duration of
+// a single batch.
+func (p *processor) newConverter() func(b []byte) ([]byte, error) {
+	dec, enc := p.from.newCoder(), p.to.newCoder()
+
+	if jEnc, ok := enc.(jsonFromCoder); ok && p.fromJSON {
+		return jEnc.fromJSON
+	}
+	if jDec, ok := dec.(jsonToCoder); ok && p.toJSON {
+		return jDec.toJSON
+	}
+	return func(b []byte) ([]byte, error) {
+		v, err := dec.decode(b)
+		if err != nil {
+			return nil, err
+		}
+		return enc.encode(v)
+	}
+}
+
+func (p *processor) ProcessBatch(ctx context.Context, batch service.MessageBatch) ([]service.MessageBatch, error) {
+	convert := p.newConverter()
+
+	batch = batch.Copy()
+	for _, msg := range batch {
+		b, err := msg.AsBytes()
+		if err != nil {
+			msg.SetError(err)
+			continue
+		}
+		if b, err = convert(b); err != nil {
+			p.log.Debugf("Failed to transcode message: %v", err)
+			msg.SetError(err)
+			continue
+		}
+		msg.SetBytes(b)
+	}
+	return []service.MessageBatch{batch}, nil
+}
+
+func (p *processor) Close(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,206 @@
+package transcode
+
+import (
+	"context"
+	"testing"
+
+	"github.com/linkedin/goavro/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmihailenco/msgpack/v5"
+
+	"github.com/benthosdev/benthos/v4/public/service"
+)
+
+const testAvroSchema = `{
+  "type": "record",
+  "name": "Person",
+  "fields": [
+    { "name": "first_name", "type": "string" },
+    { "name": "age", "type": "int" },
+    { "name": "email", "type": ["null", "string"], "default": null }
+  ]
+}`
+
+func testProcessor(t *testing.T, from, to string) *processor {
+	t.Helper()
+
+	conf, err := processorConfig().ParseYAML(`
+from: `+from+`
+to: `+to+`
+avro:
+  schema: '`+testAvroSchema+`'
+protobuf:
+  message: testing.Person
+  import_paths: [ ../../../config/test/protobuf/schema ]
+`, nil)
+	require.NoError(t, err)
+
+	p, err := newProcessorFromConfig(conf, nil)
+	require.NoError(t, err)
+	return p
+}
+
+func testTranscode(t *testing.T, p *processor, inputs ...[]byte) [][]byte {
+	t.Helper()
+
+	var batch service.MessageBatch
+	for _, in := range inputs {
+		batch = append(batch, service.NewMessage(in))
+	}
+
+	res, err := p.ProcessBatch(context.Background(), batch)
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	require.Len(t, res[0], len(inputs))
+
+	var outputs [][]byte
+	for _, msg := range res[0] {
+		require.NoError(t, msg.GetError())
+		b, err := msg.AsBytes()
+		require.NoError(t, err)
+		outputs = append(outputs, b)
+	}
+	return outputs
+}
+
+func TestTranscodeRoundTrips(t *testing.T) {
+	inputs := [][]byte{
+		[]byte(`{"first_name":"caleb","age":30,"email":"caleb@myspace.com"}`),
+		[]byte(`{"first_name":"ash","age":25}`),
+	}
+
+	chains := [][]string{
+		{"json", "protobuf", "json"},
+		{"json", "avro", "json"},
+		{"json", "msgpack", "json"},
+		{"json", "avro", "protobuf", "msgpack", "avro", "json"},
+		{"json", "protobuf", "avro", "msgpack", "protobuf", "json"},
+	}
+
+	for _, chain := range chains {
+		chain := chain
+		t.Run(chain[0]+"_via_"+chain[1], func(t *testing.T) {
+			docs := inputs
+			for i := 1; i < len(chain); i++ {
+				docs = testTranscode(t, testProcessor(t, chain[i-1], chain[i]), docs...)
+			}
+			require.Len(t, docs, 2)
+
+			for i, exp := range []string{
+				`{"first_name":"caleb","age":30,"email":"caleb@myspace.com"}`,
+				`{"first_name":"ash","age":25}`,
+			} {
+				var actual, expected map[string]interface{}
+				require.NoError(t, unmarshalNormalisedJSON(docs[i], &actual))
+				require.NoError(t, unmarshalNormalisedJSON([]byte(exp), &expected))
+
+				// Formats with schemas emit fields that are unset within
+				// the source document.
+				for k, v := range expected {
+					assert.Equal(t, v, actual[k], k)
+				}
+			}
+		})
+	}
+}
+
+func unmarshalNormalisedJSON(b []byte, v *map[string]interface{}) error {
+	d, err := jsonFormat{}.decode(b)
+	if err != nil {
+		return err
+	}
+	*v = map[string]interface{}{}
+	for k, e := range normaliseNumbers(d).(map[string]interface{}) {
+		(*v)[k] = e
+	}
+	return nil
+}
+
+func TestTranscodeAvroUnions(t *testing.T) {
+	codec, err := goavro.NewCodec(testAvroSchema)
+	require.NoError(t, err)
+
+	b, err := codec.BinaryFromNative(nil, map[string]interface{}{
+		"first_name": "caleb",
+		"age":        30,
+		"email":      goavro.Union("string", "caleb@myspace.com"),
+	})
+	require.NoError(t, err)
+
+	out := testTranscode(t, testProcessor(t, "avro", "msgpack"), b)
+
+	var actual map[string]interface{}
+	require.NoError(t, msgpack.Unmarshal(out[0], &actual))
+	assert.Equal(t, map[string]interface{}{
+		"first_name": "caleb",
+		"age":        int32(30),
+		"email":      "caleb@myspace.com",
+	}, actual)
+}
+
+func TestTranscodeProtobufFieldNames(t *testing.T) {
+	out := testTranscode(t, testProcessor(t, "json", "protobuf"), []byte(`{"firstName":"caleb","last_name":"quaye"}`))
+	out = testTranscode(t, testProcessor(t, "protobuf", "json"), out...)
+
+	assert.JSONEq(t, `{
+  "first_name": "caleb",
+  "last_name": "quaye",
+  "full_name": "",
+  "age": 0,
+  "id": 0,
+  "email": "",
+  "last_updated": null
+}`, string(out[0]))
+}
+
+func TestTranscodeErrors(t *testing.T) {
+	p := testProcessor(t, "json", "protobuf")
+
+	res, err := p.ProcessBatch(context.Background(), service.MessageBatch{
+		service.NewMessage([]byte(`{"first_name":"caleb"}`)),
+		service.NewMessage([]byte(`{"nope":"caleb"}`)),
+		service.NewMessage([]byte(`not json`)),
+	})
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	require.Len(t, res[0], 3)
+
+	assert.NoError(t, res[0][0].GetError())
+	assert.Error(t, res[0][1].GetError())
+	assert.Error(t, res[0][2].GetError())
+
+	b, err := res[0][2].AsBytes()
+	require.NoError(t, err)
+	assert.Equal(t, "not json", string(b))
+}
+
+func TestTranscodeConfigErrors(t *testing.T) {
+	for _, yamlStr := range []string{
+		`
+from: json
+to: json
+`,
+		`
+from: json
+to: avro
+`,
+		`
+from: protobuf
+to: json
+`,
+		`
+from: json
+to: protobuf
+protobuf:
+  message: testing.Nope
+  import_paths: [ ../../../config/test/protobuf/schema ]
+`,
+	} {
+		conf, err := processorConfig().ParseYAML(yamlStr, nil)
+		require.NoError(t, err)
+
+		_, err = newProcessorFromConfig(conf, nil)
+		assert.Error(t, err, yamlStr)
+	}
+}
@@ -14,20 +14,42 @@ func Spec() docs.FieldSpecs {
 	return docs.FieldSpecs{
 		docs.FieldString("level", "Set the minimum severity level for emitting logs.").HasOptions(
 			"OFF", "FATAL", "ERROR", "WARN", "INFO", "DEBUG", "TRACE", "ALL", "NONE",
-		).HasDefault("INFO").LinterFunc(nil),
+		).IsTunable().HasDefault("INFO").LinterFunc(nil),
 		docs.FieldString("format", "Set the format of emitted logs.").HasOptions("json", "logfmt").HasDefault("logfmt"),
 		docs.FieldBool("add_timestamp", "Whether to include timestamps in logs.").HasDefault(false),
 		docs.FieldString("static_fields", "A map of key/value pairs to add to each structured log.").Map().HasDefault(map[string]string{
 			"@service": "benthos",
 		}),
+		docs.FieldInterpolatedString("message_fields", "A map of key/value pairs to add to logs emitted in relation to a message, where values are resolved from each message as it is consumed by an input. This is useful for correlating logs with specific records, for example by adding a tenant or correlation ID from the metadata of messages.", map[string]string{
+			"correlation_id": `${! meta("correlation_id") }`,
+		}).Map().HasDefault(map[string]string{}).Advanced(),
 		docs.FieldObject("file", "Experimental: Specify fields for optionally writing logs to a file.").WithChildren(
 			docs.FieldString("path", "The file path to write logs to, if the file does not exist it will be created. Leave this field empty or unset to disable file based logging.").HasDefault(""),
 			docs.FieldBool("rotate", "Whether to rotate log files automatically.").HasDefault(false),
 			docs.FieldInt("rotate_max_age_days", "The maximum number of days to retain old log files based on the timestamp encoded in their filename, after which they are deleted. Setting to zero disables this mechanism.").HasDefault(0),
+			targetLevelField(),
 		),
+		docs.FieldObject("syslog", "Specify fields for optionally writing logs to a syslog daemon in addition to the standard output or file. This target is not supported on Windows.").WithChildren(
+			docs.FieldBool("enabled", "Whether logs should be written to syslog.").HasDefault(false),
+			docs.FieldString("network", "The network of the syslog daemon, such as `udp` or `tcp`. Leave this field and `address` empty in order to connect to the local syslog daemon.").HasDefault(""),
+			docs.FieldString("address", "The address of the syslog daemon.", "localhost:514").HasDefault(""),
+			docs.FieldString("tag", "A tag to add to each log written to syslog.").HasDefault("benthos"),
+			targetLevelField(),
+		).Advanced(),
+		docs.FieldObject("ring_buffer", "Specify fields for optionally keeping the most recent logs in memory in addition to the standard output or file. These logs can be obtained from the HTTP endpoint `/debug/logs`, which is useful for debugging deployments where the output of the process is not easily accessible.").WithChildren(
+			docs.FieldBool("enabled", "Whether the most recent logs should be kept in memory.").HasDefault(false),
+			docs.FieldInt("capacity", "The maximum number of logs to keep in memory, once exceeded the oldest logs are discarded.").HasDefault(1000),
+			targetLevelField(),
+		).Advanced(),
 	}
 }
 
+func targetLevelField() docs.FieldSpec {
+	return docs.FieldString("level", "An optional minimum severity level for emitting logs to this target, overriding the top level `level` field. Leave this field empty in order to use the top level `level` field.").HasOptions(
+		"OFF", "FATAL", "ERROR", "WARN", "INFO", "DEBUG", "TRACE", "ALL", "NONE",
+	).HasDefault("").LinterFunc(nil)
+}
+
 //go:embed docs.md
 var loggerDocs string
 
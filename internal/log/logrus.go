@@ -2,20 +2,25 @@ package log
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"strings"
+	"sync"
 
 	"github.com/sirupsen/logrus"
 )
 
 // Config holds configuration options for a logger object.
 type Config struct {
-	LogLevel     string            `json:"level" yaml:"level"`
-	Format       string            `json:"format" yaml:"format"`
-	AddTimeStamp bool              `json:"add_timestamp" yaml:"add_timestamp"`
-	StaticFields map[string]string `json:"static_fields" yaml:"static_fields"`
-	File         File              `json:"file" yaml:"file"`
+	LogLevel      string            `json:"level" yaml:"level"`
+	Format        string            `json:"format" yaml:"format"`
+	AddTimeStamp  bool              `json:"add_timestamp" yaml:"add_timestamp"`
+	StaticFields  map[string]string `json:"static_fields" yaml:"static_fields"`
+	MessageFields map[string]string `json:"message_fields" yaml:"message_fields"`
+	File          File              `json:"file" yaml:"file"`
+	Syslog        Syslog            `json:"syslog" yaml:"syslog"`
+	RingBuffer    RingBuffer        `json:"ring_buffer" yaml:"ring_buffer"`
 }
 
 // File contains configuration for file based logging.
@@ -23,6 +28,24 @@ type File struct {
 	Path         string `json:"path" yaml:"path"`
 	Rotate       bool   `json:"rotate" yaml:"rotate"`
 	RotateMaxAge int    `json:"rotate_max_age_days" yaml:"rotate_max_age_days"`
+	Level        string `json:"level" yaml:"level"`
+}
+
+// Syslog contains configuration for writing logs to a syslog daemon.
+type Syslog struct {
+	Enabled bool   `json:"enabled" yaml:"enabled"`
+	Network string `json:"network" yaml:"network"`
+	Address string `json:"address" yaml:"address"`
+	Tag     string `json:"tag" yaml:"tag"`
+	Level   string `json:"level" yaml:"level"`
+}
+
+// RingBuffer contains configuration for keeping the most recent logs in
+// memory.
+type RingBuffer struct {
+	Enabled  bool   `json:"enabled" yaml:"enabled"`
+	Capacity int    `json:"capacity" yaml:"capacity"`
+	Level    string `json:"level" yaml:"level"`
 }
 
 // NewConfig returns a config struct with the default values for each field.
@@ -34,6 +57,13 @@ func NewConfig() Config {
 		StaticFields: map[string]string{
 			"@service": "benthos",
 		},
+		MessageFields: map[string]string{},
+		Syslog: Syslog{
+			Tag: "benthos",
+		},
+		RingBuffer: RingBuffer{
+			Capacity: 1000,
+		},
 	}
 }
 
@@ -84,54 +114,175 @@ func (conf *Config) UnmarshalYAML(unmarshal func(interface{}) error) error {
 
 // Logger is an object with support for levelled logging and modular components.
 type Logger struct {
-	entry *logrus.Entry
+	entry      *logrus.Entry
+	ringBuffer *RingBufferTarget
+
+	// Targets that inherit the top level log level, and are therefore
+	// modified by SetLevel.
+	levelMut        *sync.Mutex
+	levelTargets    []*targetHook
+	overrideTargets []*targetHook
 }
 
 // NewV2 returns a new logger from a config, or returns an error if the config
 // is invalid.
 func NewV2(stream io.Writer, config Config) (Modular, error) {
-	logger := logrus.New()
-	logger.Out = stream
+	return New(stream, config)
+}
 
+// New returns a new logger from a config, or returns an error if the config
+// is invalid. Logs are written to the provided stream unless a file path is
+// configured, in which case they are written to that file instead. Logs are
+// also written to any additional targets that are enabled within the config,
+// each at their own level.
+func New(stream io.Writer, config Config) (*Logger, error) {
+	var formatter logrus.Formatter
 	switch config.Format {
 	case "json":
-		logger.SetFormatter(&logrus.JSONFormatter{
+		formatter = &logrus.JSONFormatter{
 			DisableTimestamp: !config.AddTimeStamp,
-		})
+		}
 	case "logfmt":
-		logger.SetFormatter(&logrus.TextFormatter{
+		formatter = &logrus.TextFormatter{
 			DisableTimestamp: !config.AddTimeStamp,
 			QuoteEmptyFields: true,
-		})
+		}
 	default:
 		return nil, fmt.Errorf("log format '%v' not recognized", config.Format)
 	}
 
-	switch strings.ToUpper(config.LogLevel) {
+	level := parseLevel(config.LogLevel, logrus.InfoLevel)
+
+	var levelTargets, overrideTargets []*targetHook
+	addTarget := func(t *targetHook, override string) *targetHook {
+		if _, ok := levelFromString(override); ok {
+			overrideTargets = append(overrideTargets, t)
+		} else {
+			levelTargets = append(levelTargets, t)
+		}
+		return t
+	}
+	targetLevel := func(override string) logrus.Level {
+		return parseLevel(override, level)
+	}
+
+	streamLevel, streamOverride := level, ""
+	if config.File.Path != "" {
+		var err error
+		if stream, err = newFileWriter(config.File); err != nil {
+			return nil, fmt.Errorf("failed to open log file: %w", err)
+		}
+		streamLevel, streamOverride = targetLevel(config.File.Level), config.File.Level
+	}
+
+	logger := logrus.New()
+
+	// All logs are written via hooks so that each target can apply its own
+	// level, the logger itself is therefore set to the most verbose level of
+	// all targets.
+	logger.Out = io.Discard
+	logger.SetFormatter(discardFormatter{})
+
+	targets := []*targetHook{addTarget(newTargetHook(stream, formatter, streamLevel), streamOverride)}
+
+	if config.Syslog.Enabled {
+		write, err := newSyslogWriter(config.Syslog)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to syslog: %w", err)
+		}
+		targets = append(targets, addTarget(newLevelledTargetHook(write, formatter, targetLevel(config.Syslog.Level)), config.Syslog.Level))
+	}
+
+	var ringBuffer *RingBufferTarget
+	if config.RingBuffer.Enabled {
+		ringBuffer = NewRingBufferTarget(config.RingBuffer.Capacity)
+		targets = append(targets, addTarget(newTargetHook(ringBuffer, formatter, targetLevel(config.RingBuffer.Level)), config.RingBuffer.Level))
+	}
+
+	logger.Level = logrus.PanicLevel
+	for _, t := range targets {
+		if l := t.getLevel(); l > logger.Level {
+			logger.Level = l
+		}
+		logger.AddHook(t)
+	}
+
+	sFields := logrus.Fields{}
+	for k, v := range config.StaticFields {
+		sFields[k] = v
+	}
+	logEntry := logger.WithFields(sFields)
+
+	return &Logger{
+		entry:           logEntry,
+		ringBuffer:      ringBuffer,
+		levelMut:        &sync.Mutex{},
+		levelTargets:    levelTargets,
+		overrideTargets: overrideTargets,
+	}, nil
+}
+
+func parseLevel(level string, fallback logrus.Level) logrus.Level {
+	if l, ok := levelFromString(level); ok {
+		return l
+	}
+	return fallback
+}
+
+func levelFromString(level string) (logrus.Level, bool) {
+	switch strings.ToUpper(level) {
 	case "OFF", "NONE":
-		logger.Level = logrus.PanicLevel
+		return logrus.PanicLevel, true
 	case "FATAL":
-		logger.Level = logrus.FatalLevel
+		return logrus.FatalLevel, true
 	case "ERROR":
-		logger.Level = logrus.ErrorLevel
+		return logrus.ErrorLevel, true
 	case "WARN":
-		logger.Level = logrus.WarnLevel
+		return logrus.WarnLevel, true
 	case "INFO":
-		logger.Level = logrus.InfoLevel
+		return logrus.InfoLevel, true
 	case "DEBUG":
-		logger.Level = logrus.DebugLevel
+		return logrus.DebugLevel, true
 	case "TRACE", "ALL":
-		logger.Level = logrus.TraceLevel
-		logger.Level = logrus.TraceLevel
+		return logrus.TraceLevel, true
 	}
+	return 0, false
+}
 
-	sFields := logrus.Fields{}
-	for k, v := range config.StaticFields {
-		sFields[k] = v
+// SetLevel changes the log level of the logger at runtime, which applies to
+// all targets that do not override the level with their own.
+func (l *Logger) SetLevel(level string) error {
+	newLevel, ok := levelFromString(level)
+	if !ok {
+		return fmt.Errorf("log level '%v' not recognized", level)
 	}
-	logEntry := logger.WithFields(sFields)
+	if l.levelMut == nil {
+		return errors.New("logger does not support changing its level")
+	}
+
+	l.levelMut.Lock()
+	defer l.levelMut.Unlock()
+
+	maxLevel := logrus.PanicLevel
+	for _, t := range l.levelTargets {
+		t.setLevel(newLevel)
+		if newLevel > maxLevel {
+			maxLevel = newLevel
+		}
+	}
+	for _, t := range l.overrideTargets {
+		if tl := t.getLevel(); tl > maxLevel {
+			maxLevel = tl
+		}
+	}
+	l.entry.Logger.SetLevel(maxLevel)
+	return nil
+}
 
-	return &Logger{entry: logEntry}, nil
+// RingBuffer returns the in-memory target of the logger, or nil if it is not
+// enabled.
+func (l *Logger) RingBuffer() *RingBufferTarget {
+	return l.ringBuffer
 }
 
 //------------------------------------------------------------------------------
@@ -2,10 +2,16 @@ package log
 
 import (
 	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/message"
 )
 
 func TestLoggerWith(t *testing.T) {
@@ -136,3 +142,131 @@ func TestLogLevels(t *testing.T) {
 		}
 	}
 }
+
+func TestLoggerForMessage(t *testing.T) {
+	loggerConfig := NewConfig()
+	loggerConfig.AddTimeStamp = false
+	loggerConfig.Format = "logfmt"
+	loggerConfig.LogLevel = "WARN"
+	loggerConfig.StaticFields = map[string]string{
+		"@service": "benthos_service",
+	}
+
+	var buf bytes.Buffer
+
+	logger, err := NewV2(&buf, loggerConfig)
+	require.NoError(t, err)
+
+	withFields := func(p *message.Part, fields map[string]string) *message.Part {
+		return message.WithContext(ContextWithMessageFields(p.GetContext(), fields), p)
+	}
+
+	batch := message.QuickBatch(nil)
+	batch.Append(
+		withFields(message.NewPart(nil), map[string]string{"tenant": "foo", "id": "1"}),
+		withFields(message.NewPart(nil), map[string]string{"tenant": "foo", "id": "2"}),
+	)
+
+	ForMessage(logger, batch.Get(1)).Warnln("Warning message part")
+	ForMessage(logger, message.NewPart(nil)).Warnln("Warning message plain part")
+	ForBatch(logger, batch).Warnln("Warning message batch")
+
+	expected := `level=warning msg="Warning message part" @service=benthos_service id=2 tenant=foo
+level=warning msg="Warning message plain part" @service=benthos_service
+level=warning msg="Warning message batch" @service=benthos_service tenant=foo
+`
+
+	assert.Equal(t, expected, buf.String())
+}
+
+func TestLoggerTargetLevels(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "benthos.log")
+
+	loggerConfig := NewConfig()
+	loggerConfig.AddTimeStamp = false
+	loggerConfig.Format = "logfmt"
+	loggerConfig.LogLevel = "WARN"
+	loggerConfig.StaticFields = map[string]string{}
+	loggerConfig.File.Path = logFile
+	loggerConfig.File.Level = "ERROR"
+	loggerConfig.RingBuffer.Enabled = true
+	loggerConfig.RingBuffer.Capacity = 2
+	loggerConfig.RingBuffer.Level = "DEBUG"
+
+	var buf bytes.Buffer
+
+	logger, err := New(&buf, loggerConfig)
+	require.NoError(t, err)
+
+	logger.Errorln("Error message")
+	logger.Warnln("Warning message")
+	logger.Infoln("Info message")
+	logger.Debugln("Debug message")
+	logger.Traceln("Trace message")
+
+	assert.Empty(t, buf.String())
+
+	fileBytes, err := os.ReadFile(logFile)
+	require.NoError(t, err)
+	assert.Equal(t, "level=error msg=\"Error message\"\n", string(fileBytes))
+
+	w := httptest.NewRecorder()
+	logger.RingBuffer().Handler()(w, httptest.NewRequest(http.MethodGet, "/debug/logs", nil))
+	assert.Equal(t, `level=info msg="Info message"
+level=debug msg="Debug message"
+`, w.Body.String())
+}
+
+func TestLoggerRingBufferDisabled(t *testing.T) {
+	loggerConfig := NewConfig()
+	loggerConfig.AddTimeStamp = false
+	loggerConfig.Format = "logfmt"
+	loggerConfig.StaticFields = map[string]string{}
+
+	var buf bytes.Buffer
+
+	logger, err := New(&buf, loggerConfig)
+	require.NoError(t, err)
+	assert.Nil(t, logger.RingBuffer())
+
+	logger.Infoln("Info message")
+	logger.Debugln("Debug message")
+	assert.Equal(t, "level=info msg=\"Info message\"\n", buf.String())
+}
+
+func TestLoggerSetLevel(t *testing.T) {
+	loggerConfig := NewConfig()
+	loggerConfig.AddTimeStamp = false
+	loggerConfig.Format = "logfmt"
+	loggerConfig.LogLevel = "WARN"
+	loggerConfig.StaticFields = map[string]string{}
+	loggerConfig.RingBuffer.Enabled = true
+	loggerConfig.RingBuffer.Capacity = 10
+	loggerConfig.RingBuffer.Level = "ERROR"
+
+	var buf bytes.Buffer
+
+	logger, err := New(&buf, loggerConfig)
+	require.NoError(t, err)
+
+	logger.Infoln("Info message")
+	require.NoError(t, logger.SetLevel("DEBUG"))
+	logger.Infoln("Info message two")
+	logger.Debugln("Debug message")
+	logger.Traceln("Trace message")
+	logger.Errorln("Error message")
+
+	require.Error(t, logger.SetLevel("nope"))
+	require.NoError(t, logger.SetLevel("ERROR"))
+	logger.Warnln("Warning message")
+
+	assert.Equal(t, `level=info msg="Info message two"
+level=debug msg="Debug message"
+level=error msg="Error message"
+`, buf.String())
+
+	// Targets that override the level are not changed.
+	w := httptest.NewRecorder()
+	logger.RingBuffer().Handler()(w, httptest.NewRequest(http.MethodGet, "/debug/logs", nil))
+	assert.Equal(t, "level=error msg=\"Error message\"\n", w.Body.String())
+}
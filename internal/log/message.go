@@ -0,0 +1,58 @@
+package log
+
+import (
+	"context"
+
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+type messageFieldsKey struct{}
+
+// ContextWithMessageFields returns a context carrying fields that should be
+// added to logs emitted in relation to the message that the context belongs
+// to.
+func ContextWithMessageFields(ctx context.Context, fields map[string]string) context.Context {
+	return context.WithValue(ctx, messageFieldsKey{}, fields)
+}
+
+// MessageFields returns the log fields carried by a message context, or nil if
+// there are none.
+func MessageFields(ctx context.Context) map[string]string {
+	fields, _ := ctx.Value(messageFieldsKey{}).(map[string]string)
+	return fields
+}
+
+// ForMessage returns a logger enriched with the log fields carried by the
+// context of a message part.
+func ForMessage(l Modular, p *message.Part) Modular {
+	if fields := MessageFields(p.GetContext()); len(fields) > 0 {
+		return l.WithFields(fields)
+	}
+	return l
+}
+
+// ForBatch returns a logger enriched with the log fields that are carried by
+// the context of, and are identical for, all message parts of a batch.
+func ForBatch(l Modular, b *message.Batch) Modular {
+	var shared map[string]string
+	_ = b.Iter(func(i int, p *message.Part) error {
+		fields := MessageFields(p.GetContext())
+		if i == 0 {
+			shared = make(map[string]string, len(fields))
+			for k, v := range fields {
+				shared[k] = v
+			}
+			return nil
+		}
+		for k, v := range shared {
+			if fields[k] != v {
+				delete(shared, k)
+			}
+		}
+		return nil
+	})
+	if len(shared) > 0 {
+		return l.WithFields(shared)
+	}
+	return l
+}
@@ -0,0 +1,73 @@
+package log
+
+import (
+	"net/http"
+	"sync"
+)
+
+// RingBufferTarget is a log target that keeps the most recent log lines in
+// memory, which can be served over HTTP.
+type RingBufferTarget struct {
+	mut   sync.Mutex
+	lines [][]byte
+	next  int
+	full  bool
+}
+
+// NewRingBufferTarget creates a log target that keeps up to a capacity of log
+// lines.
+func NewRingBufferTarget(capacity int) *RingBufferTarget {
+	if capacity <= 0 {
+		capacity = 1
+	}
+	return &RingBufferTarget{
+		lines: make([][]byte, capacity),
+	}
+}
+
+// Write adds a log line to the buffer, evicting the oldest line if the buffer
+// is full.
+func (r *RingBufferTarget) Write(p []byte) (int, error) {
+	line := make([]byte, len(p))
+	copy(line, p)
+
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	r.lines[r.next] = line
+	r.next++
+	if r.next >= len(r.lines) {
+		r.next = 0
+		r.full = true
+	}
+	return len(p), nil
+}
+
+// Lines returns a copy of the log lines currently held, ordered from oldest to
+// newest.
+func (r *RingBufferTarget) Lines() [][]byte {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	if !r.full {
+		lines := make([][]byte, r.next)
+		copy(lines, r.lines[:r.next])
+		return lines
+	}
+
+	lines := make([][]byte, 0, len(r.lines))
+	lines = append(lines, r.lines[r.next:]...)
+	lines = append(lines, r.lines[:r.next]...)
+	return lines
+}
+
+// Handler returns an HTTP handler that responds with the log lines currently
+// held, ordered from oldest to newest.
+func (r *RingBufferTarget) Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		for _, line := range r.Lines() {
+			_, _ = w.Write(line)
+		}
+	}
+}
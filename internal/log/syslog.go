@@ -0,0 +1,31 @@
+//go:build !windows && !plan9
+// +build !windows,!plan9
+
+package log
+
+import (
+	"log/syslog"
+
+	"github.com/sirupsen/logrus"
+)
+
+func newSyslogWriter(conf Syslog) (func(level logrus.Level, b []byte) error, error) {
+	w, err := syslog.Dial(conf.Network, conf.Address, syslog.LOG_INFO|syslog.LOG_DAEMON, conf.Tag)
+	if err != nil {
+		return nil, err
+	}
+	return func(level logrus.Level, b []byte) error {
+		msg := string(b)
+		switch level {
+		case logrus.PanicLevel, logrus.FatalLevel:
+			return w.Crit(msg)
+		case logrus.ErrorLevel:
+			return w.Err(msg)
+		case logrus.WarnLevel:
+			return w.Warning(msg)
+		case logrus.InfoLevel:
+			return w.Info(msg)
+		}
+		return w.Debug(msg)
+	}, nil
+}
@@ -0,0 +1,14 @@
+//go:build windows || plan9
+// +build windows plan9
+
+package log
+
+import (
+	"errors"
+
+	"github.com/sirupsen/logrus"
+)
+
+func newSyslogWriter(_ Syslog) (func(level logrus.Level, b []byte) error, error) {
+	return nil, errors.New("syslog is not supported on this platform")
+}
@@ -0,0 +1,87 @@
+package log
+
+import (
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+
+	"github.com/sirupsen/logrus"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// targetHook writes log entries at or above a given level to a target. The
+// level can be changed at runtime.
+type targetHook struct {
+	level     uint32
+	formatter logrus.Formatter
+
+	mut   sync.Mutex
+	write func(level logrus.Level, b []byte) error
+}
+
+func newTargetHook(w io.Writer, formatter logrus.Formatter, level logrus.Level) *targetHook {
+	return newLevelledTargetHook(func(_ logrus.Level, b []byte) error {
+		_, err := w.Write(b)
+		return err
+	}, formatter, level)
+}
+
+func newLevelledTargetHook(write func(level logrus.Level, b []byte) error, formatter logrus.Formatter, level logrus.Level) *targetHook {
+	return &targetHook{
+		level:     uint32(level),
+		formatter: formatter,
+		write:     write,
+	}
+}
+
+func (t *targetHook) getLevel() logrus.Level {
+	return logrus.Level(atomic.LoadUint32(&t.level))
+}
+
+func (t *targetHook) setLevel(level logrus.Level) {
+	atomic.StoreUint32(&t.level, uint32(level))
+}
+
+// Levels returns all levels as hooks are registered against levels once when
+// added to a logger, entries above the level of the target are instead
+// filtered when fired.
+func (t *targetHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (t *targetHook) Fire(entry *logrus.Entry) error {
+	if entry.Level > t.getLevel() {
+		return nil
+	}
+
+	b, err := t.formatter.Format(entry)
+	if err != nil {
+		return err
+	}
+
+	t.mut.Lock()
+	defer t.mut.Unlock()
+	return t.write(entry.Level, b)
+}
+
+// discardFormatter is used by the underlying logger as all logs are written
+// by target hooks.
+type discardFormatter struct{}
+
+func (discardFormatter) Format(*logrus.Entry) ([]byte, error) {
+	return nil, nil
+}
+
+func newFileWriter(conf File) (io.Writer, error) {
+	if conf.Rotate {
+		return &lumberjack.Logger{
+			Filename:   conf.Path,
+			MaxSize:    10,
+			MaxAge:     conf.RotateMaxAge,
+			MaxBackups: 1,
+			Compress:   true,
+		}, nil
+	}
+	return os.OpenFile(conf.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o666)
+}
@@ -0,0 +1,142 @@
+package manager
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/benthosdev/benthos/v4/internal/bloblang/query"
+	"github.com/benthosdev/benthos/v4/internal/component/input"
+)
+
+// ErrInputNotFound represents an error where an input could not be paused or
+// resumed because no input was found with the identifier.
+type ErrInputNotFound string
+
+// Error implements the standard error interface.
+func (e ErrInputNotFound) Error() string {
+	return fmt.Sprintf("unable to locate input: %v", string(e))
+}
+
+// inputControls keeps track of the inputs created by a manager, keyed by their
+// label or, for inputs without a label, their path, so that they can be paused
+// and resumed at runtime. Inputs that have closed are removed whenever the
+// controls are accessed.
+type inputControls struct {
+	mut    sync.Mutex
+	inputs map[string][]*input.Throttled
+}
+
+func newInputControls() *inputControls {
+	return &inputControls{
+		inputs: map[string][]*input.Throttled{},
+	}
+}
+
+func (c *inputControls) add(key string, i *input.Throttled) {
+	c.mut.Lock()
+	c.prune()
+	c.inputs[key] = append(c.inputs[key], i)
+	c.mut.Unlock()
+}
+
+// prune removes closed inputs, and must be called with the mutex held.
+func (c *inputControls) prune() {
+	for k, inputs := range c.inputs {
+		open := inputs[:0]
+		for _, i := range inputs {
+			if !i.Closed() {
+				open = append(open, i)
+			}
+		}
+		if len(open) == 0 {
+			delete(c.inputs, k)
+			continue
+		}
+		c.inputs[k] = open
+	}
+}
+
+func (c *inputControls) keys() []string {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	c.prune()
+	keys := make([]string, 0, len(c.inputs))
+	for k := range c.inputs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (c *inputControls) get(key string) ([]*input.Throttled, error) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	c.prune()
+	inputs, exists := c.inputs[key]
+	if !exists {
+		return nil, ErrInputNotFound(key)
+	}
+	return inputs, nil
+}
+
+// isStreamInput returns true if the manager belongs to the input of a stream.
+func (t *Type) isStreamInput() bool {
+	return len(t.componentPath) == 1 && t.componentPath[0] == "input"
+}
+
+func (t *Type) inputKey() string {
+	if t.label != "" {
+		return t.label
+	}
+	return "root." + query.SliceToDotPath(t.componentPath...)
+}
+
+// PauseInput stops the consumption of messages from all inputs identified by a
+// label or, for the input of a stream without a label, the path `root.input`.
+// Unlabelled inputs nested within other inputs cannot be paused unless they
+// have a throttle configured. The connections of paused inputs remain open.
+func (t *Type) PauseInput(key string) error {
+	inputs, err := t.inputControls.get(key)
+	if err != nil {
+		return err
+	}
+	for _, i := range inputs {
+		i.Pause()
+	}
+	return nil
+}
+
+// ResumeInput continues the consumption of messages from all inputs identified
+// by a label or path that were previously paused.
+func (t *Type) ResumeInput(key string) error {
+	inputs, err := t.inputControls.get(key)
+	if err != nil {
+		return err
+	}
+	for _, i := range inputs {
+		i.Resume()
+	}
+	return nil
+}
+
+// InputKeys returns the labels or paths of all inputs that can be paused and
+// resumed, sorted alphabetically.
+func (t *Type) InputKeys() []string {
+	return t.inputControls.keys()
+}
+
+// InputPaused returns whether the inputs identified by a label or path are
+// paused.
+func (t *Type) InputPaused(key string) (bool, error) {
+	inputs, err := t.inputControls.get(key)
+	if err != nil {
+		return false, err
+	}
+	for _, i := range inputs {
+		if !i.Paused() {
+			return false, nil
+		}
+	}
+	return true, nil
+}
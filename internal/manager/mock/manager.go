@@ -34,6 +34,10 @@ type Manager struct {
 	// by components.
 	OnRegisterEndpoint func(path string, h http.HandlerFunc)
 
+	// OnRegisterTunable can be set in order to intercept tunables registered
+	// by components.
+	OnRegisterTunable func(field, value string, set func(value string) error)
+
 	M metrics.Type
 	L log.Modular
 	T trace.TracerProvider
@@ -63,6 +67,9 @@ func (m *Manager) IntoPath(segments ...string) bundle.NewManagement { return m }
 // WithAddedMetrics returns the same mock manager.
 func (m *Manager) WithAddedMetrics(m2 metrics.Type) bundle.NewManagement { return m }
 
+// WithLabels returns the same mock manager.
+func (m *Manager) WithLabels(labels map[string]string) bundle.NewManagement { return m }
+
 // NewBuffer always errors on invalid type.
 func (m *Manager) NewBuffer(conf buffer.Config) (buffer.Streamed, error) {
 	return nil, component.ErrInvalidType("buffer", conf.Type)
@@ -140,6 +147,14 @@ func (m *Manager) RegisterEndpoint(path, desc string, h http.HandlerFunc) {
 	}
 }
 
+// RegisterTunable registers a config field that can be changed at runtime.
+func (m *Manager) RegisterTunable(field, desc, value string, set func(value string) error) (unregister func()) {
+	if m.OnRegisterTunable != nil {
+		m.OnRegisterTunable(field, value, set)
+	}
+	return func() {}
+}
+
 // BloblEnvironment always returns the global environment.
 func (m *Manager) BloblEnvironment() *bloblang.Environment {
 	return bloblang.GlobalEnvironment()
@@ -2,9 +2,11 @@ package manager
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"path"
+	"sort"
 	"sync"
 	"time"
 
@@ -25,6 +27,8 @@ import (
 	"github.com/benthosdev/benthos/v4/internal/log"
 	"github.com/benthosdev/benthos/v4/internal/manager/mock"
 	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/tracing"
+	"github.com/benthosdev/benthos/v4/internal/tunable"
 )
 
 // ErrResourceNotFound represents an error where a named resource could not be
@@ -63,7 +67,17 @@ type Type struct {
 	// Keeps track of the label of the component holding this manager.
 	label string
 
-	apiReg APIReg
+	// Keeps track of the name of the resource that the component holding this
+	// manager belongs to, if any.
+	resource string
+
+	// An optional backoff policy configured for the connection attempts of the
+	// input or output holding this manager, which also applies to any child
+	// inputs or outputs that do not configure their own.
+	connectBackoff *component.ConnectBackoffConfig
+
+	apiReg   APIReg
+	tunables *tunable.Registry
 
 	inputs       map[string]*inputWrapper
 	caches       map[string]cache.V1
@@ -72,6 +86,8 @@ type Type struct {
 	rateLimits   map[string]ratelimit.V1
 	resourceLock *sync.RWMutex
 
+	inputControls *inputControls
+
 	// Collections of component constructors
 	env      *bundle.Environment
 	bloblEnv *bloblang.Environment
@@ -95,6 +111,14 @@ func OptSetAPIReg(r APIReg) OptFunc {
 	}
 }
 
+// OptSetTunables sets the registry to which components of this manager add
+// config fields that can be changed at runtime.
+func OptSetTunables(r *tunable.Registry) OptFunc {
+	return func(t *Type) {
+		t.tunables = r
+	}
+}
+
 // OptSetLogger sets the logger from which the manager emits log events for
 // components.
 func OptSetLogger(logger log.Modular) OptFunc {
@@ -158,6 +182,8 @@ func New(conf ResourceConfig, opts ...OptFunc) (*Type, error) {
 		rateLimits:   map[string]ratelimit.V1{},
 		resourceLock: &sync.RWMutex{},
 
+		inputControls: newInputControls(),
+
 		// Environment defaults to global (everything that was imported).
 		env:      bundle.GlobalEnvironment,
 		bloblEnv: bloblang.GlobalEnvironment(),
@@ -269,6 +295,7 @@ func (t *Type) ForStream(id string) bundle.NewManagement {
 func (t *Type) forStream(id string) *Type {
 	newT := *t
 	newT.stream = id
+	newT.inputControls = newInputControls()
 	newT.logger = t.logger.WithFields(map[string]string{
 		"stream": id,
 	})
@@ -308,6 +335,23 @@ func (t *Type) intoPath(segments ...string) *Type {
 	return &newT
 }
 
+func (t *Type) intoResource(typeStr, name string) *Type {
+	newT := t.intoPath(typeStr)
+	newT.resource = name
+	return newT
+}
+
+func (t *Type) withConnectBackoff(conf *component.ConnectBackoffConfig) (*Type, error) {
+	if conf == nil {
+		return t, nil
+	}
+	if _, err := conf.Get(); err != nil {
+		return nil, fmt.Errorf("failed to parse connect backoff: %w", err)
+	}
+	t.connectBackoff = conf
+	return t, nil
+}
+
 // Path returns the current component path held by a manager.
 func (t *Type) Path() []string {
 	return t.componentPath
@@ -326,8 +370,48 @@ func (t *Type) WithAddedMetrics(m metrics.Type) bundle.NewManagement {
 	return &newT
 }
 
+// WithLabels returns a modified version of the manager where the provided
+// labels are added to all log lines, metrics and spans of components created
+// with it.
+func (t *Type) WithLabels(labels map[string]string) bundle.NewManagement {
+	return t.withLabels(labels)
+}
+
+func (t *Type) withLabels(labels map[string]string) *Type {
+	if len(labels) == 0 {
+		return t
+	}
+
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	statsLabels := make([]string, 0, len(keys)*2)
+	for _, k := range keys {
+		statsLabels = append(statsLabels, k, labels[k])
+	}
+
+	newT := *t
+	newT.logger = t.logger.WithFields(labels)
+	newT.stats = t.stats.WithLabels(statsLabels...)
+	newT.tracer = tracing.WithAttributes(t.tracer, labels)
+	return &newT
+}
+
 //------------------------------------------------------------------------------
 
+// ConnectBackoff returns a new backoff policy for the connection attempts of
+// the input or output holding this manager, or nil if one isn't configured.
+func (t *Type) ConnectBackoff() *component.ConnectBackoff {
+	if t.connectBackoff == nil {
+		return nil
+	}
+	boff, _ := t.connectBackoff.Get()
+	return boff
+}
+
 // RegisterEndpoint registers a server wide HTTP endpoint.
 func (t *Type) RegisterEndpoint(apiPath, desc string, h http.HandlerFunc) {
 	if len(t.stream) > 0 {
@@ -338,6 +422,33 @@ func (t *Type) RegisterEndpoint(apiPath, desc string, h http.HandlerFunc) {
 	}
 }
 
+// RegisterTunable registers a config field of the component holding this
+// manager that can be changed at runtime. The name of the tunable is the field
+// prefixed with the path of the component, where the name of a resource
+// follows the resources field that it belongs to, and the stream identifier in
+// streams mode. The returned function unregisters the tunable, and should be
+// called when the component is closed.
+func (t *Type) RegisterTunable(field, desc, value string, set func(value string) error) (unregister func()) {
+	if t.tunables == nil {
+		return func() {}
+	}
+
+	segments := make([]string, 0, len(t.componentPath)+2)
+	for i, s := range t.componentPath {
+		segments = append(segments, s)
+		if i == 0 && t.resource != "" {
+			segments = append(segments, t.resource)
+		}
+	}
+	segments = append(segments, field)
+
+	name := "root." + query.SliceToDotPath(segments...)
+	if len(t.stream) > 0 {
+		name = t.stream + "." + name
+	}
+	return t.tunables.Register(name, desc, value, set)
+}
+
 // SetPipe registers a new transaction chan to a named pipe.
 func (t *Type) SetPipe(name string, tran <-chan message.Transaction) {
 	t.pipeLock.Lock()
@@ -488,7 +599,7 @@ func (t *Type) StoreCache(ctx context.Context, name string, conf cache.Config) e
 		}
 	}
 
-	newCache, err := t.intoPath("cache_resources").NewCache(conf)
+	newCache, err := t.intoResource("cache_resources", name).NewCache(conf)
 	if err != nil {
 		return err
 	}
@@ -527,7 +638,35 @@ func (t *Type) AccessInput(ctx context.Context, name string, fn func(input.Strea
 
 // NewInput attempts to create a new input component from a config.
 func (t *Type) NewInput(conf input.Config, pipelines ...processor.PipelineConstructorFunc) (input.Streamed, error) {
-	return t.env.InputInit(conf, t.forLabel(conf.Label), pipelines...)
+	if err := bundle.ValidateLabels(conf.Labels); err != nil {
+		return nil, err
+	}
+	nm, err := t.forLabel(conf.Label).withLabels(conf.Labels).withConnectBackoff(conf.ConnectBackoff)
+	if err != nil {
+		return nil, err
+	}
+	i, err := t.env.InputInit(conf, nm, pipelines...)
+	if err != nil || i == nil {
+		return i, err
+	}
+
+	tConf := input.NewThrottleConfig()
+	if conf.Throttle != nil {
+		tConf = *conf.Throttle
+	}
+
+	// Inputs are wrapped in order to support pausing them at runtime when they
+	// can be identified by a label or are the input of a stream, otherwise
+	// they're only wrapped when a throttle is configured.
+	if conf.Label == "" && !nm.isStreamInput() && tConf.IsNoop() {
+		return i, nil
+	}
+	ti, err := input.WrapWithThrottle(tConf, i, nm)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create input throttle: %w", err)
+	}
+	t.inputControls.add(nm.inputKey(), ti)
+	return ti, nil
 }
 
 // StoreInput attempts to store a new input resource. If an existing resource
@@ -551,7 +690,7 @@ func (t *Type) StoreInput(ctx context.Context, name string, conf input.Config) e
 		return fmt.Errorf("label '%v' must be empty or match the resource name '%v'", conf.Label, name)
 	}
 
-	newInput, err := t.intoPath("input_resources").NewInput(conf)
+	newInput, err := t.intoResource("input_resources", name).NewInput(conf)
 	if err != nil {
 		return err
 	}
@@ -620,7 +759,7 @@ func (t *Type) StoreProcessor(ctx context.Context, name string, conf processor.C
 		return fmt.Errorf("label '%v' must be empty or match the resource name '%v'", conf.Label, name)
 	}
 
-	newProcessor, err := t.intoPath("processor_resources").NewProcessor(conf)
+	newProcessor, err := t.intoResource("processor_resources", name).NewProcessor(conf)
 	if err != nil {
 		return err
 	}
@@ -660,7 +799,30 @@ func (t *Type) AccessOutput(ctx context.Context, name string, fn func(output.Syn
 
 // NewOutput attempts to create a new output component from a config.
 func (t *Type) NewOutput(conf output.Config, pipelines ...processor.PipelineConstructorFunc) (output.Streamed, error) {
-	return t.env.OutputInit(conf, t.forLabel(conf.Label), pipelines...)
+	nm, err := t.forLabel(conf.Label).withConnectBackoff(conf.ConnectBackoff)
+	if err != nil {
+		return nil, err
+	}
+	o, err := t.env.OutputInit(conf, nm, pipelines...)
+	if err != nil || conf.Journal == nil {
+		return o, err
+	}
+
+	if conf.Journal.Key == "" {
+		o.CloseAsync()
+		return nil, errors.New("journal key must not be empty")
+	}
+	key, err := t.BloblEnvironment().NewField(conf.Journal.Key)
+	if err != nil {
+		o.CloseAsync()
+		return nil, fmt.Errorf("failed to parse journal key: %w", err)
+	}
+	j, err := output.WrapWithJournal(o, *conf.Journal, key, nm)
+	if err != nil {
+		o.CloseAsync()
+		return nil, err
+	}
+	return j, nil
 }
 
 // StoreOutput attempts to store a new output resource. If an existing resource
@@ -684,7 +846,7 @@ func (t *Type) StoreOutput(ctx context.Context, name string, conf output.Config)
 		return fmt.Errorf("label '%v' must be empty or match the resource name '%v'", conf.Label, name)
 	}
 
-	tmpOutput, err := t.intoPath("output_resources").NewOutput(conf)
+	tmpOutput, err := t.intoResource("output_resources", name).NewOutput(conf)
 	if err == nil {
 		if t.outputs[name], err = wrapOutput(tmpOutput); err != nil {
 			tmpOutput.CloseAsync()
@@ -748,7 +910,7 @@ func (t *Type) StoreRateLimit(ctx context.Context, name string, conf ratelimit.C
 		}
 	}
 
-	newRateLimit, err := t.intoPath("rate_limit_resources").NewRateLimit(conf)
+	newRateLimit, err := t.intoResource("rate_limit_resources", name).NewRateLimit(conf)
 	if err != nil {
 		return err
 	}
@@ -2,21 +2,30 @@ package manager_test
 
 import (
 	"context"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	yaml "gopkg.in/yaml.v3"
 
 	"github.com/benthosdev/benthos/v4/internal/bundle"
 	"github.com/benthosdev/benthos/v4/internal/component"
 	"github.com/benthosdev/benthos/v4/internal/component/cache"
 	"github.com/benthosdev/benthos/v4/internal/component/input"
+	"github.com/benthosdev/benthos/v4/internal/component/metrics"
 	"github.com/benthosdev/benthos/v4/internal/component/output"
 	"github.com/benthosdev/benthos/v4/internal/component/processor"
 	"github.com/benthosdev/benthos/v4/internal/component/ratelimit"
 	"github.com/benthosdev/benthos/v4/internal/docs"
+	"github.com/benthosdev/benthos/v4/internal/log"
 	"github.com/benthosdev/benthos/v4/internal/manager"
 	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/tunable"
 
 	_ "github.com/benthosdev/benthos/v4/public/components/all"
 )
@@ -458,3 +467,239 @@ func TestManagerPipeGetSet(t *testing.T) {
 }
 
 //------------------------------------------------------------------------------
+
+func TestManagerInputControls(t *testing.T) {
+	mgr, err := manager.New(manager.NewResourceConfig())
+	require.NoError(t, err)
+
+	newGenerate := func(label string) input.Config {
+		conf := input.NewConfig()
+		conf.Label = label
+		conf.Type = "generate"
+		conf.Generate.Mapping = `root = "hello world"`
+		return conf
+	}
+
+	labelled, err := mgr.NewInput(newGenerate("foo"))
+	require.NoError(t, err)
+	_, isThrottled := labelled.(*input.Throttled)
+	assert.True(t, isThrottled)
+
+	streamInput, err := mgr.IntoPath("input").NewInput(newGenerate(""))
+	require.NoError(t, err)
+	_, isThrottled = streamInput.(*input.Throttled)
+	assert.True(t, isThrottled)
+
+	nested, err := mgr.IntoPath("input", "broker", "inputs", "0").NewInput(newGenerate(""))
+	require.NoError(t, err)
+	_, isThrottled = nested.(*input.Throttled)
+	assert.False(t, isThrottled)
+
+	assert.Equal(t, []string{"foo", "root.input"}, mgr.InputKeys())
+
+	for _, in := range []input.Streamed{labelled, streamInput, nested} {
+		in.CloseAsync()
+		require.NoError(t, in.WaitForClose(time.Second*5))
+	}
+
+	assert.Empty(t, mgr.InputKeys())
+	assert.Error(t, mgr.PauseInput("foo"))
+}
+
+func TestManagerTunables(t *testing.T) {
+	tunables := tunable.NewRegistry(log.Noop())
+
+	conf := manager.NewResourceConfig()
+	rConf := ratelimit.NewConfig()
+	rConf.Label = "foo"
+	rConf.Type = "local"
+	conf.ResourceRateLimits = append(conf.ResourceRateLimits, rConf)
+
+	mgr, err := manager.New(conf, manager.OptSetTunables(tunables))
+	require.NoError(t, err)
+
+	noop := func(string) error { return nil }
+	_ = mgr.IntoPath("output", "batching").RegisterTunable("count", "", "10", noop)
+	unregisterBaz := mgr.ForStream("bar").IntoPath("input").RegisterTunable("baz", "", "buz", noop)
+
+	names := func() (names []string) {
+		for _, info := range tunables.List() {
+			names = append(names, info.Name+"="+info.Value)
+		}
+		return
+	}
+	assert.Equal(t, []string{
+		"bar.root.input.baz=buz",
+		"root.output.batching.count=10",
+		"root.rate_limit_resources.foo.count=1000",
+		"root.rate_limit_resources.foo.interval=1s",
+	}, names())
+
+	unregisterBaz()
+	assert.Equal(t, []string{
+		"root.output.batching.count=10",
+		"root.rate_limit_resources.foo.count=1000",
+		"root.rate_limit_resources.foo.interval=1s",
+	}, names())
+
+	// Tunables of resources are removed when the resources are closed.
+	mgr.CloseAsync()
+	require.NoError(t, mgr.WaitForClose(time.Second))
+	assert.Equal(t, []string{
+		"root.output.batching.count=10",
+	}, names())
+}
+
+func TestManagerWithLabels(t *testing.T) {
+	stats := metrics.NewLocal()
+	spans := tracetest.NewSpanRecorder()
+
+	mgr, err := manager.New(
+		manager.NewResourceConfig(),
+		manager.OptSetMetrics(metrics.NewNamespaced(stats)),
+		manager.OptSetTracer(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans))),
+	)
+	require.NoError(t, err)
+
+	lMgr := mgr.ForStream("foo").WithLabels(map[string]string{
+		"tenant": "acme",
+		"team":   "payments",
+	})
+	lMgr.Metrics().GetCounter("bar").Incr(1)
+	_, span := lMgr.Tracer().Tracer("test").Start(context.Background(), "baz")
+	span.End()
+
+	assert.Equal(t, map[string]int64{
+		`bar{stream="foo",team="payments",tenant="acme"}`: 1,
+	}, stats.GetCounters())
+
+	require.Len(t, spans.Ended(), 1)
+	assert.Equal(t, []attribute.KeyValue{
+		attribute.String("team", "payments"),
+		attribute.String("tenant", "acme"),
+	}, spans.Ended()[0].Attributes())
+}
+
+func TestManagerConnectBackoff(t *testing.T) {
+	mgr, err := manager.New(manager.NewResourceConfig())
+	require.NoError(t, err)
+
+	boffConf := component.NewConnectBackoffConfig()
+	boffConf.InitialInterval = "nope"
+
+	inConf := input.NewConfig()
+	inConf.Type = "generate"
+	inConf.Generate.Mapping = `root = "hello world"`
+	inConf.ConnectBackoff = &boffConf
+
+	_, err = mgr.NewInput(inConf)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to parse connect backoff")
+
+	outConf := output.NewConfig()
+	outConf.Type = "drop"
+	outConf.ConnectBackoff = &boffConf
+
+	_, err = mgr.NewOutput(outConf)
+	require.Error(t, err)
+
+	boffConf.InitialInterval = "1s"
+
+	in, err := mgr.NewInput(inConf)
+	require.NoError(t, err)
+
+	in.CloseAsync()
+	require.NoError(t, in.WaitForClose(time.Second*5))
+}
+
+func TestManagerInputLabels(t *testing.T) {
+	stats := metrics.NewLocal()
+	mgr, err := manager.New(manager.NewResourceConfig(), manager.OptSetMetrics(metrics.NewNamespaced(stats)))
+	require.NoError(t, err)
+
+	inConf := input.NewConfig()
+	require.NoError(t, yaml.Unmarshal([]byte(`
+broker:
+  inputs:
+    - generate:
+        count: 1
+        interval: ""
+        mapping: 'root = "foo"'
+      labels:
+        source: foo
+      processors:
+        - bloblang: 'root = content().uppercase()'
+    - generate:
+        count: 1
+        interval: ""
+        mapping: 'root = "bar"'
+      labels:
+        source: bar
+`), &inConf))
+
+	in, err := mgr.NewInput(inConf)
+	require.NoError(t, err)
+
+	var contents []string
+	for len(contents) < 2 {
+		select {
+		case tran, open := <-in.TransactionChan():
+			require.True(t, open)
+			contents = append(contents, string(tran.Payload.Get(0).Get()))
+			require.NoError(t, tran.Ack(context.Background(), nil))
+		case <-time.After(time.Second * 5):
+			t.Fatal("timed out")
+		}
+	}
+	assert.ElementsMatch(t, []string{"FOO", "bar"}, contents)
+
+	counters := stats.GetCounters()
+	assert.Equal(t, int64(1), counters[`input_received{label="",path="root.broker.inputs.0",source="foo"}`])
+	assert.Equal(t, int64(1), counters[`input_received{label="",path="root.broker.inputs.1",source="bar"}`])
+	assert.Equal(t, int64(1), counters[`processor_received{label="",path="root.broker.inputs.0.processors.0",source="foo"}`])
+
+	in.CloseAsync()
+	require.NoError(t, in.WaitForClose(time.Second*5))
+
+	inConf = input.NewConfig()
+	inConf.Type = "generate"
+	inConf.Generate.Mapping = `root = "hello world"`
+	inConf.Labels = map[string]string{"path": "foo"}
+
+	_, err = mgr.NewInput(inConf)
+	require.EqualError(t, err, "label name 'path' is reserved")
+}
+
+func TestManagerOutputJournal(t *testing.T) {
+	mgr, err := manager.New(manager.NewResourceConfig())
+	require.NoError(t, err)
+
+	journalPath := filepath.Join(t.TempDir(), "journal.jsonl")
+
+	outConf := output.NewConfig()
+	require.NoError(t, yaml.Unmarshal([]byte(`
+drop: {}
+journal:
+  path: `+journalPath+`
+  key: ${! json("id")
+`), &outConf))
+	require.NotNil(t, outConf.Journal)
+	assert.Equal(t, "skip", outConf.Journal.OnRedelivery)
+	assert.Equal(t, "24h", outConf.Journal.Retention)
+
+	_, err = mgr.NewOutput(outConf)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to parse journal key")
+
+	outConf.Journal.Key = `${! json("id") }`
+
+	out, err := mgr.NewOutput(outConf)
+	require.NoError(t, err)
+	assert.IsType(t, &output.WithJournal{}, out)
+	assert.FileExists(t, journalPath)
+
+	tChan := make(chan message.Transaction)
+	require.NoError(t, out.Consume(tChan))
+	close(tChan)
+	require.NoError(t, out.WaitForClose(time.Second*5))
+}
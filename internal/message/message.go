@@ -1,7 +1,14 @@
 package message
 
+import (
+	"sync/atomic"
+)
+
+var batchIDCounter uint64
+
 // Batch represents zero or more messages.
 type Batch struct {
+	id    uint64
 	parts []*Part
 }
 
@@ -19,6 +26,20 @@ func QuickBatch(bslice [][]byte) *Batch {
 
 //------------------------------------------------------------------------------
 
+// ID returns an identifier that is unique to this batch within the process,
+// which can be used to associate cached data with a batch without holding a
+// reference to it. Copies of a batch have a different ID.
+func (m *Batch) ID() uint64 {
+	if id := atomic.LoadUint64(&m.id); id != 0 {
+		return id
+	}
+	id := atomic.AddUint64(&batchIDCounter, 1)
+	if !atomic.CompareAndSwapUint64(&m.id, 0, id) {
+		return atomic.LoadUint64(&m.id)
+	}
+	return id
+}
+
 // Copy creates a new shallow copy of the message. Parts can be re-arranged in
 // the new copy and JSON parts can be get/set without impacting other message
 // copies. However, it is still unsafe to edit the raw content of message parts.
@@ -543,3 +543,11 @@ func BenchmarkJSONGet(b *testing.B) {
 		}
 	}
 }
+
+func TestMessageBatchID(t *testing.T) {
+	a, b := QuickBatch(nil), QuickBatch(nil)
+	assert.NotZero(t, a.ID())
+	assert.Equal(t, a.ID(), a.ID())
+	assert.NotEqual(t, a.ID(), b.ID())
+	assert.NotEqual(t, a.ID(), a.Copy().ID())
+}
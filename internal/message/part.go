@@ -5,8 +5,10 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"os"
+	"sync/atomic"
 )
 
 var useNumber = true
@@ -21,9 +23,19 @@ func init() {
 
 type rwData struct {
 	rawBytes  []byte
+	spool     *Spool
 	jsonCache interface{}
 	metadata  map[string]string
 	err       error
+
+	// Counts the message parts that share the metadata map, which must be
+	// cloned before it is modified when shared.
+	metaRefs *int32
+}
+
+func newMetaRefs() *int32 {
+	refs := int32(1)
+	return &refs
 }
 
 // Part represents a single Benthos message.
@@ -42,21 +54,33 @@ func NewPart(data []byte) *Part {
 	}
 }
 
+// NewSpooledPart initializes a new message part where the contents are
+// backed by a spool. The contents are only read into memory when accessed via
+// Get, components that support streamed payloads should use BodyReader.
+func NewSpooledPart(s *Spool) *Part {
+	return &Part{
+		data: &rwData{
+			spool: s,
+		},
+		ctx: context.Background(),
+	}
+}
+
 //------------------------------------------------------------------------------
 
-// Copy creates a shallow copy of the message part.
+// Copy creates a shallow copy of the message part. The metadata of the copy is
+// shared with the original until either part modifies it, at which point it is
+// cloned.
 func (p *Part) Copy() *Part {
-	var clonedMeta map[string]string
 	if p.data.metadata != nil {
-		clonedMeta = make(map[string]string, len(p.data.metadata))
-		for k, v := range p.data.metadata {
-			clonedMeta[k] = v
-		}
+		atomic.AddInt32(p.data.metaRefs, 1)
 	}
 	return &Part{
 		data: &rwData{
 			rawBytes:  p.data.rawBytes,
-			metadata:  clonedMeta,
+			spool:     p.data.spool,
+			metadata:  p.data.metadata,
+			metaRefs:  p.data.metaRefs,
 			jsonCache: p.data.jsonCache,
 			err:       p.data.err,
 		},
@@ -67,11 +91,10 @@ func (p *Part) Copy() *Part {
 // DeepCopy creates a new deep copy of the message part.
 func (p *Part) DeepCopy() *Part {
 	var clonedMeta map[string]string
+	var metaRefs *int32
 	if p.data.metadata != nil {
-		clonedMeta = make(map[string]string, len(p.data.metadata))
-		for k, v := range p.data.metadata {
-			clonedMeta[k] = v
-		}
+		clonedMeta = cloneMeta(p.data.metadata)
+		metaRefs = newMetaRefs()
 	}
 	var clonedJSON interface{}
 	if p.data.jsonCache != nil {
@@ -88,7 +111,9 @@ func (p *Part) DeepCopy() *Part {
 	return &Part{
 		data: &rwData{
 			rawBytes:  np,
+			spool:     p.data.spool,
 			metadata:  clonedMeta,
+			metaRefs:  metaRefs,
 			jsonCache: clonedJSON,
 			err:       p.data.err,
 		},
@@ -137,8 +162,18 @@ func (p *Part) ErrorSet(err error) {
 	p.data.err = err
 }
 
-// Get returns the body of the message part.
+// Get returns the body of the message part. If the body is backed by a spool
+// then it is read into memory, and when the spool cannot be read the message
+// part is flagged with the error and nil is returned.
 func (p *Part) Get() []byte {
+	if p.data.rawBytes == nil && p.data.spool != nil {
+		b, err := p.readSpool()
+		if err != nil {
+			p.data.err = fmt.Errorf("failed to read spooled payload: %w", err)
+			return nil
+		}
+		p.data.rawBytes = b
+	}
 	if len(p.data.rawBytes) == 0 && p.data.jsonCache != nil {
 		var buf bytes.Buffer
 		enc := json.NewEncoder(&buf)
@@ -160,6 +195,13 @@ func (p *Part) JSON() (interface{}, error) {
 	if p.data.jsonCache != nil {
 		return p.data.jsonCache, nil
 	}
+	if p.data.rawBytes == nil && p.data.spool != nil {
+		b, err := p.readSpool()
+		if err != nil {
+			return nil, err
+		}
+		p.data.rawBytes = b
+	}
 	if p.data.rawBytes == nil {
 		return nil, ErrMessagePartNotExist
 	}
@@ -189,14 +231,58 @@ func (p *Part) JSON() (interface{}, error) {
 // Set the value of the message part.
 func (p *Part) Set(data []byte) *Part {
 	p.data.rawBytes = data
+	p.data.spool = nil
 	p.data.jsonCache = nil
 	return p
 }
 
+// SetSpool sets the value of the message part to the contents of a spool.
+func (p *Part) SetSpool(s *Spool) *Part {
+	p.data.rawBytes = nil
+	p.data.spool = s
+	p.data.jsonCache = nil
+	return p
+}
+
+// Spool returns the spool backing the contents of the message part, or nil if
+// the contents are held in memory.
+func (p *Part) Spool() *Spool {
+	return p.data.spool
+}
+
+// BodyReader returns a reader of the body of the message part, which must be
+// closed by the caller once finished. If the body is backed by a spool then it
+// is streamed without being read into memory.
+func (p *Part) BodyReader() (io.ReadCloser, error) {
+	if p.data.rawBytes == nil && p.data.spool != nil {
+		return p.data.spool.Open()
+	}
+	return io.NopCloser(bytes.NewReader(p.Get())), nil
+}
+
+// BodySize returns the size in bytes of the body of the message part without
+// reading spooled contents into memory.
+func (p *Part) BodySize() int64 {
+	if p.data.rawBytes == nil && p.data.spool != nil {
+		return p.data.spool.Size()
+	}
+	return int64(len(p.Get()))
+}
+
+func (p *Part) readSpool() ([]byte, error) {
+	r, err := p.data.spool.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
 // SetJSON attempts to marshal a JSON document into a byte slice and stores the
 // result as the contents of the message part.
 func (p *Part) SetJSON(jObj interface{}) {
 	p.data.rawBytes = nil
+	p.data.spool = nil
 	if jObj == nil {
 		p.data.rawBytes = []byte(`null`)
 	}
@@ -215,21 +301,15 @@ func (p *Part) MetaGet(key string) string {
 
 // MetaSet sets the value of a metadata key.
 func (p *Part) MetaSet(key, value string) {
-	if p.data.metadata == nil {
-		p.data.metadata = map[string]string{
-			key: value,
-		}
-		return
-	}
-	p.data.metadata[key] = value
+	p.writableMeta()[key] = value
 }
 
 // MetaDelete removes the value of a metadata key.
 func (p *Part) MetaDelete(key string) {
-	if p.data.metadata == nil {
+	if _, exists := p.data.metadata[key]; !exists {
 		return
 	}
-	delete(p.data.metadata, key)
+	delete(p.writableMeta(), key)
 }
 
 // MetaIter iterates each metadata key/value pair.
@@ -237,7 +317,7 @@ func (p *Part) MetaIter(f func(k, v string) error) error {
 	if p.data.metadata == nil {
 		// Warning: If we remove this we need to compensate with a way to force
 		// initialisation
-		p.data.metadata = map[string]string{}
+		p.writableMeta()
 		return nil
 	}
 	for ak, av := range p.data.metadata {
@@ -248,9 +328,38 @@ func (p *Part) MetaIter(f func(k, v string) error) error {
 	return nil
 }
 
+// writableMeta returns the metadata map of the part in a state where it is
+// safe to modify, which means cloning it first when it is shared with other
+// message parts.
+func (p *Part) writableMeta() map[string]string {
+	if p.data.metadata == nil {
+		p.data.metadata = map[string]string{}
+		p.data.metaRefs = newMetaRefs()
+		return p.data.metadata
+	}
+	if atomic.LoadInt32(p.data.metaRefs) > 1 {
+		cloned := cloneMeta(p.data.metadata)
+		atomic.AddInt32(p.data.metaRefs, -1)
+		p.data.metadata = cloned
+		p.data.metaRefs = newMetaRefs()
+	}
+	return p.data.metadata
+}
+
+func cloneMeta(m map[string]string) map[string]string {
+	cloned := make(map[string]string, len(m))
+	for k, v := range m {
+		cloned[k] = v
+	}
+	return cloned
+}
+
 //------------------------------------------------------------------------------
 
 // IsEmpty returns true if the message part is empty.
 func (p *Part) IsEmpty() bool {
+	if p.data.rawBytes == nil && p.data.spool != nil {
+		return p.data.spool.Size() == 0
+	}
 	return len(p.data.rawBytes) == 0 && p.data.jsonCache == nil
 }
@@ -1,6 +1,7 @@
 package message
 
 import (
+	"fmt"
 	"reflect"
 	"testing"
 )
@@ -83,6 +84,62 @@ func TestPartShallowCopy(t *testing.T) {
 	}
 }
 
+func TestPartCopyOnWriteMetadata(t *testing.T) {
+	p := NewPart(nil)
+	p.MetaSet("foo", "bar")
+
+	p2 := p.Copy()
+	p3 := p.Copy()
+	if exp, act := reflect.ValueOf(p.data.metadata).Pointer(), reflect.ValueOf(p2.data.metadata).Pointer(); exp != act {
+		t.Error("Expected metadata to be shared after copy")
+	}
+
+	p2.MetaSet("foo", "baz")
+	p3.MetaDelete("foo")
+	p.MetaSet("bar", "qux")
+
+	if exp, act := "bar", p.MetaGet("foo"); exp != act {
+		t.Errorf("Wrong result: %v != %v", act, exp)
+	}
+	if exp, act := "qux", p.MetaGet("bar"); exp != act {
+		t.Errorf("Wrong result: %v != %v", act, exp)
+	}
+	if exp, act := "baz", p2.MetaGet("foo"); exp != act {
+		t.Errorf("Wrong result: %v != %v", act, exp)
+	}
+	if exp, act := "", p2.MetaGet("bar"); exp != act {
+		t.Errorf("Wrong result: %v != %v", act, exp)
+	}
+	if exp, act := "", p3.MetaGet("foo"); exp != act {
+		t.Errorf("Wrong result: %v != %v", act, exp)
+	}
+	if exp, act := "", p3.MetaGet("bar"); exp != act {
+		t.Errorf("Wrong result: %v != %v", act, exp)
+	}
+
+	// Once no longer shared the map is modified in place
+	metaPtr := reflect.ValueOf(p2.data.metadata).Pointer()
+	p2.MetaSet("buz", "quz")
+	if exp, act := metaPtr, reflect.ValueOf(p2.data.metadata).Pointer(); exp != act {
+		t.Error("Expected unshared metadata to be modified in place")
+	}
+}
+
+func BenchmarkPartCopy(b *testing.B) {
+	p := NewPart([]byte(`hello world`))
+	for i := 0; i < 10; i++ {
+		p.MetaSet(fmt.Sprintf("key%v", i), "value")
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		p2 := p.Copy()
+		_ = p2.MetaGet("key0")
+	}
+}
+
 func TestPartCopyDirtyJSON(t *testing.T) {
 	p := NewPart(nil)
 	dirtyObj := map[string]int{
@@ -0,0 +1,155 @@
+package message
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+)
+
+// Spool is a reference to a message payload that has been written to a
+// temporary file rather than held in memory. Spools are immutable once
+// created and can therefore be shared between copies of a message part.
+//
+// The underlying file should be removed explicitly with Remove once the
+// message it was created for has been acknowledged. Spools derived from
+// another spool with Derive are removed along with it, which allows
+// processors to replace the spool of a message without managing its
+// lifetime. As a fallback for spools that are never removed explicitly, the
+// file is also removed once the spool is garbage collected.
+type Spool struct {
+	path string
+	size int64
+
+	mut     sync.Mutex
+	removed bool
+	derived []*Spool
+	rmErr   error
+}
+
+// NewSpool creates a temporary file within a directory (or the default
+// directory for temporary files when empty) and calls the provided closure
+// in order to write the contents of the spool.
+func NewSpool(dir string, fn func(w io.Writer) error) (*Spool, error) {
+	f, err := os.CreateTemp(dir, "benthos-spool-*")
+	if err != nil {
+		return nil, err
+	}
+
+	counter := &countingWriter{w: f}
+	if err = fn(counter); err == nil {
+		err = f.Sync()
+	}
+	if cErr := f.Close(); err == nil {
+		err = cErr
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+		return nil, err
+	}
+
+	s := &Spool{
+		path: f.Name(),
+		size: counter.n,
+	}
+	runtime.SetFinalizer(s, func(s *Spool) {
+		_ = s.Remove()
+	})
+	return s, nil
+}
+
+// Derive creates a new spool within the same directory as this spool, calling
+// the provided closure in order to write its contents. The new spool is
+// removed when this spool is removed.
+func (s *Spool) Derive(fn func(w io.Writer) error) (*Spool, error) {
+	d, err := NewSpool(filepath.Dir(s.path), fn)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mut.Lock()
+	removed := s.removed
+	if !removed {
+		s.derived = append(s.derived, d)
+	}
+	s.mut.Unlock()
+
+	if removed {
+		_ = d.Remove()
+		return nil, errors.New("spool has been removed")
+	}
+	return d, nil
+}
+
+// DeriveFromReader creates a new spool from the contents of an io.Reader that
+// is removed when this spool is removed.
+func (s *Spool) DeriveFromReader(r io.Reader) (*Spool, error) {
+	return s.Derive(func(w io.Writer) error {
+		_, err := io.Copy(w, r)
+		return err
+	})
+}
+
+// NewSpoolFromReader creates a spool from the contents of an io.Reader.
+func NewSpoolFromReader(dir string, r io.Reader) (*Spool, error) {
+	return NewSpool(dir, func(w io.Writer) error {
+		_, err := io.Copy(w, r)
+		return err
+	})
+}
+
+// Path returns the path of the file backing the spool.
+func (s *Spool) Path() string {
+	return s.path
+}
+
+// Size returns the size in bytes of the spooled payload.
+func (s *Spool) Size() int64 {
+	return s.size
+}
+
+// Open returns a reader of the spooled payload, which must be closed by the
+// caller once finished.
+func (s *Spool) Open() (io.ReadCloser, error) {
+	return os.Open(s.path)
+}
+
+// Remove deletes the file backing the spool along with any spools derived
+// from it. Subsequent attempts to read the spool will fail.
+func (s *Spool) Remove() error {
+	s.mut.Lock()
+	if s.removed {
+		err := s.rmErr
+		s.mut.Unlock()
+		return err
+	}
+	s.removed = true
+	derived := s.derived
+	s.derived = nil
+
+	if err := os.Remove(s.path); err != nil && !os.IsNotExist(err) {
+		s.rmErr = err
+	}
+	err := s.rmErr
+	s.mut.Unlock()
+
+	for _, d := range derived {
+		if dErr := d.Remove(); err == nil {
+			err = dErr
+		}
+	}
+	return err
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
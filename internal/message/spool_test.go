@@ -0,0 +1,102 @@
+package message
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpooledPart(t *testing.T) {
+	dir := t.TempDir()
+
+	spool, err := NewSpool(dir, func(w io.Writer) error {
+		_, err := w.Write([]byte(`{"foo":"bar"}`))
+		return err
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(13), spool.Size())
+
+	p := NewSpooledPart(spool)
+	p.MetaSet("foo", "bar")
+	assert.False(t, p.IsEmpty())
+	assert.Equal(t, int64(13), p.BodySize())
+
+	r, err := p.BodyReader()
+	require.NoError(t, err)
+	b, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+	assert.Equal(t, `{"foo":"bar"}`, string(b))
+
+	pCopy := p.Copy()
+	assert.Equal(t, spool, pCopy.Spool())
+
+	v, err := pCopy.JSON()
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"foo": "bar"}, v)
+
+	assert.Equal(t, `{"foo":"bar"}`, string(p.Get()))
+	assert.Equal(t, spool, p.Spool())
+
+	p.Set([]byte("changed"))
+	assert.Nil(t, p.Spool())
+	assert.Equal(t, spool, pCopy.Spool())
+
+	require.NoError(t, spool.Remove())
+	_, err = os.Stat(spool.Path())
+	assert.True(t, os.IsNotExist(err))
+	require.NoError(t, spool.Remove())
+}
+
+func TestSpoolWriteError(t *testing.T) {
+	dir := t.TempDir()
+
+	_, err := NewSpool(dir, func(w io.Writer) error {
+		return io.ErrUnexpectedEOF
+	})
+	require.Error(t, err)
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestSpoolDerivedRemoved(t *testing.T) {
+	dir := t.TempDir()
+
+	spool, err := NewSpoolFromReader(dir, strings.NewReader("foo"))
+	require.NoError(t, err)
+
+	derived, err := spool.DeriveFromReader(strings.NewReader("bar"))
+	require.NoError(t, err)
+	assert.Equal(t, dir, filepath.Dir(derived.Path()))
+
+	require.NoError(t, spool.Remove())
+	for _, p := range []string{spool.Path(), derived.Path()} {
+		_, err = os.Stat(p)
+		assert.True(t, os.IsNotExist(err), p)
+	}
+
+	_, err = spool.DeriveFromReader(strings.NewReader("baz"))
+	require.Error(t, err)
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestSpooledPartReadError(t *testing.T) {
+	spool, err := NewSpoolFromReader(t.TempDir(), strings.NewReader("foo"))
+	require.NoError(t, err)
+	require.NoError(t, spool.Remove())
+
+	p := NewSpooledPart(spool)
+	assert.Nil(t, p.Get())
+	require.Error(t, p.ErrorGet())
+	assert.Contains(t, p.ErrorGet().Error(), "failed to read spooled payload")
+}
@@ -0,0 +1,208 @@
+package metadata
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/benthosdev/benthos/v4/internal/docs"
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+// PolicyFields returns a docs spec for the fields within a metadata policy
+// config struct.
+func PolicyFields() docs.FieldSpecs {
+	return docs.FieldSpecs{
+		docs.FieldString("allow_prefixes", "A list of metadata key prefixes, where when either this or `allow_patterns` is set only keys matching at least one prefix or pattern are kept, and all other keys are removed from messages before they are written by any output.").
+			Array().HasDefault([]interface{}{}),
+		docs.FieldString("allow_patterns", "A list of regular expression patterns, where when either this or `allow_prefixes` is set only keys matching at least one prefix or pattern are kept, and all other keys are removed from messages before they are written by any output.").
+			Array().HasDefault([]interface{}{}),
+		docs.FieldString("exclude_prefixes", "A list of metadata key prefixes, where matching keys are removed from messages before they are written by any output.").
+			Array().HasDefault([]interface{}{}),
+		docs.FieldString("exclude_patterns", "A list of regular expression patterns, where matching keys are removed from messages before they are written by any output.").
+			Array().HasDefault([]interface{}{}),
+		docs.FieldString("redact_patterns", "A list of regular expression patterns, where the values of matching keys are replaced with the `redact_value` before messages are written by any output.").
+			Array().HasDefault([]interface{}{}),
+		docs.FieldString("redact_value", "The value to replace redacted metadata values with.").HasDefault("[REDACTED]"),
+		docs.FieldInt("max_size_bytes", "The maximum combined size of the keys and values of the metadata of a message. When exceeded the largest metadata entries are removed until the total falls within the limit. Set to zero in order to disable.").HasDefault(0),
+	}
+}
+
+// PolicyConfig describes rules applied to the metadata of all messages before
+// they are written by an output. Allow rules are applied first, followed by
+// exclusions, redactions and finally the size limit. By default no rules are
+// set and metadata is written unchanged.
+type PolicyConfig struct {
+	AllowPrefixes   []string `json:"allow_prefixes" yaml:"allow_prefixes"`
+	AllowPatterns   []string `json:"allow_patterns" yaml:"allow_patterns"`
+	ExcludePrefixes []string `json:"exclude_prefixes" yaml:"exclude_prefixes"`
+	ExcludePatterns []string `json:"exclude_patterns" yaml:"exclude_patterns"`
+	RedactPatterns  []string `json:"redact_patterns" yaml:"redact_patterns"`
+	RedactValue     string   `json:"redact_value" yaml:"redact_value"`
+	MaxSizeBytes    int      `json:"max_size_bytes" yaml:"max_size_bytes"`
+}
+
+// NewPolicyConfig returns a PolicyConfig struct with default values.
+func NewPolicyConfig() PolicyConfig {
+	return PolicyConfig{
+		AllowPrefixes:   []string{},
+		AllowPatterns:   []string{},
+		ExcludePrefixes: []string{},
+		ExcludePatterns: []string{},
+		RedactPatterns:  []string{},
+		RedactValue:     "[REDACTED]",
+		MaxSizeBytes:    0,
+	}
+}
+
+// IsSet returns true if there are any rules configured within the policy.
+func (c PolicyConfig) IsSet() bool {
+	return len(c.AllowPrefixes) > 0 ||
+		len(c.AllowPatterns) > 0 ||
+		len(c.ExcludePrefixes) > 0 ||
+		len(c.ExcludePatterns) > 0 ||
+		len(c.RedactPatterns) > 0 ||
+		c.MaxSizeBytes > 0
+}
+
+// Policy attempts to construct a metadata policy.
+func (c PolicyConfig) Policy() (*Policy, error) {
+	p := &Policy{
+		allowAll:        len(c.AllowPrefixes) == 0 && len(c.AllowPatterns) == 0,
+		allowPrefixes:   c.AllowPrefixes,
+		excludePrefixes: c.ExcludePrefixes,
+		redactValue:     c.RedactValue,
+		maxSize:         c.MaxSizeBytes,
+	}
+	for _, pattern := range c.AllowPatterns {
+		compiled, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compile allow pattern '%v': %w", pattern, err)
+		}
+		p.allowPatterns = append(p.allowPatterns, compiled)
+	}
+	for _, pattern := range c.ExcludePatterns {
+		compiled, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compile exclude pattern '%v': %w", pattern, err)
+		}
+		p.excludePatterns = append(p.excludePatterns, compiled)
+	}
+	for _, pattern := range c.RedactPatterns {
+		compiled, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compile redact pattern '%v': %w", pattern, err)
+		}
+		p.redactPatterns = append(p.redactPatterns, compiled)
+	}
+	return p, nil
+}
+
+// Policy applies allow, exclusion, redaction and size rules to message
+// metadata.
+type Policy struct {
+	allowAll        bool
+	allowPrefixes   []string
+	allowPatterns   []*regexp.Regexp
+	excludePrefixes []string
+	excludePatterns []*regexp.Regexp
+	redactPatterns  []*regexp.Regexp
+	redactValue     string
+	maxSize         int
+}
+
+func (p *Policy) allowed(k string) bool {
+	if p.allowAll {
+		return true
+	}
+	for _, prefix := range p.allowPrefixes {
+		if strings.HasPrefix(k, prefix) {
+			return true
+		}
+	}
+	for _, pattern := range p.allowPatterns {
+		if pattern.MatchString(k) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Policy) excluded(k string) bool {
+	for _, prefix := range p.excludePrefixes {
+		if strings.HasPrefix(k, prefix) {
+			return true
+		}
+	}
+	for _, pattern := range p.excludePatterns {
+		if pattern.MatchString(k) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Policy) redacted(k string) bool {
+	for _, pattern := range p.redactPatterns {
+		if pattern.MatchString(k) {
+			return true
+		}
+	}
+	return false
+}
+
+// Apply modifies the metadata of a message part in place according to the
+// policy.
+func (p *Policy) Apply(part *message.Part) {
+	type entry struct {
+		key  string
+		size int
+	}
+
+	var entries []entry
+	var remove []string
+	redact := map[string]struct{}{}
+	totalSize := 0
+
+	_ = part.MetaIter(func(k, v string) error {
+		if !p.allowed(k) || p.excluded(k) {
+			remove = append(remove, k)
+			return nil
+		}
+		if p.redacted(k) {
+			redact[k] = struct{}{}
+			v = p.redactValue
+		}
+		entries = append(entries, entry{key: k, size: len(k) + len(v)})
+		totalSize += len(k) + len(v)
+		return nil
+	})
+
+	for _, k := range remove {
+		part.MetaDelete(k)
+	}
+	for k := range redact {
+		part.MetaSet(k, p.redactValue)
+	}
+
+	if p.maxSize <= 0 || totalSize <= p.maxSize {
+		return
+	}
+
+	// Remove the largest entries first, and ties are broken by key so that
+	// the result is deterministic.
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].size == entries[j].size {
+			return entries[i].key < entries[j].key
+		}
+		return entries[i].size > entries[j].size
+	})
+	for _, e := range entries {
+		if totalSize <= p.maxSize {
+			break
+		}
+		part.MetaDelete(e.key)
+		totalSize -= e.size
+	}
+}
@@ -0,0 +1,171 @@
+package metadata
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/message"
+)
+
+func TestPolicy(t *testing.T) {
+	tests := []struct {
+		name       string
+		inputMeta  map[string]string
+		outputMeta map[string]string
+		conf       func(c *PolicyConfig)
+	}{
+		{
+			name: "no rules",
+			inputMeta: map[string]string{
+				"foo": "foo1",
+				"bar": "bar1",
+			},
+			outputMeta: map[string]string{
+				"foo": "foo1",
+				"bar": "bar1",
+			},
+			conf: func(c *PolicyConfig) {},
+		},
+		{
+			name: "exclude prefixes and patterns",
+			inputMeta: map[string]string{
+				"internal_foo": "foo1",
+				"x-secret":     "bar1",
+				"baz":          "baz1",
+			},
+			outputMeta: map[string]string{
+				"baz": "baz1",
+			},
+			conf: func(c *PolicyConfig) {
+				c.ExcludePrefixes = []string{"internal_"}
+				c.ExcludePatterns = []string{"(?i)secret"}
+			},
+		},
+		{
+			name: "allow prefixes and patterns",
+			inputMeta: map[string]string{
+				"public_foo": "foo1",
+				"trace_id":   "bar1",
+				"baz":        "baz1",
+			},
+			outputMeta: map[string]string{
+				"public_foo": "foo1",
+				"trace_id":   "bar1",
+			},
+			conf: func(c *PolicyConfig) {
+				c.AllowPrefixes = []string{"public_"}
+				c.AllowPatterns = []string{"_id$"}
+			},
+		},
+		{
+			name: "allow then exclude",
+			inputMeta: map[string]string{
+				"public_foo":    "foo1",
+				"public_secret": "bar1",
+				"baz":           "baz1",
+			},
+			outputMeta: map[string]string{
+				"public_foo": "foo1",
+			},
+			conf: func(c *PolicyConfig) {
+				c.AllowPrefixes = []string{"public_"}
+				c.ExcludePatterns = []string{"secret"}
+			},
+		},
+		{
+			name: "redact patterns",
+			inputMeta: map[string]string{
+				"Authorization": "Bearer abc",
+				"baz":           "baz1",
+			},
+			outputMeta: map[string]string{
+				"Authorization": "***",
+				"baz":           "baz1",
+			},
+			conf: func(c *PolicyConfig) {
+				c.RedactPatterns = []string{"^Authorization$"}
+				c.RedactValue = "***"
+			},
+		},
+		{
+			name: "size cap removes largest",
+			inputMeta: map[string]string{
+				"a": "1234567890",
+				"b": "12",
+				"c": "12",
+			},
+			outputMeta: map[string]string{
+				"b": "12",
+				"c": "12",
+			},
+			conf: func(c *PolicyConfig) {
+				c.MaxSizeBytes = 10
+			},
+		},
+		{
+			name: "size cap ties broken by key",
+			inputMeta: map[string]string{
+				"a": "12",
+				"b": "12",
+				"c": "12",
+			},
+			outputMeta: map[string]string{
+				"b": "12",
+				"c": "12",
+			},
+			conf: func(c *PolicyConfig) {
+				c.MaxSizeBytes = 6
+			},
+		},
+		{
+			name: "size cap counts redacted values",
+			inputMeta: map[string]string{
+				"token": "a very long token value that would otherwise exceed the cap",
+				"b":     "12",
+			},
+			outputMeta: map[string]string{
+				"token": "x",
+				"b":     "12",
+			},
+			conf: func(c *PolicyConfig) {
+				c.RedactPatterns = []string{"token"}
+				c.RedactValue = "x"
+				c.MaxSizeBytes = 10
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			part := message.NewPart(nil)
+			for k, v := range test.inputMeta {
+				part.MetaSet(k, v)
+			}
+
+			conf := NewPolicyConfig()
+			test.conf(&conf)
+			policy, err := conf.Policy()
+			require.NoError(t, err)
+
+			policy.Apply(part)
+
+			outputMeta := map[string]string{}
+			require.NoError(t, part.MetaIter(func(k, v string) error {
+				outputMeta[k] = v
+				return nil
+			}))
+			assert.Equal(t, test.outputMeta, outputMeta)
+		})
+	}
+}
+
+func TestPolicyBadPattern(t *testing.T) {
+	conf := NewPolicyConfig()
+	conf.ExcludePatterns = []string{"("}
+	_, err := conf.Policy()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to compile exclude pattern")
+}
@@ -0,0 +1,36 @@
+package schema
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// LoadAvro reads an Avro schema from a path, which must either be a file path
+// prefixed with file:// or an HTTP(S) URL.
+func LoadAvro(schemaPath string) (string, error) {
+	if !(strings.HasPrefix(schemaPath, "file://") || strings.HasPrefix(schemaPath, "http://") || strings.HasPrefix(schemaPath, "https://")) {
+		return "", fmt.Errorf("invalid schema_path provided, must start with file://, http:// or https://")
+	}
+
+	t := &http.Transport{}
+	t.RegisterProtocol("file", http.NewFileTransport(http.Dir("/")))
+	c := &http.Client{Transport: t}
+
+	res, err := c.Get(schemaPath)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("request returned status: %v", res.StatusCode)
+	}
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return "", err
+	}
+	return string(body), nil
+}
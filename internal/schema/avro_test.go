@@ -0,0 +1,44 @@
+package schema
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadAvro(t *testing.T) {
+	schemaPath := filepath.Join(t.TempDir(), "schema.json")
+	require.NoError(t, os.WriteFile(schemaPath, []byte(`{"type":"string"}`), 0o644))
+
+	s, err := LoadAvro("file://" + schemaPath)
+	require.NoError(t, err)
+	assert.Equal(t, `{"type":"string"}`, s)
+
+	_, err = LoadAvro(schemaPath)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid schema_path")
+}
+
+func TestLoadAvroHTTPStatus(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/schema.json" {
+			_, _ = w.Write([]byte(`{"type":"string"}`))
+			return
+		}
+		http.Error(w, "not found", http.StatusNotFound)
+	}))
+	defer ts.Close()
+
+	s, err := LoadAvro(ts.URL + "/schema.json")
+	require.NoError(t, err)
+	assert.Equal(t, `{"type":"string"}`, s)
+
+	_, err = LoadAvro(ts.URL + "/nope.json")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "404")
+}
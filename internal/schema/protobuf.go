@@ -0,0 +1,49 @@
+package schema
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/desc/protoparse"
+)
+
+// LoadProtoDescriptors parses all .proto files found within a list of import
+// paths, or the current directory when none are provided.
+func LoadProtoDescriptors(importPaths []string) ([]*desc.FileDescriptor, error) {
+	var parser protoparse.Parser
+	if len(importPaths) == 0 {
+		importPaths = []string{"."}
+	} else {
+		parser.ImportPaths = importPaths
+	}
+
+	var files []string
+	for _, importPath := range importPaths {
+		if err := filepath.Walk(importPath, func(path string, info os.FileInfo, ferr error) error {
+			if ferr != nil || info.IsDir() {
+				return ferr
+			}
+			if filepath.Ext(info.Name()) == ".proto" {
+				rPath, ferr := filepath.Rel(importPath, path)
+				if ferr != nil {
+					return fmt.Errorf("failed to get relative path: %v", ferr)
+				}
+				files = append(files, rPath)
+			}
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	fds, err := parser.ParseFiles(files...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse .proto file: %v", err)
+	}
+	if len(fds) == 0 {
+		return nil, fmt.Errorf("no .proto files were found in the paths '%v'", importPaths)
+	}
+	return fds, nil
+}
@@ -15,10 +15,11 @@ import (
 // Config is a configuration struct representing all four layers of a Benthos
 // stream.
 type Config struct {
-	Input    input.Config    `json:"input" yaml:"input"`
-	Buffer   buffer.Config   `json:"buffer" yaml:"buffer"`
-	Pipeline pipeline.Config `json:"pipeline" yaml:"pipeline"`
-	Output   output.Config   `json:"output" yaml:"output"`
+	Input    input.Config      `json:"input" yaml:"input"`
+	Buffer   buffer.Config     `json:"buffer" yaml:"buffer"`
+	Pipeline pipeline.Config   `json:"pipeline" yaml:"pipeline"`
+	Output   output.Config     `json:"output" yaml:"output"`
+	Labels   map[string]string `json:"labels" yaml:"labels"`
 }
 
 // NewConfig returns a new configuration with default values.
@@ -28,6 +29,7 @@ func NewConfig() Config {
 		Buffer:   buffer.NewConfig(),
 		Pipeline: pipeline.NewConfig(),
 		Output:   output.NewConfig(),
+		Labels:   map[string]string{},
 	}
 }
 
@@ -0,0 +1,140 @@
+package stream
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/benthosdev/benthos/v4/internal/component"
+)
+
+// InputController is implemented by managers that are able to pause and resume
+// the inputs that they create.
+type InputController interface {
+	PauseInput(key string) error
+	ResumeInput(key string) error
+	InputKeys() []string
+	InputPaused(key string) (bool, error)
+}
+
+type inFlightCounter interface {
+	InFlight() int
+}
+
+// Drain stops the stream by closing the input layer and waiting for all
+// in-flight messages to be resolved before closing the remaining layers. If
+// the timeout is reached before the stream has drained then it is stopped
+// regardless. Returns the number of messages that were still in flight when the
+// stream was stopped.
+func (t *Type) Drain(timeout time.Duration) (remaining int, err error) {
+	started := time.Now()
+
+	counter, _ := t.inputLayer.(inFlightCounter)
+	t.inputLayer.CloseAsync()
+	if err = t.inputLayer.WaitForClose(timeout); err == nil && counter != nil {
+		for counter.InFlight() > 0 {
+			if time.Since(started) >= timeout {
+				err = component.ErrTimeout
+				break
+			}
+			time.Sleep(time.Millisecond * 10)
+		}
+	}
+	if counter != nil {
+		remaining = counter.InFlight()
+	}
+
+	if err == nil {
+		err = t.StopGracefully(timeout - time.Since(started))
+	}
+	if err != nil {
+		_ = t.StopUnordered(time.Second)
+	}
+	return
+}
+
+func (t *Type) registerControlEndpoints() {
+	if ctrl, ok := t.manager.(InputController); ok {
+		t.manager.RegisterEndpoint(
+			"/inputs",
+			"GET: List the labels of all inputs that can be paused along with whether they are paused, where an unlabelled stream input is listed as `root.input`.",
+			func(w http.ResponseWriter, r *http.Request) {
+				type inputState struct {
+					Input  string `json:"input"`
+					Paused bool   `json:"paused"`
+				}
+				states := []inputState{}
+				for _, k := range ctrl.InputKeys() {
+					paused, _ := ctrl.InputPaused(k)
+					states = append(states, inputState{Input: k, Paused: paused})
+				}
+				resBytes, err := json.Marshal(states)
+				if err != nil {
+					http.Error(w, err.Error(), http.StatusInternalServerError)
+					return
+				}
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write(resBytes)
+			},
+		)
+		t.manager.RegisterEndpoint(
+			"/inputs/{input}/pause",
+			"POST: Pause the consumption of messages from an input identified by its label or path, the connection of the input remains open.",
+			inputControlHandler(ctrl.PauseInput),
+		)
+		t.manager.RegisterEndpoint(
+			"/inputs/{input}/resume",
+			"POST: Resume the consumption of messages from a paused input identified by its label or path.",
+			inputControlHandler(ctrl.ResumeInput),
+		)
+	}
+	t.manager.RegisterEndpoint(
+		"/drain",
+		"POST: Stop consuming from inputs and wait for in-flight messages to be resolved before stopping the stream. The deadline can be set with the query parameter `timeout` (default 30s), once exceeded the stream is stopped regardless. Responds with the number of messages still in flight when the stream stopped.",
+		func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPost {
+				http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+				return
+			}
+			timeout := time.Second * 30
+			if tStr := r.URL.Query().Get("timeout"); tStr != "" {
+				var err error
+				if timeout, err = time.ParseDuration(tStr); err != nil {
+					http.Error(w, fmt.Sprintf("Failed to parse timeout: %v", err), http.StatusBadRequest)
+					return
+				}
+			}
+			remaining, err := t.Drain(timeout)
+			if err != nil && !errors.Is(err, component.ErrTimeout) {
+				t.manager.Logger().Errorf("Encountered error whilst draining: %v\n", err)
+			}
+			resBytes, err := json.Marshal(struct {
+				Remaining int `json:"remaining"`
+			}{Remaining: remaining})
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write(resBytes)
+		},
+	)
+}
+
+func inputControlHandler(fn func(key string) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if err := fn(mux.Vars(r)["input"]); err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
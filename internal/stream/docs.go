@@ -14,5 +14,9 @@ func Spec() docs.FieldSpecs {
 			docs.FieldProcessor("processors", "A list of processors to apply to messages.").Array().HasDefault([]interface{}{}),
 		),
 		docs.FieldOutput("output", "An output to sink messages to.").Optional(),
+		docs.FieldString(
+			"labels", "A map of labels that are added to all metrics, log lines and tracing spans produced by the components of the stream, which makes it possible to attribute telemetry to the tenant or team that a stream belongs to when many streams share a Benthos instance. Label names must consist of letters, numbers and underscores, and must not be `stream`, `label` or `path`. Components defined as resources are not labelled as they can be shared by multiple streams.",
+			map[string]string{"tenant": "acme", "team": "payments"},
+		).Map().Advanced().HasDefault(map[string]interface{}{}),
 	}
 }
@@ -28,11 +28,19 @@ type Type struct {
 
 	manager bundle.NewManagement
 
-	onClose func()
+	onClose        func()
+	interceptInput func(<-chan message.Transaction) <-chan message.Transaction
 }
 
 // New creates a new stream.Type.
 func New(conf Config, mgr bundle.NewManagement, opts ...func(*Type)) (*Type, error) {
+	if len(conf.Labels) > 0 {
+		if err := bundle.ValidateLabels(conf.Labels); err != nil {
+			return nil, err
+		}
+		mgr = mgr.WithLabels(conf.Labels)
+	}
+
 	t := &Type{
 		conf:    conf,
 		manager: mgr,
@@ -66,6 +74,7 @@ func New(conf Config, mgr bundle.NewManagement, opts ...func(*Type)) (*Type, err
 		"Returns 200 OK if all inputs and outputs are connected, otherwise a 503 is returned.",
 		healthCheck,
 	)
+	t.registerControlEndpoints()
 	return t, nil
 }
 
@@ -78,6 +87,14 @@ func OptOnClose(onClose func()) func(*Type) {
 	}
 }
 
+// OptInterceptInput sets a closure that receives the transactions of the input
+// layer and returns the transactions to be consumed by the remaining layers.
+func OptInterceptInput(fn func(<-chan message.Transaction) <-chan message.Transaction) func(*Type) {
+	return func(t *Type) {
+		t.interceptInput = fn
+	}
+}
+
 //------------------------------------------------------------------------------
 
 // IsReady returns a boolean indicating whether both the input and output layers
@@ -113,6 +130,9 @@ func (t *Type) start() (err error) {
 	var nextTranChan <-chan message.Transaction
 
 	nextTranChan = t.inputLayer.TransactionChan()
+	if t.interceptInput != nil {
+		nextTranChan = t.interceptInput(nextTranChan)
+	}
 	if t.bufferLayer != nil {
 		if err = t.bufferLayer.Consume(nextTranChan); err != nil {
 			return
@@ -1,14 +1,20 @@
 package stream_test
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
+	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/benthosdev/benthos/v4/internal/component"
+	"github.com/benthosdev/benthos/v4/internal/component/metrics"
 	"github.com/benthosdev/benthos/v4/internal/component/processor"
 	"github.com/benthosdev/benthos/v4/internal/manager"
+	"github.com/benthosdev/benthos/v4/internal/manager/mock"
 	"github.com/benthosdev/benthos/v4/internal/stream"
 
 	_ "github.com/benthosdev/benthos/v4/public/components/all"
@@ -115,3 +121,113 @@ func TestTypeCloseUnordered(t *testing.T) {
 	require.NoError(t, err)
 	assert.NoError(t, strm.StopUnordered(time.Minute))
 }
+
+func TestTypeInputControlEndpoints(t *testing.T) {
+	handlers := map[string]http.HandlerFunc{}
+	apiReg := mock.NewManager()
+	apiReg.OnRegisterEndpoint = func(path string, h http.HandlerFunc) {
+		handlers[path] = h
+	}
+
+	conf := stream.NewConfig()
+	conf.Input.Label = "foo"
+	conf.Input.Type = "generate"
+	conf.Input.Generate.Mapping = `root = "hello world"`
+	conf.Input.Generate.Interval = "1ms"
+	conf.Output.Type = "drop"
+
+	newMgr, err := manager.New(manager.NewResourceConfig(), manager.OptSetAPIReg(apiReg))
+	require.NoError(t, err)
+
+	_, err = stream.New(conf, newMgr)
+	require.NoError(t, err)
+
+	listInputs := func() string {
+		t.Helper()
+		w := httptest.NewRecorder()
+		handlers["/inputs"](w, httptest.NewRequest(http.MethodGet, "/inputs", nil))
+		require.Equal(t, http.StatusOK, w.Code)
+		return w.Body.String()
+	}
+
+	controlInput := func(input, action string) int {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "/inputs/"+input+"/"+action, nil)
+		req = mux.SetURLVars(req, map[string]string{"input": input})
+		w := httptest.NewRecorder()
+		handlers["/inputs/{input}/"+action](w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, `[{"input":"foo","paused":false}]`, listInputs())
+
+	assert.Equal(t, http.StatusNoContent, controlInput("foo", "pause"))
+	assert.Equal(t, `[{"input":"foo","paused":true}]`, listInputs())
+
+	assert.Equal(t, http.StatusNoContent, controlInput("foo", "resume"))
+	assert.Equal(t, `[{"input":"foo","paused":false}]`, listInputs())
+
+	assert.Equal(t, http.StatusNotFound, controlInput("bar", "pause"))
+
+	w := httptest.NewRecorder()
+	handlers["/drain"](w, httptest.NewRequest(http.MethodPost, "/drain?timeout=10s", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `{"remaining":0}`, w.Body.String())
+}
+
+func TestTypeDrainTimeout(t *testing.T) {
+	conf := stream.NewConfig()
+	conf.Input.Type = "generate"
+	conf.Input.Generate.Mapping = `root = "hello world"`
+	conf.Input.Generate.Interval = "1ms"
+	conf.Output.Type = "drop"
+
+	procConf := processor.NewConfig()
+	procConf.Type = "sleep"
+	procConf.Sleep.Duration = "500ms"
+	conf.Pipeline.Processors = append(conf.Pipeline.Processors, procConf)
+
+	newMgr, err := manager.New(manager.NewResourceConfig())
+	require.NoError(t, err)
+
+	strm, err := stream.New(conf, newMgr)
+	require.NoError(t, err)
+
+	// Give the pipeline a chance to begin processing a message that will not
+	// be resolved before the deadline.
+	time.Sleep(time.Millisecond * 50)
+
+	remaining, err := strm.Drain(time.Millisecond * 100)
+	assert.Equal(t, component.ErrTimeout, err)
+	assert.Greater(t, remaining, 0)
+}
+
+func TestTypeLabels(t *testing.T) {
+	conf := stream.NewConfig()
+	conf.Input.Type = "generate"
+	conf.Input.Generate.Mapping = `root = "hello world"`
+	conf.Input.Generate.Interval = "1ms"
+	conf.Output.Type = "drop"
+
+	stats := metrics.NewLocal()
+	newMgr, err := manager.New(manager.NewResourceConfig(), manager.OptSetMetrics(metrics.NewNamespaced(stats)))
+	require.NoError(t, err)
+
+	conf.Labels = map[string]string{"path": "nope"}
+	_, err = stream.New(conf, newMgr)
+	require.EqualError(t, err, "label name 'path' is reserved")
+
+	conf.Labels = map[string]string{"not-valid": "nope"}
+	_, err = stream.New(conf, newMgr)
+	require.Error(t, err)
+
+	conf.Labels = map[string]string{"tenant": "acme"}
+	strm, err := stream.New(conf, newMgr)
+	require.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		return stats.GetCounters()[`output_sent{label="",path="root.output",tenant="acme"}`] > 0
+	}, time.Second*5, time.Millisecond*10)
+
+	require.NoError(t, strm.Stop(time.Minute))
+}
@@ -145,8 +145,15 @@ func registerTemplate(tmpl *compiled) error {
 }
 
 // WithMetricsMapping attempts to wrap the metrics of a manager with a metrics
-// mapping.
+// mapping. Managers that wrap another manager are unwrapped.
 func WithMetricsMapping(nm bundle.NewManagement, m *metrics.Mapping) bundle.NewManagement {
+	for {
+		u, ok := nm.(interface{ Unwrap() bundle.NewManagement })
+		if !ok {
+			break
+		}
+		nm = u.Unwrap()
+	}
 	if t, ok := nm.(*manager.Type); ok {
 		return t.WithMetricsMapping(m)
 	}
@@ -0,0 +1,119 @@
+package tls
+
+import (
+	"crypto/tls"
+	"os"
+	"sync"
+	"time"
+)
+
+// certReloader provides client certificates to a TLS config, reloading those
+// that were loaded from files when the files are modified.
+type certReloader struct {
+	confs    []ClientCertConfig
+	interval time.Duration
+	nowFn    func() time.Time
+
+	mut       sync.Mutex
+	lastCheck time.Time
+	modTimes  []time.Time
+	certs     []tls.Certificate
+}
+
+// WatchClientCerts modifies a TLS config so that client certificates loaded
+// from files are reloaded when either of their files are modified, allowing
+// certificates to be rotated without a restart. Files are checked for
+// modifications at most once per interval when a handshake requests a client
+// certificate, and therefore reloaded certificates are only used by new
+// connections. When a modified certificate fails to load, for example when
+// only one of its files has been replaced so far, the previous certificate
+// continues to be used until the next check.
+func WatchClientCerts(tlsConf *tls.Config, confs []ClientCertConfig, interval time.Duration) error {
+	r, err := newCertReloader(confs, interval, time.Now)
+	if err != nil {
+		return err
+	}
+	tlsConf.Certificates = nil
+	tlsConf.GetClientCertificate = r.getClientCertificate
+	return nil
+}
+
+func newCertReloader(confs []ClientCertConfig, interval time.Duration, nowFn func() time.Time) (*certReloader, error) {
+	r := &certReloader{
+		confs:    confs,
+		interval: interval,
+		nowFn:    nowFn,
+		modTimes: make([]time.Time, len(confs)),
+		certs:    make([]tls.Certificate, len(confs)),
+	}
+	for i, c := range confs {
+		var err error
+		if r.modTimes[i], err = c.modTime(); err != nil {
+			return nil, err
+		}
+		if r.certs[i], err = c.Load(); err != nil {
+			return nil, err
+		}
+	}
+	r.lastCheck = nowFn()
+	return r, nil
+}
+
+// modTime returns the latest modification time of the files of a client
+// certificate, or a zero time when the certificate isn't loaded from files.
+func (c *ClientCertConfig) modTime() (time.Time, error) {
+	var latest time.Time
+	for _, path := range []string{c.CertFile, c.KeyFile} {
+		if path == "" {
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if t := info.ModTime(); t.After(latest) {
+			latest = t
+		}
+	}
+	return latest, nil
+}
+
+func (r *certReloader) reloadIfModified() {
+	now := r.nowFn()
+	if now.Sub(r.lastCheck) < r.interval {
+		return
+	}
+	r.lastCheck = now
+
+	for i, c := range r.confs {
+		if c.CertFile == "" && c.KeyFile == "" {
+			continue
+		}
+		modTime, err := c.modTime()
+		if err != nil || modTime.Equal(r.modTimes[i]) {
+			continue
+		}
+		cert, err := c.Load()
+		if err != nil {
+			continue
+		}
+		r.certs[i] = cert
+		r.modTimes[i] = modTime
+	}
+}
+
+// getClientCertificate selects the first certificate supported by the server,
+// matching the behaviour of a TLS config with static certificates.
+func (r *certReloader) getClientCertificate(info *tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	r.reloadIfModified()
+	for i := range r.certs {
+		if err := info.SupportsCertificate(&r.certs[i]); err == nil {
+			cert := r.certs[i]
+			return &cert, nil
+		}
+	}
+	return &tls.Certificate{}, nil
+}
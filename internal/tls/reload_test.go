@@ -0,0 +1,111 @@
+package tls
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTestCert(t *testing.T, certPath, keyPath, commonName string, modTime time.Time) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	require.NoError(t, os.Chtimes(certPath, modTime, modTime))
+	require.NoError(t, os.Chtimes(keyPath, modTime, modTime))
+}
+
+func certCommonName(t *testing.T, cert *tls.Certificate) string {
+	t.Helper()
+
+	require.NotEmpty(t, cert.Certificate)
+	parsed, err := x509.ParseCertificate(cert.Certificate[0])
+	require.NoError(t, err)
+	return parsed.Subject.CommonName
+}
+
+func TestWatchClientCerts(t *testing.T) {
+	dir := t.TempDir()
+	certPath, keyPath := filepath.Join(dir, "client.pem"), filepath.Join(dir, "client.key")
+
+	modTime := time.Now().Add(-time.Minute)
+	writeTestCert(t, certPath, keyPath, "first", modTime)
+
+	confs := []ClientCertConfig{{CertFile: certPath, KeyFile: keyPath}}
+
+	tlsConf := &tls.Config{}
+	require.NoError(t, WatchClientCerts(tlsConf, confs, time.Minute))
+	assert.Nil(t, tlsConf.Certificates)
+	require.NotNil(t, tlsConf.GetClientCertificate)
+
+	now := time.Now()
+	r, err := newCertReloader(confs, time.Minute, func() time.Time { return now })
+	require.NoError(t, err)
+
+	info := &tls.CertificateRequestInfo{
+		SignatureSchemes: []tls.SignatureScheme{tls.ECDSAWithP256AndSHA256},
+		Version:          tls.VersionTLS13,
+	}
+
+	cert, err := r.getClientCertificate(info)
+	require.NoError(t, err)
+	assert.Equal(t, "first", certCommonName(t, cert))
+
+	// Only the key has been rotated so far, which results in a mismatched pair
+	// and the previous certificate is kept.
+	writeTestCert(t, filepath.Join(dir, "next.pem"), keyPath, "second", modTime.Add(time.Second))
+	now = now.Add(time.Minute)
+
+	cert, err = r.getClientCertificate(info)
+	require.NoError(t, err)
+	assert.Equal(t, "first", certCommonName(t, cert))
+
+	// Both files rotated, but the interval hasn't elapsed since the last check.
+	writeTestCert(t, certPath, keyPath, "second", modTime.Add(2*time.Second))
+	now = now.Add(30 * time.Second)
+
+	cert, err = r.getClientCertificate(info)
+	require.NoError(t, err)
+	assert.Equal(t, "first", certCommonName(t, cert))
+
+	now = now.Add(30 * time.Second)
+
+	cert, err = r.getClientCertificate(info)
+	require.NoError(t, err)
+	assert.Equal(t, "second", certCommonName(t, cert))
+}
+
+func TestWatchClientCertsMissingFile(t *testing.T) {
+	err := WatchClientCerts(&tls.Config{}, []ClientCertConfig{
+		{CertFile: "/does/not/exist.pem", KeyFile: "/does/not/exist.key"},
+	}, time.Minute)
+	require.Error(t, err)
+}
@@ -0,0 +1,51 @@
+package tracing
+
+import (
+	"context"
+	"sort"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// WithAttributes returns a tracer provider where all spans started by its
+// tracers are given a set of static attributes.
+func WithAttributes(prov trace.TracerProvider, attrs map[string]string) trace.TracerProvider {
+	if len(attrs) == 0 {
+		return prov
+	}
+
+	keys := make([]string, 0, len(attrs))
+	for k := range attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	kvs := make([]attribute.KeyValue, 0, len(keys))
+	for _, k := range keys {
+		kvs = append(kvs, attribute.String(k, attrs[k]))
+	}
+	return &attributesProvider{prov: prov, attrs: kvs}
+}
+
+type attributesProvider struct {
+	prov  trace.TracerProvider
+	attrs []attribute.KeyValue
+}
+
+func (a *attributesProvider) Tracer(instrumentationName string, opts ...trace.TracerOption) trace.Tracer {
+	return &attributesTracer{
+		tracer: a.prov.Tracer(instrumentationName, opts...),
+		attrs:  a.attrs,
+	}
+}
+
+type attributesTracer struct {
+	tracer trace.Tracer
+	attrs  []attribute.KeyValue
+}
+
+func (a *attributesTracer) Start(ctx context.Context, spanName string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	opts = append(opts, trace.WithAttributes(a.attrs...))
+	return a.tracer.Start(ctx, spanName, opts...)
+}
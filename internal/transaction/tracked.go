@@ -3,6 +3,7 @@ package transaction
 import (
 	"context"
 	"errors"
+	"sync"
 
 	"github.com/benthosdev/benthos/v4/internal/batch"
 	"github.com/benthosdev/benthos/v4/internal/message"
@@ -79,3 +80,37 @@ func (t *Tracked) resFromError(err error) error {
 func (t *Tracked) Ack(ctx context.Context, err error) error {
 	return t.ackFn(ctx, t.resFromError(err))
 }
+
+// Split returns n transactions that share the message of this transaction,
+// where the upstream acknowledgement is only made once all n have been
+// acknowledged, with the first error received. This is useful when the
+// messages of a transaction are delivered across multiple batches.
+func (t *Tracked) Split(n int) []*Tracked {
+	var mut sync.Mutex
+	remaining := n
+	var res error
+
+	ackFn := func(ctx context.Context, err error) error {
+		mut.Lock()
+		if err != nil && res == nil {
+			res = err
+		}
+		remaining--
+		finished := remaining == 0
+		mut.Unlock()
+		if !finished {
+			return nil
+		}
+		return t.ackFn(ctx, res)
+	}
+
+	splits := make([]*Tracked, n)
+	for i := range splits {
+		splits[i] = &Tracked{
+			msg:   t.msg,
+			group: t.group,
+			ackFn: ackFn,
+		}
+	}
+	return splits
+}
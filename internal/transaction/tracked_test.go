@@ -1,10 +1,12 @@
 package transaction
 
 import (
+	"context"
 	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/benthosdev/benthos/v4/internal/batch"
 	"github.com/benthosdev/benthos/v4/internal/message"
@@ -268,3 +270,31 @@ func BenchmarkErrorNoTagging(b *testing.B) {
 		assert.Equal(b, errTest1, tran.resFromError(errTest1))
 	}
 }
+
+func TestTrackedSplit(t *testing.T) {
+	msg := message.QuickBatch([][]byte{
+		[]byte("foo"),
+		[]byte("bar"),
+	})
+
+	var acks []error
+	tran := NewTracked(msg, func(ctx context.Context, err error) error {
+		acks = append(acks, err)
+		return nil
+	})
+
+	splits := tran.Split(2)
+	require.Len(t, splits, 2)
+
+	errTest := errors.New("test err")
+	require.NoError(t, splits[0].Ack(context.Background(), errTest))
+	assert.Empty(t, acks)
+
+	require.NoError(t, splits[1].Ack(context.Background(), nil))
+	assert.Equal(t, []error{errTest}, acks)
+
+	splits = tran.Split(2)
+	require.NoError(t, splits[1].Ack(context.Background(), nil))
+	require.NoError(t, splits[0].Ack(context.Background(), nil))
+	assert.Equal(t, []error{errTest, nil}, acks)
+}
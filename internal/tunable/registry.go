@@ -0,0 +1,167 @@
+// Package tunable provides a registry of component config fields that can be
+// adjusted at runtime without restarting the stream they belong to.
+package tunable
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/benthosdev/benthos/v4/internal/log"
+)
+
+// ErrTunableNotFound is returned when attempting to change a tunable that has
+// not been registered.
+var ErrTunableNotFound = errors.New("tunable not found")
+
+// Info describes a registered tunable and its current value.
+type Info struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Value       string `json:"value"`
+}
+
+type tunable struct {
+	description string
+	value       string
+	set         func(value string) error
+}
+
+// Registry keeps track of tunables registered by components, where each
+// tunable has a unique name and a function that applies new values to the
+// component that registered it. All changes are logged in order to provide an
+// audit trail.
+type Registry struct {
+	log log.Modular
+
+	mut      sync.Mutex
+	tunables map[string]*tunable
+}
+
+// NewRegistry creates an empty registry of tunables.
+func NewRegistry(logger log.Modular) *Registry {
+	return &Registry{
+		log:      logger,
+		tunables: map[string]*tunable{},
+	}
+}
+
+// Register adds a tunable with its current value and a function for applying
+// new values. The function should return an error if a value is invalid, in
+// which case the value of the tunable is left unchanged. Registering a name
+// that already exists replaces it, which happens when a component is
+// recreated.
+//
+// The returned function removes the tunable, and should be called when the
+// component that registered it is closed. It does nothing if the tunable has
+// since been replaced.
+func (r *Registry) Register(name, description, value string, set func(value string) error) (unregister func()) {
+	t := &tunable{
+		description: description,
+		value:       value,
+		set:         set,
+	}
+
+	r.mut.Lock()
+	r.tunables[name] = t
+	r.mut.Unlock()
+
+	return func() {
+		r.mut.Lock()
+		if r.tunables[name] == t {
+			delete(r.tunables, name)
+		}
+		r.mut.Unlock()
+	}
+}
+
+// List returns all registered tunables sorted by name.
+func (r *Registry) List() []Info {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	infos := make([]Info, 0, len(r.tunables))
+	for k, t := range r.tunables {
+		infos = append(infos, Info{
+			Name:        k,
+			Description: t.description,
+			Value:       t.value,
+		})
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Name < infos[j].Name
+	})
+	return infos
+}
+
+// Set attempts to change the value of a tunable, where source describes the
+// origin of the change for the audit log.
+func (r *Registry) Set(name, value, source string) (Info, error) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	t, exists := r.tunables[name]
+	if !exists {
+		return Info{}, ErrTunableNotFound
+	}
+
+	auditLog := r.log.With("tunable", name, "source", source)
+	if err := t.set(value); err != nil {
+		auditLog.Warnf("Rejected change of tunable '%v' from '%v' to '%v': %v\n", name, t.value, value, err)
+		return Info{}, err
+	}
+	auditLog.Infof("Changed tunable '%v' from '%v' to '%v'\n", name, t.value, value)
+
+	t.value = value
+	return Info{
+		Name:        name,
+		Description: t.description,
+		Value:       t.value,
+	}, nil
+}
+
+// Handler returns an HTTP handler that lists tunables with a GET request, and
+// changes a tunable with a POST request containing a JSON object body with the
+// fields name and value.
+func (r *Registry) Handler() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		var res interface{}
+		switch req.Method {
+		case http.MethodGet:
+			res = r.List()
+		case http.MethodPost:
+			var change struct {
+				Name  string `json:"name"`
+				Value string `json:"value"`
+			}
+			if err := json.NewDecoder(req.Body).Decode(&change); err != nil {
+				http.Error(w, fmt.Sprintf("failed to parse request body: %v", err), http.StatusBadRequest)
+				return
+			}
+			info, err := r.Set(change.Name, change.Value, req.RemoteAddr)
+			if err != nil {
+				status := http.StatusBadRequest
+				if errors.Is(err, ErrTunableNotFound) {
+					status = http.StatusNotFound
+				}
+				http.Error(w, err.Error(), status)
+				return
+			}
+			res = info
+		default:
+			http.Error(w, "method not supported", http.StatusMethodNotAllowed)
+			return
+		}
+
+		resBytes, err := json.Marshal(res)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(resBytes)
+	}
+}
@@ -0,0 +1,102 @@
+package tunable_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/benthosdev/benthos/v4/internal/log"
+	"github.com/benthosdev/benthos/v4/internal/tunable"
+)
+
+func TestRegistrySet(t *testing.T) {
+	r := tunable.NewRegistry(log.Noop())
+
+	var applied []string
+	r.Register("foo", "A foo.", "a", func(value string) error {
+		if value == "bad" {
+			return errors.New("bad value")
+		}
+		applied = append(applied, value)
+		return nil
+	})
+	r.Register("bar", "A bar.", "b", func(string) error { return nil })
+
+	_, err := r.Set("nope", "c", "test")
+	assert.ErrorIs(t, err, tunable.ErrTunableNotFound)
+
+	_, err = r.Set("foo", "bad", "test")
+	assert.EqualError(t, err, "bad value")
+
+	info, err := r.Set("foo", "c", "test")
+	require.NoError(t, err)
+	assert.Equal(t, tunable.Info{Name: "foo", Description: "A foo.", Value: "c"}, info)
+
+	assert.Equal(t, []string{"c"}, applied)
+	assert.Equal(t, []tunable.Info{
+		{Name: "bar", Description: "A bar.", Value: "b"},
+		{Name: "foo", Description: "A foo.", Value: "c"},
+	}, r.List())
+}
+
+func TestRegistryUnregister(t *testing.T) {
+	r := tunable.NewRegistry(log.Noop())
+
+	noop := func(string) error { return nil }
+	unregisterFoo := r.Register("foo", "A foo.", "a", noop)
+	unregisterBar := r.Register("bar", "A bar.", "b", noop)
+
+	unregisterFoo()
+	assert.Equal(t, []tunable.Info{
+		{Name: "bar", Description: "A bar.", Value: "b"},
+	}, r.List())
+
+	_, err := r.Set("foo", "c", "test")
+	assert.ErrorIs(t, err, tunable.ErrTunableNotFound)
+
+	// A tunable that was replaced by a recreated component is not removed by
+	// the component it replaced.
+	_ = r.Register("bar", "A new bar.", "c", noop)
+	unregisterBar()
+	assert.Equal(t, []tunable.Info{
+		{Name: "bar", Description: "A new bar.", Value: "c"},
+	}, r.List())
+}
+
+func TestRegistryHandler(t *testing.T) {
+	r := tunable.NewRegistry(log.Noop())
+	r.Register("foo", "A foo.", "a", func(value string) error {
+		if value == "bad" {
+			return errors.New("bad value")
+		}
+		return nil
+	})
+
+	handler := r.Handler()
+	do := func(method, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler(w, httptest.NewRequest(method, "/tunables", strings.NewReader(body)))
+		return w
+	}
+
+	w := do(http.MethodGet, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `[{"name":"foo","description":"A foo.","value":"a"}]`, w.Body.String())
+
+	w = do(http.MethodPost, `{"name":"foo","value":"b"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"name":"foo","description":"A foo.","value":"b"}`, w.Body.String())
+
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodPost, `{"name":"foo","value":"bad"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodPost, `not json`).Code)
+	assert.Equal(t, http.StatusNotFound, do(http.MethodPost, `{"name":"nope","value":"b"}`).Code)
+	assert.Equal(t, http.StatusMethodNotAllowed, do(http.MethodDelete, "").Code)
+
+	w = do(http.MethodGet, "")
+	assert.JSONEq(t, `[{"name":"foo","description":"A foo.","value":"b"}]`, w.Body.String())
+}
@@ -14,31 +14,42 @@ import (
 	_ "github.com/benthosdev/benthos/v4/internal/impl/cassandra"
 	_ "github.com/benthosdev/benthos/v4/internal/impl/confluent"
 	_ "github.com/benthosdev/benthos/v4/internal/impl/dgraph"
+	_ "github.com/benthosdev/benthos/v4/internal/impl/dns"
+	_ "github.com/benthosdev/benthos/v4/internal/impl/docker"
 	_ "github.com/benthosdev/benthos/v4/internal/impl/elasticsearch"
 	_ "github.com/benthosdev/benthos/v4/internal/impl/elasticsearch/aws"
 	_ "github.com/benthosdev/benthos/v4/internal/impl/gcp"
+	_ "github.com/benthosdev/benthos/v4/internal/impl/graphql"
 	_ "github.com/benthosdev/benthos/v4/internal/impl/hdfs"
 	_ "github.com/benthosdev/benthos/v4/internal/impl/influxdb"
 	_ "github.com/benthosdev/benthos/v4/internal/impl/io"
 	_ "github.com/benthosdev/benthos/v4/internal/impl/jaeger"
 	_ "github.com/benthosdev/benthos/v4/internal/impl/kafka"
+	_ "github.com/benthosdev/benthos/v4/internal/impl/kafka/aws"
+	_ "github.com/benthosdev/benthos/v4/internal/impl/kubernetes"
 	_ "github.com/benthosdev/benthos/v4/internal/impl/lang"
+	_ "github.com/benthosdev/benthos/v4/internal/impl/ldap"
 	_ "github.com/benthosdev/benthos/v4/internal/impl/maxmind"
 	_ "github.com/benthosdev/benthos/v4/internal/impl/memcached"
+	_ "github.com/benthosdev/benthos/v4/internal/impl/modbus"
 	_ "github.com/benthosdev/benthos/v4/internal/impl/mongodb"
 	_ "github.com/benthosdev/benthos/v4/internal/impl/mqtt"
 	_ "github.com/benthosdev/benthos/v4/internal/impl/msgpack"
 	_ "github.com/benthosdev/benthos/v4/internal/impl/nanomsg"
 	_ "github.com/benthosdev/benthos/v4/internal/impl/nats"
+	_ "github.com/benthosdev/benthos/v4/internal/impl/notify"
 	_ "github.com/benthosdev/benthos/v4/internal/impl/nsq"
+	_ "github.com/benthosdev/benthos/v4/internal/impl/opa"
 	_ "github.com/benthosdev/benthos/v4/internal/impl/parquet"
 	_ "github.com/benthosdev/benthos/v4/internal/impl/prometheus"
 	_ "github.com/benthosdev/benthos/v4/internal/impl/pure"
 	_ "github.com/benthosdev/benthos/v4/internal/impl/redis"
 	_ "github.com/benthosdev/benthos/v4/internal/impl/sftp"
+	_ "github.com/benthosdev/benthos/v4/internal/impl/snmp"
 	_ "github.com/benthosdev/benthos/v4/internal/impl/snowflake"
 	_ "github.com/benthosdev/benthos/v4/internal/impl/sql"
 	_ "github.com/benthosdev/benthos/v4/internal/impl/statsd"
+	_ "github.com/benthosdev/benthos/v4/internal/impl/transcode"
 	_ "github.com/benthosdev/benthos/v4/internal/impl/xml"
 	"github.com/benthosdev/benthos/v4/internal/template"
 
@@ -4,4 +4,5 @@ import (
 	// Bring in the internal plugin definitions.
 	_ "github.com/benthosdev/benthos/v4/internal/impl/aws"
 	_ "github.com/benthosdev/benthos/v4/internal/impl/elasticsearch/aws"
+	_ "github.com/benthosdev/benthos/v4/internal/impl/kafka/aws"
 )
@@ -162,6 +162,17 @@ func (c *ConfigField) Advanced() *ConfigField {
 	return c
 }
 
+// Tunable marks a config field as being tunable, indicating in documentation
+// that its value can be changed at runtime. Components must register tunable
+// fields with the method RegisterTunable of their resources.
+//
+// Experimental: This method is experimental and therefore subject to change
+// outside of major version releases.
+func (c *ConfigField) Tunable() *ConfigField {
+	c.field = c.field.IsTunable()
+	return c
+}
+
 // Deprecated marks a config field as being deprecated, and therefore it will not
 // appear in documentation examples.
 func (c *ConfigField) Deprecated() *ConfigField {
@@ -21,6 +21,7 @@ type BatchPolicy struct {
 	ByteSize int
 	Count    int
 	Check    string
+	Key      string
 	Period   string
 
 	// Only available when using NewBatchPolicyField.
@@ -32,6 +33,7 @@ func (b BatchPolicy) toInternal() batchconfig.Config {
 	batchConf.ByteSize = b.ByteSize
 	batchConf.Count = b.Count
 	batchConf.Check = b.Check
+	batchConf.Key = b.Key
 	batchConf.Period = b.Period
 	batchConf.Processors = b.procs
 	return batchConf
@@ -53,6 +55,10 @@ type Batcher struct {
 
 // Add a message to the batch. Returns true if the batching policy has been
 // triggered by this new addition, in which case Flush should be called.
+//
+// When the policy has a key and the message changes it, the message is held
+// back in order to start the next batch, and is therefore not included in the
+// batch returned by the following call to Flush.
 func (b *Batcher) Add(msg *Message) bool {
 	return b.p.Add(msg.part)
 }
@@ -145,6 +151,9 @@ func (p *ParsedConfig) FieldBatchPolicy(path ...string) (conf BatchPolicy, err e
 	if conf.Check, err = p.FieldString(append(path, "check")...); err != nil {
 		return conf, err
 	}
+	if conf.Key, err = p.FieldString(append(path, "key")...); err != nil {
+		return conf, err
+	}
 	if conf.Period, err = p.FieldString(append(path, "period")...); err != nil {
 		return conf, err
 	}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigCheckpointStore(t *testing.T) {
+	spec := NewConfigSpec().
+		Field(NewCheckpointStoreField("a"))
+
+	tests := []struct {
+		name        string
+		config      string
+		errContains string
+		expected    CheckpointStoreConfig
+	}{
+		{
+			name: "cache",
+			config: `
+a:
+  cache: foo
+  key_prefix: bar_
+`,
+			expected: CheckpointStoreConfig{Cache: "foo", KeyPrefix: "bar_"},
+		},
+		{
+			name: "sql",
+			config: `
+a:
+  sql:
+    driver: postgres
+    dsn: postgres://localhost:5432/foodb
+`,
+			expected: CheckpointStoreConfig{
+				SQLDriver: "postgres",
+				SQLDSN:    "postgres://localhost:5432/foodb",
+				SQLTable:  "benthos_checkpoints",
+			},
+		},
+		{
+			name:        "neither",
+			config:      `a: {}`,
+			errContains: "either a cache or sql table must be specified",
+		},
+		{
+			name: "both",
+			config: `
+a:
+  cache: foo
+  sql:
+    driver: mysql
+    dsn: foo
+`,
+			errContains: "cannot be both",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			parsedConfig, err := spec.ParseYAML(test.config, nil)
+			require.NoError(t, err)
+
+			conf, err := parsedConfig.FieldCheckpointStore("a")
+			if test.errContains != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.errContains)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, conf)
+		})
+	}
+}
+
+func TestCheckpointCommitterCache(t *testing.T) {
+	ctx := context.Background()
+	res := MockResources(MockResourcesOptAddCache("foo"))
+
+	_, err := CheckpointStoreConfig{Cache: "bar"}.NewStore(ctx, res)
+	require.Error(t, err)
+
+	store, err := CheckpointStoreConfig{Cache: "foo", KeyPrefix: "offsets_"}.NewStore(ctx, res)
+	require.NoError(t, err)
+	defer store.Close(ctx)
+
+	_, err = store.Get(ctx, "partition_0")
+	assert.Equal(t, ErrKeyNotFound, err)
+
+	committer := store.NewCommitter("partition_0")
+	ackFirst := committer.Track("10")
+	ackSecond := committer.Track("11")
+	ackThird := committer.Track("12")
+
+	// Positions are not committed until all prior positions are acknowledged.
+	require.NoError(t, ackSecond(ctx))
+	_, err = store.Get(ctx, "partition_0")
+	assert.Equal(t, ErrKeyNotFound, err)
+
+	require.NoError(t, ackFirst(ctx))
+	position, err := store.Get(ctx, "partition_0")
+	require.NoError(t, err)
+	assert.Equal(t, "11", position)
+
+	require.NoError(t, ackThird(ctx))
+	position, err = store.Get(ctx, "partition_0")
+	require.NoError(t, err)
+	assert.Equal(t, "12", position)
+
+	require.NoError(t, res.AccessCache(ctx, "foo", func(c Cache) {
+		v, err := c.Get(ctx, "offsets_partition_0")
+		require.NoError(t, err)
+		assert.Equal(t, "12", string(v))
+	}))
+}
@@ -45,6 +45,37 @@ func (p *ParsedConfig) FieldInput(path ...string) (*OwnedInput, error) {
 	return &OwnedInput{iproc}, nil
 }
 
+// FieldInputConstructor accesses a field from a parsed config that was defined
+// with NewInputField and returns a function that creates a new OwnedInput from
+// it each time it is called, or an error if the configuration was invalid. This
+// allows plugins to defer the creation of an input until it is needed, as
+// inputs begin consuming as soon as they are created.
+func (p *ParsedConfig) FieldInputConstructor(path ...string) (func() (*OwnedInput, error), error) {
+	field, exists := p.field(path...)
+	if !exists {
+		return nil, fmt.Errorf("field '%v' was not found in the config", strings.Join(path, "."))
+	}
+
+	pNode, ok := field.(*yaml.Node)
+	if !ok {
+		return nil, fmt.Errorf("unexpected value, expected object, got %T", field)
+	}
+
+	var conf input.Config
+	if err := pNode.Decode(&conf); err != nil {
+		return nil, err
+	}
+
+	tmpMgr := p.mgr.IntoPath(path...)
+	return func() (*OwnedInput, error) {
+		iproc, err := tmpMgr.NewInput(conf)
+		if err != nil {
+			return nil, err
+		}
+		return &OwnedInput{iproc}, nil
+	}, nil
+}
+
 // NewInputListField defines a new input list field, it is then possible
 // to extract a list of OwnedInput from the resulting parsed config with the
 // method FieldInputList.
@@ -40,6 +40,43 @@ a:
 	require.NoError(t, input.Close(context.Background()))
 }
 
+func TestConfigInputConstructor(t *testing.T) {
+	spec := NewConfigSpec().
+		Field(NewInputField("a"))
+
+	parsedConfig, err := spec.ParseYAML(`
+a:
+  generate:
+    count: 1
+    interval: ""
+    mapping: 'root = "hello world"'
+`, nil)
+	require.NoError(t, err)
+
+	ctor, err := parsedConfig.FieldInputConstructor("a")
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		input, err := ctor()
+		require.NoError(t, err)
+
+		res, aFn, err := input.ReadBatch(context.Background())
+		require.NoError(t, err)
+		require.Len(t, res, 1)
+
+		require.NoError(t, aFn(context.Background(), nil))
+
+		resBytes, err := res[0].AsBytes()
+		require.NoError(t, err)
+		assert.Equal(t, "hello world", string(resBytes))
+
+		_, _, err = input.ReadBatch(context.Background())
+		require.Equal(t, ErrEndOfInput, err)
+
+		require.NoError(t, input.Close(context.Background()))
+	}
+}
+
 func TestConfigInputList(t *testing.T) {
 	spec := NewConfigSpec().
 		Field(NewInputListField("a"))
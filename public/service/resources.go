@@ -109,3 +109,20 @@ func (r *Resources) AccessRateLimit(ctx context.Context, name string, fn func(r
 func (r *Resources) HasRateLimit(name string) bool {
 	return r.mgr.ProbeRateLimit(name)
 }
+
+// RegisterTunable registers a config field of the component that can be
+// changed at runtime via the tunables endpoint of the HTTP server, where value
+// is the current value of the field and set is called with new values. The
+// function set should return an error when a value is invalid, in which case
+// the field is left unchanged. Fields that are registered as tunables should
+// also be marked as such in the config spec with the method Tunable.
+//
+// The returned function unregisters the tunable and should be called when the
+// component is closed, otherwise the tunable remains registered after the
+// component is removed, such as when a stream is updated in streams mode.
+//
+// Experimental: This method is experimental and therefore subject to change
+// outside of major version releases.
+func (r *Resources) RegisterTunable(field, description, value string, set func(value string) error) (unregister func()) {
+	return r.mgr.RegisterTunable(field, description, value, set)
+}
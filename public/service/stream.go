@@ -99,7 +99,48 @@ func (s *Stream) StopWithin(timeout time.Duration) error {
 		}()
 		return err
 	}
+	return s.closeResources(stopAt)
+}
+
+// PauseInput stops the consumption of messages from all inputs of the stream
+// identified by a label or, for inputs without a label, a path such as
+// `root.input`. The connections of paused inputs remain open, and consumption
+// can be continued with ResumeInput.
+func (s *Stream) PauseInput(label string) error {
+	return s.mgr.PauseInput(label)
+}
+
+// ResumeInput continues the consumption of messages from all inputs of the
+// stream identified by a label or path that were previously paused.
+func (s *Stream) ResumeInput(label string) error {
+	return s.mgr.ResumeInput(label)
+}
+
+// DrainWithin stops the stream by no longer consuming from its inputs and
+// waiting for all in-flight messages to be resolved. If the timeout is reached
+// before the stream has drained then it is stopped regardless. The number of
+// messages that were still in flight when the stream stopped is returned.
+func (s *Stream) DrainWithin(timeout time.Duration) (int, error) {
+	s.strmMut.Lock()
+	strm := s.strm
+	s.strmMut.Unlock()
+	if strm == nil {
+		return 0, errors.New("stream has not been run yet")
+	}
+
+	stopAt := time.Now().Add(timeout)
+	remaining, err := strm.Drain(timeout)
+	if err != nil {
+		go func() {
+			s.mgr.CloseAsync()
+			s.stats.Close()
+		}()
+		return remaining, err
+	}
+	return remaining, s.closeResources(stopAt)
+}
 
+func (s *Stream) closeResources(stopAt time.Time) error {
 	s.mgr.CloseAsync()
 	if err := s.mgr.WaitForClose(time.Until(stopAt)); err != nil {
 		// Same as above, attempt to shut down other resources but do not block.
@@ -3,6 +3,7 @@ package service
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
 	"errors"
 	"fmt"
 	"net/http"
@@ -14,6 +15,11 @@ import (
 
 	"github.com/benthosdev/benthos/v4/internal/api"
 	"github.com/benthosdev/benthos/v4/internal/bundle"
+	"github.com/benthosdev/benthos/v4/internal/bundle/accounting"
+	"github.com/benthosdev/benthos/v4/internal/bundle/flightrecorder"
+	"github.com/benthosdev/benthos/v4/internal/bundle/latency"
+	"github.com/benthosdev/benthos/v4/internal/bundle/lineage"
+	"github.com/benthosdev/benthos/v4/internal/bundle/quarantine"
 	"github.com/benthosdev/benthos/v4/internal/bundle/tracing"
 	"github.com/benthosdev/benthos/v4/internal/component/buffer"
 	"github.com/benthosdev/benthos/v4/internal/component/cache"
@@ -28,6 +34,7 @@ import (
 	"github.com/benthosdev/benthos/v4/internal/log"
 	"github.com/benthosdev/benthos/v4/internal/manager"
 	"github.com/benthosdev/benthos/v4/internal/message"
+	"github.com/benthosdev/benthos/v4/internal/metadata"
 	"github.com/benthosdev/benthos/v4/internal/stream"
 )
 
@@ -53,6 +60,13 @@ type StreamBuilder struct {
 	tracer     tracer.Config
 	logger     log.Config
 
+	lineage        lineage.Config
+	latency        latency.Config
+	metadataPolicy metadata.PolicyConfig
+	flightRecorder flightrecorder.Config
+	quarantine     quarantine.Config
+	accounting     accounting.Config
+
 	producerChan chan message.Transaction
 	producerID   string
 	consumerFunc MessageBatchHandlerFunc
@@ -74,7 +88,15 @@ func NewStreamBuilder() *StreamBuilder {
 		metrics:   metrics.NewConfig(),
 		tracer:    tracer.NewConfig(),
 		logger:    log.NewConfig(),
-		env:       globalEnvironment,
+
+		lineage:        lineage.NewConfig(),
+		latency:        latency.NewConfig(),
+		metadataPolicy: metadata.NewPolicyConfig(),
+		flightRecorder: flightrecorder.NewConfig(),
+		quarantine:     quarantine.NewConfig(),
+		accounting:     accounting.NewConfig(),
+
+		env: globalEnvironment,
 	}
 }
 
@@ -552,6 +574,12 @@ func (s *StreamBuilder) setFromConfig(sconf config.Type) {
 	s.logger = sconf.Logger
 	s.metrics = sconf.Metrics
 	s.tracer = sconf.Tracer
+	s.lineage = sconf.Lineage
+	s.latency = sconf.Latency
+	s.metadataPolicy = sconf.MetadataPolicy
+	s.flightRecorder = sconf.FlightRecorder
+	s.quarantine = sconf.Quarantine
+	s.accounting = sconf.Accounting
 }
 
 // SetBufferYAML parses a buffer YAML configuration and sets it to the builder
@@ -752,16 +780,16 @@ func (s *StreamBuilder) buildWithEnv(env *bundle.Environment) (*Stream, error) {
 		return nil, err
 	}
 
+	var sanitNode yaml.Node
+	if err := sanitNode.Encode(conf); err == nil {
+		sanitConf := docs.NewSanitiseConfig()
+		sanitConf.RemoveTypeField = true
+		sanitConf.DocsProvider = env
+		_ = config.Spec().SanitiseYAML(&sanitNode, sanitConf)
+	}
+
 	apiMut := s.apiMut
 	if apiMut == nil {
-		var sanitNode yaml.Node
-		err := sanitNode.Encode(conf)
-		if err == nil {
-			sanitConf := docs.NewSanitiseConfig()
-			sanitConf.RemoveTypeField = true
-			sanitConf.DocsProvider = env
-			_ = config.Spec().SanitiseYAML(&sanitNode, sanitConf)
-		}
 		if apiMut, err = api.New("", "", s.http, sanitNode, logger, stats); err != nil {
 			return nil, fmt.Errorf("unable to create stream HTTP server due to: %w. Tip: you can disable the server with `http.enabled` set to `false`, or override the configured server with SetHTTPMux", err)
 		}
@@ -770,6 +798,25 @@ func (s *StreamBuilder) buildWithEnv(env *bundle.Environment) (*Stream, error) {
 		apiMut.RegisterEndpoint("/metrics", "Exposes service-wide metrics in the format configured.", hler)
 	}
 
+	var configHash string
+	if s.lineage.Enabled {
+		if hashBytes, err := yaml.Marshal(&sanitNode); err == nil {
+			configHash = fmt.Sprintf("%x", sha256.Sum256(hashBytes))
+		}
+	}
+
+	sconf := config.New()
+	sconf.Logger = s.logger
+	sconf.Lineage = s.lineage
+	sconf.Latency = s.latency
+	sconf.MetadataPolicy = s.metadataPolicy
+	sconf.FlightRecorder = s.flightRecorder
+	sconf.Quarantine = s.quarantine
+	sconf.Accounting = s.accounting
+	if env, err = config.WrapEnvironment(env, sconf, configHash, logger, apiMut); err != nil {
+		return nil, err
+	}
+
 	mgr, err := manager.New(
 		conf.ResourceConfig,
 		manager.OptSetAPIReg(apiMut),
@@ -801,6 +848,12 @@ type builderConfig struct {
 	Metrics                metrics.Config `yaml:"metrics"`
 	Logger                 *log.Config    `yaml:"logger,omitempty"`
 	Tracer                 tracer.Config  `yaml:"tracer"`
+	Lineage                lineage.Config        `yaml:"lineage"`
+	Latency                latency.Config        `yaml:"latency"`
+	MetadataPolicy         metadata.PolicyConfig `yaml:"metadata_policy"`
+	FlightRecorder         flightrecorder.Config `yaml:"flight_recorder"`
+	Quarantine             quarantine.Config     `yaml:"quarantine"`
+	Accounting             accounting.Config     `yaml:"accounting"`
 }
 
 func (s *StreamBuilder) buildConfig() builderConfig {
@@ -834,6 +887,12 @@ func (s *StreamBuilder) buildConfig() builderConfig {
 	conf.ResourceConfig = s.resources
 	conf.Metrics = s.metrics
 	conf.Tracer = s.tracer
+	conf.Lineage = s.lineage
+	conf.Latency = s.latency
+	conf.MetadataPolicy = s.metadataPolicy
+	conf.FlightRecorder = s.flightRecorder
+	conf.Quarantine = s.quarantine
+	conf.Accounting = s.accounting
 	if s.customLogger == nil {
 		conf.Logger = &s.logger
 	}
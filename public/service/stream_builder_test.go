@@ -9,6 +9,7 @@ import (
 	"path/filepath"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -433,6 +434,62 @@ type: local`))
 	}
 }
 
+func TestStreamBuilderSetYAMLServiceSections(t *testing.T) {
+	b := service.NewStreamBuilder()
+	require.NoError(t, b.SetYAML(`
+input:
+  label: foo
+  generate:
+    count: 1
+    interval: ""
+    mapping: |
+      root = "hello world"
+      meta secret_token = "nope"
+      meta kept = "yep"
+logger:
+  level: NONE
+lineage:
+  enabled: true
+  instance_id: bar
+metadata_policy:
+  exclude_prefixes: [ secret_ ]
+`))
+
+	var outMsgs []*service.Message
+	var outMut sync.Mutex
+	require.NoError(t, b.AddConsumerFunc(func(_ context.Context, m *service.Message) error {
+		outMut.Lock()
+		outMsgs = append(outMsgs, m)
+		outMut.Unlock()
+		return nil
+	}))
+
+	act, err := b.AsYAML()
+	require.NoError(t, err)
+	assert.Contains(t, act, `lineage:
+    enabled: true`)
+
+	strm, err := b.Build()
+	require.NoError(t, err)
+	require.NoError(t, strm.Run(context.Background()))
+
+	outMut.Lock()
+	defer outMut.Unlock()
+	require.Len(t, outMsgs, 1)
+
+	_, exists := outMsgs[0].MetaGet("secret_token")
+	assert.False(t, exists)
+
+	v, _ := outMsgs[0].MetaGet("kept")
+	assert.Equal(t, "yep", v)
+
+	v, _ = outMsgs[0].MetaGet("lineage_source")
+	assert.Equal(t, "foo", v)
+
+	v, _ = outMsgs[0].MetaGet("lineage_instance_id")
+	assert.Equal(t, "bar", v)
+}
+
 func TestStreamBuilderSetResourcesYAML(t *testing.T) {
 	b := service.NewStreamBuilder()
 	require.NoError(t, b.AddResourcesYAML(`
@@ -879,3 +936,59 @@ logger:
 		require.NoError(b, strm.Run(context.Background()))
 	}
 }
+
+func TestStreamBuilderPauseAndDrain(t *testing.T) {
+	b := service.NewStreamBuilder()
+	require.NoError(t, b.SetLoggerYAML("level: NONE"))
+	require.NoError(t, b.AddInputYAML(`
+label: foo
+generate:
+  mapping: 'root = "hello world"'
+  interval: 1ms
+`))
+
+	var count int64
+	require.NoError(t, b.AddConsumerFunc(func(_ context.Context, m *service.Message) error {
+		atomic.AddInt64(&count, 1)
+		return nil
+	}))
+
+	strm, err := b.Build()
+	require.NoError(t, err)
+
+	require.Error(t, strm.PauseInput("foo"))
+
+	runErrChan := make(chan error, 1)
+	go func() {
+		runErrChan <- strm.Run(context.Background())
+	}()
+
+	assert.Eventually(t, func() bool {
+		return atomic.LoadInt64(&count) > 0
+	}, time.Second*5, time.Millisecond*10)
+
+	require.Error(t, strm.PauseInput("bar"))
+	require.NoError(t, strm.PauseInput("foo"))
+
+	// Wait for anything already consumed to flush through.
+	<-time.After(time.Millisecond * 100)
+	paused := atomic.LoadInt64(&count)
+	<-time.After(time.Millisecond * 100)
+	assert.Equal(t, paused, atomic.LoadInt64(&count))
+
+	require.NoError(t, strm.ResumeInput("foo"))
+	assert.Eventually(t, func() bool {
+		return atomic.LoadInt64(&count) > paused
+	}, time.Second*5, time.Millisecond*10)
+
+	remaining, err := strm.DrainWithin(time.Second * 5)
+	require.NoError(t, err)
+	assert.Equal(t, 0, remaining)
+
+	select {
+	case err := <-runErrChan:
+		require.NoError(t, err)
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out")
+	}
+}